@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"mosn.io/mosn/pkg/bench"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/mosn"
+)
+
+var cmdBench = cli.Command{
+	Name:  "bench",
+	Usage: "generate load to a target, optionally through a local mosn started by the config",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "config, c",
+			Usage: "start a local mosn by the configuration `FILE` before generating load",
+		}, cli.StringFlag{
+			Name:  "target, t",
+			Usage: "target address, such as 127.0.0.1:2045",
+		}, cli.StringFlag{
+			Name:  "protocol, p",
+			Usage: "load protocol, http1, http2 or bolt",
+			Value: bench.HTTP1,
+		}, cli.StringFlag{
+			Name:  "method",
+			Usage: "http request method",
+			Value: "GET",
+		}, cli.StringFlag{
+			Name:  "path",
+			Usage: "http request path",
+			Value: "/",
+		}, cli.StringSliceFlag{
+			Name:  "header, H",
+			Usage: "request header in `key:value` format, the bolt request headers are used for routing",
+		}, cli.StringFlag{
+			Name:  "body",
+			Usage: "request body",
+		}, cli.IntFlag{
+			Name:  "qps, q",
+			Usage: "max requests per second, zero means no limit",
+		}, cli.IntFlag{
+			Name:  "concurrency, n",
+			Usage: "number of concurrent connections",
+			Value: 1,
+		}, cli.DurationFlag{
+			Name:  "ramp-up",
+			Usage: "start the connections evenly in the duration",
+		}, cli.DurationFlag{
+			Name:  "duration, d",
+			Usage: "running duration",
+			Value: 10 * time.Second,
+		}, cli.Int64Flag{
+			Name:  "requests, r",
+			Usage: "total requests, zero means running until the duration elapses",
+		}, cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "request timeout",
+			Value: 5 * time.Second,
+		}, cli.StringFlag{
+			Name:  "output, o",
+			Usage: "report format, text or json",
+			Value: "text",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		headers, err := parseBenchHeaders(c.StringSlice("header"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if configPath := c.String("config"); configPath != "" {
+			m := mosn.NewMosn(configmanager.Load(configPath))
+			m.Start()
+			defer m.Close()
+			// waits the listeners are ready
+			time.Sleep(time.Second)
+		}
+		report, err := bench.Run(bench.Config{
+			Protocol:    c.String("protocol"),
+			Target:      c.String("target"),
+			Path:        c.String("path"),
+			Method:      c.String("method"),
+			Headers:     headers,
+			Body:        []byte(c.String("body")),
+			QPS:         c.Int("qps"),
+			Concurrency: c.Int("concurrency"),
+			RampUp:      c.Duration("ramp-up"),
+			Duration:    c.Duration("duration"),
+			Requests:    c.Int64("requests"),
+			Timeout:     c.Duration("timeout"),
+		})
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if c.String("output") == "json" {
+			return json.NewEncoder(os.Stdout).Encode(report)
+		}
+		fmt.Fprint(os.Stdout, report.String())
+		return nil
+	},
+}
+
+func parseBenchHeaders(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(values))
+	for _, v := range values {
+		kv := strings.SplitN(v, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid header %s, expected key:value", v)
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers, nil
+}
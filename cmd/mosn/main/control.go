@@ -26,12 +26,14 @@ import (
 
 	"github.com/urfave/cli"
 	"mosn.io/mosn/pkg/admin/store"
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/configmanager"
 	"mosn.io/mosn/pkg/featuregate"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/mosn"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/xds/conv"
 )
 
 var (
@@ -60,6 +62,9 @@ var (
 				Name:   "feature-gates, f",
 				Usage:  "config feature gates",
 				EnvVar: "FEATURE_GATES",
+			}, cli.StringFlag{
+				Name:  "mode, m",
+				Usage: "running mode, `check` validates the configuration and exits without binding sockets",
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -68,6 +73,10 @@ var (
 			serviceNode := c.String("service-node")
 			serviceMeta := c.StringSlice("service-meta")
 
+			// the envoy bootstrap protobuf files are loaded besides the mosn json config
+			if conv.IsBootstrapFile(configPath) {
+				configmanager.RegisterConfigLoadFunc(conv.BootstrapConfigLoad)
+			}
 			conf := configmanager.Load(configPath)
 			// set feature gates
 			err := featuregate.Set(c.String("feature-gates"))
@@ -75,6 +84,11 @@ var (
 				log.StartLogger.Infof("[mosn] [start] parse feature-gates flag fail : %+v", err)
 				os.Exit(1)
 			}
+			// dry run, check the configuration only
+			if c.String("mode") == modeCheck {
+				checkConfig(configPath, conf)
+				return nil
+			}
 			// start pprof
 			if conf.Debug.StartDebug {
 				port := 9090 //default use 9090
@@ -113,3 +127,16 @@ var (
 		},
 	}
 )
+
+// modeCheck validates the configuration and exits
+const modeCheck = "check"
+
+// checkConfig validates the configuration, reports all the errors and exits
+func checkConfig(configPath string, conf *v2.MOSNConfig) {
+	if err := configmanager.Validate(conf); err != nil {
+		fmt.Fprintf(os.Stderr, "configuration %s is invalid:\n%v\n", configPath, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stdout, "configuration %s is valid\n", configPath)
+	os.Exit(0)
+}
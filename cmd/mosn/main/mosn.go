@@ -25,14 +25,32 @@ import (
 
 	"github.com/urfave/cli"
 	_ "mosn.io/mosn/pkg/buffer"
+	_ "mosn.io/mosn/pkg/filter/network/connectionratelimit"
+	_ "mosn.io/mosn/pkg/filter/network/kafkaproxy"
 	_ "mosn.io/mosn/pkg/filter/network/proxy"
+	_ "mosn.io/mosn/pkg/filter/network/redisproxy"
 	_ "mosn.io/mosn/pkg/filter/network/tcpproxy"
+	_ "mosn.io/mosn/pkg/filter/stream/apikey"
+	_ "mosn.io/mosn/pkg/filter/stream/bandwidthlimit"
+	_ "mosn.io/mosn/pkg/filter/stream/basicauth"
+	_ "mosn.io/mosn/pkg/filter/stream/coalesce"
+	_ "mosn.io/mosn/pkg/filter/stream/compression"
+	_ "mosn.io/mosn/pkg/filter/stream/datamask"
+	_ "mosn.io/mosn/pkg/filter/stream/dynamicforwardproxy"
 	_ "mosn.io/mosn/pkg/filter/stream/faultinject"
+	_ "mosn.io/mosn/pkg/filter/stream/grpcbridge"
 	_ "mosn.io/mosn/pkg/filter/stream/healthcheck/sofarpc"
 	_ "mosn.io/mosn/pkg/filter/stream/mixer"
+	_ "mosn.io/mosn/pkg/filter/stream/oauth2"
 	_ "mosn.io/mosn/pkg/filter/stream/payloadlimit"
+	_ "mosn.io/mosn/pkg/filter/stream/priority"
+	_ "mosn.io/mosn/pkg/filter/stream/requestsign"
+	_ "mosn.io/mosn/pkg/filter/stream/statefulsession"
+	_ "mosn.io/mosn/pkg/filter/stream/transformation"
+	_ "mosn.io/mosn/pkg/filter/stream/waf"
 	_ "mosn.io/mosn/pkg/metrics/sink"
 	_ "mosn.io/mosn/pkg/metrics/sink/prometheus"
+	_ "mosn.io/mosn/pkg/metrics/sink/statsd"
 	_ "mosn.io/mosn/pkg/network"
 	_ "mosn.io/mosn/pkg/protocol"
 	_ "mosn.io/mosn/pkg/protocol/http/conv"
@@ -40,6 +58,7 @@ import (
 	_ "mosn.io/mosn/pkg/protocol/rpc/sofarpc/codec"
 	_ "mosn.io/mosn/pkg/protocol/rpc/sofarpc/conv"
 	_ "mosn.io/mosn/pkg/protocol/rpc/xprotocol/tars"
+	_ "mosn.io/mosn/pkg/registry"
 	_ "mosn.io/mosn/pkg/router"
 	_ "mosn.io/mosn/pkg/stream/http"
 	_ "mosn.io/mosn/pkg/stream/http2"
@@ -68,6 +87,8 @@ func main() {
 		cmdStart,
 		cmdStop,
 		cmdReload,
+		cmdBench,
+		cmdReplay,
 	}
 
 	//action
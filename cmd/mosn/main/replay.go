@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+	"mosn.io/mosn/pkg/bench"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/mosn"
+)
+
+var cmdReplay = cli.Command{
+	Name:  "replay",
+	Usage: "replay the captured requests to a target or the hosts of a cluster",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "input, i",
+			Usage: "the captured requests `FILE` in json lines, the records or the json format access logs",
+		}, cli.StringFlag{
+			Name:  "config, c",
+			Usage: "the mosn configuration `FILE`, a local mosn is started unless the cluster is specified",
+		}, cli.StringSliceFlag{
+			Name:  "target, t",
+			Usage: "target address, such as the listener address of the local mosn",
+		}, cli.StringFlag{
+			Name:  "cluster",
+			Usage: "replay directly to the hosts of the cluster in the configuration",
+		}, cli.StringFlag{
+			Name:  "protocol, p",
+			Usage: "replay protocol, http1 or http2",
+			Value: bench.HTTP1,
+		}, cli.Float64Flag{
+			Name:  "speed, s",
+			Usage: "the multiple of the captured pace, zero means as fast as possible",
+			Value: 1,
+		}, cli.IntFlag{
+			Name:  "concurrency, n",
+			Usage: "number of concurrent workers",
+			Value: 1,
+		}, cli.IntFlag{
+			Name:  "loops",
+			Usage: "times the captured requests are replayed",
+			Value: 1,
+		}, cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "request timeout",
+			Value: 5 * time.Second,
+		}, cli.StringFlag{
+			Name:  "output, o",
+			Usage: "report format, text or json",
+			Value: "text",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		f, err := os.Open(c.String("input"))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		records, err := bench.ReadRecords(f)
+		f.Close()
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		targets := c.StringSlice("target")
+		if configPath := c.String("config"); configPath != "" {
+			cfg := configmanager.Load(configPath)
+			if name := c.String("cluster"); name != "" {
+				if targets, err = clusterTargets(cfg, name); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+			} else {
+				m := mosn.NewMosn(cfg)
+				m.Start()
+				defer m.Close()
+				// waits the listeners are ready
+				time.Sleep(time.Second)
+			}
+		} else if c.String("cluster") != "" {
+			return cli.NewExitError("the configuration is required to replay to a cluster", 1)
+		}
+		report, err := bench.Replay(bench.ReplayConfig{
+			Protocol:    c.String("protocol"),
+			Targets:     targets,
+			Records:     records,
+			Speed:       c.Float64("speed"),
+			Concurrency: c.Int("concurrency"),
+			Loops:       c.Int("loops"),
+			Timeout:     c.Duration("timeout"),
+		})
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		if c.String("output") == "json" {
+			return json.NewEncoder(os.Stdout).Encode(report)
+		}
+		fmt.Fprint(os.Stdout, report.String())
+		return nil
+	},
+}
+
+// clusterTargets returns the host addresses of the cluster in the configuration
+func clusterTargets(cfg *v2.MOSNConfig, name string) ([]string, error) {
+	for _, cluster := range cfg.ClusterManager.Clusters {
+		if cluster.Name != name {
+			continue
+		}
+		targets := make([]string, 0, len(cluster.Hosts))
+		for _, host := range cluster.Hosts {
+			targets = append(targets, host.Address)
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("cluster %s has no hosts", name)
+		}
+		return targets, nil
+	}
+	return nil, fmt.Errorf("cluster %s is not found", name)
+}
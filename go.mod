@@ -15,7 +15,7 @@ require (
 	github.com/golang/protobuf v1.3.2
 	github.com/json-iterator/go v1.1.6
 	github.com/juju/errors v0.0.0-20190207033735-e65537c515d7
-	github.com/klauspost/compress v1.7.5 // indirect
+	github.com/klauspost/compress v1.7.5
 	github.com/klauspost/cpuid v1.2.1 // indirect
 	github.com/lyft/protoc-gen-validate v0.0.14
 	github.com/mailru/easyjson v0.0.0-20190626092158-b2ccc519800e // indirect
@@ -19,12 +19,15 @@ package server
 
 import (
 	"bytes"
+	rawjson "encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 
 	"mosn.io/mosn/pkg/admin/store"
+	"mosn.io/mosn/pkg/event"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/metrics/sink/console"
@@ -191,3 +194,94 @@ func getState(w http.ResponseWriter, r *http.Request) {
 	msg := fmt.Sprintf("pid=%d&state=%d\n", pid, state)
 	fmt.Fprint(w, msg)
 }
+
+// DetailedStatsData is the per-route and per-upstream-host stats switch
+type DetailedStatsData struct {
+	Route          bool `json:"route"`
+	Host           bool `json:"host"`
+	MaxCardinality int  `json:"max_cardinality"`
+}
+
+// GET returns the detailed stats switch state
+// POST turns the detailed stats on or off
+func detailedStats(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		data := &DetailedStatsData{}
+		data.Route, data.Host, data.MaxCardinality = metrics.DetailedStats()
+		buf, _ := json.Marshal(data)
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf)
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: read body failed, %v", "detailed stats", err)
+			w.WriteHeader(http.StatusBadRequest)
+			msg := fmt.Sprintf(errMsgFmt, "read body error")
+			fmt.Fprint(w, msg)
+			return
+		}
+		data := &DetailedStatsData{}
+		if err := json.Unmarshal(body, data); err != nil {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, toggle detailed stats failed with bad request data: %s", "detailed stats", string(body))
+			w.WriteHeader(http.StatusBadRequest)
+			msg := fmt.Sprintf(errMsgFmt, "toggle detailed stats failed")
+			fmt.Fprint(w, msg)
+			return
+		}
+		metrics.SetDetailedStats(data.Route, data.Host, data.MaxCardinality)
+		log.DefaultLogger.Infof("[admin api] [detailed stats] set detailed stats, route: %v, host: %v, max cardinality: %d", data.Route, data.Host, data.MaxCardinality)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "set detailed stats success\n")
+	default:
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "detailed stats", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+const defaultRecentEvents = 100
+
+// recentEvents returns the recent events published by the event bus,
+// the number of the events can be specified by query "n", default is 100
+func recentEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "recent events", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	n := defaultRecentEvents
+	if s := r.URL.Query().Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid events number: %s", "recent events", s)
+			w.WriteHeader(http.StatusBadRequest)
+			msg := fmt.Sprintf(errMsgFmt, "invalid events number")
+			fmt.Fprint(w, msg)
+			return
+		}
+		n = v
+	}
+	buf, _ := rawjson.Marshal(event.Recent(n))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
+
+// logWriteFailures reports the outputs that enable the log fallback, and the log
+// entries kept in memory when the outputs can not be written
+func logWriteFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "log write failures", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	failures := struct {
+		Outputs    []log.LogWriteStatus `json:"outputs"`
+		RecentLogs []log.FallbackEntry  `json:"recent_logs,omitempty"`
+	}{
+		Outputs:    log.LogWriteStatuses(),
+		RecentLogs: log.RecentFallbackLogs(),
+	}
+	buf, _ := rawjson.Marshal(failures)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// TokenAuth wraps an admin api, the request is served only if the token is configured
+// and the request carries it in Authorization header. The api is disabled if the token is empty.
+func TokenAuth(api string, token func() string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := token()
+		if expected == "" {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: api is disabled without auth token", api)
+			w.WriteHeader(http.StatusForbidden)
+			msg := fmt.Sprintf(errMsgFmt, "api is disabled without auth token")
+			fmt.Fprint(w, msg)
+			return
+		}
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(expected)) != 1 {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid auth token from %s", api, r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			msg := fmt.Sprintf(errMsgFmt, "invalid auth token")
+			fmt.Fprint(w, msg)
+			return
+		}
+		handler(w, r)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	rpprof "runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	defaultProfileSeconds = 30
+	maxProfileSeconds     = 300
+)
+
+// pprofConfig is the config of the pprof apis, nil means the apis are disabled
+var pprofConfig atomic.Value
+
+// capturing makes sure only one profile is captured at the same time
+var capturing int32
+
+var errCapturing = errors.New("another profile is capturing")
+
+func setPProfConfig(config *v2.PProfConfig) {
+	pprofConfig.Store(config)
+}
+
+func getPProfConfig() *v2.PProfConfig {
+	config, _ := pprofConfig.Load().(*v2.PProfConfig)
+	return config
+}
+
+// pprofAuth wraps the pprof apis, the request is served only if
+// an auth token is configured and the request carries it in Authorization header
+func pprofAuth(api string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return TokenAuth(api, func() string {
+		if config := getPProfConfig(); config != nil {
+			return config.AuthToken
+		}
+		return ""
+	}, handler)
+}
+
+type profileResult struct {
+	Type    string `json:"type"`
+	Seconds int    `json:"seconds"`
+	Path    string `json:"path"`
+}
+
+// captureProfile captures a cpu, heap or trace profile and writes it into the profile dir.
+// cpu and trace profiles last for the seconds in query, heap profile is a snapshot.
+func captureProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "capture profile", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	typ := r.URL.Query().Get("type")
+	if typ != "cpu" && typ != "heap" && typ != "trace" {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid profile type: %s", "capture profile", typ)
+		w.WriteHeader(http.StatusBadRequest)
+		msg := fmt.Sprintf(errMsgFmt, "invalid profile type")
+		fmt.Fprint(w, msg)
+		return
+	}
+	seconds := defaultProfileSeconds
+	if s := r.URL.Query().Get("seconds"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxProfileSeconds {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid seconds: %s", "capture profile", s)
+			w.WriteHeader(http.StatusBadRequest)
+			msg := fmt.Sprintf(errMsgFmt, "invalid seconds")
+			fmt.Fprint(w, msg)
+			return
+		}
+		seconds = n
+	}
+	if typ == "heap" {
+		seconds = 0
+	}
+	path, err := writeProfile(getPProfConfig().ProfileDir, typ, time.Duration(seconds)*time.Second)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "capture profile", err)
+		if err == errCapturing {
+			w.WriteHeader(http.StatusConflict)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		msg := fmt.Sprintf(errMsgFmt, err.Error())
+		fmt.Fprint(w, msg)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [capture profile] %s profile is written to %s", typ, path)
+	buf, _ := json.Marshal(&profileResult{
+		Type:    typ,
+		Seconds: seconds,
+		Path:    path,
+	})
+	w.Write(buf)
+}
+
+func writeProfile(dir, typ string, duration time.Duration) (string, error) {
+	if !atomic.CompareAndSwapInt32(&capturing, 0, 1) {
+		return "", errCapturing
+	}
+	defer atomic.StoreInt32(&capturing, 0)
+
+	if dir == "" {
+		dir = filepath.Join(types.MosnBasePath, "profiles")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d-%s.prof", typ, os.Getpid(), time.Now().Format("20060102150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	switch typ {
+	case "cpu":
+		if err := rpprof.StartCPUProfile(f); err != nil {
+			return "", err
+		}
+		time.Sleep(duration)
+		rpprof.StopCPUProfile()
+	case "trace":
+		if err := trace.Start(f); err != nil {
+			return "", err
+		}
+		time.Sleep(duration)
+		trace.Stop()
+	case "heap":
+		if err := rpprof.Lookup("heap").WriteTo(f, 0); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
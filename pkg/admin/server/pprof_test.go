@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/admin/store"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+type mockPProfConfig struct {
+	mockMOSNConfig
+	pprof v2.PProfConfig
+}
+
+func (m *mockPProfConfig) GetPProf() *v2.PProfConfig {
+	return &m.pprof
+}
+
+func pprofRequest(t *testing.T, method, url, token string) (int, []byte) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, b
+}
+
+func TestPProfDisabled(t *testing.T) {
+	time.Sleep(time.Second)
+	server := Server{}
+	config := &mockMOSNConfig{
+		Name: "mock",
+		Port: 8889,
+	}
+	server.Start(config)
+	store.StartService(nil)
+	defer store.StopService()
+
+	time.Sleep(time.Second) //wait server start
+	for _, path := range []string{"/debug/pprof/", "/api/v1/profile?type=heap"} {
+		url := fmt.Sprintf("http://localhost:%d%s", config.Port, path)
+		if code, _ := pprofRequest(t, http.MethodPost, url, "token"); code != http.StatusForbidden {
+			t.Errorf("%s expected forbidden, but got: %d", path, code)
+		}
+	}
+	store.Reset()
+}
+
+func TestPProfCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mosn_profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	time.Sleep(time.Second)
+	server := Server{}
+	config := &mockPProfConfig{
+		mockMOSNConfig: mockMOSNConfig{
+			Name: "mock",
+			Port: 8889,
+		},
+		pprof: v2.PProfConfig{
+			ProfileDir: dir,
+			AuthToken:  "token",
+		},
+	}
+	server.Start(config)
+	store.StartService(nil)
+	defer store.StopService()
+
+	time.Sleep(time.Second) //wait server start
+	addr := fmt.Sprintf("http://localhost:%d", config.Port)
+	// pprof index
+	if code, _ := pprofRequest(t, http.MethodGet, addr+"/debug/pprof/", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized without token, but got: %d", code)
+	}
+	if code, _ := pprofRequest(t, http.MethodGet, addr+"/debug/pprof/", "invalid"); code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized with invalid token, but got: %d", code)
+	}
+	if code, _ := pprofRequest(t, http.MethodGet, addr+"/debug/pprof/goroutine?debug=1", "token"); code != http.StatusOK {
+		t.Errorf("query goroutine profile failed: %d", code)
+	}
+	// capture profiles
+	if code, _ := pprofRequest(t, http.MethodGet, addr+"/api/v1/profile?type=cpu", "token"); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected method not allowed, but got: %d", code)
+	}
+	for _, query := range []string{"type=unknown", "type=cpu&seconds=0", "type=cpu&seconds=a"} {
+		if code, _ := pprofRequest(t, http.MethodPost, addr+"/api/v1/profile?"+query, "token"); code != http.StatusBadRequest {
+			t.Errorf("%s expected bad request, but got: %d", query, code)
+		}
+	}
+	for _, typ := range []string{"cpu", "heap", "trace"} {
+		code, b := pprofRequest(t, http.MethodPost, addr+"/api/v1/profile?seconds=1&type="+typ, "token")
+		if code != http.StatusOK {
+			t.Fatalf("capture %s profile failed: %d, %s", typ, code, string(b))
+		}
+		result := &profileResult{}
+		if err := json.Unmarshal(b, result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Type != typ {
+			t.Errorf("unexpected profile result: %v", result)
+		}
+		info, err := os.Stat(result.Path)
+		if err != nil {
+			t.Fatalf("profile is not written: %v", err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s profile is empty", typ)
+		}
+	}
+	store.Reset()
+}
+
+func TestPProfTokenNotDumped(t *testing.T) {
+	store.Reset()
+	defer store.Reset()
+	store.SetMOSNConfig(&v2.MOSNConfig{
+		Debug: v2.PProfConfig{
+			StartDebug: true,
+			AuthToken:  "pprof-secret-token",
+		},
+	})
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/config_dump", nil)
+	w := httptest.NewRecorder()
+	configDump(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "pprof-secret-token") {
+		t.Fatalf("config dump leaks the pprof auth token: %s", body)
+	}
+}
@@ -20,6 +20,7 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"net/http/pprof"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	jsoniter "github.com/json-iterator/go"
@@ -47,6 +48,15 @@ func init() {
 		"/api/v1/enable_log":      enableLogger,
 		"/api/v1/disbale_log":     disableLogger,
 		"/api/v1/states":          getState,
+		"/api/v1/detailed_stats":  detailedStats,
+		"/api/v1/events":          recentEvents,
+		"/api/v1/log_failures":    logWriteFailures,
+		"/api/v1/profile":         pprofAuth("capture profile", captureProfile),
+		"/debug/pprof/":           pprofAuth("pprof index", pprof.Index),
+		"/debug/pprof/cmdline":    pprofAuth("pprof cmdline", pprof.Cmdline),
+		"/debug/pprof/profile":    pprofAuth("pprof profile", pprof.Profile),
+		"/debug/pprof/symbol":     pprofAuth("pprof symbol", pprof.Symbol),
+		"/debug/pprof/trace":      pprofAuth("pprof trace", pprof.Trace),
 		"/":                       help,
 	}
 }
@@ -60,6 +70,12 @@ func (s *Server) Start(config Config) {
 	if config != nil {
 		// merge MOSNConfig into global context
 		store.SetMOSNConfig(config)
+		// the pprof apis are enabled by the pprof config only
+		if pc, ok := config.(PProfConfig); ok {
+			setPProfConfig(pc.GetPProf())
+		} else {
+			setPProfConfig(nil)
+		}
 		// get admin config
 		adminConfig := config.GetAdmin()
 		if adminConfig == nil {
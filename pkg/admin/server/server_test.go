@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
@@ -33,6 +34,8 @@ import (
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	v2 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
 	"mosn.io/mosn/pkg/admin/store"
+	mosnv2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/event"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/metrics"
 )
@@ -376,6 +379,50 @@ func TestRegisterNewAPI(t *testing.T) {
 	}
 }
 
+func TestRecentEvents(t *testing.T) {
+	time.Sleep(time.Second)
+	server := Server{}
+	config := &mockMOSNConfig{
+		Name: "mock",
+		Port: 8889,
+	}
+	server.Start(config)
+	store.StartService(nil)
+	defer store.StopService()
+
+	time.Sleep(time.Second) //wait server start
+	event.Publish(event.ClusterAdded, "test_cluster", nil)
+	event.Publish(event.ClusterRemoved, "test_cluster", nil)
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/v1/events?n=2", config.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []event.Event
+	if err := rawjson.Unmarshal(b, &events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != event.ClusterAdded || events[1].Type != event.ClusterRemoved {
+		t.Fatalf("unexpected events: %s", string(b))
+	}
+	resp2, err := http.Get(fmt.Sprintf("http://localhost:%d/api/v1/events?n=a", config.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status code: %d", resp2.StatusCode)
+	}
+	store.Reset()
+}
+
 func TestHelpAPI(t *testing.T) {
 	// reset
 	apiHandleFuncStore = map[string]func(http.ResponseWriter, *http.Request){
@@ -439,3 +486,45 @@ func readLines(path string) ([]string, error) {
 	}
 	return lines, scanner.Err()
 }
+
+func TestLogWriteFailures(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/log_failures", nil)
+	w := httptest.NewRecorder()
+	logWriteFailures(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	failures := struct {
+		Outputs []log.LogWriteStatus `json:"outputs"`
+	}{}
+	if err := rawjson.Unmarshal(w.Body.Bytes(), &failures); err != nil {
+		t.Fatalf("unexpected response: %s, error: %v", w.Body.String(), err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/log_failures", nil)
+	w = httptest.NewRecorder()
+	logWriteFailures(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+}
+
+func TestDumpConfigRedactsAdminToken(t *testing.T) {
+	store.Reset()
+	defer store.Reset()
+	store.SetMOSNConfig(&mosnv2.MOSNConfig{
+		AdminAPI: &mosnv2.AdminAPIConfig{AuthToken: "admin-secret-token"},
+	})
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/config_dump", nil)
+	w := httptest.NewRecorder()
+	configDump(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "admin-secret-token") {
+		t.Fatalf("config dump leaks the admin api auth token: %s", body)
+	}
+	if !strings.Contains(body, `"admin_api"`) {
+		t.Fatalf("config dump misses the admin api config: %s", body)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	. "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+	v2 "mosn.io/mosn/pkg/config/v2"
 )
 
 /*
@@ -19,3 +20,9 @@ import (
 type Config interface {
 	GetAdmin() *Admin
 }
+
+// PProfConfig is an optional interface of Config,
+// the pprof apis of the admin server are enabled if it is implemented with an auth token
+type PProfConfig interface {
+	GetPProf() *v2.PProfConfig
+}
@@ -18,10 +18,15 @@
 package store
 
 import (
+	"bytes"
 	"encoding/json"
+	"strings"
 	"sync"
+	"time"
 
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/pkg/utils"
 )
 
 // effectiveConfig represents mosn's runtime config model
@@ -31,27 +36,82 @@ type effectiveConfig struct {
 	Listener   map[string]v2.Listener            `json:"listener,omitempty"`
 	Cluster    map[string]v2.Cluster             `json:"cluster,omitempty"`
 	Routers    map[string]v2.RouterConfiguration `json:"routers,omitempty"`
+	// StreamFilterChains are the stream filters of the listeners in the running order
+	StreamFilterChains map[string][]v2.Filter `json:"stream_filter_chains,omitempty"`
+	// Version increases when any resource is updated
+	Version          uint64                     `json:"version,omitempty"`
+	ListenerVersions map[string]ResourceVersion `json:"listener_versions,omitempty"`
+	ClusterVersions  map[string]ResourceVersion `json:"cluster_versions,omitempty"`
+	RouterVersions   map[string]ResourceVersion `json:"router_versions,omitempty"`
 }
 
+// ResourceVersion records the resource's last update.
+// Version is the effective config's version when the resource is updated
+type ResourceVersion struct {
+	Version     uint64    `json:"version"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// secretKeys are the config keys that should be redacted in dump besides the secret key patterns
+var secretKeys = map[string]bool{
+	"ticket": true,
+	"keys":   true,
+}
+
+// the keys match the secret key patterns but reference the secrets only, such as the environment variable names
+var nonSecretSuffixes = []string{"_env", "_file", "_endpoint", "_name"}
+
+// isSecretKey returns true if the values of the config key should be redacted in dump,
+// the keys end with "_key", or contain "secret", "token" or "password" are secret keys.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range nonSecretSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return false
+		}
+	}
+	return secretKeys[key] ||
+		strings.HasSuffix(key, "_key") ||
+		strings.Contains(key, "secret") ||
+		strings.Contains(key, "token") ||
+		strings.Contains(key, "password")
+}
+
+const redacted = "[redacted]"
+
 var conf effectiveConfig
 var mutex sync.RWMutex
 
 func init() {
 
-	conf = effectiveConfig{
-		Listener: make(map[string]v2.Listener),
-		Cluster:  make(map[string]v2.Cluster),
-		Routers:  make(map[string]v2.RouterConfiguration),
+	conf = newEffectiveConfig()
+}
+
+func newEffectiveConfig() effectiveConfig {
+	return effectiveConfig{
+		Listener:           make(map[string]v2.Listener),
+		Cluster:            make(map[string]v2.Cluster),
+		Routers:            make(map[string]v2.RouterConfiguration),
+		StreamFilterChains: make(map[string][]v2.Filter),
+		ListenerVersions:   make(map[string]ResourceVersion),
+		ClusterVersions:    make(map[string]ResourceVersion),
+		RouterVersions:     make(map[string]ResourceVersion),
+	}
+}
+
+// updateVersion increases the config version, and records the resource's version
+func updateVersion(versions map[string]ResourceVersion, name string) {
+	conf.Version++
+	versions[name] = ResourceVersion{
+		Version:     conf.Version,
+		LastUpdated: time.Now(),
 	}
 }
 
 func Reset() {
 	mutex.Lock()
 	defer mutex.Unlock()
-	conf.MOSNConfig = nil
-	conf.Listener = make(map[string]v2.Listener)
-	conf.Cluster = make(map[string]v2.Cluster)
-	conf.Routers = make(map[string]v2.RouterConfiguration)
+	conf = newEffectiveConfig()
 }
 
 func SetMOSNConfig(msonConfig interface{}) {
@@ -74,18 +134,41 @@ func SetListenerConfig(listenerName string, listenerConfig v2.Listener) {
 	} else {
 		conf.Listener[listenerName] = listenerConfig
 	}
+	if len(listenerConfig.StreamFilters) > 0 {
+		conf.StreamFilterChains[listenerName] = filter.SortStreamFilters(listenerConfig.StreamFilters)
+	} else {
+		delete(conf.StreamFilterChains, listenerName)
+	}
+	updateVersion(conf.ListenerVersions, listenerName)
+}
+
+// RemoveListenerConfig removes the listener config when the listener is deleted
+func RemoveListenerConfig(listenerName string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := conf.Listener[listenerName]; ok {
+		delete(conf.Listener, listenerName)
+		delete(conf.ListenerVersions, listenerName)
+		delete(conf.StreamFilterChains, listenerName)
+		conf.Version++
+	}
 }
 
 func SetClusterConfig(clusterName string, cluster v2.Cluster) {
 	mutex.Lock()
 	defer mutex.Unlock()
 	conf.Cluster[clusterName] = cluster
+	updateVersion(conf.ClusterVersions, clusterName)
 }
 
 func RemoveClusterConfig(clusterName string) {
 	mutex.Lock()
 	defer mutex.Unlock()
-	delete(conf.Cluster, clusterName)
+	if _, ok := conf.Cluster[clusterName]; ok {
+		delete(conf.Cluster, clusterName)
+		delete(conf.ClusterVersions, clusterName)
+		conf.Version++
+	}
 }
 
 func SetHosts(clusterName string, hostConfigs []v2.Host) {
@@ -94,6 +177,7 @@ func SetHosts(clusterName string, hostConfigs []v2.Host) {
 	if cluster, ok := conf.Cluster[clusterName]; ok {
 		cluster.Hosts = hostConfigs
 		conf.Cluster[clusterName] = cluster
+		updateVersion(conf.ClusterVersions, clusterName)
 	}
 }
 
@@ -103,12 +187,78 @@ func SetRouter(routerName string, router v2.RouterConfiguration) {
 	// clear the router's dynamic mode, so the dump api will show all routes in the router
 	router.RouterConfigPath = ""
 	conf.Routers[routerName] = router
+	updateVersion(conf.RouterVersions, routerName)
 }
 
 // Dump
-// Dump all config
+// Dump all config, the secrets such as tls private key are redacted
 func Dump() ([]byte, error) {
 	mutex.RLock()
-	defer mutex.RUnlock()
-	return json.Marshal(conf)
+	data, err := json.Marshal(conf)
+	mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactSecrets(v))
+}
+
+// DumpToFile writes the effective config into the file
+func DumpToFile(path string) error {
+	data, err := Dump()
+	if err != nil {
+		return err
+	}
+	// the dump is readable by the owner only, though the secrets are redacted
+	return utils.WriteFileSafety(path, data, 0600)
+}
+
+// redactSecrets replaces the secrets in a json object,
+// the string values and the string arrays of the secret keys are redacted
+func redactSecrets(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if isSecretKey(key) {
+				if r, ok := redactValue(item); ok {
+					value[key] = r
+					continue
+				}
+			}
+			value[key] = redactSecrets(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactSecrets(item)
+		}
+	}
+	return v
+}
+
+// redactValue redacts the string or the strings in array, returns false if the value is not a string or string array.
+// the empty strings are kept, so the dump shows the secret is not configured.
+func redactValue(v interface{}) (interface{}, bool) {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return value, true
+		}
+		return redacted, true
+	case []interface{}:
+		for _, item := range value {
+			if _, ok := item.(string); !ok {
+				return v, false
+			}
+		}
+		for i, item := range value {
+			value[i], _ = redactValue(item)
+		}
+		return value, true
+	}
+	return v, false
 }
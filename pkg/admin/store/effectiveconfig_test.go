@@ -20,6 +20,10 @@ package store
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"mosn.io/mosn/pkg/config/v2"
@@ -298,7 +302,7 @@ func BenchmarkSetListenerConfig_Add(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		num := i % 100
-		SetListenerConfig(string(num), listener)
+		SetListenerConfig(fmt.Sprint(num), listener)
 	}
 	Reset()
 }
@@ -310,3 +314,125 @@ func BenchmarkDump(b *testing.B) {
 	}
 	Reset()
 }
+
+func TestDumpRedactAndVersion(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetClusterConfig("test", v2.Cluster{
+		Name: "test",
+		TLS: v2.TLSConfig{
+			Status:     true,
+			CertChain:  "cert",
+			PrivateKey: "secret key",
+		},
+	})
+	SetRouter("test_router", v2.RouterConfiguration{})
+	SetClusterConfig("test", v2.Cluster{
+		Name: "test",
+		TLS: v2.TLSConfig{
+			Status:     true,
+			CertChain:  "cert",
+			PrivateKey: "secret key",
+		},
+	})
+	data, err := Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret key") || !strings.Contains(string(data), `"private_key":"[redacted]"`) {
+		t.Fatalf("private key is not redacted: %s", string(data))
+	}
+	if !strings.Contains(string(data), `"cert_chain":"cert"`) {
+		t.Fatalf("cert chain should not be redacted: %s", string(data))
+	}
+	if conf.Version != 3 || conf.ClusterVersions["test"].Version != 3 || conf.RouterVersions["test_router"].Version != 2 {
+		t.Fatalf("unexpected versions, config version: %d, cluster versions: %v, router versions: %v", conf.Version, conf.ClusterVersions, conf.RouterVersions)
+	}
+	RemoveClusterConfig("test")
+	if _, ok := conf.ClusterVersions["test"]; ok || conf.Version != 4 {
+		t.Fatalf("cluster version should be removed, config version: %d", conf.Version)
+	}
+}
+
+func TestDumpRedactSecretKeys(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetMOSNConfig(map[string]interface{}{
+		"oauth2": map[string]interface{}{
+			"client_secret":  "oauth client secret",
+			"cookie_secret":  "oauth cookie secret",
+			"token_endpoint": "https://idp/token",
+		},
+		"tls": map[string]interface{}{
+			"enc_private_key": "sm2 private key",
+			"session_ticket": map[string]interface{}{
+				"keys": []interface{}{"ticket key 1", "ticket key 2"},
+			},
+		},
+		"profile": map[string]interface{}{
+			"trigger_token": "trigger token",
+		},
+		"credentials": map[string]interface{}{
+			"secret_key_env": "AWS_SECRET_ACCESS_KEY",
+			"password":       "",
+		},
+	})
+	data, err := Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := string(data)
+	for _, secret := range []string{"oauth client secret", "oauth cookie secret", "sm2 private key", "ticket key", "trigger token"} {
+		if strings.Contains(dump, secret) {
+			t.Fatalf("%s is not redacted: %s", secret, dump)
+		}
+	}
+	// the references of the secrets and the empty secrets are kept
+	for _, kept := range []string{`"token_endpoint":"https://idp/token"`, `"secret_key_env":"AWS_SECRET_ACCESS_KEY"`, `"password":""`} {
+		if !strings.Contains(dump, kept) {
+			t.Fatalf("%s should not be redacted: %s", kept, dump)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "config_dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dump.json")
+	if err := DumpToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("dump file should be readable by the owner only: %v, %v", info, err)
+	}
+}
+
+func TestDumpStreamFilterChains(t *testing.T) {
+	Reset()
+	defer Reset()
+	SetListenerConfig("test", v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name: "test",
+			StreamFilters: []v2.Filter{
+				{Type: "transform", Phase: v2.StreamFilterPhaseTransform},
+				{Type: "authz", Phase: v2.StreamFilterPhaseAuthz},
+			},
+		},
+	})
+	chain := conf.StreamFilterChains["test"]
+	if len(chain) != 2 || chain[0].Type != "authz" || chain[1].Type != "transform" {
+		t.Fatalf("unexpected stream filter chain: %+v", chain)
+	}
+	data, err := Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"stream_filter_chains":{"test":[{"phase":"authz","type":"authz"},{"phase":"transform","type":"transform"}]}`) {
+		t.Fatalf("stream filter chains are not dumped: %s", string(data))
+	}
+	RemoveListenerConfig("test")
+	if _, ok := conf.StreamFilterChains["test"]; ok {
+		t.Fatal("stream filter chain should be removed")
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package authcache caches the decisions of the authentication filters.
+// The credentials are hashed, so the cache never keeps the raw tokens or passwords.
+package authcache
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+const (
+	defaultPositiveTTL = 5 * time.Minute
+	defaultNegativeTTL = 30 * time.Second
+	defaultMaxEntries  = 10000
+
+	shardCount = 16
+)
+
+// metrics of the caches
+const (
+	authCacheType = "auth_cache"
+
+	statsHit      = "hit"
+	statsMiss     = "miss"
+	statsEviction = "eviction"
+)
+
+var timeNow = time.Now
+
+// Key is the hash of the credentials
+type Key [sha256.Size]byte
+
+// NewKey hashes the parts of the credentials, the parts are separated so ("ab", "c") and ("a", "bc")
+// are different keys. The first part is usually the namespace of the filter, such as the credential file,
+// so the filters sharing a cache do not share the decisions.
+func NewKey(parts ...string) Key {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	var k Key
+	h.Sum(k[:0])
+	return k
+}
+
+type entry struct {
+	allowed bool
+	value   string
+	expire  time.Time
+}
+
+type shard struct {
+	mux     sync.Mutex
+	entries map[Key]entry
+}
+
+// Cache keeps the allowed and denied decisions with different ttl
+type Cache struct {
+	positiveTTL time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+	shards      [shardCount]shard
+
+	hit      gometrics.Counter
+	miss     gometrics.Counter
+	eviction gometrics.Counter
+}
+
+var (
+	cachesMux sync.Mutex
+	caches    = make(map[string]*Cache)
+)
+
+// GetOrCreate returns the cache of the name, the cache is created with the config if not exists
+func GetOrCreate(name string, config *v2.AuthCache) *Cache {
+	cachesMux.Lock()
+	defer cachesMux.Unlock()
+	if c, ok := caches[name]; ok {
+		return c
+	}
+	c := New(name, config)
+	caches[name] = c
+	return c
+}
+
+// New creates a cache, the name is used in the stats
+func New(name string, config *v2.AuthCache) *Cache {
+	m, _ := metrics.NewMetrics(authCacheType, map[string]string{"cache": name})
+	c := &Cache{
+		positiveTTL: config.PositiveTTL.Duration,
+		negativeTTL: config.NegativeTTL.Duration,
+		maxEntries:  config.MaxEntries,
+		hit:         m.Counter(statsHit),
+		miss:        m.Counter(statsMiss),
+		eviction:    m.Counter(statsEviction),
+	}
+	if c.positiveTTL <= 0 {
+		c.positiveTTL = defaultPositiveTTL
+	}
+	if c.negativeTTL <= 0 {
+		c.negativeTTL = defaultNegativeTTL
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultMaxEntries
+	}
+	for i := range c.shards {
+		c.shards[i].entries = make(map[Key]entry)
+	}
+	return c
+}
+
+func (c *Cache) shard(k Key) *shard {
+	return &c.shards[k[0]%shardCount]
+}
+
+// Get returns the decision and the value cached with it, ok is false if not cached or expired
+func (c *Cache) Get(k Key) (allowed bool, value string, ok bool) {
+	s := c.shard(k)
+	s.mux.Lock()
+	e, ok := s.entries[k]
+	if ok && !timeNow().Before(e.expire) {
+		delete(s.entries, k)
+		ok = false
+	}
+	s.mux.Unlock()
+	if !ok {
+		c.miss.Inc(1)
+		return false, "", false
+	}
+	c.hit.Inc(1)
+	return e.allowed, e.value, true
+}
+
+// Set caches the decision, the value is the result of the authentication, such as the user name.
+// An arbitrary entry is evicted if the cache is full.
+func (c *Cache) Set(k Key, allowed bool, value string) {
+	ttl := c.negativeTTL
+	if allowed {
+		ttl = c.positiveTTL
+	}
+	s := c.shard(k)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, exists := s.entries[k]; !exists && len(s.entries) >= c.maxEntries/shardCount+1 {
+		for evict := range s.entries {
+			delete(s.entries, evict)
+			c.eviction.Inc(1)
+			break
+		}
+	}
+	s.entries[k] = entry{
+		allowed: allowed,
+		value:   value,
+		expire:  timeNow().Add(ttl),
+	}
+}
+
+// Len returns the entries cached, including the expired entries not removed yet
+func (c *Cache) Len() int {
+	n := 0
+	for i := range c.shards {
+		c.shards[i].mux.Lock()
+		n += len(c.shards[i].entries)
+		c.shards[i].mux.Unlock()
+	}
+	return n
+}
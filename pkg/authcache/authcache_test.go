@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authcache
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func TestNewKey(t *testing.T) {
+	if NewKey("ab", "c") == NewKey("a", "bc") {
+		t.Error("expected different keys for different parts")
+	}
+	if NewKey("a", "b") != NewKey("a", "b") {
+		t.Error("expected same keys for same parts")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	c := New("test_ttl", &v2.AuthCache{
+		PositiveTTL: api.DurationConfig{Duration: time.Minute},
+		NegativeTTL: api.DurationConfig{Duration: time.Second},
+	})
+	allowedKey, deniedKey := NewKey("user1", "secret"), NewKey("user1", "wrong")
+	if _, _, ok := c.Get(allowedKey); ok {
+		t.Fatal("expected miss")
+	}
+	c.Set(allowedKey, true, "user1")
+	c.Set(deniedKey, false, "")
+	if allowed, value, ok := c.Get(allowedKey); !ok || !allowed || value != "user1" {
+		t.Fatalf("unexpected allowed decision: %v %s %v", allowed, value, ok)
+	}
+	if allowed, _, ok := c.Get(deniedKey); !ok || allowed {
+		t.Fatalf("unexpected denied decision: %v %v", allowed, ok)
+	}
+	// the negative decision expires first
+	now = now.Add(time.Second)
+	if _, _, ok := c.Get(deniedKey); ok {
+		t.Fatal("expected the denied decision expired")
+	}
+	if _, _, ok := c.Get(allowedKey); !ok {
+		t.Fatal("expected the allowed decision cached")
+	}
+	now = now.Add(time.Minute)
+	if _, _, ok := c.Get(allowedKey); ok {
+		t.Fatal("expected the allowed decision expired")
+	}
+	if c.Len() != 0 || c.hit.Count() != 3 || c.miss.Count() != 3 {
+		t.Fatalf("unexpected cache stats, len: %d, hit: %d, miss: %d", c.Len(), c.hit.Count(), c.miss.Count())
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := New("test_eviction", &v2.AuthCache{MaxEntries: 32})
+	for i := 0; i < 1000; i++ {
+		c.Set(NewKey(string(rune(i))), true, "")
+	}
+	if n := c.Len(); n > 32+shardCount {
+		t.Fatalf("expected the entries limited, but got %d", n)
+	}
+	if c.eviction.Count() == 0 {
+		t.Fatal("expected evictions")
+	}
+}
+
+func TestGetOrCreate(t *testing.T) {
+	c := GetOrCreate("test_shared", &v2.AuthCache{MaxEntries: 100})
+	if GetOrCreate("test_shared", &v2.AuthCache{}) != c {
+		t.Fatal("expected the cache shared by name")
+	}
+	if GetOrCreate("test_other", &v2.AuthCache{}) == c {
+		t.Fatal("expected a different cache")
+	}
+	if c.positiveTTL != defaultPositiveTTL || c.negativeTTL != defaultNegativeTTL || c.maxEntries != 100 {
+		t.Fatalf("unexpected cache config: %v %v %d", c.positiveTTL, c.negativeTTL, c.maxEntries)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package baggage propagates the application metadata of the downstream requests to the upstream requests.
+// The baggage is the selected headers of the downstream request and the entries appended by the stream filters,
+// it is set to every upstream request after the protocol conversion.
+package baggage
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+)
+
+// Propagator selects the baggage headers of the downstream requests
+type Propagator struct {
+	headers  map[string]bool
+	prefixes []string
+}
+
+// NewPropagator creates a propagator by the config, nil means no header is propagated
+func NewPropagator(cfg *v2.Baggage) *Propagator {
+	if cfg == nil || (len(cfg.Headers) == 0 && len(cfg.Prefixes) == 0) {
+		return nil
+	}
+	p := &Propagator{
+		headers: make(map[string]bool, len(cfg.Headers)),
+	}
+	for _, h := range cfg.Headers {
+		p.headers[strings.ToLower(h)] = true
+	}
+	for _, prefix := range cfg.Prefixes {
+		p.prefixes = append(p.prefixes, strings.ToLower(prefix))
+	}
+	return p
+}
+
+// IsBaggage returns true if the header should be propagated
+func (p *Propagator) IsBaggage(name string) bool {
+	name = strings.ToLower(name)
+	if p.headers[name] {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Propagate sets the baggage headers of the downstream request headers to the upstream request headers,
+// they are the same headers if the protocol is not converted.
+func (p *Propagator) Propagate(downstream, upstream api.HeaderMap) {
+	if downstream == nil || upstream == nil {
+		return
+	}
+	downstream.Range(func(key, value string) bool {
+		if p.IsBaggage(key) {
+			if v, ok := upstream.Get(key); !ok || v != value {
+				upstream.Set(key, value)
+			}
+		}
+		return true
+	})
+}
+
+type entry struct {
+	key   string
+	value string
+}
+
+// Baggage is the entries appended to a stream
+type Baggage struct {
+	mutex   sync.Mutex
+	entries []entry
+}
+
+// Append appends a baggage entry to the stream, the entry is sent as a header of the upstream requests.
+// The value replaces the value of the entry with the same key. The stream context is a mosn value context,
+// so the baggage is visible to the proxy.
+func Append(ctx context.Context, key, value string) context.Context {
+	b, ok := mosnctx.Get(ctx, types.ContextKeyBaggage).(*Baggage)
+	if !ok {
+		b = &Baggage{}
+		ctx = mosnctx.WithValue(ctx, types.ContextKeyBaggage, b)
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i := range b.entries {
+		if b.entries[i].key == key {
+			b.entries[i].value = value
+			return ctx
+		}
+	}
+	b.entries = append(b.entries, entry{key: key, value: value})
+	return ctx
+}
+
+// Get returns the value of the baggage entry appended to the stream
+func Get(ctx context.Context, key string) (string, bool) {
+	b, ok := mosnctx.Get(ctx, types.ContextKeyBaggage).(*Baggage)
+	if !ok {
+		return "", false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, e := range b.entries {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return "", false
+}
+
+// Inject sets the baggage entries appended to the stream to the headers
+func Inject(ctx context.Context, headers api.HeaderMap) {
+	if ctx == nil || headers == nil {
+		return
+	}
+	b, ok := mosnctx.Get(ctx, types.ContextKeyBaggage).(*Baggage)
+	if !ok {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, e := range b.entries {
+		headers.Set(e.key, e.value)
+	}
+}
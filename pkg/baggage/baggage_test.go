@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package baggage
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestPropagator(t *testing.T) {
+	if NewPropagator(nil) != nil || NewPropagator(&v2.Baggage{}) != nil {
+		t.Fatal("expected no propagator without headers")
+	}
+	p := NewPropagator(&v2.Baggage{
+		Headers:  []string{"X-Tenant"},
+		Prefixes: []string{"X-Biz-"},
+	})
+	downstream := protocol.CommonHeader{
+		"x-tenant":     "alipay",
+		"x-biz-region": "hz",
+		"X-Biz-User":   "1001",
+		"x-other":      "value",
+	}
+	// the converted headers that drop the unknown headers
+	upstream := protocol.CommonHeader{
+		"service": "test",
+	}
+	p.Propagate(downstream, upstream)
+	expected := map[string]string{
+		"service":      "test",
+		"x-tenant":     "alipay",
+		"x-biz-region": "hz",
+		"X-Biz-User":   "1001",
+	}
+	if len(upstream) != len(expected) {
+		t.Fatalf("unexpected upstream headers: %v", upstream)
+	}
+	for k, v := range expected {
+		if upstream[k] != v {
+			t.Errorf("header %s expected %s, but got %s", k, v, upstream[k])
+		}
+	}
+	// the same headers if the protocol is not converted
+	p.Propagate(downstream, downstream)
+	if len(downstream) != 4 {
+		t.Fatalf("unexpected downstream headers: %v", downstream)
+	}
+}
+
+func TestAppendBaggage(t *testing.T) {
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+	if _, ok := Get(ctx, "x-order"); ok {
+		t.Fatal("expected no baggage")
+	}
+	// the baggage is kept in the mosn value context
+	Append(ctx, "x-order", "1")
+	Append(ctx, "x-trace-tag", "gray")
+	Append(ctx, "x-order", "2")
+	if v, ok := Get(ctx, "x-order"); !ok || v != "2" {
+		t.Fatalf("unexpected baggage: %s", v)
+	}
+	headers := protocol.CommonHeader{}
+	Inject(ctx, headers)
+	if len(headers) != 2 || headers["x-order"] != "2" || headers["x-trace-tag"] != "gray" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	// a new value context is created for the standard context
+	ctx = Append(context.Background(), "x-order", "3")
+	if v, ok := Get(ctx, "x-order"); !ok || v != "3" {
+		t.Fatalf("unexpected baggage: %s", v)
+	}
+}
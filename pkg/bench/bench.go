@@ -0,0 +1,245 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package bench is a load generator that drives HTTP/1, HTTP/2 and SofaRpc traffic,
+// it is used to measure the performance of the proxy path.
+package bench
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Protocols that the load generator supports
+const (
+	HTTP1   = "http1"
+	HTTP2   = "http2"
+	SofaRPC = "bolt"
+)
+
+const (
+	defaultConcurrency = 1
+	defaultDuration    = 10 * time.Second
+	defaultTimeout     = 5 * time.Second
+)
+
+var (
+	ErrNoTarget            = errors.New("bench target address is required")
+	ErrUnsupportedProtocol = errors.New("bench protocol is not supported")
+)
+
+// Config is the load generation configuration
+type Config struct {
+	Protocol string
+	Target   string
+	// Path, Method are used in http
+	Path    string
+	Method  string
+	Headers map[string]string
+	Body    []byte
+	// QPS is the max requests per second of all the workers, zero means no limit
+	QPS int
+	// Concurrency is the number of workers, each worker has its own connection
+	Concurrency int
+	// RampUp starts the workers evenly in the duration
+	RampUp time.Duration
+	// Duration is the running time, if Requests is set, the generator stops
+	// when the requests are sent or the duration elapses
+	Duration time.Duration
+	Requests int64
+	Timeout  time.Duration
+}
+
+func (cfg *Config) setDefaults() error {
+	if cfg.Target == "" {
+		return ErrNoTarget
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = HTTP1
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = defaultDuration
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Method == "" {
+		cfg.Method = "GET"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	return nil
+}
+
+// Client sends a request and waits for the response
+type Client interface {
+	Do() error
+	Close()
+}
+
+// NewClient creates a client by the protocol
+func NewClient(cfg *Config) (Client, error) {
+	switch cfg.Protocol {
+	case HTTP1:
+		return newHTTPClient(cfg, false), nil
+	case HTTP2:
+		return newHTTPClient(cfg, true), nil
+	case SofaRPC:
+		return newBoltClient(cfg)
+	}
+	return nil, ErrUnsupportedProtocol
+}
+
+// Run generates the load, and returns the report when finished
+func Run(cfg Config) (*Report, error) {
+	if err := cfg.setDefaults(); err != nil {
+		return nil, err
+	}
+	clients := make([]Client, 0, cfg.Concurrency)
+	defer func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}()
+	for i := 0; i < cfg.Concurrency; i++ {
+		c, err := NewClient(&cfg)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	r := &runner{
+		cfg:  &cfg,
+		stop: make(chan struct{}),
+	}
+	return r.run(clients), nil
+}
+
+type runner struct {
+	cfg     *Config
+	stop    chan struct{}
+	once    sync.Once
+	sent    int64
+	tokens  chan struct{}
+	results []*recorder
+}
+
+func (r *runner) close() {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+}
+
+// acquire returns false if the runner should be stopped
+func (r *runner) acquire() bool {
+	if r.cfg.Requests > 0 && atomic.AddInt64(&r.sent, 1) > r.cfg.Requests {
+		r.close()
+		return false
+	}
+	if r.tokens == nil {
+		select {
+		case <-r.stop:
+			return false
+		default:
+			return true
+		}
+	}
+	select {
+	case <-r.stop:
+		return false
+	case <-r.tokens:
+		return true
+	}
+}
+
+// limit produces the tokens in a fixed rate
+func (r *runner) limit() {
+	interval := time.Second / time.Duration(r.cfg.QPS)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			select {
+			case r.tokens <- struct{}{}:
+			default:
+				// the workers can not catch up with the rate, drop the token
+			}
+		}
+	}
+}
+
+func (r *runner) run(clients []Client) *Report {
+	if r.cfg.QPS > 0 {
+		r.tokens = make(chan struct{}, len(clients))
+		go r.limit()
+	}
+	var rampStep time.Duration
+	if r.cfg.RampUp > 0 && len(clients) > 1 {
+		rampStep = r.cfg.RampUp / time.Duration(len(clients)-1)
+	}
+	start := time.Now()
+	timer := time.AfterFunc(r.cfg.Duration, r.close)
+	defer timer.Stop()
+	wg := sync.WaitGroup{}
+	r.results = make([]*recorder, len(clients))
+	for i, c := range clients {
+		if i > 0 && rampStep > 0 {
+			select {
+			case <-r.stop:
+			case <-time.After(rampStep):
+			}
+		}
+		rec := &recorder{}
+		r.results[i] = rec
+		wg.Add(1)
+		go func(c Client) {
+			defer wg.Done()
+			for r.acquire() {
+				begin := time.Now()
+				err := c.Do()
+				rec.record(time.Since(begin), err)
+			}
+		}(c)
+	}
+	wg.Wait()
+	return newReport(r.cfg, time.Since(start), r.results)
+}
+
+// recorder records the results of a worker
+type recorder struct {
+	latencies []time.Duration
+	errors    int64
+	lastError error
+}
+
+func (rec *recorder) record(latency time.Duration, err error) {
+	if err != nil {
+		rec.errors++
+		rec.lastError = err
+		return
+	}
+	rec.latencies = append(rec.latencies, latency)
+}
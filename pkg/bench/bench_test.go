@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bench
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunHTTP1(t *testing.T) {
+	var count int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&count, 1)%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	report, err := Run(Config{
+		Target:      strings.TrimPrefix(server.URL, "http://"),
+		Concurrency: 4,
+		Requests:    100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Requests != 100 || report.Errors != 10 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+	if report.Percentiles["p50"] == 0 || report.Max < report.Percentiles["p99"] {
+		t.Fatalf("unexpected latency: %s", report)
+	}
+}
+
+func TestRunQPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	report, err := Run(Config{
+		Target:      strings.TrimPrefix(server.URL, "http://"),
+		Concurrency: 2,
+		QPS:         50,
+		RampUp:      100 * time.Millisecond,
+		Duration:    500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50 qps in 500ms, about 25 requests
+	if report.Requests < 15 || report.Requests > 30 || report.Errors != 0 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+}
+
+func TestRunConfigError(t *testing.T) {
+	if _, err := Run(Config{}); err != ErrNoTarget {
+		t.Fatalf("expected no target error, but got %v", err)
+	}
+	if _, err := Run(Config{Target: "127.0.0.1:80", Protocol: "unknown"}); err != ErrUnsupportedProtocol {
+		t.Fatalf("expected unsupported protocol error, but got %v", err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	rec := &recorder{}
+	for i := 1; i <= 1000; i++ {
+		rec.record(time.Duration(i)*time.Millisecond, nil)
+	}
+	rec.record(0, errors.New("mock error"))
+	report := newReport(&Config{}, time.Second, []*recorder{rec})
+	if report.Requests != 1001 || report.Errors != 1 || report.LastError != "mock error" {
+		t.Fatalf("unexpected report: %s", report)
+	}
+	expected := map[string]time.Duration{
+		"p50":  500 * time.Millisecond,
+		"p90":  900 * time.Millisecond,
+		"p99":  990 * time.Millisecond,
+		"p999": 999 * time.Millisecond,
+	}
+	for name, v := range expected {
+		if report.Percentiles[name] != v {
+			t.Fatalf("%s expected %s, but got %s", name, v, report.Percentiles[name])
+		}
+	}
+	if report.Min != time.Millisecond || report.Max != time.Second {
+		t.Fatalf("unexpected report: %s", report)
+	}
+}
@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/rpc"
+	"mosn.io/mosn/pkg/protocol/rpc/sofarpc"
+	_ "mosn.io/mosn/pkg/protocol/rpc/sofarpc/codec"
+	"mosn.io/mosn/pkg/protocol/serialize"
+	"mosn.io/mosn/pkg/stream"
+	_ "mosn.io/mosn/pkg/stream/sofarpc"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+var errTimeout = errors.New("request timeout")
+
+// httpClient sends http/1 or http/2 requests, each client has its own connection
+type httpClient struct {
+	cfg       *Config
+	url       string
+	client    *http.Client
+	transport io.Closer
+}
+
+type idleCloser interface {
+	CloseIdleConnections()
+}
+
+type transportCloser struct {
+	idleCloser
+}
+
+func (c transportCloser) Close() error {
+	c.CloseIdleConnections()
+	return nil
+}
+
+func newHTTPClient(cfg *Config, h2 bool) *httpClient {
+	var transport http.RoundTripper
+	if h2 {
+		// h2c, http/2 without tls
+		transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.DialTimeout(network, addr, cfg.Timeout)
+			},
+		}
+	} else {
+		transport = &http.Transport{
+			MaxIdleConnsPerHost: 1,
+			DisableCompression:  true,
+		}
+	}
+	return &httpClient{
+		cfg: cfg,
+		url: fmt.Sprintf("http://%s%s", cfg.Target, cfg.Path),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+		},
+		transport: transportCloser{transport.(idleCloser)},
+	}
+}
+
+func (c *httpClient) Do() error {
+	return c.send(c.cfg.Method, c.url, "", c.cfg.Headers, c.cfg.Body)
+}
+
+// send sends a request, the host overrides the host in the url if it is not empty
+func (c *httpClient) send(method, url, host string, headers map[string]string, data []byte) error {
+	var body io.Reader
+	if len(data) > 0 {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if host != "" {
+		req.Host = host
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// read the body to reuse the connection
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("response status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *httpClient) Close() {
+	c.transport.Close()
+}
+
+// boltClient sends sofarpc bolt v1 requests by the mosn stream client
+type boltClient struct {
+	cfg     *Config
+	conn    types.ClientConnection
+	client  stream.Client
+	headers []byte
+}
+
+func newBoltClient(cfg *Config) (*boltClient, error) {
+	remoteAddr, err := net.ResolveTCPAddr("tcp", cfg.Target)
+	if err != nil {
+		return nil, err
+	}
+	conn := network.NewClientConnection(nil, cfg.Timeout, nil, remoteAddr, make(chan struct{}))
+	if err := conn.Connect(); err != nil {
+		return nil, err
+	}
+	client := stream.NewStreamClient(context.Background(), protocol.SofaRPC, conn, nil)
+	if client == nil {
+		conn.Close(api.NoFlush, api.LocalClose)
+		return nil, ErrUnsupportedProtocol
+	}
+	headers := cfg.Headers
+	if len(headers) == 0 {
+		// used for sofa routing
+		headers = map[string]string{"service": "bench"}
+	}
+	buf := buffer.NewIoBuffer(128)
+	if err := serialize.Instance.SerializeMap(headers, buf); err != nil {
+		conn.Close(api.NoFlush, api.LocalClose)
+		return nil, err
+	}
+	return &boltClient{
+		cfg:     cfg,
+		conn:    conn,
+		client:  client,
+		headers: buf.Bytes(),
+	}, nil
+}
+
+func (c *boltClient) Do() error {
+	receiver := &boltReceiver{
+		done: make(chan error, 1),
+	}
+	request := &sofarpc.BoltRequest{
+		Protocol:   sofarpc.PROTOCOL_CODE_V1,
+		CmdType:    sofarpc.REQUEST,
+		CmdCode:    sofarpc.RPC_REQUEST,
+		Version:    1,
+		Codec:      sofarpc.HESSIAN2_SERIALIZE,
+		Timeout:    int(c.cfg.Timeout / time.Millisecond),
+		HeaderMap:  c.headers,
+		HeaderLen:  int16(len(c.headers)),
+		ContentLen: len(c.cfg.Body),
+	}
+	ctx := context.Background()
+	encoder := c.client.NewStream(ctx, receiver)
+	if len(c.cfg.Body) == 0 {
+		encoder.AppendHeaders(ctx, request, true)
+	} else {
+		encoder.AppendHeaders(ctx, request, false)
+		encoder.AppendData(ctx, buffer.NewIoBufferBytes(c.cfg.Body), true)
+	}
+	select {
+	case err := <-receiver.done:
+		return err
+	case <-time.After(c.cfg.Timeout):
+		return errTimeout
+	}
+}
+
+func (c *boltClient) Close() {
+	c.conn.Close(api.NoFlush, api.LocalClose)
+}
+
+type boltReceiver struct {
+	done chan error
+}
+
+// finish does not block, only the first result is received
+func (r *boltReceiver) finish(err error) {
+	select {
+	case r.done <- err:
+	default:
+	}
+}
+
+func (r *boltReceiver) OnReceive(ctx context.Context, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+	if resp, ok := headers.(rpc.RespStatus); ok {
+		if status := int16(resp.RespStatus()); status != sofarpc.RESPONSE_STATUS_SUCCESS {
+			r.finish(fmt.Errorf("response status: %d", status))
+			return
+		}
+	}
+	r.finish(nil)
+}
+
+func (r *boltReceiver) OnDecodeError(ctx context.Context, err error, headers types.HeaderMap) {
+	r.finish(err)
+}
@@ -0,0 +1,251 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/protocol"
+)
+
+const (
+	// accessLogHeaderPrefix is the prefix of the request header fields in the json format access log
+	accessLogHeaderPrefix = "request_header_"
+	accessLogStartTime    = "start_time"
+	// accessLogTimeLayout is the layout of the start time in the access log
+	accessLogTimeLayout = "2006/01/02 15:04:05.000"
+	// maxRecordSize limits the size of a captured request line
+	maxRecordSize = 16 * 1024 * 1024
+)
+
+var ErrNoRecords = errors.New("no requests to replay")
+
+// Record is a captured http request
+type Record struct {
+	Time    time.Time         `json:"time,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Path    string            `json:"path,omitempty"`
+	Host    string            `json:"host,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is encoded in base64 in json
+	Body []byte `json:"body,omitempty"`
+}
+
+// ReadRecords reads the captured requests in json lines. A line is a Record, or a json format access log
+// which has the request headers fields, such as "request_header_x-mosn-path". The access logs have no bodies.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(data), &fields); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		var record Record
+		var err error
+		if isAccessLog(fields) {
+			record, err = parseAccessLog(fields)
+		} else {
+			err = json.Unmarshal([]byte(data), &record)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func isAccessLog(fields map[string]json.RawMessage) bool {
+	for key := range fields {
+		if strings.HasPrefix(key, accessLogHeaderPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAccessLog(fields map[string]json.RawMessage) (Record, error) {
+	record := Record{
+		Headers: make(map[string]string),
+	}
+	for key, raw := range fields {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// not a string field
+			continue
+		}
+		if key == accessLogStartTime {
+			t, err := time.ParseInLocation(accessLogTimeLayout, value, time.Local)
+			if err != nil {
+				return record, err
+			}
+			record.Time = t
+			continue
+		}
+		if !strings.HasPrefix(key, accessLogHeaderPrefix) || value == "" {
+			continue
+		}
+		name := strings.ToLower(key[len(accessLogHeaderPrefix):])
+		switch name {
+		case protocol.MosnHeaderMethod:
+			record.Method = value
+		case protocol.MosnHeaderPathKey:
+			record.Path = value + record.Path
+		case protocol.MosnHeaderQueryStringKey:
+			record.Path = record.Path + "?" + value
+		case protocol.MosnHeaderHostKey:
+			record.Host = value
+		default:
+			if !strings.HasPrefix(name, "x-mosn-") {
+				record.Headers[name] = value
+			}
+		}
+	}
+	return record, nil
+}
+
+// ReplayConfig is the configuration of replaying the captured requests
+type ReplayConfig struct {
+	// Protocol is http1 or http2
+	Protocol string
+	// Targets are the addresses the requests are sent to in turn, such as the mosn listener or the cluster hosts
+	Targets []string
+	Records []Record
+	// Speed is the multiple of the captured pace, zero means as fast as possible.
+	// The records without time are sent as fast as possible
+	Speed float64
+	// Concurrency is the number of workers, each worker has its own connections
+	Concurrency int
+	Timeout     time.Duration
+	// Loops is the times the records are replayed, default is 1
+	Loops int
+}
+
+// Replay sends the captured requests at the configured speed, and returns the report when finished
+func Replay(cfg ReplayConfig) (*Report, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, ErrNoTarget
+	}
+	if len(cfg.Records) == 0 {
+		return nil, ErrNoRecords
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = HTTP1
+	}
+	if cfg.Protocol != HTTP1 && cfg.Protocol != HTTP2 {
+		return nil, ErrUnsupportedProtocol
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.Loops <= 0 {
+		cfg.Loops = 1
+	}
+	clientCfg := &Config{
+		Protocol:    cfg.Protocol,
+		Target:      strings.Join(cfg.Targets, ","),
+		Concurrency: cfg.Concurrency,
+		Timeout:     cfg.Timeout,
+	}
+	requests := make(chan int, cfg.Concurrency)
+	results := make([]*recorder, cfg.Concurrency)
+	wg := sync.WaitGroup{}
+	start := time.Now()
+	for i := 0; i < cfg.Concurrency; i++ {
+		rec := &recorder{}
+		results[i] = rec
+		c := newHTTPClient(clientCfg, cfg.Protocol == HTTP2)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer c.Close()
+			for seq := range requests {
+				r := &cfg.Records[seq%len(cfg.Records)]
+				target := cfg.Targets[seq%len(cfg.Targets)]
+				begin := time.Now()
+				err := c.send(r.method(), "http://"+target+r.path(), r.Host, r.Headers, r.Body)
+				rec.record(time.Since(begin), err)
+			}
+		}()
+	}
+	dispatch(&cfg, requests, start)
+	close(requests)
+	wg.Wait()
+	return newReport(clientCfg, time.Since(start), results), nil
+}
+
+// dispatch sends the sequences of the requests when they are due, a loop starts after the previous one
+func dispatch(cfg *ReplayConfig, requests chan<- int, start time.Time) {
+	seq := 0
+	for loop := 0; loop < cfg.Loops; loop++ {
+		first := cfg.Records[0].Time
+		loopStart := time.Now()
+		if loop == 0 {
+			loopStart = start
+		}
+		for i := range cfg.Records {
+			r := &cfg.Records[i]
+			if cfg.Speed > 0 && !first.IsZero() && !r.Time.IsZero() {
+				due := loopStart.Add(time.Duration(float64(r.Time.Sub(first)) / cfg.Speed))
+				if wait := time.Until(due); wait > 0 {
+					time.Sleep(wait)
+				}
+			}
+			requests <- seq
+			seq++
+		}
+	}
+}
+
+func (r *Record) method() string {
+	if r.Method == "" {
+		return "GET"
+	}
+	return r.Method
+}
+
+func (r *Record) path() string {
+	if r.Path == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(r.Path, "/") {
+		return "/" + r.Path
+	}
+	return r.Path
+}
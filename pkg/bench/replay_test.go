@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bench
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadRecords(t *testing.T) {
+	input := `{"time":"2020-03-01T10:00:00Z","method":"POST","path":"/api?id=1","host":"example.com","headers":{"x-user":"u1"},"body":"aGVsbG8="}
+
+{"start_time":"2020/03/01 10:00:00.500","request_header_x-mosn-method":"GET","request_header_x-mosn-path":"/users","request_header_x-mosn-querystring":"page=2","request_header_x-mosn-host":"example.com","request_header_X-User":"u2","response_code":"200"}
+`
+	records, err := ReadRecords(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("unexpected records: %v", records)
+	}
+	r := records[0]
+	if r.Method != "POST" || r.Path != "/api?id=1" || r.Host != "example.com" || r.Headers["x-user"] != "u1" || string(r.Body) != "hello" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	r = records[1]
+	if r.Method != "GET" || r.Path != "/users?page=2" || r.Host != "example.com" || r.Headers["x-user"] != "u2" || len(r.Headers) != 1 {
+		t.Fatalf("unexpected access log record: %+v", r)
+	}
+	if r.Time.Sub(time.Date(2020, 3, 1, 10, 0, 0, 0, time.Local)) != 500*time.Millisecond {
+		t.Fatalf("unexpected access log time: %v", r.Time)
+	}
+	if _, err := ReadRecords(strings.NewReader("not json\n")); err == nil {
+		t.Fatal("expected invalid record error")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var mux sync.Mutex
+	var received []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mux.Lock()
+		received = append(received, r.Method+" "+r.Host+" "+r.URL.String()+" "+r.Header.Get("x-user")+" "+string(body))
+		mux.Unlock()
+	})
+	s1 := httptest.NewServer(handler)
+	defer s1.Close()
+	s2 := httptest.NewServer(handler)
+	defer s2.Close()
+	report, err := Replay(ReplayConfig{
+		Targets: []string{strings.TrimPrefix(s1.URL, "http://"), strings.TrimPrefix(s2.URL, "http://")},
+		Records: []Record{
+			{Method: "POST", Path: "/api", Host: "example.com", Headers: map[string]string{"x-user": "u1"}, Body: []byte("hello")},
+			{Path: "users"},
+		},
+		Loops: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Requests != 4 || report.Errors != 0 {
+		t.Fatalf("unexpected report: %s", report)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(received) != 4 {
+		t.Fatalf("unexpected received: %v", received)
+	}
+	count := 0
+	for _, r := range received {
+		if r == "POST example.com /api u1 hello" {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Fatalf("unexpected received: %v", received)
+	}
+}
+
+func TestReplaySpeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	now := time.Now()
+	records := []Record{{Time: now}, {Time: now.Add(200 * time.Millisecond)}}
+	report, err := Replay(ReplayConfig{
+		Targets: []string{strings.TrimPrefix(server.URL, "http://")},
+		Records: records,
+		Speed:   2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Requests != 2 || report.Duration < 100*time.Millisecond || report.Duration > time.Second {
+		t.Fatalf("unexpected report: %s", report)
+	}
+	// as fast as possible
+	report, err = Replay(ReplayConfig{
+		Targets: []string{strings.TrimPrefix(server.URL, "http://")},
+		Records: records,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Duration >= 100*time.Millisecond {
+		t.Fatalf("expected replayed without pacing: %s", report)
+	}
+}
+
+func TestReplayInvalid(t *testing.T) {
+	if _, err := Replay(ReplayConfig{Records: []Record{{}}}); err != ErrNoTarget {
+		t.Fatalf("expected no target, got %v", err)
+	}
+	if _, err := Replay(ReplayConfig{Targets: []string{"127.0.0.1:80"}}); err != ErrNoRecords {
+		t.Fatalf("expected no records, got %v", err)
+	}
+	if _, err := Replay(ReplayConfig{Targets: []string{"127.0.0.1:80"}, Records: []Record{{}}, Protocol: SofaRPC}); err != ErrUnsupportedProtocol {
+		t.Fatalf("expected unsupported protocol, got %v", err)
+	}
+}
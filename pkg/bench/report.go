@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bench
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the result of a load generation
+type Report struct {
+	Protocol    string                   `json:"protocol"`
+	Target      string                   `json:"target"`
+	Concurrency int                      `json:"concurrency"`
+	Duration    time.Duration            `json:"duration"`
+	Requests    int64                    `json:"requests"`
+	Errors      int64                    `json:"errors"`
+	LastError   string                   `json:"last_error,omitempty"`
+	QPS         float64                  `json:"qps"`
+	Mean        time.Duration            `json:"mean"`
+	Min         time.Duration            `json:"min"`
+	Max         time.Duration            `json:"max"`
+	Percentiles map[string]time.Duration `json:"percentiles"`
+}
+
+var percentiles = []struct {
+	name  string
+	value float64
+}{
+	{"p50", 0.5},
+	{"p90", 0.9},
+	{"p99", 0.99},
+	{"p999", 0.999},
+}
+
+func newReport(cfg *Config, duration time.Duration, results []*recorder) *Report {
+	report := &Report{
+		Protocol:    cfg.Protocol,
+		Target:      cfg.Target,
+		Concurrency: cfg.Concurrency,
+		Duration:    duration,
+		Percentiles: make(map[string]time.Duration, len(percentiles)),
+	}
+	var latencies []time.Duration
+	for _, rec := range results {
+		if rec == nil {
+			continue
+		}
+		latencies = append(latencies, rec.latencies...)
+		report.Errors += rec.errors
+		if rec.lastError != nil {
+			report.LastError = rec.lastError.Error()
+		}
+	}
+	report.Requests = int64(len(latencies)) + report.Errors
+	if duration > 0 {
+		report.QPS = float64(report.Requests) / duration.Seconds()
+	}
+	if len(latencies) == 0 {
+		return report
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	report.Mean = total / time.Duration(len(latencies))
+	report.Min = latencies[0]
+	report.Max = latencies[len(latencies)-1]
+	for _, p := range percentiles {
+		idx := int(float64(len(latencies))*p.value+0.5) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= len(latencies) {
+			idx = len(latencies) - 1
+		}
+		report.Percentiles[p.name] = latencies[idx]
+	}
+	return report
+}
+
+func (r *Report) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "target: %s, protocol: %s, concurrency: %d, duration: %s\n", r.Target, r.Protocol, r.Concurrency, r.Duration)
+	fmt.Fprintf(b, "requests: %d, errors: %d, qps: %.2f\n", r.Requests, r.Errors, r.QPS)
+	fmt.Fprintf(b, "latency: mean %s, min %s, max %s\n", r.Mean, r.Min, r.Max)
+	for _, p := range percentiles {
+		fmt.Fprintf(b, "  %-5s %s\n", p.name, r.Percentiles[p.name])
+	}
+	if r.LastError != "" {
+		fmt.Fprintf(b, "last error: %s\n", r.LastError)
+	}
+	return b.String()
+}
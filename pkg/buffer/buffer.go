@@ -19,11 +19,13 @@ package buffer
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"unsafe"
 
 	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/memory"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -74,13 +76,38 @@ func RegisterBuffer(poolCtx types.BufferPoolCtx) {
 		panic("bufferSize over full")
 	}
 	bPool[i].ctx = poolCtx
+	bPool[i].account = memory.GetAccount(memoryModule(poolCtx))
+	bPool[i].size = objectSize(poolCtx.New())
 	setIndex(poolCtx, int(i))
 }
 
+// MemoryModule is implemented by the buffer pool context that is accounted as a module
+// other than the codec scratch buffers, such as the stream objects
+type MemoryModule interface {
+	MemoryModule() string
+}
+
+func memoryModule(poolCtx types.BufferPoolCtx) string {
+	if m, ok := poolCtx.(MemoryModule); ok {
+		return m.MemoryModule()
+	}
+	return memory.ModuleCodec
+}
+
+// objectSize returns the shallow size of the buffer
+func objectSize(x interface{}) int64 {
+	if x == nil {
+		return 0
+	}
+	return int64(reflect.Indirect(reflect.ValueOf(x)).Type().Size())
+}
+
 // bufferPool is buffer pool
 type bufferPool struct {
 	ctx types.BufferPoolCtx
 	sync.Pool
+	account *memory.Account
+	size    int64
 }
 
 type valuePool struct {
@@ -93,6 +120,7 @@ func (p *bufferPool) take() (value interface{}) {
 	if value == nil {
 		value = p.ctx.New()
 	}
+	p.account.Alloc(p.size)
 	return
 }
 
@@ -100,6 +128,7 @@ func (p *bufferPool) take() (value interface{}) {
 func (p *bufferPool) give(value interface{}) {
 	p.ctx.Reset(value)
 	p.Put(value)
+	p.account.Free(p.size)
 }
 
 // bufferValue is buffer pool's Value
@@ -178,6 +207,22 @@ func (bv *bufferValue) Give() {
 	vPool.Put(bv)
 }
 
+// Discard releases the accounting of the buffers that are not given back to the buffer pools,
+// the buffers are released by the GC
+func (bv *bufferValue) Discard() {
+	if index <= 0 {
+		return
+	}
+	for i := 1; i <= int(index); i++ {
+		if bv.value[i] != nil {
+			bPool[i].account.Free(bPool[i].size)
+		}
+		if bv.transmit[i] != nil {
+			bPool[i].account.Free(bPool[i].size)
+		}
+	}
+}
+
 // PoolContext returns bufferValue by context
 func PoolContext(ctx context.Context) *bufferValue {
 	if ctx != nil {
@@ -21,6 +21,9 @@ import (
 	"context"
 	"runtime/debug"
 	"testing"
+	"unsafe"
+
+	"mosn.io/mosn/pkg/memory"
 )
 
 //test bufferpool
@@ -91,3 +94,34 @@ func Test_BufferPool(t *testing.T) {
 
 	debug.SetGCPercent(100)
 }
+
+var accountMock accountMockBufferCtx
+
+type accountMockBufferCtx struct {
+	mock_bufferctx
+}
+
+func (ctx *accountMockBufferCtx) MemoryModule() string {
+	return "test_buffer"
+}
+
+func Test_BufferPoolAccount(t *testing.T) {
+	memory.Enable()
+	RegisterBuffer(&accountMock)
+	account := memory.GetAccount("test_buffer")
+
+	ctx1 := NewBufferPoolContext(context.Background())
+	PoolContext(ctx1).Find(&accountMock, nil)
+	ctx2 := NewBufferPoolContext(context.Background())
+	PoolContext(ctx2).Find(&accountMock, nil)
+	if account.Objects() != 2 || account.Bytes() != 2*int64(unsafe.Sizeof(mock_buffers{})) {
+		t.Fatalf("buffers taken are not accounted, objects: %d, bytes: %d", account.Objects(), account.Bytes())
+	}
+	// given back to the pool
+	PoolContext(ctx1).Give()
+	// not reused
+	PoolContext(ctx2).Discard()
+	if account.Objects() != 0 || account.Bytes() != 0 {
+		t.Fatalf("buffers released are not accounted, objects: %d, bytes: %d", account.Objects(), account.Bytes())
+	}
+}
@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package chaos provides the deterministic fault hooks in the proxy core, such as dropping the upstream
+// connection after some bytes, delaying the pool allocation and corrupting the response trailers.
+// The hooks do nothing unless the faults are installed by the tests, or by the chaos config
+// in a mosn built with the "chaos" tag.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+)
+
+// CorruptedValue replaces the values of the corrupted trailers
+const CorruptedValue = "\x00chaos-corrupted"
+
+var (
+	ErrUnknownFaultType = errors.New("unknown chaos fault type")
+	ErrDuplicateFault   = errors.New("duplicate chaos fault name")
+)
+
+type fault struct {
+	config  v2.ChaosFault
+	matched uint32
+	fired   uint32
+}
+
+func (f *fault) match(faultType, cluster, host string) bool {
+	if f.config.Type != faultType {
+		return false
+	}
+	if cluster != "" && f.config.Cluster != "" && f.config.Cluster != cluster {
+		return false
+	}
+	if host != "" && f.config.Host != "" && f.config.Host != host {
+		return false
+	}
+	return true
+}
+
+// fire counts the matched point and returns whether the fault fires on it
+func (f *fault) fire() bool {
+	n := atomic.AddUint32(&f.matched, 1)
+	if n <= f.config.After {
+		return false
+	}
+	if f.config.Times > 0 && n > f.config.After+f.config.Times {
+		return false
+	}
+	atomic.AddUint32(&f.fired, 1)
+	return true
+}
+
+type faults struct {
+	list   []*fault
+	byName map[string]*fault
+}
+
+var (
+	mutex sync.Mutex
+	// installed stores the *faults, nil if no fault is installed
+	installed atomic.Value
+)
+
+func current() *faults {
+	fs, _ := installed.Load().(*faults)
+	return fs
+}
+
+// Install replaces the installed faults
+func Install(configs []v2.ChaosFault) error {
+	fs := &faults{
+		byName: make(map[string]*fault, len(configs)),
+	}
+	for i, config := range configs {
+		switch config.Type {
+		case v2.ChaosDropUpstreamConnection, v2.ChaosDelayPoolAllocation, v2.ChaosCorruptTrailer:
+		default:
+			return fmt.Errorf("%v: %s", ErrUnknownFaultType, config.Type)
+		}
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("%s-%d", config.Type, i)
+		}
+		if _, ok := fs.byName[config.Name]; ok {
+			return fmt.Errorf("%v: %s", ErrDuplicateFault, config.Name)
+		}
+		f := &fault{config: config}
+		fs.list = append(fs.list, f)
+		fs.byName[config.Name] = f
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(fs.list) == 0 {
+		installed.Store((*faults)(nil))
+	} else {
+		installed.Store(fs)
+	}
+	return nil
+}
+
+// Uninstall removes all the installed faults
+func Uninstall() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	installed.Store((*faults)(nil))
+}
+
+// Enabled returns whether any fault is installed
+func Enabled() bool {
+	return current() != nil
+}
+
+// Fired returns the times the installed fault fired
+func Fired(name string) uint32 {
+	fs := current()
+	if fs == nil {
+		return 0
+	}
+	if f, ok := fs.byName[name]; ok {
+		return atomic.LoadUint32(&f.fired)
+	}
+	return 0
+}
+
+// firing returns the first installed fault of the type that fires on the point
+func firing(faultType, cluster, host string) *fault {
+	fs := current()
+	if fs == nil {
+		return nil
+	}
+	for _, f := range fs.list {
+		if f.match(faultType, cluster, host) && f.fire() {
+			log.DefaultLogger.Infof("[chaos] fault %s fired, cluster: %s, host: %s", f.config.Name, cluster, host)
+			return f
+		}
+	}
+	return nil
+}
+
+// ConnectionFault drops an upstream connection after the budget bytes are read
+type ConnectionFault struct {
+	budget int64
+	read   int64
+}
+
+// UpstreamConnection returns the fault of the new upstream connection to the host, nil if no fault fires
+func UpstreamConnection(host string) *ConnectionFault {
+	f := firing(v2.ChaosDropUpstreamConnection, "", host)
+	if f == nil {
+		return nil
+	}
+	return &ConnectionFault{budget: f.config.Bytes}
+}
+
+// Read counts the bytes read from the connection, returns the bytes kept and whether the connection is dropped
+func (c *ConnectionFault) Read(n int64) (int64, bool) {
+	if c.read+n < c.budget {
+		c.read += n
+		return n, false
+	}
+	kept := c.budget - c.read
+	if kept < 0 {
+		kept = 0
+	}
+	c.read = c.budget
+	return kept, true
+}
+
+// DelayPoolAllocation sleeps before the connection pool of the cluster is allocated
+func DelayPoolAllocation(cluster string) {
+	if f := firing(v2.ChaosDelayPoolAllocation, cluster, ""); f != nil {
+		time.Sleep(f.config.Delay.Duration)
+	}
+}
+
+// CorruptTrailer replaces the values of the response trailers received from the host
+func CorruptTrailer(cluster, host string, trailers api.HeaderMap) {
+	if trailers == nil {
+		return
+	}
+	if f := firing(v2.ChaosCorruptTrailer, cluster, host); f == nil {
+		return
+	}
+	var keys []string
+	trailers.Range(func(key, value string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	for _, key := range keys {
+		trailers.Set(key, CorruptedValue)
+	}
+}
+
+// Init installs the faults in the chaos config, the config is ignored unless mosn is built with the "chaos" tag
+func Init(config *v2.ChaosConfig) error {
+	if config == nil || len(config.Faults) == 0 {
+		return nil
+	}
+	if !buildEnabled {
+		log.DefaultLogger.Errorf("[chaos] %d faults are ignored, mosn is not built with the chaos tag", len(config.Faults))
+		return nil
+	}
+	log.DefaultLogger.Warnf("[chaos] %d faults are installed", len(config.Faults))
+	return Install(config.Faults)
+}
@@ -0,0 +1,196 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chaos
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+func TestInstall(t *testing.T) {
+	defer Uninstall()
+	if err := Install([]v2.ChaosFault{{Type: "unknown"}}); err == nil {
+		t.Error("expected unknown fault type error")
+	}
+	if err := Install([]v2.ChaosFault{
+		{Name: "a", Type: v2.ChaosCorruptTrailer},
+		{Name: "a", Type: v2.ChaosCorruptTrailer},
+	}); err == nil {
+		t.Error("expected duplicate fault name error")
+	}
+	if Enabled() {
+		t.Error("no fault should be installed after the failures")
+	}
+	if err := Install([]v2.ChaosFault{{Type: v2.ChaosCorruptTrailer}}); err != nil {
+		t.Fatal(err)
+	}
+	if !Enabled() {
+		t.Error("expected the faults installed")
+	}
+	Uninstall()
+	if Enabled() {
+		t.Error("expected the faults uninstalled")
+	}
+}
+
+func TestFireAfterTimes(t *testing.T) {
+	defer Uninstall()
+	if err := Install([]v2.ChaosFault{{
+		Name:  "drop",
+		Type:  v2.ChaosDropUpstreamConnection,
+		Host:  "127.0.0.1:8080",
+		After: 1,
+		Times: 2,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if UpstreamConnection("127.0.0.1:8081") != nil {
+		t.Error("other host should not be matched")
+	}
+	var fired []bool
+	for i := 0; i < 5; i++ {
+		fired = append(fired, UpstreamConnection("127.0.0.1:8080") != nil)
+	}
+	expected := []bool{false, true, true, false, false}
+	for i := range expected {
+		if fired[i] != expected[i] {
+			t.Errorf("connection %d expected fired %v, but got %v", i, expected[i], fired[i])
+		}
+	}
+	if n := Fired("drop"); n != 2 {
+		t.Errorf("expected fired 2 times, but got %d", n)
+	}
+}
+
+func TestConnectionFaultRead(t *testing.T) {
+	c := &ConnectionFault{budget: 10}
+	if kept, drop := c.Read(6); kept != 6 || drop {
+		t.Errorf("unexpected read result %d %v", kept, drop)
+	}
+	if kept, drop := c.Read(6); kept != 4 || !drop {
+		t.Errorf("unexpected read result %d %v", kept, drop)
+	}
+}
+
+func TestDelayPoolAllocation(t *testing.T) {
+	defer Uninstall()
+	if err := Install([]v2.ChaosFault{{
+		Type:    v2.ChaosDelayPoolAllocation,
+		Cluster: "slow",
+		Delay:   api.DurationConfig{Duration: 50 * time.Millisecond},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	DelayPoolAllocation("fast")
+	if d := time.Since(start); d >= 50*time.Millisecond {
+		t.Errorf("other cluster should not be delayed, but took %v", d)
+	}
+	start = time.Now()
+	DelayPoolAllocation("slow")
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("expected delayed, but took %v", d)
+	}
+}
+
+func TestCorruptTrailer(t *testing.T) {
+	defer Uninstall()
+	if err := Install([]v2.ChaosFault{{
+		Type:  v2.ChaosCorruptTrailer,
+		Times: 1,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	trailers := protocol.CommonHeader{"grpc-status": "0", "grpc-message": "ok"}
+	CorruptTrailer("cluster", "127.0.0.1:8080", trailers)
+	for k, v := range trailers {
+		if v != CorruptedValue {
+			t.Errorf("trailer %s is not corrupted: %s", k, v)
+		}
+	}
+	trailers = protocol.CommonHeader{"grpc-status": "0"}
+	CorruptTrailer("cluster", "127.0.0.1:8080", trailers)
+	if trailers["grpc-status"] != "0" {
+		t.Error("trailer should be corrupted only once")
+	}
+}
+
+func TestInitWithoutTag(t *testing.T) {
+	if buildEnabled {
+		t.Skip("built with the chaos tag")
+	}
+	defer Uninstall()
+	if err := Init(&v2.ChaosConfig{
+		Faults: []v2.ChaosFault{{Type: v2.ChaosCorruptTrailer}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if Enabled() {
+		t.Error("faults should not be installed without the chaos tag")
+	}
+}
+
+func TestRunScenarios(t *testing.T) {
+	faults := []v2.ChaosFault{{
+		Name:  "trailer",
+		Type:  v2.ChaosCorruptTrailer,
+		Times: 1,
+	}}
+	corrupt := func() error {
+		CorruptTrailer("cluster", "host", protocol.CommonHeader{"k": "v"})
+		CorruptTrailer("cluster", "host", protocol.CommonHeader{"k": "v"})
+		return nil
+	}
+	runErr := errors.New("run failed")
+	results := RunScenarios(Scenario{
+		Name:   "ok",
+		Faults: faults,
+		Run:    corrupt,
+		Expect: map[string]uint32{"trailer": 1},
+	}, Scenario{
+		Name:   "mismatched",
+		Faults: faults,
+		Run:    corrupt,
+		Expect: map[string]uint32{"trailer": 2},
+	}, Scenario{
+		Name:   "failed",
+		Faults: faults,
+		Run:    func() error { return runErr },
+	})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, but got %d", len(results))
+	}
+	if results[0].Err != nil || results[0].Fired["trailer"] != 1 {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "trailer fired 1 times, expected 2") {
+		t.Errorf("unexpected result %+v", results[1])
+	}
+	if results[2].Err != runErr {
+		t.Errorf("unexpected result %+v", results[2])
+	}
+	if Enabled() {
+		t.Error("faults should be uninstalled after the scenarios")
+	}
+}
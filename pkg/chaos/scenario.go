@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chaos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// Scenario runs a function with the faults installed,
+// and expects the faults fired the times in Expect
+type Scenario struct {
+	Name   string
+	Faults []v2.ChaosFault
+	Run    func() error
+	// Expect maps the fault names to the times they fire, the faults not in Expect are not checked
+	Expect map[string]uint32
+}
+
+// Result is the result of a scenario
+type Result struct {
+	Name  string
+	Fired map[string]uint32
+	// Err is the error of the scenario run or the mismatched fired times
+	Err error
+}
+
+// scenarioMutex runs the scenarios one by one, as the faults are installed globally
+var scenarioMutex sync.Mutex
+
+// RunScenarios runs the scenarios in order and returns the results
+func RunScenarios(scenarios ...Scenario) []Result {
+	scenarioMutex.Lock()
+	defer scenarioMutex.Unlock()
+	results := make([]Result, 0, len(scenarios))
+	for _, s := range scenarios {
+		results = append(results, runScenario(s))
+	}
+	return results
+}
+
+func runScenario(s Scenario) Result {
+	result := Result{
+		Name:  s.Name,
+		Fired: make(map[string]uint32, len(s.Faults)),
+	}
+	if err := Install(s.Faults); err != nil {
+		result.Err = err
+		return result
+	}
+	defer Uninstall()
+	if s.Run != nil {
+		result.Err = s.Run()
+	}
+	for _, f := range current().listOrNil() {
+		result.Fired[f.config.Name] = Fired(f.config.Name)
+	}
+	if result.Err != nil {
+		return result
+	}
+	var mismatched []string
+	for name, times := range s.Expect {
+		if fired := result.Fired[name]; fired != times {
+			mismatched = append(mismatched, fmt.Sprintf("%s fired %d times, expected %d", name, fired, times))
+		}
+	}
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		result.Err = fmt.Errorf("scenario %s: %s", s.Name, strings.Join(mismatched, "; "))
+	}
+	return result
+}
+
+func (fs *faults) listOrNil() []*fault {
+	if fs == nil {
+		return nil
+	}
+	return fs.list
+}
@@ -0,0 +1,171 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// the algorithm names are the same as the HTTP content codings
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown compression algorithm")
+	ErrTooLarge         = errors.New("decompressed data is too large")
+)
+
+// Writer compresses the data written to it, Flush writes the pending data to the underlying writer
+// so that the written data can be decompressed without Close
+type Writer interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Codec creates the compression writers and the decompression readers of an algorithm
+type Codec interface {
+	Name() string
+	// NewWriter creates a writer with the level, zero means the default level of the algorithm
+	NewWriter(w io.Writer, level int) (Writer, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecs      = map[string]Codec{}
+	codecsMutex sync.RWMutex
+)
+
+func init() {
+	RegisterCodec(gzipCodec{})
+	RegisterCodec(zstdCodec{})
+}
+
+// RegisterCodec registers a codec, the codec registered before with the same name is replaced
+func RegisterCodec(codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	codecs[strings.ToLower(codec.Name())] = codec
+}
+
+// GetCodec returns the codec of the algorithm, the name is case insensitive
+func GetCodec(name string) (Codec, error) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	if codec, ok := codecs[strings.ToLower(name)]; ok {
+		return codec, nil
+	}
+	return nil, ErrUnknownAlgorithm
+}
+
+// Compress compresses the data in one shot
+func Compress(codec Codec, level int, data []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	w, err := codec.NewWriter(out, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Decompress decompresses the data in one shot, ErrTooLarge is returned if the
+// decompressed data exceeds the limit, a limit not greater than zero means no limit
+func Decompress(codec Codec, data []byte, limit int64) ([]byte, error) {
+	r, err := codec.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if limit <= 0 {
+		return ioutil.ReadAll(r)
+	}
+	out, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrTooLarge
+	}
+	return out, nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string {
+	return Gzip
+}
+
+func (gzipCodec) NewWriter(w io.Writer, level int) (Writer, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	return gzip.NewWriterLevel(w, level)
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// zstdCodec maps the level to the closest encoder level, the levels lower than 3 are the fastest
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string {
+	return Zstd
+}
+
+func (zstdCodec) NewWriter(w io.Writer, level int) (Writer, error) {
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if level != 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+	return zstd.NewWriter(w, opts...)
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return zstdReader{d}, nil
+}
+
+// zstdReader releases the decoder's goroutines on Close
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r zstdReader) Close() error {
+	r.Decoder.Close()
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compress
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("mosn compression "), 100)
+	for _, name := range []string{Gzip, Zstd, "ZSTD"} {
+		codec, err := GetCodec(name)
+		if err != nil {
+			t.Fatalf("get codec %s failed: %v", name, err)
+		}
+		for _, level := range []int{0, 1, 9} {
+			compressed, err := Compress(codec, level, data)
+			if err != nil {
+				t.Fatalf("%s compress failed: %v", name, err)
+			}
+			if len(compressed) >= len(data) {
+				t.Fatalf("%s is not compressed: %d bytes", name, len(compressed))
+			}
+			out, err := Decompress(codec, compressed, 0)
+			if err != nil || !bytes.Equal(out, data) {
+				t.Fatalf("%s decompress failed: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	codec, _ := GetCodec(Zstd)
+	data := bytes.Repeat([]byte("a"), 1024)
+	compressed, err := Compress(codec, 0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decompress(codec, compressed, 1023); err != ErrTooLarge {
+		t.Fatalf("expected too large, got %v", err)
+	}
+	if out, err := Decompress(codec, compressed, 1024); err != nil || len(out) != 1024 {
+		t.Fatalf("decompress with limit failed: %v", err)
+	}
+	gzip, _ := GetCodec(Gzip)
+	if _, err := Decompress(gzip, compressed, 0); err == nil {
+		t.Fatal("expected an error when the data is not gzip")
+	}
+}
+
+func TestStreamFlush(t *testing.T) {
+	for _, name := range []string{Gzip, Zstd} {
+		codec, _ := GetCodec(name)
+		out := &bytes.Buffer{}
+		w, err := codec.NewWriter(out, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("line 1\n"))
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		// the flushed data can be read before the writer is closed
+		r, err := codec.NewReader(bytes.NewReader(out.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 7)
+		if n, _ := r.Read(buf); string(buf[:n]) != "line 1\n" {
+			t.Fatalf("%s read flushed data failed: %q", name, buf[:n])
+		}
+		r.Close()
+		w.Close()
+	}
+}
+
+func TestGetCodecUnknown(t *testing.T) {
+	if _, err := GetCodec("br"); err != ErrUnknownAlgorithm {
+		t.Fatalf("expected unknown algorithm, got %v", err)
+	}
+}
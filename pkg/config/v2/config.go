@@ -23,6 +23,7 @@ import (
 	"github.com/c2h5oh/datasize"
 	xdsboot "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
 	"github.com/gogo/protobuf/jsonpb"
+	"mosn.io/api"
 )
 
 // MOSNConfig make up mosn to start the mosn project
@@ -40,12 +41,173 @@ type MOSNConfig struct {
 	RawAdmin            json.RawMessage `json:"admin,omitempty"`             // admin raw message
 	Debug               PProfConfig     `json:"pprof,omitempty"`
 	Pid                 string          `json:"pid,omitempty"` // pid file
+	// HotReload watches the config file and applies the changes at runtime
+	HotReload *HotReloadConfig `json:"hot_reload,omitempty"`
+	// ServiceRoute generates routes from the services subscribed by clusters
+	ServiceRoute *ServiceRouteConfig `json:"service_route,omitempty"`
+	// Watchdog samples the goroutines, connections and active streams to detect leaks
+	Watchdog *WatchdogConfig `json:"watchdog,omitempty"`
+	// Warmup pre-connects the hot clusters after start or config reload
+	Warmup *WarmupConfig `json:"warmup,omitempty"`
+	// Secrets are the named secrets fetched from the secret providers,
+	// the config values such as "secret://tls-key" are resolved to the secret values
+	Secrets *SecretsConfig `json:"secrets,omitempty"`
+	// DNS configures the resolver of the upstream host names, the system resolver is used if not configured
+	DNS *DNSConfig `json:"dns,omitempty"`
+	// Chaos installs the fault hooks in the proxy core, it takes effect only if mosn is built with the "chaos" tag
+	Chaos *ChaosConfig `json:"chaos,omitempty"`
+	// AdminAPI guards the admin apis that change the runtime state, such as the listener management
+	AdminAPI *AdminAPIConfig `json:"admin_api,omitempty"`
+}
+
+// The address families of the dns lookup
+const (
+	DNSLookupV4Only      = "v4_only"
+	DNSLookupV6Only      = "v6_only"
+	DNSLookupV4Preferred = "v4_preferred"
+	DNSLookupAll         = "all"
+)
+
+// DNSConfig is a configuration of the internal dns resolver.
+// The resolved addresses are cached by the ttl of the records, which is at least MinTTL,
+// and the failed lookups are cached for NegativeTTL so a missing name is not queried for each request.
+type DNSConfig struct {
+	// Nameservers are the "ip:port" of the dns servers, the system resolver is used if it is empty,
+	// and the addresses resolved by the system resolver are cached for MinTTL
+	Nameservers []string `json:"nameservers,omitempty"`
+	// LookupFamily is "v4_only", "v6_only", "v4_preferred" or "all", default is "v4_preferred",
+	// which looks up the ipv6 addresses only if no ipv4 address is found
+	LookupFamily string `json:"lookup_family,omitempty"`
+	// MinTTL is the min time the addresses are cached, default is 5s
+	MinTTL api.DurationConfig `json:"min_ttl,omitempty"`
+	// NegativeTTL is the time the failed lookups are cached, default is 5s
+	NegativeTTL api.DurationConfig `json:"negative_ttl,omitempty"`
+	// Timeout is the timeout of a query to a nameserver, default is 5s
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+}
+
+// The fault types of the chaos hooks
+const (
+	ChaosDropUpstreamConnection = "drop_upstream_connection"
+	ChaosDelayPoolAllocation    = "delay_pool_allocation"
+	ChaosCorruptTrailer         = "corrupt_trailer"
+)
+
+// ChaosConfig is a configuration of the deterministic faults injected in the proxy core,
+// which is used to test the retry, timeout and reset handling.
+type ChaosConfig struct {
+	Faults []ChaosFault `json:"faults,omitempty"`
+}
+
+// ChaosFault is a fault fired on the matched points. The matched points are counted,
+// the fault skips the first After points and fires at most Times, so the fired points are deterministic.
+type ChaosFault struct {
+	// Name identifies the fault in the fired counts
+	Name string `json:"name,omitempty"`
+	// Type is "drop_upstream_connection", "delay_pool_allocation" or "corrupt_trailer"
+	Type string `json:"type,omitempty"`
+	// Cluster matches the upstream cluster, empty matches all clusters.
+	// The upstream connections are matched by Host only
+	Cluster string `json:"cluster,omitempty"`
+	// Host matches the upstream host address, empty matches all hosts.
+	// The pool allocations are matched by Cluster only
+	Host string `json:"host,omitempty"`
+	// Bytes is the bytes read from the upstream connection before it is dropped
+	Bytes int64 `json:"bytes,omitempty"`
+	// Delay is the delay of the pool allocation
+	Delay api.DurationConfig `json:"delay,omitempty"`
+	// After is the matched points skipped before the fault fires
+	After uint32 `json:"after,omitempty"`
+	// Times is the max times the fault fires, zero means unlimited
+	Times uint32 `json:"times,omitempty"`
+}
+
+// ServiceRouteConfig is a configuration of the routes generated from the subscribed services.
+// Each service subscribed by a cluster (see ClusterSpecInfo) generates a route that matches the "service" header
+// and routes to the cluster. The routes configured in the router config take precedence over the generated ones.
+type ServiceRouteConfig struct {
+	// RouterConfigName is the router config that the generated routes are added into
+	RouterConfigName string `json:"router_config_name,omitempty"`
+	// Domain is the virtual host domain that the generated routes are added into, default is "*"
+	Domain string `json:"domain,omitempty"`
+}
+
+// HotReloadConfig is a configuration of the config file hot reload
+type HotReloadConfig struct {
+	// CheckInterval is the interval to check the config file changes, default is 5s
+	CheckInterval api.DurationConfig `json:"check_interval,omitempty"`
+}
+
+// WarmupConfig is a configuration of the connection warm-up. The upstream usage of the clusters is recorded,
+// the hosts of the hottest clusters are resolved and connected in advance after start or config reload.
+type WarmupConfig struct {
+	// TopClusters is the number of the hottest clusters that are warmed up, default is 10
+	TopClusters int `json:"top_clusters,omitempty"`
+	// UsageFile persists the usage so that the warm-up works after restart,
+	// default is warmup_usage.json in the mosn base path
+	UsageFile string `json:"usage_file,omitempty"`
+	// SaveInterval is the interval to save the usage file, default is 1m
+	SaveInterval api.DurationConfig `json:"save_interval,omitempty"`
+}
+
+// SecretsConfig is a configuration of the secret providers and the named secrets
+type SecretsConfig struct {
+	Providers []SecretProviderConfig `json:"providers,omitempty"`
+	Secrets   []SecretConfig         `json:"secrets,omitempty"`
+	// RefreshInterval is the interval to fetch the secrets again, the rotation callbacks are
+	// called if the values are changed, default is 1m
+	RefreshInterval api.DurationConfig `json:"refresh_interval,omitempty"`
+}
+
+// SecretProviderConfig describes a secret provider, the builtin types are "env", "file", "vault" and "kms"
+type SecretProviderConfig struct {
+	Name   string                 `json:"name"`
+	Type   string                 `json:"type"`
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// SecretConfig is a named secret, the key is interpreted by the provider, such as the environment
+// variable name, the file path, "path#field" in vault, or the base64 encoded ciphertext blob in kms
+type SecretConfig struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	Key      string `json:"key"`
+}
+
+// WatchdogConfig is a configuration of the goroutine and connection leak watchdog.
+// A leak is suspected when a sample exceeds the ceiling, or grows beyond the ratio of its historical baseline.
+type WatchdogConfig struct {
+	// Interval is the sample interval, default is 30s
+	Interval api.DurationConfig `json:"interval,omitempty"`
+	// MaxGoroutines, MaxConnections and MaxActiveStreams are the ceilings, 0 means no ceiling
+	MaxGoroutines    int64 `json:"max_goroutines,omitempty"`
+	MaxConnections   int64 `json:"max_connections,omitempty"`
+	MaxActiveStreams int64 `json:"max_active_streams,omitempty"`
+	// BaselineSamples is the number of the samples that the baseline is averaged over, default is 20
+	BaselineSamples int `json:"baseline_samples,omitempty"`
+	// GrowthRatio is the ratio of a sample to the baseline that a leak is suspected, default is 2
+	GrowthRatio float64 `json:"growth_ratio,omitempty"`
+	// HeapDumpDir is the directory that a heap profile is written to when a leak is suspected,
+	// no heap profile is written if it is empty
+	HeapDumpDir string `json:"heap_dump_dir,omitempty"`
+	// HeapDumpInterval is the min interval between two heap dumps, default is 10m
+	HeapDumpInterval api.DurationConfig `json:"heap_dump_interval,omitempty"`
 }
 
 // PProfConfig is used to start a pprof server for debug
 type PProfConfig struct {
 	StartDebug bool `json:"debug"`      // If StartDebug is true, start a pprof, default is false
 	Port       int  `json:"port_value"` // If port value is 0, will use 9090 as default
+	// ProfileDir is the directory that the profiles captured by the admin api are written to
+	ProfileDir string `json:"profile_dir,omitempty"`
+	// AuthToken guards the pprof apis of the admin server, the apis are disabled if it is empty
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// AdminAPIConfig is the config of the admin apis that change the runtime state
+type AdminAPIConfig struct {
+	// AuthToken guards the apis, the apis are disabled if it is empty
+	AuthToken string `json:"auth_token,omitempty"`
 }
 
 // Tracing configuration for a server
@@ -62,6 +224,42 @@ type MetricsConfig struct {
 	StatsMatcher StatsMatcher      `json:"stats_matcher"`
 	ShmZone      string            `json:"shm_zone"`
 	ShmSize      datasize.ByteSize `json:"shm_size"`
+	// HistogramBuckets overwrites the histogram buckets by metrics key
+	HistogramBuckets map[string][]float64 `json:"histogram_buckets,omitempty"`
+	// DetailedStats enables the per-route and per-upstream-host detailed stats
+	DetailedStats *DetailedStatsConfig `json:"detailed_stats,omitempty"`
+	// StatsTags extracts tags from the flat stat names
+	StatsTags []StatsTag `json:"stats_tags,omitempty"`
+	// UseAllDefaultTags enables the default tag extraction rules, default is false.
+	// The default rules change the metrics names of the sinks, so they are opt-in.
+	UseAllDefaultTags bool `json:"use_all_default_tags,omitempty"`
+	// MemoryStats enables the memory accounting of the buffers, headers, stream objects and codec scratch buffers
+	MemoryStats *MemoryStatsConfig `json:"memory_stats,omitempty"`
+}
+
+// MemoryStatsConfig is a configuration of the memory accounting,
+// the accounted bytes of each module are reported as the gauges of the memory stats.
+type MemoryStatsConfig struct {
+	// FlushInterval is the interval that the memory stats are updated, default is 10s
+	FlushInterval api.DurationConfig `json:"flush_interval,omitempty"`
+}
+
+// StatsTag is a configuration for the tag extraction.
+// If the FixedValue is set, the tag is added to all metrics,
+// otherwise the tag value is extracted from the stat name by the Regex.
+type StatsTag struct {
+	TagName    string `json:"tag_name"`
+	Regex      string `json:"regex,omitempty"`
+	FixedValue string `json:"fixed_value,omitempty"`
+}
+
+// DetailedStatsConfig is a configuration for the per-route and per-upstream-host stats.
+// The detailed stats can be toggled at runtime by admin api.
+type DetailedStatsConfig struct {
+	Route bool `json:"route,omitempty"`
+	Host  bool `json:"host,omitempty"`
+	// MaxCardinality limits the number of detailed stats scopes, the stats beyond the limit are dropped
+	MaxCardinality int `json:"max_cardinality,omitempty"`
 }
 
 // StatsMatcher is a configuration for disabling stat instantiation.
@@ -109,3 +307,8 @@ func (c *MOSNConfig) GetAdmin() *xdsboot.Admin {
 	}
 	return nil
 }
+
+// GetPProf returns the pprof config, which is used by the admin server's profiling apis
+func (c *MOSNConfig) GetPProf() *PProfConfig {
+	return &c.Debug
+}
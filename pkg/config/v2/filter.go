@@ -50,13 +50,40 @@ const (
 	FAULT_INJECT_NETWORK_FILTER = "fault_inject"
 	RPC_PROXY                   = "rpc_proxy"
 	X_PROXY                     = "x_proxy"
+	REDIS_PROXY                 = "redis_proxy"
+	KAFKA_PROXY                 = "kafka_proxy"
+	CONNECTION_RATE_LIMIT       = "connection_rate_limit"
 )
 
 // Stream Filter's Type
 const (
-	MIXER        = "mixer"
-	FaultStream  = "fault"
-	PayloadLimit = "payload_limit"
+	MIXER           = "mixer"
+	FaultStream     = "fault"
+	PayloadLimit    = "payload_limit"
+	RequestSign     = "request_sign"
+	OAuth2          = "oauth2"
+	APIKey          = "api_key"
+	BasicAuth       = "basic_auth"
+	WAF             = "waf"
+	DataMask        = "data_mask"
+	StatefulSession = "stateful_session"
+	Coalesce        = "coalesce"
+	BandwidthLimit  = "bandwidth_limit"
+	Transformation  = "transformation"
+	Compression     = "compression"
+	GRPCBridge      = "grpc_reverse_bridge"
+	// DynamicForwardProxy should be used with the DYNAMIC_FORWARD_PROXY cluster
+	DynamicForwardProxy = "dynamic_forward_proxy"
+	Priority            = "priority"
+)
+
+// Stream Filter's Phase, the stream filters run by the phases in order
+const (
+	StreamFilterPhaseAuthn         = "authn"
+	StreamFilterPhaseAuthz         = "authz"
+	StreamFilterPhaseRateLimit     = "rate_limit"
+	StreamFilterPhaseTransform     = "transform"
+	StreamFilterPhaseObservability = "observability"
 )
 
 // HealthCheckFilter
@@ -84,12 +111,367 @@ type FaultInject struct {
 	DelayDuration uint64 `json:"-"`
 }
 
+// ConnectionRateLimit limits the new connections per source ip by a token bucket, the connections
+// exceeding the limit are closed before any data is read, so no tls handshake is made for them.
+type ConnectionRateLimit struct {
+	// ConnectionsPerSecond is the rate of the new connections from a source ip
+	ConnectionsPerSecond float64 `json:"connections_per_second,omitempty"`
+	// Burst is the max new connections from a source ip at a moment, default is the rate
+	Burst int `json:"burst,omitempty"`
+	// MaxSources is the max source ips tracked, the least recently seen sources are evicted, default is 10000
+	MaxSources int `json:"max_sources,omitempty"`
+}
+
 // PayloadLimitInject
 type StreamPayloadLimit struct {
 	MaxEntitySize int32 `json:"max_entity_size "`
 	HttpStatus    int32 `json:"http_status"`
 }
 
+// StreamRequestSign is the config of the stream filter that signs the upstream requests
+type StreamRequestSign struct {
+	// Algorithm is the sign algorithm, "sigv4" or "hmac"
+	Algorithm string `json:"algorithm,omitempty"`
+	// Service and Region are used in the sigv4 credential scope
+	Service string `json:"service,omitempty"`
+	Region  string `json:"region,omitempty"`
+	// SignedHeaders are the request headers signed besides the required ones
+	SignedHeaders []string `json:"signed_headers,omitempty"`
+	// Hash is the hmac hash function, "sha256" or "sha1", default is "sha256"
+	Hash string `json:"hash,omitempty"`
+	// SignatureHeader is the request header that the hmac signature is set to, default is "X-Signature"
+	SignatureHeader string          `json:"signature_header,omitempty"`
+	Credentials     SignCredentials `json:"credentials,omitempty"`
+}
+
+// SignCredentials describes where the credentials used to sign requests come from
+type SignCredentials struct {
+	// Source is "env", "file", "sts" or "secret", default is "env"
+	Source string `json:"source,omitempty"`
+	// AccessKeyEnv, SecretKeyEnv and SessionTokenEnv are the environment variable names used in "env" source,
+	// default are AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	AccessKeyEnv    string `json:"access_key_env,omitempty"`
+	SecretKeyEnv    string `json:"secret_key_env,omitempty"`
+	SessionTokenEnv string `json:"session_token_env,omitempty"`
+	// Path is a json file contains access_key_id, secret_access_key and session_token, used in "file" source.
+	// The file is reloaded when it is modified.
+	Path string `json:"path,omitempty"`
+	// STSEndpoint, RoleArn, WebIdentityTokenFile and SessionName are used in "sts" source,
+	// the credentials are assumed by AssumeRoleWithWebIdentity and refreshed before expired.
+	// RoleArn and WebIdentityTokenFile default are AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE in environment
+	STSEndpoint          string `json:"sts_endpoint,omitempty"`
+	RoleArn              string `json:"role_arn,omitempty"`
+	WebIdentityTokenFile string `json:"web_identity_token_file,omitempty"`
+	SessionName          string `json:"session_name,omitempty"`
+	// SecretName is the named secret used in "secret" source, the value is a json
+	// like the file source. The credentials are updated when the secret is rotated.
+	SecretName string `json:"secret_name,omitempty"`
+}
+
+// StreamOAuth2 is the config of the stream filter that authenticates the requests
+// with the OAuth2 / OIDC authorization code flow
+type StreamOAuth2 struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint         string `json:"token_endpoint,omitempty"`
+	ClientID              string `json:"client_id,omitempty"`
+	// ClientSecret and CookieSecret can reference the named secrets, such as "secret://oauth2-client"
+	ClientSecret string `json:"client_secret,omitempty"`
+	// RedirectURI is the callback url registered in the identity provider,
+	// the request matches the path of it is handled as the callback.
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	// Scopes default is ["openid"]
+	Scopes []string `json:"scopes,omitempty"`
+	// CookieName is the name of session cookie, default is "mosn_oauth2"
+	CookieName string `json:"cookie_name,omitempty"`
+	// CookieSecret is a base64 encoded 16, 24 or 32 bytes key, used to encrypt the session cookie
+	CookieSecret string `json:"cookie_secret,omitempty"`
+	// SignoutPath clears the session cookie
+	SignoutPath string `json:"signout_path,omitempty"`
+	// ClaimHeaders maps the id token claims to the request headers sent to upstream
+	ClaimHeaders map[string]string `json:"claim_headers,omitempty"`
+	// ForwardAccessToken sets the access token in the Authorization header sent to upstream
+	ForwardAccessToken bool `json:"forward_access_token,omitempty"`
+	// PassThroughPaths are the path prefixes that do not require authentication
+	PassThroughPaths []string `json:"pass_through_paths,omitempty"`
+}
+
+// StreamAPIKey is the config of the stream filter that authenticates the requests by api keys
+type StreamAPIKey struct {
+	// Header is the request header contains the api key, default is "x-api-key"
+	Header string `json:"header,omitempty"`
+	// QueryParam is the query parameter contains the api key, used if the header is not found
+	QueryParam string `json:"query_param,omitempty"`
+	// MetadataPrefix is the prefix of request headers that the metadata of the key is set to,
+	// default is "x-mosn-apikey-"
+	MetadataPrefix string      `json:"metadata_prefix,omitempty"`
+	KeyStore       APIKeyStore `json:"key_store,omitempty"`
+}
+
+// APIKeyStore describes where the api keys are loaded from, the keys are reloaded periodically
+type APIKeyStore struct {
+	// Path is a local json file of the keys
+	Path string `json:"path,omitempty"`
+	// URL is a remote http address of the keys, used if the path is empty
+	URL string `json:"url,omitempty"`
+	// RefreshInterval default is 30s
+	RefreshInterval api.DurationConfig `json:"refresh_interval,omitempty"`
+}
+
+// StreamBasicAuth is the config of the stream filter that enforces the HTTP basic authentication
+type StreamBasicAuth struct {
+	// Realm is the realm in the WWW-Authenticate response header, default is "mosn"
+	Realm string `json:"realm,omitempty"`
+	// HtpasswdFile is a htpasswd-style credential file, bcrypt and SHA passwords are supported.
+	// The file is reloaded when it is modified.
+	HtpasswdFile string `json:"htpasswd_file,omitempty"`
+	// UserHeader is the request header that the authenticated user name is set to, optional
+	UserHeader string `json:"user_header,omitempty"`
+	// Cache caches the password verification results, optional.
+	// The results are cached with the default cache config if it is not set and the file has bcrypt passwords,
+	// as the bcrypt verification is slow by design.
+	Cache *AuthCache `json:"cache,omitempty"`
+}
+
+// AuthCache caches the authentication decisions of the auth filters, keyed by the hash of the credentials,
+// so the repeated callers are not verified for each request. The filters with the same cache name share
+// the cache, the config of the cache is decided by the first filter created.
+type AuthCache struct {
+	// Name is the name of the shared cache, default is the filter type
+	Name string `json:"name,omitempty"`
+	// PositiveTTL is how long the allowed decisions are cached, default is 5m
+	PositiveTTL api.DurationConfig `json:"positive_ttl,omitempty"`
+	// NegativeTTL is how long the denied decisions are cached, default is 30s
+	NegativeTTL api.DurationConfig `json:"negative_ttl,omitempty"`
+	// MaxEntries limits the decisions cached, default is 10000
+	MaxEntries int `json:"max_entries,omitempty"`
+}
+
+// StreamWAF is the config of the stream filter that inspects the request and response payload
+// to block the common injection patterns
+type StreamWAF struct {
+	// Mode is "block" or "log", default is "block".
+	// In "log" mode the matched requests are logged only.
+	Mode string `json:"mode,omitempty"`
+	// Status is the response status code of the blocked requests, default is 403
+	Status int `json:"status,omitempty"`
+	// MaxBodySize is the max bytes of the body that is inspected, default is 8192
+	MaxBodySize int            `json:"max_body_size,omitempty"`
+	Rules       []WAFRule      `json:"rules,omitempty"`
+	Exclusions  []WAFExclusion `json:"exclusions,omitempty"`
+}
+
+// WAFRule matches the target of the request or response
+type WAFRule struct {
+	ID string `json:"id,omitempty"`
+	// Direction is "request" or "response", default is "request"
+	Direction string `json:"direction,omitempty"`
+	// Target is one of "method", "path", "query", "header", "headers" and "body".
+	// "headers" means all of the header values.
+	Target string `json:"target,omitempty"`
+	// Header is the header name when the target is "header"
+	Header string `json:"header,omitempty"`
+	// Pattern is a regular expression, the rule matches if the target matches the pattern
+	Pattern string `json:"pattern,omitempty"`
+	// MaxSize makes the rule match if the size of the target exceeds it
+	MaxSize int `json:"max_size,omitempty"`
+	// ContentTypes makes the rule match if the content type is not one of them
+	ContentTypes []string `json:"content_types,omitempty"`
+	// Mode overrides the filter mode for the rule, optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// WAFExclusion skips the rules for the requests with the path prefix
+type WAFExclusion struct {
+	PathPrefix string `json:"path_prefix,omitempty"`
+	// Rules are the skipped rule ids, empty means all of the rules are skipped
+	Rules []string `json:"rules,omitempty"`
+}
+
+// StreamDataMask is the config of the stream filter that masks the sensitive data
+// before they reach the access logs and the debug logs
+type StreamDataMask struct {
+	// Headers are the sensitive header names, default are authorization, proxy-authorization, cookie and set-cookie
+	Headers []string `json:"headers,omitempty"`
+	// JSONFields are the sensitive field names in the JSON bodies, such as card_number and phone
+	JSONFields []string `json:"json_fields,omitempty"`
+	// MaskChar is the character that replaces the sensitive data, default is "*"
+	MaskChar string `json:"mask_char,omitempty"`
+	// KeepLast is the count of the last characters of the JSON fields that are kept, such as the last 4 digits of the card number
+	KeepLast int `json:"keep_last,omitempty"`
+}
+
+// StreamStatefulSession is the config of the stream filter that makes the requests with the
+// session cookie sent to the same upstream host while the host is healthy
+type StreamStatefulSession struct {
+	// CookieName is the name of the cookie that encodes the upstream host, default is "mosn-session"
+	CookieName string `json:"cookie_name,omitempty"`
+	// Path is the path of the cookie, default is "/"
+	Path string `json:"path,omitempty"`
+	// TTL is the max age of the cookie, the cookie is a session cookie if it is not set
+	TTL api.DurationConfig `json:"ttl,omitempty"`
+}
+
+// StreamCoalesce is the config of the stream filter that collapses the concurrent identical requests
+// into a single upstream request, the response is fanned out to all the waiting requests
+type StreamCoalesce struct {
+	// Methods are the idempotent methods that can be coalesced, default is GET and HEAD
+	Methods []string `json:"methods,omitempty"`
+	// VaryHeaders are the request headers that make up the coalesce key besides method and path.
+	// Authorization and Cookie are always part of the key, the personalised responses are not shared.
+	VaryHeaders []string `json:"vary_headers,omitempty"`
+	// Timeout is the max time that a request waits for the coalesced response, default is 10s.
+	// the request is sent to upstream itself after timeout.
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+	// MaxWaiters is the max requests that wait for a coalesced response, default is 1000.
+	// more requests are sent to upstream directly.
+	MaxWaiters int `json:"max_waiters,omitempty"`
+}
+
+// StreamBandwidthLimit is the config of the stream filter that throttles the request and response bodies
+// by token buckets, a nil direction config means no limit in the direction.
+type StreamBandwidthLimit struct {
+	Request  *BandwidthLimitConfig `json:"request,omitempty"`
+	Response *BandwidthLimitConfig `json:"response,omitempty"`
+	// PerConnection makes the streams on the same downstream connection share the token buckets,
+	// the route level config always limits the bandwidth per stream.
+	PerConnection bool `json:"per_connection,omitempty"`
+}
+
+// BandwidthLimitConfig is a token bucket config
+type BandwidthLimitConfig struct {
+	// FillRate is the bytes added to the bucket per second
+	FillRate int64 `json:"fill_rate"`
+	// Burst is the bucket size in bytes, default is the fill rate
+	Burst int64 `json:"burst,omitempty"`
+}
+
+// StreamTransformation is the config of the stream filter that rebuilds the headers and the bodies
+// by templates, a nil direction config means the direction is not transformed.
+type StreamTransformation struct {
+	Request  *TransformationConfig `json:"request,omitempty"`
+	Response *TransformationConfig `json:"response,omitempty"`
+}
+
+// TransformationConfig describes the transformation of one direction.
+// The templates are Go templates, the data of the templates contains .headers, .body (the parsed JSON body)
+// and .raw (the original body), the functions json and jsonpath are available, such as
+// {"code":0,"data":{{json .body}}} and {{jsonpath .body "$.data.id"}}
+type TransformationConfig struct {
+	// Headers are the headers set by the templates, the header is removed if the result is empty
+	Headers map[string]string `json:"headers,omitempty"`
+	// Extractors are the headers set by the JSON paths of the body, such as "$.data.items[0].id"
+	Extractors map[string]string `json:"extractors,omitempty"`
+	// Body is the template that rebuilds the body, the body is not changed if it is empty
+	Body string `json:"body,omitempty"`
+}
+
+// StreamCompression is the config of the stream filter that compresses the response bodies
+// by the content codings accepted by the downstream, and decompresses the request bodies.
+type StreamCompression struct {
+	// Algorithms are the content codings used to compress the responses, in the preferred order.
+	// gzip and zstd are supported, the default is gzip.
+	Algorithms []string `json:"algorithms,omitempty"`
+	// Level is the compression level of the algorithms, zero means the default level
+	Level int `json:"level,omitempty"`
+	// MinContentLength is the minimum size of the response body to compress, the default is 30 bytes
+	MinContentLength int `json:"min_content_length,omitempty"`
+	// ContentTypes are the prefixes of the response content types to compress, all types are compressed if it is empty
+	ContentTypes []string `json:"content_types,omitempty"`
+	// DisableResponseCompression disables the response compression, so the filter only decompresses the requests
+	DisableResponseCompression bool `json:"disable_response_compression,omitempty"`
+	// DecompressRequest decompresses the request bodies encoded by the algorithms before they are sent to upstream
+	DecompressRequest bool `json:"decompress_request,omitempty"`
+	// MaxDecompressedSize is the limit of the decompressed request body, the default is 10MB
+	MaxDecompressedSize int64 `json:"max_decompressed_size,omitempty"`
+}
+
+// StreamGRPCBridge is the config of the stream filter that bridges the gRPC clients to the HTTP/1 upstreams.
+// The gRPC frame of the request message is stripped, and the response body is framed as a gRPC message
+// with the grpc-status trailer mapped from the response status code. The requests are sent to the upstream
+// with the protocol of the proxy, which should be Http1.
+type StreamGRPCBridge struct {
+	// ContentType is the content type of the requests sent to the upstream, default is "application/x-protobuf"
+	ContentType string `json:"content_type,omitempty"`
+	// ContentTypeMappings maps the gRPC content types to the upstream content types, such as
+	// "application/grpc+json": "application/json", the content types not mapped use the ContentType
+	ContentTypeMappings map[string]string `json:"content_type_mappings,omitempty"`
+}
+
+// StreamDynamicForwardProxy is the config of the stream filter that resolves the request host,
+// the requests are routed to the host by the DYNAMIC_FORWARD_PROXY cluster
+type StreamDynamicForwardProxy struct {
+	// DefaultPort is used if the request host has no port, default is 80
+	DefaultPort uint32 `json:"default_port,omitempty"`
+	// AllowedHosts limits the request hosts, the "*." prefix matches the sub domains. Any host is allowed if it is empty
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+	// EgressPolicy is checked before the request host is resolved, optional
+	EgressPolicy *EgressPolicy `json:"egress_policy,omitempty"`
+}
+
+// Egress policy actions
+const (
+	EgressActionAllow = "allow"
+	EgressActionDeny  = "deny"
+)
+
+// EgressPolicy limits the external destinations of the egress traffic
+type EgressPolicy struct {
+	// DefaultAction is used if no rule matches, "allow" or "deny", default is "deny"
+	DefaultAction string `json:"default_action,omitempty"`
+	// Rules are matched in order, the first matched rule decides the action
+	Rules []EgressRule `json:"rules,omitempty"`
+	// Status is the response status code of the denied requests, default is 403
+	Status int `json:"status,omitempty"`
+	// AuditLog is the output of the audit log of the denied requests, the denied requests
+	// are logged in the proxy log if it is empty
+	AuditLog string `json:"audit_log,omitempty"`
+}
+
+// EgressRule matches the destination by the host, port and protocol, the empty conditions match any
+type EgressRule struct {
+	Name string `json:"name,omitempty"`
+	// Action is "allow" or "deny"
+	Action string `json:"action,omitempty"`
+	// Hosts are the domain patterns, the "*." prefix matches the sub domains, and "*" matches any host
+	Hosts []string `json:"hosts,omitempty"`
+	Ports []uint32 `json:"ports,omitempty"`
+	// Protocols are the downstream protocols, such as "Http1" and "Http2"
+	Protocols []string `json:"protocols,omitempty"`
+}
+
+// Request priorities, from the highest to the lowest
+const (
+	PriorityCritical = "critical"
+	PriorityHigh     = "high"
+	PriorityNormal   = "normal"
+	PriorityLow      = "low"
+)
+
+// StreamPriority is the config of the stream filter that classifies the requests into priorities,
+// and sheds the low priority requests first when the upstream cluster or the local resources are saturated
+type StreamPriority struct {
+	// Header is the request header that carries the priority, default is "x-mosn-priority"
+	Header string `json:"header,omitempty"`
+	// Priority is used if the request has no valid priority header, default is "normal".
+	// The route can override it by the per filter config, such as {"priority": {"priority": "high"}}
+	Priority string `json:"priority,omitempty"`
+	// Shedding is the overload policy, no request is shed if it is nil
+	Shedding *PriorityShedding `json:"shedding,omitempty"`
+}
+
+// PriorityShedding sheds the requests by the saturation, which is the max usage of the route cluster's
+// max requests and the local max active streams. A request is shed if the saturation reaches the threshold
+// of its priority
+type PriorityShedding struct {
+	// Thresholds maps the priorities to the saturation thresholds in (0, 1], default is {"low": 0.8, "normal": 0.95}.
+	// The priorities without a threshold are never shed
+	Thresholds map[string]float64 `json:"thresholds,omitempty"`
+	// MaxActiveStreams is the local limit of the active streams of all the listeners, zero means no local limit
+	MaxActiveStreams int64 `json:"max_active_streams,omitempty"`
+	// Status is the response status code of the shed requests, default is 503
+	Status int `json:"status,omitempty"`
+}
+
 func (f FaultInject) Marshal() (b []byte, err error) {
 	f.FaultInjectConfig.DelayDurationConfig.Duration = time.Duration(f.DelayDuration)
 	return json.Marshal(f.FaultInjectConfig)
@@ -28,6 +28,36 @@ type TCPProxy struct {
 	Routes             []*TCPRoute    `json:"routes,omitempty"`
 }
 
+// RedisProxy proxies the redis commands to the shards of a redis cluster.
+// The hosts of the cluster describe the hash slots they serve by the metadata "redis_slots", such as "0-5460,5462",
+// and the replicas are marked by the metadata "redis_role" with value "replica", the hosts with the same slots
+// make up a shard. A host without the slots metadata serves all the slots.
+type RedisProxy struct {
+	StatPrefix string `json:"stat_prefix,omitempty"`
+	Cluster    string `json:"cluster,omitempty"`
+	// ReadPolicy decides where the read commands are sent: "master" (default), "prefer_replica" or "replica"
+	ReadPolicy string `json:"read_policy,omitempty"`
+}
+
+// KafkaProxy proxies the kafka clients to the brokers of a cluster.
+// The broker addresses in the metadata and the find coordinator responses are rewritten by AdvertisedListeners,
+// which maps the broker "host:port" to the address that the clients should connect, such as a mosn listener
+// proxying the broker. If the TopicACLs are configured, the produce and fetch requests are allowed only if all
+// of the topics are allowed by the first matched acl, the connection is closed if a request is denied.
+type KafkaProxy struct {
+	StatPrefix          string            `json:"stat_prefix,omitempty"`
+	Cluster             string            `json:"cluster,omitempty"`
+	AdvertisedListeners map[string]string `json:"advertised_listeners,omitempty"`
+	TopicACLs           []KafkaTopicACL   `json:"topic_acls,omitempty"`
+}
+
+// KafkaTopicACL allows the operations on the topic, the topic ends with "*" matches the topics by prefix
+type KafkaTopicACL struct {
+	Topic   string `json:"topic,omitempty"`
+	Produce bool   `json:"produce,omitempty"`
+	Fetch   bool   `json:"fetch,omitempty"`
+}
+
 // WebSocketProxy
 type WebSocketProxy struct {
 	StatPrefix         string
@@ -43,6 +73,129 @@ type Proxy struct {
 	RouterConfigName   string                 `json:"router_config_name,omitempty"`
 	ValidateClusters   bool                   `json:"validate_clusters,omitempty"`
 	ExtendConfig       map[string]interface{} `json:"extend_config,omitempty"`
+	// ConnectionBinding pins all the streams of a downstream connection to the same upstream connection, optional
+	ConnectionBinding *ConnectionBinding `json:"connection_binding,omitempty"`
+	// RequestBlocklist rejects the matched requests before route lookup, optional
+	RequestBlocklist *RequestBlocklist `json:"request_blocklist,omitempty"`
+	// DebugHeaders adds the upstream information headers to the responses, optional
+	DebugHeaders *DebugHeaders `json:"debug_headers,omitempty"`
+	// HTTP2Push pushes the related resources to the http2 downstream clients, optional
+	HTTP2Push *HTTP2Push `json:"http2_push,omitempty"`
+	// ForwardClientCert populates the x-forwarded-client-cert header of the requests, optional
+	ForwardClientCert *ForwardClientCert `json:"forward_client_cert,omitempty"`
+	// IncludeAttemptCount adds the x-mosn-attempt-count header to the upstream requests, optional
+	IncludeAttemptCount bool `json:"include_attempt_count,omitempty"`
+	// LoopDetection rejects the requests that have passed too many proxies, optional
+	LoopDetection *LoopDetection `json:"loop_detection,omitempty"`
+	// Baggage propagates the selected downstream request headers to the upstream requests, optional
+	Baggage *Baggage `json:"baggage,omitempty"`
+}
+
+// ConnectionBinding is the session affinity config for the stateful protocols
+type ConnectionBinding struct {
+	// RebindOnFailure makes the downstream connection bound to a new upstream connection when the
+	// bound one is broken, otherwise the downstream connection is closed
+	RebindOnFailure bool `json:"rebind_on_failure,omitempty"`
+}
+
+// RequestBlocklist rejects the requests matched any of the rules at decode time,
+// before the route lookup and the stream filters creation.
+type RequestBlocklist struct {
+	// StatusCode is the response code of the rejected requests, default is 403
+	StatusCode int             `json:"status_code,omitempty"`
+	Rules      []BlocklistRule `json:"rules,omitempty"`
+}
+
+// BlocklistRule matches a request if all the conditions configured are matched.
+// The header conditions require the header exists.
+type BlocklistRule struct {
+	PathPrefix string `json:"path_prefix,omitempty"`
+	PathRegex  string `json:"path_regex,omitempty"`
+	Header     string `json:"header,omitempty"`
+	ValueRegex string `json:"value_regex,omitempty"`
+	// MaxValueSize matches the header value longer than it, such as an oversized cookie
+	MaxValueSize int `json:"max_value_size,omitempty"`
+}
+
+// DebugHeaders adds the upstream service time, the upstream host, the retry count and the route name
+// headers to the responses for the client side debugging.
+// The headers are added to all the responses if Always is true, otherwise the headers are added only
+// if the request contains the trigger header, and the header value equals the token if it is configured.
+type DebugHeaders struct {
+	Always        bool   `json:"always,omitempty"`
+	TriggerHeader string `json:"trigger_header,omitempty"`
+	TriggerToken  string `json:"trigger_token,omitempty"`
+}
+
+// HTTP2Push configures the http2 server push of the downstream connections.
+// The resources configured in the rules matched by the request path are pushed, and the resources in the
+// Link headers with rel=preload of the responses are pushed if LinkPreload is true, the Link headers can
+// be set by the upstream or the stream filters.
+// The resources are the request uris or the urls of the same authority with the request.
+type HTTP2Push struct {
+	LinkPreload bool       `json:"link_preload,omitempty"`
+	Rules       []PushRule `json:"rules,omitempty"`
+	// MaxPushesPerConnection is the max number of the resources pushed on a connection, default is 100
+	MaxPushesPerConnection uint32 `json:"max_pushes_per_connection,omitempty"`
+}
+
+// PushRule pushes the resources for the requests matched the path prefix
+type PushRule struct {
+	PathPrefix string   `json:"path_prefix,omitempty"`
+	Resources  []string `json:"resources,omitempty"`
+}
+
+// Baggage propagates the selected headers of the downstream requests to the upstream requests.
+// The headers are kept even if they are dropped by the protocol conversion, and they are sent with every retry.
+type Baggage struct {
+	// Headers are the names of the headers propagated, case insensitive
+	Headers []string `json:"headers,omitempty"`
+	// Prefixes propagates the headers with any of the prefixes, case insensitive, such as x-biz-
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// LoopDetection detects the proxy loops caused by the misconfigured routes.
+// The hop header is incremented in each request proxied, and the request is rejected if the hops
+// reach MaxHops, so a request looping between the proxies is stopped.
+type LoopDetection struct {
+	// HopHeader is the header counts the hops, default is x-mosn-hops
+	HopHeader string `json:"hop_header,omitempty"`
+	// MaxHops is the max hops of a request, default is 10
+	MaxHops uint32 `json:"max_hops,omitempty"`
+	// StatusCode is the response code of the rejected requests, default is 508
+	StatusCode int `json:"status_code,omitempty"`
+}
+
+// The modes of handling the x-forwarded-client-cert header in the requests
+const (
+	// ForwardClientCertSanitize removes the header, it is the default mode
+	ForwardClientCertSanitize = "sanitize"
+	// ForwardClientCertForwardOnly keeps the header if the downstream connection is mTLS, otherwise removes it
+	ForwardClientCertForwardOnly = "forward_only"
+	// ForwardClientCertAppendForward appends the client certificate details to the header if the downstream
+	// connection is mTLS, otherwise removes it
+	ForwardClientCertAppendForward = "append_forward"
+	// ForwardClientCertSanitizeSet replaces the header with the client certificate details if the downstream
+	// connection is mTLS, otherwise removes it
+	ForwardClientCertSanitizeSet = "sanitize_set"
+	// ForwardClientCertAlwaysForwardOnly always keeps the header
+	ForwardClientCertAlwaysForwardOnly = "always_forward_only"
+)
+
+// ForwardClientCert configures the x-forwarded-client-cert header, so the upstream applications can see
+// the identity of the callers when the downstream mTLS is terminated.
+// The header in the request is sanitized, forwarded or appended according to the Mode, and an element
+// is added in append_forward and sanitize_set mode, the element contains the hash of the client certificate
+// and the details configured, such as: Hash=<sha256 hex>;Subject="CN=client";URI=spiffe://foo;DNS=foo.com
+type ForwardClientCert struct {
+	Mode    string `json:"mode,omitempty"`
+	Subject bool   `json:"subject,omitempty"`
+	URI     bool   `json:"uri,omitempty"`
+	DNS     bool   `json:"dns,omitempty"`
+	// Cert adds the url encoded pem of the client certificate
+	Cert bool `json:"cert,omitempty"`
+	// Chain adds the url encoded pem of the full client certificate chain
+	Chain bool `json:"chain,omitempty"`
 }
 
 // XProxyExtendConfig
@@ -27,8 +27,22 @@ type ServiceRegistryInfo struct {
 	MqClientKey    map[string]string   `json:"mq_client_key,omitempty"`
 	MqMeta         map[string]string   `json:"mq_meta_info,omitempty"`
 	MqConsumers    map[string][]string `json:"mq_consumers,omitempty"`
+	// Registries are the names of the registry clients that the published services are registered into
+	Registries []string `json:"registries,omitempty"`
+	// UnhealthyAction is applied to the registered services when the local application fails the health check,
+	// it is "deregister" or "mark_unhealthy", default is "deregister"
+	UnhealthyAction string `json:"unhealthy_action,omitempty"`
+	// APISocket is the unix domain socket path that serves the service registration api for the co-located
+	// application, the api is served by the admin server too
+	APISocket string `json:"api_socket,omitempty"`
 }
 
+// Unhealthy actions of the registered services
+const (
+	RegistryUnhealthyDeregister    = "deregister"
+	RegistryUnhealthyMarkUnhealthy = "mark_unhealthy"
+)
+
 type ApplicationInfo struct {
 	AntShareCloud bool   `json:"ant_share_cloud,omitempty"`
 	DataCenter    string `json:"data_center,omitempty"`
@@ -40,27 +40,76 @@ type RouterConfigurationConfig struct {
 }
 
 type RouterConfig struct {
+	// Name is an optional name of the route, used in the per-route stats
+	Name            string                 `json:"name,omitempty"`
 	Match           RouterMatch            `json:"match,omitempty"`
 	Route           RouteAction            `json:"route,omitempty"`
 	DirectResponse  *DirectResponseAction  `json:"direct_response,omitempty"`
+	StaticFiles     *StaticFilesAction     `json:"static_files,omitempty"`
 	MetadataConfig  *MetadataConfig        `json:"metadata,omitempty"`
 	PerFilterConfig map[string]interface{} `json:"per_filter_config,omitempty"`
+	// FilterOverrides overrides the listener stream filters on the route, keyed by the filter type
+	FilterOverrides map[string]StreamFilterOverride `json:"filter_overrides,omitempty"`
+}
+
+// StreamFilterOverride overrides a listener stream filter when the route is matched.
+// The filter is skipped if it is disabled, or else it is created with the config if the config is not empty.
+type StreamFilterOverride struct {
+	Disabled bool                   `json:"disabled,omitempty"`
+	Config   map[string]interface{} `json:"config,omitempty"`
 }
 
 type RouterActionConfig struct {
-	ClusterName             string               `json:"cluster_name,omitempty"`
-	UpstreamProtocol        string               `json:"upstream_protocol,omitempty"`
-	ClusterHeader           string               `json:"cluster_header,omitempty"`
-	WeightedClusters        []WeightedCluster    `json:"weighted_clusters,omitempty"`
-	MetadataConfig          *MetadataConfig      `json:"metadata_match,omitempty"`
-	TimeoutConfig           api.DurationConfig   `json:"timeout,omitempty"`
-	RetryPolicy             *RetryPolicy         `json:"retry_policy,omitempty"`
-	PrefixRewrite           string               `json:"prefix_rewrite,omitempty"`
-	HostRewrite             string               `json:"host_rewrite,omitempty"`
-	AutoHostRewrite         bool                 `json:"auto_host_rewrite,omitempty"`
-	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
-	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
-	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
+	ClusterName             string                  `json:"cluster_name,omitempty"`
+	UpstreamProtocol        string                  `json:"upstream_protocol,omitempty"`
+	ClusterHeader           string                  `json:"cluster_header,omitempty"`
+	WeightedClusters        []WeightedCluster       `json:"weighted_clusters,omitempty"`
+	MetadataConfig          *MetadataConfig         `json:"metadata_match,omitempty"`
+	TimeoutConfig           api.DurationConfig      `json:"timeout,omitempty"`
+	RetryPolicy             *RetryPolicy            `json:"retry_policy,omitempty"`
+	PrefixRewrite           string                  `json:"prefix_rewrite,omitempty"`
+	HostRewrite             string                  `json:"host_rewrite,omitempty"`
+	AutoHostRewrite         bool                    `json:"auto_host_rewrite,omitempty"`
+	RequestHeadersToAdd     []*HeaderValueOption    `json:"request_headers_to_add,omitempty"`
+	ResponseHeadersToAdd    []*HeaderValueOption    `json:"response_headers_to_add,omitempty"`
+	ResponseHeadersToRemove []string                `json:"response_headers_to_remove,omitempty"`
+	ConcurrencyLimit        *ConcurrencyLimit       `json:"concurrency_limit,omitempty"`
+	InternalRedirectPolicy  *InternalRedirectPolicy `json:"internal_redirect_policy,omitempty"`
+	FailoverPolicy          *FailoverPolicy         `json:"failover_policy,omitempty"`
+}
+
+// ConcurrencyLimit limits the in-flight requests of a route, the excess requests wait in a bounded queue.
+// A request is replied with 503 if the queue is full or the wait is timeout.
+type ConcurrencyLimit struct {
+	MaxConcurrentRequests uint32 `json:"max_concurrent_requests"`
+	// MaxQueueSize is the max requests waiting for a slot, zero means no request waits
+	MaxQueueSize uint32 `json:"max_queue_size,omitempty"`
+	// QueueTimeout is the max time a request waits in the queue, default is 1s
+	QueueTimeout api.DurationConfig `json:"queue_timeout,omitempty"`
+}
+
+// InternalRedirectPolicy makes the redirect responses of the upstream followed by mosn.
+// The request is routed again with the location, instead of the redirect response being sent to the downstream.
+type InternalRedirectPolicy struct {
+	// MaxInternalRedirects is the max internal redirects of a request, the default is 1 and the limit is 5
+	MaxInternalRedirects uint32 `json:"max_internal_redirects,omitempty"`
+	// RedirectResponseCodes are the status codes that are followed, the default is 302.
+	// 301, 302, 303, 307 and 308 are supported.
+	RedirectResponseCodes []int `json:"redirect_response_codes,omitempty"`
+	// AllowedLocations are the regular expressions that the location must match, all locations are allowed if it is empty
+	AllowedLocations []string `json:"allowed_locations,omitempty"`
+	// AllowCrossSchemeRedirect allows redirecting to a location with a scheme different from the downstream request
+	AllowCrossSchemeRedirect bool `json:"allow_cross_scheme_redirect,omitempty"`
+}
+
+// FailoverPolicy routes a request to the failover cluster when the primary cluster of the route has no healthy hosts,
+// or the upstream request fails with the configured conditions and can not be retried.
+type FailoverPolicy struct {
+	ClusterName string `json:"cluster_name"`
+	// ResponseCodes are the upstream status codes that fail over, no response code fails over if it is empty
+	ResponseCodes []int `json:"response_codes,omitempty"`
+	// OnReset fails over the request if the upstream request is reset, for example connection failure or timeout
+	OnReset bool `json:"on_reset,omitempty"`
 }
 
 type ClusterWeightConfig struct {
@@ -73,6 +122,9 @@ type RetryPolicyConfig struct {
 	RetryOn            bool               `json:"retry_on,omitempty"`
 	RetryTimeoutConfig api.DurationConfig `json:"retry_timeout,omitempty"`
 	NumRetries         uint32             `json:"num_retries,omitempty"`
+	// RetryAfterMaxIntervalConfig is the max Retry-After of the 503 and 429 responses that the retry waits for,
+	// the response is not retried if its Retry-After is longer. The default is 1s.
+	RetryAfterMaxIntervalConfig api.DurationConfig `json:"retry_after_max_interval,omitempty"`
 }
 
 // Router, the list of routes that will be matched, in order, for incoming requests.
@@ -145,11 +197,13 @@ func (cw *ClusterWeight) UnmarshalJSON(b []byte) error {
 // RetryPolicy represents the retry parameters
 type RetryPolicy struct {
 	RetryPolicyConfig
-	RetryTimeout time.Duration `json:"-"`
+	RetryTimeout          time.Duration `json:"-"`
+	RetryAfterMaxInterval time.Duration `json:"-"`
 }
 
 func (rp RetryPolicy) MarshalJSON() (b []byte, err error) {
 	rp.RetryPolicyConfig.RetryTimeoutConfig.Duration = rp.RetryTimeout
+	rp.RetryPolicyConfig.RetryAfterMaxIntervalConfig.Duration = rp.RetryAfterMaxInterval
 	return json.Marshal(rp.RetryPolicyConfig)
 }
 
@@ -158,6 +212,7 @@ func (rp *RetryPolicy) UnmarshalJSON(b []byte) error {
 		return err
 	}
 	rp.RetryTimeout = rp.RetryTimeoutConfig.Duration
+	rp.RetryAfterMaxInterval = rp.RetryAfterMaxIntervalConfig.Duration
 	return nil
 }
 
@@ -279,6 +334,23 @@ type RouterMatch struct {
 type DirectResponseAction struct {
 	StatusCode int    `json:"status,omitempty"`
 	Body       string `json:"body,omitempty"`
+	// File is the path of the file used as the body, it is read when the route is created
+	File string `json:"file,omitempty"`
+	// ContentType is the content type of the body, the content type of the File is detected by
+	// the file extension if it is not configured
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// StaticFilesAction serves the files in the Root directory without an upstream cluster.
+// The file is looked up by the request path with the StripPrefix removed, the paths outside
+// the Root are rejected. Only the GET and HEAD requests are served.
+type StaticFilesAction struct {
+	Root        string `json:"root,omitempty"`
+	StripPrefix string `json:"strip_prefix,omitempty"`
+	// IndexFile is served for the directory paths, default is index.html
+	IndexFile string `json:"index_file,omitempty"`
+	// MaxFileSize is the max size of the files served, default is 4MB
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
 }
 
 // WeightedCluster.
@@ -31,6 +31,8 @@ type ServerConfig struct {
 	DefaultLogPath  string `json:"default_log_path,omitempty"`
 	DefaultLogLevel string `json:"default_log_level,omitempty"`
 	GlobalLogRoller string `json:"global_log_roller,omitempty"`
+	// log fallback config, used when the log files can not be written
+	LogFallback *LogFallbackConfig `json:"log_fallback,omitempty"`
 
 	UseNetpollMode bool `json:"use_netpoll_mode,omitempty"`
 	//graceful shutdown config
@@ -39,9 +41,66 @@ type ServerConfig struct {
 	//go processor number
 	Processor int `json:"processor,omitempty"`
 
+	// overload config, used to pause accepting new connections
+	Overload *OverloadConfig `json:"overload,omitempty"`
+
+	// Workers configures the io workers and the cpu affinity, optional
+	Workers *WorkersConfig `json:"workers,omitempty"`
+
 	Listeners []Listener `json:"listeners,omitempty"`
 }
 
+// OverloadConfig contains the thresholds for overload detection.
+// When any threshold is reached, all listeners pause accepting new connections until the pressure is released.
+// A zero value threshold means no limit.
+type OverloadConfig struct {
+	MaxActiveStreams     int64              `json:"max_active_streams,omitempty"`
+	MaxActiveConnections int64              `json:"max_active_connections,omitempty"`
+	CheckInterval        api.DurationConfig `json:"check_interval,omitempty"`
+}
+
+// WorkersConfig configures the workers serving the connections.
+// The event loops and the read/write worker pools are used in the netpoll mode, the default sizes are the cpu number.
+type WorkersConfig struct {
+	EventLoops   int `json:"event_loops,omitempty"`
+	ReadWorkers  int `json:"read_workers,omitempty"`
+	WriteWorkers int `json:"write_workers,omitempty"`
+	// ReadBufferSize is the initial read buffer size of the connections, default is 128
+	ReadBufferSize int `json:"read_buffer_size,omitempty"`
+	// WriteQueueSize is the max pending writes of a connection before the writers are blocked, default is 8
+	WriteQueueSize int `json:"write_queue_size,omitempty"`
+	// CPUAffinity pins the process to the cpus, it is supported on linux only.
+	// The processor is the number of the cpus if it is not configured.
+	CPUAffinity []int `json:"cpu_affinity,omitempty"`
+	// HandshakeWorkers is the number of the workers that run the TLS handshakes of the accepted connections,
+	// the handshakes run in the connection IO workers if it is 0
+	HandshakeWorkers int `json:"handshake_workers,omitempty"`
+	// HandshakeQueueSize is the max handshakes waiting for the handshake workers, the new connections
+	// are closed when the queue is full, default is 1024
+	HandshakeQueueSize int `json:"handshake_queue_size,omitempty"`
+	// HandshakeTimeout is the max duration of a handshake in the handshake workers, default is 10s
+	HandshakeTimeout *api.DurationConfig `json:"handshake_timeout,omitempty"`
+	// TelemetryWorkers is the number of the workers that record the stats and write the access logs
+	// of the finished requests, they are done in the request goroutines if it is 0
+	TelemetryWorkers int `json:"telemetry_workers,omitempty"`
+	// TelemetryQueueSize is the max finished requests waiting for a telemetry worker, the access logs
+	// are dropped when the queue is full, default is 4096
+	TelemetryQueueSize int `json:"telemetry_queue_size,omitempty"`
+}
+
+// LogFallbackConfig contains the fallback of the file logs.
+// The log outputs are checked periodically, when the disk is full or the file is unwritable,
+// the log entries are written to the fallback target instead of being dropped silently.
+type LogFallbackConfig struct {
+	// Target is the fallback target, stderr or memory, the default is stderr
+	Target string `json:"target,omitempty"`
+	// RingSize is the max entries kept in the memory ring buffer
+	RingSize      int                `json:"ring_size,omitempty"`
+	CheckInterval api.DurationConfig `json:"check_interval,omitempty"`
+	// MinFreeBytes is the free space threshold of the disk, below which the disk is considered full
+	MinFreeBytes uint64 `json:"min_free_bytes,omitempty"`
+}
+
 // ListenerType: Ingress or Egress
 type ListenerType string
 
@@ -55,12 +114,44 @@ type ListenerConfig struct {
 	BindToPort            bool                `json:"bind_port,omitempty"`
 	UseOriginalDst        bool                `json:"use_original_dst,omitempty"`
 	AccessLogs            []AccessLog         `json:"access_logs,omitempty"`
-	FilterChains          []FilterChain       `json:"filter_chains,omitempty"` // only one filterchains at this time
+	FilterChains          []FilterChain       `json:"filter_chains,omitempty"`
 	StreamFilters         []Filter            `json:"stream_filters,omitempty"`
 	Inspector             bool                `json:"inspector,omitempty"`
 	ConnectionIdleTimeout *api.DurationConfig `json:"connection_idle_timeout,omitempty"`
+	// DrainTimeout is the time to wait before closing the connections created by the old filter chains
+	// when the listener is updated or removed, the server's graceful timeout is used if it is not set
+	DrainTimeout *api.DurationConfig `json:"drain_timeout,omitempty"`
+	// ConnectionBalance is how the connections are distributed to the workers, the default is
+	// in turn, and exact chooses the least loaded worker. It takes effect in the netpoll mode only.
+	ConnectionBalance string `json:"connection_balance,omitempty"`
+	// ConnectionAccessLogs are written for the connections independent of the streams
+	ConnectionAccessLogs []ConnectionAccessLog `json:"connection_access_logs,omitempty"`
+	// ConnectionBuffer configures the read and write buffers of the accepted connections
+	ConnectionBuffer *ConnectionBufferConfig `json:"connection_buffer,omitempty"`
 }
 
+// ConnectionBufferConfig configures the read and write buffers of the connections.
+// The tiny-RPC workloads prefer a small read buffer and coalescing many writes,
+// and the large-payload workloads prefer a large read buffer and bounded write chunks.
+type ConnectionBufferConfig struct {
+	// ReadBufferBytes is the initial capacity of the read buffer, the read buffer shrinks
+	// to it when the connection is idle, default is the read buffer size of the workers
+	ReadBufferBytes uint32 `json:"read_buffer_bytes,omitempty"`
+	// WriteCoalesceBuffers is the max buffers coalesced into one write, default is 11
+	WriteCoalesceBuffers uint32 `json:"write_coalesce_buffers,omitempty"`
+	// WriteCoalesceBytes stops coalescing the buffers when the coalesced bytes reach it, 0 means no limit
+	WriteCoalesceBytes uint32 `json:"write_coalesce_bytes,omitempty"`
+	// MaxWriteChunkBytes splits the coalesced buffers into the writes of at most the bytes, 0 means no limit
+	MaxWriteChunkBytes uint32 `json:"max_write_chunk_bytes,omitempty"`
+	// WriteFlushDelay is the max delay to wait for more writes, such as the writes of the streams sharing
+	// a multiplexed connection, to batch the small writes into fewer syscalls. The connection with a flush
+	// delay writes in a write loop, and it does not take effect in the netpoll mode.
+	WriteFlushDelay *api.DurationConfig `json:"write_flush_delay,omitempty"`
+}
+
+// ConnectionBalanceExact distributes the connections to the least loaded workers
+const ConnectionBalanceExact = "exact"
+
 // Listener contains the listener's information
 type Listener struct {
 	ListenerConfig
@@ -76,6 +167,22 @@ type Listener struct {
 type AccessLog struct {
 	Path   string `json:"log_path,omitempty"`
 	Format string `json:"log_format,omitempty"`
+	// Type is the format type, text or json, the default is text
+	Type string `json:"log_format_type,omitempty"`
+	// JSONFormat contains the fields of the json format, the key is the field name and the value is a format.
+	// If it is empty, each variable in the log_format is a field named by the variable name.
+	JSONFormat map[string]string `json:"json_format,omitempty"`
+	// Compression is the algorithm that compresses the log file, gzip or zstd, the log is not compressed if it is empty.
+	// It is ignored if the log path is not a file.
+	Compression string `json:"log_compression,omitempty"`
+}
+
+// ConnectionAccessLog is written when a connection is closed, and when a connection is established
+// if LogOnOpen is true. The connection variables can be used in the format, such as connection_duration,
+// connection_bytes_received, connection_termination and connection_tls_version.
+type ConnectionAccessLog struct {
+	AccessLog
+	LogOnOpen bool `json:"log_on_open,omitempty"`
 }
 
 // FilterChain wraps a set of match criteria, an option TLS context,
@@ -113,15 +220,48 @@ func (fc *FilterChain) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// FilterChainMatchConfig describes the criteria used to select a filter chain
+// for a new connection when a listener has multiple filter chains.
+// An empty field matches any connection, all the non-empty fields must match.
+type FilterChainMatchConfig struct {
+	// DestinationPorts matches the local (or original destination) port
+	DestinationPorts []uint32 `json:"destination_ports,omitempty"`
+	// ServerNames matches the SNI of the TLS ClientHello, "*.example.com" is supported
+	ServerNames []string `json:"server_names,omitempty"`
+	// SourcePrefixRanges matches the remote address in CIDR notation
+	SourcePrefixRanges []string `json:"source_prefix_ranges,omitempty"`
+	// ApplicationProtocols matches any of the ALPN offered in the TLS ClientHello
+	ApplicationProtocols []string `json:"application_protocols,omitempty"`
+	// TransportProtocol matches "tls" or "raw_buffer"
+	TransportProtocol string `json:"transport_protocol,omitempty"`
+}
+
 // Filter is a config to make up a filter
 type Filter struct {
 	Type   string                 `json:"type,omitempty"`
 	Config map[string]interface{} `json:"config,omitempty"`
+	// Phase and Priority order the stream filters, the filters run by the phases in order,
+	// and by the priority from low to high in a phase. The phase registered by the filter type is used if it is empty
+	Phase    string `json:"phase,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	// Match gates the stream filter by the request, the filter is skipped if the request is not matched
+	Match *StreamFilterMatch `json:"match,omitempty"`
+}
+
+// StreamFilterMatch is the request predicate of a stream filter, all the configured conditions must be matched
+type StreamFilterMatch struct {
+	Path    string          `json:"path,omitempty"`
+	Prefix  string          `json:"prefix,omitempty"`
+	Methods []string        `json:"methods,omitempty"`
+	Headers []HeaderMatcher `json:"headers,omitempty"`
+	// Metadata matches the variables of the request by the variable names
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type FilterChainConfig struct {
-	FilterChainMatch string      `json:"match,omitempty"`
-	TLSConfig        *TLSConfig  `json:"tls_context,omitempty"`
-	TLSConfigs       []TLSConfig `json:"tls_context_set,omitempty"`
-	Filters          []Filter    `json:"filters,omitempty"`
+	FilterChainMatch string                  `json:"match,omitempty"`
+	MatchCriteria    *FilterChainMatchConfig `json:"filter_chain_match,omitempty"`
+	TLSConfig        *TLSConfig              `json:"tls_context,omitempty"`
+	TLSConfigs       []TLSConfig             `json:"tls_context_set,omitempty"`
+	Filters          []Filter                `json:"filters,omitempty"`
 }
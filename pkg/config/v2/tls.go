@@ -44,6 +44,29 @@ type TLSConfig struct {
 	Fallback          bool                   `json:"fall_back,omitempty"`
 	ExtendVerify      map[string]interface{} `json:"extend_verify,omitempty"`
 	SdsConfig         *SdsConfig             `json:"sds_source,omitempty"`
+	SessionTicket     *SessionTicketConfig   `json:"session_ticket,omitempty"`
+	// GM enables the GM/T 0024 tls with the SM2 certificates, the CertChain and the PrivateKey
+	// is the signature certificate, and the CACert is the SM2 root ca
+	GM            bool   `json:"gm,omitempty"`
+	EncCertChain  string `json:"enc_cert_chain,omitempty"`
+	EncPrivateKey string `json:"enc_private_key,omitempty"`
+}
+
+// SessionTicketConfig configures the tls session resumption by session tickets
+type SessionTicketConfig struct {
+	// Disabled disables the session tickets, the sessions are never resumed
+	Disabled bool `json:"disabled,omitempty"`
+	// Keys are the base64 encoded server ticket keys, the first key encrypts the new tickets and
+	// all of the keys decrypt the tickets, so a new key should be added at the front when rotating.
+	// A key can reference a named secret, such as "secret://ticket-key".
+	// The keys should be 32 bytes, the other lengths are hashed to 32 bytes.
+	// A random key is used if no keys configured, the tickets can not be shared by the mosn instances.
+	Keys []string `json:"keys,omitempty"`
+	// SdsConfig delivers the ticket keys by sds, it overrides the Keys
+	SdsConfig *SecretConfigWrapper `json:"sds_source,omitempty"`
+	// ClientCacheSize is the sessions cached by the client to resume the upstream connections,
+	// zero means the client sessions are not cached
+	ClientCacheSize int `json:"client_cache_size,omitempty"`
 }
 
 type SdsConfig struct {
@@ -47,6 +47,8 @@ type HostConfig struct {
 	Weight         uint32          `json:"weight,omitempty"`
 	MetaDataConfig *MetadataConfig `json:"metadata,omitempty"`
 	TLSDisable     bool            `json:"tls_disable,omitempty"`
+	Locality       string          `json:"locality,omitempty"`
+	LocalityWeight uint32          `json:"locality_weight,omitempty"`
 }
 
 // ClusterType
@@ -58,6 +60,12 @@ const (
 	SIMPLE_CLUSTER  ClusterType = "SIMPLE"
 	DYNAMIC_CLUSTER ClusterType = "DYNAMIC"
 	EDS_CLUSTER     ClusterType = "EDS"
+	// DYNAMIC_FORWARD_PROXY_CLUSTER creates the hosts on demand by the request host,
+	// the hosts config is ignored
+	DYNAMIC_FORWARD_PROXY_CLUSTER ClusterType = "DYNAMIC_FORWARD_PROXY"
+	// STATIC_RESPONSE_CLUSTER sends the requests to a local server that echoes the requests
+	// or responds the canned responses, the hosts config is ignored
+	STATIC_RESPONSE_CLUSTER ClusterType = "STATIC_RESPONSE"
 )
 
 // LbType
@@ -67,6 +75,9 @@ type LbType string
 const (
 	LB_RANDOM     LbType = "LB_RANDOM"
 	LB_ROUNDROBIN LbType = "LB_ROUNDROBIN"
+	// LB_LOCALITY_WEIGHTED chooses a locality by the locality weights first,
+	// and then round robin in the locality
+	LB_LOCALITY_WEIGHTED LbType = "LB_LOCALITY_WEIGHTED"
 )
 
 // Cluster represents a cluster's information
@@ -84,6 +95,161 @@ type Cluster struct {
 	TLS                  TLSConfig           `json:"tls_context,omitempty"`
 	Hosts                []Host              `json:"hosts,omitempty"`
 	ConnectTimeout       *api.DurationConfig `json:"connect_timeout,omitempty"`
+	// IgnoreNewHostsUntilFirstHC makes the new hosts warming, a warming host is not used by the
+	// load balancer until it passes the first active health check or the warm timeout elapses
+	IgnoreNewHostsUntilFirstHC bool                `json:"ignore_new_hosts_until_first_hc,omitempty"`
+	WarmTimeout                *api.DurationConfig `json:"warm_timeout,omitempty"`
+	TenantPool                 *TenantPool         `json:"tenant_pool,omitempty"`
+	// UpstreamProtocol is the protocol the cluster speaks, it overrides the upstream protocol of the proxy,
+	// and can be overridden by the route. Auto means the same as the downstream protocol
+	UpstreamProtocol string `json:"upstream_protocol,omitempty"`
+	// ConnPool configures the connection pools of the hosts, only the multiplexing protocols use it now
+	ConnPool *ConnPoolConfig `json:"conn_pool,omitempty"`
+	// DynamicForwardProxy configures the host cache of the DYNAMIC_FORWARD_PROXY cluster
+	DynamicForwardProxy *DynamicForwardProxyConfig `json:"dynamic_forward_proxy,omitempty"`
+	// StaticResponse configures the local server of the STATIC_RESPONSE cluster
+	StaticResponse *StaticResponseConfig `json:"static_response,omitempty"`
+	// DrainTimeout is the max duration to wait for the active streams of the removed hosts,
+	// the connections are closed after the timeout, default is 30s
+	DrainTimeout *api.DurationConfig `json:"drain_timeout,omitempty"`
+	// SLO tracks the success rate and the burn rate of the upstream responses
+	SLO *SLOConfig `json:"slo,omitempty"`
+	// CellRouting routes the requests to the hosts in the cell of the requests
+	CellRouting *CellRouting `json:"cell_routing,omitempty"`
+	// LaneRouting routes the colored requests to the hosts in their lanes
+	LaneRouting *LaneRouting `json:"lane_routing,omitempty"`
+	// ConnectionBuffer configures the read and write buffers of the upstream connections
+	ConnectionBuffer *ConnectionBufferConfig `json:"connection_buffer,omitempty"`
+}
+
+// LaneRouting routes a request colored by the lane header to the hosts in the lane, the lane of a host is
+// its metadata value of the metadata key. The hosts without the metadata key are in the base lane.
+// A request falls back to the base lane if its lane has no healthy hosts, and the requests without
+// a color are routed to the base lane too.
+type LaneRouting struct {
+	// Header is the request header that carries the lane of the request
+	Header string `json:"header"`
+	// MetadataKey is the host metadata key of the lane, default is lane
+	MetadataKey string `json:"metadata_key,omitempty"`
+	// BaseLane is the lane name of the base lane, the hosts in it are in the base lane too
+	BaseLane string `json:"base_lane,omitempty"`
+}
+
+// CellRouting routes a request to the hosts in its cell, the cell of a host is the locality of the host.
+// The cell of a request is the value of the cell header, or else the hash header is hashed to one of the cells.
+// The requests without a cell are routed to the local cell if PreferLocalCell, or else to all the hosts.
+type CellRouting struct {
+	// Header is the request header that carries the cell of the request
+	Header string `json:"header,omitempty"`
+	// HashHeader is the request header hashed to one of the Cells, such as a user id
+	HashHeader string `json:"hash_header,omitempty"`
+	// Cells are the cells that the hash header is hashed to, in order.
+	// The cells of the hosts in name order are used if it is empty
+	Cells []string `json:"cells,omitempty"`
+	// LocalCell is the cell that mosn is deployed in
+	LocalCell string `json:"local_cell,omitempty"`
+	// PreferLocalCell routes the requests without a cell to the local cell
+	PreferLocalCell bool `json:"prefer_local_cell,omitempty"`
+	// CrossCellFallback routes the request to the other cells if its cell has no healthy hosts,
+	// the local cell is preferred. The request fails with no healthy upstream if it is false
+	CrossCellFallback bool `json:"cross_cell_fallback,omitempty"`
+}
+
+// SLOConfig tracks the upstream responses by the code classes over the sliding windows,
+// and computes the success rate and the burn rate of the error budget in each window.
+// A response is failed if its code is 5xx or the request gets no response
+type SLOConfig struct {
+	// Target is the success rate objective, such as 0.999
+	Target float64 `json:"target"`
+	// Windows are the sliding windows, default is 5m and 1h
+	Windows []SLOWindow `json:"windows,omitempty"`
+	// Webhook is notified by a POST request when the burn rate of a window exceeds its threshold
+	Webhook string `json:"webhook,omitempty"`
+}
+
+// SLOWindow is a sliding window of the slo
+type SLOWindow struct {
+	Window api.DurationConfig `json:"window"`
+	// BurnRateThreshold alerts if the burn rate exceeds it, zero means no alert
+	BurnRateThreshold float64 `json:"burn_rate_threshold,omitempty"`
+	// MinRequests is the min requests in the window to alert, default is 10
+	MinRequests uint64 `json:"min_requests,omitempty"`
+}
+
+// StaticResponseConfig configures the responses of the static response cluster.
+// The local server speaks both http1 and http2, the request is echoed as a json if no canned response matches
+type StaticResponseConfig struct {
+	// Responses are the canned responses, the first one whose prefix matches the request path is used
+	Responses []StaticResponse `json:"responses,omitempty"`
+	// Latency is the delay before responding
+	Latency api.DurationConfig `json:"latency,omitempty"`
+	// Jitter is the max random delay added to the latency
+	Jitter api.DurationConfig `json:"jitter,omitempty"`
+}
+
+// StaticResponse is a canned response of the static response cluster
+type StaticResponse struct {
+	// Prefix matches the request path, empty matches all
+	Prefix string `json:"prefix,omitempty"`
+	// Status is the response status, default is 200
+	Status int `json:"status,omitempty"`
+	// Headers are the response headers
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the response body
+	Body string `json:"body,omitempty"`
+}
+
+// DynamicForwardProxyConfig configures the hosts created on demand by the dynamic forward proxy cluster
+type DynamicForwardProxyConfig struct {
+	// MaxHosts limits the cached hosts, the least recently used host is evicted if it is full, default is 1024
+	MaxHosts uint32 `json:"max_hosts,omitempty"`
+	// HostTTL is the idle time after which a host is evicted, default is 5 minutes
+	HostTTL api.DurationConfig `json:"host_ttl,omitempty"`
+}
+
+// Connection pool modes
+const (
+	// ConnPoolModeMultiplex shares the connections by all the streams, a connection has concurrent streams
+	ConnPoolModeMultiplex = "multiplex"
+	// ConnPoolModePingPong uses a connection for one stream at a time,
+	// the connection is closed if the stream is reset before the response, such as timeout
+	ConnPoolModePingPong = "pingpong"
+	// ConnPoolModeBind binds the connections to the downstream connection, they are closed together
+	ConnPoolModeBind = "bind"
+)
+
+// ConnPoolConfig configures the upstream connection pool of a host
+type ConnPoolConfig struct {
+	// Mode is the connection pool mode, empty means the protocol's default mode
+	Mode string `json:"mode,omitempty"`
+	// ConnectionsPerHost is the max connections of a host for each sub protocol,
+	// default is 1 in multiplex mode and the circuit breaker's max connections in pingpong mode.
+	// It is always 1 for each downstream connection in bind mode
+	ConnectionsPerHost uint32 `json:"connections_per_host,omitempty"`
+	// MaxConcurrentStreams limits the concurrent streams of a connection, zero means no limit
+	MaxConcurrentStreams uint32 `json:"max_concurrent_streams,omitempty"`
+}
+
+// TenantPool isolates the upstream connection pools by the downstream tenant,
+// the tenant is identified by a request header or the downstream client certificate
+type TenantPool struct {
+	Header string `json:"header,omitempty"`
+	// FromCert uses the common name of the downstream client certificate as tenant
+	FromCert bool `json:"from_cert,omitempty"`
+	// MaxTenants limits the tenants that have isolated pools in a cluster,
+	// the other tenants use the shared pools
+	MaxTenants uint32 `json:"max_tenants,omitempty"`
+	// AllowedTenants is the tenants that can have isolated pools, empty means all the tenants,
+	// the other tenants use the shared pools
+	AllowedTenants []string `json:"allowed_tenants,omitempty"`
+	// MaxConnections limits the connections of a tenant to each host, zero means the cluster limit
+	MaxConnections uint32 `json:"max_connections,omitempty"`
+	// MaxRequests limits the concurrent requests of a tenant, the cluster limit is applied too.
+	// zero means only the cluster limit
+	MaxRequests uint32 `json:"max_requests,omitempty"`
+	// IdleTimeout is the duration that a tenant without requests can be evicted when the max tenants is reached,
+	// the pools of the evicted tenant are drained. zero means the tenants are never evicted
+	IdleTimeout api.DurationConfig `json:"idle_timeout,omitempty"`
 }
 
 // HealthCheck is a configuration of health check
@@ -241,6 +241,10 @@ func addOrUpdateStreamFilters(listenername string, typ string, cfg map[string]in
 			listeners[idx] = ln
 		}
 	} else {
+		// keep the order and the match of the updated filter
+		filter.Phase = ln.StreamFilters[filterIndex].Phase
+		filter.Priority = ln.StreamFilters[filterIndex].Priority
+		filter.Match = ln.StreamFilters[filterIndex].Match
 		ln.StreamFilters[filterIndex] = filter
 	}
 	return true
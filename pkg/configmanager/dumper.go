@@ -25,6 +25,7 @@ import (
 
 	"mosn.io/mosn/pkg/admin/store"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/event"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
@@ -122,6 +123,9 @@ func DumpConfig() {
 
 		//update mosn_config
 		store.SetMOSNConfig(config)
+		event.Publish(event.ConfigUpdated, configPath, map[string]string{
+			"reason": "dynamic",
+		})
 		// use golang original json lib, so the marshal ident can handle MarshalJSON interface implement correctly
 		configLock.Lock()
 		defer configLock.Unlock()
@@ -71,3 +71,28 @@ func Load(path string) *v2.MOSNConfig {
 	}
 	return &config
 }
+
+// SetConfig replaces the loaded config, it is used when the config is reloaded at runtime
+func SetConfig(cfg *v2.MOSNConfig) {
+	configLock.Lock()
+	config = *cfg
+	configLock.Unlock()
+}
+
+// ReadConfig reads and parses the config file without any side effects on the loaded config
+func ReadConfig(path string) (*v2.MOSNConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(content)
+}
+
+// ParseConfig parses the config content without any side effects on the loaded config
+func ParseConfig(content []byte) (*v2.MOSNConfig, error) {
+	cfg := &v2.MOSNConfig{}
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -26,8 +26,11 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/healthcheck"
 )
 
 type ContentKey string
@@ -38,6 +41,7 @@ var ProtocolsSupported = map[string]bool{
 	string(protocol.HTTP1):     true,
 	string(protocol.HTTP2):     true,
 	string(protocol.Xprotocol): true,
+	string(protocol.HTTPAuto):  true,
 }
 
 const (
@@ -47,6 +51,14 @@ const (
 	DefaultConnBufferLimitBytes = uint32(16 * 1024)
 )
 
+// healthCheckProtocolSupported checks the protocol is a supported protocol or a registered health check session
+func healthCheckProtocolSupported(p string) bool {
+	if _, ok := ProtocolsSupported[p]; ok || p == "" {
+		return true
+	}
+	return healthcheck.IsSessionFactoryRegistered(types.Protocol(p))
+}
+
 // RegisterProtocolParser
 // used to register parser
 func RegisterProtocolParser(key string) bool {
@@ -113,7 +125,7 @@ func ParseClusterConfig(clusters []v2.Cluster) ([]v2.Cluster, map[string][]v2.Ho
 				"For 1, represent ANY_ENDPOINT" +
 				"For 2, represent DEFAULT_SUBSET")
 		}
-		if _, ok := ProtocolsSupported[c.HealthCheck.Protocol]; !ok && c.HealthCheck.Protocol != "" {
+		if !healthCheckProtocolSupported(c.HealthCheck.Protocol) {
 			log.StartLogger.Fatalf("[config] [parse cluster] unsupported health check protocol: %v", c.HealthCheck.Protocol)
 		}
 		c.Hosts = parseHostConfig(c.Hosts)
@@ -240,6 +252,10 @@ func ParseServerConfig(c *v2.ServerConfig) *v2.ServerConfig {
 		c.Processor = n
 	} else if c.Processor == 0 {
 		c.Processor = runtime.NumCPU()
+		// the process is pinned to the cpus
+		if c.Workers != nil && len(c.Workers.CPUAffinity) > 0 && len(c.Workers.CPUAffinity) < c.Processor {
+			c.Processor = len(c.Workers.CPUAffinity)
+		}
 	}
 
 	// trigger processor callbacks
@@ -251,17 +267,24 @@ func ParseServerConfig(c *v2.ServerConfig) *v2.ServerConfig {
 	return c
 }
 
-// GetStreamFilters returns a stream filter factory by filter.Type
+// GetStreamFilters returns a stream filter factory by filter.Type,
+// the factories are ordered by the filter phases and priorities
 func GetStreamFilters(configs []v2.Filter) []api.StreamFilterChainFactory {
 	var factories []api.StreamFilterChainFactory
 
-	for _, c := range configs {
+	for _, c := range filter.SortStreamFilters(configs) {
 		sfcc, err := api.CreateStreamFilterChainFactory(c.Type, c.Config)
 		if err != nil {
 			log.DefaultLogger.Errorf("[config] get stream filter failed, type: %s, error: %v", c.Type, err)
 			continue
 		}
-		factories = append(factories, sfcc)
+		if c.Match != nil {
+			if sfcc, err = filter.WithStreamFilterMatch(c.Match, sfcc); err != nil {
+				log.DefaultLogger.Errorf("[config] get stream filter match failed, type: %s, error: %v", c.Type, err)
+				continue
+			}
+		}
+		factories = append(factories, filter.WithStreamFilterType(c.Type, sfcc))
 	}
 
 	return factories
@@ -20,7 +20,9 @@ package configmanager
 import (
 	"encoding/json"
 	"net"
+	"os"
 	"reflect"
+	"runtime"
 	"testing"
 
 	"mosn.io/mosn/pkg/config/v2"
@@ -246,3 +248,28 @@ func TestParseServiceRegistry(t *testing.T) {
 		t.Error("no callback")
 	}
 }
+
+func TestParseServerConfigProcessor(t *testing.T) {
+	os.Unsetenv("GOMAXPROCS")
+	c := ParseServerConfig(&v2.ServerConfig{})
+	if c.Processor != runtime.NumCPU() {
+		t.Errorf("expected processor %d, but got %d", runtime.NumCPU(), c.Processor)
+	}
+	c = ParseServerConfig(&v2.ServerConfig{
+		Workers: &v2.WorkersConfig{
+			CPUAffinity: []int{0},
+		},
+	})
+	if c.Processor != 1 {
+		t.Errorf("expected processor 1 for the cpu affinity, but got %d", c.Processor)
+	}
+	c = ParseServerConfig(&v2.ServerConfig{
+		Processor: 2,
+		Workers: &v2.WorkersConfig{
+			CPUAffinity: []int{0},
+		},
+	})
+	if c.Processor != 2 {
+		t.Errorf("the configured processor should be used, but got %d", c.Processor)
+	}
+}
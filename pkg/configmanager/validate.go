@@ -0,0 +1,285 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+)
+
+// ValidationErrors contains all the errors found in a config validation
+type ValidationErrors []error
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// validator collects the errors, so all the errors can be reported at once
+type validator struct {
+	errs ValidationErrors
+	// cluster names in the config
+	clusters map[string]bool
+	// router config names in the connection manager filters
+	routers map[string]*v2.RouterConfiguration
+	// routers config names referenced by proxy filters
+	routerRefs map[string]string
+}
+
+func (v *validator) addError(format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+// Validate checks the mosn config without creating any listeners or clusters.
+// Validate returns ValidationErrors contains all the errors found, or nil if the config is valid.
+// The checks contains:
+//   - field values checks, such as address, protocol and lb subset fall back policy.
+//   - network filters and stream filters are registered, and the filter configs can be parsed.
+//   - cross reference checks, such as the route points to an existing cluster.
+func Validate(c *v2.MOSNConfig) error {
+	v := &validator{
+		clusters:   make(map[string]bool),
+		routers:    make(map[string]*v2.RouterConfiguration),
+		routerRefs: make(map[string]string),
+	}
+	mode := c.Mode()
+	v.validateClusters(c.ClusterManager.Clusters)
+	if mode != v2.Xds {
+		if len(c.ClusterManager.Clusters) == 0 && !c.ClusterManager.AutoDiscovery {
+			v.addError("no cluster found and cluster manager doesn't support auto discovery")
+		}
+		if len(c.Servers) == 0 {
+			v.addError("no server found")
+		}
+	}
+	if len(c.Servers) > 1 {
+		v.addError("multiple server not supported yet, got %d", len(c.Servers))
+	}
+	for i := range c.Servers {
+		v.validateServer(&c.Servers[i], mode)
+	}
+	for listener, name := range v.routerRefs {
+		if _, ok := v.routers[name]; !ok {
+			v.addError("listener %s: router config %s is not found", listener, name)
+		}
+	}
+	// the clusters may be delivered by dynamic resources, ignore the route's cluster check
+	if mode == v2.File && !c.ClusterManager.AutoDiscovery {
+		v.validateRouteClusters()
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// ValidateListener checks a listener config that is added or updated at runtime.
+// The routers and clusters referenced by the listener are not checked, they may be delivered separately.
+func ValidateListener(ln *v2.Listener) error {
+	v := &validator{
+		clusters:   make(map[string]bool),
+		routers:    make(map[string]*v2.RouterConfiguration),
+		routerRefs: make(map[string]string),
+	}
+	if ln.Name == "" {
+		v.addError("listener: name is required in listener config")
+	}
+	v.validateServer(&v2.ServerConfig{
+		Listeners: []v2.Listener{*ln},
+	}, v2.Xds)
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func (v *validator) validateClusters(clusters []v2.Cluster) {
+	for _, c := range clusters {
+		if c.Name == "" {
+			v.addError("cluster: name is required in cluster config")
+			continue
+		}
+		if v.clusters[c.Name] {
+			v.addError("cluster %s: duplicate cluster name", c.Name)
+		}
+		v.clusters[c.Name] = true
+		if c.LBSubSetConfig.FallBackPolicy > 2 {
+			v.addError("cluster %s: invalid lb subset fall back policy %d", c.Name, c.LBSubSetConfig.FallBackPolicy)
+		}
+		if _, ok := ProtocolsSupported[c.UpstreamProtocol]; c.UpstreamProtocol != "" && !ok {
+			v.addError("cluster %s: invalid upstream protocol %s", c.Name, c.UpstreamProtocol)
+		}
+		if !healthCheckProtocolSupported(c.HealthCheck.Protocol) {
+			v.addError("cluster %s: unsupported health check protocol %s", c.Name, c.HealthCheck.Protocol)
+		}
+		for _, h := range c.Hosts {
+			if _, _, err := net.SplitHostPort(h.Address); err != nil {
+				v.addError("cluster %s: invalid host address %s: %v", c.Name, h.Address, err)
+			}
+		}
+	}
+}
+
+func (v *validator) validateServer(c *v2.ServerConfig, mode v2.Mode) {
+	if mode != v2.Xds && len(c.Listeners) == 0 {
+		v.addError("server %s: no listener found", c.ServerName)
+	}
+	names := make(map[string]bool, len(c.Listeners))
+	addrs := make(map[string]bool, len(c.Listeners))
+	for i := range c.Listeners {
+		lc := &c.Listeners[i]
+		if lc.Name != "" {
+			if names[lc.Name] {
+				v.addError("listener %s: duplicate listener name", lc.Name)
+			}
+			names[lc.Name] = true
+		}
+		name := lc.Name
+		if name == "" {
+			name = lc.AddrConfig
+		}
+		if lc.AddrConfig == "" {
+			v.addError("listener %s: address is required in listener config", name)
+		} else if _, err := net.ResolveTCPAddr("tcp", lc.AddrConfig); err != nil {
+			v.addError("listener %s: invalid address %s: %v", name, lc.AddrConfig, err)
+		} else {
+			// virtual listeners with different traffic directions can share an address,
+			// connections are dispatched to them by the original destination.
+			key := lc.AddrConfig
+			if !lc.BindToPort {
+				key = key + "|" + string(lc.Type)
+			}
+			if addrs[key] {
+				v.addError("listener %s: duplicate listener address %s", name, lc.AddrConfig)
+			}
+			addrs[key] = true
+		}
+		v.validateListener(name, lc)
+	}
+}
+
+func (v *validator) validateListener(name string, lc *v2.Listener) {
+	if len(lc.FilterChains) == 0 {
+		v.addError("listener %s: no filter chain found", name)
+		return
+	}
+	for i := range lc.FilterChains {
+		fc := &lc.FilterChains[i]
+		v.validateFilterChainMatch(name, i, fc.MatchCriteria)
+		for _, f := range fc.Filters {
+			if f.Type == v2.CONNECTION_MANAGER {
+				v.validateRouters(name, f.Config)
+				continue
+			}
+			if _, err := api.CreateNetworkFilterChainFactory(f.Type, f.Config); err != nil {
+				v.addError("listener %s: network filter %s: %v", name, f.Type, err)
+				continue
+			}
+			if f.Type == v2.DEFAULT_NETWORK_FILTER {
+				p := &v2.Proxy{}
+				if data, err := json.Marshal(f.Config); err == nil && json.Unmarshal(data, p) == nil && p.RouterConfigName != "" {
+					v.routerRefs[name] = p.RouterConfigName
+				}
+			}
+		}
+	}
+	for _, f := range lc.StreamFilters {
+		if err := filter.ValidateStreamFilterPhase(f); err != nil {
+			v.addError("listener %s: stream filter %s: %v", name, f.Type, err)
+		}
+		if err := filter.ValidateStreamFilterMatch(f); err != nil {
+			v.addError("listener %s: stream filter %s: %v", name, f.Type, err)
+		}
+		if _, err := api.CreateStreamFilterChainFactory(f.Type, f.Config); err != nil {
+			v.addError("listener %s: stream filter %s: %v", name, f.Type, err)
+		}
+	}
+}
+
+func (v *validator) validateFilterChainMatch(name string, idx int, m *v2.FilterChainMatchConfig) {
+	if m == nil {
+		return
+	}
+	for _, prefix := range m.SourcePrefixRanges {
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			v.addError("listener %s: filter chain %d: invalid source prefix range %s", name, idx, prefix)
+		}
+	}
+	switch m.TransportProtocol {
+	case "", "tls", "raw_buffer":
+	default:
+		v.addError("listener %s: filter chain %d: invalid transport protocol %s", name, idx, m.TransportProtocol)
+	}
+}
+
+func (v *validator) validateRouters(name string, cfg map[string]interface{}) {
+	routerConfig := &v2.RouterConfiguration{}
+	data, err := json.Marshal(cfg)
+	if err == nil {
+		err = json.Unmarshal(data, routerConfig)
+	}
+	if err != nil {
+		v.addError("listener %s: invalid router configuration: %v", name, err)
+		return
+	}
+	if routerConfig.RouterConfigName == "" {
+		return
+	}
+	if _, ok := v.routers[routerConfig.RouterConfigName]; ok {
+		v.addError("listener %s: duplicate router config name %s", name, routerConfig.RouterConfigName)
+	}
+	v.routers[routerConfig.RouterConfigName] = routerConfig
+}
+
+func (v *validator) validateRouteClusters() {
+	for routerName, rc := range v.routers {
+		for _, vh := range rc.VirtualHosts {
+			for _, r := range vh.Routers {
+				if r.DirectResponse != nil || r.StaticFiles != nil || r.Route.ClusterHeader != "" {
+					continue
+				}
+				if len(r.Route.WeightedClusters) > 0 {
+					for _, wc := range r.Route.WeightedClusters {
+						v.checkRouteCluster(routerName, vh.Name, wc.Cluster.Name)
+					}
+					continue
+				}
+				v.checkRouteCluster(routerName, vh.Name, r.Route.ClusterName)
+			}
+		}
+	}
+}
+
+func (v *validator) checkRouteCluster(routerName, vhName, cluster string) {
+	if cluster == "" {
+		v.addError("router %s: virtual host %s: route has no cluster", routerName, vhName)
+		return
+	}
+	if !v.clusters[cluster] {
+		v.addError("router %s: virtual host %s: route points to an unknown cluster %s", routerName, vhName, cluster)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configmanager
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+type mockNetworkFilterFactory struct{}
+
+func (f *mockNetworkFilterFactory) CreateFilterChain(ctx context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+}
+
+func init() {
+	creator := func(conf map[string]interface{}) (api.NetworkFilterChainFactory, error) {
+		return &mockNetworkFilterFactory{}, nil
+	}
+	api.RegisterNetwork(v2.DEFAULT_NETWORK_FILTER, creator)
+	api.RegisterNetwork(v2.CONNECTION_MANAGER, creator)
+}
+
+const validateConfig = `{
+	"servers":[{
+		"listeners":[{
+			"name":"%listener%",
+			"address":"%address%",
+			"filter_chains":[{
+				"filters":[
+					{
+						"type":"proxy",
+						"config":{
+							"downstream_protocol":"Http1",
+							"upstream_protocol":"Http1",
+							"router_config_name":"%router_ref%"
+						}
+					},
+					{
+						"type":"connection_manager",
+						"config":{
+							"router_config_name":"test_router",
+							"virtual_hosts":[{
+								"name":"test",
+								"domains":["*"],
+								"routers":[{
+									"match":{"prefix":"/"},
+									"route":{"cluster_name":"%route_cluster%"}
+								}]
+							}]
+						}
+					}
+				]
+			}],
+			"stream_filters":[%stream_filters%]
+		}]
+	}],
+	"cluster_manager":{
+		"clusters":[{
+			"name":"test_cluster",
+			"hosts":[{"address":"%host%"}]
+		}]
+	}
+}`
+
+func makeValidateConfig(t *testing.T, replacer *strings.Replacer) *v2.MOSNConfig {
+	cfg := &v2.MOSNConfig{}
+	if err := json.Unmarshal([]byte(replacer.Replace(validateConfig)), cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	cfg := makeValidateConfig(t, strings.NewReplacer(
+		"%listener%", "test_listener",
+		"%address%", "127.0.0.1:2045",
+		"%router_ref%", "test_router",
+		"%route_cluster%", "test_cluster",
+		"%stream_filters%", "",
+		"%host%", "127.0.0.1:8080",
+	))
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("expected valid config, but got: %v", err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	cfg := makeValidateConfig(t, strings.NewReplacer(
+		"%listener%", "test_listener",
+		"%address%", "127.0.0.1",
+		"%router_ref%", "unknown_router",
+		"%route_cluster%", "unknown_cluster",
+		"%stream_filters%", `{"type":"unknown_filter"}`,
+		"%host%", "127.0.0.1",
+	))
+	err := Validate(cfg)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected validation errors, but got: %v", err)
+	}
+	expected := []string{
+		"cluster test_cluster: invalid host address 127.0.0.1",
+		"listener test_listener: invalid address 127.0.0.1",
+		"listener test_listener: stream filter unknown_filter",
+		"listener test_listener: router config unknown_router is not found",
+		"route points to an unknown cluster unknown_cluster",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, but got: %v", len(expected), err)
+	}
+	for _, e := range expected {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("error %s is not reported, errors: %v", e, err)
+		}
+	}
+}
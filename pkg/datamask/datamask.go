@@ -0,0 +1,246 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datamask
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultMaskChar = "*"
+
+var defaultHeaders = []string{"authorization", "proxy-authorization", "cookie", "set-cookie"}
+
+// the prefixes of the variables that get the header or cookie values, see the variables registered in proxy and stream
+var (
+	headerVariablePrefixes = []string{"request_header_", "response_header_", "http_header_"}
+	cookieVariablePrefix   = "http_cookie_"
+)
+
+// Masker masks the sensitive headers and JSON body fields
+type Masker struct {
+	headers    map[string]bool
+	jsonFields map[string]bool
+	maskChar   string
+	keepLast   int
+}
+
+// NewMasker creates a masker by the config
+func NewMasker(cfg *v2.StreamDataMask) *Masker {
+	m := &Masker{
+		headers:    make(map[string]bool),
+		jsonFields: make(map[string]bool),
+		maskChar:   cfg.MaskChar,
+		keepLast:   cfg.KeepLast,
+	}
+	headers := cfg.Headers
+	if len(headers) == 0 {
+		headers = defaultHeaders
+	}
+	for _, h := range headers {
+		m.headers[strings.ToLower(h)] = true
+	}
+	for _, f := range cfg.JSONFields {
+		m.jsonFields[strings.ToLower(f)] = true
+	}
+	if m.maskChar == "" {
+		m.maskChar = defaultMaskChar
+	}
+	return m
+}
+
+// streamData is stored in the stream context, it contains the masker
+// and the masked bodies of the stream
+type streamData struct {
+	masker       *Masker
+	requestBody  string
+	responseBody string
+}
+
+// WithMasker sets the masker into the stream context, the stream context is a mosn value context,
+// so the masker is visible to the access logs of the stream.
+func WithMasker(ctx context.Context, m *Masker) context.Context {
+	return mosnctx.WithValue(ctx, types.ContextKeyDataMasker, &streamData{masker: m})
+}
+
+func streamDataFromContext(ctx context.Context) *streamData {
+	if ctx == nil {
+		return nil
+	}
+	if d, ok := mosnctx.Get(ctx, types.ContextKeyDataMasker).(*streamData); ok {
+		return d
+	}
+	return nil
+}
+
+// MaskerFromContext returns the masker of the stream, nil means no data should be masked
+func MaskerFromContext(ctx context.Context) *Masker {
+	if d := streamDataFromContext(ctx); d != nil {
+		return d.masker
+	}
+	return nil
+}
+
+// MaskHeadersFromContext masks the headers with the masker of the stream
+func MaskHeadersFromContext(ctx context.Context, headers api.HeaderMap) api.HeaderMap {
+	if m := MaskerFromContext(ctx); m != nil {
+		return m.MaskHeaders(headers)
+	}
+	return headers
+}
+
+// SetMaskedBody masks the JSON body and saves it in the stream context,
+// the body that is not a valid JSON is not saved.
+func SetMaskedBody(ctx context.Context, isRequest bool, body []byte) {
+	d := streamDataFromContext(ctx)
+	if d == nil || len(body) == 0 {
+		return
+	}
+	masked, ok := d.masker.MaskJSON(body)
+	if !ok {
+		return
+	}
+	if isRequest {
+		d.requestBody = string(masked)
+	} else {
+		d.responseBody = string(masked)
+	}
+}
+
+// MaskedBodyFromContext returns the masked body saved in the stream context
+func MaskedBodyFromContext(ctx context.Context, isRequest bool) (string, bool) {
+	d := streamDataFromContext(ctx)
+	if d == nil {
+		return "", false
+	}
+	body := d.responseBody
+	if isRequest {
+		body = d.requestBody
+	}
+	return body, body != ""
+}
+
+// IsSensitiveHeader returns true if the header should be masked
+func (m *Masker) IsSensitiveHeader(name string) bool {
+	return m.headers[strings.ToLower(name)]
+}
+
+// MaskHeader masks the header value if the header is sensitive
+func (m *Masker) MaskHeader(name, value string) string {
+	if value == "" || !m.IsSensitiveHeader(name) {
+		return value
+	}
+	return m.mask(value, 0)
+}
+
+// MaskHeaders returns a copy of the headers with the sensitive headers masked.
+// The headers are returned directly if there is no sensitive header.
+func (m *Masker) MaskHeaders(headers api.HeaderMap) api.HeaderMap {
+	if headers == nil {
+		return nil
+	}
+	sensitive := false
+	headers.Range(func(key, value string) bool {
+		sensitive = m.IsSensitiveHeader(key)
+		return !sensitive
+	})
+	if !sensitive {
+		return headers
+	}
+	masked := protocol.CommonHeader{}
+	headers.Range(func(key, value string) bool {
+		masked.Set(key, m.MaskHeader(key, value))
+		return true
+	})
+	return masked
+}
+
+// MaskVariable masks the value of the variables that get the sensitive headers or cookies
+func (m *Masker) MaskVariable(name, value string) string {
+	for _, prefix := range headerVariablePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return m.MaskHeader(name[len(prefix):], value)
+		}
+	}
+	if strings.HasPrefix(name, cookieVariablePrefix) {
+		return m.MaskHeader("cookie", value)
+	}
+	return value
+}
+
+// MaskJSON masks the sensitive fields in the JSON body, in any depth.
+// It returns false if the body is not a valid JSON.
+func (m *Masker) MaskJSON(body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	if len(m.jsonFields) == 0 {
+		return body, true
+	}
+	masked, err := json.Marshal(m.maskValue(v, false))
+	if err != nil {
+		return nil, false
+	}
+	return masked, true
+}
+
+func (m *Masker) maskValue(v interface{}, sensitive bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range value {
+			value[k] = m.maskValue(fv, sensitive || m.jsonFields[strings.ToLower(k)])
+		}
+		return value
+	case []interface{}:
+		for i, ev := range value {
+			value[i] = m.maskValue(ev, sensitive)
+		}
+		return value
+	case string:
+		if sensitive {
+			return m.mask(value, m.keepLast)
+		}
+	case json.Number:
+		if sensitive {
+			return m.mask(value.String(), m.keepLast)
+		}
+	}
+	return v
+}
+
+// mask replaces the value with the mask char, and keeps the last characters
+func (m *Masker) mask(value string, keepLast int) string {
+	runes := []rune(value)
+	// at least the half of the value is masked
+	if keepLast > len(runes)/2 {
+		keepLast = len(runes) / 2
+	}
+	masked := len(runes) - keepLast
+	return strings.Repeat(m.maskChar, masked) + string(runes[masked:])
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datamask
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+func TestMaskHeaders(t *testing.T) {
+	m := NewMasker(&v2.StreamDataMask{})
+	headers := protocol.CommonHeader{
+		"Authorization": "Bearer token",
+		"cookie":        "session=abc",
+		"service":       "test",
+	}
+	masked := m.MaskHeaders(headers)
+	expected := protocol.CommonHeader{
+		"Authorization": "************",
+		"cookie":        "***********",
+		"service":       "test",
+	}
+	if !reflect.DeepEqual(masked, expected) {
+		t.Fatalf("unexpected masked headers: %v", masked)
+	}
+	// the original headers are not modified
+	if v, _ := headers.Get("cookie"); v != "session=abc" {
+		t.Fatal("original headers should not be modified")
+	}
+	plain := protocol.CommonHeader{"service": "test"}
+	if m.MaskHeaders(plain).(protocol.CommonHeader)["service"] != "test" {
+		t.Fatal("headers without sensitive header should be returned directly")
+	}
+
+	for name, value := range map[string]string{
+		"request_header_authorization": "************",
+		"response_header_Set-Cookie":   "************",
+		"http_header_authorization":    "************",
+		"http_cookie_session":          "************",
+		"request_header_service":       "Bearer token",
+		"masked_request_body":          "Bearer token",
+	} {
+		if v := m.MaskVariable(name, "Bearer token"); v != value {
+			t.Fatalf("variable %s expected %s, but got %s", name, value, v)
+		}
+	}
+}
+
+func TestMaskJSON(t *testing.T) {
+	m := NewMasker(&v2.StreamDataMask{
+		JSONFields: []string{"card_number", "Phone", "secret"},
+		MaskChar:   "#",
+		KeepLast:   4,
+	})
+	body := `{"name":"alice","card_number":"6222020200001234","phone":13800001234,"profile":{"PHONE":"555"},` +
+		`"cards":[{"card_number":"4111111111111111"}],"secret":{"key":"abcdefgh"},"amount":12.5}`
+	masked, ok := m.MaskJSON([]byte(body))
+	if !ok {
+		t.Fatal("mask json failed")
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(masked, &v); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name":        "alice",
+		"card_number": "############1234",
+		"phone":       "#######1234",
+		"profile":     map[string]interface{}{"PHONE": "##5"},
+		"cards":       []interface{}{map[string]interface{}{"card_number": "############1111"}},
+		"secret":      map[string]interface{}{"key": "####efgh"},
+		"amount":      12.5,
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected masked json: %s", masked)
+	}
+	if _, ok := m.MaskJSON([]byte("card_number=1234")); ok {
+		t.Fatal("invalid json should not be masked")
+	}
+}
+
+func TestMaskedBodyVariable(t *testing.T) {
+	m := NewMasker(&v2.StreamDataMask{JSONFields: []string{"password"}})
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+	if v, _ := variable.GetVariableValue(ctx, VarMaskedRequestBody); v != variable.ValueNotFound {
+		t.Fatalf("expected not found without masker, but got %s", v)
+	}
+	ctx = WithMasker(ctx, m)
+	SetMaskedBody(ctx, true, []byte(`{"password":"123456"}`))
+	SetMaskedBody(ctx, false, []byte(`not json`))
+	if v, _ := variable.GetVariableValue(ctx, VarMaskedRequestBody); v != `{"password":"******"}` {
+		t.Fatalf("unexpected masked request body: %s", v)
+	}
+	if v, _ := variable.GetVariableValue(ctx, VarMaskedResponseBody); v != variable.ValueNotFound {
+		t.Fatalf("expected not found for invalid json, but got %s", v)
+	}
+}
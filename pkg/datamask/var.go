@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datamask
+
+import (
+	"context"
+
+	"mosn.io/mosn/pkg/variable"
+)
+
+// The masked bodies can be used in the access log format,
+// the value is "-" if the body is not a valid JSON or the data mask filter is not configured.
+const (
+	VarMaskedRequestBody  = "masked_request_body"
+	VarMaskedResponseBody = "masked_response_body"
+)
+
+var builtinVariables = []variable.Variable{
+	variable.NewBasicVariable(VarMaskedRequestBody, true, maskedBodyGetter, nil, 0),
+	variable.NewBasicVariable(VarMaskedResponseBody, false, maskedBodyGetter, nil, 0),
+}
+
+func init() {
+	for idx := range builtinVariables {
+		variable.RegisterVariable(builtinVariables[idx])
+	}
+}
+
+func maskedBodyGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	body, ok := MaskedBodyFromContext(ctx, data.(bool))
+	if !ok {
+		return variable.ValueNotFound, nil
+	}
+	return body, nil
+}
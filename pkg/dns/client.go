@@ -0,0 +1,229 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// maxUDPSize is the max size of the udp responses without edns
+const maxUDPSize = 512
+
+var errMismatchedID = errors.New("mismatched dns response id")
+
+// client queries the nameservers, the nameservers are tried in turn until one of them responds
+type client struct {
+	servers []string
+	timeout time.Duration
+	next    uint32
+}
+
+func newClient(servers []string, timeout time.Duration) (*client, error) {
+	c := &client{timeout: timeout}
+	for _, s := range servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			// the default dns port
+			if ip := net.ParseIP(s); ip == nil {
+				return nil, fmt.Errorf("invalid nameserver %s: %v", s, err)
+			}
+			s = net.JoinHostPort(s, "53")
+		}
+		c.servers = append(c.servers, s)
+	}
+	return c, nil
+}
+
+// lookup queries the addresses of the family, the ttl is the min ttl of the records
+func (c *client) lookup(ctx context.Context, name, family string) ([]net.IP, time.Duration, error) {
+	switch family {
+	case v2.DNSLookupV4Only:
+		return c.query(ctx, name, dnsmessage.TypeA)
+	case v2.DNSLookupV6Only:
+		return c.query(ctx, name, dnsmessage.TypeAAAA)
+	case v2.DNSLookupV4Preferred:
+		ips, ttl, err := c.query(ctx, name, dnsmessage.TypeA)
+		if err != nil || len(ips) > 0 {
+			return ips, ttl, err
+		}
+		return c.query(ctx, name, dnsmessage.TypeAAAA)
+	default:
+		v4, ttl4, err := c.query(ctx, name, dnsmessage.TypeA)
+		if err != nil {
+			return nil, 0, err
+		}
+		v6, ttl6, err := c.query(ctx, name, dnsmessage.TypeAAAA)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(v4) == 0 {
+			return v6, ttl6, nil
+		}
+		if len(v6) > 0 && ttl6 < ttl4 {
+			ttl4 = ttl6
+		}
+		return append(v4, v6...), ttl4, nil
+	}
+}
+
+// query tries the nameservers in turn, a name not exists is not retried
+func (c *client) query(ctx context.Context, name string, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	fqdn, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	start := atomic.AddUint32(&c.next, 1)
+	var lastErr error
+	for i := 0; i < len(c.servers); i++ {
+		server := c.servers[(int(start)+i)%len(c.servers)]
+		ips, ttl, err := c.exchange(ctx, server, fqdn, qtype)
+		if err == nil {
+			return ips, ttl, nil
+		}
+		if re, ok := err.(*rcodeError); ok && re.rcode == dnsmessage.RCodeNameError {
+			return nil, 0, err
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, 0, fmt.Errorf("%v: %v", ErrNoServer, lastErr)
+}
+
+type rcodeError struct {
+	rcode dnsmessage.RCode
+}
+
+func (e *rcodeError) Error() string {
+	return "dns response: " + e.rcode.String()
+}
+
+// exchange sends the query by udp, and retries by tcp if the response is truncated
+func (c *client) exchange(ctx context.Context, server string, name dnsmessage.Name, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	query, err := msg.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.roundTrip(ctx, "udp", server, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.Header.Truncated {
+		if resp, err = c.roundTrip(ctx, "tcp", server, query); err != nil {
+			return nil, 0, err
+		}
+	}
+	if resp.Header.ID != id {
+		return nil, 0, errMismatchedID
+	}
+	return parseAnswers(resp, qtype)
+}
+
+func (c *client) roundTrip(ctx context.Context, network, server string, query []byte) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	var buf []byte
+	if network == "tcp" {
+		// the tcp messages are prefixed by 2 bytes length
+		req := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(req, uint16(len(query)))
+		copy(req[2:], query)
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, maxUDPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+	resp := &dnsmessage.Message{}
+	if err := resp.Unpack(buf); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseAnswers returns the addresses of the type in the answers, the cname records are followed
+// by the nameserver, so only the address records are used
+func parseAnswers(resp *dnsmessage.Message, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	if resp.Header.RCode != dnsmessage.RCodeSuccess {
+		return nil, 0, &rcodeError{rcode: resp.Header.RCode}
+	}
+	var ips []net.IP
+	var ttl uint32
+	for _, answer := range resp.Answers {
+		if answer.Header.Type != qtype {
+			continue
+		}
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(append([]byte(nil), body.A[:]...)))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(append([]byte(nil), body.AAAA[:]...)))
+		default:
+			continue
+		}
+		if len(ips) == 1 || answer.Header.TTL < ttl {
+			ttl = answer.Header.TTL
+		}
+	}
+	return ips, time.Duration(ttl) * time.Second, nil
+}
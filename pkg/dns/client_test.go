@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// serveDNS starts a udp nameserver answers the A and AAAA queries of the records
+func serveDNS(t *testing.T, records map[string][]net.IP) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, maxUDPSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if err := req.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			q := req.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: req.Header.ID, Response: true},
+				Questions: req.Questions,
+			}
+			ips, ok := records[q.Name.String()]
+			if !ok {
+				resp.Header.RCode = dnsmessage.RCodeNameError
+			}
+			for i, ip := range ips {
+				h := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: uint32(30 + i*10)}
+				if ip4 := ip.To4(); ip4 != nil && q.Type == dnsmessage.TypeA {
+					h.Type = dnsmessage.TypeA
+					r := &dnsmessage.AResource{}
+					copy(r.A[:], ip4)
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: h, Body: r})
+				} else if ip.To4() == nil && q.Type == dnsmessage.TypeAAAA {
+					h.Type = dnsmessage.TypeAAAA
+					r := &dnsmessage.AAAAResource{}
+					copy(r.AAAA[:], ip)
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: h, Body: r})
+				}
+			}
+			b, _ := resp.Pack()
+			conn.WriteTo(b, addr)
+		}
+	}()
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func TestClientLookup(t *testing.T) {
+	server, stop := serveDNS(t, map[string][]net.IP{
+		"example.com.":    {net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"), net.ParseIP("fd00::1")},
+		"v6.example.com.": {net.ParseIP("fd00::2")},
+	})
+	defer stop()
+	c, err := newClient([]string{server}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips, ttl, err := c.lookup(context.Background(), "example.com", v2.DNSLookupV4Only)
+	if err != nil || len(ips) != 2 || !ips[1].Equal(net.ParseIP("10.0.0.2")) {
+		t.Fatalf("unexpected result: %v, %v", ips, err)
+	}
+	if ttl != 30*time.Second {
+		t.Fatalf("expected the min ttl of the records, got %v", ttl)
+	}
+	ips, _, err = c.lookup(context.Background(), "example.com", v2.DNSLookupAll)
+	if err != nil || len(ips) != 3 {
+		t.Fatalf("unexpected result: %v, %v", ips, err)
+	}
+	ips, _, err = c.lookup(context.Background(), "v6.example.com", v2.DNSLookupV4Preferred)
+	if err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("fd00::2")) {
+		t.Fatalf("expected fallback to ipv6, got %v, %v", ips, err)
+	}
+	if _, _, err = c.lookup(context.Background(), "unknown.example.com", v2.DNSLookupV4Only); err == nil {
+		t.Fatal("expected name error")
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	server, stop := serveDNS(t, map[string][]net.IP{
+		"example.com.": {net.ParseIP("10.0.0.1")},
+	})
+	defer stop()
+	// nothing listens on the closed address
+	dead, _ := net.ListenPacket("udp", "127.0.0.1:0")
+	deadAddr := dead.LocalAddr().String()
+	dead.Close()
+	c, err := newClient([]string{deadAddr, server}, 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if ips, _, err := c.lookup(context.Background(), "example.com", v2.DNSLookupV4Only); err != nil || len(ips) != 1 {
+			t.Fatalf("unexpected result: %v, %v", ips, err)
+		}
+	}
+	c, _ = newClient([]string{deadAddr}, 200*time.Millisecond)
+	if _, _, err := c.lookup(context.Background(), "example.com", v2.DNSLookupV4Only); err == nil {
+		t.Fatal("expected all nameservers failed")
+	}
+}
+
+func TestNewClientDefaultPort(t *testing.T) {
+	c, err := newClient([]string{"8.8.8.8", "[::1]:5353"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.servers[0] != "8.8.8.8:53" || c.servers[1] != "[::1]:5353" {
+		t.Fatalf("unexpected servers: %v", c.servers)
+	}
+}
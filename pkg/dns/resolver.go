@@ -0,0 +1,311 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dns resolves the upstream host names with the configured nameservers,
+// and caches the addresses by the ttl of the records.
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+const (
+	defaultMinTTL      = 5 * time.Second
+	defaultNegativeTTL = 5 * time.Second
+	defaultTimeout     = 5 * time.Second
+)
+
+var (
+	ErrNoAddress = errors.New("no address found")
+	ErrNoServer  = errors.New("all nameservers failed")
+)
+
+var timeNow = time.Now
+
+// lookupFunc looks up the addresses of the name, returns the addresses and the ttl of them.
+// zero ttl means the ttl is unknown
+type lookupFunc func(ctx context.Context, name string) ([]net.IP, time.Duration, error)
+
+type cacheEntry struct {
+	ips    []net.IP
+	err    error
+	expire time.Time
+}
+
+// call is an in-flight lookup, the concurrent lookups of the same name wait for it
+type call struct {
+	wg    sync.WaitGroup
+	entry *cacheEntry
+}
+
+type resolverStats struct {
+	query        gometrics.Counter
+	queryFailure gometrics.Counter
+	queryTimeout gometrics.Counter
+	queryLatency gometrics.Histogram
+	cacheHit     gometrics.Counter
+	cacheMiss    gometrics.Counter
+}
+
+// Resolver resolves the names and caches the results
+type Resolver struct {
+	family      string
+	minTTL      time.Duration
+	negativeTTL time.Duration
+	lookup      lookupFunc
+	stats       resolverStats
+
+	mux       sync.Mutex
+	cache     map[string]*cacheEntry
+	inflight  map[string]*call
+	lastPurge time.Time
+}
+
+// NewResolver creates a resolver, the name is used in the stats
+func NewResolver(name string, config *v2.DNSConfig) (*Resolver, error) {
+	if config == nil {
+		config = &v2.DNSConfig{}
+	}
+	m := metrics.NewDNSStats(name)
+	r := &Resolver{
+		family:      config.LookupFamily,
+		minTTL:      config.MinTTL.Duration,
+		negativeTTL: config.NegativeTTL.Duration,
+		cache:       make(map[string]*cacheEntry),
+		inflight:    make(map[string]*call),
+		stats: resolverStats{
+			query:        m.Counter(metrics.DNSQuery),
+			queryFailure: m.Counter(metrics.DNSQueryFailure),
+			queryTimeout: m.Counter(metrics.DNSQueryTimeout),
+			queryLatency: m.Histogram(metrics.DNSQueryLatency),
+			cacheHit:     m.Counter(metrics.DNSCacheHit),
+			cacheMiss:    m.Counter(metrics.DNSCacheMiss),
+		},
+	}
+	switch r.family {
+	case "":
+		r.family = v2.DNSLookupV4Preferred
+	case v2.DNSLookupV4Only, v2.DNSLookupV6Only, v2.DNSLookupV4Preferred, v2.DNSLookupAll:
+	default:
+		return nil, fmt.Errorf("unknown dns lookup family: %s", config.LookupFamily)
+	}
+	if r.minTTL <= 0 {
+		r.minTTL = defaultMinTTL
+	}
+	if r.negativeTTL <= 0 {
+		r.negativeTTL = defaultNegativeTTL
+	}
+	timeout := config.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if len(config.Nameservers) == 0 {
+		r.lookup = r.systemLookup
+		return r, nil
+	}
+	c, err := newClient(config.Nameservers, timeout)
+	if err != nil {
+		return nil, err
+	}
+	r.lookup = func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		return c.lookup(ctx, name, r.family)
+	}
+	return r, nil
+}
+
+// Resolve returns the addresses of the host, the ip literal is returned directly
+func (r *Resolver) Resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(host, "."))
+	if name == "" {
+		return nil, ErrNoAddress
+	}
+	now := timeNow()
+	r.mux.Lock()
+	if e, ok := r.cache[name]; ok && now.Before(e.expire) {
+		r.mux.Unlock()
+		r.stats.cacheHit.Inc(1)
+		return e.ips, e.err
+	}
+	r.stats.cacheMiss.Inc(1)
+	if c, ok := r.inflight[name]; ok {
+		r.mux.Unlock()
+		c.wg.Wait()
+		return c.entry.ips, c.entry.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	r.inflight[name] = c
+	r.mux.Unlock()
+
+	c.entry = r.query(ctx, name)
+
+	r.mux.Lock()
+	r.purgeLocked()
+	r.cache[name] = c.entry
+	delete(r.inflight, name)
+	r.mux.Unlock()
+	c.wg.Done()
+	return c.entry.ips, c.entry.err
+}
+
+// purgeLocked removes the expired entries, at most once in the min ttl,
+// so the names looked up once do not stay in the cache
+func (r *Resolver) purgeLocked() {
+	now := timeNow()
+	if now.Sub(r.lastPurge) < r.minTTL {
+		return
+	}
+	r.lastPurge = now
+	for name, e := range r.cache {
+		if !now.Before(e.expire) {
+			delete(r.cache, name)
+		}
+	}
+}
+
+// ResolveTCPAddr resolves the "host:port" address, the first address resolved is used
+func (r *Resolver) ResolveTCPAddr(ctx context.Context, address string) (*net.TCPAddr, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		if port, err = lookupPort(portStr); err != nil {
+			return nil, err
+		}
+	}
+	ips, err := r.Resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return &net.TCPAddr{IP: ips[0], Port: int(port)}, nil
+}
+
+func lookupPort(service string) (uint64, error) {
+	port, err := net.LookupPort("tcp", service)
+	return uint64(port), err
+}
+
+func (r *Resolver) query(ctx context.Context, name string) *cacheEntry {
+	start := timeNow()
+	r.stats.query.Inc(1)
+	ips, ttl, err := r.lookup(ctx, name)
+	r.stats.queryLatency.Update(int64(timeNow().Sub(start)))
+	if err == nil && len(ips) == 0 {
+		err = ErrNoAddress
+	}
+	if err != nil {
+		r.stats.queryFailure.Inc(1)
+		if isTimeout(err) {
+			r.stats.queryTimeout.Inc(1)
+		}
+		log.DefaultLogger.Errorf("[dns] resolve %s failed: %v", name, err)
+		return &cacheEntry{err: err, expire: timeNow().Add(r.negativeTTL)}
+	}
+	if ttl < r.minTTL {
+		ttl = r.minTTL
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[dns] resolve %s: %v, ttl %v", name, ips, ttl)
+	}
+	return &cacheEntry{ips: ips, expire: timeNow().Add(ttl)}
+}
+
+// systemLookup looks up the name by the system resolver, the ttl is unknown
+func (r *Resolver) systemLookup(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	var v4, v6 []net.IP
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			v4 = append(v4, addr.IP)
+		} else {
+			v6 = append(v6, addr.IP)
+		}
+	}
+	switch r.family {
+	case v2.DNSLookupV4Only:
+		return v4, 0, nil
+	case v2.DNSLookupV6Only:
+		return v6, 0, nil
+	case v2.DNSLookupV4Preferred:
+		if len(v4) > 0 {
+			return v4, 0, nil
+		}
+		return v6, 0, nil
+	default:
+		return append(v4, v6...), 0, nil
+	}
+}
+
+func isTimeout(err error) bool {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return true
+	}
+	return err == context.DeadlineExceeded
+}
+
+var (
+	defaultMux      sync.RWMutex
+	defaultResolver *Resolver
+)
+
+// Init replaces the default resolver with the config
+func Init(config *v2.DNSConfig) error {
+	r, err := NewResolver("default", config)
+	if err != nil {
+		return err
+	}
+	defaultMux.Lock()
+	defaultResolver = r
+	defaultMux.Unlock()
+	return nil
+}
+
+// Default returns the default resolver, it uses the system resolver if Init is not called
+func Default() *Resolver {
+	defaultMux.RLock()
+	r := defaultResolver
+	defaultMux.RUnlock()
+	if r != nil {
+		return r
+	}
+	defaultMux.Lock()
+	defer defaultMux.Unlock()
+	if defaultResolver == nil {
+		defaultResolver, _ = NewResolver("default", nil)
+	}
+	return defaultResolver
+}
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func newTestResolver(t *testing.T, lookup lookupFunc) *Resolver {
+	r, err := NewResolver("test", &v2.DNSConfig{
+		MinTTL:      api.DurationConfig{Duration: time.Second},
+		NegativeTTL: api.DurationConfig{Duration: 500 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.lookup = lookup
+	return r
+}
+
+func TestResolveCache(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+	var count int32
+	r := newTestResolver(t, func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		atomic.AddInt32(&count, 1)
+		return []net.IP{net.ParseIP("127.0.0.1")}, 10 * time.Second, nil
+	})
+	for i := 0; i < 3; i++ {
+		ips, err := r.Resolve(context.Background(), "Example.com.")
+		if err != nil || len(ips) != 1 || !ips[0].Equal(net.ParseIP("127.0.0.1")) {
+			t.Fatalf("unexpected result: %v, %v", ips, err)
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 lookup, got %d", count)
+	}
+	// expired by the record ttl
+	now = now.Add(10 * time.Second)
+	if _, err := r.Resolve(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 lookups, got %d", count)
+	}
+	if r.stats.cacheHit.Count() != 2 || r.stats.cacheMiss.Count() != 2 {
+		t.Fatalf("unexpected cache stats: hit %d, miss %d", r.stats.cacheHit.Count(), r.stats.cacheMiss.Count())
+	}
+}
+
+func TestResolveMinTTL(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+	var count int32
+	r := newTestResolver(t, func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		atomic.AddInt32(&count, 1)
+		return []net.IP{net.ParseIP("127.0.0.1")}, 0, nil
+	})
+	r.Resolve(context.Background(), "example.com")
+	now = now.Add(500 * time.Millisecond)
+	r.Resolve(context.Background(), "example.com")
+	if count != 1 {
+		t.Fatalf("expected cached by min ttl, got %d lookups", count)
+	}
+	now = now.Add(500 * time.Millisecond)
+	r.Resolve(context.Background(), "example.com")
+	if count != 2 {
+		t.Fatalf("expected expired by min ttl, got %d lookups", count)
+	}
+}
+
+func TestResolveNegativeCache(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+	var count int32
+	lookupErr := errors.New("lookup failed")
+	r := newTestResolver(t, func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		atomic.AddInt32(&count, 1)
+		return nil, 0, lookupErr
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := r.Resolve(context.Background(), "example.com"); err != lookupErr {
+			t.Fatalf("expected lookup error, got %v", err)
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected failure cached, got %d lookups", count)
+	}
+	now = now.Add(500 * time.Millisecond)
+	r.Resolve(context.Background(), "example.com")
+	if count != 2 {
+		t.Fatalf("expected failure expired by negative ttl, got %d lookups", count)
+	}
+	if r.stats.queryFailure.Count() != 2 {
+		t.Fatalf("unexpected failure stats: %d", r.stats.queryFailure.Count())
+	}
+}
+
+func TestResolveNoAddress(t *testing.T) {
+	r := newTestResolver(t, func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		return nil, 0, nil
+	})
+	if _, err := r.Resolve(context.Background(), "example.com"); err != ErrNoAddress {
+		t.Fatalf("expected no address, got %v", err)
+	}
+}
+
+func TestResolveInflight(t *testing.T) {
+	var count int32
+	block := make(chan struct{})
+	r := newTestResolver(t, func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		atomic.AddInt32(&count, 1)
+		<-block
+		return []net.IP{net.ParseIP("127.0.0.1")}, time.Minute, nil
+	})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ips, err := r.Resolve(context.Background(), "example.com"); err != nil || len(ips) != 1 {
+				t.Errorf("unexpected result: %v, %v", ips, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(block)
+	wg.Wait()
+	if count != 1 {
+		t.Fatalf("expected concurrent lookups coalesced, got %d lookups", count)
+	}
+}
+
+func TestResolveIPLiteral(t *testing.T) {
+	r := newTestResolver(t, func(ctx context.Context, name string) ([]net.IP, time.Duration, error) {
+		t.Fatalf("unexpected lookup %s", name)
+		return nil, 0, nil
+	})
+	addr, err := r.ResolveTCPAddr(context.Background(), "[::1]:8080")
+	if err != nil || !addr.IP.Equal(net.ParseIP("::1")) || addr.Port != 8080 {
+		t.Fatalf("unexpected address: %v, %v", addr, err)
+	}
+}
+
+func TestSystemLookupFamily(t *testing.T) {
+	for family, expected := range map[string]string{
+		v2.DNSLookupV4Only:      "127.0.0.1",
+		v2.DNSLookupV4Preferred: "127.0.0.1",
+		v2.DNSLookupV6Only:      "::1",
+	} {
+		r, err := NewResolver("test", &v2.DNSConfig{LookupFamily: family})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ips, _, err := r.systemLookup(context.Background(), "127.0.0.1")
+		if family == v2.DNSLookupV6Only {
+			if len(ips) != 0 {
+				t.Fatalf("%s: unexpected result: %v", family, ips)
+			}
+			continue
+		}
+		if err != nil || len(ips) != 1 || ips[0].String() != expected {
+			t.Fatalf("%s: unexpected result: %v, %v", family, ips, err)
+		}
+	}
+}
+
+func TestNewResolverInvalid(t *testing.T) {
+	if _, err := NewResolver("test", &v2.DNSConfig{LookupFamily: "v5"}); err == nil {
+		t.Fatal("expected unknown family error")
+	}
+	if _, err := NewResolver("test", &v2.DNSConfig{Nameservers: []string{"not a server"}}); err == nil {
+		t.Fatal("expected invalid nameserver error")
+	}
+}
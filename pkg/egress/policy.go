@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package egress checks the external destinations of the egress traffic by the policy,
+// and audits the denied attempts.
+package egress
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	pkglog "mosn.io/pkg/log"
+)
+
+const egressType = "egress"
+
+// defaultRule is the rule name in the decision if no rule matches
+const defaultRule = "default"
+
+type rule struct {
+	name      string
+	allow     bool
+	hosts     []string
+	ports     map[uint32]bool
+	protocols map[string]bool
+}
+
+func (r *rule) match(host string, port uint32, protocol string) bool {
+	if len(r.ports) > 0 && !r.ports[port] {
+		return false
+	}
+	if len(r.protocols) > 0 && !r.protocols[strings.ToLower(protocol)] {
+		return false
+	}
+	if len(r.hosts) == 0 {
+		return true
+	}
+	for _, pattern := range r.hosts {
+		if MatchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchHost matches the host by the domain pattern, the "*." prefix matches the sub domains,
+// and "*" matches any host. The host should be in lower case
+func MatchHost(pattern, host string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	default:
+		return host == pattern
+	}
+}
+
+// Policy decides whether the destinations are allowed
+type Policy struct {
+	rules        []*rule
+	defaultAllow bool
+	status       int
+	audit        *pkglog.Logger
+
+	allowed gometrics.Counter
+	denied  gometrics.Counter
+}
+
+// NewPolicy creates a policy, the name is used in the stats
+func NewPolicy(name string, config *v2.EgressPolicy) (*Policy, error) {
+	m, _ := metrics.NewMetrics(egressType, map[string]string{"policy": name})
+	p := &Policy{
+		status:  config.Status,
+		allowed: m.Counter("allowed"),
+		denied:  m.Counter("denied"),
+	}
+	if p.status == 0 {
+		p.status = http.StatusForbidden
+	}
+	allow, err := parseAction(config.DefaultAction, v2.EgressActionDeny)
+	if err != nil {
+		return nil, err
+	}
+	p.defaultAllow = allow
+	for i, rc := range config.Rules {
+		r := &rule{
+			name: rc.Name,
+		}
+		if r.name == "" {
+			r.name = fmt.Sprintf("rule_%d", i)
+		}
+		if r.allow, err = parseAction(rc.Action, ""); err != nil {
+			return nil, fmt.Errorf("egress rule %s: %v", r.name, err)
+		}
+		for _, h := range rc.Hosts {
+			r.hosts = append(r.hosts, strings.ToLower(h))
+		}
+		if len(rc.Ports) > 0 {
+			r.ports = make(map[uint32]bool, len(rc.Ports))
+			for _, port := range rc.Ports {
+				r.ports[port] = true
+			}
+		}
+		if len(rc.Protocols) > 0 {
+			r.protocols = make(map[string]bool, len(rc.Protocols))
+			for _, proto := range rc.Protocols {
+				r.protocols[strings.ToLower(proto)] = true
+			}
+		}
+		p.rules = append(p.rules, r)
+	}
+	if config.AuditLog != "" {
+		if p.audit, err = log.GetOrCreateLogger(config.AuditLog, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func parseAction(action, defaultAction string) (bool, error) {
+	if action == "" {
+		action = defaultAction
+	}
+	switch action {
+	case v2.EgressActionAllow:
+		return true, nil
+	case v2.EgressActionDeny:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown egress action: %q", action)
+	}
+}
+
+// Status returns the response status code of the denied requests
+func (p *Policy) Status() int {
+	return p.status
+}
+
+// Check returns whether the destination is allowed, and the name of the rule decides it
+func (p *Policy) Check(host string, port uint32, protocol string) (bool, string) {
+	host = strings.ToLower(host)
+	allowed, name := p.defaultAllow, defaultRule
+	for _, r := range p.rules {
+		if r.match(host, port, protocol) {
+			allowed, name = r.allow, r.name
+			break
+		}
+	}
+	if allowed {
+		p.allowed.Inc(1)
+	} else {
+		p.denied.Inc(1)
+	}
+	return allowed, name
+}
+
+// auditRecord is a line of the audit log
+type auditRecord struct {
+	Time       string `json:"time"`
+	Host       string `json:"host"`
+	Port       uint32 `json:"port"`
+	Protocol   string `json:"protocol,omitempty"`
+	Rule       string `json:"rule"`
+	Downstream string `json:"downstream,omitempty"`
+}
+
+// Audit logs the denied attempt, the downstream is the remote address of the downstream connection
+func (p *Policy) Audit(host string, port uint32, protocol, rule, downstream string) {
+	if p.audit == nil {
+		log.DefaultLogger.Warnf("[egress] [audit] denied by rule %s, destination: %s:%d, protocol: %s, downstream: %s", rule, host, port, protocol, downstream)
+		return
+	}
+	data, err := json.Marshal(&auditRecord{
+		Time:       time.Now().Format(time.RFC3339),
+		Host:       host,
+		Port:       port,
+		Protocol:   protocol,
+		Rule:       rule,
+		Downstream: downstream,
+	})
+	if err != nil {
+		return
+	}
+	p.audit.Println(string(data))
+}
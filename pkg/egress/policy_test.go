@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package egress
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p, err := NewPolicy("test_check", &v2.EgressPolicy{
+		Rules: []v2.EgressRule{
+			{Name: "deny_internal", Action: v2.EgressActionDeny, Hosts: []string{"*.internal.example.com"}},
+			{Name: "allow_https", Action: v2.EgressActionAllow, Hosts: []string{"*.example.com"}, Ports: []uint32{443}},
+			{Name: "allow_api", Action: v2.EgressActionAllow, Hosts: []string{"API.example.org"}, Protocols: []string{"Http1"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		host     string
+		port     uint32
+		protocol string
+		allowed  bool
+		rule     string
+	}{
+		{"www.example.com", 443, "Http1", true, "allow_https"},
+		{"www.example.com", 80, "Http1", false, defaultRule},
+		{"db.internal.example.com", 443, "Http1", false, "deny_internal"},
+		{"api.example.org", 80, "Http1", true, "allow_api"},
+		{"api.example.org", 80, "Http2", false, defaultRule},
+		{"other.com", 443, "Http1", false, defaultRule},
+	} {
+		allowed, rule := p.Check(tc.host, tc.port, tc.protocol)
+		if allowed != tc.allowed || rule != tc.rule {
+			t.Fatalf("%s:%d %s: expected %v by %s, got %v by %s", tc.host, tc.port, tc.protocol, tc.allowed, tc.rule, allowed, rule)
+		}
+	}
+	if p.allowed.Count() != 2 || p.denied.Count() != 4 {
+		t.Fatalf("unexpected stats: allowed %d, denied %d", p.allowed.Count(), p.denied.Count())
+	}
+	if p.Status() != 403 {
+		t.Fatalf("unexpected status: %d", p.Status())
+	}
+}
+
+func TestPolicyDefaultAllow(t *testing.T) {
+	p, err := NewPolicy("test_default", &v2.EgressPolicy{
+		DefaultAction: v2.EgressActionAllow,
+		Status:        451,
+		Rules: []v2.EgressRule{
+			{Action: v2.EgressActionDeny, Ports: []uint32{25}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed, _ := p.Check("example.com", 80, ""); !allowed {
+		t.Fatal("expected allowed by default")
+	}
+	if allowed, rule := p.Check("example.com", 25, ""); allowed || rule != "rule_0" {
+		t.Fatalf("expected denied by rule_0, got %v by %s", allowed, rule)
+	}
+	if p.Status() != 451 {
+		t.Fatalf("unexpected status: %d", p.Status())
+	}
+}
+
+func TestPolicyInvalidAction(t *testing.T) {
+	if _, err := NewPolicy("test_invalid", &v2.EgressPolicy{DefaultAction: "reject"}); err == nil {
+		t.Fatal("expected invalid default action")
+	}
+	if _, err := NewPolicy("test_invalid", &v2.EgressPolicy{Rules: []v2.EgressRule{{Hosts: []string{"*"}}}}); err == nil {
+		t.Fatal("expected rule action required")
+	}
+}
+
+func TestPolicyAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "egress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "audit.log")
+	p, err := NewPolicy("test_audit", &v2.EgressPolicy{AuditLog: output})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Audit("example.com", 443, "Http1", defaultRule, "127.0.0.1:12345")
+	var record auditRecord
+	for i := 0; i < 50; i++ {
+		time.Sleep(20 * time.Millisecond)
+		data, _ := ioutil.ReadFile(output)
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &record); err != nil {
+				t.Fatalf("invalid audit record %s: %v", data, err)
+			}
+			break
+		}
+	}
+	if record.Host != "example.com" || record.Port != 443 || record.Rule != defaultRule || record.Downstream != "127.0.0.1:12345" {
+		t.Fatalf("unexpected audit record: %+v", record)
+	}
+}
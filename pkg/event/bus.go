@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package event is an internal event bus, which publishes the cluster, host, listener and config changes.
+// The filters, stats sinks and plugins can subscribe the events they are interested in.
+package event
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/utils"
+)
+
+// Type is the type of an event
+type Type string
+
+// the events published by mosn
+const (
+	ClusterAdded      Type = "cluster_added"
+	ClusterUpdated    Type = "cluster_updated"
+	ClusterRemoved    Type = "cluster_removed"
+	HostsUpdated      Type = "hosts_updated"
+	HostHealthChanged Type = "host_health_changed"
+	ListenerDrain     Type = "listener_drain"
+	ConfigUpdated     Type = "config_updated"
+)
+
+const (
+	// recentEventsSize is the number of the recent events that kept for tailing
+	recentEventsSize = 1024
+	// subscriberQueueSize is the number of the events that a subscriber can be delayed,
+	// more events are dropped
+	subscriberQueueSize = 256
+)
+
+// Event is a change notification
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// Source is the name of the changed object, such as the cluster name or the listener name
+	Source string            `json:"source"`
+	Detail map[string]string `json:"detail,omitempty"`
+}
+
+// Handler handles the subscribed events, the events are delivered in the publish order
+type Handler func(e Event)
+
+type subscriber struct {
+	types   map[Type]bool // nil means all types
+	handler Handler
+	queue   chan Event
+	stop    chan struct{}
+}
+
+func (s *subscriber) interested(t Type) bool {
+	return s.types == nil || s.types[t]
+}
+
+func (s *subscriber) run() {
+	utils.GoWithRecover(func() {
+		for {
+			select {
+			case e := <-s.queue:
+				s.handler(e)
+			case <-s.stop:
+				return
+			}
+		}
+	}, func(r interface{}) {
+		// the handler panics, keeps the subscriber running
+		s.run()
+	})
+}
+
+type bus struct {
+	mutex       sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	recent      []Event // ring buffer
+	next        int
+	full        bool
+}
+
+var defaultBus = newBus()
+
+func newBus() *bus {
+	return &bus{
+		subscribers: make(map[*subscriber]struct{}),
+		recent:      make([]Event, recentEventsSize),
+	}
+}
+
+func (b *bus) subscribe(handler Handler, types []Type) func() {
+	s := &subscriber{
+		handler: handler,
+		queue:   make(chan Event, subscriberQueueSize),
+		stop:    make(chan struct{}),
+	}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	b.mutex.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mutex.Unlock()
+	s.run()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, s)
+			b.mutex.Unlock()
+			close(s.stop)
+		})
+	}
+}
+
+func (b *bus) publish(e Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.recent[b.next] = e
+	b.next++
+	if b.next == len(b.recent) {
+		b.next = 0
+		b.full = true
+	}
+	for s := range b.subscribers {
+		if !s.interested(e.Type) {
+			continue
+		}
+		select {
+		case s.queue <- e:
+		default:
+			log.DefaultLogger.Warnf("[event] subscriber is too slow, drop event %s of %s", e.Type, e.Source)
+		}
+	}
+}
+
+func (b *bus) tail(n int) []Event {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	size := b.next
+	if b.full {
+		size = len(b.recent)
+	}
+	if n <= 0 || n > size {
+		n = size
+	}
+	events := make([]Event, 0, n)
+	for i := n; i > 0; i-- {
+		idx := (b.next - i + len(b.recent)) % len(b.recent)
+		events = append(events, b.recent[idx])
+	}
+	return events
+}
+
+// Subscribe subscribes the events of the types, all types are subscribed if no type is specified.
+// the handler is called in a dedicated goroutine, the returned function cancels the subscription.
+func Subscribe(handler Handler, types ...Type) (unsubscribe func()) {
+	return defaultBus.subscribe(handler, types)
+}
+
+// Publish publishes an event
+func Publish(t Type, source string, detail map[string]string) {
+	defaultBus.publish(Event{
+		Type:   t,
+		Time:   time.Now(),
+		Source: source,
+		Detail: detail,
+	})
+}
+
+// Recent returns the recent n events in the publish order, all the kept events are returned if n is not positive
+func Recent(n int) []Event {
+	return defaultBus.tail(n)
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package event
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	b := newBus()
+	all := make(chan Event, 10)
+	clusters := make(chan Event, 10)
+	unsubscribe := b.subscribe(func(e Event) {
+		all <- e
+	}, nil)
+	b.subscribe(func(e Event) {
+		clusters <- e
+	}, []Type{ClusterAdded, ClusterRemoved})
+
+	b.publish(Event{Type: ClusterAdded, Source: "c1"})
+	b.publish(Event{Type: HostHealthChanged, Source: "c1"})
+	b.publish(Event{Type: ClusterRemoved, Source: "c1"})
+	expect := func(ch chan Event, types ...Type) {
+		for _, typ := range types {
+			select {
+			case e := <-ch:
+				if e.Type != typ {
+					t.Fatalf("expected event %s, but got %s", typ, e.Type)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("wait event %s timeout", typ)
+			}
+		}
+		select {
+		case e := <-ch:
+			t.Fatalf("unexpected event: %v", e)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	expect(all, ClusterAdded, HostHealthChanged, ClusterRemoved)
+	expect(clusters, ClusterAdded, ClusterRemoved)
+	// unsubscribe
+	unsubscribe()
+	unsubscribe()
+	b.publish(Event{Type: ClusterAdded, Source: "c2"})
+	expect(all)
+	expect(clusters, ClusterAdded)
+}
+
+func TestSubscriberPanic(t *testing.T) {
+	b := newBus()
+	ch := make(chan Event, 10)
+	b.subscribe(func(e Event) {
+		if e.Source == "panic" {
+			panic("test panic")
+		}
+		ch <- e
+	}, nil)
+	b.publish(Event{Type: ClusterAdded, Source: "panic"})
+	b.publish(Event{Type: ClusterAdded, Source: "c1"})
+	select {
+	case e := <-ch:
+		if e.Source != "c1" {
+			t.Fatalf("unexpected event: %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber is not recovered from panic")
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	b := newBus()
+	block := make(chan struct{})
+	defer close(block)
+	b.subscribe(func(e Event) {
+		<-block
+	}, nil)
+	// the publish never blocks
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberQueueSize*2; i++ {
+			b.publish(Event{Type: ClusterAdded, Source: strconv.Itoa(i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish is blocked by slow subscriber")
+	}
+}
+
+func TestRecent(t *testing.T) {
+	b := newBus()
+	if events := b.tail(10); len(events) != 0 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	for i := 0; i < recentEventsSize+10; i++ {
+		b.publish(Event{Type: ClusterAdded, Source: strconv.Itoa(i)})
+	}
+	events := b.tail(3)
+	if len(events) != 3 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	for i, e := range events {
+		if e.Source != strconv.Itoa(recentEventsSize+7+i) {
+			t.Errorf("unexpected event #%d: %v", i, e)
+		}
+	}
+	if events := b.tail(0); len(events) != recentEventsSize || events[0].Source != "10" {
+		t.Fatalf("unexpected events: %d", len(events))
+	}
+	// the default bus
+	Publish(ConfigUpdated, "mosn.json", map[string]string{"reason": "reload"})
+	if events := Recent(1); len(events) != 1 || events[0].Type != ConfigUpdated || events[0].Detail["reason"] != "reload" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
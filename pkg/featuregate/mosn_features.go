@@ -21,6 +21,9 @@ const (
 	XdsMtlsEnable      Feature = "XdsMtlsEnable"
 	PayLoadLimitEnable Feature = "PayLoadLimitEnable"
 	MultiTenantMode    Feature = "MultiTenantMode"
+	// XdsHostWeightEnable uses the load balancing weights of the hosts pushed by eds,
+	// the hosts in the range of the host weight have the same weight if it is disabled
+	XdsHostWeightEnable Feature = "XdsHostWeightEnable"
 )
 
 func init() {
@@ -36,5 +39,9 @@ func init() {
 		DefaultValue:    false,
 		PreReleaseValue: Alpha,
 	})
+	AddFeatureSpec(XdsHostWeightEnable, &BaseFeatureSpec{
+		DefaultValue:    false,
+		PreReleaseValue: Alpha,
+	})
 
 }
@@ -20,7 +20,6 @@ package originaldst
 import (
 	"errors"
 	"fmt"
-	__tl "log"
 	"net"
 	"syscall"
 
@@ -54,7 +53,9 @@ func (filter *originalDst) OnAccept(cb types.ListenerFilterCallbacks) api.Filter
 	}
 	ips := fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])
 
-	__tl.Print("ips:", ips)
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[originaldst] original dst %s:%d", ips, port)
+	}
 
 	cb.SetOriginalAddr(ips, port)
 
@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+// streamFilterMatcher matches the request by the stream filter match config
+type streamFilterMatcher struct {
+	path     string
+	prefix   string
+	methods  []string
+	headers  []headerMatcher
+	metadata map[string]string
+}
+
+type headerMatcher struct {
+	name  string
+	value string
+	regex *regexp.Regexp
+}
+
+func newStreamFilterMatcher(cfg *v2.StreamFilterMatch) (*streamFilterMatcher, error) {
+	m := &streamFilterMatcher{
+		path:     cfg.Path,
+		prefix:   cfg.Prefix,
+		methods:  cfg.Methods,
+		metadata: cfg.Metadata,
+	}
+	for _, h := range cfg.Headers {
+		if h.Name == "" {
+			return nil, fmt.Errorf("header matcher without a name")
+		}
+		hm := headerMatcher{
+			name:  strings.ToLower(h.Name),
+			value: h.Value,
+		}
+		if h.Regex {
+			regex, err := regexp.Compile(h.Value)
+			if err != nil {
+				return nil, fmt.Errorf("header matcher %s: %v", h.Name, err)
+			}
+			hm.regex = regex
+		}
+		m.headers = append(m.headers, hm)
+	}
+	return m, nil
+}
+
+// Match returns true if the request matches all the conditions
+func (m *streamFilterMatcher) Match(ctx context.Context, headers api.HeaderMap) bool {
+	if m.path != "" || m.prefix != "" {
+		path, _ := getHeader(headers, protocol.MosnHeaderPathKey)
+		if m.path != "" && path != m.path {
+			return false
+		}
+		if m.prefix != "" && !strings.HasPrefix(path, m.prefix) {
+			return false
+		}
+	}
+	if len(m.methods) > 0 {
+		method, _ := getHeader(headers, protocol.MosnHeaderMethod)
+		matched := false
+		for _, expected := range m.methods {
+			if strings.EqualFold(method, expected) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, h := range m.headers {
+		value, ok := getHeader(headers, h.name)
+		if !ok {
+			return false
+		}
+		if h.regex != nil {
+			if !h.regex.MatchString(value) {
+				return false
+			}
+		} else if h.value != "" && value != h.value {
+			return false
+		}
+	}
+	for name, expected := range m.metadata {
+		value, err := variable.GetVariableValue(ctx, name)
+		if err != nil || value != expected {
+			return false
+		}
+	}
+	return true
+}
+
+func getHeader(headers api.HeaderMap, key string) (string, bool) {
+	if headers == nil {
+		return "", false
+	}
+	return headers.Get(key)
+}
+
+// ValidateStreamFilterMatch checks the match set in the filter config
+func ValidateStreamFilterMatch(config v2.Filter) error {
+	if config.Match == nil {
+		return nil
+	}
+	_, err := newStreamFilterMatcher(config.Match)
+	return err
+}
+
+// WithStreamFilterMatch gates the filters created by the factory with the match config,
+// the filters are skipped if the request is not matched
+func WithStreamFilterMatch(cfg *v2.StreamFilterMatch, factory api.StreamFilterChainFactory) (api.StreamFilterChainFactory, error) {
+	matcher, err := newStreamFilterMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &matchStreamFilterChainFactory{
+		StreamFilterChainFactory: factory,
+		matcher:                  matcher,
+	}, nil
+}
+
+type matchStreamFilterChainFactory struct {
+	api.StreamFilterChainFactory
+	matcher *streamFilterMatcher
+}
+
+// CreateFilterChain adds a filter matching the request before the route, which runs before the gated filters,
+// so the request is matched once for all the filters of the factory
+func (f *matchStreamFilterChainFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	m := &streamFilterMatch{
+		matcher: f.matcher,
+	}
+	callbacks.AddStreamReceiverFilter(m, api.BeforeRoute)
+	f.StreamFilterChainFactory.CreateFilterChain(context, &matchStreamFilterChainFactoryCallbacks{
+		StreamFilterChainFactoryCallbacks: callbacks,
+		match:                             m,
+	})
+}
+
+// streamFilterMatch records the match result of a stream
+type streamFilterMatch struct {
+	matcher *streamFilterMatcher
+	matched bool
+}
+
+func (m *streamFilterMatch) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	m.matched = m.matcher.Match(ctx, headers)
+	return api.StreamFilterContinue
+}
+
+func (m *streamFilterMatch) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {}
+
+func (m *streamFilterMatch) OnDestroy() {}
+
+type matchStreamFilterChainFactoryCallbacks struct {
+	api.StreamFilterChainFactoryCallbacks
+	match *streamFilterMatch
+}
+
+func (cb *matchStreamFilterChainFactoryCallbacks) AddStreamReceiverFilter(filter api.StreamReceiverFilter, p api.FilterPhase) {
+	cb.StreamFilterChainFactoryCallbacks.AddStreamReceiverFilter(&matchStreamReceiverFilter{
+		StreamReceiverFilter: filter,
+		match:                cb.match,
+	}, p)
+}
+
+func (cb *matchStreamFilterChainFactoryCallbacks) AddStreamSenderFilter(filter api.StreamSenderFilter) {
+	cb.StreamFilterChainFactoryCallbacks.AddStreamSenderFilter(&matchStreamSenderFilter{
+		StreamSenderFilter: filter,
+		match:              cb.match,
+	})
+}
+
+type matchStreamReceiverFilter struct {
+	api.StreamReceiverFilter
+	match *streamFilterMatch
+}
+
+func (f *matchStreamReceiverFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if !f.match.matched {
+		return api.StreamFilterContinue
+	}
+	return f.StreamReceiverFilter.OnReceive(ctx, headers, buf, trailers)
+}
+
+type matchStreamSenderFilter struct {
+	api.StreamSenderFilter
+	match *streamFilterMatch
+}
+
+func (f *matchStreamSenderFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if !f.match.matched {
+		return api.StreamFilterContinue
+	}
+	return f.StreamSenderFilter.Append(ctx, headers, buf, trailers)
+}
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+func TestStreamFilterMatcher(t *testing.T) {
+	name := "test_filter_match_variable"
+	if err := variable.RegisterVariable(variable.NewIndexedVariable(name, nil, nil, variable.BasicSetter, 0)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := variable.NewVariableContext(context.Background())
+	if err := variable.SetVariableValue(ctx, name, "gray"); err != nil {
+		t.Fatal(err)
+	}
+	headers := protocol.CommonHeader{
+		protocol.MosnHeaderPathKey: "/api/v1/users",
+		protocol.MosnHeaderMethod:  "POST",
+		"x-user":                   "alice",
+	}
+	for i, tc := range []struct {
+		match    v2.StreamFilterMatch
+		expected bool
+	}{
+		{v2.StreamFilterMatch{}, true},
+		{v2.StreamFilterMatch{Prefix: "/api/"}, true},
+		{v2.StreamFilterMatch{Prefix: "/web/"}, false},
+		{v2.StreamFilterMatch{Path: "/api/v1/users"}, true},
+		{v2.StreamFilterMatch{Path: "/api/v1"}, false},
+		{v2.StreamFilterMatch{Methods: []string{"get", "post"}}, true},
+		{v2.StreamFilterMatch{Methods: []string{"GET"}}, false},
+		{v2.StreamFilterMatch{Headers: []v2.HeaderMatcher{{Name: "X-User", Value: "alice"}}}, true},
+		{v2.StreamFilterMatch{Headers: []v2.HeaderMatcher{{Name: "x-user", Value: "^b", Regex: true}}}, false},
+		{v2.StreamFilterMatch{Headers: []v2.HeaderMatcher{{Name: "x-user"}}}, true},
+		{v2.StreamFilterMatch{Headers: []v2.HeaderMatcher{{Name: "x-missing"}}}, false},
+		{v2.StreamFilterMatch{Metadata: map[string]string{name: "gray"}}, true},
+		{v2.StreamFilterMatch{Metadata: map[string]string{name: "stable"}}, false},
+		{v2.StreamFilterMatch{Metadata: map[string]string{"test_undefined_variable": "gray"}}, false},
+		{v2.StreamFilterMatch{Prefix: "/api/", Methods: []string{"GET"}}, false},
+	} {
+		m, err := newStreamFilterMatcher(&tc.match)
+		if err != nil {
+			t.Fatalf("#%d create matcher failed: %v", i, err)
+		}
+		if matched := m.Match(ctx, headers); matched != tc.expected {
+			t.Errorf("#%d expected matched %v, got %v", i, tc.expected, matched)
+		}
+	}
+}
+
+func TestValidateStreamFilterMatch(t *testing.T) {
+	if err := ValidateStreamFilterMatch(v2.Filter{Type: "test"}); err != nil {
+		t.Errorf("no match should be valid: %v", err)
+	}
+	for _, match := range []*v2.StreamFilterMatch{
+		{Headers: []v2.HeaderMatcher{{Value: "value"}}},
+		{Headers: []v2.HeaderMatcher{{Name: "x-user", Value: "(", Regex: true}}},
+	} {
+		if err := ValidateStreamFilterMatch(v2.Filter{Type: "test", Match: match}); err == nil {
+			t.Errorf("expected an error of the match %+v", match)
+		}
+	}
+}
+
+type countStreamFilter struct {
+	receives int
+	appends  int
+}
+
+func (f *countStreamFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	f.receives++
+	return api.StreamFilterContinue
+}
+
+func (f *countStreamFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	f.appends++
+	return api.StreamFilterContinue
+}
+
+func (f *countStreamFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {}
+
+func (f *countStreamFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {}
+
+func (f *countStreamFilter) OnDestroy() {}
+
+type countStreamFilterFactory struct {
+	filter *countStreamFilter
+}
+
+func (f *countStreamFilterFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	callbacks.AddStreamReceiverFilter(f.filter, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(f.filter)
+}
+
+type mockFilterChainCallbacks struct {
+	receivers []api.StreamReceiverFilter
+	senders   []api.StreamSenderFilter
+}
+
+func (cb *mockFilterChainCallbacks) AddStreamReceiverFilter(filter api.StreamReceiverFilter, p api.FilterPhase) {
+	cb.receivers = append(cb.receivers, filter)
+}
+
+func (cb *mockFilterChainCallbacks) AddStreamSenderFilter(filter api.StreamSenderFilter) {
+	cb.senders = append(cb.senders, filter)
+}
+
+func (cb *mockFilterChainCallbacks) AddStreamAccessLog(accessLog api.AccessLog) {}
+
+func TestWithStreamFilterMatch(t *testing.T) {
+	if _, err := WithStreamFilterMatch(&v2.StreamFilterMatch{
+		Headers: []v2.HeaderMatcher{{Name: "x-user", Value: "(", Regex: true}},
+	}, &countStreamFilterFactory{}); err == nil {
+		t.Fatal("expected an error of the invalid regex")
+	}
+	for _, tc := range []struct {
+		method   string
+		expected int
+	}{
+		{"POST", 1},
+		{"GET", 0},
+	} {
+		filter := &countStreamFilter{}
+		factory, err := WithStreamFilterMatch(&v2.StreamFilterMatch{Methods: []string{"POST"}}, &countStreamFilterFactory{filter: filter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cb := &mockFilterChainCallbacks{}
+		factory.CreateFilterChain(context.Background(), cb)
+		// the match filter and the gated filter
+		if len(cb.receivers) != 2 || len(cb.senders) != 1 {
+			t.Fatalf("unexpected filters: %d receivers, %d senders", len(cb.receivers), len(cb.senders))
+		}
+		headers := protocol.CommonHeader{protocol.MosnHeaderMethod: tc.method}
+		for _, r := range cb.receivers {
+			r.OnReceive(context.Background(), headers, nil, nil)
+		}
+		cb.senders[0].Append(context.Background(), protocol.CommonHeader{}, nil, nil)
+		if filter.receives != tc.expected || filter.appends != tc.expected {
+			t.Errorf("%s: expected the filter is called %d times, got receives %d, appends %d", tc.method, tc.expected, filter.receives, filter.appends)
+		}
+	}
+}
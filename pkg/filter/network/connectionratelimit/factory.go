@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connectionratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	api.RegisterNetwork(v2.CONNECTION_RATE_LIMIT, CreateConnectionRateLimitFactory)
+}
+
+type connectionRateLimitFactory struct {
+	limiter *limiter
+}
+
+func (f *connectionRateLimitFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+	listener, _ := mosnctx.Get(context, types.ContextKeyListenerName).(string)
+	callbacks.AddReadFilter(newRateLimitFilter(f.limiter, metrics.NewConnectionRateLimitStats(listener)))
+}
+
+// CreateConnectionRateLimitFactory creates the connection rate limit filter factory,
+// the connections of the listener share a limiter
+func CreateConnectionRateLimitFactory(conf map[string]interface{}) (api.NetworkFilterChainFactory, error) {
+	cfg, err := ParseConnectionRateLimit(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &connectionRateLimitFactory{
+		limiter: newLimiter(cfg),
+	}, nil
+}
+
+// ParseConnectionRateLimit
+func ParseConnectionRateLimit(cfg map[string]interface{}) (*v2.ConnectionRateLimit, error) {
+	limit := &v2.ConnectionRateLimit{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, limit); err != nil {
+		return nil, err
+	}
+	if limit.ConnectionsPerSecond <= 0 {
+		return nil, fmt.Errorf("[config] connection rate limit connections_per_second must be positive")
+	}
+	if limit.Burst < 0 || limit.MaxSources < 0 {
+		return nil, fmt.Errorf("[config] connection rate limit burst and max_sources must not be negative")
+	}
+	return limit, nil
+}
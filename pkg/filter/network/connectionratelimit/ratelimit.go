@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connectionratelimit
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultMaxSources = 10000
+
+// bucket is the token bucket of a source ip
+type bucket struct {
+	source string
+	tokens float64
+	last   time.Time
+}
+
+// limiter keeps the token buckets of the recently seen source ips in a lru list
+type limiter struct {
+	rate       float64
+	burst      float64
+	maxSources int
+
+	mutex   sync.Mutex
+	lru     *list.List // the front is the most recently seen
+	buckets map[string]*list.Element
+}
+
+func newLimiter(cfg *v2.ConnectionRateLimit) *limiter {
+	l := &limiter{
+		rate:       cfg.ConnectionsPerSecond,
+		burst:      float64(cfg.Burst),
+		maxSources: cfg.MaxSources,
+		lru:        list.New(),
+		buckets:    make(map[string]*list.Element),
+	}
+	if l.burst <= 0 {
+		l.burst = math.Max(1, l.rate)
+	}
+	if l.maxSources <= 0 {
+		l.maxSources = defaultMaxSources
+	}
+	return l
+}
+
+// allow takes a token from the bucket of the source, returns false if the bucket is empty.
+// a new source starts with a full bucket, the least recently seen source is evicted if the lru is full
+func (l *limiter) allow(source string, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var b *bucket
+	if e, ok := l.buckets[source]; ok {
+		l.lru.MoveToFront(e)
+		b = e.Value.(*bucket)
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	} else {
+		if l.lru.Len() >= l.maxSources {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*bucket).source)
+		}
+		b = &bucket{source: source, tokens: l.burst, last: now}
+		l.buckets[source] = l.lru.PushFront(b)
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimitFilter closes the new connection if the source ip exceeds the rate limit
+type rateLimitFilter struct {
+	limiter       *limiter
+	stats         types.Metrics
+	readCallbacks api.ReadFilterCallbacks
+}
+
+func newRateLimitFilter(l *limiter, stats types.Metrics) api.ReadFilter {
+	return &rateLimitFilter{
+		limiter: l,
+		stats:   stats,
+	}
+}
+
+func (f *rateLimitFilter) OnData(buffer types.IoBuffer) api.FilterStatus {
+	return api.Continue
+}
+
+// OnNewConnection is called before the connection starts reading, so the rejected connection
+// is closed before the tls handshake
+func (f *rateLimitFilter) OnNewConnection() api.FilterStatus {
+	conn := f.readCallbacks.Connection()
+	source := sourceIP(conn.RemoteAddr())
+	if f.limiter.allow(source, time.Now()) {
+		f.stats.Counter(metrics.ConnectionRateLimitAllowed).Inc(1)
+		return api.Continue
+	}
+	f.stats.Counter(metrics.ConnectionRateLimitRejected).Inc(1)
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[connection rate limit] source %s exceeds the rate limit, close the connection %d", source, conn.ID())
+	}
+	conn.Close(api.NoFlush, api.LocalClose)
+	return api.Stop
+}
+
+func (f *rateLimitFilter) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	f.readCallbacks = cb
+}
+
+func sourceIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connectionratelimit
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(&v2.ConnectionRateLimit{
+		ConnectionsPerSecond: 2,
+		Burst:                3,
+		MaxSources:           2,
+	})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !l.allow("10.0.0.1", now) {
+			t.Fatalf("#%d connection should be allowed in the burst", i)
+		}
+	}
+	if l.allow("10.0.0.1", now) {
+		t.Fatal("connection exceeds the burst should be rejected")
+	}
+	// 2 tokens per second
+	if !l.allow("10.0.0.1", now.Add(500*time.Millisecond)) {
+		t.Fatal("connection should be allowed after the bucket refilled")
+	}
+	if l.allow("10.0.0.1", now.Add(500*time.Millisecond)) {
+		t.Fatal("connection should be rejected")
+	}
+	// the other sources are limited separately
+	if !l.allow("10.0.0.2", now) {
+		t.Fatal("connection of another source should be allowed")
+	}
+	// the least recently seen source 10.0.0.1 is evicted, and starts with a full bucket
+	l.allow("10.0.0.3", now)
+	if len(l.buckets) != 2 || l.lru.Len() != 2 {
+		t.Fatalf("unexpected tracked sources: %d", len(l.buckets))
+	}
+	if _, ok := l.buckets["10.0.0.1"]; ok {
+		t.Fatal("the least recently seen source should be evicted")
+	}
+	if !l.allow("10.0.0.1", now.Add(500*time.Millisecond)) {
+		t.Fatal("the evicted source should start with a full bucket")
+	}
+}
+
+func TestLimiterDefaults(t *testing.T) {
+	l := newLimiter(&v2.ConnectionRateLimit{ConnectionsPerSecond: 0.5})
+	if l.burst != 1 || l.maxSources != defaultMaxSources {
+		t.Fatalf("unexpected defaults, burst: %v, max sources: %d", l.burst, l.maxSources)
+	}
+}
+
+type mockConnection struct {
+	api.Connection
+	addr   net.Addr
+	closed bool
+}
+
+func (c *mockConnection) ID() uint64 {
+	return 1
+}
+
+func (c *mockConnection) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func (c *mockConnection) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	c.closed = true
+	return nil
+}
+
+type mockReadFilterCallbacks struct {
+	api.ReadFilterCallbacks
+	conn api.Connection
+}
+
+func (cb *mockReadFilterCallbacks) Connection() api.Connection {
+	return cb.conn
+}
+
+func TestRateLimitFilter(t *testing.T) {
+	l := newLimiter(&v2.ConnectionRateLimit{ConnectionsPerSecond: 1})
+	stats := metrics.NewConnectionRateLimitStats("test_connection_rate_limit")
+	addr := &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 12345}
+	newConn := func() (*mockConnection, api.FilterStatus) {
+		conn := &mockConnection{addr: addr}
+		f := newRateLimitFilter(l, stats)
+		f.InitializeReadFilterCallbacks(&mockReadFilterCallbacks{conn: conn})
+		return conn, f.OnNewConnection()
+	}
+	if conn, status := newConn(); status != api.Continue || conn.closed {
+		t.Fatal("the first connection should be allowed")
+	}
+	// the port is different, but the source ip is the same
+	addr = &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 12346}
+	if conn, status := newConn(); status != api.Stop || !conn.closed {
+		t.Fatal("the second connection should be rejected and closed")
+	}
+	if stats.Counter(metrics.ConnectionRateLimitAllowed).Count() != 1 ||
+		stats.Counter(metrics.ConnectionRateLimitRejected).Count() != 1 {
+		t.Fatal("unexpected stats")
+	}
+}
+
+func TestParseConnectionRateLimit(t *testing.T) {
+	cfg, err := ParseConnectionRateLimit(map[string]interface{}{
+		"connections_per_second": 10,
+		"burst":                  20,
+		"max_sources":            100,
+	})
+	if err != nil || cfg.ConnectionsPerSecond != 10 || cfg.Burst != 20 || cfg.MaxSources != 100 {
+		t.Fatalf("parse config failed: %v, %+v", err, cfg)
+	}
+	for _, conf := range []map[string]interface{}{
+		{},
+		{"connections_per_second": -1},
+		{"connections_per_second": 1, "burst": -1},
+		{"connections_per_second": "invalid"},
+	} {
+		if _, err := ParseConnectionRateLimit(conf); err == nil {
+			t.Errorf("config %v expected an error", conf)
+		}
+	}
+	if _, err := CreateConnectionRateLimitFactory(map[string]interface{}{"connections_per_second": 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSourceIP(t *testing.T) {
+	for _, tc := range []struct {
+		addr     net.Addr
+		expected string
+	}{
+		{nil, ""},
+		{&net.TCPAddr{IP: net.ParseIP("::1"), Port: 80}, "::1"},
+		{&net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 80}, "10.0.0.1"},
+		{&net.UnixAddr{Name: "/tmp/mosn.sock", Net: "unix"}, "/tmp/mosn.sock"},
+	} {
+		if ip := sourceIP(tc.addr); ip != tc.expected {
+			t.Errorf("expected %s, but got %s", tc.expected, ip)
+		}
+	}
+}
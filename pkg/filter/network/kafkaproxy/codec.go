@@ -0,0 +1,456 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaproxy
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// the api keys inspected by the proxy
+const (
+	apiProduce         int16 = 0
+	apiFetch           int16 = 1
+	apiMetadata        int16 = 3
+	apiFindCoordinator int16 = 10
+)
+
+var apiNames = map[int16]string{
+	0:  "produce",
+	1:  "fetch",
+	2:  "list_offsets",
+	3:  "metadata",
+	8:  "offset_commit",
+	9:  "offset_fetch",
+	10: "find_coordinator",
+	11: "join_group",
+	12: "heartbeat",
+	13: "leave_group",
+	14: "sync_group",
+	15: "describe_groups",
+	16: "list_groups",
+	17: "sasl_handshake",
+	18: "api_versions",
+	19: "create_topics",
+	20: "delete_topics",
+	22: "init_producer_id",
+	36: "sasl_authenticate",
+}
+
+// apiName returns the name of the api key used in the stats
+func apiName(apiKey int16) string {
+	if name, ok := apiNames[apiKey]; ok {
+		return name
+	}
+	return "api_" + strconv.Itoa(int(apiKey))
+}
+
+// the first flexible versions, which use the compact strings, compact arrays and tagged fields
+var flexibleVersions = map[int16]int16{
+	apiProduce:         9,
+	apiFetch:           12,
+	apiMetadata:        9,
+	apiFindCoordinator: 3,
+}
+
+// the max versions the proxy can parse, the produce and fetch requests of the higher versions
+// refer to the topics by ids instead of names
+var maxParsedVersions = map[int16]int16{
+	apiProduce:         12,
+	apiFetch:           12,
+	apiMetadata:        12,
+	apiFindCoordinator: 4,
+}
+
+func isFlexible(apiKey, version int16) bool {
+	v, ok := flexibleVersions[apiKey]
+	return ok && version >= v
+}
+
+const maxFrameSize = 100 * 1024 * 1024
+
+var (
+	errIncomplete         = errors.New("kafka: incomplete data")
+	errMalformed          = errors.New("kafka: malformed data")
+	errFrameTooLarge      = errors.New("kafka: frame too large")
+	errUnsupportedVersion = errors.New("kafka: unsupported api version")
+	errDenied             = errors.New("kafka: request denied")
+)
+
+// readFrame returns the first size delimited frame in the data, the frame contains the size
+func readFrame(data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, errIncomplete
+	}
+	size := int(int32(binary.BigEndian.Uint32(data)))
+	if size < 0 || size > maxFrameSize {
+		return nil, errFrameTooLarge
+	}
+	if len(data) < 4+size {
+		return nil, errIncomplete
+	}
+	return data[:4+size], nil
+}
+
+// requestHeader is the header of a request frame
+type requestHeader struct {
+	apiKey        int16
+	apiVersion    int16
+	correlationID int32
+	clientID      string
+}
+
+// decodeRequestHeader decodes the request header, and returns the decoder positioned at the request body
+func decodeRequestHeader(frame []byte) (*requestHeader, *decoder, error) {
+	d := &decoder{data: frame, off: 4}
+	h := &requestHeader{
+		apiKey:        d.int16(),
+		apiVersion:    d.int16(),
+		correlationID: d.int32(),
+	}
+	// the client id is never a compact string
+	h.clientID, _ = d.nullableString()
+	d.flexible = isFlexible(h.apiKey, h.apiVersion)
+	d.tags()
+	return h, d, d.err
+}
+
+// responseCorrelationID returns the correlation id of the response frame
+func responseCorrelationID(frame []byte) (int32, error) {
+	if len(frame) < 8 {
+		return 0, errMalformed
+	}
+	return int32(binary.BigEndian.Uint32(frame[4:])), nil
+}
+
+// produceRequest returns the acks and the topics of a produce request
+func produceRequest(h *requestHeader, d *decoder) (int16, []string, error) {
+	if h.apiVersion > maxParsedVersions[apiProduce] {
+		return 0, nil, errUnsupportedVersion
+	}
+	if h.apiVersion >= 3 {
+		d.nullableString() // transactional id
+	}
+	acks := d.int16()
+	d.int32() // timeout
+	var topics []string
+	for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+		topics = append(topics, d.string())
+		for j, m := 0, d.arrayLen(); j < m && d.err == nil; j++ {
+			d.int32() // partition
+			d.bytes() // records
+			d.tags()
+		}
+		d.tags()
+	}
+	return acks, topics, d.err
+}
+
+// fetchRequest returns the topics of a fetch request
+func fetchRequest(h *requestHeader, d *decoder) ([]string, error) {
+	v := h.apiVersion
+	if v > maxParsedVersions[apiFetch] {
+		return nil, errUnsupportedVersion
+	}
+	// replica id, max wait ms, min bytes
+	d.skip(12)
+	if v >= 3 {
+		d.skip(4) // max bytes
+	}
+	if v >= 4 {
+		d.skip(1) // isolation level
+	}
+	if v >= 7 {
+		d.skip(8) // session id and epoch
+	}
+	partitionSize := 4 + 8 + 4 // partition, fetch offset, partition max bytes
+	if v >= 9 {
+		partitionSize += 4 // current leader epoch
+	}
+	if v >= 12 {
+		partitionSize += 4 // last fetched epoch
+	}
+	if v >= 5 {
+		partitionSize += 8 // log start offset
+	}
+	var topics []string
+	for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+		topics = append(topics, d.string())
+		for j, m := 0, d.arrayLen(); j < m && d.err == nil; j++ {
+			d.skip(partitionSize)
+			d.tags()
+		}
+		d.tags()
+	}
+	return topics, d.err
+}
+
+// rewriteMetadataResponse rewrites the broker addresses of a metadata response
+func rewriteMetadataResponse(frame []byte, version int16, rewrite func(host string, port int32) (string, int32)) ([]byte, error) {
+	if version > maxParsedVersions[apiMetadata] {
+		return nil, errUnsupportedVersion
+	}
+	flexible := isFlexible(apiMetadata, version)
+	d := &decoder{data: frame, off: 8, flexible: flexible}
+	d.tags() // response header
+	if version >= 3 {
+		d.int32() // throttle time
+	}
+	start := d.off
+	e := &encoder{flexible: flexible}
+	n := d.arrayLen()
+	e.putArrayLen(n)
+	for i := 0; i < n && d.err == nil; i++ {
+		e.putInt32(d.int32()) // node id
+		host, port := rewrite(d.string(), d.int32())
+		e.putString(host)
+		e.putInt32(port)
+		if version >= 1 {
+			rack, ok := d.nullableString()
+			e.putNullableString(rack, ok)
+		}
+		tagStart := d.off
+		d.tags()
+		if d.err == nil {
+			e.putRaw(d.data[tagStart:d.off])
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return rebuildFrame(frame, start, d.off, e.buf), nil
+}
+
+// rewriteFindCoordinatorResponse rewrites the coordinator addresses of a find coordinator response
+func rewriteFindCoordinatorResponse(frame []byte, version int16, rewrite func(host string, port int32) (string, int32)) ([]byte, error) {
+	if version > maxParsedVersions[apiFindCoordinator] {
+		return nil, errUnsupportedVersion
+	}
+	flexible := isFlexible(apiFindCoordinator, version)
+	d := &decoder{data: frame, off: 8, flexible: flexible}
+	d.tags() // response header
+	if version >= 1 {
+		d.int32() // throttle time
+	}
+	if version < 4 {
+		d.int16() // error code
+		if version >= 1 {
+			d.nullableString() // error message
+		}
+		d.int32() // node id
+		start := d.off
+		host, port := rewrite(d.string(), d.int32())
+		if d.err != nil {
+			return nil, d.err
+		}
+		e := &encoder{flexible: flexible}
+		e.putString(host)
+		e.putInt32(port)
+		return rebuildFrame(frame, start, d.off, e.buf), nil
+	}
+
+	start := d.off
+	e := &encoder{flexible: flexible}
+	n := d.arrayLen()
+	e.putArrayLen(n)
+	for i := 0; i < n && d.err == nil; i++ {
+		e.putString(d.string()) // key
+		e.putInt32(d.int32())   // node id
+		host, port := rewrite(d.string(), d.int32())
+		e.putString(host)
+		e.putInt32(port)
+		e.putInt16(d.int16()) // error code
+		msg, ok := d.nullableString()
+		e.putNullableString(msg, ok)
+		tagStart := d.off
+		d.tags()
+		if d.err == nil {
+			e.putRaw(d.data[tagStart:d.off])
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return rebuildFrame(frame, start, d.off, e.buf), nil
+}
+
+// rebuildFrame replaces the frame data in [start, end) with the replacement, and updates the frame size
+func rebuildFrame(frame []byte, start, end int, replacement []byte) []byte {
+	out := make([]byte, 0, len(frame)-(end-start)+len(replacement))
+	out = append(out, frame[:start]...)
+	out = append(out, replacement...)
+	out = append(out, frame[end:]...)
+	binary.BigEndian.PutUint32(out, uint32(len(out)-4))
+	return out
+}
+
+// decoder decodes the kafka primitive types, the first error is kept and the later reads are ignored
+type decoder struct {
+	data     []byte
+	off      int
+	flexible bool
+	err      error
+}
+
+func (d *decoder) need(n int) bool {
+	if d.err != nil {
+		return false
+	}
+	if n < 0 || d.off+n > len(d.data) {
+		d.err = errMalformed
+		return false
+	}
+	return true
+}
+
+func (d *decoder) skip(n int) {
+	if d.need(n) {
+		d.off += n
+	}
+}
+
+func (d *decoder) int16() int16 {
+	if !d.need(2) {
+		return 0
+	}
+	v := int16(binary.BigEndian.Uint16(d.data[d.off:]))
+	d.off += 2
+	return v
+}
+
+func (d *decoder) int32() int32 {
+	if !d.need(4) {
+		return 0
+	}
+	v := int32(binary.BigEndian.Uint32(d.data[d.off:]))
+	d.off += 4
+	return v
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data[d.off:])
+	if n <= 0 {
+		d.err = errMalformed
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+// length reads the length of a string, bytes or array, -1 means null
+func (d *decoder) length(classic func() int) int {
+	if d.flexible {
+		return int(d.uvarint()) - 1
+	}
+	return classic()
+}
+
+func (d *decoder) nullableString() (string, bool) {
+	n := d.length(func() int { return int(d.int16()) })
+	if n < 0 || !d.need(n) {
+		return "", false
+	}
+	s := string(d.data[d.off : d.off+n])
+	d.off += n
+	return s, true
+}
+
+func (d *decoder) string() string {
+	s, _ := d.nullableString()
+	return s
+}
+
+func (d *decoder) bytes() {
+	if n := d.length(func() int { return int(d.int32()) }); n > 0 {
+		d.skip(n)
+	}
+}
+
+func (d *decoder) arrayLen() int {
+	n := d.length(func() int { return int(d.int32()) })
+	if n > len(d.data) {
+		// an array can not have more elements than the bytes
+		d.err = errMalformed
+		return 0
+	}
+	return n
+}
+
+// tags skips the tagged fields of the flexible versions
+func (d *decoder) tags() {
+	if !d.flexible {
+		return
+	}
+	for i, n := 0, int(d.uvarint()); i < n && d.err == nil; i++ {
+		d.uvarint() // tag
+		d.skip(int(d.uvarint()))
+	}
+}
+
+// encoder encodes the kafka primitive types
+type encoder struct {
+	buf      []byte
+	flexible bool
+}
+
+func (e *encoder) putInt16(v int16) {
+	e.buf = append(e.buf, byte(v>>8), byte(v))
+}
+
+func (e *encoder) putInt32(v int32) {
+	e.buf = append(e.buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (e *encoder) putUvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	e.buf = append(e.buf, b[:n]...)
+}
+
+func (e *encoder) putLength(n int, classic func(int)) {
+	if e.flexible {
+		e.putUvarint(uint64(n + 1))
+		return
+	}
+	classic(n)
+}
+
+func (e *encoder) putNullableString(s string, ok bool) {
+	if !ok {
+		e.putLength(-1, func(n int) { e.putInt16(int16(n)) })
+		return
+	}
+	e.putLength(len(s), func(n int) { e.putInt16(int16(n)) })
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) putString(s string) {
+	e.putNullableString(s, true)
+}
+
+func (e *encoder) putArrayLen(n int) {
+	e.putLength(n, func(n int) { e.putInt32(int32(n)) })
+}
+
+func (e *encoder) putRaw(b []byte) {
+	e.buf = append(e.buf, b...)
+}
@@ -0,0 +1,253 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaproxy
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// frameOf prepends the size to the frame data
+func frameOf(data []byte) []byte {
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	return append(frame, data...)
+}
+
+func requestFrame(apiKey, version int16, correlationID int32, body func(e *encoder)) []byte {
+	e := &encoder{}
+	e.putInt16(apiKey)
+	e.putInt16(version)
+	e.putInt32(correlationID)
+	e.putString("client")
+	e.flexible = isFlexible(apiKey, version)
+	if e.flexible {
+		e.putUvarint(0) // tagged fields
+	}
+	body(e)
+	return frameOf(e.buf)
+}
+
+func TestReadFrame(t *testing.T) {
+	frame := frameOf([]byte{1, 2, 3})
+	data := append(append([]byte(nil), frame...), 0, 0)
+	f, err := readFrame(data)
+	if err != nil || !reflect.DeepEqual(f, frame) {
+		t.Errorf("unexpected frame %v, error: %v", f, err)
+	}
+	if _, err := readFrame(data[len(frame):]); err != errIncomplete {
+		t.Errorf("expected incomplete, but got %v", err)
+	}
+	if _, err := readFrame([]byte{0xff, 0xff, 0xff, 0xff}); err != errFrameTooLarge {
+		t.Errorf("expected frame too large, but got %v", err)
+	}
+}
+
+func TestProduceRequest(t *testing.T) {
+	for _, version := range []int16{2, 3, 9} {
+		frame := requestFrame(apiProduce, version, 7, func(e *encoder) {
+			if version >= 3 {
+				e.putNullableString("", false) // transactional id
+			}
+			e.putInt16(1)    // acks
+			e.putInt32(1000) // timeout
+			e.putArrayLen(2)
+			for _, topic := range []string{"orders", "payments"} {
+				e.putString(topic)
+				e.putArrayLen(1)
+				e.putInt32(0) // partition
+				e.putLength(3, func(n int) { e.putInt32(int32(n)) })
+				e.putRaw([]byte("abc"))
+				if e.flexible {
+					e.putUvarint(0)
+					e.putUvarint(0)
+				}
+			}
+			if e.flexible {
+				e.putUvarint(0)
+			}
+		})
+		h, d, err := decodeRequestHeader(frame)
+		if err != nil || h.apiKey != apiProduce || h.apiVersion != version || h.correlationID != 7 || h.clientID != "client" {
+			t.Fatalf("unexpected header %+v, error: %v", h, err)
+		}
+		acks, topics, err := produceRequest(h, d)
+		if err != nil || acks != 1 || !reflect.DeepEqual(topics, []string{"orders", "payments"}) {
+			t.Errorf("version %d unexpected produce request: acks %d, topics %v, error: %v", version, acks, topics, err)
+		}
+	}
+
+	h := &requestHeader{apiKey: apiProduce, apiVersion: 13}
+	if _, _, err := produceRequest(h, &decoder{}); err != errUnsupportedVersion {
+		t.Errorf("expected unsupported version, but got %v", err)
+	}
+}
+
+func TestFetchRequest(t *testing.T) {
+	for _, version := range []int16{0, 4, 11, 12} {
+		frame := requestFrame(apiFetch, version, 8, func(e *encoder) {
+			e.putInt32(-1)  // replica id
+			e.putInt32(500) // max wait
+			e.putInt32(1)   // min bytes
+			if version >= 3 {
+				e.putInt32(1024)
+			}
+			if version >= 4 {
+				e.buf = append(e.buf, 0)
+			}
+			if version >= 7 {
+				e.putInt32(0)
+				e.putInt32(-1)
+			}
+			e.putArrayLen(1)
+			e.putString("orders")
+			e.putArrayLen(2)
+			for i := 0; i < 2; i++ {
+				size := 16
+				if version >= 9 {
+					size += 4
+				}
+				if version >= 12 {
+					size += 4
+				}
+				if version >= 5 {
+					size += 8
+				}
+				e.putRaw(make([]byte, size))
+				if e.flexible {
+					e.putUvarint(0)
+				}
+			}
+			if e.flexible {
+				e.putUvarint(0)
+			}
+		})
+		h, d, err := decodeRequestHeader(frame)
+		if err != nil {
+			t.Fatalf("decode header failed: %v", err)
+		}
+		topics, err := fetchRequest(h, d)
+		if err != nil || !reflect.DeepEqual(topics, []string{"orders"}) {
+			t.Errorf("version %d unexpected fetch topics %v, error: %v", version, topics, err)
+		}
+	}
+
+	// truncated request
+	frame := requestFrame(apiFetch, 4, 8, func(e *encoder) { e.putInt32(-1) })
+	h, d, _ := decodeRequestHeader(frame)
+	if _, err := fetchRequest(h, d); err != errMalformed {
+		t.Errorf("expected malformed, but got %v", err)
+	}
+}
+
+func metadataResponse(version int16, host string, port int32) []byte {
+	e := &encoder{flexible: isFlexible(apiMetadata, version)}
+	e.putInt32(99) // correlation id
+	if e.flexible {
+		e.putUvarint(0)
+	}
+	if version >= 3 {
+		e.putInt32(0) // throttle time
+	}
+	e.putArrayLen(1)
+	e.putInt32(1) // node id
+	e.putString(host)
+	e.putInt32(port)
+	if version >= 1 {
+		e.putString("rack1")
+	}
+	if e.flexible {
+		e.putUvarint(0)
+	}
+	e.putRaw([]byte("rest of the response"))
+	return frameOf(e.buf)
+}
+
+func TestRewriteMetadataResponse(t *testing.T) {
+	rewrite := func(host string, port int32) (string, int32) {
+		if host == "kafka-0.internal" && port == 9092 {
+			return "mosn.example.com", 19092
+		}
+		return host, port
+	}
+	for _, version := range []int16{0, 1, 3, 9, 12} {
+		frame := metadataResponse(version, "kafka-0.internal", 9092)
+		rewritten, err := rewriteMetadataResponse(frame, version, rewrite)
+		if err != nil {
+			t.Fatalf("version %d rewrite failed: %v", version, err)
+		}
+		expected := metadataResponse(version, "mosn.example.com", 19092)
+		if !reflect.DeepEqual(rewritten, expected) {
+			t.Errorf("version %d expected %q, but got %q", version, expected, rewritten)
+		}
+	}
+	if _, err := rewriteMetadataResponse(metadataResponse(1, "a", 1)[:20], 1, rewrite); err != errMalformed {
+		t.Errorf("expected malformed, but got %v", err)
+	}
+}
+
+func findCoordinatorResponse(version int16, host string, port int32) []byte {
+	e := &encoder{flexible: isFlexible(apiFindCoordinator, version)}
+	e.putInt32(100) // correlation id
+	if e.flexible {
+		e.putUvarint(0)
+	}
+	if version >= 1 {
+		e.putInt32(0) // throttle time
+	}
+	if version < 4 {
+		e.putInt16(0)
+		if version >= 1 {
+			e.putNullableString("", false)
+		}
+		e.putInt32(1)
+		e.putString(host)
+		e.putInt32(port)
+	} else {
+		e.putArrayLen(1)
+		e.putString("group1")
+		e.putInt32(1)
+		e.putString(host)
+		e.putInt32(port)
+		e.putInt16(0)
+		e.putNullableString("", false)
+		e.putUvarint(0)
+	}
+	if e.flexible {
+		e.putUvarint(0)
+	}
+	return frameOf(e.buf)
+}
+
+func TestRewriteFindCoordinatorResponse(t *testing.T) {
+	rewrite := func(host string, port int32) (string, int32) {
+		return "mosn", port + 10000
+	}
+	for _, version := range []int16{0, 1, 3, 4} {
+		frame := findCoordinatorResponse(version, "kafka-1", 9092)
+		rewritten, err := rewriteFindCoordinatorResponse(frame, version, rewrite)
+		if err != nil {
+			t.Fatalf("version %d rewrite failed: %v", version, err)
+		}
+		expected := findCoordinatorResponse(version, "mosn", 19092)
+		if !reflect.DeepEqual(rewritten, expected) {
+			t.Errorf("version %d expected %q, but got %q", version, expected, rewritten)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func init() {
+	api.RegisterNetwork(v2.KAFKA_PROXY, CreateKafkaProxyFactory)
+}
+
+type kafkaProxyFilterConfigFactory struct {
+	config *proxyConfig
+}
+
+func (f *kafkaProxyFilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+	rf := NewProxy(context, f.config)
+	callbacks.AddReadFilter(rf)
+}
+
+func CreateKafkaProxyFactory(conf map[string]interface{}) (api.NetworkFilterChainFactory, error) {
+	p, err := ParseKafkaProxy(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newProxyConfig(p)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaProxyFilterConfigFactory{
+		config: config,
+	}, nil
+}
+
+// ParseKafkaProxy
+func ParseKafkaProxy(cfg map[string]interface{}) (*v2.KafkaProxy, error) {
+	proxy := &v2.KafkaProxy{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, proxy)
+	} else {
+		return nil, fmt.Errorf("[config] config is not a kafka proxy config: %v", err)
+	}
+	if proxy.Cluster == "" {
+		return nil, fmt.Errorf("[config] kafka proxy cluster is required")
+	}
+	return proxy, nil
+}
+
+// listener is a host and port pair
+type listener struct {
+	host string
+	port int32
+}
+
+func parseListener(addr string) (listener, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return listener{}, err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return listener{}, fmt.Errorf("invalid port in %s", addr)
+	}
+	return listener{host: host, port: int32(p)}, nil
+}
@@ -0,0 +1,410 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaproxy
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// apiStats is the per-api stats
+type apiStats struct {
+	total   gometrics.Counter
+	denied  gometrics.Counter
+	latency gometrics.Histogram
+}
+
+type topicACL struct {
+	topic   string
+	prefix  bool
+	produce bool
+	fetch   bool
+}
+
+func (acl *topicACL) match(topic string) bool {
+	if acl.prefix {
+		return strings.HasPrefix(topic, acl.topic)
+	}
+	return topic == acl.topic
+}
+
+// proxyConfig is shared by the proxies of the same filter config
+type proxyConfig struct {
+	statPrefix string
+	cluster    string
+	listeners  map[listener]listener
+	acls       []*topicACL
+
+	stats sync.Map
+}
+
+func newProxyConfig(config *v2.KafkaProxy) (*proxyConfig, error) {
+	pc := &proxyConfig{
+		statPrefix: config.StatPrefix,
+		cluster:    config.Cluster,
+	}
+	if len(config.AdvertisedListeners) > 0 {
+		pc.listeners = make(map[listener]listener, len(config.AdvertisedListeners))
+		for broker, advertised := range config.AdvertisedListeners {
+			from, err := parseListener(broker)
+			if err != nil {
+				return nil, err
+			}
+			to, err := parseListener(advertised)
+			if err != nil {
+				return nil, err
+			}
+			pc.listeners[from] = to
+		}
+	}
+	for _, cfg := range config.TopicACLs {
+		acl := &topicACL{
+			topic:   cfg.Topic,
+			produce: cfg.Produce,
+			fetch:   cfg.Fetch,
+		}
+		if strings.HasSuffix(acl.topic, "*") {
+			acl.topic = strings.TrimSuffix(acl.topic, "*")
+			acl.prefix = true
+		}
+		pc.acls = append(pc.acls, acl)
+	}
+	return pc, nil
+}
+
+// allowed checks the topics of the produce or fetch request by the acls,
+// the request is denied if the topics can not be parsed
+func (pc *proxyConfig) allowed(apiKey int16, topics []string, err error) bool {
+	if len(pc.acls) == 0 {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	for _, topic := range topics {
+		allowed := false
+		for _, acl := range pc.acls {
+			if acl.match(topic) {
+				allowed = (apiKey == apiProduce && acl.produce) || (apiKey == apiFetch && acl.fetch)
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// rewrite returns the advertised listener of the broker address
+func (pc *proxyConfig) rewrite(host string, port int32) (string, int32) {
+	if to, ok := pc.listeners[listener{host: host, port: port}]; ok {
+		return to.host, to.port
+	}
+	return host, port
+}
+
+func (pc *proxyConfig) apiStats(apiKey int16) *apiStats {
+	if v, ok := pc.stats.Load(apiKey); ok {
+		return v.(*apiStats)
+	}
+	s := metrics.NewKafkaStats(pc.statPrefix, apiName(apiKey))
+	v, _ := pc.stats.LoadOrStore(apiKey, &apiStats{
+		total:   s.Counter(metrics.KafkaRequestTotal),
+		denied:  s.Counter(metrics.KafkaRequestDenied),
+		latency: s.Histogram(metrics.KafkaRequestLatency),
+	})
+	return v.(*apiStats)
+}
+
+// pendingRequest is a request waiting for the response
+type pendingRequest struct {
+	apiKey     int16
+	apiVersion int16
+	start      time.Time
+}
+
+// ReadFilter
+type proxy struct {
+	config             *proxyConfig
+	clusterManager     types.ClusterManager
+	readCallbacks      api.ReadFilterCallbacks
+	upstreamConnection types.ClientConnection
+
+	mux     sync.Mutex
+	pending map[int32]*pendingRequest
+}
+
+func NewProxy(ctx context.Context, config *proxyConfig) api.ReadFilter {
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		pending:        make(map[int32]*pendingRequest),
+	}
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(&downstreamCallbacks{proxy: p})
+	// the downstream is read after the upstream connection is connected
+	p.readCallbacks.Connection().SetReadDisable(true)
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	return p.initializeUpstreamConnection()
+}
+
+func (p *proxy) initializeUpstreamConnection() api.FilterStatus {
+	clusterSnapshot, ok := p.clusterManager.GetClusterSnapshot(context.Background(), p.config.cluster)
+	if !ok {
+		log.DefaultLogger.Errorf("[kafkaproxy] cluster %s not found", p.config.cluster)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+
+	connectionData := p.clusterManager.TCPConnForCluster(&lbContext{conn: p.readCallbacks}, clusterSnapshot)
+	if connectionData.Connection == nil {
+		log.DefaultLogger.Errorf("[kafkaproxy] no healthy upstream in cluster %s", p.config.cluster)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	p.readCallbacks.SetUpstreamHost(connectionData.Host)
+	upstreamConnection := connectionData.Connection
+	upstreamCallbacks := &upstreamCallbacks{proxy: p}
+	upstreamConnection.AddConnectionEventListener(upstreamCallbacks)
+	upstreamConnection.FilterManager().AddReadFilter(upstreamCallbacks)
+	p.upstreamConnection = upstreamConnection
+	if err := upstreamConnection.Connect(); err != nil {
+		log.DefaultLogger.Errorf("[kafkaproxy] connect to %s failed: %v", connectionData.Host.AddressString(), err)
+		p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+		return api.Stop
+	}
+	return api.Continue
+}
+
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := buf.Bytes()
+	consumed := 0
+	for {
+		frame, err := readFrame(data[consumed:])
+		if err == errIncomplete {
+			break
+		}
+		if err == nil && !p.onRequest(frame) {
+			err = errDenied
+		}
+		if err != nil {
+			log.DefaultLogger.Errorf("[kafkaproxy] close the downstream connection %s: %v",
+				p.readCallbacks.Connection().RemoteAddr(), err)
+			buf.Drain(buf.Len())
+			p.readCallbacks.Connection().Close(api.NoFlush, api.LocalClose)
+			return api.Stop
+		}
+		consumed += len(frame)
+	}
+	if consumed > 0 {
+		p.upstreamConnection.Write(buffer.NewIoBufferBytes(append([]byte(nil), data[:consumed]...)))
+		buf.Drain(consumed)
+	}
+	return api.Stop
+}
+
+// onRequest records the request waiting for the response, returns false if the request is denied
+func (p *proxy) onRequest(frame []byte) bool {
+	h, d, err := decodeRequestHeader(frame)
+	if err != nil {
+		return false
+	}
+	stats := p.config.apiStats(h.apiKey)
+	stats.total.Inc(1)
+
+	expectResponse := true
+	switch h.apiKey {
+	case apiProduce:
+		acks, topics, err := produceRequest(h, d)
+		// no response is sent for the produce requests without acks
+		if err == nil && acks == 0 {
+			expectResponse = false
+		}
+		if !p.config.allowed(h.apiKey, topics, err) {
+			stats.denied.Inc(1)
+			log.DefaultLogger.Warnf("[kafkaproxy] produce request of client %s is denied, topics: %v, error: %v", h.clientID, topics, err)
+			return false
+		}
+	case apiFetch:
+		topics, err := fetchRequest(h, d)
+		if !p.config.allowed(h.apiKey, topics, err) {
+			stats.denied.Inc(1)
+			log.DefaultLogger.Warnf("[kafkaproxy] fetch request of client %s is denied, topics: %v, error: %v", h.clientID, topics, err)
+			return false
+		}
+	}
+
+	if expectResponse {
+		p.mux.Lock()
+		p.pending[h.correlationID] = &pendingRequest{
+			apiKey:     h.apiKey,
+			apiVersion: h.apiVersion,
+			start:      time.Now(),
+		}
+		p.mux.Unlock()
+	}
+	return true
+}
+
+func (p *proxy) onUpstreamData(buf buffer.IoBuffer) {
+	data := buf.Bytes()
+	consumed := 0
+	var out []byte
+	for {
+		frame, err := readFrame(data[consumed:])
+		if err == errIncomplete {
+			break
+		}
+		if err != nil {
+			log.DefaultLogger.Errorf("[kafkaproxy] close the upstream connection: %v", err)
+			buf.Drain(buf.Len())
+			p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+			return
+		}
+		consumed += len(frame)
+		out = append(out, p.onResponse(frame)...)
+	}
+	if consumed > 0 {
+		p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(out))
+		buf.Drain(consumed)
+	}
+}
+
+// onResponse records the latency of the request, and rewrites the broker addresses in the response
+func (p *proxy) onResponse(frame []byte) []byte {
+	correlationID, err := responseCorrelationID(frame)
+	if err != nil {
+		return frame
+	}
+	p.mux.Lock()
+	req, ok := p.pending[correlationID]
+	delete(p.pending, correlationID)
+	p.mux.Unlock()
+	if !ok {
+		return frame
+	}
+	p.config.apiStats(req.apiKey).latency.Update(time.Since(req.start).Nanoseconds())
+
+	if len(p.config.listeners) == 0 {
+		return frame
+	}
+	var rewritten []byte
+	switch req.apiKey {
+	case apiMetadata:
+		rewritten, err = rewriteMetadataResponse(frame, req.apiVersion, p.config.rewrite)
+	case apiFindCoordinator:
+		rewritten, err = rewriteFindCoordinatorResponse(frame, req.apiVersion, p.config.rewrite)
+	default:
+		return frame
+	}
+	if err != nil {
+		log.DefaultLogger.Errorf("[kafkaproxy] rewrite %s response version %d failed: %v", apiName(req.apiKey), req.apiVersion, err)
+		return frame
+	}
+	return rewritten
+}
+
+func (p *proxy) onUpstreamEvent(event api.ConnectionEvent) {
+	switch event {
+	case api.Connected:
+		p.upstreamConnection.SetNoDelay(true)
+		p.readCallbacks.Connection().SetReadDisable(false)
+	case api.RemoteClose, api.OnReadErrClose, api.OnWriteErrClose:
+		p.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
+	if p.upstreamConnection == nil {
+		return
+	}
+	if event == api.RemoteClose {
+		p.upstreamConnection.Close(api.FlushWrite, api.LocalClose)
+	} else if event.IsClose() {
+		p.upstreamConnection.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+// ConnectionEventListener
+// ReadFilter
+type upstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (uc *upstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	uc.proxy.onUpstreamEvent(event)
+}
+
+func (uc *upstreamCallbacks) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	uc.proxy.onUpstreamData(buf)
+	return api.Stop
+}
+
+func (uc *upstreamCallbacks) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (uc *upstreamCallbacks) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+// ConnectionEventListener
+type downstreamCallbacks struct {
+	proxy *proxy
+}
+
+func (dc *downstreamCallbacks) OnEvent(event api.ConnectionEvent) {
+	dc.proxy.onDownstreamEvent(event)
+}
+
+// lbContext is a types.LoadBalancerContext implementation
+type lbContext struct {
+	conn api.ReadFilterCallbacks
+}
+
+func (c *lbContext) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	return nil
+}
+
+func (c *lbContext) DownstreamConnection() net.Conn {
+	return c.conn.Connection().RawConn()
+}
+
+func (c *lbContext) DownstreamHeaders() api.HeaderMap {
+	return nil
+}
+
+func (c *lbContext) DownstreamContext() context.Context {
+	return nil
+}
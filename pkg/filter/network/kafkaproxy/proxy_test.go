@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kafkaproxy
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type testConnection struct {
+	api.Connection
+	written bytes.Buffer
+	closed  bool
+}
+
+func (c *testConnection) Write(bufs ...buffer.IoBuffer) error {
+	for _, buf := range bufs {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+func (c *testConnection) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	c.closed = true
+	return nil
+}
+
+func (c *testConnection) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000}
+}
+
+type testClientConnection struct {
+	types.ClientConnection
+	written bytes.Buffer
+}
+
+func (c *testClientConnection) Write(bufs ...buffer.IoBuffer) error {
+	for _, buf := range bufs {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+type testReadFilterCallbacks struct {
+	api.ReadFilterCallbacks
+	conn *testConnection
+}
+
+func (cb *testReadFilterCallbacks) Connection() api.Connection {
+	return cb.conn
+}
+
+func newTestProxy(t *testing.T, cfg *v2.KafkaProxy) (*proxy, *testConnection, *testClientConnection) {
+	config, err := newProxyConfig(cfg)
+	if err != nil {
+		t.Fatalf("new proxy config failed: %v", err)
+	}
+	downstream := &testConnection{}
+	upstream := &testClientConnection{}
+	p := &proxy{
+		config:             config,
+		readCallbacks:      &testReadFilterCallbacks{conn: downstream},
+		upstreamConnection: upstream,
+		pending:            make(map[int32]*pendingRequest),
+	}
+	return p, downstream, upstream
+}
+
+func produceFrame(correlationID int32, acks int16, topic string) []byte {
+	return requestFrame(apiProduce, 3, correlationID, func(e *encoder) {
+		e.putNullableString("", false)
+		e.putInt16(acks)
+		e.putInt32(1000)
+		e.putArrayLen(1)
+		e.putString(topic)
+		e.putArrayLen(0)
+	})
+}
+
+func TestTopicACL(t *testing.T) {
+	config, _ := newProxyConfig(&v2.KafkaProxy{
+		TopicACLs: []v2.KafkaTopicACL{
+			{Topic: "orders", Produce: true, Fetch: true},
+			{Topic: "audit.*", Fetch: true},
+		},
+	})
+	cases := []struct {
+		apiKey  int16
+		topics  []string
+		allowed bool
+	}{
+		{apiProduce, []string{"orders"}, true},
+		{apiFetch, []string{"orders", "audit.login"}, true},
+		{apiProduce, []string{"audit.login"}, false},
+		{apiFetch, []string{"orders", "payments"}, false},
+	}
+	for _, c := range cases {
+		if allowed := config.allowed(c.apiKey, c.topics, nil); allowed != c.allowed {
+			t.Errorf("%s %v expected allowed %v, but got %v", apiName(c.apiKey), c.topics, c.allowed, allowed)
+		}
+	}
+	if config.allowed(apiFetch, nil, errUnsupportedVersion) {
+		t.Error("the requests can not be parsed are expected to be denied")
+	}
+	config, _ = newProxyConfig(&v2.KafkaProxy{})
+	if !config.allowed(apiFetch, nil, errUnsupportedVersion) {
+		t.Error("the requests are expected to be allowed without acls")
+	}
+}
+
+func TestProxyForwardAndRewrite(t *testing.T) {
+	p, downstream, upstream := newTestProxy(t, &v2.KafkaProxy{
+		StatPrefix:          "test",
+		Cluster:             "kafka",
+		AdvertisedListeners: map[string]string{"kafka-0.internal:9092": "mosn.example.com:19092"},
+	})
+
+	metadata := requestFrame(apiMetadata, 1, 99, func(e *encoder) { e.putArrayLen(-1) })
+	produce := produceFrame(100, 0, "orders")
+	data := append(append([]byte(nil), metadata...), produce...)
+	p.OnData(buffer.NewIoBufferBytes(data))
+	if !bytes.Equal(upstream.written.Bytes(), data) {
+		t.Errorf("the requests are expected to be forwarded")
+	}
+	// the produce request without acks has no response
+	if len(p.pending) != 1 || p.pending[99] == nil {
+		t.Errorf("unexpected pending requests: %v", p.pending)
+	}
+
+	p.onUpstreamData(buffer.NewIoBufferBytes(metadataResponse(1, "kafka-0.internal", 9092)))
+	expected := metadataResponse(1, "mosn.example.com", 19092)
+	if !reflect.DeepEqual(downstream.written.Bytes(), expected) {
+		t.Errorf("expected rewritten response %q, but got %q", expected, downstream.written.Bytes())
+	}
+	if len(p.pending) != 0 {
+		t.Errorf("unexpected pending requests: %v", p.pending)
+	}
+}
+
+func TestProxyDenied(t *testing.T) {
+	p, downstream, upstream := newTestProxy(t, &v2.KafkaProxy{
+		Cluster:   "kafka",
+		TopicACLs: []v2.KafkaTopicACL{{Topic: "orders", Produce: true}},
+	})
+	p.OnData(buffer.NewIoBufferBytes(produceFrame(1, 1, "orders")))
+	if downstream.closed || upstream.written.Len() == 0 {
+		t.Fatal("the allowed request is expected to be forwarded")
+	}
+	upstream.written.Reset()
+	p.OnData(buffer.NewIoBufferBytes(produceFrame(2, 1, "payments")))
+	if !downstream.closed || upstream.written.Len() != 0 {
+		t.Error("the denied request is expected to close the connection")
+	}
+}
+
+func TestParseKafkaProxy(t *testing.T) {
+	cfg, err := ParseKafkaProxy(map[string]interface{}{
+		"cluster":              "kafka",
+		"advertised_listeners": map[string]interface{}{"kafka-0:9092": "mosn:19092"},
+		"topic_acls":           []interface{}{map[string]interface{}{"topic": "orders", "produce": true}},
+	})
+	if err != nil || len(cfg.AdvertisedListeners) != 1 || len(cfg.TopicACLs) != 1 {
+		t.Fatalf("unexpected config %+v, error: %v", cfg, err)
+	}
+	if _, err := ParseKafkaProxy(map[string]interface{}{}); err == nil {
+		t.Error("kafka proxy without cluster is expected invalid")
+	}
+	if _, err := newProxyConfig(&v2.KafkaProxy{AdvertisedListeners: map[string]string{"kafka-0": "mosn:19092"}}); err == nil {
+		t.Error("advertised listener without port is expected invalid")
+	}
+}
@@ -25,7 +25,10 @@ import (
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/configmanager"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/proxy"
+	"mosn.io/mosn/pkg/types"
 )
 
 func init() {
@@ -33,10 +36,14 @@ func init() {
 }
 
 type genericProxyFilterConfigFactory struct {
-	Proxy *v2.Proxy
+	Proxy     *v2.Proxy
+	blocklist *proxy.RequestBlocklist
 }
 
 func (gfcf *genericProxyFilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+	if gfcf.blocklist != nil {
+		context = mosnctx.WithValue(context, types.ContextKeyRequestBlocklist, gfcf.blocklist)
+	}
 	p := proxy.NewProxy(context, gfcf.Proxy)
 	callbacks.AddReadFilter(p)
 }
@@ -46,9 +53,15 @@ func CreateProxyFactory(conf map[string]interface{}) (api.NetworkFilterChainFact
 	if err != nil {
 		return nil, err
 	}
-	return &genericProxyFilterConfigFactory{
+	factory := &genericProxyFilterConfigFactory{
 		Proxy: p,
-	}, nil
+	}
+	if p.RequestBlocklist != nil {
+		if factory.blocklist, err = proxy.NewRequestBlocklist(p.RequestBlocklist); err != nil {
+			return nil, err
+		}
+	}
+	return factory, nil
 }
 
 // ParseProxyFilter
@@ -64,7 +77,7 @@ func ParseProxyFilter(cfg map[string]interface{}) (*v2.Proxy, error) {
 
 	if proxyConfig.DownstreamProtocol == "" || proxyConfig.UpstreamProtocol == "" {
 		return nil, fmt.Errorf("protocol in string needed in proxy network filter")
-	} else if _, ok := configmanager.ProtocolsSupported[proxyConfig.DownstreamProtocol]; !ok {
+	} else if _, ok := configmanager.ProtocolsSupported[proxyConfig.DownstreamProtocol]; !ok || proxyConfig.DownstreamProtocol == string(protocol.HTTPAuto) {
 		return nil, fmt.Errorf("invalid downstream protocol %s", proxyConfig.DownstreamProtocol)
 	} else if _, ok := configmanager.ProtocolsSupported[proxyConfig.UpstreamProtocol]; !ok {
 		return nil, fmt.Errorf("invalid upstream protocol %s", proxyConfig.UpstreamProtocol)
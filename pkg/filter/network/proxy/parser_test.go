@@ -29,3 +29,45 @@ func TestParseProxyFilter(t *testing.T) {
 		t.Error("parse proxy filter failed")
 	}
 }
+
+func TestCreateProxyFactoryWithBlocklist(t *testing.T) {
+	cfg := map[string]interface{}{
+		"downstream_protocol": "Http1",
+		"upstream_protocol":   "Http1",
+		"request_blocklist": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"path_prefix": "/wp-admin"},
+			},
+		},
+	}
+	f, err := CreateProxyFactory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.(*genericProxyFilterConfigFactory).blocklist == nil {
+		t.Error("blocklist should be compiled")
+	}
+	cfg["request_blocklist"] = map[string]interface{}{
+		"rules": []interface{}{
+			map[string]interface{}{"path_regex": "("},
+		},
+	}
+	if _, err := CreateProxyFactory(cfg); err == nil {
+		t.Error("invalid blocklist should be failed")
+	}
+}
+
+func TestParseProxyFilterHTTPAuto(t *testing.T) {
+	if _, err := ParseProxyFilter(map[string]interface{}{
+		"downstream_protocol": "Http1",
+		"upstream_protocol":   "HttpAuto",
+	}); err != nil {
+		t.Errorf("http auto upstream protocol should be supported: %v", err)
+	}
+	if _, err := ParseProxyFilter(map[string]interface{}{
+		"downstream_protocol": "HttpAuto",
+		"upstream_protocol":   "Http1",
+	}); err == nil {
+		t.Error("http auto downstream protocol should be failed")
+	}
+}
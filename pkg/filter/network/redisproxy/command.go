@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandSpec describes the keys positions in the arguments of a command,
+// lastKey -1 means the last argument
+type commandSpec struct {
+	readOnly bool
+	firstKey int
+	lastKey  int
+	step     int
+}
+
+var (
+	singleKeyRead  = commandSpec{readOnly: true, firstKey: 1, lastKey: 1, step: 1}
+	singleKeyWrite = commandSpec{firstKey: 1, lastKey: 1, step: 1}
+	multiKeyRead   = commandSpec{readOnly: true, firstKey: 1, lastKey: -1, step: 1}
+	multiKeyWrite  = commandSpec{firstKey: 1, lastKey: -1, step: 1}
+)
+
+// commands are the supported commands, the commands without keys are handled by the proxy
+var commands = map[string]commandSpec{
+	// strings
+	"GET":         singleKeyRead,
+	"GETRANGE":    singleKeyRead,
+	"STRLEN":      singleKeyRead,
+	"GETBIT":      singleKeyRead,
+	"BITCOUNT":    singleKeyRead,
+	"MGET":        multiKeyRead,
+	"SET":         singleKeyWrite,
+	"SETEX":       singleKeyWrite,
+	"PSETEX":      singleKeyWrite,
+	"SETNX":       singleKeyWrite,
+	"SETRANGE":    singleKeyWrite,
+	"SETBIT":      singleKeyWrite,
+	"GETSET":      singleKeyWrite,
+	"APPEND":      singleKeyWrite,
+	"INCR":        singleKeyWrite,
+	"DECR":        singleKeyWrite,
+	"INCRBY":      singleKeyWrite,
+	"DECRBY":      singleKeyWrite,
+	"INCRBYFLOAT": singleKeyWrite,
+	"MSET":        {firstKey: 1, lastKey: -1, step: 2},
+	// keys
+	"EXISTS":   multiKeyRead,
+	"TTL":      singleKeyRead,
+	"PTTL":     singleKeyRead,
+	"TYPE":     singleKeyRead,
+	"DEL":      multiKeyWrite,
+	"UNLINK":   multiKeyWrite,
+	"EXPIRE":   singleKeyWrite,
+	"PEXPIRE":  singleKeyWrite,
+	"EXPIREAT": singleKeyWrite,
+	"PERSIST":  singleKeyWrite,
+	// hashes
+	"HGET":    singleKeyRead,
+	"HMGET":   singleKeyRead,
+	"HGETALL": singleKeyRead,
+	"HKEYS":   singleKeyRead,
+	"HVALS":   singleKeyRead,
+	"HLEN":    singleKeyRead,
+	"HEXISTS": singleKeyRead,
+	"HSET":    singleKeyWrite,
+	"HSETNX":  singleKeyWrite,
+	"HMSET":   singleKeyWrite,
+	"HDEL":    singleKeyWrite,
+	"HINCRBY": singleKeyWrite,
+	// lists
+	"LRANGE": singleKeyRead,
+	"LLEN":   singleKeyRead,
+	"LINDEX": singleKeyRead,
+	"LPUSH":  singleKeyWrite,
+	"RPUSH":  singleKeyWrite,
+	"LPOP":   singleKeyWrite,
+	"RPOP":   singleKeyWrite,
+	"LSET":   singleKeyWrite,
+	"LREM":   singleKeyWrite,
+	"LTRIM":  singleKeyWrite,
+	// sets
+	"SMEMBERS":    singleKeyRead,
+	"SISMEMBER":   singleKeyRead,
+	"SCARD":       singleKeyRead,
+	"SRANDMEMBER": singleKeyRead,
+	"SADD":        singleKeyWrite,
+	"SREM":        singleKeyWrite,
+	"SPOP":        singleKeyWrite,
+	// sorted sets
+	"ZRANGE":           singleKeyRead,
+	"ZREVRANGE":        singleKeyRead,
+	"ZRANGEBYSCORE":    singleKeyRead,
+	"ZREVRANGEBYSCORE": singleKeyRead,
+	"ZSCORE":           singleKeyRead,
+	"ZCARD":            singleKeyRead,
+	"ZCOUNT":           singleKeyRead,
+	"ZRANK":            singleKeyRead,
+	"ZREVRANK":         singleKeyRead,
+	"ZADD":             singleKeyWrite,
+	"ZREM":             singleKeyWrite,
+	"ZINCRBY":          singleKeyWrite,
+	"ZREMRANGEBYRANK":  singleKeyWrite,
+	"ZREMRANGEBYSCORE": singleKeyWrite,
+	// hyperloglog
+	"PFCOUNT": singleKeyRead,
+	"PFADD":   singleKeyWrite,
+}
+
+// the commands handled by the proxy
+const (
+	commandPing = "PING"
+	commandQuit = "QUIT"
+)
+
+// command is a parsed redis command
+type command struct {
+	name     string
+	readOnly bool
+	keys     [][]byte
+	args     [][]byte
+}
+
+// newCommand returns the command of the arguments, the keys refer to the arguments
+func newCommand(args [][]byte) (*command, error) {
+	name := strings.ToUpper(string(args[0]))
+	cmd := &command{
+		name: name,
+		args: args,
+	}
+	if name == commandPing || name == commandQuit {
+		return cmd, nil
+	}
+	spec, ok := commands[name]
+	if !ok {
+		return nil, fmt.Errorf("ERR unsupported command '%s'", strings.ToLower(name))
+	}
+	lastKey := spec.lastKey
+	if lastKey < 0 {
+		lastKey = len(args) - 1
+	}
+	if len(args) <= spec.firstKey || (spec.step > 1 && (len(args)-spec.firstKey)%spec.step != 0) {
+		return nil, fmt.Errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(name))
+	}
+	cmd.readOnly = spec.readOnly
+	for i := spec.firstKey; i <= lastKey; i += spec.step {
+		cmd.keys = append(cmd.keys, args[i])
+	}
+	return cmd, nil
+}
+
+// slot returns the hash slot of the command keys, all the keys must be in the same slot
+func (cmd *command) slot() (int, error) {
+	slot := keySlot(cmd.keys[0])
+	for _, key := range cmd.keys[1:] {
+		if keySlot(key) != slot {
+			return 0, errCrossSlot
+		}
+	}
+	return slot, nil
+}
+
+// localReply returns the reply of the commands handled by the proxy
+func (cmd *command) localReply() []byte {
+	switch cmd.name {
+	case commandPing:
+		if len(cmd.args) > 1 {
+			return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(cmd.args[1]), cmd.args[1]))
+		}
+		return []byte("+PONG\r\n")
+	case commandQuit:
+		return []byte("+OK\r\n")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func init() {
+	api.RegisterNetwork(v2.REDIS_PROXY, CreateRedisProxyFactory)
+}
+
+type redisProxyFilterConfigFactory struct {
+	config *proxyConfig
+}
+
+func (f *redisProxyFilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.NetWorkFilterChainFactoryCallbacks) {
+	rf := NewProxy(context, f.config)
+	callbacks.AddReadFilter(rf)
+}
+
+func CreateRedisProxyFactory(conf map[string]interface{}) (api.NetworkFilterChainFactory, error) {
+	p, err := ParseRedisProxy(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &redisProxyFilterConfigFactory{
+		config: newProxyConfig(p),
+	}, nil
+}
+
+// ParseRedisProxy
+func ParseRedisProxy(cfg map[string]interface{}) (*v2.RedisProxy, error) {
+	proxy := &v2.RedisProxy{}
+	if data, err := json.Marshal(cfg); err == nil {
+		json.Unmarshal(data, proxy)
+	} else {
+		return nil, fmt.Errorf("[config] config is not a redis proxy config: %v", err)
+	}
+	if proxy.Cluster == "" {
+		return nil, fmt.Errorf("[config] redis proxy cluster is required")
+	}
+	switch proxy.ReadPolicy {
+	case "", ReadMaster, ReadPreferReplica, ReadReplica:
+	default:
+		return nil, fmt.Errorf("[config] unknown redis proxy read policy: %s", proxy.ReadPolicy)
+	}
+	return proxy, nil
+}
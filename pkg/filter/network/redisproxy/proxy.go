@@ -0,0 +1,387 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+const readOnlyCommand = "*1\r\n$8\r\nREADONLY\r\n"
+
+var (
+	errNoCluster      = errors.New("ERR no upstream cluster")
+	errNoHost         = errors.New("ERR no upstream host for the slot")
+	errUpstreamFailed = errors.New("ERR upstream connection failed")
+	errUpstreamClosed = errors.New("ERR upstream connection closed")
+)
+
+// commandStats is the per-command stats
+type commandStats struct {
+	total   gometrics.Counter
+	errors  gometrics.Counter
+	latency gometrics.Histogram
+}
+
+// proxyConfig is shared by the proxies of the same filter config
+type proxyConfig struct {
+	statPrefix string
+	cluster    string
+	readPolicy string
+
+	mux     sync.Mutex
+	hostSet types.HostSet
+	router  *shardRouter
+
+	stats sync.Map
+}
+
+func newProxyConfig(config *v2.RedisProxy) *proxyConfig {
+	pc := &proxyConfig{
+		statPrefix: config.StatPrefix,
+		cluster:    config.Cluster,
+		readPolicy: config.ReadPolicy,
+	}
+	if pc.readPolicy == "" {
+		pc.readPolicy = ReadMaster
+	}
+	return pc
+}
+
+// getRouter returns the shard router of the host set, the router is rebuilt when the hosts are changed
+func (pc *proxyConfig) getRouter(hostSet types.HostSet) *shardRouter {
+	pc.mux.Lock()
+	defer pc.mux.Unlock()
+	if pc.router == nil || pc.hostSet != hostSet {
+		pc.hostSet = hostSet
+		pc.router = newShardRouter(hostSet.Hosts())
+	}
+	return pc.router
+}
+
+func (pc *proxyConfig) commandStats(name string) *commandStats {
+	if v, ok := pc.stats.Load(name); ok {
+		return v.(*commandStats)
+	}
+	s := metrics.NewRedisStats(pc.statPrefix, name)
+	v, _ := pc.stats.LoadOrStore(name, &commandStats{
+		total:   s.Counter(metrics.RedisCommandTotal),
+		errors:  s.Counter(metrics.RedisCommandError),
+		latency: s.Histogram(metrics.RedisCommandLatency),
+	})
+	return v.(*commandStats)
+}
+
+// request is a command waiting for its reply, the replies are written to the downstream in the commands order
+type request struct {
+	reply []byte
+	done  bool
+	start time.Time
+	stats *commandStats
+}
+
+// ReadFilter
+type proxy struct {
+	config         *proxyConfig
+	clusterManager types.ClusterManager
+	readCallbacks  api.ReadFilterCallbacks
+
+	mux       sync.Mutex
+	pending   []*request
+	upstreams map[string]*upstream
+	// closing is set by the QUIT command, the connection is closed after the replies are written
+	closing bool
+}
+
+func NewProxy(ctx context.Context, config *proxyConfig) api.ReadFilter {
+	return &proxy{
+		config:         config,
+		clusterManager: cluster.GetClusterMngAdapterInstance().ClusterManager,
+		upstreams:      make(map[string]*upstream),
+	}
+}
+
+func (p *proxy) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
+	p.readCallbacks = cb
+	p.readCallbacks.Connection().AddConnectionEventListener(p)
+}
+
+func (p *proxy) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := buf.Bytes()
+	consumed := 0
+	for !p.closing {
+		args, n, err := parseCommand(data[consumed:])
+		if err == errIncomplete {
+			break
+		}
+		if err != nil {
+			log.DefaultLogger.Errorf("[redisproxy] parse command failed: %v", err)
+			p.readCallbacks.Connection().Write(buffer.NewIoBufferBytes(errorReply("ERR Protocol error")))
+			p.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+			buf.Drain(buf.Len())
+			return api.Stop
+		}
+		raw := data[consumed : consumed+n]
+		consumed += n
+		if len(args) > 0 {
+			p.onCommand(args, raw)
+		}
+	}
+	buf.Drain(consumed)
+	p.flush()
+	return api.Stop
+}
+
+// onCommand sends the command to the upstream or replies it, the raw bytes are copied before sent
+func (p *proxy) onCommand(args [][]byte, raw []byte) {
+	req := &request{
+		start: time.Now(),
+	}
+	p.mux.Lock()
+	p.pending = append(p.pending, req)
+	p.mux.Unlock()
+
+	cmd, err := newCommand(args)
+	if err != nil {
+		p.complete(req, errorReply(err.Error()))
+		return
+	}
+	req.stats = p.config.commandStats(cmd.name)
+	req.stats.total.Inc(1)
+
+	if reply := cmd.localReply(); reply != nil {
+		if cmd.name == commandQuit {
+			p.mux.Lock()
+			p.closing = true
+			p.mux.Unlock()
+		}
+		p.complete(req, reply)
+		return
+	}
+
+	slot, err := cmd.slot()
+	if err != nil {
+		p.complete(req, errorReply(err.Error()))
+		return
+	}
+	snapshot, ok := p.clusterManager.GetClusterSnapshot(context.Background(), p.config.cluster)
+	if !ok {
+		p.complete(req, errorReply(errNoCluster.Error()))
+		return
+	}
+	host := p.config.getRouter(snapshot.HostSet()).route(slot, cmd.readOnly, p.config.readPolicy)
+	if host == nil {
+		p.complete(req, errorReply(errNoHost.Error()))
+		return
+	}
+	up := p.getUpstream(host)
+	if up == nil {
+		p.complete(req, errorReply(errUpstreamFailed.Error()))
+		return
+	}
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[redisproxy] command %s slot %d is sent to %s", cmd.name, slot, host.AddressString())
+	}
+	up.send(req, append([]byte(nil), raw...))
+}
+
+// getUpstream returns the upstream connection of the host, the connection is created if not exists
+func (p *proxy) getUpstream(host types.Host) *upstream {
+	addr := host.AddressString()
+	p.mux.Lock()
+	up, ok := p.upstreams[addr]
+	p.mux.Unlock()
+	if ok {
+		return up
+	}
+
+	connData := host.CreateConnection(context.Background())
+	if connData.Connection == nil {
+		return nil
+	}
+	up = &upstream{
+		proxy: p,
+		addr:  addr,
+		conn:  connData.Connection,
+	}
+	up.conn.AddConnectionEventListener(up)
+	up.conn.FilterManager().AddReadFilter(up)
+	if err := up.conn.Connect(); err != nil {
+		log.DefaultLogger.Errorf("[redisproxy] connect to %s failed: %v", addr, err)
+		return nil
+	}
+	up.conn.SetNoDelay(true)
+	// the replicas of a redis cluster serve the read commands only after READONLY,
+	// the reply is consumed by the proxy
+	if host.Metadata()[MetadataRole] == RoleReplica {
+		up.send(&request{start: time.Now()}, []byte(readOnlyCommand))
+	}
+
+	p.mux.Lock()
+	p.upstreams[addr] = up
+	p.mux.Unlock()
+	return up
+}
+
+// complete sets the reply of the request and records the stats
+func (p *proxy) complete(req *request, reply []byte) {
+	p.mux.Lock()
+	p.setReply(req, reply)
+	p.mux.Unlock()
+}
+
+// setReply must be called with the lock held
+func (p *proxy) setReply(req *request, reply []byte) {
+	if req.done {
+		return
+	}
+	req.reply = reply
+	req.done = true
+	if req.stats != nil {
+		req.stats.latency.Update(time.Since(req.start).Nanoseconds())
+		if len(reply) > 0 && reply[0] == '-' {
+			req.stats.errors.Inc(1)
+		}
+	}
+}
+
+// flush writes the replies of the completed requests in order,
+// the replies are written with the lock held to keep the order
+func (p *proxy) flush() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	n := 0
+	for n < len(p.pending) && p.pending[n].done {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	size := 0
+	for _, req := range p.pending[:n] {
+		size += len(req.reply)
+	}
+	out := buffer.NewIoBuffer(size)
+	for _, req := range p.pending[:n] {
+		out.Write(req.reply)
+	}
+	p.pending = p.pending[n:]
+	conn := p.readCallbacks.Connection()
+	conn.Write(out)
+	if p.closing && len(p.pending) == 0 {
+		conn.Close(api.FlushWrite, api.LocalClose)
+	}
+}
+
+// OnEvent closes the upstream connections when the downstream connection is closed
+func (p *proxy) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	p.mux.Lock()
+	upstreams := p.upstreams
+	p.upstreams = make(map[string]*upstream)
+	p.mux.Unlock()
+	for _, up := range upstreams {
+		up.conn.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+// upstream is a connection to a redis host, the replies are matched with the requests in order
+type upstream struct {
+	proxy   *proxy
+	addr    string
+	conn    types.ClientConnection
+	pending []*request
+}
+
+func (u *upstream) send(req *request, raw []byte) {
+	u.proxy.mux.Lock()
+	u.pending = append(u.pending, req)
+	u.proxy.mux.Unlock()
+	if err := u.conn.Write(buffer.NewIoBufferBytes(raw)); err != nil {
+		u.proxy.complete(req, errorReply(errUpstreamFailed.Error()))
+	}
+}
+
+func (u *upstream) OnData(buf buffer.IoBuffer) api.FilterStatus {
+	data := buf.Bytes()
+	consumed := 0
+	var err error
+	u.proxy.mux.Lock()
+	for len(u.pending) > 0 {
+		var n int
+		if n, err = replyLength(data[consumed:]); err != nil {
+			break
+		}
+		req := u.pending[0]
+		u.pending = u.pending[1:]
+		u.proxy.setReply(req, append([]byte(nil), data[consumed:consumed+n]...))
+		consumed += n
+	}
+	u.proxy.mux.Unlock()
+
+	if err != nil && err != errIncomplete {
+		log.DefaultLogger.Errorf("[redisproxy] parse reply from %s failed: %v", u.addr, err)
+		buf.Drain(buf.Len())
+		u.conn.Close(api.NoFlush, api.LocalClose)
+	} else {
+		buf.Drain(consumed)
+	}
+	u.proxy.flush()
+	return api.Stop
+}
+
+func (u *upstream) OnNewConnection() api.FilterStatus {
+	return api.Continue
+}
+
+func (u *upstream) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {}
+
+// OnEvent fails the pending requests when the upstream connection is closed
+func (u *upstream) OnEvent(event api.ConnectionEvent) {
+	if !event.IsClose() {
+		return
+	}
+	u.proxy.mux.Lock()
+	if u.proxy.upstreams[u.addr] == u {
+		delete(u.proxy.upstreams, u.addr)
+	}
+	for _, req := range u.pending {
+		u.proxy.setReply(req, errorReply(errUpstreamClosed.Error()))
+	}
+	u.pending = nil
+	u.proxy.mux.Unlock()
+	u.proxy.flush()
+}
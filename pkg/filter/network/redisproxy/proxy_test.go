@@ -0,0 +1,222 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"bytes"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type testHost struct {
+	types.Host
+	addr     string
+	metadata api.Metadata
+	healthy  bool
+}
+
+func (h *testHost) AddressString() string  { return h.addr }
+func (h *testHost) Metadata() api.Metadata { return h.metadata }
+func (h *testHost) Health() bool           { return h.healthy }
+
+func newTestHost(addr, slots, role string) *testHost {
+	return &testHost{
+		addr:     addr,
+		metadata: api.Metadata{MetadataSlots: slots, MetadataRole: role},
+		healthy:  true,
+	}
+}
+
+func TestShardRouter(t *testing.T) {
+	master1 := newTestHost("127.0.0.1:7001", "0-8191", "")
+	replica1 := newTestHost("127.0.0.1:7002", "0-8191", RoleReplica)
+	master2 := newTestHost("127.0.0.1:7003", "8192-16383", "master")
+	r := newShardRouter([]types.Host{master1, replica1, master2})
+
+	fooSlot := keySlot([]byte("foo")) // 12182
+	barSlot := keySlot([]byte("bar")) // 5061
+	if h := r.route(fooSlot, false, ReadMaster); h != master2 {
+		t.Errorf("foo expected routed to %s, but got %v", master2.addr, h)
+	}
+	if h := r.route(barSlot, true, ReadMaster); h != master1 {
+		t.Errorf("bar read expected routed to master %s, but got %v", master1.addr, h)
+	}
+	if h := r.route(barSlot, true, ReadPreferReplica); h != replica1 {
+		t.Errorf("bar read expected routed to replica %s, but got %v", replica1.addr, h)
+	}
+	if h := r.route(barSlot, false, ReadReplica); h != master1 {
+		t.Errorf("bar write expected routed to master %s, but got %v", master1.addr, h)
+	}
+	// no replica in the shard of foo
+	if h := r.route(fooSlot, true, ReadPreferReplica); h != master2 {
+		t.Errorf("foo read expected fallback to master %s, but got %v", master2.addr, h)
+	}
+	if h := r.route(fooSlot, true, ReadReplica); h != nil {
+		t.Errorf("foo read expected no host, but got %v", h)
+	}
+	replica1.healthy = false
+	if h := r.route(barSlot, true, ReadPreferReplica); h != master1 {
+		t.Errorf("bar read expected fallback to master %s, but got %v", master1.addr, h)
+	}
+
+	// the hosts without slots serve all the slots
+	single := newTestHost("127.0.0.1:6379", "", "")
+	r = newShardRouter([]types.Host{single})
+	if r.route(0, false, ReadMaster) != single || r.route(slotCount-1, false, ReadMaster) != single {
+		t.Error("the host without slots is expected to serve all the slots")
+	}
+
+	if _, err := parseSlots("0-100,200"); err != nil {
+		t.Errorf("parse slots failed: %v", err)
+	}
+	for _, invalid := range []string{"a-1", "100-1", "0-16384"} {
+		if _, err := parseSlots(invalid); err == nil {
+			t.Errorf("slots %s expected invalid", invalid)
+		}
+	}
+}
+
+type testConnection struct {
+	api.Connection
+	written bytes.Buffer
+	closed  bool
+}
+
+func (c *testConnection) Write(bufs ...buffer.IoBuffer) error {
+	for _, buf := range bufs {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+func (c *testConnection) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	c.closed = true
+	return nil
+}
+
+func (c *testConnection) AddConnectionEventListener(listener api.ConnectionEventListener) {}
+
+type testClientConnection struct {
+	types.ClientConnection
+	written bytes.Buffer
+}
+
+func (c *testClientConnection) Write(bufs ...buffer.IoBuffer) error {
+	for _, buf := range bufs {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+type testReadFilterCallbacks struct {
+	api.ReadFilterCallbacks
+	conn *testConnection
+}
+
+func (cb *testReadFilterCallbacks) Connection() api.Connection {
+	return cb.conn
+}
+
+func newTestProxy() (*proxy, *testConnection) {
+	p := &proxy{
+		config:    newProxyConfig(&v2.RedisProxy{StatPrefix: "test", Cluster: "redis"}),
+		upstreams: make(map[string]*upstream),
+	}
+	conn := &testConnection{}
+	p.InitializeReadFilterCallbacks(&testReadFilterCallbacks{conn: conn})
+	return p, conn
+}
+
+func TestProxyRepliesInOrder(t *testing.T) {
+	p, downstream := newTestProxy()
+	upConn := &testClientConnection{}
+	up := &upstream{
+		proxy: p,
+		addr:  "127.0.0.1:6379",
+		conn:  upConn,
+	}
+
+	// the first command is waiting for the upstream reply
+	p.mux.Lock()
+	req := &request{stats: p.config.commandStats("GET")}
+	p.pending = append(p.pending, req)
+	p.mux.Unlock()
+	up.send(req, []byte("*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+
+	// the local replies are held until the reply of the first command is received
+	p.OnData(buffer.NewIoBufferString("*1\r\n$4\r\nPING\r\n*2\r\n$4\r\nKEYS\r\n$1\r\n*\r\n*3\r\n$4\r\nMGET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n"))
+	if downstream.written.Len() != 0 {
+		t.Fatalf("expected no reply written, but got %q", downstream.written.String())
+	}
+
+	up.OnData(buffer.NewIoBufferString("$3\r\nbar\r\n"))
+	expected := "$3\r\nbar\r\n+PONG\r\n-ERR unsupported command 'keys'\r\n-" + errCrossSlot.Error() + "\r\n"
+	if downstream.written.String() != expected {
+		t.Errorf("expected replies %q, but got %q", expected, downstream.written.String())
+	}
+	if upConn.written.String() != "*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n" {
+		t.Errorf("unexpected upstream data %q", upConn.written.String())
+	}
+}
+
+func TestProxyUpstreamClosed(t *testing.T) {
+	p, downstream := newTestProxy()
+	up := &upstream{
+		proxy: p,
+		addr:  "127.0.0.1:6379",
+		conn:  &testClientConnection{},
+	}
+	p.upstreams[up.addr] = up
+	p.mux.Lock()
+	req := &request{}
+	p.pending = append(p.pending, req)
+	p.mux.Unlock()
+	up.send(req, []byte("*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+
+	up.OnEvent(api.RemoteClose)
+	if downstream.written.String() != "-"+errUpstreamClosed.Error()+"\r\n" {
+		t.Errorf("unexpected reply %q", downstream.written.String())
+	}
+	if _, ok := p.upstreams[up.addr]; ok {
+		t.Error("the closed upstream is expected to be removed")
+	}
+}
+
+func TestProxyQuit(t *testing.T) {
+	p, downstream := newTestProxy()
+	p.OnData(buffer.NewIoBufferString("QUIT\r\nPING\r\n"))
+	if downstream.written.String() != "+OK\r\n" || !downstream.closed {
+		t.Errorf("expected the connection closed after QUIT, but got %q", downstream.written.String())
+	}
+}
+
+func TestParseRedisProxy(t *testing.T) {
+	if _, err := ParseRedisProxy(map[string]interface{}{"cluster": "redis", "read_policy": "prefer_replica"}); err != nil {
+		t.Errorf("parse redis proxy failed: %v", err)
+	}
+	if _, err := ParseRedisProxy(map[string]interface{}{"read_policy": "master"}); err == nil {
+		t.Error("redis proxy without cluster is expected invalid")
+	}
+	if _, err := ParseRedisProxy(map[string]interface{}{"cluster": "redis", "read_policy": "any"}); err == nil {
+		t.Error("unknown read policy is expected invalid")
+	}
+}
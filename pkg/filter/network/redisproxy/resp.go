@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+const (
+	maxBulkSize  = 512 * 1024 * 1024
+	maxArraySize = 1024 * 1024
+)
+
+var (
+	errIncomplete = errors.New("redis: incomplete data")
+	errProtocol   = errors.New("redis: protocol error")
+
+	crlf = []byte("\r\n")
+)
+
+// parseCommand parses the first command in the data, the command is an array of bulk strings
+// or an inline command. It returns the arguments and the length of the command,
+// errIncomplete is returned if the data does not contain a whole command.
+func parseCommand(data []byte) ([][]byte, int, error) {
+	if len(data) == 0 {
+		return nil, 0, errIncomplete
+	}
+	if data[0] != '*' {
+		// inline command, such as the ones sent by telnet
+		end := bytes.Index(data, crlf)
+		if end < 0 {
+			return nil, 0, errIncomplete
+		}
+		return bytes.Fields(data[:end]), end + 2, nil
+	}
+
+	count, n, err := parseInteger(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	if count < 0 || count > maxArraySize {
+		return nil, 0, errProtocol
+	}
+	args := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		if n >= len(data) {
+			return nil, 0, errIncomplete
+		}
+		if data[n] != '$' {
+			return nil, 0, errProtocol
+		}
+		size, m, err := parseInteger(data[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		if size < 0 || size > maxBulkSize {
+			return nil, 0, errProtocol
+		}
+		n += m
+		if len(data) < n+size+2 {
+			return nil, 0, errIncomplete
+		}
+		if data[n+size] != '\r' || data[n+size+1] != '\n' {
+			return nil, 0, errProtocol
+		}
+		args = append(args, data[n:n+size])
+		n += size + 2
+	}
+	return args, n, nil
+}
+
+// replyLength returns the length of the first whole reply in the data
+func replyLength(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errIncomplete
+	}
+	switch data[0] {
+	case '+', '-', ':':
+		end := bytes.Index(data, crlf)
+		if end < 0 {
+			return 0, errIncomplete
+		}
+		return end + 2, nil
+	case '$':
+		size, n, err := parseInteger(data)
+		if err != nil {
+			return 0, err
+		}
+		// null bulk string
+		if size < 0 {
+			return n, nil
+		}
+		if size > maxBulkSize {
+			return 0, errProtocol
+		}
+		if len(data) < n+size+2 {
+			return 0, errIncomplete
+		}
+		return n + size + 2, nil
+	case '*':
+		count, n, err := parseInteger(data)
+		if err != nil {
+			return 0, err
+		}
+		for i := 0; i < count; i++ {
+			m, err := replyLength(data[n:])
+			if err != nil {
+				return 0, err
+			}
+			n += m
+		}
+		return n, nil
+	default:
+		return 0, errProtocol
+	}
+}
+
+// parseInteger parses the integer line after the type byte,
+// returns the integer and the length of the line
+func parseInteger(data []byte) (int, int, error) {
+	end := bytes.Index(data, crlf)
+	if end < 0 {
+		return 0, 0, errIncomplete
+	}
+	v, err := strconv.Atoi(string(data[1:end]))
+	if err != nil {
+		return 0, 0, errProtocol
+	}
+	return v, end + 2, nil
+}
+
+// errorReply returns an error reply with the message
+func errorReply(msg string) []byte {
+	return []byte("-" + msg + "\r\n")
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	data := []byte("*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n")
+	args, n, err := parseCommand(data)
+	if err != nil {
+		t.Fatalf("parse command failed: %v", err)
+	}
+	if n != 31 || !reflect.DeepEqual(args, [][]byte{[]byte("SET"), []byte("foo"), []byte("bar")}) {
+		t.Errorf("unexpected command: %q, length %d", args, n)
+	}
+	args, _, err = parseCommand(data[n:])
+	if err != nil || len(args) != 2 || string(args[0]) != "GET" {
+		t.Errorf("unexpected command: %q, error: %v", args, err)
+	}
+
+	// inline command
+	args, n, err = parseCommand([]byte("ping hello\r\n"))
+	if err != nil || n != 12 || len(args) != 2 || string(args[1]) != "hello" {
+		t.Errorf("unexpected inline command: %q, error: %v", args, err)
+	}
+
+	for _, incomplete := range []string{"", "*2\r\n$3\r\nGET\r\n", "*2\r\n$3\r\nGET\r\n$3\r\nfo", "GET foo"} {
+		if _, _, err := parseCommand([]byte(incomplete)); err != errIncomplete {
+			t.Errorf("%q expected incomplete, but got %v", incomplete, err)
+		}
+	}
+	for _, invalid := range []string{"*x\r\n", "*1\r\n:1\r\n", "*1\r\n$3\r\nGETX\r\n"} {
+		if _, _, err := parseCommand([]byte(invalid)); err != errProtocol {
+			t.Errorf("%q expected protocol error, but got %v", invalid, err)
+		}
+	}
+}
+
+func TestReplyLength(t *testing.T) {
+	cases := map[string]int{
+		"+OK\r\n":                            5,
+		"-ERR failed\r\n":                    13,
+		":100\r\n":                           6,
+		"$3\r\nbar\r\n":                      9,
+		"$-1\r\n":                            5,
+		"*2\r\n$3\r\nfoo\r\n$-1\r\n":         18,
+		"*2\r\n*1\r\n:1\r\n+OK\r\n+NEXT\r\n": 17,
+	}
+	for reply, expected := range cases {
+		if n, err := replyLength([]byte(reply)); err != nil || n != expected {
+			t.Errorf("%q expected length %d, but got %d, error: %v", reply, expected, n, err)
+		}
+	}
+	for _, incomplete := range []string{"+OK", "$3\r\nba", "*2\r\n$3\r\nfoo\r\n"} {
+		if _, err := replyLength([]byte(incomplete)); err != errIncomplete {
+			t.Errorf("%q expected incomplete, but got %v", incomplete, err)
+		}
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	cmd, err := newCommand([][]byte{[]byte("mset"), []byte("{a}1"), []byte("v1"), []byte("{a}2"), []byte("v2")})
+	if err != nil {
+		t.Fatalf("new command failed: %v", err)
+	}
+	if cmd.name != "MSET" || cmd.readOnly || len(cmd.keys) != 2 || string(cmd.keys[1]) != "{a}2" {
+		t.Errorf("unexpected command: %+v", cmd)
+	}
+	if _, err := cmd.slot(); err != nil {
+		t.Errorf("keys with the same hash tag are expected in the same slot, but got %v", err)
+	}
+
+	cmd, _ = newCommand([][]byte{[]byte("MGET"), []byte("foo"), []byte("bar")})
+	if !cmd.readOnly {
+		t.Error("MGET is expected to be read only")
+	}
+	if _, err := cmd.slot(); err != errCrossSlot {
+		t.Errorf("expected cross slot error, but got %v", err)
+	}
+
+	if _, err := newCommand([][]byte{[]byte("KEYS"), []byte("*")}); err == nil {
+		t.Error("KEYS is expected to be unsupported")
+	}
+	if _, err := newCommand([][]byte{[]byte("GET")}); err == nil {
+		t.Error("GET without key is expected to be invalid")
+	}
+	if _, err := newCommand([][]byte{[]byte("MSET"), []byte("a"), []byte("1"), []byte("b")}); err == nil {
+		t.Error("MSET without the last value is expected to be invalid")
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	// the slots are the same as CLUSTER KEYSLOT of redis
+	cases := map[string]int{
+		"foo":                  12182,
+		"bar":                  5061,
+		"{user1000}.following": keySlot([]byte("user1000")),
+		"{}foo":                keySlot([]byte("{}foo")),
+	}
+	for key, slot := range cases {
+		if s := keySlot([]byte(key)); s != slot {
+			t.Errorf("key %s expected slot %d, but got %d", key, slot, s)
+		}
+	}
+	if crc16([]byte("123456789")) != 0x31c3 {
+		t.Errorf("unexpected crc16: %x", crc16([]byte("123456789")))
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redisproxy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// the host metadata of the redis cluster
+const (
+	MetadataSlots = "redis_slots"
+	MetadataRole  = "redis_role"
+	RoleReplica   = "replica"
+)
+
+// the read policies
+const (
+	ReadMaster        = "master"
+	ReadPreferReplica = "prefer_replica"
+	ReadReplica       = "replica"
+)
+
+// shard is the hosts serving the same slots
+type shard struct {
+	master   types.Host
+	replicas []types.Host
+	next     uint32
+}
+
+// replica returns a healthy replica by round robin
+func (s *shard) replica() types.Host {
+	n := len(s.replicas)
+	for i := 0; i < n; i++ {
+		h := s.replicas[int(atomic.AddUint32(&s.next, 1))%n]
+		if h.Health() {
+			return h
+		}
+	}
+	return nil
+}
+
+// shardRouter routes the hash slots to the shards
+type shardRouter struct {
+	slots []*shard
+}
+
+func newShardRouter(hosts []types.Host) *shardRouter {
+	r := &shardRouter{
+		slots: make([]*shard, slotCount),
+	}
+	shards := make(map[string]*shard)
+	for _, h := range hosts {
+		slots := strings.TrimSpace(h.Metadata()[MetadataSlots])
+		if slots == "" {
+			slots = fmt.Sprintf("0-%d", slotCount-1)
+		}
+		s, ok := shards[slots]
+		if !ok {
+			ranges, err := parseSlots(slots)
+			if err != nil {
+				continue
+			}
+			s = &shard{}
+			shards[slots] = s
+			for _, rg := range ranges {
+				for slot := rg[0]; slot <= rg[1]; slot++ {
+					r.slots[slot] = s
+				}
+			}
+		}
+		if h.Metadata()[MetadataRole] == RoleReplica {
+			s.replicas = append(s.replicas, h)
+		} else if s.master == nil {
+			s.master = h
+		}
+	}
+	return r
+}
+
+// route returns the host of the slot, the read only commands may be sent to the replicas by the policy
+func (r *shardRouter) route(slot int, readOnly bool, policy string) types.Host {
+	s := r.slots[slot]
+	if s == nil {
+		return nil
+	}
+	if readOnly && (policy == ReadPreferReplica || policy == ReadReplica) {
+		if h := s.replica(); h != nil {
+			return h
+		}
+		if policy == ReadReplica {
+			return nil
+		}
+	}
+	return s.master
+}
+
+// parseSlots parses the slot ranges such as "0-5460,5462"
+func parseSlots(value string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		bounds := strings.SplitN(item, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid slots %s", value)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid slots %s", value)
+			}
+		}
+		if start < 0 || end >= slotCount || start > end {
+			return nil, fmt.Errorf("invalid slots %s", value)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
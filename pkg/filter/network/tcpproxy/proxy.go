@@ -20,7 +20,6 @@ package tcpproxy
 import (
 	"context"
 	"net"
-	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -102,9 +101,9 @@ func (p *proxy) InitializeReadFilterCallbacks(cb api.ReadFilterCallbacks) {
 func (p *proxy) initializeUpstreamConnection() api.FilterStatus {
 	clusterName := p.getUpstreamCluster()
 
-	clusterSnapshot := p.clusterManager.GetClusterSnapshot(context.Background(), clusterName)
+	clusterSnapshot, ok := p.clusterManager.GetClusterSnapshot(context.Background(), clusterName)
 
-	if reflect.ValueOf(clusterSnapshot).IsNil() {
+	if !ok {
 		p.requestInfo.SetResponseFlag(api.NoRouteFound)
 		p.onInitFailure(NoRoute)
 
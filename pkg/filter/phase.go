@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// streamFilterPhases are the stream filter phases in order
+var streamFilterPhases = []string{
+	v2.StreamFilterPhaseAuthn,
+	v2.StreamFilterPhaseAuthz,
+	v2.StreamFilterPhaseRateLimit,
+	v2.StreamFilterPhaseTransform,
+	v2.StreamFilterPhaseObservability,
+}
+
+// DefaultStreamFilterPhase is the phase of the filter types that register no phase
+const DefaultStreamFilterPhase = v2.StreamFilterPhaseTransform
+
+var (
+	phaseMutex        sync.RWMutex
+	streamFilterPhase = make(map[string]string)
+)
+
+// RegisterStreamFilterPhase registers the phase of a stream filter type, which is used if the filter config sets no phase
+func RegisterStreamFilterPhase(filterType string, phase string) {
+	if phaseIndex(phase) < 0 {
+		panic(fmt.Sprintf("unknown stream filter phase %s of filter %s", phase, filterType))
+	}
+	phaseMutex.Lock()
+	defer phaseMutex.Unlock()
+	streamFilterPhase[filterType] = phase
+}
+
+// StreamFilterPhase returns the phase of the filter config
+func StreamFilterPhase(config v2.Filter) string {
+	if config.Phase != "" {
+		return config.Phase
+	}
+	phaseMutex.RLock()
+	defer phaseMutex.RUnlock()
+	if phase, ok := streamFilterPhase[config.Type]; ok {
+		return phase
+	}
+	return DefaultStreamFilterPhase
+}
+
+// ValidateStreamFilterPhase checks the phase set in the filter config
+func ValidateStreamFilterPhase(config v2.Filter) error {
+	if config.Phase != "" && phaseIndex(config.Phase) < 0 {
+		return fmt.Errorf("unknown stream filter phase %s", config.Phase)
+	}
+	return nil
+}
+
+func phaseIndex(phase string) int {
+	for i, p := range streamFilterPhases {
+		if p == phase {
+			return i
+		}
+	}
+	return -1
+}
+
+// SortStreamFilters returns the stream filters in the running order with the phases resolved.
+// The filters are ordered by the phase and the priority, and keep the config order if both are the same.
+// The filters in an unknown phase run at last
+func SortStreamFilters(configs []v2.Filter) []v2.Filter {
+	sorted := make([]v2.Filter, len(configs))
+	copy(sorted, configs)
+	for i := range sorted {
+		sorted[i].Phase = StreamFilterPhase(sorted[i])
+	}
+	order := func(f v2.Filter) int {
+		if i := phaseIndex(f.Phase); i >= 0 {
+			return i
+		}
+		return len(streamFilterPhases)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := order(sorted[i]), order(sorted[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return sorted
+}
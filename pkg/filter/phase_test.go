@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func TestSortStreamFilters(t *testing.T) {
+	RegisterStreamFilterPhase("test_authn", v2.StreamFilterPhaseAuthn)
+	RegisterStreamFilterPhase("test_observability", v2.StreamFilterPhaseObservability)
+	configs := []v2.Filter{
+		{Type: "test_observability"},
+		{Type: "test_transform"},
+		{Type: "test_rate_limit", Phase: v2.StreamFilterPhaseRateLimit, Priority: 2},
+		{Type: "test_rate_limit_first", Phase: v2.StreamFilterPhaseRateLimit, Priority: 1},
+		{Type: "test_authn"},
+		{Type: "test_authz", Phase: v2.StreamFilterPhaseAuthz},
+		// the config phase overrides the registered phase
+		{Type: "test_authn", Phase: v2.StreamFilterPhaseObservability, Priority: -1},
+		{Type: "test_unknown", Phase: "unknown"},
+	}
+	expected := []struct {
+		typ   string
+		phase string
+	}{
+		{"test_authn", v2.StreamFilterPhaseAuthn},
+		{"test_authz", v2.StreamFilterPhaseAuthz},
+		{"test_rate_limit_first", v2.StreamFilterPhaseRateLimit},
+		{"test_rate_limit", v2.StreamFilterPhaseRateLimit},
+		{"test_transform", DefaultStreamFilterPhase},
+		{"test_authn", v2.StreamFilterPhaseObservability},
+		{"test_observability", v2.StreamFilterPhaseObservability},
+		{"test_unknown", "unknown"},
+	}
+	sorted := SortStreamFilters(configs)
+	if len(sorted) != len(expected) {
+		t.Fatalf("expected %d filters, but got %d", len(expected), len(sorted))
+	}
+	for i, e := range expected {
+		if sorted[i].Type != e.typ || sorted[i].Phase != e.phase {
+			t.Errorf("filter %d expected %s in %s, but got %s in %s", i, e.typ, e.phase, sorted[i].Type, sorted[i].Phase)
+		}
+	}
+	// the configs are not changed
+	if configs[0].Type != "test_observability" || configs[0].Phase != "" {
+		t.Error("the configs should not be changed")
+	}
+}
+
+func TestValidateStreamFilterPhase(t *testing.T) {
+	if err := ValidateStreamFilterPhase(v2.Filter{Type: "test"}); err != nil {
+		t.Errorf("empty phase should be valid: %v", err)
+	}
+	if err := ValidateStreamFilterPhase(v2.Filter{Type: "test", Phase: v2.StreamFilterPhaseAuthz}); err != nil {
+		t.Errorf("authz phase should be valid: %v", err)
+	}
+	if err := ValidateStreamFilterPhase(v2.Filter{Type: "test", Phase: "authorization"}); err == nil {
+		t.Error("unknown phase should be invalid")
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/buffer"
+	"mosn.io/mosn/pkg/log"
+)
+
+func init() {
+	buffer.RegisterBuffer(&requestObjectsIns)
+}
+
+// RequestPool is a pool of the request-scoped objects allocated by the stream filters.
+// An object taken by Get is tied to the stream of the context and is reset and released
+// when the stream is cleaned, so the filters neither leak nor re-allocate per request scratch structures.
+type RequestPool struct {
+	name    string
+	newFunc func() interface{}
+	reset   func(interface{})
+	pool    sync.Pool
+
+	// quarantine keeps the released objects in debug mode
+	mux        sync.Mutex
+	quarantine []releasedObject
+}
+
+type releasedObject struct {
+	value       interface{}
+	fingerprint string
+	caller      string
+}
+
+// requestPoolDebug is 1 if the use-after-release detection is enabled
+var requestPoolDebug int32
+
+// requestPoolQuarantineSize is the number of the released objects kept by a pool in debug mode
+const requestPoolQuarantineSize = 64
+
+// reportUseAfterRelease is called if a released object is modified, it can be replaced in tests
+var reportUseAfterRelease = func(name, caller string) {
+	log.DefaultLogger.Errorf("[filter] [pool] object of request pool %s is used after release, taken at %s", name, caller)
+}
+
+// SetRequestPoolDebug enables or disables the use-after-release detection of the request pools.
+// In debug mode a released object is kept for a while before it is reused, if it is modified
+// during the time an error is logged and the object is dropped.
+func SetRequestPoolDebug(enable bool) {
+	if enable {
+		atomic.StoreInt32(&requestPoolDebug, 1)
+	} else {
+		atomic.StoreInt32(&requestPoolDebug, 0)
+	}
+}
+
+func requestPoolDebugEnabled() bool {
+	return atomic.LoadInt32(&requestPoolDebug) == 1
+}
+
+// NewRequestPool creates a request pool, newFunc should return a pointer,
+// reset is called before the object is released, it can be nil if no reset is needed.
+func NewRequestPool(name string, newFunc func() interface{}, reset func(interface{})) *RequestPool {
+	return &RequestPool{
+		name:    name,
+		newFunc: newFunc,
+		reset:   reset,
+	}
+}
+
+// Name returns the name of the pool
+func (p *RequestPool) Name() string {
+	return p.name
+}
+
+// Get returns an object from the pool, the object is released when the stream of ctx is cleaned.
+// The object must not be used after the stream is finished.
+func (p *RequestPool) Get(ctx context.Context) interface{} {
+	value := p.pool.Get()
+	if value == nil {
+		value = p.newFunc()
+	}
+	objects := buffer.PoolContext(ctx).Find(&requestObjectsIns, nil).(*requestObjects)
+	obj := requestObject{
+		pool:  p,
+		value: value,
+	}
+	if requestPoolDebugEnabled() {
+		if _, file, line, ok := runtime.Caller(1); ok {
+			obj.caller = fmt.Sprintf("%s:%d", file, line)
+		}
+	}
+	objects.objects = append(objects.objects, obj)
+	return value
+}
+
+func (p *RequestPool) release(obj requestObject) {
+	if p.reset != nil {
+		p.reset(obj.value)
+	}
+	if !requestPoolDebugEnabled() {
+		p.pool.Put(obj.value)
+		return
+	}
+	released := releasedObject{
+		value:       obj.value,
+		fingerprint: fingerprint(obj.value),
+		caller:      obj.caller,
+	}
+	p.mux.Lock()
+	p.quarantine = append(p.quarantine, released)
+	var evicted []releasedObject
+	if len(p.quarantine) > requestPoolQuarantineSize {
+		n := len(p.quarantine) - requestPoolQuarantineSize
+		evicted = append(evicted, p.quarantine[:n]...)
+		p.quarantine = append(p.quarantine[:0], p.quarantine[n:]...)
+	}
+	p.mux.Unlock()
+	for _, e := range evicted {
+		p.recycle(e)
+	}
+}
+
+// recycle puts the quarantined object back to the pool if it is not modified after release
+func (p *RequestPool) recycle(released releasedObject) {
+	if fingerprint(released.value) != released.fingerprint {
+		reportUseAfterRelease(p.name, released.caller)
+		return
+	}
+	p.pool.Put(released.value)
+}
+
+// Verify checks all the objects released in debug mode, the objects modified after release are reported.
+func (p *RequestPool) Verify() {
+	p.mux.Lock()
+	quarantine := p.quarantine
+	p.quarantine = nil
+	p.mux.Unlock()
+	for _, released := range quarantine {
+		p.recycle(released)
+	}
+}
+
+// fingerprint is the content of the object that is compared to detect the modifications
+func fingerprint(value interface{}) string {
+	return fmt.Sprintf("%+v", value)
+}
+
+// requestObjects is the request pool objects taken in a stream,
+// it is released with the other buffers of the stream.
+type requestObjects struct {
+	objects []requestObject
+}
+
+type requestObject struct {
+	pool   *RequestPool
+	value  interface{}
+	caller string
+}
+
+var requestObjectsIns = requestObjectsCtx{}
+
+type requestObjectsCtx struct {
+	buffer.TempBufferCtx
+}
+
+func (ctx requestObjectsCtx) New() interface{} {
+	return new(requestObjects)
+}
+
+func (ctx requestObjectsCtx) Reset(i interface{}) {
+	objects, _ := i.(*requestObjects)
+	for i, obj := range objects.objects {
+		obj.pool.release(obj)
+		objects.objects[i] = requestObject{}
+	}
+	objects.objects = objects.objects[:0]
+}
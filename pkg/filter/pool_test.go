@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/buffer"
+)
+
+type scratch struct {
+	values []string
+	count  int
+}
+
+func newScratchPool() *RequestPool {
+	return NewRequestPool("scratch", func() interface{} {
+		return &scratch{}
+	}, func(i interface{}) {
+		s := i.(*scratch)
+		s.values = s.values[:0]
+		s.count = 0
+	})
+}
+
+func TestRequestPoolRelease(t *testing.T) {
+	pool := newScratchPool()
+	ctx := buffer.NewBufferPoolContext(context.Background())
+	s1 := pool.Get(ctx).(*scratch)
+	s2 := pool.Get(ctx).(*scratch)
+	if s1 == s2 {
+		t.Fatal("objects taken in a stream should be different")
+	}
+	s1.values = append(s1.values, "a")
+	s2.count = 2
+
+	buffer.PoolContext(ctx).Give()
+	if len(s1.values) != 0 || s2.count != 0 {
+		t.Errorf("objects are not reset at release: %+v, %+v", s1, s2)
+	}
+}
+
+func TestRequestPoolUseAfterRelease(t *testing.T) {
+	SetRequestPoolDebug(true)
+	defer SetRequestPoolDebug(false)
+	var reported []string
+	report := reportUseAfterRelease
+	reportUseAfterRelease = func(name, caller string) {
+		reported = append(reported, name+" "+caller)
+	}
+	defer func() {
+		reportUseAfterRelease = report
+	}()
+
+	pool := newScratchPool()
+	ctx := buffer.NewBufferPoolContext(context.Background())
+	leaked := pool.Get(ctx).(*scratch)
+	clean := pool.Get(ctx).(*scratch)
+	buffer.PoolContext(ctx).Give()
+
+	// the leaked object is used after the stream is finished
+	leaked.count = 1
+	pool.Verify()
+	if len(reported) != 1 {
+		t.Fatalf("use after release is not reported: %v", reported)
+	}
+	// the modified object is dropped, the clean one is reused
+	ctx = buffer.NewBufferPoolContext(context.Background())
+	for i := 0; i < 2; i++ {
+		if s := pool.Get(ctx).(*scratch); s == leaked {
+			t.Error("the object used after release should not be reused")
+		} else if s.count != 0 {
+			t.Errorf("object from the pool is not reset: %+v", s)
+		}
+	}
+	_ = clean
+	buffer.PoolContext(ctx).Give()
+}
+
+func TestRequestPoolQuarantineSize(t *testing.T) {
+	SetRequestPoolDebug(true)
+	defer SetRequestPoolDebug(false)
+	pool := newScratchPool()
+	for i := 0; i < requestPoolQuarantineSize*2; i++ {
+		ctx := buffer.NewBufferPoolContext(context.Background())
+		pool.Get(ctx)
+		buffer.PoolContext(ctx).Give()
+	}
+	pool.mux.Lock()
+	n := len(pool.quarantine)
+	pool.mux.Unlock()
+	if n != requestPoolQuarantineSize {
+		t.Errorf("quarantine size expected %d, but got %d", requestPoolQuarantineSize, n)
+	}
+}
+
+func BenchmarkRequestPool(b *testing.B) {
+	pool := newScratchPool()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx := buffer.NewBufferPoolContext(context.Background())
+		s := pool.Get(ctx).(*scratch)
+		s.values = append(s.values, "a")
+		buffer.PoolContext(ctx).Give()
+	}
+}
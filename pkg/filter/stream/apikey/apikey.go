@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// nameMetadata is the metadata key of the key name
+const nameMetadata = "name"
+
+// apiKeyFilter validates the api key in the request, and sets the metadata of the key to the request headers,
+// so the following filters, such as the rate limiter, can use them
+type apiKeyFilter struct {
+	ctx     context.Context
+	config  *v2.StreamAPIKey
+	store   *keyStore
+	handler api.StreamReceiverFilterHandler
+}
+
+func NewFilter(ctx context.Context, config *v2.StreamAPIKey, store *keyStore) api.StreamReceiverFilter {
+	return &apiKeyFilter{
+		ctx:    ctx,
+		config: config,
+		store:  store,
+	}
+}
+
+func (f *apiKeyFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *apiKeyFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	// the metadata headers from downstream are removed, they can not be forged
+	var forged []string
+	headers.Range(func(key, value string) bool {
+		if strings.HasPrefix(strings.ToLower(key), f.config.MetadataPrefix) {
+			forged = append(forged, key)
+		}
+		return true
+	})
+	for _, key := range forged {
+		headers.Del(key)
+	}
+
+	key := f.getKey(headers)
+	if key == "" {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [api key] no api key found in request")
+		}
+		f.handler.RequestInfo().SetResponseFlag(types.UnauthorizedExternalService)
+		f.handler.SendHijackReply(http.StatusUnauthorized, nil)
+		return api.StreamFilterStop
+	}
+	k, ok := f.store.lookup(key)
+	if !ok || k.Disabled {
+		log.Proxy.Infof(ctx, "[stream filter] [api key] invalid api key, found: %v", ok)
+		f.handler.RequestInfo().SetResponseFlag(types.UnauthorizedExternalService)
+		f.handler.SendHijackReply(http.StatusForbidden, nil)
+		return api.StreamFilterStop
+	}
+	if k.Name != "" {
+		headers.Set(f.config.MetadataPrefix+nameMetadata, k.Name)
+	}
+	for mk, mv := range k.Metadata {
+		headers.Set(f.config.MetadataPrefix+strings.ToLower(mk), mv)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *apiKeyFilter) getKey(headers api.HeaderMap) string {
+	if v, ok := headers.Get(f.config.Header); ok && v != "" {
+		return v
+	}
+	if f.config.QueryParam != "" {
+		if query, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok && query != "" {
+			if values, err := url.ParseQuery(query); err == nil {
+				return values.Get(f.config.QueryParam)
+			}
+		}
+	}
+	return ""
+}
+
+func (f *apiKeyFilter) OnDestroy() {}
@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code int
+	info api.RequestInfo
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	if h.info == nil {
+		h.info = network.NewRequestInfo()
+	}
+	return h.info
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+const testKeys = `{
+	"keys": [
+		{"key": "key1", "name": "client1", "metadata": {"tenant": "t1", "Tier": "gold"}},
+		{"key_sha256": "%s", "name": "client2"},
+		{"key": "key3", "disabled": true}
+	]
+}`
+
+func TestAPIKeyFilter(t *testing.T) {
+	f, err := ioutil.TempFile("", "apikeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	fmt.Fprintf(f, testKeys, hashKey("key2"))
+	f.Close()
+
+	factory, err := CreateAPIKeyFilterFactory(map[string]interface{}{
+		"query_param": "apikey",
+		"key_store": map[string]interface{}{
+			"path": f.Name(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := factory.(*FilterConfigFactory)
+	for i, c := range []struct {
+		headers protocol.CommonHeader
+		code    int
+		expect  map[string]string
+	}{
+		{protocol.CommonHeader{}, http.StatusUnauthorized, nil},
+		{protocol.CommonHeader{"x-api-key": "unknown"}, http.StatusForbidden, nil},
+		{protocol.CommonHeader{"x-api-key": "key3"}, http.StatusForbidden, nil},
+		{
+			protocol.CommonHeader{"x-api-key": "key1", "x-mosn-apikey-tenant": "forged", "x-mosn-apikey-admin": "true"},
+			0,
+			map[string]string{"x-mosn-apikey-name": "client1", "x-mosn-apikey-tenant": "t1", "x-mosn-apikey-tier": "gold", "x-mosn-apikey-admin": ""},
+		},
+		{
+			protocol.CommonHeader{protocol.MosnHeaderQueryStringKey: "a=b&apikey=key2"},
+			0,
+			map[string]string{"x-mosn-apikey-name": "client2"},
+		},
+	} {
+		h := &mockReceiverHandler{}
+		filter := NewFilter(context.Background(), ff.Config, ff.store)
+		filter.SetReceiveFilterHandler(h)
+		status := filter.OnReceive(context.Background(), c.headers, nil, nil)
+		if h.code != c.code || (c.code == 0) != (status == api.StreamFilterContinue) {
+			t.Errorf("case %d: unexpected result, code: %d, status: %v", i, h.code, status)
+		}
+		if (c.code != 0) != h.RequestInfo().GetResponseFlag(types.UnauthorizedExternalService) {
+			t.Errorf("case %d: unexpected response flag", i)
+		}
+		for k, v := range c.expect {
+			if c.headers[k] != v {
+				t.Errorf("case %d: header %s expected %s, but got %s", i, k, v, c.headers[k])
+			}
+		}
+	}
+	// the key store is shared
+	if s, _ := getKeyStore(v2.APIKeyStore{Path: f.Name()}); s != ff.store {
+		t.Error("key store is not shared")
+	}
+}
+
+func TestRemoteKeyStoreReload(t *testing.T) {
+	var keys atomic.Value
+	keys.Store(`{"keys": [{"key": "key1"}]}`)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(keys.Load().(string)))
+	}))
+	defer s.Close()
+	store, err := getKeyStore(v2.APIKeyStore{
+		URL:             s.URL,
+		RefreshInterval: api.DurationConfig{Duration: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.lookup("key1"); !ok {
+		t.Fatal("key1 not found")
+	}
+	keys.Store(`{"keys": [{"key": "key2"}]}`)
+	time.Sleep(200 * time.Millisecond)
+	if _, ok := store.lookup("key2"); !ok {
+		t.Fatal("key store is not reloaded")
+	}
+	if _, ok := store.lookup("key1"); ok {
+		t.Fatal("removed key is still found")
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+)
+
+const (
+	defaultHeader         = "x-api-key"
+	defaultMetadataPrefix = "x-mosn-apikey-"
+)
+
+func init() {
+	api.RegisterStream(v2.APIKey, CreateAPIKeyFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.APIKey, v2.StreamFilterPhaseAuthn)
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamAPIKey
+	store  *keyStore
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config, f.store)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+}
+
+func CreateAPIKeyFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create api key stream filter factory")
+	cfg, err := ParseStreamAPIKeyFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	store, err := getKeyStore(cfg.KeyStore)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: cfg,
+		store:  store,
+	}, nil
+}
+
+// ParseStreamAPIKeyFilter
+func ParseStreamAPIKeyFilter(cfg map[string]interface{}) (*v2.StreamAPIKey, error) {
+	filterConfig := &v2.StreamAPIKey{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.Header == "" {
+		filterConfig.Header = defaultHeader
+	}
+	if filterConfig.MetadataPrefix == "" {
+		filterConfig.MetadataPrefix = defaultMetadataPrefix
+	}
+	filterConfig.MetadataPrefix = strings.ToLower(filterConfig.MetadataPrefix)
+	return filterConfig, nil
+}
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apikey
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultRefreshInterval = 30 * time.Second
+	remoteTimeout          = 10 * time.Second
+)
+
+// apiKey is a key in the key store, the key can be stored as plain text or sha256 hex
+type apiKey struct {
+	Key       string            `json:"key,omitempty"`
+	KeySHA256 string            `json:"key_sha256,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
+}
+
+type keyFile struct {
+	Keys []*apiKey `json:"keys"`
+}
+
+// keyStore holds the keys indexed by the sha256 of key, and reloads the keys periodically.
+// The key stores are shared by the filters with the same source.
+type keyStore struct {
+	source   string
+	load     func() ([]byte, error)
+	interval time.Duration
+	keys     atomic.Value // map[string]*apiKey
+	content  []byte
+}
+
+var (
+	keyStores   = make(map[string]*keyStore)
+	keyStoresMu sync.Mutex
+)
+
+func getKeyStore(cfg v2.APIKeyStore) (*keyStore, error) {
+	source := cfg.Path
+	if source == "" {
+		source = cfg.URL
+	}
+	if source == "" {
+		return nil, errors.New("key store path or url is required")
+	}
+	keyStoresMu.Lock()
+	defer keyStoresMu.Unlock()
+	if s, ok := keyStores[source]; ok {
+		return s, nil
+	}
+	s := &keyStore{
+		source:   source,
+		interval: cfg.RefreshInterval.Duration,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultRefreshInterval
+	}
+	if cfg.Path != "" {
+		s.load = func() ([]byte, error) {
+			return ioutil.ReadFile(cfg.Path)
+		}
+	} else {
+		client := &http.Client{Timeout: remoteTimeout}
+		s.load = func() ([]byte, error) {
+			resp, err := client.Get(cfg.URL)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("remote key store returns status %d", resp.StatusCode)
+			}
+			return ioutil.ReadAll(resp.Body)
+		}
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	keyStores[source] = s
+	utils.GoWithRecover(s.run, nil)
+	return s, nil
+}
+
+func (s *keyStore) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.reload(); err != nil {
+			// keep the last loaded keys
+			log.DefaultLogger.Errorf("[stream filter] [api key] reload key store %s failed: %v", s.source, err)
+		}
+	}
+}
+
+func (s *keyStore) reload() error {
+	content, err := s.load()
+	if err != nil {
+		return err
+	}
+	if s.content != nil && bytes.Equal(content, s.content) {
+		return nil
+	}
+	f := &keyFile{}
+	if err := json.Unmarshal(content, f); err != nil {
+		return err
+	}
+	keys := make(map[string]*apiKey, len(f.Keys))
+	for _, k := range f.Keys {
+		hash := strings.ToLower(k.KeySHA256)
+		if k.Key != "" {
+			hash = hashKey(k.Key)
+		}
+		if hash == "" {
+			continue
+		}
+		keys[hash] = k
+	}
+	s.keys.Store(keys)
+	s.content = content
+	log.DefaultLogger.Infof("[stream filter] [api key] key store %s loaded, keys: %d", s.source, len(keys))
+	return nil
+}
+
+func (s *keyStore) lookup(key string) (*apiKey, bool) {
+	keys, _ := s.keys.Load().(map[string]*apiKey)
+	k, ok := keys[hashKey(key)]
+	return k, ok
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
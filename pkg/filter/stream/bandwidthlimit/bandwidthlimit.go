@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidthlimit
+
+import (
+	"context"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/buffer"
+)
+
+// bandwidthLimitFilter delays the request and response bodies until the token buckets have
+// enough tokens. The downstream connection reading is paused while the request is throttled.
+type bandwidthLimitFilter struct {
+	ctx            context.Context
+	config         *v2.StreamBandwidthLimit
+	conns          *connRegistry
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	// perStream is true if the buckets are not shared by the connection
+	perStream bool
+	request   *tokenBucket
+	response  *tokenBucket
+	stop      chan struct{}
+}
+
+func NewFilter(ctx context.Context, config *v2.StreamBandwidthLimit, conns *connRegistry) *bandwidthLimitFilter {
+	return &bandwidthLimitFilter{
+		ctx:       ctx,
+		config:    config,
+		conns:     conns,
+		perStream: !config.PerConnection,
+		stop:      make(chan struct{}),
+	}
+}
+
+// ReadPerRouteConfig makes route-level configuration override filter-level configuration
+func (f *bandwidthLimitFilter) ReadPerRouteConfig(cfg map[string]interface{}) {
+	if cfg == nil {
+		return
+	}
+	if limit, ok := cfg[v2.BandwidthLimit]; ok {
+		conf, ok := limit.(map[string]interface{})
+		if !ok {
+			return
+		}
+		config, err := ParseStreamBandwidthLimitFilter(conf)
+		if err != nil {
+			log.DefaultLogger.Errorf("[stream filter] [bandwidth limit] invalid route config: %v", err)
+			return
+		}
+		f.config = config
+		f.perStream = true
+	}
+}
+
+func (f *bandwidthLimitFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *bandwidthLimitFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+func (f *bandwidthLimitFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if route := f.receiveHandler.Route(); route != nil {
+		f.ReadPerRouteConfig(route.RouteRule().PerFilterConfig())
+	}
+	f.initBuckets(f.receiveHandler.Connection())
+	if f.request == nil || buf == nil || buf.Len() == 0 {
+		return api.StreamFilterContinue
+	}
+	wait := f.request.reserve(buf.Len(), time.Now())
+	if wait <= 0 {
+		return api.StreamFilterContinue
+	}
+	if conn := f.receiveHandler.Connection(); conn != nil {
+		f.conns.pause(conn)
+		defer f.conns.resume(conn)
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(f.ctx, "[stream filter] [bandwidth limit] request body %d bytes is delayed %v", buf.Len(), wait)
+	}
+	if !f.wait(wait) {
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *bandwidthLimitFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.response == nil || buf == nil || buf.Len() == 0 {
+		return api.StreamFilterContinue
+	}
+	wait := f.response.reserve(buf.Len(), time.Now())
+	if wait <= 0 {
+		return api.StreamFilterContinue
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(f.ctx, "[stream filter] [bandwidth limit] response body %d bytes is delayed %v", buf.Len(), wait)
+	}
+	if !f.wait(wait) {
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *bandwidthLimitFilter) OnDestroy() {
+	close(f.stop)
+}
+
+// initBuckets creates the token buckets of the stream, or gets the buckets shared by the connection
+func (f *bandwidthLimitFilter) initBuckets(conn api.Connection) {
+	if !f.perStream && conn != nil {
+		f.request, f.response = f.conns.buckets(conn.ID(), f.config)
+		return
+	}
+	now := time.Now()
+	if f.config.Request != nil {
+		f.request = newTokenBucket(f.config.Request, now)
+	}
+	if f.config.Response != nil {
+		f.response = newTokenBucket(f.config.Response, now)
+	}
+}
+
+// wait returns false if the stream is destroyed while waiting
+func (f *bandwidthLimitFilter) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-f.stop:
+		return false
+	}
+}
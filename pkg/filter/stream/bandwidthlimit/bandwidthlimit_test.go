@@ -0,0 +1,172 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidthlimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/pkg/buffer"
+)
+
+type mockConnection struct {
+	api.Connection
+	id           uint64
+	readDisabled []bool
+}
+
+func (c *mockConnection) ID() uint64 {
+	return c.id
+}
+
+func (c *mockConnection) SetReadDisable(disable bool) {
+	c.readDisabled = append(c.readDisabled, disable)
+}
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	conn api.Connection
+}
+
+func (h *mockReceiverHandler) Route() api.Route {
+	return nil
+}
+
+func (h *mockReceiverHandler) Connection() api.Connection {
+	return h.conn
+}
+
+func createFactory(t *testing.T, conf map[string]interface{}) *FilterConfigFactory {
+	factory, err := CreateBandwidthLimitFilterFactory(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factory.(*FilterConfigFactory)
+}
+
+func TestParseConfig(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{
+		"request": map[string]interface{}{
+			"fill_rate": 1024,
+		},
+	})
+	if f.Config.Request.Burst != 1024 || f.Config.Response != nil {
+		t.Fatalf("unexpected config: %+v", f.Config)
+	}
+	if _, err := CreateBandwidthLimitFilterFactory(map[string]interface{}{
+		"response": map[string]interface{}{
+			"fill_rate": 0,
+		},
+	}); err == nil {
+		t.Fatal("expected invalid fill rate error")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(&v2.BandwidthLimitConfig{
+		FillRate: 1000,
+		Burst:    500,
+	}, now)
+	if wait := b.reserve(500, now); wait != 0 {
+		t.Fatalf("burst should be sent immediately, wait: %v", wait)
+	}
+	// 1000 bytes debt
+	if wait := b.reserve(1000, now); wait != time.Second {
+		t.Fatalf("unexpected wait: %v", wait)
+	}
+	// the debt is paid after one second
+	if wait := b.reserve(100, now.Add(1100*time.Millisecond)); wait != 0 {
+		t.Fatalf("unexpected wait: %v", wait)
+	}
+	// the tokens are limited by the burst
+	if wait := b.reserve(600, now.Add(time.Hour)); wait != 100*time.Millisecond {
+		t.Fatalf("unexpected wait: %v", wait)
+	}
+}
+
+func TestRequestThrottled(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{
+		"request": map[string]interface{}{
+			"fill_rate": 10000,
+			"burst":     1000,
+		},
+		"per_connection": true,
+	})
+	conn := &mockConnection{id: 1}
+	ctx := context.Background()
+	body := buffer.NewIoBufferString(string(make([]byte, 1000)))
+	// the first request is sent with the burst
+	first := NewFilter(ctx, f.Config, f.conns)
+	first.SetReceiveFilterHandler(&mockReceiverHandler{conn: conn})
+	if status := first.OnReceive(ctx, nil, body, nil); status != api.StreamFilterContinue {
+		t.Fatal("first request should be continued")
+	}
+	// the second request on the same connection waits for 100ms
+	second := NewFilter(ctx, f.Config, f.conns)
+	second.SetReceiveFilterHandler(&mockReceiverHandler{conn: conn})
+	start := time.Now()
+	if status := second.OnReceive(ctx, nil, body, nil); status != api.StreamFilterContinue {
+		t.Fatal("second request should be continued")
+	}
+	if cost := time.Since(start); cost < 80*time.Millisecond {
+		t.Fatalf("second request should be throttled, cost: %v", cost)
+	}
+	// the connection reading is paused while throttling
+	if len(conn.readDisabled) != 2 || !conn.readDisabled[0] || conn.readDisabled[1] {
+		t.Fatalf("unexpected read disable calls: %v", conn.readDisabled)
+	}
+	// a request on another connection is not throttled
+	other := NewFilter(ctx, f.Config, f.conns)
+	other.SetReceiveFilterHandler(&mockReceiverHandler{conn: &mockConnection{id: 2}})
+	start = time.Now()
+	other.OnReceive(ctx, nil, body, nil)
+	if cost := time.Since(start); cost > 50*time.Millisecond {
+		t.Fatalf("request on another connection should not be throttled, cost: %v", cost)
+	}
+}
+
+func TestResponseThrottledAndDestroyed(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{
+		"response": map[string]interface{}{
+			"fill_rate": 1000,
+		},
+	})
+	ctx := context.Background()
+	filter := NewFilter(ctx, f.Config, f.conns)
+	filter.SetReceiveFilterHandler(&mockReceiverHandler{})
+	filter.OnReceive(ctx, nil, nil, nil)
+	done := make(chan api.StreamFilterStatus)
+	go func() {
+		// 10 seconds to wait
+		done <- filter.Append(ctx, nil, buffer.NewIoBufferString(string(make([]byte, 11000))), nil)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	filter.OnDestroy()
+	select {
+	case status := <-done:
+		if status != api.StreamFilterStop {
+			t.Fatal("destroyed stream should be stopped")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("destroyed stream is still waiting")
+	}
+}
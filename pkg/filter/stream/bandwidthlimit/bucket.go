@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidthlimit
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// connIdleTimeout is the time that the token buckets of a connection are kept after last used
+const connIdleTimeout = time.Minute
+
+// tokenBucket is a token bucket in bytes, the tokens can be borrowed so a body larger
+// than the burst can be sent after waiting for the debt to be paid.
+type tokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(cfg *v2.BandwidthLimitConfig, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(cfg.FillRate),
+		burst:  float64(cfg.Burst),
+		tokens: float64(cfg.Burst),
+		last:   now,
+	}
+}
+
+// reserve takes n tokens from the bucket, returns the time to wait before the n bytes can be sent
+func (b *tokenBucket) reserve(n int, now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// connLimiter is the state of a downstream connection
+type connLimiter struct {
+	request  *tokenBucket
+	response *tokenBucket
+	// paused is the number of streams that pause the connection reading
+	paused     int
+	lastActive time.Time
+}
+
+// connRegistry keeps the connection states of the filter, the idle connections are pruned lazily
+type connRegistry struct {
+	mutex     sync.Mutex
+	conns     map[uint64]*connLimiter
+	lastPrune time.Time
+}
+
+func newConnRegistry() *connRegistry {
+	return &connRegistry{
+		conns:     make(map[uint64]*connLimiter),
+		lastPrune: time.Now(),
+	}
+}
+
+// buckets returns the token buckets shared by the streams on the connection
+func (r *connRegistry) buckets(id uint64, cfg *v2.StreamBandwidthLimit) (request, response *tokenBucket) {
+	now := time.Now()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	cl := r.get(id, now)
+	if cl.request == nil && cfg.Request != nil {
+		cl.request = newTokenBucket(cfg.Request, now)
+	}
+	if cl.response == nil && cfg.Response != nil {
+		cl.response = newTokenBucket(cfg.Response, now)
+	}
+	return cl.request, cl.response
+}
+
+func (r *connRegistry) get(id uint64, now time.Time) *connLimiter {
+	if now.Sub(r.lastPrune) > connIdleTimeout {
+		for cid, cl := range r.conns {
+			if cl.paused == 0 && now.Sub(cl.lastActive) > connIdleTimeout {
+				delete(r.conns, cid)
+			}
+		}
+		r.lastPrune = now
+	}
+	cl, ok := r.conns[id]
+	if !ok {
+		cl = &connLimiter{}
+		r.conns[id] = cl
+	}
+	cl.lastActive = now
+	return cl
+}
+
+// pause disables the connection reading while a request is throttled, so the downstream is
+// back-pressured by the tcp flow control instead of being buffered in mosn.
+func (r *connRegistry) pause(conn api.Connection) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	cl := r.get(conn.ID(), time.Now())
+	cl.paused++
+	if cl.paused == 1 {
+		conn.SetReadDisable(true)
+	}
+}
+
+func (r *connRegistry) resume(conn api.Connection) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	cl := r.get(conn.ID(), time.Now())
+	if cl.paused == 0 {
+		return
+	}
+	cl.paused--
+	if cl.paused == 0 {
+		conn.SetReadDisable(false)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bandwidthlimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+)
+
+func init() {
+	api.RegisterStream(v2.BandwidthLimit, CreateBandwidthLimitFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.BandwidthLimit, v2.StreamFilterPhaseRateLimit)
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamBandwidthLimit
+	conns  *connRegistry
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config, f.conns)
+	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateBandwidthLimitFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create bandwidth limit stream filter factory")
+	cfg, err := ParseStreamBandwidthLimitFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: cfg,
+		conns:  newConnRegistry(),
+	}, nil
+}
+
+// ParseStreamBandwidthLimitFilter
+func ParseStreamBandwidthLimitFilter(cfg map[string]interface{}) (*v2.StreamBandwidthLimit, error) {
+	filterConfig := &v2.StreamBandwidthLimit{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	for _, c := range []*v2.BandwidthLimitConfig{filterConfig.Request, filterConfig.Response} {
+		if c == nil {
+			continue
+		}
+		if c.FillRate <= 0 {
+			return nil, errors.New("fill rate should be greater than 0")
+		}
+		if c.Burst <= 0 {
+			c.Burst = c.FillRate
+		}
+	}
+	return filterConfig, nil
+}
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basicauth
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/authcache"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	authorizationHeader   = "authorization"
+	wwwAuthenticateHeader = "www-authenticate"
+	basicScheme           = "basic "
+)
+
+// basicAuthFilter authenticates the request with the http basic authentication
+type basicAuthFilter struct {
+	ctx     context.Context
+	config  *v2.StreamBasicAuth
+	users   *htpasswd
+	stats   *realmStats
+	cache   *authcache.Cache
+	handler api.StreamReceiverFilterHandler
+}
+
+// NewFilter creates a basic auth filter, the cache is optional
+func NewFilter(ctx context.Context, config *v2.StreamBasicAuth, users *htpasswd, stats *realmStats, cache *authcache.Cache) api.StreamReceiverFilter {
+	return &basicAuthFilter{
+		ctx:    ctx,
+		config: config,
+		users:  users,
+		stats:  stats,
+		cache:  cache,
+	}
+}
+
+func (f *basicAuthFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *basicAuthFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	// the user header from downstream is removed, it can not be forged
+	if f.config.UserHeader != "" {
+		headers.Del(f.config.UserHeader)
+	}
+	user, pw, ok := parseBasicAuth(headers)
+	if !ok {
+		f.stats.missingCredentials.Inc(1)
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [basic auth] no basic credentials found in request")
+		}
+		f.unauthorized()
+		return api.StreamFilterStop
+	}
+	exists, matched := f.authenticate(user, pw)
+	if !exists {
+		f.stats.unknownUser.Inc(1)
+		log.Proxy.Infof(ctx, "[stream filter] [basic auth] unknown user %s", user)
+		f.unauthorized()
+		return api.StreamFilterStop
+	}
+	if !matched {
+		f.stats.user(user).denied.Inc(1)
+		log.Proxy.Infof(ctx, "[stream filter] [basic auth] password mismatch for user %s", user)
+		f.unauthorized()
+		return api.StreamFilterStop
+	}
+	f.stats.user(user).allowed.Inc(1)
+	if f.config.UserHeader != "" {
+		headers.Set(f.config.UserHeader, user)
+	}
+	return api.StreamFilterContinue
+}
+
+// authenticate verifies the password of the user, the results of the existing users are cached.
+// the cache key contains the generation of the htpasswd file, so the results are not used after reload
+func (f *basicAuthFilter) authenticate(user, pw string) (bool, bool) {
+	if f.cache == nil {
+		return f.users.authenticate(user, pw)
+	}
+	key := authcache.NewKey(f.users.path, strconv.FormatUint(f.users.currentGeneration(), 10), user, pw)
+	if allowed, _, ok := f.cache.Get(key); ok {
+		return true, allowed
+	}
+	exists, matched := f.users.authenticate(user, pw)
+	if exists {
+		f.cache.Set(key, matched, user)
+	}
+	return exists, matched
+}
+
+func (f *basicAuthFilter) unauthorized() {
+	f.handler.RequestInfo().SetResponseFlag(types.UnauthorizedExternalService)
+	f.handler.SendHijackReply(http.StatusUnauthorized, protocol.CommonHeader{
+		wwwAuthenticateHeader: "Basic realm=" + strconv.Quote(f.config.Realm),
+	})
+}
+
+func (f *basicAuthFilter) OnDestroy() {}
+
+// parseBasicAuth parses the user and password from the authorization header
+func parseBasicAuth(headers api.HeaderMap) (string, string, bool) {
+	auth, ok := headers.Get(authorizationHeader)
+	if !ok {
+		auth, ok = headers.Get("Authorization")
+	}
+	if !ok || len(auth) < len(basicScheme) || !strings.EqualFold(auth[:len(basicScheme)], basicScheme) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(auth[len(basicScheme):]))
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
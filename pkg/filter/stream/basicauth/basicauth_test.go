@@ -0,0 +1,298 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basicauth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code    int
+	headers api.HeaderMap
+	info    api.RequestInfo
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	if h.info == nil {
+		h.info = network.NewRequestInfo()
+	}
+	return h.info
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+	h.headers = headers
+}
+
+func basicAuth(user, pw string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pw))
+}
+
+func shaHash(pw string) string {
+	sum := sha1.Sum([]byte(pw))
+	return "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func writeHtpasswd(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBasicAuthFilter(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	writeHtpasswd(t, f.Name(), "# comment\n\nuser1:"+string(hash)+"\nuser2:"+shaHash("secret2")+"\nuser3:plain\n")
+
+	factory, err := CreateBasicAuthFilterFactory(map[string]interface{}{
+		"realm":         "test",
+		"htpasswd_file": f.Name(),
+		"user_header":   "x-auth-user",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := factory.(*FilterConfigFactory)
+	for i, c := range []struct {
+		headers protocol.CommonHeader
+		code    int
+		user    string
+	}{
+		{protocol.CommonHeader{}, http.StatusUnauthorized, ""},
+		{protocol.CommonHeader{"authorization": "Bearer token"}, http.StatusUnauthorized, ""},
+		{protocol.CommonHeader{"authorization": "Basic !!!"}, http.StatusUnauthorized, ""},
+		{protocol.CommonHeader{"authorization": basicAuth("user1", "wrong")}, http.StatusUnauthorized, ""},
+		{protocol.CommonHeader{"authorization": basicAuth("unknown", "secret1")}, http.StatusUnauthorized, ""},
+		// the unsupported hash is ignored
+		{protocol.CommonHeader{"authorization": basicAuth("user3", "plain")}, http.StatusUnauthorized, ""},
+		{protocol.CommonHeader{"authorization": basicAuth("user1", "secret1"), "x-auth-user": "forged"}, 0, "user1"},
+		{protocol.CommonHeader{"Authorization": basicAuth("user2", "secret2")}, 0, "user2"},
+	} {
+		handler := &mockReceiverHandler{}
+		filter := NewFilter(context.Background(), ff.Config, ff.users, ff.stats, nil)
+		filter.SetReceiveFilterHandler(handler)
+		status := filter.OnReceive(context.Background(), c.headers, nil, nil)
+		if handler.code != c.code {
+			t.Fatalf("case %d expected code %d, but got %d", i, c.code, handler.code)
+		}
+		if c.code != 0 {
+			if status != api.StreamFilterStop {
+				t.Fatalf("case %d expected stop", i)
+			}
+			if v, _ := handler.headers.Get("www-authenticate"); v != `Basic realm="test"` {
+				t.Fatalf("case %d unexpected www-authenticate: %s", i, v)
+			}
+			continue
+		}
+		if status != api.StreamFilterContinue {
+			t.Fatalf("case %d expected continue", i)
+		}
+		if v, _ := c.headers.Get("x-auth-user"); v != c.user {
+			t.Fatalf("case %d expected user header %s, but got %s", i, c.user, v)
+		}
+	}
+	if ff.stats.user("user1").allowed.Count() != 1 || ff.stats.user("user1").denied.Count() != 1 {
+		t.Fatal("unexpected user1 stats")
+	}
+	if ff.stats.unknownUser.Count() != 2 || ff.stats.missingCredentials.Count() != 3 {
+		t.Fatalf("unexpected realm stats: %d, %d", ff.stats.unknownUser.Count(), ff.stats.missingCredentials.Count())
+	}
+}
+
+func TestHtpasswdReload(t *testing.T) {
+	f, err := ioutil.TempFile("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	writeHtpasswd(t, f.Name(), "user1:"+shaHash("secret1")+"\n")
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	h, err := newHtpasswd(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.authenticate("user1", "secret1"); !ok {
+		t.Fatal("user1 should be authenticated")
+	}
+	writeHtpasswd(t, f.Name(), "user2:"+shaHash("secret2")+"\n")
+	os.Chtimes(f.Name(), now.Add(time.Minute), now.Add(time.Minute))
+	// not reloaded in the check interval
+	if exists, _ := h.authenticate("user2", "secret2"); exists {
+		t.Fatal("htpasswd should not be reloaded in check interval")
+	}
+	now = now.Add(htpasswdCheckInterval)
+	if exists, _ := h.authenticate("user1", "secret1"); exists {
+		t.Fatal("user1 should be removed after reload")
+	}
+	if _, ok := h.authenticate("user2", "secret2"); !ok {
+		t.Fatal("user2 should be authenticated after reload")
+	}
+	// the last loaded users are kept if the file is removed
+	os.Remove(f.Name())
+	now = now.Add(htpasswdCheckInterval)
+	if _, ok := h.authenticate("user2", "secret2"); !ok {
+		t.Fatal("user2 should be kept")
+	}
+}
+
+func TestCreateBasicAuthFilterFactoryError(t *testing.T) {
+	if _, err := CreateBasicAuthFilterFactory(map[string]interface{}{}); err == nil {
+		t.Fatal("expected error without htpasswd file")
+	}
+	if _, err := CreateBasicAuthFilterFactory(map[string]interface{}{"htpasswd_file": "/not/exists/htpasswd"}); err == nil {
+		t.Fatal("expected error with not exists htpasswd file")
+	}
+}
+
+func TestBasicAuthFilterCache(t *testing.T) {
+	f, err := ioutil.TempFile("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	writeHtpasswd(t, f.Name(), "user1:"+shaHash("secret1")+"\n")
+
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	factory, err := CreateBasicAuthFilterFactory(map[string]interface{}{
+		"realm":         "test_cache",
+		"htpasswd_file": f.Name(),
+		"cache": map[string]interface{}{
+			"name": "test_basic_auth_cache",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := factory.(*FilterConfigFactory)
+	if ff.cache == nil {
+		t.Fatal("expected cache created")
+	}
+	check := func(user, pw string, code int) {
+		handler := &mockReceiverHandler{}
+		filter := NewFilter(context.Background(), ff.Config, ff.users, ff.stats, ff.cache)
+		filter.SetReceiveFilterHandler(handler)
+		filter.OnReceive(context.Background(), protocol.CommonHeader{"authorization": basicAuth(user, pw)}, nil, nil)
+		if handler.code != code {
+			t.Fatalf("%s:%s expected code %d, but got %d", user, pw, code, handler.code)
+		}
+	}
+	check("user1", "secret1", 0)
+	check("user1", "secret1", 0)
+	check("user1", "wrong", http.StatusUnauthorized)
+	check("user1", "wrong", http.StatusUnauthorized)
+	// the unknown users are not cached
+	check("unknown", "secret1", http.StatusUnauthorized)
+	if ff.cache.Len() != 2 {
+		t.Fatalf("expected 2 decisions cached, but got %d", ff.cache.Len())
+	}
+	// the cached decisions are not used after the file reloaded
+	writeHtpasswd(t, f.Name(), "user1:"+shaHash("wrong")+"\n")
+	os.Chtimes(f.Name(), now.Add(time.Minute), now.Add(time.Minute))
+	now = now.Add(htpasswdCheckInterval)
+	check("user1", "secret1", http.StatusUnauthorized)
+	check("user1", "wrong", 0)
+}
+
+func TestBasicAuthBcryptDummy(t *testing.T) {
+	f, err := ioutil.TempFile("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret1"), bcrypt.MinCost+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeHtpasswd(t, f.Name(), "user1:"+string(hash)+"\n")
+
+	factory, err := CreateBasicAuthFilterFactory(map[string]interface{}{
+		"realm":         "test_bcrypt",
+		"htpasswd_file": f.Name(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := factory.(*FilterConfigFactory)
+	// the decisions are cached by default for the bcrypt passwords
+	if ff.cache == nil {
+		t.Fatal("expected cache created for bcrypt passwords")
+	}
+	// the unknown users are verified against a dummy bcrypt hash with the same cost
+	dummy, ok := ff.users.dummy.(bcryptPassword)
+	if !ok {
+		t.Fatalf("expected bcrypt dummy password, but got %T", ff.users.dummy)
+	}
+	if cost, _ := bcrypt.Cost(dummy); cost != bcrypt.MinCost+1 {
+		t.Fatalf("expected dummy cost %d, but got %d", bcrypt.MinCost+1, cost)
+	}
+	if exists, ok := ff.users.authenticate("unknown", "secret1"); exists || ok {
+		t.Fatal("unknown user should not be authenticated")
+	}
+	if _, ok := ff.users.authenticate("user1", "secret1"); !ok {
+		t.Fatal("user1 should be authenticated")
+	}
+
+	// no cache is created by default for the sha passwords
+	sf, err := ioutil.TempFile("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf.Close()
+	defer os.Remove(sf.Name())
+	writeHtpasswd(t, sf.Name(), "user1:"+shaHash("secret1")+"\n")
+	factory, err = CreateBasicAuthFilterFactory(map[string]interface{}{
+		"htpasswd_file": sf.Name(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if factory.(*FilterConfigFactory).cache != nil {
+		t.Fatal("expected no cache for sha passwords")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basicauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/authcache"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+)
+
+const defaultRealm = "mosn"
+
+func init() {
+	api.RegisterStream(v2.BasicAuth, CreateBasicAuthFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.BasicAuth, v2.StreamFilterPhaseAuthn)
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamBasicAuth
+	users  *htpasswd
+	stats  *realmStats
+	cache  *authcache.Cache
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config, f.users, f.stats, f.cache)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+}
+
+func CreateBasicAuthFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create basic auth stream filter factory")
+	cfg, err := ParseStreamBasicAuthFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	users, err := getHtpasswd(cfg.HtpasswdFile)
+	if err != nil {
+		return nil, err
+	}
+	factory := &FilterConfigFactory{
+		Config: cfg,
+		users:  users,
+		stats:  newRealmStats(cfg.Realm),
+	}
+	cacheConfig := cfg.Cache
+	// the bcrypt passwords are slow to verify, the decisions are cached by default
+	if cacheConfig == nil && users.hasBcrypt() {
+		cacheConfig = &v2.AuthCache{}
+	}
+	if cacheConfig != nil {
+		name := cacheConfig.Name
+		if name == "" {
+			name = v2.BasicAuth
+		}
+		factory.cache = authcache.GetOrCreate(name, cacheConfig)
+	}
+	return factory, nil
+}
+
+// ParseStreamBasicAuthFilter
+func ParseStreamBasicAuthFilter(cfg map[string]interface{}) (*v2.StreamBasicAuth, error) {
+	filterConfig := &v2.StreamBasicAuth{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.HtpasswdFile == "" {
+		return nil, errors.New("basic auth filter requires htpasswd_file")
+	}
+	if filterConfig.Realm == "" {
+		filterConfig.Realm = defaultRealm
+	}
+	return filterConfig, nil
+}
@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basicauth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"mosn.io/mosn/pkg/log"
+)
+
+// the htpasswd file is checked for modification at most once in the interval
+const htpasswdCheckInterval = 5 * time.Second
+
+var timeNow = time.Now
+
+// password verifies the password of a user
+type password interface {
+	verify(pw string) bool
+}
+
+type bcryptPassword []byte
+
+func (p bcryptPassword) verify(pw string) bool {
+	return bcrypt.CompareHashAndPassword(p, []byte(pw)) == nil
+}
+
+type shaPassword []byte
+
+func (p shaPassword) verify(pw string) bool {
+	sum := sha1.Sum([]byte(pw))
+	return subtle.ConstantTimeCompare(p, sum[:]) == 1
+}
+
+func parsePassword(hash string) (password, error) {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcryptPassword(hash), nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum, err := base64.StdEncoding.DecodeString(hash[len("{SHA}"):])
+		if err != nil {
+			return nil, err
+		}
+		return shaPassword(sum), nil
+	default:
+		return nil, errors.New("unsupported password hash")
+	}
+}
+
+// dummyPassword returns the password verified for the unknown users, so the unknown users take as long as
+// the existing users to verify, and the user names can not be probed by the response time.
+// A bcrypt hash with the highest cost in the users is used if there are any bcrypt passwords.
+func dummyPassword(users map[string]password) password {
+	cost := 0
+	for _, p := range users {
+		if b, ok := p.(bcryptPassword); ok {
+			if c, err := bcrypt.Cost(b); err == nil && c > cost {
+				cost = c
+			}
+		}
+	}
+	if cost == 0 {
+		return shaPassword(make([]byte, sha1.Size))
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("mosn dummy password"), cost)
+	if err != nil {
+		return shaPassword(make([]byte, sha1.Size))
+	}
+	return bcryptPassword(hash)
+}
+
+// parseHtpasswd parses the lines of "user:hash", the lines with unsupported hash are ignored
+func parseHtpasswd(content []byte) map[string]password {
+	users := make(map[string]password)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		pw, err := parsePassword(parts[1])
+		if err != nil {
+			log.DefaultLogger.Errorf("[stream filter] [basic auth] ignore user %s: %v", parts[0], err)
+			continue
+		}
+		users[parts[0]] = pw
+	}
+	return users
+}
+
+// htpasswd holds the users of a htpasswd file, and reloads the file when it is modified
+type htpasswd struct {
+	path      string
+	mux       sync.RWMutex
+	users     map[string]password
+	dummy     password // verified for the unknown users
+	modTime   time.Time
+	lastCheck time.Time
+	// generation is increased when the file is loaded
+	generation uint64
+}
+
+var (
+	htpasswdsMux sync.Mutex
+	htpasswds    = make(map[string]*htpasswd)
+)
+
+// getHtpasswd returns the htpasswd of the path, the htpasswd is shared by the filters with the same file.
+func getHtpasswd(path string) (*htpasswd, error) {
+	htpasswdsMux.Lock()
+	defer htpasswdsMux.Unlock()
+	if h, ok := htpasswds[path]; ok {
+		return h, nil
+	}
+	h, err := newHtpasswd(path)
+	if err != nil {
+		return nil, err
+	}
+	htpasswds[path] = h
+	return h, nil
+}
+
+func newHtpasswd(path string) (*htpasswd, error) {
+	h := &htpasswd{path: path}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *htpasswd) load() error {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(h.path)
+	if err != nil {
+		return err
+	}
+	users := parseHtpasswd(content)
+	dummy := dummyPassword(users)
+	h.mux.Lock()
+	h.users = users
+	h.dummy = dummy
+	h.modTime = info.ModTime()
+	h.generation++
+	h.mux.Unlock()
+	log.DefaultLogger.Infof("[stream filter] [basic auth] htpasswd file %s loaded, users: %d", h.path, len(users))
+	return nil
+}
+
+// checkReload reloads the file if it is modified, the last loaded users are kept if reload failed
+func (h *htpasswd) checkReload() {
+	now := timeNow()
+	h.mux.Lock()
+	if now.Sub(h.lastCheck) < htpasswdCheckInterval {
+		h.mux.Unlock()
+		return
+	}
+	h.lastCheck = now
+	modTime := h.modTime
+	h.mux.Unlock()
+	info, err := os.Stat(h.path)
+	if err != nil || info.ModTime().Equal(modTime) {
+		return
+	}
+	if err := h.load(); err != nil {
+		log.DefaultLogger.Errorf("[stream filter] [basic auth] reload htpasswd file %s failed: %v", h.path, err)
+	}
+}
+
+// currentGeneration returns the generation of the users, the file is reloaded if it is modified
+func (h *htpasswd) currentGeneration() uint64 {
+	h.checkReload()
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	return h.generation
+}
+
+// hasBcrypt returns whether any user has a bcrypt password, which is slow to verify by design
+func (h *htpasswd) hasBcrypt() bool {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	_, ok := h.dummy.(bcryptPassword)
+	return ok
+}
+
+// authenticate returns whether the user exists and whether the password is matched.
+// The password of an unknown user is verified against a dummy hash, so it takes the same time as a known user.
+func (h *htpasswd) authenticate(user, pw string) (bool, bool) {
+	h.checkReload()
+	h.mux.RLock()
+	p, ok := h.users[user]
+	dummy := h.dummy
+	h.mux.RUnlock()
+	if !ok {
+		dummy.verify(pw)
+		return false, false
+	}
+	return true, p.verify(pw)
+}
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package basicauth
+
+import (
+	"sync"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+const (
+	basicAuthType = "basic_auth"
+
+	statsAllowed            = "allowed"
+	statsDenied             = "denied"
+	statsMissingCredentials = "missing_credentials"
+	statsUnknownUser        = "unknown_user"
+)
+
+// realmStats records the realm level stats, and the per user stats.
+// the per user stats are created only for the users in the htpasswd file.
+type realmStats struct {
+	realm              string
+	missingCredentials gometrics.Counter
+	unknownUser        gometrics.Counter
+	users              sync.Map // user -> *userStats
+}
+
+type userStats struct {
+	allowed gometrics.Counter
+	denied  gometrics.Counter
+}
+
+func newRealmStats(realm string) *realmStats {
+	m, _ := metrics.NewMetrics(basicAuthType, map[string]string{"realm": realm})
+	return &realmStats{
+		realm:              realm,
+		missingCredentials: m.Counter(statsMissingCredentials),
+		unknownUser:        m.Counter(statsUnknownUser),
+	}
+}
+
+func (s *realmStats) user(name string) *userStats {
+	if v, ok := s.users.Load(name); ok {
+		return v.(*userStats)
+	}
+	m, _ := metrics.NewMetrics(basicAuthType, map[string]string{"realm": s.realm, "user": name})
+	v, _ := s.users.LoadOrStore(name, &userStats{
+		allowed: m.Counter(statsAllowed),
+		denied:  m.Counter(statsDenied),
+	})
+	return v.(*userStats)
+}
@@ -0,0 +1,205 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coalesce
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// privateHeaders are always part of the coalesce key, the personalised responses are not shared across users
+var privateHeaders = []string{"authorization", "cookie"}
+
+// coalesceFilter collapses the concurrent identical requests into a single upstream request.
+// The first request (the leader) is sent to upstream, the others are paused until its response
+// and reply the copied response directly. If the leader is failed, or the wait is timeout,
+// the waiting requests are sent to upstream themselves.
+type coalesceFilter struct {
+	ctx     context.Context
+	config  *v2.StreamCoalesce
+	group   *group
+	handler api.StreamReceiverFilterHandler
+	// the in-flight call that the filter is leader of
+	key  string
+	call *call
+	// the waiter of the in-flight call that the filter waits for
+	waiter *waiter
+	timer  *time.Timer
+}
+
+func NewFilter(ctx context.Context, config *v2.StreamCoalesce, g *group) *coalesceFilter {
+	return &coalesceFilter{
+		ctx:    ctx,
+		config: config,
+		group:  g,
+	}
+}
+
+func (f *coalesceFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *coalesceFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {}
+
+func (f *coalesceFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	// the requests with body are not idempotent in general
+	if buf != nil && buf.Len() > 0 {
+		return api.StreamFilterContinue
+	}
+	method, _ := headers.Get(protocol.MosnHeaderMethod)
+	if !f.matchMethod(method) {
+		return api.StreamFilterContinue
+	}
+	key := f.coalesceKey(method, headers)
+	// the waiters pause the stream instead of blocking it, the requests can not wait without the async handler
+	handler, async := f.handler.(types.AsyncStreamReceiverFilterHandler)
+	var w *waiter
+	if async {
+		w = newWaiter(func(resp *response) {
+			f.wake(ctx, handler, key, resp)
+		})
+		// pause before joined, the waiter may be woken up before OnReceive returns
+		handler.PauseReceiving()
+	}
+	c, leader := f.group.join(key, f.config.MaxWaiters, w)
+	if c == nil {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [coalesce] too many waiters, send request directly, key: %s", key)
+		}
+		return api.StreamFilterContinue
+	}
+	if leader {
+		f.key = key
+		f.call = c
+		return api.StreamFilterContinue
+	}
+	f.waiter = w
+	f.timer = time.AfterFunc(f.config.Timeout.Duration, func() {
+		if w.notify(nil) && log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [coalesce] wait coalesced response timeout, key: %s", key)
+		}
+	})
+	return api.StreamFilterStop
+}
+
+// wake resumes the paused stream, replies the coalesced response, or sends the request to upstream
+// if the response is nil
+func (f *coalesceFilter) wake(ctx context.Context, handler types.AsyncStreamReceiverFilterHandler, key string, resp *response) {
+	if resp == nil {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [coalesce] no coalesced response, send request directly, key: %s", key)
+		}
+	} else {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [coalesce] reply with coalesced response, key: %s", key)
+		}
+		handler.SendDirectResponse(resp.clone())
+	}
+	handler.ContinueReceiving()
+}
+
+func (f *coalesceFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.call == nil {
+		return api.StreamFilterContinue
+	}
+	var resp *response
+	// the server errors are not shared, the waiters retry by themselves
+	if status, ok := headers.Get(types.HeaderStatus); !ok || !isServerError(status) {
+		resp = &response{
+			headers: headers.Clone(),
+		}
+		if buf != nil {
+			resp.data = append([]byte(nil), buf.Bytes()...)
+		}
+		if trailers != nil {
+			resp.trailers = trailers.Clone()
+		}
+	}
+	f.group.finish(f.key, f.call, resp)
+	f.call = nil
+	return api.StreamFilterContinue
+}
+
+func (f *coalesceFilter) OnDestroy() {
+	if f.waiter != nil {
+		f.waiter.cancel()
+		f.timer.Stop()
+	}
+	// the leader is finished without response
+	if f.call != nil {
+		f.group.finish(f.key, f.call, nil)
+		f.call = nil
+	}
+}
+
+func (f *coalesceFilter) matchMethod(method string) bool {
+	for _, m := range f.config.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// coalesceKey makes the key of a request by the cluster, method, path, query, private headers and vary headers
+func (f *coalesceFilter) coalesceKey(method string, headers api.HeaderMap) string {
+	var sb strings.Builder
+	if f.handler != nil {
+		if route := f.handler.Route(); route != nil && route.RouteRule() != nil {
+			sb.WriteString(route.RouteRule().ClusterName())
+		}
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(strings.ToUpper(method))
+	sb.WriteByte(' ')
+	path, _ := headers.Get(protocol.MosnHeaderPathKey)
+	sb.WriteString(path)
+	if query, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok && query != "" {
+		sb.WriteByte('?')
+		sb.WriteString(query)
+	}
+	for _, h := range privateHeaders {
+		if v, ok := headers.Get(h); ok {
+			sb.WriteByte('\n')
+			sb.WriteString(h)
+			sb.WriteByte('=')
+			sb.WriteString(v)
+		}
+	}
+	for _, h := range f.config.VaryHeaders {
+		v, _ := headers.Get(h)
+		sb.WriteByte('\n')
+		sb.WriteString(h)
+		sb.WriteByte('=')
+		sb.WriteString(v)
+	}
+	return sb.String()
+}
+
+func isServerError(status string) bool {
+	code, err := strconv.Atoi(status)
+	return err == nil && code >= 500
+}
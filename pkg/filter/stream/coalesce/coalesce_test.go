@@ -0,0 +1,255 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coalesce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	headers api.HeaderMap
+	data    buffer.IoBuffer
+	paused  bool
+	resumed chan struct{}
+}
+
+func newMockReceiverHandler() *mockReceiverHandler {
+	return &mockReceiverHandler{
+		resumed: make(chan struct{}, 1),
+	}
+}
+
+func (h *mockReceiverHandler) Route() api.Route {
+	return nil
+}
+
+func (h *mockReceiverHandler) SendDirectResponse(headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) {
+	h.headers = headers
+	h.data = buf
+}
+
+func (h *mockReceiverHandler) PauseReceiving() {
+	h.paused = true
+}
+
+func (h *mockReceiverHandler) ContinueReceiving() {
+	h.resumed <- struct{}{}
+}
+
+// waitResumed waits the paused stream resumed, returns false if it is not resumed in time
+func (h *mockReceiverHandler) waitResumed(timeout time.Duration) bool {
+	select {
+	case <-h.resumed:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func newRequest(method, path, accept string) api.HeaderMap {
+	return protocol.CommonHeader{
+		protocol.MosnHeaderMethod:  method,
+		protocol.MosnHeaderPathKey: path,
+		"accept":                   accept,
+	}
+}
+
+func createFactory(t *testing.T, conf map[string]interface{}) *FilterConfigFactory {
+	factory, err := CreateCoalesceFilterFactory(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factory.(*FilterConfigFactory)
+}
+
+func TestParseConfig(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{
+		"methods":      []string{"get"},
+		"vary_headers": []string{"Accept"},
+	})
+	cfg := f.Config
+	if len(cfg.Methods) != 1 || cfg.Methods[0] != "GET" ||
+		len(cfg.VaryHeaders) != 1 || cfg.VaryHeaders[0] != "accept" ||
+		cfg.Timeout.Duration != defaultTimeout || cfg.MaxWaiters != defaultMaxWaiters {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{
+		"vary_headers": []string{"accept"},
+	})
+	ctx := context.Background()
+	leader := NewFilter(ctx, f.Config, f.group)
+	leader.SetReceiveFilterHandler(newMockReceiverHandler())
+	if status := leader.OnReceive(ctx, newRequest("GET", "/a", "json"), nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("leader should be sent to upstream")
+	}
+	// not coalesced requests
+	for _, req := range []api.HeaderMap{
+		newRequest("POST", "/a", "json"),
+		newRequest("GET", "/b", "json"),
+		newRequest("GET", "/a", "xml"),
+	} {
+		filter := NewFilter(ctx, f.Config, f.group)
+		filter.SetReceiveFilterHandler(newMockReceiverHandler())
+		if status := filter.OnReceive(ctx, req, nil, nil); status != api.StreamFilterContinue {
+			t.Fatalf("request should not be coalesced: %v", req)
+		}
+		filter.OnDestroy()
+	}
+	// waiters are paused without blocking
+	var handlers []*mockReceiverHandler
+	for i := 0; i < 5; i++ {
+		handler := newMockReceiverHandler()
+		filter := NewFilter(ctx, f.Config, f.group)
+		filter.SetReceiveFilterHandler(handler)
+		defer filter.OnDestroy()
+		if status := filter.OnReceive(ctx, newRequest("GET", "/a", "json"), nil, nil); status != api.StreamFilterStop || !handler.paused {
+			t.Fatal("waiter should be paused")
+		}
+		handlers = append(handlers, handler)
+	}
+	leader.Append(ctx, protocol.CommonHeader{types.HeaderStatus: "200", "x-resp": "leader"}, buffer.NewIoBufferString("body"), nil)
+	leader.OnDestroy()
+	for _, handler := range handlers {
+		if !handler.waitResumed(time.Second) {
+			t.Fatal("waiter is not resumed")
+		}
+		if v, _ := handler.headers.Get("x-resp"); v != "leader" || handler.data.String() != "body" {
+			t.Fatal("waiter is not replied with the coalesced response")
+		}
+	}
+	// the call is finished, a new leader is elected
+	filter := NewFilter(ctx, f.Config, f.group)
+	filter.SetReceiveFilterHandler(newMockReceiverHandler())
+	if status := filter.OnReceive(ctx, newRequest("GET", "/a", "json"), nil, nil); status != api.StreamFilterContinue || filter.call == nil {
+		t.Fatal("expected a new leader")
+	}
+	filter.OnDestroy()
+}
+
+func TestCoalesceLeaderFailed(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{})
+	ctx := context.Background()
+	for _, finish := range []func(leader *coalesceFilter){
+		// server error
+		func(leader *coalesceFilter) {
+			leader.Append(ctx, protocol.CommonHeader{types.HeaderStatus: "503"}, nil, nil)
+		},
+		// no response
+		func(leader *coalesceFilter) {
+			leader.OnDestroy()
+		},
+	} {
+		leader := NewFilter(ctx, f.Config, f.group)
+		leader.SetReceiveFilterHandler(newMockReceiverHandler())
+		leader.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil)
+		handler := newMockReceiverHandler()
+		filter := NewFilter(ctx, f.Config, f.group)
+		filter.SetReceiveFilterHandler(handler)
+		if status := filter.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil); status != api.StreamFilterStop {
+			t.Fatal("waiter should be paused")
+		}
+		finish(leader)
+		if !handler.waitResumed(time.Second) || handler.headers != nil {
+			t.Fatal("waiter should be resumed and sent to upstream")
+		}
+		filter.OnDestroy()
+	}
+}
+
+func TestCoalesceLimit(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{
+		"timeout":     "50ms",
+		"max_waiters": 1,
+	})
+	ctx := context.Background()
+	leader := NewFilter(ctx, f.Config, f.group)
+	leader.SetReceiveFilterHandler(newMockReceiverHandler())
+	leader.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil)
+	defer leader.OnDestroy()
+	// wait timeout
+	handler := newMockReceiverHandler()
+	waiter := NewFilter(ctx, f.Config, f.group)
+	waiter.SetReceiveFilterHandler(handler)
+	defer waiter.OnDestroy()
+	start := time.Now()
+	if status := waiter.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil); status != api.StreamFilterStop {
+		t.Fatal("waiter should be paused")
+	}
+	if !handler.waitResumed(time.Second) || time.Since(start) < 50*time.Millisecond || handler.headers != nil {
+		t.Fatal("waiter should be sent to upstream after timeout")
+	}
+	// the waiters reached max
+	filter := NewFilter(ctx, f.Config, f.group)
+	filter.SetReceiveFilterHandler(newMockReceiverHandler())
+	if status := filter.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("request should be sent to upstream directly")
+	}
+}
+
+func TestCoalescePrivateHeaders(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{})
+	ctx := context.Background()
+	alice := newRequest("GET", "/a", "")
+	alice.Set("authorization", "Bearer alice")
+	leader := NewFilter(ctx, f.Config, f.group)
+	leader.SetReceiveFilterHandler(newMockReceiverHandler())
+	leader.OnReceive(ctx, alice, nil, nil)
+	defer leader.OnDestroy()
+	for _, h := range []string{"authorization", "cookie"} {
+		req := newRequest("GET", "/a", "")
+		req.Set(h, "bob")
+		filter := NewFilter(ctx, f.Config, f.group)
+		filter.SetReceiveFilterHandler(newMockReceiverHandler())
+		if status := filter.OnReceive(ctx, req, nil, nil); status != api.StreamFilterContinue || filter.call == nil {
+			t.Fatalf("request with a different %s should not be coalesced", h)
+		}
+		filter.OnDestroy()
+	}
+}
+
+func TestCoalesceWithoutAsyncHandler(t *testing.T) {
+	f := createFactory(t, map[string]interface{}{})
+	ctx := context.Background()
+	leader := NewFilter(ctx, f.Config, f.group)
+	leader.SetReceiveFilterHandler(newMockReceiverHandler())
+	leader.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil)
+	defer leader.OnDestroy()
+	filter := NewFilter(ctx, f.Config, f.group)
+	filter.SetReceiveFilterHandler(&syncReceiverHandler{})
+	if status := filter.OnReceive(ctx, newRequest("GET", "/a", ""), nil, nil); status != api.StreamFilterContinue {
+		t.Fatal("request can not wait without the async handler")
+	}
+}
+
+type syncReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+}
+
+func (h *syncReceiverHandler) Route() api.Route {
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coalesce
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultMaxWaiters = 1000
+)
+
+func init() {
+	api.RegisterStream(v2.Coalesce, CreateCoalesceFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.Coalesce, v2.StreamFilterPhaseRateLimit)
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamCoalesce
+	group  *group
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config, f.group)
+	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateCoalesceFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create coalesce stream filter factory")
+	cfg, err := ParseStreamCoalesceFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: cfg,
+		group:  newGroup(),
+	}, nil
+}
+
+// ParseStreamCoalesceFilter
+func ParseStreamCoalesceFilter(cfg map[string]interface{}) (*v2.StreamCoalesce, error) {
+	filterConfig := &v2.StreamCoalesce{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if len(filterConfig.Methods) == 0 {
+		filterConfig.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	for i, m := range filterConfig.Methods {
+		filterConfig.Methods[i] = strings.ToUpper(m)
+	}
+	for i, h := range filterConfig.VaryHeaders {
+		filterConfig.VaryHeaders[i] = strings.ToLower(h)
+	}
+	if filterConfig.Timeout.Duration <= 0 {
+		filterConfig.Timeout.Duration = defaultTimeout
+	}
+	if filterConfig.MaxWaiters <= 0 {
+		filterConfig.MaxWaiters = defaultMaxWaiters
+	}
+	return filterConfig, nil
+}
@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coalesce
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/api"
+	"mosn.io/pkg/buffer"
+)
+
+// response is the upstream response of a coalesced request
+type response struct {
+	headers  api.HeaderMap
+	data     []byte
+	trailers api.HeaderMap
+}
+
+// call is an in-flight upstream request, the waiters are woken up with the response when the call is finished.
+// the response is nil if the leader request is failed, the waiters send the request to upstream themselves.
+type call struct {
+	waiters []*waiter
+}
+
+// waiter is a request waiting for a call, it is woken up once by the call finished, or by the timeout
+type waiter struct {
+	woken uint32
+	wake  func(resp *response)
+}
+
+func newWaiter(wake func(resp *response)) *waiter {
+	return &waiter{wake: wake}
+}
+
+// notify wakes up the waiter with the response, returns false if the waiter is woken up already
+func (w *waiter) notify(resp *response) bool {
+	if !atomic.CompareAndSwapUint32(&w.woken, 0, 1) {
+		return false
+	}
+	w.wake(resp)
+	return true
+}
+
+// cancel makes the waiter is never woken up
+func (w *waiter) cancel() {
+	atomic.StoreUint32(&w.woken, 1)
+}
+
+// group collapses the identical requests by the key
+type group struct {
+	mutex sync.Mutex
+	calls map[string]*call
+}
+
+func newGroup() *group {
+	return &group{
+		calls: make(map[string]*call),
+	}
+}
+
+// join returns the in-flight call of the key, and whether the caller is the leader that sends the request.
+// the waiter is added to the call if the caller is not the leader.
+// a nil call is returned if the waiters of the call reached max, or the waiter is nil,
+// the caller should send the request directly.
+func (g *group) join(key string, maxWaiters int, w *waiter) (*call, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if c, ok := g.calls[key]; ok {
+		if w == nil || len(c.waiters) >= maxWaiters {
+			return nil, false
+		}
+		c.waiters = append(c.waiters, w)
+		return c, false
+	}
+	c := &call{}
+	g.calls[key] = c
+	return c, true
+}
+
+// finish removes the call, and wakes up the waiters with the response
+func (g *group) finish(key string, c *call, resp *response) {
+	g.mutex.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	waiters := c.waiters
+	c.waiters = nil
+	g.mutex.Unlock()
+	for _, w := range waiters {
+		w.notify(resp)
+	}
+}
+
+// clone copies the response for a waiter, the headers and data are owned by the stream after sent
+func (r *response) clone() (api.HeaderMap, buffer.IoBuffer, api.HeaderMap) {
+	var headers, trailers api.HeaderMap
+	var data buffer.IoBuffer
+	if r.headers != nil {
+		headers = r.headers.Clone()
+	}
+	if r.data != nil {
+		data = buffer.NewIoBufferBytes(append([]byte(nil), r.data...))
+	}
+	if r.trailers != nil {
+		trailers = r.trailers.Clone()
+	}
+	return headers, data, trailers
+}
@@ -23,6 +23,8 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/filter/stream/commonrule/model"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
@@ -33,6 +35,7 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
 func init() {
 	api.RegisterStream("commonrule", CreateCommonRuleFilterFactory)
+	filter.RegisterStreamFilterPhase("commonrule", v2.StreamFilterPhaseAuthz)
 }
 
 func parseCommonRuleConfig(config map[string]interface{}) *model.CommonRuleConfig {
@@ -0,0 +1,228 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compression
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/compress"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	headerAcceptEncoding  = "accept-encoding"
+	headerContentEncoding = "content-encoding"
+	headerContentLength   = "content-length"
+	headerContentType     = "content-type"
+	headerCacheControl    = "cache-control"
+	headerVary            = "vary"
+)
+
+// compressionFilter decompresses the request body encoded by one of the configured algorithms,
+// and compresses the response body by the algorithm negotiated with the request's Accept-Encoding.
+type compressionFilter struct {
+	ctx            context.Context
+	config         *compressionConfig
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	// codec compresses the response, nil means the response is not compressed
+	codec compress.Codec
+}
+
+func NewFilter(ctx context.Context, config *compressionConfig) *compressionFilter {
+	return &compressionFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *compressionFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *compressionFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+func (f *compressionFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if headers == nil {
+		return api.StreamFilterContinue
+	}
+	if f.config.compressResponse {
+		if accept, ok := headers.Get(headerAcceptEncoding); ok {
+			f.codec = negotiate(accept, f.config.codecs)
+		}
+	}
+	if f.config.decompressRequest && buf != nil && buf.Len() > 0 {
+		if err := f.decompress(headers, buf); err != nil {
+			log.Proxy.Errorf(ctx, "[stream filter] [compression] decompress request failed: %v", err)
+			status := http.StatusBadRequest
+			if err == compress.ErrTooLarge {
+				status = http.StatusRequestEntityTooLarge
+			}
+			f.receiveHandler.SendHijackReply(status, headers)
+			return api.StreamFilterStop
+		}
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *compressionFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.codec == nil || headers == nil || buf == nil || !f.shouldCompress(headers, buf) {
+		return api.StreamFilterContinue
+	}
+	data, err := compress.Compress(f.codec, f.config.level, buf.Bytes())
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [compression] compress response by %s failed: %v", f.codec.Name(), err)
+		return api.StreamFilterContinue
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [compression] response is compressed by %s, %d to %d bytes", f.codec.Name(), buf.Len(), len(data))
+	}
+	buf.Reset()
+	buf.Write(data)
+	headers.Set(headerContentEncoding, f.codec.Name())
+	if _, ok := headers.Get(headerContentLength); ok {
+		headers.Set(headerContentLength, strconv.Itoa(len(data)))
+	}
+	addVary(headers)
+	return api.StreamFilterContinue
+}
+
+func (f *compressionFilter) OnDestroy() {}
+
+// decompress replaces the body with the decompressed data if the body is encoded by one of the algorithms
+func (f *compressionFilter) decompress(headers api.HeaderMap, buf buffer.IoBuffer) error {
+	encoding, ok := headers.Get(headerContentEncoding)
+	if !ok {
+		return nil
+	}
+	codec := findCodec(strings.TrimSpace(encoding), f.config.codecs)
+	if codec == nil {
+		return nil
+	}
+	data, err := compress.Decompress(codec, buf.Bytes(), f.config.maxDecompressedSize)
+	if err != nil {
+		return err
+	}
+	buf.Reset()
+	buf.Write(data)
+	headers.Del(headerContentEncoding)
+	if _, ok := headers.Get(headerContentLength); ok {
+		headers.Set(headerContentLength, strconv.Itoa(len(data)))
+	}
+	return nil
+}
+
+// shouldCompress returns false if the response is encoded already, too small,
+// not the configured content types, or forbidden to be transformed
+func (f *compressionFilter) shouldCompress(headers api.HeaderMap, buf buffer.IoBuffer) bool {
+	if buf.Len() < f.config.minContentLength {
+		return false
+	}
+	if encoding, ok := headers.Get(headerContentEncoding); ok && encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return false
+	}
+	if cacheControl, ok := headers.Get(headerCacheControl); ok && strings.Contains(strings.ToLower(cacheControl), "no-transform") {
+		return false
+	}
+	if len(f.config.contentTypes) == 0 {
+		return true
+	}
+	contentType, _ := headers.Get(headerContentType)
+	contentType = strings.ToLower(contentType)
+	for _, t := range f.config.contentTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate returns the codec with the highest quality value in the Accept-Encoding,
+// the codecs order is used if the quality values are the same. nil means none is acceptable.
+func negotiate(accept string, codecs []compress.Codec) compress.Codec {
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(accept, ",") {
+		coding, q := parseCoding(item)
+		if coding != "" {
+			qualities[coding] = q
+		}
+	}
+	var best compress.Codec
+	var bestQ float64
+	for _, codec := range codecs {
+		q, ok := qualities[strings.ToLower(codec.Name())]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = codec, q
+		}
+	}
+	return best
+}
+
+// parseCoding parses a coding of the Accept-Encoding, such as "gzip;q=0.8"
+func parseCoding(item string) (string, float64) {
+	params := strings.Split(item, ";")
+	coding := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(param[2:]), 64)
+		if err != nil {
+			return "", 0
+		}
+		q = v
+	}
+	return coding, q
+}
+
+func findCodec(name string, codecs []compress.Codec) compress.Codec {
+	for _, codec := range codecs {
+		if strings.EqualFold(codec.Name(), name) {
+			return codec
+		}
+	}
+	return nil
+}
+
+// addVary adds Accept-Encoding to the Vary header, so the caches keep the responses of different encodings
+func addVary(headers api.HeaderMap) {
+	vary, ok := headers.Get(headerVary)
+	if !ok || vary == "" {
+		headers.Set(headerVary, "Accept-Encoding")
+		return
+	}
+	for _, v := range strings.Split(vary, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.EqualFold(v, "Accept-Encoding") {
+			return
+		}
+	}
+	headers.Set(headerVary, vary+", Accept-Encoding")
+}
@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compression
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/compress"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/pkg/buffer"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code int
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+func newTestFilter(t *testing.T, conf map[string]interface{}) (*compressionFilter, *mockReceiverHandler) {
+	factory, err := CreateCompressionFilterFactory(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewFilter(context.Background(), factory.(*FilterConfigFactory).config)
+	handler := &mockReceiverHandler{}
+	f.SetReceiveFilterHandler(handler)
+	return f, handler
+}
+
+func TestCreateCompressionFilterFactoryInvalid(t *testing.T) {
+	if _, err := CreateCompressionFilterFactory(map[string]interface{}{
+		"algorithms": []string{"gzip", "br"},
+	}); err == nil {
+		t.Fatal("expected an error for the unknown algorithm")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	gzip, _ := compress.GetCodec(compress.Gzip)
+	zstd, _ := compress.GetCodec(compress.Zstd)
+	codecs := []compress.Codec{zstd, gzip}
+	for _, tc := range []struct {
+		accept   string
+		expected compress.Codec
+	}{
+		{"gzip, deflate", gzip},
+		{"gzip, zstd", zstd},
+		{"gzip;q=1.0, zstd;q=0.5", gzip},
+		{"ZSTD", zstd},
+		{"*", zstd},
+		{"zstd;q=0, *;q=0.1", gzip},
+		{"br, identity", nil},
+		{"gzip;q=0", nil},
+		{"gzip;q=abc", nil},
+	} {
+		if codec := negotiate(tc.accept, codecs); codec != tc.expected {
+			t.Errorf("accept %q expected %v, got %v", tc.accept, tc.expected, codec)
+		}
+	}
+}
+
+func TestCompressResponse(t *testing.T) {
+	f, _ := newTestFilter(t, map[string]interface{}{
+		"algorithms":    []string{"zstd", "gzip"},
+		"content_types": []string{"text/", "application/json"},
+	})
+	body := bytes.Repeat([]byte(`{"key":"value"}`), 20)
+	f.OnReceive(context.Background(), protocol.CommonHeader{"accept-encoding": "gzip, zstd"}, nil, nil)
+
+	headers := protocol.CommonHeader{
+		"content-type":   "application/json; charset=utf-8",
+		"content-length": "300",
+		"vary":           "Origin",
+	}
+	buf := buffer.NewIoBufferString(string(body))
+	f.Append(context.Background(), headers, buf, nil)
+	if headers["content-encoding"] != compress.Zstd || headers["vary"] != "Origin, Accept-Encoding" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	if headers["content-length"] == "300" {
+		t.Fatalf("content length is not updated: %v", headers)
+	}
+	codec, _ := compress.GetCodec(compress.Zstd)
+	if out, err := compress.Decompress(codec, buf.Bytes(), 0); err != nil || !bytes.Equal(out, body) {
+		t.Fatalf("decompress response failed: %v", err)
+	}
+
+	// the responses that should not be compressed
+	for i, headers := range []protocol.CommonHeader{
+		{"content-type": "image/png"},
+		{"content-type": "text/plain", "content-encoding": "br"},
+		{"content-type": "text/plain", "cache-control": "private, no-transform"},
+	} {
+		buf := buffer.NewIoBufferBytes(body)
+		f.Append(context.Background(), headers, buf, nil)
+		if !bytes.Equal(buf.Bytes(), body) {
+			t.Fatalf("case %d the response should not be compressed", i)
+		}
+	}
+	small := buffer.NewIoBufferString("small")
+	f.Append(context.Background(), protocol.CommonHeader{"content-type": "text/plain"}, small, nil)
+	if small.String() != "small" {
+		t.Fatal("the small response should not be compressed")
+	}
+}
+
+func TestNoAcceptEncoding(t *testing.T) {
+	f, _ := newTestFilter(t, map[string]interface{}{})
+	f.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil)
+	body := bytes.Repeat([]byte("a"), 100)
+	headers := protocol.CommonHeader{}
+	buf := buffer.NewIoBufferBytes(body)
+	f.Append(context.Background(), headers, buf, nil)
+	if !bytes.Equal(buf.Bytes(), body) || headers["content-encoding"] != "" {
+		t.Fatal("the response should not be compressed without accept encoding")
+	}
+}
+
+func TestDecompressRequest(t *testing.T) {
+	f, handler := newTestFilter(t, map[string]interface{}{
+		"algorithms":                   []string{"gzip", "zstd"},
+		"decompress_request":           true,
+		"disable_response_compression": true,
+		"max_decompressed_size":        1000,
+	})
+	codec, _ := compress.GetCodec(compress.Zstd)
+	body := bytes.Repeat([]byte("request "), 100)
+	compressed, _ := compress.Compress(codec, 0, body)
+	headers := protocol.CommonHeader{
+		"content-encoding": "zstd",
+		"content-length":   "1",
+		"accept-encoding":  "zstd",
+	}
+	buf := buffer.NewIoBufferString(string(compressed))
+	if status := f.OnReceive(context.Background(), headers, buf, nil); status != api.StreamFilterContinue {
+		t.Fatalf("unexpected status %v", status)
+	}
+	if !bytes.Equal(buf.Bytes(), body) || headers["content-length"] != "800" {
+		t.Fatalf("request is not decompressed: %v", headers)
+	}
+	if _, ok := headers["content-encoding"]; ok || f.codec != nil {
+		t.Fatalf("unexpected state, headers: %v, codec: %v", headers, f.codec)
+	}
+
+	// too large
+	large, _ := compress.Compress(codec, 0, bytes.Repeat([]byte("a"), 1001))
+	headers = protocol.CommonHeader{"content-encoding": "zstd"}
+	if status := f.OnReceive(context.Background(), headers, buffer.NewIoBufferBytes(large), nil); status != api.StreamFilterStop || handler.code != 413 {
+		t.Fatalf("expected 413, got %d", handler.code)
+	}
+	// invalid data
+	headers = protocol.CommonHeader{"content-encoding": "gzip"}
+	if status := f.OnReceive(context.Background(), headers, buffer.NewIoBufferBytes(compressed), nil); status != api.StreamFilterStop || handler.code != 400 {
+		t.Fatalf("expected 400, got %d", handler.code)
+	}
+	// unknown encoding is passed through
+	headers = protocol.CommonHeader{"content-encoding": "br"}
+	buf = buffer.NewIoBufferString("brotli")
+	if status := f.OnReceive(context.Background(), headers, buf, nil); status != api.StreamFilterContinue || buf.String() != "brotli" {
+		t.Fatal("unknown encoding should be passed through")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compression
+
+import (
+	"context"
+	"encoding/json"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/compress"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+)
+
+const (
+	defaultMinContentLength    = 30
+	defaultMaxDecompressedSize = 10 << 20
+)
+
+func init() {
+	api.RegisterStream(v2.Compression, CreateCompressionFilterFactory)
+}
+
+// compressionConfig is the parsed config, the codecs are in the preferred order
+type compressionConfig struct {
+	codecs              []compress.Codec
+	level               int
+	minContentLength    int
+	contentTypes        []string
+	compressResponse    bool
+	decompressRequest   bool
+	maxDecompressedSize int64
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamCompression
+	config *compressionConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.config)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateCompressionFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create compression stream filter factory")
+	cfg, err := ParseStreamCompressionFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := makeCompressionConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: cfg,
+		config: config,
+	}, nil
+}
+
+// ParseStreamCompressionFilter
+func ParseStreamCompressionFilter(cfg map[string]interface{}) (*v2.StreamCompression, error) {
+	filterConfig := &v2.StreamCompression{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+func makeCompressionConfig(cfg *v2.StreamCompression) (*compressionConfig, error) {
+	algorithms := cfg.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{compress.Gzip}
+	}
+	config := &compressionConfig{
+		level:               cfg.Level,
+		minContentLength:    cfg.MinContentLength,
+		contentTypes:        cfg.ContentTypes,
+		compressResponse:    !cfg.DisableResponseCompression,
+		decompressRequest:   cfg.DecompressRequest,
+		maxDecompressedSize: cfg.MaxDecompressedSize,
+	}
+	for _, algorithm := range algorithms {
+		codec, err := compress.GetCodec(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		config.codecs = append(config.codecs, codec)
+	}
+	if config.minContentLength <= 0 {
+		config.minContentLength = defaultMinContentLength
+	}
+	if config.maxDecompressedSize <= 0 {
+		config.maxDecompressedSize = defaultMaxDecompressedSize
+	}
+	return config, nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datamask
+
+import (
+	"context"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/datamask"
+	"mosn.io/pkg/buffer"
+)
+
+// dataMaskFilter sets the masker into the stream context, so the sensitive data is masked
+// in the access logs and the debug logs. The request and response bodies are masked and
+// saved for the masked_request_body and masked_response_body variables.
+// The data sent to upstream and downstream is not modified.
+type dataMaskFilter struct {
+	ctx    context.Context
+	masker *datamask.Masker
+}
+
+func NewFilter(ctx context.Context, masker *datamask.Masker) *dataMaskFilter {
+	return &dataMaskFilter{
+		ctx:    ctx,
+		masker: masker,
+	}
+}
+
+func (f *dataMaskFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {}
+
+func (f *dataMaskFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {}
+
+func (f *dataMaskFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	f.ctx = datamask.WithMasker(ctx, f.masker)
+	if buf != nil {
+		datamask.SetMaskedBody(f.ctx, true, buf.Bytes())
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *dataMaskFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if buf != nil {
+		datamask.SetMaskedBody(ctx, false, buf.Bytes())
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *dataMaskFilter) OnDestroy() {}
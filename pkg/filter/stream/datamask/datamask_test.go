@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package datamask
+
+import (
+	"context"
+	"testing"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/datamask"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
+)
+
+func TestDataMaskFilter(t *testing.T) {
+	factory, err := CreateDataMaskFilterFactory(map[string]interface{}{
+		"headers":     []string{"x-token"},
+		"json_fields": []string{"phone"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ff := factory.(*FilterConfigFactory)
+	// the stream context is a mosn value context
+	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(1))
+	filter := NewFilter(ctx, ff.masker)
+	reqBody := buffer.NewIoBufferString(`{"phone":"13800001234"}`)
+	filter.OnReceive(ctx, protocol.CommonHeader{}, reqBody, nil)
+	respBody := buffer.NewIoBufferString(`{"user":{"phone":"555"}}`)
+	filter.Append(ctx, protocol.CommonHeader{}, respBody, nil)
+
+	// the data is not modified
+	if reqBody.String() != `{"phone":"13800001234"}` || respBody.String() != `{"user":{"phone":"555"}}` {
+		t.Fatal("the body should not be modified")
+	}
+	if v, _ := variable.GetVariableValue(ctx, datamask.VarMaskedRequestBody); v != `{"phone":"***********"}` {
+		t.Fatalf("unexpected masked request body: %s", v)
+	}
+	if v, _ := variable.GetVariableValue(ctx, datamask.VarMaskedResponseBody); v != `{"user":{"phone":"***"}}` {
+		t.Fatalf("unexpected masked response body: %s", v)
+	}
+	masked := datamask.MaskHeadersFromContext(ctx, protocol.CommonHeader{"x-token": "abc", "authorization": "basic"})
+	if v, _ := masked.Get("x-token"); v != "***" {
+		t.Fatalf("unexpected masked header: %s", v)
+	}
+	// only the configured headers are masked
+	if v, _ := masked.Get("authorization"); v != "basic" {
+		t.Fatalf("unexpected masked header: %s", v)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicforwardproxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/dns"
+	"mosn.io/mosn/pkg/egress"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// dynamicForwardProxyFilter resolves the request host before the request is routed, and sets it as
+// the host of the DYNAMIC_FORWARD_PROXY cluster. The requests are replied directly if the host is not
+// allowed or can not be resolved, so the cluster uses the cached addresses.
+type dynamicForwardProxyFilter struct {
+	ctx     context.Context
+	config  *v2.StreamDynamicForwardProxy
+	policy  *egress.Policy
+	handler api.StreamReceiverFilterHandler
+	resolve func(ctx context.Context, hostport string) (*net.TCPAddr, error)
+}
+
+func NewFilter(ctx context.Context, config *v2.StreamDynamicForwardProxy, policy *egress.Policy) *dynamicForwardProxyFilter {
+	return &dynamicForwardProxyFilter{
+		ctx:     ctx,
+		config:  config,
+		policy:  policy,
+		resolve: dns.Default().ResolveTCPAddr,
+	}
+}
+
+func (f *dynamicForwardProxyFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *dynamicForwardProxyFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	value, _ := headers.Get(protocol.MosnHeaderHostKey)
+	host, port := splitHostPort(strings.ToLower(value), f.config.DefaultPort)
+	if host == "" {
+		f.handler.SendHijackReply(http.StatusBadRequest, protocol.CommonHeader{})
+		return api.StreamFilterStop
+	}
+	if !f.allowed(host) {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [dynamic forward proxy] host %s is not allowed", host)
+		}
+		f.handler.SendHijackReply(http.StatusForbidden, protocol.CommonHeader{})
+		return api.StreamFilterStop
+	}
+	if f.policy != nil && !f.checkPolicy(host, port) {
+		f.handler.SendHijackReply(f.policy.Status(), protocol.CommonHeader{})
+		return api.StreamFilterStop
+	}
+	hostport := net.JoinHostPort(host, port)
+	if _, err := f.resolve(ctx, hostport); err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [dynamic forward proxy] resolve %s failed: %v", hostport, err)
+		f.handler.SendHijackReply(http.StatusServiceUnavailable, protocol.CommonHeader{})
+		return api.StreamFilterStop
+	}
+	cluster.SetDynamicForwardHost(ctx, hostport)
+	return api.StreamFilterContinue
+}
+
+func (f *dynamicForwardProxyFilter) OnDestroy() {}
+
+// checkPolicy checks the destination by the egress policy, the denied attempts are audited
+func (f *dynamicForwardProxyFilter) checkPolicy(host, port string) bool {
+	portNum, _ := strconv.ParseUint(port, 10, 32)
+	var proto, downstream string
+	if info := f.handler.RequestInfo(); info != nil {
+		proto = string(info.Protocol())
+		if addr := info.DownstreamRemoteAddress(); addr != nil {
+			downstream = addr.String()
+		}
+	}
+	allowed, rule := f.policy.Check(host, uint32(portNum), proto)
+	if !allowed {
+		f.policy.Audit(host, uint32(portNum), proto, rule, downstream)
+	}
+	return allowed
+}
+
+// allowed checks the host by the allowed hosts, the "*." prefix matches the sub domains
+func (f *dynamicForwardProxyFilter) allowed(host string) bool {
+	if len(f.config.AllowedHosts) == 0 {
+		return true
+	}
+	for _, allowed := range f.config.AllowedHosts {
+		if egress.MatchHost(strings.ToLower(allowed), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitHostPort splits the host header, the default port is used if the host has no port
+func splitHostPort(value string, defaultPort uint32) (string, string) {
+	if host, port, err := net.SplitHostPort(value); err == nil {
+		return host, port
+	}
+	// the ipv6 literal without port
+	host := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	return host, strconv.FormatUint(uint64(defaultPort), 10)
+}
@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicforwardproxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code int
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	info := network.NewRequestInfo()
+	info.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345})
+	return info
+}
+
+func TestDynamicForwardProxyFilter(t *testing.T) {
+	cfg, err := ParseStreamDynamicForwardProxyFilter(map[string]interface{}{
+		"allowed_hosts": []string{"example.com", "*.example.org"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := map[string]bool{
+		"example.com:80":     true,
+		"api.example.org:80": true,
+	}
+	for _, tc := range []struct {
+		host     string
+		code     int
+		hostport string
+	}{
+		{host: "Example.com", hostport: "example.com:80"},
+		{host: "api.example.org", hostport: "api.example.org:80"},
+		{host: "example.org", code: http.StatusForbidden},
+		{host: "other.com", code: http.StatusForbidden},
+		{host: "", code: http.StatusBadRequest},
+		{host: "example.com:8080", code: http.StatusServiceUnavailable},
+	} {
+		ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, 1)
+		f := NewFilter(ctx, cfg, nil)
+		f.resolve = func(ctx context.Context, hostport string) (*net.TCPAddr, error) {
+			if !resolved[hostport] {
+				return nil, errors.New("no such host")
+			}
+			return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 80}, nil
+		}
+		handler := &mockReceiverHandler{}
+		f.SetReceiveFilterHandler(handler)
+		headers := protocol.CommonHeader{}
+		if tc.host != "" {
+			headers.Set(protocol.MosnHeaderHostKey, tc.host)
+		}
+		status := f.OnReceive(ctx, headers, nil, nil)
+		if tc.code != 0 {
+			if status != api.StreamFilterStop || handler.code != tc.code {
+				t.Fatalf("%s: expected reply %d, got %d", tc.host, tc.code, handler.code)
+			}
+			continue
+		}
+		hostport, _ := mosnctx.Get(ctx, types.ContextKeyDynamicForwardHost).(string)
+		if status != api.StreamFilterContinue || hostport != tc.hostport {
+			t.Fatalf("%s: unexpected host %s, reply %d", tc.host, hostport, handler.code)
+		}
+	}
+}
+
+func TestDynamicForwardProxyEgressPolicy(t *testing.T) {
+	factory, err := CreateDynamicForwardProxyFilterFactory(map[string]interface{}{
+		"egress_policy": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"action": "allow",
+					"hosts":  []string{"*.example.com"},
+					"ports":  []uint32{443},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := factory.(*FilterConfigFactory).policy
+	if policy == nil {
+		t.Fatal("expected the egress policy created")
+	}
+	for host, code := range map[string]int{
+		"www.example.com:443": 0,
+		"www.example.com":     http.StatusForbidden,
+		"other.com:443":       http.StatusForbidden,
+	} {
+		ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, 1)
+		f := NewFilter(ctx, factory.(*FilterConfigFactory).Config, policy)
+		f.resolve = func(ctx context.Context, hostport string) (*net.TCPAddr, error) {
+			return &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 443}, nil
+		}
+		handler := &mockReceiverHandler{}
+		f.SetReceiveFilterHandler(handler)
+		headers := protocol.CommonHeader{}
+		headers.Set(protocol.MosnHeaderHostKey, host)
+		f.OnReceive(ctx, headers, nil, nil)
+		if handler.code != code {
+			t.Fatalf("%s: expected reply %d, got %d", host, code, handler.code)
+		}
+	}
+}
+
+func TestSplitHostPort(t *testing.T) {
+	for value, expected := range map[string][2]string{
+		"example.com":      {"example.com", "80"},
+		"example.com:8080": {"example.com", "8080"},
+		"[::1]":            {"::1", "80"},
+		"[::1]:8080":       {"::1", "8080"},
+	} {
+		host, port := splitHostPort(value, 80)
+		if host != expected[0] || port != expected[1] {
+			t.Fatalf("%s: unexpected host %s, port %s", value, host, port)
+		}
+	}
+}
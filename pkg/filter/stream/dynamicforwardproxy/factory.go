@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dynamicforwardproxy
+
+import (
+	"context"
+	"encoding/json"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/egress"
+	"mosn.io/mosn/pkg/log"
+)
+
+const defaultPort = 80
+
+func init() {
+	api.RegisterStream(v2.DynamicForwardProxy, CreateDynamicForwardProxyFilterFactory)
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamDynamicForwardProxy
+	policy *egress.Policy
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config, f.policy)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+}
+
+func CreateDynamicForwardProxyFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create dynamic forward proxy stream filter factory")
+	cfg, err := ParseStreamDynamicForwardProxyFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	factory := &FilterConfigFactory{
+		Config: cfg,
+	}
+	if cfg.EgressPolicy != nil {
+		if factory.policy, err = egress.NewPolicy(v2.DynamicForwardProxy, cfg.EgressPolicy); err != nil {
+			return nil, err
+		}
+	}
+	return factory, nil
+}
+
+// ParseStreamDynamicForwardProxyFilter
+func ParseStreamDynamicForwardProxyFilter(cfg map[string]interface{}) (*v2.StreamDynamicForwardProxy, error) {
+	filterConfig := &v2.StreamDynamicForwardProxy{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.DefaultPort == 0 {
+		filterConfig.DefaultPort = defaultPort
+	}
+	return filterConfig, nil
+}
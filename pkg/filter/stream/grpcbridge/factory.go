@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcbridge
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+)
+
+const defaultContentType = "application/x-protobuf"
+
+func init() {
+	api.RegisterStream(v2.GRPCBridge, CreateGRPCBridgeFilterFactory)
+}
+
+// bridgeConfig is the parsed config, the content types are lower case
+type bridgeConfig struct {
+	contentType         string
+	contentTypeMappings map[string]string
+}
+
+// upstreamContentType returns the content type sent to the upstream for the gRPC content type
+func (c *bridgeConfig) upstreamContentType(grpcContentType string) string {
+	if ct, ok := c.contentTypeMappings[grpcContentType]; ok {
+		return ct
+	}
+	return c.contentType
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamGRPCBridge
+	config *bridgeConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.config)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateGRPCBridgeFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create grpc reverse bridge stream filter factory")
+	cfg, err := ParseStreamGRPCBridgeFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: cfg,
+		config: makeBridgeConfig(cfg),
+	}, nil
+}
+
+// ParseStreamGRPCBridgeFilter
+func ParseStreamGRPCBridgeFilter(cfg map[string]interface{}) (*v2.StreamGRPCBridge, error) {
+	filterConfig := &v2.StreamGRPCBridge{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+func makeBridgeConfig(cfg *v2.StreamGRPCBridge) *bridgeConfig {
+	config := &bridgeConfig{
+		contentType:         cfg.ContentType,
+		contentTypeMappings: make(map[string]string, len(cfg.ContentTypeMappings)),
+	}
+	if config.contentType == "" {
+		config.contentType = defaultContentType
+	}
+	for grpcContentType, ct := range cfg.ContentTypeMappings {
+		config.contentTypeMappings[strings.ToLower(grpcContentType)] = ct
+	}
+	return config
+}
@@ -0,0 +1,240 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcbridge
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	headerContentType   = "content-type"
+	headerContentLength = "content-length"
+	headerAccept        = "accept"
+	headerTE            = "te"
+	headerGRPCStatus    = "grpc-status"
+	headerGRPCMessage   = "grpc-message"
+
+	grpcContentTypePrefix = "application/grpc"
+	// the gRPC message is prefixed by 1 byte compressed flag and 4 bytes message length
+	grpcFrameHeaderSize = 5
+	// the response body is used as the grpc-message of the failed responses, it is truncated
+	maxGRPCMessageSize = 1024
+)
+
+// the gRPC status codes, see https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	grpcOK               = 0
+	grpcUnknown          = 2
+	grpcPermissionDenied = 7
+	grpcUnimplemented    = 12
+	grpcInternal         = 13
+	grpcUnavailable      = 14
+	grpcUnauthenticated  = 16
+)
+
+var (
+	errFrameTooShort   = errors.New("grpc frame too short")
+	errFrameCompressed = errors.New("compressed grpc message is not supported")
+	errFrameLength     = errors.New("grpc message length mismatch")
+)
+
+// grpcBridgeFilter strips the gRPC frame of the requests and frames the responses of the HTTP/1 upstreams
+type grpcBridgeFilter struct {
+	ctx            context.Context
+	config         *bridgeConfig
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+	// grpcContentType is the content type of the gRPC request, empty means the request is not bridged
+	grpcContentType string
+}
+
+func NewFilter(ctx context.Context, config *bridgeConfig) *grpcBridgeFilter {
+	return &grpcBridgeFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *grpcBridgeFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *grpcBridgeFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+func (f *grpcBridgeFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if headers == nil {
+		return api.StreamFilterContinue
+	}
+	contentType, ok := grpcContentType(headers)
+	if !ok {
+		return api.StreamFilterContinue
+	}
+	var message []byte
+	if buf != nil {
+		var err error
+		if message, err = unframe(buf.Bytes()); err != nil {
+			log.Proxy.Errorf(ctx, "[stream filter] [grpc bridge] invalid grpc request: %v", err)
+			f.receiveHandler.SendHijackReply(http.StatusBadRequest, protocol.CommonHeader{})
+			return api.StreamFilterStop
+		}
+		buf.Drain(grpcFrameHeaderSize)
+	}
+	f.grpcContentType = contentType
+	upstreamContentType := f.config.upstreamContentType(contentType)
+	headers.Set(headerContentType, upstreamContentType)
+	headers.Set(headerAccept, upstreamContentType)
+	headers.Del(headerTE)
+	if _, ok := headers.Get(headerContentLength); ok || buf != nil {
+		headers.Set(headerContentLength, strconv.Itoa(len(message)))
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [grpc bridge] bridge %s request as %s, message size %d", contentType, upstreamContentType, len(message))
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *grpcBridgeFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.grpcContentType == "" || headers == nil {
+		return api.StreamFilterContinue
+	}
+	code := http.StatusOK
+	if status, ok := headers.Get(types.HeaderStatus); ok {
+		if c, err := strconv.Atoi(status); err == nil {
+			code = c
+		}
+	}
+	var body []byte
+	if buf != nil {
+		body = buf.Bytes()
+	}
+	// the gRPC responses are always 200, the result is in the grpc-status trailer
+	headers.Set(types.HeaderStatus, strconv.Itoa(http.StatusOK))
+	headers.Set(headerContentType, f.grpcContentType)
+	headers.Del(headerContentLength)
+	if trailers == nil {
+		trailers = protocol.CommonHeader{}
+		f.sendHandler.SetResponseTrailers(trailers)
+	}
+	if code == http.StatusOK {
+		f.sendHandler.SetResponseData(buffer.NewIoBufferBytes(frame(body)))
+		trailers.Set(headerGRPCStatus, strconv.Itoa(grpcOK))
+		return api.StreamFilterContinue
+	}
+	log.Proxy.Warnf(ctx, "[stream filter] [grpc bridge] upstream responds %d", code)
+	if buf != nil {
+		buf.Reset()
+	}
+	trailers.Set(headerGRPCStatus, strconv.Itoa(grpcStatus(code)))
+	trailers.Set(headerGRPCMessage, grpcMessage(code, body))
+	return api.StreamFilterContinue
+}
+
+func (f *grpcBridgeFilter) OnDestroy() {}
+
+// grpcContentType returns the lower case content type without the parameters if it is a gRPC request
+func grpcContentType(headers api.HeaderMap) (string, bool) {
+	contentType, ok := headers.Get(headerContentType)
+	if !ok {
+		return "", false
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if contentType != grpcContentTypePrefix && !strings.HasPrefix(contentType, grpcContentTypePrefix+"+") {
+		return "", false
+	}
+	return contentType, true
+}
+
+// unframe returns the message of the gRPC frame, only one uncompressed message is supported
+func unframe(data []byte) ([]byte, error) {
+	if len(data) < grpcFrameHeaderSize {
+		return nil, errFrameTooShort
+	}
+	if data[0] != 0 {
+		return nil, errFrameCompressed
+	}
+	length := binary.BigEndian.Uint32(data[1:grpcFrameHeaderSize])
+	if uint64(length) != uint64(len(data)-grpcFrameHeaderSize) {
+		return nil, fmt.Errorf("%v: %d in frame, %d received", errFrameLength, length, len(data)-grpcFrameHeaderSize)
+	}
+	return data[grpcFrameHeaderSize:], nil
+}
+
+// frame prefixes the message with the gRPC frame header
+func frame(message []byte) []byte {
+	data := make([]byte, grpcFrameHeaderSize+len(message))
+	binary.BigEndian.PutUint32(data[1:grpcFrameHeaderSize], uint32(len(message)))
+	copy(data[grpcFrameHeaderSize:], message)
+	return data
+}
+
+// grpcStatus maps the http status code to the gRPC status code,
+// see https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+func grpcStatus(code int) int {
+	switch code {
+	case http.StatusBadRequest:
+		return grpcInternal
+	case http.StatusUnauthorized:
+		return grpcUnauthenticated
+	case http.StatusForbidden:
+		return grpcPermissionDenied
+	case http.StatusNotFound:
+		return grpcUnimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return grpcUnavailable
+	default:
+		return grpcUnknown
+	}
+}
+
+// grpcMessage returns the percent encoded grpc-message of the failed response
+func grpcMessage(code int, body []byte) string {
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	if len(message) > maxGRPCMessageSize {
+		message = message[:maxGRPCMessageSize]
+	}
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
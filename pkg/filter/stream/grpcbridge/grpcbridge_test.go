@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcbridge
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code int
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+type mockSenderHandler struct {
+	api.StreamSenderFilterHandler
+	data     buffer.IoBuffer
+	trailers api.HeaderMap
+}
+
+func (h *mockSenderHandler) SetResponseData(data buffer.IoBuffer) {
+	h.data = data
+}
+
+func (h *mockSenderHandler) SetResponseTrailers(trailers api.HeaderMap) {
+	h.trailers = trailers
+}
+
+func newTestFilter(t *testing.T) (*grpcBridgeFilter, *mockReceiverHandler, *mockSenderHandler) {
+	factory, err := CreateGRPCBridgeFilterFactory(map[string]interface{}{
+		"content_type_mappings": map[string]interface{}{
+			"application/grpc+json": "application/json",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewFilter(context.Background(), factory.(*FilterConfigFactory).config)
+	rh, sh := &mockReceiverHandler{}, &mockSenderHandler{}
+	f.SetReceiveFilterHandler(rh)
+	f.SetSenderFilterHandler(sh)
+	return f, rh, sh
+}
+
+func TestGRPCBridgeRequest(t *testing.T) {
+	for i, tc := range []struct {
+		contentType string
+		upstream    string
+	}{
+		{"application/grpc", "application/x-protobuf"},
+		{"application/grpc+proto; charset=utf-8", "application/x-protobuf"},
+		{"Application/GRPC+JSON", "application/json"},
+	} {
+		f, _, _ := newTestFilter(t)
+		headers := protocol.CommonHeader{
+			headerContentType: tc.contentType,
+			headerTE:          "trailers",
+		}
+		buf := buffer.NewIoBufferBytes(frame([]byte("message")))
+		if f.OnReceive(context.Background(), headers, buf, nil) != api.StreamFilterContinue {
+			t.Fatalf("case %d: expected continue", i)
+		}
+		if buf.String() != "message" {
+			t.Errorf("case %d: unexpected body %q", i, buf.String())
+		}
+		if headers[headerContentType] != tc.upstream || headers[headerAccept] != tc.upstream || headers[headerContentLength] != "7" {
+			t.Errorf("case %d: unexpected headers %v", i, headers)
+		}
+		if _, ok := headers[headerTE]; ok {
+			t.Errorf("case %d: expected te removed", i)
+		}
+	}
+}
+
+func TestGRPCBridgeNotGRPC(t *testing.T) {
+	f, _, sh := newTestFilter(t)
+	headers := protocol.CommonHeader{headerContentType: "application/json"}
+	buf := buffer.NewIoBufferString("{}")
+	f.OnReceive(context.Background(), headers, buf, nil)
+	if buf.String() != "{}" || headers[headerContentType] != "application/json" {
+		t.Fatal("expected the request not changed")
+	}
+	respHeaders := protocol.CommonHeader{types.HeaderStatus: "404"}
+	f.Append(context.Background(), respHeaders, buffer.NewIoBufferString("not found"), nil)
+	if respHeaders[types.HeaderStatus] != "404" || sh.trailers != nil {
+		t.Fatal("expected the response not changed")
+	}
+}
+
+func TestGRPCBridgeInvalidRequest(t *testing.T) {
+	compressed := frame([]byte("message"))
+	compressed[0] = 1
+	for i, body := range [][]byte{
+		[]byte("abc"),
+		compressed,
+		append(frame([]byte("message")), frame([]byte("second"))...),
+	} {
+		f, rh, _ := newTestFilter(t)
+		headers := protocol.CommonHeader{headerContentType: "application/grpc"}
+		if f.OnReceive(context.Background(), headers, buffer.NewIoBufferBytes(body), nil) != api.StreamFilterStop {
+			t.Errorf("case %d: expected stop", i)
+		}
+		if rh.code != http.StatusBadRequest {
+			t.Errorf("case %d: unexpected code %d", i, rh.code)
+		}
+	}
+}
+
+func TestGRPCBridgeResponse(t *testing.T) {
+	f, _, sh := newTestFilter(t)
+	f.OnReceive(context.Background(), protocol.CommonHeader{headerContentType: "application/grpc+json"},
+		buffer.NewIoBufferBytes(frame([]byte("{}"))), nil)
+
+	headers := protocol.CommonHeader{
+		types.HeaderStatus:  "200",
+		headerContentType:   "application/json",
+		headerContentLength: "13",
+	}
+	f.Append(context.Background(), headers, buffer.NewIoBufferString(`{"id": "abc"}`), nil)
+	if headers[headerContentType] != "application/grpc+json" || headers[types.HeaderStatus] != "200" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+	if _, ok := headers[headerContentLength]; ok {
+		t.Error("expected content length removed")
+	}
+	if sh.data == nil || !bytes.Equal(sh.data.Bytes(), frame([]byte(`{"id": "abc"}`))) {
+		t.Error("expected the response framed")
+	}
+	if v, _ := sh.trailers.Get(headerGRPCStatus); v != "0" {
+		t.Errorf("unexpected grpc status %s", v)
+	}
+
+	// the failed responses
+	for i, tc := range []struct {
+		code    string
+		body    string
+		status  string
+		message string
+	}{
+		{"503", "", "14", "Service Unavailable"},
+		{"404", "no such method\n", "12", "no such method"},
+		{"500", "100% failed: 中", "2", "100%25 failed: %E4%B8%AD"},
+	} {
+		f, _, sh := newTestFilter(t)
+		f.OnReceive(context.Background(), protocol.CommonHeader{headerContentType: "application/grpc"}, nil, nil)
+		headers := protocol.CommonHeader{types.HeaderStatus: tc.code}
+		trailers := protocol.CommonHeader{}
+		buf := buffer.NewIoBufferString(tc.body)
+		f.Append(context.Background(), headers, buf, trailers)
+		if headers[types.HeaderStatus] != "200" || buf.Len() != 0 || sh.trailers != nil {
+			t.Errorf("case %d: unexpected response %v", i, headers)
+		}
+		if trailers[headerGRPCStatus] != tc.status || trailers[headerGRPCMessage] != tc.message {
+			t.Errorf("case %d: unexpected trailers %v", i, trailers)
+		}
+	}
+}
+
+func TestParseGRPCBridgeConfig(t *testing.T) {
+	config := makeBridgeConfig(&v2.StreamGRPCBridge{
+		ContentType: "application/octet-stream",
+	})
+	if config.upstreamContentType("application/grpc") != "application/octet-stream" {
+		t.Error("unexpected upstream content type")
+	}
+}
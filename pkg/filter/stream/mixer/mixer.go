@@ -26,6 +26,7 @@ import (
 	"istio.io/api/mixer/v1/config/client"
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/istio/control/http"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/pkg/buffer"
@@ -34,6 +35,7 @@ import (
 func init() {
 	// static mixer stream filter factory
 	api.RegisterStream(v2.MIXER, CreateMixerFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.MIXER, v2.StreamFilterPhaseObservability)
 }
 
 // FilterConfigFactory filter config factory
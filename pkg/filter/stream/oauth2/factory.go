@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/secret"
+)
+
+const defaultCookieName = "mosn_oauth2"
+
+func init() {
+	api.RegisterStream(v2.OAuth2, CreateOAuth2FilterFactory)
+	filter.RegisterStreamFilterPhase(v2.OAuth2, v2.StreamFilterPhaseAuthn)
+}
+
+// oauth2Config is the parsed config shared by the filters
+type oauth2Config struct {
+	authEndpoint       string
+	clientID           string
+	redirectURI        string
+	callbackPath       string
+	scopes             string
+	cookieName         string
+	secureCookie       bool
+	signoutPath        string
+	claimHeaders       map[string]string
+	forwardAccessToken bool
+	passThroughPaths   []string
+	codec              *cookieCodec
+	client             *tokenClient
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamOAuth2
+	config *oauth2Config
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.config)
+	// authenticate before route, so the routes can match the identity headers
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+func CreateOAuth2FilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create oauth2 stream filter factory")
+	cfg, err := ParseStreamOAuth2Filter(conf)
+	if err != nil {
+		return nil, err
+	}
+	c, err := makeOAuth2Config(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: cfg,
+		config: c,
+	}, nil
+}
+
+// ParseStreamOAuth2Filter
+func ParseStreamOAuth2Filter(cfg map[string]interface{}) (*v2.StreamOAuth2, error) {
+	filterConfig := &v2.StreamOAuth2{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	return filterConfig, nil
+}
+
+func makeOAuth2Config(cfg *v2.StreamOAuth2) (*oauth2Config, error) {
+	if cfg.AuthorizationEndpoint == "" || cfg.TokenEndpoint == "" || cfg.ClientID == "" {
+		return nil, errors.New("authorization endpoint, token endpoint and client id are required")
+	}
+	redirect, err := url.Parse(cfg.RedirectURI)
+	if err != nil || redirect.Path == "" {
+		return nil, errors.New("invalid redirect uri: " + cfg.RedirectURI)
+	}
+	// the secrets can reference the named secrets, such as "secret://oauth2-client-secret"
+	clientSecret, err := secret.Resolve(cfg.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	cookieSecret, err := secret.Resolve(cfg.CookieSecret)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := newCookieCodec(cookieSecret)
+	if err != nil {
+		return nil, errors.New("invalid cookie secret: " + err.Error())
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid"}
+	}
+	cookieName := cfg.CookieName
+	if cookieName == "" {
+		cookieName = defaultCookieName
+	}
+	return &oauth2Config{
+		authEndpoint:       cfg.AuthorizationEndpoint,
+		clientID:           cfg.ClientID,
+		redirectURI:        cfg.RedirectURI,
+		callbackPath:       redirect.Path,
+		scopes:             strings.Join(scopes, " "),
+		cookieName:         cookieName,
+		secureCookie:       redirect.Scheme == "https",
+		signoutPath:        cfg.SignoutPath,
+		claimHeaders:       cfg.ClaimHeaders,
+		forwardAccessToken: cfg.ForwardAccessToken,
+		passThroughPaths:   cfg.PassThroughPaths,
+		codec:              codec,
+		client:             newTokenClient(cfg.TokenEndpoint, cfg.ClientID, clientSecret, cfg.RedirectURI),
+	}, nil
+}
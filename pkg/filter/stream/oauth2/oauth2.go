@@ -0,0 +1,334 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	headerCookie        = "cookie"
+	headerSetCookie     = "set-cookie"
+	headerLocation      = "location"
+	headerAuthorization = "authorization"
+	headerRequestedWith = "x-requested-with"
+	headerAuthenticate  = "www-authenticate"
+
+	nonceCookieSuffix = "_nonce"
+	stateTimeout      = 10 * time.Minute
+)
+
+// oauth2Filter authenticates the requests by the session cookie, the unauthenticated browsers are redirected
+// to the identity provider and the session cookie is set in the callback.
+type oauth2Filter struct {
+	ctx           context.Context
+	config        *oauth2Config
+	handler       api.StreamReceiverFilterHandler
+	senderHandler api.StreamSenderFilterHandler
+	// setCookie is set to the response when the session is refreshed
+	setCookie string
+}
+
+func NewFilter(ctx context.Context, config *oauth2Config) *oauth2Filter {
+	return &oauth2Filter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *oauth2Filter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *oauth2Filter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.senderHandler = handler
+}
+
+func (f *oauth2Filter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	path, _ := headers.Get(protocol.MosnHeaderPathKey)
+	query, _ := headers.Get(protocol.MosnHeaderQueryStringKey)
+	for _, prefix := range f.config.passThroughPaths {
+		if strings.HasPrefix(path, prefix) {
+			return api.StreamFilterContinue
+		}
+	}
+	switch {
+	case path == f.config.callbackPath:
+		if !f.runAsync(ctx, func() { f.handleCallback(ctx, headers, query) }) {
+			f.handleCallback(ctx, headers, query)
+		}
+		return api.StreamFilterStop
+	case f.config.signoutPath != "" && path == f.config.signoutPath:
+		f.redirect("/", f.cookie(f.config.cookieName, "", -1))
+		return api.StreamFilterStop
+	}
+
+	s := f.loadSession(headers)
+	if s != nil && s.expired() {
+		refresh := func() api.StreamFilterStatus {
+			return f.authenticate(ctx, headers, path, query, f.refreshSession(ctx, s))
+		}
+		if f.runAsync(ctx, func() { refresh() }) {
+			return api.StreamFilterStop
+		}
+		return refresh()
+	}
+	return f.authenticate(ctx, headers, path, query, s)
+}
+
+// runAsync runs the call that requests the token endpoint in a new goroutine, so the stream goroutine is not blocked.
+// The stream is paused until the call returns, the call either sends a direct response, or lets the stream continue.
+// False is returned if the stream can not be paused, the caller should run the call synchronously.
+func (f *oauth2Filter) runAsync(ctx context.Context, call func()) bool {
+	handler, ok := f.handler.(types.AsyncStreamReceiverFilterHandler)
+	if !ok {
+		return false
+	}
+	handler.PauseReceiving()
+	utils.GoWithRecover(func() {
+		call()
+		handler.ContinueReceiving()
+	}, func(r interface{}) {
+		log.Proxy.Errorf(ctx, "[stream filter] [oauth2] request token endpoint panic: %v", r)
+		handler.SendHijackReply(http.StatusInternalServerError, nil)
+		handler.ContinueReceiving()
+	})
+	return true
+}
+
+// authenticate sets the identity headers of the session, or rejects the request if the session is nil
+func (f *oauth2Filter) authenticate(ctx context.Context, headers api.HeaderMap, path, query string, s *session) api.StreamFilterStatus {
+	if s == nil {
+		f.unauthenticated(ctx, headers, path, query)
+		return api.StreamFilterStop
+	}
+	// remove the headers from downstream, the identity headers can not be forged
+	for claim, header := range f.config.claimHeaders {
+		headers.Del(header)
+		if v, ok := s.Claims[claim]; ok {
+			headers.Set(header, v)
+		}
+	}
+	if f.config.forwardAccessToken {
+		headers.Set(headerAuthorization, "Bearer "+s.AccessToken)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *oauth2Filter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.setCookie != "" {
+		headers.Set(headerSetCookie, f.setCookie)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *oauth2Filter) OnDestroy() {}
+
+func (f *oauth2Filter) getCookie(headers api.HeaderMap, name string) string {
+	v, ok := headers.Get(headerCookie)
+	if !ok {
+		return ""
+	}
+	r := &http.Request{Header: http.Header{"Cookie": []string{v}}}
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+func (f *oauth2Filter) cookie(name, value string, maxAge int) string {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   f.config.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	return c.String()
+}
+
+func (f *oauth2Filter) redirect(location, setCookie string) {
+	headers := protocol.CommonHeader{
+		headerLocation: location,
+	}
+	if setCookie != "" {
+		headers[headerSetCookie] = setCookie
+	}
+	f.handler.SendHijackReply(http.StatusFound, headers)
+}
+
+func (f *oauth2Filter) loadSession(headers api.HeaderMap) *session {
+	v := f.getCookie(headers, f.config.cookieName)
+	if v == "" {
+		return nil
+	}
+	s := &session{}
+	if err := f.config.codec.decode(v, s); err != nil {
+		log.Proxy.Infof(f.ctx, "[stream filter] [oauth2] decode session cookie failed: %v", err)
+		return nil
+	}
+	return s
+}
+
+func (f *oauth2Filter) refreshSession(ctx context.Context, s *session) *session {
+	if s.RefreshToken == "" {
+		return nil
+	}
+	token, err := f.config.client.refresh(s.RefreshToken)
+	if err != nil {
+		log.Proxy.Infof(ctx, "[stream filter] [oauth2] refresh token failed: %v", err)
+		return nil
+	}
+	refreshed, cookie, err := f.newSession(token, s)
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [oauth2] create session failed: %v", err)
+		return nil
+	}
+	f.setCookie = cookie
+	return refreshed
+}
+
+// newSession creates a session from the token response, the claims are kept if no id token is returned
+func (f *oauth2Filter) newSession(token *tokenResponse, old *session) (*session, string, error) {
+	s := &session{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	}
+	if token.ExpiresIn > 0 {
+		s.Expiry = timeNow().Unix() + token.ExpiresIn
+	}
+	if old != nil {
+		if s.RefreshToken == "" {
+			s.RefreshToken = old.RefreshToken
+		}
+		s.Claims = old.Claims
+	}
+	if token.IDToken != "" {
+		claims, err := parseClaims(token.IDToken)
+		if err != nil {
+			return nil, "", err
+		}
+		// only the claims sent to upstream are stored, keep the cookie small
+		s.Claims = make(map[string]string, len(f.config.claimHeaders))
+		for claim := range f.config.claimHeaders {
+			if v, ok := claims[claim]; ok {
+				s.Claims[claim] = fmt.Sprint(v)
+			}
+		}
+	}
+	value, err := f.config.codec.encode(s)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, f.cookie(f.config.cookieName, value, 0), nil
+}
+
+// unauthenticated redirects the browsers to the identity provider, other requests get 401
+func (f *oauth2Filter) unauthenticated(ctx context.Context, headers api.HeaderMap, path, query string) {
+	method, _ := headers.Get(protocol.MosnHeaderMethod)
+	requestedWith, _ := headers.Get(headerRequestedWith)
+	if (method != "" && method != http.MethodGet) || requestedWith != "" {
+		f.handler.RequestInfo().SetResponseFlag(types.UnauthorizedExternalService)
+		f.handler.SendHijackReply(http.StatusUnauthorized, protocol.CommonHeader{
+			headerAuthenticate: "Bearer",
+		})
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [oauth2] create nonce failed: %v", err)
+		f.handler.SendHijackReply(http.StatusInternalServerError, nil)
+		return
+	}
+	original := path
+	if !strings.HasPrefix(original, "/") || strings.HasPrefix(original, "//") {
+		// redirect to the relative path only
+		original = "/"
+	}
+	if query != "" {
+		original += "?" + query
+	}
+	st, err := f.config.codec.encode(&state{
+		Nonce:  nonce,
+		URL:    original,
+		Expiry: timeNow().Add(stateTimeout).Unix(),
+	})
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [oauth2] create state failed: %v", err)
+		f.handler.SendHijackReply(http.StatusInternalServerError, nil)
+		return
+	}
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", f.config.clientID)
+	params.Set("redirect_uri", f.config.redirectURI)
+	params.Set("scope", f.config.scopes)
+	params.Set("state", st)
+	sep := "?"
+	if strings.Contains(f.config.authEndpoint, "?") {
+		sep = "&"
+	}
+	f.redirect(f.config.authEndpoint+sep+params.Encode(),
+		f.cookie(f.config.cookieName+nonceCookieSuffix, nonce, int(stateTimeout/time.Second)))
+}
+
+// handleCallback exchanges the code for tokens, sets the session cookie and redirects to the original url
+func (f *oauth2Filter) handleCallback(ctx context.Context, headers api.HeaderMap, query string) {
+	params, err := url.ParseQuery(query)
+	if err != nil || params.Get("code") == "" {
+		log.Proxy.Infof(ctx, "[stream filter] [oauth2] invalid callback query: %s", query)
+		f.handler.SendHijackReply(http.StatusBadRequest, nil)
+		return
+	}
+	st := &state{}
+	if err := f.config.codec.decode(params.Get("state"), st); err != nil ||
+		st.Nonce != f.getCookie(headers, f.config.cookieName+nonceCookieSuffix) || timeNow().Unix() > st.Expiry {
+		log.Proxy.Infof(ctx, "[stream filter] [oauth2] invalid callback state")
+		f.handler.RequestInfo().SetResponseFlag(types.UnauthorizedExternalService)
+		f.handler.SendHijackReply(http.StatusForbidden, nil)
+		return
+	}
+	token, err := f.config.client.exchange(params.Get("code"))
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [oauth2] exchange code failed: %v", err)
+		f.handler.SendHijackReply(http.StatusBadGateway, nil)
+		return
+	}
+	_, cookie, err := f.newSession(token, nil)
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [oauth2] create session failed: %v", err)
+		f.handler.SendHijackReply(http.StatusBadGateway, nil)
+		return
+	}
+	f.redirect(st.URL, cookie)
+}
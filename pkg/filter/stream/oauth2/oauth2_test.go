@@ -0,0 +1,273 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oauth2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code    int
+	headers api.HeaderMap
+	info    api.RequestInfo
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	if h.info == nil {
+		h.info = network.NewRequestInfo()
+	}
+	return h.info
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+	h.headers = headers
+}
+
+func fakeIDToken(claims map[string]interface{}) string {
+	payload, _ := json.Marshal(claims)
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func newTestIdP(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var token tokenResponse
+		switch r.FormValue("grant_type") {
+		case "authorization_code":
+			if r.FormValue("code") != "code" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			token = tokenResponse{
+				AccessToken:  "access1",
+				RefreshToken: "refresh",
+				ExpiresIn:    60,
+				IDToken:      fakeIDToken(map[string]interface{}{"sub": "alice", "email": "alice@example.com", "secret": "x"}),
+			}
+		case "refresh_token":
+			token = tokenResponse{AccessToken: "access2", ExpiresIn: 60}
+		}
+		json.NewEncoder(w).Encode(token)
+	}))
+}
+
+func newTestFilter(t *testing.T, idp string) *oauth2Filter {
+	cfg, err := makeOAuth2Config(&v2.StreamOAuth2{
+		AuthorizationEndpoint: "https://idp.example.com/authorize",
+		TokenEndpoint:         idp,
+		ClientID:              "client",
+		ClientSecret:          "secret",
+		RedirectURI:           "https://app.example.com/oauth2/callback",
+		CookieSecret:          base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")),
+		SignoutPath:           "/signout",
+		ClaimHeaders:          map[string]string{"sub": "x-user", "email": "x-email"},
+		ForwardAccessToken:    true,
+		PassThroughPaths:      []string{"/public"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFilter(context.Background(), cfg)
+}
+
+func request(f *oauth2Filter, headers protocol.CommonHeader) (*mockReceiverHandler, api.StreamFilterStatus) {
+	h := &mockReceiverHandler{}
+	f.SetReceiveFilterHandler(h)
+	f.setCookie = ""
+	status := f.OnReceive(context.Background(), headers, nil, nil)
+	return h, status
+}
+
+func cookiePair(setCookie string) string {
+	return strings.SplitN(setCookie, ";", 2)[0]
+}
+
+func TestOAuth2Flow(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.Close()
+	f := newTestFilter(t, idp.URL)
+
+	// pass through
+	if h, status := request(f, protocol.CommonHeader{protocol.MosnHeaderPathKey: "/public/a"}); status != api.StreamFilterContinue || h.code != 0 {
+		t.Fatalf("pass through path should not be authenticated")
+	}
+	// api request gets 401
+	if h, _ := request(f, protocol.CommonHeader{protocol.MosnHeaderPathKey: "/api", protocol.MosnHeaderMethod: "POST"}); h.code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", h.code)
+	}
+	// browser is redirected to the identity provider
+	h, status := request(f, protocol.CommonHeader{
+		protocol.MosnHeaderPathKey:        "/app",
+		protocol.MosnHeaderQueryStringKey: "a=b",
+		protocol.MosnHeaderMethod:         "GET",
+	})
+	if status != api.StreamFilterStop || h.code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", h.code)
+	}
+	location, _ := h.headers.Get(headerLocation)
+	u, _ := url.Parse(location)
+	if u.Host != "idp.example.com" || u.Query().Get("client_id") != "client" || u.Query().Get("scope") != "openid" {
+		t.Fatalf("unexpected location: %s", location)
+	}
+	nonceCookie, _ := h.headers.Get(headerSetCookie)
+
+	// callback without the nonce cookie is rejected
+	callbackQuery := url.Values{"code": {"code"}, "state": {u.Query().Get("state")}}.Encode()
+	if h, _ := request(f, protocol.CommonHeader{
+		protocol.MosnHeaderPathKey:        "/oauth2/callback",
+		protocol.MosnHeaderQueryStringKey: callbackQuery,
+	}); h.code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", h.code)
+	}
+	h, _ = request(f, protocol.CommonHeader{
+		protocol.MosnHeaderPathKey:        "/oauth2/callback",
+		protocol.MosnHeaderQueryStringKey: callbackQuery,
+		headerCookie:                      cookiePair(nonceCookie),
+	})
+	if location, _ := h.headers.Get(headerLocation); h.code != http.StatusFound || location != "/app?a=b" {
+		t.Fatalf("expected redirect to original url, got %d %s", h.code, location)
+	}
+	sessionCookie, _ := h.headers.Get(headerSetCookie)
+	if !strings.Contains(sessionCookie, "HttpOnly") || !strings.Contains(sessionCookie, "Secure") {
+		t.Fatalf("unexpected session cookie: %s", sessionCookie)
+	}
+
+	// authenticated request with identity headers
+	headers := protocol.CommonHeader{
+		protocol.MosnHeaderPathKey: "/app",
+		headerCookie:               cookiePair(sessionCookie),
+		"x-user":                   "forged",
+	}
+	if h, status := request(f, headers); status != api.StreamFilterContinue || h.code != 0 {
+		t.Fatalf("authenticated request is rejected: %d", h.code)
+	}
+	if headers["x-user"] != "alice" || headers["x-email"] != "alice@example.com" || headers[headerAuthorization] != "Bearer access1" {
+		t.Fatalf("unexpected upstream headers: %v", headers)
+	}
+
+	// expired session is refreshed, claims are kept
+	timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	defer func() { timeNow = time.Now }()
+	headers = protocol.CommonHeader{
+		protocol.MosnHeaderPathKey: "/app",
+		headerCookie:               cookiePair(sessionCookie),
+	}
+	if _, status := request(f, headers); status != api.StreamFilterContinue {
+		t.Fatal("expired session is not refreshed")
+	}
+	if headers["x-user"] != "alice" || headers[headerAuthorization] != "Bearer access2" || f.setCookie == "" {
+		t.Fatalf("unexpected refreshed headers: %v", headers)
+	}
+	respHeaders := protocol.CommonHeader{}
+	f.Append(context.Background(), respHeaders, nil, nil)
+	if respHeaders[headerSetCookie] != f.setCookie {
+		t.Fatal("refreshed session cookie is not set in response")
+	}
+
+	// sign out
+	h, _ = request(f, protocol.CommonHeader{protocol.MosnHeaderPathKey: "/signout"})
+	if c, _ := h.headers.Get(headerSetCookie); h.code != http.StatusFound || !strings.Contains(c, "Max-Age=0") {
+		t.Fatalf("unexpected sign out response: %d %s", h.code, c)
+	}
+}
+
+func TestInvalidSessionCookie(t *testing.T) {
+	f := newTestFilter(t, "http://127.0.0.1:0")
+	h, _ := request(f, protocol.CommonHeader{
+		protocol.MosnHeaderPathKey: "/app",
+		headerCookie:               fmt.Sprintf("%s=invalid", defaultCookieName),
+	})
+	if h.code != http.StatusFound {
+		t.Fatalf("invalid cookie should be redirected, got %d", h.code)
+	}
+}
+
+type mockAsyncReceiverHandler struct {
+	mockReceiverHandler
+	paused    bool
+	continued chan struct{}
+}
+
+func (h *mockAsyncReceiverHandler) PauseReceiving() {
+	h.paused = true
+}
+
+func (h *mockAsyncReceiverHandler) ContinueReceiving() {
+	close(h.continued)
+}
+
+func TestOAuth2AsyncRefresh(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.Close()
+	f := newTestFilter(t, idp.URL)
+	_, sessionCookie, err := f.newSession(&tokenResponse{AccessToken: "access1", RefreshToken: "refresh", ExpiresIn: 60}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeNow = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	defer func() { timeNow = time.Now }()
+
+	refresh := func(f *oauth2Filter) (*mockAsyncReceiverHandler, protocol.CommonHeader) {
+		h := &mockAsyncReceiverHandler{continued: make(chan struct{})}
+		f.SetReceiveFilterHandler(h)
+		headers := protocol.CommonHeader{
+			protocol.MosnHeaderPathKey: "/app",
+			protocol.MosnHeaderMethod:  "GET",
+			headerCookie:               cookiePair(sessionCookie),
+		}
+		// the token endpoint is requested without blocking the stream goroutine
+		if status := f.OnReceive(context.Background(), headers, nil, nil); status != api.StreamFilterStop || !h.paused {
+			t.Fatal("the stream should be paused while refreshing the session")
+		}
+		select {
+		case <-h.continued:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the paused stream is not continued")
+		}
+		return h, headers
+	}
+	h, headers := refresh(f)
+	if h.code != 0 || headers[headerAuthorization] != "Bearer access2" || f.setCookie == "" {
+		t.Fatalf("unexpected refreshed request: %d %v", h.code, headers)
+	}
+	// the refresh is failed, the browser is redirected to the identity provider
+	idp.Close()
+	f = newTestFilter(t, idp.URL)
+	if h, _ := refresh(f); h.code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", h.code)
+	}
+}
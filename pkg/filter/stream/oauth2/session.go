@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oauth2
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var timeNow = time.Now
+
+var errInvalidCookie = errors.New("invalid cookie")
+
+// session is stored in the encrypted session cookie
+type session struct {
+	AccessToken  string            `json:"at"`
+	RefreshToken string            `json:"rt,omitempty"`
+	Expiry       int64             `json:"exp"`
+	Claims       map[string]string `json:"c,omitempty"`
+}
+
+func (s *session) expired() bool {
+	return s.Expiry != 0 && timeNow().Unix() >= s.Expiry
+}
+
+// state is sent to the identity provider and returned in the callback,
+// it binds the callback to the browser by the nonce cookie and records the original request url
+type state struct {
+	Nonce  string `json:"n"`
+	URL    string `json:"u"`
+	Expiry int64  `json:"exp"`
+}
+
+// cookieCodec encrypts and authenticates values with AES-GCM
+type cookieCodec struct {
+	aead cipher.AEAD
+}
+
+func newCookieCodec(secret string) (*cookieCodec, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &cookieCodec{aead: aead}, nil
+}
+
+func (c *cookieCodec) encode(v interface{}) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (c *cookieCodec) decode(value string, v interface{}) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	size := c.aead.NonceSize()
+	if len(sealed) < size {
+		return errInvalidCookie
+	}
+	plain, err := c.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, v)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseClaims decodes the payload of the id token.
+// The id token is received from the token endpoint directly over TLS, so the signature is not verified,
+// see https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+func parseClaims(idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, err
+	}
+	claims := make(map[string]interface{})
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -23,11 +23,13 @@ import (
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 )
 
 func init() {
 	api.RegisterStream(v2.PayloadLimit, CreatePayloadLimitFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.PayloadLimit, v2.StreamFilterPhaseRateLimit)
 }
 
 type FilterConfigFactory struct {
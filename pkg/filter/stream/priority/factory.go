@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package priority
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	defaultHeader = "x-mosn-priority"
+
+	statsRequest = "request"
+	statsShed    = "shed"
+)
+
+func init() {
+	api.RegisterStream(v2.Priority, CreatePriorityFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.Priority, v2.StreamFilterPhaseRateLimit)
+}
+
+// priorityLevels orders the priorities, the lower level is the higher priority
+var priorityLevels = map[string]int{
+	v2.PriorityCritical: 0,
+	v2.PriorityHigh:     1,
+	v2.PriorityNormal:   2,
+	v2.PriorityLow:      3,
+}
+
+var defaultThresholds = map[string]float64{
+	v2.PriorityLow:    0.8,
+	v2.PriorityNormal: 0.95,
+}
+
+type FilterConfigFactory struct {
+	Config *priorityConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config)
+	// the route is needed for the route priority and the cluster saturation
+	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+}
+
+func CreatePriorityFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create priority stream filter factory")
+	cfg, err := ParseStreamPriorityFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newPriorityConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: config,
+	}, nil
+}
+
+// ParseStreamPriorityFilter
+func ParseStreamPriorityFilter(cfg map[string]interface{}) (*v2.StreamPriority, error) {
+	filterConfig := &v2.StreamPriority{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.Header == "" {
+		filterConfig.Header = defaultHeader
+	}
+	if filterConfig.Priority == "" {
+		filterConfig.Priority = v2.PriorityNormal
+	}
+	if s := filterConfig.Shedding; s != nil {
+		if s.Thresholds == nil {
+			s.Thresholds = defaultThresholds
+		}
+		if s.Status == 0 {
+			s.Status = http.StatusServiceUnavailable
+		}
+	}
+	return filterConfig, nil
+}
+
+// priorityConfig is the compiled StreamPriority, shared by the filters created by the factory
+type priorityConfig struct {
+	header           string
+	priority         string
+	shedding         bool
+	thresholds       map[string]float64
+	maxActiveStreams int64
+	status           int
+	// activeStreams counts the active streams of all the listeners
+	activeStreams gometrics.Counter
+	stats         map[string]*priorityStats
+}
+
+type priorityStats struct {
+	request gometrics.Counter
+	shed    gometrics.Counter
+}
+
+func newPriorityConfig(cfg *v2.StreamPriority) (*priorityConfig, error) {
+	if _, ok := priorityLevels[cfg.Priority]; !ok {
+		return nil, fmt.Errorf("priority filter has unknown priority %q", cfg.Priority)
+	}
+	config := &priorityConfig{
+		header:        cfg.Header,
+		priority:      cfg.Priority,
+		activeStreams: metrics.NewProxyStats(types.GlobalProxyName).Counter(metrics.DownstreamRequestActive),
+		stats:         make(map[string]*priorityStats, len(priorityLevels)),
+	}
+	if s := cfg.Shedding; s != nil {
+		for priority, threshold := range s.Thresholds {
+			if _, ok := priorityLevels[priority]; !ok {
+				return nil, fmt.Errorf("priority filter has unknown priority %q in the shedding thresholds", priority)
+			}
+			if threshold <= 0 || threshold > 1 {
+				return nil, fmt.Errorf("priority filter has invalid shedding threshold %v of priority %s", threshold, priority)
+			}
+		}
+		config.shedding = true
+		config.thresholds = s.Thresholds
+		config.maxActiveStreams = s.MaxActiveStreams
+		config.status = s.Status
+	}
+	for priority := range priorityLevels {
+		m, _ := metrics.NewMetrics(v2.Priority, map[string]string{"priority": priority})
+		config.stats[priority] = &priorityStats{
+			request: m.Counter(statsRequest),
+			shed:    m.Counter(statsShed),
+		}
+	}
+	return config, nil
+}
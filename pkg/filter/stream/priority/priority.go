@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package priority
+
+import (
+	"context"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+// priorityFilter classifies the request by the priority header, the route config and the default priority
+// in order, and sheds the request if the saturation reaches the threshold of its priority
+type priorityFilter struct {
+	ctx     context.Context
+	handler api.StreamReceiverFilterHandler
+	config  *priorityConfig
+}
+
+func NewFilter(ctx context.Context, config *priorityConfig) api.StreamReceiverFilter {
+	return &priorityFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *priorityFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *priorityFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	priority := f.classify(headers)
+	stats := f.config.stats[priority]
+	stats.request.Inc(1)
+	if !f.config.shedding {
+		return api.StreamFilterContinue
+	}
+	threshold, ok := f.config.thresholds[priority]
+	if !ok {
+		return api.StreamFilterContinue
+	}
+	if saturation := f.saturation(); saturation >= threshold {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [priority] shed %s priority request, saturation: %.2f, threshold: %.2f", priority, saturation, threshold)
+		}
+		stats.shed.Inc(1)
+		f.handler.RequestInfo().SetResponseFlag(types.OverloadShed)
+		f.handler.SendHijackReply(f.config.status, headers)
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *priorityFilter) classify(headers api.HeaderMap) string {
+	if value, ok := headers.Get(f.config.header); ok {
+		priority := strings.ToLower(strings.TrimSpace(value))
+		if _, ok := priorityLevels[priority]; ok {
+			return priority
+		}
+	}
+	if priority := f.routePriority(); priority != "" {
+		return priority
+	}
+	return f.config.priority
+}
+
+// routePriority returns the priority in the per filter config of the route, such as {"priority": {"priority": "high"}}
+func (f *priorityFilter) routePriority() string {
+	route := f.handler.Route()
+	if route == nil || route.RouteRule() == nil {
+		return ""
+	}
+	cfg, ok := route.RouteRule().PerFilterConfig()[v2.Priority].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	priority, _ := cfg["priority"].(string)
+	if _, ok := priorityLevels[priority]; !ok {
+		return ""
+	}
+	return priority
+}
+
+// saturation is the max usage of the route cluster's max requests and the local max active streams
+func (f *priorityFilter) saturation() float64 {
+	var saturation float64
+	if f.config.maxActiveStreams > 0 {
+		// the active streams contain the current request
+		saturation = float64(f.config.activeStreams.Count()-1) / float64(f.config.maxActiveStreams)
+	}
+	route := f.handler.Route()
+	if route == nil || route.RouteRule() == nil {
+		return saturation
+	}
+	snapshot, ok := cluster.GetClusterMngAdapterInstance().GetClusterSnapshot(f.ctx, route.RouteRule().ClusterName())
+	if !ok {
+		return saturation
+	}
+	info := snapshot.ClusterInfo()
+	if max := info.ResourceManager().Requests().Max(); max > 0 {
+		if usage := float64(info.Stats().UpstreamRequestActive.Count()) / float64(max); usage > saturation {
+			saturation = usage
+		}
+	}
+	return saturation
+}
+
+func (f *priorityFilter) OnDestroy() {}
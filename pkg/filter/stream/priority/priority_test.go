@@ -0,0 +1,200 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package priority
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+type mockRouteRule struct {
+	api.RouteRule
+	cluster string
+	config  map[string]interface{}
+}
+
+func (r *mockRouteRule) ClusterName() string {
+	return r.cluster
+}
+
+func (r *mockRouteRule) PerFilterConfig() map[string]interface{} {
+	return r.config
+}
+
+type mockRoute struct {
+	api.Route
+	rule *mockRouteRule
+}
+
+func (r *mockRoute) RouteRule() api.RouteRule {
+	return r.rule
+}
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	route api.Route
+	code  int
+	info  api.RequestInfo
+}
+
+func (h *mockReceiverHandler) Route() api.Route {
+	return h.route
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	if h.info == nil {
+		h.info = network.NewRequestInfo()
+	}
+	return h.info
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+func newTestFactory(t *testing.T, conf map[string]interface{}) *FilterConfigFactory {
+	factory, err := CreatePriorityFilterFactory(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factory.(*FilterConfigFactory)
+}
+
+func TestPriorityClassify(t *testing.T) {
+	ff := newTestFactory(t, map[string]interface{}{
+		"priority": "low",
+	})
+	handler := &mockReceiverHandler{}
+	f := NewFilter(context.Background(), ff.Config).(*priorityFilter)
+	f.SetReceiveFilterHandler(handler)
+	for _, tc := range []struct {
+		header   string
+		route    string
+		expected string
+	}{
+		{"", "", v2.PriorityLow},
+		{"High", "", v2.PriorityHigh},
+		{"unknown", "", v2.PriorityLow},
+		{"", v2.PriorityCritical, v2.PriorityCritical},
+		{"normal", v2.PriorityCritical, v2.PriorityNormal},
+	} {
+		headers := protocol.CommonHeader{}
+		if tc.header != "" {
+			headers[defaultHeader] = tc.header
+		}
+		handler.route = nil
+		if tc.route != "" {
+			handler.route = &mockRoute{rule: &mockRouteRule{config: map[string]interface{}{
+				v2.Priority: map[string]interface{}{"priority": tc.route},
+			}}}
+		}
+		if priority := f.classify(headers); priority != tc.expected {
+			t.Errorf("header %q, route %q, expected priority %s, but got %s", tc.header, tc.route, tc.expected, priority)
+		}
+	}
+}
+
+func TestPriorityShedByActiveStreams(t *testing.T) {
+	ff := newTestFactory(t, map[string]interface{}{
+		"shedding": map[string]interface{}{
+			"max_active_streams": 10,
+		},
+	})
+	activeStreams := gometrics.NewCounter()
+	ff.Config.activeStreams = activeStreams
+	shed := func(priority string) bool {
+		handler := &mockReceiverHandler{}
+		f := NewFilter(context.Background(), ff.Config)
+		f.SetReceiveFilterHandler(handler)
+		status := f.OnReceive(context.Background(), protocol.CommonHeader{defaultHeader: priority}, nil, nil)
+		if (status == api.StreamFilterStop) != (handler.code == http.StatusServiceUnavailable) {
+			t.Fatalf("unexpected filter status %v with code %d", status, handler.code)
+		}
+		if status == api.StreamFilterStop && !handler.RequestInfo().GetResponseFlag(types.OverloadShed) {
+			t.Fatal("shed request should set the response flag")
+		}
+		return status == api.StreamFilterStop
+	}
+	// 8 active streams besides the current one, saturation is 0.8
+	activeStreams.Inc(9)
+	if !shed(v2.PriorityLow) || shed(v2.PriorityNormal) || shed(v2.PriorityHigh) {
+		t.Fatal("only the low priority requests should be shed")
+	}
+	// saturation is 1
+	activeStreams.Inc(2)
+	if !shed(v2.PriorityLow) || !shed(v2.PriorityNormal) || shed(v2.PriorityCritical) {
+		t.Fatal("the low and normal priority requests should be shed")
+	}
+	if ff.Config.stats[v2.PriorityLow].shed.Count() < 2 {
+		t.Fatal("unexpected shed stats")
+	}
+}
+
+func TestPriorityShedByCluster(t *testing.T) {
+	cm := cluster.NewClusterManagerSingleton([]v2.Cluster{
+		{
+			Name:   "priority_cluster",
+			LbType: v2.LB_RANDOM,
+			CirBreThresholds: v2.CircuitBreakers{
+				Thresholds: []v2.Thresholds{{MaxRequests: 10}},
+			},
+		},
+	}, nil)
+	defer cm.Destroy()
+	ff := newTestFactory(t, map[string]interface{}{
+		"shedding": map[string]interface{}{
+			"thresholds": map[string]interface{}{"normal": 0.5},
+			"status":     429,
+		},
+	})
+	snapshot, _ := cm.GetClusterSnapshot(context.Background(), "priority_cluster")
+	snapshot.ClusterInfo().Stats().UpstreamRequestActive.Inc(5)
+	handler := &mockReceiverHandler{
+		route: &mockRoute{rule: &mockRouteRule{cluster: "priority_cluster"}},
+	}
+	f := NewFilter(context.Background(), ff.Config)
+	f.SetReceiveFilterHandler(handler)
+	if f.OnReceive(context.Background(), protocol.CommonHeader{}, nil, nil) != api.StreamFilterStop || handler.code != 429 {
+		t.Fatalf("normal priority request should be shed, code: %d", handler.code)
+	}
+	handler.code = 0
+	if f.OnReceive(context.Background(), protocol.CommonHeader{defaultHeader: "low"}, nil, nil) != api.StreamFilterContinue {
+		t.Fatal("low priority has no threshold, should not be shed")
+	}
+}
+
+func TestPriorityInvalidConfig(t *testing.T) {
+	for _, conf := range []map[string]interface{}{
+		{"priority": "urgent"},
+		{"shedding": map[string]interface{}{"thresholds": map[string]interface{}{"urgent": 0.5}}},
+		{"shedding": map[string]interface{}{"thresholds": map[string]interface{}{"low": 1.5}}},
+	} {
+		if _, err := CreatePriorityFilterFactory(conf); err == nil {
+			t.Errorf("config %v should be invalid", conf)
+		}
+	}
+}
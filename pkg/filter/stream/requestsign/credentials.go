@@ -0,0 +1,341 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/secret"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	sourceEnv    = "env"
+	sourceFile   = "file"
+	sourceSTS    = "sts"
+	sourceSecret = "secret"
+
+	defaultAccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	defaultSecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	defaultSessionTokenEnv = "AWS_SESSION_TOKEN"
+	defaultRoleArnEnv      = "AWS_ROLE_ARN"
+	defaultTokenFileEnv    = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	defaultSTSEndpoint     = "https://sts.amazonaws.com"
+	defaultSessionName     = "mosn"
+
+	// the sts credentials are refreshed before expired
+	stsExpiryWindow = 5 * time.Minute
+	stsTimeout      = 10 * time.Second
+	// the credentials file is checked for modification at most once in the interval
+	fileCheckInterval = 10 * time.Second
+)
+
+var errNoCredentials = errors.New("no credentials found")
+
+// credentials is used to sign the requests
+type credentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token,omitempty"`
+}
+
+// credentialsProvider retrieves the credentials, it should be safe for concurrent use
+type credentialsProvider interface {
+	Retrieve() (*credentials, error)
+}
+
+func newCredentialsProvider(cfg v2.SignCredentials) (credentialsProvider, error) {
+	switch cfg.Source {
+	case "", sourceEnv:
+		return &envProvider{
+			accessKey:    orDefault(cfg.AccessKeyEnv, defaultAccessKeyEnv),
+			secretKey:    orDefault(cfg.SecretKeyEnv, defaultSecretKeyEnv),
+			sessionToken: orDefault(cfg.SessionTokenEnv, defaultSessionTokenEnv),
+		}, nil
+	case sourceFile:
+		if cfg.Path == "" {
+			return nil, errors.New("credentials file path is required")
+		}
+		return &fileProvider{path: cfg.Path}, nil
+	case sourceSTS:
+		p := &stsProvider{
+			endpoint:    orDefault(cfg.STSEndpoint, defaultSTSEndpoint),
+			roleArn:     orDefault(cfg.RoleArn, os.Getenv(defaultRoleArnEnv)),
+			tokenFile:   orDefault(cfg.WebIdentityTokenFile, os.Getenv(defaultTokenFileEnv)),
+			sessionName: orDefault(cfg.SessionName, defaultSessionName),
+			client:      &http.Client{Timeout: stsTimeout},
+		}
+		if p.roleArn == "" || p.tokenFile == "" {
+			return nil, errors.New("role arn and web identity token file are required in sts credentials")
+		}
+		return p, nil
+	case sourceSecret:
+		if cfg.SecretName == "" {
+			return nil, errors.New("credentials secret name is required")
+		}
+		return newSecretCredentialsProvider(cfg.SecretName)
+	default:
+		return nil, fmt.Errorf("unknown credentials source: %s", cfg.Source)
+	}
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+// envProvider reads the credentials from environment variables
+type envProvider struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func (p *envProvider) Retrieve() (*credentials, error) {
+	c := &credentials{
+		AccessKeyID:     os.Getenv(p.accessKey),
+		SecretAccessKey: os.Getenv(p.secretKey),
+		SessionToken:    os.Getenv(p.sessionToken),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errNoCredentials
+	}
+	return c, nil
+}
+
+// fileProvider reads the credentials from a json file, and reloads it when the file is modified.
+// The file is checked at most once in fileCheckInterval, the cached credentials are served in between.
+type fileProvider struct {
+	path    string
+	mux     sync.Mutex
+	modTime time.Time
+	checked int64        // the unix nano time of the last check
+	creds   atomic.Value // stored *credentials
+}
+
+func (p *fileProvider) cached() *credentials {
+	c, _ := p.creds.Load().(*credentials)
+	if c != nil && timeNow().UnixNano()-atomic.LoadInt64(&p.checked) < int64(fileCheckInterval) {
+		return c
+	}
+	return nil
+}
+
+func (p *fileProvider) Retrieve() (*credentials, error) {
+	if c := p.cached(); c != nil {
+		return c, nil
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if c := p.cached(); c != nil {
+		return c, nil
+	}
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return nil, err
+	}
+	if c, _ := p.creds.Load().(*credentials); c != nil && info.ModTime().Equal(p.modTime) {
+		atomic.StoreInt64(&p.checked, timeNow().UnixNano())
+		return c, nil
+	}
+	b, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	c := &credentials{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errNoCredentials
+	}
+	p.creds.Store(c)
+	p.modTime = info.ModTime()
+	atomic.StoreInt64(&p.checked, timeNow().UnixNano())
+	return c, nil
+}
+
+// secretCredentialsProvider reads the credentials from a named secret, the value is a json like the file provider.
+// The credentials are updated when the secret is rotated.
+type secretCredentialsProvider struct {
+	name  string
+	creds atomic.Value // stored *credentials
+}
+
+func newSecretCredentialsProvider(name string) (*secretCredentialsProvider, error) {
+	p := &secretCredentialsProvider{name: name}
+	value, err := secret.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.update(value); err != nil {
+		return nil, err
+	}
+	if err := secret.Watch(name, p.onRotate); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *secretCredentialsProvider) update(value []byte) error {
+	c := &credentials{}
+	if err := json.Unmarshal(value, c); err != nil {
+		return err
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return errNoCredentials
+	}
+	p.creds.Store(c)
+	return nil
+}
+
+func (p *secretCredentialsProvider) onRotate(value []byte) {
+	if err := p.update(value); err != nil {
+		log.DefaultLogger.Errorf("[stream filter] [request sign] invalid credentials in secret %s: %v", p.name, err)
+	}
+}
+
+func (p *secretCredentialsProvider) Retrieve() (*credentials, error) {
+	return p.creds.Load().(*credentials), nil
+}
+
+// stsProvider assumes a role with web identity token, the temporary credentials are cached until expired.
+// The credentials are refreshed in the background before expired, so the requests are not blocked by the sts.
+// Only the first retrieve, or the retrieve after the credentials are expired, waits for the sts.
+type stsProvider struct {
+	endpoint    string
+	roleArn     string
+	tokenFile   string
+	sessionName string
+	client      *http.Client
+	mux         sync.Mutex   // serializes the calls to the sts
+	cached      atomic.Value // stored *stsCredentials
+	refreshing  int32
+}
+
+type stsCredentials struct {
+	creds      *credentials
+	expiration time.Time
+}
+
+type assumeRoleWithWebIdentityResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+func (p *stsProvider) Retrieve() (*credentials, error) {
+	now := timeNow()
+	if c, ok := p.cached.Load().(*stsCredentials); ok && now.Before(c.expiration) {
+		if !now.Add(stsExpiryWindow).Before(c.expiration) {
+			p.refreshAsync()
+		}
+		return c.creds, nil
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	// the credentials may be refreshed while waiting for the lock
+	if c, ok := p.cached.Load().(*stsCredentials); ok && timeNow().Before(c.expiration) {
+		return c.creds, nil
+	}
+	c, err := p.assumeRole()
+	if err != nil {
+		return nil, err
+	}
+	p.cached.Store(c)
+	return c.creds, nil
+}
+
+// refreshAsync refreshes the credentials in the background, the cached credentials are kept if it is failed
+func (p *stsProvider) refreshAsync() {
+	if !atomic.CompareAndSwapInt32(&p.refreshing, 0, 1) {
+		return
+	}
+	utils.GoWithRecover(func() {
+		defer atomic.StoreInt32(&p.refreshing, 0)
+		p.mux.Lock()
+		defer p.mux.Unlock()
+		if c, ok := p.cached.Load().(*stsCredentials); ok && timeNow().Add(stsExpiryWindow).Before(c.expiration) {
+			return
+		}
+		c, err := p.assumeRole()
+		if err != nil {
+			log.DefaultLogger.Errorf("[stream filter] [request sign] refresh sts credentials failed: %v", err)
+			return
+		}
+		p.cached.Store(c)
+	}, nil)
+}
+
+func (p *stsProvider) assumeRole() (*stsCredentials, error) {
+	token, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("Action", "AssumeRoleWithWebIdentity")
+	form.Set("Version", "2011-06-15")
+	form.Set("RoleArn", p.roleArn)
+	form.Set("RoleSessionName", p.sessionName)
+	form.Set("WebIdentityToken", strings.TrimSpace(string(token)))
+	resp, err := p.client.PostForm(p.endpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("assume role with web identity failed, status: %d, body: %s", resp.StatusCode, body)
+	}
+	result := &assumeRoleWithWebIdentityResponse{}
+	if err := xml.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	if result.Credentials.AccessKeyID == "" {
+		return nil, errNoCredentials
+	}
+	return &stsCredentials{
+		creds: &credentials{
+			AccessKeyID:     result.Credentials.AccessKeyID,
+			SecretAccessKey: result.Credentials.SecretAccessKey,
+			SessionToken:    result.Credentials.SessionToken,
+		},
+		expiration: result.Credentials.Expiration,
+	}, nil
+}
@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/secret"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	os.Setenv("TEST_SIGN_AK", "ak")
+	os.Setenv("TEST_SIGN_SK", "sk")
+	defer os.Unsetenv("TEST_SIGN_AK")
+	defer os.Unsetenv("TEST_SIGN_SK")
+	p, err := newCredentialsProvider(v2.SignCredentials{
+		AccessKeyEnv: "TEST_SIGN_AK",
+		SecretKeyEnv: "TEST_SIGN_SK",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.Retrieve()
+	if err != nil || c.AccessKeyID != "ak" || c.SecretAccessKey != "sk" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+	os.Unsetenv("TEST_SIGN_SK")
+	if _, err := p.Retrieve(); err == nil {
+		t.Fatal("expected no credentials error")
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "requestsign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials.json")
+	write := func(ak string, mod time.Time) {
+		content := fmt.Sprintf(`{"access_key_id":"%s","secret_access_key":"sk"}`, ak)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mod, mod)
+	}
+	write("ak1", time.Now().Add(-time.Minute))
+	p, err := newCredentialsProvider(v2.SignCredentials{Source: "file", Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := p.Retrieve(); err != nil || c.AccessKeyID != "ak1" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+	// the file is not checked again in the check interval
+	write("ak2", time.Now())
+	if c, err := p.Retrieve(); err != nil || c.AccessKeyID != "ak1" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+	// reload when the file is modified
+	atomic.StoreInt64(&p.(*fileProvider).checked, 0)
+	if c, err := p.Retrieve(); err != nil || c.AccessKeyID != "ak2" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+}
+
+func TestSTSCredentials(t *testing.T) {
+	var mux sync.Mutex
+	count := 0
+	expiration := time.Now().Add(time.Hour)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Lock()
+		defer mux.Unlock()
+		count++
+		if r.FormValue("Action") != "AssumeRoleWithWebIdentity" || r.FormValue("WebIdentityToken") != "token" ||
+			r.FormValue("RoleArn") != "arn:aws:iam::123456789012:role/test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <SessionToken>session%d</SessionToken>
+      <SecretAccessKey>sk</SecretAccessKey>
+      <Expiration>%s</Expiration>
+      <AccessKeyId>ak</AccessKeyId>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, count, expiration.UTC().Format(time.RFC3339))
+	}))
+	defer s.Close()
+	tokenFile, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("token\n")
+	tokenFile.Close()
+
+	p, err := newCredentialsProvider(v2.SignCredentials{
+		Source:               "sts",
+		STSEndpoint:          s.URL,
+		RoleArn:              "arn:aws:iam::123456789012:role/test",
+		WebIdentityTokenFile: tokenFile.Name(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		c, err := p.Retrieve()
+		if err != nil || c.AccessKeyID != "ak" || c.SessionToken != "session1" {
+			t.Fatalf("unexpected credentials: %v, %v", c, err)
+		}
+	}
+	// refresh in the background before expired, the cached credentials are served
+	sts := p.(*stsProvider)
+	cached := sts.cached.Load().(*stsCredentials)
+	sts.cached.Store(&stsCredentials{creds: cached.creds, expiration: time.Now().Add(time.Minute)})
+	if c, err := p.Retrieve(); err != nil || c.SessionToken != "session1" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+	for i := 0; i < 50; i++ {
+		if c, err := p.Retrieve(); err == nil && c.SessionToken == "session2" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c, err := p.Retrieve(); err != nil || c.SessionToken != "session2" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+	// refresh synchronously after expired
+	cached = sts.cached.Load().(*stsCredentials)
+	sts.cached.Store(&stsCredentials{creds: cached.creds, expiration: time.Now().Add(-time.Minute)})
+	if c, err := p.Retrieve(); err != nil || c.SessionToken != "session3" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+}
+
+func TestSecretCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "requestsign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "creds.json")
+	if err := ioutil.WriteFile(path, []byte(`{"access_key_id":"ak1","secret_access_key":"sk1"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newCredentialsProvider(v2.SignCredentials{Source: "secret", SecretName: "sign"}); err == nil {
+		t.Fatal("expected an error for the unknown secret")
+	}
+	if err := secret.Init(&v2.SecretsConfig{
+		Providers: []v2.SecretProviderConfig{{Name: "files", Type: secret.ProviderFile}},
+		Secrets:   []v2.SecretConfig{{Name: "sign", Provider: "files", Key: path}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer secret.Stop()
+	p, err := newCredentialsProvider(v2.SignCredentials{Source: "secret", SecretName: "sign"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := p.Retrieve(); err != nil || c.AccessKeyID != "ak1" || c.SecretAccessKey != "sk1" {
+		t.Fatalf("unexpected credentials: %v, %v", c, err)
+	}
+	// the credentials are rotated, the invalid credentials are ignored
+	for _, content := range []string{`{"access_key_id":"ak2","secret_access_key":"sk2"}`, `{"access_key_id":"ak3"}`} {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		secret.Refresh()
+		if c, err := p.Retrieve(); err != nil || c.AccessKeyID != "ak2" || c.SecretAccessKey != "sk2" {
+			t.Fatalf("unexpected credentials: %v, %v", c, err)
+		}
+	}
+}
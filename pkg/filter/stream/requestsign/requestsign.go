@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"context"
+	"net/http"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/buffer"
+)
+
+// requestSignFilter is an implement of StreamReceiverFilter, it signs the request before sent to upstream
+type requestSignFilter struct {
+	ctx      context.Context
+	handler  api.StreamReceiverFilterHandler
+	signer   signer
+	provider credentialsProvider
+}
+
+func NewFilter(ctx context.Context, s signer, p credentialsProvider) api.StreamReceiverFilter {
+	return &requestSignFilter{
+		ctx:      ctx,
+		signer:   s,
+		provider: p,
+	}
+}
+
+func (f *requestSignFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *requestSignFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	creds, err := f.provider.Retrieve()
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [request sign] retrieve credentials failed: %v", err)
+		f.handler.SendHijackReply(http.StatusInternalServerError, headers)
+		return api.StreamFilterStop
+	}
+	var body []byte
+	if buf != nil {
+		body = buf.Bytes()
+	}
+	f.signer.Sign(headers, body, creds, timeNow())
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [request sign] request signed with key %s", creds.AccessKeyID)
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *requestSignFilter) OnDestroy() {}
@@ -0,0 +1,206 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/sigv4"
+)
+
+const (
+	algorithmSigV4 = "sigv4"
+	algorithmHmac  = "hmac"
+
+	headerHost        = "host"
+	headerDate        = "date"
+	defaultSignHeader = "x-signature"
+)
+
+var timeNow = time.Now
+
+// signer signs the request in headers
+type signer interface {
+	Sign(headers api.HeaderMap, body []byte, creds *credentials, now time.Time)
+}
+
+func newSigner(cfg *v2.StreamRequestSign) (signer, error) {
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", algorithmSigV4:
+		if cfg.Service == "" || cfg.Region == "" {
+			return nil, fmt.Errorf("service and region are required in sigv4")
+		}
+		return &sigV4Signer{
+			service:       cfg.Service,
+			region:        cfg.Region,
+			signedHeaders: cfg.SignedHeaders,
+		}, nil
+	case algorithmHmac:
+		s := &hmacSigner{
+			header:        strings.ToLower(orDefault(cfg.SignatureHeader, defaultSignHeader)),
+			signedHeaders: cfg.SignedHeaders,
+		}
+		switch strings.ToLower(cfg.Hash) {
+		case "", "sha256":
+			s.name, s.hash = "hmac-sha256", sha256.New
+		case "sha1":
+			s.name, s.hash = "hmac-sha1", sha1.New
+		default:
+			return nil, fmt.Errorf("unsupported hmac hash: %s", cfg.Hash)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported sign algorithm: %s", cfg.Algorithm)
+	}
+}
+
+// request is the request info used in signature
+type request struct {
+	method string
+	path   string
+	query  string
+	host   string
+}
+
+func getRequest(headers api.HeaderMap) request {
+	r := request{
+		method: http.MethodGet,
+		path:   "/",
+	}
+	if v, ok := headers.Get(protocol.MosnHeaderMethod); ok && v != "" {
+		r.method = strings.ToUpper(v)
+	}
+	if v, ok := headers.Get(protocol.MosnHeaderPathKey); ok && v != "" {
+		r.path = v
+	}
+	if v, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok {
+		r.query = v
+	}
+	if v := getHeader(headers, headerHost); v != "" {
+		r.host = v
+	} else if v, ok := headers.Get(protocol.MosnHeaderHostKey); ok {
+		r.host = v
+	}
+	return r
+}
+
+// getHeader returns the trimmed header value, the multiple spaces are replaced by one.
+// The header is searched by the name and its lower case, as some protocols keep the header case.
+func getHeader(headers api.HeaderMap, name string) string {
+	v, ok := headers.Get(name)
+	if !ok {
+		v, _ = headers.Get(strings.ToLower(name))
+	}
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// sigV4Signer implements AWS Signature Version 4 by the sigv4 package
+type sigV4Signer struct {
+	service       string
+	region        string
+	signedHeaders []string
+}
+
+func (s *sigV4Signer) Sign(headers api.HeaderMap, body []byte, creds *credentials, now time.Time) {
+	r := getRequest(headers)
+	req := &sigv4.Request{
+		Method:  r.method,
+		Path:    r.path,
+		Query:   r.query,
+		Host:    r.host,
+		Headers: make(map[string]string, len(s.signedHeaders)),
+		Body:    body,
+	}
+	for _, h := range s.signedHeaders {
+		req.Headers[strings.ToLower(h)] = getHeader(headers, h)
+	}
+	signed := sigv4.Sign(req, s.service, s.region, &sigv4.Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}, now)
+	for name, value := range signed {
+		headers.Set(name, value)
+	}
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSum(h func() hash.Hash, key, data []byte) []byte {
+	mac := hmac.New(h, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// hmacSigner signs the request with a generic hmac signature. The string to sign is
+//
+//	method + "\n" + path + "\n" + query + "\n" + name:value of each signed header + "\n" + hex(sha256(body))
+//
+// and the signature header is set to
+//
+//	keyId="<access key id>",algorithm="hmac-sha256",headers="<signed headers>",signature="<base64 signature>"
+type hmacSigner struct {
+	header        string
+	name          string
+	hash          func() hash.Hash
+	signedHeaders []string
+}
+
+func (s *hmacSigner) Sign(headers api.HeaderMap, body []byte, creds *credentials, now time.Time) {
+	r := getRequest(headers)
+	var b strings.Builder
+	b.WriteString(r.method + "\n" + r.path + "\n" + r.query + "\n")
+	names := make([]string, 0, len(s.signedHeaders))
+	for _, h := range s.signedHeaders {
+		name := strings.ToLower(h)
+		names = append(names, name)
+		var value string
+		switch name {
+		case "host":
+			value = r.host
+		case "date":
+			// the date header is added if not exists, so the signature can expire
+			if value = getHeader(headers, headerDate); value == "" {
+				value = now.UTC().Format(http.TimeFormat)
+				headers.Set(headerDate, value)
+			}
+		default:
+			value = getHeader(headers, h)
+		}
+		b.WriteString(name + ":" + value + "\n")
+	}
+	b.WriteString(hashHex(body))
+	signature := base64.StdEncoding.EncodeToString(hmacSum(s.hash, []byte(creds.SecretAccessKey), []byte(b.String())))
+	headers.Set(s.header, fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		creds.AccessKeyID, s.name, strings.Join(names, " "), signature))
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package requestsign
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/sigv4"
+)
+
+var testCreds = &credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+func TestSigV4Sign(t *testing.T) {
+	// get-vanilla and get-vanilla-query-order-key-case in the aws sigv4 test suite
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	s, err := newSigner(&v2.StreamRequestSign{
+		Algorithm: "sigv4",
+		Service:   "service",
+		Region:    "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		query     string
+		signature string
+	}{
+		{"", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	} {
+		headers := protocol.CommonHeader{
+			protocol.MosnHeaderMethod:         "GET",
+			protocol.MosnHeaderPathKey:        "/",
+			protocol.MosnHeaderQueryStringKey: c.query,
+			"host":                            "example.amazonaws.com",
+		}
+		s.Sign(headers, nil, testCreds, now)
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + c.signature
+		if auth, _ := headers.Get(sigv4.HeaderAuthorization); auth != expected {
+			t.Errorf("unexpected authorization: %s", auth)
+		}
+		if date, _ := headers.Get(sigv4.HeaderDate); date != "20150830T123600Z" {
+			t.Errorf("unexpected date: %s", date)
+		}
+	}
+}
+
+func TestHmacSign(t *testing.T) {
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	s, err := newSigner(&v2.StreamRequestSign{
+		Algorithm:     "hmac",
+		SignedHeaders: []string{"Host", "Date", "X-Tenant"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(tenant string) string {
+		headers := protocol.CommonHeader{
+			protocol.MosnHeaderMethod:  "POST",
+			protocol.MosnHeaderPathKey: "/api",
+			"host":                     "example.com",
+			"X-Tenant":                 tenant,
+		}
+		s.Sign(headers, []byte("body"), testCreds, now)
+		if date, _ := headers.Get(headerDate); date != "Sun, 30 Aug 2015 12:36:00 GMT" {
+			t.Errorf("unexpected date header: %s", date)
+		}
+		v, _ := headers.Get(defaultSignHeader)
+		return v
+	}
+	v1 := sign("a")
+	if !strings.HasPrefix(v1, `keyId="AKIDEXAMPLE",algorithm="hmac-sha256",headers="host date x-tenant",signature="`) {
+		t.Errorf("unexpected signature header: %s", v1)
+	}
+	if v1 != sign("a") || v1 == sign("b") {
+		t.Error("signature should depend on signed headers only")
+	}
+}
+
+func TestNewSignerError(t *testing.T) {
+	for _, cfg := range []*v2.StreamRequestSign{
+		{Algorithm: "sigv4"},
+		{Algorithm: "hmac", Hash: "md5"},
+		{Algorithm: "unknown"},
+	} {
+		if _, err := newSigner(cfg); err == nil {
+			t.Errorf("expected error for config %+v", cfg)
+		}
+	}
+}
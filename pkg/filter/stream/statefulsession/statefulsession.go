@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statefulsession
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+const (
+	headerCookie    = "cookie"
+	headerSetCookie = "set-cookie"
+)
+
+// statefulSessionFilter makes the requests with the session cookie sent to the upstream host encoded in
+// the cookie, the load balancer is bypassed while the host is healthy. The cookie is set in the response
+// if the request has no session cookie, or the upstream host is changed.
+type statefulSessionFilter struct {
+	ctx     context.Context
+	config  *v2.StreamStatefulSession
+	handler api.StreamReceiverFilterHandler
+	// the upstream host address in the request cookie
+	sessionHost string
+}
+
+func NewFilter(ctx context.Context, config *v2.StreamStatefulSession) *statefulSessionFilter {
+	return &statefulSessionFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *statefulSessionFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *statefulSessionFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {}
+
+func (f *statefulSessionFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	value := getCookie(headers, f.config.CookieName)
+	if value == "" {
+		return api.StreamFilterContinue
+	}
+	addr, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(ctx, "[stream filter] [stateful session] invalid session cookie: %s", value)
+		}
+		return api.StreamFilterContinue
+	}
+	f.sessionHost = string(addr)
+	cluster.SetOverrideHost(ctx, f.sessionHost)
+	return api.StreamFilterContinue
+}
+
+func (f *statefulSessionFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if f.handler == nil {
+		return api.StreamFilterContinue
+	}
+	host := f.handler.RequestInfo().UpstreamHost()
+	if host == nil || host.AddressString() == f.sessionHost {
+		return api.StreamFilterContinue
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [stateful session] set session host %s, previous: %s", host.AddressString(), f.sessionHost)
+	}
+	// the cookies set by the upstream are kept
+	if _, ok := headers.Get(headerSetCookie); ok {
+		headers.Add(headerSetCookie, f.cookie(host.AddressString()))
+	} else {
+		headers.Set(headerSetCookie, f.cookie(host.AddressString()))
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *statefulSessionFilter) OnDestroy() {}
+
+func (f *statefulSessionFilter) cookie(addr string) string {
+	c := &http.Cookie{
+		Name:     f.config.CookieName,
+		Value:    base64.RawURLEncoding.EncodeToString([]byte(addr)),
+		Path:     f.config.Path,
+		MaxAge:   int(f.config.TTL.Seconds()),
+		HttpOnly: true,
+	}
+	return c.String()
+}
+
+func getCookie(headers api.HeaderMap, name string) string {
+	v, ok := headers.Get(headerCookie)
+	if !ok {
+		return ""
+	}
+	r := &http.Request{Header: http.Header{"Cookie": []string{v}}}
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
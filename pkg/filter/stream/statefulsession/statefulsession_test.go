@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statefulsession
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockHost struct {
+	api.HostInfo
+	addr string
+}
+
+func (h *mockHost) AddressString() string {
+	return h.addr
+}
+
+type mockRequestInfo struct {
+	api.RequestInfo
+	host api.HostInfo
+}
+
+func (info *mockRequestInfo) UpstreamHost() api.HostInfo {
+	return info.host
+}
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	info *mockRequestInfo
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	return h.info
+}
+
+func TestStatefulSessionFilter(t *testing.T) {
+	factory, err := CreateStatefulSessionFilterFactory(map[string]interface{}{
+		"cookie_name": "sticky",
+		"ttl":         "1h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := factory.(*FilterConfigFactory).Config
+	if cfg.Path != "/" {
+		t.Fatalf("unexpected default path: %s", cfg.Path)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString([]byte("127.0.0.1:8080"))
+
+	for i, c := range []struct {
+		cookie    string
+		upstream  string
+		override  string
+		setCookie bool
+	}{
+		// no session cookie, the cookie is set
+		{"", "127.0.0.1:8080", "", true},
+		// the session host is used
+		{"a=b; sticky=" + encoded, "127.0.0.1:8080", "127.0.0.1:8080", false},
+		// the session host is unavailable, the cookie is updated
+		{"sticky=" + encoded, "127.0.0.1:8081", "127.0.0.1:8080", true},
+		// invalid cookie
+		{"sticky=!!!", "127.0.0.1:8081", "", true},
+	} {
+		ctx := mosnctx.WithValue(context.Background(), types.ContextKeyStreamID, uint64(i))
+		handler := &mockReceiverHandler{info: &mockRequestInfo{}}
+		filter := NewFilter(ctx, cfg)
+		filter.SetReceiveFilterHandler(handler)
+		headers := protocol.CommonHeader{}
+		if c.cookie != "" {
+			headers.Set("cookie", c.cookie)
+		}
+		filter.OnReceive(ctx, headers, nil, nil)
+		override, _ := mosnctx.Get(ctx, types.ContextKeyUpstreamOverrideHost).(string)
+		if override != c.override {
+			t.Fatalf("case %d expected override host %s, but got %s", i, c.override, override)
+		}
+		handler.info.host = &mockHost{addr: c.upstream}
+		respHeaders := protocol.CommonHeader{}
+		filter.Append(ctx, respHeaders, nil, nil)
+		setCookie, ok := respHeaders.Get("set-cookie")
+		if ok != c.setCookie {
+			t.Fatalf("case %d expected set cookie %t, but got %s", i, c.setCookie, setCookie)
+		}
+		if ok {
+			expected := "sticky=" + base64.RawURLEncoding.EncodeToString([]byte(c.upstream)) + "; Path=/; Max-Age=3600; HttpOnly"
+			if !strings.EqualFold(setCookie, expected) {
+				t.Fatalf("case %d unexpected set cookie: %s", i, setCookie)
+			}
+		}
+	}
+}
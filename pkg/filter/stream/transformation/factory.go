@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package transformation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+)
+
+func init() {
+	api.RegisterStream(v2.Transformation, CreateTransformationFilterFactory)
+}
+
+// transformers are the compiled transformations of both directions
+type transformers struct {
+	request  *transformer
+	response *transformer
+}
+
+type FilterConfigFactory struct {
+	Config *v2.StreamTransformation
+	// transformers is the compiled filter level config
+	transformers *transformers
+	// routes caches the compiled route level configs, the key is the route rule
+	routes sync.Map
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f)
+	callbacks.AddStreamReceiverFilter(filter, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+// routeTransformers returns the compiled route level config, nil means the route has no transformation config
+func (f *FilterConfigFactory) routeTransformers(rule api.RouteRule) *transformers {
+	cfg, ok := rule.PerFilterConfig()[v2.Transformation]
+	if !ok {
+		return nil
+	}
+	if v, ok := f.routes.Load(rule); ok {
+		return v.(*transformers)
+	}
+	conf, ok := cfg.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	config, err := ParseStreamTransformationFilter(conf)
+	if err != nil {
+		log.DefaultLogger.Errorf("[stream filter] [transformation] invalid route config: %v", err)
+		return nil
+	}
+	compiled, err := compile(config)
+	if err != nil {
+		log.DefaultLogger.Errorf("[stream filter] [transformation] invalid route config: %v", err)
+		return nil
+	}
+	v, _ := f.routes.LoadOrStore(rule, compiled)
+	return v.(*transformers)
+}
+
+func CreateTransformationFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create transformation stream filter factory")
+	cfg, err := ParseStreamTransformationFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := compile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config:       cfg,
+		transformers: compiled,
+	}, nil
+}
+
+// ParseStreamTransformationFilter
+func ParseStreamTransformationFilter(cfg map[string]interface{}) (*v2.StreamTransformation, error) {
+	filterConfig := &v2.StreamTransformation{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.Request == nil && filterConfig.Response == nil {
+		return nil, errors.New("transformation requires request or response config")
+	}
+	return filterConfig, nil
+}
+
+func compile(cfg *v2.StreamTransformation) (*transformers, error) {
+	t := &transformers{}
+	var err error
+	if cfg.Request != nil {
+		if t.request, err = newTransformer(cfg.Request); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Response != nil {
+		if t.response, err = newTransformer(cfg.Response); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package transformation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+var templateFuncs = template.FuncMap{
+	"json":     toJSON,
+	"jsonpath": lookupJSONPath,
+}
+
+// transformer rebuilds the headers and the body of one direction
+type transformer struct {
+	headers    map[string]*template.Template
+	extractors map[string]jsonPath
+	body       *template.Template
+}
+
+func newTransformer(cfg *v2.TransformationConfig) (*transformer, error) {
+	t := &transformer{
+		headers:    make(map[string]*template.Template, len(cfg.Headers)),
+		extractors: make(map[string]jsonPath, len(cfg.Extractors)),
+	}
+	for name, text := range cfg.Headers {
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of header %s: %v", name, err)
+		}
+		t.headers[name] = tmpl
+	}
+	for name, path := range cfg.Extractors {
+		p, err := parseJSONPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json path of header %s: %v", name, err)
+		}
+		t.extractors[name] = p
+	}
+	if cfg.Body != "" {
+		tmpl, err := template.New("body").Funcs(templateFuncs).Parse(cfg.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of body: %v", err)
+		}
+		t.body = tmpl
+	}
+	return t, nil
+}
+
+// transform modifies the headers, and returns the new body if the body is rebuilt.
+// the templates and the extractors are all evaluated with the original headers and body.
+func (t *transformer) transform(headers api.HeaderMap, body []byte) ([]byte, bool, error) {
+	data := newTemplateData(headers, body)
+	for name, path := range t.extractors {
+		if data["body"] == nil {
+			break
+		}
+		if v, ok := path.lookup(data["body"]); ok {
+			headers.Set(name, toString(v))
+		}
+	}
+	for name, tmpl := range t.headers {
+		value, err := execute(tmpl, data)
+		if err != nil {
+			return nil, false, err
+		}
+		if value == "" {
+			headers.Del(name)
+		} else {
+			headers.Set(name, value)
+		}
+	}
+	if t.body == nil {
+		return nil, false, nil
+	}
+	value, err := execute(t.body, data)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(value), true, nil
+}
+
+func newTemplateData(headers api.HeaderMap, body []byte) map[string]interface{} {
+	hm := map[string]string{}
+	if headers != nil {
+		headers.Range(func(key, value string) bool {
+			hm[strings.ToLower(key)] = value
+			return true
+		})
+	}
+	var parsed interface{}
+	if len(body) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&parsed); err != nil {
+			parsed = nil
+		}
+	}
+	return map[string]interface{}{
+		"headers": hm,
+		"body":    parsed,
+		"raw":     string(body),
+	}
+}
+
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// toString returns the strings and the numbers as is, other values are encoded as JSON
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	}
+	s, _ := toJSON(v)
+	return s
+}
+
+func lookupJSONPath(v interface{}, path string) (interface{}, error) {
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := p.lookup(v)
+	return value, nil
+}
+
+// jsonPath is a simplified JSONPath, the segments are object keys or array indexes,
+// such as $.data.items[0].id, the leading $ is optional.
+type jsonPath []interface{}
+
+func parseJSONPath(path string) (jsonPath, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var p jsonPath
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in %s", path)
+			}
+			p = append(p, s[:end])
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed index in %s", path)
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index in %s", path)
+			}
+			p = append(p, index)
+			s = s[end+1:]
+		default:
+			// the first key without a leading dot
+			if len(p) > 0 {
+				return nil, fmt.Errorf("invalid json path %s", path)
+			}
+			s = "." + s
+		}
+	}
+	return p, nil
+}
+
+func (p jsonPath) lookup(v interface{}) (interface{}, bool) {
+	for _, seg := range p {
+		switch key := seg.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[key]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || key >= len(a) {
+				return nil, false
+			}
+			v = a[key]
+		}
+	}
+	return v, true
+}
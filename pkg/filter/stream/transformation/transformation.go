@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package transformation
+
+import (
+	"context"
+	"strconv"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/buffer"
+)
+
+// transformationFilter rebuilds the request headers and body before they are sent to upstream,
+// and the response headers and body before they are sent to downstream.
+// The route level config replaces the filter level config.
+type transformationFilter struct {
+	ctx            context.Context
+	factory        *FilterConfigFactory
+	transformers   *transformers
+	receiveHandler api.StreamReceiverFilterHandler
+	sendHandler    api.StreamSenderFilterHandler
+}
+
+func NewFilter(ctx context.Context, factory *FilterConfigFactory) *transformationFilter {
+	return &transformationFilter{
+		ctx:          ctx,
+		factory:      factory,
+		transformers: factory.transformers,
+	}
+}
+
+func (f *transformationFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.receiveHandler = handler
+}
+
+func (f *transformationFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
+	f.sendHandler = handler
+}
+
+func (f *transformationFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if route := f.receiveHandler.Route(); route != nil && route.RouteRule() != nil {
+		if t := f.factory.routeTransformers(route.RouteRule()); t != nil {
+			f.transformers = t
+		}
+	}
+	f.transform(ctx, f.transformers.request, headers, buf, "request")
+	return api.StreamFilterContinue
+}
+
+func (f *transformationFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	f.transform(ctx, f.transformers.response, headers, buf, "response")
+	return api.StreamFilterContinue
+}
+
+func (f *transformationFilter) OnDestroy() {}
+
+// transform rebuilds the headers and the body in place, the body is not changed if the transformation fails
+func (f *transformationFilter) transform(ctx context.Context, t *transformer, headers api.HeaderMap, buf buffer.IoBuffer, direction string) {
+	if t == nil || headers == nil {
+		return
+	}
+	var body []byte
+	if buf != nil {
+		body = buf.Bytes()
+	}
+	newBody, changed, err := t.transform(headers, body)
+	if err != nil {
+		log.Proxy.Errorf(ctx, "[stream filter] [transformation] transform %s failed: %v", direction, err)
+		return
+	}
+	// the body can only be rebuilt if the stream has a body
+	if !changed || buf == nil {
+		return
+	}
+	buf.Reset()
+	buf.Write(newBody)
+	if _, ok := headers.Get("Content-Length"); ok {
+		headers.Set("Content-Length", strconv.Itoa(len(newBody)))
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(ctx, "[stream filter] [transformation] %s body is rebuilt, %d bytes", direction, len(newBody))
+	}
+}
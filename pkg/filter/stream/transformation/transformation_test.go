@@ -0,0 +1,188 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package transformation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/pkg/buffer"
+)
+
+type mockRouteRule struct {
+	api.RouteRule
+	config map[string]interface{}
+}
+
+func (r *mockRouteRule) PerFilterConfig() map[string]interface{} {
+	return r.config
+}
+
+type mockRoute struct {
+	api.Route
+	rule *mockRouteRule
+}
+
+func (r *mockRoute) RouteRule() api.RouteRule {
+	return r.rule
+}
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	route api.Route
+}
+
+func (h *mockReceiverHandler) Route() api.Route {
+	return h.route
+}
+
+func createFactory(t *testing.T, conf map[string]interface{}) *FilterConfigFactory {
+	factory, err := CreateTransformationFilterFactory(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factory.(*FilterConfigFactory)
+}
+
+func TestCreateTransformationFilterFactoryInvalid(t *testing.T) {
+	for i, conf := range []map[string]interface{}{
+		{},
+		{"request": map[string]interface{}{"body": "{{.body"}},
+		{"response": map[string]interface{}{"headers": map[string]interface{}{"x-id": "{{"}}},
+		{"response": map[string]interface{}{"extractors": map[string]interface{}{"x-id": "$.data[a]"}}},
+	} {
+		if _, err := CreateTransformationFilterFactory(conf); err == nil {
+			t.Fatalf("case %d expected an error", i)
+		}
+	}
+}
+
+func TestTransformationFilter(t *testing.T) {
+	factory := createFactory(t, map[string]interface{}{
+		"request": map[string]interface{}{
+			"headers": map[string]interface{}{
+				"x-caller": `{{index .headers "x-user"}}-mosn`,
+				"x-user":   "",
+			},
+		},
+		"response": map[string]interface{}{
+			"extractors": map[string]interface{}{
+				"x-order-id": "$.data.orders[1].id",
+				"x-count":    "$.data.count",
+				"x-missing":  "$.data.missing",
+			},
+			"body": `{"code":0,"data":{{json .body}}}`,
+		},
+	})
+	ctx := context.Background()
+	filter := NewFilter(ctx, factory)
+	filter.SetReceiveFilterHandler(&mockReceiverHandler{})
+
+	reqHeaders := protocol.CommonHeader{"x-user": "alice"}
+	filter.OnReceive(ctx, reqHeaders, nil, nil)
+	if !reflect.DeepEqual(reqHeaders, protocol.CommonHeader{"x-caller": "alice-mosn"}) {
+		t.Fatalf("unexpected request headers: %v", reqHeaders)
+	}
+
+	respHeaders := protocol.CommonHeader{"Content-Length": "52"}
+	respBody := buffer.NewIoBufferString(`{"data":{"count":2,"orders":[{"id":1},{"id":"b2"}]}}`)
+	filter.Append(ctx, respHeaders, respBody, nil)
+	expected := `{"code":0,"data":{"data":{"count":2,"orders":[{"id":1},{"id":"b2"}]}}}`
+	if respBody.String() != expected {
+		t.Fatalf("unexpected response body: %s", respBody.String())
+	}
+	if !reflect.DeepEqual(respHeaders, protocol.CommonHeader{
+		"Content-Length": "70",
+		"x-order-id":     "b2",
+		"x-count":        "2",
+	}) {
+		t.Fatalf("unexpected response headers: %v", respHeaders)
+	}
+
+	// the body is not changed if the template fails
+	factory = createFactory(t, map[string]interface{}{
+		"response": map[string]interface{}{
+			"body": `{{index .body 1}}`,
+		},
+	})
+	filter = NewFilter(ctx, factory)
+	respBody = buffer.NewIoBufferString(`{"a":1}`)
+	filter.Append(ctx, protocol.CommonHeader{}, respBody, nil)
+	if respBody.String() != `{"a":1}` {
+		t.Fatalf("unexpected response body: %s", respBody.String())
+	}
+}
+
+func TestTransformationFilterPerRoute(t *testing.T) {
+	factory := createFactory(t, map[string]interface{}{
+		"response": map[string]interface{}{
+			"headers": map[string]interface{}{"x-level": "filter"},
+		},
+	})
+	rule := &mockRouteRule{config: map[string]interface{}{
+		v2.Transformation: map[string]interface{}{
+			"response": map[string]interface{}{
+				"headers": map[string]interface{}{"x-level": "route"},
+				"body":    `{{jsonpath .body "items[0]"}}`,
+			},
+		},
+	}}
+	for i := 0; i < 2; i++ {
+		ctx := context.Background()
+		filter := NewFilter(ctx, factory)
+		filter.SetReceiveFilterHandler(&mockReceiverHandler{route: &mockRoute{rule: rule}})
+		filter.OnReceive(ctx, protocol.CommonHeader{}, nil, nil)
+		headers := protocol.CommonHeader{}
+		body := buffer.NewIoBufferString(`{"items":["first","second"]}`)
+		filter.Append(ctx, headers, body, nil)
+		if v, _ := headers.Get("x-level"); v != "route" || body.String() != "first" {
+			t.Fatalf("unexpected response: %v %s", headers, body.String())
+		}
+	}
+	if _, ok := factory.routes.Load(rule); !ok {
+		t.Fatal("the route config is not cached")
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	for _, c := range []struct {
+		path     string
+		expected jsonPath
+		valid    bool
+	}{
+		{"$.data.items[0].id", jsonPath{"data", "items", 0, "id"}, true},
+		{"data.items[10]", jsonPath{"data", "items", 10}, true},
+		{"$[1][2]", jsonPath{1, 2}, true},
+		{"$", nil, true},
+		{"$.data..id", nil, false},
+		{"$.items[1", nil, false},
+		{"$.items[-1]", nil, false},
+		{"$.items[0]id", nil, false},
+	} {
+		p, err := parseJSONPath(c.path)
+		if (err == nil) != c.valid {
+			t.Fatalf("path %s expected valid %t, but got %v", c.path, c.valid, err)
+		}
+		if c.valid && !reflect.DeepEqual(p, c.expected) {
+			t.Fatalf("path %s expected %v, but got %v", c.path, c.expected, p)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/filter"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+const (
+	defaultMaxBodySize = 8192
+
+	statsBlocked = "blocked"
+	statsLogged  = "logged"
+)
+
+func init() {
+	api.RegisterStream(v2.WAF, CreateWAFFilterFactory)
+	filter.RegisterStreamFilterPhase(v2.WAF, v2.StreamFilterPhaseAuthz)
+}
+
+type FilterConfigFactory struct {
+	Config *wafConfig
+}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	filter := NewFilter(context, f.Config)
+	callbacks.AddStreamReceiverFilter(filter, api.BeforeRoute)
+	if len(f.Config.responseRules) > 0 {
+		callbacks.AddStreamSenderFilter(filter)
+	}
+}
+
+func CreateWAFFilterFactory(conf map[string]interface{}) (api.StreamFilterChainFactory, error) {
+	log.DefaultLogger.Debugf("create waf stream filter factory")
+	cfg, err := ParseStreamWAFFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+	config, err := newWAFConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &FilterConfigFactory{
+		Config: config,
+	}, nil
+}
+
+// ParseStreamWAFFilter
+func ParseStreamWAFFilter(cfg map[string]interface{}) (*v2.StreamWAF, error) {
+	filterConfig := &v2.StreamWAF{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, filterConfig); err != nil {
+		return nil, err
+	}
+	if filterConfig.Mode == "" {
+		filterConfig.Mode = modeBlock
+	}
+	if filterConfig.Status == 0 {
+		filterConfig.Status = http.StatusForbidden
+	}
+	if filterConfig.MaxBodySize <= 0 {
+		filterConfig.MaxBodySize = defaultMaxBodySize
+	}
+	return filterConfig, nil
+}
+
+// wafConfig is the compiled StreamWAF, shared by the filters created by the factory
+type wafConfig struct {
+	status        int
+	maxBodySize   int
+	requestRules  []*rule
+	responseRules []*rule
+	exclusions    []exclusion
+	blocked       gometrics.Counter
+	logged        gometrics.Counter
+}
+
+type exclusion struct {
+	pathPrefix string
+	rules      map[string]bool
+}
+
+func newWAFConfig(cfg *v2.StreamWAF) (*wafConfig, error) {
+	if cfg.Mode != modeBlock && cfg.Mode != modeLog {
+		return nil, fmt.Errorf("waf filter has unknown mode %q", cfg.Mode)
+	}
+	m, _ := metrics.NewMetrics(wafType, map[string]string{"waf": "global"})
+	config := &wafConfig{
+		status:      cfg.Status,
+		maxBodySize: cfg.MaxBodySize,
+		blocked:     m.Counter(statsBlocked),
+		logged:      m.Counter(statsLogged),
+	}
+	ids := make(map[string]bool, len(cfg.Rules))
+	for i, rc := range cfg.Rules {
+		r, err := newRule(rc, i, cfg.Mode)
+		if err != nil {
+			return nil, err
+		}
+		if ids[r.id] {
+			return nil, fmt.Errorf("waf rule %s is duplicated", r.id)
+		}
+		ids[r.id] = true
+		switch rc.Direction {
+		case "", directionRequest:
+			config.requestRules = append(config.requestRules, r)
+		case directionResponse:
+			config.responseRules = append(config.responseRules, r)
+		default:
+			return nil, fmt.Errorf("waf rule %s has unknown direction %q", r.id, rc.Direction)
+		}
+	}
+	for _, ec := range cfg.Exclusions {
+		e := exclusion{pathPrefix: ec.PathPrefix}
+		if len(ec.Rules) > 0 {
+			e.rules = make(map[string]bool, len(ec.Rules))
+			for _, id := range ec.Rules {
+				e.rules[id] = true
+			}
+		}
+		config.exclusions = append(config.exclusions, e)
+	}
+	return config, nil
+}
+
+// excluded returns true if the rule is skipped for the path
+func (c *wafConfig) excluded(path string, r *rule) bool {
+	for _, e := range c.exclusions {
+		if !strings.HasPrefix(path, e.pathPrefix) {
+			continue
+		}
+		if e.rules == nil || e.rules[r.id] {
+			return true
+		}
+	}
+	return false
+}
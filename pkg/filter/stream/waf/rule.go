@@ -0,0 +1,193 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+const (
+	modeBlock = "block"
+	modeLog   = "log"
+
+	directionRequest  = "request"
+	directionResponse = "response"
+
+	targetMethod  = "method"
+	targetPath    = "path"
+	targetQuery   = "query"
+	targetHeader  = "header"
+	targetHeaders = "headers"
+	targetBody    = "body"
+
+	contentTypeHeader = "content-type"
+	// the internal headers of mosn are not inspected as the "headers" target
+	internalHeaderPrefix = "x-mosn-"
+
+	wafType  = "waf"
+	statsHit = "hit"
+)
+
+// rule is the compiled WAFRule
+type rule struct {
+	id           string
+	target       string
+	header       string
+	pattern      *regexp.Regexp
+	maxSize      int
+	contentTypes []string
+	mode         string
+	hits         gometrics.Counter
+}
+
+func newRule(cfg v2.WAFRule, index int, mode string) (*rule, error) {
+	r := &rule{
+		id:      cfg.ID,
+		target:  cfg.Target,
+		header:  strings.ToLower(cfg.Header),
+		maxSize: cfg.MaxSize,
+		mode:    cfg.Mode,
+	}
+	if r.id == "" {
+		r.id = fmt.Sprintf("rule_%d", index)
+	}
+	switch r.target {
+	case targetMethod, targetPath, targetQuery, targetHeaders, targetBody:
+	case targetHeader:
+		if r.header == "" {
+			return nil, fmt.Errorf("waf rule %s requires header", r.id)
+		}
+	default:
+		return nil, fmt.Errorf("waf rule %s has unknown target %q", r.id, r.target)
+	}
+	switch r.mode {
+	case "":
+		r.mode = mode
+	case modeBlock, modeLog:
+	default:
+		return nil, fmt.Errorf("waf rule %s has unknown mode %q", r.id, r.mode)
+	}
+	if cfg.Pattern != "" {
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("waf rule %s has invalid pattern: %v", r.id, err)
+		}
+		r.pattern = pattern
+	}
+	for _, ct := range cfg.ContentTypes {
+		r.contentTypes = append(r.contentTypes, strings.ToLower(ct))
+	}
+	if r.pattern == nil && r.maxSize <= 0 && len(r.contentTypes) == 0 {
+		return nil, fmt.Errorf("waf rule %s requires pattern, max_size or content_types", r.id)
+	}
+	m, _ := metrics.NewMetrics(wafType, map[string]string{"rule": r.id})
+	r.hits = m.Counter(statsHit)
+	return r, nil
+}
+
+// match returns true if any of the conditions of the rule is matched.
+// the body may be truncated, bodySize is the size of the whole body.
+func (r *rule) match(headers api.HeaderMap, body []byte, bodySize int) bool {
+	if len(r.contentTypes) > 0 && !r.contentTypeAllowed(headers) {
+		return true
+	}
+	if r.pattern == nil && r.maxSize <= 0 {
+		return false
+	}
+	switch r.target {
+	case targetBody:
+		if r.maxSize > 0 && bodySize > r.maxSize {
+			return true
+		}
+		return r.pattern != nil && r.pattern.Match(body)
+	case targetHeaders:
+		matched := false
+		headers.Range(func(key, value string) bool {
+			if strings.HasPrefix(strings.ToLower(key), internalHeaderPrefix) {
+				return true
+			}
+			matched = r.matchValue([]byte(value))
+			return !matched
+		})
+		return matched
+	default:
+		value, ok := r.targetValue(headers)
+		if !ok {
+			return false
+		}
+		return r.matchValue([]byte(value))
+	}
+}
+
+func (r *rule) matchValue(value []byte) bool {
+	if r.maxSize > 0 && len(value) > r.maxSize {
+		return true
+	}
+	return r.pattern != nil && r.pattern.Match(value)
+}
+
+func (r *rule) targetValue(headers api.HeaderMap) (string, bool) {
+	switch r.target {
+	case targetMethod:
+		return headers.Get(protocol.MosnHeaderMethod)
+	case targetPath:
+		path, ok := headers.Get(protocol.MosnHeaderPathKey)
+		return unescape(path, url.PathUnescape), ok
+	case targetQuery:
+		query, ok := headers.Get(protocol.MosnHeaderQueryStringKey)
+		return unescape(query, url.QueryUnescape), ok
+	default:
+		return headers.Get(r.header)
+	}
+}
+
+func (r *rule) contentTypeAllowed(headers api.HeaderMap) bool {
+	ct, ok := headers.Get(contentTypeHeader)
+	if !ok || ct == "" {
+		// the requests without body have no content type
+		return true
+	}
+	ct = strings.ToLower(ct)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.TrimSpace(ct)
+	for _, allowed := range r.contentTypes {
+		if ct == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// unescape decodes the encoded value, so the encoded injection patterns can be matched
+func unescape(value string, fn func(string) (string, error)) string {
+	if decoded, err := fn(value); err == nil {
+		return decoded
+	}
+	return value
+}
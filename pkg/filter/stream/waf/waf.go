@@ -0,0 +1,103 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+import (
+	"context"
+	"strconv"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+// wafFilter inspects the headers and the bounded body of the request and response
+type wafFilter struct {
+	ctx     context.Context
+	config  *wafConfig
+	path    string
+	handler api.StreamReceiverFilterHandler
+}
+
+func NewFilter(ctx context.Context, config *wafConfig) *wafFilter {
+	return &wafFilter{
+		ctx:    ctx,
+		config: config,
+	}
+}
+
+func (f *wafFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler
+}
+
+func (f *wafFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {}
+
+func (f *wafFilter) OnReceive(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	f.path, _ = headers.Get(protocol.MosnHeaderPathKey)
+	if r := f.inspect(ctx, f.config.requestRules, headers, buf); r != nil {
+		// the request headers are not echoed in the reply
+		f.handler.RequestInfo().SetResponseFlag(types.RequestRejected)
+		f.handler.SendHijackReply(f.config.status, nil)
+		return api.StreamFilterStop
+	}
+	return api.StreamFilterContinue
+}
+
+func (f *wafFilter) Append(ctx context.Context, headers api.HeaderMap, buf buffer.IoBuffer, trailers api.HeaderMap) api.StreamFilterStatus {
+	if r := f.inspect(ctx, f.config.responseRules, headers, buf); r != nil {
+		// the response is replaced by an empty response with the block status
+		headers.Set(types.HeaderStatus, strconv.Itoa(f.config.status))
+		headers.Del("content-length")
+		if buf != nil {
+			buf.Drain(buf.Len())
+		}
+	}
+	return api.StreamFilterContinue
+}
+
+// inspect returns the first matched rule in block mode, the matched rules in log mode are logged only
+func (f *wafFilter) inspect(ctx context.Context, rules []*rule, headers api.HeaderMap, buf buffer.IoBuffer) *rule {
+	var body []byte
+	bodySize := 0
+	if buf != nil {
+		body = buf.Bytes()
+		bodySize = len(body)
+		if len(body) > f.config.maxBodySize {
+			body = body[:f.config.maxBodySize]
+		}
+	}
+	for _, r := range rules {
+		if f.config.excluded(f.path, r) || !r.match(headers, body, bodySize) {
+			continue
+		}
+		r.hits.Inc(1)
+		if r.mode == modeLog {
+			f.config.logged.Inc(1)
+			log.Proxy.Warnf(ctx, "[stream filter] [waf] rule %s matched, path: %s", r.id, f.path)
+			continue
+		}
+		f.config.blocked.Inc(1)
+		log.Proxy.Warnf(ctx, "[stream filter] [waf] request blocked by rule %s, path: %s", r.id, f.path)
+		return r
+	}
+	return nil
+}
+
+func (f *wafFilter) OnDestroy() {}
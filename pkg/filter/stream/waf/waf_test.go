@@ -0,0 +1,173 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package waf
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type mockReceiverHandler struct {
+	api.StreamReceiverFilterHandler
+	code int
+	info api.RequestInfo
+}
+
+func (h *mockReceiverHandler) RequestInfo() api.RequestInfo {
+	if h.info == nil {
+		h.info = network.NewRequestInfo()
+	}
+	return h.info
+}
+
+func (h *mockReceiverHandler) SendHijackReply(code int, headers api.HeaderMap) {
+	h.code = code
+}
+
+func newTestFactory(t *testing.T, conf map[string]interface{}) *FilterConfigFactory {
+	factory, err := CreateWAFFilterFactory(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return factory.(*FilterConfigFactory)
+}
+
+func TestWAFFilterRequest(t *testing.T) {
+	ff := newTestFactory(t, map[string]interface{}{
+		"max_body_size": 16,
+		"rules": []interface{}{
+			map[string]interface{}{"id": "sqli", "target": "query", "pattern": `(?i)union\s+select`},
+			map[string]interface{}{"id": "traversal", "target": "path", "pattern": `\.\./`},
+			map[string]interface{}{"id": "ua", "target": "header", "header": "User-Agent", "pattern": `sqlmap`},
+			map[string]interface{}{"id": "xss", "target": "body", "pattern": `<script`},
+			map[string]interface{}{"id": "body_size", "target": "body", "max_size": 32},
+			map[string]interface{}{"id": "json_only", "target": "body", "content_types": []string{"application/json", "text/plain"}},
+			map[string]interface{}{"id": "method", "target": "method", "pattern": "^TRACE$", "mode": "log"},
+		},
+		"exclusions": []interface{}{
+			map[string]interface{}{"path_prefix": "/admin"},
+			map[string]interface{}{"path_prefix": "/upload", "rules": []string{"body_size"}},
+		},
+	})
+	for i, c := range []struct {
+		headers protocol.CommonHeader
+		body    string
+		code    int
+		rule    string
+	}{
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index"}, "", 0, ""},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", protocol.MosnHeaderQueryStringKey: "id=1%20UNION%20SELECT%20*"}, "", http.StatusForbidden, "sqli"},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/static/%2E%2E/etc/passwd"}, "", http.StatusForbidden, "traversal"},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", "user-agent": "sqlmap/1.0"}, "", http.StatusForbidden, "ua"},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", "content-type": "text/plain"}, "<script>alert(1)</script>", http.StatusForbidden, "xss"},
+		// the body exceeds the inspected size is not matched
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", "content-type": "text/plain"}, "0123456789abcdef<script>", 0, ""},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", "content-type": "text/plain"}, "0123456789abcdef0123456789abcdef0", http.StatusForbidden, "body_size"},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/upload", "content-type": "text/plain"}, "0123456789abcdef0123456789abcdef0", 0, ""},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", "content-type": "Application/JSON; charset=utf-8"}, "{}", 0, ""},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", "content-type": "application/xml"}, "<a/>", http.StatusForbidden, "json_only"},
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/admin", protocol.MosnHeaderQueryStringKey: "q=union select"}, "", 0, ""},
+		// log only
+		{protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index", protocol.MosnHeaderMethod: "TRACE"}, "", 0, "method"},
+	} {
+		handler := &mockReceiverHandler{}
+		filter := NewFilter(context.Background(), ff.Config)
+		filter.SetReceiveFilterHandler(handler)
+		var buf buffer.IoBuffer
+		if c.body != "" {
+			buf = buffer.NewIoBufferString(c.body)
+		}
+		var hits int64
+		if c.rule != "" {
+			hits = findRule(ff.Config, c.rule).hits.Count()
+		}
+		status := filter.OnReceive(context.Background(), c.headers, buf, nil)
+		if handler.code != c.code {
+			t.Fatalf("case %d expected code %d, but got %d", i, c.code, handler.code)
+		}
+		if (c.code == 0) != (status == api.StreamFilterContinue) {
+			t.Fatalf("case %d unexpected status %s", i, status)
+		}
+		if (c.code != 0) != handler.RequestInfo().GetResponseFlag(types.RequestRejected) {
+			t.Fatalf("case %d unexpected response flag", i)
+		}
+		if c.rule != "" && findRule(ff.Config, c.rule).hits.Count() != hits+1 {
+			t.Fatalf("case %d expected rule %s hit", i, c.rule)
+		}
+	}
+}
+
+func TestWAFFilterResponse(t *testing.T) {
+	ff := newTestFactory(t, map[string]interface{}{
+		"status": 502,
+		"rules": []interface{}{
+			map[string]interface{}{"id": "leak", "direction": "response", "target": "body", "pattern": `\b\d{4}-\d{4}-\d{4}-\d{4}\b`},
+		},
+	})
+	filter := NewFilter(context.Background(), ff.Config)
+	headers := protocol.CommonHeader{types.HeaderStatus: "200", "content-length": "24"}
+	buf := buffer.NewIoBufferString("card: 1234-5678-9012-3456")
+	// the request is not matched by the response rules
+	if status := filter.OnReceive(context.Background(), protocol.CommonHeader{}, buf, nil); status != api.StreamFilterContinue {
+		t.Fatal("request should not be blocked")
+	}
+	filter.Append(context.Background(), headers, buf, nil)
+	if v, _ := headers.Get(types.HeaderStatus); v != "502" {
+		t.Fatalf("expected response status replaced, but got %s", v)
+	}
+	if _, ok := headers.Get("content-length"); ok || buf.Len() != 0 {
+		t.Fatal("expected response body removed")
+	}
+}
+
+func TestWAFFilterConfigError(t *testing.T) {
+	for i, conf := range []map[string]interface{}{
+		{"mode": "unknown"},
+		{"rules": []interface{}{map[string]interface{}{"target": "unknown", "pattern": "a"}}},
+		{"rules": []interface{}{map[string]interface{}{"target": "header", "pattern": "a"}}},
+		{"rules": []interface{}{map[string]interface{}{"target": "body", "pattern": "("}}},
+		{"rules": []interface{}{map[string]interface{}{"target": "body"}}},
+		{"rules": []interface{}{map[string]interface{}{"target": "body", "pattern": "a", "direction": "unknown"}}},
+		{"rules": []interface{}{
+			map[string]interface{}{"id": "a", "target": "body", "pattern": "a"},
+			map[string]interface{}{"id": "a", "target": "path", "pattern": "a"},
+		}},
+	} {
+		if _, err := CreateWAFFilterFactory(conf); err == nil {
+			t.Fatalf("case %d expected error", i)
+		}
+	}
+}
+
+func findRule(c *wafConfig, id string) *rule {
+	for _, rules := range [][]*rule{c.requestRules, c.responseRules} {
+		for _, r := range rules {
+			if r.id == id {
+				return r
+			}
+		}
+	}
+	return nil
+}
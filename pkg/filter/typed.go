@@ -0,0 +1,45 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"mosn.io/api"
+)
+
+// typedStreamFilterChainFactory is a stream filter factory that knows its filter type,
+// so the filters it creates can be overridden by the filter type
+type typedStreamFilterChainFactory struct {
+	api.StreamFilterChainFactory
+	filterType string
+}
+
+// WithStreamFilterType records the filter type of the factory
+func WithStreamFilterType(filterType string, factory api.StreamFilterChainFactory) api.StreamFilterChainFactory {
+	return &typedStreamFilterChainFactory{
+		StreamFilterChainFactory: factory,
+		filterType:               filterType,
+	}
+}
+
+// StreamFilterType returns the filter type of the factory, it is empty if the type is not recorded
+func StreamFilterType(factory api.StreamFilterChainFactory) string {
+	if f, ok := factory.(*typedStreamFilterChainFactory); ok {
+		return f.filterType
+	}
+	return ""
+}
@@ -87,8 +87,8 @@ func (c *mixerClient) tryConnect(retry bool) error {
 	if mngAdaper == nil {
 		return fmt.Errorf("mng adapter nil")
 	}
-	snapshot := mngAdaper.GetClusterSnapshot(context.Background(), c.reportCluster)
-	if snapshot == nil {
+	snapshot, ok := mngAdaper.GetClusterSnapshot(context.Background(), c.reportCluster)
+	if !ok {
 		err := fmt.Errorf("get mixer server cluster config error, report cluster: %s", c.reportCluster)
 		log.DefaultLogger.Errorf("%s", err.Error())
 		return err
@@ -20,8 +20,12 @@ package log
 import (
 	"context"
 	"errors"
+	"sort"
+	"unicode/utf8"
 
 	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/datamask"
 	"mosn.io/mosn/pkg/variable"
 	"mosn.io/pkg/buffer"
 	"mosn.io/pkg/log"
@@ -35,6 +39,14 @@ var (
 	ErrLogFormatUndefined = errors.New("access log format undefined")
 	ErrEmptyVarDef        = errors.New("access log format error: empty variable definition")
 	ErrUnclosedVarDef     = errors.New("access log format error: unclosed variable definition")
+	ErrUnknownFormatType  = errors.New("access log format type unknown")
+	ErrNoVariableDef      = errors.New("access log format error: no variable definition")
+)
+
+// access log format types
+const (
+	AccessLogFormatText = "text"
+	AccessLogFormatJSON = "json"
 )
 
 const AccessLogLen = 1 << 8
@@ -50,47 +62,169 @@ func DisableAllAccessLog() {
 	}
 }
 
+// accessLogPrinter prints the access logs, it is implemented by the log.Logger, the failsafe logger and the syslog logger
+type accessLogPrinter interface {
+	Print(buf buffer.IoBuffer, discard bool) error
+	Toggle(disable bool)
+	Disable() bool
+}
+
 // types.AccessLog
 type accesslog struct {
 	output  string
 	entries []*logEntry
-	logger  *log.Logger
+	// fields is not nil if the log is written in the json format
+	fields []*jsonField
+	logger accessLogPrinter
+	// syslog is not nil if the variables are set in the syslog structured data
+	syslog *syslogAccessLogger
 }
 
 type logEntry struct {
-	text     string
+	text string
+	// name is the variable name in the format, it is different from the name of
+	// the variable if the variable is a prefix variable, such as request_header_xxx
+	name     string
 	variable variable.Variable
 }
 
-func (le *logEntry) log(ctx context.Context, buf buffer.IoBuffer) {
+func (le *logEntry) log(ctx context.Context, buf buffer.IoBuffer, masker *datamask.Masker) {
 	if le.text != "" {
 		buf.WriteString(le.text)
 	} else {
-		value, err := variable.GetVariableValue(ctx, le.variable.Name())
-		if err != nil {
-			buf.WriteString(variable.ValueNotFound)
-		} else {
-			buf.WriteString(value)
-		}
+		buf.WriteString(le.value(ctx, masker))
 	}
 }
 
-// NewAccessLog
+func (le *logEntry) value(ctx context.Context, masker *datamask.Masker) string {
+	value, err := variable.GetVariableValue(ctx, le.name)
+	if err != nil {
+		return variable.ValueNotFound
+	}
+	if masker != nil {
+		value = masker.MaskVariable(le.name, value)
+	}
+	return value
+}
+
+// jsonField is a field of the json format access log, the value is made up by the entries
+type jsonField struct {
+	key     string
+	entries []*logEntry
+}
+
+// NewAccessLog creates an access log in the text format
 func NewAccessLog(output string, format string) (api.AccessLog, error) {
-	lg, err := log.GetOrCreateLogger(output, nil)
+	entries, err := parseFormat(format)
 	if err != nil {
 		return nil, err
 	}
+	return newAccessLog(output, "", entries, nil)
+}
 
+// NewAccessLogWithConfig creates an access log in the format type of the config.
+// The json format fields are generated by the text format if they are not configured,
+// so an access log can be written in both formats to different outputs during the migration.
+func NewAccessLogWithConfig(config v2.AccessLog) (api.AccessLog, error) {
+	switch config.Type {
+	case "", AccessLogFormatText:
+		entries, err := parseFormat(config.Format)
+		if err != nil {
+			return nil, err
+		}
+		return newAccessLog(config.Path, config.Compression, entries, nil)
+	case AccessLogFormatJSON:
+		jsonFormat := config.JSONFormat
+		if len(jsonFormat) == 0 {
+			var err error
+			if jsonFormat, err = ConvertFormatToJSON(config.Format); err != nil {
+				return nil, err
+			}
+		}
+		fields, err := parseJSONFormat(jsonFormat)
+		if err != nil {
+			return nil, err
+		}
+		return newAccessLog(config.Path, config.Compression, nil, fields)
+	default:
+		return nil, ErrUnknownFormatType
+	}
+}
+
+// ConvertFormatToJSON converts a text format to the json format,
+// each variable in the text format is a field named by the variable name.
+func ConvertFormatToJSON(format string) (map[string]string, error) {
 	entries, err := parseFormat(format)
 	if err != nil {
 		return nil, err
 	}
+	jsonFormat := make(map[string]string, len(entries))
+	for _, le := range entries {
+		if le.text == "" {
+			jsonFormat[le.name] = "%" + le.name + "%"
+		}
+	}
+	if len(jsonFormat) == 0 {
+		return nil, ErrNoVariableDef
+	}
+	return jsonFormat, nil
+}
+
+// parseJSONFormat parses the json format fields, the fields are sorted by the key
+func parseJSONFormat(jsonFormat map[string]string) ([]*jsonField, error) {
+	if len(jsonFormat) == 0 {
+		return nil, ErrLogFormatUndefined
+	}
+	fields := make([]*jsonField, 0, len(jsonFormat))
+	for key, format := range jsonFormat {
+		entries, err := parseFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &jsonField{key: key, entries: entries})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].key < fields[j].key
+	})
+	return fields, nil
+}
+
+func newAccessLog(output string, compression string, entries []*logEntry, fields []*jsonField) (api.AccessLog, error) {
+	var lg accessLogPrinter
+	var syslog *syslogAccessLogger
+	if compression != "" && isFileOutput(output) {
+		w, err := getOrCreateCompressedWriter(output, compression)
+		if err != nil {
+			return nil, err
+		}
+		lg = w
+	} else if IsSyslogOutput(output) {
+		w, err := getOrCreateSyslogWriter(output)
+		if err != nil {
+			return nil, err
+		}
+		lg = &syslogAccessLogger{w}
+		// the json format log is not split into the structured data
+		if w.config.structured && fields == nil {
+			syslog = &syslogAccessLogger{w}
+		}
+	} else {
+		l, err := log.GetOrCreateLogger(output, nil)
+		if err != nil {
+			return nil, err
+		}
+		lg = l
+		if fl := newFailsafeLogger(output, l); fl != nil {
+			lg = fl
+		}
+	}
 
 	l := &accesslog{
 		output:  output,
 		entries: entries,
+		fields:  fields,
 		logger:  lg,
+		syslog:  syslog,
 	}
 
 	if DefaultDisableAccessLog {
@@ -109,13 +243,103 @@ func (l *accesslog) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeade
 	}
 
 	buf := buffer.GetIoBuffer(AccessLogLen)
-	for idx := range l.entries {
-		l.entries[idx].log(ctx, buf)
+	// the sensitive data is masked if the data mask filter is configured
+	masker := datamask.MaskerFromContext(ctx)
+	if l.syslog != nil {
+		l.logStructured(ctx, buf, masker)
+		return
+	}
+	if l.fields != nil {
+		l.logJSON(ctx, buf, masker)
+	} else {
+		for idx := range l.entries {
+			l.entries[idx].log(ctx, buf, masker)
+		}
 	}
 	buf.WriteString("\n")
 	l.logger.Print(buf, true)
 }
 
+// logJSON writes the fields as a json object, all the values are json strings
+func (l *accesslog) logJSON(ctx context.Context, buf buffer.IoBuffer, masker *datamask.Masker) {
+	value := buffer.GetIoBuffer(AccessLogLen)
+	buf.WriteString("{")
+	for idx, f := range l.fields {
+		if idx > 0 {
+			buf.WriteString(",")
+		}
+		writeJSONString(buf, f.key)
+		buf.WriteString(":")
+		value.Reset()
+		for _, le := range f.entries {
+			le.log(ctx, value, masker)
+		}
+		writeJSONString(buf, value.String())
+	}
+	buf.WriteString("}")
+	buffer.PutIoBuffer(value)
+}
+
+const hex = "0123456789abcdef"
+
+// writeJSONString writes s as a quoted json string
+func writeJSONString(buf buffer.IoBuffer, s string) {
+	buf.WriteString(`"`)
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(s[start:i])
+				buf.WriteString(`\ufffd`)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if c >= 0x20 && c != '"' && c != '\\' {
+			i++
+			continue
+		}
+		buf.WriteString(s[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteString(`\`)
+			buf.Write([]byte{c})
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.Write([]byte{'\\', 'u', '0', '0', hex[c>>4], hex[c&0xf]})
+		}
+		i++
+		start = i
+	}
+	buf.WriteString(s[start:])
+	buf.WriteString(`"`)
+}
+
+// logStructured sets the variables in the syslog structured data besides the message
+func (l *accesslog) logStructured(ctx context.Context, buf buffer.IoBuffer, masker *datamask.Masker) {
+	params := make([]sdParam, 0, len(l.entries))
+	for _, le := range l.entries {
+		if le.text != "" {
+			buf.WriteString(le.text)
+			continue
+		}
+		value := le.value(ctx, masker)
+		buf.WriteString(value)
+		params = append(params, sdParam{name: le.name, value: value})
+	}
+	l.syslog.printWithParams(buf, params)
+}
+
 func parseFormat(format string) ([]*logEntry, error) {
 	if format == "" {
 		return nil, ErrLogFormatUndefined
@@ -143,11 +367,12 @@ func parseFormat(format string) ([]*logEntry, error) {
 					}
 
 					// var def ends, add variable
-					varEntry, err := variable.AddVariable(format[lastMark+1 : pos])
+					name := format[lastMark+1 : pos]
+					varEntry, err := variable.AddVariable(name)
 					if err != nil {
 						return nil, err
 					}
-					entries = append(entries, &logEntry{variable: varEntry})
+					entries = append(entries, &logEntry{name: name, variable: varEntry})
 				} else {
 					// ignore empty text
 					if pos > lastMark+1 {
@@ -18,8 +18,11 @@
 package log
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
@@ -31,8 +34,13 @@ import (
 	"time"
 
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/compress"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/datamask"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/variable"
+	"mosn.io/pkg/buffer"
 	"mosn.io/pkg/log"
 )
 
@@ -137,6 +145,33 @@ func TestAccessLogWithCustomText(t *testing.T) {
 	}
 }
 
+func TestAccessLogMaskHeader(t *testing.T) {
+	registerTestVarDefs()
+
+	entries, err := parseFormat("%request_header_service% %request_header_authorization%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), requestHeaderMapKey, protocol.CommonHeader{
+		"service":       "test",
+		"authorization": "Bearer token",
+	})
+	format := func(ctx context.Context) string {
+		buf := buffer.GetIoBuffer(AccessLogLen)
+		for _, entry := range entries {
+			entry.log(ctx, buf, datamask.MaskerFromContext(ctx))
+		}
+		return buf.String()
+	}
+	if s := format(ctx); s != "test Bearer token" {
+		t.Fatalf("unexpected access log: %s", s)
+	}
+	ctx = datamask.WithMasker(ctx, datamask.NewMasker(&v2.StreamDataMask{}))
+	if s := format(ctx); s != "test ************" {
+		t.Fatalf("unexpected masked access log: %s", s)
+	}
+}
+
 func TestAccessLogWithEmptyVar(t *testing.T) {
 	registerTestVarDefs()
 
@@ -654,3 +689,131 @@ func responseHeaderMapGetter(ctx context.Context, value *variable.IndexedValue,
 
 	return string(headerValue), nil
 }
+
+func TestAccessLogDualWriteJSON(t *testing.T) {
+	registerTestVarDefs()
+	disabled := DefaultDisableAccessLog
+	DefaultDisableAccessLog = false
+	defer func() {
+		DefaultDisableAccessLog = disabled
+	}()
+
+	format := "send request to %upstream_local_address% from %downstream_remote_address%"
+	textLog := "/tmp/mosn_accesslog/dual_text_access.log"
+	jsonLog := "/tmp/mosn_accesslog/dual_json_access.log"
+	customLog := "/tmp/mosn_accesslog/custom_json_access.log"
+	os.Remove(textLog)
+	os.Remove(jsonLog)
+	os.Remove(customLog)
+	configs := []v2.AccessLog{
+		{Path: textLog, Format: format},
+		{Path: jsonLog, Format: format, Type: AccessLogFormatJSON},
+		{Path: customLog, Type: AccessLogFormatJSON, JSONFormat: map[string]string{
+			"upstream": "local \"%upstream_local_address%\"",
+		}},
+	}
+	ctx := prepareLocalIpv6Ctx()
+	for _, cfg := range configs {
+		al, err := NewAccessLogWithConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		al.Log(ctx, nil, nil, nil)
+	}
+	time.Sleep(time.Second)
+	if b, err := ioutil.ReadFile(textLog); err != nil || string(b) != "send request to 127.0.0.1:23456 from 127.0.0.1:53242\n" {
+		t.Fatalf("unexpected text log: %s, error: %v", string(b), err)
+	}
+	b, err := ioutil.ReadFile(jsonLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"downstream_remote_address":"127.0.0.1:53242","upstream_local_address":"127.0.0.1:23456"}`+"\n" {
+		t.Fatalf("unexpected json log: %s", string(b))
+	}
+	b, err = ioutil.ReadFile(customLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]string{}
+	if err := json.Unmarshal(b, &fields); err != nil || fields["upstream"] != "local \"127.0.0.1:23456\"" {
+		t.Fatalf("unexpected json log: %s, error: %v", string(b), err)
+	}
+
+	if _, err := NewAccessLogWithConfig(v2.AccessLog{Path: jsonLog, Format: format, Type: "xml"}); err != ErrUnknownFormatType {
+		t.Fatalf("expected unknown format type error, but got: %v", err)
+	}
+	if _, err := NewAccessLogWithConfig(v2.AccessLog{Path: jsonLog, Format: "no variables", Type: AccessLogFormatJSON}); err != ErrNoVariableDef {
+		t.Fatalf("expected no variable error, but got: %v", err)
+	}
+}
+
+func TestWriteJSONString(t *testing.T) {
+	for s, expected := range map[string]string{
+		"":                      "",
+		"plain":                 "plain",
+		"quote \" and \\ slash": "quote \" and \\ slash",
+		"ctrl \n\r\t\x01":       "ctrl \n\r\t\x01",
+		"中文":                    "中文",
+		"invalid \xff utf8":     "invalid \ufffd utf8",
+	} {
+		buf := buffer.NewIoBuffer(16)
+		writeJSONString(buf, s)
+		var v string
+		if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+			t.Fatalf("write %q failed: %s, error: %v", s, buf.String(), err)
+		}
+		if v != expected {
+			t.Errorf("write %q got %q", s, v)
+		}
+	}
+}
+
+func TestAccessLogCompressed(t *testing.T) {
+	registerTestVarDefs()
+	disabled := DefaultDisableAccessLog
+	DefaultDisableAccessLog = false
+	defer func() {
+		DefaultDisableAccessLog = disabled
+	}()
+
+	format := "send request to %upstream_local_address%"
+	ctx := prepareLocalIpv6Ctx()
+	for _, algorithm := range []string{compress.Gzip, compress.Zstd} {
+		output := "/tmp/mosn_accesslog/compressed_access.log." + algorithm
+		os.Remove(output)
+		for _, cfg := range []v2.AccessLog{
+			{Path: output, Format: format, Compression: algorithm},
+			{Path: output, Format: format, Type: AccessLogFormatJSON, Compression: algorithm},
+		} {
+			al, err := NewAccessLogWithConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			al.Log(ctx, nil, nil, nil)
+		}
+		// the compressed data is flushed every second
+		time.Sleep(1500 * time.Millisecond)
+		b, err := ioutil.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		codec, _ := compress.GetCodec(algorithm)
+		r, err := codec.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "send request to 127.0.0.1:23456\n" + `{"upstream_local_address":"127.0.0.1:23456"}` + "\n"
+		data := make([]byte, len(expected))
+		if _, err := io.ReadFull(r, data); err != nil || string(data) != expected {
+			t.Fatalf("unexpected %s log: %q, error: %v", algorithm, string(data), err)
+		}
+		r.Close()
+	}
+	if _, err := NewAccessLogWithConfig(v2.AccessLog{Path: "/tmp/mosn_accesslog/compressed_access.log.gzip", Format: format, Compression: compress.Zstd}); err == nil {
+		t.Fatal("expected an error when the log is compressed by another algorithm")
+	}
+	if _, err := NewAccessLogWithConfig(v2.AccessLog{Path: "/tmp/mosn_accesslog/compressed_access.log.br", Format: format, Compression: "br"}); err != compress.ErrUnknownAlgorithm {
+		t.Fatalf("expected unknown algorithm error, but got: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/compress"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/log"
+)
+
+const (
+	compressedBufferSize    = 1000
+	compressedFlushInterval = time.Second
+)
+
+var (
+	// compressedWriters keeps the writers, the same output references the same writer
+	compressedWriters   = map[string]*compressedWriter{}
+	compressedWritersMu sync.Mutex
+)
+
+// compressedWriter compresses the logs asynchronously and appends them to the file,
+// the pending data is flushed every second so the file can be read while it is written.
+// A new gzip member or zstd frame is appended each time the file is opened, the
+// concatenated members or frames are decompressed as a whole by gzip and zstd.
+// The file is not rotated by the log roller.
+type compressedWriter struct {
+	output      string
+	compression string
+
+	mutex   sync.Mutex
+	disable bool
+	file    *os.File
+	writer  compress.Writer
+	// dirty is true if some data is written after the last flush
+	dirty bool
+
+	buffers chan buffer.IoBuffer
+}
+
+func getOrCreateCompressedWriter(output string, compression string) (*compressedWriter, error) {
+	compressedWritersMu.Lock()
+	defer compressedWritersMu.Unlock()
+	if w, ok := compressedWriters[output]; ok {
+		if w.compression != compression {
+			return nil, fmt.Errorf("log %s is compressed by %s already", output, w.compression)
+		}
+		return w, nil
+	}
+	codec, err := compress.GetCodec(compression)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := codec.NewWriter(file, 0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := &compressedWriter{
+		output:      output,
+		compression: compression,
+		file:        file,
+		writer:      writer,
+		buffers:     make(chan buffer.IoBuffer, compressedBufferSize),
+	}
+	go w.run()
+	compressedWriters[output] = w
+	return w, nil
+}
+
+// Print writes the buffer asynchronously, if discard is true and the buffer chan is full, the buffer is dropped
+func (w *compressedWriter) Print(buf buffer.IoBuffer, discard bool) error {
+	if w.Disable() {
+		buffer.PutIoBuffer(buf)
+		return nil
+	}
+	select {
+	case w.buffers <- buf:
+	default:
+		if discard {
+			buffer.PutIoBuffer(buf)
+			return log.ErrChanFull
+		}
+		w.buffers <- buf
+	}
+	return nil
+}
+
+func (w *compressedWriter) Toggle(disable bool) {
+	w.mutex.Lock()
+	w.disable = disable
+	w.mutex.Unlock()
+}
+
+func (w *compressedWriter) Disable() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.disable
+}
+
+func (w *compressedWriter) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			go w.run()
+		}
+	}()
+	ticker := time.NewTicker(compressedFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case buf := <-w.buffers:
+			w.write(buf)
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+func (w *compressedWriter) write(buf buffer.IoBuffer) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, err := w.writer.Write(buf.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "write compressed log %s failed: %v\n", w.output, err)
+	}
+	w.dirty = true
+	buffer.PutIoBuffer(buf)
+}
+
+// flush writes the compressed data of the written logs to the file
+func (w *compressedWriter) flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if !w.dirty {
+		return
+	}
+	if err := w.writer.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "flush compressed log %s failed: %v\n", w.output, err)
+	}
+	w.dirty = false
+}
@@ -25,19 +25,36 @@ import (
 // we use ErrorLogger to write common log message.
 type errorLogger struct {
 	*log.SimpleErrorLog
+	// printer prints the formatted log message, it is the Logger itself
+	// or a failsafe logger wraps the Logger if the log fallback is enabled
+	printer logPrinter
+}
+
+// logPrinter prints the formatted log message
+type logPrinter interface {
+	Printf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
 }
 
 func CreateDefaultErrorLogger(output string, level log.Level) (log.ErrorLogger, error) {
+	if IsSyslogOutput(output) {
+		return newSyslogErrorLogger(output, level)
+	}
 	lg, err := log.GetOrCreateLogger(output, nil)
 	if err != nil {
 		return nil, err
 	}
+	var printer logPrinter = lg
+	if fl := newFailsafeLogger(output, lg); fl != nil {
+		printer = fl
+	}
 	return &errorLogger{
 		SimpleErrorLog: &log.SimpleErrorLog{
 			Logger:    lg,
 			Formatter: log.DefaultFormatter,
 			Level:     level,
 		},
+		printer: printer,
 	}, nil
 }
 
@@ -52,6 +69,59 @@ func (l *errorLogger) Errorf(format string, args ...interface{}) {
 	}
 	if l.Level >= log.ERROR {
 		s := l.SimpleErrorLog.Formatter(log.ErrorPre, defaultErrorCode, format)
-		l.Logger.Printf(s, args...)
+		l.printer.Printf(s, args...)
+	}
+}
+
+func (l *errorLogger) Alertf(alert string, format string, args ...interface{}) {
+	if l.Disable() {
+		return
+	}
+	if l.Level >= log.ERROR {
+		s := l.SimpleErrorLog.Formatter(log.ErrorPre, alert, format)
+		l.printer.Printf(s, args...)
+	}
+}
+
+func (l *errorLogger) Infof(format string, args ...interface{}) {
+	if l.Level >= log.INFO {
+		l.levelf(log.InfoPre, format, args...)
+	}
+}
+
+func (l *errorLogger) Debugf(format string, args ...interface{}) {
+	if l.Level >= log.DEBUG {
+		l.levelf(log.DebugPre, format, args...)
+	}
+}
+
+func (l *errorLogger) Warnf(format string, args ...interface{}) {
+	if l.Level >= log.WARN {
+		l.levelf(log.WarnPre, format, args...)
+	}
+}
+
+func (l *errorLogger) Tracef(format string, args ...interface{}) {
+	if l.Level >= log.TRACE {
+		l.levelf(log.TracePre, format, args...)
+	}
+}
+
+func (l *errorLogger) levelf(lv string, format string, args ...interface{}) {
+	if l.Disable() {
+		return
 	}
+	s := l.SimpleErrorLog.Formatter(lv, "", format)
+	l.printer.Printf(s, args...)
+}
+
+// Fatalf cannot be disabled
+func (l *errorLogger) Fatalf(format string, args ...interface{}) {
+	s := l.SimpleErrorLog.Formatter(log.FatalPre, "", format)
+	l.printer.Fatalf(s, args...)
+}
+
+// Printf prints the formatted message without level
+func (l *errorLogger) Printf(format string, args ...interface{}) {
+	l.printer.Printf(format, args...)
 }
@@ -0,0 +1,413 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/log"
+)
+
+// log fallback targets
+const (
+	FallbackStderr = "stderr"
+	FallbackMemory = "memory"
+)
+
+const (
+	defaultFallbackRingSize      = 1000
+	defaultFallbackCheckInterval = 5 * time.Second
+	defaultFallbackMinFreeBytes  = 1 << 20
+)
+
+var ErrDiskFull = errors.New("no enough free space on disk")
+
+// the log fallback is disabled by default
+var fallback = &fallbackManager{
+	loggers: make(map[string]*failsafeLogger),
+}
+
+// fallbackManager checks the log outputs periodically,
+// and marks the failsafe loggers unhealthy if the output can not be written.
+type fallbackManager struct {
+	mutex   sync.RWMutex
+	config  *v2.LogFallbackConfig
+	loggers map[string]*failsafeLogger
+	ring    *fallbackRing
+	stop    chan struct{}
+	// hook is called when a log entry is not written to its output
+	hook func(output string)
+}
+
+// SetLogFallback enables the log fallback, a nil config disables it.
+// It should be called before the loggers are created, the loggers created before
+// are not affected.
+func SetLogFallback(config *v2.LogFallbackConfig) {
+	fallback.mutex.Lock()
+	defer fallback.mutex.Unlock()
+	if fallback.stop != nil {
+		close(fallback.stop)
+		fallback.stop = nil
+	}
+	if config == nil {
+		fallback.config = nil
+		return
+	}
+	cfg := *config
+	if cfg.Target == "" {
+		cfg.Target = FallbackStderr
+	}
+	if cfg.RingSize <= 0 {
+		cfg.RingSize = defaultFallbackRingSize
+	}
+	if cfg.CheckInterval.Duration <= 0 {
+		cfg.CheckInterval.Duration = defaultFallbackCheckInterval
+	}
+	if cfg.MinFreeBytes == 0 {
+		cfg.MinFreeBytes = defaultFallbackMinFreeBytes
+	}
+	fallback.config = &cfg
+	if fallback.ring == nil || fallback.ring.size != cfg.RingSize {
+		fallback.ring = newFallbackRing(cfg.RingSize)
+	}
+	fallback.stop = make(chan struct{})
+	go fallback.run(cfg.CheckInterval.Duration, fallback.stop)
+}
+
+// SetWriteFailureHook sets a hook that is called for each log entry that is not written to its output.
+func SetWriteFailureHook(hook func(output string)) {
+	fallback.mutex.Lock()
+	defer fallback.mutex.Unlock()
+	fallback.hook = hook
+}
+
+// LogWriteStatus describes the write state of a log output
+type LogWriteStatus struct {
+	Output      string `json:"output"`
+	Healthy     bool   `json:"healthy"`
+	Failures    int64  `json:"failures"`
+	LastError   string `json:"last_error,omitempty"`
+	LastFailure string `json:"last_failure,omitempty"`
+}
+
+// FallbackEntry is a log entry kept in the memory ring buffer
+type FallbackEntry struct {
+	Output string `json:"output"`
+	Log    string `json:"log"`
+}
+
+// LogWriteStatuses returns the write states of the outputs that enable the log fallback
+func LogWriteStatuses() []LogWriteStatus {
+	fallback.mutex.RLock()
+	defer fallback.mutex.RUnlock()
+	statuses := make([]LogWriteStatus, 0, len(fallback.loggers))
+	for _, l := range fallback.loggers {
+		statuses = append(statuses, l.status())
+	}
+	return statuses
+}
+
+// RecentFallbackLogs returns the log entries kept in the memory ring buffer, the oldest first
+func RecentFallbackLogs() []FallbackEntry {
+	fallback.mutex.RLock()
+	ring := fallback.ring
+	fallback.mutex.RUnlock()
+	if ring == nil {
+		return nil
+	}
+	return ring.entries()
+}
+
+func (m *fallbackManager) run(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+func (m *fallbackManager) check() {
+	m.mutex.RLock()
+	cfg := m.config
+	loggers := make([]*failsafeLogger, 0, len(m.loggers))
+	for _, l := range m.loggers {
+		loggers = append(loggers, l)
+	}
+	m.mutex.RUnlock()
+	if cfg == nil {
+		return
+	}
+	for _, l := range loggers {
+		l.setHealth(checkOutput(l.output, cfg.MinFreeBytes))
+	}
+}
+
+func (m *fallbackManager) onFailure(output string, entry string) {
+	m.mutex.RLock()
+	cfg, ring, hook := m.config, m.ring, m.hook
+	m.mutex.RUnlock()
+	if hook != nil {
+		hook(output)
+	}
+	if cfg != nil && cfg.Target == FallbackMemory && ring != nil {
+		ring.add(FallbackEntry{Output: output, Log: entry})
+		return
+	}
+	os.Stderr.WriteString(entry)
+}
+
+// checkOutput returns an error if the log file can not be written
+func checkOutput(output string, minFree uint64) error {
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	if free, err := diskFree(filepath.Dir(output)); err == nil && free < minFree {
+		return ErrDiskFull
+	}
+	return nil
+}
+
+// diskFree returns the free space in bytes of the disk that contains the path
+func diskFree(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// isFileOutput returns true if the output is a file path
+func isFileOutput(output string) bool {
+	switch output {
+	case "", "stderr", "/dev/stderr", "stdout", "/dev/stdout", "syslog":
+		return false
+	}
+	for _, prefix := range []string{"syslog://", "syslog+tcp://", "syslog+udp://"} {
+		if strings.HasPrefix(output, prefix) {
+			return false
+		}
+	}
+	return !IsSyslogOutput(output)
+}
+
+// failsafeLogger wraps a file Logger, if the file can not be written,
+// the log entries are written to the fallback target instead of being dropped.
+type failsafeLogger struct {
+	*log.Logger
+	output string
+	// unhealthy is 1 if the output can not be written
+	unhealthy   int32
+	failures    int64
+	mutex       sync.Mutex
+	lastError   error
+	lastFailure time.Time
+}
+
+// newFailsafeLogger returns nil if the log fallback is disabled or the output is not a file
+func newFailsafeLogger(output string, lg *log.Logger) *failsafeLogger {
+	if !isFileOutput(output) {
+		return nil
+	}
+	fallback.mutex.Lock()
+	defer fallback.mutex.Unlock()
+	if fallback.config == nil {
+		return nil
+	}
+	if l, ok := fallback.loggers[output]; ok && l.Logger == lg {
+		return l
+	}
+	l := &failsafeLogger{
+		Logger: lg,
+		output: output,
+	}
+	fallback.loggers[output] = l
+	return l
+}
+
+func (l *failsafeLogger) healthy() bool {
+	return atomic.LoadInt32(&l.unhealthy) == 0
+}
+
+func (l *failsafeLogger) setHealth(err error) {
+	if err == nil {
+		if atomic.CompareAndSwapInt32(&l.unhealthy, 1, 0) {
+			// the file may be recreated, reopen it
+			l.Logger.Reopen()
+			StartLogger.Infof("[log] [fallback] output %s recovered", l.output)
+		}
+		return
+	}
+	l.recordError(err)
+	if atomic.CompareAndSwapInt32(&l.unhealthy, 0, 1) {
+		StartLogger.Errorf("[log] [fallback] output %s is unwritable, write to fallback: %v", l.output, err)
+	}
+}
+
+func (l *failsafeLogger) recordError(err error) {
+	l.mutex.Lock()
+	l.lastError = err
+	l.lastFailure = time.Now()
+	l.mutex.Unlock()
+}
+
+func (l *failsafeLogger) status() LogWriteStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	s := LogWriteStatus{
+		Output:   l.output,
+		Healthy:  l.healthy(),
+		Failures: atomic.LoadInt64(&l.failures),
+	}
+	if l.lastError != nil {
+		s.LastError = l.lastError.Error()
+		s.LastFailure = l.lastFailure.Format(time.RFC3339)
+	}
+	return s
+}
+
+func (l *failsafeLogger) fail(buf buffer.IoBuffer) {
+	atomic.AddInt64(&l.failures, 1)
+	fallback.onFailure(l.output, buf.String())
+	buffer.PutIoBuffer(buf)
+}
+
+// Print writes the buffer to the Logger, or the fallback target if the output is unhealthy
+// or the Logger's buffer chan is full
+func (l *failsafeLogger) Print(buf buffer.IoBuffer, discard bool) error {
+	if l.Disable() {
+		buffer.PutIoBuffer(buf)
+		return nil
+	}
+	if !l.healthy() {
+		l.fail(buf)
+		return nil
+	}
+	if err := l.Logger.Print(buf, discard); err != nil {
+		if err == log.ErrChanFull {
+			l.recordError(err)
+		}
+		l.fail(buf)
+	}
+	return nil
+}
+
+func (l *failsafeLogger) Printf(format string, args ...interface{}) {
+	if l.Disable() {
+		return
+	}
+	s := fmt.Sprintf(format, args...)
+	buf := buffer.GetIoBuffer(len(s) + 1)
+	buf.WriteString(s)
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+	l.Print(buf, true)
+}
+
+// Fatalf writes the log entries kept in memory to stderr before the process exits,
+// and writes the fatal message to stderr if the output is unhealthy
+func (l *failsafeLogger) Fatalf(format string, args ...interface{}) {
+	FlushFallbackLogs()
+	if l.healthy() {
+		l.Logger.Fatalf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+// FlushFallbackLogs writes the log entries kept in the memory ring buffer to stderr,
+// and clears the ring buffer.
+func FlushFallbackLogs() {
+	fallback.mutex.RLock()
+	ring := fallback.ring
+	fallback.mutex.RUnlock()
+	if ring == nil {
+		return
+	}
+	for _, e := range ring.reset() {
+		os.Stderr.WriteString(e.Log)
+	}
+}
+
+// fallbackRing keeps the latest log entries in memory
+type fallbackRing struct {
+	mutex sync.Mutex
+	size  int
+	buf   []FallbackEntry
+	next  int
+	full  bool
+}
+
+func newFallbackRing(size int) *fallbackRing {
+	return &fallbackRing{
+		size: size,
+		buf:  make([]FallbackEntry, size),
+	}
+}
+
+func (r *fallbackRing) add(e FallbackEntry) {
+	r.mutex.Lock()
+	r.buf[r.next] = e
+	r.next = (r.next + 1) % r.size
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mutex.Unlock()
+}
+
+func (r *fallbackRing) entries() []FallbackEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.copyEntries()
+}
+
+func (r *fallbackRing) reset() []FallbackEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entries := r.copyEntries()
+	r.buf = make([]FallbackEntry, r.size)
+	r.next = 0
+	r.full = false
+	return entries
+}
+
+func (r *fallbackRing) copyEntries() []FallbackEntry {
+	if !r.full {
+		return append([]FallbackEntry(nil), r.buf[:r.next]...)
+	}
+	entries := make([]FallbackEntry, 0, r.size)
+	entries = append(entries, r.buf[r.next:]...)
+	return append(entries, r.buf[:r.next]...)
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/pkg/log"
+)
+
+func waitHealth(t *testing.T, output string, healthy bool) LogWriteStatus {
+	for i := 0; i < 50; i++ {
+		for _, s := range LogWriteStatuses() {
+			if s.Output == output && s.Healthy == healthy {
+				return s
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("output %s is not changed to healthy: %v", output, healthy)
+	return LogWriteStatus{}
+}
+
+func TestLogFallbackMemory(t *testing.T) {
+	SetLogFallback(&v2.LogFallbackConfig{
+		Target:        FallbackMemory,
+		RingSize:      10,
+		CheckInterval: api.DurationConfig{Duration: 20 * time.Millisecond},
+		MinFreeBytes:  1,
+	})
+	defer SetLogFallback(nil)
+	var hooked int32
+	SetWriteFailureHook(func(output string) {
+		atomic.AddInt32(&hooked, 1)
+	})
+	defer SetWriteFailureHook(nil)
+
+	dir, err := ioutil.TempDir("", "log_fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logDir := filepath.Join(dir, "logs")
+	output := filepath.Join(logDir, "fallback.log")
+	lg, err := CreateDefaultErrorLogger(output, log.INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lg.(*errorLogger).printer.(*failsafeLogger); !ok {
+		t.Fatal("file logger should be wrapped by failsafe logger")
+	}
+	lg.Infof("before failure")
+	waitHealth(t, output, true)
+
+	// make the log file unwritable: the log directory is replaced by a file
+	if err := os.RemoveAll(logDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(logDir, []byte("not a directory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status := waitHealth(t, output, false)
+	if status.LastError == "" {
+		t.Fatalf("last error is not recorded: %+v", status)
+	}
+	lg.Infof("during failure %d", 1)
+	entries := RecentFallbackLogs()
+	if len(entries) != 1 || entries[0].Output != output || !strings.Contains(entries[0].Log, "during failure 1") {
+		t.Fatalf("unexpected fallback entries: %+v", entries)
+	}
+	if atomic.LoadInt32(&hooked) != 1 {
+		t.Fatalf("write failure hook is not called")
+	}
+
+	// recover
+	if err := os.Remove(logDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	status = waitHealth(t, output, true)
+	if status.Failures != 1 {
+		t.Fatalf("unexpected failures: %+v", status)
+	}
+	time.Sleep(50 * time.Millisecond) // wait reopen
+	lg.Infof("after recover")
+	time.Sleep(100 * time.Millisecond) // wait flush
+	b, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "after recover") || strings.Contains(string(b), "during failure") {
+		t.Fatalf("unexpected log file content: %s", string(b))
+	}
+}
+
+func TestLogFallbackDisabled(t *testing.T) {
+	SetLogFallback(nil)
+	dir, err := ioutil.TempDir("", "log_fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lg, err := CreateDefaultErrorLogger(filepath.Join(dir, "disabled.log"), log.INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lg.(*errorLogger).printer.(*failsafeLogger); ok {
+		t.Fatal("failsafe logger should not be used if log fallback is disabled")
+	}
+	SetLogFallback(&v2.LogFallbackConfig{})
+	defer SetLogFallback(nil)
+	for _, output := range []string{"", "stdout", "syslog://127.0.0.1:514", "syslog5424+udp://127.0.0.1:514"} {
+		if isFileOutput(output) {
+			t.Errorf("output %s should not be a file", output)
+		}
+	}
+}
+
+func TestFallbackRing(t *testing.T) {
+	ring := newFallbackRing(3)
+	for _, s := range []string{"a", "b", "c", "d"} {
+		ring.add(FallbackEntry{Log: s})
+	}
+	entries := ring.entries()
+	if len(entries) != 3 || entries[0].Log != "b" || entries[2].Log != "d" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if len(ring.reset()) != 3 || len(ring.entries()) != 0 {
+		t.Fatal("ring is not reset")
+	}
+}
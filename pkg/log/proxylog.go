@@ -41,6 +41,8 @@ func CreateDefaultContextLogger(output string, level log.Level) (log.ContextLogg
 	}
 	if l, ok := lg.(*errorLogger); ok {
 		return &proxyLogger{l}, nil
+	} else if l, ok := lg.(*syslogErrorLogger); ok {
+		return &syslogProxyLogger{l}, nil
 	} else {
 		return nil, errors.New("proxy logger should equal mosn default error log")
 	}
@@ -106,7 +108,7 @@ func (l *proxyLogger) Fatalf(ctx context.Context, format string, args ...interfa
 	}
 	if l.Level >= log.FATAL {
 		s := l.fomatter(ctx, log.FatalPre, "", format)
-		l.Logger.Fatalf(s, args...)
+		l.printer.Fatalf(s, args...)
 	}
 }
 
@@ -0,0 +1,452 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/pkg/buffer"
+	"mosn.io/pkg/log"
+)
+
+// SyslogPrefix is the prefix of the RFC5424 syslog outputs, such as
+// syslog5424+udp://127.0.0.1:514, syslog5424+tcp://127.0.0.1:601 and syslog5424+unix:///dev/log.
+// The query parameters are:
+// facility: the facility name, default is local0
+// severity: the severity name of the access logs, default is info
+// app_name: the app name, default is mosn
+// sd_id: the id of the structured data, default is mosn@32473
+// structured: the access log variables are set in the structured data if it is true
+const SyslogPrefix = "syslog5424+"
+
+const (
+	defaultSyslogAppName = "mosn"
+	defaultSyslogSdID    = "mosn@32473"
+	syslogTimeFormat     = "2006-01-02T15:04:05.000000Z07:00"
+	syslogBufferSize     = 500
+	// the max length of the structured data param name
+	syslogMaxParamName = 32
+)
+
+// RFC5424 severities
+const (
+	severityEmergency = iota
+	severityAlert
+	severityCritical
+	severityError
+	severityWarning
+	severityNotice
+	severityInformational
+	severityDebug
+)
+
+var (
+	syslogFacilities = map[string]int{
+		"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+		"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+		"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+		"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	}
+	syslogSeverities = map[string]int{
+		"emerg": severityEmergency, "alert": severityAlert, "crit": severityCritical, "err": severityError,
+		"warning": severityWarning, "notice": severityNotice, "info": severityInformational, "debug": severityDebug,
+	}
+
+	sdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+	// syslogWriters keeps the writers, the same output references the same writer
+	syslogWriters   = map[string]*syslogWriter{}
+	syslogWritersMu sync.Mutex
+)
+
+// IsSyslogOutput returns true if the output is a RFC5424 syslog output
+func IsSyslogOutput(output string) bool {
+	return strings.HasPrefix(output, SyslogPrefix)
+}
+
+type syslogConfig struct {
+	network    string
+	address    string
+	facility   int
+	severity   int
+	appName    string
+	sdID       string
+	structured bool
+}
+
+func parseSyslogOutput(output string) (*syslogConfig, error) {
+	u, err := url.Parse(strings.TrimPrefix(output, SyslogPrefix))
+	if err != nil {
+		return nil, err
+	}
+	cfg := &syslogConfig{
+		network:  u.Scheme,
+		facility: syslogFacilities["local0"],
+		severity: severityInformational,
+		appName:  defaultSyslogAppName,
+		sdID:     defaultSyslogSdID,
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		cfg.address = u.Host
+	case "unix":
+		cfg.address = u.Path
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", u.Scheme)
+	}
+	if cfg.address == "" {
+		return nil, errors.New("syslog address is required")
+	}
+	query := u.Query()
+	if v := query.Get("facility"); v != "" {
+		f, ok := syslogFacilities[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog facility: %s", v)
+		}
+		cfg.facility = f
+	}
+	if v := query.Get("severity"); v != "" {
+		s, ok := syslogSeverities[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog severity: %s", v)
+		}
+		cfg.severity = s
+	}
+	if v := query.Get("app_name"); v != "" {
+		cfg.appName = v
+	}
+	if v := query.Get("sd_id"); v != "" {
+		cfg.sdID = v
+	}
+	cfg.structured, _ = strconv.ParseBool(query.Get("structured"))
+	return cfg, nil
+}
+
+// sdParam is a param of the structured data
+type sdParam struct {
+	name  string
+	value string
+}
+
+// syslogWriter formats the RFC5424 messages and sends them asynchronously.
+// The messages are octet counting framed in the stream connections (RFC6587).
+type syslogWriter struct {
+	config   *syslogConfig
+	hostname string
+	procID   string
+	stream   bool
+
+	mutex   sync.Mutex
+	conn    net.Conn
+	disable bool
+
+	messages chan []byte
+}
+
+func getOrCreateSyslogWriter(output string) (*syslogWriter, error) {
+	syslogWritersMu.Lock()
+	defer syslogWritersMu.Unlock()
+	if w, ok := syslogWriters[output]; ok {
+		return w, nil
+	}
+	cfg, err := parseSyslogOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	w := &syslogWriter{
+		config:   cfg,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		messages: make(chan []byte, syslogBufferSize),
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	syslogWriters[output] = w
+	return w, nil
+}
+
+func (w *syslogWriter) connect() error {
+	var conn net.Conn
+	var err error
+	switch w.config.network {
+	case "unix":
+		// the local syslog daemon usually listens on a datagram socket
+		conn, err = net.Dial("unixgram", w.config.address)
+		if err != nil {
+			conn, err = net.Dial("unix", w.config.address)
+			w.stream = true
+		}
+	default:
+		conn, err = net.Dial(w.config.network, w.config.address)
+		w.stream = w.config.network == "tcp"
+	}
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *syslogWriter) Toggle(disable bool) {
+	w.mutex.Lock()
+	w.disable = disable
+	w.mutex.Unlock()
+}
+
+func (w *syslogWriter) Disable() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.disable
+}
+
+// format makes a RFC5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (w *syslogWriter) format(severity int, params []sdParam, msg []byte) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "<%d>1 %s %s %s %s - ", w.config.facility*8+severity,
+		time.Now().Format(syslogTimeFormat), w.hostname, w.config.appName, w.procID)
+	if len(params) == 0 {
+		buf.WriteString("-")
+	} else {
+		buf.WriteString("[")
+		buf.WriteString(w.config.sdID)
+		for _, p := range params {
+			name := p.name
+			if len(name) > syslogMaxParamName {
+				name = name[:syslogMaxParamName]
+			}
+			buf.WriteString(" ")
+			buf.WriteString(name)
+			buf.WriteString(`="`)
+			sdValueEscaper.WriteString(buf, p.value)
+			buf.WriteString(`"`)
+		}
+		buf.WriteString("]")
+	}
+	msg = bytes.TrimRight(msg, "\n")
+	if len(msg) > 0 {
+		buf.WriteString(" ")
+		buf.Write(msg)
+	}
+	return buf.Bytes()
+}
+
+// send writes the message asynchronously, the message is dropped if the buffer is full
+func (w *syslogWriter) send(severity int, params []sdParam, msg []byte) {
+	if w.Disable() {
+		return
+	}
+	select {
+	case w.messages <- w.format(severity, params, msg):
+	default:
+	}
+}
+
+// sendSync writes the message synchronously, it is used before the process exits
+func (w *syslogWriter) sendSync(severity int, params []sdParam, msg []byte) {
+	w.write(w.format(severity, params, msg))
+}
+
+func (w *syslogWriter) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			go w.run()
+		}
+	}()
+	for msg := range w.messages {
+		w.write(msg)
+	}
+}
+
+// write sends the message, and reconnects once if the connection is broken
+func (w *syslogWriter) write(msg []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for i := 0; i < 2; i++ {
+		if w.conn == nil {
+			if err := w.connect(); err != nil {
+				return
+			}
+		}
+		var err error
+		if w.stream {
+			_, err = w.conn.Write(append([]byte(strconv.Itoa(len(msg))+" "), msg...))
+		} else {
+			_, err = w.conn.Write(msg)
+		}
+		if err == nil {
+			return
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+func levelSeverity(level log.Level) int {
+	switch level {
+	case log.FATAL:
+		return severityCritical
+	case log.ERROR:
+		return severityError
+	case log.WARN:
+		return severityWarning
+	case log.INFO:
+		return severityInformational
+	default:
+		return severityDebug
+	}
+}
+
+// syslogErrorLogger is an implementation of ErrorLogger and ContextLogger that writes RFC5424 syslog messages.
+// The log levels are mapped to the severities, the alert and the trace info are set in the structured data.
+type syslogErrorLogger struct {
+	writer *syslogWriter
+	level  log.Level
+}
+
+func newSyslogErrorLogger(output string, level log.Level) (*syslogErrorLogger, error) {
+	w, err := getOrCreateSyslogWriter(output)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogErrorLogger{
+		writer: w,
+		level:  level,
+	}, nil
+}
+
+func (l *syslogErrorLogger) logf(ctx context.Context, level log.Level, alert string, format string, args ...interface{}) {
+	if l.level < level || l.writer.Disable() {
+		return
+	}
+	params := l.params(ctx, alert)
+	l.writer.send(levelSeverity(level), params, []byte(fmt.Sprintf(format, args...)))
+}
+
+func (l *syslogErrorLogger) params(ctx context.Context, alert string) []sdParam {
+	var params []sdParam
+	if alert != "" {
+		params = append(params, sdParam{name: "alert", value: alert})
+	}
+	if ctx != nil {
+		params = append(params, sdParam{name: "trace", value: traceInfo(ctx)})
+	}
+	return params
+}
+
+func (l *syslogErrorLogger) Alertf(alert string, format string, args ...interface{}) {
+	l.logf(nil, log.ERROR, alert, format, args...)
+}
+
+func (l *syslogErrorLogger) Infof(format string, args ...interface{}) {
+	l.logf(nil, log.INFO, "", format, args...)
+}
+
+func (l *syslogErrorLogger) Debugf(format string, args ...interface{}) {
+	l.logf(nil, log.DEBUG, "", format, args...)
+}
+
+func (l *syslogErrorLogger) Warnf(format string, args ...interface{}) {
+	l.logf(nil, log.WARN, "", format, args...)
+}
+
+func (l *syslogErrorLogger) Errorf(format string, args ...interface{}) {
+	l.logf(nil, log.ERROR, defaultErrorCode, format, args...)
+}
+
+func (l *syslogErrorLogger) Tracef(format string, args ...interface{}) {
+	l.logf(nil, log.TRACE, "", format, args...)
+}
+
+// Fatalf cannot be disabled
+func (l *syslogErrorLogger) Fatalf(format string, args ...interface{}) {
+	l.writer.sendSync(severityCritical, nil, []byte(fmt.Sprintf(format, args...)))
+	os.Exit(1)
+}
+
+func (l *syslogErrorLogger) SetLogLevel(level log.Level) {
+	l.level = level
+}
+
+func (l *syslogErrorLogger) GetLogLevel() log.Level {
+	return l.level
+}
+
+func (l *syslogErrorLogger) Toggle(disable bool) {
+	l.writer.Toggle(disable)
+}
+
+// syslogProxyLogger is the ContextLogger of the syslogErrorLogger
+type syslogProxyLogger struct {
+	*syslogErrorLogger
+}
+
+func (l *syslogProxyLogger) Alertf(ctx context.Context, alert string, format string, args ...interface{}) {
+	l.logf(ctx, log.ERROR, alert, format, args...)
+}
+
+func (l *syslogProxyLogger) Infof(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, log.INFO, "", format, args...)
+}
+
+func (l *syslogProxyLogger) Debugf(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, log.DEBUG, "", format, args...)
+}
+
+func (l *syslogProxyLogger) Warnf(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, log.WARN, "", format, args...)
+}
+
+func (l *syslogProxyLogger) Errorf(ctx context.Context, format string, args ...interface{}) {
+	l.logf(ctx, log.ERROR, defaultErrorCode, format, args...)
+}
+
+func (l *syslogProxyLogger) Fatalf(ctx context.Context, format string, args ...interface{}) {
+	l.writer.sendSync(severityCritical, l.params(ctx, ""), []byte(fmt.Sprintf(format, args...)))
+	os.Exit(1)
+}
+
+// syslogAccessLogger writes the access logs in the configured severity
+type syslogAccessLogger struct {
+	*syslogWriter
+}
+
+func (l *syslogAccessLogger) Print(buf buffer.IoBuffer, discard bool) error {
+	l.printWithParams(buf, nil)
+	return nil
+}
+
+func (l *syslogAccessLogger) printWithParams(buf buffer.IoBuffer, params []sdParam) {
+	l.send(l.config.severity, params, buf.Bytes())
+	buffer.PutIoBuffer(buf)
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package log
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/pkg/log"
+)
+
+func listenSyslogUDP(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func readSyslogUDP(t *testing.T, conn *net.UDPConn) string {
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	b := make([]byte, 4096)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b[:n])
+}
+
+func TestParseSyslogOutput(t *testing.T) {
+	cfg, err := parseSyslogOutput("syslog5424+unix:///dev/log?facility=local3&severity=notice&app_name=gw&sd_id=gw@1&structured=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.network != "unix" || cfg.address != "/dev/log" || cfg.facility != 19 || cfg.severity != severityNotice ||
+		cfg.appName != "gw" || cfg.sdID != "gw@1" || !cfg.structured {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	for _, output := range []string{
+		"syslog5424+http://127.0.0.1:514",
+		"syslog5424+udp://",
+		"syslog5424+udp://127.0.0.1:514?facility=unknown",
+		"syslog5424+udp://127.0.0.1:514?severity=unknown",
+	} {
+		if _, err := parseSyslogOutput(output); err == nil {
+			t.Fatalf("%s expected an error", output)
+		}
+	}
+}
+
+func TestSyslogErrorLogger(t *testing.T) {
+	conn := listenSyslogUDP(t)
+	defer conn.Close()
+	output := "syslog5424+udp://" + conn.LocalAddr().String() + "?facility=local1&app_name=test"
+	lg, err := CreateDefaultErrorLogger(output, log.INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lg.Debugf("not logged")
+	lg.Alertf("upstream_failed", "connect %s failed", "127.0.0.1:8080")
+	// local1 * 8 + err
+	expected := regexp.MustCompile(`^<139>1 \S+ \S+ test \d+ - \[mosn@32473 alert="upstream_failed"\] connect 127.0.0.1:8080 failed$`)
+	if msg := readSyslogUDP(t, conn); !expected.MatchString(msg) {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+
+	proxy, err := CreateDefaultContextLogger(output, log.INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy.Infof(nil, "no trace")
+	expected = regexp.MustCompile(`^<142>1 \S+ \S+ test \d+ - - no trace$`)
+	if msg := readSyslogUDP(t, conn); !expected.MatchString(msg) {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+	proxy.Warnf(prepareLocalIpv6Ctx(), "with trace")
+	expected = regexp.MustCompile(`^<140>1 \S+ \S+ test \d+ - \[mosn@32473 trace="\[-,-\\\]"\] with trace$`)
+	if msg := readSyslogUDP(t, conn); !expected.MatchString(msg) {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+}
+
+func TestSyslogAccessLog(t *testing.T) {
+	registerTestVarDefs()
+	disabled := DefaultDisableAccessLog
+	DefaultDisableAccessLog = false
+	defer func() {
+		DefaultDisableAccessLog = disabled
+	}()
+	conn := listenSyslogUDP(t)
+	defer conn.Close()
+	output := "syslog5424+udp://" + conn.LocalAddr().String() + "?structured=true"
+	accessLog, err := NewAccessLog(output, "request from %downstream_remote_address%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessLog.Log(prepareLocalIpv6Ctx(), nil, nil, nil)
+	// local0 * 8 + info
+	expected := `[mosn@32473 downstream_remote_address="127.0.0.1:53242"] request from 127.0.0.1:53242`
+	if msg := readSyslogUDP(t, conn); !strings.HasPrefix(msg, "<134>1 ") || !strings.HasSuffix(msg, expected) {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+}
+
+func TestSyslogTCPFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		length, _ := r.ReadString(' ')
+		received <- length
+	}()
+	w, err := getOrCreateSyslogWriter("syslog5424+tcp://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := w.format(severityError, nil, []byte("framed"))
+	w.send(severityError, nil, []byte("framed"))
+	select {
+	case length := <-received:
+		if strings.TrimSpace(length) != strconv.Itoa(len(msg)) {
+			t.Fatalf("unexpected frame length: %q", length)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no message received")
+	}
+}
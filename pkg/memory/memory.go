@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package memory accounts the memory held by the modules of mosn, such as the connection buffers,
+// the headers, the stream objects and the codec scratch buffers, the accounts are reported as stats
+// to find out where the memory is going without a heap profile.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// the modules that the memory is accounted
+const (
+	// ModuleBuffer is the connection read buffers and the data buffers held by the streams
+	ModuleBuffer = "buffer"
+	// ModuleHeader is the headers and trailers held by the streams
+	ModuleHeader = "header"
+	// ModuleStream is the pooled stream objects
+	ModuleStream = "stream"
+	// ModuleCodec is the pooled codec scratch buffers
+	ModuleCodec = "codec"
+)
+
+var enabled uint32
+
+// Enable enables the memory accounting, it should be called before any connection is created,
+// or the memory allocated before it is not accounted
+func Enable() {
+	atomic.StoreUint32(&enabled, 1)
+}
+
+// Enabled returns true if the memory accounting is enabled
+func Enabled() bool {
+	return atomic.LoadUint32(&enabled) == 1
+}
+
+// Account accounts the memory held by a module
+type Account struct {
+	module    string
+	bytes     int64
+	objects   int64
+	allocated int64
+}
+
+var (
+	mux      sync.RWMutex
+	accounts = make(map[string]*Account)
+)
+
+// GetAccount returns the account of the module, the account is created if not exists
+func GetAccount(module string) *Account {
+	mux.RLock()
+	a, ok := accounts[module]
+	mux.RUnlock()
+	if ok {
+		return a
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if a, ok = accounts[module]; !ok {
+		a = &Account{module: module}
+		accounts[module] = a
+	}
+	return a
+}
+
+// Range calls f for each account in the order of the module name
+func Range(f func(a *Account)) {
+	mux.RLock()
+	all := make([]*Account, 0, len(accounts))
+	for _, a := range accounts {
+		all = append(all, a)
+	}
+	mux.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].module < all[j].module
+	})
+	for _, a := range all {
+		f(a)
+	}
+}
+
+// Module returns the module name of the account
+func (a *Account) Module() string {
+	return a.module
+}
+
+// Alloc accounts an object of size bytes
+func (a *Account) Alloc(size int64) {
+	if !Enabled() {
+		return
+	}
+	atomic.AddInt64(&a.objects, 1)
+	atomic.AddInt64(&a.bytes, size)
+	atomic.AddInt64(&a.allocated, size)
+}
+
+// Free releases an object of size bytes
+func (a *Account) Free(size int64) {
+	if !Enabled() {
+		return
+	}
+	atomic.AddInt64(&a.objects, -1)
+	atomic.AddInt64(&a.bytes, -size)
+}
+
+// Grow accounts the size change of an object that is accounted already
+func (a *Account) Grow(delta int64) {
+	if !Enabled() {
+		return
+	}
+	atomic.AddInt64(&a.bytes, delta)
+	if delta > 0 {
+		atomic.AddInt64(&a.allocated, delta)
+	}
+}
+
+// Bytes returns the bytes in use
+func (a *Account) Bytes() int64 {
+	return atomic.LoadInt64(&a.bytes)
+}
+
+// Objects returns the objects in use
+func (a *Account) Objects() int64 {
+	return atomic.LoadInt64(&a.objects)
+}
+
+// Allocated returns the total bytes allocated
+func (a *Account) Allocated() int64 {
+	return atomic.LoadInt64(&a.allocated)
+}
+
+// reset resets the account, for test
+func (a *Account) reset() {
+	atomic.StoreInt64(&a.bytes, 0)
+	atomic.StoreInt64(&a.objects, 0)
+	atomic.StoreInt64(&a.allocated, 0)
+}
@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"testing"
+)
+
+func TestAccount(t *testing.T) {
+	Enable()
+	a := GetAccount("test")
+	a.reset()
+	if GetAccount("test") != a {
+		t.Fatal("account of the same module should be the same")
+	}
+	a.Alloc(100)
+	a.Alloc(50)
+	a.Grow(28)
+	a.Grow(-8)
+	a.Free(50)
+	if a.Bytes() != 120 || a.Objects() != 1 || a.Allocated() != 178 {
+		t.Fatalf("unexpected account, bytes: %d, objects: %d, allocated: %d", a.Bytes(), a.Objects(), a.Allocated())
+	}
+	a.Free(120)
+	if a.Bytes() != 0 || a.Objects() != 0 {
+		t.Fatalf("account should be released, bytes: %d, objects: %d", a.Bytes(), a.Objects())
+	}
+}
+
+func TestRange(t *testing.T) {
+	GetAccount("test_b")
+	GetAccount("test_a")
+	var modules []string
+	Range(func(a *Account) {
+		modules = append(modules, a.Module())
+	})
+	for i := 1; i < len(modules); i++ {
+		if modules[i-1] >= modules[i] {
+			t.Fatalf("accounts are not ranged in order: %v", modules)
+		}
+	}
+}
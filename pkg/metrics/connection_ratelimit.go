@@ -0,0 +1,37 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// ConnectionRateLimitType represents the connection rate limit metrics type
+const ConnectionRateLimitType = "connection_rate_limit"
+
+// connection rate limit metrics key in listener
+const (
+	ConnectionRateLimitAllowed  = "allowed"
+	ConnectionRateLimitRejected = "rejected"
+)
+
+// NewConnectionRateLimitStats returns a stats with namespace prefix connection rate limit and listener
+func NewConnectionRateLimitStats(listener string) types.Metrics {
+	metrics, _ := NewMetrics(ConnectionRateLimitType, map[string]string{"listener": listener})
+	return metrics
+}
@@ -0,0 +1,171 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// RouteType represents the per-route detailed metrics type
+const RouteType = "route"
+
+// HostDetailType represents the per-upstream-host detailed metrics type
+const HostDetailType = "host_detail"
+
+// metrics key in route/host detailed stats
+const (
+	DetailRequestTotal   = "request_total"
+	DetailResponse2xx    = "response_2xx"
+	DetailResponse4xx    = "response_4xx"
+	DetailResponse5xx    = "response_5xx"
+	DetailRequestTimeout = "request_timeout"
+	DetailRequestReset   = "request_reset"
+)
+
+// DetailOverflow is the key in mosn meta metrics that counts the
+// detailed stats dropped by the cardinality guard
+const DetailOverflow = "detail_stats_overflow"
+
+// DefaultMaxDetailStats is the default max number of detailed stats scopes
+const DefaultMaxDetailStats = 1000
+
+// detailSwitch stores the runtime state of the detailed stats
+type detailSwitch struct {
+	route          bool
+	host           bool
+	maxCardinality int
+}
+
+var (
+	detailState atomic.Value // *detailSwitch
+
+	detailMutex sync.Mutex
+	detailStats = make(map[string]types.Metrics)
+
+	// detailGeneration is increased when the created detailed stats are invalidated,
+	// the callers caching the detailed stats should drop their cache when it is changed
+	detailGeneration uint64
+)
+
+func init() {
+	detailState.Store(&detailSwitch{
+		maxCardinality: DefaultMaxDetailStats,
+	})
+}
+
+// SetDetailedStats turns the per-route and per-host detailed stats on or off at runtime.
+// maxCardinality limits the number of detailed stats scopes, a non-positive value means the default.
+func SetDetailedStats(route, host bool, maxCardinality int) {
+	if maxCardinality <= 0 {
+		maxCardinality = DefaultMaxDetailStats
+	}
+	old := detailState.Load().(*detailSwitch)
+	detailState.Store(&detailSwitch{
+		route:          route,
+		host:           host,
+		maxCardinality: maxCardinality,
+	})
+	// the nil metrics returned by the cardinality guard are valid stats after the limit changed
+	if old.maxCardinality != maxCardinality {
+		atomic.AddUint64(&detailGeneration, 1)
+	}
+}
+
+// DetailStatsGeneration returns the generation of the detailed stats,
+// it is changed when the detailed stats are reset, removed or the cardinality limit is changed.
+func DetailStatsGeneration() uint64 {
+	return atomic.LoadUint64(&detailGeneration)
+}
+
+// DetailedStats returns the detailed stats switch state
+func DetailedStats() (route, host bool, maxCardinality int) {
+	s := detailState.Load().(*detailSwitch)
+	return s.route, s.host, s.maxCardinality
+}
+
+// RouteStatsEnabled returns whether the per-route stats is enabled
+func RouteStatsEnabled() bool {
+	return detailState.Load().(*detailSwitch).route
+}
+
+// HostDetailStatsEnabled returns whether the per-upstream-host detailed stats is enabled
+func HostDetailStatsEnabled() bool {
+	return detailState.Load().(*detailSwitch).host
+}
+
+// NewRouteStats returns a stats with namespace prefix route.
+// If the cardinality guard is exceeded, a nil metrics is returned.
+func NewRouteStats(routeName string) types.Metrics {
+	return newDetailStats(RouteType, map[string]string{"route": routeName})
+}
+
+// NewHostDetailStats returns a detailed stats that namespace contains cluster and host address.
+// If the cardinality guard is exceeded, a nil metrics is returned.
+func NewHostDetailStats(clusterName string, addr string) types.Metrics {
+	return newDetailStats(HostDetailType, map[string]string{"cluster": clusterName, "host": addr})
+}
+
+func newDetailStats(typ string, labels map[string]string) types.Metrics {
+	name, _, _ := fullName(typ, labels)
+	detailMutex.Lock()
+	defer detailMutex.Unlock()
+	if m, ok := detailStats[name]; ok {
+		return m
+	}
+	if len(detailStats) >= detailState.Load().(*detailSwitch).maxCardinality {
+		NewMosnMetrics().Counter(DetailOverflow).Inc(1)
+		m, _ := NewNilMetrics(typ, labels)
+		return m
+	}
+	m, err := NewMetrics(typ, labels)
+	if err != nil {
+		m, _ = NewNilMetrics(typ, labels)
+	}
+	detailStats[name] = m
+	return m
+}
+
+// RemoveHostDetailStats removes the detailed stats of the host, called when the host is removed from the cluster
+func RemoveHostDetailStats(clusterName string, addr string) {
+	name, _, _ := fullName(HostDetailType, map[string]string{"cluster": clusterName, "host": addr})
+	detailMutex.Lock()
+	m, ok := detailStats[name]
+	delete(detailStats, name)
+	detailMutex.Unlock()
+	if !ok {
+		return
+	}
+	defaultStore.mutex.Lock()
+	if defaultStore.metrics[name] == m {
+		delete(defaultStore.metrics, name)
+	}
+	defaultStore.mutex.Unlock()
+	m.UnregisterAll()
+	atomic.AddUint64(&detailGeneration, 1)
+}
+
+// resetDetailStats clears the detailed stats scopes, used in ResetAll
+func resetDetailStats() {
+	detailMutex.Lock()
+	defer detailMutex.Unlock()
+	detailStats = make(map[string]types.Metrics)
+	atomic.AddUint64(&detailGeneration, 1)
+}
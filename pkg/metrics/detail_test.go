@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetailedStatsCardinality(t *testing.T) {
+	ResetAll()
+	defer SetDetailedStats(false, false, 0)
+
+	SetDetailedStats(true, true, 2)
+	if !RouteStatsEnabled() || !HostDetailStatsEnabled() {
+		t.Fatal("detailed stats should be enabled")
+	}
+	NewRouteStats("route1").Counter(DetailRequestTotal).Inc(1)
+	NewHostDetailStats("cluster1", "127.0.0.1:8080").Counter(DetailRequestTotal).Inc(1)
+	// exceeds the cardinality limit
+	overflow := NewRouteStats("route2")
+	if _, ok := overflow.(*NilMetrics); !ok {
+		t.Fatal("route stats beyond the cardinality limit should be nil metrics")
+	}
+	// created stats is still available
+	m := NewRouteStats("route1")
+	if _, ok := m.(*NilMetrics); ok {
+		t.Fatal("created route stats should not be nil metrics")
+	}
+	if m.Counter(DetailRequestTotal).Count() != 1 {
+		t.Fatal("route stats should be the same instance")
+	}
+	typs := map[string]int{}
+	for _, m := range GetAll() {
+		typs[m.Type()]++
+	}
+	if typs[RouteType] != 1 || typs[HostDetailType] != 1 {
+		t.Fatalf("unexpected metrics: %v", typs)
+	}
+}
+
+func TestDetailedStatsToggle(t *testing.T) {
+	ResetAll()
+	defer SetDetailedStats(false, false, 0)
+
+	if RouteStatsEnabled() || HostDetailStatsEnabled() {
+		t.Fatal("detailed stats should be disabled by default")
+	}
+	SetDetailedStats(true, false, 0)
+	route, host, max := DetailedStats()
+	if !route || host || max != DefaultMaxDetailStats {
+		t.Fatalf("unexpected detailed stats state, route: %v, host: %v, max: %d", route, host, max)
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := NewRouteStats(fmt.Sprintf("route%d", i)).(*NilMetrics); ok {
+			t.Fatal("route stats should not be nil metrics")
+		}
+	}
+}
+
+func TestDetailedStatsGeneration(t *testing.T) {
+	ResetAll()
+	defer SetDetailedStats(false, false, 0)
+
+	SetDetailedStats(false, true, 1)
+	gen := DetailStatsGeneration()
+	NewHostDetailStats("cluster1", "127.0.0.1:8080").Counter(DetailRequestTotal).Inc(1)
+	if _, ok := NewHostDetailStats("cluster1", "127.0.0.1:8081").(*NilMetrics); !ok {
+		t.Fatal("host stats beyond the cardinality limit should be nil metrics")
+	}
+	// switch without limit changed keeps the generation
+	SetDetailedStats(true, true, 1)
+	if DetailStatsGeneration() != gen {
+		t.Fatal("generation should not be changed if the limit is not changed")
+	}
+
+	RemoveHostDetailStats("cluster1", "127.0.0.1:8080")
+	if DetailStatsGeneration() == gen {
+		t.Fatal("generation should be changed after host stats removed")
+	}
+	for _, m := range GetAll() {
+		if m.Type() == HostDetailType {
+			t.Fatalf("removed host stats is still registered: %v", m.Labels())
+		}
+	}
+	// the removed host releases the cardinality
+	m := NewHostDetailStats("cluster1", "127.0.0.1:8081")
+	if _, ok := m.(*NilMetrics); ok {
+		t.Fatal("host stats should be created after the removed one released")
+	}
+
+	gen = DetailStatsGeneration()
+	SetDetailedStats(true, true, 10)
+	if DetailStatsGeneration() == gen {
+		t.Fatal("generation should be changed after the limit changed")
+	}
+	gen = DetailStatsGeneration()
+	ResetAll()
+	if DetailStatsGeneration() == gen {
+		t.Fatal("generation should be changed after reset")
+	}
+}
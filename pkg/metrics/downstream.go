@@ -41,6 +41,25 @@ const (
 	DownstreamProcessTime        = "process_time"
 	DownstreamProcessTimeTotal   = "process_time_total"
 	DownstreamRequestFailed      = "request_failed"
+	DownstreamRequestBytes       = "request_bytes"
+	DownstreamResponseBytes      = "response_bytes"
+	// DownstreamRequestRejected is the requests rejected by the request blocklist
+	DownstreamRequestRejected = "request_rejected"
+	// DownstreamRequestLoopDetected is the requests rejected by the loop detection
+	DownstreamRequestLoopDetected = "request_loop_detected"
+	// DownstreamRequestInternalRedirect is the redirect responses followed by mosn
+	DownstreamRequestInternalRedirect = "request_internal_redirect"
+	// DownstreamUpstreamConnectTime is the time cost to get a ready upstream stream from the connection pool
+	DownstreamUpstreamConnectTime = "upstream_connect_time"
+	// DownstreamResponseFlagPrefix is the prefix of the requests with a response flag, followed by the flag name
+	DownstreamResponseFlagPrefix = "response_flag_"
+)
+
+// metrics key in listener accept throttle
+const (
+	DownstreamAcceptPaused          = "accept_paused"
+	DownstreamAcceptPausedTotal     = "accept_paused_total"
+	DownstreamAcceptPausedTimeTotal = "accept_paused_time_total"
 )
 
 // NewProxyStats returns a stats with namespace prefix proxy
@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// HandshakeType represents the connection handshake metrics type
+const HandshakeType = "handshake"
+
+// the sides of a handshake
+const (
+	HandshakeSideServer = "server"
+	HandshakeSideClient = "client"
+)
+
+// metrics key in handshake protocol/side
+const (
+	HandshakeTotal   = "handshake_total"
+	HandshakeSuccess = "handshake_success"
+	HandshakeFailed  = "handshake_failed"
+	// HandshakeDuration is the duration of the completed handshakes in nanoseconds
+	HandshakeDuration = "handshake_duration"
+	// HandshakeFailedPrefix is the prefix of the failed handshakes by the cause, followed by the cause
+	HandshakeFailedPrefix = "handshake_failed_"
+	// HandshakeALPNMismatch is the completed handshakes that negotiate none of the protocols offered
+	HandshakeALPNMismatch = "handshake_alpn_mismatch"
+	// HandshakeQueueDepth is the handshakes waiting for the handshake workers
+	HandshakeQueueDepth = "handshake_queue_depth"
+	// HandshakeQueueRejected is the connections closed as the handshake queue is full
+	HandshakeQueueRejected = "handshake_queue_rejected"
+)
+
+// NewHandshakeStats returns a stats with namespace prefix handshake, the protocol and the side
+func NewHandshakeStats(protocol string, side string) types.Metrics {
+	metrics, _ := NewMetrics(HandshakeType, map[string]string{"protocol": protocol, "side": side})
+	return metrics
+}
+
+// RecordHandshake records a handshake, the cause is empty if the handshake is completed
+func RecordHandshake(protocol, side string, duration time.Duration, cause string) {
+	s := NewHandshakeStats(protocol, side)
+	s.Counter(HandshakeTotal).Inc(1)
+	if cause != "" {
+		s.Counter(HandshakeFailed).Inc(1)
+		s.Counter(HandshakeFailedPrefix + cause).Inc(1)
+		return
+	}
+	s.Counter(HandshakeSuccess).Inc(1)
+	s.Histogram(HandshakeDuration).Update(duration.Nanoseconds())
+}
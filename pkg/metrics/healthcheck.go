@@ -34,6 +34,7 @@ const (
 	HealthCheckNetworkFailure = "network_failure"
 	HealthCheckVeirfyCluster  = "verify_cluster"
 	HealthCheckHealthy        = "healty"
+	HealthCheckDegraded       = "degraded"
 )
 
 // NewHealthStats returns a stats with namespace prefix service
@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// histogram sample size, the sample is used to calculate percentiles
+const histogramSampleSize = 100
+
+// Default buckets for the histograms
+// durations are recorded in nanoseconds, sizes are recorded in bytes
+var (
+	DefaultDurationBuckets = []float64{
+		1e6, 5e6, 10e6, 25e6, 50e6, 100e6, 250e6, 500e6, 1e9, 2.5e9, 5e9, 10e9,
+	}
+	DefaultSizeBuckets = []float64{
+		64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304,
+	}
+)
+
+var (
+	histogramBucketsMutex sync.RWMutex
+	// metrics key -> buckets
+	histogramBuckets = map[string][]float64{
+		DownstreamRequestTime:         DefaultDurationBuckets,
+		DownstreamUpstreamConnectTime: DefaultDurationBuckets,
+		DownstreamRequestBytes:        DefaultSizeBuckets,
+		DownstreamResponseBytes:       DefaultSizeBuckets,
+		UpstreamRequestDuration:       DefaultDurationBuckets,
+	}
+)
+
+// SetHistogramBuckets sets the histogram buckets for the metrics key.
+// Histograms with buckets can be exported as real histograms by sinks like prometheus,
+// set an empty buckets to disable the buckets for the key.
+// Only the histograms created after the call are affected.
+func SetHistogramBuckets(key string, buckets []float64) {
+	histogramBucketsMutex.Lock()
+	defer histogramBucketsMutex.Unlock()
+	if len(buckets) == 0 {
+		delete(histogramBuckets, key)
+		return
+	}
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+	histogramBuckets[key] = b
+}
+
+func getHistogramBuckets(key string) []float64 {
+	histogramBucketsMutex.RLock()
+	defer histogramBucketsMutex.RUnlock()
+	return histogramBuckets[key]
+}
+
+// BucketHistogram is a histogram with buckets
+type BucketHistogram interface {
+	gometrics.Histogram
+
+	// Buckets returns the buckets upper bounds and the cumulative counts of each bucket
+	Buckets() (upperBounds []float64, counts []int64)
+}
+
+// NewHistogram returns a histogram for the metrics key.
+// If the key has configured buckets, a BucketHistogram is returned.
+func NewHistogram(key string) gometrics.Histogram {
+	sample := gometrics.NewHistogram(gometrics.NewUniformSample(histogramSampleSize))
+	if buckets := getHistogramBuckets(key); len(buckets) > 0 {
+		return &bucketHistogram{
+			Histogram:   sample,
+			upperBounds: buckets,
+			counts:      make([]int64, len(buckets)),
+		}
+	}
+	return sample
+}
+
+// bucketHistogram records the values into buckets, and keeps a sample for percentiles
+type bucketHistogram struct {
+	gometrics.Histogram
+	upperBounds []float64
+	counts      []int64 // not cumulative
+	count       int64
+	sum         int64
+}
+
+func (h *bucketHistogram) Update(v int64) {
+	h.Histogram.Update(v)
+	// the values greater than all of the upper bounds are only counted in count (+Inf bucket)
+	if idx := sort.SearchFloat64s(h.upperBounds, float64(v)); idx < len(h.upperBounds) {
+		atomic.AddInt64(&h.counts[idx], 1)
+	}
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, v)
+}
+
+func (h *bucketHistogram) Clear() {
+	h.Histogram.Clear()
+	for i := range h.counts {
+		atomic.StoreInt64(&h.counts[i], 0)
+	}
+	atomic.StoreInt64(&h.count, 0)
+	atomic.StoreInt64(&h.sum, 0)
+}
+
+// Count returns the total count of the values, not the sample count
+func (h *bucketHistogram) Count() int64 {
+	return atomic.LoadInt64(&h.count)
+}
+
+// Sum returns the total sum of the values, not the sample sum
+func (h *bucketHistogram) Sum() int64 {
+	return atomic.LoadInt64(&h.sum)
+}
+
+func (h *bucketHistogram) Buckets() ([]float64, []int64) {
+	counts := make([]int64, len(h.counts))
+	var cumulative int64
+	for i := range h.counts {
+		cumulative += atomic.LoadInt64(&h.counts[i])
+		counts[i] = cumulative
+	}
+	return h.upperBounds, counts
+}
+
+func (h *bucketHistogram) Snapshot() gometrics.Histogram {
+	_, counts := h.Buckets()
+	return &bucketHistogramSnapshot{
+		Histogram:   h.Histogram.Snapshot(),
+		upperBounds: h.upperBounds,
+		counts:      counts,
+		count:       h.Count(),
+		sum:         h.Sum(),
+	}
+}
+
+// bucketHistogramSnapshot is a read-only copy of bucketHistogram
+type bucketHistogramSnapshot struct {
+	gometrics.Histogram
+	upperBounds []float64
+	counts      []int64 // cumulative
+	count       int64
+	sum         int64
+}
+
+func (h *bucketHistogramSnapshot) Update(int64) {
+	panic("Update called on a bucketHistogramSnapshot")
+}
+
+func (h *bucketHistogramSnapshot) Clear() {
+	panic("Clear called on a bucketHistogramSnapshot")
+}
+
+func (h *bucketHistogramSnapshot) Count() int64 {
+	return h.count
+}
+
+func (h *bucketHistogramSnapshot) Sum() int64 {
+	return h.sum
+}
+
+func (h *bucketHistogramSnapshot) Buckets() ([]float64, []int64) {
+	return h.upperBounds, h.counts
+}
+
+func (h *bucketHistogramSnapshot) Snapshot() gometrics.Histogram {
+	return h
+}
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"testing"
+)
+
+func TestBucketHistogram(t *testing.T) {
+	ResetAll()
+	SetHistogramBuckets("test_bucket", []float64{100, 10, 1000})
+	defer SetHistogramBuckets("test_bucket", nil)
+
+	m, _ := NewMetrics("test", map[string]string{"lbk": "lbv"})
+	h, ok := m.Histogram("test_bucket").(BucketHistogram)
+	if !ok {
+		t.Fatal("histogram with buckets configured should be a BucketHistogram")
+	}
+	for _, v := range []int64{1, 10, 50, 100, 500, 5000} {
+		h.Update(v)
+	}
+	snapshot := h.Snapshot().(BucketHistogram)
+	upperBounds, counts := snapshot.Buckets()
+	expectedBounds := []float64{10, 100, 1000}
+	expectedCounts := []int64{2, 4, 5}
+	for i := range expectedBounds {
+		if upperBounds[i] != expectedBounds[i] || counts[i] != expectedCounts[i] {
+			t.Fatalf("unexpected buckets, bounds: %v, counts: %v", upperBounds, counts)
+		}
+	}
+	if snapshot.Count() != 6 || snapshot.Sum() != 5661 {
+		t.Fatalf("unexpected count: %d, sum: %d", snapshot.Count(), snapshot.Sum())
+	}
+	if snapshot.Max() != 5000 || snapshot.Min() != 1 {
+		t.Fatalf("unexpected max: %d, min: %d", snapshot.Max(), snapshot.Min())
+	}
+	// no buckets
+	if _, ok := m.Histogram("test_no_bucket").(BucketHistogram); ok {
+		t.Fatal("histogram without buckets configured should not be a BucketHistogram")
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// LogType represents log output metrics type
+const LogType = "log"
+
+// log output metrics key
+const (
+	LogWriteFailure = "write_failure"
+)
+
+func init() {
+	// the log entries written to the fallback target are counted as write failures
+	log.SetWriteFailureHook(func(output string) {
+		NewLogStats(output).Counter(LogWriteFailure).Inc(1)
+	})
+}
+
+// NewLogStats returns a stats with namespace prefix log output
+func NewLogStats(output string) types.Metrics {
+	metrics, _ := NewMetrics(LogType, map[string]string{"output": output})
+	return metrics
+}
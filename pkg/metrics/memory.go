@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/memory"
+	"mosn.io/mosn/pkg/types"
+)
+
+// MemoryType represents the memory accounting metrics type
+const MemoryType = "memory"
+
+// metrics key in memory module
+const (
+	MemoryBytesInUse     = "bytes_in_use"
+	MemoryObjectsInUse   = "objects_in_use"
+	MemoryBytesAllocated = "bytes_allocated"
+)
+
+// NewMemoryStats returns a stats with namespace prefix memory and the module
+func NewMemoryStats(module string) types.Metrics {
+	metrics, _ := NewMetrics(MemoryType, map[string]string{"module": module})
+	return metrics
+}
+
+// FlushMemoryStats updates the memory stats of all the modules by the memory accounts
+func FlushMemoryStats() {
+	memory.Range(func(a *memory.Account) {
+		s := NewMemoryStats(a.Module())
+		s.Gauge(MemoryBytesInUse).Update(a.Bytes())
+		s.Gauge(MemoryObjectsInUse).Update(a.Objects())
+		s.Gauge(MemoryBytesAllocated).Update(a.Allocated())
+	})
+}
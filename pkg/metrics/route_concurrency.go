@@ -0,0 +1,40 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// RouteConcurrencyType represents per-route concurrency limit metrics type
+const RouteConcurrencyType = "route_concurrency"
+
+// route concurrency limit metrics key
+const (
+	RouteConcurrencyActive        = "active"
+	RouteConcurrencyQueueDepth    = "queue_depth"
+	RouteConcurrencyQueueWait     = "queue_wait_time"
+	RouteConcurrencyQueueOverflow = "queue_overflow"
+	RouteConcurrencyQueueTimeout  = "queue_timeout"
+)
+
+// NewRouteConcurrencyStats returns a stats with namespace prefix route concurrency
+func NewRouteConcurrencyStats(route string) types.Metrics {
+	metrics, _ := NewMetrics(RouteConcurrencyType, map[string]string{"route": route})
+	return metrics
+}
@@ -111,13 +111,19 @@ func (psink *promSink) Flush(writer io.Writer, ms []types.Metrics) {
 			if sink.IsExclusionKeys(name) {
 				return
 			}
+			// decompose the flat name into name + labels
+			labels := suffix
+			name, tagKeys, tagVals := metrics.ExtractTags(name)
+			if len(tagKeys) > 0 {
+				labels = makeLabelStr(metrics.MergeTags(labelKeys, labelVals, tagKeys, tagVals))
+			}
 			switch metric := i.(type) {
 			case gometrics.Counter:
-				psink.flushCounter(tracker, buf, flattenKey(prefix+name), suffix, float64(metric.Count()))
+				psink.flushCounter(tracker, buf, flattenKey(prefix+name), labels, float64(metric.Count()))
 			case gometrics.Gauge:
-				psink.flushGauge(tracker, buf, flattenKey(prefix+name), suffix, float64(metric.Value()))
+				psink.flushGauge(tracker, buf, flattenKey(prefix+name), labels, float64(metric.Value()))
 			case gometrics.Histogram:
-				psink.flushHistogram(tracker, buf, flattenKey(prefix+name), suffix, metric.Snapshot())
+				psink.flushHistogram(tracker, buf, flattenKey(prefix+name), labels, metric.Snapshot())
 			}
 			buf.WriteTo(w)
 			buf.Reset()
@@ -126,6 +132,9 @@ func (psink *promSink) Flush(writer io.Writer, ms []types.Metrics) {
 }
 
 func (psink *promSink) flushHistogram(tracker map[string]bool, buf types.IoBuffer, name string, labels string, snapshot gometrics.Histogram) {
+	if bh, ok := snapshot.(metrics.BucketHistogram); ok {
+		psink.flushBuckets(tracker, buf, name, labels, bh)
+	}
 	// min
 	psink.flushGauge(tracker, buf, name+"_min", labels, float64(snapshot.Min()))
 	// max
@@ -133,6 +142,51 @@ func (psink *promSink) flushHistogram(tracker map[string]bool, buf types.IoBuffe
 	// TODO: flush P90 P95 P99 if configured
 }
 
+func (psink *promSink) flushBuckets(tracker map[string]bool, buf types.IoBuffer, name string, labels string, bh metrics.BucketHistogram) {
+	// type
+	if !tracker[name] {
+		buf.WriteString("# TYPE ")
+		buf.WriteString(name)
+		buf.WriteString(" histogram\n")
+		tracker[name] = true
+	}
+	leLabels := labels
+	if leLabels != "" {
+		leLabels += ","
+	}
+	upperBounds, counts := bh.Buckets()
+	for i := range upperBounds {
+		buf.WriteString(name)
+		buf.WriteString("_bucket{")
+		buf.WriteString(leLabels)
+		buf.WriteString("le=\"")
+		writeFloat(buf, upperBounds[i])
+		buf.WriteString("\"} ")
+		writeFloat(buf, float64(counts[i]))
+		buf.WriteString("\n")
+	}
+	buf.WriteString(name)
+	buf.WriteString("_bucket{")
+	buf.WriteString(leLabels)
+	buf.WriteString("le=\"+Inf\"} ")
+	writeFloat(buf, float64(bh.Count()))
+	buf.WriteString("\n")
+	// sum
+	buf.WriteString(name)
+	buf.WriteString("_sum{")
+	buf.WriteString(labels)
+	buf.WriteString("} ")
+	writeFloat(buf, float64(bh.Sum()))
+	buf.WriteString("\n")
+	// count
+	buf.WriteString(name)
+	buf.WriteString("_count{")
+	buf.WriteString(labels)
+	buf.WriteString("} ")
+	writeFloat(buf, float64(bh.Count()))
+	buf.WriteString("\n")
+}
+
 func (psink *promSink) flushGauge(tracker map[string]bool, buf types.IoBuffer, name string, labels string, val float64) {
 	// type
 	if !tracker[name] {
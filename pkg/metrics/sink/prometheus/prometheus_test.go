@@ -357,3 +357,53 @@ func BenchmarkPromSink_Filter(b *testing.B) {
 	})
 
 }
+
+func TestPrometheusHistogramBuckets(t *testing.T) {
+	metrics.ResetAll()
+	metrics.SetHistogramBuckets("test_bucket", []float64{10, 100})
+	defer metrics.SetHistogramBuckets("test_bucket", nil)
+
+	m, _ := metrics.NewMetrics("t1", map[string]string{"lbk3": "lbv3"})
+	h := m.Histogram("test_bucket")
+	h.Update(5)
+	h.Update(50)
+	h.Update(500)
+
+	buf := &bytes.Buffer{}
+	sink := NewPromeSink(&promConfig{Port: 8089, Endpoint: "/metrics"})
+	sink.Flush(buf, metrics.GetAll())
+	expected := []string{
+		"# TYPE t1_test_bucket histogram",
+		`t1_test_bucket_bucket{lbk3="lbv3",le="10.0"} 1.0`,
+		`t1_test_bucket_bucket{lbk3="lbv3",le="100.0"} 2.0`,
+		`t1_test_bucket_bucket{lbk3="lbv3",le="+Inf"} 3.0`,
+		`t1_test_bucket_sum{lbk3="lbv3"} 555.0`,
+		`t1_test_bucket_count{lbk3="lbv3"} 3.0`,
+	}
+	for _, line := range expected {
+		if !bytes.Contains(buf.Bytes(), []byte(line+"\n")) {
+			t.Errorf("line %s not found in:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestPrometheusExtractTags(t *testing.T) {
+	metrics.ResetAll()
+	extractor, err := metrics.NewTagExtractor("cluster_name", `^cluster\.((.+?)\.)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics.SetTagExtractors([]metrics.TagExtractor{extractor}, map[string]string{"zone": "gz00b"})
+	defer metrics.SetTagExtractors(nil, nil)
+
+	m, _ := metrics.NewMetrics("t2", map[string]string{"lbk3": "lbv3"})
+	m.Counter("cluster.foo.upstream_rq_timeout").Inc(2)
+
+	buf := &bytes.Buffer{}
+	sink := NewPromeSink(&promConfig{Port: 8090, Endpoint: "/metrics"})
+	sink.Flush(buf, metrics.GetAll())
+	line := `t2_cluster_upstream_rq_timeout{cluster_name="foo",lbk3="lbv3",zone="gz00b"} 2.0`
+	if !bytes.Contains(buf.Bytes(), []byte(line+"\n")) {
+		t.Errorf("line %s not found in:\n%s", line, buf.String())
+	}
+}
@@ -0,0 +1,279 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statsd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/metrics/sink"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+var (
+	sinkType             = "statsd"
+	defaultNetwork       = "udp"
+	defaultFlushInterval = 10 * time.Second
+	// keep the udp packet under the typical MTU
+	defaultMaxPacketSize = 1432
+)
+
+// histogram output percents
+var percents = []float64{0.5, 0.95, 0.99}
+
+func init() {
+	sink.RegisterSink(sinkType, builder)
+}
+
+// statsdConfig contains config for the statsd sink
+type statsdConfig struct {
+	Address       string             `json:"address"`
+	Network       string             `json:"network,omitempty"`
+	FlushInterval api.DurationConfig `json:"flush_interval,omitempty"`
+	Prefix        string             `json:"prefix,omitempty"`
+	MaxPacketSize int                `json:"max_packet_size,omitempty"`
+	// DogStatsD enables the DogStatsD tags extension, the metrics labels are sent as tags
+	DogStatsD bool `json:"dogstatsd,omitempty"`
+	// Tags are static tags attached to every metric, only used in DogStatsD mode
+	Tags map[string]string `json:"tags,omitempty"`
+	// TagLabels specifies which metrics labels are extracted as tags in DogStatsD mode,
+	// the others are flattened into the metric name. If it is empty, all labels are extracted as tags.
+	TagLabels []string `json:"tag_labels,omitempty"`
+}
+
+// statsdSink pushes the metrics to a statsd server with specified interval
+type statsdSink struct {
+	config     *statsdConfig
+	staticTags []string
+	tagLabels  map[string]bool
+	// stream is true if the network is tcp
+	stream bool
+
+	mutex sync.Mutex
+	// counters last flushed value, statsd counters are delta values
+	counters map[string]int64
+}
+
+// ~ MetricsSink
+func (s *statsdSink) Flush(writer io.Writer, ms []types.Metrics) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	buf := &bytes.Buffer{}
+	for _, m := range ms {
+		labelKeys, labelVals := m.SortedLabels()
+		if sink.IsExclusionLabels(labelKeys) {
+			continue
+		}
+		prefix, tags := s.makePrefixAndTags(m.Type(), labelKeys, labelVals)
+
+		m.Each(func(key string, i interface{}) {
+			if sink.IsExclusionKeys(key) {
+				return
+			}
+			name, lineTags := prefix+flattenKey(key), tags
+			// in dogstatsd mode, the tags in the flat key are extracted
+			if s.config.DogStatsD {
+				var tagKeys, tagVals []string
+				key, tagKeys, tagVals = metrics.ExtractTags(key)
+				name = prefix + flattenKey(key)
+				lineTags = appendTags(tags, tagKeys, tagVals)
+			}
+			switch metric := i.(type) {
+			case gometrics.Counter:
+				count := metric.Count()
+				// the same name may have different tags in dogstatsd mode
+				counterKey := name + "|" + lineTags
+				delta := count - s.counters[counterKey]
+				s.counters[counterKey] = count
+				s.writeLine(writer, buf, name, strconv.FormatInt(delta, 10), "c", lineTags)
+			case gometrics.Gauge:
+				s.writeLine(writer, buf, name, strconv.FormatInt(metric.Value(), 10), "g", lineTags)
+			case gometrics.Histogram:
+				h := metric.Snapshot()
+				s.writeLine(writer, buf, name+".min", strconv.FormatInt(h.Min(), 10), "g", lineTags)
+				s.writeLine(writer, buf, name+".max", strconv.FormatInt(h.Max(), 10), "g", lineTags)
+				s.writeLine(writer, buf, name+".mean", strconv.FormatFloat(h.Mean(), 'f', 2, 64), "g", lineTags)
+				ps := h.Percentiles(percents)
+				for idx, p := range percents {
+					s.writeLine(writer, buf, name+".p"+strconv.Itoa(int(p*100)), strconv.FormatFloat(ps[idx], 'f', 2, 64), "g", lineTags)
+				}
+			}
+		})
+	}
+	if buf.Len() > 0 {
+		s.writePacket(writer, buf)
+	}
+}
+
+// writePacket writes the buffered lines into writer as a packet.
+// On a stream network, every packet is terminated with a newline, so the last line of a packet
+// is not concatenated with the first line of the next packet.
+func (s *statsdSink) writePacket(writer io.Writer, buf *bytes.Buffer) {
+	if s.stream {
+		buf.WriteByte('\n')
+	}
+	writer.Write(buf.Bytes())
+}
+
+// writeLine appends a statsd line into buf, the buf is written into writer when the packet is full
+func (s *statsdSink) writeLine(writer io.Writer, buf *bytes.Buffer, name, value, typ, tags string) {
+	line := name + ":" + value + "|" + typ
+	if tags != "" {
+		line += "|#" + tags
+	}
+	if buf.Len() > 0 && buf.Len()+len(line)+1 > s.config.MaxPacketSize {
+		s.writePacket(writer, buf)
+		buf.Reset()
+	}
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(line)
+}
+
+// makePrefixAndTags returns the metric name prefix and the tags string.
+// input: typ=upstream, keys=[cluster,host] values=[app1,10.0.0.1:80]
+// statsd output: prefix.upstream.cluster.app1.host.10_0_0_1_80.
+// dogstatsd output: prefix.upstream. and cluster:app1,host:10.0.0.1:80
+func (s *statsdSink) makePrefixAndTags(typ string, keys, values []string) (string, string) {
+	prefix := typ + "."
+	if s.config.Prefix != "" {
+		prefix = s.config.Prefix + "." + prefix
+	}
+	tags := make([]string, 0, len(keys)+len(s.staticTags))
+	for i := range keys {
+		if s.config.DogStatsD && (len(s.tagLabels) == 0 || s.tagLabels[keys[i]]) {
+			tags = append(tags, keys[i]+":"+values[i])
+		} else {
+			prefix += flattenKey(keys[i]) + "." + flattenKey(values[i]) + "."
+		}
+	}
+	tags = append(tags, s.staticTags...)
+	return prefix, strings.Join(tags, ",")
+}
+
+// appendTags appends the extracted tags into the tags string
+func appendTags(tags string, keys, values []string) string {
+	for i := range keys {
+		if tags != "" {
+			tags += ","
+		}
+		tags += keys[i] + ":" + values[i]
+	}
+	return tags
+}
+
+func (s *statsdSink) start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(s.config.FlushInterval.Duration)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.flushOnce()
+		}
+	}, func(r interface{}) {
+		s.start()
+	})
+}
+
+func (s *statsdSink) flushOnce() {
+	conn, err := net.DialTimeout(s.config.Network, s.config.Address, time.Second)
+	if err != nil {
+		log.DefaultLogger.Errorf("[metrics] [statsd] dial %s failed: %v", s.config.Address, err)
+		return
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	s.Flush(conn, metrics.GetAll())
+}
+
+// NewStatsdSink returns a metrics sink that pushes metrics to a statsd server
+func NewStatsdSink(config *statsdConfig) types.MetricsSink {
+	s := &statsdSink{
+		config:    config,
+		tagLabels: make(map[string]bool, len(config.TagLabels)),
+		counters:  make(map[string]int64),
+		stream:    strings.HasPrefix(config.Network, "tcp"),
+	}
+	for _, l := range config.TagLabels {
+		s.tagLabels[l] = true
+	}
+	if config.DogStatsD {
+		for k, v := range config.Tags {
+			s.staticTags = append(s.staticTags, k+":"+v)
+		}
+		sort.Strings(s.staticTags)
+	}
+	return s
+}
+
+// factory
+func builder(cfg map[string]interface{}) (types.MetricsSink, error) {
+	// parse config
+	statsdCfg := &statsdConfig{}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing statsd sink error, err: %v, cfg: %v", err, cfg)
+	}
+	if err := json.Unmarshal(data, statsdCfg); err != nil {
+		return nil, fmt.Errorf("parsing statsd sink error, err: %v, cfg: %v", err, cfg)
+	}
+
+	if statsdCfg.Address == "" {
+		return nil, errors.New("statsd sink's address is not specified")
+	}
+	if statsdCfg.Network == "" {
+		statsdCfg.Network = defaultNetwork
+	}
+	if statsdCfg.FlushInterval.Duration <= 0 {
+		statsdCfg.FlushInterval.Duration = defaultFlushInterval
+	}
+	if statsdCfg.MaxPacketSize <= 0 {
+		statsdCfg.MaxPacketSize = defaultMaxPacketSize
+	}
+
+	s := NewStatsdSink(statsdCfg).(*statsdSink)
+	s.start()
+	return s, nil
+}
+
+// name regex [a-zA-Z0-9_.]
+func flattenKey(key string) string {
+	key = strings.Replace(key, " ", "_", -1)
+	key = strings.Replace(key, ".", "_", -1)
+	key = strings.Replace(key, ":", "_", -1)
+	key = strings.Replace(key, "|", "_", -1)
+	key = strings.Replace(key, "@", "_", -1)
+	key = strings.Replace(key, "#", "_", -1)
+	return key
+}
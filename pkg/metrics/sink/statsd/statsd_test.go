@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statsd
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+func flushLines(s types.MetricsSink) []string {
+	buf := &bytes.Buffer{}
+	s.Flush(buf, metrics.GetAll())
+	return strings.Split(buf.String(), "\n")
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStatsdFlush(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("upstream", map[string]string{"cluster": "app1", "host": "10.0.0.1:80"})
+	m.Counter("request_total").Inc(3)
+	m.Gauge("connection_active").Update(2)
+
+	s := NewStatsdSink(&statsdConfig{
+		Prefix:        "mosn",
+		MaxPacketSize: defaultMaxPacketSize,
+	})
+	lines := flushLines(s)
+	if !containsLine(lines, "mosn.upstream.cluster.app1.host.10_0_0_1_80.request_total:3|c") ||
+		!containsLine(lines, "mosn.upstream.cluster.app1.host.10_0_0_1_80.connection_active:2|g") {
+		t.Fatalf("unexpected statsd output: %v", lines)
+	}
+	// counters are sent as delta
+	m.Counter("request_total").Inc(2)
+	lines = flushLines(s)
+	if !containsLine(lines, "mosn.upstream.cluster.app1.host.10_0_0_1_80.request_total:2|c") {
+		t.Fatalf("unexpected statsd output: %v", lines)
+	}
+}
+
+func TestDogStatsdFlush(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("upstream", map[string]string{"cluster": "app1", "host": "10.0.0.1:80"})
+	m.Counter("request_total").Inc(1)
+
+	// extract all labels
+	s := NewStatsdSink(&statsdConfig{
+		DogStatsD:     true,
+		Tags:          map[string]string{"env": "test"},
+		MaxPacketSize: defaultMaxPacketSize,
+	})
+	lines := flushLines(s)
+	if !containsLine(lines, "upstream.request_total:1|c|#cluster:app1,host:10.0.0.1:80,env:test") {
+		t.Fatalf("unexpected dogstatsd output: %v", lines)
+	}
+	// extract cluster label only
+	s = NewStatsdSink(&statsdConfig{
+		DogStatsD:     true,
+		TagLabels:     []string{"cluster"},
+		MaxPacketSize: defaultMaxPacketSize,
+	})
+	lines = flushLines(s)
+	if !containsLine(lines, "upstream.host.10_0_0_1_80.request_total:1|c|#cluster:app1") {
+		t.Fatalf("unexpected dogstatsd output: %v", lines)
+	}
+}
+
+func TestStatsdPacketSize(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("test", map[string]string{"k": "v"})
+	for _, key := range []string{"a", "b", "c", "d"} {
+		m.Counter(key).Inc(1)
+	}
+	w := &packetWriter{}
+	s := NewStatsdSink(&statsdConfig{MaxPacketSize: 20})
+	s.Flush(w, metrics.GetAll())
+	if len(w.packets) != 4 {
+		t.Fatalf("expected 4 packets, but got %d: %v", len(w.packets), w.packets)
+	}
+	for _, p := range w.packets {
+		if len(p) > 20 {
+			t.Fatalf("packet size exceeded: %s", p)
+		}
+	}
+}
+
+func TestStatsdTCPPacket(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("test", map[string]string{"k": "v"})
+	for _, key := range []string{"a", "b", "c", "d"} {
+		m.Counter(key).Inc(1)
+	}
+	w := &packetWriter{}
+	s := NewStatsdSink(&statsdConfig{Network: "tcp", MaxPacketSize: 20})
+	s.Flush(w, metrics.GetAll())
+	if len(w.packets) != 4 {
+		t.Fatalf("expected 4 packets, but got %d: %v", len(w.packets), w.packets)
+	}
+	// every packet is terminated with a newline on tcp
+	for _, p := range w.packets {
+		if !strings.HasSuffix(p, "\n") || strings.Count(p, "\n") != 1 {
+			t.Fatalf("unexpected tcp packet: %q", p)
+		}
+	}
+	lines := strings.Split(strings.Join(w.packets, ""), "\n")
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if !containsLine(lines, "test.k.v."+key+":1|c") {
+			t.Fatalf("unexpected statsd output: %v", lines)
+		}
+	}
+}
+
+type packetWriter struct {
+	packets []string
+}
+
+func (w *packetWriter) Write(b []byte) (int, error) {
+	w.packets = append(w.packets, string(b))
+	return len(b), nil
+}
+
+func TestStatsdBuilder(t *testing.T) {
+	metrics.ResetAll()
+	m, _ := metrics.NewMetrics("test", map[string]string{"k": "v"})
+	m.Gauge("g").Update(1)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := builder(map[string]interface{}{}); err == nil {
+		t.Fatal("builder without address should be failed")
+	}
+	if _, err := builder(map[string]interface{}{
+		"address":        conn.LocalAddr().String(),
+		"flush_interval": "100ms",
+	}); err != nil {
+		t.Fatalf("create statsd sink failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	b := make([]byte, defaultMaxPacketSize)
+	n, _, err := conn.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("read statsd packet failed: %v", err)
+	}
+	if string(b[:n]) != "test.k.v.g:1|g" {
+		t.Fatalf("unexpected packet: %s", string(b[:n]))
+	}
+}
@@ -172,7 +172,7 @@ func (s *metrics) Histogram(key string) gometrics.Histogram {
 	}
 
 	// TODO: notice the histogram only keeps 100 values as we set
-	return s.registry.GetOrRegister(key, func() gometrics.Histogram { return gometrics.NewHistogram(gometrics.NewUniformSample(100)) }).(gometrics.Histogram)
+	return s.registry.GetOrRegister(key, func() gometrics.Histogram { return NewHistogram(key) }).(gometrics.Histogram)
 }
 
 func (s *metrics) Each(f func(string, interface{})) {
@@ -208,6 +208,8 @@ func ResetAll() {
 	}
 	defaultStore.metrics = make(map[string]types.Metrics, 100)
 	defaultStore.matcher = defaultMatcher
+
+	resetDetailStats()
 }
 
 func fullName(typ string, labels map[string]string) (fullName string, keys, values []string) {
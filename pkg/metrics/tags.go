@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// TagExtractor extracts a tag from a flat stat name
+type TagExtractor interface {
+	// Name returns the tag name
+	Name() string
+	// Extract returns the tag value and the stat name with the tag removed
+	Extract(name string) (value string, remain string, ok bool)
+}
+
+// regexTagExtractor extracts a tag by a regex.
+// the regex must contain at least one capture group, the first capture group is removed from the stat name,
+// the value of the second capture group (or the first if there is only one) is used as the tag value.
+// for example, regex ^response_flag(_(.+))$ extracts response_flag_UH into
+// name response_flag and tag value UH.
+type regexTagExtractor struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// NewTagExtractor creates a regex tag extractor
+func NewTagExtractor(tagName, regex string) (TagExtractor, error) {
+	if tagName == "" {
+		return nil, fmt.Errorf("tag name is empty")
+	}
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag regex %s: %v", regex, err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("tag regex %s has no capture group", regex)
+	}
+	return &regexTagExtractor{
+		name:  tagName,
+		regex: re,
+	}, nil
+}
+
+func (e *regexTagExtractor) Name() string {
+	return e.name
+}
+
+func (e *regexTagExtractor) Extract(name string) (string, string, bool) {
+	idx := e.regex.FindStringSubmatchIndex(name)
+	// the first capture group is not matched
+	if idx == nil || idx[2] < 0 {
+		return "", name, false
+	}
+	value := name[idx[2]:idx[3]]
+	if len(idx) > 4 && idx[4] >= 0 {
+		value = name[idx[4]:idx[5]]
+	}
+	return value, name[:idx[2]] + name[idx[3]:], true
+}
+
+// default tag extraction rules, they are applied to the metrics keys,
+// the cluster, listener and so on are the metrics labels already.
+var defaultTagRules = []struct {
+	name  string
+	regex string
+}{
+	// meta: go_version:go1.12, version:1.0.0, listener_address:0.0.0.0:80
+	{"go_version", `^go_version(:(.+))$`},
+	{"version", `^version(:(.+))$`},
+	{"listener_address", `^listener_address(:(.+))$`},
+	// downstream: response_flag_UH
+	{"response_flag", `^response_flag(_(.+))$`},
+	// handshake: handshake_failed_timeout
+	{"cause", `^handshake_failed(_(.+))$`},
+	// upstream slo window: response_2xx, response_reset
+	{"response_code_class", `^response(_([1-9]xx|reset))$`},
+}
+
+// DefaultTagExtractors returns the default tag extractors,
+// they are not installed unless configured, no tag is extracted by default.
+func DefaultTagExtractors() []TagExtractor {
+	extractors := make([]TagExtractor, 0, len(defaultTagRules))
+	for _, rule := range defaultTagRules {
+		e, _ := NewTagExtractor(rule.name, rule.regex)
+		extractors = append(extractors, e)
+	}
+	return extractors
+}
+
+// tagProducer applies the tag extractors and the fixed tags to a stat name
+type tagProducer struct {
+	extractors []TagExtractor
+	fixedKeys  []string
+	fixedVals  []string
+	// cache the extracted result, the stat names are limited
+	cache sync.Map
+}
+
+type extractedName struct {
+	name   string
+	keys   []string
+	values []string
+}
+
+var defaultTagProducer atomic.Value // *tagProducer
+
+func init() {
+	defaultTagProducer.Store(&tagProducer{})
+}
+
+// SetTagExtractors sets the tag extractors and the fixed tags that added to all metrics
+func SetTagExtractors(extractors []TagExtractor, fixedTags map[string]string) {
+	keys, values := sortedLabels(fixedTags)
+	defaultTagProducer.Store(&tagProducer{
+		extractors: extractors,
+		fixedKeys:  keys,
+		fixedVals:  values,
+	})
+}
+
+// ExtractTags decomposes a flat stat name into a name and tags.
+// the tags contains the extracted tags and the fixed tags, sorted by the tag name.
+func ExtractTags(name string) (string, []string, []string) {
+	p := defaultTagProducer.Load().(*tagProducer)
+	if v, ok := p.cache.Load(name); ok {
+		e := v.(*extractedName)
+		return e.name, e.keys, e.values
+	}
+	e := p.extract(name)
+	p.cache.Store(name, e)
+	return e.name, e.keys, e.values
+}
+
+func (p *tagProducer) extract(name string) *extractedName {
+	tags := make(map[string]string, len(p.extractors)+len(p.fixedKeys))
+	for _, extractor := range p.extractors {
+		if value, remain, ok := extractor.Extract(name); ok {
+			tags[extractor.Name()] = value
+			name = remain
+		}
+	}
+	for i := range p.fixedKeys {
+		tags[p.fixedKeys[i]] = p.fixedVals[i]
+	}
+	e := &extractedName{name: name}
+	if len(tags) > 0 {
+		e.keys, e.values = sortedLabels(tags)
+	}
+	return e
+}
+
+// MergeTags merges two sorted tags, the tag in the first tags is kept if the tag name exists in both.
+func MergeTags(keys1, values1, keys2, values2 []string) ([]string, []string) {
+	if len(keys2) == 0 {
+		return keys1, values1
+	}
+	if len(keys1) == 0 {
+		return keys2, values2
+	}
+	tags := make(map[string]string, len(keys1)+len(keys2))
+	for i := range keys2 {
+		tags[keys2[i]] = values2[i]
+	}
+	for i := range keys1 {
+		tags[keys1[i]] = values1[i]
+	}
+	return sortedLabels(tags)
+}
@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagExtractor(t *testing.T) {
+	if _, err := NewTagExtractor("no_group", `^cluster\.`); err == nil {
+		t.Fatal("regex without capture group should be invalid")
+	}
+	if _, err := NewTagExtractor("", `^cluster\.((.+?)\.)`); err == nil {
+		t.Fatal("empty tag name should be invalid")
+	}
+	e, err := NewTagExtractor("cluster_name", `^cluster\.((.+?)\.)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, remain, ok := e.Extract("cluster.foo.upstream_rq_timeout")
+	if !ok || value != "foo" || remain != "cluster.upstream_rq_timeout" {
+		t.Fatalf("unexpected extract result, value: %s, remain: %s, ok: %v", value, remain, ok)
+	}
+	if _, remain, ok := e.Extract("listener.foo.request_total"); ok || remain != "listener.foo.request_total" {
+		t.Fatal("name not matched should not be extracted")
+	}
+	// only one capture group
+	e, _ = NewTagExtractor("code", `\.(\d{3})$`)
+	if value, remain, ok := e.Extract("response.200"); !ok || value != "200" || remain != "response." {
+		t.Fatalf("unexpected extract result, value: %s, remain: %s, ok: %v", value, remain, ok)
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	// no tag is extracted by default
+	for _, input := range []string{"go_version:go1.12", "response_flag_UH"} {
+		if name, keys, _ := ExtractTags(input); name != input || len(keys) != 0 {
+			t.Errorf("extract %s by default, got name: %s, keys: %v", input, name, keys)
+		}
+	}
+	SetTagExtractors(DefaultTagExtractors(), nil)
+	defer SetTagExtractors(nil, nil)
+
+	testCases := []struct {
+		input  string
+		name   string
+		keys   []string
+		values []string
+	}{
+		{"request_total", "request_total", nil, nil},
+		{"go_version:go1.12", "go_version", []string{"go_version"}, []string{"go1.12"}},
+		{"listener_address:0.0.0.0:80", "listener_address", []string{"listener_address"}, []string{"0.0.0.0:80"}},
+		{"response_flag_UH", "response_flag", []string{"response_flag"}, []string{"UH"}},
+		{"handshake_failed_timeout", "handshake_failed", []string{"cause"}, []string{"timeout"}},
+		{"response_5xx", "response", []string{"response_code_class"}, []string{"5xx"}},
+		{"response_success", "response_success", nil, nil},
+	}
+	for _, tc := range testCases {
+		name, keys, values := ExtractTags(tc.input)
+		if name != tc.name || !reflect.DeepEqual(keys, tc.keys) || !reflect.DeepEqual(values, tc.values) {
+			t.Errorf("extract %s failed, got name: %s, keys: %v, values: %v", tc.input, name, keys, values)
+		}
+	}
+	// fixed tags
+	SetTagExtractors(nil, map[string]string{"zone": "gz00b"})
+	name, keys, values := ExtractTags("go_version:go1.12")
+	if name != "go_version:go1.12" || !reflect.DeepEqual(keys, []string{"zone"}) || !reflect.DeepEqual(values, []string{"gz00b"}) {
+		t.Errorf("fixed tags failed, got name: %s, keys: %v, values: %v", name, keys, values)
+	}
+}
+
+func TestExtractTagsMetrics(t *testing.T) {
+	ResetAll()
+	defer ResetAll()
+	SetTagExtractors(DefaultTagExtractors(), nil)
+	defer SetTagExtractors(nil, nil)
+
+	proxy := NewListenerStats("listener1")
+	proxy.Counter(DownstreamRequestTotal).Inc(1)
+	proxy.Counter(DownstreamResponseFlagPrefix + "UH").Inc(1)
+	cluster := NewClusterStats("cluster1")
+	cluster.Counter(UpstreamRequestTotal).Inc(1)
+	cluster.Counter(UpstreamResponseSuccess).Inc(1)
+	slo := NewSLOStats("cluster1", "5m")
+	slo.Gauge(UpstreamSLOResponsePrefix + "5xx").Update(1)
+	slo.Gauge(UpstreamSLOResponsePrefix + "reset").Update(1)
+	slo.Gauge(UpstreamSLOSuccessRate).Update(9990)
+	RecordHandshake("tls", "server", 0, "timeout")
+	FlushMosnMetrics = true
+	defer func() { FlushMosnMetrics = false }()
+	SetVersion("1.0.0")
+
+	type result struct {
+		name string
+		tags map[string]string
+	}
+	got := map[string]result{}
+	for _, m := range GetAll() {
+		labelKeys, labelVals := m.SortedLabels()
+		m.Each(func(key string, i interface{}) {
+			name, keys, values := ExtractTags(key)
+			keys, values = MergeTags(labelKeys, labelVals, keys, values)
+			tags := make(map[string]string, len(keys))
+			for i := range keys {
+				tags[keys[i]] = values[i]
+			}
+			got[m.Type()+"/"+key] = result{name, tags}
+		})
+	}
+	testCases := []struct {
+		key  string
+		name string
+		tags map[string]string
+	}{
+		{"downstream/request_total", "request_total", map[string]string{"listener": "listener1"}},
+		{"downstream/response_flag_UH", "response_flag", map[string]string{"listener": "listener1", "response_flag": "UH"}},
+		{"upstream/request_total", "request_total", map[string]string{"cluster": "cluster1"}},
+		{"upstream/response_success", "response_success", map[string]string{"cluster": "cluster1"}},
+		{"upstream/response_5xx", "response", map[string]string{"cluster": "cluster1", "slo_window": "5m", "response_code_class": "5xx"}},
+		{"upstream/response_reset", "response", map[string]string{"cluster": "cluster1", "slo_window": "5m", "response_code_class": "reset"}},
+		{"upstream/success_rate", "success_rate", map[string]string{"cluster": "cluster1", "slo_window": "5m"}},
+		{"handshake/handshake_failed_timeout", "handshake_failed", map[string]string{"protocol": "tls", "side": "server", "cause": "timeout"}},
+		{"meta/version:1.0.0", "version", map[string]string{"mosn": "info", "version": "1.0.0"}},
+	}
+	for _, tc := range testCases {
+		r, ok := got[tc.key]
+		if !ok {
+			t.Errorf("metrics %s not found", tc.key)
+			continue
+		}
+		if r.name != tc.name || !reflect.DeepEqual(r.tags, tc.tags) {
+			t.Errorf("extract %s failed, got name: %s, tags: %v", tc.key, r.name, r.tags)
+		}
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	keys, values := MergeTags([]string{"cluster", "host"}, []string{"c1", "h1"}, []string{"cluster", "zone"}, []string{"c2", "z1"})
+	if !reflect.DeepEqual(keys, []string{"cluster", "host", "zone"}) || !reflect.DeepEqual(values, []string{"c1", "h1", "z1"}) {
+		t.Errorf("merge tags failed, got keys: %v, values: %v", keys, values)
+	}
+}
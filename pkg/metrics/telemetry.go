@@ -0,0 +1,41 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"strconv"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// TelemetryType represents the metrics type of the telemetry workers
+const TelemetryType = "telemetry"
+
+// metrics key in telemetry
+const (
+	// TelemetryQueueDepth is the number of the finished requests waiting for a telemetry worker
+	TelemetryQueueDepth = "queue_depth"
+	// TelemetryDropped is the number of the finished requests whose access logs are dropped as the queue is full
+	TelemetryDropped = "dropped"
+)
+
+// NewTelemetryStats returns a stats with namespace prefix telemetry and the worker index
+func NewTelemetryStats(worker int) types.Metrics {
+	metrics, _ := NewMetrics(TelemetryType, map[string]string{"worker": strconv.Itoa(worker)})
+	return metrics
+}
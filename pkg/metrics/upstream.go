@@ -55,10 +55,76 @@ const (
 	UpstreamRequestRetryOverflow = "request_retry_overflow"
 	UpstreamLBSubSetsFallBack    = "lb_subsets_fallback"
 	UpstreamLBSubsetsCreated     = "lb_subsets_created"
+	UpstreamRequestDegraded      = "request_degraded"
+	UpstreamHostBackoff          = "host_backoff"
+	UpstreamTenantPoolOverflow   = "tenant_pool_overflow"
 	UpstreamBytesReadTotal       = "connection_bytes_read_total"
 	UpstreamBytesReadBuffered    = "connection_bytes_read_buffered"
 	UpstreamBytesWriteTotal      = "connection_bytes_write"
 	UpstreamBytesWriteBuffered   = "connection_bytes_write_buffered"
+	UpstreamDynamicHostCreated   = "dynamic_host_created"
+	UpstreamDynamicHostEvicted   = "dynamic_host_evicted"
+	UpstreamDynamicHostFailure   = "dynamic_host_failure"
+	UpstreamHostDraining         = "host_draining"
+	UpstreamHostDrained          = "host_drained"
+	UpstreamHostDrainTimeout     = "host_drain_timeout"
+	// UpstreamRequestFailover counts the requests of the primary cluster that are failed over,
+	// and UpstreamRequestFailoverReceived counts the requests that the failover cluster receives
+	UpstreamRequestFailover         = "request_failover"
+	UpstreamRequestFailoverReceived = "request_failover_received"
+	// UpstreamLaneRequestTotal counts the colored requests routed to the hosts in their lanes,
+	// and UpstreamLaneRequestFallback counts the colored requests fall back to the base lane
+	UpstreamLaneRequestTotal    = "lane_request_total"
+	UpstreamLaneRequestFallback = "lane_request_fallback"
+	// UpstreamPoolCreated and UpstreamPoolClosed count the connection pools created and removed
+	// by the cluster manager, a high churn means the hosts or their tls states change frequently
+	UpstreamPoolCreated = "pool_created"
+	UpstreamPoolClosed  = "pool_closed"
+)
+
+//  key in cluster/tenant
+const (
+	UpstreamTenantPoolCreated  = "pool_created"
+	UpstreamTenantRequestTotal = "request_total"
+)
+
+//  key in cluster/cell
+const (
+	// UpstreamCellRequestTotal counts the requests routed to the hosts in the cell
+	UpstreamCellRequestTotal = "request_total"
+	// UpstreamCellRequestCrossCell counts the requests of the cell that are routed to the other cells
+	UpstreamCellRequestCrossCell = "request_cross_cell"
+	// UpstreamCellRequestNoHost counts the requests of the cell that find no healthy host
+	UpstreamCellRequestNoHost = "request_no_host"
+)
+
+//  key in cluster/slo window, the rates are gauges of integers
+const (
+	// UpstreamSLOSuccessRate is the success rate in basis points, 9990 means 99.9%
+	UpstreamSLOSuccessRate = "success_rate"
+	// UpstreamSLOBurnRate is the burn rate multiplied by 100
+	UpstreamSLOBurnRate = "burn_rate"
+	// UpstreamSLOResponsePrefix is the prefix of the response counts by code class in the window,
+	// followed by the class such as "2xx", and "reset" for the requests get no response
+	UpstreamSLOResponsePrefix = "response_"
+)
+
+//  key in cluster/pool mode
+const (
+	UpstreamPoolTimeoutClose = "timeout_close"
+	UpstreamPoolBindTotal    = "bind_total"
+	UpstreamPoolBindClose    = "bind_close"
+)
+
+//  key in cluster/host/protocol connection pool, the gauges are updated periodically
+const (
+	UpstreamPoolConnections       = "pool_connections"
+	UpstreamPoolConnectionsActive = "pool_connections_active"
+	UpstreamPoolConnectionsIdle   = "pool_connections_idle"
+	UpstreamPoolActiveStreams     = "pool_active_streams"
+	// UpstreamPoolPendingStreams is the number of the requests waiting for the pool connected
+	UpstreamPoolPendingStreams = "pool_pending_streams"
+	UpstreamPoolConnectFailed  = "pool_connect_failed"
 )
 
 // NewHostStats returns a stats that namespace contains cluster and host address
@@ -67,6 +133,36 @@ func NewHostStats(clusterName string, addr string) types.Metrics {
 	return metrics
 }
 
+// NewTenantStats returns a stats that namespace contains cluster and downstream tenant
+func NewTenantStats(clusterName string, tenant string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "tenant": tenant})
+	return metrics
+}
+
+// NewSLOStats returns a stats that namespace contains cluster and slo window
+func NewSLOStats(clusterName string, window string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "slo_window": window})
+	return metrics
+}
+
+// NewCellStats returns a stats that namespace contains cluster and cell
+func NewCellStats(clusterName string, cell string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "cell": cell})
+	return metrics
+}
+
+// NewConnPoolStats returns a stats that namespace contains cluster and connection pool mode
+func NewConnPoolStats(clusterName string, mode string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "pool_mode": mode})
+	return metrics
+}
+
+// NewPoolStats returns a stats that namespace contains cluster, host address and the protocol of the connection pool
+func NewPoolStats(clusterName string, addr string, protocol string) types.Metrics {
+	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName, "host": addr, "protocol": protocol})
+	return metrics
+}
+
 // NewClusterStats returns a stats with namespace prefix cluster
 func NewClusterStats(clusterName string) types.Metrics {
 	metrics, _ := NewMetrics(UpstreamType, map[string]string{"cluster": clusterName})
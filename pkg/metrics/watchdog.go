@@ -0,0 +1,37 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metrics
+
+import (
+	"mosn.io/mosn/pkg/types"
+)
+
+// WatchdogType represents leak watchdog metrics type
+const WatchdogType = "watchdog"
+
+// leak watchdog metrics key, the sampled value of a resource is
+// recorded in a gauge named by the resource
+const (
+	WatchdogLeakSuspected = "leak_suspected"
+	WatchdogHeapDump      = "heap_dump"
+)
+
+// NewWatchdogStats returns a stats with namespace prefix watchdog resource
+func NewWatchdogStats(resource string) types.Metrics {
+	metrics, _ := NewMetrics(WatchdogType, map[string]string{"resource": resource})
+	return metrics
+}
@@ -0,0 +1,39 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"strconv"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// WorkerType represents the io worker metrics type
+const WorkerType = "worker"
+
+// worker metrics key in event loop
+const (
+	WorkerConnections = "connections"
+	WorkerEvents      = "events"
+)
+
+// NewEventLoopStats returns a stats with namespace prefix worker and the event loop
+func NewEventLoopStats(id int) types.Metrics {
+	metrics, _ := NewMetrics(WorkerType, map[string]string{"event_loop": strconv.Itoa(id)})
+	return metrics
+}
@@ -146,6 +146,10 @@ type MServerConn struct {
 	serverConn
 	mu sync.Mutex
 
+	// maxPushes is the max number of the streams pushed on the connection, push is disabled if it is zero
+	maxPushes uint32
+	pushes    uint32
+
 	Framer *MFramer
 	api.Connection
 }
@@ -167,7 +171,8 @@ func NewServerConn(conn api.Connection) *MServerConn {
 	sc.serverConn.maxFrameSize = initialMaxFrameSize
 	sc.serverConn.headerTableSize = initialHeaderTableSize
 
-	sc.serverConn.pushEnabled = false
+	// the peer allows push until it sends SETTINGS_ENABLE_PUSH with 0
+	sc.serverConn.pushEnabled = true
 
 	// init MFramer
 	fr := new(MFramer)
@@ -204,6 +209,115 @@ func (sc *MServerConn) Init() error {
 	return nil
 }
 
+// SetMaxPushes sets the max number of the streams pushed on the connection, zero disables the push.
+func (sc *MServerConn) SetMaxPushes(n uint32) {
+	sc.mu.Lock()
+	sc.maxPushes = n
+	sc.mu.Unlock()
+}
+
+// Push sends a PUSH_PROMISE frame on the parent stream for the GET request of the path,
+// and returns the promised stream that the pushed response should be sent on.
+// The path is the request uri of the pushed resource, which has the same authority with the parent request.
+func (sc *MServerConn) Push(parent *MStream, scheme, path string, header http.Header) (*MStream, error) {
+	// http://tools.ietf.org/html/rfc7540#section-6.6.
+	// PUSH_PROMISE frames MUST only be sent on a peer-initiated stream that
+	// is in either the "open" or "half-closed (remote)" state.
+	if parent.isPushed() {
+		return nil, ErrRecursivePush
+	}
+	if parent.state != stateOpen && parent.state != stateHalfClosedRemote {
+		return nil, errStreamClosed
+	}
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil, err
+	}
+	authority := parent.Request.Host
+
+	sc.mu.Lock()
+	if !sc.pushEnabled || sc.maxPushes == 0 {
+		sc.mu.Unlock()
+		return nil, http.ErrNotSupported
+	}
+	// http://tools.ietf.org/html/rfc7540#section-6.5.2.
+	if sc.pushes >= sc.maxPushes || sc.curPushedStreams+1 > sc.clientMaxStreams || sc.maxPushPromiseID+2 >= 1<<31 {
+		sc.mu.Unlock()
+		return nil, ErrPushLimitReached
+	}
+	// http://tools.ietf.org/html/rfc7540#section-5.1.1.
+	// Streams initiated by the server MUST use even-numbered identifiers,
+	// the promised ids are allocated and written under the lock to keep them increasing.
+	sc.maxPushPromiseID += 2
+	promisedID := sc.maxPushPromiseID
+	sc.pushes++
+	err = sc.writePushPromise(parent.id, promisedID, scheme, authority, path, header)
+	sc.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// the promised stream starts in "half closed (remote)" for simplicity, same as the http2 server
+	st := sc.newStream(promisedID, parent.id, stateHalfClosedRemote)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		RemoteAddr: sc.remoteAddrStr,
+		Header:     header,
+		RequestURI: path,
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		ProtoMinor: 0,
+		Host:       authority,
+	}
+
+	return &MStream{
+		stream:  st,
+		conn:    sc,
+		Request: req,
+	}, nil
+}
+
+// writePushPromise encodes the promised request headers and writes them, sc.mu must be held
+func (sc *MServerConn) writePushPromise(streamID, promisedID uint32, scheme, authority, path string, header http.Header) error {
+	enc, buf := sc.hpackEncoder, &sc.headerWriteBuf
+	buf.Reset()
+
+	encKV(enc, ":method", http.MethodGet)
+	encKV(enc, ":scheme", scheme)
+	encKV(enc, ":authority", authority)
+	encKV(enc, ":path", path)
+	encodeHeaders(enc, header, nil)
+
+	const maxFrameSize = 16384
+
+	headerBlock := buf.Bytes()
+	first := true
+	var err error
+	for len(headerBlock) > 0 {
+		frag := headerBlock
+		if len(frag) > maxFrameSize {
+			frag = frag[:maxFrameSize]
+		}
+		headerBlock = headerBlock[len(frag):]
+		if first {
+			err = sc.Framer.writePushPromise(PushPromiseParam{
+				StreamID:      streamID,
+				PromiseID:     promisedID,
+				BlockFragment: frag,
+				EndHeaders:    len(headerBlock) == 0,
+			})
+		} else {
+			err = sc.Framer.writeContinuation(streamID, len(headerBlock) == 0, frag)
+		}
+		first = false
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (sc *MServerConn) getStream(id uint32) *stream {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
@@ -1515,6 +1629,29 @@ func (fr *MFramer) sendData(streamID uint32, endStream bool, data []byte) error
 	return fr.endWrite(buf)
 }
 
+// writePushPromise writes PushPromise Frame
+func (fr *MFramer) writePushPromise(p PushPromiseParam) error {
+	if !validStreamID(p.StreamID) || !validStreamID(p.PromiseID) {
+		return errStreamID
+	}
+	var flags Flags
+	buf := buffer.NewIoBuffer(len(p.BlockFragment) + frameHeaderLen + 8)
+	if p.PadLength != 0 {
+		flags |= FlagPushPromisePadded
+	}
+	if p.EndHeaders {
+		flags |= FlagPushPromiseEndHeaders
+	}
+	fr.startWrite(buf, FramePushPromise, flags, p.StreamID)
+	if p.PadLength != 0 {
+		fr.writeByte(buf, p.PadLength)
+	}
+	fr.writeUint32(buf, p.PromiseID)
+	buf.Write(p.BlockFragment)
+	buf.Write(padZeros[:p.PadLength])
+	return fr.endWrite(buf)
+}
+
 // WriteContinuation writes Continuation Frame
 func (fr *MFramer) writeContinuation(streamID uint32, endHeaders bool, headerBlockFragment []byte) error {
 	if !validStreamID(streamID) {
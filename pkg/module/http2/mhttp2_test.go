@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"golang.org/x/net/http2/hpack"
+	"mosn.io/api"
+	"mosn.io/pkg/buffer"
+)
+
+type pushTestConn struct {
+	api.Connection
+	written bytes.Buffer
+}
+
+func (c *pushTestConn) Write(bufs ...buffer.IoBuffer) error {
+	for _, buf := range bufs {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+func newPushTestParent(sc *MServerConn) *MStream {
+	return &MStream{
+		stream:  sc.newStream(1, 0, stateHalfClosedRemote),
+		conn:    sc,
+		Request: &http.Request{Method: http.MethodGet, Host: "example.com"},
+	}
+}
+
+func TestMServerConnPush(t *testing.T) {
+	conn := &pushTestConn{}
+	sc := NewServerConn(conn)
+	sc.SetMaxPushes(1)
+	parent := newPushTestParent(sc)
+
+	header := http.Header{}
+	header.Set("User-Agent", "test")
+	ms, err := sc.Push(parent, "https", "/style.css?v=1", header)
+	if err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	if ms.ID() != 2 || ms.Request.Method != http.MethodGet || ms.Request.URL.Path != "/style.css" || ms.Request.Host != "example.com" {
+		t.Errorf("unexpected promised stream: id = %d, request = %+v", ms.ID(), ms.Request)
+	}
+	if !ms.isPushed() || sc.curPushedStreams != 1 {
+		t.Errorf("promised stream is not counted as pushed")
+	}
+
+	fr := NewFramer(nil, bytes.NewReader(conn.written.Bytes()))
+	f, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("read frame failed: %v", err)
+	}
+	pp, ok := f.(*PushPromiseFrame)
+	if !ok {
+		t.Fatalf("expected push promise frame, but got %v", f)
+	}
+	if pp.StreamID != 1 || pp.PromiseID != 2 || !pp.HeadersEnded() {
+		t.Errorf("unexpected push promise frame: %v", pp)
+	}
+	fields, err := hpack.NewDecoder(initialHeaderTableSize, nil).DecodeFull(pp.HeaderBlockFragment())
+	if err != nil {
+		t.Fatalf("decode push promise headers failed: %v", err)
+	}
+	got := map[string]string{}
+	for _, hf := range fields {
+		got[hf.Name] = hf.Value
+	}
+	expected := map[string]string{
+		":method":    "GET",
+		":scheme":    "https",
+		":authority": "example.com",
+		":path":      "/style.css?v=1",
+		"user-agent": "test",
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("push promise header %s expected %s, but got %s", k, v, got[k])
+		}
+	}
+
+	// the per-connection limit is reached
+	if _, err := sc.Push(parent, "https", "/app.js", nil); err != ErrPushLimitReached {
+		t.Errorf("expected push limit reached, but got %v", err)
+	}
+	// recursive push is not allowed
+	if _, err := sc.Push(ms, "https", "/app.js", nil); err != ErrRecursivePush {
+		t.Errorf("expected recursive push error, but got %v", err)
+	}
+}
+
+func TestMServerConnPushDisabled(t *testing.T) {
+	sc := NewServerConn(&pushTestConn{})
+	parent := newPushTestParent(sc)
+	// push is disabled by default
+	if _, err := sc.Push(parent, "http", "/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("expected push not supported, but got %v", err)
+	}
+	// the client disables push by SETTINGS_ENABLE_PUSH
+	sc.SetMaxPushes(10)
+	sc.pushEnabled = false
+	if _, err := sc.Push(parent, "http", "/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("expected push not supported, but got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	admin "mosn.io/mosn/pkg/admin/server"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/server"
+	"mosn.io/mosn/pkg/types"
+)
+
+// listenerManager adds, updates and removes the listeners at runtime by the admin api.
+// the connections created by the old filter chains are drained when a listener is updated or removed.
+type listenerManager struct {
+	applier configApplier
+	// authToken guards the changes of the listeners, the changes are disabled if it is empty
+	authToken string
+	// statuses returns the runtime state of the listeners
+	statuses func() []server.ListenerStatus
+}
+
+func newListenerManager(applier configApplier, authToken string) *listenerManager {
+	return &listenerManager{
+		applier:   applier,
+		authToken: authToken,
+		statuses: func() []server.ListenerStatus {
+			if adapter := server.GetListenerAdapterInstance(); adapter != nil {
+				return adapter.ListenerStatuses("")
+			}
+			return nil
+		},
+	}
+}
+
+// addOrUpdate validates the listener config, applies the routers in the listener and the listener
+func (m *listenerManager) addOrUpdate(ln v2.Listener) error {
+	if err := configmanager.ValidateListener(&ln); err != nil {
+		return err
+	}
+	for i := range ln.FilterChains {
+		if rc := configmanager.ParseRouterConfiguration(&ln.FilterChains[i]); rc.RouterConfigName != "" {
+			if err := m.applier.AddOrUpdateRouters(rc); err != nil {
+				return fmt.Errorf("update router %s failed: %v", rc.RouterConfigName, err)
+			}
+		}
+	}
+	return m.applier.AddOrUpdateListener(ln)
+}
+
+func (m *listenerManager) hasListener(name string) bool {
+	for _, st := range m.statuses() {
+		if st.Name == name && !st.Removed {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *listenerManager) token() string {
+	return m.authToken
+}
+
+// serveHTTP returns the listeners state with drain status,
+// a POST request adds or updates a listener, a DELETE request removes the listener in the query "name".
+// the POST and DELETE requests need the admin api auth token.
+func (m *listenerManager) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		buf, _ := json.Marshal(m.statuses())
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf)
+	case http.MethodPost:
+		admin.TokenAuth("add or update listener", m.token, m.serveAddOrUpdate)(w, req)
+	case http.MethodDelete:
+		admin.TokenAuth("delete listener", m.token, m.serveDelete)(w, req)
+	default:
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "listeners", req.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *listenerManager) serveAddOrUpdate(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ln := v2.Listener{}
+	if err := json.Unmarshal(body, &ln); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid listener config: %v", "listeners", err)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "invalid listener config: %v", err)
+		return
+	}
+	if err := m.addOrUpdate(ln); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: add or update listener %s failed: %v", "listeners", ln.Name, err)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "add or update listener %s failed: %v", ln.Name, err)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [listeners] listener %s added or updated by admin api", ln.Name)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *listenerManager) serveDelete(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("name")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "listener name is required")
+		return
+	}
+	if !m.hasListener(name) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "listener %s is not found", name)
+		return
+	}
+	if err := m.applier.DeleteListener(name); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: delete listener %s failed: %v", "listeners", name, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "delete listener %s failed: %v", name, err)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [listeners] listener %s removed by admin api", name)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mosn.io/mosn/pkg/server"
+)
+
+const testRuntimeListener = `{
+	"name": "runtime_listener",
+	"address": "127.0.0.1:34901",
+	"filter_chains": [{
+		"filters": [
+			{"type": "proxy", "config": {"downstream_protocol": "Http1", "upstream_protocol": "Http1", "router_config_name": "runtime_router"}},
+			{"type": "connection_manager", "config": {"router_config_name": "runtime_router", "virtual_hosts": [{"name": "vh", "domains": ["*"]}]}}
+		]
+	}]
+}`
+
+func TestListenerManager(t *testing.T) {
+	applier := newMockApplier()
+	m := newListenerManager(applier, "token")
+	m.statuses = func() []server.ListenerStatus {
+		statuses := []server.ListenerStatus{}
+		for name := range applier.listeners {
+			statuses = append(statuses, server.ListenerStatus{Name: name})
+		}
+		return statuses
+	}
+
+	// the changes need the auth token
+	rec := httptest.NewRecorder()
+	m.serveHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/listeners", strings.NewReader(testRuntimeListener)))
+	if rec.Code != http.StatusUnauthorized || len(applier.listeners) != 0 {
+		t.Fatalf("add listener without auth token should be unauthorized, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	newListenerManager(applier, "").serveHTTP(rec, authRequest(http.MethodDelete, "/api/v1/listeners?name=runtime_listener", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("changes should be disabled without auth token configured, got %d", rec.Code)
+	}
+
+	// add a listener with routers
+	rec = httptest.NewRecorder()
+	m.serveHTTP(rec, authRequest(http.MethodPost, "/api/v1/listeners", strings.NewReader(testRuntimeListener)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("add listener failed: %d %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := applier.listeners["runtime_listener"]; !ok {
+		t.Fatal("listener is not added")
+	}
+	if _, ok := applier.routers["runtime_router"]; !ok {
+		t.Fatal("router is not added")
+	}
+
+	// invalid listener
+	rec = httptest.NewRecorder()
+	m.serveHTTP(rec, authRequest(http.MethodPost, "/api/v1/listeners", strings.NewReader(`{"address": "127.0.0.1:34902"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("listener without name should be rejected, got %d", rec.Code)
+	}
+
+	// list listeners
+	rec = httptest.NewRecorder()
+	m.serveHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/listeners", nil))
+	var statuses []server.ListenerStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil || len(statuses) != 1 {
+		t.Fatalf("unexpected listener statuses: %s, %v", rec.Body.String(), err)
+	}
+
+	// remove listeners
+	rec = httptest.NewRecorder()
+	m.serveHTTP(rec, authRequest(http.MethodDelete, "/api/v1/listeners?name=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("remove unknown listener should be not found, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	m.serveHTTP(rec, authRequest(http.MethodDelete, "/api/v1/listeners?name=runtime_listener", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("remove listener failed: %d", rec.Code)
+	}
+	if _, ok := applier.listeners["runtime_listener"]; ok {
+		t.Fatal("listener is not removed")
+	}
+}
+
+func authRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Authorization", "Bearer token")
+	return req
+}
@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/memory"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/pkg/utils"
+)
+
+const defaultMemoryStatsInterval = 10 * time.Second
+
+// memoryStats updates the memory stats by the memory accounts periodically
+type memoryStats struct {
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// newMemoryStats enables the memory accounting, it should be called before any connection is created
+func newMemoryStats(cfg *v2.MemoryStatsConfig) *memoryStats {
+	memory.Enable()
+	m := &memoryStats{
+		interval: defaultMemoryStatsInterval,
+		stop:     make(chan struct{}),
+	}
+	if cfg.FlushInterval.Duration > 0 {
+		m.interval = cfg.FlushInterval.Duration
+	}
+	return m
+}
+
+func (m *memoryStats) Start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				metrics.FlushMemoryStats()
+			case <-m.stop:
+				return
+			}
+		}
+	}, nil)
+}
+
+func (m *memoryStats) Stop() {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+}
@@ -0,0 +1,453 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/event"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/server"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/utils"
+)
+
+const defaultReloadCheckInterval = 5 * time.Second
+
+// ReloadStatus is the state of the config hot reload, reported by the admin api
+type ReloadStatus struct {
+	ConfigPath string `json:"config_path"`
+	// Version increases when a config change is applied successfully
+	Version         uint64    `json:"version"`
+	LastReloadTime  time.Time `json:"last_reload_time,omitempty"`
+	LastSuccessTime time.Time `json:"last_success_time,omitempty"`
+	Success         bool      `json:"success"`
+	RolledBack      bool      `json:"rolled_back"`
+	// Changes are the changes applied in the last reload
+	Changes []string `json:"changes,omitempty"`
+	// Diagnostics are the errors in the last reload
+	Diagnostics []string `json:"diagnostics,omitempty"`
+}
+
+// configApplier applies the config changes to the running mosn
+type configApplier interface {
+	AddOrUpdateCluster(c v2.Cluster) error
+	RemoveCluster(name string) error
+	AddOrUpdateRouters(rc *v2.RouterConfiguration) error
+	AddOrUpdateListener(ln v2.Listener) error
+	DeleteListener(name string) error
+}
+
+// reloadAction is a config change, undo is called when the reload is rolled back
+type reloadAction struct {
+	desc string
+	do   func() error
+	undo func() error
+}
+
+// configReloader watches the config file, applies the changes when the file is changed.
+// if any change is failed to apply, all the applied changes are rolled back to the last good config.
+type configReloader struct {
+	path     string
+	interval time.Duration
+	applier  configApplier
+
+	mutex   sync.Mutex
+	current *v2.MOSNConfig // the last good config
+	// fingerprint is the fingerprint of the last good config files,
+	// failedFingerprint is the one of the last config files failed to apply
+	fingerprint       [md5.Size]byte
+	failedFingerprint [md5.Size]byte
+	status            ReloadStatus
+	stop              chan struct{}
+}
+
+func newConfigReloader(path string, cfg *v2.MOSNConfig, applier configApplier) *configReloader {
+	interval := defaultReloadCheckInterval
+	if cfg.HotReload != nil && cfg.HotReload.CheckInterval.Duration > 0 {
+		interval = cfg.HotReload.CheckInterval.Duration
+	}
+	r := &configReloader{
+		path:     path,
+		interval: interval,
+		applier:  applier,
+		current:  cfg,
+		status: ReloadStatus{
+			ConfigPath: path,
+			Success:    true,
+		},
+		stop: make(chan struct{}),
+	}
+	if content, err := ioutil.ReadFile(path); err == nil {
+		r.fingerprint = r.configFingerprint(content, cfg)
+	}
+	return r
+}
+
+func (r *configReloader) Start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.checkAndReload(false)
+			case <-r.stop:
+				return
+			}
+		}
+	}, nil)
+}
+
+func (r *configReloader) Stop() {
+	close(r.stop)
+}
+
+// Status returns the reload status
+func (r *configReloader) Status() ReloadStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.status
+}
+
+// configFingerprint returns the md5 of the config file content and the cluster config files
+func (r *configReloader) configFingerprint(content []byte, cfg *v2.MOSNConfig) [md5.Size]byte {
+	h := md5.New()
+	h.Write(content)
+	if cfg != nil && cfg.ClusterManager.ClusterConfigPath != "" {
+		files, _ := filepath.Glob(filepath.Join(cfg.ClusterManager.ClusterConfigPath, "*"))
+		sort.Strings(files)
+		for _, f := range files {
+			if content, err := ioutil.ReadFile(f); err == nil {
+				h.Write(content)
+			}
+		}
+	}
+	var sum [md5.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// checkAndReload reloads the config if the config files are changed, or force is true.
+// the config file is read once, the fingerprint and the reloaded config both come from the same content.
+// the config files failed to apply are not retried until they are changed again, or a reload is forced.
+func (r *configReloader) checkAndReload(force bool) ReloadStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	content, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		log.DefaultLogger.Errorf("[mosn] [config reload] read config file %s failed: %v", r.path, err)
+		return r.status
+	}
+	old := r.current
+	sum := r.configFingerprint(content, old)
+	if !force && (sum == r.fingerprint || sum == r.failedFingerprint) {
+		return r.status
+	}
+	if !r.reload(content) {
+		r.failedFingerprint = sum
+		return r.status
+	}
+	// the cluster config files are read from the path in the applied config
+	if r.current.ClusterManager.ClusterConfigPath != old.ClusterManager.ClusterConfigPath {
+		sum = r.configFingerprint(content, r.current)
+	}
+	r.fingerprint = sum
+	r.failedFingerprint = [md5.Size]byte{}
+	return r.status
+}
+
+// reload applies the config content, returns true if the config is applied
+func (r *configReloader) reload(content []byte) bool {
+	status := ReloadStatus{
+		ConfigPath:      r.path,
+		Version:         r.status.Version,
+		LastReloadTime:  time.Now(),
+		LastSuccessTime: r.status.LastSuccessTime,
+	}
+	defer func() {
+		r.status = status
+	}()
+	cfg, err := configmanager.ParseConfig(content)
+	if err != nil {
+		status.Diagnostics = append(status.Diagnostics, fmt.Sprintf("parse config failed: %v", err))
+		log.DefaultLogger.Errorf("[mosn] [config reload] parse config failed: %v", err)
+		return false
+	}
+	if err := configmanager.Validate(cfg); err != nil {
+		if errs, ok := err.(configmanager.ValidationErrors); ok {
+			for _, e := range errs {
+				status.Diagnostics = append(status.Diagnostics, e.Error())
+			}
+		} else {
+			status.Diagnostics = append(status.Diagnostics, err.Error())
+		}
+		log.DefaultLogger.Errorf("[mosn] [config reload] invalid config, ignore it: %v", err)
+		return false
+	}
+	actions := r.diff(r.current, cfg)
+	if len(actions) == 0 {
+		status.Success = true
+		return true
+	}
+	for idx, action := range actions {
+		if err := action.do(); err != nil {
+			status.Diagnostics = append(status.Diagnostics, fmt.Sprintf("%s failed: %v", action.desc, err))
+			log.DefaultLogger.Errorf("[mosn] [config reload] %s failed: %v, rollback", action.desc, err)
+			// rollback the applied actions in reverse order, include the failed one
+			for i := idx; i >= 0; i-- {
+				if err := actions[i].undo(); err != nil {
+					status.Diagnostics = append(status.Diagnostics, fmt.Sprintf("rollback %s failed: %v", actions[i].desc, err))
+					log.DefaultLogger.Errorf("[mosn] [config reload] rollback %s failed: %v", actions[i].desc, err)
+				}
+			}
+			status.RolledBack = true
+			return false
+		}
+		status.Changes = append(status.Changes, action.desc)
+	}
+	log.DefaultLogger.Infof("[mosn] [config reload] config reloaded, changes: %v", status.Changes)
+	r.current = cfg
+	configmanager.SetConfig(cfg)
+	event.Publish(event.ConfigUpdated, r.path, map[string]string{
+		"reason":  "reload",
+		"changes": strings.Join(status.Changes, ", "),
+	})
+	status.Version++
+	status.Success = true
+	status.LastSuccessTime = status.LastReloadTime
+	return true
+}
+
+// diff returns the actions that changes the config from old to new.
+// the listeners are compared by the listener config only, the runtime fields are set when the listener is parsed.
+// the actions are ordered as: add or update clusters, routers and listeners, then remove listeners and clusters.
+func (r *configReloader) diff(old, new *v2.MOSNConfig) []reloadAction {
+	var actions []reloadAction
+	oldClusters := clustersByName(old)
+	newClusters := clustersByName(new)
+	for _, name := range sortedKeys(newClusters) {
+		c := newClusters[name]
+		if oc, ok := oldClusters[name]; !ok {
+			actions = append(actions, reloadAction{
+				desc: "add cluster " + name,
+				do:   func() error { return r.applier.AddOrUpdateCluster(c) },
+				undo: func() error { return r.applier.RemoveCluster(c.Name) },
+			})
+		} else if !reflect.DeepEqual(oc, c) {
+			actions = append(actions, reloadAction{
+				desc: "update cluster " + name,
+				do:   func() error { return r.applier.AddOrUpdateCluster(c) },
+				undo: func() error { return r.applier.AddOrUpdateCluster(oc) },
+			})
+		}
+	}
+	oldRouters := routersByName(old)
+	newRouters := routersByName(new)
+	for _, name := range sortedKeys(newRouters) {
+		rc := newRouters[name]
+		if orc, ok := oldRouters[name]; !ok {
+			actions = append(actions, reloadAction{
+				desc: "add router " + name,
+				do:   func() error { return r.applier.AddOrUpdateRouters(rc) },
+				// the router manager does not support remove a router, the unused router is harmless
+				undo: func() error { return nil },
+			})
+		} else if !reflect.DeepEqual(orc, rc) {
+			actions = append(actions, reloadAction{
+				desc: "update router " + name,
+				do:   func() error { return r.applier.AddOrUpdateRouters(rc) },
+				undo: func() error { return r.applier.AddOrUpdateRouters(orc) },
+			})
+		}
+	}
+	oldListeners := listenersByName(old)
+	newListeners := listenersByName(new)
+	for _, name := range sortedKeys(newListeners) {
+		ln := newListeners[name]
+		if ln.Name == "" {
+			if oln, ok := oldListeners[name]; !ok || !reflect.DeepEqual(oln.ListenerConfig, ln.ListenerConfig) {
+				actions = append(actions, reloadAction{
+					desc: "reload listener " + name,
+					do:   func() error { return fmt.Errorf("listener without name can not be reloaded") },
+					undo: func() error { return nil },
+				})
+			}
+			continue
+		}
+		if oln, ok := oldListeners[name]; !ok {
+			actions = append(actions, reloadAction{
+				desc: "add listener " + name,
+				do:   func() error { return r.applier.AddOrUpdateListener(ln) },
+				undo: func() error { return r.applier.DeleteListener(ln.Name) },
+			})
+		} else if !reflect.DeepEqual(oln.ListenerConfig, ln.ListenerConfig) {
+			actions = append(actions, reloadAction{
+				desc: "update listener " + name,
+				do:   func() error { return r.applier.AddOrUpdateListener(ln) },
+				undo: func() error { return r.applier.AddOrUpdateListener(oln) },
+			})
+		}
+	}
+	for _, name := range sortedKeys(oldListeners) {
+		if _, ok := newListeners[name]; !ok {
+			oln := oldListeners[name]
+			actions = append(actions, reloadAction{
+				desc: "remove listener " + name,
+				do:   func() error { return r.applier.DeleteListener(oln.Name) },
+				undo: func() error { return r.applier.AddOrUpdateListener(oln) },
+			})
+		}
+	}
+	for _, name := range sortedKeys(oldClusters) {
+		if _, ok := newClusters[name]; !ok {
+			oc := oldClusters[name]
+			actions = append(actions, reloadAction{
+				desc: "remove cluster " + name,
+				do:   func() error { return r.applier.RemoveCluster(oc.Name) },
+				undo: func() error { return r.applier.AddOrUpdateCluster(oc) },
+			})
+		}
+	}
+	return actions
+}
+
+func clustersByName(cfg *v2.MOSNConfig) map[string]v2.Cluster {
+	clusters := make(map[string]v2.Cluster, len(cfg.ClusterManager.Clusters))
+	for _, c := range cfg.ClusterManager.Clusters {
+		clusters[c.Name] = c
+	}
+	return clusters
+}
+
+func listenersByName(cfg *v2.MOSNConfig) map[string]v2.Listener {
+	listeners := make(map[string]v2.Listener)
+	for _, srv := range cfg.Servers {
+		for _, ln := range srv.Listeners {
+			name := ln.Name
+			if name == "" {
+				name = ln.AddrConfig
+			}
+			listeners[name] = ln
+		}
+	}
+	return listeners
+}
+
+func routersByName(cfg *v2.MOSNConfig) map[string]*v2.RouterConfiguration {
+	routers := make(map[string]*v2.RouterConfiguration)
+	for _, ln := range listenersByName(cfg) {
+		for i := range ln.FilterChains {
+			if rc := configmanager.ParseRouterConfiguration(&ln.FilterChains[i]); rc.RouterConfigName != "" {
+				routers[rc.RouterConfigName] = rc
+			}
+		}
+	}
+	return routers
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		names = append(names, k.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultConfigApplier applies the changes by the cluster adapter, the router manager and the listener adapter
+type defaultConfigApplier struct{}
+
+func (a *defaultConfigApplier) AddOrUpdateCluster(c v2.Cluster) error {
+	adapter := cluster.GetClusterMngAdapterInstance()
+	if adapter == nil {
+		return fmt.Errorf("cluster manager adapter is not initialized")
+	}
+	clusters, _ := configmanager.ParseClusterConfig([]v2.Cluster{c})
+	return adapter.TriggerClusterAndHostsAddOrUpdate(clusters[0], clusters[0].Hosts)
+}
+
+func (a *defaultConfigApplier) RemoveCluster(name string) error {
+	adapter := cluster.GetClusterMngAdapterInstance()
+	if adapter == nil {
+		return fmt.Errorf("cluster manager adapter is not initialized")
+	}
+	return adapter.TriggerClusterDel(name)
+}
+
+func (a *defaultConfigApplier) AddOrUpdateRouters(rc *v2.RouterConfiguration) error {
+	return router.GetRoutersMangerInstance().AddOrUpdateRouters(rc)
+}
+
+func (a *defaultConfigApplier) AddOrUpdateListener(ln v2.Listener) error {
+	adapter := server.GetListenerAdapterInstance()
+	if adapter == nil {
+		return fmt.Errorf("listener adapter is not initialized")
+	}
+	lc := configmanager.ParseListenerConfig(&ln, nil)
+	var nfcf []api.NetworkFilterChainFactory
+	var sfcf []api.StreamFilterChainFactory
+	if !lc.UseOriginalDst {
+		nfcf = configmanager.GetNetworkFilters(&lc.FilterChains[0])
+		sfcf = configmanager.GetStreamFilters(lc.StreamFilters)
+	}
+	return adapter.AddOrUpdateListener("", lc, nfcf, sfcf)
+}
+
+func (a *defaultConfigApplier) DeleteListener(name string) error {
+	adapter := server.GetListenerAdapterInstance()
+	if adapter == nil {
+		return fmt.Errorf("listener adapter is not initialized")
+	}
+	return adapter.DeleteListener("", name)
+}
+
+// serveHTTP returns the config reload status, a POST request triggers a reload immediately
+func (r *configReloader) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	var status ReloadStatus
+	switch req.Method {
+	case http.MethodGet:
+		status = r.Status()
+	case http.MethodPost:
+		log.DefaultLogger.Infof("[admin api] [config reload] reload config by admin api")
+		status = r.checkAndReload(true)
+	default:
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "config reload", req.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	buf, _ := json.Marshal(status)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
@@ -0,0 +1,214 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mosn
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	_ "mosn.io/mosn/pkg/filter/network/proxy"
+)
+
+type mockApplier struct {
+	clusters  map[string]v2.Cluster
+	listeners map[string]v2.Listener
+	routers   map[string]*v2.RouterConfiguration
+	failOn    string
+}
+
+func newMockApplier() *mockApplier {
+	return &mockApplier{
+		clusters:  map[string]v2.Cluster{},
+		listeners: map[string]v2.Listener{},
+		routers:   map[string]*v2.RouterConfiguration{},
+	}
+}
+
+func (a *mockApplier) AddOrUpdateCluster(c v2.Cluster) error {
+	if a.failOn == c.Name {
+		return errors.New("mock failure")
+	}
+	a.clusters[c.Name] = c
+	return nil
+}
+
+func (a *mockApplier) RemoveCluster(name string) error {
+	delete(a.clusters, name)
+	return nil
+}
+
+func (a *mockApplier) AddOrUpdateRouters(rc *v2.RouterConfiguration) error {
+	a.routers[rc.RouterConfigName] = rc
+	return nil
+}
+
+func (a *mockApplier) AddOrUpdateListener(ln v2.Listener) error {
+	if a.failOn == ln.Name {
+		return errors.New("mock failure")
+	}
+	a.listeners[ln.Name] = ln
+	return nil
+}
+
+func (a *mockApplier) DeleteListener(name string) error {
+	delete(a.listeners, name)
+	return nil
+}
+
+const reloadConfig = `{
+	"servers":[{
+		"listeners":[{
+			"name":"test_listener",
+			"address":"127.0.0.1:2045",
+			"filter_chains":[{
+				"filters":[
+					{
+						"type":"proxy",
+						"config":{
+							"downstream_protocol":"Http1",
+							"upstream_protocol":"Http1",
+							"router_config_name":"test_router"
+						}
+					},
+					{
+						"type":"connection_manager",
+						"config":{
+							"router_config_name":"test_router",
+							"virtual_hosts":[{
+								"name":"test",
+								"domains":["*"],
+								"routers":[{
+									"match":{"prefix":"/"},
+									"route":{"cluster_name":"%cluster%"}
+								}]
+							}]
+						}
+					}
+				]
+			}]
+		}]
+	}],
+	"cluster_manager":{
+		"clusters":[{
+			"name":"%cluster%",
+			"hosts":[{"address":"%host%"}]
+		}]
+	}
+}`
+
+func writeReloadConfig(t *testing.T, path, cluster, host string) *v2.MOSNConfig {
+	content := strings.NewReplacer("%cluster%", cluster, "%host%", host).Replace(reloadConfig)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := configmanager.ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mosn_reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mosn.json")
+	cfg := writeReloadConfig(t, path, "cluster1", "127.0.0.1:8080")
+
+	applier := newMockApplier()
+	r := newConfigReloader(path, cfg, applier)
+	// no changes
+	if status := r.checkAndReload(false); !status.Success || status.Version != 0 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	// update cluster hosts
+	writeReloadConfig(t, path, "cluster1", "127.0.0.1:8081")
+	status := r.checkAndReload(false)
+	if !status.Success || status.Version != 1 || !reflect.DeepEqual(status.Changes, []string{"update cluster cluster1"}) {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	// replace the cluster, the router and the listener filters are changed too
+	writeReloadConfig(t, path, "cluster2", "127.0.0.1:8081")
+	status = r.checkAndReload(false)
+	expected := []string{"add cluster cluster2", "update router test_router", "update listener test_listener", "remove cluster cluster1"}
+	if !status.Success || status.Version != 2 || !reflect.DeepEqual(status.Changes, expected) {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if _, ok := applier.clusters["cluster2"]; !ok || len(applier.clusters) != 1 {
+		t.Fatalf("unexpected clusters: %v", applier.clusters)
+	}
+}
+
+func TestConfigReloadRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mosn_reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mosn.json")
+	cfg := writeReloadConfig(t, path, "cluster1", "127.0.0.1:8080")
+
+	applier := newMockApplier()
+	applier.clusters["cluster1"] = cfg.ClusterManager.Clusters[0]
+	r := newConfigReloader(path, cfg, applier)
+	// invalid config is not applied
+	writeReloadConfig(t, path, "cluster1", "127.0.0.1")
+	status := r.checkAndReload(false)
+	if status.Success || len(status.Diagnostics) == 0 || status.RolledBack {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	// apply failed, rollback
+	applier.failOn = "cluster2"
+	writeReloadConfig(t, path, "cluster2", "127.0.0.1:8081")
+	status = r.checkAndReload(false)
+	if status.Success || !status.RolledBack || status.Version != 0 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if _, ok := applier.clusters["cluster1"]; !ok || len(applier.clusters) != 1 {
+		t.Fatalf("unexpected clusters after rollback: %v", applier.clusters)
+	}
+	if r.current != cfg {
+		t.Fatal("the last good config should be kept")
+	}
+	// the failed config is not retried until the config file is changed
+	applier.failOn = ""
+	if again := r.checkAndReload(false); again.Version != 0 || again.LastReloadTime != status.LastReloadTime {
+		t.Fatalf("unexpected status: %+v", again)
+	}
+	// a forced reload retries the failed config
+	status = r.checkAndReload(true)
+	if !status.Success || status.Version != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if _, ok := applier.clusters["cluster2"]; !ok || len(applier.clusters) != 1 {
+		t.Fatalf("unexpected clusters after retry: %v", applier.clusters)
+	}
+	// not reloaded again after the config is applied
+	if again := r.checkAndReload(false); again.Version != 1 || again.LastReloadTime != status.LastReloadTime {
+		t.Fatalf("unexpected status: %+v", again)
+	}
+}
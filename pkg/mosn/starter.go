@@ -19,13 +19,17 @@ package mosn
 
 import (
 	"net"
+	"path/filepath"
 	"sync"
+	"syscall"
 
 	"mosn.io/api"
 	admin "mosn.io/mosn/pkg/admin/server"
 	"mosn.io/mosn/pkg/admin/store"
+	"mosn.io/mosn/pkg/chaos"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/dns"
 	"mosn.io/mosn/pkg/featuregate"
 	_ "mosn.io/mosn/pkg/filter/network/connectionmanager"
 	"mosn.io/mosn/pkg/log"
@@ -33,16 +37,23 @@ import (
 	"mosn.io/mosn/pkg/metrics/shm"
 	"mosn.io/mosn/pkg/metrics/sink"
 	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/proxy"
+	"mosn.io/mosn/pkg/registry"
 	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/secret"
 	"mosn.io/mosn/pkg/server"
 	"mosn.io/mosn/pkg/server/keeper"
 	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/mosn/pkg/watchdog"
 	"mosn.io/mosn/pkg/xds"
 	"mosn.io/pkg/utils"
 )
 
+// effectiveConfigDumpFile is the file name of the effective config dumped by signal
+const effectiveConfigDumpFile = "mosn_effective_config.json"
+
 // Mosn class which wrapper server
 type Mosn struct {
 	servers        []server.Server
@@ -51,6 +62,10 @@ type Mosn struct {
 	config         *v2.MOSNConfig
 	adminServer    admin.Server
 	xdsClient      *xds.Client
+	reloader       *configReloader
+	watchdog       *watchdog.Watchdog
+	warmer         *connectionWarmer
+	memoryStats    *memoryStats
 	wg             sync.WaitGroup
 	// for smooth upgrade. reconfigure
 	inheritListeners []net.Listener
@@ -84,6 +99,10 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 	}
 
 	initializeMetrics(c.Metrics)
+	initializeSecrets(c.Secrets)
+
+	initializeDNS(c.DNS)
+	initializeChaos(c.Chaos)
 
 	m := &Mosn{
 		config:           c,
@@ -91,6 +110,10 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 		inheritListeners: inheritListeners,
 		reconfigure:      reconfigure,
 	}
+	// memory accounting is enabled before any connection is created
+	if c.Metrics.MemoryStats != nil {
+		m.memoryStats = newMemoryStats(c.Metrics.MemoryStats)
+	}
 	mode := c.Mode()
 
 	if mode == v2.Xds {
@@ -141,6 +164,9 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 		// new server config
 		sc := server.NewConfig(c)
 
+		// start the telemetry workers before the streams are served
+		proxy.InitTelemetryWorkers(c.Workers)
+
 		// init default log
 		server.InitDefaultLogger(sc)
 
@@ -161,8 +187,10 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 				lc := configmanager.ParseListenerConfig(&serverConfig.Listeners[idx], inheritListeners)
 
 				// parse routers from connection_manager filter and add it the routerManager
-				if routerConfig := configmanager.ParseRouterConfiguration(&lc.FilterChains[0]); routerConfig.RouterConfigName != "" {
-					m.routerManager.AddOrUpdateRouters(routerConfig)
+				for i := range lc.FilterChains {
+					if routerConfig := configmanager.ParseRouterConfiguration(&lc.FilterChains[i]); routerConfig.RouterConfigName != "" {
+						m.routerManager.AddOrUpdateRouters(routerConfig)
+					}
 				}
 
 				var nfcf []api.NetworkFilterChainFactory
@@ -185,9 +213,50 @@ func NewMosn(c *v2.MOSNConfig) *Mosn {
 		m.servers = append(m.servers, srv)
 	}
 
+	// generate routes from the subscribed services
+	if c.ServiceRoute != nil {
+		initServiceRoute(c, m.routerManager)
+	}
+
+	// config hot reload
+	if c.HotReload != nil && mode != v2.Xds {
+		m.reloader = newConfigReloader(configmanager.GetConfigPath(), c, &defaultConfigApplier{})
+		admin.RegisterAdminHandleFunc("/api/v1/config_reload", m.reloader.serveHTTP)
+	}
+
+	// listeners and published services changed at runtime, the changes need the admin api auth token
+	var adminToken string
+	if c.AdminAPI != nil {
+		adminToken = c.AdminAPI.AuthToken
+	}
+	admin.RegisterAdminHandleFunc("/api/v1/listeners", newListenerManager(&defaultConfigApplier{}, adminToken).serveHTTP)
+	registry.SetAuthToken(adminToken)
+
+	// goroutine and connection leak watchdog
+	if c.Watchdog != nil {
+		m.watchdog = watchdog.NewWatchdog(c.Watchdog)
+	}
+
+	// connection warm-up of the hot clusters
+	if c.Warmup != nil {
+		m.warmer = newConnectionWarmer(m.clustermanager, c.Warmup)
+		admin.RegisterAdminHandleFunc("/api/v1/warmup", m.warmer.serveHTTP)
+	}
+
 	return m
 }
 
+// initServiceRoute generates routes for the services subscribed by the configured clusters,
+// and keeps the routes updated when the clusters or the routers changed
+func initServiceRoute(c *v2.MOSNConfig, routerManager types.RouterManager) {
+	generator := router.NewServiceRouteGenerator(*c.ServiceRoute, routerManager)
+	for i := range c.ClusterManager.Clusters {
+		cfg := c.ClusterManager.Clusters[i]
+		generator.OnClusterConfig(cfg.Name, &cfg)
+	}
+	cluster.RegisterClusterConfigCallback(generator.OnClusterConfig)
+}
+
 // beforeStart prepares some actions before mosn start proxy listener
 func (m *Mosn) beforeStart() {
 	// start adminApi
@@ -235,6 +304,9 @@ func (m *Mosn) beforeStart() {
 		configmanager.DumpConfigHandler()
 	}, nil)
 
+	// dump the effective config into file when SIGUSR2 received
+	keeper.AddSignalCallback(syscall.SIGUSR2, dumpEffectiveConfig)
+
 	// start reconfigure domain socket
 	utils.GoWithRecover(func() {
 		server.ReconfigureHandler()
@@ -268,6 +340,26 @@ func (m *Mosn) Start() {
 			srv.Start()
 		}, nil)
 	}
+
+	if m.reloader != nil {
+		log.StartLogger.Infof("mosn start config hot reload, config file: %s", m.reloader.path)
+		m.reloader.Start()
+	}
+
+	if m.watchdog != nil {
+		log.StartLogger.Infof("mosn start leak watchdog")
+		m.watchdog.Start()
+	}
+
+	if m.warmer != nil {
+		log.StartLogger.Infof("mosn start connection warm-up")
+		m.warmer.Start()
+	}
+
+	if m.memoryStats != nil {
+		log.StartLogger.Infof("mosn start memory stats")
+		m.memoryStats.Start()
+	}
 }
 
 // Close mosn's server
@@ -278,6 +370,29 @@ func (m *Mosn) Close() {
 	// stop reconfigure domain socket
 	server.StopReconfigureHandler()
 
+	// stop config hot reload
+	if m.reloader != nil {
+		m.reloader.Stop()
+	}
+
+	// stop leak watchdog
+	if m.watchdog != nil {
+		m.watchdog.Stop()
+	}
+
+	// stop connection warm-up
+	if m.warmer != nil {
+		m.warmer.Stop()
+	}
+
+	// stop memory stats
+	if m.memoryStats != nil {
+		m.memoryStats.Stop()
+	}
+
+	// stop refreshing secrets
+	secret.Stop()
+
 	// stop mosn server
 	for _, srv := range m.servers {
 		srv.Close()
@@ -313,6 +428,34 @@ func initializeTracing(config v2.TracingConfig) {
 	}
 }
 
+// initializeSecrets fetches the named secrets before the listeners and clusters reference them
+func initializeSecrets(config *v2.SecretsConfig) {
+	if config == nil {
+		return
+	}
+	if err := secret.Init(config); err != nil {
+		log.StartLogger.Fatalf("[mosn] [init secrets] init secrets failed: %v", err)
+	}
+	log.StartLogger.Infof("[mosn] [init secrets] %d secrets are fetched", len(config.Secrets))
+}
+
+// initializeDNS configures the resolver of the upstream host names before the clusters are added
+func initializeDNS(config *v2.DNSConfig) {
+	if config == nil {
+		return
+	}
+	if err := dns.Init(config); err != nil {
+		log.StartLogger.Fatalf("[mosn] [init dns] init dns resolver failed: %v", err)
+	}
+}
+
+// initializeChaos installs the fault hooks of the chaos tests
+func initializeChaos(config *v2.ChaosConfig) {
+	if err := chaos.Init(config); err != nil {
+		log.StartLogger.Fatalf("[mosn] [init chaos] install chaos faults failed: %v", err)
+	}
+}
+
 func initializeMetrics(config v2.MetricsConfig) {
 	// init shm zone
 	if config.ShmZone != "" && config.ShmSize > 0 {
@@ -322,6 +465,15 @@ func initializeMetrics(config v2.MetricsConfig) {
 	// set metrics package
 	statsMatcher := config.StatsMatcher
 	metrics.SetStatsMatcher(statsMatcher.RejectAll, statsMatcher.ExclusionLabels, statsMatcher.ExclusionKeys)
+	for key, buckets := range config.HistogramBuckets {
+		metrics.SetHistogramBuckets(key, buckets)
+	}
+	if err := initStatsTags(config); err != nil {
+		log.StartLogger.Errorf("[mosn] [init metrics] init stats tags failed: %v", err)
+	}
+	if ds := config.DetailedStats; ds != nil {
+		metrics.SetDetailedStats(ds.Route, ds.Host, ds.MaxCardinality)
+	}
 	// create sinks
 	for _, cfg := range config.SinkConfigs {
 		_, err := sink.CreateMetricsSink(cfg.Type, cfg.Config)
@@ -334,6 +486,41 @@ func initializeMetrics(config v2.MetricsConfig) {
 	}
 }
 
+// initStatsTags sets the tag extractors, the tag extractors are not changed if any config is invalid
+func initStatsTags(config v2.MetricsConfig) error {
+	if len(config.StatsTags) == 0 && !config.UseAllDefaultTags {
+		return nil
+	}
+	var extractors []metrics.TagExtractor
+	if config.UseAllDefaultTags {
+		extractors = metrics.DefaultTagExtractors()
+	}
+	fixedTags := make(map[string]string)
+	for _, tag := range config.StatsTags {
+		if tag.FixedValue != "" {
+			fixedTags[tag.TagName] = tag.FixedValue
+			continue
+		}
+		e, err := metrics.NewTagExtractor(tag.TagName, tag.Regex)
+		if err != nil {
+			return err
+		}
+		extractors = append(extractors, e)
+	}
+	metrics.SetTagExtractors(extractors, fixedTags)
+	return nil
+}
+
+// dumpEffectiveConfig writes the effective config into the config directory
+func dumpEffectiveConfig() {
+	path := filepath.Join(types.MosnConfigPath, effectiveConfigDumpFile)
+	if err := store.DumpToFile(path); err != nil {
+		log.DefaultLogger.Errorf("[mosn] [config dump] dump effective config to %s failed: %v", path, err)
+		return
+	}
+	log.DefaultLogger.Infof("[mosn] [config dump] dump effective config to %s", path)
+}
+
 func initializePidFile(pid string) {
 	keeper.SetPid(pid)
 }
@@ -0,0 +1,281 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mosn
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/event"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultWarmupTopClusters  = 10
+	defaultWarmupSaveInterval = time.Minute
+	defaultWarmupUsageFile    = "warmup_usage.json"
+	// warmupConcurrency is the max hosts that are connected at the same time
+	warmupConcurrency = 16
+)
+
+// WarmupStatus is the progress of the connection warm-up, reported by the admin api
+type WarmupStatus struct {
+	Running   bool      `json:"running"`
+	StartTime time.Time `json:"start_time,omitempty"`
+	EndTime   time.Time `json:"end_time,omitempty"`
+	// Clusters are the hot clusters that are warmed up
+	Clusters []string `json:"clusters,omitempty"`
+	Hosts    int      `json:"hosts"`
+	Warmed   int      `json:"warmed"`
+	Failed   int      `json:"failed"`
+}
+
+// warmupLbContext chooses the host to warm up by the override host
+type warmupLbContext struct {
+	ctx context.Context
+}
+
+func (c *warmupLbContext) MetadataMatchCriteria() api.MetadataMatchCriteria {
+	return nil
+}
+
+func (c *warmupLbContext) DownstreamConnection() net.Conn {
+	return nil
+}
+
+func (c *warmupLbContext) DownstreamHeaders() api.HeaderMap {
+	return nil
+}
+
+func (c *warmupLbContext) DownstreamContext() context.Context {
+	return c.ctx
+}
+
+// connectionWarmer resolves and connects the hosts of the hot clusters in advance,
+// the hot clusters are found by the recorded upstream usage, which is saved to file periodically.
+type connectionWarmer struct {
+	clusterManager types.ClusterManager
+	topClusters    int
+	usageFile      string
+	saveInterval   time.Duration
+
+	mutex       sync.Mutex
+	status      WarmupStatus
+	unsubscribe func()
+	stop        chan struct{}
+	once        sync.Once
+}
+
+func newConnectionWarmer(cm types.ClusterManager, cfg *v2.WarmupConfig) *connectionWarmer {
+	w := &connectionWarmer{
+		clusterManager: cm,
+		topClusters:    defaultWarmupTopClusters,
+		usageFile:      filepath.Join(types.MosnBasePath, defaultWarmupUsageFile),
+		saveInterval:   defaultWarmupSaveInterval,
+		stop:           make(chan struct{}),
+	}
+	if cfg.TopClusters > 0 {
+		w.topClusters = cfg.TopClusters
+	}
+	if cfg.UsageFile != "" {
+		w.usageFile = cfg.UsageFile
+	}
+	if cfg.SaveInterval.Duration > 0 {
+		w.saveInterval = cfg.SaveInterval.Duration
+	}
+	w.loadUsage()
+	return w
+}
+
+// Start warms up the hot clusters, and saves the usage periodically.
+// the hot clusters are warmed up again when the config is updated.
+func (w *connectionWarmer) Start() {
+	w.Warmup()
+	w.unsubscribe = event.Subscribe(func(e event.Event) {
+		w.Warmup()
+	}, event.ConfigUpdated)
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(w.saveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.saveUsage()
+			case <-w.stop:
+				return
+			}
+		}
+	}, nil)
+}
+
+// Stop saves the usage and stops the warmer
+func (w *connectionWarmer) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+		if w.unsubscribe != nil {
+			w.unsubscribe()
+		}
+		w.saveUsage()
+	})
+}
+
+// Status returns the warm-up status
+func (w *connectionWarmer) Status() WarmupStatus {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	status := w.status
+	status.Clusters = append([]string(nil), w.status.Clusters...)
+	return status
+}
+
+// Warmup starts a warm-up in background, returns false if a warm-up is running
+func (w *connectionWarmer) Warmup() bool {
+	w.mutex.Lock()
+	if w.status.Running {
+		w.mutex.Unlock()
+		return false
+	}
+	w.status = WarmupStatus{
+		Running:   true,
+		StartTime: time.Now(),
+	}
+	w.mutex.Unlock()
+	utils.GoWithRecover(func() {
+		w.warmup()
+	}, func(r interface{}) {
+		w.finish()
+	})
+	return true
+}
+
+func (w *connectionWarmer) finish() {
+	w.mutex.Lock()
+	w.status.Running = false
+	w.status.EndTime = time.Now()
+	status := w.status
+	w.mutex.Unlock()
+	log.DefaultLogger.Infof("[mosn] [warmup] warm up clusters %v finished, hosts: %d, warmed: %d, failed: %d",
+		status.Clusters, status.Hosts, status.Warmed, status.Failed)
+}
+
+func (w *connectionWarmer) warmup() {
+	defer w.finish()
+	sem := make(chan struct{}, warmupConcurrency)
+	var wg sync.WaitGroup
+	for _, usage := range cluster.HotClusters(w.topClusters) {
+		snap, ok := w.clusterManager.GetClusterSnapshot(context.Background(), usage.Cluster)
+		if !ok {
+			continue
+		}
+		hosts := snap.HostSet().HealthyHosts()
+		w.mutex.Lock()
+		w.status.Clusters = append(w.status.Clusters, usage.Cluster)
+		w.status.Hosts += len(hosts)
+		w.mutex.Unlock()
+		for _, host := range hosts {
+			sem <- struct{}{}
+			wg.Add(1)
+			snap, host, protocol := snap, host, usage.Protocol
+			utils.GoWithRecover(func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				ok := w.warmupHost(snap, host, protocol)
+				w.mutex.Lock()
+				if ok {
+					w.status.Warmed++
+				} else {
+					w.status.Failed++
+				}
+				w.mutex.Unlock()
+			}, nil)
+		}
+	}
+	wg.Wait()
+}
+
+// warmupHost resolves the host address, and creates the connection pool and connection of the host
+func (w *connectionWarmer) warmupHost(snap types.ClusterSnapshot, host types.Host, protocol types.Protocol) bool {
+	if cluster.GetOrCreateAddr(host.AddressString()) == nil {
+		return false
+	}
+	ctx := cluster.SetOverrideHost(context.Background(), host.AddressString())
+	pool := w.clusterManager.ConnPoolForCluster(&warmupLbContext{ctx: ctx}, snap, protocol)
+	if pool == nil {
+		return false
+	}
+	if wp, ok := pool.(types.WarmupConnectionPool); ok {
+		return wp.Warmup(ctx)
+	}
+	return pool.CheckAndInit(ctx)
+}
+
+func (w *connectionWarmer) loadUsage() {
+	content, err := ioutil.ReadFile(w.usageFile)
+	if err != nil {
+		return
+	}
+	var usages []cluster.ClusterUsage
+	if err := json.Unmarshal(content, &usages); err != nil {
+		log.DefaultLogger.Warnf("[mosn] [warmup] invalid usage file %s: %v", w.usageFile, err)
+		return
+	}
+	cluster.LoadClusterUsages(usages)
+}
+
+func (w *connectionWarmer) saveUsage() {
+	content, err := json.Marshal(cluster.HotClusters(0))
+	if err == nil {
+		err = utils.WriteFileSafety(w.usageFile, content, 0644)
+	}
+	if err != nil {
+		log.DefaultLogger.Errorf("[mosn] [warmup] save usage file %s failed: %v", w.usageFile, err)
+	}
+}
+
+// serveHTTP returns the warm-up status, a POST request triggers a warm-up immediately
+func (w *connectionWarmer) serveHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		log.DefaultLogger.Infof("[admin api] [warmup] warm up by admin api")
+		if !w.Warmup() {
+			rw.WriteHeader(http.StatusConflict)
+			rw.Write([]byte("warm up is running\n"))
+			return
+		}
+	default:
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "warmup", req.Method)
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	buf, _ := json.Marshal(w.Status())
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(buf)
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mosn
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	_ "mosn.io/mosn/pkg/stream/http"
+	"mosn.io/mosn/pkg/upstream/cluster"
+)
+
+func waitWarmup(t *testing.T, w *connectionWarmer) WarmupStatus {
+	for i := 0; i < 100; i++ {
+		if status := w.Status(); !status.Running {
+			return status
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("warm up is not finished")
+	return WarmupStatus{}
+}
+
+func TestConnectionWarmup(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	var accepted int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			defer conn.Close()
+		}
+	}()
+	dir, err := ioutil.TempDir("", "mosn_warmup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cluster.ResetClusterUsages()
+	defer cluster.ResetClusterUsages()
+	// the usage saved before restart
+	usageFile := filepath.Join(dir, "usage.json")
+	content, _ := json.Marshal([]cluster.ClusterUsage{
+		{Cluster: "warmup", Protocol: protocol.HTTP1, Count: 10},
+		{Cluster: "not_exists", Protocol: protocol.HTTP1, Count: 1},
+	})
+	if err := ioutil.WriteFile(usageFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cm := cluster.NewClusterManagerSingleton([]v2.Cluster{
+		{
+			Name:   "warmup",
+			LbType: v2.LB_RANDOM,
+		},
+	}, map[string][]v2.Host{
+		"warmup": {
+			{HostConfig: v2.HostConfig{Address: ln.Addr().String()}},
+		},
+	})
+	defer cm.Destroy()
+
+	w := newConnectionWarmer(cm, &v2.WarmupConfig{
+		UsageFile: usageFile,
+	})
+	w.Start()
+	status := waitWarmup(t, w)
+	if len(status.Clusters) != 1 || status.Hosts != 1 || status.Warmed != 1 || status.Failed != 0 {
+		t.Fatalf("unexpected warm up status: %+v", status)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&accepted) != 1 {
+		t.Fatalf("expected 1 connection warmed up, but got %d", accepted)
+	}
+	// the pool has an available connection, no more connections
+	rec := httptest.NewRecorder()
+	w.serveHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/warmup", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	waitWarmup(t, w)
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&accepted) != 1 {
+		t.Fatalf("expected 1 connection warmed up, but got %d", accepted)
+	}
+	rec = httptest.NewRecorder()
+	w.serveHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/warmup", nil))
+	got := WarmupStatus{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Warmed != 1 {
+		t.Fatalf("unexpected warm up status: %s", rec.Body.String())
+	}
+	// the usage is saved when stop
+	cluster.RecordClusterUsage("warmup", protocol.HTTP1)
+	w.Stop()
+	content, err = ioutil.ReadFile(usageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usages []cluster.ClusterUsage
+	if err := json.Unmarshal(content, &usages); err != nil || len(usages) != 2 || usages[0].Count != 11 {
+		t.Fatalf("unexpected saved usages: %s", string(content))
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package certtool
+
+import (
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+type gmCertificate struct {
+	*CertificateInfo
+	cert *sm2.Certificate
+	priv *sm2.PrivateKey
+}
+
+// gmRootCA is the SM2 root ca, GM certificates are signed by it
+var gmRootCA *gmCertificate
+
+func createGMTemplate(cn string, isca bool, dns []string) (*sm2.Certificate, error) {
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, err
+	}
+	template := &sm2.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"sofa mesh tls util"},
+			CommonName:   cn,
+		},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(100, 0, 0),
+		SignatureAlgorithm:    sm2.SM2WithSM3,
+		BasicConstraintsValid: true,
+		DNSNames:              dns,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isca {
+		template.IsCA = true
+		template.KeyUsage = sm2.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []sm2.ExtKeyUsage{sm2.ExtKeyUsageServerAuth, sm2.ExtKeyUsageClientAuth}
+	}
+	return template, nil
+}
+
+func createGMCertificate(template, parent *sm2.Certificate, templatePriv, parentPriv *sm2.PrivateKey) (*gmCertificate, error) {
+	certPem, err := sm2.CreateCertificateToMem(template, parent, &templatePriv.PublicKey, parentPriv)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := sm2.ReadCertificateFromMem(certPem)
+	if err != nil {
+		return nil, err
+	}
+	keyPem, err := sm2.WritePrivateKeytoMem(templatePriv, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &gmCertificate{
+		CertificateInfo: &CertificateInfo{
+			CertPem: string(certPem),
+			KeyPem:  string(keyPem),
+		},
+		cert: cert,
+		priv: templatePriv,
+	}, nil
+}
+
+// InitializeGM creates the SM2 RootCA only if it is nil
+func InitializeGM() error {
+	if gmRootCA != nil {
+		return nil
+	}
+	ca, err := createGMTemplate("", true, nil)
+	if err != nil {
+		return err
+	}
+	priv, err := sm2.GenerateKey()
+	if err != nil {
+		return err
+	}
+	cert, err := createGMCertificate(ca, ca, priv, priv)
+	if err != nil {
+		return err
+	}
+	gmRootCA = cert
+	return nil
+}
+
+// GetGMRootCA returns the SM2 RootCA's certificate info
+func GetGMRootCA() *CertificateInfo {
+	if err := InitializeGM(); err != nil {
+		return nil
+	}
+	return &CertificateInfo{
+		CertPem: gmRootCA.CertPem,
+		KeyPem:  gmRootCA.KeyPem,
+	}
+}
+
+// SignGMCertificate creates a SM2 certificate signed by the SM2 RootCA.
+// A GM server needs two certificates, one for signature and one for encryption.
+func SignGMCertificate(cn string, dns []string) (*CertificateInfo, error) {
+	if err := InitializeGM(); err != nil {
+		return nil, err
+	}
+	template, err := createGMTemplate(cn, false, dns)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := sm2.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := createGMCertificate(template, gmRootCA.cert, priv, gmRootCA.priv)
+	if err != nil {
+		return nil, err
+	}
+	return cert.CertificateInfo, nil
+}
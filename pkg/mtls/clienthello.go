@@ -0,0 +1,185 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	recordTypeHandshake    = 0x16
+	handshakeClientHello   = 0x01
+	recordHeaderLen        = 5
+	maxClientHelloLen      = 16384
+	extensionServerName    = 0
+	extensionALPN          = 16
+	serverNameTypeHostname = 0
+)
+
+// ClientHelloInfo contains the fields of a TLS ClientHello that are used
+// to select a filter chain before the handshake.
+type ClientHelloInfo struct {
+	ServerName string
+	Protocols  []string
+}
+
+// NewPeekConn wraps the connection, so the data read by PeekClientHello can be read again.
+func NewPeekConn(c net.Conn) *Conn {
+	if conn, ok := c.(*Conn); ok {
+		return conn
+	}
+	return &Conn{
+		Conn: c,
+	}
+}
+
+// PeekClientHello reads the first TLS record without draining it.
+// A nil ClientHelloInfo is returned if the connection is not a TLS connection.
+// If the record is not a valid ClientHello, an empty ClientHelloInfo is returned
+// and the TLS handshake will report the error.
+func (c *Conn) PeekClientHello() (*ClientHelloInfo, error) {
+	return c.peekClientHello(time.Now().Add(types.DefaultConnReadTimeout))
+}
+
+// peekClientHello is PeekClientHello with the deadline of reading the whole record
+func (c *Conn) peekClientHello(deadline time.Time) (*ClientHelloInfo, error) {
+	if err := c.peekFullDeadline(1, deadline); err != nil {
+		return nil, err
+	}
+	if c.peek[0] != recordTypeHandshake {
+		return nil, nil
+	}
+	if err := c.peekFullDeadline(recordHeaderLen, deadline); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint16(c.peek[3:5]))
+	if n > maxClientHelloLen {
+		return &ClientHelloInfo{}, nil
+	}
+	if err := c.peekFullDeadline(recordHeaderLen+n, deadline); err != nil {
+		return nil, err
+	}
+	return parseClientHello(c.peek[recordHeaderLen : recordHeaderLen+n]), nil
+}
+
+// parseClientHello parses the handshake message in the first record.
+// only the server name and the alpn extensions are parsed.
+func parseClientHello(data []byte) *ClientHelloInfo {
+	info := &ClientHelloInfo{}
+	// handshake type(1) + length(3) + version(2) + random(32)
+	if len(data) < 38 || data[0] != handshakeClientHello {
+		return info
+	}
+	data = data[38:]
+	// session id
+	data, ok := skipVector(data, 1)
+	if !ok {
+		return info
+	}
+	// cipher suites
+	if data, ok = skipVector(data, 2); !ok {
+		return info
+	}
+	// compression methods
+	if data, ok = skipVector(data, 1); !ok {
+		return info
+	}
+	if len(data) < 2 {
+		return info
+	}
+	extLen := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	if len(data) > extLen {
+		data = data[:extLen]
+	}
+	for len(data) >= 4 {
+		typ := binary.BigEndian.Uint16(data)
+		length := int(binary.BigEndian.Uint16(data[2:]))
+		data = data[4:]
+		if len(data) < length {
+			break
+		}
+		ext := data[:length]
+		data = data[length:]
+		switch typ {
+		case extensionServerName:
+			info.ServerName = parseServerName(ext)
+		case extensionALPN:
+			info.Protocols = parseALPN(ext)
+		}
+	}
+	return info
+}
+
+func parseServerName(ext []byte) string {
+	if len(ext) < 2 {
+		return ""
+	}
+	ext = ext[2:]
+	for len(ext) >= 3 {
+		typ := ext[0]
+		length := int(binary.BigEndian.Uint16(ext[1:]))
+		ext = ext[3:]
+		if len(ext) < length {
+			return ""
+		}
+		if typ == serverNameTypeHostname {
+			return string(ext[:length])
+		}
+		ext = ext[length:]
+	}
+	return ""
+}
+
+func parseALPN(ext []byte) []string {
+	if len(ext) < 2 {
+		return nil
+	}
+	ext = ext[2:]
+	var protos []string
+	for len(ext) >= 1 {
+		length := int(ext[0])
+		ext = ext[1:]
+		if length == 0 || len(ext) < length {
+			break
+		}
+		protos = append(protos, string(ext[:length]))
+		ext = ext[length:]
+	}
+	return protos
+}
+
+// skipVector skips a vector with a length prefix of size bytes
+func skipVector(data []byte, size int) ([]byte, bool) {
+	if len(data) < size {
+		return nil, false
+	}
+	length := 0
+	for i := 0; i < size; i++ {
+		length = length<<8 | int(data[i])
+	}
+	data = data[size:]
+	if len(data) < length {
+		return nil, false
+	}
+	return data[length:], true
+}
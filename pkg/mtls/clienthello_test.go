@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestPeekClientHelloNonTLS(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		client.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+		client.Close()
+	}()
+	conn := NewPeekConn(server)
+	info, err := conn.PeekClientHello()
+	if err != nil || info != nil {
+		t.Fatalf("expected non tls connection, got %v, %v", info, err)
+	}
+	// the peeked data can be read again
+	data, err := ioutil.ReadAll(conn)
+	if err != nil || string(data) != "GET / HTTP/1.1\r\n\r\n" {
+		t.Fatalf("unexpected data: %q, %v", data, err)
+	}
+}
+
+func TestParseClientHelloInvalid(t *testing.T) {
+	for _, data := range [][]byte{nil, {0x01}, make([]byte, 40)} {
+		if info := parseClientHello(data); info == nil || info.ServerName != "" || len(info.Protocols) != 0 {
+			t.Errorf("unexpected client hello info: %v", info)
+		}
+	}
+}
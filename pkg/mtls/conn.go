@@ -21,6 +21,7 @@ import (
 	gotls "crypto/tls"
 	"encoding/gob"
 	"errors"
+	"io"
 	"net"
 	"time"
 
@@ -36,48 +37,63 @@ import (
 // It implements the net.Conn interface.
 type TLSConn struct {
 	*tls.Conn
+	handshake handshakeRecorder
 }
 
 // Conn is a generic stream-oriented network connection.
 // It implements the net.Conn interface.
 type Conn struct {
 	net.Conn
-	peek    [1]byte
-	haspeek bool
+	peek []byte
 }
 
 // Peek returns 1 byte from connection, without draining any buffered data.
 func (c *Conn) Peek() ([]byte, error) {
-	b := make([]byte, 1, 1)
-	c.Conn.SetReadDeadline(time.Now().Add(types.DefaultConnReadTimeout))
-	_, err := c.Conn.Read(b)
+	if len(c.peek) > 0 {
+		return c.peek[:1], nil
+	}
+	if err := c.peekFull(1); err != nil {
+		return nil, err
+	}
+	return c.peek[:1], nil
+}
+
+// peekFull makes sure at least n bytes are buffered, the buffered bytes will be
+// returned by Read first.
+func (c *Conn) peekFull(n int) error {
+	return c.peekFullDeadline(n, time.Now().Add(types.DefaultConnReadTimeout))
+}
+
+// peekFullDeadline is peekFull with the read deadline
+func (c *Conn) peekFullDeadline(n int, deadline time.Time) error {
+	if len(c.peek) >= n {
+		return nil
+	}
+	b := make([]byte, n-len(c.peek))
+	c.Conn.SetReadDeadline(deadline)
+	_, err := io.ReadFull(c.Conn, b)
 	c.Conn.SetReadDeadline(time.Time{}) // clear read deadline
 	if err != nil {
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 			log.DefaultLogger.Debugf("[mtls] TLS Peek() error: %v", err)
 		}
-		return nil, err
+		return err
 	}
-	c.peek[0] = b[0]
-	c.haspeek = true
-	return b, nil
+	c.peek = append(c.peek, b...)
+	return nil
 }
 
 // Read reads data from the connection.
 func (c *Conn) Read(b []byte) (int, error) {
-	peek := 0
-	if c.haspeek {
-		c.haspeek = false
-		b[0] = c.peek[0]
-		if len(b) == 1 {
-			return 1, nil
+	if len(c.peek) > 0 {
+		n := copy(b, c.peek)
+		c.peek = c.peek[n:]
+		if len(c.peek) == 0 {
+			c.peek = nil
 		}
-		peek = 1
-		b = b[peek:]
+		return n, nil
 	}
-
-	n, err := c.Conn.Read(b)
-	return n + peek, err
+	return c.Conn.Read(b)
 }
 
 // ConnectionState records basic TLS details about the connection.
@@ -141,6 +157,10 @@ func (c *TLSConn) WriteTo(v *net.Buffers) (int64, error) {
 	}
 	*buffers = (*buffers)[:0]
 
+	if err := c.Handshake(); err != nil {
+		buffer.PutBytes(buf)
+		return 0, err
+	}
 	off = 0
 	for off < size {
 		l, err := c.Conn.Write((*buf)[off:])
@@ -174,7 +194,9 @@ func GetTLSConn(c net.Conn, b []byte) (net.Conn, error) {
 		return nil, errors.New("TransferTLSConn error")
 	}
 	mtlsConn := &TLSConn{
-		conn,
+		Conn: conn,
 	}
+	// the transferred connection has completed the handshake
+	mtlsConn.handshake.done()
 	return mtlsConn, nil
 }
@@ -60,6 +60,9 @@ const (
 	// suiteDefaultOff indicates that this cipher suite is not included by
 	// default.
 	suiteDefaultOff
+	// suiteGM indicates that the cipher suite should only be advertised
+	// and accepted when using the GM/T 0024 protocol.
+	suiteGM
 )
 
 // A cipherSuite is a specific combination of key agreement, cipher and MAC
@@ -109,6 +112,7 @@ var cipherSuites = []*cipherSuite{
 	// gm
 	{TLS_ECDHE_RSA_WITH_SM4_SM3, 16, 32, 16, ecdheRSAKA, suiteECDHE, cipherSM4, macSM3, nil},
 	{TLS_ECDHE_ECDSA_WITH_SM4_SM3, 16, 32, 16, ecdheECDSAKA, suiteECDHE | suiteECDSA, cipherSM4, macSM3, nil},
+	{GMTLS_ECC_SM4_CBC_SM3, 16, 32, 16, eccGMKA, suiteGM, cipherSM4, macSM3, nil},
 }
 
 func cipherRC4(key, iv []byte, isRead bool) interface{} {
@@ -430,6 +434,9 @@ const (
 	TLS_ECDHE_RSA_WITH_SM4_SM3              uint16 = 0xe00f
 	TLS_ECDHE_ECDSA_WITH_SM4_SM3            uint16 = 0xe010
 
+	// GM/T 0024 cipher suites
+	GMTLS_ECC_SM4_CBC_SM3 uint16 = 0xe013
+
 	// TLS_FALLBACK_SCSV isn't a standard cipher suite but an indicator
 	// that the client is doing version fallback. See
 	// https://tools.ietf.org/html/rfc7507.
@@ -19,6 +19,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/tjfoc/gmsm/sm2"
+
 	"mosn.io/mosn/pkg/mtls/crypto/tls/cipherhw"
 )
 
@@ -27,6 +29,9 @@ const (
 	VersionTLS10 = 0x0301
 	VersionTLS11 = 0x0302
 	VersionTLS12 = 0x0303
+
+	// VersionGMSSL is the version of the GM/T 0024 protocol
+	VersionGMSSL = 0x0101
 )
 
 const (
@@ -179,6 +184,10 @@ type ConnectionState struct {
 	// change in future versions of Go once the TLS master-secret fix has
 	// been standardized and implemented.
 	TLSUnique []byte
+
+	// GMPeerCertificates are the signature and the encryption certificates
+	// presented by a GM server, followed by the intermediate certificates (client side only)
+	GMPeerCertificates []*sm2.Certificate
 }
 
 // ClientAuthType declares the policy the server will follow for
@@ -508,6 +517,19 @@ type Config struct {
 	// used for debugging.
 	KeyLogWriter io.Writer
 
+	// GMSupport makes the connection use the GM/T 0024 handshake only,
+	// the GM version and the GM cipher suites are negotiated.
+	// Client certificates are not supported in the GM handshake.
+	GMSupport bool
+
+	// GMEncCertificate is the SM2 encryption certificate of a GM server,
+	// the Certificates contains the SM2 signature certificate.
+	GMEncCertificate *Certificate
+
+	// GMRootCAs defines the root certificate authorities that a GM client
+	// uses to verify the server certificates.
+	GMRootCAs *sm2.CertPool
+
 	serverInitOnce sync.Once // guards calling (*Config).serverInit
 
 	// mutex protects sessionTicketKeys.
@@ -581,6 +603,9 @@ func (c *Config) Clone() *Config {
 		DynamicRecordSizingDisabled: c.DynamicRecordSizingDisabled,
 		Renegotiation:               c.Renegotiation,
 		KeyLogWriter:                c.KeyLogWriter,
+		GMSupport:                   c.GMSupport,
+		GMEncCertificate:            c.GMEncCertificate,
+		GMRootCAs:                   c.GMRootCAs,
 		sessionTicketKeys:           sessionTicketKeys,
 	}
 }
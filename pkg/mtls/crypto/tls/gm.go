@@ -0,0 +1,286 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package tls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// The GM/T 0024 handshake is a TLS 1.1 like handshake.
+// A GM server presents a signature certificate and an encryption certificate,
+// the client encrypts the pre-master secret with the SM2 public key of the encryption certificate,
+// and the server signs the randoms and the encryption certificate with the signature certificate.
+// The prf and the finished hash use SM3, the records use explicit IVs as TLS 1.1.
+
+// explicitIV reports whether the CBC records of the version use explicit IVs.
+func explicitIV(version uint16) bool {
+	return version >= VersionTLS11 || version == VersionGMSSL
+}
+
+func eccGMKA(version uint16) keyAgreement {
+	return &eccGMKeyAgreement{
+		version: version,
+	}
+}
+
+// eccGMKeyAgreement implements the ECC key agreement of GM/T 0024.
+type eccGMKeyAgreement struct {
+	version uint16
+	// peerCertificates is set by the client before the server key exchange is processed
+	peerCertificates []*sm2.Certificate
+}
+
+func (ka *eccGMKeyAgreement) generateServerKeyExchange(config *Config, cert *Certificate, clientHello *clientHelloMsg, hello *serverHelloMsg) (*serverKeyExchangeMsg, error) {
+	enc := config.GMEncCertificate
+	if enc == nil || len(enc.Certificate) == 0 {
+		return nil, errors.New("tls: no encryption certificate for the GM handshake")
+	}
+	priv, ok := cert.PrivateKey.(*sm2.PrivateKey)
+	if !ok {
+		return nil, errors.New("tls: the GM signature certificate private key is not a SM2 private key")
+	}
+	sig, err := priv.Sign(config.rand(), gmSignedParams(clientHello.random, hello.random, enc.Certificate[0]), nil)
+	if err != nil {
+		return nil, errors.New("tls: failed to sign GM parameters: " + err.Error())
+	}
+	skx := new(serverKeyExchangeMsg)
+	skx.key = make([]byte, 2+len(sig))
+	skx.key[0] = byte(len(sig) >> 8)
+	skx.key[1] = byte(len(sig))
+	copy(skx.key[2:], sig)
+	return skx, nil
+}
+
+func (ka *eccGMKeyAgreement) processClientKeyExchange(config *Config, cert *Certificate, ckx *clientKeyExchangeMsg, version uint16) ([]byte, error) {
+	enc := config.GMEncCertificate
+	if enc == nil {
+		return nil, errors.New("tls: no encryption certificate for the GM handshake")
+	}
+	priv, ok := enc.PrivateKey.(*sm2.PrivateKey)
+	if !ok {
+		return nil, errors.New("tls: the GM encryption certificate private key is not a SM2 private key")
+	}
+	if len(ckx.ciphertext) < 2 {
+		return nil, errClientKeyExchange
+	}
+	n := int(ckx.ciphertext[0])<<8 | int(ckx.ciphertext[1])
+	if n != len(ckx.ciphertext)-2 {
+		return nil, errClientKeyExchange
+	}
+	preMasterSecret, err := sm2.Decrypt(priv, ckx.ciphertext[2:])
+	if err != nil || len(preMasterSecret) != masterSecretLength {
+		return nil, errClientKeyExchange
+	}
+	return preMasterSecret, nil
+}
+
+func (ka *eccGMKeyAgreement) processServerKeyExchange(config *Config, clientHello *clientHelloMsg, serverHello *serverHelloMsg, cert *x509.Certificate, skx *serverKeyExchangeMsg) error {
+	if len(ka.peerCertificates) < 2 {
+		return errors.New("tls: the GM server did not send the encryption certificate")
+	}
+	if len(skx.key) < 2 {
+		return errServerKeyExchange
+	}
+	n := int(skx.key[0])<<8 | int(skx.key[1])
+	if n != len(skx.key)-2 {
+		return errServerKeyExchange
+	}
+	pub, ok := gmPublicKey(ka.peerCertificates[0].PublicKey)
+	if !ok {
+		return errors.New("tls: the GM signature certificate does not contain a SM2 public key")
+	}
+	if !pub.Verify(gmSignedParams(clientHello.random, serverHello.random, ka.peerCertificates[1].Raw), skx.key[2:]) {
+		return errors.New("tls: invalid signature of the GM parameters")
+	}
+	return nil
+}
+
+func (ka *eccGMKeyAgreement) generateClientKeyExchange(config *Config, clientHello *clientHelloMsg, cert *x509.Certificate) ([]byte, *clientKeyExchangeMsg, error) {
+	if len(ka.peerCertificates) < 2 {
+		return nil, nil, errors.New("tls: the GM server did not send the encryption certificate")
+	}
+	pub, ok := gmPublicKey(ka.peerCertificates[1].PublicKey)
+	if !ok {
+		return nil, nil, errors.New("tls: the GM encryption certificate does not contain a SM2 public key")
+	}
+	preMasterSecret := make([]byte, masterSecretLength)
+	preMasterSecret[0] = byte(clientHello.vers >> 8)
+	preMasterSecret[1] = byte(clientHello.vers)
+	if _, err := io.ReadFull(config.rand(), preMasterSecret[2:]); err != nil {
+		return nil, nil, err
+	}
+	encrypted, err := sm2.Encrypt(pub, preMasterSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	ckx := new(clientKeyExchangeMsg)
+	ckx.ciphertext = make([]byte, 2+len(encrypted))
+	ckx.ciphertext[0] = byte(len(encrypted) >> 8)
+	ckx.ciphertext[1] = byte(len(encrypted))
+	copy(ckx.ciphertext[2:], encrypted)
+	return preMasterSecret, ckx, nil
+}
+
+// gmSignedParams returns the signed content of the GM server key exchange:
+// client random, server random and the encryption certificate with a 3 bytes length
+func gmSignedParams(clientRandom, serverRandom, encCert []byte) []byte {
+	n := len(encCert)
+	msg := make([]byte, 0, len(clientRandom)+len(serverRandom)+3+n)
+	msg = append(msg, clientRandom...)
+	msg = append(msg, serverRandom...)
+	msg = append(msg, byte(n>>16), byte(n>>8), byte(n))
+	return append(msg, encCert...)
+}
+
+// gmPublicKey returns the SM2 public key of a certificate parsed by the sm2 package
+func gmPublicKey(pub interface{}) (*sm2.PublicKey, bool) {
+	switch key := pub.(type) {
+	case *sm2.PublicKey:
+		return key, true
+	case *ecdsa.PublicKey:
+		if key.Curve != sm2.P256Sm2() {
+			return nil, false
+		}
+		return &sm2.PublicKey{
+			Curve: key.Curve,
+			X:     key.X,
+			Y:     key.Y,
+		}, true
+	}
+	return nil, false
+}
+
+// gmCertificates returns the certificates sent by a GM server:
+// the signature certificate, the encryption certificate and the intermediate certificates
+func gmCertificates(sign, enc *Certificate) [][]byte {
+	certificates := make([][]byte, 0, len(sign.Certificate)+1)
+	certificates = append(certificates, sign.Certificate[0], enc.Certificate[0])
+	return append(certificates, sign.Certificate[1:]...)
+}
+
+// processGMCertificates parses and verifies the certificates sent by a GM server
+func (hs *clientHandshakeState) processGMCertificates(certificates [][]byte) error {
+	c := hs.c
+
+	if c.handshakes > 0 {
+		// This is a renegotiation handshake, the server's identity should not be changed.
+		if !bytes.Equal(c.gmPeerCertificates[0].Raw, certificates[0]) {
+			c.sendAlert(alertBadCertificate)
+			return errors.New("tls: server's identity changed during renegotiation")
+		}
+		return nil
+	}
+	if len(certificates) < 2 {
+		c.sendAlert(alertBadCertificate)
+		return errors.New("tls: the GM server should send the signature and the encryption certificates")
+	}
+	certs := make([]*sm2.Certificate, len(certificates))
+	for i, asn1Data := range certificates {
+		cert, err := sm2.ParseCertificate(asn1Data)
+		if err != nil {
+			c.sendAlert(alertBadCertificate)
+			return errors.New("tls: failed to parse certificate from server: " + err.Error())
+		}
+		certs[i] = cert
+	}
+
+	if !c.config.InsecureSkipVerify {
+		opts := sm2.VerifyOptions{
+			Roots:         c.config.GMRootCAs,
+			CurrentTime:   c.config.time(),
+			DNSName:       c.config.ServerName,
+			Intermediates: sm2.NewCertPool(),
+		}
+		for _, cert := range certs[2:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			c.sendAlert(alertBadCertificate)
+			return err
+		}
+		// the server name is verified by the signature certificate
+		opts.DNSName = ""
+		opts.KeyUsages = []sm2.ExtKeyUsage{sm2.ExtKeyUsageAny}
+		if _, err := certs[1].Verify(opts); err != nil {
+			c.sendAlert(alertBadCertificate)
+			return err
+		}
+	}
+
+	if c.config.VerifyPeerCertificate != nil {
+		if err := c.config.VerifyPeerCertificate(certificates, nil); err != nil {
+			c.sendAlert(alertBadCertificate)
+			return err
+		}
+	}
+
+	for _, cert := range certs[:2] {
+		if _, ok := gmPublicKey(cert.PublicKey); !ok {
+			c.sendAlert(alertUnsupportedCertificate)
+			return fmt.Errorf("tls: the GM server's certificate contains an unsupported type of public key: %T", cert.PublicKey)
+		}
+	}
+
+	c.gmPeerCertificates = certs
+	return nil
+}
+
+// GMX509KeyPair parses a SM2 public/private key pair from a pair of PEM encoded data.
+// The private key should be an unencrypted PKCS #8 SM2 private key.
+func GMX509KeyPair(certPEMBlock, keyPEMBlock []byte) (Certificate, error) {
+	var cert Certificate
+	for {
+		var certDERBlock *pem.Block
+		certDERBlock, certPEMBlock = pem.Decode(certPEMBlock)
+		if certDERBlock == nil {
+			break
+		}
+		if certDERBlock.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, certDERBlock.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return Certificate{}, errors.New("tls: failed to find any PEM data in certificate input")
+	}
+	priv, err := sm2.ReadPrivateKeyFromMem(keyPEMBlock, nil)
+	if err != nil {
+		return Certificate{}, errors.New("tls: failed to parse SM2 private key: " + err.Error())
+	}
+	leaf, err := sm2.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return Certificate{}, err
+	}
+	pub, ok := gmPublicKey(leaf.PublicKey)
+	if !ok {
+		return Certificate{}, errors.New("tls: the certificate does not contain a SM2 public key")
+	}
+	if pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 {
+		return Certificate{}, errors.New("tls: private key does not match public key")
+	}
+	cert.PrivateKey = priv
+	return cert, nil
+}
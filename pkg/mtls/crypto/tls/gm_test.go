@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tls
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"mosn.io/mosn/pkg/mtls/certtool"
+)
+
+func gmTestCertificate(t *testing.T) Certificate {
+	info, err := certtool.SignGMCertificate("gm server", []string{"www.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := GMX509KeyPair([]byte(info.CertPem), []byte(info.KeyPem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func gmTestServerConfig(t *testing.T) *Config {
+	sign := gmTestCertificate(t)
+	enc := gmTestCertificate(t)
+	return &Config{
+		GMSupport:        true,
+		Certificates:     []Certificate{sign},
+		GMEncCertificate: &enc,
+	}
+}
+
+func gmTestClientConfig(t *testing.T) *Config {
+	pool := sm2.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(certtool.GetGMRootCA().CertPem)) {
+		t.Fatal("append gm root ca failed")
+	}
+	return &Config{
+		GMSupport:  true,
+		GMRootCAs:  pool,
+		ServerName: "www.example.com",
+	}
+}
+
+// gmHandshake makes a handshake and sends a message on a pipe
+func gmHandshake(server, client *Config) (ConnectionState, error) {
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		srv := Server(sc, server)
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(srv, buf); err != nil {
+			errCh <- err
+			sc.Close()
+			return
+		}
+		_, err := srv.Write(buf)
+		errCh <- err
+	}()
+	clt := Client(cc, client)
+	if _, err := clt.Write([]byte("hello")); err != nil {
+		return ConnectionState{}, err
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(clt, buf); err != nil {
+		return ConnectionState{}, err
+	}
+	if err := <-errCh; err != nil {
+		return ConnectionState{}, err
+	}
+	return clt.ConnectionState(), nil
+}
+
+func TestGMHandshake(t *testing.T) {
+	server := gmTestServerConfig(t)
+	client := gmTestClientConfig(t)
+	client.ClientSessionCache = NewLRUClientSessionCache(1)
+	state, err := gmHandshake(server, client)
+	if err != nil {
+		t.Fatalf("gm handshake failed: %v", err)
+	}
+	if state.Version != VersionGMSSL || state.CipherSuite != GMTLS_ECC_SM4_CBC_SM3 || len(state.GMPeerCertificates) != 2 {
+		t.Fatalf("unexpected connection state: %x %x %d", state.Version, state.CipherSuite, len(state.GMPeerCertificates))
+	}
+	// resume the session
+	state, err = gmHandshake(server, client)
+	if err != nil {
+		t.Fatalf("gm resume handshake failed: %v", err)
+	}
+	if !state.DidResume {
+		t.Fatal("the gm session is not resumed")
+	}
+}
+
+func TestGMHandshakeFailed(t *testing.T) {
+	server := gmTestServerConfig(t)
+	// the tls client can not connect to the gm server
+	if _, err := gmHandshake(server, &Config{InsecureSkipVerify: true}); err == nil {
+		t.Fatal("expected an error for the tls client")
+	}
+	// the server name is not matched
+	client := gmTestClientConfig(t)
+	client.ServerName = "www.unknown.com"
+	if _, err := gmHandshake(server, client); err == nil {
+		t.Fatal("expected an error for the unknown server name")
+	}
+	// the root ca is not trusted
+	client = gmTestClientConfig(t)
+	client.GMRootCAs = sm2.NewCertPool()
+	if _, err := gmHandshake(server, client); err == nil {
+		t.Fatal("expected an error for the untrusted certificate")
+	}
+	// client certificates are not supported
+	server.ClientAuth = RequireAnyClientCert
+	if _, err := gmHandshake(server, gmTestClientConfig(t)); err == nil {
+		t.Fatal("expected an error for the client certificate")
+	}
+}
+
+func TestGMX509KeyPair(t *testing.T) {
+	a, err := certtool.SignGMCertificate("a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := certtool.SignGMCertificate("b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GMX509KeyPair([]byte(a.CertPem), []byte(b.KeyPem)); err == nil {
+		t.Fatal("expected an error for the mismatched key")
+	}
+	if _, err := GMX509KeyPair([]byte(a.KeyPem), []byte(a.KeyPem)); err == nil {
+		t.Fatal("expected an error for the invalid certificate")
+	}
+}
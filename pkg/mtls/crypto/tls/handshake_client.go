@@ -69,6 +69,10 @@ func (c *Conn) clientHandshake() error {
 		alpnProtocols:                c.config.NextProtos,
 	}
 
+	if c.config.GMSupport {
+		hello.vers = VersionGMSSL
+	}
+
 	if c.handshakes > 0 {
 		hello.secureRenegotiation = c.clientFinished[:]
 	}
@@ -87,6 +91,10 @@ NextCipherSuite:
 			if hello.vers < VersionTLS12 && suite.flags&suiteTLS12 != 0 {
 				continue
 			}
+			// The GM cipher suites are only used in the GM handshake.
+			if (suite.flags&suiteGM != 0) != (hello.vers == VersionGMSSL) {
+				continue
+			}
 			hello.cipherSuites = append(hello.cipherSuites, suiteId)
 			continue NextCipherSuite
 		}
@@ -134,6 +142,9 @@ NextCipherSuite:
 
 			versOk := candidateSession.vers >= c.config.minVersion() &&
 				candidateSession.vers <= c.config.maxVersion()
+			if c.config.GMSupport {
+				versOk = candidateSession.vers == VersionGMSSL
+			}
 			if versOk && cipherSuiteOk {
 				session = candidateSession
 			}
@@ -166,9 +177,15 @@ NextCipherSuite:
 		return unexpectedMessageError(serverHello, msg)
 	}
 
-	vers, ok := c.config.mutualVersion(serverHello.vers)
-	if !ok || vers < VersionTLS10 {
+	var vers uint16
+	if c.config.GMSupport {
+		vers, ok = VersionGMSSL, serverHello.vers == VersionGMSSL
+	} else {
+		vers, ok = c.config.mutualVersion(serverHello.vers)
 		// TLS 1.0 is the minimum version supported as a client.
+		ok = ok && vers >= VersionTLS10
+	}
+	if !ok {
 		c.sendAlert(alertProtocolVersion)
 		return fmt.Errorf("tls: server selected unsupported protocol version %x", serverHello.vers)
 	}
@@ -270,7 +287,11 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 	}
 	hs.finishedHash.Write(certMsg.marshal())
 
-	if c.handshakes == 0 {
+	if c.vers == VersionGMSSL {
+		if err := hs.processGMCertificates(certMsg.certificates); err != nil {
+			return err
+		}
+	} else if c.handshakes == 0 {
 		// If this is the first handshake on a connection, process and
 		// (optionally) verify the server's certificates.
 		certs := make([]*x509.Certificate, len(certMsg.certificates))
@@ -356,11 +377,19 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 	}
 
 	keyAgreement := hs.suite.ka(c.vers)
+	if ka, ok := keyAgreement.(*eccGMKeyAgreement); ok {
+		ka.peerCertificates = c.gmPeerCertificates
+	}
+	// the x509 certificate is not used in the GM handshake
+	var serverCert *x509.Certificate
+	if len(c.peerCertificates) > 0 {
+		serverCert = c.peerCertificates[0]
+	}
 
 	skx, ok := msg.(*serverKeyExchangeMsg)
 	if ok {
 		hs.finishedHash.Write(skx.marshal())
-		err = keyAgreement.processServerKeyExchange(c.config, hs.hello, hs.serverHello, c.peerCertificates[0], skx)
+		err = keyAgreement.processServerKeyExchange(c.config, hs.hello, hs.serverHello, serverCert, skx)
 		if err != nil {
 			c.sendAlert(alertUnexpectedMessage)
 			return err
@@ -379,7 +408,11 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 		certRequested = true
 		hs.finishedHash.Write(certReq.marshal())
 
-		if chainToSend, err = hs.getCertificate(certReq); err != nil {
+		if c.vers == VersionGMSSL {
+			// Client certificates are not supported in the GM handshake,
+			// an empty certificate is sent.
+			chainToSend = new(Certificate)
+		} else if chainToSend, err = hs.getCertificate(certReq); err != nil {
 			c.sendAlert(alertInternalError)
 			return err
 		}
@@ -409,7 +442,7 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 		}
 	}
 
-	preMasterSecret, ckx, err := keyAgreement.generateClientKeyExchange(c.config, hs.hello, c.peerCertificates[0])
+	preMasterSecret, ckx, err := keyAgreement.generateClientKeyExchange(c.config, hs.hello, serverCert)
 	if err != nil {
 		c.sendAlert(alertInternalError)
 		return err
@@ -14,6 +14,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+
+	"github.com/tjfoc/gmsm/sm2"
 )
 
 // serverHandshakeState contains details of a server handshake in progress.
@@ -27,6 +29,7 @@ type serverHandshakeState struct {
 	ecdsaOk               bool
 	rsaDecryptOk          bool
 	rsaSignOk             bool
+	sm2SignOk             bool
 	sessionState          *sessionState
 	finishedHash          finishedHash
 	masterSecret          []byte
@@ -136,12 +139,20 @@ func (hs *serverHandshakeState) readClientHello() (isResume bool, err error) {
 		}
 	}
 
-	c.vers, ok = c.config.mutualVersion(hs.clientHello.vers)
+	if c.config.GMSupport {
+		c.vers, ok = VersionGMSSL, hs.clientHello.vers == VersionGMSSL
+	} else {
+		c.vers, ok = c.config.mutualVersion(hs.clientHello.vers)
+	}
 	if !ok {
 		c.sendAlert(alertProtocolVersion)
 		return false, fmt.Errorf("tls: client offered an unsupported, maximum protocol version of %x", hs.clientHello.vers)
 	}
 	c.haveVers = true
+	if c.vers == VersionGMSSL && c.config.ClientAuth != NoClientCert {
+		c.sendAlert(alertInternalError)
+		return false, errors.New("tls: client certificates are not supported in the GM handshake")
+	}
 
 	hs.hello = new(serverHelloMsg)
 
@@ -230,6 +241,8 @@ Curves:
 			hs.ecdsaOk = true
 		case *rsa.PublicKey:
 			hs.rsaSignOk = true
+		case *sm2.PublicKey:
+			hs.sm2SignOk = true
 		default:
 			c.sendAlert(alertInternalError)
 			return false, fmt.Errorf("tls: unsupported signing key type (%T)", priv.Public())
@@ -383,6 +396,9 @@ func (hs *serverHandshakeState) doFullHandshake() error {
 
 	certMsg := new(certificateMsg)
 	certMsg.certificates = hs.cert.Certificate
+	if c.vers == VersionGMSSL {
+		certMsg.certificates = gmCertificates(hs.cert, c.config.GMEncCertificate)
+	}
 	hs.finishedHash.Write(certMsg.marshal())
 	if _, err := c.writeRecord(recordTypeHandshake, certMsg.marshal()); err != nil {
 		return err
@@ -779,9 +795,17 @@ func (hs *serverHandshakeState) setCipherSuite(id uint16, supportedCipherSuites
 			if candidate == nil {
 				continue
 			}
+			// The GM cipher suites are only used in the GM handshake.
+			if (candidate.flags&suiteGM != 0) != (version == VersionGMSSL) {
+				continue
+			}
 			// Don't select a ciphersuite which we can't
 			// support for this client.
-			if candidate.flags&suiteECDHE != 0 {
+			if candidate.flags&suiteGM != 0 {
+				if !hs.sm2SignOk || hs.c.config.GMEncCertificate == nil {
+					continue
+				}
+			} else if candidate.flags&suiteECDHE != 0 {
 				if !hs.ellipticOk {
 					continue
 				}
@@ -818,6 +842,8 @@ func (hs *serverHandshakeState) clientHelloInfo() *ClientHelloInfo {
 		supportedVersions = suppVersArray[:]
 	} else if hs.clientHello.vers >= VersionSSL30 {
 		supportedVersions = suppVersArray[VersionTLS12-hs.clientHello.vers:]
+	} else if hs.clientHello.vers == VersionGMSSL {
+		supportedVersions = []uint16{VersionGMSSL}
 	}
 
 	signatureSchemes := make([]SignatureScheme, 0, len(hs.clientHello.signatureAndHashes))
@@ -13,6 +13,8 @@ import (
 	"crypto/sha512"
 	"errors"
 	"hash"
+
+	"github.com/tjfoc/gmsm/sm3"
 )
 
 // Split a premaster secret in two as specified in RFC 4346, section 5.
@@ -132,6 +134,9 @@ func prfAndHashForVersion(version uint16, suite *cipherSuite) (func(result, secr
 			return prf12(sha512.New384), crypto.SHA384
 		}
 		return prf12(sha256.New), crypto.SHA256
+	case VersionGMSSL:
+		// the GM/T 0024 prf is the TLS 1.2 prf with SM3
+		return prf12(sm3.New), crypto.Hash(0)
 	default:
 		panic("unknown version")
 	}
@@ -201,6 +206,9 @@ func newFinishedHash(version uint16, cipherSuite *cipherSuite) finishedHash {
 	}
 
 	prf, hash := prfAndHashForVersion(version, cipherSuite)
+	if version == VersionGMSSL {
+		return finishedHash{sm3.New(), sm3.New(), nil, nil, buffer, version, prf}
+	}
 	if hash != 0 {
 		return finishedHash{hash.New(), hash.New(), nil, nil, buffer, version, prf}
 	}
@@ -229,7 +237,7 @@ func (h *finishedHash) Write(msg []byte) (n int, err error) {
 	h.client.Write(msg)
 	h.server.Write(msg)
 
-	if h.version < VersionTLS12 {
+	if h.clientMD5 != nil {
 		h.clientMD5.Write(msg)
 		h.serverMD5.Write(msg)
 	}
@@ -242,7 +250,7 @@ func (h *finishedHash) Write(msg []byte) (n int, err error) {
 }
 
 func (h finishedHash) Sum() []byte {
-	if h.version >= VersionTLS12 {
+	if h.clientMD5 == nil {
 		return h.client.Sum(nil)
 	}
 
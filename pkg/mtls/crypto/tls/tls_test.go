@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/tjfoc/gmsm/sm2"
 	"mosn.io/mosn/pkg/mtls/crypto/tls/testenv"
 )
 
@@ -639,6 +640,10 @@ func TestCloneNonFuncFields(t *testing.T) {
 			f.Set(reflect.ValueOf(map[string]*Certificate{"a": nil}))
 		case "RootCAs", "ClientCAs":
 			f.Set(reflect.ValueOf(x509.NewCertPool()))
+		case "GMRootCAs":
+			f.Set(reflect.ValueOf(sm2.NewCertPool()))
+		case "GMEncCertificate":
+			f.Set(reflect.ValueOf(&Certificate{Certificate: [][]byte{{'c'}}}))
 		case "ClientSessionCache":
 			f.Set(reflect.ValueOf(NewLRUClientSessionCache(10)))
 		case "KeyLogWriter":
@@ -649,7 +654,7 @@ func TestCloneNonFuncFields(t *testing.T) {
 			f.Set(reflect.ValueOf("b"))
 		case "ClientAuth":
 			f.Set(reflect.ValueOf(VerifyClientCertIfGiven))
-		case "InsecureSkipVerify", "SessionTicketsDisabled", "DynamicRecordSizingDisabled", "PreferServerCipherSuites":
+		case "InsecureSkipVerify", "SessionTicketsDisabled", "DynamicRecordSizingDisabled", "PreferServerCipherSuites", "GMSupport":
 			f.Set(reflect.ValueOf(true))
 		case "MinVersion", "MaxVersion":
 			f.Set(reflect.ValueOf(uint16(VersionTLS12)))
@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/types"
+)
+
+// ErrorGMClientCert represents the gm tls config requires the client certificate
+var ErrorGMClientCert = errors.New("client certificate is not supported in gm tls")
+
+// gmProvider is implemented by the providers that can make the gm tls config
+type gmProvider interface {
+	isGM() bool
+}
+
+func isGMProvider(p types.TLSProvider) bool {
+	gp, ok := p.(gmProvider)
+	return ok && gp.isGM()
+}
+
+// isGMClientHello reports whether the client hello is sent by a gm client
+func isGMClientHello(info *tls.ClientHelloInfo) bool {
+	return len(info.SupportedVersions) == 1 && info.SupportedVersions[0] == tls.VersionGMSSL
+}
+
+// newGMTLSContext makes a tls context for the GM/T 0024 tls.
+// the server needs a SM2 signature certificate and a SM2 encryption certificate,
+// the client verifies the server's certificates by the SM2 root ca.
+func newGMTLSContext(cfg *v2.TLSConfig, secret *secretInfo) (*tlsContext, error) {
+	if cfg.RequireClientCert {
+		return nil, ErrorGMClientCert
+	}
+	tmpl, err := tlsConfigTemplate(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := setGMCipherSuites(tmpl); err != nil {
+		return nil, err
+	}
+	tmpl.GMSupport = true
+	if tmpl.GMRootCAs, err = getGMPool(secret.Validation); err != nil {
+		return nil, err
+	}
+	ctx := &tlsContext{
+		serverName: cfg.ServerName,
+		ticket:     cfg.Ticket,
+		gm:         true,
+	}
+	sign, err := getGMCertificate(secret.Certificate, secret.PrivateKey)
+	switch err {
+	case ErrorNoCertConfigure:
+		// no certificate
+	case nil:
+		enc, err := getGMCertificate(secret.EncCertificate, secret.EncPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("get gm encryption certificate failed: %v", err)
+		}
+		tmpl.Certificates = append(tmpl.Certificates, sign)
+		tmpl.GMEncCertificate = &enc
+	default:
+		// get certificate failed, if fallback is configured, it is ok
+		if !cfg.Fallback {
+			return nil, err
+		}
+		log.DefaultLogger.Alertf(types.ErrorKeyTLSFallback, "get gm certificate failed: %v, fallback tls", err)
+	}
+	if len(tmpl.Certificates) > 0 {
+		ctx.server = tmpl.Clone()
+		ctx.server.ClientAuth = tls.NoClientCert
+		ctx.buildMatch()
+	}
+	client := tmpl.Clone()
+	client.ServerName = cfg.ServerName
+	hooks := getFactory(cfg.Type).CreateConfigHooks(cfg.ExtendVerify)
+	client.VerifyPeerCertificate = hooks.ClientHandshakeVerify(client)
+	if client.VerifyPeerCertificate != nil {
+		// use self verify, skip normal verify
+		client.InsecureSkipVerify = true
+	}
+	if cfg.InsecureSkip {
+		client.InsecureSkipVerify = true
+		client.VerifyPeerCertificate = nil
+	}
+	ctx.client = client
+	return ctx, nil
+}
+
+// setGMCipherSuites sets the gm cipher suites if no cipher suites configured,
+// or checks the configured cipher suites contain a gm cipher suite
+func setGMCipherSuites(c *tls.Config) error {
+	if len(c.CipherSuites) == 0 {
+		c.CipherSuites = gmCiphers
+		return nil
+	}
+	for _, cipher := range c.CipherSuites {
+		for _, gm := range gmCiphers {
+			if cipher == gm {
+				return nil
+			}
+		}
+	}
+	return errors.New("no gm cipher suites configured")
+}
+
+// getGMCertificate returns the SM2 certificate if the index is cert/key file or pem string
+func getGMCertificate(certIndex, keyIndex string) (tls.Certificate, error) {
+	if certIndex == "" || keyIndex == "" {
+		return tls.Certificate{}, ErrorNoCertConfigure
+	}
+	if strings.Contains(certIndex, "-----BEGIN") && strings.Contains(keyIndex, "-----BEGIN") {
+		return tls.GMX509KeyPair([]byte(certIndex), []byte(keyIndex))
+	}
+	certPEMBlock, err := ioutil.ReadFile(certIndex)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEMBlock, err := ioutil.ReadFile(keyIndex)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.GMX509KeyPair(certPEMBlock, keyPEMBlock)
+}
+
+// getGMPool returns a SM2 CertPool with index's file or pem string
+func getGMPool(caIndex string) (*sm2.CertPool, error) {
+	if caIndex == "" {
+		return nil, nil
+	}
+	var caBytes []byte
+	var err error
+	if strings.Contains(caIndex, "-----BEGIN") {
+		caBytes = []byte(caIndex)
+	} else {
+		caBytes, err = ioutil.ReadFile(caIndex)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load gm ca certificate error: %v", err)
+	}
+	pool := sm2.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(caBytes); !ok {
+		return nil, fmt.Errorf("load gm ca certificate error: no certificate")
+	}
+	return pool, nil
+}
+
+// certificateNames returns the common name and the dns names of a certificate,
+// the SM2 certificates are parsed by the sm2 package
+func certificateNames(der []byte) []string {
+	var cn string
+	var dns []string
+	if cert, err := x509.ParseCertificate(der); err == nil {
+		cn, dns = cert.Subject.CommonName, cert.DNSNames
+	} else if cert, err := sm2.ParseCertificate(der); err == nil {
+		cn, dns = cert.Subject.CommonName, cert.DNSNames
+	} else {
+		return nil
+	}
+	names := make([]string, 0, len(dns)+1)
+	if cn != "" {
+		names = append(names, cn)
+	}
+	return append(names, dns...)
+}
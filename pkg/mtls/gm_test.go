@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"net"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls/certtool"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/types"
+)
+
+func createGMConfig(t *testing.T, dns string) *v2.TLSConfig {
+	sign, err := certtool.SignGMCertificate("gm sign", []string{dns})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := certtool.SignGMCertificate("gm enc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v2.TLSConfig{
+		Status:        true,
+		GM:            true,
+		CACert:        certtool.GetGMRootCA().CertPem,
+		CertChain:     sign.CertPem,
+		PrivateKey:    sign.KeyPem,
+		EncCertChain:  enc.CertPem,
+		EncPrivateKey: enc.KeyPem,
+	}
+}
+
+// gmHandshake makes a tls handshake on a pipe, returns the client's connection state
+func gmHandshake(t *testing.T, server types.TLSContextManager, client types.TLSProvider) tls.ConnectionState {
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Server(sc, server.(*serverContextManager).config.Clone()).Handshake()
+	}()
+	clt := tls.Client(cc, client.GetTLSConfig(true))
+	if err := clt.Handshake(); err != nil {
+		t.Fatalf("client handshake failed: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("server handshake failed: %v", err)
+	}
+	return clt.ConnectionState()
+}
+
+func TestGMTLSContext(t *testing.T) {
+	gmCfg := createGMConfig(t, "gm.example.com")
+	info := &certInfo{"rsa", "RSA", "www.example.com"}
+	rsaCfg, err := info.CreateCertConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a listener with both gm and normal certificates
+	lc := &v2.Listener{}
+	lc.FilterChains = []v2.FilterChain{
+		{
+			TLSContexts: []v2.TLSConfig{*rsaCfg, *gmCfg},
+		},
+	}
+	mng, err := NewTLSServerContextManager(lc)
+	if err != nil {
+		t.Fatalf("create context manager failed %v", err)
+	}
+	gmClient, err := NewProvider(&v2.TLSConfig{
+		Status:     true,
+		GM:         true,
+		ServerName: "gm.example.com",
+		CACert:     gmCfg.CACert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := gmHandshake(t, mng, gmClient)
+	if state.Version != tls.VersionGMSSL || state.CipherSuite != tls.GMTLS_ECC_SM4_CBC_SM3 {
+		t.Fatalf("unexpected gm connection state, version: %x, cipher: %x", state.Version, state.CipherSuite)
+	}
+	if len(state.GMPeerCertificates) != 2 || state.GMPeerCertificates[0].Subject.CommonName != "gm sign" {
+		t.Fatalf("unexpected gm peer certificates: %v", state.GMPeerCertificates)
+	}
+	// the normal client gets the normal certificate
+	client, err := NewProvider(&v2.TLSConfig{
+		Status:       true,
+		ServerName:   "gm.example.com",
+		InsecureSkip: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = gmHandshake(t, mng, client)
+	if state.Version != tls.VersionTLS12 || state.PeerCertificates[0].Subject.CommonName != "rsa" {
+		t.Fatalf("unexpected connection state, version: %x", state.Version)
+	}
+}
+
+func TestGMTLSContextConfig(t *testing.T) {
+	cfg := createGMConfig(t, "gm.example.com")
+	cfg.RequireClientCert = true
+	if _, err := NewProvider(cfg); err != ErrorGMClientCert {
+		t.Fatalf("expected client certificate error, but got %v", err)
+	}
+	cfg = createGMConfig(t, "gm.example.com")
+	cfg.CipherSuites = "ECDHE-RSA-AES256-GCM-SHA384"
+	if _, err := NewProvider(cfg); err == nil {
+		t.Fatal("expected an error for no gm cipher suites")
+	}
+	cfg = createGMConfig(t, "gm.example.com")
+	cfg.EncPrivateKey = ""
+	if _, err := NewProvider(cfg); err == nil {
+		t.Fatal("expected an error for no encryption certificate")
+	}
+	// the gm context matches the server name by the sm2 certificate
+	p, err := NewProvider(createGMConfig(t, "gm.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.MatchedServerName("gm.example.com") || !p.MatchedServerName("gm sign") {
+		t.Fatal("the gm server name is not matched")
+	}
+}
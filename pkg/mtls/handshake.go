@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/x509"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/types"
+)
+
+const handshakeProtocol = "tls"
+
+// the causes of the failed handshakes
+const (
+	HandshakeFailureTimeout       = "timeout"
+	HandshakeFailureCertVerify    = "cert_verify"
+	HandshakeFailureNoCertificate = "no_certificate"
+	HandshakeFailureALPNMismatch  = "alpn_mismatch"
+	HandshakeFailureClosed        = "closed"
+	HandshakeFailureProtocol      = "protocol"
+)
+
+// handshakeRecorder records the stats of the handshake once, the handshake is made by
+// the first read or write of the connection
+type handshakeRecorder struct {
+	once sync.Once
+	err  error
+	side string
+	// alpn is the protocols offered by the client
+	alpn []string
+}
+
+// done marks the handshake recorded without a handshake
+func (r *handshakeRecorder) done() {
+	r.once.Do(func() {})
+}
+
+func newServerTLSConn(conn *tls.Conn) *TLSConn {
+	c := &TLSConn{Conn: conn}
+	c.handshake.side = metrics.HandshakeSideServer
+	return c
+}
+
+func newClientTLSConn(conn *tls.Conn, alpn []string) *TLSConn {
+	c := &TLSConn{Conn: conn}
+	c.handshake.side = metrics.HandshakeSideClient
+	c.handshake.alpn = alpn
+	return c
+}
+
+// Handshake runs the TLS handshake if it has not been run, the handshake is recorded by the stats,
+// and the failure is logged with the cause
+func (c *TLSConn) Handshake() error {
+	r := &c.handshake
+	r.once.Do(func() {
+		start := time.Now()
+		c.recordHandshake(c.Conn.Handshake(), time.Since(start))
+	})
+	return r.err
+}
+
+// abortHandshake fails the handshake without running it, the failure is recorded as a handshake failure
+func (c *TLSConn) abortHandshake(err error, duration time.Duration) error {
+	r := &c.handshake
+	r.once.Do(func() {
+		c.recordHandshake(err, duration)
+	})
+	return r.err
+}
+
+func (c *TLSConn) recordHandshake(err error, duration time.Duration) {
+	r := &c.handshake
+	r.err = err
+	if err != nil {
+		cause := handshakeFailureCause(err)
+		metrics.RecordHandshake(handshakeProtocol, r.side, duration, cause)
+		log.DefaultLogger.Alertf(types.ErrorKeyTLSHandshake+cause, "[mtls] %s handshake with %s failed, cause: %s, duration: %v, error: %v",
+			r.side, remoteAddr(c.Conn), cause, duration, err)
+		return
+	}
+	metrics.RecordHandshake(handshakeProtocol, r.side, duration, "")
+	if len(r.alpn) > 0 && c.Conn.ConnectionState().NegotiatedProtocol == "" {
+		metrics.NewHandshakeStats(handshakeProtocol, r.side).Counter(metrics.HandshakeALPNMismatch).Inc(1)
+		log.DefaultLogger.Warnf("[mtls] %s handshake with %s negotiates none of the protocols %v",
+			r.side, remoteAddr(c.Conn), r.alpn)
+	}
+}
+
+// Read reads data from the connection, the handshake is made first
+func (c *TLSConn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// Write writes data to the connection, the handshake is made first
+func (c *TLSConn) Write(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+func remoteAddr(c *tls.Conn) string {
+	if addr := c.RemoteAddr(); addr != nil {
+		return addr.String()
+	}
+	return ""
+}
+
+// handshakeFailureCause classifies the handshake error, the alerts of the peer are classified by the messages
+func handshakeFailureCause(err error) string {
+	if err == ErrorNoCertConfigure {
+		return HandshakeFailureNoCertificate
+	}
+	switch e := err.(type) {
+	case x509.UnknownAuthorityError, x509.CertificateInvalidError, x509.HostnameError,
+		*x509.UnknownAuthorityError, *x509.CertificateInvalidError, *x509.HostnameError:
+		return HandshakeFailureCertVerify
+	case net.Error:
+		if e.Timeout() {
+			return HandshakeFailureTimeout
+		}
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return HandshakeFailureClosed
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no application protocol"):
+		return HandshakeFailureALPNMismatch
+	case strings.Contains(msg, "certificate"):
+		return HandshakeFailureCertVerify
+	case strings.Contains(msg, "connection reset") || strings.Contains(msg, "use of closed"):
+		return HandshakeFailureClosed
+	}
+	return HandshakeFailureProtocol
+}
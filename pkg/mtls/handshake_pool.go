@@ -0,0 +1,123 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultHandshakeQueueSize = 1024
+	defaultHandshakeTimeout   = 10 * time.Second
+)
+
+// ErrHandshakeQueueFull is returned when the handshake queue of the pool is full
+var ErrHandshakeQueueFull = errors.New("tls handshake queue is full")
+
+type handshakeTask struct {
+	conn *TLSConn
+	done func(err error)
+}
+
+// HandshakePool runs the TLS handshakes of the accepted connections in a bounded number of workers,
+// so a burst of new TLS connections does not stall the processing of the established connections.
+type HandshakePool struct {
+	queue    chan handshakeTask
+	timeout  time.Duration
+	depth    gometrics.Gauge
+	rejected gometrics.Counter
+}
+
+// NewHandshakePool creates a handshake pool with the workers, the default queue size and timeout are used
+// if they are not positive
+func NewHandshakePool(workers, queueSize int, timeout time.Duration) *HandshakePool {
+	if queueSize <= 0 {
+		queueSize = defaultHandshakeQueueSize
+	}
+	if timeout <= 0 {
+		timeout = defaultHandshakeTimeout
+	}
+	s := metrics.NewHandshakeStats(handshakeProtocol, metrics.HandshakeSideServer)
+	p := &HandshakePool{
+		queue:    make(chan handshakeTask, queueSize),
+		timeout:  timeout,
+		depth:    s.Gauge(metrics.HandshakeQueueDepth),
+		rejected: s.Counter(metrics.HandshakeQueueRejected),
+	}
+	for i := 0; i < workers; i++ {
+		utils.GoWithRecover(p.work, nil)
+	}
+	return p
+}
+
+// Handshake runs the handshake of the connection in the workers, and calls done with the handshake result.
+// The done is called immediately if the connection is not a TLS connection, or the ClientHello is not
+// received in the timeout, the ClientHello is read in the calling goroutine.
+// ErrHandshakeQueueFull is returned if the queue is full, and the done is not called.
+func (p *HandshakePool) Handshake(conn net.Conn, done func(err error)) error {
+	tlsConn, ok := conn.(*TLSConn)
+	if !ok {
+		done(nil)
+		return nil
+	}
+	// the ClientHello is read in the calling goroutine before the handshake is queued,
+	// so the connections that send nothing do not occupy the workers.
+	if raw, ok := tlsConn.GetRawConn().(*Conn); ok {
+		start := time.Now()
+		if _, err := raw.peekClientHello(start.Add(p.timeout)); err != nil {
+			done(tlsConn.abortHandshake(err, time.Since(start)))
+			return nil
+		}
+	}
+	select {
+	case p.queue <- handshakeTask{conn: tlsConn, done: done}:
+		p.depth.Update(int64(len(p.queue)))
+		return nil
+	default:
+		p.rejected.Inc(1)
+		log.DefaultLogger.Errorf("[mtls] [handshake pool] reject the handshake with %s, queue is full", remoteAddr(tlsConn.Conn))
+		return ErrHandshakeQueueFull
+	}
+}
+
+func (p *HandshakePool) work() {
+	for task := range p.queue {
+		p.depth.Update(int64(len(p.queue)))
+		p.handshake(task)
+	}
+}
+
+func (p *HandshakePool) handshake(task handshakeTask) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.DefaultLogger.Errorf("[mtls] [handshake pool] handshake panic: %v", r)
+		}
+	}()
+	conn := task.conn
+	conn.SetDeadline(time.Now().Add(p.timeout))
+	err := conn.Handshake()
+	conn.SetDeadline(time.Time{})
+	task.done(err)
+}
@@ -0,0 +1,220 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+)
+
+func testServerTLSConfig(t *testing.T) *tls.Config {
+	info := &certInfo{
+		CommonName: "test",
+		Curve:      "P256",
+		DNS:        "www.test.com",
+	}
+	secret, _ := info.CreateSecret()
+	ctx, err := newTLSContext(&v2.TLSConfig{Status: true}, secret)
+	if err != nil {
+		t.Fatalf("create tls context failed, %v", err)
+	}
+	return ctx.GetTLSConfig(false)
+}
+
+func TestHandshakePool(t *testing.T) {
+	metrics.ResetAll()
+	pool := NewHandshakePool(1, 0, 0)
+	// not a tls connection
+	s, c := net.Pipe()
+	defer s.Close()
+	defer c.Close()
+	called := false
+	if err := pool.Handshake(s, func(err error) { called = err == nil }); err != nil || !called {
+		t.Fatal("done should be called immediately for the non tls connection")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+		client.Handshake()
+		client.Close()
+	}()
+	rawc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	defer rawc.Close()
+	result := make(chan error, 1)
+	if err := pool.Handshake(newServerTLSConn(tls.Server(rawc, testServerTLSConfig(t))), func(err error) {
+		result <- err
+	}); err != nil {
+		t.Fatalf("handshake is not queued: %v", err)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("handshake failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handshake is not done")
+	}
+}
+
+func TestHandshakePoolTimeout(t *testing.T) {
+	metrics.ResetAll()
+	pool := NewHandshakePool(1, 0, 50*time.Millisecond)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	// the client never sends the client hello
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	rawc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	defer rawc.Close()
+	result := make(chan error, 1)
+	pool.Handshake(newServerTLSConn(tls.Server(rawc, testServerTLSConfig(t))), func(err error) {
+		result <- err
+	})
+	select {
+	case err := <-result:
+		if err == nil || handshakeFailureCause(err) != HandshakeFailureTimeout {
+			t.Fatalf("handshake should be timeout, but got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handshake is not timeout")
+	}
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeFailedPrefix+HandshakeFailureTimeout) != 1 {
+		t.Fatal("handshake timeout is not recorded")
+	}
+}
+
+func TestHandshakePoolQueueFull(t *testing.T) {
+	metrics.ResetAll()
+	// no workers, the handshakes are queued only
+	pool := NewHandshakePool(0, 1, 0)
+	done := func(err error) {
+		t.Error("handshake should not be done")
+	}
+	s1, c1 := net.Pipe()
+	defer s1.Close()
+	defer c1.Close()
+	if err := pool.Handshake(newServerTLSConn(tls.Server(s1, &tls.Config{})), done); err != nil {
+		t.Fatalf("handshake is not queued: %v", err)
+	}
+	s2, c2 := net.Pipe()
+	defer s2.Close()
+	defer c2.Close()
+	if err := pool.Handshake(newServerTLSConn(tls.Server(s2, &tls.Config{})), done); err != ErrHandshakeQueueFull {
+		t.Fatalf("handshake should be rejected, but got: %v", err)
+	}
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeQueueRejected) != 1 {
+		t.Error("rejected handshake is not recorded")
+	}
+	if metrics.NewHandshakeStats(handshakeProtocol, metrics.HandshakeSideServer).Gauge(metrics.HandshakeQueueDepth).Value() != 1 {
+		t.Error("handshake queue depth is not recorded")
+	}
+}
+
+func TestHandshakePoolSilentConnections(t *testing.T) {
+	metrics.ResetAll()
+	pool := NewHandshakePool(1, 0, 2*time.Second)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	config := testServerTLSConfig(t)
+	// the silent clients never send the client hello
+	silent := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		defer conn.Close()
+		rawc, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("accept failed: %v", err)
+		}
+		defer rawc.Close()
+		go pool.Handshake(newServerTLSConn(tls.Server(NewPeekConn(rawc), config)), func(err error) {
+			silent <- err
+		})
+	}
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return
+		}
+		client := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+		client.Handshake()
+		client.Close()
+	}()
+	rawc, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	defer rawc.Close()
+	result := make(chan error, 1)
+	go pool.Handshake(newServerTLSConn(tls.Server(NewPeekConn(rawc), config)), func(err error) {
+		result <- err
+	})
+	// the good handshake is not blocked by the silent connections
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("handshake failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handshake is blocked by the silent connections")
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-silent:
+			if err == nil || handshakeFailureCause(err) != HandshakeFailureTimeout {
+				t.Fatalf("handshake should be timeout, but got: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("handshake is not timeout")
+		}
+	}
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeFailedPrefix+HandshakeFailureTimeout) != 3 {
+		t.Fatal("handshake timeout is not recorded")
+	}
+}
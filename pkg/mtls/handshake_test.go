@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestHandshakeFailureCause(t *testing.T) {
+	for _, tc := range []struct {
+		err   error
+		cause string
+	}{
+		{ErrorNoCertConfigure, HandshakeFailureNoCertificate},
+		{x509.UnknownAuthorityError{}, HandshakeFailureCertVerify},
+		{x509.HostnameError{Host: "www.test.com", Certificate: &x509.Certificate{}}, HandshakeFailureCertVerify},
+		{errors.New("remote error: tls: bad certificate"), HandshakeFailureCertVerify},
+		{errors.New("tls: no application protocol"), HandshakeFailureALPNMismatch},
+		{&net.OpError{Op: "read", Err: timeoutError{}}, HandshakeFailureTimeout},
+		{io.EOF, HandshakeFailureClosed},
+		{errors.New("tls: unsupported SSLv2 handshake received"), HandshakeFailureProtocol},
+	} {
+		if cause := handshakeFailureCause(tc.err); cause != tc.cause {
+			t.Errorf("error %v expected cause %s, but got %s", tc.err, tc.cause, cause)
+		}
+	}
+}
+
+func handshakeCount(side, key string) int64 {
+	return metrics.NewHandshakeStats(handshakeProtocol, side).Counter(key).Count()
+}
+
+func handshakeLoopback(t *testing.T, serverConfig, clientConfig *tls.Config) (error, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	errc := make(chan error, 1)
+	go func() {
+		s, err := ln.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		server := newServerTLSConn(tls.Server(s, serverConfig))
+		errc <- server.Handshake()
+		s.Close()
+	}()
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	client := newClientTLSConn(tls.Client(c, clientConfig), clientConfig.NextProtos)
+	clientErr := client.Handshake()
+	serverErr := <-errc
+	// the handshake is made once
+	client.Handshake()
+	c.Close()
+	return serverErr, clientErr
+}
+
+func TestHandshakeStats(t *testing.T) {
+	info := &certInfo{
+		CommonName: "test",
+		Curve:      "P256",
+		DNS:        "www.test.com",
+	}
+	secret, _ := info.CreateSecret()
+	ctx, err := newTLSContext(&v2.TLSConfig{
+		Status: true,
+		ALPN:   "h2",
+	}, secret)
+	if err != nil {
+		t.Fatalf("create tls context failed, %v", err)
+	}
+	// success
+	metrics.ResetAll()
+	serverErr, clientErr := handshakeLoopback(t, ctx.GetTLSConfig(false), &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"sofa"},
+	})
+	if serverErr != nil || clientErr != nil {
+		t.Fatalf("handshake failed, server: %v, client: %v", serverErr, clientErr)
+	}
+	for _, side := range []string{metrics.HandshakeSideServer, metrics.HandshakeSideClient} {
+		if handshakeCount(side, metrics.HandshakeTotal) != 1 || handshakeCount(side, metrics.HandshakeSuccess) != 1 {
+			t.Errorf("%s handshake success is not recorded", side)
+		}
+	}
+	if handshakeCount(metrics.HandshakeSideClient, metrics.HandshakeALPNMismatch) != 1 {
+		t.Error("client alpn mismatch is not recorded")
+	}
+	// the client does not trust the server certificate
+	metrics.ResetAll()
+	_, clientErr = handshakeLoopback(t, ctx.GetTLSConfig(false), &tls.Config{
+		ServerName: "www.test.com",
+	})
+	if clientErr == nil {
+		t.Fatal("client handshake should be failed")
+	}
+	if handshakeCount(metrics.HandshakeSideClient, metrics.HandshakeTotal) != 1 ||
+		handshakeCount(metrics.HandshakeSideClient, metrics.HandshakeFailedPrefix+HandshakeFailureCertVerify) != 1 {
+		t.Errorf("client handshake failure is not recorded, error: %v", clientErr)
+	}
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeFailed) != 1 {
+		t.Error("server handshake failure is not recorded")
+	}
+}
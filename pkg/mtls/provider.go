@@ -36,7 +36,7 @@ func (p *staticProvider) Empty() bool {
 }
 
 // NewProvider returns a types.Provider.
-// we support sds provider and static provider.
+// we support sds provider, secret provider and static provider.
 func NewProvider(cfg *v2.TLSConfig) (types.TLSProvider, error) {
 	if !cfg.Status {
 		return nil, nil
@@ -46,17 +46,31 @@ func NewProvider(cfg *v2.TLSConfig) (types.TLSProvider, error) {
 			return nil, ErrorNoCertConfigure
 		}
 		return getOrCreateProvider(cfg), nil
+	} else if names := referenceSecrets(cfg); len(names) > 0 {
+		p, err := newSecretProvider(cfg, names)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
 	} else {
 		// static provider
 		secret := &secretInfo{
 			Certificate: cfg.CertChain,
 			PrivateKey:  cfg.PrivateKey,
 			Validation:  cfg.CACert,
+
+			EncCertificate: cfg.EncCertChain,
+			EncPrivateKey:  cfg.EncPrivateKey,
 		}
 		ctx, err := newTLSContext(cfg, secret)
 		if err != nil {
 			return nil, err
 		}
+		tickets, err := newSessionTickets(cfg.SessionTicket)
+		if err != nil {
+			return nil, err
+		}
+		ctx.setSessionTickets(tickets)
 		return &staticProvider{
 			tlsContext: ctx,
 		}, nil
@@ -84,6 +84,11 @@ func (mng *secretManager) getOrCreateProvider(cfg *v2.TLSConfig) *sdsProvider {
 			},
 		}
 		v.certificates[certName] = p
+		tickets, err := newSessionTickets(cfg.SessionTicket)
+		if err != nil {
+			log.DefaultLogger.Errorf("[mtls] [sds provider] create session tickets failed: %v", err)
+		}
+		p.tickets = tickets
 		// set a certificate callback
 		client := GetSdsClient(cfg.SdsConfig.CertificateConfig.Config)
 		if client == nil {
@@ -121,9 +126,10 @@ func (mng *secretManager) setValidation(name string, secret *types.SdsSecret) {
 // sdsProvider stored a tls context that makes by sds
 // do not support delete certificate for sds api
 type sdsProvider struct {
-	value  atomic.Value // stored tlsContext
-	config *v2.TLSConfig
-	info   *secretInfo
+	value   atomic.Value // stored tlsContext
+	config  *v2.TLSConfig
+	info    *secretInfo
+	tickets *sessionTickets
 }
 
 func (p *sdsProvider) setValidation(v string) {
@@ -150,6 +156,7 @@ func (p *sdsProvider) update() {
 		log.DefaultLogger.Errorf("[mtls] [sds] update tls context failed: %v", err)
 		return
 	}
+	ctx.setSessionTickets(p.tickets)
 	p.value.Store(ctx)
 	log.DefaultLogger.Infof("[mtls] [sds] update tls context success")
 	// notify certificates updates
@@ -158,6 +165,10 @@ func (p *sdsProvider) update() {
 	}
 }
 
+func (p *sdsProvider) isGM() bool {
+	return p.config.GM
+}
+
 func (p *sdsProvider) GetTLSConfig(client bool) *tls.Config {
 	v := p.value.Load()
 	ctx, ok := v.(*tlsContext)
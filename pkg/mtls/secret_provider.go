@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mtls
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/secret"
+)
+
+// referenceSecrets returns the names of the secrets referenced by the certificate, private key and ca
+func referenceSecrets(cfg *v2.TLSConfig) []string {
+	var names []string
+	for _, v := range []string{cfg.CertChain, cfg.PrivateKey, cfg.CACert, cfg.EncCertChain, cfg.EncPrivateKey} {
+		if name, ok := secret.ReferenceName(v); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// secretProvider is an implementation of types.Provider
+// secretProvider stored a tls context that makes by the named secrets,
+// the tls context is rebuilt when the secrets are rotated
+type secretProvider struct {
+	value   atomic.Value // stored tlsContext
+	config  *v2.TLSConfig
+	tickets *sessionTickets
+	mutex   sync.Mutex
+}
+
+func newSecretProvider(cfg *v2.TLSConfig, names []string) (*secretProvider, error) {
+	tickets, err := newSessionTickets(cfg.SessionTicket)
+	if err != nil {
+		return nil, err
+	}
+	p := &secretProvider{
+		config:  cfg,
+		tickets: tickets,
+	}
+	if err := p.update(); err != nil {
+		return nil, err
+	}
+	watched := make(map[string]bool, len(names))
+	for _, name := range names {
+		if watched[name] {
+			continue
+		}
+		watched[name] = true
+		if err := secret.Watch(name, p.onRotate); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *secretProvider) onRotate(value []byte) {
+	if err := p.update(); err != nil {
+		log.DefaultLogger.Errorf("[mtls] [secret provider] update tls context failed: %v", err)
+		return
+	}
+	log.DefaultLogger.Infof("[mtls] [secret provider] update tls context success")
+}
+
+func (p *secretProvider) update() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	info := &secretInfo{}
+	var err error
+	if info.Certificate, err = secret.Resolve(p.config.CertChain); err != nil {
+		return err
+	}
+	if info.PrivateKey, err = secret.Resolve(p.config.PrivateKey); err != nil {
+		return err
+	}
+	if info.Validation, err = secret.Resolve(p.config.CACert); err != nil {
+		return err
+	}
+	if info.EncCertificate, err = secret.Resolve(p.config.EncCertChain); err != nil {
+		return err
+	}
+	if info.EncPrivateKey, err = secret.Resolve(p.config.EncPrivateKey); err != nil {
+		return err
+	}
+	ctx, err := newTLSContext(p.config, info)
+	if err != nil {
+		return err
+	}
+	ctx.setSessionTickets(p.tickets)
+	p.value.Store(ctx)
+	return nil
+}
+
+func (p *secretProvider) context() *tlsContext {
+	ctx, _ := p.value.Load().(*tlsContext)
+	return ctx
+}
+
+func (p *secretProvider) isGM() bool {
+	return p.config.GM
+}
+
+func (p *secretProvider) GetTLSConfig(client bool) *tls.Config {
+	return p.context().GetTLSConfig(client)
+}
+
+func (p *secretProvider) MatchedServerName(sn string) bool {
+	return p.context().MatchedServerName(sn)
+}
+
+func (p *secretProvider) MatchedALPN(protos []string) bool {
+	return p.context().MatchedALPN(protos)
+}
+
+func (p *secretProvider) Ready() bool {
+	return true
+}
+
+func (p *secretProvider) Empty() bool {
+	return p.context().server == nil
+}
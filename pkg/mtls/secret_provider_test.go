@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mtls
+
+import (
+	"sync"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/secret"
+)
+
+type memorySecretProvider struct {
+	mutex  sync.Mutex
+	values map[string]string
+}
+
+func (p *memorySecretProvider) set(key, value string) {
+	p.mutex.Lock()
+	p.values[key] = value
+	p.mutex.Unlock()
+}
+
+func (p *memorySecretProvider) Fetch(key string) ([]byte, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return []byte(p.values[key]), nil
+}
+
+func TestSecretProvider(t *testing.T) {
+	memory := &memorySecretProvider{values: map[string]string{}}
+	secret.RegisterProvider("mtls_test_memory", func(map[string]interface{}) (secret.Provider, error) {
+		return memory, nil
+	})
+	setCert := func(dns string) {
+		info := &certInfo{CommonName: dns, Curve: "P256", DNS: dns}
+		s, err := info.CreateSecret()
+		if err != nil {
+			t.Fatal(err)
+		}
+		memory.set("cert", s.Certificate)
+		memory.set("key", s.PrivateKey)
+		memory.set("ca", s.Validation)
+	}
+	setCert("a.test")
+	if err := secret.Init(&v2.SecretsConfig{
+		Providers: []v2.SecretProviderConfig{{Name: "memory", Type: "mtls_test_memory"}},
+		Secrets: []v2.SecretConfig{
+			{Name: "tls-cert", Provider: "memory", Key: "cert"},
+			{Name: "tls-key", Provider: "memory", Key: "key"},
+			{Name: "tls-ca", Provider: "memory", Key: "ca"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer secret.Stop()
+
+	provider, err := NewProvider(&v2.TLSConfig{
+		Status:     true,
+		CertChain:  "secret://tls-cert",
+		PrivateKey: "secret://tls-key",
+		CACert:     "secret://tls-ca",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := provider.(*secretProvider); !ok || provider.Empty() || !provider.Ready() {
+		t.Fatalf("unexpected provider: %T", provider)
+	}
+	if !provider.MatchedServerName("a.test") || provider.MatchedServerName("b.test") {
+		t.Fatal("unexpected server name matched")
+	}
+	// rotate the certificate
+	setCert("b.test")
+	secret.Refresh()
+	if !provider.MatchedServerName("b.test") || provider.MatchedServerName("a.test") {
+		t.Fatal("the certificate is not rotated")
+	}
+	// the invalid secrets are ignored
+	memory.set("key", "invalid")
+	secret.Refresh()
+	if !provider.MatchedServerName("b.test") {
+		t.Fatal("the certificate is changed by the invalid secret")
+	}
+
+	// unknown secret
+	if _, err := NewProvider(&v2.TLSConfig{
+		Status:     true,
+		CertChain:  "secret://unknown",
+		PrivateKey: "secret://tls-key",
+	}); err == nil {
+		t.Fatal("expected an error for the unknown secret")
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/secret"
+	"mosn.io/mosn/pkg/types"
+)
+
+// ticketKeyLen is the length of a session ticket key
+const ticketKeyLen = 32
+
+// sessionTickets holds the session ticket keys of a tls config,
+// the keys can be rotated by the named secrets or sds without rebuilding the tls context
+type sessionTickets struct {
+	config *v2.SessionTicketConfig
+	keys   atomic.Value // stored [][ticketKeyLen]byte, empty means the random key
+	server atomic.Value // stored the server tls config of the current tls context
+	cache  tls.ClientSessionCache
+}
+
+func newSessionTickets(cfg *v2.SessionTicketConfig) (*sessionTickets, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	t := &sessionTickets{
+		config: cfg,
+	}
+	t.keys.Store([][ticketKeyLen]byte(nil))
+	if cfg.Disabled {
+		return t, nil
+	}
+	// the client session cache is kept when the tls context is rebuilt
+	if cfg.ClientCacheSize > 0 {
+		t.cache = tls.NewLRUClientSessionCache(cfg.ClientCacheSize)
+	}
+	if cfg.SdsConfig != nil && cfg.SdsConfig.Config != nil {
+		client := GetSdsClient(cfg.SdsConfig.Config)
+		if client == nil {
+			return nil, errors.New("get sds client for session ticket keys failed")
+		}
+		if err := client.AddUpdateCallback(cfg.SdsConfig.Config, t.setSdsKeys); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	if err := t.update(); err != nil {
+		return nil, err
+	}
+	for _, v := range cfg.Keys {
+		if name, ok := secret.ReferenceName(v); ok {
+			if err := secret.Watch(name, t.onRotate); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// update resolves the configured keys
+func (t *sessionTickets) update() error {
+	keys := make([][ticketKeyLen]byte, 0, len(t.config.Keys))
+	for _, v := range t.config.Keys {
+		value, err := secret.Resolve(v)
+		if err != nil {
+			return err
+		}
+		material, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("invalid session ticket key: %v", err)
+		}
+		keys = append(keys, ticketKey(material))
+	}
+	t.storeKeys(keys)
+	return nil
+}
+
+func (t *sessionTickets) onRotate(value []byte) {
+	if err := t.update(); err != nil {
+		log.DefaultLogger.Errorf("[mtls] [session ticket] update session ticket keys failed: %v", err)
+		return
+	}
+	log.DefaultLogger.Infof("[mtls] [session ticket] update session ticket keys success")
+}
+
+// setSdsKeys is called in sds client
+func (t *sessionTickets) setSdsKeys(name string, s *types.SdsSecret) {
+	if len(s.SessionTicketKeys) == 0 {
+		return
+	}
+	keys := make([][ticketKeyLen]byte, 0, len(s.SessionTicketKeys))
+	for _, material := range s.SessionTicketKeys {
+		keys = append(keys, ticketKey(material))
+	}
+	t.storeKeys(keys)
+	log.DefaultLogger.Infof("[mtls] [session ticket] receive %d session ticket keys from sds %s", len(keys), name)
+}
+
+// storeKeys stores the keys and rotates the keys of the current server tls config,
+// the configs cloned from it use the new keys
+func (t *sessionTickets) storeKeys(keys [][ticketKeyLen]byte) {
+	t.keys.Store(keys)
+	if c, ok := t.server.Load().(*tls.Config); ok && len(keys) > 0 {
+		c.SetSessionTicketKeys(keys)
+	}
+}
+
+// setServerConfig sets the session ticket keys of a new server tls config,
+// and rotates the keys of it later
+func (t *sessionTickets) setServerConfig(c *tls.Config) {
+	if t == nil {
+		return
+	}
+	if t.config.Disabled {
+		c.SessionTicketsDisabled = true
+		return
+	}
+	if keys := t.keys.Load().([][ticketKeyLen]byte); len(keys) > 0 {
+		c.SetSessionTicketKeys(keys)
+	}
+	t.server.Store(c)
+}
+
+// setClientConfig sets the session cache of a client tls config
+func (t *sessionTickets) setClientConfig(c *tls.Config) {
+	if t == nil {
+		return
+	}
+	if t.config.Disabled {
+		c.SessionTicketsDisabled = true
+		return
+	}
+	if t.cache != nil {
+		c.ClientSessionCache = t.cache
+	}
+}
+
+// ticketKey converts the key material to a session ticket key,
+// the material that is not 32 bytes is hashed to 32 bytes
+func ticketKey(material []byte) [ticketKeyLen]byte {
+	if len(material) != ticketKeyLen {
+		return sha256.Sum256(material)
+	}
+	var key [ticketKeyLen]byte
+	copy(key[:], material)
+	return key
+}
@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mtls
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls/crypto/tls"
+	"mosn.io/mosn/pkg/types"
+)
+
+// handshake makes a tls handshake on a pipe, returns whether the session is resumed
+func handshake(t *testing.T, server, client types.TLSProvider) bool {
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+	srv := tls.Server(sc, server.GetTLSConfig(false))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Handshake()
+	}()
+	clt := tls.Client(cc, client.GetTLSConfig(true))
+	if err := clt.Handshake(); err != nil {
+		t.Fatalf("client handshake failed: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("server handshake failed: %v", err)
+	}
+	return clt.ConnectionState().DidResume
+}
+
+func TestSessionTicketKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("session ticket key for the test"))
+	newServer := func(keys ...string) types.TLSProvider {
+		info := &certInfo{CommonName: "server", Curve: "P256", DNS: "www.example.com"}
+		cfg, err := info.CreateCertConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.SessionTicket = &v2.SessionTicketConfig{Keys: keys}
+		p, err := NewProvider(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	client, err := NewProvider(&v2.TLSConfig{
+		Status:       true,
+		InsecureSkip: true,
+		SessionTicket: &v2.SessionTicketConfig{
+			ClientCacheSize: 8,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := newServer(key)
+	if handshake(t, first, client) {
+		t.Fatal("the first handshake should not be resumed")
+	}
+	if !handshake(t, first, client) {
+		t.Fatal("the session should be resumed")
+	}
+	// the servers with the same keys can resume the sessions of each other
+	if !handshake(t, newServer(key), client) {
+		t.Fatal("the session should be resumed by the server with the same keys")
+	}
+	// the servers with the random keys can not
+	if handshake(t, newServer(), client) {
+		t.Fatal("the session should not be resumed by the server with a random key")
+	}
+	// invalid keys
+	info := &certInfo{CommonName: "server", Curve: "P256", DNS: "www.example.com"}
+	cfg, err := info.CreateCertConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SessionTicket = &v2.SessionTicketConfig{Keys: []string{"!invalid"}}
+	if _, err := NewProvider(cfg); err == nil {
+		t.Fatal("expected an error for the invalid key")
+	}
+}
+
+func TestSessionTicketRotate(t *testing.T) {
+	tickets, err := newSessionTickets(&v2.SessionTicketConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &tls.Config{}
+	tickets.setServerConfig(c)
+	tickets.setSdsKeys("ticket", &types.SdsSecret{
+		SessionTicketKeys: [][]byte{[]byte("new key")},
+	})
+	keys := tickets.keys.Load().([][ticketKeyLen]byte)
+	if len(keys) != 1 || keys[0] != sha256.Sum256([]byte("new key")) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	// empty keys are ignored
+	tickets.setSdsKeys("ticket", &types.SdsSecret{})
+	if keys := tickets.keys.Load().([][ticketKeyLen]byte); len(keys) != 1 {
+		t.Fatal("the keys should not be changed by the empty secret")
+	}
+}
+
+func TestSessionTicketDisabled(t *testing.T) {
+	tickets, err := newSessionTickets(&v2.SessionTicketConfig{
+		Disabled:        true,
+		ClientCacheSize: 8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, client := &tls.Config{}, &tls.Config{}
+	tickets.setServerConfig(server)
+	tickets.setClientConfig(client)
+	if !server.SessionTicketsDisabled || !client.SessionTicketsDisabled || client.ClientSessionCache != nil {
+		t.Fatal("the session tickets should be disabled")
+	}
+	// nil session tickets are ignored
+	var none *sessionTickets
+	none.setServerConfig(server)
+	none.setClientConfig(client)
+}
+
+func TestTicketKey(t *testing.T) {
+	material := make([]byte, ticketKeyLen)
+	material[0] = 1
+	if key := ticketKey(material); key[0] != 1 {
+		t.Fatal("the 32 bytes key should be used directly")
+	}
+	if key := ticketKey([]byte("short")); key != sha256.Sum256([]byte("short")) {
+		t.Fatal("the short key should be hashed")
+	}
+}
@@ -18,7 +18,6 @@
 package mtls
 
 import (
-	"crypto/x509"
 	"fmt"
 	"strings"
 
@@ -32,6 +31,9 @@ type secretInfo struct {
 	Certificate string
 	PrivateKey  string
 	Validation  string // root ca
+	// the encryption certificate of gm tls
+	EncCertificate string
+	EncPrivateKey  string
 }
 
 // full returns whether the secret info is full enough for a tls config
@@ -46,6 +48,12 @@ type tlsContext struct {
 	matches    map[string]struct{}
 	client     *tls.Config
 	server     *tls.Config
+	tickets    *sessionTickets
+	gm         bool
+}
+
+func (ctx *tlsContext) isGM() bool {
+	return ctx.gm
 }
 
 func (ctx *tlsContext) buildMatch() {
@@ -55,16 +63,8 @@ func (ctx *tlsContext) buildMatch() {
 	matches := make(map[string]struct{})
 	certs := ctx.server.Certificates
 	for i := range certs {
-		cert := certs[i]
-		x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
-		if err != nil {
-			continue
-		}
-		if len(x509Cert.Subject.CommonName) > 0 {
-			matches[x509Cert.Subject.CommonName] = struct{}{}
-		}
-		for _, san := range x509Cert.DNSNames {
-			matches[san] = struct{}{}
+		for _, name := range certificateNames(certs[i].Certificate[0]) {
+			matches[name] = struct{}{}
 		}
 	}
 	for _, protocol := range ctx.server.NextProtos {
@@ -151,7 +151,19 @@ func (ctx *tlsContext) GetTLSConfig(client bool) *tls.Config {
 	}
 }
 
+// setSessionTickets sets the session tickets of the tls context
+func (ctx *tlsContext) setSessionTickets(t *sessionTickets) {
+	ctx.tickets = t
+	if ctx.server != nil {
+		t.setServerConfig(ctx.server)
+	}
+	t.setClientConfig(ctx.client)
+}
+
 func newTLSContext(cfg *v2.TLSConfig, secret *secretInfo) (*tlsContext, error) {
+	if cfg.GM {
+		return newGMTLSContext(cfg, secret)
+	}
 	// basic template
 	tmpl, err := tlsConfigTemplate(cfg)
 	if err != nil {
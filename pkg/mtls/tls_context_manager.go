@@ -68,10 +68,15 @@ func NewTLSServerContextManager(cfg *v2.Listener) (types.TLSContextManager, erro
 
 func (mng *serverContextManager) GetConfigForClient(info *tls.ClientHelloInfo) (*tls.Config, error) {
 	var defaultProvider types.TLSProvider
+	gm := isGMClientHello(info)
 	for _, provider := range mng.providers {
 		if !provider.Ready() {
 			continue
 		}
+		// the gm client hello is handled by the gm providers only
+		if isGMProvider(provider) != gm {
+			continue
+		}
 		// the default provider is the first provider which is ready
 		if defaultProvider == nil {
 			defaultProvider = provider
@@ -90,21 +95,21 @@ func (mng *serverContextManager) GetConfigForClient(info *tls.ClientHelloInfo) (
 }
 
 func (mng *serverContextManager) Conn(c net.Conn) (net.Conn, error) {
-	if _, ok := c.(*net.TCPConn); !ok {
+	switch c.(type) {
+	// a *Conn may have been peeked by the filter chain selection
+	case *net.TCPConn, *Conn:
+	default:
 		return c, nil
 	}
 	if !mng.Enabled() {
 		return c, nil
 	}
 	if !mng.inspector {
-		return &TLSConn{
-			tls.Server(c, mng.config.Clone()),
-		}, nil
+		// the raw connection can be peeked, so the handshake pool reads the ClientHello before the handshake
+		return newServerTLSConn(tls.Server(NewPeekConn(c), mng.config.Clone())), nil
 	}
 	// inspector
-	conn := &Conn{
-		Conn: c,
-	}
+	conn := NewPeekConn(c)
 	buf, err := conn.Peek()
 	if err != nil {
 		return nil, err
@@ -112,9 +117,7 @@ func (mng *serverContextManager) Conn(c net.Conn) (net.Conn, error) {
 	switch buf[0] {
 	// TLS handshake
 	case 0x16:
-		return &TLSConn{
-			tls.Server(conn, mng.config.Clone()),
-		}, nil
+		return newServerTLSConn(tls.Server(conn, mng.config.Clone())), nil
 	// Non TLS
 	default:
 		return conn, nil
@@ -154,9 +157,8 @@ func (mng *clientContextManager) Conn(c net.Conn) (net.Conn, error) {
 	if !mng.Enabled() {
 		return c, nil
 	}
-	return &TLSConn{
-		tls.Client(c, mng.provider.GetTLSConfig(true)),
-	}, nil
+	cfg := mng.provider.GetTLSConfig(true)
+	return newClientTLSConn(tls.Client(c, cfg), cfg.NextProtos), nil
 }
 
 func (mng *clientContextManager) Enabled() bool {
@@ -121,3 +121,21 @@ func TestTLSContextMatch(t *testing.T) {
 	}
 
 }
+
+func TestVersionAndCipherSuiteName(t *testing.T) {
+	if name := VersionName(tls.VersionTLS12); name != "tlsv1_2" {
+		t.Errorf("unexpected version name: %s", name)
+	}
+	if name := VersionName(tls.VersionGMSSL); name != "gmsslv1_1" {
+		t.Errorf("unexpected version name: %s", name)
+	}
+	if name := VersionName(0x0999); name != "0x0999" {
+		t.Errorf("unexpected version name: %s", name)
+	}
+	if name := CipherSuiteName(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256); name != "ECDHE-RSA-AES128-GCM-SHA256" {
+		t.Errorf("unexpected cipher suite name: %s", name)
+	}
+	if name := CipherSuiteName(0xffff); name != "0xffff" {
+		t.Errorf("unexpected cipher suite name: %s", name)
+	}
+}
@@ -20,6 +20,7 @@ package mtls
 import (
 	"crypto/x509"
 	"errors"
+	"fmt"
 
 	"mosn.io/mosn/pkg/mtls/crypto/tls"
 )
@@ -38,6 +39,32 @@ var version = map[string]uint16{
 	"tlsv1_2":  tls.VersionTLS12,
 }
 
+// VersionName returns the name of the tls protocol version
+func VersionName(v uint16) string {
+	switch v {
+	case tls.VersionGMSSL:
+		return "gmsslv1_1"
+	case tls.VersionSSL30:
+		return "sslv3_0"
+	}
+	for name, ver := range version {
+		if ver == v && ver != 0 {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+// CipherSuiteName returns the name of the cipher suite in the cipher_suites config
+func CipherSuiteName(id uint16) string {
+	for name, cipher := range ciphersMap {
+		if cipher == id {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", id)
+}
+
 // Curves
 var (
 	defaultCurves = []tls.CurveID{
@@ -94,6 +121,11 @@ var (
 		"RSA-3DES-EDE-CBC-SHA":               tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
 		"ECDHE-RSA-SM4-SM3":                  tls.TLS_ECDHE_RSA_WITH_SM4_SM3,
 		"ECDHE-ECDSA-SM4-SM3":                tls.TLS_ECDHE_ECDSA_WITH_SM4_SM3,
+		"ECC-SM4-CBC-SM3":                    tls.GMTLS_ECC_SM4_CBC_SM3,
+	}
+	// gmCiphers are the ciphers of GM/T 0024 tls
+	gmCiphers = []uint16{
+		tls.GMTLS_ECC_SM4_CBC_SM3,
 	}
 )
 
@@ -0,0 +1,181 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"net"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// DefaultWriteCoalesceBuffers is the max buffers coalesced into one write by default
+const DefaultWriteCoalesceBuffers = 11
+
+// BufferPolicy is the read and write buffer policy of a connection
+type BufferPolicy struct {
+	// ReadBufferSize is the initial capacity of the read buffer, and the capacity it shrinks to when idle
+	ReadBufferSize int
+	// WriteCoalesceBuffers is the max buffers coalesced into one write
+	WriteCoalesceBuffers int
+	// WriteCoalesceBytes stops coalescing the buffers when the coalesced bytes reach it, 0 means no limit
+	WriteCoalesceBytes int
+	// MaxWriteChunkSize splits the coalesced buffers into the writes of at most the size, 0 means no limit
+	MaxWriteChunkSize int
+	// WriteFlushDelay is the max delay that the write loop waits for more buffers to batch the small writes,
+	// 0 means the buffers queued are written immediately
+	WriteFlushDelay time.Duration
+}
+
+// NewBufferPolicy creates a buffer policy by the config, the default policy is used if the config is nil
+func NewBufferPolicy(cfg *v2.ConnectionBufferConfig) *BufferPolicy {
+	if cfg == nil {
+		return nil
+	}
+	policy := &BufferPolicy{
+		ReadBufferSize:       int(cfg.ReadBufferBytes),
+		WriteCoalesceBuffers: int(cfg.WriteCoalesceBuffers),
+		WriteCoalesceBytes:   int(cfg.WriteCoalesceBytes),
+		MaxWriteChunkSize:    int(cfg.MaxWriteChunkBytes),
+	}
+	if cfg.WriteFlushDelay != nil {
+		policy.WriteFlushDelay = cfg.WriteFlushDelay.Duration
+	}
+	return policy
+}
+
+// SetBufferPolicy sets the buffer policy of the connection created by the network package,
+// it should be called before the connection is started
+func SetBufferPolicy(conn api.Connection, policy *BufferPolicy) {
+	if c, ok := conn.(bufferPolicySetter); ok && policy != nil {
+		c.setBufferPolicy(policy)
+	}
+}
+
+// bufferPolicySetter is implemented by the server and client connections
+type bufferPolicySetter interface {
+	setBufferPolicy(policy *BufferPolicy)
+}
+
+func (c *connection) setBufferPolicy(policy *BufferPolicy) {
+	c.bufferPolicy = policy
+}
+
+// readBufferCapacity returns the initial capacity of the read buffer
+func (c *connection) readBufferCapacity() int {
+	if c.bufferPolicy != nil && c.bufferPolicy.ReadBufferSize > 0 {
+		return c.bufferPolicy.ReadBufferSize
+	}
+	return DefaultBufferReadCapacity
+}
+
+// coalesceMore returns true if more buffers can be coalesced into the write of the coalesced buffers
+func (c *connection) coalesceMore(coalesced int) bool {
+	max := DefaultWriteCoalesceBuffers
+	if c.bufferPolicy != nil {
+		if c.bufferPolicy.WriteCoalesceBuffers > 0 {
+			max = c.bufferPolicy.WriteCoalesceBuffers
+		}
+		if c.bufferPolicy.WriteCoalesceBytes > 0 && c.writeBufLen() >= c.bufferPolicy.WriteCoalesceBytes {
+			return false
+		}
+	}
+	return coalesced < max
+}
+
+// flushDelay returns the max delay of the batched writes, 0 means no delay
+func (c *connection) flushDelay() time.Duration {
+	if c.bufferPolicy != nil {
+		return c.bufferPolicy.WriteFlushDelay
+	}
+	return 0
+}
+
+// coalesceBuffers appends the queued buffers to the write until the coalesce limits are reached,
+// if there is no queued buffer, it waits for more buffers in the flush delay to batch the small writes
+// from the streams sharing the connection. It returns false if the write queue is closed.
+func (c *connection) coalesceBuffers() bool {
+	delay := c.flushDelay()
+	waiting := false
+	defer func() {
+		if waiting && !c.flushTimer.Stop() {
+			<-c.flushTimer.C
+		}
+	}()
+	for i := 1; c.coalesceMore(i); i++ {
+		select {
+		case buf, ok := <-c.writeBufferChan:
+			if !ok {
+				return false
+			}
+			c.appendBuffer(buf)
+			continue
+		default:
+		}
+		if delay <= 0 {
+			continue
+		}
+		if !waiting {
+			waiting = true
+			if c.flushTimer == nil {
+				c.flushTimer = time.NewTimer(delay)
+			} else {
+				c.flushTimer.Reset(delay)
+			}
+		}
+		select {
+		case buf, ok := <-c.writeBufferChan:
+			if !ok {
+				return false
+			}
+			c.appendBuffer(buf)
+		case <-c.flushTimer.C:
+			waiting = false
+			return true
+		case <-c.internalStopChan:
+			return true
+		}
+	}
+	return true
+}
+
+// maxWriteChunkSize returns the max bytes of a write, 0 means no limit
+func (c *connection) maxWriteChunkSize() int {
+	if c.bufferPolicy != nil {
+		return c.bufferPolicy.MaxWriteChunkSize
+	}
+	return 0
+}
+
+// nextChunk returns the buffers of at most size bytes from the head of the buffers, the buffers are consumed
+func nextChunk(buffers *net.Buffers, size int) net.Buffers {
+	var chunk net.Buffers
+	for len(*buffers) > 0 && size > 0 {
+		b := (*buffers)[0]
+		if len(b) > size {
+			chunk = append(chunk, b[:size])
+			(*buffers)[0] = b[size:]
+			return chunk
+		}
+		chunk = append(chunk, b)
+		size -= len(b)
+		*buffers = (*buffers)[1:]
+	}
+	return chunk
+}
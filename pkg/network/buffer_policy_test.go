@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/pkg/buffer"
+)
+
+type recordWriteConn struct {
+	net.Conn
+	writes []int
+	data   bytes.Buffer
+}
+
+func (c *recordWriteConn) Write(b []byte) (int, error) {
+	c.writes = append(c.writes, len(b))
+	return c.data.Write(b)
+}
+
+func TestNextChunk(t *testing.T) {
+	buffers := net.Buffers{[]byte("hello"), []byte(" world"), []byte("!")}
+	var chunks []string
+	for len(buffers) > 0 {
+		chunk := nextChunk(&buffers, 4)
+		chunks = append(chunks, string(bytes.Join(chunk, []byte("|"))))
+	}
+	expected := []string{"hell", "o| wo", "rld|!"}
+	if len(chunks) != len(expected) {
+		t.Fatalf("unexpected chunks: %q", chunks)
+	}
+	for i := range chunks {
+		if chunks[i] != expected[i] {
+			t.Fatalf("unexpected chunks: %q", chunks)
+		}
+	}
+}
+
+func TestBufferPolicy(t *testing.T) {
+	c := &connection{}
+	if c.readBufferCapacity() != DefaultBufferReadCapacity || c.maxWriteChunkSize() != 0 {
+		t.Fatal("connection without a buffer policy should use the default policy")
+	}
+	if !c.coalesceMore(DefaultWriteCoalesceBuffers-1) || c.coalesceMore(DefaultWriteCoalesceBuffers) {
+		t.Fatal("connection without a buffer policy should coalesce the default buffers")
+	}
+	SetBufferPolicy(c, NewBufferPolicy(nil))
+	if c.bufferPolicy != nil {
+		t.Fatal("nil config should not set a buffer policy")
+	}
+
+	SetBufferPolicy(c, NewBufferPolicy(&v2.ConnectionBufferConfig{
+		ReadBufferBytes:      4096,
+		WriteCoalesceBuffers: 4,
+		WriteCoalesceBytes:   10,
+	}))
+	if c.readBufferCapacity() != 4096 {
+		t.Fatalf("unexpected read buffer capacity: %d", c.readBufferCapacity())
+	}
+	if !c.coalesceMore(3) || c.coalesceMore(4) {
+		t.Fatal("buffers coalesced should be limited by the policy")
+	}
+	c.writeBuffers = net.Buffers{make([]byte, 10)}
+	if c.coalesceMore(1) {
+		t.Fatal("bytes coalesced should be limited by the policy")
+	}
+
+	// the policy is set on the client connections too
+	cc := NewClientConnection(nil, 0, nil, nil, nil)
+	SetBufferPolicy(cc, c.bufferPolicy)
+	if cc.(*clientConnection).bufferPolicy != c.bufferPolicy {
+		t.Fatal("client connection buffer policy is not set")
+	}
+}
+
+func TestMaxWriteChunk(t *testing.T) {
+	raw := &recordWriteConn{}
+	c := &connection{rawConnection: raw}
+	SetBufferPolicy(c, &BufferPolicy{MaxWriteChunkSize: 5})
+	bufs := []buffer.IoBuffer{buffer.NewIoBufferString("hello world"), buffer.NewIoBufferString("!!")}
+	c.appendBuffer(&bufs)
+	n, err := c.doWriteIo()
+	if err != nil || n != 13 || raw.data.String() != "hello world!!" {
+		t.Fatalf("write failed, sent: %d, data: %s, error: %v", n, raw.data.String(), err)
+	}
+	for _, w := range raw.writes {
+		if w > 5 {
+			t.Fatalf("write exceeds the max chunk size: %v", raw.writes)
+		}
+	}
+	if len(c.ioBuffers) != 0 || len(c.writeBuffers) != 0 {
+		t.Fatal("buffers written should be released")
+	}
+}
+
+func TestWriteFlushDelay(t *testing.T) {
+	c := &connection{
+		writeBufferChan:  make(chan *[]buffer.IoBuffer, 8),
+		internalStopChan: make(chan struct{}),
+	}
+	send := func() {
+		bufs := []buffer.IoBuffer{buffer.NewIoBufferString("ping")}
+		c.writeBufferChan <- &bufs
+	}
+	// no flush delay, only the queued buffers are coalesced
+	send()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		send()
+	}()
+	if !c.coalesceBuffers() || len(c.ioBuffers) != 1 {
+		t.Fatalf("unexpected coalesced buffers: %d", len(c.ioBuffers))
+	}
+	// the late buffer is written by the next write
+	<-c.writeBufferChan
+	c.ioBuffers, c.writeBuffers = c.ioBuffers[:0], c.writeBuffers[:0]
+
+	SetBufferPolicy(c, NewBufferPolicy(&v2.ConnectionBufferConfig{
+		WriteFlushDelay: &api.DurationConfig{Duration: 100 * time.Millisecond},
+	}))
+	if !c.checkUseWriteLoop() {
+		t.Fatal("connection with a flush delay should use the write loop")
+	}
+	// the buffers written in the flush delay are batched
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			send()
+		}
+	}()
+	start := time.Now()
+	if !c.coalesceBuffers() || len(c.ioBuffers) != 3 {
+		t.Fatalf("unexpected coalesced buffers: %d", len(c.ioBuffers))
+	}
+	if d := time.Since(start); d < 100*time.Millisecond || d > time.Second {
+		t.Fatalf("unexpected flush delay: %v", d)
+	}
+	c.ioBuffers, c.writeBuffers = c.ioBuffers[:0], c.writeBuffers[:0]
+
+	// the coalesce limit flushes the buffers without the delay
+	c.bufferPolicy.WriteCoalesceBuffers = 2
+	send()
+	start = time.Now()
+	if !c.coalesceBuffers() || len(c.ioBuffers) != 1 {
+		t.Fatalf("unexpected coalesced buffers: %d", len(c.ioBuffers))
+	}
+	if time.Since(start) >= 100*time.Millisecond {
+		t.Fatal("buffers reach the coalesce limit should be flushed immediately")
+	}
+	close(c.writeBufferChan)
+	if c.coalesceBuffers() {
+		t.Fatal("coalesce should be stopped when the write queue is closed")
+	}
+}
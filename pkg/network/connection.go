@@ -33,18 +33,26 @@ import (
 
 	"github.com/rcrowley/go-metrics"
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/chaos"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/memory"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/buffer"
 	"mosn.io/pkg/utils"
 )
 
-// Network related const
-const (
+// Network related default values, they can be changed by the workers config
+var (
+	// DefaultBufferReadCapacity is the initial read buffer size of the connections
 	DefaultBufferReadCapacity = 1 << 7
+	// DefaultWriteQueueSize is the max pending writes of a connection before the writers are blocked
+	DefaultWriteQueueSize = 8
+)
 
+// Network related const
+const (
 	NetBufferDefaultSize     = 0
 	NetBufferDefaultCapacity = 1 << 4
 
@@ -54,6 +62,8 @@ const (
 
 var idCounter uint64 = 1
 
+var readBufferAccount = memory.GetAccount(memory.ModuleBuffer)
+
 type connection struct {
 	id         uint64
 	file       *os.File //copy of origin connection fd
@@ -85,6 +95,13 @@ type connection struct {
 	writeBufferChan    chan *[]buffer.IoBuffer
 	transferChan       chan uint64
 
+	// the capacity of the read buffer accounted in the memory accounting
+	readBufferBytes int64
+	// the read and write buffer policy, the default policy is used if it is nil
+	bufferPolicy *BufferPolicy
+	// flushTimer bounds the delay of the batched writes in the write loop
+	flushTimer *time.Timer
+
 	// readLoop/writeLoop goroutine fields:
 	internalLoopStarted bool
 	internalStopChan    chan struct{}
@@ -97,6 +114,9 @@ type connection struct {
 	lastBytesSizeRead  int64
 	lastWriteSizeWrite int64
 
+	// chaosFault drops the upstream connection after some bytes are read, only set in the chaos tests
+	chaosFault *chaos.ConnectionFault
+
 	closed    uint32
 	connected uint32
 	startOnce sync.Once
@@ -121,7 +141,7 @@ func NewServerConnection(ctx context.Context, rawc net.Conn, stopChan chan struc
 		connected:        1,
 		readEnabledChan:  make(chan bool, 1),
 		internalStopChan: make(chan struct{}),
-		writeBufferChan:  make(chan *[]buffer.IoBuffer, 8),
+		writeBufferChan:  make(chan *[]buffer.IoBuffer, DefaultWriteQueueSize),
 		writeSchedChan:   make(chan bool, 1),
 		transferChan:     make(chan uint64),
 		stats: &types.ConnectionStats{
@@ -182,7 +202,8 @@ func (c *connection) SetIdleTimeout(d time.Duration) {
 
 func (c *connection) attachEventLoop(lctx context.Context) {
 	// Choose one event loop to register, the implement is platform-dependent(epoll for linux and kqueue for bsd)
-	c.eventLoop = attach()
+	balance, _ := mosnctx.Get(lctx, types.ContextKeyConnectionBalance).(string)
+	c.eventLoop = attach(balance)
 
 	// Register read only, write is supported now because it is more complex than read.
 	// We need to write our own code based on syscall.write to deal with the EAGAIN and writable epoll event
@@ -195,7 +216,8 @@ func (c *connection) attachEventLoop(lctx context.Context) {
 					if te, ok := err.(net.Error); ok && te.Timeout() {
 						if c.readBuffer != nil && c.readBuffer.Len() == 0 {
 							c.readBuffer.Free()
-							c.readBuffer.Alloc(DefaultBufferReadCapacity)
+							c.readBuffer.Alloc(c.readBufferCapacity())
+							c.accountReadBuffer()
 						}
 						return true
 					}
@@ -228,11 +250,16 @@ func (c *connection) attachEventLoop(lctx context.Context) {
 	})
 
 	if err != nil {
+		c.eventLoop.detach()
 		log.DefaultLogger.Errorf("[network] [event loop] [register] conn %d register read failed:%s", c.id, err.Error())
 	}
 }
 
 func (c *connection) checkUseWriteLoop() bool {
+	// the small writes are batched by the write loop
+	if c.flushDelay() > 0 {
+		return true
+	}
 	tcpAddr, ok := c.remoteAddr.(*net.TCPAddr)
 	if !ok {
 		return false
@@ -282,7 +309,7 @@ func (c *connection) scheduleWrite() {
 			//	runtime.Gosched()
 			//}
 
-			for i := 0; i < 10; i++ {
+			for i := 1; c.coalesceMore(i); i++ {
 				select {
 				case buf, ok := <-c.writeBufferChan:
 					if !ok {
@@ -351,9 +378,10 @@ func (c *connection) startReadLoop() {
 				err := c.doRead()
 				if err != nil {
 					if te, ok := err.(net.Error); ok && te.Timeout() {
-						if c.readBuffer != nil && c.readBuffer.Len() == 0 && c.readBuffer.Cap() > DefaultBufferReadCapacity {
+						if c.readBuffer != nil && c.readBuffer.Len() == 0 && c.readBuffer.Cap() > c.readBufferCapacity() {
 							c.readBuffer.Free()
-							c.readBuffer.Alloc(DefaultBufferReadCapacity)
+							c.readBuffer.Alloc(c.readBufferCapacity())
+							c.accountReadBuffer()
 						}
 						continue
 					}
@@ -423,7 +451,7 @@ func (c *connection) transferWrite(id uint64) {
 
 func (c *connection) doRead() (err error) {
 	if c.readBuffer == nil {
-		c.readBuffer = buffer.GetIoBuffer(DefaultBufferReadCapacity)
+		c.readBuffer = buffer.GetIoBuffer(c.readBufferCapacity())
 	}
 
 	var bytesRead int64
@@ -446,6 +474,13 @@ func (c *connection) doRead() (err error) {
 		}
 	}
 
+	if c.chaosFault != nil && bytesRead > 0 {
+		if kept, drop := c.chaosFault.Read(bytesRead); drop {
+			c.dropChaosBytes(bytesRead - kept)
+			bytesRead, err = kept, io.EOF
+		}
+	}
+
 	//todo: ReadOnce maybe always return (0, nil) and causes dead loop (hack)
 	if bytesRead == 0 && err == nil {
 		err = io.EOF
@@ -459,9 +494,32 @@ func (c *connection) doRead() (err error) {
 
 	c.onRead()
 	c.updateReadBufStats(bytesRead, int64(c.readBuffer.Len()))
+	c.accountReadBuffer()
 	return
 }
 
+// accountReadBuffer accounts the capacity change of the read buffer, the read buffer
+// is released when the connection is closed
+func (c *connection) accountReadBuffer() {
+	if !memory.Enabled() {
+		return
+	}
+	var size int64
+	if c.readBuffer != nil && atomic.LoadUint32(&c.closed) == 0 {
+		size = int64(c.readBuffer.Cap())
+	}
+	old := atomic.SwapInt64(&c.readBufferBytes, size)
+	switch {
+	case old == size:
+	case old == 0:
+		readBufferAccount.Alloc(size)
+	case size == 0:
+		readBufferAccount.Free(old)
+	default:
+		readBufferAccount.Grow(size - old)
+	}
+}
+
 func (c *connection) updateReadBufStats(bytesRead int64, bytesBufSize int64) {
 	if c.stats == nil {
 		return
@@ -479,6 +537,14 @@ func (c *connection) updateReadBufStats(bytesRead int64, bytesBufSize int64) {
 	}
 }
 
+// dropChaosBytes removes the last n bytes read, as if the connection was dropped before them
+func (c *connection) dropChaosBytes(n int64) {
+	data := c.readBuffer.Bytes()
+	kept := append([]byte(nil), data[:len(data)-int(n)]...)
+	c.readBuffer.Reset()
+	c.readBuffer.Write(kept)
+}
+
 func (c *connection) onRead() {
 	if !c.readEnabled {
 		return
@@ -614,17 +680,8 @@ func (c *connection) startWriteLoop() {
 			}
 			c.appendBuffer(buf)
 
-			//todo: dynamic set loop nums
-			for i := 0; i < 10; i++ {
-				select {
-				case buf, ok := <-c.writeBufferChan:
-					if !ok {
-						return
-					}
-					c.appendBuffer(buf)
-				default:
-					break
-				}
+			if !c.coalesceBuffers() {
+				return
 			}
 
 			c.rawConnection.SetWriteDeadline(time.Now().Add(types.DefaultConnWriteTimeout))
@@ -680,11 +737,15 @@ func (c *connection) doWrite() (int64, error) {
 
 func (c *connection) doWriteIo() (bytesSent int64, err error) {
 	buffers := c.writeBuffers
-	if tlsConn, ok := c.rawConnection.(*mtls.TLSConn); ok {
-		bytesSent, err = tlsConn.WriteTo(&buffers)
+	if size := c.maxWriteChunkSize(); size > 0 {
+		for len(buffers) > 0 && err == nil {
+			chunk := nextChunk(&buffers, size)
+			var n int64
+			n, err = c.writeBuffersTo(&chunk)
+			bytesSent += n
+		}
 	} else {
-		//todo: writev(runtime) has memroy leak.
-		bytesSent, err = buffers.WriteTo(c.rawConnection)
+		bytesSent, err = c.writeBuffersTo(&buffers)
 	}
 	if err != nil {
 		return bytesSent, err
@@ -704,6 +765,14 @@ func (c *connection) doWriteIo() (bytesSent int64, err error) {
 	return
 }
 
+func (c *connection) writeBuffersTo(buffers *net.Buffers) (int64, error) {
+	if tlsConn, ok := c.rawConnection.(*mtls.TLSConn); ok {
+		return tlsConn.WriteTo(buffers)
+	}
+	//todo: writev(runtime) has memroy leak.
+	return buffers.WriteTo(c.rawConnection)
+}
+
 func (c *connection) updateWriteBuffStats(bytesWrite int64, bytesBufSize int64) {
 	if c.stats == nil {
 		return
@@ -774,6 +843,7 @@ func (c *connection) Close(ccType api.ConnectionCloseType, eventType api.Connect
 
 	c.updateReadBufStats(0, 0)
 	c.updateWriteBuffStats(0, 0)
+	c.accountReadBuffer()
 
 	for _, cb := range c.connCallbacks {
 		cb.OnEvent(eventType)
@@ -909,6 +979,9 @@ type clientConnection struct {
 	connectTimeout time.Duration
 
 	connectOnce sync.Once
+
+	// established is used by Connect instead of dialing, if it is not nil
+	established net.Conn
 }
 
 // NewClientConnection new client-side connection
@@ -924,7 +997,7 @@ func NewClientConnection(sourceAddr net.Addr, connectTimeout time.Duration, tlsM
 			readEnabled:      true,
 			readEnabledChan:  make(chan bool, 1),
 			internalStopChan: make(chan struct{}),
-			writeBufferChan:  make(chan *[]buffer.IoBuffer, 8),
+			writeBufferChan:  make(chan *[]buffer.IoBuffer, DefaultWriteQueueSize),
 			writeSchedChan:   make(chan bool, 1),
 			stats: &types.ConnectionStats{
 				ReadTotal:     metrics.NewCounter(),
@@ -945,6 +1018,14 @@ func NewClientConnection(sourceAddr net.Addr, connectTimeout time.Duration, tlsM
 	return conn
 }
 
+// NewClientConnectionWithConn creates a client-side connection on an established connection,
+// Connect uses the established connection instead of dialing a new one.
+func NewClientConnectionWithConn(established net.Conn, connectTimeout time.Duration, tlsMng types.TLSContextManager, remoteAddr net.Addr, stopChan chan struct{}) types.ClientConnection {
+	conn := NewClientConnection(nil, connectTimeout, tlsMng, remoteAddr, stopChan).(*clientConnection)
+	conn.established = established
+	return conn
+}
+
 func (cc *clientConnection) Connect() (err error) {
 	cc.connectOnce.Do(func() {
 		var event api.ConnectionEvent
@@ -955,7 +1036,9 @@ func (cc *clientConnection) Connect() (err error) {
 		}
 
 		addr := cc.RemoteAddr()
-		if addr != nil {
+		if cc.established != nil {
+			cc.rawConnection, cc.established = cc.established, nil
+		} else if addr != nil {
 			cc.rawConnection, err = net.DialTimeout("tcp", cc.RemoteAddr().String(), timeout)
 		} else {
 			err = errors.New("ClientConnection RemoteAddr is nil")
@@ -973,6 +1056,7 @@ func (cc *clientConnection) Connect() (err error) {
 		} else {
 			atomic.StoreUint32(&cc.connected, 1)
 			event = api.Connected
+			cc.chaosFault = chaos.UpstreamConnection(addr.String())
 
 			// ensure ioEnabled and UseNetpollMode
 			if UseNetpollMode {
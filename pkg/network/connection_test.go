@@ -22,10 +22,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/chaos"
+	"mosn.io/mosn/pkg/config/v2"
 )
 
 type MyEventListener struct{}
@@ -194,3 +197,64 @@ func TestConnState(t *testing.T) {
 		t.Errorf("ConnState should be ConnClosed")
 	}
 }
+
+type closeEventListener struct {
+	closed chan api.ConnectionEvent
+}
+
+func (el *closeEventListener) OnEvent(event api.ConnectionEvent) {
+	if event.IsClose() {
+		el.closed <- event
+	}
+}
+
+func TestChaosDropUpstreamConnection(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.Write(make([]byte, 100))
+		time.Sleep(time.Second)
+	}()
+	if err := chaos.Install([]v2.ChaosFault{{
+		Name:  "drop",
+		Type:  v2.ChaosDropUpstreamConnection,
+		Host:  l.Addr().String(),
+		Bytes: 10,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	defer chaos.Uninstall()
+
+	cc := NewClientConnection(nil, 0, nil, l.Addr(), nil)
+	var read uint64
+	cc.AddBytesReadListener(func(n uint64) {
+		atomic.AddUint64(&read, n)
+	})
+	el := &closeEventListener{closed: make(chan api.ConnectionEvent, 1)}
+	cc.AddConnectionEventListener(el)
+	if err := cc.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-el.closed:
+		if event != api.RemoteClose {
+			t.Errorf("expected remote close, but got %v", event)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("connection is not dropped")
+	}
+	if n := atomic.LoadUint64(&read); n != 10 {
+		t.Errorf("expected 10 bytes read, but got %d", n)
+	}
+	if fired := chaos.Fired("drop"); fired != 1 {
+		t.Errorf("expected fault fired once, but got %d", fired)
+	}
+}
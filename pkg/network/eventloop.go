@@ -24,6 +24,10 @@ import (
 	"sync/atomic"
 
 	"github.com/neverhook/easygo/netpoll"
+	gometrics "github.com/rcrowley/go-metrics"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
 	mosnsync "mosn.io/mosn/pkg/sync"
 )
 
@@ -36,27 +40,80 @@ var (
 	writePool = mosnsync.NewWorkerPool(runtime.NumCPU())
 
 	rrCounter                 uint32
-	poolSize                  uint32 = 1 //uint32(runtime.NumCPU())
-	eventLoopPool                    = make([]*eventLoop, poolSize)
-	errEventAlreadyRegistered        = errors.New("event already registered")
+	poolSize                  = uint32(runtime.NumCPU())
+	eventLoopPool             []*eventLoop
+	eventLoopOnce             sync.Once
+	balanceMutex              sync.Mutex
+	errEventAlreadyRegistered = errors.New("event already registered")
 )
 
-func init() {
-	//for i := range eventLoopPool {
-	//	poller, err := netpoll.New(nil)
-	//	if err != nil {
-	//		log.Fatalln("create poller failed, caused by ", err)
-	//	}
-	//
-	//	eventLoopPool[i] = &eventLoop{
-	//		poller: poller,
-	//		conn:   make(map[uint64]*connEvent), //TODO init size
-	//	}
-	//}
+// SetWorkers configures the workers and the connection buffers,
+// it should be called before any connection is created
+func SetWorkers(cfg *v2.WorkersConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.EventLoops > 0 {
+		poolSize = uint32(cfg.EventLoops)
+	}
+	if cfg.ReadWorkers > 0 {
+		readPool = mosnsync.NewWorkerPool(cfg.ReadWorkers)
+	}
+	if cfg.WriteWorkers > 0 {
+		writePool = mosnsync.NewWorkerPool(cfg.WriteWorkers)
+	}
+	if cfg.ReadBufferSize > 0 {
+		DefaultBufferReadCapacity = cfg.ReadBufferSize
+	}
+	if cfg.WriteQueueSize > 0 {
+		DefaultWriteQueueSize = cfg.WriteQueueSize
+	}
 }
 
-func attach() *eventLoop {
-	return eventLoopPool[atomic.AddUint32(&rrCounter, 1)%poolSize]
+// getEventLoops creates the event loops at the first time the netpoll mode is used
+func getEventLoops() []*eventLoop {
+	eventLoopOnce.Do(func() {
+		pool := make([]*eventLoop, 0, poolSize)
+		for i := uint32(0); i < poolSize; i++ {
+			poller, err := netpoll.New(nil)
+			if err != nil {
+				log.DefaultLogger.Fatalf("[network] [event loop] create poller failed: %v", err)
+			}
+			pool = append(pool, newEventLoop(int(i), poller))
+		}
+		eventLoopPool = pool
+	})
+	return eventLoopPool
+}
+
+// attach chooses an event loop to serve a connection. The event loops are chosen in turn by default,
+// the long-lived connections may be imbalanced among the event loops as the short-lived connections
+// are closed, so the least loaded event loop is chosen if the connection balance is exact.
+// In the goroutine mode, the connections are not bound to any worker and balanced by the go runtime.
+func attach(balance string) *eventLoop {
+	return attachTo(getEventLoops(), balance)
+}
+
+func attachTo(loops []*eventLoop, balance string) *eventLoop {
+	if balance == v2.ConnectionBalanceExact {
+		return attachLeastLoaded(loops)
+	}
+	el := loops[atomic.AddUint32(&rrCounter, 1)%uint32(len(loops))]
+	el.attach()
+	return el
+}
+
+func attachLeastLoaded(loops []*eventLoop) *eventLoop {
+	balanceMutex.Lock()
+	defer balanceMutex.Unlock()
+	least := loops[0]
+	for _, el := range loops[1:] {
+		if el.load() < least.load() {
+			least = el
+		}
+	}
+	least.attach()
+	return least
 }
 
 type connEvent struct {
@@ -76,6 +133,68 @@ type eventLoop struct {
 	poller netpoll.Poller
 
 	conn map[uint64]*connEvent
+
+	// connections is the number of the connections attached to the event loop
+	connections int64
+
+	// the load of the event loop
+	connectionsGauge gometrics.Gauge
+	eventsCounter    gometrics.Counter
+}
+
+func newEventLoop(id int, poller netpoll.Poller) *eventLoop {
+	stats := metrics.NewEventLoopStats(id)
+	return &eventLoop{
+		poller:           poller,
+		conn:             make(map[uint64]*connEvent),
+		connectionsGauge: stats.Gauge(metrics.WorkerConnections),
+		eventsCounter:    stats.Counter(metrics.WorkerEvents),
+	}
+}
+
+func (el *eventLoop) attach() {
+	n := atomic.AddInt64(&el.connections, 1)
+	if el.connectionsGauge != nil {
+		el.connectionsGauge.Update(n)
+	}
+}
+
+// onEvent counts the io events of the event loop
+func (el *eventLoop) onEvent() {
+	if el.eventsCounter != nil {
+		el.eventsCounter.Inc(1)
+	}
+}
+
+// load returns the number of the connections served by the event loop
+func (el *eventLoop) load() int64 {
+	return atomic.LoadInt64(&el.connections)
+}
+
+// detach releases a connection attached but not registered
+func (el *eventLoop) detach() {
+	n := atomic.AddInt64(&el.connections, -1)
+	if el.connectionsGauge != nil {
+		el.connectionsGauge.Update(n)
+	}
+}
+
+// remove deletes the connection's events, the connection is detached
+func (el *eventLoop) remove(id uint64) {
+	el.mu.Lock()
+	_, ok := el.conn[id]
+	delete(el.conn, id)
+	el.mu.Unlock()
+	if ok {
+		el.detach()
+	}
+}
+
+func (el *eventLoop) event(id uint64) (*connEvent, bool) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	event, ok := el.conn[id]
+	return event, ok
 }
 
 func (el *eventLoop) register(conn *connection, handler *connEventHandler) error {
@@ -145,7 +264,7 @@ func (el *eventLoop) registerWrite(conn *connection, handler *connEventHandler)
 
 func (el *eventLoop) unregister(id uint64) {
 
-	if event, ok := el.conn[id]; ok {
+	if event, ok := el.event(id); ok {
 		if event.read != nil {
 			el.poller.Stop(event.read)
 		}
@@ -154,34 +273,28 @@ func (el *eventLoop) unregister(id uint64) {
 			el.poller.Stop(event.write)
 		}
 
-		el.mu.Lock()
-		delete(el.conn, id)
-		el.mu.Unlock()
+		el.remove(id)
 	}
 
 }
 
 func (el *eventLoop) unregisterRead(id uint64) {
-	if event, ok := el.conn[id]; ok {
+	if event, ok := el.event(id); ok {
 		if event.read != nil {
 			el.poller.Stop(event.read)
 		}
 
-		el.mu.Lock()
-		delete(el.conn, id)
-		el.mu.Unlock()
+		el.remove(id)
 	}
 }
 
 func (el *eventLoop) unregisterWrite(id uint64) {
-	if event, ok := el.conn[id]; ok {
+	if event, ok := el.event(id); ok {
 		if event.write != nil {
 			el.poller.Stop(event.write)
 		}
 
-		el.mu.Lock()
-		delete(el.conn, id)
-		el.mu.Unlock()
+		el.remove(id)
 	}
 }
 
@@ -194,6 +307,7 @@ func (el *eventLoop) readWrapper(desc *netpoll.Desc, handler *connEventHandler)
 				return
 			}
 		}
+		el.onEvent()
 		readPool.Schedule(func() {
 			if !handler.onRead() {
 				return
@@ -212,6 +326,7 @@ func (el *eventLoop) writeWrapper(desc *netpoll.Desc, handler *connEventHandler)
 				return
 			}
 		}
+		el.onEvent()
 		writePool.ScheduleAlways(func() {
 			if !handler.onWrite() {
 				return
@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestAttachEventLoop(t *testing.T) {
+	loops := make([]*eventLoop, 3)
+	for i := range loops {
+		loops[i] = &eventLoop{conn: make(map[uint64]*connEvent)}
+	}
+	// the connections are attached in turn by default
+	for i := 0; i < 6; i++ {
+		attachTo(loops, "")
+	}
+	for i, el := range loops {
+		if el.load() != 2 {
+			t.Fatalf("#%d unexpected event loop load: %d", i, el.load())
+		}
+	}
+	// the short-lived connections of the other event loops are closed,
+	// the long-lived connections remain on the first event loop
+	loops[1].detach()
+	loops[2].detach()
+	loops[2].detach()
+	// the exact balance chooses the least loaded event loop
+	for i, expected := range []int{2, 1, 2, 0, 1, 2} {
+		if el := attachTo(loops, v2.ConnectionBalanceExact); el != loops[expected] {
+			t.Fatalf("#%d expected the event loop %d to be chosen", i, expected)
+		}
+	}
+	for i, el := range loops {
+		if el.load() != 3 {
+			t.Fatalf("#%d unexpected event loop load: %d", i, el.load())
+		}
+	}
+}
+
+func TestGetEventLoops(t *testing.T) {
+	loops := getEventLoops()
+	if len(loops) != int(poolSize) || loops[0].poller == nil {
+		t.Fatalf("expected %d event loops, but got %d", poolSize, len(loops))
+	}
+}
+
+func TestEventLoopRemove(t *testing.T) {
+	el := &eventLoop{
+		conn: map[uint64]*connEvent{1: {}},
+	}
+	el.connections = 1
+	el.remove(2)
+	if el.load() != 1 {
+		t.Fatal("remove an unknown connection should not change the load")
+	}
+	el.remove(1)
+	if el.load() != 0 || len(el.conn) != 0 {
+		t.Fatal("the connection should be removed")
+	}
+}
+
+func TestEventLoopStats(t *testing.T) {
+	el := newEventLoop(100, nil)
+	el.attach()
+	el.attach()
+	el.detach()
+	el.onEvent()
+	if el.connectionsGauge.Value() != 1 || el.eventsCounter.Count() != 1 {
+		t.Fatalf("unexpected event loop stats, connections: %d, events: %d", el.connectionsGauge.Value(), el.eventsCounter.Count())
+	}
+}
+
+func TestSetWorkers(t *testing.T) {
+	size, read, write := poolSize, readPool, writePool
+	readCapacity, queueSize := DefaultBufferReadCapacity, DefaultWriteQueueSize
+	defer func() {
+		poolSize, readPool, writePool = size, read, write
+		DefaultBufferReadCapacity, DefaultWriteQueueSize = readCapacity, queueSize
+	}()
+	SetWorkers(nil)
+	SetWorkers(&v2.WorkersConfig{})
+	if poolSize != size || readPool != read || writePool != write ||
+		DefaultBufferReadCapacity != readCapacity || DefaultWriteQueueSize != queueSize {
+		t.Fatal("the workers should not be changed by the empty config")
+	}
+	SetWorkers(&v2.WorkersConfig{
+		EventLoops:     4,
+		ReadWorkers:    2,
+		WriteWorkers:   2,
+		ReadBufferSize: 4096,
+		WriteQueueSize: 32,
+	})
+	if poolSize != 4 || readPool == read || writePool == write ||
+		DefaultBufferReadCapacity != 4096 || DefaultWriteQueueSize != 32 {
+		t.Fatal("the workers are not configured")
+	}
+}
@@ -25,8 +25,10 @@ import (
 	"sync"
 	"time"
 
+	gometrics "github.com/rcrowley/go-metrics"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
 )
@@ -57,6 +59,25 @@ type listener struct {
 	mutex                   sync.Mutex
 	// listener state indicates the listener's running state. The listener state effects if a listener binded to a port
 	state ListenerState
+	// stopChan is closed by Stop, so the accept loop paused under overload is stopped
+	stopChan chan struct{}
+	stats    *acceptStats
+}
+
+// acceptStats records how long a listener stops accepting because of overload
+type acceptStats struct {
+	Paused          gometrics.Gauge
+	PausedTotal     gometrics.Counter
+	PausedTimeTotal gometrics.Counter
+}
+
+func newAcceptStats(listenerName string) *acceptStats {
+	s := metrics.NewListenerStats(listenerName)
+	return &acceptStats{
+		Paused:          s.Gauge(metrics.DownstreamAcceptPaused),
+		PausedTotal:     s.Counter(metrics.DownstreamAcceptPausedTotal),
+		PausedTimeTotal: s.Counter(metrics.DownstreamAcceptPausedTimeTotal),
+	}
 }
 
 func NewListener(lc *v2.Listener) types.Listener {
@@ -69,6 +90,7 @@ func NewListener(lc *v2.Listener) types.Listener {
 		perConnBufferLimitBytes: lc.PerConnBufferLimitBytes,
 		useOriginalDst:          lc.UseOriginalDst,
 		config:                  lc,
+		stats:                   newAcceptStats(lc.Name),
 	}
 
 	if lc.InheritListener != nil {
@@ -131,13 +153,19 @@ func (l *listener) Start(lctx context.Context, restart bool) {
 				}
 			}
 			l.state = ListenerRunning
+			l.stopChan = make(chan struct{})
 			return false
 		}()
 		if ignore {
 			return
 		}
+		stop := l.stopSignal()
 
 		for {
+			if !l.waitAcceptable(stop) {
+				log.DefaultLogger.Infof("[network] [listener start] listener %s stopped while accept paused", l.name)
+				return
+			}
 			if err := l.accept(lctx); err != nil {
 				if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
 					log.DefaultLogger.Infof("[network] [listener start] [accept] listener %s stop accepting connections by deadline", l.name)
@@ -163,9 +191,21 @@ func (l *listener) Start(lctx context.Context, restart bool) {
 }
 
 func (l *listener) Stop() error {
+	l.mutex.Lock()
+	if l.stopChan != nil {
+		close(l.stopChan)
+		l.stopChan = nil
+	}
+	l.mutex.Unlock()
 	return l.rawl.SetDeadline(time.Now())
 }
 
+func (l *listener) stopSignal() chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.stopChan
+}
+
 func (l *listener) ListenerTag() uint64 {
 	return l.listenerTag
 }
@@ -213,6 +253,43 @@ func (l *listener) Close(lctx context.Context) error {
 	return nil
 }
 
+// waitAcceptable blocks while the accept throttle reports overload,
+// so the pending connections stay in the kernel backlog instead of being accepted and then failed.
+// returns false if the listener is stopped or closed while waiting.
+func (l *listener) waitAcceptable(stop chan struct{}) bool {
+	throttle, interval := getAcceptThrottle()
+	if throttle == nil || !throttle() {
+		return true
+	}
+	start := time.Now()
+	l.stats.Paused.Update(1)
+	l.stats.PausedTotal.Inc(1)
+	log.DefaultLogger.Warnf("[network] [listener] listener %s pause accepting connections under overload", l.name)
+	defer func() {
+		paused := time.Since(start)
+		l.stats.Paused.Update(0)
+		l.stats.PausedTimeTotal.Inc(paused.Nanoseconds())
+		log.DefaultLogger.Infof("[network] [listener] listener %s resume accepting connections, paused %s", l.name, paused)
+	}()
+	for throttle() {
+		select {
+		case <-stop:
+			return false
+		case <-time.After(interval):
+		}
+		if !l.isRunning() {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *listener) isRunning() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state == ListenerRunning
+}
+
 func (l *listener) listen(lctx context.Context) error {
 	var err error
 
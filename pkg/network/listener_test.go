@@ -20,6 +20,7 @@ package network
 import (
 	"context"
 	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -98,3 +99,95 @@ func TestListenerStart(t *testing.T) {
 	}
 
 }
+
+type countEventListener struct {
+	mockEventListener
+	accepted int32
+}
+
+func (e *countEventListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemoteAddr net.Addr, c chan api.Connection, buf []byte) {
+	atomic.AddInt32(&e.accepted, 1)
+	rawc.Close()
+}
+
+func TestListenerAcceptThrottle(t *testing.T) {
+	var overload int32 = 1
+	SetAcceptThrottle(func() bool {
+		return atomic.LoadInt32(&overload) == 1
+	}, 10*time.Millisecond)
+	defer SetAcceptThrottle(nil, 0)
+
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:10102")
+	cfg := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name:       "test_throttle_listener",
+			BindToPort: true,
+		},
+		Addr: addr,
+	}
+	ln := NewListener(cfg)
+	cb := &countEventListener{}
+	ln.SetListenerCallbacks(cb)
+	go ln.Start(nil, false)
+	defer ln.Close(nil)
+	time.Sleep(100 * time.Millisecond)
+
+	// connection is in the backlog, but not accepted
+	conn, err := net.Dial("tcp", "127.0.0.1:10102")
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&cb.accepted) != 0 {
+		t.Fatal("listener should not accept connections under overload")
+	}
+	stats := ln.(*listener).stats
+	if stats.Paused.Value() != 1 || stats.PausedTotal.Count() != 1 {
+		t.Fatalf("unexpected paused stats, paused: %d, total: %d", stats.Paused.Value(), stats.PausedTotal.Count())
+	}
+	// release the pressure
+	atomic.StoreInt32(&overload, 0)
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&cb.accepted) != 1 {
+		t.Fatal("listener should accept connection after overload released")
+	}
+	if stats.Paused.Value() != 0 || stats.PausedTimeTotal.Count() <= 0 {
+		t.Fatalf("unexpected paused stats, paused: %d, time: %d", stats.Paused.Value(), stats.PausedTimeTotal.Count())
+	}
+}
+
+func TestListenerStopWhilePaused(t *testing.T) {
+	// the overload is never released, and the interval is longer than the test
+	SetAcceptThrottle(func() bool {
+		return true
+	}, time.Minute)
+	defer SetAcceptThrottle(nil, 0)
+
+	addr, _ := net.ResolveTCPAddr("tcp", "127.0.0.1:10103")
+	cfg := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name:       "test_stop_paused_listener",
+			BindToPort: true,
+		},
+		Addr: addr,
+	}
+	ln := NewListener(cfg)
+	ln.SetListenerCallbacks(&countEventListener{})
+	done := make(chan struct{})
+	go func() {
+		ln.Start(nil, false)
+		close(done)
+	}()
+	defer ln.Close(nil)
+	time.Sleep(100 * time.Millisecond)
+	if ln.(*listener).stats.Paused.Value() != 1 {
+		t.Fatal("listener should be paused under overload")
+	}
+	ln.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("paused listener is not stopped")
+	}
+}
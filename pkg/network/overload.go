@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package network
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultAcceptPauseInterval is the interval a paused listener waits before checking the overload state again
+const DefaultAcceptPauseInterval = 100 * time.Millisecond
+
+// AcceptThrottle reports whether listeners should stop accepting new connections.
+// It is called before each accept, so it should be cheap.
+type AcceptThrottle func() bool
+
+type acceptThrottleHolder struct {
+	throttle AcceptThrottle
+	interval time.Duration
+}
+
+var acceptThrottle atomic.Value // store *acceptThrottleHolder
+
+// SetAcceptThrottle sets the global accept throttle used by all listeners.
+// A nil throttle disables accept throttling.
+func SetAcceptThrottle(throttle AcceptThrottle, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultAcceptPauseInterval
+	}
+	acceptThrottle.Store(&acceptThrottleHolder{
+		throttle: throttle,
+		interval: interval,
+	})
+}
+
+func getAcceptThrottle() (AcceptThrottle, time.Duration) {
+	if h, ok := acceptThrottle.Load().(*acceptThrottleHolder); ok && h.throttle != nil {
+		return h.throttle, h.interval
+	}
+	return nil, 0
+}
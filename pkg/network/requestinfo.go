@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
 )
 
 // RequestInfo
@@ -41,6 +42,13 @@ type RequestInfo struct {
 	downstreamRemoteAddress  net.Addr
 	isHealthCheckRequest     bool
 	routerRule               api.RouteRule
+	// timeline of the request
+	routeMatchedDuration      time.Duration
+	upstreamConnectedDuration time.Duration
+	firstUpstreamByteDuration time.Duration
+	receiveFiltersDuration    time.Duration
+	appendFiltersDuration     time.Duration
+	upstreamAttempts          []types.UpstreamAttempt
 }
 
 // todo check
@@ -178,3 +186,86 @@ func (r *RequestInfo) RouteEntry() api.RouteRule {
 func (r *RequestInfo) SetRouteEntry(routerRule api.RouteRule) {
 	r.routerRule = routerRule
 }
+
+func (r *RequestInfo) RouteMatchedDuration() time.Duration {
+	return r.routeMatchedDuration
+}
+
+func (r *RequestInfo) SetRouteMatchedDuration(t time.Time) {
+	r.routeMatchedDuration = t.Sub(r.startTime)
+}
+
+func (r *RequestInfo) UpstreamConnectedDuration() time.Duration {
+	return r.upstreamConnectedDuration
+}
+
+func (r *RequestInfo) SetUpstreamConnectedDuration(t time.Time) {
+	r.upstreamConnectedDuration = t.Sub(r.startTime)
+}
+
+func (r *RequestInfo) FirstUpstreamByteDuration() time.Duration {
+	return r.firstUpstreamByteDuration
+}
+
+func (r *RequestInfo) SetFirstUpstreamByteDuration(t time.Time) {
+	if r.firstUpstreamByteDuration == 0 {
+		r.firstUpstreamByteDuration = t.Sub(r.startTime)
+	}
+}
+
+func (r *RequestInfo) ReceiveFiltersDuration() time.Duration {
+	return r.receiveFiltersDuration
+}
+
+func (r *RequestInfo) AddReceiveFiltersDuration(d time.Duration) {
+	r.receiveFiltersDuration += d
+}
+
+func (r *RequestInfo) AppendFiltersDuration() time.Duration {
+	return r.appendFiltersDuration
+}
+
+func (r *RequestInfo) AddAppendFiltersDuration(d time.Duration) {
+	r.appendFiltersDuration += d
+}
+
+func (r *RequestInfo) AttemptStartDurations() []time.Duration {
+	if len(r.upstreamAttempts) == 0 {
+		return nil
+	}
+	durations := make([]time.Duration, 0, len(r.upstreamAttempts))
+	for _, attempt := range r.upstreamAttempts {
+		durations = append(durations, attempt.StartDuration)
+	}
+	return durations
+}
+
+func (r *RequestInfo) AddAttemptStart(t time.Time) {
+	r.upstreamAttempts = append(r.upstreamAttempts, types.UpstreamAttempt{
+		StartDuration: t.Sub(r.startTime),
+	})
+}
+
+func (r *RequestInfo) UpstreamAttempts() []types.UpstreamAttempt {
+	return r.upstreamAttempts
+}
+
+func (r *RequestInfo) SetAttemptHost(host string) {
+	if n := len(r.upstreamAttempts); n > 0 {
+		r.upstreamAttempts[n-1].Host = host
+	}
+}
+
+func (r *RequestInfo) FinishAttempt(t time.Time, responseCode int, resetReason types.StreamResetReason) {
+	n := len(r.upstreamAttempts)
+	if n == 0 {
+		return
+	}
+	attempt := &r.upstreamAttempts[n-1]
+	if attempt.Duration != 0 || attempt.ResponseCode != 0 || attempt.ResetReason != "" {
+		return
+	}
+	attempt.Duration = t.Sub(r.startTime) - attempt.StartDuration
+	attempt.ResponseCode = responseCode
+	attempt.ResetReason = resetReason
+}
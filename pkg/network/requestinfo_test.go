@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package network
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestRequestTimeline(t *testing.T) {
+	info := NewRequestInfo()
+	timeline, ok := info.(types.RequestTimeline)
+	if !ok {
+		t.Fatal("request info should record the timeline")
+	}
+	start := info.StartTime()
+	timeline.SetRouteMatchedDuration(start.Add(time.Millisecond))
+	timeline.AddAttemptStart(start.Add(2 * time.Millisecond))
+	timeline.SetUpstreamConnectedDuration(start.Add(3 * time.Millisecond))
+	timeline.SetFirstUpstreamByteDuration(start.Add(5 * time.Millisecond))
+	// retry
+	timeline.AddAttemptStart(start.Add(6 * time.Millisecond))
+	timeline.SetFirstUpstreamByteDuration(start.Add(9 * time.Millisecond))
+	timeline.AddReceiveFiltersDuration(time.Millisecond)
+	timeline.AddReceiveFiltersDuration(time.Millisecond)
+	timeline.AddAppendFiltersDuration(time.Millisecond)
+
+	if timeline.RouteMatchedDuration() != time.Millisecond ||
+		timeline.UpstreamConnectedDuration() != 3*time.Millisecond ||
+		timeline.FirstUpstreamByteDuration() != 5*time.Millisecond ||
+		timeline.ReceiveFiltersDuration() != 2*time.Millisecond ||
+		timeline.AppendFiltersDuration() != time.Millisecond {
+		t.Fatalf("unexpected timeline: %+v", info)
+	}
+	attempts := timeline.AttemptStartDurations()
+	if len(attempts) != 2 || attempts[0] != 2*time.Millisecond || attempts[1] != 6*time.Millisecond {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+}
+
+func TestRequestUpstreamAttempts(t *testing.T) {
+	info := NewRequestInfo()
+	timeline := info.(types.RequestTimeline)
+	recorder, ok := info.(types.UpstreamAttemptsRecorder)
+	if !ok {
+		t.Fatal("request info should record the upstream attempts")
+	}
+	// no attempt started
+	recorder.SetAttemptHost("127.0.0.1:8080")
+	recorder.FinishAttempt(time.Now(), 200, "")
+	if len(recorder.UpstreamAttempts()) != 0 {
+		t.Fatalf("unexpected attempts: %v", recorder.UpstreamAttempts())
+	}
+
+	start := info.StartTime()
+	timeline.AddAttemptStart(start.Add(time.Millisecond))
+	recorder.SetAttemptHost("127.0.0.1:8080")
+	recorder.FinishAttempt(start.Add(3*time.Millisecond), 0, types.UpstreamPerTryTimeout)
+	// the late reset is ignored
+	recorder.FinishAttempt(start.Add(4*time.Millisecond), 0, types.StreamLocalReset)
+	timeline.AddAttemptStart(start.Add(5 * time.Millisecond))
+	recorder.SetAttemptHost("127.0.0.1:8081")
+	recorder.FinishAttempt(start.Add(8*time.Millisecond), 200, "")
+
+	attempts := recorder.UpstreamAttempts()
+	if len(attempts) != 2 {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+	if attempts[0].String() != "127.0.0.1:8080|1ms|2ms|UpstreamPerTryTimeout" ||
+		attempts[1].String() != "127.0.0.1:8081|5ms|3ms|200" {
+		t.Fatalf("unexpected attempts: %v", attempts)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"unsafe"
+)
+
+const headerArenaChunkSize = 1 << 10
+
+// HeaderArena batches the allocations of the header values decoded in a stream.
+// The values are copied into a shared chunk, so decoding n values costs about one allocation.
+// The bytes in a chunk are never written again once a string refers to them, so an arena can be
+// kept by a pooled object across streams and a chunk is released by gc when all its strings are dropped.
+// The zero value is ready to use, a nil *HeaderArena allocates each string alone.
+type HeaderArena struct {
+	chunk []byte
+}
+
+// String returns a string with the same content of b
+func (a *HeaderArena) String(b []byte) string {
+	if a == nil || len(b) > headerArenaChunkSize/4 {
+		return string(b)
+	}
+	if len(b) == 0 {
+		return ""
+	}
+	if cap(a.chunk)-len(a.chunk) < len(b) {
+		a.chunk = make([]byte, 0, headerArenaChunkSize)
+	}
+	start := len(a.chunk)
+	a.chunk = append(a.chunk, b...)
+	s := a.chunk[start:len(a.chunk)]
+	return *(*string)(unsafe.Pointer(&s))
+}
@@ -46,6 +46,8 @@ type ProtocolBuffers struct {
 	rspHeader   buffer.IoBuffer
 	rspHeaders  map[string]string
 	rspTrailers map[string]string
+
+	headerArena HeaderArena
 }
 
 type protocolBufferCtx struct {
@@ -140,6 +142,12 @@ func (p *ProtocolBuffers) GetRspTailers() map[string]string {
 	return p.rspTrailers
 }
 
+// GetHeaderArena returns the arena for the decoded header values.
+// It is not reset with the buffers, the strings decoded in the previous streams stay valid.
+func (p *ProtocolBuffers) GetHeaderArena() *HeaderArena {
+	return &p.headerArena
+}
+
 // ProtocolBuffersByContext returns ProtocolBuffers by context
 func ProtocolBuffersByContext(ctx context.Context) *ProtocolBuffers {
 	poolCtx := mbuffer.PoolContext(ctx)
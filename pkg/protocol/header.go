@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+type headerEntry struct {
+	key   []byte
+	value []byte
+}
+
+// OrderedHeader is a HeaderMap keeps the order and the multiple values of the headers.
+// The keys are case-insensitive and stored in lower case, the pseudo headers (starts with ':')
+// are always in front of the regular headers.
+type OrderedHeader struct {
+	entries []headerEntry
+}
+
+// NewOrderedHeader returns an empty OrderedHeader with the capacity of size headers
+func NewOrderedHeader(size int) *OrderedHeader {
+	return &OrderedHeader{
+		entries: make([]headerEntry, 0, size),
+	}
+}
+
+// Get value of key
+// If multiple values associated with this key, first one will be returned.
+func (h *OrderedHeader) Get(key string) (string, bool) {
+	for i := range h.entries {
+		if equalFold(h.entries[i].key, key) {
+			return string(h.entries[i].value), true
+		}
+	}
+	return "", false
+}
+
+// GetBytes returns the first value of the key without allocations
+func (h *OrderedHeader) GetBytes(key []byte) ([]byte, bool) {
+	for i := range h.entries {
+		if equalFoldBytes(h.entries[i].key, key) {
+			return h.entries[i].value, true
+		}
+	}
+	return nil, false
+}
+
+// Values returns all the values of the key in order
+func (h *OrderedHeader) Values(key string) []string {
+	var values []string
+	for i := range h.entries {
+		if equalFold(h.entries[i].key, key) {
+			values = append(values, string(h.entries[i].value))
+		}
+	}
+	return values
+}
+
+// Set key-value pair in header map, the previous pairs of the key will be replaced
+func (h *OrderedHeader) Set(key, value string) {
+	for i := range h.entries {
+		if equalFold(h.entries[i].key, key) {
+			h.entries[i].value = append(h.entries[i].value[:0], value...)
+			h.delFrom(i+1, key)
+			return
+		}
+	}
+	h.Add(key, value)
+}
+
+// Add value for given key.
+// Multiple headers with the same key may be added with this function.
+func (h *OrderedHeader) Add(key, value string) {
+	entry := headerEntry{
+		key:   toLowerBytes(key),
+		value: []byte(value),
+	}
+	if !isPseudoHeader(key) {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	// insert the pseudo header after the last pseudo header
+	idx := 0
+	for idx < len(h.entries) && isPseudoHeader(string(h.entries[idx].key)) {
+		idx++
+	}
+	h.entries = append(h.entries, headerEntry{})
+	copy(h.entries[idx+1:], h.entries[idx:])
+	h.entries[idx] = entry
+}
+
+// Del delete all the pairs of specified key
+func (h *OrderedHeader) Del(key string) {
+	h.delFrom(0, key)
+}
+
+func (h *OrderedHeader) delFrom(start int, key string) {
+	n := start
+	for i := start; i < len(h.entries); i++ {
+		if equalFold(h.entries[i].key, key) {
+			continue
+		}
+		h.entries[n] = h.entries[i]
+		n++
+	}
+	for i := n; i < len(h.entries); i++ {
+		h.entries[i] = headerEntry{}
+	}
+	h.entries = h.entries[:n]
+}
+
+// Range calls f sequentially for each key and value present in the map in order.
+// If f returns false, range stops the iteration.
+func (h *OrderedHeader) Range(f func(key, value string) bool) {
+	for i := range h.entries {
+		if !f(InternHeaderName(h.entries[i].key), string(h.entries[i].value)) {
+			break
+		}
+	}
+}
+
+// RangeValues is the same as Range, the multiple values are always visited
+func (h *OrderedHeader) RangeValues(f func(key, value string) bool) {
+	h.Range(f)
+}
+
+// Clone used to deep copy header's map
+func (h *OrderedHeader) Clone() api.HeaderMap {
+	clone := NewOrderedHeader(len(h.entries))
+	for _, e := range h.entries {
+		clone.entries = append(clone.entries, headerEntry{
+			key:   append([]byte(nil), e.key...),
+			value: append([]byte(nil), e.value...),
+		})
+	}
+	return clone
+}
+
+// ByteSize return size of HeaderMap
+func (h *OrderedHeader) ByteSize() uint64 {
+	var size uint64
+	for _, e := range h.entries {
+		size += uint64(len(e.key) + len(e.value))
+	}
+	return size
+}
+
+// HeaderValues returns all the values of the key.
+// The first value is returned if the headers does not keep the multiple values.
+func HeaderValues(headers api.HeaderMap, key string) []string {
+	if h, ok := headers.(types.MultiValueHeaderMap); ok {
+		return h.Values(key)
+	}
+	if v, ok := headers.Get(key); ok {
+		return []string{v}
+	}
+	return nil
+}
+
+// RangeHeaderValues calls f for each value of each key if the headers keeps the multiple values,
+// otherwise it is the same as headers.Range
+func RangeHeaderValues(headers api.HeaderMap, f func(key, value string) bool) {
+	if h, ok := headers.(types.MultiValueHeaderMap); ok {
+		h.RangeValues(f)
+		return
+	}
+	headers.Range(f)
+}
+
+// GetHeaderBytes returns the first value of the key, it does not allocate if the headers supports byte slice keys
+func GetHeaderBytes(headers api.HeaderMap, key []byte) ([]byte, bool) {
+	if h, ok := headers.(types.BytesHeaderMap); ok {
+		return h.GetBytes(key)
+	}
+	if v, ok := headers.Get(string(key)); ok {
+		return []byte(v), true
+	}
+	return nil, false
+}
+
+func isPseudoHeader(key string) bool {
+	return len(key) > 0 && key[0] == ':'
+}
+
+func toLowerBytes(s string) []byte {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return b
+}
+
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// equalFold reports whether the lower case key equals to s case-insensitively
+func equalFold(key []byte, s string) bool {
+	if len(key) != len(s) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if key[i] != lower(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFoldBytes(key []byte, b []byte) bool {
+	if len(key) != len(b) {
+		return false
+	}
+	for i := 0; i < len(b); i++ {
+		if key[i] != lower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"reflect"
+	"testing"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestOrderedHeader(t *testing.T) {
+	h := NewOrderedHeader(8)
+	h.Add("Accept", "text/html")
+	h.Add("x-forwarded-for", "10.0.0.1")
+	h.Add("X-Forwarded-For", "10.0.0.2")
+	h.Add(":method", "GET")
+	h.Add(":path", "/")
+
+	if v, ok := h.Get("x-forwarded-for"); !ok || v != "10.0.0.1" {
+		t.Errorf("get first value failed: %s, %t", v, ok)
+	}
+	if v, ok := h.GetBytes([]byte("ACCEPT")); !ok || string(v) != "text/html" {
+		t.Errorf("get bytes failed: %s, %t", v, ok)
+	}
+	if values := h.Values("X-Forwarded-For"); !reflect.DeepEqual(values, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("unexpected values: %v", values)
+	}
+	// pseudo headers are in front of the regular headers, the order is kept
+	var keys []string
+	h.Range(func(key, value string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []string{":method", ":path", "accept", "x-forwarded-for", "x-forwarded-for"}) {
+		t.Errorf("unexpected order: %v", keys)
+	}
+
+	// set replaces all the values
+	h.Set("x-forwarded-for", "10.0.0.3")
+	if values := h.Values("x-forwarded-for"); !reflect.DeepEqual(values, []string{"10.0.0.3"}) {
+		t.Errorf("unexpected values after set: %v", values)
+	}
+	h.Set("x-new", "")
+	if v, ok := h.Get("x-new"); !ok || v != "" {
+		t.Errorf("empty value should be kept: %s, %t", v, ok)
+	}
+
+	clone := h.Clone()
+	h.Del("accept")
+	if _, ok := h.Get("accept"); ok {
+		t.Error("header should be deleted")
+	}
+	if _, ok := clone.Get("accept"); !ok {
+		t.Error("clone should not be affected")
+	}
+	if size := clone.ByteSize(); size != uint64(len(":methodGET:path/accepttext/htmlx-forwarded-for10.0.0.3x-new")) {
+		t.Errorf("unexpected byte size: %d", size)
+	}
+}
+
+func TestHeaderValues(t *testing.T) {
+	var _ types.MultiValueHeaderMap = &OrderedHeader{}
+	var _ types.BytesHeaderMap = &OrderedHeader{}
+
+	h := NewOrderedHeader(2)
+	h.Add("cookie", "a=1")
+	h.Add("cookie", "b=2")
+	if values := HeaderValues(h, "cookie"); len(values) != 2 {
+		t.Errorf("unexpected values: %v", values)
+	}
+	common := CommonHeader{"cookie": "a=1"}
+	if values := HeaderValues(common, "cookie"); !reflect.DeepEqual(values, []string{"a=1"}) {
+		t.Errorf("unexpected values of common header: %v", values)
+	}
+	if values := HeaderValues(common, "none"); values != nil {
+		t.Errorf("unexpected values of absent key: %v", values)
+	}
+	if v, ok := GetHeaderBytes(common, []byte("cookie")); !ok || string(v) != "a=1" {
+		t.Errorf("get bytes of common header failed: %s, %t", v, ok)
+	}
+	count := 0
+	RangeHeaderValues(h, func(key, value string) bool {
+		count++
+		return true
+	})
+	if count != 2 {
+		t.Errorf("range values visited %d", count)
+	}
+}
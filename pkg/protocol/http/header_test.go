@@ -59,3 +59,35 @@ func TestResponseHeader_Add(t *testing.T) {
 		t.Errorf("ResponseHeader.String not contains all header values")
 	}
 }
+
+func TestRequestHeader_Values(t *testing.T) {
+	header := RequestHeader{&fasthttp.RequestHeader{}, map[string]bool{}}
+	header.Add("test-multiple", "value-one")
+	header.Add("test-multiple", "value-two")
+	header.Set("test-empty", "")
+
+	values := header.Values("Test-Multiple")
+	if len(values) != 2 || values[0] != "value-one" || values[1] != "value-two" {
+		t.Errorf("RequestHeader.Values return not expected: %v", values)
+	}
+	if val, ok := header.GetBytes([]byte("test-multiple")); !ok || string(val) != "value-one" {
+		t.Errorf("RequestHeader.GetBytes return not expected")
+	}
+	if val, ok := header.GetBytes([]byte("test-empty")); !ok || len(val) != 0 {
+		t.Errorf("RequestHeader.GetBytes empty value not expected")
+	}
+	if _, ok := header.GetBytes([]byte("test-none")); ok {
+		t.Errorf("RequestHeader.GetBytes absent key not expected")
+	}
+}
+
+func TestResponseHeader_Values(t *testing.T) {
+	header := ResponseHeader{&fasthttp.ResponseHeader{}, nil}
+	header.Add("set-cookie", "a=1")
+	header.Add("set-cookie", "b=2")
+
+	values := header.Values("set-cookie")
+	if len(values) != 2 || values[0] != "a=1" || values[1] != "b=2" {
+		t.Errorf("ResponseHeader.Values return not expected: %v", values)
+	}
+}
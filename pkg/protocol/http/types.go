@@ -168,6 +168,30 @@ func (h RequestHeader) ByteSize() (size uint64) {
 	return size
 }
 
+// GetBytes returns the first value of the key without allocations
+func (h RequestHeader) GetBytes(key []byte) ([]byte, bool) {
+	result := h.PeekBytes(key)
+	if result != nil || h.EmptyValueHeaders[string(key)] {
+		return result, true
+	}
+	return nil, false
+}
+
+// Values returns all the values of the key in order
+func (h RequestHeader) Values(key string) (values []string) {
+	h.VisitAll(func(k, v []byte) {
+		if equalFold(k, key) {
+			values = append(values, string(v))
+		}
+	})
+	return values
+}
+
+// RangeValues is the same as Range, fasthttp visits all the values
+func (h RequestHeader) RangeValues(f func(key, value string) bool) {
+	h.Range(f)
+}
+
 type ResponseHeader struct {
 	*fasthttp.ResponseHeader
 
@@ -248,3 +272,46 @@ func (h ResponseHeader) ByteSize() (size uint64) {
 	})
 	return size
 }
+
+// GetBytes returns the first value of the key without allocations
+func (h ResponseHeader) GetBytes(key []byte) ([]byte, bool) {
+	result := h.PeekBytes(key)
+	if result != nil || h.EmptyValueHeaders[string(key)] {
+		return result, true
+	}
+	return nil, false
+}
+
+// Values returns all the values of the key in order
+func (h ResponseHeader) Values(key string) (values []string) {
+	h.VisitAll(func(k, v []byte) {
+		if equalFold(k, key) {
+			values = append(values, string(v))
+		}
+	})
+	return values
+}
+
+// RangeValues is the same as Range, fasthttp visits all the values
+func (h ResponseHeader) RangeValues(f func(key, value string) bool) {
+	h.Range(f)
+}
+
+func equalFold(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if lower(b[i]) != lower(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -35,16 +35,10 @@ type common2http struct{}
 
 func (c *common2http) ConvHeader(ctx context.Context, headerMap types.HeaderMap) (types.HeaderMap, error) {
 	if header, ok := headerMap.(protocol.CommonHeader); ok {
-		cheader := make(map[string]string, len(header))
-
+		// the headers are converted to http.Header by the stream codec,
+		// so the map is passed through instead of being copied
 		delete(header, protocol.MosnHeaderDirection)
-
-		// copy headers
-		for k, v := range header {
-			cheader[k] = v
-		}
-
-		return protocol.CommonHeader(cheader), nil
+		return header, nil
 	}
 	return nil, errors.New("header type not supported")
 }
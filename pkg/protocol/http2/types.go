@@ -19,6 +19,7 @@ package http2
 
 import (
 	"net/http"
+	"net/textproto"
 	"strings"
 
 	"mosn.io/mosn/pkg/protocol"
@@ -149,6 +150,22 @@ func (h HeaderMap) ByteSize() uint64 {
 	return size
 }
 
+// Values returns all the values of the key in order
+func (h HeaderMap) Values(key string) []string {
+	return h.H[textproto.CanonicalMIMEHeaderKey(key)]
+}
+
+// RangeValues calls f for each value of each key, stops if f returns false
+func (h HeaderMap) RangeValues(f func(key, value string) bool) {
+	for k, vv := range h.H {
+		for _, v := range vv {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
+
 func EncodeHeader(in map[string]string) (header http.Header) {
 	header = http.Header((make(map[string][]string, len(in))))
 	for k, v := range in {
@@ -172,7 +189,7 @@ func DecodeHeader(header types.HeaderMap) types.HeaderMap {
 
 	out := make(map[string]string, len(in))
 	for k, v := range in {
-		out[strings.ToLower(k)] = strings.Join(v, ",")
+		out[protocol.LowerHeaderName(k)] = strings.Join(v, ",")
 	}
 	return protocol.CommonHeader(out)
 }
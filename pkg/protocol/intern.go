@@ -0,0 +1,87 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"net/textproto"
+	"strings"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// headerNames maps the registered header names to the shared string,
+// foldedHeaderNames maps the canonical form of the lower case names to the shared string.
+// Both are only written in init, so the lookups need no lock.
+var (
+	headerNames       = make(map[string]string, 128)
+	foldedHeaderNames = make(map[string]string, 128)
+)
+
+func init() {
+	for _, name := range []string{
+		// http
+		"accept", "accept-charset", "accept-encoding", "accept-language", "accept-ranges",
+		"authorization", "cache-control", "connection", "content-disposition", "content-encoding",
+		"content-language", "content-length", "content-range", "content-type", "cookie", "date",
+		"etag", "expect", "expires", "from", "host", "if-match", "if-modified-since", "if-none-match",
+		"if-range", "if-unmodified-since", "keep-alive", "last-modified", "location", "pragma",
+		"proxy-authenticate", "proxy-authorization", "proxy-connection", "range", "referer",
+		"retry-after", "server", "set-cookie", "te", "trailer", "transfer-encoding", "upgrade",
+		"user-agent", "vary", "via", "www-authenticate", "x-forwarded-for", "x-forwarded-proto",
+		"x-real-ip", "x-request-id", types.HeaderForwardedClientCert,
+		// http2 pseudo headers
+		":authority", ":method", ":path", ":scheme", ":status",
+		// mosn
+		MosnHeaderDirection, MosnHeaderHostKey, MosnHeaderPathKey, MosnHeaderQueryStringKey,
+		MosnHeaderMethod, MosnOriginalHeaderPathKey, IstioHeaderHostKey,
+		types.HeaderStatus, types.HeaderStreamID, types.HeaderGlobalTimeout, types.HeaderTryTimeout,
+		types.HeaderException, types.HeaderStremEnd, types.HeaderRPCService, types.HeaderRPCMethod,
+		types.HeaderUpstreamServiceTime, types.HeaderUpstreamHost, types.HeaderRetryCount,
+		types.HeaderRouteName, types.HeaderAttemptCount, types.SofaRouteMatchKey,
+	} {
+		RegisterHeaderName(name)
+	}
+}
+
+// RegisterHeaderName registers a header name that is shared by all the decoded headers.
+// It is not concurrency safe and should be called in init, protocols register the names they use frequently.
+func RegisterHeaderName(name string) {
+	headerNames[name] = name
+	if strings.ToLower(name) == name {
+		foldedHeaderNames[name] = name
+		foldedHeaderNames[textproto.CanonicalMIMEHeaderKey(name)] = name
+	}
+}
+
+// InternHeaderName returns the registered string equals to the name without allocations,
+// a new string is allocated if the name is not registered.
+func InternHeaderName(name []byte) string {
+	if s, ok := headerNames[string(name)]; ok {
+		return s
+	}
+	return string(name)
+}
+
+// LowerHeaderName returns the lower case of the name, the registered string is returned without
+// allocations if the name is a registered name in lower case or in canonical form.
+func LowerHeaderName(name string) string {
+	if s, ok := foldedHeaderNames[name]; ok {
+		return s
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInternHeaderName(t *testing.T) {
+	if name := InternHeaderName([]byte("content-type")); name != "content-type" {
+		t.Errorf("intern registered name failed: %s", name)
+	}
+	if name := InternHeaderName([]byte("x-unknown")); name != "x-unknown" {
+		t.Errorf("intern unregistered name failed: %s", name)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		InternHeaderName([]byte(MosnHeaderHostKey))
+	})
+	if allocs != 0 {
+		t.Errorf("intern registered name allocates %f times", allocs)
+	}
+}
+
+func TestLowerHeaderName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Content-Type":     "content-type",
+		"content-type":     "content-type",
+		"X-Mosn-Host":      MosnHeaderHostKey,
+		"X-Unknown-Header": "x-unknown-header",
+		":authority":       ":authority",
+	} {
+		if lower := LowerHeaderName(name); lower != expected {
+			t.Errorf("lower %s expected %s, but got %s", name, expected, lower)
+		}
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		LowerHeaderName("Content-Length")
+	})
+	if allocs != 0 {
+		t.Errorf("lower registered name allocates %f times", allocs)
+	}
+}
+
+func TestHeaderArena(t *testing.T) {
+	arena := &HeaderArena{}
+	b := []byte("value")
+	s := arena.String(b)
+	b[0] = 'V'
+	if s != "value" {
+		t.Errorf("arena string changed with the source: %s", s)
+	}
+	// a string larger than the chunk is allocated alone
+	large := strings.Repeat("a", headerArenaChunkSize*2)
+	if s := arena.String([]byte(large)); s != large {
+		t.Error("arena large string failed")
+	}
+	// the strings in a full chunk are not changed by the new chunk
+	var values []string
+	for i := 0; i < headerArenaChunkSize/16+1; i++ {
+		values = append(values, arena.String([]byte("0123456789abcdef")))
+	}
+	for _, v := range values {
+		if v != "0123456789abcdef" {
+			t.Errorf("arena string changed: %s", v)
+		}
+	}
+	var nilArena *HeaderArena
+	if s := nilArena.String([]byte("value")); s != "value" {
+		t.Errorf("nil arena string failed: %s", s)
+	}
+}
+
+func BenchmarkHeaderName(b *testing.B) {
+	name := []byte("content-type")
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		m := make(map[string]string, 1)
+		for i := 0; i < b.N; i++ {
+			m[string(name)] = ""
+		}
+	})
+	b.Run("intern", func(b *testing.B) {
+		b.ReportAllocs()
+		m := make(map[string]string, 1)
+		for i := 0; i < b.N; i++ {
+			m[InternHeaderName(name)] = ""
+		}
+	})
+}
+
+func BenchmarkHeaderValues(b *testing.B) {
+	values := [][]byte{[]byte("text/html"), []byte("gzip"), []byte("10.0.0.1"), []byte("keep-alive")}
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		var s string
+		for i := 0; i < b.N; i++ {
+			for _, v := range values {
+				s = string(v)
+			}
+		}
+		_ = s
+	})
+	b.Run("arena", func(b *testing.B) {
+		b.ReportAllocs()
+		arena := &HeaderArena{}
+		var s string
+		for i := 0; i < b.N; i++ {
+			for _, v := range values {
+				s = arena.String(v)
+			}
+		}
+		_ = s
+	})
+}
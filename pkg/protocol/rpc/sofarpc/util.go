@@ -23,8 +23,19 @@ import (
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/serialize"
+	"mosn.io/mosn/pkg/protocol/sofarpc/models"
 )
 
+func init() {
+	// the keys carried by most of the bolt requests are shared by the decoded headers
+	for _, name := range []string{
+		models.TARGET_SERVICE_KEY, models.TARGET_METHOD, models.RPC_ID_KEY, models.TRACER_ID_KEY,
+		models.CALLER_IP_KEY, models.CALLER_ZONE_KEY, models.APP_NAME, models.SOFA_TRACE_BAGGAGE_DATA,
+	} {
+		protocol.RegisterHeaderName(name)
+	}
+}
+
 // NewResponse build sofa response msg according to given protocol code and respStatus
 func NewResponse(protocolCode byte, respStatus int16) SofaRpcCmd {
 	if builder, ok := responseFactory[protocolCode]; ok {
@@ -64,7 +75,7 @@ func DeserializeBoltRequest(ctx context.Context, request *BoltRequest) {
 	debugEnabled := logger.GetLogLevel() >= log.DEBUG
 
 	//deserialize header
-	serializeIns.DeserializeMap(request.HeaderMap, request.RequestHeader)
+	serializeIns.DeserializeMapArena(request.HeaderMap, request.RequestHeader, protocolCtx.GetHeaderArena())
 	if debugEnabled {
 		logger.Debugf(ctx, "[protocol][sofarpc] deserialize bolt request, header: %v", request.RequestHeader)
 	}
@@ -90,7 +101,7 @@ func DeserializeBoltResponse(ctx context.Context, response *BoltResponse) {
 	//response.ResponseHeader = make(map[string]string, 8)
 
 	//deserialize header
-	serializeIns.DeserializeMap(response.HeaderMap, response.ResponseHeader)
+	serializeIns.DeserializeMapArena(response.HeaderMap, response.ResponseHeader, protocolCtx.GetHeaderArena())
 	if debugEnabled {
 		logger.Debugf(ctx, "[protocol][sofarpc] deserialize bolt response, header: %+v", response.ResponseHeader)
 	}
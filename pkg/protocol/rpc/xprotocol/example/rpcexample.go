@@ -22,6 +22,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"net/http"
 	"strconv"
 
 	"mosn.io/mosn/pkg/log"
@@ -52,6 +53,27 @@ var (
 	ReqIDBeginOffset = 8
 	// ReqDataLen rpc-example request package size
 	ReqDataLen = 16
+	// TypeOffset rpc-example frame type field offset
+	TypeOffset = 2
+	// StatusOffset rpc-example response status field offset, 2 bytes
+	StatusOffset = 6
+)
+
+// rpc-example frame types
+const (
+	TypeRequest           byte = 0
+	TypeResponse          byte = 1
+	TypeHeartbeat         byte = 2
+	TypeHeartbeatResponse byte = 3
+)
+
+// rpc-example response status
+const (
+	StatusSuccess     uint16 = 0
+	StatusServerError uint16 = 1
+	StatusBusy        uint16 = 2
+	StatusTimeout     uint16 = 3
+	StatusNoService   uint16 = 4
 )
 
 func (re *rpcExample) SplitFrame(data []byte) [][]byte {
@@ -100,3 +122,52 @@ func (re *rpcExample) SetStreamID(data []byte, streamID string) []byte {
 	}
 	return data
 }
+
+func (re *rpcExample) IsHeartbeat(data []byte) bool {
+	return data[TypeOffset] == TypeHeartbeat || data[TypeOffset] == TypeHeartbeatResponse
+}
+
+func (re *rpcExample) HeartbeatReply(data []byte) []byte {
+	if data[TypeOffset] != TypeHeartbeat {
+		return nil
+	}
+	reply := make([]byte, ReqDataLen)
+	copy(reply, data)
+	reply[TypeOffset] = TypeHeartbeatResponse
+	return reply
+}
+
+func (re *rpcExample) GetStatusCode(data []byte) int {
+	switch binary.BigEndian.Uint16(data[StatusOffset:]) {
+	case StatusSuccess:
+		return http.StatusOK
+	case StatusBusy:
+		return http.StatusServiceUnavailable
+	case StatusTimeout:
+		return http.StatusGatewayTimeout
+	case StatusNoService:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (re *rpcExample) HijackResponse(streamID string, statusCode int) []byte {
+	var status uint16
+	switch statusCode {
+	case http.StatusOK:
+		status = StatusSuccess
+	case http.StatusServiceUnavailable:
+		status = StatusBusy
+	case http.StatusGatewayTimeout:
+		status = StatusTimeout
+	case http.StatusNotFound:
+		status = StatusNoService
+	default:
+		status = StatusServerError
+	}
+	resp := make([]byte, ReqDataLen)
+	resp[TypeOffset] = TypeResponse
+	binary.BigEndian.PutUint16(resp[StatusOffset:], status)
+	return re.SetStreamID(resp, streamID)
+}
@@ -187,6 +187,40 @@ func (xRpcCmd *XRpcCmd) Convert(data []byte) (map[string]string, []byte) {
 	return nil, nil
 }
 
+//Heartbeater
+func (xRpcCmd *XRpcCmd) IsHeartbeat(data []byte) bool {
+	heartbeatCmd, ok := xRpcCmd.codec.(Heartbeater)
+	if ok {
+		return heartbeatCmd.IsHeartbeat(data)
+	}
+	return false
+}
+func (xRpcCmd *XRpcCmd) HeartbeatReply(data []byte) []byte {
+	heartbeatCmd, ok := xRpcCmd.codec.(Heartbeater)
+	if ok {
+		return heartbeatCmd.HeartbeatReply(data)
+	}
+	return nil
+}
+
+//ResponseStatus
+func (xRpcCmd *XRpcCmd) GetStatusCode(data []byte) int {
+	responseStatusCmd, ok := xRpcCmd.codec.(ResponseStatus)
+	if ok {
+		return responseStatusCmd.GetStatusCode(data)
+	}
+	return 0
+}
+
+//Hijacker
+func (xRpcCmd *XRpcCmd) HijackResponse(streamID string, statusCode int) []byte {
+	hijackerCmd, ok := xRpcCmd.codec.(Hijacker)
+	if ok {
+		return hijackerCmd.HijackResponse(streamID, statusCode)
+	}
+	return nil
+}
+
 func (xRpcCmd *XRpcCmd) Get(key string) (value string, ok bool) {
 	value, ok = xRpcCmd.header[key]
 	return
@@ -0,0 +1,42 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"errors"
+	"strconv"
+
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	protocol.RegisterMapping(protocol.Xprotocol, &xprotocolMapping{})
+}
+
+type xprotocolMapping struct{}
+
+// MappingHeaderStatusCode reads the status code set by the stream layer,
+// the subprotocol codec should implement ResponseStatus
+func (m *xprotocolMapping) MappingHeaderStatusCode(headers types.HeaderMap) (int, error) {
+	status, ok := headers.Get(types.HeaderStatus)
+	if !ok {
+		return 0, errors.New("no response status in headers")
+	}
+	return strconv.Atoi(status)
+}
@@ -40,6 +40,16 @@ type XCmd interface {
 
 	//ProtocolConvertor
 	Convert(data []byte) (map[string]string, []byte)
+
+	//Heartbeater
+	IsHeartbeat(data []byte) bool
+	HeartbeatReply(data []byte) []byte
+
+	//ResponseStatus
+	GetStatusCode(data []byte) int
+
+	//Hijacker
+	HijackResponse(streamID string, statusCode int) []byte
 }
 
 // SubProtocol Name
@@ -70,3 +80,27 @@ type ProtocolConvertor interface {
 	Multiplexing
 	Convert(data []byte) (map[string]string, []byte)
 }
+
+// Heartbeater replies heartbeat frames in the stream layer base on Multiplexing,
+// heartbeats are never routed to the upstream
+type Heartbeater interface {
+	Multiplexing
+	// IsHeartbeat returns true if the frame is a heartbeat request or response
+	IsHeartbeat(data []byte) bool
+	// HeartbeatReply returns the reply of a heartbeat frame, nil means no reply
+	HeartbeatReply(data []byte) []byte
+}
+
+// ResponseStatus maps the response status to the http status code base on Multiplexing,
+// so that the proxy retries and stats work for the subprotocol
+type ResponseStatus interface {
+	Multiplexing
+	GetStatusCode(data []byte) int
+}
+
+// Hijacker builds the response of a request hijacked by the proxy base on Multiplexing,
+// such as no route or no healthy upstream. statusCode is a http status code
+type Hijacker interface {
+	Multiplexing
+	HijackResponse(streamID string, statusCode int) []byte
+}
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"unsafe"
 
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -60,6 +61,12 @@ func (s *simpleSerialization) SerializeMap(m map[string]string, b types.IoBuffer
 }
 
 func (s *simpleSerialization) DeserializeMap(b []byte, m map[string]string) error {
+	return s.DeserializeMapArena(b, m, nil)
+}
+
+// DeserializeMapArena is the same as DeserializeMap, the registered keys are shared
+// and the values are allocated in the arena.
+func (s *simpleSerialization) DeserializeMapArena(b []byte, m map[string]string, arena *protocol.HeaderArena) error {
 	totalLen := len(b)
 	index := 0
 
@@ -87,7 +94,7 @@ func (s *simpleSerialization) DeserializeMap(b []byte, m map[string]string) erro
 		value := b[index:end]
 		index = end
 
-		m[string(key)] = string(value)
+		m[protocol.InternHeaderName(key)] = arena.String(value)
 	}
 	return nil
 }
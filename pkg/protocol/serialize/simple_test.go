@@ -18,8 +18,11 @@
 package serialize
 
 import (
+	"encoding/binary"
+	"reflect"
 	"testing"
 
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/pkg/buffer"
 )
 
@@ -51,3 +54,62 @@ func BenchmarkDeserializeMap(b *testing.B) {
 		Instance.DeserializeMap(bytes, header)
 	}
 }
+
+func BenchmarkDeserializeMapArena(b *testing.B) {
+	headers := map[string]string{
+		"service":               "com.alipay.test.TestService:1.0",
+		"sofa_head_method_name": "echo",
+		"x-unregistered":        "value",
+	}
+
+	buf := buffer.GetIoBuffer(256)
+	Instance.SerializeMap(headers, buf)
+	bytes := buf.Bytes()
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		header := make(map[string]string)
+		for n := 0; n < b.N; n++ {
+			deserializeMapString(bytes, header)
+		}
+	})
+	b.Run("arena", func(b *testing.B) {
+		b.ReportAllocs()
+		header := make(map[string]string)
+		arena := &protocol.HeaderArena{}
+		for n := 0; n < b.N; n++ {
+			Instance.DeserializeMapArena(bytes, header, arena)
+		}
+	})
+}
+
+// deserializeMapString is the decoding without interning and arena, as the baseline of the benchmark
+func deserializeMapString(b []byte, m map[string]string) {
+	for index := 0; index < len(b); {
+		length := int(binary.BigEndian.Uint32(b[index:]))
+		key := b[index+4 : index+4+length]
+		index += 4 + length
+		length = int(binary.BigEndian.Uint32(b[index:]))
+		value := b[index+4 : index+4+length]
+		index += 4 + length
+		m[string(key)] = string(value)
+	}
+}
+
+func TestDeserializeMapArena(t *testing.T) {
+	headers := map[string]string{
+		"service":        "com.alipay.test.TestService:1.0",
+		"x-unregistered": "value",
+		"empty":          "",
+	}
+	buf := buffer.GetIoBuffer(256)
+	Instance.SerializeMap(headers, buf)
+
+	decoded := make(map[string]string)
+	if err := Instance.DeserializeMapArena(buf.Bytes(), decoded, &protocol.HeaderArena{}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(headers, decoded) {
+		t.Errorf("deserialize map expected %v, but got %v", headers, decoded)
+	}
+}
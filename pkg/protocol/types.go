@@ -28,6 +28,9 @@ const (
 	HTTP1     api.Protocol = "Http1"
 	HTTP2     api.Protocol = "Http2"
 	Xprotocol api.Protocol = "X"
+	// HTTPAuto is an upstream protocol only, it is HTTP/2 if h2 is negotiated by the upstream TLS handshake,
+	// otherwise HTTP/1.1
+	HTTPAuto api.Protocol = "HttpAuto"
 )
 
 // header direction definition
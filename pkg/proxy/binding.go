@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+)
+
+var (
+	errBindingBroken = errors.New("the bound upstream connection is broken")
+	errBindingClosed = errors.New("the downstream connection is closed")
+	errBindingNoHost = errors.New("no host is chosen to bind")
+)
+
+// upstreamBinding is the upstream connection that the downstream connection is bound to
+type upstreamBinding struct {
+	host   types.Host
+	pool   types.ConnectionPool
+	broken uint32
+}
+
+func (ub *upstreamBinding) markBroken() {
+	atomic.StoreUint32(&ub.broken, 1)
+}
+
+func (ub *upstreamBinding) isBroken() bool {
+	return atomic.LoadUint32(&ub.broken) == 1
+}
+
+func (ub *upstreamBinding) usable(protocol types.Protocol) bool {
+	return !ub.isBroken() && ub.pool.Protocol() == protocol && ub.host.Health()
+}
+
+// connectionBinding pins all the streams of a downstream connection to the same upstream connection
+// of each cluster, which is needed by the stateful protocols.
+// A dedicated connection pool is created for the downstream connection, so the upstream connection
+// is not shared with the other downstream connections, and it is closed with the downstream connection.
+type connectionBinding struct {
+	mux      sync.Mutex
+	rebind   bool
+	closed   bool
+	bindings map[string]*upstreamBinding // cluster name -> binding
+}
+
+func newConnectionBinding(cfg *v2.ConnectionBinding) *connectionBinding {
+	return &connectionBinding{
+		rebind:   cfg.RebindOnFailure,
+		bindings: make(map[string]*upstreamBinding),
+	}
+}
+
+// get returns the binding of the cluster, the downstream connection is bound to a new upstream connection
+// if there is no binding, or the binding is broken and rebind is allowed.
+func (b *connectionBinding) get(lbCtx types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol types.Protocol) (*upstreamBinding, error) {
+	name := snapshot.ClusterInfo().Name()
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.closed {
+		return nil, errBindingClosed
+	}
+	if ub, ok := b.bindings[name]; ok {
+		if ub.usable(protocol) {
+			return ub, nil
+		}
+		delete(b.bindings, name)
+		ub.pool.Close()
+		if !b.rebind {
+			return nil, errBindingBroken
+		}
+		log.DefaultLogger.Infof("[proxy] [binding] upstream %s of cluster %s is broken, rebind", ub.host.AddressString(), name)
+	}
+
+	factory, ok := network.ConnNewPoolFactories[protocol]
+	if !ok {
+		return nil, errUnknownPoolProtocol(protocol)
+	}
+	host := snapshot.LoadBalancer().ChooseHost(lbCtx)
+	if host == nil {
+		return nil, errBindingNoHost
+	}
+	ub := &upstreamBinding{
+		host: host,
+		pool: factory(host),
+	}
+	b.bindings[name] = ub
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[proxy] [binding] bind to upstream %s of cluster %s", host.AddressString(), name)
+	}
+	return ub, nil
+}
+
+// close shuts down the dedicated connection pools, it is called when the downstream connection is closed
+func (b *connectionBinding) close() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.closed = true
+	for name, ub := range b.bindings {
+		ub.pool.Close()
+		delete(b.bindings, name)
+	}
+}
+
+func errUnknownPoolProtocol(protocol types.Protocol) error {
+	return errors.New("protocol " + string(protocol) + " is not registered in pool factory")
+}
@@ -0,0 +1,175 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+)
+
+const bindingTestProtocol types.Protocol = "BindingTest"
+
+func init() {
+	network.RegisterNewPoolFactory(bindingTestProtocol, func(host types.Host) types.ConnectionPool {
+		return &bindingTestPool{host: host}
+	})
+}
+
+type bindingTestPool struct {
+	types.ConnectionPool
+	host   types.Host
+	closed bool
+}
+
+func (p *bindingTestPool) Protocol() types.Protocol {
+	return bindingTestProtocol
+}
+
+func (p *bindingTestPool) Close() {
+	p.closed = true
+}
+
+type bindingTestHost struct {
+	types.Host
+	addr    string
+	healthy bool
+}
+
+func (h *bindingTestHost) AddressString() string {
+	return h.addr
+}
+
+func (h *bindingTestHost) Health() bool {
+	return h.healthy
+}
+
+type bindingTestClusterInfo struct {
+	types.ClusterInfo
+}
+
+func (ci *bindingTestClusterInfo) Name() string {
+	return "binding_test"
+}
+
+// bindingTestSnapshot chooses the hosts in turn
+type bindingTestSnapshot struct {
+	types.ClusterSnapshot
+	hosts []*bindingTestHost
+	index int
+}
+
+type bindingTestLoadBalancer struct {
+	types.LoadBalancer
+	snapshot *bindingTestSnapshot
+}
+
+func (lb *bindingTestLoadBalancer) ChooseHost(ctx types.LoadBalancerContext) types.Host {
+	s := lb.snapshot
+	h := s.hosts[s.index%len(s.hosts)]
+	s.index++
+	return h
+}
+
+func (s *bindingTestSnapshot) ClusterInfo() types.ClusterInfo {
+	return &bindingTestClusterInfo{}
+}
+
+func (s *bindingTestSnapshot) LoadBalancer() types.LoadBalancer {
+	return &bindingTestLoadBalancer{snapshot: s}
+}
+
+func newBindingTestSnapshot() *bindingTestSnapshot {
+	return &bindingTestSnapshot{
+		hosts: []*bindingTestHost{
+			{addr: "127.0.0.1:8080", healthy: true},
+			{addr: "127.0.0.1:8081", healthy: true},
+		},
+	}
+}
+
+func TestConnectionBinding(t *testing.T) {
+	snapshot := newBindingTestSnapshot()
+	b := newConnectionBinding(&v2.ConnectionBinding{RebindOnFailure: true})
+	ub, err := b.get(nil, snapshot, bindingTestProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all the streams use the bound pool
+	for i := 0; i < 3; i++ {
+		next, err := b.get(nil, snapshot, bindingTestProtocol)
+		if err != nil || next != ub {
+			t.Fatalf("expected the bound upstream, but got %v, %v", next, err)
+		}
+	}
+	// rebind if the bound connection is broken
+	ub.markBroken()
+	rebound, err := b.get(nil, snapshot, bindingTestProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rebound == ub || rebound.host.AddressString() != "127.0.0.1:8081" || !ub.pool.(*bindingTestPool).closed {
+		t.Fatal("expected rebind to another upstream")
+	}
+	// rebind if the bound host is unhealthy
+	snapshot.hosts[1].healthy = false
+	if next, err := b.get(nil, snapshot, bindingTestProtocol); err != nil || next == rebound {
+		t.Fatal("expected rebind when the host is unhealthy")
+	}
+	b.close()
+	if _, err := b.get(nil, snapshot, bindingTestProtocol); err != errBindingClosed {
+		t.Fatalf("expected binding closed, but got %v", err)
+	}
+}
+
+func TestConnectionBindingWithoutRebind(t *testing.T) {
+	snapshot := newBindingTestSnapshot()
+	b := newConnectionBinding(&v2.ConnectionBinding{})
+	ub, err := b.get(nil, snapshot, bindingTestProtocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ub.markBroken()
+	if _, err := b.get(nil, snapshot, bindingTestProtocol); err != errBindingBroken {
+		t.Fatalf("expected binding broken, but got %v", err)
+	}
+	if !ub.pool.(*bindingTestPool).closed {
+		t.Fatal("the broken pool should be closed")
+	}
+	if _, err := b.get(nil, snapshot, types.Protocol("Unknown")); err == nil {
+		t.Fatal("expected error for unknown protocol")
+	}
+}
+
+func TestDownstreamUpstreamConnectionBroken(t *testing.T) {
+	p := &proxy{binding: newConnectionBinding(&v2.ConnectionBinding{})}
+	s := &downStream{proxy: p}
+	// no binding for the stream
+	s.onUpstreamConnectionBroken()
+	if s.closeDownstream {
+		t.Fatal("unexpected close downstream without binding")
+	}
+	ub, _ := p.binding.get(nil, newBindingTestSnapshot(), bindingTestProtocol)
+	s.upstreamBinding = ub
+	s.onUpstreamConnectionBroken()
+	if !ub.isBroken() || !s.closeDownstream {
+		t.Fatal("expected the binding broken and the downstream closed")
+	}
+}
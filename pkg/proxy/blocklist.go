@@ -0,0 +1,127 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// RequestBlocklist is the compiled v2.RequestBlocklist, it is shared by all the proxies created
+// by the same network filter config.
+type RequestBlocklist struct {
+	code  int
+	rules []blocklistRule
+}
+
+type blocklistRule struct {
+	pathPrefix   string
+	pathRegex    *regexp.Regexp
+	header       string
+	valueRegex   *regexp.Regexp
+	maxValueSize int
+}
+
+// NewRequestBlocklist compiles the blocklist config, returns an error if any regex is invalid
+func NewRequestBlocklist(cfg *v2.RequestBlocklist) (*RequestBlocklist, error) {
+	bl := &RequestBlocklist{
+		code:  cfg.StatusCode,
+		rules: make([]blocklistRule, 0, len(cfg.Rules)),
+	}
+	if bl.code == 0 {
+		bl.code = types.PermissionDeniedCode
+	}
+	for i, r := range cfg.Rules {
+		rule := blocklistRule{
+			pathPrefix:   r.PathPrefix,
+			header:       r.Header,
+			maxValueSize: r.MaxValueSize,
+		}
+		if r.PathRegex != "" {
+			re, err := regexp.Compile(r.PathRegex)
+			if err != nil {
+				return nil, fmt.Errorf("blocklist rule %d: invalid path regex: %v", i, err)
+			}
+			rule.pathRegex = re
+		}
+		if r.ValueRegex != "" {
+			if r.Header == "" {
+				return nil, fmt.Errorf("blocklist rule %d: value regex without header", i)
+			}
+			re, err := regexp.Compile(r.ValueRegex)
+			if err != nil {
+				return nil, fmt.Errorf("blocklist rule %d: invalid value regex: %v", i, err)
+			}
+			rule.valueRegex = re
+		}
+		if r.MaxValueSize > 0 && r.Header == "" {
+			return nil, fmt.Errorf("blocklist rule %d: max value size without header", i)
+		}
+		if rule.pathPrefix == "" && rule.pathRegex == nil && rule.header == "" {
+			return nil, fmt.Errorf("blocklist rule %d: no condition", i)
+		}
+		bl.rules = append(bl.rules, rule)
+	}
+	return bl, nil
+}
+
+// Match returns the response code if the request headers match any of the rules
+func (bl *RequestBlocklist) Match(headers types.HeaderMap) (int, bool) {
+	if headers == nil {
+		return 0, false
+	}
+	for i := range bl.rules {
+		if bl.rules[i].match(headers) {
+			return bl.code, true
+		}
+	}
+	return 0, false
+}
+
+func (r *blocklistRule) match(headers types.HeaderMap) bool {
+	if r.pathPrefix != "" || r.pathRegex != nil {
+		path, ok := headers.Get(protocol.MosnHeaderPathKey)
+		if !ok {
+			return false
+		}
+		if r.pathPrefix != "" && !strings.HasPrefix(path, r.pathPrefix) {
+			return false
+		}
+		if r.pathRegex != nil && !r.pathRegex.MatchString(path) {
+			return false
+		}
+	}
+	if r.header != "" {
+		value, ok := headers.Get(r.header)
+		if !ok {
+			return false
+		}
+		if r.maxValueSize > 0 && len(value) <= r.maxValueSize {
+			return false
+		}
+		if r.valueRegex != nil && !r.valueRegex.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
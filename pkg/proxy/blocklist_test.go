@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestRequestBlocklistMatch(t *testing.T) {
+	bl, err := NewRequestBlocklist(&v2.RequestBlocklist{
+		Rules: []v2.BlocklistRule{
+			{PathPrefix: "/wp-admin"},
+			{PathRegex: `\.(php|asp)$`},
+			{Header: "cookie", MaxValueSize: 16},
+			{PathPrefix: "/api", Header: "user-agent", ValueRegex: "(?i)sqlmap"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		headers map[string]string
+		reject  bool
+	}{
+		{map[string]string{protocol.MosnHeaderPathKey: "/wp-admin/setup"}, true},
+		{map[string]string{protocol.MosnHeaderPathKey: "/index.php"}, true},
+		{map[string]string{protocol.MosnHeaderPathKey: "/index.html"}, false},
+		{map[string]string{protocol.MosnHeaderPathKey: "/", "cookie": strings.Repeat("a", 17)}, true},
+		{map[string]string{protocol.MosnHeaderPathKey: "/", "cookie": strings.Repeat("a", 16)}, false},
+		{map[string]string{protocol.MosnHeaderPathKey: "/api/users", "user-agent": "SQLMap/1.0"}, true},
+		{map[string]string{protocol.MosnHeaderPathKey: "/home", "user-agent": "sqlmap/1.0"}, false},
+		{map[string]string{protocol.MosnHeaderPathKey: "/api/users", "user-agent": "curl"}, false},
+		{map[string]string{"user-agent": "sqlmap/1.0"}, false},
+	}
+	for i, tc := range testCases {
+		code, ok := bl.Match(protocol.CommonHeader(tc.headers))
+		if ok != tc.reject {
+			t.Errorf("case %d: expected reject %t, got %t", i, tc.reject, ok)
+		}
+		if ok && code != types.PermissionDeniedCode {
+			t.Errorf("case %d: unexpected code %d", i, code)
+		}
+	}
+}
+
+func TestNewRequestBlocklistInvalid(t *testing.T) {
+	for i, rule := range []v2.BlocklistRule{
+		{},
+		{PathRegex: "("},
+		{Header: "cookie", ValueRegex: "["},
+		{ValueRegex: "sqlmap"},
+		{MaxValueSize: 10},
+	} {
+		if _, err := NewRequestBlocklist(&v2.RequestBlocklist{Rules: []v2.BlocklistRule{rule}}); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+	bl, err := NewRequestBlocklist(&v2.RequestBlocklist{
+		StatusCode: 400,
+		Rules:      []v2.BlocklistRule{{Header: "x-scanner"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, ok := bl.Match(protocol.CommonHeader{"x-scanner": ""}); !ok || code != 400 {
+		t.Errorf("unexpected match result: %d, %t", code, ok)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 
 	"mosn.io/mosn/pkg/buffer"
+	"mosn.io/mosn/pkg/memory"
 	"mosn.io/mosn/pkg/network"
 )
 
@@ -38,6 +39,11 @@ func (ctx proxyBufferCtx) New() interface{} {
 	return new(proxyBuffers)
 }
 
+// MemoryModule accounts the buffers as the stream objects
+func (ctx proxyBufferCtx) MemoryModule() string {
+	return memory.ModuleStream
+}
+
 func (ctx proxyBufferCtx) Reset(i interface{}) {
 	buf, _ := i.(*proxyBuffers)
 	*buf = proxyBuffers{}
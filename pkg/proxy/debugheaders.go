@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package proxy
+
+import (
+	"strconv"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// debugHeaders decides whether the debug headers are added to a response
+type debugHeaders struct {
+	always        bool
+	triggerHeader string
+	triggerToken  string
+}
+
+func newDebugHeaders(config *v2.DebugHeaders) *debugHeaders {
+	if config == nil || (!config.Always && config.TriggerHeader == "") {
+		return nil
+	}
+	return &debugHeaders{
+		always:        config.Always,
+		triggerHeader: config.TriggerHeader,
+		triggerToken:  config.TriggerToken,
+	}
+}
+
+// enabled returns true if the debug headers should be added to the response of the request.
+// the trigger header is removed from the request, so the token is not sent to the upstream
+func (d *debugHeaders) enabled(headers types.HeaderMap) bool {
+	if d == nil || headers == nil {
+		return false
+	}
+	if d.triggerHeader == "" {
+		return d.always
+	}
+	value, ok := headers.Get(d.triggerHeader)
+	if !ok {
+		return d.always
+	}
+	headers.Del(d.triggerHeader)
+	return d.always || d.triggerToken == "" || value == d.triggerToken
+}
+
+// addDebugHeaders adds the upstream information of the stream to the response headers
+func (s *downStream) addDebugHeaders(headers types.HeaderMap) {
+	if headers == nil {
+		return
+	}
+	if r := s.upstreamRequest; r != nil && r.host != nil {
+		headers.Set(types.HeaderUpstreamHost, r.host.AddressString())
+		if received := s.requestInfo.ResponseReceivedDuration(); received > 0 && !r.startTime.IsZero() {
+			serviceTime := received - r.startTime.Sub(s.requestInfo.StartTime())
+			headers.Set(types.HeaderUpstreamServiceTime, strconv.FormatInt(int64(serviceTime/time.Millisecond), 10))
+		}
+	}
+	retries := 0
+	if a := s.attempts(); a != nil && len(a.UpstreamAttempts()) > 1 {
+		retries = len(a.UpstreamAttempts()) - 1
+	}
+	headers.Set(types.HeaderRetryCount, strconv.Itoa(retries))
+	if s.route != nil {
+		if rule, ok := s.route.RouteRule().(types.NamedRouteRule); ok && rule.RouteName() != "" {
+			headers.Set(types.HeaderRouteName, rule.RouteName())
+		}
+	}
+}
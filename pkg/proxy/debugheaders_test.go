@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockDebugHost struct {
+	types.Host
+	addr string
+}
+
+func (h *mockDebugHost) AddressString() string {
+	return h.addr
+}
+
+func TestDebugHeadersEnabled(t *testing.T) {
+	if newDebugHeaders(nil) != nil || newDebugHeaders(&v2.DebugHeaders{}) != nil {
+		t.Fatal("debug headers should be disabled")
+	}
+	var disabled *debugHeaders
+	if disabled.enabled(protocol.CommonHeader{}) {
+		t.Fatal("nil debug headers should not be enabled")
+	}
+	always := newDebugHeaders(&v2.DebugHeaders{Always: true})
+	if !always.enabled(protocol.CommonHeader{}) {
+		t.Fatal("debug headers should be always enabled")
+	}
+	triggered := newDebugHeaders(&v2.DebugHeaders{
+		TriggerHeader: "x-debug",
+		TriggerToken:  "secret",
+	})
+	for _, tc := range []struct {
+		headers protocol.CommonHeader
+		enabled bool
+	}{
+		{protocol.CommonHeader{}, false},
+		{protocol.CommonHeader{"x-debug": "wrong"}, false},
+		{protocol.CommonHeader{"x-debug": "secret"}, true},
+	} {
+		if triggered.enabled(tc.headers) != tc.enabled {
+			t.Errorf("headers %v expected enabled: %v", tc.headers, tc.enabled)
+		}
+		if _, ok := tc.headers.Get("x-debug"); ok {
+			t.Errorf("trigger header should be removed")
+		}
+	}
+}
+
+func TestAddDebugHeaders(t *testing.T) {
+	info := network.NewRequestInfo()
+	start := info.StartTime()
+	timeline := info.(types.RequestTimeline)
+	timeline.AddAttemptStart(start)
+	timeline.AddAttemptStart(start.Add(10 * time.Millisecond))
+	info.SetResponseReceivedDuration(start.Add(50 * time.Millisecond))
+	s := &downStream{
+		requestInfo: info,
+		route: &mockRoute{
+			rule: &mockNamedRouteRule{name: "debug_route"},
+		},
+		upstreamRequest: &upstreamRequest{
+			host:      &mockDebugHost{addr: "127.0.0.1:8080"},
+			startTime: start.Add(20 * time.Millisecond),
+		},
+	}
+	headers := protocol.CommonHeader{}
+	s.addDebugHeaders(headers)
+	expected := map[string]string{
+		types.HeaderUpstreamHost:        "127.0.0.1:8080",
+		types.HeaderUpstreamServiceTime: "30",
+		types.HeaderRetryCount:          "1",
+		types.HeaderRouteName:           "debug_route",
+	}
+	for k, v := range expected {
+		if value, _ := headers.Get(k); value != v {
+			t.Errorf("header %s expected %s, but got %s", k, v, value)
+		}
+	}
+
+	// direct response has no upstream host
+	s = &downStream{
+		requestInfo:     network.NewRequestInfo(),
+		route:           &mockRoute{},
+		upstreamRequest: &upstreamRequest{},
+	}
+	headers = protocol.CommonHeader{}
+	s.addDebugHeaders(headers)
+	if len(headers) != 1 || headers[types.HeaderRetryCount] != "0" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+}
@@ -30,16 +30,19 @@ import (
 
 	"mosn.io/api"
 	mbuffer "mosn.io/mosn/pkg/buffer"
+	"mosn.io/mosn/pkg/chaos"
 	"mosn.io/mosn/pkg/config/v2"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
 	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/timer"
 	"mosn.io/mosn/pkg/trace"
 	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
 	"mosn.io/pkg/buffer"
-	"mosn.io/pkg/utils"
 )
 
 // types.StreamEventListener
@@ -63,8 +66,8 @@ type downStream struct {
 	requestInfo     types.RequestInfo
 	responseSender  types.StreamSender
 	upstreamRequest *upstreamRequest
-	perRetryTimer   *utils.Timer
-	responseTimer   *utils.Timer
+	perRetryTimer   *timer.Timer
+	responseTimer   *timer.Timer
 
 	// ~~~ downstream request buf
 	downstreamReqHeaders  types.HeaderMap
@@ -107,6 +110,11 @@ type downStream struct {
 	receiverFilters      []*activeStreamReceiverFilter
 	receiverFiltersIndex int
 	receiverFiltersAgain bool
+	// the receive filters pause state, and the phase that the stream is paused in
+	receivePause uint32
+	pausedPhase  types.Phase
+	// the stream filter overrides of the route are applied
+	filterOverridden bool
 
 	context context.Context
 
@@ -115,6 +123,34 @@ type downStream struct {
 	logDone          uint32
 
 	snapshot types.ClusterSnapshot
+
+	// time cost to get a ready upstream stream from the connection pool
+	upstreamConnectDuration time.Duration
+
+	// the upstream connection that the downstream connection is bound to, if connection binding is enabled
+	upstreamBinding *upstreamBinding
+	// close the downstream connection when the stream is cleaned, as the bound upstream connection is broken
+	closeDownstream bool
+
+	// the route's concurrency limiter that the stream holds a slot of or is queued in
+	concurrencyLimiter types.ConcurrencyLimiter
+	concurrencyState   uint32
+	concurrencyCancel  func()
+
+	// add the debug headers to the response
+	debugHeaders bool
+
+	// the redirect responses followed by the internal redirect policy
+	internalRedirects uint32
+
+	// the upstream attempts of the request, including the retries
+	upstreamAttempts uint32
+
+	// the request is routed to the failover cluster of the route
+	failedOver bool
+
+	// the memory of the headers and data held by the stream
+	memory streamMemory
 }
 
 func newActiveStream(ctx context.Context, proxy *proxy, responseSender types.StreamSender, span types.Span) *downStream {
@@ -201,20 +237,20 @@ func (s *downStream) cleanStream() {
 		ef.filter.OnDestroy()
 	}
 
-	// record metrics
-	s.requestMetrics()
-
 	// finish tracing
 	s.finishTracing()
 
-	// write access log
-	s.writeLog()
+	// the downstream connection is closed as the session state on the bound upstream connection is lost
+	if s.closeDownstream {
+		log.Proxy.Warnf(s.context, "[proxy] [downstream] bound upstream connection is broken, close the downstream connection")
+		s.proxy.readCallbacks.Connection().Close(api.FlushWrite, api.LocalClose)
+	}
 
 	// delete stream reference
 	s.delete()
 
-	// recycle if no reset events
-	s.giveStream()
+	// record metrics, write access log and recycle the stream
+	s.telemetry()
 }
 
 // requestMetrics records the request metrics when cleanStream
@@ -244,18 +280,52 @@ func (s *downStream) requestMetrics() {
 		s.proxy.listenerStats.DownstreamRequestTime.Update(streamDurationNs)
 		s.proxy.listenerStats.DownstreamRequestTimeTotal.Inc(streamDurationNs)
 
+		requestBytes := int64(s.requestInfo.BytesReceived())
+		responseBytes := int64(s.requestInfo.BytesSent())
+		s.proxy.stats.DownstreamRequestBytes.Update(requestBytes)
+		s.proxy.stats.DownstreamResponseBytes.Update(responseBytes)
+		s.proxy.listenerStats.DownstreamRequestBytes.Update(requestBytes)
+		s.proxy.listenerStats.DownstreamResponseBytes.Update(responseBytes)
+
+		if s.upstreamConnectDuration > 0 {
+			connectNs := s.upstreamConnectDuration.Nanoseconds()
+			s.proxy.stats.UpstreamConnectTime.Update(connectNs)
+			s.proxy.listenerStats.UpstreamConnectTime.Update(connectNs)
+		}
+
 		if s.isRequestFailed() {
 			s.proxy.stats.DownstreamRequestFailed.Inc(1)
 			s.proxy.listenerStats.DownstreamRequestFailed.Inc(1)
 		}
 
+		for _, name := range types.ResponseFlagNames(s.requestInfo) {
+			s.proxy.stats.responseFlag(name).Inc(1)
+			s.proxy.listenerStats.responseFlag(name).Inc(1)
+		}
+
+		s.detailMetrics()
+		s.recordRequestError()
 	}
 	// countdown metrics
 	s.proxy.stats.DownstreamRequestActive.Dec(1)
 	s.proxy.listenerStats.DownstreamRequestActive.Dec(1)
 }
 
-const mosnProcessFailed = api.NoHealthyUpstream | api.NoRouteFound | api.FaultInjected | api.RateLimited
+// detailMetrics records the per-route and per-upstream-host detailed stats if enabled
+func (s *downStream) detailMetrics() {
+	if metrics.RouteStatsEnabled() && s.route != nil {
+		if rule, ok := s.route.RouteRule().(types.NamedRouteRule); ok && rule.RouteName() != "" {
+			getRouteStats(rule.RouteName()).record(s.requestInfo)
+		}
+	}
+	if metrics.HostDetailStatsEnabled() && s.upstreamRequest != nil && s.upstreamRequest.host != nil {
+		host := s.upstreamRequest.host
+		getHostDetailStats(host.ClusterInfo().Name(), host.AddressString()).record(s.requestInfo)
+	}
+}
+
+const mosnProcessFailed = api.NoHealthyUpstream | api.NoRouteFound | api.FaultInjected | api.RateLimited |
+	types.RequestRejected | types.LoopDetected | types.RouteConcurrencyLimited | types.OverloadShed
 
 // isRequestFailed marks request failed due to mosn process
 func (s *downStream) isRequestFailed() bool {
@@ -308,6 +378,7 @@ func (s *downStream) OnResetStream(reason types.StreamResetReason) {
 func (s *downStream) ResetStream(reason types.StreamResetReason) {
 	s.proxy.stats.DownstreamRequestReset.Inc(1)
 	s.proxy.listenerStats.DownstreamRequestReset.Inc(1)
+	s.requestInfo.SetResponseFlag(types.DownstreamConnectionTermination)
 	// we assume downstream client close the connection when timeout, we do not care about the network makes connection closed.
 	s.requestInfo.SetResponseCode(types.TimeoutExceptionCode)
 	s.cleanStream()
@@ -323,12 +394,17 @@ func (s *downStream) OnReceive(ctx context.Context, headers types.HeaderMap, dat
 		data.Drain(data.Len())
 	}
 	s.downstreamReqTrailers = trailers
+	s.memory.holdRequest(headers, s.downstreamReqDataBuf, trailers)
 
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
 		log.Proxy.Debugf(s.context, "[proxy] [downstream] OnReceive headers:%+v, data:%+v, trailers:%+v", headers, data, trailers)
 	}
 
-	id := s.ID
+	s.scheduleReceive(ctx, s.ID, types.InitPhase)
+}
+
+// scheduleReceive runs the proxy phases of the stream from the phase in a goroutine
+func (s *downStream) scheduleReceive(ctx context.Context, id uint32, phase types.Phase) {
 	// goroutine for proxy
 	pool.ScheduleAuto(func() {
 		defer func() {
@@ -342,7 +418,6 @@ func (s *downStream) OnReceive(ctx context.Context, headers types.HeaderMap, dat
 			}
 		}()
 
-		phase := types.InitPhase
 		for i := 0; i < 10; i++ {
 			s.cleanNotify()
 
@@ -366,6 +441,17 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 		switch phase {
 		// init phase
 		case types.InitPhase:
+			// reject the garbage requests before the route lookup and the stream filters creation
+			if s.rejectByBlocklist() || s.rejectByLoopDetection() {
+				if p, err := s.processError(id); err != nil {
+					return p
+				}
+			}
+			s.debugHeaders = s.proxy.debugHeaders.enabled(s.downstreamReqHeaders)
+			if s.proxy.forwardClientCert != nil {
+				s.proxy.forwardClientCert.apply(s.downstreamReqHeaders, s.downstreamCertificates())
+			}
+			s.proxy.createStreamFilters(s)
 			phase++
 
 			// downstream filter before route
@@ -373,7 +459,9 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 			if log.Proxy.GetLogLevel() >= log.DEBUG {
 				log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
 			}
-			s.runReceiveFilters(phase, s.downstreamReqHeaders, s.downstreamReqDataBuf, s.downstreamReqTrailers)
+			if s.runReceiveFiltersOrPause(phase) {
+				return types.End
+			}
 
 			if p, err := s.processError(id); err != nil {
 				return p
@@ -389,6 +477,7 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 			if p, err := s.processError(id); err != nil {
 				return p
 			}
+			s.applyFilterOverrides()
 			phase++
 
 			// downstream filter after route
@@ -396,7 +485,9 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 			if log.Proxy.GetLogLevel() >= log.DEBUG {
 				log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
 			}
-			s.runReceiveFilters(phase, s.downstreamReqHeaders, s.downstreamReqDataBuf, s.downstreamReqTrailers)
+			if s.runReceiveFiltersOrPause(phase) {
+				return types.End
+			}
 
 			if p, err := s.processError(id); err != nil {
 				return p
@@ -409,7 +500,9 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 				if log.Proxy.GetLogLevel() >= log.DEBUG {
 					log.Proxy.Debugf(s.context, "[proxy] [downstream] enter phase %d, proxyId = %d  ", phase, id)
 				}
-				s.receiveHeaders(s.downstreamReqDataBuf == nil && s.downstreamReqTrailers == nil)
+				if s.receiveHeadersOrQueue(s.downstreamReqDataBuf == nil && s.downstreamReqTrailers == nil) {
+					return types.End
+				}
 
 				if p, err := s.processError(id); err != nil {
 					return p
@@ -487,6 +580,11 @@ func (s *downStream) receive(ctx context.Context, id uint32, phase types.Phase)
 				return p
 			}
 
+			// follow the redirect response, the request is routed again
+			if s.internalRedirect() {
+				return types.MatchRoute
+			}
+
 			if log.Proxy.GetLogLevel() >= log.DEBUG {
 				log.Proxy.Debugf(s.context, "[proxy] [downstream] OnReceive send downstream response %+v", s.downstreamRespHeaders)
 			}
@@ -593,11 +691,29 @@ func (s *downStream) matchRoute() {
 		return
 	}
 	s.snapshot, s.route = handlerChain.DoNextHandler()
+	if t := s.timeline(); t != nil {
+		t.SetRouteMatchedDuration(time.Now())
+	}
+}
+
+// timeline returns the request timeline, it is nil if the request info does not record it
+func (s *downStream) timeline() types.RequestTimeline {
+	t, _ := s.requestInfo.(types.RequestTimeline)
+	return t
+}
+
+// attempts returns the upstream attempts recorder, it is nil if the request info does not record the attempts
+func (s *downStream) attempts() types.UpstreamAttemptsRecorder {
+	a, _ := s.requestInfo.(types.UpstreamAttemptsRecorder)
+	return a
 }
 
 func (s *downStream) convertProtocol() (dp, up types.Protocol) {
 	dp = s.getDownstreamProtocol()
 	up = s.getUpstreamProtocol()
+	if up == protocol.HTTPAuto && s.upstreamRequest != nil {
+		up = s.upstreamRequest.protocol
+	}
 	return
 }
 
@@ -613,7 +729,12 @@ func (s *downStream) getDownstreamProtocol() (prot types.Protocol) {
 func (s *downStream) getUpstreamProtocol() (currentProtocol types.Protocol) {
 	configProtocol := s.proxy.config.UpstreamProtocol
 
-	// if route exists upstream protocol, it will replace the proxy config's upstream protocol
+	// if cluster exists upstream protocol, it will replace the proxy config's upstream protocol
+	if s.cluster != nil && s.cluster.UpstreamProtocol() != "" {
+		configProtocol = string(s.cluster.UpstreamProtocol())
+	}
+
+	// if route exists upstream protocol, it will replace the cluster's upstream protocol
 	if s.route != nil && s.route.RouteRule() != nil && s.route.RouteRule().UpstreamProtocol() != "" {
 		configProtocol = s.route.RouteRule().UpstreamProtocol()
 	}
@@ -628,6 +749,16 @@ func (s *downStream) getUpstreamProtocol() (currentProtocol types.Protocol) {
 	return currentProtocol
 }
 
+// resolveUpstreamProtocol returns the protocol of the upstream request,
+// the HTTP auto protocol is resolved to the protocol of the chosen connection pool
+func (s *downStream) resolveUpstreamProtocol(pool types.ConnectionPool) types.Protocol {
+	prot := s.getUpstreamProtocol()
+	if prot == protocol.HTTPAuto {
+		prot = pool.Protocol()
+	}
+	return prot
+}
+
 func (s *downStream) receiveHeaders(endStream bool) {
 	s.downstreamRecvDone = endStream
 
@@ -647,6 +778,12 @@ func (s *downStream) receiveHeaders(endStream bool) {
 		} else {
 			s.sendHijackReply(resp.StatusCode(), s.downstreamReqHeaders)
 		}
+		if r, ok := resp.(types.ContentTypeDirectResponseRule); ok && r.ContentType() != "" {
+			s.downstreamRespHeaders.Set(headerContentType, r.ContentType())
+		}
+		return
+	}
+	if s.serveStaticFiles() {
 		return
 	}
 	// not direct response, needs a cluster snapshot and route rule
@@ -656,7 +793,8 @@ func (s *downStream) receiveHeaders(endStream bool) {
 		s.sendHijackReply(types.RouterUnavailableCode, s.downstreamReqHeaders)
 		return
 	}
-	if s.snapshot == nil || reflect.ValueOf(s.snapshot).IsNil() {
+	s.failoverUnavailableCluster()
+	if s.snapshot == nil {
 		// no available cluster
 		log.Proxy.Alertf(s.context, types.ErrorKeyClusterGet, " cluster snapshot is nil, cluster name is: %s", s.route.RouteRule().ClusterName())
 		s.requestInfo.SetResponseFlag(api.NoRouteFound)
@@ -673,7 +811,19 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	s.cluster = s.snapshot.ClusterInfo()
 	s.requestInfo.SetRouteEntry(s.route.RouteRule())
 
+	if !s.acquireConcurrency() {
+		// the stream is queued or rejected by the route concurrency limit
+		return
+	}
+	s.sendUpstreamHeaders(endStream)
+}
+
+// sendUpstreamHeaders chooses the upstream connection pool and sends the request headers
+func (s *downStream) sendUpstreamHeaders(endStream bool) {
 	pool, err := s.initializeUpstreamConnectionPool(s)
+	if err != nil && s.failover(failoverNoHealthyUpstream) {
+		pool, err = s.initializeUpstreamConnectionPool(s)
+	}
 	if err != nil {
 		log.Proxy.Alertf(s.context, types.ErrorKeyUpstreamConn, "initialize Upstream Connection Pool error, request can't be proxyed, error = %v", err)
 		s.requestInfo.SetResponseFlag(api.NoHealthyUpstream)
@@ -686,7 +836,7 @@ func (s *downStream) receiveHeaders(endStream bool) {
 		log.Proxy.Debugf(s.context, "[proxy] [downstream] timeout info: %+v", s.timeout)
 	}
 
-	prot := s.getUpstreamProtocol()
+	prot := s.resolveUpstreamProtocol(pool)
 
 	s.retryState = newRetryState(s.route.RouteRule().Policy().RetryPolicy(), s.downstreamReqHeaders, s.cluster, prot)
 
@@ -697,7 +847,7 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	s.upstreamRequest.proxy = s.proxy
 	s.upstreamRequest.protocol = prot
 	s.upstreamRequest.connPool = pool
-	s.route.RouteRule().FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
+	s.finalizeRequestHeaders()
 
 	//Call upstream's append header method to build upstream's request
 	s.upstreamRequest.appendHeaders(endStream)
@@ -707,6 +857,162 @@ func (s *downStream) receiveHeaders(endStream bool) {
 	}
 }
 
+// finalizeRequestHeaders mutates the request headers by the route, the variables
+// in the headers are evaluated with the stream context if the route supports
+func (s *downStream) finalizeRequestHeaders() {
+	rule := s.route.RouteRule()
+	if r, ok := rule.(types.ContextRouteRule); ok {
+		r.FinalizeRequestHeadersWithContext(s.context, s.downstreamReqHeaders, s.requestInfo)
+		return
+	}
+	rule.FinalizeRequestHeaders(s.downstreamReqHeaders, s.requestInfo)
+}
+
+func (s *downStream) finalizeResponseHeaders(headers types.HeaderMap) {
+	rule := s.route.RouteRule()
+	if r, ok := rule.(types.ContextRouteRule); ok {
+		r.FinalizeResponseHeadersWithContext(s.context, headers, s.requestInfo)
+		return
+	}
+	rule.FinalizeResponseHeaders(headers, s.requestInfo)
+}
+
+// rejectByBlocklist sends a hijack reply if the request headers match the request blocklist
+func (s *downStream) rejectByBlocklist() bool {
+	if s.proxy.blocklist == nil {
+		return false
+	}
+	code, ok := s.proxy.blocklist.Match(s.downstreamReqHeaders)
+	if !ok {
+		return false
+	}
+	s.proxy.stats.DownstreamRequestRejected.Inc(1)
+	s.proxy.listenerStats.DownstreamRequestRejected.Inc(1)
+	s.requestInfo.SetResponseFlag(types.RequestRejected)
+	s.sendHijackReply(code, nil)
+	return true
+}
+
+// the route concurrency limit states of the stream
+const (
+	concurrencyNone uint32 = iota
+	// the stream is queued, the stream goroutine is not released yet
+	concurrencyQueuing
+	// the stream goroutine is released, the stream is resumed by the limiter
+	concurrencyQueued
+	// the slot is got in the queue, the stream is not resumed yet
+	concurrencyResumed
+	// the queue wait is timeout, the stream is not resumed yet
+	concurrencyTimeout
+	// the stream holds a slot
+	concurrencyAcquired
+	// the slot is released or the queue wait is cancelled
+	concurrencyDone
+)
+
+// receiveHeadersOrQueue receives the headers, or continues the stream resumed by the route concurrency limit.
+// returns true if the stream is queued, the stream goroutine should be released
+func (s *downStream) receiveHeadersOrQueue(endStream bool) bool {
+	state := atomic.LoadUint32(&s.concurrencyState)
+	if state != concurrencyResumed && state != concurrencyTimeout {
+		s.receiveHeaders(endStream)
+		if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyQueuing, concurrencyQueued) {
+			return true
+		}
+		// not queued, or notified before the stream goroutine is released
+		state = atomic.LoadUint32(&s.concurrencyState)
+	}
+	switch state {
+	case concurrencyResumed:
+		if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyResumed, concurrencyAcquired) {
+			s.sendUpstreamHeaders(endStream)
+		}
+	case concurrencyTimeout:
+		if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyTimeout, concurrencyDone) {
+			s.rejectByConcurrency()
+		}
+	}
+	return false
+}
+
+// acquireConcurrency gets an in-flight slot if the route has a concurrency limit,
+// returns false if the stream is queued or rejected.
+// the queued stream is resumed in the DownRecvHeader phase when the slot is got or the wait is timeout,
+// the slot is released when the stream is cleaned
+func (s *downStream) acquireConcurrency() bool {
+	// the route is matched again, release the slot of the previous route
+	s.releaseConcurrency()
+	rule, ok := s.route.RouteRule().(types.ConcurrencyLimitedRouteRule)
+	if !ok {
+		return true
+	}
+	limiter := rule.ConcurrencyLimiter()
+	if limiter == nil {
+		return true
+	}
+	atomic.StoreUint32(&s.concurrencyState, concurrencyQueuing)
+	acquired, cancel := limiter.Acquire(func(acquired bool) {
+		s.onConcurrencyNotify(limiter, acquired)
+	})
+	s.concurrencyLimiter = limiter
+	if acquired {
+		atomic.StoreUint32(&s.concurrencyState, concurrencyAcquired)
+		return true
+	}
+	if cancel == nil {
+		// the queue is full
+		atomic.StoreUint32(&s.concurrencyState, concurrencyDone)
+		s.rejectByConcurrency()
+		return false
+	}
+	s.concurrencyCancel = cancel
+	return false
+}
+
+// onConcurrencyNotify is called by the limiter when the queued stream gets the slot or the wait is timeout
+func (s *downStream) onConcurrencyNotify(limiter types.ConcurrencyLimiter, acquired bool) {
+	state := concurrencyTimeout
+	if acquired {
+		state = concurrencyResumed
+	}
+	// the stream goroutine is not released, it continues the stream
+	if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyQueuing, state) {
+		return
+	}
+	if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyQueued, state) {
+		s.scheduleReceive(s.context, s.ID, types.DownRecvHeader)
+		return
+	}
+	// the stream is cleaned
+	if acquired {
+		limiter.Release()
+	}
+}
+
+// releaseConcurrency releases the slot or cancels the queue wait of the route concurrency limit
+func (s *downStream) releaseConcurrency() {
+	if s.concurrencyLimiter == nil {
+		return
+	}
+	if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyQueuing, concurrencyDone) ||
+		atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyQueued, concurrencyDone) {
+		if s.concurrencyCancel != nil {
+			s.concurrencyCancel()
+		}
+	} else if atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyAcquired, concurrencyDone) ||
+		atomic.CompareAndSwapUint32(&s.concurrencyState, concurrencyResumed, concurrencyDone) {
+		s.concurrencyLimiter.Release()
+	}
+	s.concurrencyLimiter = nil
+	s.concurrencyCancel = nil
+}
+
+func (s *downStream) rejectByConcurrency() {
+	log.Proxy.Warnf(s.context, "[proxy] [downstream] route concurrency limit exceeded, cluster name is: %s", s.cluster.Name())
+	s.requestInfo.SetResponseFlag(api.UpstreamOverflow | types.RouteConcurrencyLimited)
+	s.sendHijackReply(types.UpstreamOverFlowCode, s.downstreamReqHeaders)
+}
+
 func (s *downStream) receiveData(endStream bool) {
 	// if active stream finished before receive data, just ignore further data
 	if s.processDone() {
@@ -785,7 +1091,7 @@ func (s *downStream) onUpstreamRequestSent() {
 			}
 
 			ID := s.ID
-			s.responseTimer = utils.NewTimer(s.timeout.GlobalTimeout,
+			s.responseTimer = timer.AfterFunc(s.timeout.GlobalTimeout,
 				func() {
 					atomic.StoreUint32(&s.reuseBuffer, 0)
 
@@ -838,7 +1144,7 @@ func (s *downStream) setupPerReqTimeout() {
 		}
 
 		ID := s.ID
-		s.perRetryTimer = utils.NewTimer(timeout.TryTimeout,
+		s.perRetryTimer = timer.AfterFunc(timeout.TryTimeout,
 			func() {
 				atomic.StoreUint32(&s.reuseBuffer, 0)
 
@@ -890,21 +1196,56 @@ func (s *downStream) initializeUpstreamConnectionPool(lbCtx types.LoadBalancerCo
 
 	currentProtocol := s.getUpstreamProtocol()
 
+	if s.proxy.binding != nil {
+		return s.boundConnectionPool(lbCtx, currentProtocol)
+	}
+
+	chaos.DelayPoolAllocation(s.cluster.Name())
 	connPool = s.proxy.clusterManager.ConnPoolForCluster(lbCtx, s.snapshot, currentProtocol)
 
 	if connPool == nil {
 		return nil, fmt.Errorf("[proxy] [downstream] no healthy upstream in cluster %s", s.cluster.Name())
 	}
 
+	cluster.RecordClusterUsage(s.cluster.Name(), currentProtocol)
+
 	// TODO: update upstream stats
 
 	return connPool, nil
 }
 
+// boundConnectionPool returns the connection pool that the downstream connection is bound to
+func (s *downStream) boundConnectionPool(lbCtx types.LoadBalancerContext, protocol types.Protocol) (types.ConnectionPool, error) {
+	ub, err := s.proxy.binding.get(lbCtx, s.snapshot, protocol)
+	if err != nil {
+		if err == errBindingBroken {
+			s.closeDownstream = true
+		}
+		return nil, fmt.Errorf("[proxy] [downstream] get bound upstream in cluster %s failed: %v", s.cluster.Name(), err)
+	}
+	s.upstreamBinding = ub
+	return ub.pool, nil
+}
+
+// onUpstreamConnectionBroken marks the bound upstream connection broken, the downstream connection is
+// closed if rebind is not allowed
+func (s *downStream) onUpstreamConnectionBroken() {
+	if s.upstreamBinding == nil {
+		return
+	}
+	s.upstreamBinding.markBroken()
+	if !s.proxy.binding.rebind {
+		s.closeDownstream = true
+	}
+}
+
 // ~~~ active stream sender wrapper
 
 func (s *downStream) appendHeaders(endStream bool) {
 	s.upstreamProcessDone = endStream
+	if s.debugHeaders {
+		s.addDebugHeaders(s.downstreamRespHeaders)
+	}
 	headers := s.convertHeader(s.downstreamRespHeaders)
 	//Currently, just log the error
 	if err := s.responseSender.AppendHeaders(s.context, headers, endStream); err != nil {
@@ -1001,6 +1342,7 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 			if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 				s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 				s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+				s.recordUpstreamSLO(0)
 			}
 
 			// setup retry timer and return
@@ -1010,9 +1352,17 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 			return
 		} else if retryCheck == api.RetryOverflow {
 			s.requestInfo.SetResponseFlag(api.UpstreamOverflow)
+		} else if s.retryState.limitExceeded {
+			s.requestInfo.SetResponseFlag(types.UpstreamRetryLimitExceeded)
 		}
 	}
 
+	if reason != types.UpstreamGlobalTimeout && !s.downstreamResponseStarted && s.failoverUpstream(0, true) {
+		log.Proxy.Infof(s.context, "[proxy] [downstream] onUpstreamReset, failover, reason %v", reason)
+		atomic.CompareAndSwapUint32(&s.upstreamReset, 1, 0)
+		return
+	}
+
 	// clean up all timers
 	s.cleanUp()
 
@@ -1036,6 +1386,7 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 		if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 			s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+			s.recordUpstreamSLO(0)
 		}
 		// clear reset flag
 		log.Proxy.Infof(s.context, "[proxy] [downstream] onUpstreamReset, send hijack, reason %v", reason)
@@ -1047,6 +1398,8 @@ func (s *downStream) onUpstreamReset(reason types.StreamResetReason) {
 func (s *downStream) onUpstreamHeaders(endStream bool) {
 	headers := s.downstreamRespHeaders
 
+	s.backoffUpstreamHost(headers)
+
 	// check retry
 	if s.retryState != nil {
 		retryCheck := s.retryState.retry(headers, "")
@@ -1055,23 +1408,30 @@ func (s *downStream) onUpstreamHeaders(endStream bool) {
 			if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
 				s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
 				s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+				s.recordUpstreamSLO(s.requestInfo.ResponseCode())
 			}
 
 			return
 		} else if retryCheck == api.RetryOverflow {
 			s.requestInfo.SetResponseFlag(api.UpstreamOverflow)
+		} else if s.retryState.limitExceeded {
+			s.requestInfo.SetResponseFlag(types.UpstreamRetryLimitExceeded)
 		}
 
 		s.retryState.reset()
 	}
 
+	if s.failoverUpstream(s.requestInfo.ResponseCode(), endStream) {
+		return
+	}
+
 	s.handleUpstreamStatusCode()
 
 	s.downstreamResponseStarted = true
 
 	// directResponse for no route should be nil
 	if s.route != nil {
-		s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
+		s.finalizeResponseHeaders(headers)
 	}
 
 	if endStream {
@@ -1092,6 +1452,17 @@ func (s *downStream) handleUpstreamStatusCode() {
 			s.upstreamRequest.host.HostStats().UpstreamResponseSuccess.Inc(1)
 			s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseSuccess.Inc(1)
 		}
+		s.recordUpstreamSLO(s.requestInfo.ResponseCode())
+	}
+}
+
+// recordUpstreamSLO records the upstream response code if the cluster tracks slo,
+// zero code means the upstream request gets no response
+func (s *downStream) recordUpstreamSLO(code int) {
+	if info, ok := s.upstreamRequest.host.ClusterInfo().(types.SLOClusterInfo); ok {
+		if tracker := info.SLOTracker(); tracker != nil {
+			tracker.Record(code)
+		}
 	}
 }
 
@@ -1156,10 +1527,29 @@ func (s *downStream) setupRetry(endStream bool) bool {
 	return true
 }
 
+// backoffUpstreamHost backs off the upstream host that is overloaded, so the retries and the
+// following requests prefer the other hosts until the Retry-After is elapsed
+func (s *downStream) backoffUpstreamHost(headers types.HeaderMap) {
+	if headers == nil || s.upstreamRequest == nil || s.upstreamRequest.host == nil {
+		return
+	}
+	code, err := protocol.MappingHeaderStatusCode(s.upstreamRequest.protocol, headers)
+	if err != nil {
+		return
+	}
+	if d, ok := retryAfterOf(code, headers); ok {
+		cluster.BackoffHost(s.upstreamRequest.host, d)
+	}
+}
+
 // Note: retry-timer MUST be stopped before active stream got recycled, otherwise resetting stream's properties will cause panic here
 func (s *downStream) doRetry() {
-	// retry interval
-	time.Sleep(10 * time.Millisecond)
+	// retry interval, waits for the Retry-After of the upstream if it is longer
+	interval := 10 * time.Millisecond
+	if s.retryState != nil && s.retryState.retryAfter > interval {
+		interval = s.retryState.retryAfter
+	}
+	time.Sleep(interval)
 
 	// no reuse buffer
 	atomic.StoreUint32(&s.reuseBuffer, 0)
@@ -1176,6 +1566,7 @@ func (s *downStream) doRetry() {
 	s.upstreamRequest = &upstreamRequest{
 		downStream: s,
 		proxy:      s.proxy,
+		protocol:   s.resolveUpstreamProtocol(pool),
 		connPool:   pool,
 	}
 
@@ -1263,6 +1654,9 @@ func (s *downStream) cleanUp() {
 		s.responseTimer = nil
 	}
 
+	// release the route concurrency slot
+	s.releaseConcurrency()
+
 }
 
 func (s *downStream) setBufferLimit(bufferLimit uint32) {
@@ -1321,10 +1715,11 @@ func (s *downStream) DownstreamContext() context.Context {
 }
 
 func (s *downStream) giveStream() {
-	if atomic.LoadUint32(&s.reuseBuffer) != 1 {
-		return
-	}
-	if atomic.LoadUint32(&s.upstreamReset) == 1 || atomic.LoadUint32(&s.downstreamReset) == 1 {
+	if atomic.LoadUint32(&s.reuseBuffer) != 1 || atomic.LoadUint32(&s.upstreamReset) == 1 || atomic.LoadUint32(&s.downstreamReset) == 1 {
+		// the buffers are not reused, and released by the GC
+		if ctx := mbuffer.PoolContext(s.context); ctx != nil {
+			ctx.Discard()
+		}
 		return
 	}
 
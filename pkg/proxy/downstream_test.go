@@ -19,11 +19,13 @@ package proxy
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/trace"
@@ -298,3 +300,220 @@ func TestProcessError(t *testing.T) {
 		t.Errorf("TestprocessError Error")
 	}
 }
+
+func TestDetailMetrics(t *testing.T) {
+	metrics.SetDetailedStats(true, false, 0)
+	defer metrics.SetDetailedStats(false, false, 0)
+
+	for _, code := range []int{200, 200, 404, 503} {
+		s := &downStream{
+			requestInfo: &network.RequestInfo{},
+			route: &mockRoute{
+				rule: &mockNamedRouteRule{name: "test_detail_route"},
+			},
+		}
+		s.requestInfo.SetResponseCode(code)
+		if code == 503 {
+			s.requestInfo.SetResponseFlag(api.UpstreamRequestTimeout)
+		}
+		s.detailMetrics()
+	}
+	stats := getRouteStats("test_detail_route")
+	if stats.RequestTotal.Count() != 4 ||
+		stats.Response2xx.Count() != 2 ||
+		stats.Response4xx.Count() != 1 ||
+		stats.Response5xx.Count() != 1 ||
+		stats.RequestTimeout.Count() != 1 ||
+		stats.RequestReset.Count() != 0 {
+		t.Fatalf("unexpected route stats: %+v", stats)
+	}
+}
+
+func TestDetailMetricsCacheEviction(t *testing.T) {
+	metrics.ResetAll()
+	metrics.SetDetailedStats(true, true, 1)
+	defer metrics.SetDetailedStats(false, false, 0)
+
+	getRouteStats("test_cached_route").RequestTotal.Inc(1)
+	// the overflow nil metrics is not kept after the limit changed
+	if getRouteStats("test_overflow_route").RequestTotal.Inc(1); getRouteStats("test_overflow_route").RequestTotal.Count() != 0 {
+		t.Fatal("route stats beyond the limit should be nil metrics")
+	}
+	metrics.SetDetailedStats(true, true, 10)
+	if getRouteStats("test_overflow_route").RequestTotal.Inc(1); getRouteStats("test_overflow_route").RequestTotal.Count() != 1 {
+		t.Fatal("route stats should be created after the limit raised")
+	}
+	// the removed host stats is not kept
+	getHostDetailStats("test_cluster", "127.0.0.1:8080").RequestTotal.Inc(1)
+	metrics.RemoveHostDetailStats("test_cluster", "127.0.0.1:8080")
+	if c := getHostDetailStats("test_cluster", "127.0.0.1:8080").RequestTotal.Count(); c != 0 {
+		t.Fatalf("removed host stats should not be cached, count: %d", c)
+	}
+	// the stats is dropped after reset
+	metrics.ResetAll()
+	if c := getRouteStats("test_cached_route").RequestTotal.Count(); c != 0 {
+		t.Fatalf("reset route stats should not be cached, count: %d", c)
+	}
+}
+
+func TestRouteConcurrencyLimit(t *testing.T) {
+	limiter := &mockConcurrencyLimiter{max: 1, maxQueue: 2}
+	route := &mockRoute{
+		rule: &mockLimitedRouteRule{limiter: limiter},
+	}
+	first := &downStream{route: route}
+	if !first.acquireConcurrency() || first.concurrencyState != concurrencyAcquired {
+		t.Fatal("first stream should get the slot")
+	}
+	// the second stream is queued without blocking
+	second := &downStream{route: route}
+	if second.acquireConcurrency() || second.concurrencyState != concurrencyQueuing || len(limiter.waiters) != 1 {
+		t.Fatal("second stream should be queued")
+	}
+	// the slot is released when the stream is cleaned
+	first.cleanUp()
+	first.cleanUp()
+	if limiter.active != 0 {
+		t.Fatalf("unexpected active streams: %d", limiter.active)
+	}
+	// the slot is got before the stream goroutine is released, the stream goroutine continues the stream
+	limiter.active++
+	limiter.waiters[0](true)
+	if second.concurrencyState != concurrencyResumed {
+		t.Fatalf("unexpected second stream state: %d", second.concurrencyState)
+	}
+	second.cleanUp()
+	if limiter.active != 0 || second.concurrencyState != concurrencyDone {
+		t.Fatal("resumed stream should release the slot when it is cleaned")
+	}
+	// the queue wait is cancelled when the queued stream is cleaned
+	limiter.active++
+	third := &downStream{route: route}
+	if third.acquireConcurrency() {
+		t.Fatal("third stream should be queued")
+	}
+	third.concurrencyState = concurrencyQueued
+	third.cleanUp()
+	if limiter.cancelled != 1 || third.concurrencyState != concurrencyDone {
+		t.Fatal("queue wait should be cancelled when the stream is cleaned")
+	}
+	// the slot got after the stream is cleaned is released
+	limiter.waiters[1](true)
+	if limiter.active != 0 {
+		t.Fatalf("unexpected active streams: %d", limiter.active)
+	}
+	// no limit for the route without concurrency limit
+	if s := (&downStream{route: &mockRoute{}}); !s.acquireConcurrency() {
+		t.Fatal("route without limit should not be limited")
+	}
+}
+
+type countStreamFilterFactory struct {
+	created int32
+}
+
+func (f *countStreamFilterFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	atomic.AddInt32(&f.created, 1)
+}
+
+func TestRequestBlocklistReject(t *testing.T) {
+	initGlobalStats()
+	factory := &countStreamFilterFactory{}
+	ffs := &atomic.Value{}
+	ffs.Store([]api.StreamFilterChainFactory{factory})
+	bl, err := NewRequestBlocklist(&v2.RequestBlocklist{
+		Rules: []v2.BlocklistRule{{PathPrefix: "/.git"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &proxy{
+		config:         &v2.Proxy{},
+		context:        mosnctx.WithValue(context.Background(), types.ContextKeyStreamFilterChainFactories, ffs),
+		routersWrapper: nil,
+		clusterManager: &mockClusterManager{},
+		readCallbacks:  &mockReadFilterCallbacks{},
+		stats:          globalStats,
+		listenerStats:  newListenerStats("test"),
+		blocklist:      bl,
+	}
+	// the blocked request is rejected without the stream filters and the route lookup
+	client := &mockResponseSender{}
+	s := &downStream{proxy: proxy, responseSender: client, requestInfo: &network.RequestInfo{}}
+	s.OnReceive(context.Background(), protocol.CommonHeader{protocol.MosnHeaderPathKey: "/.git/config"}, nil, nil)
+	time.Sleep(100 * time.Millisecond)
+	if client.headers == nil {
+		t.Fatal("want to receive a header response")
+	}
+	if code, ok := client.headers.Get(types.HeaderStatus); !ok || code != "403" {
+		t.Errorf("unexpected response status: %s", code)
+	}
+	if atomic.LoadInt32(&factory.created) != 0 {
+		t.Errorf("stream filters should not be created for the rejected request")
+	}
+	// the other requests create the stream filters as usual, and get 404 for no route
+	client = &mockResponseSender{}
+	s = &downStream{proxy: proxy, responseSender: client, requestInfo: &network.RequestInfo{}}
+	s.OnReceive(context.Background(), protocol.CommonHeader{protocol.MosnHeaderPathKey: "/index.html"}, nil, nil)
+	time.Sleep(100 * time.Millisecond)
+	if created := atomic.LoadInt32(&factory.created); created != 1 {
+		t.Errorf("stream filters should be created, created: %d", created)
+	}
+	if client.headers == nil {
+		t.Fatal("want to receive a header response")
+	}
+	if code, _ := client.headers.Get(types.HeaderStatus); code == "403" {
+		t.Error("request should not be rejected")
+	}
+}
+
+func TestGetUpstreamProtocol(t *testing.T) {
+	p := &proxy{
+		config: &v2.Proxy{
+			DownstreamProtocol: string(protocol.HTTP1),
+			UpstreamProtocol:   string(protocol.HTTP2),
+		},
+	}
+	testCases := []struct {
+		cluster  types.ClusterInfo
+		route    types.Route
+		expected types.Protocol
+	}{
+		// proxy config
+		{nil, nil, protocol.HTTP2},
+		{&mockClusterInfo{}, &mockRoute{}, protocol.HTTP2},
+		// cluster overrides proxy
+		{&mockClusterInfo{protocol: protocol.SofaRPC}, &mockRoute{}, protocol.SofaRPC},
+		{&mockClusterInfo{protocol: protocol.Auto}, &mockRoute{}, protocol.HTTP1},
+		// route overrides cluster
+		{&mockClusterInfo{protocol: protocol.SofaRPC}, &mockRoute{rule: &mockRouteRule{protocol: string(protocol.HTTP1)}}, protocol.HTTP1},
+	}
+	for i, tc := range testCases {
+		s := &downStream{proxy: p, cluster: tc.cluster, route: tc.route}
+		if prot := s.getUpstreamProtocol(); prot != tc.expected {
+			t.Errorf("case %d: expected protocol %s, got %s", i, tc.expected, prot)
+		}
+	}
+}
+
+func TestResolveHTTPAutoProtocol(t *testing.T) {
+	p := &proxy{
+		config: &v2.Proxy{
+			DownstreamProtocol: string(protocol.HTTP1),
+			UpstreamProtocol:   string(protocol.HTTPAuto),
+		},
+	}
+	s := &downStream{proxy: p}
+	if prot := s.resolveUpstreamProtocol(&mockConnPool{protocol: protocol.HTTP2}); prot != protocol.HTTP2 {
+		t.Errorf("expected protocol of the pool, got %s", prot)
+	}
+	// the negotiated protocol is used to convert
+	s.upstreamRequest = &upstreamRequest{protocol: protocol.HTTP2}
+	if _, up := s.convertProtocol(); up != protocol.HTTP2 {
+		t.Errorf("expected upstream protocol http2, got %s", up)
+	}
+	p.config.UpstreamProtocol = string(protocol.SofaRPC)
+	if prot := s.resolveUpstreamProtocol(&mockConnPool{protocol: protocol.HTTP2}); prot != protocol.SofaRPC {
+		t.Errorf("expected configured protocol, got %s", prot)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// failoverNoHealthyUpstream is the failover code if the primary cluster is not found or has no healthy hosts,
+// the request is always failed over in this case
+const failoverNoHealthyUpstream = -1
+
+// failoverPolicy returns the failover policy of the route, nil if the route has no failover policy
+// or the request has been failed over
+func (s *downStream) failoverPolicy() types.FailoverPolicy {
+	if s.failedOver || s.route == nil {
+		return nil
+	}
+	rule, ok := s.route.RouteRule().(types.FailoverRouteRule)
+	if !ok {
+		return nil
+	}
+	return rule.FailoverPolicy()
+}
+
+// failover switches the cluster snapshot of the request to the failover cluster if the code should fail over.
+// The code is the upstream status code, zero means the upstream request is reset.
+// Returns true if the request is failed over.
+func (s *downStream) failover(code int) bool {
+	policy := s.failoverPolicy()
+	if policy == nil {
+		return false
+	}
+	if code != failoverNoHealthyUpstream && !policy.ShouldFailover(code) {
+		return false
+	}
+	snapshot, ok := s.proxy.clusterManager.GetClusterSnapshot(context.Background(), policy.ClusterName())
+	if !ok {
+		log.Proxy.Warnf(s.context, "[proxy] [downstream] failover cluster %s is not found", policy.ClusterName())
+		return false
+	}
+	if primary := s.snapshot; primary != nil {
+		if primary.ClusterInfo().Name() == policy.ClusterName() {
+			return false
+		}
+		primary.ClusterInfo().Stats().UpstreamRequestFailover.Inc(1)
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] fail over to cluster %s, code: %d", policy.ClusterName(), code)
+	}
+	s.failedOver = true
+	s.snapshot = snapshot
+	s.cluster = snapshot.ClusterInfo()
+	s.cluster.Stats().UpstreamRequestFailoverReceived.Inc(1)
+	s.requestInfo.SetResponseFlag(types.UpstreamFailover)
+	return true
+}
+
+// failoverUnavailableCluster fails over the request before it is sent to the upstream,
+// if the primary cluster of the route is not found or has no healthy hosts
+func (s *downStream) failoverUnavailableCluster() {
+	if s.failoverPolicy() == nil {
+		return
+	}
+	if s.snapshot != nil && len(s.snapshot.HostSet().HealthyHosts()) > 0 {
+		return
+	}
+	s.failover(failoverNoHealthyUpstream)
+}
+
+// failoverUpstream fails over the upstream request that can not be retried in the primary cluster,
+// the request is sent to the failover cluster in the retry phase with a new retry state
+func (s *downStream) failoverUpstream(code int, endStream bool) bool {
+	if s.upstreamRequest == nil || !s.failover(code) {
+		return false
+	}
+	if s.upstreamRequest.host != nil {
+		s.upstreamRequest.host.HostStats().UpstreamResponseFailed.Inc(1)
+		s.upstreamRequest.host.ClusterInfo().Stats().UpstreamResponseFailed.Inc(1)
+		s.recordUpstreamSLO(code)
+	}
+	s.retryState = newRetryState(s.route.RouteRule().Policy().RetryPolicy(), s.downstreamReqHeaders, s.cluster, s.upstreamRequest.protocol)
+	return s.setupRetry(endStream)
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	metrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/router"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockFailoverRouteRule struct {
+	mockRouteRule
+	base *router.RouteRuleImplBase
+}
+
+func (r *mockFailoverRouteRule) Policy() api.Policy {
+	return r.base.Policy()
+}
+
+func (r *mockFailoverRouteRule) FailoverPolicy() types.FailoverPolicy {
+	return r.base.FailoverPolicy()
+}
+
+type failoverClusterInfo struct {
+	types.ClusterInfo
+	name  string
+	stats types.ClusterStats
+}
+
+func (ci *failoverClusterInfo) Name() string {
+	return ci.name
+}
+
+func (ci *failoverClusterInfo) Stats() types.ClusterStats {
+	return ci.stats
+}
+
+func (ci *failoverClusterInfo) ResourceManager() types.ResourceManager {
+	return &fakeResourceManager{}
+}
+
+func newFailoverClusterInfo(name string) *failoverClusterInfo {
+	return &failoverClusterInfo{
+		name: name,
+		stats: types.ClusterStats{
+			UpstreamRequestFailover:         metrics.NewCounter(),
+			UpstreamRequestFailoverReceived: metrics.NewCounter(),
+		},
+	}
+}
+
+type failoverSnapshot struct {
+	types.ClusterSnapshot
+	info    *failoverClusterInfo
+	healthy []types.Host
+}
+
+func (s *failoverSnapshot) ClusterInfo() types.ClusterInfo {
+	return s.info
+}
+
+func (s *failoverSnapshot) HostSet() types.HostSet {
+	return s
+}
+
+func (s *failoverSnapshot) Hosts() []types.Host {
+	return s.healthy
+}
+
+func (s *failoverSnapshot) HealthyHosts() []types.Host {
+	return s.healthy
+}
+
+type failoverClusterManager struct {
+	types.ClusterManager
+	snapshots map[string]types.ClusterSnapshot
+}
+
+func (m *failoverClusterManager) GetClusterSnapshot(ctx context.Context, name string) (types.ClusterSnapshot, bool) {
+	snapshot, ok := m.snapshots[name]
+	return snapshot, ok
+}
+
+func newFailoverTestStream(t *testing.T, policy *v2.FailoverPolicy, primary, backup *failoverSnapshot) *downStream {
+	rcfg := &v2.Router{}
+	rcfg.Route.ClusterName = "primary"
+	rcfg.Route.FailoverPolicy = policy
+	base, err := router.NewRouteRuleImplBase(nil, rcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := &failoverClusterManager{
+		snapshots: map[string]types.ClusterSnapshot{},
+	}
+	if backup != nil {
+		cm.snapshots[backup.info.name] = backup
+	}
+	s := &downStream{
+		proxy: &proxy{
+			config:         &v2.Proxy{},
+			clusterManager: cm,
+		},
+		context:              context.Background(),
+		requestInfo:          network.NewRequestInfo(),
+		route:                &mockRoute{rule: &mockFailoverRouteRule{base: base}},
+		downstreamReqHeaders: protocol.CommonHeader{},
+	}
+	if primary != nil {
+		s.snapshot = primary
+		s.cluster = primary.info
+	}
+	return s
+}
+
+func TestFailoverUnavailableCluster(t *testing.T) {
+	policy := &v2.FailoverPolicy{ClusterName: "backup"}
+	backup := &failoverSnapshot{info: newFailoverClusterInfo("backup")}
+
+	// the primary cluster has healthy hosts
+	primary := &failoverSnapshot{info: newFailoverClusterInfo("primary"), healthy: []types.Host{nil}}
+	s := newFailoverTestStream(t, policy, primary, backup)
+	s.failoverUnavailableCluster()
+	if s.failedOver || s.snapshot != primary {
+		t.Fatal("the request should not be failed over")
+	}
+
+	// the primary cluster has no healthy hosts
+	primary = &failoverSnapshot{info: newFailoverClusterInfo("primary")}
+	s = newFailoverTestStream(t, policy, primary, backup)
+	s.failoverUnavailableCluster()
+	if !s.failedOver || s.snapshot != backup || s.cluster != backup.info {
+		t.Fatal("the request should be failed over to the backup cluster")
+	}
+	if !s.requestInfo.GetResponseFlag(types.UpstreamFailover) {
+		t.Error("the failover response flag is not set")
+	}
+	if primary.info.stats.UpstreamRequestFailover.Count() != 1 || backup.info.stats.UpstreamRequestFailoverReceived.Count() != 1 {
+		t.Error("the failover is not counted")
+	}
+	// a request fails over once
+	if s.failover(failoverNoHealthyUpstream) {
+		t.Fatal("the request should fail over once")
+	}
+
+	// the primary cluster is not found
+	s = newFailoverTestStream(t, policy, nil, backup)
+	s.failoverUnavailableCluster()
+	if !s.failedOver || s.snapshot != backup {
+		t.Fatal("the request should be failed over to the backup cluster")
+	}
+
+	// the failover cluster is not found
+	s = newFailoverTestStream(t, &v2.FailoverPolicy{ClusterName: "unknown"}, primary, backup)
+	s.failoverUnavailableCluster()
+	if s.failedOver || s.snapshot != primary {
+		t.Fatal("the request should not be failed over")
+	}
+}
+
+func TestFailoverUpstream(t *testing.T) {
+	policy := &v2.FailoverPolicy{
+		ClusterName:   "backup",
+		ResponseCodes: []int{503},
+		OnReset:       true,
+	}
+	for i, tc := range []struct {
+		code     int
+		expected bool
+	}{
+		{503, true},
+		{0, true},
+		{500, false},
+		{200, false},
+	} {
+		primary := &failoverSnapshot{info: newFailoverClusterInfo("primary"), healthy: []types.Host{nil}}
+		backup := &failoverSnapshot{info: newFailoverClusterInfo("backup")}
+		s := newFailoverTestStream(t, policy, primary, backup)
+		s.upstreamRequest = &upstreamRequest{protocol: protocol.HTTP1}
+		if s.failoverUpstream(tc.code, true) != tc.expected {
+			t.Fatalf("case %d expected failover %v", i, tc.expected)
+		}
+		if !tc.expected {
+			if s.upstreamRequest.setupRetry || s.retryState != nil {
+				t.Errorf("case %d the stream state should not be changed", i)
+			}
+			continue
+		}
+		// the request is retried in the failover cluster
+		if !s.upstreamRequest.setupRetry || s.retryState == nil || s.retryState.cluster != backup.info {
+			t.Errorf("case %d the request should be retried in the failover cluster", i)
+		}
+		if s.failoverUpstream(tc.code, true) {
+			t.Errorf("case %d the request should fail over once", i)
+		}
+	}
+
+	// no failover policy
+	primary := &failoverSnapshot{info: newFailoverClusterInfo("primary"), healthy: []types.Host{nil}}
+	s := newFailoverTestStream(t, nil, primary, nil)
+	s.upstreamRequest = &upstreamRequest{protocol: protocol.HTTP1}
+	if s.failoverUpstream(503, true) || s.failedOver {
+		t.Fatal("the request should not be failed over without a failover policy")
+	}
+}
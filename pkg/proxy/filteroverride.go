@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// setStreamFilterType sets the filter type of the filters added since the receivers and senders positions
+func (s *downStream) setStreamFilterType(filterType string, receivers, senders int) {
+	if filterType == "" {
+		return
+	}
+	for _, f := range s.receiverFilters[receivers:] {
+		f.filterType = filterType
+	}
+	for _, f := range s.senderFilters[senders:] {
+		f.filterType = filterType
+	}
+}
+
+// applyFilterOverrides applies the stream filter overrides of the matched route.
+// Only the overrides of the first matched route are applied, and only to the filters that are not run:
+// the receiver filters before the route are run already, so they are not overridden.
+func (s *downStream) applyFilterOverrides() {
+	if s.filterOverridden || s.route == nil {
+		return
+	}
+	s.filterOverridden = true
+	rule, ok := s.route.RouteRule().(types.StreamFilterOverridesRouteRule)
+	if !ok {
+		return
+	}
+	for filterType, factory := range rule.StreamFilterOverrides() {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(s.context, "[proxy] [downstream] stream filter %s is overridden by the route, disabled: %v", filterType, factory == nil)
+		}
+		s.overrideStreamFilters(filterType, factory)
+	}
+}
+
+// overrideStreamFilters removes the filters of the filter type, and puts the filters created by the factory
+// at the position of the first removed filter. The filters are only removed if the factory is nil.
+func (s *downStream) overrideStreamFilters(filterType string, factory api.StreamFilterChainFactory) {
+	receiverPos, senderPos := -1, -1
+
+	receivers := s.receiverFilters[:s.receiverFiltersIndex]
+	for _, f := range s.receiverFilters[s.receiverFiltersIndex:] {
+		if f.filterType != filterType || f.p != types.DownFilterAfterRoute {
+			receivers = append(receivers, f)
+			continue
+		}
+		if receiverPos < 0 {
+			receiverPos = len(receivers)
+		}
+		f.filter.OnDestroy()
+	}
+	senders := s.senderFilters[:s.senderFiltersIndex]
+	for _, f := range s.senderFilters[s.senderFiltersIndex:] {
+		if f.filterType != filterType {
+			senders = append(senders, f)
+			continue
+		}
+		if senderPos < 0 {
+			senderPos = len(senders)
+		}
+		f.filter.OnDestroy()
+	}
+	s.receiverFilters, s.senderFilters = receivers, senders
+
+	if factory == nil {
+		return
+	}
+
+	receiverCount, senderCount := len(receivers), len(senders)
+	factory.CreateFilterChain(s.proxy.context, s)
+	s.setStreamFilterType(filterType, receiverCount, senderCount)
+
+	// move the created filters to the position of the removed filters, they are appended if no filter is removed
+	if added := s.receiverFilters[receiverCount:]; receiverPos >= 0 && len(added) > 0 {
+		added = append([]*activeStreamReceiverFilter(nil), added...)
+		copy(s.receiverFilters[receiverPos+len(added):], s.receiverFilters[receiverPos:receiverCount])
+		copy(s.receiverFilters[receiverPos:], added)
+	}
+	if added := s.senderFilters[senderCount:]; senderPos >= 0 && len(added) > 0 {
+		added = append([]*activeStreamSenderFilter(nil), added...)
+		copy(s.senderFilters[senderPos+len(added):], s.senderFilters[senderPos:senderCount])
+		copy(s.senderFilters[senderPos:], added)
+	}
+}
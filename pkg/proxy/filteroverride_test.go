@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockOverridesRouteRule struct {
+	mockRouteRule
+	overrides map[string]api.StreamFilterChainFactory
+}
+
+func (r *mockOverridesRouteRule) StreamFilterOverrides() map[string]api.StreamFilterChainFactory {
+	return r.overrides
+}
+
+type mockOverrideFilterFactory struct {
+	receiver *mockStreamReceiverFilter
+	sender   *mockStreamSenderFilter
+}
+
+func (f *mockOverrideFilterFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+	callbacks.AddStreamReceiverFilter(f.receiver, api.AfterRoute)
+	callbacks.AddStreamSenderFilter(f.sender)
+}
+
+func TestApplyFilterOverrides(t *testing.T) {
+	s := &downStream{
+		proxy: &proxy{},
+	}
+	before := &mockStreamReceiverFilter{phase: api.BeforeRoute}
+	replaced := &mockStreamReceiverFilter{phase: api.AfterRoute}
+	disabled := &mockStreamReceiverFilter{phase: api.AfterRoute}
+	last := &mockStreamReceiverFilter{phase: api.AfterRoute}
+	replacedSender := &mockStreamSenderFilter{}
+	disabledSender := &mockStreamSenderFilter{}
+	lastSender := &mockStreamSenderFilter{}
+
+	s.AddStreamReceiverFilter(before, before.phase)
+	s.AddStreamReceiverFilter(replaced, replaced.phase)
+	s.AddStreamSenderFilter(replacedSender)
+	s.setStreamFilterType("ratelimit", 0, 0)
+	s.AddStreamReceiverFilter(disabled, disabled.phase)
+	s.AddStreamSenderFilter(disabledSender)
+	s.setStreamFilterType("auth", 2, 1)
+	s.AddStreamReceiverFilter(last, last.phase)
+	s.AddStreamSenderFilter(lastSender)
+
+	factory := &mockOverrideFilterFactory{
+		receiver: &mockStreamReceiverFilter{},
+		sender:   &mockStreamSenderFilter{},
+	}
+	rule := &mockOverridesRouteRule{
+		overrides: map[string]api.StreamFilterChainFactory{
+			"ratelimit": factory,
+			"auth":      nil,
+		},
+	}
+	s.route = &mockRoute{rule: rule}
+	s.applyFilterOverrides()
+
+	receivers := []api.StreamReceiverFilter{before, factory.receiver, last}
+	if len(s.receiverFilters) != len(receivers) {
+		t.Fatalf("expected %d receiver filters, got %d", len(receivers), len(s.receiverFilters))
+	}
+	for i, f := range receivers {
+		if s.receiverFilters[i].filter != f {
+			t.Errorf("#%d unexpected receiver filter %+v", i, s.receiverFilters[i].filter)
+		}
+	}
+	if s.receiverFilters[1].filterType != "ratelimit" || s.receiverFilters[1].p != types.DownFilterAfterRoute {
+		t.Errorf("unexpected replaced receiver filter: %+v", s.receiverFilters[1])
+	}
+	senders := []api.StreamSenderFilter{factory.sender, lastSender}
+	if len(s.senderFilters) != len(senders) {
+		t.Fatalf("expected %d sender filters, got %d", len(senders), len(s.senderFilters))
+	}
+	for i, f := range senders {
+		if s.senderFilters[i].filter != f {
+			t.Errorf("#%d unexpected sender filter %+v", i, s.senderFilters[i].filter)
+		}
+	}
+
+	// the overrides are applied once
+	rule.overrides = map[string]api.StreamFilterChainFactory{"ratelimit": nil}
+	s.applyFilterOverrides()
+	if len(s.receiverFilters) != 3 || len(s.senderFilters) != 2 {
+		t.Fatal("the overrides should be applied to the first matched route only")
+	}
+}
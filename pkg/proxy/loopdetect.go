@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strconv"
+	"strings"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultMaxHops = 10
+
+// loopDetection counts the hops of the requests and rejects the requests exceed the max hops
+type loopDetection struct {
+	header  string
+	maxHops uint64
+	code    int
+}
+
+func newLoopDetection(config *v2.LoopDetection) *loopDetection {
+	if config == nil {
+		return nil
+	}
+	l := &loopDetection{
+		header:  strings.ToLower(config.HopHeader),
+		maxHops: uint64(config.MaxHops),
+		code:    config.StatusCode,
+	}
+	if l.header == "" {
+		l.header = types.HeaderHops
+	}
+	if l.maxHops == 0 {
+		l.maxHops = defaultMaxHops
+	}
+	if l.code == 0 {
+		l.code = types.LoopDetectedCode
+	}
+	return l
+}
+
+// check returns false if the request has reached the max hops, otherwise the hop header is incremented.
+// an invalid hop header is treated as zero hops
+func (l *loopDetection) check(headers types.HeaderMap) bool {
+	if l == nil || headers == nil {
+		return true
+	}
+	var hops uint64
+	if v, ok := headers.Get(l.header); ok {
+		hops, _ = strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+	}
+	if hops >= l.maxHops {
+		return false
+	}
+	headers.Set(l.header, strconv.FormatUint(hops+1, 10))
+	return true
+}
+
+// rejectByLoopDetection sends a hijack reply if the request has passed too many proxies
+func (s *downStream) rejectByLoopDetection() bool {
+	if s.proxy.loopDetection.check(s.downstreamReqHeaders) {
+		return false
+	}
+	s.proxy.stats.DownstreamLoopDetected.Inc(1)
+	s.proxy.listenerStats.DownstreamLoopDetected.Inc(1)
+	s.requestInfo.SetResponseFlag(types.LoopDetected)
+	s.sendHijackReply(s.proxy.loopDetection.code, nil)
+	return true
+}
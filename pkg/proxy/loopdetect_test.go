@@ -0,0 +1,66 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestLoopDetection(t *testing.T) {
+	l := newLoopDetection(&v2.LoopDetection{MaxHops: 2})
+	if l.header != types.HeaderHops || l.code != types.LoopDetectedCode {
+		t.Fatalf("unexpected defaults: %+v", l)
+	}
+	testCases := []struct {
+		hops     string
+		pass     bool
+		expected string
+	}{
+		{"", true, "1"},
+		{"1", true, "2"},
+		{"2", false, "2"},
+		{"100", false, "100"},
+		{"invalid", true, "1"},
+	}
+	for i, tc := range testCases {
+		headers := protocol.CommonHeader{}
+		if tc.hops != "" {
+			headers.Set(types.HeaderHops, tc.hops)
+		}
+		if l.check(headers) != tc.pass {
+			t.Errorf("case %d: expected pass %t", i, tc.pass)
+		}
+		if v, _ := headers.Get(types.HeaderHops); v != tc.expected {
+			t.Errorf("case %d: expected hops %s, got %s", i, tc.expected, v)
+		}
+	}
+	// disabled
+	var disabled *loopDetection
+	if !disabled.check(protocol.CommonHeader{types.HeaderHops: "100"}) {
+		t.Error("expected pass if the loop detection is disabled")
+	}
+	l = newLoopDetection(&v2.LoopDetection{HopHeader: "X-Hops", StatusCode: 400})
+	headers := protocol.CommonHeader{"x-hops": "9"}
+	if !l.check(headers) || headers["x-hops"] != "10" || l.check(headers) || l.code != 400 {
+		t.Errorf("unexpected loop detection with custom header, headers: %v", headers)
+	}
+}
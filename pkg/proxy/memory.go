@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"mosn.io/mosn/pkg/memory"
+	"mosn.io/mosn/pkg/types"
+)
+
+var (
+	headerAccount = memory.GetAccount(memory.ModuleHeader)
+	bufferAccount = memory.GetAccount(memory.ModuleBuffer)
+)
+
+// streamMemory is the memory of the headers and data held by the stream, in bytes
+type streamMemory struct {
+	reqHeaders  int64
+	reqData     int64
+	respHeaders int64
+	respData    int64
+}
+
+// holdRequest accounts the request held by the stream
+func (m *streamMemory) holdRequest(headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+	if !memory.Enabled() {
+		return
+	}
+	hold(headerAccount, &m.reqHeaders, headersSize(headers, trailers))
+	hold(bufferAccount, &m.reqData, dataSize(data))
+}
+
+// holdResponse accounts the response held by the stream, the response of the previous attempt is released
+func (m *streamMemory) holdResponse(headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) {
+	if !memory.Enabled() {
+		return
+	}
+	hold(headerAccount, &m.respHeaders, headersSize(headers, trailers))
+	hold(bufferAccount, &m.respData, dataSize(data))
+}
+
+// release releases all the memory held by the stream
+func (m *streamMemory) release() {
+	hold(headerAccount, &m.reqHeaders, 0)
+	hold(bufferAccount, &m.reqData, 0)
+	hold(headerAccount, &m.respHeaders, 0)
+	hold(bufferAccount, &m.respData, 0)
+}
+
+// hold replaces the held object with a new one of size bytes, nothing is held if size is 0
+func hold(a *memory.Account, held *int64, size int64) {
+	if *held > 0 {
+		a.Free(*held)
+	}
+	if size > 0 {
+		a.Alloc(size)
+	}
+	*held = size
+}
+
+func headersSize(headers, trailers types.HeaderMap) int64 {
+	var size int64
+	if headers != nil {
+		size += int64(headers.ByteSize())
+	}
+	if trailers != nil {
+		size += int64(trailers.ByteSize())
+	}
+	return size
+}
+
+func dataSize(data types.IoBuffer) int64 {
+	if data == nil {
+		return 0
+	}
+	return int64(data.Cap())
+}
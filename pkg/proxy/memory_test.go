@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/memory"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/pkg/buffer"
+)
+
+func TestStreamMemory(t *testing.T) {
+	memory.Enable()
+	headerBytes, headerObjects := headerAccount.Bytes(), headerAccount.Objects()
+	bufferBytes, bufferObjects := bufferAccount.Bytes(), bufferAccount.Objects()
+	check := func(hb, ho, bb, bo int64) {
+		t.Helper()
+		if headerAccount.Bytes()-headerBytes != hb || headerAccount.Objects()-headerObjects != ho {
+			t.Errorf("unexpected header account, bytes: %d, objects: %d", headerAccount.Bytes()-headerBytes, headerAccount.Objects()-headerObjects)
+		}
+		if bufferAccount.Bytes()-bufferBytes != bb || bufferAccount.Objects()-bufferObjects != bo {
+			t.Errorf("unexpected buffer account, bytes: %d, objects: %d", bufferAccount.Bytes()-bufferBytes, bufferAccount.Objects()-bufferObjects)
+		}
+	}
+
+	m := &streamMemory{}
+	// request headers, trailers and data
+	reqData := buffer.NewIoBuffer(64)
+	reqSize := int64(reqData.Cap())
+	m.holdRequest(protocol.CommonHeader{"service": "test"}, reqData, protocol.CommonHeader{"k": "v"})
+	check(13, 1, reqSize, 1)
+	// the response of the retried attempt replaces the previous one
+	m.holdResponse(protocol.CommonHeader{"status": "503"}, nil, nil)
+	check(22, 2, reqSize, 1)
+	respData := buffer.NewIoBuffer(32)
+	m.holdResponse(protocol.CommonHeader{"status": "200"}, respData, nil)
+	check(22, 2, reqSize+int64(respData.Cap()), 2)
+	m.release()
+	check(0, 0, 0, 0)
+}
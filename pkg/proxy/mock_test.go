@@ -83,6 +83,7 @@ func (r *mockRoute) DirectResponseRule() api.DirectResponseRule {
 
 type mockRouteRule struct {
 	api.RouteRule
+	protocol string
 }
 
 func (r *mockRouteRule) ClusterName() string {
@@ -90,13 +91,66 @@ func (r *mockRouteRule) ClusterName() string {
 }
 
 func (r *mockRouteRule) UpstreamProtocol() string {
-	return ""
+	return r.protocol
+}
+
+type mockClusterInfo struct {
+	types.ClusterInfo
+	protocol types.Protocol
+}
+
+func (ci *mockClusterInfo) UpstreamProtocol() types.Protocol {
+	return ci.protocol
 }
 
 func (c *mockRouteRule) FinalizeResponseHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
 	return
 }
 
+type mockNamedRouteRule struct {
+	mockRouteRule
+	name string
+}
+
+func (r *mockNamedRouteRule) RouteName() string {
+	return r.name
+}
+
+type mockLimitedRouteRule struct {
+	mockRouteRule
+	limiter types.ConcurrencyLimiter
+}
+
+func (r *mockLimitedRouteRule) ConcurrencyLimiter() types.ConcurrencyLimiter {
+	return r.limiter
+}
+
+type mockConcurrencyLimiter struct {
+	max       int
+	active    int
+	maxQueue  int
+	waiters   []func(bool)
+	cancelled int
+}
+
+func (l *mockConcurrencyLimiter) Acquire(notify func(acquired bool)) (bool, func()) {
+	if l.active < l.max {
+		l.active++
+		return true, nil
+	}
+	if len(l.waiters) >= l.maxQueue {
+		return false, nil
+	}
+	l.waiters = append(l.waiters, notify)
+	return false, func() {
+		l.cancelled++
+	}
+}
+
+func (l *mockConcurrencyLimiter) Release() {
+	l.active--
+}
+
 type mockDirectRule struct {
 	status int
 	body   string
@@ -114,8 +168,8 @@ type mockClusterManager struct {
 	types.ClusterManager
 }
 
-func (m *mockClusterManager) GetClusterSnapshot(ctx context.Context, name string) types.ClusterSnapshot {
-	return &mockClusterSnapshot{}
+func (m *mockClusterManager) GetClusterSnapshot(ctx context.Context, name string) (types.ClusterSnapshot, bool) {
+	return &mockClusterSnapshot{}, true
 }
 func (m *mockClusterManager) PutClusterSnapshot(snapshot types.ClusterSnapshot) {
 }
@@ -231,3 +285,12 @@ func (s *mockSpan) InjectContext(requestHeaders types.HeaderMap) {
 func (s *mockSpan) SpawnChild(operationName string, startTime time.Time) types.Span {
 	return nil
 }
+
+type mockConnPool struct {
+	types.ConnectionPool
+	protocol types.Protocol
+}
+
+func (p *mockConnPool) Protocol() types.Protocol {
+	return p.protocol
+}
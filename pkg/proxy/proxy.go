@@ -26,9 +26,11 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	"mosn.io/api"
+	"mosn.io/mosn/pkg/baggage"
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/configmanager"
 	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/filter"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/protocol"
@@ -90,7 +92,14 @@ type proxy struct {
 	asMux              sync.RWMutex
 	stats              *Stats
 	listenerStats      *Stats
+	listenerName       string
 	accessLogs         []api.AccessLog
+	binding            *connectionBinding
+	blocklist          *RequestBlocklist
+	debugHeaders       *debugHeaders
+	loopDetection      *loopDetection
+	forwardClientCert  *forwardClientCert
+	baggage            *baggage.Propagator
 }
 
 // NewProxy create proxy instance for given v2.Proxy config
@@ -115,8 +124,40 @@ func NewProxy(ctx context.Context, config *v2.Proxy) Proxy {
 		log.DefaultLogger.Errorf("[proxy] get proxy extend config fail = %v", err)
 	}
 
-	listenerName := mosnctx.Get(ctx, types.ContextKeyListenerName).(string)
-	proxy.listenerStats = newListenerStats(listenerName)
+	if config.ConnectionBinding != nil {
+		proxy.binding = newConnectionBinding(config.ConnectionBinding)
+	}
+
+	// the blocklist is compiled by the network filter factory and shared by the proxies
+	if value := mosnctx.Get(ctx, types.ContextKeyRequestBlocklist); value != nil {
+		proxy.blocklist = value.(*RequestBlocklist)
+	} else if config.RequestBlocklist != nil {
+		if bl, err := NewRequestBlocklist(config.RequestBlocklist); err == nil {
+			proxy.blocklist = bl
+		} else {
+			log.DefaultLogger.Errorf("[proxy] invalid request blocklist: %v", err)
+		}
+	}
+
+	proxy.debugHeaders = newDebugHeaders(config.DebugHeaders)
+	proxy.loopDetection = newLoopDetection(config.LoopDetection)
+	proxy.baggage = baggage.NewPropagator(config.Baggage)
+
+	if fcc, err := newForwardClientCert(config.ForwardClientCert); err == nil {
+		proxy.forwardClientCert = fcc
+	} else {
+		// the untrusted client certificate header is never forwarded
+		log.DefaultLogger.Errorf("[proxy] invalid forward client cert config: %v, sanitize the header", err)
+		proxy.forwardClientCert = &forwardClientCert{mode: v2.ForwardClientCertSanitize}
+	}
+
+	// the push is done by the http2 server stream connection, which is created with the proxy context
+	if config.HTTP2Push != nil {
+		proxy.context = mosnctx.WithValue(proxy.context, types.ContextKeyHTTP2Push, config.HTTP2Push)
+	}
+
+	proxy.listenerName = mosnctx.Get(ctx, types.ContextKeyListenerName).(string)
+	proxy.listenerStats = newListenerStats(proxy.listenerName)
 
 	if routersWrapper := router.GetRoutersMangerInstance().GetRouterWrapperByName(proxy.config.RouterConfigName); routersWrapper != nil {
 		proxy.routersWrapper = routersWrapper
@@ -177,6 +218,11 @@ func (p *proxy) onDownstreamEvent(event api.ConnectionEvent) {
 			ds := urEle.Value.(*downStream)
 			ds.OnResetStream(types.StreamConnectionTermination)
 		}
+
+		// the dedicated upstream connections are closed with the downstream connection
+		if p.binding != nil {
+			p.binding.close()
+		}
 	}
 }
 
@@ -210,6 +256,20 @@ func (p *proxy) OnGoAway() {}
 func (p *proxy) NewStreamDetect(ctx context.Context, responseSender types.StreamSender, span types.Span) types.StreamReceiveListener {
 	stream := newActiveStream(ctx, p, responseSender, span)
 
+	// the stream filters are created when the request headers are received,
+	// so the requests rejected by the blocklist do not pay for them
+	p.asMux.Lock()
+	stream.element = p.activeSteams.PushBack(stream)
+	p.asMux.Unlock()
+
+	return stream
+}
+
+// createStreamFilters creates the stream filters of the listener for the stream
+func (p *proxy) createStreamFilters(stream *downStream) {
+	if p.context == nil {
+		return
+	}
 	if value := mosnctx.Get(p.context, types.ContextKeyStreamFilterChainFactories); value != nil {
 		ff := value.(*atomic.Value)
 		ffs, ok := ff.Load().([]api.StreamFilterChainFactory)
@@ -220,16 +280,12 @@ func (p *proxy) NewStreamDetect(ctx context.Context, responseSender types.Stream
 			}
 
 			for _, f := range ffs {
+				receivers, senders := len(stream.receiverFilters), len(stream.senderFilters)
 				f.CreateFilterChain(p.context, stream)
+				stream.setStreamFilterType(filter.StreamFilterType(f), receivers, senders)
 			}
 		}
 	}
-
-	p.asMux.Lock()
-	stream.element = p.activeSteams.PushBack(stream)
-	p.asMux.Unlock()
-
-	return stream
 }
 
 func (p *proxy) OnNewConnection() api.FilterStatus {
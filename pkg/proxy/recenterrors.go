@@ -0,0 +1,195 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	admin "mosn.io/mosn/pkg/admin/server"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	recentErrorsPath = "/api/v1/recent_errors"
+	// recentErrorsSize is the number of the recent request errors that kept for querying
+	recentErrorsSize = 1024
+)
+
+// requestErrorFlags are the response flags that mark a request failed,
+// the delay injected is not a failure
+const requestErrorFlags = ^api.DelayInjected
+
+// RequestError is a failed request, the request is failed if the response code is 5xx or a response flag is set
+type RequestError struct {
+	StartTime    time.Time `json:"start_time"`
+	FinishTime   time.Time `json:"finish_time"`
+	Listener     string    `json:"listener,omitempty"`
+	Route        string    `json:"route,omitempty"`
+	Cluster      string    `json:"cluster,omitempty"`
+	UpstreamHost string    `json:"upstream_host,omitempty"`
+	ResponseCode int       `json:"response_code"`
+	Flags        []string  `json:"flags,omitempty"`
+}
+
+// recentErrors is a ring of the recent request errors
+type recentErrors struct {
+	mutex  sync.RWMutex
+	errors []RequestError
+	next   int
+	full   bool
+}
+
+var defaultRecentErrors = newRecentErrors(recentErrorsSize)
+
+func init() {
+	admin.RegisterAdminHandleFunc(recentErrorsPath, serveRecentErrors)
+}
+
+func newRecentErrors(size int) *recentErrors {
+	return &recentErrors{
+		errors: make([]RequestError, size),
+	}
+}
+
+func (r *recentErrors) add(e RequestError) {
+	r.mutex.Lock()
+	r.errors[r.next] = e
+	r.next++
+	if r.next == len(r.errors) {
+		r.next = 0
+		r.full = true
+	}
+	r.mutex.Unlock()
+}
+
+// query returns the latest n errors that match the filter, the newest is the first
+func (r *recentErrors) query(filter func(e *RequestError) bool, n int) []RequestError {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	size := r.next
+	if r.full {
+		size = len(r.errors)
+	}
+	errors := []RequestError{}
+	for i := 1; i <= size && (n <= 0 || len(errors) < n); i++ {
+		e := &r.errors[(r.next-i+len(r.errors))%len(r.errors)]
+		if filter(e) {
+			errors = append(errors, *e)
+		}
+	}
+	return errors
+}
+
+// recordRequestError records the request into the recent errors if it is failed
+func (s *downStream) recordRequestError() {
+	info := s.requestInfo
+	if info.ResponseCode() < http.StatusInternalServerError && !info.GetResponseFlag(requestErrorFlags) {
+		return
+	}
+	e := RequestError{
+		StartTime:    info.StartTime(),
+		FinishTime:   info.StartTime().Add(info.RequestFinishedDuration()),
+		Listener:     s.proxy.listenerName,
+		ResponseCode: info.ResponseCode(),
+		Flags:        types.ResponseFlagNames(info),
+	}
+	if s.route != nil {
+		if rule, ok := s.route.RouteRule().(types.NamedRouteRule); ok {
+			e.Route = rule.RouteName()
+		}
+	}
+	if s.cluster != nil {
+		e.Cluster = s.cluster.Name()
+	}
+	if host := info.UpstreamHost(); host != nil {
+		e.UpstreamHost = host.AddressString()
+	}
+	defaultRecentErrors.add(e)
+}
+
+// serveRecentErrors returns the recent request errors, the newest is the first.
+// The errors can be filtered by the query "listener", "route", "cluster", "host", "flag", "code" and "since",
+// the "since" is a duration such as "5m", and the query "n" limits the number of the errors.
+func serveRecentErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "recent errors", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	filter, n, err := parseRecentErrorsQuery(r)
+	if err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: %v", "recent errors", err)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, err.Error())
+		return
+	}
+	buf, _ := json.Marshal(defaultRecentErrors.query(filter, n))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
+
+func parseRecentErrorsQuery(r *http.Request) (filter func(e *RequestError) bool, n int, err error) {
+	query := r.URL.Query()
+	if s := query.Get("n"); s != "" {
+		if n, err = strconv.Atoi(s); err != nil || n <= 0 {
+			return nil, 0, fmt.Errorf("invalid errors number: %s", s)
+		}
+	}
+	code := 0
+	if s := query.Get("code"); s != "" {
+		if code, err = strconv.Atoi(s); err != nil {
+			return nil, 0, fmt.Errorf("invalid response code: %s", s)
+		}
+	}
+	var since time.Time
+	if s := query.Get("since"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, 0, fmt.Errorf("invalid since duration: %s", s)
+		}
+		since = time.Now().Add(-d)
+	}
+	listener, route, cluster := query.Get("listener"), query.Get("route"), query.Get("cluster")
+	host, flag := query.Get("host"), query.Get("flag")
+	filter = func(e *RequestError) bool {
+		if (listener != "" && e.Listener != listener) ||
+			(route != "" && e.Route != route) ||
+			(cluster != "" && e.Cluster != cluster) ||
+			(host != "" && e.UpstreamHost != host) ||
+			(code != 0 && e.ResponseCode != code) ||
+			(!since.IsZero() && e.FinishTime.Before(since)) {
+			return false
+		}
+		if flag == "" {
+			return true
+		}
+		for _, f := range e.Flags {
+			if f == flag {
+				return true
+			}
+		}
+		return false
+	}
+	return filter, n, nil
+}
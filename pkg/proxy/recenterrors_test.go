@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestRecentErrorsRing(t *testing.T) {
+	r := newRecentErrors(3)
+	all := func(e *RequestError) bool { return true }
+	if errs := r.query(all, 0); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	for code := 500; code < 505; code++ {
+		r.add(RequestError{ResponseCode: code})
+	}
+	errs := r.query(all, 0)
+	if len(errs) != 3 || errs[0].ResponseCode != 504 || errs[2].ResponseCode != 502 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	errs = r.query(func(e *RequestError) bool { return e.ResponseCode != 503 }, 1)
+	if len(errs) != 1 || errs[0].ResponseCode != 504 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestRecordRequestError(t *testing.T) {
+	defaultRecentErrors = newRecentErrors(recentErrorsSize)
+	for _, tc := range []struct {
+		code     int
+		flag     api.ResponseFlag
+		recorded bool
+	}{
+		{200, 0, false},
+		{404, 0, false},
+		{200, api.DelayInjected, false},
+		{503, 0, true},
+		{0, types.DownstreamConnectionTermination, true},
+	} {
+		info := network.NewRequestInfo()
+		info.SetResponseCode(tc.code)
+		info.SetResponseFlag(tc.flag)
+		s := &downStream{
+			proxy:       &proxy{listenerName: "test_listener"},
+			requestInfo: info,
+			route: &mockRoute{
+				rule: &mockNamedRouteRule{name: "test_route"},
+			},
+		}
+		s.recordRequestError()
+		errs := defaultRecentErrors.query(func(e *RequestError) bool { return true }, 1)
+		recorded := len(errs) == 1 && errs[0].ResponseCode == tc.code
+		if recorded != tc.recorded {
+			t.Errorf("code %d, flag %#x: expected recorded %v", tc.code, int(tc.flag), tc.recorded)
+		}
+		if recorded && (errs[0].Listener != "test_listener" || errs[0].Route != "test_route") {
+			t.Errorf("unexpected error: %+v", errs[0])
+		}
+	}
+}
+
+func TestServeRecentErrors(t *testing.T) {
+	defaultRecentErrors = newRecentErrors(recentErrorsSize)
+	now := time.Now()
+	defaultRecentErrors.add(RequestError{FinishTime: now.Add(-time.Hour), Cluster: "a", ResponseCode: 503, Flags: []string{"UH"}})
+	defaultRecentErrors.add(RequestError{FinishTime: now, Cluster: "a", ResponseCode: 504, Flags: []string{"UT"}})
+	defaultRecentErrors.add(RequestError{FinishTime: now, Cluster: "b", UpstreamHost: "127.0.0.1:8080", ResponseCode: 502})
+	for _, tc := range []struct {
+		query    string
+		status   int
+		expected []int
+	}{
+		{"", http.StatusOK, []int{502, 504, 503}},
+		{"?n=1", http.StatusOK, []int{502}},
+		{"?cluster=a", http.StatusOK, []int{504, 503}},
+		{"?cluster=a&since=10m", http.StatusOK, []int{504}},
+		{"?flag=UH", http.StatusOK, []int{503}},
+		{"?host=127.0.0.1:8080", http.StatusOK, []int{502}},
+		{"?code=504", http.StatusOK, []int{504}},
+		{"?route=unknown", http.StatusOK, []int{}},
+		{"?n=0", http.StatusBadRequest, nil},
+		{"?since=yesterday", http.StatusBadRequest, nil},
+		{"?code=5xx", http.StatusBadRequest, nil},
+	} {
+		w := httptest.NewRecorder()
+		serveRecentErrors(w, httptest.NewRequest(http.MethodGet, recentErrorsPath+tc.query, nil))
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.query, tc.status, w.Code)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var errs []RequestError
+		if err := json.Unmarshal(w.Body.Bytes(), &errs); err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		if len(errs) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %+v", tc.query, tc.expected, errs)
+			continue
+		}
+		for i, code := range tc.expected {
+			if errs[i].ResponseCode != code {
+				t.Errorf("%s: expected %v, got %+v", tc.query, tc.expected, errs)
+			}
+		}
+	}
+	w := httptest.NewRecorder()
+	serveRecentErrors(w, httptest.NewRequest(http.MethodPost, recentErrorsPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
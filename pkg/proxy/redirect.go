@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+const headerLocation = "location"
+
+// internalRedirect follows the redirect response of the upstream if the route has an internal redirect policy.
+// The request headers are rewritten by the location, and the request is routed again instead of the
+// response being sent to the downstream. Returns true if the response is followed.
+func (s *downStream) internalRedirect() bool {
+	if s.oneway || s.route == nil || s.downstreamRespHeaders == nil || s.downstreamResponseStarted {
+		return false
+	}
+	rule, ok := s.route.RouteRule().(types.InternalRedirectRouteRule)
+	if !ok {
+		return false
+	}
+	policy := rule.InternalRedirectPolicy()
+	if policy == nil {
+		return false
+	}
+	code := s.requestInfo.ResponseCode()
+	location, _ := s.downstreamRespHeaders.Get(headerLocation)
+	if !policy.ShouldRedirect(code, location) {
+		return false
+	}
+	if s.internalRedirects >= policy.MaxInternalRedirects() {
+		if log.Proxy.GetLogLevel() >= log.DEBUG {
+			log.Proxy.Debugf(s.context, "[proxy] [downstream] internal redirects exceed the limit %d, location: %s", policy.MaxInternalRedirects(), location)
+		}
+		return false
+	}
+	if !s.rewriteRequest(code, location, policy.AllowCrossSchemeRedirect()) {
+		return false
+	}
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] internal redirect to %s, status code: %d", location, code)
+	}
+	s.internalRedirects++
+	s.proxy.stats.DownstreamInternalRedirect.Inc(1)
+	s.proxy.listenerStats.DownstreamInternalRedirect.Inc(1)
+	s.resetForRedirect()
+	return true
+}
+
+// rewriteRequest rewrites the request headers by the location, returns false if the location can not be followed
+func (s *downStream) rewriteRequest(code int, location string, allowCrossScheme bool) bool {
+	target, err := url.Parse(location)
+	if err != nil {
+		log.Proxy.Warnf(s.context, "[proxy] [downstream] invalid internal redirect location %s: %v", location, err)
+		return false
+	}
+	headers := s.downstreamReqHeaders
+	scheme := s.downstreamScheme()
+	if target.IsAbs() {
+		if target.Scheme != "http" && target.Scheme != "https" {
+			return false
+		}
+		if target.Scheme != scheme && !allowCrossScheme {
+			return false
+		}
+	}
+	host, _ := headers.Get(protocol.MosnHeaderHostKey)
+	path, _ := headers.Get(protocol.MosnHeaderPathKey)
+	base := &url.URL{Scheme: scheme, Host: host, Path: path}
+	resolved := base.ResolveReference(target)
+
+	headers.Set(protocol.MosnHeaderPathKey, resolved.Path)
+	if resolved.RawQuery != "" {
+		headers.Set(protocol.MosnHeaderQueryStringKey, resolved.RawQuery)
+	} else {
+		headers.Del(protocol.MosnHeaderQueryStringKey)
+	}
+	if resolved.Host != "" && resolved.Host != host {
+		headers.Set(protocol.MosnHeaderHostKey, resolved.Host)
+		if _, ok := headers.Get(protocol.IstioHeaderHostKey); ok {
+			headers.Set(protocol.IstioHeaderHostKey, resolved.Host)
+		}
+	}
+	// the redirected request of 303 is a GET request without body
+	if code == http.StatusSeeOther {
+		if method, _ := headers.Get(protocol.MosnHeaderMethod); method != http.MethodHead {
+			headers.Set(protocol.MosnHeaderMethod, http.MethodGet)
+		}
+		headers.Del("Content-Length")
+		s.downstreamReqDataBuf = nil
+		s.downstreamReqTrailers = nil
+	}
+	return true
+}
+
+// resetForRedirect cleans the state of the last upstream request, so the request can be routed again
+func (s *downStream) resetForRedirect() {
+	s.cleanUp()
+	// the timers of the last upstream request may still reference the stream
+	atomic.StoreUint32(&s.reuseBuffer, 0)
+	if s.upstreamRequest != nil && s.upstreamRequest.requestSender != nil {
+		s.upstreamRequest.requestSender.GetStream().RemoveEventListener(s.upstreamRequest)
+	}
+	proxyBuffersByContext(s.context).request = upstreamRequest{}
+	s.upstreamRequest = nil
+	s.upstreamRequestSent = false
+	s.failedOver = false
+	s.downstreamRecvDone = false
+	s.downstreamRespHeaders = nil
+	s.downstreamRespDataBuf = nil
+	s.downstreamRespTrailers = nil
+}
+
+// downstreamScheme returns https if the downstream connection is a TLS connection
+func (s *downStream) downstreamScheme() string {
+	if s.proxy.readCallbacks != nil {
+		if _, ok := s.DownstreamConnection().(*mtls.TLSConn); ok {
+			return "https"
+		}
+	}
+	return "http"
+}
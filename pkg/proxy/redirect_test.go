@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type mockRedirectRouteRule struct {
+	mockRouteRule
+	policy types.InternalRedirectPolicy
+}
+
+func (r *mockRedirectRouteRule) InternalRedirectPolicy() types.InternalRedirectPolicy {
+	return r.policy
+}
+
+type mockRedirectPolicy struct {
+	max         uint32
+	crossScheme bool
+}
+
+func (p *mockRedirectPolicy) MaxInternalRedirects() uint32 {
+	return p.max
+}
+
+func (p *mockRedirectPolicy) ShouldRedirect(code int, location string) bool {
+	return code >= 301 && code <= 308 && location != ""
+}
+
+func (p *mockRedirectPolicy) AllowCrossSchemeRedirect() bool {
+	return p.crossScheme
+}
+
+func newRedirectTestStream(policy types.InternalRedirectPolicy, code int, location string) *downStream {
+	initGlobalStats()
+	s := &downStream{
+		proxy: &proxy{
+			config:        &v2.Proxy{},
+			stats:         globalStats,
+			listenerStats: newListenerStats("test"),
+		},
+		context:     context.Background(),
+		requestInfo: network.NewRequestInfo(),
+		route: &mockRoute{
+			rule: &mockRedirectRouteRule{policy: policy},
+		},
+		downstreamReqHeaders: protocol.CommonHeader{
+			protocol.MosnHeaderHostKey:        "www.example.com",
+			protocol.MosnHeaderPathKey:        "/api/v1/users",
+			protocol.MosnHeaderQueryStringKey: "id=1",
+			protocol.MosnHeaderMethod:         "POST",
+			"Content-Length":                  "4",
+		},
+		downstreamReqDataBuf: buffer.NewIoBufferString("body"),
+		upstreamRequest:      &upstreamRequest{},
+		upstreamRequestSent:  true,
+	}
+	s.setRedirectResponse(code, location)
+	return s
+}
+
+func (s *downStream) setRedirectResponse(code int, location string) {
+	s.requestInfo.SetResponseCode(code)
+	s.downstreamRespHeaders = protocol.CommonHeader{"location": location}
+	s.downstreamRespDataBuf = buffer.NewIoBufferString("redirect")
+}
+
+func TestInternalRedirect(t *testing.T) {
+	s := newRedirectTestStream(&mockRedirectPolicy{max: 2}, 302, "../v2/users?id=2")
+	redirects := s.proxy.listenerStats.DownstreamInternalRedirect.Count()
+	if !s.internalRedirect() {
+		t.Fatal("expected the redirect followed")
+	}
+	headers := s.downstreamReqHeaders
+	if path, _ := headers.Get(protocol.MosnHeaderPathKey); path != "/api/v2/users" {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if query, _ := headers.Get(protocol.MosnHeaderQueryStringKey); query != "id=2" {
+		t.Errorf("unexpected query string: %s", query)
+	}
+	if host, _ := headers.Get(protocol.MosnHeaderHostKey); host != "www.example.com" {
+		t.Errorf("unexpected host: %s", host)
+	}
+	// the request body is kept, and the last response is dropped
+	if s.downstreamReqDataBuf == nil || s.downstreamRespHeaders != nil || s.downstreamRespDataBuf != nil {
+		t.Fatal("unexpected stream buffers after redirect")
+	}
+	if s.upstreamRequest != nil || s.upstreamRequestSent || s.internalRedirects != 1 {
+		t.Fatal("unexpected stream state after redirect")
+	}
+	if s.proxy.listenerStats.DownstreamInternalRedirect.Count() != redirects+1 {
+		t.Error("internal redirect is not counted")
+	}
+
+	// absolute location to another host, the query string is removed
+	s.setRedirectResponse(307, "http://backup.example.com/users")
+	if !s.internalRedirect() {
+		t.Fatal("expected the redirect followed")
+	}
+	if host, _ := headers.Get(protocol.MosnHeaderHostKey); host != "backup.example.com" {
+		t.Errorf("unexpected host: %s", host)
+	}
+	if _, ok := headers.Get(protocol.MosnHeaderQueryStringKey); ok {
+		t.Error("query string should be removed")
+	}
+
+	// exceeds the max redirects
+	s.setRedirectResponse(302, "/again")
+	if s.internalRedirect() {
+		t.Fatal("the redirect should not be followed after the max redirects")
+	}
+}
+
+func TestInternalRedirectSeeOther(t *testing.T) {
+	s := newRedirectTestStream(&mockRedirectPolicy{max: 1}, 303, "/result")
+	if !s.internalRedirect() {
+		t.Fatal("expected the redirect followed")
+	}
+	headers := s.downstreamReqHeaders
+	if method, _ := headers.Get(protocol.MosnHeaderMethod); method != "GET" {
+		t.Errorf("unexpected method: %s", method)
+	}
+	if _, ok := headers.Get("Content-Length"); ok || s.downstreamReqDataBuf != nil {
+		t.Error("the request body should be removed")
+	}
+}
+
+func TestInternalRedirectNotFollowed(t *testing.T) {
+	for i, tc := range []struct {
+		policy   types.InternalRedirectPolicy
+		code     int
+		location string
+	}{
+		// no policy
+		{nil, 302, "/new"},
+		// not a redirect
+		{&mockRedirectPolicy{max: 1}, 200, "/new"},
+		{&mockRedirectPolicy{max: 1}, 302, ""},
+		// cross scheme
+		{&mockRedirectPolicy{max: 1}, 302, "https://www.example.com/new"},
+		{&mockRedirectPolicy{max: 1, crossScheme: true}, 302, "ftp://www.example.com/new"},
+	} {
+		s := newRedirectTestStream(tc.policy, tc.code, tc.location)
+		if s.internalRedirect() {
+			t.Errorf("case %d should not be followed", i)
+		}
+		if s.downstreamRespHeaders == nil || s.upstreamRequest == nil {
+			t.Errorf("case %d the stream state should not be changed", i)
+		}
+	}
+	s := newRedirectTestStream(&mockRedirectPolicy{max: 1, crossScheme: true}, 302, "https://www.example.com/new")
+	if !s.internalRedirect() {
+		t.Fatal("expected the cross scheme redirect followed")
+	}
+}
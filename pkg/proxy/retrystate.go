@@ -18,6 +18,11 @@
 package proxy
 
 import (
+	nethttp "net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/protocol/http"
@@ -31,8 +36,17 @@ type retryState struct {
 	retryOn          bool
 	retiesRemaining  uint32
 	upstreamProtocol types.Protocol
+	// retryAfter is the Retry-After of the last response, the retry waits for it
+	retryAfter time.Duration
+	// retryAfterMax is the max Retry-After the retry waits for
+	retryAfterMax time.Duration
+	// limitExceeded is true if the last response should be retried, but no retry is remaining
+	limitExceeded bool
 }
 
+// defaultRetryAfterMaxInterval is the max Retry-After if the retry policy does not set it
+const defaultRetryAfterMaxInterval = time.Second
+
 func newRetryState(retryPolicy api.RetryPolicy,
 	requestHeaders api.HeaderMap, cluster types.ClusterInfo, proto api.Protocol) *retryState {
 	rs := &retryState{
@@ -42,6 +56,11 @@ func newRetryState(retryPolicy api.RetryPolicy,
 		retryOn:          retryPolicy.RetryOn(),
 		retiesRemaining:  3,
 		upstreamProtocol: proto,
+		retryAfterMax:    defaultRetryAfterMaxInterval,
+	}
+
+	if p, ok := retryPolicy.(types.RetryAfterPolicy); ok && p.RetryAfterMaxInterval() > 0 {
+		rs.retryAfterMax = p.RetryAfterMaxInterval()
 	}
 
 	if retryPolicy.NumRetries() > rs.retiesRemaining {
@@ -68,6 +87,7 @@ func (r *retryState) retry(headers api.HeaderMap, reason types.StreamResetReason
 
 func (r *retryState) shouldRetry(headers api.HeaderMap, reason types.StreamResetReason) api.RetryCheckStatus {
 	if r.retiesRemaining == 0 {
+		r.limitExceeded = r.retryOn && r.doRetryCheck(headers, reason)
 		return api.NoRetry
 	}
 
@@ -87,6 +107,8 @@ func (r *retryState) shouldRetry(headers api.HeaderMap, reason types.StreamReset
 }
 
 func (r *retryState) doRetryCheck(headers types.HeaderMap, reason types.StreamResetReason) bool {
+	r.retryAfter = 0
+
 	if reason == types.StreamOverflow {
 		return false
 	}
@@ -97,6 +119,14 @@ func (r *retryState) doRetryCheck(headers types.HeaderMap, reason types.StreamRe
 			// default policy , mapping all headers to http status code
 			code, err := protocol.MappingHeaderStatusCode(r.upstreamProtocol, headers)
 			if err == nil {
+				if d, ok := retryAfterOf(code, headers); ok {
+					// the upstream asks to wait longer than we can afford, just returns the response
+					if d > r.retryAfterMax {
+						return false
+					}
+					r.retryAfter = d
+					return true
+				}
 				// todo: support config?
 				return code >= http.InternalServerError
 			}
@@ -126,3 +156,35 @@ func (r *retryState) doRetryCheck(headers types.HeaderMap, reason types.StreamRe
 func (r *retryState) reset() {
 	r.cluster.ResourceManager().Retries().Decrease()
 }
+
+// retryAfterOf returns the Retry-After of the 503 and 429 responses
+func retryAfterOf(code int, headers types.HeaderMap) (time.Duration, bool) {
+	if code != http.ServiceUnavailable && code != http.TooManyRequests {
+		return 0, false
+	}
+	v, ok := headers.Get(types.HeaderRetryAfter)
+	if !ok {
+		return 0, false
+	}
+	return parseRetryAfter(v)
+}
+
+// parseRetryAfter parses the Retry-After value, which is either the delay seconds or a http date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(v, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := nethttp.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
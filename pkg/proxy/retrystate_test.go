@@ -18,6 +18,7 @@
 package proxy
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
@@ -132,3 +133,105 @@ func TestRetryConnetionFailed(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRetryAfter(t *testing.T) {
+	testcases := []struct {
+		Value    string
+		Expected time.Duration
+		Ok       bool
+	}{
+		{"3", 3 * time.Second, true},
+		{" 0 ", 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+	}
+	for i, tc := range testcases {
+		d, ok := parseRetryAfter(tc.Value)
+		if d != tc.Expected || ok != tc.Ok {
+			t.Errorf("#%d parse retry after %q failed, got %v %v", i, tc.Value, d, ok)
+		}
+	}
+	d, ok := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if !ok || d <= 50*time.Minute || d > time.Hour {
+		t.Errorf("parse retry after http date failed, got %v %v", d, ok)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	rcfg := &v2.Router{}
+	pcfg := &v2.RetryPolicy{
+		RetryPolicyConfig: v2.RetryPolicyConfig{
+			RetryOn:    true,
+			NumRetries: 10,
+		},
+		RetryTimeout:          time.Second,
+		RetryAfterMaxInterval: 2 * time.Second,
+	}
+	rcfg.Route = v2.RouteAction{}
+	rcfg.Route.RetryPolicy = pcfg
+	r, _ := router.NewRouteRuleImplBase(nil, rcfg)
+	policy := r.Policy().RetryPolicy()
+	clusterInfo := &fakeClusterInfo{
+		mgr: &fakeResourceManager{},
+	}
+	rs := newRetryState(policy, nil, clusterInfo, protocol.HTTP1)
+	testcases := []struct {
+		Header     types.HeaderMap
+		Expected   api.RetryCheckStatus
+		RetryAfter time.Duration
+	}{
+		{protocol.CommonHeader{types.HeaderStatus: "503", types.HeaderRetryAfter: "1"}, api.ShouldRetry, time.Second},
+		{protocol.CommonHeader{types.HeaderStatus: "429", types.HeaderRetryAfter: "2"}, api.ShouldRetry, 2 * time.Second},
+		// longer than the max interval
+		{protocol.CommonHeader{types.HeaderStatus: "503", types.HeaderRetryAfter: "3"}, api.NoRetry, 0},
+		// 429 without Retry-After is not retried
+		{protocol.CommonHeader{types.HeaderStatus: "429"}, api.NoRetry, 0},
+		{protocol.CommonHeader{types.HeaderStatus: "503"}, api.ShouldRetry, 0},
+		// Retry-After is ignored for other codes
+		{protocol.CommonHeader{types.HeaderStatus: "500", types.HeaderRetryAfter: "1"}, api.ShouldRetry, 0},
+	}
+	for i, tc := range testcases {
+		if rs.retry(tc.Header, "") != tc.Expected || rs.retryAfter != tc.RetryAfter {
+			t.Errorf("#%d retry after failed, retry after %v", i, rs.retryAfter)
+		}
+	}
+	// default max interval
+	rcfg.Route.RetryPolicy = &v2.RetryPolicy{}
+	r, _ = router.NewRouteRuleImplBase(nil, rcfg)
+	rs = newRetryState(r.Policy().RetryPolicy(), nil, clusterInfo, protocol.HTTP1)
+	if rs.retryAfterMax != defaultRetryAfterMaxInterval {
+		t.Errorf("expected default max interval, but got %v", rs.retryAfterMax)
+	}
+}
+
+func TestRetryLimitExceeded(t *testing.T) {
+	rcfg := &v2.Router{}
+	rcfg.Route = v2.RouteAction{}
+	rcfg.Route.RetryPolicy = &v2.RetryPolicy{
+		RetryPolicyConfig: v2.RetryPolicyConfig{
+			RetryOn: true,
+		},
+		RetryTimeout: time.Second,
+	}
+	r, _ := router.NewRouteRuleImplBase(nil, rcfg)
+	clusterInfo := &fakeClusterInfo{
+		mgr: &fakeResourceManager{},
+	}
+	rs := newRetryState(r.Policy().RetryPolicy(), nil, clusterInfo, protocol.HTTP1)
+	headerException := protocol.CommonHeader{
+		types.HeaderStatus: "500",
+	}
+	for i := 0; i < 3; i++ {
+		if rs.retry(headerException, "") != api.ShouldRetry || rs.limitExceeded {
+			t.Fatalf("#%d should be retried", i)
+		}
+	}
+	if rs.retry(protocol.CommonHeader{types.HeaderStatus: "200"}, "") != api.NoRetry || rs.limitExceeded {
+		t.Fatal("the response not to be retried should not exceed the retry limit")
+	}
+	if rs.retry(headerException, "") != api.NoRetry || !rs.limitExceeded {
+		t.Fatal("the retry limit should be exceeded")
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+const headerContentType = "content-type"
+
+// serveStaticFiles sends the file requested if the route serves the static files
+func (s *downStream) serveStaticFiles() bool {
+	rule, ok := s.route.RouteRule().(types.StaticFilesRouteRule)
+	if !ok {
+		return false
+	}
+	files := rule.StaticFiles()
+	if files == nil {
+		return false
+	}
+	method, _ := s.downstreamReqHeaders.Get(protocol.MosnHeaderMethod)
+	path, _ := s.downstreamReqHeaders.Get(protocol.MosnHeaderPathKey)
+	code, contentType, content := files.Serve(method, path)
+	if log.Proxy.GetLogLevel() >= log.DEBUG {
+		log.Proxy.Debugf(s.context, "[proxy] [downstream] serve static file %s, code = %d, proxyId = %d", path, code, s.ID)
+	}
+	if content != nil {
+		s.sendHijackReplyWithBody(code, nil, string(content))
+	} else {
+		s.sendHijackReply(code, nil)
+	}
+	if contentType != "" {
+		s.downstreamRespHeaders.Set(headerContentType, contentType)
+	}
+	return true
+}
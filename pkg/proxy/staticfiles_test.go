@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockStaticFilesRouteRule struct {
+	mockRouteRule
+	files types.StaticFiles
+}
+
+func (r *mockStaticFilesRouteRule) StaticFiles() types.StaticFiles {
+	return r.files
+}
+
+type mockStaticFiles struct{}
+
+func (f *mockStaticFiles) Serve(method, path string) (int, string, []byte) {
+	if path != "/robots.txt" {
+		return 404, "", nil
+	}
+	return 200, "text/plain", []byte("User-agent: *")
+}
+
+func TestServeStaticFiles(t *testing.T) {
+	newStream := func(rule *mockStaticFilesRouteRule, path string) *downStream {
+		return &downStream{
+			context:     context.Background(),
+			requestInfo: network.NewRequestInfo(),
+			route:       &mockRoute{rule: rule},
+			downstreamReqHeaders: protocol.CommonHeader{
+				protocol.MosnHeaderMethod:  "GET",
+				protocol.MosnHeaderPathKey: path,
+			},
+		}
+	}
+	s := newStream(&mockStaticFilesRouteRule{files: &mockStaticFiles{}}, "/robots.txt")
+	if !s.serveStaticFiles() || !s.directResponse {
+		t.Fatal("expected static file served")
+	}
+	if code, _ := s.downstreamRespHeaders.Get(types.HeaderStatus); code != "200" {
+		t.Errorf("unexpected status: %s", code)
+	}
+	if ct, _ := s.downstreamRespHeaders.Get(headerContentType); ct != "text/plain" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	if s.downstreamRespDataBuf == nil || s.downstreamRespDataBuf.String() != "User-agent: *" {
+		t.Error("unexpected body")
+	}
+	// the request headers are not sent back
+	if _, ok := s.downstreamRespHeaders.Get(protocol.MosnHeaderPathKey); ok {
+		t.Error("unexpected request headers in the response")
+	}
+
+	s = newStream(&mockStaticFilesRouteRule{files: &mockStaticFiles{}}, "/missing")
+	if !s.serveStaticFiles() || s.downstreamRespDataBuf != nil {
+		t.Fatal("expected not found without body")
+	}
+	if code, _ := s.downstreamRespHeaders.Get(types.HeaderStatus); code != "404" {
+		t.Errorf("unexpected status: %s", code)
+	}
+
+	if s = newStream(&mockStaticFilesRouteRule{}, "/robots.txt"); s.serveStaticFiles() {
+		t.Error("expected no static files served")
+	}
+}
@@ -18,7 +18,11 @@
 package proxy
 
 import (
+	"sync"
+	"sync/atomic"
+
 	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/types"
 )
@@ -37,6 +41,15 @@ type Stats struct {
 	DownstreamProcessTime       gometrics.Histogram
 	DownstreamProcessTimeTotal  gometrics.Counter
 	DownstreamRequestFailed     gometrics.Counter
+	DownstreamRequestRejected   gometrics.Counter
+	DownstreamLoopDetected      gometrics.Counter
+	DownstreamInternalRedirect  gometrics.Counter
+	DownstreamRequestBytes      gometrics.Histogram
+	DownstreamResponseBytes     gometrics.Histogram
+	UpstreamConnectTime         gometrics.Histogram
+
+	// the metrics that the response flag counters are created in
+	metrics types.Metrics
 }
 
 func newListenerStats(listenerName string) *Stats {
@@ -63,5 +76,99 @@ func newStats(s types.Metrics) *Stats {
 		DownstreamProcessTime:       s.Histogram(metrics.DownstreamProcessTime),
 		DownstreamProcessTimeTotal:  s.Counter(metrics.DownstreamProcessTimeTotal),
 		DownstreamRequestFailed:     s.Counter(metrics.DownstreamRequestFailed),
+		DownstreamRequestRejected:   s.Counter(metrics.DownstreamRequestRejected),
+		DownstreamLoopDetected:      s.Counter(metrics.DownstreamRequestLoopDetected),
+		DownstreamInternalRedirect:  s.Counter(metrics.DownstreamRequestInternalRedirect),
+		DownstreamRequestBytes:      s.Histogram(metrics.DownstreamRequestBytes),
+		DownstreamResponseBytes:     s.Histogram(metrics.DownstreamResponseBytes),
+		UpstreamConnectTime:         s.Histogram(metrics.DownstreamUpstreamConnectTime),
+		metrics:                     s,
+	}
+}
+
+// responseFlag returns the counter of the requests with the response flag
+func (s *Stats) responseFlag(name string) gometrics.Counter {
+	return s.metrics.Counter(metrics.DownstreamResponseFlagPrefix + name)
+}
+
+// detailStats is the per-route or per-upstream-host detailed stats
+type detailStats struct {
+	RequestTotal   gometrics.Counter
+	Response2xx    gometrics.Counter
+	Response4xx    gometrics.Counter
+	Response5xx    gometrics.Counter
+	RequestTimeout gometrics.Counter
+	RequestReset   gometrics.Counter
+}
+
+// detailStatsCache caches the detailed stats, avoid building the metrics name for each request.
+// The cache is dropped when the generation of the detailed stats changed, so the removed stats
+// and the nil metrics returned by the cardinality guard are not kept.
+type detailStatsCache struct {
+	generation uint64
+	routes     sync.Map
+	hosts      sync.Map
+}
+
+var detailCache atomic.Value // *detailStatsCache
+
+func getDetailStatsCache() *detailStatsCache {
+	gen := metrics.DetailStatsGeneration()
+	if c, ok := detailCache.Load().(*detailStatsCache); ok && c.generation == gen {
+		return c
+	}
+	c := &detailStatsCache{generation: gen}
+	detailCache.Store(c)
+	return c
+}
+
+func getRouteStats(routeName string) *detailStats {
+	cache := getDetailStatsCache()
+	if v, ok := cache.routes.Load(routeName); ok {
+		return v.(*detailStats)
+	}
+	v, _ := cache.routes.LoadOrStore(routeName, newDetailStats(metrics.NewRouteStats(routeName)))
+	return v.(*detailStats)
+}
+
+func getHostDetailStats(clusterName, addr string) *detailStats {
+	cache := getDetailStatsCache()
+	key := clusterName + "@" + addr
+	if v, ok := cache.hosts.Load(key); ok {
+		return v.(*detailStats)
+	}
+	v, _ := cache.hosts.LoadOrStore(key, newDetailStats(metrics.NewHostDetailStats(clusterName, addr)))
+	return v.(*detailStats)
+}
+
+func newDetailStats(s types.Metrics) *detailStats {
+	return &detailStats{
+		RequestTotal:   s.Counter(metrics.DetailRequestTotal),
+		Response2xx:    s.Counter(metrics.DetailResponse2xx),
+		Response4xx:    s.Counter(metrics.DetailResponse4xx),
+		Response5xx:    s.Counter(metrics.DetailResponse5xx),
+		RequestTimeout: s.Counter(metrics.DetailRequestTimeout),
+		RequestReset:   s.Counter(metrics.DetailRequestReset),
+	}
+}
+
+const requestResetFlags = api.UpstreamLocalReset | api.UpstreamRemoteReset | api.UpstreamConnectionTermination
+
+// record records a finished request
+func (ds *detailStats) record(info api.RequestInfo) {
+	ds.RequestTotal.Inc(1)
+	switch code := info.ResponseCode(); {
+	case code >= 200 && code < 300:
+		ds.Response2xx.Inc(1)
+	case code >= 400 && code < 500:
+		ds.Response4xx.Inc(1)
+	case code >= 500 && code < 600:
+		ds.Response5xx.Inc(1)
+	}
+	if info.GetResponseFlag(api.UpstreamRequestTimeout) {
+		ds.RequestTimeout.Inc(1)
+	}
+	if info.GetResponseFlag(requestResetFlags) {
+		ds.RequestReset.Inc(1)
 	}
 }
@@ -19,6 +19,7 @@ package proxy
 
 import (
 	"sync/atomic"
+	"time"
 
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/types"
@@ -27,6 +28,12 @@ import (
 
 // run stream append filters
 func (s *downStream) runAppendFilters(p types.Phase, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) bool {
+	if t := s.timeline(); t != nil && len(s.senderFilters) > 0 {
+		start := time.Now()
+		defer func() {
+			t.AddAppendFiltersDuration(time.Since(start))
+		}()
+	}
 	for ; s.senderFiltersIndex < len(s.senderFilters); s.senderFiltersIndex++ {
 		f := s.senderFilters[s.senderFiltersIndex]
 
@@ -41,6 +48,12 @@ func (s *downStream) runAppendFilters(p types.Phase, headers types.HeaderMap, da
 
 // run stream receive filters
 func (s *downStream) runReceiveFilters(p types.Phase, headers types.HeaderMap, data types.IoBuffer, trailers types.HeaderMap) bool {
+	if t := s.timeline(); t != nil && len(s.receiverFilters) > 0 {
+		start := time.Now()
+		defer func() {
+			t.AddReceiveFiltersDuration(time.Since(start))
+		}()
+	}
 	for ; s.receiverFiltersIndex < len(s.receiverFilters); s.receiverFiltersIndex++ {
 		f := s.receiverFilters[s.receiverFiltersIndex]
 		if f.p != p {
@@ -51,6 +64,10 @@ func (s *downStream) runReceiveFilters(p types.Phase, headers types.HeaderMap, d
 		if status == api.StreamFilterStop {
 			return true
 		}
+		// the pause takes effect only if the filter is stopped
+		if atomic.LoadUint32(&s.receivePause) != receiveRunning {
+			atomic.StoreUint32(&s.receivePause, receiveRunning)
+		}
 
 		if status == api.StreamFilterReMatchRoute {
 			s.receiverFiltersIndex++
@@ -63,6 +80,53 @@ func (s *downStream) runReceiveFilters(p types.Phase, headers types.HeaderMap, d
 	return false
 }
 
+// the receive filters pause states
+const (
+	receiveRunning uint32 = iota
+	// a filter calls PauseReceiving and is not returned yet
+	receivePausing
+	// the stream goroutine is released
+	receivePaused
+	// the filter calls ContinueReceiving before it returns
+	receiveResumed
+)
+
+// runReceiveFiltersOrPause runs the receive filters of the phase, returns true if the stream is paused by a filter
+func (s *downStream) runReceiveFiltersOrPause(p types.Phase) bool {
+	for s.runReceiveFilters(p, s.downstreamReqHeaders, s.downstreamReqDataBuf, s.downstreamReqTrailers) {
+		s.pausedPhase = p
+		if atomic.CompareAndSwapUint32(&s.receivePause, receivePausing, receivePaused) {
+			return true
+		}
+		// the filter is stopped without pausing, or resumed before it returns
+		if !atomic.CompareAndSwapUint32(&s.receivePause, receiveResumed, receiveRunning) || s.directResponse {
+			return false
+		}
+		s.receiverFiltersIndex++
+	}
+	return false
+}
+
+// continueReceiving resumes the stream paused by a receive filter
+func (s *downStream) continueReceiving() {
+	if atomic.CompareAndSwapUint32(&s.receivePause, receivePausing, receiveResumed) {
+		return
+	}
+	if !atomic.CompareAndSwapUint32(&s.receivePause, receivePaused, receiveRunning) {
+		return
+	}
+	if atomic.LoadUint32(&s.downstreamCleaned) == 1 {
+		return
+	}
+	if s.directResponse {
+		// skip the rest filters, the direct response is sent after the phase
+		s.receiverFiltersIndex = len(s.receiverFilters)
+	} else {
+		s.receiverFiltersIndex++
+	}
+	s.scheduleReceive(s.context, s.ID, s.pausedPhase)
+}
+
 type activeStreamFilter struct {
 	activeStream *downStream
 }
@@ -85,6 +149,8 @@ type activeStreamReceiverFilter struct {
 	p types.Phase
 	activeStreamFilter
 	filter api.StreamReceiverFilter
+	// the type of the filter config that created the filter, used by the route overrides
+	filterType string
 }
 
 func newActiveStreamReceiverFilter(activeStream *downStream,
@@ -122,6 +188,14 @@ func (f *activeStreamReceiverFilter) AppendTrailers(trailers types.HeaderMap) {
 	f.activeStream.appendTrailers()
 }
 
+func (f *activeStreamReceiverFilter) PauseReceiving() {
+	atomic.CompareAndSwapUint32(&f.activeStream.receivePause, receiveRunning, receivePausing)
+}
+
+func (f *activeStreamReceiverFilter) ContinueReceiving() {
+	f.activeStream.continueReceiving()
+}
+
 func (f *activeStreamReceiverFilter) SendHijackReply(code int, headers types.HeaderMap) {
 	f.activeStream.sendHijackReply(code, headers)
 }
@@ -144,6 +218,8 @@ type activeStreamSenderFilter struct {
 	activeStreamFilter
 
 	filter api.StreamSenderFilter
+	// the type of the filter config that created the filter, used by the route overrides
+	filterType string
 }
 
 func newActiveStreamSenderFilter(activeStream *downStream,
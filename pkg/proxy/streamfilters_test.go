@@ -307,3 +307,75 @@ func (f *mockStreamSenderFilter) Append(ctx context.Context, headers types.Heade
 func (f *mockStreamSenderFilter) SetSenderFilterHandler(handler api.StreamSenderFilterHandler) {
 	f.handler = handler
 }
+
+type mockPauseReceiverFilter struct {
+	handler types.AsyncStreamReceiverFilterHandler
+	on      int
+	receive func(handler types.AsyncStreamReceiverFilterHandler) api.StreamFilterStatus
+}
+
+func (f *mockPauseReceiverFilter) OnDestroy() {}
+
+func (f *mockPauseReceiverFilter) OnReceive(ctx context.Context, headers types.HeaderMap, buf types.IoBuffer, trailers types.HeaderMap) api.StreamFilterStatus {
+	f.on++
+	if f.receive == nil {
+		return api.StreamFilterContinue
+	}
+	return f.receive(f.handler)
+}
+
+func (f *mockPauseReceiverFilter) SetReceiveFilterHandler(handler api.StreamReceiverFilterHandler) {
+	f.handler = handler.(types.AsyncStreamReceiverFilterHandler)
+}
+
+func TestRunReceiveFiltersOrPause(t *testing.T) {
+	newStream := func(receive func(handler types.AsyncStreamReceiverFilterHandler) api.StreamFilterStatus) (*downStream, *mockPauseReceiverFilter) {
+		s := &downStream{proxy: &proxy{}}
+		s.AddStreamReceiverFilter(&mockPauseReceiverFilter{receive: receive}, api.BeforeRoute)
+		next := &mockPauseReceiverFilter{}
+		s.AddStreamReceiverFilter(next, api.BeforeRoute)
+		return s, next
+	}
+	// paused, the stream goroutine is released
+	s, next := newStream(func(handler types.AsyncStreamReceiverFilterHandler) api.StreamFilterStatus {
+		handler.PauseReceiving()
+		return api.StreamFilterStop
+	})
+	if !s.runReceiveFiltersOrPause(types.DownFilter) || next.on != 0 ||
+		s.receivePause != receivePaused || s.pausedPhase != types.DownFilter {
+		t.Fatal("the stream should be paused")
+	}
+	// the cleaned stream is not resumed
+	atomic.StoreUint32(&s.downstreamCleaned, 1)
+	s.continueReceiving()
+	if s.receivePause != receiveRunning || next.on != 0 {
+		t.Fatal("the cleaned stream should not be resumed")
+	}
+	// resumed before the filter returns, the next filter runs in the stream goroutine
+	s, next = newStream(func(handler types.AsyncStreamReceiverFilterHandler) api.StreamFilterStatus {
+		handler.PauseReceiving()
+		handler.ContinueReceiving()
+		return api.StreamFilterStop
+	})
+	if s.runReceiveFiltersOrPause(types.DownFilter) || next.on != 1 || s.receivePause != receiveRunning {
+		t.Fatal("the stream should be resumed")
+	}
+	// the pause is ignored if the filter is not stopped
+	s, next = newStream(func(handler types.AsyncStreamReceiverFilterHandler) api.StreamFilterStatus {
+		handler.PauseReceiving()
+		return api.StreamFilterContinue
+	})
+	if s.runReceiveFiltersOrPause(types.DownFilter) || next.on != 1 || s.receivePause != receiveRunning {
+		t.Fatal("the pause should be ignored")
+	}
+	// stopped without pausing
+	s, next = newStream(func(handler types.AsyncStreamReceiverFilterHandler) api.StreamFilterStatus {
+		handler.SendHijackReply(403, nil)
+		return api.StreamFilterStop
+	})
+	s.requestInfo = &network.RequestInfo{}
+	s.context = context.Background()
+	if s.runReceiveFiltersOrPause(types.DownFilter) || next.on != 0 || !s.directResponse {
+		t.Fatal("the stream should be stopped with the direct response")
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"runtime/debug"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/pkg/utils"
+)
+
+const defaultTelemetryQueueSize = 4096
+
+// telemetryWorkers records the stats and writes the access logs of the finished streams off the hot path,
+// nil means they are done in the stream goroutine when the stream is cleaned
+var telemetryWorkers *telemetry
+
+// InitTelemetryWorkers starts the telemetry workers if they are configured,
+// the workers are created once and kept when the server is reconfigured
+func InitTelemetryWorkers(config *v2.WorkersConfig) {
+	if config == nil || config.TelemetryWorkers <= 0 || telemetryWorkers != nil {
+		return
+	}
+	telemetryWorkers = newTelemetry(config.TelemetryWorkers, config.TelemetryQueueSize)
+	log.DefaultLogger.Infof("[proxy] [telemetry] telemetry workers: %d, queue size: %d",
+		config.TelemetryWorkers, config.TelemetryQueueSize)
+}
+
+// telemetryWorker receives the finished streams in a ring buffer
+type telemetryWorker struct {
+	queue   chan *downStream
+	depth   gometrics.Gauge
+	dropped gometrics.Counter
+}
+
+type telemetry struct {
+	workers []*telemetryWorker
+}
+
+func newTelemetry(workers, queueSize int) *telemetry {
+	if queueSize <= 0 {
+		queueSize = defaultTelemetryQueueSize
+	}
+	t := &telemetry{
+		workers: make([]*telemetryWorker, workers),
+	}
+	for i := range t.workers {
+		s := metrics.NewTelemetryStats(i)
+		w := &telemetryWorker{
+			queue:   make(chan *downStream, queueSize),
+			depth:   s.Gauge(metrics.TelemetryQueueDepth),
+			dropped: s.Counter(metrics.TelemetryDropped),
+		}
+		t.workers[i] = w
+		utils.GoWithRecover(w.work, nil)
+	}
+	return t
+}
+
+// submit sends the stream to a worker, the streams of a connection are sent to the same worker,
+// so the access logs keep the order. false is returned if the queue is full.
+func (t *telemetry) submit(s *downStream) bool {
+	var id uint64
+	if s.proxy != nil && s.proxy.readCallbacks != nil {
+		id = s.proxy.readCallbacks.Connection().ID()
+	}
+	w := t.workers[id%uint64(len(t.workers))]
+	select {
+	case w.queue <- s:
+		w.depth.Update(int64(len(w.queue)))
+		return true
+	default:
+		w.dropped.Inc(1)
+		return false
+	}
+}
+
+func (w *telemetryWorker) work() {
+	for s := range w.queue {
+		w.depth.Update(int64(len(w.queue)))
+		w.process(s)
+	}
+}
+
+// process recovers the panic of a stream, so the worker keeps working
+func (w *telemetryWorker) process(s *downStream) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.DefaultLogger.Errorf("[proxy] [telemetry] finish stream telemetry panic: %v\n%s", r, string(debug.Stack()))
+		}
+	}()
+	s.finishTelemetry()
+}
+
+// telemetry records the stats and writes the access logs of the finished stream, and recycles the stream.
+// If the telemetry workers are busy, the access logs are dropped and the stats are recorded in place,
+// so the gauges such as the active requests are kept right.
+func (s *downStream) telemetry() {
+	if telemetryWorkers == nil {
+		s.finishTelemetry()
+		return
+	}
+	if telemetryWorkers.submit(s) {
+		return
+	}
+	s.requestMetrics()
+	s.recycle()
+}
+
+func (s *downStream) finishTelemetry() {
+	s.requestMetrics()
+	s.writeLog()
+	s.recycle()
+}
+
+func (s *downStream) recycle() {
+	// the headers and data are not held by the stream any more
+	s.memory.release()
+	// recycle if no reset events
+	s.giveStream()
+}
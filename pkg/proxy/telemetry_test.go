@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/network"
+)
+
+type mockAccessLog struct {
+	logged chan struct{}
+}
+
+func (l *mockAccessLog) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	l.logged <- struct{}{}
+}
+
+func newTelemetryTestStream(al api.AccessLog) *downStream {
+	initGlobalStats()
+	return &downStream{
+		proxy: &proxy{
+			config:        &v2.Proxy{},
+			readCallbacks: &mockReadFilterCallbacks{},
+			stats:         globalStats,
+			listenerStats: newListenerStats("test"),
+			accessLogs:    []api.AccessLog{al},
+		},
+		context:     context.Background(),
+		requestInfo: network.NewRequestInfo(),
+	}
+}
+
+func TestTelemetryWorkers(t *testing.T) {
+	defer func(t *telemetry) {
+		telemetryWorkers = t
+	}(telemetryWorkers)
+	telemetryWorkers = newTelemetry(2, 16)
+
+	al := &mockAccessLog{logged: make(chan struct{}, 1)}
+	s := newTelemetryTestStream(al)
+	s.telemetry()
+	select {
+	case <-al.logged:
+	case <-time.After(time.Second):
+		t.Fatal("access log is not written by the telemetry workers")
+	}
+}
+
+type panicAccessLog struct{}
+
+func (l *panicAccessLog) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	panic("access log panic")
+}
+
+func TestTelemetryWorkerPanic(t *testing.T) {
+	defer func(t *telemetry) {
+		telemetryWorkers = t
+	}(telemetryWorkers)
+	telemetryWorkers = newTelemetry(1, 16)
+
+	// the worker keeps working after a stream panics
+	newTelemetryTestStream(&panicAccessLog{}).telemetry()
+	al := &mockAccessLog{logged: make(chan struct{}, 1)}
+	newTelemetryTestStream(al).telemetry()
+	select {
+	case <-al.logged:
+	case <-time.After(time.Second):
+		t.Fatal("access log is not written after the telemetry worker panics")
+	}
+}
+
+func TestTelemetryDropped(t *testing.T) {
+	defer func(t *telemetry) {
+		telemetryWorkers = t
+	}(telemetryWorkers)
+	// the worker is not started, so the queue is full after a stream is submitted
+	s := metrics.NewTelemetryStats(100)
+	w := &telemetryWorker{
+		queue:   make(chan *downStream, 1),
+		depth:   s.Gauge(metrics.TelemetryQueueDepth),
+		dropped: s.Counter(metrics.TelemetryDropped),
+	}
+	telemetryWorkers = &telemetry{workers: []*telemetryWorker{w}}
+
+	al := &mockAccessLog{logged: make(chan struct{}, 2)}
+	newTelemetryTestStream(al).telemetry()
+	if depth := w.depth.Value(); depth != 1 {
+		t.Errorf("queue depth expected 1, but got %d", depth)
+	}
+
+	dropped := newTelemetryTestStream(al)
+	active := dropped.proxy.stats.DownstreamRequestActive.Count()
+	dropped.telemetry()
+	if w.dropped.Count() != 1 {
+		t.Errorf("dropped expected 1, but got %d", w.dropped.Count())
+	}
+	// the stats of the dropped stream are recorded, and the access log is not written
+	if dropped.proxy.stats.DownstreamRequestActive.Count() != active-1 {
+		t.Error("the stats of the dropped stream are not recorded")
+	}
+	if len(al.logged) != 0 {
+		t.Error("the access log of the dropped stream should not be written")
+	}
+}
@@ -20,10 +20,14 @@ package proxy
 import (
 	"container/list"
 	"context"
+	"strconv"
 	"time"
 
 	"sync/atomic"
 
+	"mosn.io/mosn/pkg/baggage"
+	"mosn.io/mosn/pkg/chaos"
+	"mosn.io/mosn/pkg/datamask"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
@@ -51,6 +55,8 @@ type upstreamRequest struct {
 
 	// time at send upstream request
 	startTime time.Time
+	// time at request a stream from the connection pool
+	poolStartTime time.Time
 
 	// list element
 	element *list.Element
@@ -72,6 +78,12 @@ func (r *upstreamRequest) resetStream() {
 // types.StreamEventListener
 // Called by stream layer normally
 func (r *upstreamRequest) OnResetStream(reason types.StreamResetReason) {
+	if a := r.downStream.attempts(); a != nil {
+		a.FinishAttempt(time.Now(), 0, reason)
+	}
+	if reason == types.StreamConnectionFailed || reason == types.StreamConnectionTermination {
+		r.downStream.onUpstreamConnectionBroken()
+	}
 	if r.setupRetry {
 		return
 	}
@@ -105,11 +117,18 @@ func (r *upstreamRequest) OnReceive(ctx context.Context, headers types.HeaderMap
 
 	r.endStream()
 
-	if code, err := protocol.MappingHeaderStatusCode(r.protocol, headers); err == nil {
+	code, err := protocol.MappingHeaderStatusCode(r.protocol, headers)
+	if err == nil {
 		r.downStream.requestInfo.SetResponseCode(code)
 	}
+	if a := r.downStream.attempts(); a != nil {
+		a.FinishAttempt(time.Now(), code, "")
+	}
 
 	r.downStream.requestInfo.SetResponseReceivedDuration(time.Now())
+	if t := r.downStream.timeline(); t != nil {
+		t.SetFirstUpstreamByteDuration(time.Now())
+	}
 	r.downStream.downstreamRespHeaders = headers
 
 	if data != nil {
@@ -117,10 +136,15 @@ func (r *upstreamRequest) OnReceive(ctx context.Context, headers types.HeaderMap
 		data.Drain(data.Len())
 	}
 
+	if trailers != nil {
+		chaos.CorruptTrailer(r.host.ClusterInfo().Name(), r.host.AddressString(), trailers)
+	}
 	r.downStream.downstreamRespTrailers = trailers
+	r.downStream.memory.holdResponse(headers, r.downStream.downstreamRespDataBuf, trailers)
 
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
-		log.Proxy.Debugf(r.downStream.context, "[proxy] [upstream] OnReceive headers: %+v, data: %+v, trailers: %+v", headers, data, trailers)
+		log.Proxy.Debugf(r.downStream.context, "[proxy] [upstream] OnReceive headers: %+v, data: %+v, trailers: %+v",
+			datamask.MaskHeadersFromContext(r.downStream.context, headers), data, trailers)
 	}
 
 	r.downStream.sendNotify()
@@ -163,9 +187,14 @@ func (r *upstreamRequest) appendHeaders(endStream bool) {
 		return
 	}
 	if log.Proxy.GetLogLevel() >= log.DEBUG {
-		log.Proxy.Debugf(r.downStream.context, "[proxy] [upstream] append headers: %+v", r.downStream.downstreamReqHeaders)
+		log.Proxy.Debugf(r.downStream.context, "[proxy] [upstream] append headers: %+v",
+			datamask.MaskHeadersFromContext(r.downStream.context, r.downStream.downstreamReqHeaders))
 	}
 	r.sendComplete = endStream
+	r.poolStartTime = time.Now()
+	if t := r.downStream.timeline(); t != nil {
+		t.AddAttemptStart(r.poolStartTime)
+	}
 
 	if r.downStream.oneway {
 		r.connPool.NewStream(r.downStream.context, nil, r)
@@ -269,6 +298,9 @@ func (r *upstreamRequest) OnFailure(reason types.PoolFailureReason, host types.H
 	}
 
 	r.host = host
+	if a := r.downStream.attempts(); a != nil {
+		a.SetAttemptHost(host.AddressString())
+	}
 	r.OnResetStream(resetReason)
 }
 
@@ -280,12 +312,29 @@ func (r *upstreamRequest) OnReady(sender types.StreamSender, host types.Host) {
 
 	r.requestSender = sender
 	r.host = host
+	if a := r.downStream.attempts(); a != nil {
+		a.SetAttemptHost(host.AddressString())
+	}
 	r.requestSender.GetStream().AddEventListener(r)
 	// start a upstream send
 	r.startTime = time.Now()
+	r.downStream.upstreamConnectDuration = r.startTime.Sub(r.poolStartTime)
+	if t := r.downStream.timeline(); t != nil {
+		t.SetUpstreamConnectedDuration(r.startTime)
+	}
 
 	endStream := r.sendComplete && !r.dataSent && !r.trailerSent
-	r.requestSender.AppendHeaders(r.downStream.context, r.convertHeader(r.downStream.downstreamReqHeaders), endStream)
+	if r.downStream.proxy.config.IncludeAttemptCount {
+		r.downStream.upstreamAttempts++
+		r.downStream.downstreamReqHeaders.Set(types.HeaderAttemptCount, strconv.FormatUint(uint64(r.downStream.upstreamAttempts), 10))
+	}
+	headers := r.convertHeader(r.downStream.downstreamReqHeaders)
+	// the baggage is set after the protocol conversion, so it is kept even if the conversion drops it
+	if p := r.downStream.proxy.baggage; p != nil {
+		p.Propagate(r.downStream.downstreamReqHeaders, headers)
+	}
+	baggage.Inject(r.downStream.context, headers)
+	r.requestSender.AppendHeaders(r.downStream.context, headers, endStream)
 
 	r.downStream.requestInfo.OnUpstreamHostSelected(host)
 	r.downStream.requestInfo.SetUpstreamLocalAddress(host.AddressString())
@@ -20,7 +20,9 @@ package proxy
 import (
 	"context"
 	"strconv"
+	"strings"
 
+	"mosn.io/mosn/pkg/types"
 	"mosn.io/mosn/pkg/variable"
 )
 
@@ -40,6 +42,17 @@ const (
 	VarDownstreamLocalAddress   string = "downstream_local_address"
 	VarDownstreamRemoteAddress  string = "downstream_remote_address"
 	VarUpstreamHost             string = "upstream_host"
+	VarUpstreamCluster          string = "upstream_cluster"
+
+	// request timeline
+	VarRouteMatchedDuration      string = "route_matched_duration"
+	VarUpstreamConnectedDuration string = "upstream_connected_duration"
+	VarFirstUpstreamByteDuration string = "first_upstream_byte_duration"
+	VarReceiveFiltersDuration    string = "receive_filters_duration"
+	VarAppendFiltersDuration     string = "append_filters_duration"
+	VarAttemptStartDurations     string = "attempt_start_durations"
+	VarUpstreamAttempts          string = "upstream_attempts"
+	VarUpstreamAttemptCount      string = "upstream_attempt_count"
 
 	// ReqHeaderPrefix is the prefix of request header's formatter
 	reqHeaderPrefix string = "request_header_"
@@ -65,6 +78,15 @@ var (
 		variable.NewBasicVariable(VarDownstreamLocalAddress, nil, downstreamLocalAddressGetter, nil, 0),
 		variable.NewBasicVariable(VarDownstreamRemoteAddress, nil, downstreamRemoteAddressGetter, nil, 0),
 		variable.NewBasicVariable(VarUpstreamHost, nil, upstreamHostGetter, nil, 0),
+		variable.NewBasicVariable(VarUpstreamCluster, nil, upstreamClusterGetter, nil, 0),
+		variable.NewBasicVariable(VarRouteMatchedDuration, nil, routeMatchedDurationGetter, nil, 0),
+		variable.NewBasicVariable(VarUpstreamConnectedDuration, nil, upstreamConnectedDurationGetter, nil, 0),
+		variable.NewBasicVariable(VarFirstUpstreamByteDuration, nil, firstUpstreamByteDurationGetter, nil, 0),
+		variable.NewBasicVariable(VarReceiveFiltersDuration, nil, receiveFiltersDurationGetter, nil, 0),
+		variable.NewBasicVariable(VarAppendFiltersDuration, nil, appendFiltersDurationGetter, nil, 0),
+		variable.NewBasicVariable(VarAttemptStartDurations, nil, attemptStartDurationsGetter, nil, 0),
+		variable.NewBasicVariable(VarUpstreamAttempts, nil, upstreamAttemptsGetter, nil, 0),
+		variable.NewBasicVariable(VarUpstreamAttemptCount, nil, upstreamAttemptCountGetter, nil, 0),
 	}
 
 	prefixVariables = []variable.Variable{
@@ -165,12 +187,11 @@ func durationGetter(ctx context.Context, value *variable.IndexedValue, data inte
 }
 
 // GetResponseFlagGetter
-// get request's response flag
+// get request's response flags, the flag names are joined by comma
 func responseFlagGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
 	proxyBuffers := proxyBuffersByContext(ctx)
-	info := proxyBuffers.info
 
-	return strconv.FormatBool(info.GetResponseFlag(0)), nil
+	return types.FormatResponseFlags(&proxyBuffers.info), nil
 }
 
 // UpstreamLocalAddressGetter
@@ -221,6 +242,108 @@ func upstreamHostGetter(ctx context.Context, value *variable.IndexedValue, data
 	return variable.ValueNotFound, nil
 }
 
+// upstreamClusterGetter
+// get the cluster name of the route chosen
+func upstreamClusterGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	cluster := proxyBuffers.stream.cluster
+
+	if cluster != nil {
+		return cluster.Name(), nil
+	}
+
+	return variable.ValueNotFound, nil
+}
+
+// routeMatchedDurationGetter
+// get duration between request arriving and route matched
+func routeMatchedDurationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	return info.RouteMatchedDuration().String(), nil
+}
+
+// upstreamConnectedDurationGetter
+// get duration between request arriving and the upstream connection pool ready
+func upstreamConnectedDurationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	return info.UpstreamConnectedDuration().String(), nil
+}
+
+// firstUpstreamByteDurationGetter
+// get duration between request arriving and the first upstream response received
+func firstUpstreamByteDurationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	return info.FirstUpstreamByteDuration().String(), nil
+}
+
+// receiveFiltersDurationGetter
+// get duration spent in the stream receiver filters
+func receiveFiltersDurationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	return info.ReceiveFiltersDuration().String(), nil
+}
+
+// appendFiltersDurationGetter
+// get duration spent in the stream sender filters
+func appendFiltersDurationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	return info.AppendFiltersDuration().String(), nil
+}
+
+// attemptStartDurationsGetter
+// get the start durations of the upstream attempts, separated by comma
+func attemptStartDurationsGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	durations := info.AttemptStartDurations()
+	if len(durations) == 0 {
+		return variable.ValueNotFound, nil
+	}
+	attempts := make([]string, 0, len(durations))
+	for _, d := range durations {
+		attempts = append(attempts, d.String())
+	}
+	return strings.Join(attempts, ","), nil
+}
+
+// upstreamAttemptsGetter
+// get all the upstream attempts, the attempts are separated by comma,
+// and each attempt is formatted as host|start duration|duration|result
+func upstreamAttemptsGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	attempts := info.UpstreamAttempts()
+	if len(attempts) == 0 {
+		return variable.ValueNotFound, nil
+	}
+	values := make([]string, 0, len(attempts))
+	for _, attempt := range attempts {
+		values = append(values, attempt.String())
+	}
+	return strings.Join(values, ","), nil
+}
+
+// upstreamAttemptCountGetter
+// get the count of the upstream attempts
+func upstreamAttemptCountGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	proxyBuffers := proxyBuffersByContext(ctx)
+	info := proxyBuffers.info
+
+	return strconv.Itoa(len(info.UpstreamAttempts())), nil
+}
+
 func requestHeaderMapGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
 	proxyBuffers := proxyBuffersByContext(ctx)
 	headers := proxyBuffers.stream.downstreamReqHeaders
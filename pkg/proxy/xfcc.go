@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/types"
+)
+
+// forwardClientCert handles the x-forwarded-client-cert header of the requests
+type forwardClientCert struct {
+	mode    string
+	subject bool
+	uri     bool
+	dns     bool
+	cert    bool
+	chain   bool
+}
+
+func newForwardClientCert(config *v2.ForwardClientCert) (*forwardClientCert, error) {
+	if config == nil {
+		return nil, nil
+	}
+	f := &forwardClientCert{
+		mode:    config.Mode,
+		subject: config.Subject,
+		uri:     config.URI,
+		dns:     config.DNS,
+		cert:    config.Cert,
+		chain:   config.Chain,
+	}
+	switch config.Mode {
+	case "":
+		f.mode = v2.ForwardClientCertSanitize
+	case v2.ForwardClientCertSanitize, v2.ForwardClientCertForwardOnly, v2.ForwardClientCertAppendForward,
+		v2.ForwardClientCertSanitizeSet, v2.ForwardClientCertAlwaysForwardOnly:
+	default:
+		return nil, fmt.Errorf("unknown forward client cert mode: %s", config.Mode)
+	}
+	return f, nil
+}
+
+// apply sanitizes, forwards or appends the header according to the mode,
+// the certs is the certificate chain of the downstream client, empty if the connection is not mTLS
+func (f *forwardClientCert) apply(headers types.HeaderMap, certs []*x509.Certificate) {
+	if f == nil || headers == nil {
+		return
+	}
+	if f.mode == v2.ForwardClientCertAlwaysForwardOnly {
+		return
+	}
+	if len(certs) == 0 || f.mode == v2.ForwardClientCertSanitize {
+		headers.Del(types.HeaderForwardedClientCert)
+		return
+	}
+	switch f.mode {
+	case v2.ForwardClientCertAppendForward:
+		element := f.element(certs)
+		if value, ok := headers.Get(types.HeaderForwardedClientCert); ok && value != "" {
+			element = value + "," + element
+		}
+		headers.Set(types.HeaderForwardedClientCert, element)
+	case v2.ForwardClientCertSanitizeSet:
+		headers.Set(types.HeaderForwardedClientCert, f.element(certs))
+	}
+}
+
+// element returns the details of the client certificate chain, the first certificate is the client's
+func (f *forwardClientCert) element(certs []*x509.Certificate) string {
+	leaf := certs[0]
+	hash := sha256.Sum256(leaf.Raw)
+	pairs := []string{"Hash=" + hex.EncodeToString(hash[:])}
+	if f.cert {
+		pairs = append(pairs, `Cert="`+url.QueryEscape(encodePEM(certs[:1]))+`"`)
+	}
+	if f.chain {
+		pairs = append(pairs, `Chain="`+url.QueryEscape(encodePEM(certs))+`"`)
+	}
+	if f.subject {
+		pairs = append(pairs, `Subject="`+strings.Replace(leaf.Subject.String(), `"`, `\"`, -1)+`"`)
+	}
+	if f.uri {
+		for _, u := range leaf.URIs {
+			pairs = append(pairs, "URI="+u.String())
+		}
+	}
+	if f.dns {
+		for _, name := range leaf.DNSNames {
+			pairs = append(pairs, "DNS="+name)
+		}
+	}
+	return strings.Join(pairs, ";")
+}
+
+func encodePEM(certs []*x509.Certificate) string {
+	var b strings.Builder
+	for _, cert := range certs {
+		pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return b.String()
+}
+
+// downstreamCertificates returns the certificate chain of the downstream mTLS client
+func (s *downStream) downstreamCertificates() []*x509.Certificate {
+	if s.proxy.readCallbacks == nil {
+		return nil
+	}
+	conn, ok := s.DownstreamConnection().(*mtls.TLSConn)
+	if !ok {
+		return nil
+	}
+	return conn.ConnectionState().PeerCertificates
+}
@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls/certtool"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func createClientCertificate(t *testing.T) *x509.Certificate {
+	priv, err := certtool.GeneratePrivateKey("P256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := certtool.CreateTemplate("client", false, []string{"client.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.URIs = []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/ns/default/sa/client"}}
+	info, err := certtool.SignCertificate(tmpl, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(info.CertPem))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestForwardClientCertModes(t *testing.T) {
+	cert := createClientCertificate(t)
+	sum := sha256.Sum256(cert.Raw)
+	hash := "Hash=" + hex.EncodeToString(sum[:])
+	const upstream = "Hash=abc"
+	for _, tc := range []struct {
+		mode     string
+		certs    []*x509.Certificate
+		expected string
+	}{
+		{"", []*x509.Certificate{cert}, ""},
+		{v2.ForwardClientCertSanitize, []*x509.Certificate{cert}, ""},
+		{v2.ForwardClientCertForwardOnly, []*x509.Certificate{cert}, upstream},
+		{v2.ForwardClientCertForwardOnly, nil, ""},
+		{v2.ForwardClientCertAppendForward, []*x509.Certificate{cert}, upstream + "," + hash},
+		{v2.ForwardClientCertAppendForward, nil, ""},
+		{v2.ForwardClientCertSanitizeSet, []*x509.Certificate{cert}, hash},
+		{v2.ForwardClientCertSanitizeSet, nil, ""},
+		{v2.ForwardClientCertAlwaysForwardOnly, nil, upstream},
+	} {
+		f, err := newForwardClientCert(&v2.ForwardClientCert{Mode: tc.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers := protocol.CommonHeader{types.HeaderForwardedClientCert: upstream}
+		f.apply(headers, tc.certs)
+		value, _ := headers.Get(types.HeaderForwardedClientCert)
+		if value != tc.expected {
+			t.Errorf("mode %s with %d certs, expected %q, but got %q", tc.mode, len(tc.certs), tc.expected, value)
+		}
+	}
+	// append to an empty header
+	f, _ := newForwardClientCert(&v2.ForwardClientCert{Mode: v2.ForwardClientCertAppendForward})
+	headers := protocol.CommonHeader{}
+	f.apply(headers, []*x509.Certificate{cert})
+	if value, _ := headers.Get(types.HeaderForwardedClientCert); value != hash {
+		t.Errorf("unexpected header: %s", value)
+	}
+	if _, err := newForwardClientCert(&v2.ForwardClientCert{Mode: "unknown"}); err == nil {
+		t.Error("expected an error for the unknown mode")
+	}
+	if f, err := newForwardClientCert(nil); f != nil || err != nil {
+		t.Error("expected nil for no config")
+	}
+}
+
+func TestForwardClientCertDetails(t *testing.T) {
+	cert := createClientCertificate(t)
+	f, err := newForwardClientCert(&v2.ForwardClientCert{
+		Mode:    v2.ForwardClientCertSanitizeSet,
+		Subject: true,
+		URI:     true,
+		DNS:     true,
+		Cert:    true,
+		Chain:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers := protocol.CommonHeader{}
+	f.apply(headers, []*x509.Certificate{cert, cert})
+	value, _ := headers.Get(types.HeaderForwardedClientCert)
+	pairs := strings.Split(value, ";")
+	if len(pairs) != 6 {
+		t.Fatalf("unexpected header: %s", value)
+	}
+	for i, prefix := range []string{"Hash=", `Cert="`, `Chain="`, `Subject="`, "URI=spiffe://cluster.local/ns/default/sa/client", "DNS=client.example.com"} {
+		if !strings.HasPrefix(pairs[i], prefix) {
+			t.Errorf("#%d expected prefix %s, but got %s", i, prefix, pairs[i])
+		}
+	}
+	if !strings.Contains(pairs[3], "CN=client") {
+		t.Errorf("unexpected subject: %s", pairs[3])
+	}
+	certPEM, _ := url.QueryUnescape(strings.Trim(strings.TrimPrefix(pairs[1], "Cert="), `"`))
+	chainPEM, _ := url.QueryUnescape(strings.Trim(strings.TrimPrefix(pairs[2], "Chain="), `"`))
+	if block, _ := pem.Decode([]byte(certPEM)); block == nil || string(block.Bytes) != string(cert.Raw) {
+		t.Error("unexpected cert")
+	}
+	if strings.Count(chainPEM, "BEGIN CERTIFICATE") != 2 {
+		t.Error("unexpected chain")
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	admin "mosn.io/mosn/pkg/admin/server"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/server/keeper"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	servicesPath = "/api/v1/services"
+	preStopPath  = "/api/v1/services/prestop"
+	// maxPreStopWait limits the wait time of the pre-stop request
+	maxPreStopWait = time.Minute
+)
+
+// adminToken guards the changes of the services on the admin api, the changes are disabled if it is empty.
+// the api socket is not guarded, the access is controlled by the file permission.
+var adminToken atomic.Value // store string
+
+// SetAuthToken sets the admin api auth token, which is required by the changes of the services on the admin api
+func SetAuthToken(token string) {
+	adminToken.Store(token)
+}
+
+func authToken() string {
+	token, _ := adminToken.Load().(string)
+	return token
+}
+
+func init() {
+	admin.RegisterAdminHandleFunc(servicesPath, serveAdminServices)
+	admin.RegisterAdminHandleFunc(preStopPath, admin.TokenAuth("services/prestop", authToken, servePreStop))
+	// the services are deregistered before mosn exits
+	keeper.OnProcessShutDown(func() error {
+		if p := getPublisher(); p != nil {
+			return p.preStop()
+		}
+		return nil
+	})
+}
+
+// serveUnix serves the service registration api on the unix domain socket
+func serveUnix(path string) (net.Listener, error) {
+	syscall.Unlink(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(servicesPath, serveServices)
+	mux.HandleFunc(preStopPath, servePreStop)
+	utils.GoWithRecover(func() {
+		if err := http.Serve(l, mux); err != nil {
+			log.DefaultLogger.Infof("[registry] api socket %s closed: %v", path, err)
+		}
+	}, nil)
+	log.DefaultLogger.Infof("[registry] serve service registration api on %s", path)
+	return l, nil
+}
+
+// serveAdminServices serves the services api on the admin api, the POST and DELETE requests need the admin api auth token.
+func serveAdminServices(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost || req.Method == http.MethodDelete {
+		admin.TokenAuth("services", authToken, serveServices)(w, req)
+		return
+	}
+	serveServices(w, req)
+}
+
+// serveServices returns the published services, a POST request publishes the services in the body,
+// and a DELETE request removes the services in the query "name".
+func serveServices(w http.ResponseWriter, req *http.Request) {
+	p := getPublisher()
+	if p == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "no registry is configured")
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		buf, _ := json.Marshal(p.published())
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf)
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var services []v2.PublishInfo
+		if err := json.Unmarshal(body, &services); err != nil {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid services: %v", "services", err)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "invalid services: %v", err)
+			return
+		}
+		pubInfo := make(map[string]string, len(services))
+		for _, s := range services {
+			if s.Pub.ServiceName == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, "service name is required")
+				return
+			}
+			pubInfo[s.Pub.ServiceName] = s.Pub.PubData
+		}
+		if err := p.publish(services); err != nil {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: publish services failed: %v", "services", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "publish services failed: %v", err)
+			return
+		}
+		configmanager.AddPubInfo(pubInfo)
+		log.DefaultLogger.Infof("[admin api] [services] %d services published by api", len(services))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		names := req.URL.Query()["name"]
+		if len(names) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "service name is required")
+			return
+		}
+		if err := p.unpublish(names); err != nil {
+			log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: unpublish services failed: %v", "services", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "unpublish services failed: %v", err)
+			return
+		}
+		for _, name := range names {
+			configmanager.DelPubInfo(name)
+		}
+		log.DefaultLogger.Infof("[admin api] [services] services %v unpublished by api", names)
+		w.WriteHeader(http.StatusOK)
+	default:
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "services", req.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// servePreStop deregisters all of the services, it is called by the pre-stop hook of the application.
+// The optional query "wait" delays the response, so the consumers can see the change before the application stops.
+func servePreStop(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	p := getPublisher()
+	if p == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "no registry is configured")
+		return
+	}
+	var wait time.Duration
+	if v := req.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "invalid wait: %s", v)
+			return
+		}
+		if d > maxPreStopWait {
+			d = maxPreStopWait
+		}
+		wait = d
+	}
+	if err := p.preStop(); err != nil {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: pre-stop failed: %v", "services/prestop", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "pre-stop failed: %v", err)
+		return
+	}
+	log.DefaultLogger.Infof("[admin api] [services] pre-stop deregisters the services, wait %v", wait)
+	time.Sleep(wait)
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,171 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func TestServicesAPI(t *testing.T) {
+	r := newFakeRegistry()
+	RegisterRegistry("test_api", r)
+	if err := Init(v2.ServiceRegistryInfo{Registries: []string{"test_api"}}); err != nil {
+		t.Fatal(err)
+	}
+	body := `[{"service_name":"com.example.Foo","pub_data":"12200"},{"service_name":"com.example.Bar","pub_data":"12200"}]`
+	w := httptest.NewRecorder()
+	serveServices(w, httptest.NewRequest(http.MethodPost, servicesPath, bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("publish failed: %d %s", w.Code, w.Body.String())
+	}
+	if !r.isRegistered("com.example.Foo") || !r.isRegistered("com.example.Bar") {
+		t.Fatalf("expected services registered: %v", r.registered)
+	}
+	w = httptest.NewRecorder()
+	serveServices(w, httptest.NewRequest(http.MethodPost, servicesPath, bytes.NewBufferString(`[{"pub_data":"12200"}]`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	serveServices(w, httptest.NewRequest(http.MethodDelete, servicesPath+"?name=com.example.Bar", nil))
+	if w.Code != http.StatusOK || r.isRegistered("com.example.Bar") {
+		t.Fatalf("unpublish failed: %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	serveServices(w, httptest.NewRequest(http.MethodGet, servicesPath, nil))
+	var services []v2.PublishInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &services); err != nil || len(services) != 1 || services[0].Pub.ServiceName != "com.example.Foo" {
+		t.Fatalf("unexpected services: %s, %v", w.Body.String(), err)
+	}
+
+	// pre-stop deregisters all the services, and they are not registered any more
+	w = httptest.NewRecorder()
+	servePreStop(w, httptest.NewRequest(http.MethodPost, preStopPath+"?wait=10ms", nil))
+	if w.Code != http.StatusOK || r.isRegistered("com.example.Foo") {
+		t.Fatalf("pre-stop failed: %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	serveServices(w, httptest.NewRequest(http.MethodPost, servicesPath, bytes.NewBufferString(body)))
+	if w.Code != http.StatusInternalServerError || r.isRegistered("com.example.Foo") {
+		t.Fatalf("expected publish rejected after pre-stop, got %d", w.Code)
+	}
+	onHealthCheck(&fakeHost{addr: "127.0.0.1:12200"}, true, false)
+	onHealthCheck(&fakeHost{addr: "127.0.0.1:12200"}, true, true)
+	if r.isRegistered("com.example.Foo") {
+		t.Fatal("expected not registered by health recovery after pre-stop")
+	}
+}
+
+func TestPreStopInvalidWait(t *testing.T) {
+	RegisterRegistry("test_wait", newFakeRegistry())
+	if err := Init(v2.ServiceRegistryInfo{Registries: []string{"test_wait"}}); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	servePreStop(w, httptest.NewRequest(http.MethodPost, preStopPath+"?wait=soon", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	servePreStop(w, httptest.NewRequest(http.MethodGet, preStopPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected method not allowed, got %d", w.Code)
+	}
+}
+
+func TestServicesAdminAPIAuth(t *testing.T) {
+	r := newFakeRegistry()
+	RegisterRegistry("test_auth", r)
+	if err := Init(v2.ServiceRegistryInfo{Registries: []string{"test_auth"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetAuthToken("")
+	body := `[{"service_name":"com.example.Foo","pub_data":"12200"}]`
+	// the changes are disabled without the auth token
+	SetAuthToken("")
+	w := httptest.NewRecorder()
+	serveAdminServices(w, httptest.NewRequest(http.MethodPost, servicesPath, bytes.NewBufferString(body)))
+	if w.Code != http.StatusForbidden || r.isRegistered("com.example.Foo") {
+		t.Fatalf("expected forbidden, got %d", w.Code)
+	}
+	SetAuthToken("token")
+	w = httptest.NewRecorder()
+	serveAdminServices(w, httptest.NewRequest(http.MethodPost, servicesPath, bytes.NewBufferString(body)))
+	if w.Code != http.StatusUnauthorized || r.isRegistered("com.example.Foo") {
+		t.Fatalf("expected unauthorized, got %d", w.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, servicesPath, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	serveAdminServices(w, req)
+	if w.Code != http.StatusOK || !r.isRegistered("com.example.Foo") {
+		t.Fatalf("publish failed: %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	serveAdminServices(w, httptest.NewRequest(http.MethodDelete, servicesPath+"?name=com.example.Foo", nil))
+	if w.Code != http.StatusUnauthorized || !r.isRegistered("com.example.Foo") {
+		t.Fatalf("expected unauthorized, got %d", w.Code)
+	}
+	// the services are listed without the auth token
+	w = httptest.NewRecorder()
+	serveAdminServices(w, httptest.NewRequest(http.MethodGet, servicesPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list services failed: %d", w.Code)
+	}
+}
+
+func TestServicesAPISocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "registry.sock")
+	r := newFakeRegistry()
+	RegisterRegistry("test_socket", r)
+	if err := Init(v2.ServiceRegistryInfo{Registries: []string{"test_socket"}, APISocket: path}); err != nil {
+		t.Fatal(err)
+	}
+	defer getPublisher().listener.Close()
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", path)
+			},
+		},
+	}
+	resp, err := client.Post("http://registry"+servicesPath, "application/json", bytes.NewBufferString(`[{"service_name":"com.example.Foo"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !r.isRegistered("com.example.Foo") {
+		t.Fatalf("publish by socket failed: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/healthcheck"
+)
+
+// HealthCheckCallback is the name of the common health check callback that publishes the health of the
+// local application. It is configured in the health check of the cluster that contains the local application
+// hosts, the health check protocol can be any of the registered health check sessions.
+const HealthCheckCallback = "registry_health"
+
+func init() {
+	healthcheck.RegisterCommonCallbacks(HealthCheckCallback, onHealthCheck)
+}
+
+func onHealthCheck(host types.Host, changed bool, healthy bool) {
+	if !changed {
+		return
+	}
+	p := getPublisher()
+	if p == nil {
+		return
+	}
+	key := host.AddressString()
+	if info := host.ClusterInfo(); info != nil {
+		key = info.Name() + "|" + key
+	}
+	p.hostHealthChanged(key, healthy)
+}
+
+// hostHealthChanged updates the health of the application, the application is healthy if all of the
+// local hosts are healthy. The services are deregistered or marked unhealthy if the application becomes
+// unhealthy, and registered again after it recovers.
+func (p *publisher) hostHealthChanged(key string, healthy bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if healthy {
+		delete(p.unhealthyHosts, key)
+	} else {
+		p.unhealthyHosts[key] = true
+	}
+	appHealthy := len(p.unhealthyHosts) == 0
+	if appHealthy == p.healthy {
+		return
+	}
+	p.healthy = appHealthy
+	log.DefaultLogger.Infof("[registry] local application health changed to %v by host %s", appHealthy, key)
+	if len(p.services) == 0 || p.stopped {
+		return
+	}
+	if p.markUnhealthy {
+		p.markHealthyLocked(p.services, appHealthy)
+		return
+	}
+	if appHealthy {
+		p.registerLocked(p.services)
+	} else {
+		p.deregisterLocked(p.services)
+	}
+}
+
+// markHealthyLocked marks the health of the services, the registries not supporting it deregister the
+// unhealthy services instead
+func (p *publisher) markHealthyLocked(services []v2.PublishInfo, healthy bool) {
+	for _, r := range p.registries {
+		marker, ok := r.registry.(HealthMarker)
+		if !ok {
+			if healthy {
+				r.register(p.app, services)
+			} else {
+				r.deregister(p.app, services)
+			}
+			continue
+		}
+		if err := marker.MarkHealthy(p.app, services, healthy); err != nil {
+			log.DefaultLogger.Errorf("[registry] mark services healthy %v in %s failed: %v", healthy, r.name, err)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package registry registers the services of the co-located application into the service registries,
+// such as SOFARegistry and Nacos. The registry clients are extensions registered by RegisterRegistry,
+// the services are deregistered or marked unhealthy when the local application fails the health check.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+const registryType = "registry"
+
+// Registry is a client of a service registry
+type Registry interface {
+	// Register registers the services of the application, the registered services are updated
+	Register(app v2.ApplicationInfo, services []v2.PublishInfo) error
+	// Deregister removes the services of the application
+	Deregister(app v2.ApplicationInfo, services []v2.PublishInfo) error
+}
+
+// HealthMarker is implemented by the registries that support the instance health state,
+// the services are marked instead of deregistered if the unhealthy action is "mark_unhealthy"
+type HealthMarker interface {
+	MarkHealthy(app v2.ApplicationInfo, services []v2.PublishInfo, healthy bool) error
+}
+
+var (
+	ErrUnknownRegistry = errors.New("unknown registry")
+	ErrStopped         = errors.New("services are deregistered by pre-stop")
+)
+
+var (
+	registriesMux sync.RWMutex
+	registries    = make(map[string]Registry)
+)
+
+// RegisterRegistry registers a registry client by name
+func RegisterRegistry(name string, r Registry) {
+	registriesMux.Lock()
+	defer registriesMux.Unlock()
+	registries[name] = r
+}
+
+// GetRegistry returns the registry client registered by name
+func GetRegistry(name string) Registry {
+	registriesMux.RLock()
+	defer registriesMux.RUnlock()
+	return registries[name]
+}
+
+func init() {
+	configmanager.RegisterConfigParsedListener(configmanager.ParseCallbackKeyServiceRgtInfo, func(data interface{}, endParsing bool) error {
+		info, ok := data.(v2.ServiceRegistryInfo)
+		if !ok {
+			return fmt.Errorf("invalid service registry info: %T", data)
+		}
+		if len(info.Registries) == 0 {
+			return nil
+		}
+		return Init(info)
+	})
+}
+
+type registryStats struct {
+	register          gometrics.Counter
+	registerFailure   gometrics.Counter
+	deregister        gometrics.Counter
+	deregisterFailure gometrics.Counter
+}
+
+func newRegistryStats(name string) registryStats {
+	m, _ := metrics.NewMetrics(registryType, map[string]string{"registry": name})
+	return registryStats{
+		register:          m.Counter("register"),
+		registerFailure:   m.Counter("register_failure"),
+		deregister:        m.Counter("deregister"),
+		deregisterFailure: m.Counter("deregister_failure"),
+	}
+}
+
+type namedRegistry struct {
+	name     string
+	registry Registry
+	stats    registryStats
+}
+
+// publisher keeps the published services, and applies them to the registries by the health of the application
+type publisher struct {
+	mux           sync.Mutex
+	app           v2.ApplicationInfo
+	registries    []*namedRegistry
+	markUnhealthy bool
+	// the published services in order, and the index of them by name
+	services []v2.PublishInfo
+	index    map[string]int
+	healthy  bool
+	// stopped is set by the pre-stop, the services are not registered any more
+	stopped bool
+	// the unhealthy local hosts of the application
+	unhealthyHosts map[string]bool
+	// the listener of the api socket
+	listener net.Listener
+}
+
+var (
+	defaultMux       sync.RWMutex
+	defaultPublisher *publisher
+)
+
+// Init creates the publisher of the registries, and registers the published services in the config
+func Init(info v2.ServiceRegistryInfo) error {
+	p, err := newPublisher(info)
+	if err != nil {
+		return err
+	}
+	if info.APISocket != "" {
+		if p.listener, err = serveUnix(info.APISocket); err != nil {
+			return err
+		}
+	}
+	defaultMux.Lock()
+	old := defaultPublisher
+	defaultPublisher = p
+	defaultMux.Unlock()
+	if old != nil && old.listener != nil {
+		old.listener.Close()
+	}
+	return p.publish(info.ServicePubInfo)
+}
+
+func getPublisher() *publisher {
+	defaultMux.RLock()
+	defer defaultMux.RUnlock()
+	return defaultPublisher
+}
+
+func newPublisher(info v2.ServiceRegistryInfo) (*publisher, error) {
+	p := &publisher{
+		app:            info.ServiceAppInfo,
+		index:          make(map[string]int),
+		healthy:        true,
+		unhealthyHosts: make(map[string]bool),
+	}
+	switch info.UnhealthyAction {
+	case "", v2.RegistryUnhealthyDeregister:
+	case v2.RegistryUnhealthyMarkUnhealthy:
+		p.markUnhealthy = true
+	default:
+		return nil, fmt.Errorf("unknown registry unhealthy action: %s", info.UnhealthyAction)
+	}
+	for _, name := range info.Registries {
+		r := GetRegistry(name)
+		if r == nil {
+			return nil, fmt.Errorf("%v: %s", ErrUnknownRegistry, name)
+		}
+		p.registries = append(p.registries, &namedRegistry{
+			name:     name,
+			registry: r,
+			stats:    newRegistryStats(name),
+		})
+	}
+	return p, nil
+}
+
+// publish adds or updates the services, they are registered if the application is healthy
+func (p *publisher) publish(services []v2.PublishInfo) error {
+	if len(services) == 0 {
+		return nil
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for _, s := range services {
+		if i, ok := p.index[s.Pub.ServiceName]; ok {
+			p.services[i] = s
+		} else {
+			p.index[s.Pub.ServiceName] = len(p.services)
+			p.services = append(p.services, s)
+		}
+	}
+	if p.stopped {
+		return ErrStopped
+	}
+	if p.healthy {
+		return p.registerLocked(services)
+	}
+	if !p.markUnhealthy {
+		// registered after the application recovers
+		return nil
+	}
+	err := p.registerLocked(services)
+	p.markHealthyLocked(services, false)
+	return err
+}
+
+// unpublish removes the services, and deregisters them
+func (p *publisher) unpublish(names []string) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	var removed []v2.PublishInfo
+	for _, name := range names {
+		i, ok := p.index[name]
+		if !ok {
+			continue
+		}
+		removed = append(removed, p.services[i])
+		p.services = append(p.services[:i], p.services[i+1:]...)
+		p.reindexLocked()
+	}
+	// the services are deregistered already if the application is unhealthy or stopped
+	if len(removed) == 0 || p.stopped || (!p.healthy && !p.markUnhealthy) {
+		return nil
+	}
+	return p.deregisterLocked(removed)
+}
+
+func (p *publisher) reindexLocked() {
+	p.index = make(map[string]int, len(p.services))
+	for i, s := range p.services {
+		p.index[s.Pub.ServiceName] = i
+	}
+}
+
+// published returns a copy of the published services
+func (p *publisher) published() []v2.PublishInfo {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	services := make([]v2.PublishInfo, len(p.services))
+	copy(services, p.services)
+	return services
+}
+
+// preStop deregisters all of the services before the application or mosn stops,
+// the services are not registered again by the health recovery
+func (p *publisher) preStop() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.stopped {
+		return nil
+	}
+	p.stopped = true
+	if len(p.services) == 0 || (!p.healthy && !p.markUnhealthy) {
+		return nil
+	}
+	log.DefaultLogger.Infof("[registry] pre-stop deregisters %d services", len(p.services))
+	return p.deregisterLocked(p.services)
+}
+
+func (p *publisher) registerLocked(services []v2.PublishInfo) error {
+	var lastErr error
+	for _, r := range p.registries {
+		if err := r.register(p.app, services); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (p *publisher) deregisterLocked(services []v2.PublishInfo) error {
+	var lastErr error
+	for _, r := range p.registries {
+		if err := r.deregister(p.app, services); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (r *namedRegistry) register(app v2.ApplicationInfo, services []v2.PublishInfo) error {
+	r.stats.register.Inc(1)
+	err := r.registry.Register(app, services)
+	if err != nil {
+		r.stats.registerFailure.Inc(1)
+		log.DefaultLogger.Errorf("[registry] register %d services into %s failed: %v", len(services), r.name, err)
+	}
+	return err
+}
+
+func (r *namedRegistry) deregister(app v2.ApplicationInfo, services []v2.PublishInfo) error {
+	r.stats.deregister.Inc(1)
+	err := r.registry.Deregister(app, services)
+	if err != nil {
+		r.stats.deregisterFailure.Inc(1)
+		log.DefaultLogger.Errorf("[registry] deregister %d services from %s failed: %v", len(services), r.name, err)
+	}
+	return err
+}
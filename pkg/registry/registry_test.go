@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registry
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+type fakeRegistry struct {
+	mux        sync.Mutex
+	registered map[string]bool
+	healthy    map[string]bool
+	err        error
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		registered: make(map[string]bool),
+		healthy:    make(map[string]bool),
+	}
+}
+
+func (r *fakeRegistry) Register(app v2.ApplicationInfo, services []v2.PublishInfo) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	for _, s := range services {
+		r.registered[s.Pub.ServiceName] = true
+		r.healthy[s.Pub.ServiceName] = true
+	}
+	return nil
+}
+
+func (r *fakeRegistry) Deregister(app v2.ApplicationInfo, services []v2.PublishInfo) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, s := range services {
+		delete(r.registered, s.Pub.ServiceName)
+		delete(r.healthy, s.Pub.ServiceName)
+	}
+	return nil
+}
+
+func (r *fakeRegistry) isRegistered(name string) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.registered[name]
+}
+
+func (r *fakeRegistry) isHealthy(name string) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.healthy[name]
+}
+
+type fakeMarkerRegistry struct {
+	*fakeRegistry
+}
+
+func (r *fakeMarkerRegistry) MarkHealthy(app v2.ApplicationInfo, services []v2.PublishInfo, healthy bool) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, s := range services {
+		r.healthy[s.Pub.ServiceName] = healthy
+	}
+	return nil
+}
+
+type fakeHost struct {
+	types.Host
+	addr string
+}
+
+func (h *fakeHost) AddressString() string {
+	return h.addr
+}
+
+func (h *fakeHost) ClusterInfo() types.ClusterInfo {
+	return nil
+}
+
+func pubInfo(name string) v2.PublishInfo {
+	return v2.PublishInfo{Pub: v2.PublishContent{ServiceName: name, PubData: "12200"}}
+}
+
+func TestInitRegisters(t *testing.T) {
+	r := newFakeRegistry()
+	RegisterRegistry("test_init", r)
+	err := Init(v2.ServiceRegistryInfo{
+		ServiceAppInfo: v2.ApplicationInfo{AppName: "app"},
+		ServicePubInfo: []v2.PublishInfo{pubInfo("com.example.Foo"), pubInfo("com.example.Bar")},
+		Registries:     []string{"test_init"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.isRegistered("com.example.Foo") || !r.isRegistered("com.example.Bar") {
+		t.Fatalf("expected services registered: %v", r.registered)
+	}
+	if err := Init(v2.ServiceRegistryInfo{Registries: []string{"unknown"}}); err == nil {
+		t.Fatal("expected unknown registry error")
+	}
+	if err := Init(v2.ServiceRegistryInfo{Registries: []string{"test_init"}, UnhealthyAction: "drop"}); err == nil {
+		t.Fatal("expected unknown action error")
+	}
+}
+
+func TestRegisterFailure(t *testing.T) {
+	r := newFakeRegistry()
+	r.err = errors.New("registry unavailable")
+	RegisterRegistry("test_failure", r)
+	p, err := newPublisher(v2.ServiceRegistryInfo{Registries: []string{"test_failure"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.publish([]v2.PublishInfo{pubInfo("com.example.Foo")}); err != r.err {
+		t.Fatalf("expected register error, got %v", err)
+	}
+	if p.registries[0].stats.registerFailure.Count() != 1 {
+		t.Fatal("expected register failure counted")
+	}
+}
+
+func TestHealthDeregister(t *testing.T) {
+	r := newFakeRegistry()
+	RegisterRegistry("test_deregister", r)
+	if err := Init(v2.ServiceRegistryInfo{
+		ServicePubInfo: []v2.PublishInfo{pubInfo("com.example.Foo")},
+		Registries:     []string{"test_deregister"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h1 := &fakeHost{addr: "127.0.0.1:12200"}
+	h2 := &fakeHost{addr: "127.0.0.1:12201"}
+	// not changed state is ignored
+	onHealthCheck(h1, false, false)
+	if !r.isRegistered("com.example.Foo") {
+		t.Fatal("expected the service registered")
+	}
+	onHealthCheck(h1, true, false)
+	onHealthCheck(h2, true, false)
+	if r.isRegistered("com.example.Foo") {
+		t.Fatal("expected the service deregistered")
+	}
+	// published when unhealthy is registered after recovered
+	getPublisher().publish([]v2.PublishInfo{pubInfo("com.example.Bar")})
+	if r.isRegistered("com.example.Bar") {
+		t.Fatal("expected the service not registered when unhealthy")
+	}
+	onHealthCheck(h1, true, true)
+	if r.isRegistered("com.example.Foo") {
+		t.Fatal("expected the service deregistered until all hosts are healthy")
+	}
+	onHealthCheck(h2, true, true)
+	if !r.isRegistered("com.example.Foo") || !r.isRegistered("com.example.Bar") {
+		t.Fatalf("expected the services registered again: %v", r.registered)
+	}
+}
+
+func TestHealthMarkUnhealthy(t *testing.T) {
+	r := &fakeMarkerRegistry{newFakeRegistry()}
+	RegisterRegistry("test_mark", r)
+	if err := Init(v2.ServiceRegistryInfo{
+		ServicePubInfo:  []v2.PublishInfo{pubInfo("com.example.Foo")},
+		Registries:      []string{"test_mark"},
+		UnhealthyAction: v2.RegistryUnhealthyMarkUnhealthy,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := &fakeHost{addr: "127.0.0.1:12200"}
+	onHealthCheck(h, true, false)
+	if !r.isRegistered("com.example.Foo") || r.isHealthy("com.example.Foo") {
+		t.Fatal("expected the service marked unhealthy")
+	}
+	onHealthCheck(h, true, true)
+	if !r.isHealthy("com.example.Foo") {
+		t.Fatal("expected the service marked healthy")
+	}
+}
@@ -18,6 +18,8 @@
 package router
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"strings"
 	"sync"
@@ -32,6 +34,7 @@ import (
 )
 
 type RouteRuleImplBase struct {
+	name string
 	// match
 	vHost                 *VirtualHostImpl
 	routerMatch           v2.RouterMatch
@@ -57,10 +60,21 @@ type RouteRuleImplBase struct {
 	totalClusterWeight uint32
 	lock               sync.Mutex
 	randInstance       *rand.Rand
+	// concurrency limit, nil if not configured
+	concurrencyLimiter types.ConcurrencyLimiter
+	// internal redirect policy, nil if not configured
+	internalRedirectPolicy types.InternalRedirectPolicy
+	// failover policy, nil if not configured
+	failoverPolicy types.FailoverPolicy
+	// static files, nil if not configured
+	staticFiles *staticFiles
+	// stream filter overrides keyed by the filter type, a nil factory disables the filter
+	filterOverrides map[string]api.StreamFilterChainFactory
 }
 
 func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleImplBase, error) {
 	base := &RouteRuleImplBase{
+		name:                  route.Name,
 		vHost:                 vHost,
 		routerMatch:           route.Match,
 		configHeaders:         getRouterHeaders(route.Match.Headers),
@@ -86,25 +100,98 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (*RouteRuleI
 	// add policy
 	if route.Route.RetryPolicy != nil {
 		base.policy.retryPolicy = &retryPolicyImpl{
-			retryOn:      route.Route.RetryPolicy.RetryOn,
-			retryTimeout: route.Route.RetryPolicy.RetryTimeout,
-			numRetries:   route.Route.RetryPolicy.NumRetries,
+			retryOn:               route.Route.RetryPolicy.RetryOn,
+			retryTimeout:          route.Route.RetryPolicy.RetryTimeout,
+			numRetries:            route.Route.RetryPolicy.NumRetries,
+			retryAfterMaxInterval: route.Route.RetryPolicy.RetryAfterMaxInterval,
 		}
 	}
+	// add concurrency limit, the stats are named by the route name or the cluster name.
+	// the limiter of a named route is kept across the reloads
+	if route.Route.ConcurrencyLimit != nil {
+		name := route.Name
+		if name == "" {
+			name = route.Route.ClusterName
+		}
+		base.concurrencyLimiter = newConcurrencyLimiter(route.Name, name, route.Route.ConcurrencyLimit)
+	}
+	if route.Route.InternalRedirectPolicy != nil {
+		policy, err := newInternalRedirectPolicy(route.Route.InternalRedirectPolicy)
+		if err != nil {
+			return nil, err
+		}
+		base.internalRedirectPolicy = policy
+	}
+	if route.Route.FailoverPolicy != nil {
+		policy, err := newFailoverPolicy(route.Route.FailoverPolicy)
+		if err != nil {
+			return nil, err
+		}
+		base.failoverPolicy = policy
+	}
 	// add direct repsonse rule
 	if route.DirectResponse != nil {
-		base.directResponseRule = &directResponseImpl{
-			status: route.DirectResponse.StatusCode,
-			body:   route.DirectResponse.Body,
+		rule, err := newDirectResponse(route.DirectResponse)
+		if err != nil {
+			return nil, err
+		}
+		base.directResponseRule = rule
+	}
+	if route.StaticFiles != nil {
+		files, err := newStaticFiles(route.StaticFiles)
+		if err != nil {
+			return nil, err
+		}
+		base.staticFiles = files
+	}
+	if len(route.FilterOverrides) > 0 {
+		overrides, err := newStreamFilterOverrides(route.FilterOverrides)
+		if err != nil {
+			return nil, err
 		}
+		base.filterOverrides = overrides
 	}
 	return base, nil
 }
 
+// newStreamFilterOverrides creates the factories of the overridden filters,
+// the filters that are neither disabled nor configured are not overridden
+func newStreamFilterOverrides(cfgs map[string]v2.StreamFilterOverride) (map[string]api.StreamFilterChainFactory, error) {
+	overrides := make(map[string]api.StreamFilterChainFactory, len(cfgs))
+	for typ, cfg := range cfgs {
+		if cfg.Disabled {
+			overrides[typ] = nil
+			continue
+		}
+		if len(cfg.Config) == 0 {
+			continue
+		}
+		factory, err := api.CreateStreamFilterChainFactory(typ, cfg.Config)
+		if err != nil {
+			return nil, fmt.Errorf("stream filter override %s: %v", typ, err)
+		}
+		overrides[typ] = factory
+	}
+	return overrides, nil
+}
+
 func (rri *RouteRuleImplBase) DirectResponseRule() api.DirectResponseRule {
 	return rri.directResponseRule
 }
 
+// StreamFilterOverrides returns the stream filters overridden on the route
+func (rri *RouteRuleImplBase) StreamFilterOverrides() map[string]api.StreamFilterChainFactory {
+	return rri.filterOverrides
+}
+
+// StaticFiles returns nil if the route does not serve the static files
+func (rri *RouteRuleImplBase) StaticFiles() types.StaticFiles {
+	if rri.staticFiles == nil {
+		return nil
+	}
+	return rri.staticFiles
+}
+
 // types.RouteRule
 // Select Cluster for Routing
 // if weighted cluster is nil, return clusterName directly, else
@@ -128,6 +215,26 @@ func (rri *RouteRuleImplBase) ClusterName() string {
 	return rri.defaultCluster.clusterName
 }
 
+// RouteName returns the configured route name
+func (rri *RouteRuleImplBase) RouteName() string {
+	return rri.name
+}
+
+// ConcurrencyLimiter returns the route's concurrency limiter
+func (rri *RouteRuleImplBase) ConcurrencyLimiter() types.ConcurrencyLimiter {
+	return rri.concurrencyLimiter
+}
+
+// InternalRedirectPolicy returns the route's internal redirect policy
+func (rri *RouteRuleImplBase) InternalRedirectPolicy() types.InternalRedirectPolicy {
+	return rri.internalRedirectPolicy
+}
+
+// FailoverPolicy returns the route's failover policy
+func (rri *RouteRuleImplBase) FailoverPolicy() types.FailoverPolicy {
+	return rri.failoverPolicy
+}
+
 func (rri *RouteRuleImplBase) UpstreamProtocol() string {
 	return rri.upstreamProtocol
 }
@@ -179,6 +286,11 @@ func (rri *RouteRuleImplBase) matchRoute(headers api.HeaderMap, randomValue uint
 	return true
 }
 
+// unconstrained returns true if the route matches the requests by the path only
+func (rri *RouteRuleImplBase) unconstrained() bool {
+	return len(rri.configHeaders) == 0 && len(rri.configQueryParameters) == 0
+}
+
 func (rri *RouteRuleImplBase) finalizePathHeader(headers api.HeaderMap, matchedPath string) {
 	if len(rri.prefixRewrite) < 1 {
 		return
@@ -193,20 +305,30 @@ func (rri *RouteRuleImplBase) finalizePathHeader(headers api.HeaderMap, matchedP
 }
 
 func (rri *RouteRuleImplBase) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.finalizeRequestHeaders(headers, requestInfo)
+	rri.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
 }
 
-func (rri *RouteRuleImplBase) finalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.requestHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.requestHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(headers, requestInfo)
+// FinalizeRequestHeadersWithContext evaluates the variables in the headers with the stream context
+func (rri *RouteRuleImplBase) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.finalizeRequestHeaders(ctx, headers, requestInfo)
+}
+
+func (rri *RouteRuleImplBase) finalizeRequestHeaders(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.globalRouteConfig.requestHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
 	if len(rri.hostRewrite) > 0 {
 		headers.Set(protocol.IstioHeaderHostKey, rri.hostRewrite)
 	}
 }
 
 func (rri *RouteRuleImplBase) FinalizeResponseHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rri.responseHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.responseHeadersParser.evaluateHeaders(headers, requestInfo)
-	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(headers, requestInfo)
+	rri.FinalizeResponseHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+// FinalizeResponseHeadersWithContext evaluates the variables in the headers with the stream context
+func (rri *RouteRuleImplBase) FinalizeResponseHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rri.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
+	rri.vHost.globalRouteConfig.responseHeadersParser.evaluateHeaders(ctx, headers, requestInfo)
 }
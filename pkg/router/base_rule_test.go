@@ -18,6 +18,8 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -481,3 +483,51 @@ func Test_RouteRuleImplBase_FinalizeResponseHeaders(t *testing.T) {
 		})
 	}
 }
+
+type overrideFilterFactory struct {
+	config map[string]interface{}
+}
+
+func (f *overrideFilterFactory) CreateFilterChain(context context.Context, callbacks api.StreamFilterChainFactoryCallbacks) {
+}
+
+func TestRouteRuleStreamFilterOverrides(t *testing.T) {
+	api.RegisterStream("test_override_filter", func(cfg map[string]interface{}) (api.StreamFilterChainFactory, error) {
+		if _, ok := cfg["invalid"]; ok {
+			return nil, errors.New("invalid config")
+		}
+		return &overrideFilterFactory{config: cfg}, nil
+	})
+	route := &v2.Router{
+		RouterConfig: v2.RouterConfig{
+			FilterOverrides: map[string]v2.StreamFilterOverride{
+				"test_override_filter": {Config: map[string]interface{}{"rate": 10}},
+				"disabled_filter":      {Disabled: true},
+				"unchanged_filter":     {},
+			},
+		},
+	}
+	rule, err := NewRouteRuleImplBase(nil, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides := rule.StreamFilterOverrides()
+	if len(overrides) != 2 {
+		t.Fatalf("expected 2 overrides, got %v", overrides)
+	}
+	if f, ok := overrides["test_override_filter"].(*overrideFilterFactory); !ok || f.config["rate"] != 10 {
+		t.Fatalf("unexpected override factory: %v", overrides["test_override_filter"])
+	}
+	if f, ok := overrides["disabled_filter"]; !ok || f != nil {
+		t.Fatal("the disabled filter should be overridden by a nil factory")
+	}
+
+	route.FilterOverrides = map[string]v2.StreamFilterOverride{"test_override_filter": {Config: map[string]interface{}{"invalid": true}}}
+	if _, err := NewRouteRuleImplBase(nil, route); err == nil {
+		t.Fatal("expected an error of the invalid override config")
+	}
+	route.FilterOverrides = map[string]v2.StreamFilterOverride{"unknown_filter": {Config: map[string]interface{}{"a": 1}}}
+	if _, err := NewRouteRuleImplBase(nil, route); err == nil {
+		t.Fatal("expected an error of the unknown filter type")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultQueueTimeout = time.Second
+
+// concurrencyLimiters keeps the limiters of the named routes, so the in-flight and the queued requests
+// are kept when the routes are reloaded.
+var (
+	concurrencyLimitersMux sync.Mutex
+	concurrencyLimiters    = make(map[string]*concurrencyLimiter)
+)
+
+// concurrencyLimiter limits the in-flight requests of a route.
+// The waiting requests get the released slots in FIFO order.
+type concurrencyLimiter struct {
+	mux      sync.Mutex
+	active   int64
+	max      int64
+	maxQueue int
+	timeout  time.Duration
+	waiters  *list.List // *concurrencyWaiter
+	stats    types.Metrics
+}
+
+type concurrencyWaiter struct {
+	notify func(acquired bool)
+	start  time.Time
+	timer  *time.Timer
+}
+
+// newConcurrencyLimiter returns the limiter of the route, the limiter of a named route is reused and updated
+// by the config. the stats are named by the name, which is the route name or the cluster name.
+func newConcurrencyLimiter(routeName string, name string, cfg *v2.ConcurrencyLimit) types.ConcurrencyLimiter {
+	if cfg == nil || cfg.MaxConcurrentRequests == 0 {
+		return nil
+	}
+	if routeName == "" {
+		l := &concurrencyLimiter{
+			waiters: list.New(),
+			stats:   metrics.NewRouteConcurrencyStats(name),
+		}
+		l.update(cfg)
+		return l
+	}
+	concurrencyLimitersMux.Lock()
+	defer concurrencyLimitersMux.Unlock()
+	l, ok := concurrencyLimiters[routeName]
+	if !ok {
+		l = &concurrencyLimiter{
+			waiters: list.New(),
+			stats:   metrics.NewRouteConcurrencyStats(name),
+		}
+		concurrencyLimiters[routeName] = l
+	}
+	l.update(cfg)
+	return l
+}
+
+// update applies the config, the queued requests get the new slots
+func (l *concurrencyLimiter) update(cfg *v2.ConcurrencyLimit) {
+	timeout := cfg.QueueTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultQueueTimeout
+	}
+	l.mux.Lock()
+	l.max = int64(cfg.MaxConcurrentRequests)
+	l.maxQueue = int(cfg.MaxQueueSize)
+	l.timeout = timeout
+	var woken []*concurrencyWaiter
+	for l.active < l.max && l.waiters.Len() > 0 {
+		woken = append(woken, l.popWaiter())
+		l.active++
+	}
+	l.updateGauges()
+	l.mux.Unlock()
+	for _, w := range woken {
+		l.wake(w)
+	}
+}
+
+func (l *concurrencyLimiter) Acquire(notify func(acquired bool)) (bool, func()) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if l.active < l.max {
+		l.active++
+		l.updateGauges()
+		return true, nil
+	}
+	if l.waiters.Len() >= l.maxQueue {
+		l.stats.Counter(metrics.RouteConcurrencyQueueOverflow).Inc(1)
+		return false, nil
+	}
+	w := &concurrencyWaiter{
+		notify: notify,
+		start:  time.Now(),
+	}
+	e := l.waiters.PushBack(w)
+	w.timer = time.AfterFunc(l.timeout, func() {
+		if l.remove(e) {
+			l.stats.Counter(metrics.RouteConcurrencyQueueTimeout).Inc(1)
+			w.notify(false)
+		}
+	})
+	l.updateGauges()
+	return false, func() {
+		if l.remove(e) {
+			w.timer.Stop()
+		}
+	}
+}
+
+func (l *concurrencyLimiter) Release() {
+	l.mux.Lock()
+	if l.active > l.max || l.waiters.Len() == 0 {
+		// the slots are reduced by the config update
+		l.active--
+		l.updateGauges()
+		l.mux.Unlock()
+		return
+	}
+	// the slot is handed over to the first waiter
+	w := l.popWaiter()
+	l.updateGauges()
+	l.mux.Unlock()
+	l.wake(w)
+}
+
+// remove removes the waiter from the queue, returns false if the waiter is not in the queue
+func (l *concurrencyLimiter) remove(e *list.Element) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	// the removed element has no list
+	if l.waiters.Remove(e) == nil {
+		return false
+	}
+	l.updateGauges()
+	return true
+}
+
+// popWaiter must be called with the lock held
+func (l *concurrencyLimiter) popWaiter() *concurrencyWaiter {
+	return l.waiters.Remove(l.waiters.Front()).(*concurrencyWaiter)
+}
+
+// wake notifies the waiter that got a slot
+func (l *concurrencyLimiter) wake(w *concurrencyWaiter) {
+	w.timer.Stop()
+	l.stats.Histogram(metrics.RouteConcurrencyQueueWait).Update(time.Since(w.start).Nanoseconds())
+	w.notify(true)
+}
+
+// updateGauges must be called with the lock held
+func (l *concurrencyLimiter) updateGauges() {
+	l.stats.Gauge(metrics.RouteConcurrencyActive).Update(l.active)
+	l.stats.Gauge(metrics.RouteConcurrencyQueueDepth).Update(int64(l.waiters.Len()))
+}
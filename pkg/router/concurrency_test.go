@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	if newConcurrencyLimiter("", "test_no_limit", &v2.ConcurrencyLimit{}) != nil {
+		t.Fatal("zero max concurrent requests means no limit")
+	}
+	limiter := newConcurrencyLimiter("", "test_concurrency", &v2.ConcurrencyLimit{
+		MaxConcurrentRequests: 1,
+		MaxQueueSize:          1,
+		QueueTimeout:          api.DurationConfig{Duration: 50 * time.Millisecond},
+	})
+	if ok, _ := limiter.Acquire(nil); !ok {
+		t.Fatal("first request should get the slot")
+	}
+	// the second request is queued without blocking, and gets the released slot
+	notified := make(chan bool, 1)
+	if ok, cancel := limiter.Acquire(func(acquired bool) {
+		notified <- acquired
+	}); ok || cancel == nil {
+		t.Fatal("second request should be queued")
+	}
+	// the queue is full
+	if ok, cancel := limiter.Acquire(nil); ok || cancel != nil {
+		t.Fatal("request should be rejected when the queue is full")
+	}
+	limiter.Release()
+	if !<-notified {
+		t.Fatal("queued request should get the released slot")
+	}
+	// the queued request is timeout
+	if ok, _ := limiter.Acquire(func(acquired bool) {
+		notified <- acquired
+	}); ok {
+		t.Fatal("request should be queued")
+	}
+	if <-notified {
+		t.Fatal("request should be timeout in the queue")
+	}
+	// the cancelled request is not notified
+	_, cancel := limiter.Acquire(func(acquired bool) {
+		notified <- acquired
+	})
+	cancel()
+	limiter.Release()
+	select {
+	case <-notified:
+		t.Fatal("cancelled request should not be notified")
+	case <-time.After(100 * time.Millisecond):
+	}
+	stats := metrics.NewRouteConcurrencyStats("test_concurrency")
+	if stats.Counter(metrics.RouteConcurrencyQueueOverflow).Count() != 1 ||
+		stats.Counter(metrics.RouteConcurrencyQueueTimeout).Count() != 1 ||
+		stats.Histogram(metrics.RouteConcurrencyQueueWait).Count() != 1 ||
+		stats.Gauge(metrics.RouteConcurrencyQueueDepth).Value() != 0 ||
+		stats.Gauge(metrics.RouteConcurrencyActive).Value() != 0 {
+		t.Fatal("unexpected route concurrency stats")
+	}
+}
+
+func TestConcurrencyLimiterReload(t *testing.T) {
+	cfg := &v2.ConcurrencyLimit{
+		MaxConcurrentRequests: 1,
+		MaxQueueSize:          1,
+		QueueTimeout:          api.DurationConfig{Duration: time.Second},
+	}
+	limiter := newConcurrencyLimiter("test_reload_route", "test_reload", cfg)
+	if ok, _ := limiter.Acquire(nil); !ok {
+		t.Fatal("first request should get the slot")
+	}
+	notified := make(chan bool, 1)
+	limiter.Acquire(func(acquired bool) {
+		notified <- acquired
+	})
+	// the limiter of the route is kept by the reload, the queued request gets the new slot
+	cfg.MaxConcurrentRequests = 2
+	if reloaded := newConcurrencyLimiter("test_reload_route", "test_reload", cfg); reloaded != limiter {
+		t.Fatal("the limiter of the named route should be kept across the reloads")
+	}
+	select {
+	case acquired := <-notified:
+		if !acquired {
+			t.Fatal("queued request should get the new slot")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("queued request is not notified")
+	}
+	ok, cancel := limiter.Acquire(func(bool) {})
+	if ok {
+		t.Fatal("the slots should be kept across the reloads")
+	}
+	cancel()
+	// the unnamed routes are not shared
+	if newConcurrencyLimiter("", "test_reload", cfg) == newConcurrencyLimiter("", "test_reload", cfg) {
+		t.Fatal("the limiter of the unnamed route should not be shared")
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -44,19 +45,25 @@ func (cu *configUtility) MatchHeaders(requestHeaders api.HeaderMap, configHeader
 		cfgValue := cfgHeaderData.Value
 		// if a condition is not matched, return false
 		// all condition matched, return true
-		value, ok := requestHeaders.Get(cfgName)
-		if !ok {
+		// a condition is matched if any value of a multi-value header is matched
+		values := protocol.HeaderValues(requestHeaders, cfgName)
+		if len(values) == 0 {
 			return false
 		}
-		if cfgHeaderData.IsRegex {
-			if !cfgHeaderData.RegexPattern.MatchString(value) {
-				return false
+		matched := false
+		for _, value := range values {
+			if cfgHeaderData.IsRegex {
+				matched = cfgHeaderData.RegexPattern.MatchString(value)
+			} else {
+				matched = cfgValue == value
 			}
-		} else {
-			if cfgValue != value {
-				return false
+			if matched {
+				break
 			}
 		}
+		if !matched {
+			return false
+		}
 	}
 	return true
 }
@@ -24,6 +24,7 @@ import (
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
 )
 
 func TestNewMetadataMatchCriteriaImpl(t *testing.T) {
@@ -215,3 +216,22 @@ func TestMetadataMatchCriteriaImplSort(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchHeadersMultiValue(t *testing.T) {
+	configHeaders := GetRouterHeaders([]v2.HeaderMatcher{
+		{Name: "x-tag", Value: "canary"},
+	})
+	headers := protocol.NewOrderedHeader(2)
+	headers.Add("x-tag", "stable")
+	headers.Add("x-tag", "canary")
+	if !ConfigUtilityInst.MatchHeaders(headers, configHeaders) {
+		t.Error("any value of a multi-value header should be matched")
+	}
+	headers.Set("x-tag", "stable")
+	if ConfigUtilityInst.MatchHeaders(headers, configHeaders) {
+		t.Error("header should not be matched")
+	}
+	if !ConfigUtilityInst.MatchHeaders(protocol.CommonHeader{"x-tag": "canary"}, configHeaders) {
+		t.Error("common header should be matched")
+	}
+}
@@ -17,9 +17,47 @@
 
 package router
 
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+// maxDirectResponseFileSize limits the file of the direct response, the file is kept in memory
+const maxDirectResponseFileSize = 4 << 20
+
 type directResponseImpl struct {
-	status int
-	body   string
+	status      int
+	body        string
+	contentType string
+}
+
+func newDirectResponse(config *v2.DirectResponseAction) (*directResponseImpl, error) {
+	rule := &directResponseImpl{
+		status:      config.StatusCode,
+		body:        config.Body,
+		contentType: config.ContentType,
+	}
+	if config.File != "" {
+		if config.Body != "" {
+			return nil, fmt.Errorf("direct response: both body and file are configured")
+		}
+		content, err := readFileLimited(config.File, maxDirectResponseFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("direct response file %s: %v", config.File, err)
+		}
+		rule.body = string(content)
+		if rule.contentType == "" {
+			rule.contentType = mime.TypeByExtension(filepath.Ext(config.File))
+		}
+	}
+	return rule, nil
 }
 
 func (rule *directResponseImpl) StatusCode() int {
@@ -29,3 +67,30 @@ func (rule *directResponseImpl) StatusCode() int {
 func (rule *directResponseImpl) Body() string {
 	return rule.body
 }
+
+func (rule *directResponseImpl) ContentType() string {
+	return rule.contentType
+}
+
+// errFileTooLarge is returned if the file is larger than the limit
+var errFileTooLarge = errors.New("file too large")
+
+// readFileLimited reads the regular file, returns errFileTooLarge if the file is larger than the limit
+func readFileLimited(name string, limit int64) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", name)
+	}
+	if info.Size() > limit {
+		return nil, errFileTooLarge
+	}
+	return ioutil.ReadAll(io.LimitReader(f, limit))
+}
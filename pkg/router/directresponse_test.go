@@ -18,11 +18,14 @@
 package router
 
 import (
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
 
 	"github.com/json-iterator/go"
 	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -70,3 +73,56 @@ func TestDirectResponse(t *testing.T) {
 		t.Error("expected a nil resposne rule, but not", noDirectRule.DirectResponseRule())
 	}
 }
+
+func TestDirectResponseFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "robots*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("User-agent: *\nDisallow: /")
+	f.Close()
+
+	rule, err := NewRouteRuleImplBase(nil, &v2.Router{
+		RouterConfig: v2.RouterConfig{
+			Match: v2.RouterMatch{Path: "/robots.txt"},
+			DirectResponse: &v2.DirectResponseAction{
+				StatusCode: 200,
+				File:       f.Name(),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr := rule.DirectResponseRule()
+	if dr.StatusCode() != 200 || dr.Body() != "User-agent: *\nDisallow: /" {
+		t.Errorf("unexpected direct response: %d %s", dr.StatusCode(), dr.Body())
+	}
+	if ct := dr.(types.ContentTypeDirectResponseRule).ContentType(); ct != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	// the configured content type is used
+	rule, _ = NewRouteRuleImplBase(nil, &v2.Router{
+		RouterConfig: v2.RouterConfig{
+			DirectResponse: &v2.DirectResponseAction{
+				StatusCode:  503,
+				Body:        "<html>maintenance</html>",
+				ContentType: "text/html",
+			},
+		},
+	})
+	if ct := rule.DirectResponseRule().(types.ContentTypeDirectResponseRule).ContentType(); ct != "text/html" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	// invalid files
+	for i, cfg := range []*v2.DirectResponseAction{
+		{StatusCode: 200, File: f.Name() + ".missing"},
+		{StatusCode: 200, File: f.Name(), Body: "body"},
+		{StatusCode: 200, File: os.TempDir()},
+	} {
+		if _, err := NewRouteRuleImplBase(nil, &v2.Router{RouterConfig: v2.RouterConfig{DirectResponse: cfg}}); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
@@ -79,7 +79,10 @@ func (h *simpleHandler) IsAvailable(ctx context.Context, manager types.ClusterMa
 		return nil, types.HandlerNotAvailable
 	}
 	clusterName := h.Route().RouteRule().ClusterName()
-	snapshot := manager.GetClusterSnapshot(context.Background(), clusterName)
+	snapshot, ok := manager.GetClusterSnapshot(context.Background(), clusterName)
+	if !ok {
+		return nil, types.HandlerAvailable
+	}
 	return snapshot, types.HandlerAvailable
 }
 
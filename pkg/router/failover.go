@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"errors"
+	"fmt"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+type failoverPolicy struct {
+	clusterName string
+	codes       map[int]bool
+	onReset     bool
+}
+
+func newFailoverPolicy(cfg *v2.FailoverPolicy) (types.FailoverPolicy, error) {
+	if cfg.ClusterName == "" {
+		return nil, errors.New("failover policy without cluster name")
+	}
+	p := &failoverPolicy{
+		clusterName: cfg.ClusterName,
+		codes:       make(map[int]bool, len(cfg.ResponseCodes)),
+		onReset:     cfg.OnReset,
+	}
+	for _, code := range cfg.ResponseCodes {
+		if code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid failover response code: %d", code)
+		}
+		p.codes[code] = true
+	}
+	return p, nil
+}
+
+func (p *failoverPolicy) ClusterName() string {
+	return p.clusterName
+}
+
+func (p *failoverPolicy) ShouldFailover(code int) bool {
+	if code == 0 {
+		return p.onReset
+	}
+	return p.codes[code]
+}
@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func TestFailoverPolicy(t *testing.T) {
+	p, err := newFailoverPolicy(&v2.FailoverPolicy{
+		ClusterName:   "backup",
+		ResponseCodes: []int{502, 503},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ClusterName() != "backup" {
+		t.Fatalf("unexpected cluster name: %s", p.ClusterName())
+	}
+	for _, tc := range []struct {
+		code     int
+		expected bool
+	}{
+		{502, true},
+		{503, true},
+		{500, false},
+		{200, false},
+		{0, false},
+	} {
+		if p.ShouldFailover(tc.code) != tc.expected {
+			t.Errorf("code %d expected %v", tc.code, tc.expected)
+		}
+	}
+
+	p, err = newFailoverPolicy(&v2.FailoverPolicy{ClusterName: "backup", OnReset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.ShouldFailover(0) || p.ShouldFailover(503) {
+		t.Fatal("the policy fails over the reset only")
+	}
+
+	for i, cfg := range []*v2.FailoverPolicy{
+		{},
+		{ClusterName: "backup", ResponseCodes: []int{1000}},
+	} {
+		if _, err := newFailoverPolicy(cfg); err == nil {
+			t.Errorf("case %d expected an error", i)
+		}
+	}
+}
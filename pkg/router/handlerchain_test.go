@@ -78,8 +78,8 @@ type mockManager struct {
 	types.ClusterManager
 }
 
-func (m *mockManager) GetClusterSnapshot(ctx context.Context, name string) types.ClusterSnapshot {
-	return nil
+func (m *mockManager) GetClusterSnapshot(ctx context.Context, name string) (types.ClusterSnapshot, bool) {
+	return nil, false
 }
 func (m *mockManager) PutClusterSnapshot(snapshot types.ClusterSnapshot) {
 }
@@ -132,7 +132,7 @@ type mockStatusHandler struct {
 
 func (h *mockStatusHandler) IsAvailable(ctx context.Context, manager types.ClusterManager) (types.ClusterSnapshot, types.HandlerStatus) {
 	clusterName := h.Route().RouteRule().ClusterName()
-	snapshot := manager.GetClusterSnapshot(context.Background(), clusterName)
+	snapshot, _ := manager.GetClusterSnapshot(context.Background(), clusterName)
 	return snapshot, h.status
 }
 func (h *mockStatusHandler) Route() api.Route {
@@ -18,17 +18,25 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"strings"
 
+	"mosn.io/api"
 	"mosn.io/mosn/pkg/log"
-	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
 )
 
+var errUnclosedHeaderVariable = errors.New("unclosed variable in header value")
+
 func getHeaderFormatter(value string, append bool) headerFormatter {
-	// TODO: variable headers would be support very soon
 	if strings.Index(value, "%") != -1 {
-		log.DefaultLogger.Warnf("variable headers not support yet, skip, value: %s", value)
-		return nil
+		f, err := newVariableHeaderFormatter(value, append)
+		if err != nil {
+			log.DefaultLogger.Warnf("invalid variable header, skip, value: %s, error: %v", value, err)
+			return nil
+		}
+		return f
 	}
 	return &plainHeaderFormatter{
 		isAppend:    append,
@@ -45,6 +53,64 @@ func (f *plainHeaderFormatter) append() bool {
 	return f.isAppend
 }
 
-func (f *plainHeaderFormatter) format(requestInfo types.RequestInfo) string {
+func (f *plainHeaderFormatter) format(ctx context.Context, requestInfo api.RequestInfo) string {
 	return f.staticValue
 }
+
+// variableHeaderPart is a static text or a variable in the header value
+type variableHeaderPart struct {
+	text     string
+	variable string
+}
+
+// variableHeaderFormatter formats the header value with the variables of the stream,
+// the variables are written as %name%, such as "%upstream_host%".
+// ValueNotFound("-") is used if the variable is not found in the stream.
+type variableHeaderFormatter struct {
+	isAppend bool
+	parts    []variableHeaderPart
+}
+
+func newVariableHeaderFormatter(value string, isAppend bool) (*variableHeaderFormatter, error) {
+	segments := strings.Split(value, "%")
+	// the segments with odd index are variables
+	if len(segments)%2 == 0 {
+		return nil, errUnclosedHeaderVariable
+	}
+	f := &variableHeaderFormatter{
+		isAppend: isAppend,
+	}
+	for i, seg := range segments {
+		if i%2 == 0 {
+			if seg != "" {
+				f.parts = append(f.parts, variableHeaderPart{text: seg})
+			}
+			continue
+		}
+		if _, err := variable.AddVariable(seg); err != nil {
+			return nil, err
+		}
+		f.parts = append(f.parts, variableHeaderPart{variable: seg})
+	}
+	return f, nil
+}
+
+func (f *variableHeaderFormatter) append() bool {
+	return f.isAppend
+}
+
+func (f *variableHeaderFormatter) format(ctx context.Context, requestInfo api.RequestInfo) string {
+	var sb strings.Builder
+	for _, part := range f.parts {
+		if part.variable == "" {
+			sb.WriteString(part.text)
+			continue
+		}
+		value, err := variable.GetVariableValue(ctx, part.variable)
+		if err != nil {
+			value = variable.ValueNotFound
+		}
+		sb.WriteString(value)
+	}
+	return sb.String()
+}
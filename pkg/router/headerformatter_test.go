@@ -18,8 +18,11 @@
 package router
 
 import (
+	"context"
 	"reflect"
 	"testing"
+
+	"mosn.io/mosn/pkg/variable"
 )
 
 func Test_getHeaderFormatter(t *testing.T) {
@@ -104,9 +107,37 @@ func Test_plainHeaderFormatter_format(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := formatter.format(nil); !reflect.DeepEqual(got, tt.want) {
+			if got := formatter.format(context.Background(), nil); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("(f *plainHeaderFormatter) format(requestInfo types.RequestInfo) = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_variableHeaderFormatter_format(t *testing.T) {
+	name := "test_header_variable"
+	if err := variable.RegisterVariable(variable.NewIndexedVariable(name, nil, nil, variable.BasicSetter, 0)); err != nil {
+		t.Fatal(err)
+	}
+	f := getHeaderFormatter("cluster=%test_header_variable%;v1", true)
+	if f == nil {
+		t.Fatal("variable header formatter should be created")
+	}
+	if !f.append() {
+		t.Error("formatter should be append")
+	}
+	ctx := variable.NewVariableContext(context.Background())
+	if got := f.format(ctx, nil); got != "cluster=-;v1" {
+		t.Errorf("unexpected value of the variable not set: %s", got)
+	}
+	variable.SetVariableValue(ctx, name, "foo")
+	if got := f.format(ctx, nil); got != "cluster=foo;v1" {
+		t.Errorf("unexpected value: %s", got)
+	}
+	// unclosed or undefined variables
+	for _, value := range []string{"%test_header_variable", "%undefined_header_variable%"} {
+		if f := getHeaderFormatter(value, false); f != nil {
+			t.Errorf("invalid variable header %s should be skipped", value)
+		}
+	}
+}
@@ -18,6 +18,7 @@
 package router
 
 import (
+	"context"
 	"regexp"
 	"strings"
 
@@ -51,7 +52,11 @@ func (prri *PathRouteRuleImpl) MatchType() api.PathMatchType {
 // types.RouteRule
 // override Base
 func (prri *PathRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	prri.finalizeRequestHeaders(headers, requestInfo)
+	prri.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+func (prri *PathRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	prri.finalizeRequestHeaders(ctx, headers, requestInfo)
 	prri.finalizePathHeader(headers, prri.path)
 }
 
@@ -95,7 +100,11 @@ func (prei *PrefixRouteRuleImpl) MatchType() api.PathMatchType {
 // types.RouteRule
 // override Base
 func (prei *PrefixRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	prei.finalizeRequestHeaders(headers, requestInfo)
+	prei.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+func (prei *PrefixRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	prei.finalizeRequestHeaders(ctx, headers, requestInfo)
 	prei.finalizePathHeader(headers, prei.prefix)
 }
 
@@ -135,7 +144,11 @@ func (rrei *RegexRouteRuleImpl) MatchType() api.PathMatchType {
 }
 
 func (rrei *RegexRouteRuleImpl) FinalizeRequestHeaders(headers api.HeaderMap, requestInfo api.RequestInfo) {
-	rrei.finalizeRequestHeaders(headers, requestInfo)
+	rrei.FinalizeRequestHeadersWithContext(context.Background(), headers, requestInfo)
+}
+
+func (rrei *RegexRouteRuleImpl) FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo) {
+	rrei.finalizeRequestHeaders(ctx, headers, requestInfo)
 	rrei.finalizePathHeader(headers, rrei.regexStr)
 }
 
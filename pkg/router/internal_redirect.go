@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+// the proxy routes a request at most 10 times, including the retries and the internal redirects,
+// so the internal redirects are limited
+const (
+	defaultMaxInternalRedirects = 1
+	maxInternalRedirects        = 5
+)
+
+// redirectResponseCodes are the status codes that can be followed
+var redirectResponseCodes = map[int]bool{
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusSeeOther:          true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+}
+
+type internalRedirectPolicy struct {
+	maxRedirects     uint32
+	codes            map[int]bool
+	allowedLocations []*regexp.Regexp
+	allowCrossScheme bool
+}
+
+func newInternalRedirectPolicy(cfg *v2.InternalRedirectPolicy) (types.InternalRedirectPolicy, error) {
+	p := &internalRedirectPolicy{
+		maxRedirects:     cfg.MaxInternalRedirects,
+		codes:            make(map[int]bool),
+		allowCrossScheme: cfg.AllowCrossSchemeRedirect,
+	}
+	if p.maxRedirects == 0 {
+		p.maxRedirects = defaultMaxInternalRedirects
+	}
+	if p.maxRedirects > maxInternalRedirects {
+		return nil, fmt.Errorf("max internal redirects %d exceeds the limit %d", p.maxRedirects, maxInternalRedirects)
+	}
+	codes := cfg.RedirectResponseCodes
+	if len(codes) == 0 {
+		codes = []int{http.StatusFound}
+	}
+	for _, code := range codes {
+		if !redirectResponseCodes[code] {
+			return nil, fmt.Errorf("unsupported internal redirect response code: %d", code)
+		}
+		p.codes[code] = true
+	}
+	for _, location := range cfg.AllowedLocations {
+		re, err := regexp.Compile(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid internal redirect location %s: %v", location, err)
+		}
+		p.allowedLocations = append(p.allowedLocations, re)
+	}
+	return p, nil
+}
+
+func (p *internalRedirectPolicy) MaxInternalRedirects() uint32 {
+	return p.maxRedirects
+}
+
+func (p *internalRedirectPolicy) ShouldRedirect(code int, location string) bool {
+	if !p.codes[code] || location == "" {
+		return false
+	}
+	if len(p.allowedLocations) == 0 {
+		return true
+	}
+	for _, re := range p.allowedLocations {
+		if re.MatchString(location) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *internalRedirectPolicy) AllowCrossSchemeRedirect() bool {
+	return p.allowCrossScheme
+}
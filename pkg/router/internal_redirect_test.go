@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func TestInternalRedirectPolicy(t *testing.T) {
+	p, err := newInternalRedirectPolicy(&v2.InternalRedirectPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MaxInternalRedirects() != 1 || p.AllowCrossSchemeRedirect() {
+		t.Fatalf("unexpected default policy: %+v", p)
+	}
+	if !p.ShouldRedirect(302, "/new") || p.ShouldRedirect(301, "/new") || p.ShouldRedirect(302, "") {
+		t.Fatal("the default policy follows 302 only")
+	}
+
+	p, err = newInternalRedirectPolicy(&v2.InternalRedirectPolicy{
+		MaxInternalRedirects:  3,
+		RedirectResponseCodes: []int{301, 307},
+		AllowedLocations:      []string{`^/api/`, `^https?://backup\.example\.com/`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		code     int
+		location string
+		expected bool
+	}{
+		{301, "/api/v2/users", true},
+		{307, "http://backup.example.com/users", true},
+		{302, "/api/v2/users", false},
+		{301, "/web/index.html", false},
+		{301, "https://www.example.com/api/", false},
+	} {
+		if p.ShouldRedirect(tc.code, tc.location) != tc.expected {
+			t.Errorf("code %d location %s expected %v", tc.code, tc.location, tc.expected)
+		}
+	}
+
+	for i, cfg := range []*v2.InternalRedirectPolicy{
+		{RedirectResponseCodes: []int{304}},
+		{AllowedLocations: []string{"("}},
+		{MaxInternalRedirects: 6},
+	} {
+		if _, err := newInternalRedirectPolicy(cfg); err == nil {
+			t.Errorf("case %d expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+// compiledMatcherMinRoutes is the least routes of a virtual host to use the compiled matcher,
+// scanning the routes is cheaper for the smaller virtual hosts
+const compiledMatcherMinRoutes = 16
+
+// regexGroupSize is the max regexes combined in a regex group
+const regexGroupSize = 32
+
+// routeMatcher indexes the routes of a virtual host by the path matchers.
+// The indexed routes are the candidates of a path only, a request still matches the candidates
+// in the config order, so the matched route is the same as scanning all the routes.
+type routeMatcher struct {
+	// exact path routes keyed by the lower cased path, the path matcher is case insensitive
+	exact map[string][]int
+	// all the exact path routes, the candidates of the non ascii paths
+	exactAll []int
+	prefixes prefixNode
+	regexes  []*regexGroup
+	// routes without a path matcher, they are always the candidates
+	others []int
+	// path and regex matchers of the routes without headers and query parameters,
+	// the later routes with the same matcher are never matched
+	unconstrained map[string]struct{}
+}
+
+// prefixNode is a byte trie node of the prefix routes
+type prefixNode struct {
+	children map[byte]*prefixNode
+	// routes whose prefix ends at the node, including the regexes anchored with the prefix
+	routes []int
+	// unconstrained is true if a route ends at the node matches all the paths with the prefix
+	unconstrained bool
+}
+
+// regexGroup combines the regexes into an alternation,
+// the routes in the group are the candidates only if the alternation matches the path
+type regexGroup struct {
+	sources []string
+	routes  []int
+	// pattern is nil if the regexes can not be combined, the routes are always the candidates
+	pattern *regexp.Regexp
+}
+
+func newRouteMatcher() *routeMatcher {
+	return &routeMatcher{
+		exact:         make(map[string][]int),
+		unconstrained: make(map[string]struct{}),
+	}
+}
+
+// add indexes the route at the index of the virtual host routes.
+// returns false if the route is shadowed by an earlier route, which means it is never matched
+func (m *routeMatcher) add(index int, route RouteBase) bool {
+	switch r := route.(type) {
+	case *PathRouteRuleImpl:
+		return m.addExact(index, r.path, r.unconstrained())
+	case *PrefixRouteRuleImpl:
+		return m.addPrefix(index, r.prefix, r.unconstrained())
+	case *RegexRouteRuleImpl:
+		return m.addRegex(index, r.regexStr, r.unconstrained())
+	}
+	m.others = append(m.others, index)
+	return true
+}
+
+func (m *routeMatcher) addExact(index int, path string, unconstrained bool) bool {
+	if !isASCII(path) {
+		// strings.EqualFold folds more than lower casing for the non ascii characters
+		m.others = append(m.others, index)
+		return true
+	}
+	key := strings.ToLower(path)
+	m.exact[key] = append(m.exact[key], index)
+	m.exactAll = append(m.exactAll, index)
+	return m.validate("path:"+key, unconstrained)
+}
+
+func (m *routeMatcher) addPrefix(index int, prefix string, unconstrained bool) bool {
+	reachable := true
+	node := m.prefixNode(prefix, func(n *prefixNode) {
+		if n.unconstrained {
+			reachable = false
+		}
+	})
+	node.routes = append(node.routes, index)
+	if unconstrained {
+		node.unconstrained = true
+	}
+	return reachable
+}
+
+// prefixNode returns the trie node of the prefix, the visit is called with the nodes on the way
+func (m *routeMatcher) prefixNode(prefix string, visit func(*prefixNode)) *prefixNode {
+	node := &m.prefixes
+	for i := 0; i < len(prefix); i++ {
+		child, ok := node.children[prefix[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[byte]*prefixNode)
+			}
+			child = &prefixNode{}
+			node.children[prefix[i]] = child
+		}
+		node = child
+		if visit != nil {
+			visit(node)
+		}
+	}
+	return node
+}
+
+func (m *routeMatcher) addRegex(index int, source string, unconstrained bool) bool {
+	// the regexes anchored with a literal are indexed by the literal prefix,
+	// as a combined alternation can not skip the paths by the anchors
+	if prefix := anchoredLiteralPrefix(source); prefix != "" {
+		node := m.prefixNode(prefix, nil)
+		node.routes = append(node.routes, index)
+		return m.validate("regex:"+source, unconstrained)
+	}
+	var group *regexGroup
+	if n := len(m.regexes); n > 0 && len(m.regexes[n-1].routes) < regexGroupSize {
+		group = m.regexes[n-1]
+	} else {
+		group = &regexGroup{}
+		m.regexes = append(m.regexes, group)
+	}
+	group.sources = append(group.sources, source)
+	group.routes = append(group.routes, index)
+	group.compile()
+	return m.validate("regex:"+source, unconstrained)
+}
+
+func (m *routeMatcher) validate(key string, unconstrained bool) bool {
+	if _, ok := m.unconstrained[key]; ok {
+		return false
+	}
+	if unconstrained {
+		m.unconstrained[key] = struct{}{}
+	}
+	return true
+}
+
+func (g *regexGroup) compile() {
+	var sb strings.Builder
+	for i, source := range g.sources {
+		if i > 0 {
+			sb.WriteByte('|')
+		}
+		sb.WriteString("(?:")
+		sb.WriteString(source)
+		sb.WriteByte(')')
+	}
+	pattern, err := regexp.Compile(sb.String())
+	if err != nil {
+		log.DefaultLogger.Warnf(RouterLogFormat, "route matcher", "compile", "combine regexes failed: "+err.Error())
+	}
+	g.pattern = pattern
+}
+
+// candidates returns the candidate routes of the headers in the config order,
+// the buf is used to store the candidates matched by the path
+func (m *routeMatcher) candidates(headers api.HeaderMap, buf []int) candidateIterator {
+	it := candidateIterator{others: m.others}
+	path, ok := headers.Get(protocol.MosnHeaderPathKey)
+	if !ok {
+		return it
+	}
+	if isASCII(path) {
+		buf = append(buf, m.exact[strings.ToLower(path)]...)
+	} else {
+		buf = append(buf, m.exactAll...)
+	}
+	node := &m.prefixes
+	for i := 0; i < len(path); i++ {
+		if node = node.children[path[i]]; node == nil {
+			break
+		}
+		buf = append(buf, node.routes...)
+	}
+	for _, group := range m.regexes {
+		if group.pattern == nil || group.pattern.MatchString(path) {
+			buf = append(buf, group.routes...)
+		}
+	}
+	sort.Ints(buf)
+	it.paths = buf
+	return it
+}
+
+// candidateIterator merges the sorted route indexes without copying the others
+type candidateIterator struct {
+	others []int
+	paths  []int
+}
+
+func (it *candidateIterator) next() (int, bool) {
+	var index int
+	switch {
+	case len(it.others) == 0 && len(it.paths) == 0:
+		return 0, false
+	case len(it.paths) == 0 || (len(it.others) > 0 && it.others[0] < it.paths[0]):
+		index, it.others = it.others[0], it.others[1:]
+	default:
+		index, it.paths = it.paths[0], it.paths[1:]
+	}
+	return index, true
+}
+
+// anchoredLiteralPrefix returns the literal that the paths matched by the regex start with,
+// it is empty if the regex is not anchored at the beginning with a case sensitive literal
+func anchoredLiteralPrefix(source string) string {
+	re, err := syntax.Parse(source, syntax.Perl)
+	if err != nil || re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+	if literal := re.Sub[1]; literal.Op == syntax.OpLiteral && literal.Flags&syntax.FoldCase == 0 {
+		return string(literal.Rune)
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func newMatcherTestRouter(cluster string, match v2.RouterMatch) v2.Router {
+	router := v2.Router{}
+	router.Match = match
+	router.Route = v2.RouteAction{RouterActionConfig: v2.RouterActionConfig{ClusterName: cluster}}
+	return router
+}
+
+func newMatcherTestVirtualHost(t testing.TB) *VirtualHostImpl {
+	var routers []v2.Router
+	for i := 0; i < 50; i++ {
+		routers = append(routers,
+			newMatcherTestRouter(fmt.Sprintf("path%d", i), v2.RouterMatch{Path: fmt.Sprintf("/service%d/Method", i)}),
+			newMatcherTestRouter(fmt.Sprintf("prefix%d", i), v2.RouterMatch{Prefix: fmt.Sprintf("/service%d/", i)}),
+			newMatcherTestRouter(fmt.Sprintf("regex%d", i), v2.RouterMatch{Regex: fmt.Sprintf("^/regex%d/[0-9]+$", i)}),
+		)
+	}
+	routers = append(routers,
+		newMatcherTestRouter("header", v2.RouterMatch{
+			Headers: []v2.HeaderMatcher{{Name: types.SofaRouteMatchKey, Value: "sofa.service"}},
+		}),
+		newMatcherTestRouter("prefix_header", v2.RouterMatch{
+			Prefix:  "/service1",
+			Headers: []v2.HeaderMatcher{{Name: "version", Value: "v2"}},
+		}),
+		newMatcherTestRouter("regex_unanchored", v2.RouterMatch{Regex: "/v[0-9]+/api$"}),
+		newMatcherTestRouter("regex_fold", v2.RouterMatch{Regex: "(?i)^/upper/"}),
+		newMatcherTestRouter("prefix_root", v2.RouterMatch{Prefix: "/"}),
+	)
+	vh, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: routers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vh
+}
+
+func scanRoutes(vh *VirtualHostImpl, headers api.HeaderMap) []api.Route {
+	var routes []api.Route
+	for _, route := range vh.routes {
+		if r := route.Match(headers, 1); r != nil {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+func TestRouteMatcherSameAsScan(t *testing.T) {
+	vh := newMatcherTestVirtualHost(t)
+	if !vh.useMatcher() {
+		t.Fatal("virtual host should use the compiled matcher")
+	}
+	testCases := []map[string]string{
+		{protocol.MosnHeaderPathKey: "/service1/Method"},
+		{protocol.MosnHeaderPathKey: "/SERVICE1/method"},
+		{protocol.MosnHeaderPathKey: "/service1/other"},
+		{protocol.MosnHeaderPathKey: "/service10/Method"},
+		{protocol.MosnHeaderPathKey: "/service1", "version": "v2"},
+		{protocol.MosnHeaderPathKey: "/regex3/123"},
+		{protocol.MosnHeaderPathKey: "/regex3/abc"},
+		{protocol.MosnHeaderPathKey: "/service1/\u212aey"},
+		{protocol.MosnHeaderPathKey: "/x/v2/api"},
+		{protocol.MosnHeaderPathKey: "/UPPER/x"},
+		{protocol.MosnHeaderPathKey: "unknown"},
+		{types.SofaRouteMatchKey: "sofa.service"},
+		{types.SofaRouteMatchKey: "sofa.service", protocol.MosnHeaderPathKey: "/service2/Method"},
+		{},
+	}
+	for i, tc := range testCases {
+		headers := protocol.CommonHeader(tc)
+		expected := scanRoutes(vh, headers)
+		all := vh.GetAllRoutesFromEntries(headers, 1)
+		if len(all) != len(expected) {
+			t.Fatalf("#%d expected %d routes, but got %d", i, len(expected), len(all))
+		}
+		for j := range all {
+			if all[j] != expected[j] {
+				t.Fatalf("#%d route %d is not matched in order", i, j)
+			}
+		}
+		route := vh.GetRouteFromEntries(headers, 1)
+		if len(expected) == 0 {
+			if route != nil {
+				t.Fatalf("#%d expected no route, but got %s", i, route.RouteRule().ClusterName())
+			}
+			continue
+		}
+		if route != expected[0] {
+			t.Fatalf("#%d expected route %s, but got %v", i, expected[0].RouteRule().ClusterName(), route)
+		}
+	}
+	// the matcher is rebuilt after the routes are removed
+	vh.RemoveAllRoutes()
+	if route := vh.GetRouteFromEntries(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/service1/Method"}, 1); route != nil {
+		t.Fatal("routes should be removed")
+	}
+}
+
+func TestRouteMatcherShadowed(t *testing.T) {
+	vh := &VirtualHostImpl{virtualHostName: "test"}
+	newRoute := func(match v2.RouterMatch) RouteBase {
+		router := newMatcherTestRouter("test", match)
+		base, err := NewRouteRuleImplBase(vh, &router)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case match.Prefix != "":
+			return &PrefixRouteRuleImpl{RouteRuleImplBase: base, prefix: match.Prefix}
+		case match.Path != "":
+			return &PathRouteRuleImpl{RouteRuleImplBase: base, path: match.Path}
+		}
+		return &RegexRouteRuleImpl{RouteRuleImplBase: base, regexStr: match.Regex}
+	}
+	versioned := []v2.HeaderMatcher{{Name: "version", Value: "v1"}}
+	m := newRouteMatcher()
+	testCases := []struct {
+		match     v2.RouterMatch
+		reachable bool
+	}{
+		{v2.RouterMatch{Path: "/foo", Headers: versioned}, true},
+		{v2.RouterMatch{Path: "/foo"}, true},
+		{v2.RouterMatch{Path: "/FOO"}, false},
+		{v2.RouterMatch{Prefix: "/bar/", Headers: versioned}, true},
+		{v2.RouterMatch{Prefix: "/bar/baz", Headers: versioned}, true},
+		{v2.RouterMatch{Prefix: "/bar"}, true},
+		{v2.RouterMatch{Prefix: "/bar/qux"}, false},
+		{v2.RouterMatch{Prefix: "/ba"}, true},
+		{v2.RouterMatch{Regex: "/re.*"}, true},
+		{v2.RouterMatch{Regex: "/re.*"}, false},
+	}
+	for i, tc := range testCases {
+		if reachable := m.add(i, newRoute(tc.match)); reachable != tc.reachable {
+			t.Errorf("#%d expected reachable %v, but got %v", i, tc.reachable, reachable)
+		}
+	}
+}
+
+func TestRouteTableStats(t *testing.T) {
+	routers, err := NewRouters(&v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: "test_route_table_stats",
+		},
+		VirtualHosts: []*v2.VirtualHost{
+			{
+				Name:    "test",
+				Domains: []string{"*"},
+				Routers: []v2.Router{newMatcherTestRouter("test", v2.RouterMatch{Prefix: "/foo"})},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	routers.MatchRoute(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/foo"}, 1)
+	routers.MatchRoute(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/bar"}, 1)
+	routers.MatchAllRoutes(protocol.CommonHeader{protocol.MosnHeaderPathKey: "/foo/bar"}, 1)
+	stats := metrics.NewRouteTableStats("test_route_table_stats")
+	if stats.Counter(metrics.RouteTableMatchTotal).Count() != 3 ||
+		stats.Counter(metrics.RouteTableMatchMiss).Count() != 1 ||
+		stats.Histogram(metrics.RouteTableMatchDuration).Count() != 3 {
+		t.Fatalf("route table stats is not expected, total: %d, miss: %d",
+			stats.Counter(metrics.RouteTableMatchTotal).Count(), stats.Counter(metrics.RouteTableMatchMiss).Count())
+	}
+}
+
+func BenchmarkGetRouteFromEntries(b *testing.B) {
+	vh := newMatcherTestVirtualHost(b)
+	headers := protocol.CommonHeader{protocol.MosnHeaderPathKey: "/service49/Method"}
+	b.Run("compiled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			vh.GetRouteFromEntries(headers, 1)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, route := range vh.routes {
+				if route.Match(headers, 1) != nil {
+					break
+				}
+			}
+		}
+	})
+}
+
+func TestAnchoredLiteralPrefix(t *testing.T) {
+	testCases := []struct {
+		regex  string
+		prefix string
+	}{
+		{"^/foo/[0-9]+$", "/foo/"},
+		{"^/foo", "/foo"},
+		{"/foo", ""},
+		{"^ab|acd", ""},
+		{"(?i)^/foo", ""},
+		{"^[a-z]+", ""},
+		{"^", ""},
+	}
+	for _, tc := range testCases {
+		if prefix := anchoredLiteralPrefix(tc.regex); prefix != tc.prefix {
+			t.Errorf("regex %s expected prefix %q, but got %q", tc.regex, tc.prefix, prefix)
+		}
+	}
+}
+
+func TestIsASCII(t *testing.T) {
+	if !isASCII("/service/Method") || isASCII("/\u212a") {
+		t.Fatal("is ascii check failed")
+	}
+}
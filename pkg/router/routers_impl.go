@@ -21,10 +21,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	gometrics "github.com/rcrowley/go-metrics"
 	"mosn.io/api"
 	"mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 )
@@ -40,17 +43,37 @@ type routersImpl struct {
 	greaterSortedWildcardVirtualHostSuffixes []int
 	// stored all vritual host, same as the config order
 	virtualHosts []types.VirtualHost
+	// route table match stats
+	stats routeTableStats
+}
+
+// routeTableStats are resolved once when the route table is created, the route match does not look up the metrics
+type routeTableStats struct {
+	matchTotal    gometrics.Counter
+	matchMiss     gometrics.Counter
+	matchDuration gometrics.Histogram
+}
+
+func newRouteTableStats(name string) routeTableStats {
+	s := metrics.NewRouteTableStats(name)
+	return routeTableStats{
+		matchTotal:    s.Counter(metrics.RouteTableMatchTotal),
+		matchMiss:     s.Counter(metrics.RouteTableMatchMiss),
+		matchDuration: s.Histogram(metrics.RouteTableMatchDuration),
+	}
 }
 
 func (ri *routersImpl) MatchRoute(headers api.HeaderMap, randomValue uint64) api.Route {
 	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 		log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchRoute", headers)
 	}
+	start := time.Now()
 	virtualHost := ri.findVirtualHost(headers)
 	if virtualHost == nil {
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 			log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchRoute", "no virtual host found")
 		}
+		ri.recordMatch(start, false)
 		return nil
 	}
 	router := virtualHost.GetRouteFromEntries(headers, randomValue)
@@ -59,6 +82,7 @@ func (ri *routersImpl) MatchRoute(headers api.HeaderMap, randomValue uint64) api
 			log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchRoute", "no route found")
 		}
 	}
+	ri.recordMatch(start, router != nil)
 	return router
 }
 
@@ -66,11 +90,13 @@ func (ri *routersImpl) MatchAllRoutes(headers api.HeaderMap, randomValue uint64)
 	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 		log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchAllRoutes", headers)
 	}
+	start := time.Now()
 	virtualHost := ri.findVirtualHost(headers)
 	if virtualHost == nil {
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 			log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchAllRoutes", "no virtual host found")
 		}
+		ri.recordMatch(start, false)
 		return nil
 	}
 	routers := virtualHost.GetAllRoutesFromEntries(headers, randomValue)
@@ -79,9 +105,19 @@ func (ri *routersImpl) MatchAllRoutes(headers api.HeaderMap, randomValue uint64)
 			log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchAllRoutes", "no route found")
 		}
 	}
+	ri.recordMatch(start, len(routers) > 0)
 	return routers
 }
 
+// recordMatch records a route match of the route table started at the start time
+func (ri *routersImpl) recordMatch(start time.Time, matched bool) {
+	ri.stats.matchTotal.Inc(1)
+	if !matched {
+		ri.stats.matchMiss.Inc(1)
+	}
+	ri.stats.matchDuration.Update(time.Since(start).Nanoseconds())
+}
+
 func (ri *routersImpl) MatchRouteFromHeaderKV(headers api.HeaderMap, key string, value string) api.Route {
 	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 		log.DefaultLogger.Debugf(RouterLogFormat, "routers", "MatchRouteFromHeaderKV", headers)
@@ -204,6 +240,7 @@ func NewRouters(routerConfig *v2.RouterConfiguration) (types.Routers, error) {
 		wildcardVirtualHostSuffixesIndex:         make(map[int]map[string]int),
 		greaterSortedWildcardVirtualHostSuffixes: []int{},
 		virtualHosts:                             []types.VirtualHost{},
+		stats:                                    newRouteTableStats(routerConfig.RouterConfigName),
 	}
 	configImpl := NewConfigImpl(routerConfig)
 	for index, vhConfig := range routerConfig.VirtualHosts {
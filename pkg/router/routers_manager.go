@@ -47,6 +47,32 @@ func (rw *RoutersWrapper) GetRoutersConfig() v2.RouterConfiguration {
 	return *rw.routersConfig
 }
 
+// RouterConfigHook is called before a router config is added or updated,
+// it returns the config to be applied and must not modify the given one.
+type RouterConfigHook func(routerConfig *v2.RouterConfiguration) *v2.RouterConfiguration
+
+var (
+	routerConfigHooks   = make(map[string]RouterConfigHook)
+	routerConfigHooksMu sync.RWMutex
+)
+
+// RegisterRouterConfigHook registers the hook of the router config name, a registered hook is replaced
+func RegisterRouterConfigHook(routerConfigName string, hook RouterConfigHook) {
+	routerConfigHooksMu.Lock()
+	defer routerConfigHooksMu.Unlock()
+	routerConfigHooks[routerConfigName] = hook
+}
+
+func applyRouterConfigHook(routerConfig *v2.RouterConfiguration) *v2.RouterConfiguration {
+	routerConfigHooksMu.RLock()
+	hook, ok := routerConfigHooks[routerConfig.RouterConfigName]
+	routerConfigHooksMu.RUnlock()
+	if !ok {
+		return routerConfig
+	}
+	return hook(routerConfig)
+}
+
 // RoutersManager implementation
 type routersManagerImpl struct {
 	routersWrapperMap sync.Map
@@ -58,6 +84,7 @@ func (rm *routersManagerImpl) AddOrUpdateRouters(routerConfig *v2.RouterConfigur
 		log.DefaultLogger.Errorf(RouterLogFormat, "routers_manager", "AddOrUpdateRouters", "error: %v", ErrNilRouterConfig)
 		return ErrNilRouterConfig
 	}
+	routerConfig = applyRouterConfigHook(routerConfig)
 	if v, ok := rm.routersWrapperMap.Load(routerConfig.RouterConfigName); ok {
 		rw, ok := v.(*RoutersWrapper)
 		if !ok {
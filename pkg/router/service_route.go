@@ -0,0 +1,210 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	// serviceRoutePrefix is the name prefix of the generated routes,
+	// used to distinguish the generated routes from the configured ones.
+	serviceRoutePrefix      = "service_route|"
+	serviceRouteVirtualHost = "service_routes"
+	defaultServiceDomain    = "*"
+)
+
+// ServiceRouteGenerator generates routes from the services subscribed by clusters.
+// A generated route matches the service header and routes to the cluster that subscribes the service.
+// The routes configured by users are kept in front of the generated ones, so they take precedence,
+// and no route is generated for a service that is already matched by a configured route.
+// The generated routes are added into every update of the router config, so they are kept when the
+// configured routes changed.
+type ServiceRouteGenerator struct {
+	mux      sync.Mutex
+	name     string
+	domain   string
+	manager  types.RouterManager
+	services map[string]map[string]string // cluster name -> service names
+}
+
+// NewServiceRouteGenerator creates a generator that adds routes into the router config
+func NewServiceRouteGenerator(cfg v2.ServiceRouteConfig, manager types.RouterManager) *ServiceRouteGenerator {
+	domain := cfg.Domain
+	if domain == "" {
+		domain = defaultServiceDomain
+	}
+	g := &ServiceRouteGenerator{
+		name:     cfg.RouterConfigName,
+		domain:   domain,
+		manager:  manager,
+		services: make(map[string]map[string]string),
+	}
+	RegisterRouterConfigHook(g.name, g.addRoutes)
+	return g
+}
+
+// OnClusterConfig updates the subscribed services of the cluster and regenerates the routes,
+// a nil config means the cluster is removed.
+func (g *ServiceRouteGenerator) OnClusterConfig(clusterName string, config *v2.Cluster) {
+	if !g.updateServices(clusterName, config) {
+		return
+	}
+	if err := g.Refresh(); err != nil {
+		log.DefaultLogger.Errorf(RouterLogFormat, "service route", "OnClusterConfig", err)
+	}
+}
+
+// updateServices returns false if the subscribed services are not changed
+func (g *ServiceRouteGenerator) updateServices(clusterName string, config *v2.Cluster) bool {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	subscribed := make(map[string]string)
+	if config != nil {
+		for _, sub := range config.Spec.Subscribes {
+			if sub.ServiceName != "" {
+				subscribed[sub.ServiceName] = clusterName
+			}
+		}
+	}
+	if len(subscribed) == 0 {
+		if _, ok := g.services[clusterName]; !ok {
+			return false
+		}
+		delete(g.services, clusterName)
+	} else {
+		g.services[clusterName] = subscribed
+	}
+	return true
+}
+
+// Refresh regenerates the routes into the current router config.
+// The routes are also regenerated by every update of the router config.
+func (g *ServiceRouteGenerator) Refresh() error {
+	cfg := &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: g.name,
+		},
+	}
+	if rw := g.manager.GetRouterWrapperByName(g.name); rw != nil {
+		*cfg = rw.GetRoutersConfig()
+	}
+	return g.manager.AddOrUpdateRouters(cfg)
+}
+
+// addRoutes is the router config hook, it returns a copy of the config with the generated routes
+func (g *ServiceRouteGenerator) addRoutes(routerConfig *v2.RouterConfiguration) *v2.RouterConfiguration {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	cfg := *routerConfig
+	// copy the virtual hosts without the generated routes, the stored config should not be modified
+	virtualHosts := make([]*v2.VirtualHost, 0, len(cfg.VirtualHosts)+1)
+	var target *v2.VirtualHost
+	for _, vh := range cfg.VirtualHosts {
+		copied := *vh
+		copied.Routers = make([]v2.Router, 0, len(vh.Routers))
+		for _, r := range vh.Routers {
+			if !strings.HasPrefix(r.Name, serviceRoutePrefix) {
+				copied.Routers = append(copied.Routers, r)
+			}
+		}
+		if target == nil && containsDomain(copied.Domains, g.domain) {
+			target = &copied
+		}
+		virtualHosts = append(virtualHosts, &copied)
+	}
+	if target == nil {
+		target = &v2.VirtualHost{
+			Name:    serviceRouteVirtualHost,
+			Domains: []string{g.domain},
+		}
+		virtualHosts = append(virtualHosts, target)
+	}
+	target.Routers = append(target.Routers, g.generate(target.Routers)...)
+	cfg.VirtualHosts = virtualHosts
+	return &cfg
+}
+
+// generate makes the routes sorted by service name, skips the services matched by the configured routes
+func (g *ServiceRouteGenerator) generate(configured []v2.Router) []v2.Router {
+	overridden := make(map[string]bool)
+	for _, r := range configured {
+		for _, h := range r.Match.Headers {
+			if h.Name == types.SofaRouteMatchKey && !h.Regex {
+				overridden[h.Value] = true
+			}
+		}
+	}
+	services := make(map[string]string)
+	for _, subscribed := range g.services {
+		for service, cluster := range subscribed {
+			// a service subscribed by more than one cluster, choose one stably
+			if exists, ok := services[service]; !ok || cluster < exists {
+				services[service] = cluster
+			}
+		}
+	}
+	names := make([]string, 0, len(services))
+	for service := range services {
+		if overridden[service] {
+			if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+				log.DefaultLogger.Debugf(RouterLogFormat, "service route", "generate", "service "+service+" is overridden by configured route")
+			}
+			continue
+		}
+		names = append(names, service)
+	}
+	sort.Strings(names)
+	routes := make([]v2.Router, 0, len(names))
+	for _, service := range names {
+		routes = append(routes, v2.Router{
+			RouterConfig: v2.RouterConfig{
+				Name: serviceRoutePrefix + service,
+				Match: v2.RouterMatch{
+					Headers: []v2.HeaderMatcher{
+						{
+							Name:  types.SofaRouteMatchKey,
+							Value: service,
+						},
+					},
+				},
+				Route: v2.RouteAction{
+					RouterActionConfig: v2.RouterActionConfig{
+						ClusterName: services[service],
+					},
+				},
+			},
+		})
+	}
+	return routes
+}
+
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func subscribeCluster(name string, services ...string) *v2.Cluster {
+	c := &v2.Cluster{Name: name}
+	for _, s := range services {
+		c.Spec.Subscribes = append(c.Spec.Subscribes, v2.SubscribeSpec{ServiceName: s})
+	}
+	return c
+}
+
+func matchServiceCluster(t *testing.T, rm types.RouterManager, name, service string) string {
+	rw := rm.GetRouterWrapperByName(name)
+	if rw == nil {
+		t.Fatalf("router %s not found", name)
+	}
+	headers := protocol.CommonHeader{types.SofaRouteMatchKey: service}
+	route := rw.GetRouters().MatchRoute(headers, 1)
+	if route == nil {
+		return ""
+	}
+	return route.RouteRule().ClusterName()
+}
+
+func TestServiceRouteGenerator(t *testing.T) {
+	rm := NewRouterManager()
+	// configured route overrides the generated one
+	cfg := &v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: "test_service_route",
+		},
+		VirtualHosts: []*v2.VirtualHost{
+			{
+				Name:    "default",
+				Domains: []string{"*"},
+				Routers: []v2.Router{
+					{
+						RouterConfig: v2.RouterConfig{
+							Match: v2.RouterMatch{
+								Headers: []v2.HeaderMatcher{{Name: "service", Value: "com.test.ServiceA:1.0"}},
+							},
+							Route: v2.RouteAction{
+								RouterActionConfig: v2.RouterActionConfig{ClusterName: "override"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := rm.AddOrUpdateRouters(cfg); err != nil {
+		t.Fatal(err)
+	}
+	g := NewServiceRouteGenerator(v2.ServiceRouteConfig{RouterConfigName: "test_service_route"}, rm)
+	g.OnClusterConfig("clusterA", subscribeCluster("clusterA", "com.test.ServiceA:1.0", "com.test.ServiceB:1.0"))
+	g.OnClusterConfig("clusterC", subscribeCluster("clusterC", "com.test.ServiceC:1.0"))
+
+	for service, expected := range map[string]string{
+		"com.test.ServiceA:1.0": "override",
+		"com.test.ServiceB:1.0": "clusterA",
+		"com.test.ServiceC:1.0": "clusterC",
+		"com.test.ServiceD:1.0": "",
+	} {
+		if c := matchServiceCluster(t, rm, "test_service_route", service); c != expected {
+			t.Errorf("service %s expected route to %s, but got %s", service, expected, c)
+		}
+	}
+	routers := rm.GetRouterWrapperByName("test_service_route").GetRoutersConfig().VirtualHosts[0].Routers
+	if len(routers) != 3 || routers[0].Route.ClusterName != "override" {
+		t.Fatalf("unexpected routes: %+v", routers)
+	}
+	// the stored config is not changed by the generator
+	if len(cfg.VirtualHosts[0].Routers) != 1 {
+		t.Fatalf("original config is modified")
+	}
+
+	// cluster removed, routes are removed too
+	g.OnClusterConfig("clusterC", nil)
+	if c := matchServiceCluster(t, rm, "test_service_route", "com.test.ServiceC:1.0"); c != "" {
+		t.Errorf("service route is not removed, route to %s", c)
+	}
+	// configured routes updated, generated routes are kept
+	if err := rm.AddOrUpdateRouters(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if c := matchServiceCluster(t, rm, "test_service_route", "com.test.ServiceB:1.0"); c != "clusterA" {
+		t.Errorf("service route is not kept after router updated, route to %s", c)
+	}
+	if c := matchServiceCluster(t, rm, "test_service_route", "com.test.ServiceA:1.0"); c != "override" {
+		t.Errorf("configured route is not kept after router updated, route to %s", c)
+	}
+	if len(cfg.VirtualHosts[0].Routers) != 1 {
+		t.Fatalf("original config is modified")
+	}
+	if err := g.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if routers := rm.GetRouterWrapperByName("test_service_route").GetRoutersConfig().VirtualHosts[0].Routers; len(routers) != 2 {
+		t.Fatalf("generated routes are duplicated: %+v", routers)
+	}
+}
+
+func TestServiceRouteGeneratorNewRouter(t *testing.T) {
+	rm := NewRouterManager()
+	g := NewServiceRouteGenerator(v2.ServiceRouteConfig{RouterConfigName: "test_service_route_new"}, rm)
+	g.OnClusterConfig("clusterA", subscribeCluster("clusterA", "com.test.ServiceA:1.0"))
+	if c := matchServiceCluster(t, rm, "test_service_route_new", "com.test.ServiceA:1.0"); c != "clusterA" {
+		t.Errorf("expected route to clusterA, but got %s", c)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+const (
+	defaultIndexFile   = "index.html"
+	defaultMaxFileSize = 4 << 20
+)
+
+// staticFiles serves the files in the root directory
+type staticFiles struct {
+	root        string
+	stripPrefix string
+	indexFile   string
+	maxFileSize int64
+}
+
+func newStaticFiles(config *v2.StaticFilesAction) (*staticFiles, error) {
+	if config.Root == "" {
+		return nil, fmt.Errorf("static files: no root directory")
+	}
+	// the root is resolved, so the files can be checked by prefix
+	root, err := filepath.Abs(config.Root)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("static files: invalid root %s: %v", config.Root, err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("static files: root %s is not a directory", config.Root)
+	}
+	f := &staticFiles{
+		root:        root,
+		stripPrefix: config.StripPrefix,
+		indexFile:   config.IndexFile,
+		maxFileSize: config.MaxFileSize,
+	}
+	if f.indexFile == "" {
+		f.indexFile = defaultIndexFile
+	}
+	if f.maxFileSize <= 0 {
+		f.maxFileSize = defaultMaxFileSize
+	}
+	return f, nil
+}
+
+// Serve returns the file requested, the HEAD requests get the content type without the content
+func (f *staticFiles) Serve(method, urlPath string) (int, string, []byte) {
+	if method != "" && method != http.MethodGet && method != http.MethodHead {
+		return http.StatusMethodNotAllowed, "", nil
+	}
+	name, ok := f.lookup(urlPath)
+	if !ok {
+		return http.StatusNotFound, "", nil
+	}
+	content, err := readFileLimited(name, f.maxFileSize)
+	if err != nil {
+		if err == errFileTooLarge {
+			return http.StatusForbidden, "", nil
+		}
+		return http.StatusNotFound, "", nil
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+	if method == http.MethodHead {
+		return http.StatusOK, contentType, nil
+	}
+	return http.StatusOK, contentType, content
+}
+
+// lookup returns the file name of the request path, the paths outside the root are not found,
+// including the paths with the encoded dot segments and the symlinks point outside the root
+func (f *staticFiles) lookup(urlPath string) (string, bool) {
+	if i := strings.IndexByte(urlPath, '?'); i >= 0 {
+		urlPath = urlPath[:i]
+	}
+	p, err := url.PathUnescape(urlPath)
+	if err != nil || strings.IndexByte(p, 0) >= 0 {
+		return "", false
+	}
+	if !strings.HasPrefix(p, f.stripPrefix) {
+		return "", false
+	}
+	// the cleaned path is rooted, the dot dot segments can not go above the root
+	p = path.Clean("/" + strings.TrimPrefix(p, f.stripPrefix))
+	name, ok := f.resolve(filepath.Join(f.root, filepath.FromSlash(p)))
+	if !ok {
+		return "", false
+	}
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		return f.resolve(filepath.Join(name, f.indexFile))
+	}
+	return name, true
+}
+
+// resolve evaluates the symlinks of the name, returns false if the file is outside the root
+func (f *staticFiles) resolve(name string) (string, bool) {
+	real, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return "", false
+	}
+	if real != f.root && !strings.HasPrefix(real, f.root+string(filepath.Separator)) {
+		return "", false
+	}
+	return real, true
+}
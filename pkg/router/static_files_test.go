@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+)
+
+func newStaticFilesTestRoot(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "static_files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(dir, "www")
+	for name, content := range map[string]string{
+		"www/index.html":       "<html>index</html>",
+		"www/robots.txt":       "User-agent: *",
+		"www/docs/index.html":  "<html>docs</html>",
+		"www/docs/large.bin":   strings.Repeat("a", 1024),
+		"www/docs/noext":       "plain text",
+		"secret.txt":           "secret",
+		"www/docs/a b/c.json":  "{}",
+		"www/.hidden/file.txt": "hidden",
+	} {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	return dir, root
+}
+
+func TestStaticFilesServe(t *testing.T) {
+	dir, root := newStaticFilesTestRoot(t)
+	defer os.RemoveAll(dir)
+	files, err := newStaticFiles(&v2.StaticFilesAction{
+		Root:        root,
+		StripPrefix: "/static",
+		MaxFileSize: 512,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		method      string
+		path        string
+		code        int
+		contentType string
+		content     string
+	}{
+		{"GET", "/static/robots.txt", http.StatusOK, "text/plain; charset=utf-8", "User-agent: *"},
+		{"", "/static/", http.StatusOK, "text/html; charset=utf-8", "<html>index</html>"},
+		{"GET", "/static", http.StatusOK, "text/html; charset=utf-8", "<html>index</html>"},
+		{"GET", "/static/docs?v=1", http.StatusOK, "text/html; charset=utf-8", "<html>docs</html>"},
+		{"GET", "/static/docs/noext", http.StatusOK, "text/plain; charset=utf-8", "plain text"},
+		{"GET", "/static/docs/a%20b/c.json", http.StatusOK, "application/json", "{}"},
+		{"HEAD", "/static/robots.txt", http.StatusOK, "text/plain; charset=utf-8", ""},
+		{"POST", "/static/robots.txt", http.StatusMethodNotAllowed, "", ""},
+		{"GET", "/static/docs/large.bin", http.StatusForbidden, "", ""},
+		{"GET", "/static/missing.txt", http.StatusNotFound, "", ""},
+		{"GET", "/other/robots.txt", http.StatusNotFound, "", ""},
+		// path traversal
+		{"GET", "/static/../secret.txt", http.StatusNotFound, "", ""},
+		{"GET", "/static/docs/../../secret.txt", http.StatusNotFound, "", ""},
+		{"GET", "/static/%2e%2e/secret.txt", http.StatusNotFound, "", ""},
+		{"GET", "/static/..%2fsecret.txt", http.StatusNotFound, "", ""},
+		{"GET", "/static/link.txt", http.StatusNotFound, "", ""},
+		{"GET", "/static/robots.txt%00.html", http.StatusNotFound, "", ""},
+		{"GET", "/static/%zz", http.StatusNotFound, "", ""},
+	}
+	for i, tc := range testCases {
+		code, contentType, content := files.Serve(tc.method, tc.path)
+		if code != tc.code || contentType != tc.contentType || string(content) != tc.content {
+			t.Errorf("case %d: serve %s %s got %d %q %q", i, tc.method, tc.path, code, contentType, content)
+		}
+	}
+}
+
+func TestNewStaticFilesInvalid(t *testing.T) {
+	dir, root := newStaticFilesTestRoot(t)
+	defer os.RemoveAll(dir)
+	for i, cfg := range []*v2.StaticFilesAction{
+		{},
+		{Root: filepath.Join(dir, "missing")},
+		{Root: filepath.Join(root, "robots.txt")},
+	} {
+		if _, err := newStaticFiles(cfg); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
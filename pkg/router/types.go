@@ -41,7 +41,7 @@ var (
 )
 
 type headerFormatter interface {
-	format(requestInfo api.RequestInfo) string
+	format(ctx context.Context, requestInfo api.RequestInfo) string
 	append() bool
 }
 
@@ -97,9 +97,10 @@ func (p *policy) ShadowPolicy() api.ShadowPolicy {
 }
 
 type retryPolicyImpl struct {
-	retryOn      bool
-	retryTimeout time.Duration
-	numRetries   uint32
+	retryOn               bool
+	retryTimeout          time.Duration
+	numRetries            uint32
+	retryAfterMaxInterval time.Duration
 }
 
 func (p *retryPolicyImpl) RetryOn() bool {
@@ -123,6 +124,13 @@ func (p *retryPolicyImpl) NumRetries() uint32 {
 	return p.numRetries
 }
 
+func (p *retryPolicyImpl) RetryAfterMaxInterval() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.retryAfterMaxInterval
+}
+
 type shadowPolicyImpl struct {
 	cluster    string
 	runtimeKey string
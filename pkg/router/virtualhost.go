@@ -18,6 +18,7 @@
 package router
 
 import (
+	"fmt"
 	"regexp"
 	"sync"
 
@@ -30,6 +31,7 @@ type VirtualHostImpl struct {
 	virtualHostName       string
 	mutex                 sync.RWMutex
 	routes                []RouteBase
+	matcher               *routeMatcher
 	fastIndex             map[string]map[string]api.Route
 	globalRouteConfig     *configImpl
 	requestHeadersParser  *headerParser
@@ -77,6 +79,13 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 	if router != nil {
 		vh.mutex.Lock()
 		vh.routes = append(vh.routes, router)
+		if vh.matcher == nil {
+			vh.matcher = newRouteMatcher()
+		}
+		if !vh.matcher.add(len(vh.routes)-1, router) {
+			msg := fmt.Sprintf("route %s is shadowed by an earlier route, it is never matched", router.Matcher())
+			log.DefaultLogger.Warnf(RouterLogFormat, "virtualhost", "addRouteBase", msg)
+		}
 		// make fast index, used in certain scenarios
 		// TODO: rule can be extended
 		if len(route.Match.Headers) == 1 && !route.Match.Headers[0].Regex {
@@ -101,6 +110,16 @@ func (vh *VirtualHostImpl) addRouteBase(route *v2.Router) error {
 func (vh *VirtualHostImpl) GetRouteFromEntries(headers api.HeaderMap, randomValue uint64) api.Route {
 	vh.mutex.RLock()
 	defer vh.mutex.RUnlock()
+	if vh.useMatcher() {
+		var buf [16]int
+		it := vh.matcher.candidates(headers, buf[:0])
+		for index, ok := it.next(); ok; index, ok = it.next() {
+			if routeEntry := vh.routes[index].Match(headers, randomValue); routeEntry != nil {
+				return routeEntry
+			}
+		}
+		return nil
+	}
 	for _, route := range vh.routes {
 		if routeEntry := route.Match(headers, randomValue); routeEntry != nil {
 			return routeEntry
@@ -113,6 +132,16 @@ func (vh *VirtualHostImpl) GetAllRoutesFromEntries(headers api.HeaderMap, random
 	vh.mutex.RLock()
 	defer vh.mutex.RUnlock()
 	var routes []api.Route
+	if vh.useMatcher() {
+		var buf [16]int
+		it := vh.matcher.candidates(headers, buf[:0])
+		for index, ok := it.next(); ok; index, ok = it.next() {
+			if r := vh.routes[index].Match(headers, randomValue); r != nil {
+				routes = append(routes, r)
+			}
+		}
+		return routes
+	}
 	for _, route := range vh.routes {
 		if r := route.Match(headers, randomValue); r != nil {
 			routes = append(routes, r)
@@ -121,6 +150,11 @@ func (vh *VirtualHostImpl) GetAllRoutesFromEntries(headers api.HeaderMap, random
 	return routes
 }
 
+// useMatcher returns true if the routes are matched by the compiled matcher instead of scanning all the routes
+func (vh *VirtualHostImpl) useMatcher() bool {
+	return vh.matcher != nil && len(vh.routes) >= compiledMatcherMinRoutes
+}
+
 func (vh *VirtualHostImpl) GetRouteFromHeaderKV(key, value string) api.Route {
 	vh.mutex.RLock()
 	defer vh.mutex.RUnlock()
@@ -143,12 +177,14 @@ func (vh *VirtualHostImpl) RemoveAllRoutes() {
 	vh.fastIndex = make(map[string]map[string]api.Route)
 	// clear the routes
 	vh.routes = vh.routes[:0]
+	vh.matcher = newRouteMatcher()
 	return
 }
 
 func NewVirtualHostImpl(virtualHost *v2.VirtualHost) (*VirtualHostImpl, error) {
 	vhImpl := &VirtualHostImpl{
 		virtualHostName:       virtualHost.Name,
+		matcher:               newRouteMatcher(),
 		fastIndex:             make(map[string]map[string]api.Route),
 		requestHeadersParser:  getHeaderParser(virtualHost.RequestHeadersToAdd, nil),
 		responseHeadersParser: getHeaderParser(virtualHost.ResponseHeadersToAdd, virtualHost.ResponseHeadersToRemove),
@@ -0,0 +1,333 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package secret
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/sigv4"
+)
+
+const (
+	ProviderEnv   = "env"
+	ProviderFile  = "file"
+	ProviderVault = "vault"
+	ProviderKMS   = "kms"
+
+	defaultVaultAddrEnv  = "VAULT_ADDR"
+	defaultVaultTokenEnv = "VAULT_TOKEN"
+	defaultVaultMount    = "secret"
+	defaultVaultTimeout  = 10 * time.Second
+
+	defaultKMSRegionEnv       = "AWS_REGION"
+	defaultKMSAccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	defaultKMSSecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	defaultKMSSessionTokenEnv = "AWS_SESSION_TOKEN"
+	defaultKMSTimeout         = 10 * time.Second
+	kmsService                = "kms"
+	kmsDecryptTarget          = "TrentService.Decrypt"
+	kmsContentType            = "application/x-amz-json-1.1"
+)
+
+// timeNow is the time used to sign the kms requests
+var timeNow = time.Now
+
+func init() {
+	RegisterProvider(ProviderEnv, newEnvProvider)
+	RegisterProvider(ProviderFile, newFileProvider)
+	RegisterProvider(ProviderVault, newVaultProvider)
+	RegisterProvider(ProviderKMS, newKMSProvider)
+}
+
+func parseConfig(cfg map[string]interface{}, v interface{}) error {
+	if cfg == nil {
+		return nil
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// envProvider reads the secrets from the environment variables, the key is the variable name
+type envProvider struct{}
+
+func newEnvProvider(cfg map[string]interface{}) (Provider, error) {
+	return &envProvider{}, nil
+}
+
+func (p *envProvider) Fetch(key string) ([]byte, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", key)
+	}
+	return []byte(v), nil
+}
+
+// fileProvider reads the secrets from the files, the key is the file path,
+// a relative path is relative to the dir in config
+type fileProvider struct {
+	Dir string `json:"dir,omitempty"`
+	// TrimSpace removes the leading and trailing white spaces, such as the newline at the end of file
+	TrimSpace bool `json:"trim_space,omitempty"`
+}
+
+func newFileProvider(cfg map[string]interface{}) (Provider, error) {
+	p := &fileProvider{}
+	if err := parseConfig(cfg, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *fileProvider) Fetch(key string) ([]byte, error) {
+	path := key
+	if !filepath.IsAbs(path) && p.Dir != "" {
+		path = filepath.Join(p.Dir, path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if p.TrimSpace {
+		b = bytes.TrimSpace(b)
+	}
+	return b, nil
+}
+
+// vaultProvider reads the secrets from the HashiCorp Vault KV version 2 secrets engine.
+// The key is "path#field", the whole data of the path is returned as json if the field is empty.
+type vaultProvider struct {
+	// Address default is VAULT_ADDR in environment
+	Address string `json:"address,omitempty"`
+	// Token default is the TokenEnv in environment
+	Token string `json:"token,omitempty"`
+	// TokenEnv default is VAULT_TOKEN
+	TokenEnv string `json:"token_env,omitempty"`
+	// Mount is the mount path of the secrets engine, default is "secret"
+	Mount string `json:"mount,omitempty"`
+	// Timeout default is 10s
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+
+	client *http.Client
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func newVaultProvider(cfg map[string]interface{}) (Provider, error) {
+	p := &vaultProvider{}
+	if err := parseConfig(cfg, p); err != nil {
+		return nil, err
+	}
+	if p.Address == "" {
+		p.Address = os.Getenv(defaultVaultAddrEnv)
+	}
+	if p.Token == "" {
+		if p.TokenEnv == "" {
+			p.TokenEnv = defaultVaultTokenEnv
+		}
+		p.Token = os.Getenv(p.TokenEnv)
+	}
+	if p.Address == "" || p.Token == "" {
+		return nil, errors.New("vault address and token are required")
+	}
+	p.Address = strings.TrimSuffix(p.Address, "/")
+	if p.Mount == "" {
+		p.Mount = defaultVaultMount
+	}
+	timeout := defaultVaultTimeout
+	if p.Timeout.Duration > 0 {
+		timeout = p.Timeout.Duration
+	}
+	p.client = &http.Client{Timeout: timeout}
+	return p, nil
+}
+
+func (p *vaultProvider) Fetch(key string) ([]byte, error) {
+	path, field := key, ""
+	if i := strings.LastIndexByte(key, '#'); i >= 0 {
+		path, field = key[:i], key[i+1:]
+	}
+	url := fmt.Sprintf("%s/v1/%s/data/%s", p.Address, p.Mount, strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.Token)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := &vaultResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("vault response status %d: %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault response status %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	if field == "" {
+		return json.Marshal(result.Data.Data)
+	}
+	v, ok := result.Data.Data[field]
+	if !ok {
+		return nil, fmt.Errorf("field %s not found in vault path %s", field, path)
+	}
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
+}
+
+// kmsProvider decrypts the secrets by the AWS KMS Decrypt api, the key is the base64 encoded ciphertext blob,
+// so the encrypted secrets can be kept in the config. The requests are signed by sigv4 with the credentials
+// in the environment variables.
+type kmsProvider struct {
+	// Region default is AWS_REGION in environment
+	Region string `json:"region,omitempty"`
+	// Endpoint default is https://kms.<region>.amazonaws.com
+	Endpoint string `json:"endpoint,omitempty"`
+	// AccessKeyEnv default is AWS_ACCESS_KEY_ID
+	AccessKeyEnv string `json:"access_key_env,omitempty"`
+	// SecretKeyEnv default is AWS_SECRET_ACCESS_KEY
+	SecretKeyEnv string `json:"secret_key_env,omitempty"`
+	// SessionTokenEnv default is AWS_SESSION_TOKEN
+	SessionTokenEnv string `json:"session_token_env,omitempty"`
+	// Timeout default is 10s
+	Timeout api.DurationConfig `json:"timeout,omitempty"`
+
+	client *http.Client
+}
+
+type kmsDecryptResponse struct {
+	Plaintext string `json:"Plaintext"`
+	Type      string `json:"__type"`
+	Message   string `json:"message"`
+}
+
+func newKMSProvider(cfg map[string]interface{}) (Provider, error) {
+	p := &kmsProvider{}
+	if err := parseConfig(cfg, p); err != nil {
+		return nil, err
+	}
+	if p.Region == "" {
+		p.Region = os.Getenv(defaultKMSRegionEnv)
+	}
+	if p.Region == "" {
+		return nil, errors.New("kms region is required")
+	}
+	if p.Endpoint == "" {
+		p.Endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", p.Region)
+	}
+	p.Endpoint = strings.TrimSuffix(p.Endpoint, "/")
+	if p.AccessKeyEnv == "" {
+		p.AccessKeyEnv = defaultKMSAccessKeyEnv
+	}
+	if p.SecretKeyEnv == "" {
+		p.SecretKeyEnv = defaultKMSSecretKeyEnv
+	}
+	if p.SessionTokenEnv == "" {
+		p.SessionTokenEnv = defaultKMSSessionTokenEnv
+	}
+	timeout := defaultKMSTimeout
+	if p.Timeout.Duration > 0 {
+		timeout = p.Timeout.Duration
+	}
+	p.client = &http.Client{Timeout: timeout}
+	return p, nil
+}
+
+// credentials are read in each fetch, so the rotated credentials in the environment variables are used
+func (p *kmsProvider) credentials() (*sigv4.Credentials, error) {
+	c := &sigv4.Credentials{
+		AccessKeyID:     os.Getenv(p.AccessKeyEnv),
+		SecretAccessKey: os.Getenv(p.SecretKeyEnv),
+		SessionToken:    os.Getenv(p.SessionTokenEnv),
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errors.New("kms credentials are not found in environment")
+	}
+	return c, nil
+}
+
+func (p *kmsProvider) Fetch(key string) ([]byte, error) {
+	creds, err := p.credentials()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{"CiphertextBlob": key})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", kmsContentType)
+	req.Header.Set("X-Amz-Target", kmsDecryptTarget)
+	signed := sigv4.Sign(&sigv4.Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Host:   req.URL.Host,
+		Headers: map[string]string{
+			"content-type": kmsContentType,
+			"x-amz-target": kmsDecryptTarget,
+		},
+		Body: body,
+	}, kmsService, p.Region, creds, timeNow())
+	for name, value := range signed {
+		req.Header.Set(name, value)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := &kmsDecryptResponse{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("kms response status %d: %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kms response status %d: %s %s", resp.StatusCode, result.Type, result.Message)
+	}
+	return base64.StdEncoding.DecodeString(result.Plaintext)
+}
@@ -0,0 +1,355 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package secret fetches the named secrets from the pluggable providers, such as environment variables,
+// files, vault and AWS KMS. The KMS provider decrypts the ciphertext in config by the KMS HTTP API signed
+// with sigv4, other providers can be registered by RegisterProvider.
+// The secrets are fetched periodically, and the rotation callbacks are called when the values are changed.
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/utils"
+)
+
+// ReferencePrefix is the prefix of the config values that reference the named secrets, such as "secret://tls-key"
+const ReferencePrefix = "secret://"
+
+const defaultRefreshInterval = time.Minute
+
+var (
+	ErrSecretNotFound   = errors.New("secret not found")
+	ErrProviderNotFound = errors.New("secret provider not found")
+)
+
+// Provider fetches the secret values, it should be safe for concurrent use
+type Provider interface {
+	Fetch(key string) ([]byte, error)
+}
+
+// ProviderFactory creates a provider by the config
+type ProviderFactory func(config map[string]interface{}) (Provider, error)
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = map[string]ProviderFactory{}
+)
+
+// RegisterProvider registers a provider type
+func RegisterProvider(typ string, factory ProviderFactory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	factories[typ] = factory
+}
+
+func getProviderFactory(typ string) (ProviderFactory, bool) {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+	f, ok := factories[typ]
+	return f, ok
+}
+
+type secret struct {
+	provider  Provider
+	key       string
+	value     []byte
+	callbacks []func(value []byte)
+}
+
+// Manager holds the named secrets
+type Manager struct {
+	mutex    sync.RWMutex
+	secrets  map[string]*secret
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewManager creates the providers and fetches the secrets, an error is returned
+// if any secret can not be fetched
+func NewManager(cfg *v2.SecretsConfig) (*Manager, error) {
+	m := &Manager{
+		secrets:  make(map[string]*secret, len(cfg.Secrets)),
+		interval: defaultRefreshInterval,
+		stop:     make(chan struct{}),
+	}
+	if cfg.RefreshInterval.Duration > 0 {
+		m.interval = cfg.RefreshInterval.Duration
+	}
+	providers := make(map[string]Provider, len(cfg.Providers))
+	for _, pc := range cfg.Providers {
+		if pc.Name == "" {
+			return nil, errors.New("secret provider name is required")
+		}
+		if _, ok := providers[pc.Name]; ok {
+			return nil, fmt.Errorf("duplicate secret provider: %s", pc.Name)
+		}
+		factory, ok := getProviderFactory(pc.Type)
+		if !ok {
+			return nil, fmt.Errorf("unknown secret provider type: %s", pc.Type)
+		}
+		p, err := factory(pc.Config)
+		if err != nil {
+			return nil, fmt.Errorf("create secret provider %s failed: %v", pc.Name, err)
+		}
+		providers[pc.Name] = p
+	}
+	for _, sc := range cfg.Secrets {
+		if sc.Name == "" {
+			return nil, errors.New("secret name is required")
+		}
+		if _, ok := m.secrets[sc.Name]; ok {
+			return nil, fmt.Errorf("duplicate secret: %s", sc.Name)
+		}
+		p, ok := providers[sc.Provider]
+		if !ok {
+			return nil, fmt.Errorf("secret %s: %v: %s", sc.Name, ErrProviderNotFound, sc.Provider)
+		}
+		value, err := p.Fetch(sc.Key)
+		if err != nil {
+			return nil, fmt.Errorf("fetch secret %s failed: %v", sc.Name, err)
+		}
+		m.secrets[sc.Name] = &secret{
+			provider: p,
+			key:      sc.Key,
+			value:    value,
+		}
+	}
+	return m, nil
+}
+
+// Get returns the value of the named secret
+func (m *Manager) Get(name string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	s, ok := m.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrSecretNotFound, name)
+	}
+	return s.value, nil
+}
+
+// Watch adds a rotation callback of the named secret, the callback is called with the new value
+func (m *Manager) Watch(name string, cb func(value []byte)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	s, ok := m.secrets[name]
+	if !ok {
+		return fmt.Errorf("%v: %s", ErrSecretNotFound, name)
+	}
+	s.callbacks = append(s.callbacks, cb)
+	return nil
+}
+
+// Refresh fetches all of the secrets again, the callbacks of the changed secrets are called.
+// The secret keeps the old value if it can not be fetched.
+func (m *Manager) Refresh() {
+	m.mutex.RLock()
+	names := make([]string, 0, len(m.secrets))
+	for name := range m.secrets {
+		names = append(names, name)
+	}
+	m.mutex.RUnlock()
+	for _, name := range names {
+		m.refresh(name)
+	}
+}
+
+func (m *Manager) refresh(name string) {
+	m.mutex.RLock()
+	s := m.secrets[name]
+	m.mutex.RUnlock()
+	value, err := s.provider.Fetch(s.key)
+	if err != nil {
+		log.DefaultLogger.Errorf("[secret] fetch secret %s failed: %v", name, err)
+		return
+	}
+	m.mutex.Lock()
+	if bytes.Equal(value, s.value) {
+		m.mutex.Unlock()
+		return
+	}
+	s.value = value
+	m.mutex.Unlock()
+	m.notify(name)
+}
+
+// Start refreshes the secrets periodically
+func (m *Manager) Start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.Refresh()
+			case <-m.stop:
+				return
+			}
+		}
+	}, nil)
+}
+
+func (m *Manager) Stop() {
+	m.once.Do(func() {
+		close(m.stop)
+	})
+}
+
+var (
+	defaultMutex   sync.RWMutex
+	defaultManager *Manager
+)
+
+// Init creates the default manager and starts refreshing, the previous default manager is stopped.
+// The rotation callbacks of the previous default manager are kept by the secrets of the same names,
+// and are called if the values are changed.
+func Init(cfg *v2.SecretsConfig) error {
+	m, err := NewManager(cfg)
+	if err != nil {
+		return err
+	}
+	defaultMutex.Lock()
+	old := defaultManager
+	var rotated []string
+	if old != nil {
+		rotated = m.takeCallbacks(old)
+	}
+	defaultManager = m
+	defaultMutex.Unlock()
+	if old != nil {
+		old.Stop()
+	}
+	for _, name := range rotated {
+		m.notify(name)
+	}
+	m.Start()
+	return nil
+}
+
+// takeCallbacks moves the rotation callbacks of the old manager to the secrets of the same names,
+// returns the names of the secrets whose values are changed
+func (m *Manager) takeCallbacks(old *Manager) []string {
+	old.mutex.Lock()
+	defer old.mutex.Unlock()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var rotated []string
+	for name, s := range old.secrets {
+		if len(s.callbacks) == 0 {
+			continue
+		}
+		ns, ok := m.secrets[name]
+		if !ok {
+			log.DefaultLogger.Warnf("[secret] secret %s is removed, the rotation callbacks are dropped", name)
+			continue
+		}
+		ns.callbacks = append(ns.callbacks, s.callbacks...)
+		s.callbacks = nil
+		if !bytes.Equal(s.value, ns.value) {
+			rotated = append(rotated, name)
+		}
+	}
+	return rotated
+}
+
+// notify calls the rotation callbacks of the secret with the current value
+func (m *Manager) notify(name string) {
+	m.mutex.RLock()
+	s := m.secrets[name]
+	value := s.value
+	callbacks := make([]func([]byte), len(s.callbacks))
+	copy(callbacks, s.callbacks)
+	m.mutex.RUnlock()
+	log.DefaultLogger.Infof("[secret] secret %s is rotated", name)
+	for _, cb := range callbacks {
+		cb(value)
+	}
+}
+
+// Stop stops the default manager
+func Stop() {
+	defaultMutex.Lock()
+	m := defaultManager
+	defaultManager = nil
+	defaultMutex.Unlock()
+	if m != nil {
+		m.Stop()
+	}
+}
+
+func getDefaultManager() *Manager {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultManager
+}
+
+// Get returns the value of the named secret in the default manager
+func Get(name string) ([]byte, error) {
+	m := getDefaultManager()
+	if m == nil {
+		return nil, fmt.Errorf("%v: %s", ErrSecretNotFound, name)
+	}
+	return m.Get(name)
+}
+
+// Watch adds a rotation callback of the named secret in the default manager,
+// the callback is kept when the default manager is replaced by Init
+func Watch(name string, cb func(value []byte)) error {
+	// hold the lock, so the callback is not added to a replaced manager
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	if defaultManager == nil {
+		return fmt.Errorf("%v: %s", ErrSecretNotFound, name)
+	}
+	return defaultManager.Watch(name, cb)
+}
+
+// Refresh fetches the secrets of the default manager again
+func Refresh() {
+	if m := getDefaultManager(); m != nil {
+		m.Refresh()
+	}
+}
+
+// ReferenceName returns the secret name if the value references a secret
+func ReferenceName(value string) (string, bool) {
+	if !strings.HasPrefix(value, ReferencePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, ReferencePrefix), true
+}
+
+// Resolve returns the secret value if the value references a secret, otherwise returns the value itself
+func Resolve(value string) (string, error) {
+	name, ok := ReferenceName(value)
+	if !ok {
+		return value, nil
+	}
+	v, err := Get(name)
+	if err != nil {
+		return "", err
+	}
+	return string(v), nil
+}
@@ -0,0 +1,284 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "hmac"), []byte("key1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_SECRET_TOKEN", "token")
+	defer os.Unsetenv("TEST_SECRET_TOKEN")
+
+	m, err := NewManager(&v2.SecretsConfig{
+		Providers: []v2.SecretProviderConfig{
+			{Name: "env", Type: ProviderEnv},
+			{Name: "files", Type: ProviderFile, Config: map[string]interface{}{
+				"dir":        dir,
+				"trim_space": true,
+			}},
+		},
+		Secrets: []v2.SecretConfig{
+			{Name: "token", Provider: "env", Key: "TEST_SECRET_TOKEN"},
+			{Name: "hmac", Provider: "files", Key: "hmac"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Get("token"); err != nil || string(v) != "token" {
+		t.Fatalf("unexpected token: %s, %v", v, err)
+	}
+	if v, err := m.Get("hmac"); err != nil || string(v) != "key1" {
+		t.Fatalf("unexpected hmac: %s, %v", v, err)
+	}
+	if _, err := m.Get("unknown"); err == nil {
+		t.Fatal("expected an error for the unknown secret")
+	}
+
+	var rotated []string
+	if err := m.Watch("hmac", func(value []byte) {
+		rotated = append(rotated, string(value))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// not changed
+	m.Refresh()
+	if len(rotated) != 0 {
+		t.Fatalf("unexpected rotation: %v", rotated)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "hmac"), []byte("key2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m.Refresh()
+	if len(rotated) != 1 || rotated[0] != "key2" {
+		t.Fatalf("unexpected rotation: %v", rotated)
+	}
+	// the old value is kept if the secret can not be fetched
+	os.Remove(filepath.Join(dir, "hmac"))
+	m.Refresh()
+	if v, _ := m.Get("hmac"); string(v) != "key2" || len(rotated) != 1 {
+		t.Fatalf("unexpected hmac: %s", v)
+	}
+}
+
+func TestManagerInvalid(t *testing.T) {
+	for i, cfg := range []*v2.SecretsConfig{
+		{Providers: []v2.SecretProviderConfig{{Name: "p", Type: "unknown"}}},
+		{Providers: []v2.SecretProviderConfig{{Name: "p", Type: ProviderEnv}, {Name: "p", Type: ProviderEnv}}},
+		{Secrets: []v2.SecretConfig{{Name: "s", Provider: "unknown", Key: "k"}}},
+		{
+			Providers: []v2.SecretProviderConfig{{Name: "env", Type: ProviderEnv}},
+			Secrets:   []v2.SecretConfig{{Name: "s", Provider: "env", Key: "TEST_SECRET_NOT_EXISTS"}},
+		},
+	} {
+		if _, err := NewManager(cfg); err == nil {
+			t.Fatalf("case %d expected an error", i)
+		}
+	}
+}
+
+func TestInitKeepsCallbacks(t *testing.T) {
+	os.Setenv("TEST_SECRET_INIT", "v1")
+	defer os.Unsetenv("TEST_SECRET_INIT")
+	cfg := &v2.SecretsConfig{
+		Providers: []v2.SecretProviderConfig{{Name: "env", Type: ProviderEnv}},
+		Secrets:   []v2.SecretConfig{{Name: "s", Provider: "env", Key: "TEST_SECRET_INIT"}},
+	}
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer Stop()
+	var rotated []string
+	if err := Watch("s", func(value []byte) {
+		rotated = append(rotated, string(value))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// not changed
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 0 {
+		t.Fatalf("unexpected rotation: %v", rotated)
+	}
+	// the callback is called by the new manager
+	os.Setenv("TEST_SECRET_INIT", "v2")
+	if err := Init(cfg); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_SECRET_INIT", "v3")
+	Refresh()
+	if len(rotated) != 2 || rotated[0] != "v2" || rotated[1] != "v3" {
+		t.Fatalf("unexpected rotation: %v", rotated)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	os.Setenv("TEST_SECRET_RESOLVE", "value")
+	defer os.Unsetenv("TEST_SECRET_RESOLVE")
+	if err := Init(&v2.SecretsConfig{
+		Providers: []v2.SecretProviderConfig{{Name: "env", Type: ProviderEnv}},
+		Secrets:   []v2.SecretConfig{{Name: "s", Provider: "env", Key: "TEST_SECRET_RESOLVE"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer Stop()
+	for _, c := range []struct {
+		value    string
+		expected string
+		valid    bool
+	}{
+		{"plain", "plain", true},
+		{"secret://s", "value", true},
+		{"secret://unknown", "", false},
+	} {
+		v, err := Resolve(c.value)
+		if (err == nil) != c.valid || v != c.expected {
+			t.Fatalf("resolve %s unexpected result: %s, %v", c.value, v, err)
+		}
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/mosn/tls" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"key":"pem","version":2}}}`))
+	}))
+	defer server.Close()
+
+	p, err := newVaultProvider(map[string]interface{}{
+		"address": server.URL + "/",
+		"token":   "root",
+		"mount":   "kv",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		key      string
+		expected string
+		valid    bool
+	}{
+		{"mosn/tls#key", "pem", true},
+		{"mosn/tls#version", "2", true},
+		{"mosn/tls", `{"key":"pem","version":2}`, true},
+		{"mosn/tls#unknown", "", false},
+		{"mosn/unknown#key", "", false},
+	} {
+		v, err := p.Fetch(c.key)
+		if (err == nil) != c.valid || string(v) != c.expected {
+			t.Fatalf("fetch %s unexpected result: %s, %v", c.key, v, err)
+		}
+	}
+
+	p, err = newVaultProvider(map[string]interface{}{
+		"address": server.URL,
+		"token":   "invalid",
+		"mount":   "kv",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Fetch("mosn/tls#key"); err == nil {
+		t.Fatal("expected a permission denied error")
+	}
+	os.Unsetenv("VAULT_ADDR")
+	if _, err := newVaultProvider(nil); err == nil {
+		t.Fatal("expected an error without address and token")
+	}
+}
+
+func TestKMSProvider(t *testing.T) {
+	plaintext := base64.StdEncoding.EncodeToString([]byte("pem"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=ak/") || !strings.Contains(auth, "/us-west-2/kms/aws4_request") ||
+			r.Header.Get("X-Amz-Target") != "TrentService.Decrypt" || r.Header.Get("X-Amz-Security-Token") != "token" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"InvalidSignatureException","message":"invalid signature"}`))
+			return
+		}
+		req := map[string]string{}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil || req["CiphertextBlob"] != "ciphertext" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"invalid ciphertext"}`))
+			return
+		}
+		w.Write([]byte(`{"KeyId":"arn:aws:kms:us-west-2:111122223333:key/test","Plaintext":"` + plaintext + `"}`))
+	}))
+	defer server.Close()
+
+	os.Setenv("TEST_KMS_AK", "ak")
+	os.Setenv("TEST_KMS_SK", "sk")
+	os.Setenv("TEST_KMS_TOKEN", "token")
+	defer os.Unsetenv("TEST_KMS_AK")
+	defer os.Unsetenv("TEST_KMS_SK")
+	defer os.Unsetenv("TEST_KMS_TOKEN")
+	p, err := newKMSProvider(map[string]interface{}{
+		"region":            "us-west-2",
+		"endpoint":          server.URL + "/",
+		"access_key_env":    "TEST_KMS_AK",
+		"secret_key_env":    "TEST_KMS_SK",
+		"session_token_env": "TEST_KMS_TOKEN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := p.Fetch("ciphertext"); err != nil || string(v) != "pem" {
+		t.Fatalf("unexpected decrypted secret: %s, %v", v, err)
+	}
+	if _, err := p.Fetch("invalid"); err == nil || !strings.Contains(err.Error(), "InvalidCiphertextException") {
+		t.Fatalf("expected an invalid ciphertext error, but got: %v", err)
+	}
+	os.Unsetenv("TEST_KMS_SK")
+	if _, err := p.Fetch("ciphertext"); err == nil {
+		t.Fatal("expected an error without credentials")
+	}
+	os.Unsetenv("AWS_REGION")
+	if _, err := newKMSProvider(nil); err == nil {
+		t.Fatal("expected an error without region")
+	}
+}
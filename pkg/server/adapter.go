@@ -145,16 +145,19 @@ func (adapter *ListenerAdapter) UpdateListenerTLS(serverName string, listenerNam
 	if ln := connHandler.FindListenerByName(listenerName); ln != nil {
 		cfg := *ln.Config() // should clone a config
 		cfg.Inspector = inspector
-		cfg.FilterChains = []v2.FilterChain{
-			{
-				FilterChainConfig: v2.FilterChainConfig{
-					FilterChainMatch: cfg.FilterChains[0].FilterChainMatch,
-					Filters:          cfg.FilterChains[0].Filters,
-					TLSConfigs:       tlsConfigs,
-				},
-				TLSContexts: tlsConfigs,
+		// the tls configs are applied to the first filter chain, the others are kept
+		chains := make([]v2.FilterChain, len(cfg.FilterChains))
+		copy(chains, cfg.FilterChains)
+		chains[0] = v2.FilterChain{
+			FilterChainConfig: v2.FilterChainConfig{
+				FilterChainMatch: cfg.FilterChains[0].FilterChainMatch,
+				MatchCriteria:    cfg.FilterChains[0].MatchCriteria,
+				Filters:          cfg.FilterChains[0].Filters,
+				TLSConfigs:       tlsConfigs,
 			},
+			TLSContexts: tlsConfigs,
 		}
+		cfg.FilterChains = chains
 		if _, err := connHandler.AddOrUpdateListener(&cfg, nil, nil); err != nil {
 			return fmt.Errorf("connHandler.UpdateListenerTLS called error, server:%s, error: %s", serverName, err.Error())
 		}
@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestSetCPUAffinity(t *testing.T) {
+	var origin unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &origin); err != nil {
+		t.Skipf("get cpu affinity failed: %v", err)
+	}
+	var cpus []int
+	for cpu := 0; cpu < len(origin)*64; cpu++ {
+		if origin.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	// pin to the first cpu allowed, and restore
+	if err := setCPUAffinity(cpus[:1]); err != nil {
+		t.Fatalf("set cpu affinity failed: %v", err)
+	}
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil || set.Count() != 1 || !set.IsSet(cpus[0]) {
+		t.Fatalf("unexpected cpu affinity: %v, %v", set, err)
+	}
+	if err := setCPUAffinity(cpus); err != nil {
+		t.Fatalf("restore cpu affinity failed: %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+// The variables of the connection access logs
+const (
+	VarConnectionEvent         = "connection_event"
+	VarConnectionID            = "connection_id"
+	VarConnectionListener      = "connection_listener"
+	VarConnectionStartTime     = "connection_start_time"
+	VarConnectionDuration      = "connection_duration"
+	VarConnectionBytesSent     = "connection_bytes_sent"
+	VarConnectionBytesReceived = "connection_bytes_received"
+	VarConnectionLocalAddress  = "connection_local_address"
+	VarConnectionRemoteAddress = "connection_remote_address"
+	VarConnectionTermination   = "connection_termination"
+	VarConnectionTLSVersion    = "connection_tls_version"
+	VarConnectionTLSCipher     = "connection_tls_cipher"
+	VarConnectionTLSServerName = "connection_tls_server_name"
+	VarConnectionTLSPeerName   = "connection_tls_peer_name"
+)
+
+// The connection events of the connection access logs
+const (
+	connectionEventOpen  = "open"
+	connectionEventClose = "close"
+)
+
+var connectionVariables = []variable.Variable{
+	variable.NewBasicVariable(VarConnectionEvent, nil, connectionEventGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionID, nil, connectionIDGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionListener, nil, connectionListenerGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionStartTime, nil, connectionStartTimeGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionDuration, nil, connectionDurationGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionBytesSent, nil, connectionBytesSentGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionBytesReceived, nil, connectionBytesReceivedGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionLocalAddress, nil, connectionLocalAddressGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionRemoteAddress, nil, connectionRemoteAddressGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionTermination, nil, connectionTerminationGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionTLSVersion, nil, connectionTLSVersionGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionTLSCipher, nil, connectionTLSCipherGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionTLSServerName, nil, connectionTLSServerNameGetter, nil, 0),
+	variable.NewBasicVariable(VarConnectionTLSPeerName, nil, connectionTLSPeerNameGetter, nil, 0),
+}
+
+func init() {
+	for idx := range connectionVariables {
+		variable.RegisterVariable(connectionVariables[idx])
+	}
+}
+
+// connectionAccessLog is an access log written for the connections
+type connectionAccessLog struct {
+	api.AccessLog
+	logOnOpen bool
+}
+
+func newConnectionAccessLogs(lc *v2.Listener) ([]*connectionAccessLog, error) {
+	logs := make([]*connectionAccessLog, 0, len(lc.ConnectionAccessLogs))
+	for _, cfg := range lc.ConnectionAccessLogs {
+		// use default listener connection log path
+		if cfg.Path == "" {
+			cfg.Path = types.MosnLogBasePath + string(os.PathSeparator) + lc.Name + "_connection.log"
+		}
+		al, err := log.NewAccessLogWithConfig(cfg.AccessLog)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, &connectionAccessLog{
+			AccessLog: al,
+			logOnOpen: cfg.LogOnOpen,
+		})
+	}
+	return logs, nil
+}
+
+// connectionLogInfo contains the information of a connection for the connection access logs
+type connectionLogInfo struct {
+	listener      string
+	conn          api.Connection
+	startTime     time.Time
+	bytesReceived uint64
+	bytesSent     uint64
+	// the fields below are set when the connection is closed
+	event       string
+	duration    time.Duration
+	termination api.ConnectionEvent
+}
+
+// connectionLogger writes the connection access logs of a connection
+type connectionLogger struct {
+	ctx  context.Context
+	info *connectionLogInfo
+	logs []*connectionAccessLog
+}
+
+func newConnectionLogger(listener string, conn api.Connection, logs []*connectionAccessLog) *connectionLogger {
+	if len(logs) == 0 {
+		return nil
+	}
+	info := &connectionLogInfo{
+		listener:  listener,
+		conn:      conn,
+		startTime: time.Now(),
+		event:     connectionEventOpen,
+	}
+	cl := &connectionLogger{
+		ctx:  mosnctx.WithValue(context.Background(), types.ContextKeyConnectionLog, info),
+		info: info,
+		logs: logs,
+	}
+	conn.AddBytesReadListener(func(bytesRead uint64) {
+		atomic.AddUint64(&info.bytesReceived, bytesRead)
+	})
+	conn.AddBytesSentListener(func(bytesSent uint64) {
+		atomic.AddUint64(&info.bytesSent, bytesSent)
+	})
+	return cl
+}
+
+// onOpen writes the logs configured to log on the connection established
+func (cl *connectionLogger) onOpen() {
+	if cl == nil {
+		return
+	}
+	for _, l := range cl.logs {
+		if l.logOnOpen {
+			l.Log(cl.ctx, nil, nil, nil)
+		}
+	}
+}
+
+// onClose writes all the logs with the termination reason
+func (cl *connectionLogger) onClose(event api.ConnectionEvent) {
+	if cl == nil {
+		return
+	}
+	cl.info.event = connectionEventClose
+	cl.info.duration = time.Since(cl.info.startTime)
+	cl.info.termination = event
+	for _, l := range cl.logs {
+		l.Log(cl.ctx, nil, nil, nil)
+	}
+}
+
+func connectionLogInfoByContext(ctx context.Context) *connectionLogInfo {
+	info, _ := mosnctx.Get(ctx, types.ContextKeyConnectionLog).(*connectionLogInfo)
+	return info
+}
+
+func connectionEventGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return info.event, nil
+}
+
+func connectionIDGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return strconv.FormatUint(info.conn.ID(), 10), nil
+}
+
+func connectionListenerGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return info.listener, nil
+}
+
+func connectionStartTimeGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return info.startTime.Format("2006/01/02 15:04:05.000"), nil
+}
+
+// connectionDurationGetter returns the duration of the closed connection, the open connection's is 0s
+func connectionDurationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return info.duration.String(), nil
+}
+
+func connectionBytesSentGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return strconv.FormatUint(atomic.LoadUint64(&info.bytesSent), 10), nil
+}
+
+func connectionBytesReceivedGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return variable.ValueNotFound, nil
+	}
+	return strconv.FormatUint(atomic.LoadUint64(&info.bytesReceived), 10), nil
+}
+
+func connectionLocalAddressGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil || info.conn.LocalAddr() == nil {
+		return variable.ValueNotFound, nil
+	}
+	return info.conn.LocalAddr().String(), nil
+}
+
+func connectionRemoteAddressGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil || info.conn.RemoteAddr() == nil {
+		return variable.ValueNotFound, nil
+	}
+	return info.conn.RemoteAddr().String(), nil
+}
+
+// connectionTerminationGetter returns the close event of the connection, such as RemoteClose and LocalClose
+func connectionTerminationGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil || info.termination == "" {
+		return variable.ValueNotFound, nil
+	}
+	return string(info.termination), nil
+}
+
+// tlsConnection returns the tls connection, nil if the connection is not tls
+func tlsConnection(ctx context.Context) *mtls.TLSConn {
+	info := connectionLogInfoByContext(ctx)
+	if info == nil {
+		return nil
+	}
+	conn, _ := info.conn.RawConn().(*mtls.TLSConn)
+	return conn
+}
+
+// the tls variables are not found if the connection is not tls, or the handshake is not completed yet
+
+func connectionTLSVersionGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	conn := tlsConnection(ctx)
+	if conn == nil {
+		return variable.ValueNotFound, nil
+	}
+	state := conn.ConnectionState()
+	if !state.HandshakeComplete {
+		return variable.ValueNotFound, nil
+	}
+	return mtls.VersionName(state.Version), nil
+}
+
+func connectionTLSCipherGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	conn := tlsConnection(ctx)
+	if conn == nil {
+		return variable.ValueNotFound, nil
+	}
+	state := conn.ConnectionState()
+	if !state.HandshakeComplete {
+		return variable.ValueNotFound, nil
+	}
+	return mtls.CipherSuiteName(state.CipherSuite), nil
+}
+
+func connectionTLSServerNameGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	conn := tlsConnection(ctx)
+	if conn == nil {
+		return variable.ValueNotFound, nil
+	}
+	state := conn.ConnectionState()
+	if !state.HandshakeComplete || state.ServerName == "" {
+		return variable.ValueNotFound, nil
+	}
+	return state.ServerName, nil
+}
+
+// connectionTLSPeerNameGetter returns the common name of the client certificate
+func connectionTLSPeerNameGetter(ctx context.Context, value *variable.IndexedValue, data interface{}) (string, error) {
+	conn := tlsConnection(ctx)
+	if conn == nil {
+		return variable.ValueNotFound, nil
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return variable.ValueNotFound, nil
+	}
+	return peerName(certs[0]), nil
+}
+
+func peerName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return variable.ValueNotFound
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/variable"
+)
+
+type mockLogConnection struct {
+	api.Connection
+	raw          net.Conn
+	readListener func(uint64)
+	sentListener func(uint64)
+}
+
+func (c *mockLogConnection) ID() uint64 {
+	return 10
+}
+
+func (c *mockLogConnection) LocalAddr() net.Addr {
+	return c.raw.LocalAddr()
+}
+
+func (c *mockLogConnection) RemoteAddr() net.Addr {
+	return c.raw.RemoteAddr()
+}
+
+func (c *mockLogConnection) RawConn() net.Conn {
+	return c.raw
+}
+
+func (c *mockLogConnection) AddBytesReadListener(cb func(bytesRead uint64)) {
+	c.readListener = cb
+}
+
+func (c *mockLogConnection) AddBytesSentListener(cb func(bytesSent uint64)) {
+	c.sentListener = cb
+}
+
+// mockConnectionAccessLog records the variable values when Log is called
+type mockConnectionAccessLog struct {
+	names   []string
+	entries []map[string]string
+}
+
+func (l *mockConnectionAccessLog) Log(ctx context.Context, reqHeaders api.HeaderMap, respHeaders api.HeaderMap, requestInfo api.RequestInfo) {
+	entry := map[string]string{}
+	for _, name := range l.names {
+		entry[name], _ = variable.GetVariableValue(ctx, name)
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func TestConnectionLogger(t *testing.T) {
+	raw, peer := net.Pipe()
+	defer raw.Close()
+	defer peer.Close()
+	conn := &mockLogConnection{raw: raw}
+	openLog := &mockConnectionAccessLog{names: []string{VarConnectionEvent, VarConnectionID, VarConnectionListener}}
+	closeLog := &mockConnectionAccessLog{names: []string{
+		VarConnectionEvent, VarConnectionBytesSent, VarConnectionBytesReceived,
+		VarConnectionTermination, VarConnectionTLSVersion, VarConnectionRemoteAddress,
+	}}
+	// no logs configured
+	if newConnectionLogger("test", conn, nil) != nil {
+		t.Fatal("expected no logger without the logs")
+	}
+	var none *connectionLogger
+	none.onOpen()
+	none.onClose(api.RemoteClose)
+
+	logger := newConnectionLogger("test_listener", conn, []*connectionAccessLog{
+		{AccessLog: openLog, logOnOpen: true},
+		{AccessLog: closeLog},
+	})
+	logger.onOpen()
+	conn.readListener(100)
+	conn.readListener(20)
+	conn.sentListener(50)
+	logger.onClose(api.RemoteClose)
+
+	if len(openLog.entries) != 2 || len(closeLog.entries) != 1 {
+		t.Fatalf("unexpected log count, open: %d, close: %d", len(openLog.entries), len(closeLog.entries))
+	}
+	if e := openLog.entries[0]; e[VarConnectionEvent] != "open" || e[VarConnectionID] != "10" || e[VarConnectionListener] != "test_listener" {
+		t.Errorf("unexpected open log: %v", e)
+	}
+	if e := openLog.entries[1]; e[VarConnectionEvent] != "close" {
+		t.Errorf("unexpected close log: %v", e)
+	}
+	e := closeLog.entries[0]
+	if e[VarConnectionEvent] != "close" || e[VarConnectionBytesSent] != "50" || e[VarConnectionBytesReceived] != "120" ||
+		e[VarConnectionTermination] != string(api.RemoteClose) || e[VarConnectionRemoteAddress] != "pipe" {
+		t.Errorf("unexpected close log: %v", e)
+	}
+	// not a tls connection
+	if e[VarConnectionTLSVersion] != variable.ValueNotFound {
+		t.Errorf("unexpected tls version: %s", e[VarConnectionTLSVersion])
+	}
+}
+
+func TestNewConnectionAccessLogs(t *testing.T) {
+	lc := &v2.Listener{}
+	lc.Name = "test_connection_log"
+	lc.ConnectionAccessLogs = []v2.ConnectionAccessLog{
+		{
+			AccessLog: v2.AccessLog{
+				Path:   "/tmp/mosn_test/connection.log",
+				Format: "%connection_event% %connection_duration%",
+			},
+			LogOnOpen: true,
+		},
+	}
+	logs, err := newConnectionAccessLogs(lc)
+	if err != nil || len(logs) != 1 || !logs[0].logOnOpen {
+		t.Fatalf("create connection access logs failed: %v", err)
+	}
+	lc.ConnectionAccessLogs[0].Format = "%connection_event"
+	if _, err := newConnectionAccessLogs(lc); err == nil {
+		t.Fatal("expected an error for the invalid format")
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/event"
+	"mosn.io/mosn/pkg/log"
+)
+
+// DrainStatus is the drain state of the connections created by the old filter chains of a listener
+type DrainStatus struct {
+	Draining bool `json:"draining"`
+	// Connections is the number of connections remain to be drained
+	Connections int       `json:"connections"`
+	StartTime   time.Time `json:"start_time,omitempty"`
+	Deadline    time.Time `json:"deadline,omitempty"`
+}
+
+// ListenerStatus is the runtime state of a listener, reported by the admin api
+type ListenerStatus struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// Removed is true if the listener is removed and its connections are still draining
+	Removed bool `json:"removed,omitempty"`
+	// Generation increases when the filter chains of the listener is updated
+	Generation  uint64      `json:"generation"`
+	Connections int         `json:"connections"`
+	Drain       DrainStatus `json:"drain"`
+}
+
+// listenerDrainer closes the connections created before a generation after the drain timeout,
+// while the new connections use the new filter chains.
+type listenerDrainer struct {
+	mutex      sync.Mutex
+	generation uint64 // connections created before the generation are draining
+	start      time.Time
+	deadline   time.Time
+	timer      *time.Timer
+}
+
+func (al *activeListener) drainTimeout() time.Duration {
+	if timeout := al.listener.Config().DrainTimeout; timeout != nil && timeout.Duration > 0 {
+		return timeout.Duration
+	}
+	return GracefulTimeout
+}
+
+// nextGeneration is called when the filter chains are updated, returns the new generation
+func (al *activeListener) nextGeneration() uint64 {
+	return atomic.AddUint64(&al.generation, 1)
+}
+
+// updateGeneration is called when the filter chains are updated, the connections created
+// by the old filter chains start draining.
+func (al *activeListener) updateGeneration() {
+	generation := al.nextGeneration()
+	if len(al.drainingConnections(generation)) > 0 {
+		al.drain(generation)
+	}
+}
+
+// drain starts to drain the connections created before the generation.
+// the connections are closed after the drain timeout if they are not closed by the peers.
+func (al *activeListener) drain(generation uint64) {
+	timeout := al.drainTimeout()
+	d := &al.drainer
+	d.mutex.Lock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.generation = generation
+	d.start = time.Now()
+	d.deadline = d.start.Add(timeout)
+	d.timer = time.AfterFunc(timeout, func() {
+		al.closeDrainingConnections(generation)
+	})
+	d.mutex.Unlock()
+
+	log.DefaultLogger.Infof("[server] [listener] listener %s start draining connections before generation %d, timeout: %v", al.listener.Name(), generation, timeout)
+	event.Publish(event.ListenerDrain, al.listener.Name(), map[string]string{
+		"generation": strconv.FormatUint(generation, 10),
+		"timeout":    timeout.String(),
+	})
+}
+
+// drainingConnections returns the connections created before the generation
+func (al *activeListener) drainingConnections(generation uint64) []*activeConnection {
+	var conns []*activeConnection
+	al.connsMux.RLock()
+	for e := al.conns.Front(); e != nil; e = e.Next() {
+		if ac := e.Value.(*activeConnection); ac.generation < generation {
+			conns = append(conns, ac)
+		}
+	}
+	al.connsMux.RUnlock()
+	return conns
+}
+
+func (al *activeListener) closeDrainingConnections(generation uint64) {
+	// the connection is removed from the list when closed, so close them out of the lock
+	conns := al.drainingConnections(generation)
+	for _, ac := range conns {
+		ac.conn.Close(api.FlushWrite, api.LocalClose)
+	}
+	if len(conns) > 0 {
+		log.DefaultLogger.Infof("[server] [listener] listener %s drain timeout, close %d connections", al.listener.Name(), len(conns))
+	}
+}
+
+// status returns the runtime state of the listener
+func (al *activeListener) status() ListenerStatus {
+	st := ListenerStatus{
+		Name:       al.listener.Name(),
+		Address:    al.listener.Addr().String(),
+		Generation: atomic.LoadUint64(&al.generation),
+	}
+	al.connsMux.RLock()
+	if al.conns != nil {
+		st.Connections = al.conns.Len()
+	}
+	al.connsMux.RUnlock()
+
+	d := &al.drainer
+	d.mutex.Lock()
+	generation, start, deadline := d.generation, d.start, d.deadline
+	d.mutex.Unlock()
+	if generation > 0 {
+		if n := len(al.drainingConnections(generation)); n > 0 {
+			st.Drain = DrainStatus{
+				Draining:    true,
+				Connections: n,
+				StartTime:   start,
+				Deadline:    deadline,
+			}
+		}
+	}
+	return st
+}
+
+// ListenerStatuses returns the runtime state of the listeners in the server, including the
+// removed listeners whose connections are still draining.
+func (adapter *ListenerAdapter) ListenerStatuses(serverName string) []ListenerStatus {
+	handler, ok := adapter.findHandler(serverName).(*connHandler)
+	if !ok {
+		return nil
+	}
+	return handler.listenerStatuses()
+}
+
+func (ch *connHandler) listenerStatuses() []ListenerStatus {
+	listeners := ch.activeListeners()
+	statuses := make([]ListenerStatus, 0, len(listeners))
+	for _, al := range listeners {
+		statuses = append(statuses, al.status())
+	}
+	ch.removedMux.Lock()
+	defer ch.removedMux.Unlock()
+	removed := ch.removed[:0]
+	for _, al := range ch.removed {
+		st := al.status()
+		if st.Connections == 0 {
+			// drain finished
+			continue
+		}
+		st.Removed = true
+		statuses = append(statuses, st)
+		removed = append(removed, al)
+	}
+	ch.removed = removed
+	return statuses
+}
@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+)
+
+type mockDrainConnection struct {
+	api.Connection
+	closed  int32
+	onClose func()
+}
+
+func (c *mockDrainConnection) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	atomic.StoreInt32(&c.closed, 1)
+	c.onClose()
+	return nil
+}
+
+func newDrainTestListener(handler *connHandler, name string) *activeListener {
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name:       name,
+			AddrConfig: "127.0.0.1:0",
+			DrainTimeout: &api.DurationConfig{
+				Duration: 50 * time.Millisecond,
+			},
+		},
+	}
+	lc.Addr, _ = net.ResolveTCPAddr("tcp", "127.0.0.1:0")
+	al := &activeListener{
+		listener: network.NewListener(lc),
+		conns:    list.New(),
+		handler:  handler,
+	}
+	handler.listeners = append(handler.listeners, al)
+	return al
+}
+
+func addDrainTestConnection(al *activeListener) *mockDrainConnection {
+	conn := &mockDrainConnection{}
+	ac := &activeConnection{
+		listener:   al,
+		conn:       conn,
+		generation: al.generation,
+	}
+	conn.onClose = func() {
+		al.removeConnection(ac)
+	}
+	al.connsMux.Lock()
+	ac.element = al.conns.PushBack(ac)
+	al.connsMux.Unlock()
+	return conn
+}
+
+func (c *mockDrainConnection) isClosed() bool {
+	return atomic.LoadInt32(&c.closed) == 1
+}
+
+func TestListenerDrainOnUpdate(t *testing.T) {
+	handler := &connHandler{}
+	al := newDrainTestListener(handler, "test_drain_update")
+	oldConn := addDrainTestConnection(al)
+	al.updateGeneration()
+	newConn := addDrainTestConnection(al)
+
+	st := al.status()
+	if st.Generation != 1 || st.Connections != 2 || !st.Drain.Draining || st.Drain.Connections != 1 {
+		t.Fatalf("unexpected listener status: %+v", st)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !oldConn.isClosed() || newConn.isClosed() {
+		t.Fatalf("the old connection should be closed after drain timeout, old: %v, new: %v", oldConn.isClosed(), newConn.isClosed())
+	}
+	if st := al.status(); st.Drain.Draining || st.Connections != 1 {
+		t.Fatalf("unexpected listener status after drained: %+v", st)
+	}
+}
+
+func TestListenerDrainOnRemove(t *testing.T) {
+	handler := &connHandler{}
+	al := newDrainTestListener(handler, "test_drain_remove")
+	newDrainTestListener(handler, "test_drain_keep")
+	conn := addDrainTestConnection(al)
+
+	handler.RemoveListeners("test_drain_remove")
+	statuses := handler.listenerStatuses()
+	if len(statuses) != 2 || statuses[1].Name != "test_drain_remove" || !statuses[1].Removed || !statuses[1].Drain.Draining {
+		t.Fatalf("unexpected listener statuses: %+v", statuses)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !conn.isClosed() {
+		t.Fatal("the connection of the removed listener should be closed")
+	}
+	// the removed listener is not reported after drained
+	if statuses := handler.listenerStatuses(); len(statuses) != 1 || statuses[0].Name != "test_drain_keep" {
+		t.Fatalf("unexpected listener statuses: %+v", statuses)
+	}
+}
+
+func TestRemoveListenersConcurrentRead(t *testing.T) {
+	handler := &connHandler{}
+	for i := 0; i < 10; i++ {
+		newDrainTestListener(handler, fmt.Sprintf("test_remove_%d", i))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		name := fmt.Sprintf("test_remove_%d", i)
+		go func() {
+			defer wg.Done()
+			handler.RemoveListeners(name)
+		}()
+		go func() {
+			defer wg.Done()
+			handler.FindListenerByName(name)
+			handler.listenerStatuses()
+		}()
+	}
+	wg.Wait()
+	if l := handler.activeListeners(); len(l) != 0 {
+		t.Fatalf("expected all listeners removed, but got %d", len(l))
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	transportProtocolTLS = "tls"
+	transportProtocolRaw = "raw_buffer"
+)
+
+var errNoFilterChainMatched = errors.New("no filter chain matched")
+
+// filterChain is one of the filter chains of a listener, a connection uses the
+// network filters and the tls context of the first matched filter chain.
+type filterChain struct {
+	match                   *filterChainMatch
+	networkFiltersFactories []api.NetworkFilterChainFactory
+	tlsMng                  types.TLSContextManager
+}
+
+// filterChainMatch is the parsed v2.FilterChainMatchConfig
+type filterChainMatch struct {
+	ports        map[int]bool
+	serverNames  []string
+	sourceRanges []*net.IPNet
+	protocols    []string
+	transport    string
+}
+
+// connectionInfo is the information of a new connection used in filter chain match
+type connectionInfo struct {
+	port       int
+	sourceIP   net.IP
+	tls        bool
+	serverName string
+	protocols  []string
+}
+
+func newFilterChainMatch(cfg *v2.FilterChainMatchConfig) (*filterChainMatch, error) {
+	m := &filterChainMatch{}
+	if cfg == nil {
+		return m, nil
+	}
+	if len(cfg.DestinationPorts) > 0 {
+		m.ports = make(map[int]bool, len(cfg.DestinationPorts))
+		for _, port := range cfg.DestinationPorts {
+			m.ports[int(port)] = true
+		}
+	}
+	for _, name := range cfg.ServerNames {
+		m.serverNames = append(m.serverNames, strings.ToLower(name))
+	}
+	for _, prefix := range cfg.SourcePrefixRanges {
+		_, ipnet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source prefix range %s: %v", prefix, err)
+		}
+		m.sourceRanges = append(m.sourceRanges, ipnet)
+	}
+	m.protocols = cfg.ApplicationProtocols
+	switch cfg.TransportProtocol {
+	case "", transportProtocolTLS, transportProtocolRaw:
+		m.transport = cfg.TransportProtocol
+	default:
+		return nil, fmt.Errorf("invalid transport protocol %s", cfg.TransportProtocol)
+	}
+	return m, nil
+}
+
+// needClientHello returns true if the match depends on the TLS ClientHello
+func (m *filterChainMatch) needClientHello() bool {
+	return len(m.serverNames) > 0 || len(m.protocols) > 0 || m.transport != ""
+}
+
+func (m *filterChainMatch) matches(info *connectionInfo) bool {
+	if m.ports != nil && !m.ports[info.port] {
+		return false
+	}
+	if len(m.sourceRanges) > 0 && !m.matchSource(info.sourceIP) {
+		return false
+	}
+	switch m.transport {
+	case transportProtocolTLS:
+		if !info.tls {
+			return false
+		}
+	case transportProtocolRaw:
+		if info.tls {
+			return false
+		}
+	}
+	if len(m.serverNames) > 0 && !m.matchServerName(info.serverName) {
+		return false
+	}
+	if len(m.protocols) > 0 && !m.matchProtocols(info.protocols) {
+		return false
+	}
+	return true
+}
+
+func (m *filterChainMatch) matchSource(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range m.sourceRanges {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchServerName supports the exact server name and the wildcard server name like "*.example.com"
+func (m *filterChainMatch) matchServerName(serverName string) bool {
+	if serverName == "" {
+		return false
+	}
+	serverName = strings.ToLower(serverName)
+	for _, name := range m.serverNames {
+		if name == serverName {
+			return true
+		}
+		if strings.HasPrefix(name, "*.") && strings.HasSuffix(serverName, name[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *filterChainMatch) matchProtocols(protocols []string) bool {
+	for _, p := range protocols {
+		for _, expected := range m.protocols {
+			if p == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newFilterChains creates the filter chains for a listener that has multiple filter chains or match criteria.
+// nil is returned if the listener has only one filter chain without match criteria, which is handled
+// by the listener's network filters and tls context directly.
+// The network filters of the first filter chain are created by the caller.
+func newFilterChains(lc *v2.Listener, networkFiltersFactories []api.NetworkFilterChainFactory) ([]*filterChain, error) {
+	if len(lc.FilterChains) == 0 || (len(lc.FilterChains) == 1 && lc.FilterChains[0].MatchCriteria == nil) {
+		return nil, nil
+	}
+	chains := make([]*filterChain, 0, len(lc.FilterChains))
+	for i := range lc.FilterChains {
+		fc := &lc.FilterChains[i]
+		match, err := newFilterChainMatch(fc.MatchCriteria)
+		if err != nil {
+			return nil, fmt.Errorf("filter chain %d: %v", i, err)
+		}
+		// each filter chain has its own tls context
+		mng, err := mtls.NewTLSServerContextManager(&v2.Listener{
+			ListenerConfig: v2.ListenerConfig{
+				FilterChains: []v2.FilterChain{*fc},
+				Inspector:    lc.Inspector,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("filter chain %d: %v", i, err)
+		}
+		factories := networkFiltersFactories
+		if i > 0 {
+			factories = configmanager.GetNetworkFilters(fc)
+		}
+		chains = append(chains, &filterChain{
+			match:                   match,
+			networkFiltersFactories: factories,
+			tlsMng:                  mng,
+		})
+	}
+	return chains, nil
+}
+
+// selectFilterChain finds the first filter chain that matches the connection, and returns the connection
+// wrapped by the tls context of the filter chain. A filter chain without tls context passes through
+// the tls connection. The TLS ClientHello is peeked only if any filter chain depends on it.
+func selectFilterChain(chains []*filterChain, rawc net.Conn, port int) (*filterChain, net.Conn, error) {
+	info := &connectionInfo{
+		port: port,
+	}
+	if addr, ok := rawc.RemoteAddr().(*net.TCPAddr); ok {
+		info.sourceIP = addr.IP
+	}
+	conn := rawc
+	for _, fc := range chains {
+		if fc.match.needClientHello() {
+			pc := mtls.NewPeekConn(rawc)
+			hello, err := pc.PeekClientHello()
+			if err != nil {
+				return nil, nil, err
+			}
+			if hello != nil {
+				info.tls = true
+				info.serverName = hello.ServerName
+				info.protocols = hello.Protocols
+			}
+			conn = pc
+			break
+		}
+	}
+	for _, fc := range chains {
+		if !fc.match.matches(info) {
+			continue
+		}
+		c, err := fc.tlsMng.Conn(conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fc, c, nil
+	}
+	return nil, nil, errNoFilterChainMatched
+}
@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls"
+)
+
+func TestFilterChainMatch(t *testing.T) {
+	m, err := newFilterChainMatch(&v2.FilterChainMatchConfig{
+		DestinationPorts:     []uint32{443},
+		ServerNames:          []string{"*.Example.com", "foo.test"},
+		SourcePrefixRanges:   []string{"10.0.0.0/8"},
+		ApplicationProtocols: []string{"h2"},
+		TransportProtocol:    "tls",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched := connectionInfo{
+		port:       443,
+		sourceIP:   net.ParseIP("10.1.2.3"),
+		tls:        true,
+		serverName: "api.example.com",
+		protocols:  []string{"http/1.1", "h2"},
+	}
+	if !m.matches(&matched) {
+		t.Fatal("expected matched")
+	}
+	cases := []func(info *connectionInfo){
+		func(info *connectionInfo) { info.port = 80 },
+		func(info *connectionInfo) { info.sourceIP = net.ParseIP("192.168.1.1") },
+		func(info *connectionInfo) { info.tls = false },
+		func(info *connectionInfo) { info.serverName = "example.com" },
+		func(info *connectionInfo) { info.serverName = "" },
+		func(info *connectionInfo) { info.protocols = []string{"http/1.1"} },
+	}
+	for i, modify := range cases {
+		info := matched
+		modify(&info)
+		if m.matches(&info) {
+			t.Errorf("case %d: expected not matched", i)
+		}
+	}
+	// empty match criteria matches any connection
+	empty, _ := newFilterChainMatch(nil)
+	if !empty.matches(&connectionInfo{}) || empty.needClientHello() {
+		t.Error("empty match criteria should match any connection")
+	}
+	if _, err := newFilterChainMatch(&v2.FilterChainMatchConfig{SourcePrefixRanges: []string{"10.0.0.1"}}); err == nil {
+		t.Error("expected invalid cidr error")
+	}
+	if _, err := newFilterChainMatch(&v2.FilterChainMatchConfig{TransportProtocol: "quic"}); err == nil {
+		t.Error("expected invalid transport protocol error")
+	}
+}
+
+func TestNewFilterChains(t *testing.T) {
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			FilterChains: []v2.FilterChain{{}},
+		},
+	}
+	// one filter chain without match criteria keeps the listener's behaviour
+	if chains, err := newFilterChains(lc, nil); err != nil || chains != nil {
+		t.Fatalf("unexpected filter chains: %v, %v", chains, err)
+	}
+	lc.FilterChains = append(lc.FilterChains, v2.FilterChain{
+		FilterChainConfig: v2.FilterChainConfig{
+			MatchCriteria: &v2.FilterChainMatchConfig{
+				DestinationPorts: []uint32{8080},
+			},
+		},
+	})
+	chains, err := newFilterChains(lc, nil)
+	if err != nil || len(chains) != 2 {
+		t.Fatalf("unexpected filter chains: %v, %v", chains, err)
+	}
+}
+
+func TestSelectFilterChainByServerName(t *testing.T) {
+	passthrough := []api.NetworkFilterChainFactory{nil}
+	matchSNI, _ := newFilterChainMatch(&v2.FilterChainMatchConfig{
+		ServerNames:          []string{"*.example.com"},
+		ApplicationProtocols: []string{"h2"},
+	})
+	matchAny, _ := newFilterChainMatch(nil)
+	// no tls context is configured, the tls connections are passed through
+	mng, err := mtls.NewTLSServerContextManager(&v2.Listener{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chains := []*filterChain{
+		{match: matchSNI, networkFiltersFactories: passthrough, tlsMng: mng},
+		{match: matchAny, tlsMng: mng},
+	}
+
+	for _, c := range []struct {
+		serverName string
+		expected   *filterChain
+	}{
+		{"foo.example.com", chains[0]},
+		{"foo.test", chains[1]},
+	} {
+		client, server := net.Pipe()
+		go func() {
+			tls.Client(client, &tls.Config{
+				ServerName:         c.serverName,
+				NextProtos:         []string{"h2"},
+				InsecureSkipVerify: true,
+			}).Handshake()
+		}()
+		fc, conn, err := selectFilterChain(chains, server, 443)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fc != c.expected {
+			t.Errorf("server name %s selects an unexpected filter chain", c.serverName)
+		}
+		// the filter chain without tls context passes through the ClientHello
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(conn, header); err != nil || header[0] != 0x16 {
+			t.Errorf("unexpected tls record header: %v, %v", header, err)
+		}
+		client.Close()
+		server.Close()
+	}
+}
@@ -36,6 +36,7 @@ import (
 	admin "mosn.io/mosn/pkg/admin/store"
 	"mosn.io/mosn/pkg/config/v2"
 	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/event"
 	"mosn.io/mosn/pkg/filter/accept/originaldst"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/metrics"
@@ -50,8 +51,13 @@ import (
 // ClusterHostFactoryCb
 type connHandler struct {
 	numConnections int64
+	// listenersMux guards the changes of the listeners, which are read by the admin api
+	listenersMux   sync.RWMutex
 	listeners      []*activeListener
 	clusterManager types.ClusterManager
+	// removed listeners that are draining connections
+	removedMux sync.Mutex
+	removed    []*activeListener
 }
 
 // NewHandler
@@ -116,9 +122,8 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 			al.listener.Addr().Network() != lc.Addr.Network() {
 			return nil, errors.New("error updating listener, listen address and listen name doesn't match")
 		}
-		// currently, we just support one filter chain
-		if len(lc.FilterChains) != 1 {
-			return nil, errors.New("error updating listener, listener have filter chains count is not 1")
+		if len(lc.FilterChains) == 0 {
+			return nil, errors.New("error updating listener, listener have no filter chains")
 		}
 		rawConfig := al.listener.Config()
 		// FIXME: update log level need the pkg/logger support.
@@ -127,8 +132,10 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 		if networkFiltersFactories != nil {
 			log.DefaultLogger.Infof("[server] [AddOrUpdateListener] [update] update network filters")
 			al.networkFiltersFactories = networkFiltersFactories
-			rawConfig.FilterChains[0].FilterChainMatch = lc.FilterChains[0].FilterChainMatch
-			rawConfig.FilterChains[0].Filters = lc.FilterChains[0].Filters
+			rawConfig.FilterChains = make([]v2.FilterChain, len(lc.FilterChains))
+			copy(rawConfig.FilterChains, lc.FilterChains)
+		} else if len(lc.FilterChains) != len(rawConfig.FilterChains) {
+			return nil, errors.New("error updating listener, filter chains count changed without network filters")
 		}
 		if streamFiltersFactories != nil {
 			log.DefaultLogger.Infof("[server] [AddOrUpdateListener] [update] update stream filters")
@@ -138,17 +145,25 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 
 		// tls update only take effects on new connections
 		// config changed
-		rawConfig.FilterChains[0].TLSContexts = lc.FilterChains[0].TLSContexts
-		rawConfig.FilterChains[0].TLSConfig = lc.FilterChains[0].TLSConfig
-		rawConfig.FilterChains[0].TLSConfigs = lc.FilterChains[0].TLSConfigs
+		for i := range lc.FilterChains {
+			rawConfig.FilterChains[i].TLSContexts = lc.FilterChains[i].TLSContexts
+			rawConfig.FilterChains[i].TLSConfig = lc.FilterChains[i].TLSConfig
+			rawConfig.FilterChains[i].TLSConfigs = lc.FilterChains[i].TLSConfigs
+		}
 		rawConfig.Inspector = lc.Inspector
 		mgr, err := mtls.NewTLSServerContextManager(rawConfig)
 		if err != nil {
 			log.DefaultLogger.Errorf("[server] [conn handler] [update listener] create tls context manager failed, %v", err)
 			return nil, err
 		}
+		chains, err := newFilterChains(rawConfig, al.networkFiltersFactories)
+		if err != nil {
+			log.DefaultLogger.Errorf("[server] [conn handler] [update listener] create filter chains failed, %v", err)
+			return nil, err
+		}
 		// object changed
 		al.tlsMng = mgr
+		al.filterChains = chains
 		// some simle config update
 		rawConfig.PerConnBufferLimitBytes = lc.PerConnBufferLimitBytes
 		al.listener.SetPerConnBufferLimitBytes(lc.PerConnBufferLimitBytes)
@@ -157,9 +172,18 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 		rawConfig.UseOriginalDst = lc.UseOriginalDst
 		al.listener.SetUseOriginalDst(lc.UseOriginalDst)
 		al.idleTimeout = lc.ConnectionIdleTimeout
+		rawConfig.ConnectionBuffer = lc.ConnectionBuffer
+		al.bufferPolicy = network.NewBufferPolicy(lc.ConnectionBuffer)
+		rawConfig.DrainTimeout = lc.DrainTimeout
+		rawConfig.ConnectionBalance = lc.ConnectionBalance
 
 		al.listener.SetConfig(rawConfig)
 
+		// the connections created by the old network filters are drained
+		if networkFiltersFactories != nil {
+			al.updateGeneration()
+		}
+
 		// set update label to true, do not start the listener again
 		al.updatedLabel = true
 		log.DefaultLogger.Infof("[server] [conn handler] [update listener] update listener: %s", lc.AddrConfig)
@@ -179,7 +203,7 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 				alConfig.Path = types.MosnLogBasePath + string(os.PathSeparator) + lc.Name + "_access.log"
 			}
 
-			if al, err := log.NewAccessLog(alConfig.Path, alConfig.Format); err == nil {
+			if al, err := log.NewAccessLogWithConfig(alConfig); err == nil {
 				als = append(als, al)
 			} else {
 				return nil, fmt.Errorf("initialize listener access logger %s failed: %v", alConfig.Path, err.Error())
@@ -194,15 +218,27 @@ func (ch *connHandler) AddOrUpdateListener(lc *v2.Listener, networkFiltersFactor
 			return al, err
 		}
 		l.SetListenerCallbacks(al)
+		ch.listenersMux.Lock()
 		ch.listeners = append(ch.listeners, al)
+		ch.listenersMux.Unlock()
 		log.DefaultLogger.Infof("[server] [conn handler] [add listener] add listener: %s", lc.AddrConfig)
 	}
 	admin.SetListenerConfig(listenerName, *al.listener.Config())
 	return al, nil
 }
 
+// activeListeners returns a snapshot of the listeners, so the callers can iterate
+// without holding the lock while the listeners are changed.
+func (ch *connHandler) activeListeners() []*activeListener {
+	ch.listenersMux.RLock()
+	defer ch.listenersMux.RUnlock()
+	listeners := make([]*activeListener, len(ch.listeners))
+	copy(listeners, ch.listeners)
+	return listeners
+}
+
 func (ch *connHandler) StartListener(lctx context.Context, listenerTag uint64) {
-	for _, l := range ch.listeners {
+	for _, l := range ch.activeListeners() {
 		if l.listener.ListenerTag() == listenerTag {
 			// TODO: use goroutine pool
 			l.GoStart(lctx)
@@ -211,7 +247,7 @@ func (ch *connHandler) StartListener(lctx context.Context, listenerTag uint64) {
 }
 
 func (ch *connHandler) StartListeners(lctx context.Context) {
-	for _, l := range ch.listeners {
+	for _, l := range ch.activeListeners() {
 		// start goroutine
 		l.GoStart(lctx)
 	}
@@ -238,22 +274,42 @@ func (ch *connHandler) FindListenerByName(name string) types.Listener {
 }
 
 func (ch *connHandler) RemoveListeners(name string) {
-	for i, l := range ch.listeners {
+	var removed []*activeListener
+	ch.listenersMux.Lock()
+	listeners := ch.listeners[:0]
+	for _, l := range ch.listeners {
 		if l.listener.Name() == name {
-			log.DefaultLogger.Infof("[server] [conn handler] remove listener name: %s", name)
-			ch.listeners = append(ch.listeners[:i], ch.listeners[i+1:]...)
+			removed = append(removed, l)
+		} else {
+			listeners = append(listeners, l)
 		}
 	}
+	for i := len(listeners); i < len(ch.listeners); i++ {
+		ch.listeners[i] = nil
+	}
+	ch.listeners = listeners
+	ch.listenersMux.Unlock()
+
+	for _, l := range removed {
+		log.DefaultLogger.Infof("[server] [conn handler] remove listener name: %s", name)
+		admin.RemoveListenerConfig(name)
+		// the connections of the removed listener are drained
+		ch.removedMux.Lock()
+		ch.removed = append(ch.removed, l)
+		ch.removedMux.Unlock()
+		l.drain(l.nextGeneration())
+	}
 }
 
 func (ch *connHandler) StopListener(lctx context.Context, name string, close bool) error {
-	for _, l := range ch.listeners {
+	for _, l := range ch.activeListeners() {
 		if l.listener.Name() == name {
 			// stop goroutine
 			if close {
 				return l.listener.Close(lctx)
 			}
 
+			event.Publish(event.ListenerDrain, name, nil)
 			return l.listener.Stop()
 		}
 	}
@@ -263,13 +319,14 @@ func (ch *connHandler) StopListener(lctx context.Context, name string, close boo
 
 func (ch *connHandler) StopListeners(lctx context.Context, close bool) error {
 	var errGlobal error
-	for _, l := range ch.listeners {
+	for _, l := range ch.activeListeners() {
 		// stop goroutine
 		if close {
 			if err := l.listener.Close(lctx); err != nil {
 				errGlobal = err
 			}
 		} else {
+			event.Publish(event.ListenerDrain, l.listener.Name(), nil)
 			if err := l.listener.Stop(); err != nil {
 				errGlobal = err
 			}
@@ -280,9 +337,10 @@ func (ch *connHandler) StopListeners(lctx context.Context, close bool) error {
 }
 
 func (ch *connHandler) ListListenersFile(lctx context.Context) []*os.File {
-	files := make([]*os.File, len(ch.listeners))
+	listeners := ch.activeListeners()
+	files := make([]*os.File, len(listeners))
 
-	for idx, l := range ch.listeners {
+	for idx, l := range listeners {
 		file, err := l.listener.ListenerFile()
 		if err != nil {
 			log.DefaultLogger.Errorf("[server] [conn handler] fail to get listener %s file descriptor: %v", l.listener.Name(), err)
@@ -294,6 +352,8 @@ func (ch *connHandler) ListListenersFile(lctx context.Context) []*os.File {
 }
 
 func (ch *connHandler) findActiveListenerByAddress(addr net.Addr) *activeListener {
+	ch.listenersMux.RLock()
+	defer ch.listenersMux.RUnlock()
 	for _, l := range ch.listeners {
 		if l.listener != nil {
 			if l.listener.Addr().Network() == addr.Network() &&
@@ -307,6 +367,8 @@ func (ch *connHandler) findActiveListenerByAddress(addr net.Addr) *activeListene
 }
 
 func (ch *connHandler) findActiveListenerByName(name string) *activeListener {
+	ch.listenersMux.RLock()
+	defer ch.listenersMux.RUnlock()
 	for _, l := range ch.listeners {
 		if l.listener != nil {
 			if l.listener.Name() == name {
@@ -319,7 +381,7 @@ func (ch *connHandler) findActiveListenerByName(name string) *activeListener {
 }
 
 func (ch *connHandler) StopConnection() {
-	for _, l := range ch.listeners {
+	for _, l := range ch.activeListeners() {
 		close(l.stopChan)
 	}
 }
@@ -337,9 +399,14 @@ type activeListener struct {
 	stopChan                    chan struct{}
 	stats                       *listenerStats
 	accessLogs                  []api.AccessLog
+	connectionLogs              []*connectionAccessLog
 	updatedLabel                bool
 	idleTimeout                 *api.DurationConfig
+	bufferPolicy                *network.BufferPolicy
 	tlsMng                      types.TLSContextManager
+	filterChains                []*filterChain // nil if the listener has only one filter chain without match
+	generation                  uint64         // increases when the network filters are updated
+	drainer                     listenerDrainer
 }
 
 func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers []api.AccessLog,
@@ -354,6 +421,7 @@ func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers [
 		accessLogs:   accessLoggers,
 		updatedLabel: false,
 		idleTimeout:  lc.ConnectionIdleTimeout,
+		bufferPolicy: network.NewBufferPolicy(lc.ConnectionBuffer),
 	}
 	al.streamFiltersFactoriesStore.Store(streamFiltersFactories)
 
@@ -370,6 +438,13 @@ func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers [
 	al.listenPort = listenPort
 	al.stats = newListenerStats(al.listener.Name())
 
+	connectionLogs, err := newConnectionAccessLogs(lc)
+	if err != nil {
+		log.DefaultLogger.Errorf("[server] [new listener] initialize connection access logger failed, %v", err)
+		return nil, err
+	}
+	al.connectionLogs = connectionLogs
+
 	mgr, err := mtls.NewTLSServerContextManager(lc)
 	if err != nil {
 		log.DefaultLogger.Errorf("[server] [new listener] create tls context manager failed, %v", err)
@@ -377,6 +452,13 @@ func newActiveListener(listener types.Listener, lc *v2.Listener, accessLoggers [
 	}
 	al.tlsMng = mgr
 
+	chains, err := newFilterChains(lc, networkFiltersFactories)
+	if err != nil {
+		log.DefaultLogger.Errorf("[server] [new listener] create filter chains failed, %v", err)
+		return nil, err
+	}
+	al.filterChains = chains
+
 	return al, nil
 }
 
@@ -394,6 +476,7 @@ func (al *activeListener) GoStart(lctx context.Context) {
 // ListenerEventListener
 func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemoteAddr net.Addr, ch chan api.Connection, buf []byte) {
 	var rawf *os.File
+	networkFiltersFactories := al.networkFiltersFactories
 
 	// only store fd and tls conn handshake in final working listener
 	if !useOriginalDst {
@@ -404,7 +487,22 @@ func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemote
 			}
 		}
 		// if ch is not nil, the conn has been initialized in func transferNewConn
-		if al.tlsMng != nil && ch == nil {
+		if chains := al.filterChains; len(chains) > 0 && ch == nil {
+			port := al.listenPort
+			if addr, ok := oriRemoteAddr.(*net.TCPAddr); ok {
+				port = addr.Port
+			}
+			fc, conn, err := selectFilterChain(chains, rawc, port)
+			if err != nil {
+				if log.DefaultLogger.GetLogLevel() >= log.INFO {
+					log.DefaultLogger.Infof("[server] [listener] accept connection failed, select filter chain error: %v", err)
+				}
+				rawc.Close()
+				return
+			}
+			rawc = conn
+			networkFiltersFactories = fc.networkFiltersFactories
+		} else if al.tlsMng != nil && ch == nil {
 			conn, err := al.tlsMng.Conn(rawc)
 			if err != nil {
 				if log.DefaultLogger.GetLogLevel() >= log.INFO {
@@ -431,9 +529,12 @@ func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemote
 	ctx := mosnctx.WithValue(context.Background(), types.ContextKeyListenerPort, al.listenPort)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerType, al.listener.Config().Type)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyListenerName, al.listener.Name())
-	ctx = mosnctx.WithValue(ctx, types.ContextKeyNetworkFilterChainFactories, al.networkFiltersFactories)
+	ctx = mosnctx.WithValue(ctx, types.ContextKeyNetworkFilterChainFactories, networkFiltersFactories)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyStreamFilterChainFactories, &al.streamFiltersFactoriesStore)
 	ctx = mosnctx.WithValue(ctx, types.ContextKeyAccessLogs, al.accessLogs)
+	if balance := al.listener.Config().ConnectionBalance; balance != "" {
+		ctx = mosnctx.WithValue(ctx, types.ContextKeyConnectionBalance, balance)
+	}
 	if rawf != nil {
 		ctx = mosnctx.WithValue(ctx, types.ContextKeyConnectionFd, rawf)
 	}
@@ -445,17 +546,42 @@ func (al *activeListener) OnAccept(rawc net.Conn, useOriginalDst bool, oriRemote
 		ctx = mosnctx.WithValue(ctx, types.ContextOriRemoteAddr, oriRemoteAddr)
 	}
 
+	// the tls handshake runs in the handshake workers, and the connection is created after the handshake
+	if pool := handshakePool; pool != nil && !useOriginalDst && ch == nil {
+		err := pool.Handshake(rawc, func(err error) {
+			if err != nil {
+				rawc.Close()
+				return
+			}
+			arc.ContinueFilterChain(ctx, true)
+		})
+		if err != nil {
+			rawc.Close()
+		}
+		return
+	}
+
 	arc.ContinueFilterChain(ctx, true)
 }
 
 func (al *activeListener) OnNewConnection(ctx context.Context, conn api.Connection) {
 	//Register Proxy's Filter
 	filterManager := conn.FilterManager()
-	for _, nfcf := range al.networkFiltersFactories {
+	// the network filters of the selected filter chain
+	networkFiltersFactories := al.networkFiltersFactories
+	if factories, ok := mosnctx.Get(ctx, types.ContextKeyNetworkFilterChainFactories).([]api.NetworkFilterChainFactory); ok {
+		networkFiltersFactories = factories
+	}
+	for _, nfcf := range networkFiltersFactories {
 		nfcf.CreateFilterChain(ctx, filterManager)
 	}
 	filterManager.InitializeReadFilters()
 
+	// the connection is closed by the network filters, such as the connection rate limit
+	if conn.State() == api.ConnClosed {
+		return
+	}
+
 	if len(filterManager.ListReadFilter()) == 0 &&
 		len(filterManager.ListWriteFilters()) == 0 {
 		// no filter found, close connection
@@ -470,6 +596,7 @@ func (al *activeListener) OnNewConnection(ctx context.Context, conn api.Connecti
 	ac.element = e
 
 	atomic.AddInt64(&al.handler.numConnections, 1)
+	ac.logger.onOpen()
 
 	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 		log.DefaultLogger.Debugf("[server] [listener] accept connection from %s, condId= %d, remote addr:%s", al.listener.Addr().String(), conn.ID(), conn.RemoteAddr().String())
@@ -508,8 +635,10 @@ func (al *activeListener) newConnection(ctx context.Context, rawc net.Conn) {
 		conn.SetRemoteAddr(oriRemoteAddr.(net.Addr))
 	}
 	newCtx := mosnctx.WithValue(ctx, types.ContextKeyConnectionID, conn.ID())
+	newCtx = mosnctx.WithValue(newCtx, types.ContextKeyDownstreamConnection, conn)
 
 	conn.SetBufferLimit(al.listener.PerConnBufferLimitBytes())
+	network.SetBufferPolicy(conn, al.bufferPolicy)
 
 	al.OnNewConnection(newCtx, conn)
 }
@@ -544,19 +673,6 @@ func (arc *activeRawConn) SetOriginalAddr(ip string, port int) {
 }
 
 func (arc *activeRawConn) UseOriginalDst(ctx context.Context) {
-	var listener, localListener *activeListener
-
-	for _, lst := range arc.activeListener.handler.listeners {
-		if lst.listenIP == arc.originalDstIP && lst.listenPort == arc.originalDstPort {
-			listener = lst
-			break
-		}
-
-		if lst.listenPort == arc.originalDstPort && lst.listenIP == "0.0.0.0" {
-			localListener = lst
-		}
-	}
-
 	var ch chan api.Connection
 	var buf []byte
 	if val := mosnctx.Get(ctx, types.ContextKeyAcceptChan); val != nil {
@@ -566,18 +682,49 @@ func (arc *activeRawConn) UseOriginalDst(ctx context.Context) {
 		}
 	}
 
-	if listener != nil {
-		if log.DefaultLogger.GetLogLevel() >= log.INFO {
-			log.DefaultLogger.Infof("[server] [conn] original dst:%s:%d", listener.listenIP, listener.listenPort)
+	listener := arc.matchOriginalDstListener()
+	if listener == nil {
+		// no listener matches the original destination, the redirecting listener handles the
+		// connection by itself if it has filters, just as the istio passthrough listener does.
+		if len(arc.activeListener.networkFiltersFactories) == 0 {
+			log.DefaultLogger.Errorf("[server] [conn] no listener found for original dst:%s:%d, close the connection", arc.originalDstIP, arc.originalDstPort)
+			arc.rawc.Close()
+			return
 		}
-		listener.OnAccept(arc.rawc, false, arc.oriRemoteAddr, ch, buf)
+		listener = arc.activeListener
 	}
-	if localListener != nil {
-		if log.DefaultLogger.GetLogLevel() >= log.INFO {
-			log.DefaultLogger.Infof("[server] [conn] original dst:%s:%d", localListener.listenIP, localListener.listenPort)
+
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[server] [conn] original dst:%s:%d, dispatch to listener %s", arc.originalDstIP, arc.originalDstPort, listener.listener.Name())
+	}
+	listener.OnAccept(arc.rawc, false, arc.oriRemoteAddr, ch, buf)
+}
+
+// matchOriginalDstListener finds the listener that handles the connection redirected by iptables.
+// A listener bound to the original destination ip and port is preferred, then a wildcard listener
+// on the same port. Listeners with a different traffic direction (ingress/egress) than the
+// redirecting listener are skipped, so inbound and outbound traffic on the same port are split.
+func (arc *activeRawConn) matchOriginalDstListener() *activeListener {
+	var wildcard *activeListener
+	direction := arc.activeListener.listener.Config().Type
+	handler := arc.activeListener.handler
+	handler.listenersMux.RLock()
+	defer handler.listenersMux.RUnlock()
+	for _, lst := range handler.listeners {
+		if lst == arc.activeListener || lst.listenPort != arc.originalDstPort {
+			continue
+		}
+		if typ := lst.listener.Config().Type; direction != "" && typ != "" && typ != direction {
+			continue
+		}
+		if lst.listenIP == arc.originalDstIP {
+			return lst
+		}
+		if lst.listenIP == "0.0.0.0" && wildcard == nil {
+			wildcard = lst
 		}
-		localListener.OnAccept(arc.rawc, false, arc.oriRemoteAddr, ch, buf)
 	}
+	return wildcard
 }
 
 func (arc *activeRawConn) ContinueFilterChain(ctx context.Context, success bool) {
@@ -610,15 +757,19 @@ func (arc *activeRawConn) Conn() net.Conn {
 // ListenerFilterManager note:unsupported now
 // ListenerFilterCallbacks note:unsupported now
 type activeConnection struct {
-	element  *list.Element
-	listener *activeListener
-	conn     api.Connection
+	element    *list.Element
+	listener   *activeListener
+	conn       api.Connection
+	generation uint64
+	logger     *connectionLogger
 }
 
 func newActiveConnection(listener *activeListener, conn api.Connection) *activeConnection {
 	ac := &activeConnection{
-		conn:     conn,
-		listener: listener,
+		conn:       conn,
+		listener:   listener,
+		generation: atomic.LoadUint64(&listener.generation),
+		logger:     newConnectionLogger(listener.listener.Name(), conn, listener.connectionLogs),
 	}
 
 	ac.conn.SetNoDelay(true)
@@ -643,6 +794,7 @@ func newActiveConnection(listener *activeListener, conn api.Connection) *activeC
 func (ac *activeConnection) OnEvent(event api.ConnectionEvent) {
 	if event.IsClose() {
 		ac.listener.removeConnection(ac)
+		ac.logger.onClose(event)
 	}
 }
 
@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/network"
+)
+
+func newTestActiveListener(handler *connHandler, name string, typ v2.ListenerType, ip string, port int) *activeListener {
+	lc := &v2.Listener{
+		ListenerConfig: v2.ListenerConfig{
+			Name: name,
+			Type: typ,
+		},
+	}
+	al := &activeListener{
+		listener:   network.NewListener(lc),
+		listenIP:   ip,
+		listenPort: port,
+		handler:    handler,
+	}
+	handler.listeners = append(handler.listeners, al)
+	return al
+}
+
+func TestMatchOriginalDstListener(t *testing.T) {
+	handler := &connHandler{}
+	outbound := newTestActiveListener(handler, "virtualOutbound", v2.EGRESS, "0.0.0.0", 15001)
+	inbound := newTestActiveListener(handler, "virtualInbound", v2.INGRESS, "0.0.0.0", 15006)
+	exact := newTestActiveListener(handler, "10.0.0.1_8080", v2.EGRESS, "10.0.0.1", 8080)
+	wildcard := newTestActiveListener(handler, "0.0.0.0_8080", v2.EGRESS, "0.0.0.0", 8080)
+	inboundApp := newTestActiveListener(handler, "inbound_9080", v2.INGRESS, "0.0.0.0", 9080)
+	newTestActiveListener(handler, "outbound_9080", v2.EGRESS, "0.0.0.0", 9080)
+
+	cases := []struct {
+		from     *activeListener
+		ip       string
+		port     int
+		expected *activeListener
+	}{
+		{outbound, "10.0.0.1", 8080, exact},
+		{outbound, "10.0.0.2", 8080, wildcard},
+		{inbound, "10.0.0.3", 9080, inboundApp},
+		{inbound, "10.0.0.1", 8080, nil},
+		{outbound, "10.0.0.1", 15001, nil},
+		{outbound, "10.0.0.1", 7777, nil},
+	}
+	for i, c := range cases {
+		arc := newActiveRawConn(nil, c.from)
+		arc.originalDstIP = c.ip
+		arc.originalDstPort = c.port
+		if lst := arc.matchOriginalDstListener(); lst != c.expected {
+			t.Errorf("case %d: unexpected listener matched: %v", i, lst)
+		}
+	}
+}
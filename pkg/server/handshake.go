@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
+)
+
+// handshakePool runs the TLS handshakes of the accepted connections, nil means the handshakes
+// run in the connection IO workers
+var handshakePool *mtls.HandshakePool
+
+func initHandshakePool(config *v2.WorkersConfig) {
+	if config == nil || config.HandshakeWorkers <= 0 {
+		return
+	}
+	// the handshake pool is created once, and kept when the server is reconfigured
+	if handshakePool != nil {
+		return
+	}
+	var timeout time.Duration
+	if config.HandshakeTimeout != nil {
+		timeout = config.HandshakeTimeout.Duration
+	}
+	handshakePool = mtls.NewHandshakePool(config.HandshakeWorkers, config.HandshakeQueueSize, timeout)
+	log.DefaultLogger.Infof("[server] [handshake pool] tls handshake workers: %d, queue size: %d",
+		config.HandshakeWorkers, config.HandshakeQueueSize)
+}
@@ -118,6 +118,11 @@ func catchSignalsCrossPlatform() {
 					}
 				}
 			case syscall.SIGUSR2:
+				if cbs, ok := signalCallback[syscall.SIGUSR2]; ok {
+					for _, cb := range cbs {
+						cb()
+					}
+				}
 			}
 		}
 	}, nil)
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+)
+
+// overloadChecker is a simple overload heuristic based on the active streams and connections
+type overloadChecker struct {
+	maxActiveStreams     int64
+	maxActiveConnections int64
+	activeStreams        gometrics.Counter
+}
+
+func newOverloadChecker(config *v2.OverloadConfig) *overloadChecker {
+	return &overloadChecker{
+		maxActiveStreams:     config.MaxActiveStreams,
+		maxActiveConnections: config.MaxActiveConnections,
+		// the global proxy stats counts all of the active streams
+		activeStreams: metrics.NewProxyStats(types.GlobalProxyName).Counter(metrics.DownstreamRequestActive),
+	}
+}
+
+func (oc *overloadChecker) overloaded() bool {
+	if oc.maxActiveStreams > 0 && oc.activeStreams.Count() >= oc.maxActiveStreams {
+		return true
+	}
+	if oc.maxActiveConnections > 0 && activeConnections() >= oc.maxActiveConnections {
+		return true
+	}
+	return false
+}
+
+func activeConnections() (n int64) {
+	// it is called before each accept, so the servers are not copied
+	serversMutex.RLock()
+	defer serversMutex.RUnlock()
+	for _, srv := range servers {
+		if ch, ok := srv.handler.(*connHandler); ok {
+			n += int64(ch.NumConnections())
+		}
+	}
+	return
+}
+
+func initAcceptThrottle(config *v2.OverloadConfig) {
+	if config == nil || (config.MaxActiveStreams <= 0 && config.MaxActiveConnections <= 0) {
+		network.SetAcceptThrottle(nil, 0)
+		return
+	}
+	log.DefaultLogger.Infof("[server] [overload] accept throttle enabled, max active streams: %d, max active connections: %d",
+		config.MaxActiveStreams, config.MaxActiveConnections)
+	network.SetAcceptThrottle(newOverloadChecker(config).overloaded, config.CheckInterval.Duration)
+}
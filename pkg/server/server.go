@@ -20,6 +20,7 @@ package server
 import (
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"mosn.io/api"
@@ -35,6 +36,7 @@ import (
 
 // currently, only one server supported
 func GetServer() Server {
+	servers := getServers()
 	if len(servers) == 0 {
 		log.DefaultLogger.Errorf("[server] Server is nil and hasn't been initiated at this time")
 		return nil
@@ -43,7 +45,19 @@ func GetServer() Server {
 	return servers[0]
 }
 
-var servers []*server
+var (
+	serversMutex sync.RWMutex
+	servers      []*server
+)
+
+// getServers returns a copy of the servers, which may be read by the accept throttle of the listeners
+func getServers() []*server {
+	serversMutex.RLock()
+	defer serversMutex.RUnlock()
+	copied := make([]*server, len(servers))
+	copy(copied, servers)
+	return copied
+}
 
 type server struct {
 	serverName string
@@ -57,9 +71,12 @@ func NewConfig(c *v2.ServerConfig) *Config {
 		LogPath:         c.DefaultLogPath,
 		LogLevel:        configmanager.ParseLogLevel(c.DefaultLogLevel),
 		LogRoller:       c.GlobalLogRoller,
+		LogFallback:     c.LogFallback,
 		GracefulTimeout: c.GracefulTimeout.Duration,
 		Processor:       c.Processor,
 		UseNetpollMode:  c.UseNetpollMode,
+		Overload:        c.Overload,
+		Workers:         c.Workers,
 	}
 }
 
@@ -74,6 +91,18 @@ func NewServer(config *Config, cmFilter types.ClusterManagerFilter, clMng types.
 		if config.UseNetpollMode {
 			log.DefaultLogger.Infof("[server] [reconfigure] [new server] Netpoll mode enabled.")
 		}
+
+		initAcceptThrottle(config.Overload)
+
+		network.SetWorkers(config.Workers)
+		initHandshakePool(config.Workers)
+		if config.Workers != nil && len(config.Workers.CPUAffinity) > 0 {
+			if err := setCPUAffinity(config.Workers.CPUAffinity); err != nil {
+				log.DefaultLogger.Errorf("[server] [new server] set cpu affinity %v failed: %v", config.Workers.CPUAffinity, err)
+			} else {
+				log.DefaultLogger.Infof("[server] [new server] cpu affinity is set to %v", config.Workers.CPUAffinity)
+			}
+		}
 	}
 
 	runtime.GOMAXPROCS(config.Processor)
@@ -88,7 +117,9 @@ func NewServer(config *Config, cmFilter types.ClusterManagerFilter, clMng types.
 
 	initListenerAdapterInstance(server.serverName, server.handler)
 
+	serversMutex.Lock()
 	servers = append(servers, server)
+	serversMutex.Unlock()
 
 	return server
 }
@@ -128,26 +159,26 @@ func (srv *server) Handler() types.ConnectionHandler {
 }
 
 func Stop() {
-	for _, server := range servers {
+	for _, server := range getServers() {
 		server.Close()
 	}
 }
 
 func StopAccept() {
-	for _, server := range servers {
+	for _, server := range getServers() {
 		server.handler.StopListeners(nil, false)
 	}
 }
 
 func StopConnection() {
-	for _, server := range servers {
+	for _, server := range getServers() {
 		server.handler.StopConnection()
 	}
 }
 
 func ListListenersFile() []*os.File {
 	var files []*os.File
-	for _, server := range servers {
+	for _, server := range getServers() {
 		files = append(files, server.handler.ListListenersFile(nil)...)
 	}
 	return files
@@ -188,6 +219,11 @@ func InitDefaultLogger(config *Config) {
 		}
 	}
 
+	// the log fallback should be set before the loggers are created
+	if config.LogFallback != nil {
+		mlog.SetLogFallback(config.LogFallback)
+	}
+
 	err := mlog.InitDefaultLogger(logPath, logLevel)
 	if err != nil {
 		mlog.StartLogger.Fatalf("[server] [init] initialize default logger failed : %v", err)
@@ -31,9 +31,12 @@ type Config struct {
 	LogPath         string
 	LogLevel        log.Level
 	LogRoller       string
+	LogFallback     *v2.LogFallbackConfig
 	GracefulTimeout time.Duration
 	Processor       int
 	UseNetpollMode  bool
+	Overload        *v2.OverloadConfig
+	Workers         *v2.WorkersConfig
 }
 
 type Server interface {
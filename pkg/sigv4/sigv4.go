@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sigv4 implements AWS Signature Version 4, which signs the requests to AWS and the compatible services,
+// see https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	Algorithm = "AWS4-HMAC-SHA256"
+
+	HeaderDate          = "x-amz-date"
+	HeaderSecurityToken = "x-amz-security-token"
+	HeaderContentSHA256 = "x-amz-content-sha256"
+	HeaderAuthorization = "authorization"
+
+	headerHost = "host"
+	dateFormat = "20060102T150405Z"
+	shortDate  = "20060102"
+)
+
+// Credentials are the keys used to sign the requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Request is the request to sign
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Host   string
+	// Headers are the other headers to sign, the values should be trimmed. The empty values are ignored,
+	// and so are the host and the headers set by Sign, which are signed with the values of Sign.
+	Headers map[string]string
+	Body    []byte
+}
+
+// Sign signs the request, and returns the headers should be set to the request, includes
+// the x-amz-date, the x-amz-security-token if the session token is not empty,
+// the x-amz-content-sha256 if the service is s3, and the authorization.
+func Sign(r *Request, service, region string, creds *Credentials, now time.Time) map[string]string {
+	now = now.UTC()
+	amzDate := now.Format(dateFormat)
+	payloadHash := hashHex(r.Body)
+	result := map[string]string{
+		HeaderDate: amzDate,
+	}
+	if creds.SessionToken != "" {
+		result[HeaderSecurityToken] = creds.SessionToken
+	}
+	if service == "s3" {
+		result[HeaderContentSHA256] = payloadHash
+	}
+
+	values := make(map[string]string, len(r.Headers)+len(result)+1)
+	for h, v := range r.Headers {
+		if v != "" {
+			values[strings.ToLower(h)] = v
+		}
+	}
+	for name, v := range result {
+		values[name] = v
+	}
+	values[headerHost] = r.Host
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		canonicalURI(r.Path),
+		canonicalQuery(r.Query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(shortDate), region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		Algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), []byte(now.Format(shortDate)))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	key = hmacSHA256(key, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+
+	result[HeaderAuthorization] = fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		Algorithm, creds.AccessKeyID, scope, signedHeaders, signature)
+	return result
+}
+
+// canonicalURI encodes each segment of the path
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		segments[i] = escape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts the query parameters by name and value
+func canonicalQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return ""
+	}
+	params := make([]string, 0, len(values))
+	for k, vs := range values {
+		for _, v := range vs {
+			params = append(params, escape(k)+"="+escape(v))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// escape escapes all characters except the unreserved characters A-Z, a-z, 0-9, '-', '.', '_' and '~'
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
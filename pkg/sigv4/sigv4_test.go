@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sigv4
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// get-vanilla and get-vanilla-query-order-key-case in the aws sigv4 test suite
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	creds := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	for _, c := range []struct {
+		query     string
+		signature string
+	}{
+		{"", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	} {
+		signed := Sign(&Request{
+			Method: "GET",
+			Path:   "/",
+			Query:  c.query,
+			Host:   "example.amazonaws.com",
+		}, "service", "us-east-1", creds, now)
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + c.signature
+		if signed[HeaderAuthorization] != expected {
+			t.Errorf("unexpected authorization: %s", signed[HeaderAuthorization])
+		}
+		if signed[HeaderDate] != "20150830T123600Z" {
+			t.Errorf("unexpected date: %s", signed[HeaderDate])
+		}
+	}
+}
+
+func TestSignHeaders(t *testing.T) {
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signed := Sign(&Request{
+		Method: "POST",
+		Path:   "/",
+		Host:   "kms.us-east-1.amazonaws.com",
+		Headers: map[string]string{
+			"X-Amz-Target": "TrentService.Decrypt",
+			"Content-Type": "application/x-amz-json-1.1",
+			"X-Amz-Date":   "ignored",
+			"X-Empty":      "",
+		},
+		Body: []byte("{}"),
+	}, "s3", "us-east-1", &Credentials{AccessKeyID: "ak", SecretAccessKey: "sk", SessionToken: "token"}, now)
+	if signed[HeaderSecurityToken] != "token" || signed[HeaderContentSHA256] == "" || signed[HeaderDate] != "20150830T123600Z" {
+		t.Fatalf("unexpected signed headers: %v", signed)
+	}
+	if !strings.Contains(signed[HeaderAuthorization], "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token;x-amz-target,") {
+		t.Fatalf("unexpected authorization: %s", signed[HeaderAuthorization])
+	}
+}
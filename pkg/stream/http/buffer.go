@@ -21,6 +21,7 @@ import (
 
 	"github.com/valyala/fasthttp"
 	"mosn.io/mosn/pkg/buffer"
+	"mosn.io/mosn/pkg/memory"
 )
 
 func init() {
@@ -37,6 +38,11 @@ func (ctx httpBufferCtx) New() interface{} {
 	return new(httpBuffers)
 }
 
+// MemoryModule accounts the buffers as the stream objects
+func (ctx httpBufferCtx) MemoryModule() string {
+	return memory.ModuleStream
+}
+
 func (ctx httpBufferCtx) Reset(i interface{}) {
 	buf, _ := i.(*httpBuffers)
 	buf.serverStream = serverStream{}
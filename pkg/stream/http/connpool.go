@@ -20,6 +20,7 @@ package http
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"mosn.io/api"
@@ -49,11 +50,21 @@ type connPool struct {
 	clientMux        sync.Mutex
 	availableClients []*activeClient // available clients
 	totalClientCount uint64          // total clients
+	// clients are all of the connected clients, which are closed if the drain is timeout
+	clients map[*activeClient]struct{}
+	// drain states are protected by the clientMux
+	draining      bool
+	drainTimeout  bool
+	drainFinished bool
+	drained       chan bool
+
+	connectFailures uint64
 }
 
 func NewConnPool(host types.Host) types.ConnectionPool {
 	pool := &connPool{
-		host: host,
+		host:    host,
+		clients: make(map[*activeClient]struct{}),
 	}
 
 	if pool.statReport {
@@ -107,6 +118,9 @@ func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types
 	p.clientMux.Lock()
 	defer p.clientMux.Unlock()
 
+	if p.draining {
+		return nil, types.ConnectionFailure
+	}
 	n := len(p.availableClients)
 	// no available client
 	if n == 0 {
@@ -116,6 +130,7 @@ func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types
 			ac, reason := newActiveClient(ctx, p)
 			if ac != nil && reason == "" {
 				p.totalClientCount++
+				p.clients[ac] = struct{}{}
 			}
 			return ac, reason
 		} else {
@@ -132,6 +147,31 @@ func (p *connPool) getAvailableClient(ctx context.Context) (*activeClient, types
 	}
 }
 
+// Warmup creates a connection in advance if the pool has no available connections
+func (p *connPool) Warmup(ctx context.Context) bool {
+	p.clientMux.Lock()
+	defer p.clientMux.Unlock()
+
+	if p.draining {
+		return false
+	}
+	if len(p.availableClients) > 0 {
+		return true
+	}
+	maxConns := p.host.ClusterInfo().ResourceManager().Connections().Max()
+	if maxConns != 0 && p.totalClientCount >= maxConns {
+		return false
+	}
+	ac, reason := newActiveClient(ctx, p)
+	if ac == nil || reason != "" {
+		return false
+	}
+	p.totalClientCount++
+	p.clients[ac] = struct{}{}
+	p.availableClients = append(p.availableClients, ac)
+	return true
+}
+
 func (p *connPool) Close() {
 	p.clientMux.Lock()
 	defer p.clientMux.Unlock()
@@ -145,6 +185,75 @@ func (p *connPool) Shutdown() {
 	// TODO: http connpool do nothing for shutdown
 }
 
+// Drain closes the idle connections at once, and the busy connections are closed after their streams finished
+func (p *connPool) Drain(timeout time.Duration) <-chan bool {
+	p.clientMux.Lock()
+	if p.draining {
+		p.clientMux.Unlock()
+		return p.drained
+	}
+	p.draining = true
+	p.drained = make(chan bool, 1)
+	idle := p.availableClients
+	p.availableClients = nil
+	if p.totalClientCount == 0 {
+		p.finishDrain()
+	}
+	drained := p.drained
+	p.clientMux.Unlock()
+
+	for _, c := range idle {
+		c.client.Close()
+	}
+	time.AfterFunc(timeout, func() {
+		p.clientMux.Lock()
+		if p.drainFinished {
+			p.clientMux.Unlock()
+			return
+		}
+		p.drainTimeout = true
+		clients := make([]*activeClient, 0, len(p.clients))
+		for c := range p.clients {
+			clients = append(clients, c)
+		}
+		p.clientMux.Unlock()
+		for _, c := range clients {
+			c.client.Close()
+		}
+		p.clientMux.Lock()
+		p.finishDrain()
+		p.clientMux.Unlock()
+	})
+	return drained
+}
+
+// State returns the current state of the pool, a connection has one active stream at most
+func (p *connPool) State() types.ConnectionPoolState {
+	p.clientMux.Lock()
+	defer p.clientMux.Unlock()
+
+	total := len(p.clients)
+	idle := len(p.availableClients)
+	return types.ConnectionPoolState{
+		Connections:       total,
+		ActiveConnections: total - idle,
+		IdleConnections:   idle,
+		ActiveStreams:     total - idle,
+		ConnectFailures:   atomic.LoadUint64(&p.connectFailures),
+		Draining:          p.draining,
+	}
+}
+
+// finishDrain notifies the drain is finished once, must be called with the clientMux held
+func (p *connPool) finishDrain() {
+	if p.drainFinished {
+		return
+	}
+	p.drainFinished = true
+	p.drained <- p.drainTimeout
+	close(p.drained)
+}
+
 func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionEvent) {
 	if event.IsClose() {
 
@@ -163,6 +272,7 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 		defer p.clientMux.Unlock()
 
 		p.totalClientCount--
+		delete(p.clients, client)
 
 		for i, c := range p.availableClients {
 			if c == client {
@@ -174,11 +284,17 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 
 		// set closed flag if not available
 		client.closed = true
+
+		if p.draining && p.totalClientCount == 0 {
+			p.finishDrain()
+		}
 	} else if event == api.ConnectTimeout {
+		atomic.AddUint64(&p.connectFailures, 1)
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
 		client.client.Close()
 	} else if event == api.ConnectFailed {
+		atomic.AddUint64(&p.connectFailures, 1)
 		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
 	}
@@ -191,10 +307,16 @@ func (p *connPool) onStreamDestroy(client *activeClient) {
 
 	// return to pool
 	p.clientMux.Lock()
-	if !client.closed {
+	draining := p.draining
+	if !client.closed && !draining {
 		p.availableClients = append(p.availableClients, client)
 	}
+	closed := client.closed
 	p.clientMux.Unlock()
+
+	if draining && !closed {
+		client.client.Close()
+	}
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -21,6 +21,7 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"mosn.io/api"
 	mosnctx "mosn.io/mosn/pkg/context"
@@ -44,6 +45,13 @@ type connPool struct {
 	host         types.Host
 
 	mux sync.Mutex
+	// drain states are protected by the lock
+	draining      bool
+	drainTimeout  bool
+	drainFinished bool
+	drained       chan bool
+
+	connectFailures uint64
 }
 
 // NewConnPool
@@ -71,6 +79,9 @@ func (p *connPool) NewStream(ctx context.Context,
 	activeClient := func() *activeClient {
 		p.mux.Lock()
 		defer p.mux.Unlock()
+		if p.draining {
+			return nil
+		}
 		if p.activeClient == nil {
 			p.activeClient = newActiveClient(ctx, p)
 		}
@@ -88,6 +99,7 @@ func (p *connPool) NewStream(ctx context.Context,
 		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
 	} else {
 		atomic.AddUint64(&activeClient.totalStream, 1)
+		atomic.AddUint32(&activeClient.activeStreams, 1)
 		p.host.HostStats().UpstreamRequestTotal.Inc(1)
 		p.host.HostStats().UpstreamRequestActive.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
@@ -102,6 +114,19 @@ func (p *connPool) NewStream(ctx context.Context,
 	return
 }
 
+// Warmup creates the connection in advance
+func (p *connPool) Warmup(ctx context.Context) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.draining {
+		return false
+	}
+	if p.activeClient == nil {
+		p.activeClient = newActiveClient(ctx, p)
+	}
+	return p.activeClient != nil
+}
+
 func (p *connPool) Close() {
 	if p.activeClient != nil {
 		p.activeClient.client.Close()
@@ -112,6 +137,75 @@ func (p *connPool) Shutdown() {
 	//TODO: http2 connpool do nothing for shutdown
 }
 
+// Drain closes the connection after its streams finished, no new stream is created in the pool
+func (p *connPool) Drain(timeout time.Duration) <-chan bool {
+	p.mux.Lock()
+	if p.draining {
+		p.mux.Unlock()
+		return p.drained
+	}
+	p.draining = true
+	p.drained = make(chan bool, 1)
+	client := p.activeClient
+	if client == nil {
+		p.finishDrain()
+	}
+	drained := p.drained
+	p.mux.Unlock()
+
+	if client != nil && atomic.LoadUint32(&client.activeStreams) == 0 {
+		client.client.Close()
+	}
+	time.AfterFunc(timeout, func() {
+		p.mux.Lock()
+		if p.drainFinished {
+			p.mux.Unlock()
+			return
+		}
+		p.drainTimeout = true
+		client := p.activeClient
+		p.mux.Unlock()
+		if client != nil {
+			client.client.Close()
+		}
+		p.mux.Lock()
+		p.finishDrain()
+		p.mux.Unlock()
+	})
+	return drained
+}
+
+// State returns the current state of the pool, the pool has one connection at most
+func (p *connPool) State() types.ConnectionPoolState {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	state := types.ConnectionPoolState{
+		ConnectFailures: atomic.LoadUint64(&p.connectFailures),
+		Draining:        p.draining,
+	}
+	if p.activeClient != nil {
+		state.Connections = 1
+		state.ActiveStreams = int(atomic.LoadUint32(&p.activeClient.activeStreams))
+		if state.ActiveStreams > 0 {
+			state.ActiveConnections = 1
+		} else {
+			state.IdleConnections = 1
+		}
+	}
+	return state
+}
+
+// finishDrain notifies the drain is finished once, must be called with the lock held
+func (p *connPool) finishDrain() {
+	if p.drainFinished {
+		return
+	}
+	p.drainFinished = true
+	p.drained <- p.drainTimeout
+	close(p.drained)
+}
+
 func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionEvent) {
 	// event.ConnectFailure() contains types.ConnectTimeout and types.ConnectTimeout
 	log.DefaultLogger.Debugf("http2 connPool onConnectionEvent: %v", event)
@@ -125,7 +219,12 @@ func (p *connPool) onConnectionEvent(client *activeClient, event api.ConnectionE
 				p.host.ClusterInfo().Stats().UpstreamConnectionRemoteCloseWithActiveRequest.Inc(1)
 			}
 		}
+		p.mux.Lock()
 		p.activeClient = nil
+		if p.draining {
+			p.finishDrain()
+		}
+		p.mux.Unlock()
 	} else if event == api.ConnectTimeout {
 		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
@@ -142,6 +241,15 @@ func (p *connPool) onStreamDestroy(client *activeClient) {
 	p.host.HostStats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
+
+	if atomic.AddUint32(&client.activeStreams, ^uint32(0)) == 0 {
+		p.mux.Lock()
+		draining := p.draining
+		p.mux.Unlock()
+		if draining {
+			client.client.Close()
+		}
+	}
 }
 
 func (p *connPool) onStreamReset(client *activeClient, reason types.StreamResetReason) {
@@ -171,6 +279,7 @@ type activeClient struct {
 	host               types.CreateConnectionData
 	closeWithActiveReq bool
 	totalStream        uint64
+	activeStreams      uint32
 }
 
 func newActiveClient(ctx context.Context, pool *connPool) *activeClient {
@@ -181,6 +290,7 @@ func newActiveClient(ctx context.Context, pool *connPool) *activeClient {
 	data := pool.host.CreateConnection(ctx)
 	ac.host = data
 	if err := ac.host.Connection.Connect(); err != nil {
+		atomic.AddUint64(&pool.connectFailures, 1)
 		return nil
 	}
 
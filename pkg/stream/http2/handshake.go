@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/module/http2"
+	"mosn.io/mosn/pkg/types"
+)
+
+const handshakeProtocol = "http2"
+
+// the causes of the failed handshakes
+const (
+	HandshakeFailureTimeout  = "timeout"
+	HandshakeFailurePreface  = "preface"
+	HandshakeFailureProtocol = "protocol"
+	HandshakeFailureClosed   = "closed"
+)
+
+// handshakeTimeout is the max duration between the connection created and the peer settings received
+var handshakeTimeout = 10 * time.Second
+
+// handshake tracks the http2 connection preface, the handshake is completed when the
+// first settings frame of the peer is received
+type handshake struct {
+	side  string
+	conn  api.Connection
+	start time.Time
+	timer *time.Timer
+	done  uint32
+}
+
+func newHandshake(conn api.Connection, side string) *handshake {
+	h := &handshake{
+		side:  side,
+		conn:  conn,
+		start: time.Now(),
+	}
+	h.timer = time.AfterFunc(handshakeTimeout, h.onTimeout)
+	conn.AddConnectionEventListener(h)
+	return h
+}
+
+// onFrame is called with the decode result of the connection
+func (h *handshake) onFrame(frame interface{}, err error) {
+	if atomic.LoadUint32(&h.done) == 1 {
+		return
+	}
+	if err != nil {
+		h.finish(handshakeFailureCause(err), err)
+		return
+	}
+	if f, ok := frame.(*http2.SettingsFrame); ok && !f.IsAck() {
+		h.finish("", nil)
+	}
+}
+
+// OnEvent records the connection closed before the handshake is completed
+func (h *handshake) OnEvent(event api.ConnectionEvent) {
+	if event.IsClose() {
+		h.finish(HandshakeFailureClosed, nil)
+	}
+}
+
+func (h *handshake) onTimeout() {
+	if h.conn.State() == api.ConnClosed {
+		h.finish(HandshakeFailureClosed, nil)
+		return
+	}
+	if h.finish(HandshakeFailureTimeout, nil) {
+		h.conn.Close(api.NoFlush, api.LocalClose)
+	}
+}
+
+// finish records the handshake once, returns false if the handshake is recorded already
+func (h *handshake) finish(cause string, err error) bool {
+	if !atomic.CompareAndSwapUint32(&h.done, 0, 1) {
+		return false
+	}
+	h.timer.Stop()
+	duration := time.Since(h.start)
+	metrics.RecordHandshake(handshakeProtocol, h.side, duration, cause)
+	if cause != "" {
+		var remote string
+		if addr := h.conn.RemoteAddr(); addr != nil {
+			remote = addr.String()
+		}
+		log.DefaultLogger.Alertf(types.ErrorKeyHTTP2Handshake+cause, "[stream] [http2] %s handshake with %s failed, cause: %s, duration: %v, error: %v",
+			h.side, remote, cause, duration, err)
+	}
+	return true
+}
+
+func handshakeFailureCause(err error) string {
+	if strings.HasPrefix(err.Error(), "bogus greeting") {
+		return HandshakeFailurePreface
+	}
+	return HandshakeFailureProtocol
+}
@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/module/http2"
+)
+
+type mockHandshakeConn struct {
+	api.Connection
+	state     api.ConnState
+	listeners []api.ConnectionEventListener
+	closed    bool
+}
+
+func (c *mockHandshakeConn) AddConnectionEventListener(listener api.ConnectionEventListener) {
+	c.listeners = append(c.listeners, listener)
+}
+
+func (c *mockHandshakeConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 12345}
+}
+
+func (c *mockHandshakeConn) State() api.ConnState {
+	return c.state
+}
+
+func (c *mockHandshakeConn) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	c.closed = true
+	return nil
+}
+
+func handshakeCount(side, key string) int64 {
+	return metrics.NewHandshakeStats(handshakeProtocol, side).Counter(key).Count()
+}
+
+func TestHandshakeSuccess(t *testing.T) {
+	metrics.ResetAll()
+	conn := &mockHandshakeConn{}
+	h := newHandshake(conn, metrics.HandshakeSideServer)
+	if len(conn.listeners) != 1 {
+		t.Fatal("handshake should listen to the connection events")
+	}
+	// settings ack is not the handshake
+	ack := &http2.SettingsFrame{FrameHeader: http2.FrameHeader{Type: http2.FrameSettings, Flags: http2.FlagSettingsAck}}
+	h.onFrame(ack, nil)
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeTotal) != 0 {
+		t.Fatal("settings ack should not complete the handshake")
+	}
+	h.onFrame(&http2.SettingsFrame{FrameHeader: http2.FrameHeader{Type: http2.FrameSettings}}, nil)
+	// the later events are ignored
+	h.onFrame(nil, errors.New("bogus greeting"))
+	h.OnEvent(api.RemoteClose)
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeTotal) != 1 ||
+		handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeSuccess) != 1 ||
+		handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeFailed) != 0 {
+		t.Fatal("handshake success is not recorded")
+	}
+	if metrics.NewHandshakeStats(handshakeProtocol, metrics.HandshakeSideServer).Histogram(metrics.HandshakeDuration).Count() != 1 {
+		t.Fatal("handshake duration is not recorded")
+	}
+}
+
+func TestHandshakeFailure(t *testing.T) {
+	metrics.ResetAll()
+	for _, tc := range []struct {
+		side  string
+		fail  func(h *handshake)
+		cause string
+	}{
+		{
+			side:  metrics.HandshakeSideServer,
+			fail:  func(h *handshake) { h.onFrame(nil, errors.New("bogus greeting \"GET / HTTP/1.1\"")) },
+			cause: HandshakeFailurePreface,
+		},
+		{
+			side:  metrics.HandshakeSideClient,
+			fail:  func(h *handshake) { h.onFrame(nil, http2.ConnectionError(http2.ErrCodeProtocol)) },
+			cause: HandshakeFailureProtocol,
+		},
+		{
+			side:  metrics.HandshakeSideClient,
+			fail:  func(h *handshake) { h.OnEvent(api.RemoteClose) },
+			cause: HandshakeFailureClosed,
+		},
+	} {
+		metrics.ResetAll()
+		h := newHandshake(&mockHandshakeConn{}, tc.side)
+		tc.fail(h)
+		if handshakeCount(tc.side, metrics.HandshakeFailed) != 1 ||
+			handshakeCount(tc.side, metrics.HandshakeFailedPrefix+tc.cause) != 1 ||
+			handshakeCount(tc.side, metrics.HandshakeSuccess) != 0 {
+			t.Errorf("handshake failure %s is not recorded", tc.cause)
+		}
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	metrics.ResetAll()
+	timeout := handshakeTimeout
+	handshakeTimeout = 10 * time.Millisecond
+	defer func() {
+		handshakeTimeout = timeout
+	}()
+	conn := &mockHandshakeConn{state: api.ConnActive}
+	newHandshake(conn, metrics.HandshakeSideServer)
+	time.Sleep(100 * time.Millisecond)
+	if !conn.closed {
+		t.Fatal("connection should be closed when the handshake is timeout")
+	}
+	if handshakeCount(metrics.HandshakeSideServer, metrics.HandshakeFailedPrefix+HandshakeFailureTimeout) != 1 {
+		t.Fatal("handshake timeout is not recorded")
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	mbuffer "mosn.io/mosn/pkg/buffer"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/module/http2"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/variable"
+)
+
+const defaultMaxPushesPerConnection = 100
+
+// pushRequestHeaders are copied from the parent request to the pushed requests,
+// so the upstream can respond the pushed requests as the same client
+var pushRequestHeaders = []string{"User-Agent", "Accept-Encoding", "Accept-Language", "Cookie"}
+
+// serverPush finds the resources to be pushed for the requests
+type serverPush struct {
+	linkPreload bool
+	rules       []v2.PushRule
+	maxPushes   uint32
+}
+
+func newServerPush(ctx context.Context) *serverPush {
+	value := mosnctx.Get(ctx, types.ContextKeyHTTP2Push)
+	if value == nil {
+		return nil
+	}
+	cfg, ok := value.(*v2.HTTP2Push)
+	if !ok || cfg == nil {
+		return nil
+	}
+	p := &serverPush{
+		linkPreload: cfg.LinkPreload,
+		rules:       cfg.Rules,
+		maxPushes:   cfg.MaxPushesPerConnection,
+	}
+	if p.maxPushes == 0 {
+		p.maxPushes = defaultMaxPushesPerConnection
+	}
+	return p
+}
+
+// resources returns the resources of the rules matched by the request path and the
+// Link preload headers, the duplicated ones and the request path itself are removed
+func (p *serverPush) resources(path string, rspHeader http.Header) []string {
+	var candidates []string
+	for _, rule := range p.rules {
+		if strings.HasPrefix(path, rule.PathPrefix) {
+			candidates = append(candidates, rule.Resources...)
+		}
+	}
+	if p.linkPreload {
+		candidates = append(candidates, parseLinkPreload(rspHeader["Link"])...)
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	resources := make([]string, 0, len(candidates))
+	for _, res := range candidates {
+		if res == "" || res == path || seen[res] {
+			continue
+		}
+		seen[res] = true
+		resources = append(resources, res)
+	}
+	return resources
+}
+
+// parseLinkPreload returns the uris of the Link header values with rel=preload,
+// the links with the nopush parameter are skipped.
+// Link: </style.css>; rel=preload; as=style, </app.js>; rel="preload"; nopush
+func parseLinkPreload(values []string) []string {
+	var uris []string
+	for _, value := range values {
+		for _, link := range splitLinks(value) {
+			link = strings.TrimSpace(link)
+			if !strings.HasPrefix(link, "<") {
+				continue
+			}
+			end := strings.Index(link, ">")
+			if end < 0 {
+				continue
+			}
+			uri := strings.TrimSpace(link[1:end])
+			preload, nopush := false, false
+			for _, param := range strings.Split(link[end+1:], ";") {
+				param = strings.TrimSpace(param)
+				kv := strings.SplitN(param, "=", 2)
+				key := strings.ToLower(strings.TrimSpace(kv[0]))
+				switch {
+				case key == "nopush":
+					nopush = true
+				case key == "rel" && len(kv) == 2:
+					rels := strings.Trim(strings.TrimSpace(kv[1]), "\"")
+					for _, rel := range strings.Fields(rels) {
+						if strings.EqualFold(rel, "preload") {
+							preload = true
+						}
+					}
+				}
+			}
+			if preload && !nopush && uri != "" {
+				uris = append(uris, uri)
+			}
+		}
+	}
+	return uris
+}
+
+// splitLinks splits the Link header value by the commas out of the uri brackets
+func splitLinks(value string) []string {
+	var links []string
+	inURI := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '<':
+			inURI = true
+		case '>':
+			inURI = false
+		case ',':
+			if !inURI {
+				links = append(links, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(links, value[start:])
+}
+
+func pushHeader(reqHeader http.Header) http.Header {
+	header := make(http.Header, len(pushRequestHeaders))
+	for _, key := range pushRequestHeaders {
+		if values, ok := reqHeader[key]; ok {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+	return header
+}
+
+// pushResources sends the push promises of the resources before the response of the stream is sent,
+// and the promised streams are dispatched as new requests.
+func (conn *serverStreamConnection) pushResources(s *serverStream) {
+	rsp := s.h2s.Response
+	if s.pushed || rsp == nil || rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		return
+	}
+	parentURL := s.h2s.Request.URL
+	for _, res := range conn.push.resources(parentURL.Path, rsp.Header) {
+		u, err := parentURL.Parse(res)
+		if err != nil || u.Host != parentURL.Host {
+			log.Proxy.Debugf(s.ctx, "http2 server skip push resource %s of stream %d", res, s.id)
+			continue
+		}
+		h2s, err := conn.sc.Push(s.h2s, u.Scheme, u.RequestURI(), pushHeader(s.h2s.Request.Header))
+		if err != nil {
+			log.Proxy.Debugf(s.ctx, "http2 server push resource %s of stream %d failed: %v", res, s.id, err)
+			if err == http2.ErrPushLimitReached || err == http.ErrNotSupported {
+				return
+			}
+			continue
+		}
+		log.Proxy.Debugf(s.ctx, "http2 server push resource %s on stream %d, parent = %d", res, h2s.ID(), s.id)
+		conn.onPushStream(h2s)
+	}
+}
+
+// onPushStream dispatches the promised stream, which has no request body
+func (conn *serverStreamConnection) onPushStream(h2s *http2.MStream) {
+	ctx := variable.NewVariableContext(mbuffer.NewBufferPoolContext(mosnctx.Clone(conn.ctx)))
+	stream, err := conn.onNewStreamDetect(ctx, h2s, true)
+	if err != nil {
+		h2s.Reset()
+		return
+	}
+	stream.pushed = true
+	header := conn.newRequestHeader(h2s)
+	stream.receiver.OnReceive(stream.ctx, header, nil, nil)
+}
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package http2
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+func TestParseLinkPreload(t *testing.T) {
+	values := []string{
+		`</style.css>; rel=preload; as=style, </app.js>; rel="preload"; nopush`,
+		`</a,b.js>; rel="prefetch preload", <https://cdn.example.com/x.png>; rel=preload`,
+		`</next.html>; rel=next`,
+		`invalid; rel=preload`,
+	}
+	uris := parseLinkPreload(values)
+	expected := []string{"/style.css", "/a,b.js", "https://cdn.example.com/x.png"}
+	if !reflect.DeepEqual(uris, expected) {
+		t.Errorf("parse link preload expected %v, but got %v", expected, uris)
+	}
+}
+
+func TestServerPushResources(t *testing.T) {
+	p := &serverPush{
+		linkPreload: true,
+		rules: []v2.PushRule{
+			{PathPrefix: "/index", Resources: []string{"/style.css", "/index.html"}},
+			{PathPrefix: "/api", Resources: []string{"/api.js"}},
+		},
+	}
+	header := http.Header{}
+	header.Add("Link", "</style.css>; rel=preload, </app.js>; rel=preload")
+	resources := p.resources("/index.html", header)
+	expected := []string{"/style.css", "/app.js"}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("push resources expected %v, but got %v", expected, resources)
+	}
+
+	p.linkPreload = false
+	if resources := p.resources("/other", header); len(resources) != 0 {
+		t.Errorf("expected no resources, but got %v", resources)
+	}
+}
+
+func TestPushHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("User-Agent", "test")
+	header.Set("Cookie", "a=b")
+	header.Set("Content-Type", "application/json")
+	pushed := pushHeader(header)
+	if len(pushed) != 2 || pushed.Get("User-Agent") != "test" || pushed.Get("Cookie") != "a=b" {
+		t.Errorf("unexpected push header: %v", pushed)
+	}
+}
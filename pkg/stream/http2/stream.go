@@ -32,6 +32,7 @@ import (
 	mbuffer "mosn.io/mosn/pkg/buffer"
 	mosnctx "mosn.io/mosn/pkg/context"
 	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
 	"mosn.io/mosn/pkg/module/http2"
 	"mosn.io/mosn/pkg/mtls"
 	"mosn.io/mosn/pkg/protocol"
@@ -94,6 +95,7 @@ type streamConnection struct {
 	cm   *str.ContextManager
 
 	codecEngine types.ProtocolEngine
+	handshake   *handshake
 }
 
 func (conn *streamConnection) Protocol() types.Protocol {
@@ -159,6 +161,7 @@ type serverStreamConnection struct {
 	mutex   sync.RWMutex
 	streams map[uint32]*serverStream
 	sc      *http2.MServerConn
+	push    *serverPush
 
 	serverCallbacks types.ServerStreamConnectionEventListener
 }
@@ -172,6 +175,7 @@ func newServerStreamConnection(ctx context.Context, connection api.Connection, s
 			ctx:         ctx,
 			conn:        connection,
 			codecEngine: mhttp2.EngineServer(h2sc),
+			handshake:   newHandshake(connection, metrics.HandshakeSideServer),
 
 			cm: str.NewContextManager(ctx),
 		},
@@ -180,6 +184,10 @@ func newServerStreamConnection(ctx context.Context, connection api.Connection, s
 		serverCallbacks: serverCallbacks,
 	}
 
+	if sc.push = newServerPush(ctx); sc.push != nil {
+		h2sc.SetMaxPushes(sc.push.maxPushes)
+	}
+
 	// init first context
 	sc.cm.Next()
 
@@ -206,6 +214,7 @@ func (conn *serverStreamConnection) Dispatch(buf types.IoBuffer) {
 		if err == http2.ErrAGAIN {
 			break
 		}
+		conn.handshake.onFrame(frame, err)
 
 		// Do handle staff. Error would also be passed to this function.
 		conn.handleFrame(ctx, frame, err)
@@ -278,28 +287,7 @@ func (conn *serverStreamConnection) handleFrame(ctx context.Context, i interface
 			return
 		}
 
-		header := mhttp2.NewReqHeader(h2s.Request)
-
-		scheme := "http"
-		if _, ok := conn.conn.RawConn().(*mtls.TLSConn); ok {
-			scheme = "https"
-		}
-		var URI string
-		if h2s.Request.URL.RawQuery == "" {
-			URI = fmt.Sprintf(scheme+"://%s%s", h2s.Request.Host, h2s.Request.URL.Path)
-		} else {
-			URI = fmt.Sprintf(scheme+"://%s%s?%s", h2s.Request.Host, h2s.Request.URL.Path, h2s.Request.URL.RawQuery)
-
-		}
-		URL, _ := url.Parse(URI)
-		h2s.Request.URL = URL
-
-		header.Set(protocol.MosnHeaderMethod, h2s.Request.Method)
-		header.Set(protocol.MosnHeaderHostKey, h2s.Request.Host)
-		header.Set(protocol.MosnHeaderPathKey, h2s.Request.URL.Path)
-		if h2s.Request.URL.RawQuery != "" {
-			header.Set(protocol.MosnHeaderQueryStringKey, h2s.Request.URL.RawQuery)
-		}
+		header := conn.newRequestHeader(h2s)
 
 		log.Proxy.Debugf(stream.ctx, "http2 server header: %d, %+v", id, h2s.Request.Header)
 
@@ -346,6 +334,33 @@ func (conn *serverStreamConnection) handleFrame(ctx context.Context, i interface
 	}
 }
 
+// newRequestHeader builds the request header of the stream, and the request url is replaced with the absolute one
+func (conn *serverStreamConnection) newRequestHeader(h2s *http2.MStream) *mhttp2.ReqHeader {
+	header := mhttp2.NewReqHeader(h2s.Request)
+
+	scheme := "http"
+	if _, ok := conn.conn.RawConn().(*mtls.TLSConn); ok {
+		scheme = "https"
+	}
+	var URI string
+	if h2s.Request.URL.RawQuery == "" {
+		URI = fmt.Sprintf(scheme+"://%s%s", h2s.Request.Host, h2s.Request.URL.Path)
+	} else {
+		URI = fmt.Sprintf(scheme+"://%s%s?%s", h2s.Request.Host, h2s.Request.URL.Path, h2s.Request.URL.RawQuery)
+
+	}
+	URL, _ := url.Parse(URI)
+	h2s.Request.URL = URL
+
+	header.Set(protocol.MosnHeaderMethod, h2s.Request.Method)
+	header.Set(protocol.MosnHeaderHostKey, h2s.Request.Host)
+	header.Set(protocol.MosnHeaderPathKey, h2s.Request.URL.Path)
+	if h2s.Request.URL.RawQuery != "" {
+		header.Set(protocol.MosnHeaderQueryStringKey, h2s.Request.URL.RawQuery)
+	}
+	return header
+}
+
 func (conn *serverStreamConnection) handleError(ctx context.Context, f http2.Frame, err error) {
 	conn.sc.HandleError(ctx, f, err)
 	if err != nil {
@@ -408,6 +423,8 @@ type serverStream struct {
 	stream
 	h2s *http2.MStream
 	sc  *serverStreamConnection
+	// pushed is true if the stream is promised by the server
+	pushed bool
 }
 
 // types.StreamSender
@@ -482,6 +499,11 @@ func (s *serverStream) AppendTrailers(context context.Context, trailers api.Head
 func (s *serverStream) endStream() {
 	defer s.DestroyStream()
 
+	// the push promises must be sent before the response that refers to the resources
+	if s.sc.push != nil {
+		s.sc.pushResources(s)
+	}
+
 	_, err := s.sc.codecEngine.Encode(s.ctx, s.h2s)
 	if err != nil {
 		// todo: other error scenes
@@ -522,6 +544,7 @@ func newClientStreamConnection(ctx context.Context, connection api.Connection,
 			ctx:         ctx,
 			conn:        connection,
 			codecEngine: mhttp2.EngineClient(h2cc),
+			handshake:   newHandshake(connection, metrics.HandshakeSideClient),
 
 			cm: str.NewContextManager(ctx),
 		},
@@ -549,6 +572,7 @@ func (conn *clientStreamConnection) Dispatch(buf types.IoBuffer) {
 		if err == http2.ErrAGAIN {
 			break
 		}
+		conn.handshake.onFrame(frame, err)
 
 		// Do handle staff. Error would also be passed to this function.
 		conn.handleFrame(ctx, frame, err)
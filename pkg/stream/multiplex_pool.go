@@ -0,0 +1,654 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+// KeepAliveFactory creates the keepalive of a connection in the multiplex pool,
+// returns nil if the sub protocol needs no keepalive
+type KeepAliveFactory func(client Client, subProtocol interface{}) types.KeepAlive
+
+// NewMultiplexPoolFactory returns a connection pool factory for a multiplexing protocol
+func NewMultiplexPoolFactory(prot types.Protocol, keepAlive KeepAliveFactory) func(host types.Host) types.ConnectionPool {
+	return func(host types.Host) types.ConnectionPool {
+		return NewMultiplexPool(host, prot, keepAlive)
+	}
+}
+
+var defaultPoolModes = make(map[types.Protocol]string)
+
+// RegisterDefaultPoolMode sets the default connection pool mode of a protocol,
+// the cluster's conn pool config overrides it
+func RegisterDefaultPoolMode(prot types.Protocol, mode string) {
+	defaultPoolModes[prot] = mode
+}
+
+func poolMode(host types.Host, prot types.Protocol) string {
+	if mode := host.ClusterInfo().ConnPoolConfig().Mode; mode != "" {
+		return mode
+	}
+	if mode, ok := defaultPoolModes[prot]; ok {
+		return mode
+	}
+	return v2.ConnPoolModeMultiplex
+}
+
+// bindKey partitions the connections by the downstream connection in bind mode
+type bindKey struct {
+	subProtocol  interface{}
+	connectionID uint64
+}
+
+// multiplex client states
+const (
+	multiplexConnecting uint32 = iota
+	multiplexConnected
+	multiplexDraining
+)
+
+// multiplexPool is a connection pool for the protocols that multiplex streams on a connection by request id,
+// such as sofarpc, dubbo and the xprotocol sub protocols.
+// The connections are partitioned by the sub protocol in the context, each sub protocol of a host has
+// at most ConnectionsPerHost connections, and a new stream chooses the connection with the least active streams.
+// In pingpong mode a connection has one stream at a time, in bind mode the connections are
+// also partitioned by the downstream connection.
+type multiplexPool struct {
+	protocol  types.Protocol
+	host      types.Host
+	keepAlive KeepAliveFactory
+	mode      string
+	stats     types.Metrics
+
+	mux     sync.Mutex
+	clients map[interface{}][]*multiplexClient
+	// drain states are protected by the lock
+	draining      bool
+	drainTimeout  bool
+	drainFinished bool
+	drained       chan bool
+
+	connectFailures uint64
+}
+
+// NewMultiplexPool creates a multiplex connection pool for the host
+func NewMultiplexPool(host types.Host, prot types.Protocol, keepAlive KeepAliveFactory) types.ConnectionPool {
+	mode := poolMode(host, prot)
+	return &multiplexPool{
+		protocol:  prot,
+		host:      host,
+		keepAlive: keepAlive,
+		mode:      mode,
+		stats:     metrics.NewConnPoolStats(host.ClusterInfo().Name(), mode),
+		clients:   make(map[interface{}][]*multiplexClient),
+	}
+}
+
+func (p *multiplexPool) SupportTLS() bool {
+	return p.host.SupportTLS()
+}
+
+func (p *multiplexPool) Protocol() types.Protocol {
+	return p.protocol
+}
+
+func (p *multiplexPool) maxConnections() int {
+	switch p.mode {
+	case v2.ConnPoolModeBind:
+		return 1
+	case v2.ConnPoolModePingPong:
+		if n := p.host.ClusterInfo().ConnPoolConfig().ConnectionsPerHost; n > 0 {
+			return int(n)
+		}
+		return int(p.host.ClusterInfo().ResourceManager().Connections().Max())
+	default:
+		if n := p.host.ClusterInfo().ConnPoolConfig().ConnectionsPerHost; n > 0 {
+			return int(n)
+		}
+		return 1
+	}
+}
+
+func (p *multiplexPool) maxStreams() uint32 {
+	if p.mode == v2.ConnPoolModePingPong {
+		return 1
+	}
+	return p.host.ClusterInfo().ConnPoolConfig().MaxConcurrentStreams
+}
+
+// poolKey returns the partition key of the connections, and the downstream connection in bind mode
+func (p *multiplexPool) poolKey(ctx context.Context) (interface{}, api.Connection) {
+	subProtocol := getSubProtocol(ctx)
+	if p.mode != v2.ConnPoolModeBind || ctx == nil {
+		return subProtocol, nil
+	}
+	// no downstream connection, such as warmup, uses the shared connections
+	conn, ok := mosnctx.Get(ctx, types.ContextKeyDownstreamConnection).(api.Connection)
+	if !ok || conn == nil {
+		return subProtocol, nil
+	}
+	return bindKey{subProtocol: subProtocol, connectionID: conn.ID()}, conn
+}
+
+// CheckAndInit returns true if the sub protocol has a connection that can take a new stream,
+// a new connection is created in background if all the connections are busy.
+// If all the connections are busy and no more connection can be created, true is returned
+// so that NewStream reports the overflow
+func (p *multiplexPool) CheckAndInit(ctx context.Context) bool {
+	key, downstream := p.poolKey(ctx)
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.draining {
+		return false
+	}
+	connected, available, connecting := false, false, false
+	for _, client := range p.clients[key] {
+		switch client.state {
+		case multiplexConnected:
+			connected = true
+			if client.available(p.maxStreams()) {
+				available = true
+			}
+		case multiplexConnecting:
+			connecting = true
+		}
+	}
+	if !available && !connecting && p.activeClients(key) < p.maxConnections() {
+		connecting = true
+		client := p.connect(key, getSubProtocol(ctx))
+		if downstream != nil {
+			// the bound connection is closed with the downstream connection
+			p.stats.Counter(metrics.UpstreamPoolBindTotal).Inc(1)
+			downstream.AddConnectionEventListener(&bindListener{client: client})
+		}
+	}
+	return available || (connected && !connecting)
+}
+
+// activeClients returns the connections not draining, must be called with the lock held
+func (p *multiplexPool) activeClients(key interface{}) int {
+	n := 0
+	for _, client := range p.clients[key] {
+		if client.state != multiplexDraining {
+			n++
+		}
+	}
+	return n
+}
+
+// connect creates a connection in background, must be called with the lock held
+func (p *multiplexPool) connect(key interface{}, subProtocol interface{}) *multiplexClient {
+	client := newMultiplexClient(p, key, subProtocol)
+	p.clients[key] = append(p.clients[key], client)
+
+	utils.GoWithRecover(func() {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[stream] [multiplex pool] %s connect to host %s", p.protocol, p.host.AddressString())
+		}
+		if err := client.connect(); err != nil {
+			log.DefaultLogger.Errorf("[stream] [multiplex pool] %s connect to host %s failed: %v", p.protocol, p.host.AddressString(), err)
+			p.removeClient(client)
+			return
+		}
+		p.mux.Lock()
+		if client.state == multiplexConnecting {
+			client.state = multiplexConnected
+		}
+		// the pool is drained while connecting
+		idle := client.state == multiplexDraining && atomic.LoadUint32(&client.activeStreams) == 0
+		p.mux.Unlock()
+		if idle {
+			client.client.Close()
+		}
+	}, nil)
+	return client
+}
+
+func (p *multiplexPool) removeClient(client *multiplexClient) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	clients := p.clients[client.key]
+	for i, c := range clients {
+		if c == client {
+			clients = append(clients[:i], clients[i+1:]...)
+			break
+		}
+	}
+	if len(clients) == 0 {
+		delete(p.clients, client.key)
+	} else {
+		p.clients[client.key] = clients
+	}
+	if p.draining && len(p.clients) == 0 {
+		p.finishDrain()
+	}
+}
+
+func (p *multiplexPool) hasClient(client *multiplexClient) bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	for _, c := range p.clients[client.key] {
+		if c == client {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseClient returns the connected connection with the least active streams,
+// overflow is true if all the connected connections reach the max concurrent streams
+func (p *multiplexPool) chooseClient(key interface{}) (chosen *multiplexClient, overflow bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	maxStreams := p.maxStreams()
+	for _, client := range p.clients[key] {
+		if client.state != multiplexConnected {
+			continue
+		}
+		if !client.available(maxStreams) {
+			overflow = true
+			continue
+		}
+		if chosen == nil || atomic.LoadUint32(&client.activeStreams) < atomic.LoadUint32(&chosen.activeStreams) {
+			chosen = client
+		}
+	}
+	if chosen != nil {
+		// reserve the stream in the lock, so the concurrent streams never exceed the limit
+		atomic.AddUint32(&chosen.activeStreams, 1)
+	}
+	return chosen, overflow
+}
+
+func (p *multiplexPool) NewStream(ctx context.Context, responseDecoder types.StreamReceiveListener, listener types.PoolEventListener) {
+	key, _ := p.poolKey(ctx)
+	client, overflow := p.chooseClient(key)
+	if client == nil {
+		if overflow {
+			listener.OnFailure(types.Overflow, p.host)
+			p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+			p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		} else {
+			listener.OnFailure(types.ConnectionFailure, p.host)
+		}
+		return
+	}
+
+	if !p.host.ClusterInfo().ResourceManager().Requests().CanCreate() {
+		atomic.AddUint32(&client.activeStreams, ^uint32(0))
+		listener.OnFailure(types.Overflow, p.host)
+		p.host.HostStats().UpstreamRequestPendingOverflow.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestPendingOverflow.Inc(1)
+		return
+	}
+
+	atomic.AddUint64(&client.totalStream, 1)
+	p.host.HostStats().UpstreamRequestTotal.Inc(1)
+	p.host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
+
+	var streamSender types.StreamSender
+	// oneway request has no response, so it is not an active stream
+	if responseDecoder == nil {
+		atomic.AddUint32(&client.activeStreams, ^uint32(0))
+		streamSender = client.client.NewStream(ctx, nil)
+	} else {
+		streamSender = client.client.NewStream(ctx, responseDecoder)
+		streamSender.GetStream().AddEventListener(client)
+
+		p.host.HostStats().UpstreamRequestActive.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
+		p.host.ClusterInfo().ResourceManager().Requests().Increase()
+	}
+
+	listener.OnReady(streamSender, p.host)
+}
+
+func (p *multiplexPool) allClients() []*multiplexClient {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	var all []*multiplexClient
+	for _, clients := range p.clients {
+		all = append(all, clients...)
+	}
+	return all
+}
+
+// Close closes all the connections
+func (p *multiplexPool) Close() {
+	for _, client := range p.allClients() {
+		client.client.Close()
+	}
+}
+
+// Shutdown stops the keepalive, so the connections will be idle after requests finished
+func (p *multiplexPool) Shutdown() {
+	for _, client := range p.allClients() {
+		if client.keepAlive != nil {
+			client.keepAlive.keepAlive.Stop()
+		}
+	}
+}
+
+// Drain marks all the connections draining, so no new stream is assigned to them.
+// The idle connections are closed at once, and the others are closed after their streams finished
+func (p *multiplexPool) Drain(timeout time.Duration) <-chan bool {
+	p.mux.Lock()
+	if p.draining {
+		p.mux.Unlock()
+		return p.drained
+	}
+	p.draining = true
+	p.drained = make(chan bool, 1)
+	var idle []*multiplexClient
+	for _, clients := range p.clients {
+		for _, client := range clients {
+			client.state = multiplexDraining
+			if atomic.LoadUint32(&client.activeStreams) == 0 {
+				idle = append(idle, client)
+			}
+		}
+	}
+	if len(p.clients) == 0 {
+		p.finishDrain()
+	}
+	drained := p.drained
+	p.mux.Unlock()
+
+	for _, client := range idle {
+		client.client.Close()
+	}
+	time.AfterFunc(timeout, func() {
+		p.mux.Lock()
+		if p.drainFinished {
+			p.mux.Unlock()
+			return
+		}
+		p.drainTimeout = true
+		p.mux.Unlock()
+		p.Close()
+		p.mux.Lock()
+		p.finishDrain()
+		p.mux.Unlock()
+	})
+	return drained
+}
+
+// State returns the current state of the connections of all the partitions
+func (p *multiplexPool) State() types.ConnectionPoolState {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	state := types.ConnectionPoolState{
+		ConnectFailures: atomic.LoadUint64(&p.connectFailures),
+		Draining:        p.draining,
+	}
+	for _, clients := range p.clients {
+		for _, client := range clients {
+			state.Connections++
+			streams := int(atomic.LoadUint32(&client.activeStreams))
+			state.ActiveStreams += streams
+			if streams > 0 {
+				state.ActiveConnections++
+			} else if client.state == multiplexConnected {
+				state.IdleConnections++
+			}
+		}
+	}
+	return state
+}
+
+// finishDrain notifies the drain is finished once, must be called with the lock held
+func (p *multiplexPool) finishDrain() {
+	if p.drainFinished {
+		return
+	}
+	p.drainFinished = true
+	p.drained <- p.drainTimeout
+	close(p.drained)
+}
+
+func (p *multiplexPool) onConnectionEvent(client *multiplexClient, event api.ConnectionEvent) {
+	// event.ConnectFailure() contains api.ConnectTimeout and api.ConnectFailed
+	if event.IsClose() {
+		p.host.HostStats().UpstreamConnectionClose.Inc(1)
+		p.host.HostStats().UpstreamConnectionActive.Dec(1)
+
+		p.host.ClusterInfo().Stats().UpstreamConnectionClose.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamConnectionActive.Dec(1)
+
+		switch event {
+		case api.LocalClose:
+			p.host.HostStats().UpstreamConnectionLocalClose.Inc(1)
+			p.host.ClusterInfo().Stats().UpstreamConnectionLocalClose.Inc(1)
+
+			if client.closeWithActiveReq {
+				p.host.HostStats().UpstreamConnectionLocalCloseWithActiveRequest.Inc(1)
+				p.host.ClusterInfo().Stats().UpstreamConnectionLocalCloseWithActiveRequest.Inc(1)
+			}
+
+		case api.RemoteClose:
+			p.host.HostStats().UpstreamConnectionRemoteClose.Inc(1)
+			p.host.ClusterInfo().Stats().UpstreamConnectionRemoteClose.Inc(1)
+
+			if client.closeWithActiveReq {
+				p.host.HostStats().UpstreamConnectionRemoteCloseWithActiveRequest.Inc(1)
+				p.host.ClusterInfo().Stats().UpstreamConnectionRemoteCloseWithActiveRequest.Inc(1)
+			}
+		default:
+			// do nothing
+		}
+		p.removeClient(client)
+	} else if event == api.ConnectTimeout {
+		atomic.AddUint64(&p.connectFailures, 1)
+		p.host.HostStats().UpstreamRequestTimeout.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestTimeout.Inc(1)
+		client.client.Close()
+	} else if event == api.ConnectFailed {
+		atomic.AddUint64(&p.connectFailures, 1)
+		p.host.HostStats().UpstreamConnectionConFail.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamConnectionConFail.Inc(1)
+	}
+}
+
+func (p *multiplexPool) onStreamDestroy(client *multiplexClient) {
+	p.host.HostStats().UpstreamRequestActive.Dec(1)
+	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
+	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
+
+	if atomic.AddUint32(&client.activeStreams, ^uint32(0)) == 0 {
+		p.mux.Lock()
+		draining := client.state == multiplexDraining
+		p.mux.Unlock()
+		if draining {
+			client.client.Close()
+		}
+	}
+}
+
+func (p *multiplexPool) onStreamReset(client *multiplexClient, reason types.StreamResetReason) {
+	if reason == types.StreamConnectionTermination || reason == types.StreamConnectionFailed {
+		p.host.HostStats().UpstreamRequestFailureEject.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestFailureEject.Inc(1)
+		client.closeWithActiveReq = true
+	} else if reason == types.StreamLocalReset {
+		p.host.HostStats().UpstreamRequestLocalReset.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestLocalReset.Inc(1)
+		if p.mode == v2.ConnPoolModePingPong {
+			// the response may arrive later and be taken as the next stream's response,
+			// so the connection is closed after the stream destroyed
+			p.stats.Counter(metrics.UpstreamPoolTimeoutClose).Inc(1)
+			p.mux.Lock()
+			client.state = multiplexDraining
+			p.mux.Unlock()
+		}
+	} else if reason == types.StreamRemoteReset {
+		p.host.HostStats().UpstreamRequestRemoteReset.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestRemoteReset.Inc(1)
+	}
+}
+
+// onGoAway drains the connection, it is closed after the active streams finished
+func (p *multiplexPool) onGoAway(client *multiplexClient) {
+	p.host.HostStats().UpstreamConnectionCloseNotify.Inc(1)
+	p.host.ClusterInfo().Stats().UpstreamConnectionCloseNotify.Inc(1)
+
+	p.mux.Lock()
+	client.state = multiplexDraining
+	p.mux.Unlock()
+
+	if atomic.LoadUint32(&client.activeStreams) == 0 {
+		client.client.Close()
+	}
+}
+
+// keepAliveListener sends the heartbeat when the connection is idle
+type keepAliveListener struct {
+	keepAlive types.KeepAlive
+}
+
+func (l *keepAliveListener) OnEvent(event api.ConnectionEvent) {
+	if event == api.OnReadTimeout {
+		l.keepAlive.SendKeepAlive()
+	}
+}
+
+// bindListener closes the bound upstream connection when the downstream connection is closed
+type bindListener struct {
+	client *multiplexClient
+}
+
+func (l *bindListener) OnEvent(event api.ConnectionEvent) {
+	if event.IsClose() && l.client.pool.hasClient(l.client) {
+		l.client.pool.stats.Counter(metrics.UpstreamPoolBindClose).Inc(1)
+		l.client.client.Close()
+	}
+}
+
+// multiplexClient is a connection in the multiplex pool
+// types.StreamEventListener
+// types.ConnectionEventListener
+// types.StreamConnectionEventListener
+type multiplexClient struct {
+	pool               *multiplexPool
+	key                interface{}
+	subProtocol        interface{}
+	client             Client
+	keepAlive          *keepAliveListener
+	closeWithActiveReq bool
+	totalStream        uint64
+	activeStreams      uint32
+	// state is protected by the pool lock
+	state uint32
+}
+
+func (c *multiplexClient) available(maxStreams uint32) bool {
+	return maxStreams == 0 || atomic.LoadUint32(&c.activeStreams) < maxStreams
+}
+
+func newMultiplexClient(pool *multiplexPool, key interface{}, subProtocol interface{}) *multiplexClient {
+	c := &multiplexClient{
+		pool:        pool,
+		key:         key,
+		subProtocol: subProtocol,
+		state:       multiplexConnecting,
+	}
+
+	data := pool.host.CreateConnection(context.Background())
+	connCtx := mosnctx.WithValue(context.Background(), types.ContextKeyConnectionID, data.Connection.ID())
+	if subProtocol != nil {
+		connCtx = mosnctx.WithValue(connCtx, types.ContextSubProtocol, subProtocol)
+	}
+	codecClient := NewStreamClient(connCtx, pool.protocol, data.Connection, data.Host)
+	codecClient.AddConnectionEventListener(c)
+	codecClient.SetStreamConnectionEventListener(c)
+	c.client = codecClient
+
+	if pool.keepAlive != nil {
+		if keepAlive := pool.keepAlive(codecClient, subProtocol); keepAlive != nil {
+			keepAlive.StartIdleTimeout()
+			c.keepAlive = &keepAliveListener{
+				keepAlive: keepAlive,
+			}
+			codecClient.AddConnectionEventListener(c.keepAlive)
+		}
+	}
+	return c
+}
+
+// connect dials the connection, it may block until the connect timeout
+func (c *multiplexClient) connect() error {
+	pool := c.pool
+	codecClient := c.client
+	if err := codecClient.Connect(); err != nil {
+		return err
+	}
+
+	// stats
+	pool.host.HostStats().UpstreamConnectionTotal.Inc(1)
+	pool.host.HostStats().UpstreamConnectionActive.Inc(1)
+	pool.host.ClusterInfo().Stats().UpstreamConnectionTotal.Inc(1)
+	pool.host.ClusterInfo().Stats().UpstreamConnectionActive.Inc(1)
+
+	// bytes total adds all connections data together
+	codecClient.SetConnectionCollector(pool.host.ClusterInfo().Stats().UpstreamBytesReadTotal, pool.host.ClusterInfo().Stats().UpstreamBytesWriteTotal)
+	return nil
+}
+
+// types.ConnectionEventListener
+func (c *multiplexClient) OnEvent(event api.ConnectionEvent) {
+	c.pool.onConnectionEvent(c, event)
+}
+
+// types.StreamEventListener
+func (c *multiplexClient) OnDestroyStream() {
+	c.pool.onStreamDestroy(c)
+}
+
+func (c *multiplexClient) OnResetStream(reason types.StreamResetReason) {
+	c.pool.onStreamReset(c, reason)
+}
+
+// types.StreamConnectionEventListener
+func (c *multiplexClient) OnGoAway() {
+	c.pool.onGoAway(c)
+}
+
+func getSubProtocol(ctx context.Context) interface{} {
+	if ctx != nil {
+		return mosnctx.Get(ctx, types.ContextSubProtocol)
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 
 	"mosn.io/mosn/pkg/buffer"
+	"mosn.io/mosn/pkg/memory"
 )
 
 func init() {
@@ -37,6 +38,11 @@ func (ctx sofaBufferCtx) New() interface{} {
 	return new(sofaBuffers)
 }
 
+// MemoryModule accounts the buffers as the stream objects
+func (ctx sofaBufferCtx) MemoryModule() string {
+	return memory.ModuleStream
+}
+
 func (ctx sofaBufferCtx) Reset(i interface{}) {
 	buf, _ := i.(*sofaBuffers)
 	*buf = sofaBuffers{}
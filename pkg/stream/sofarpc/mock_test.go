@@ -22,6 +22,7 @@ import (
 	"net"
 	"time"
 
+	v2 "mosn.io/mosn/pkg/config/v2"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/protocol/rpc/sofarpc"
 	"mosn.io/mosn/pkg/types"
@@ -143,6 +144,14 @@ func (ci *mockClusterInfo) SourceAddress() net.Addr {
 	return nil
 }
 
+func (ci *mockClusterInfo) UpstreamProtocol() types.Protocol {
+	return ""
+}
+
+func (ci *mockClusterInfo) ConnPoolConfig() v2.ConnPoolConfig {
+	return v2.ConnPoolConfig{}
+}
+
 func (ci *mockClusterInfo) ConnectTimeout() time.Duration {
 	return network.DefaultConnectTimeout
 }
@@ -0,0 +1,473 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/protocol/rpc/xprotocol/example"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/mosn/pkg/upstream/cluster"
+	"mosn.io/pkg/buffer"
+)
+
+type pendingFrame struct {
+	conn  net.Conn
+	frame []byte
+}
+
+// poolTestServer holds the requests until the test replies them
+type poolTestServer struct {
+	ln      net.Listener
+	pending chan pendingFrame
+}
+
+func newPoolTestServer(t *testing.T) *poolTestServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &poolTestServer{
+		ln:      ln,
+		pending: make(chan pendingFrame, 16),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				for {
+					frame := make([]byte, example.ReqDataLen)
+					if _, err := io.ReadFull(conn, frame); err != nil {
+						return
+					}
+					s.pending <- pendingFrame{conn: conn, frame: frame}
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+func (s *poolTestServer) reply(t *testing.T) {
+	select {
+	case p := <-s.pending:
+		p.frame[example.TypeOffset] = example.TypeResponse
+		p.conn.Write(p.frame)
+	case <-time.After(time.Second):
+		t.Fatal("no request received")
+	}
+}
+
+type poolTestListener struct {
+	sender types.StreamSender
+	reason types.PoolFailureReason
+}
+
+func (l *poolTestListener) OnFailure(reason types.PoolFailureReason, host types.Host) {
+	l.reason = reason
+}
+
+func (l *poolTestListener) OnReady(sender types.StreamSender, host types.Host) {
+	l.sender = sender
+}
+
+type poolTestReceiver struct {
+	received chan struct{}
+}
+
+func (r *poolTestReceiver) OnReceive(ctx context.Context, headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) {
+	r.received <- struct{}{}
+}
+
+func (r *poolTestReceiver) OnDecodeError(ctx context.Context, err error, headers api.HeaderMap) {}
+
+func newPoolTestHost(addr string, cfg *v2.ConnPoolConfig) types.Host {
+	c := cluster.NewCluster(v2.Cluster{
+		Name:        "test_pool",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		ConnPool:    cfg,
+	})
+	return cluster.NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:    addr,
+			TLSDisable: true,
+		},
+	}, c.Snapshot().ClusterInfo())
+}
+
+// newPoolStream waits the pool connected and sends a request on the new stream
+func newPoolStream(pool types.ConnectionPool, ctx context.Context, receiver types.StreamReceiveListener) *poolTestListener {
+	listener := &poolTestListener{}
+	for i := 0; i < 100; i++ {
+		listener.reason = ""
+		if pool.CheckAndInit(ctx) {
+			pool.NewStream(ctx, receiver, listener)
+			if listener.sender != nil || listener.reason == types.Overflow {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if listener.sender != nil {
+		listener.sender.AppendData(ctx, buffer.NewIoBufferBytes(newFrame(example.TypeRequest, 0, 0)), true)
+	}
+	return listener
+}
+
+func waitCounter(counter interface{ Count() int64 }, expected int64) bool {
+	for i := 0; i < 100; i++ {
+		if counter.Count() == expected {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestMultiplexConnPool(t *testing.T) {
+	srv := newPoolTestServer(t)
+	defer srv.ln.Close()
+
+	host := newPoolTestHost(srv.ln.Addr().String(), &v2.ConnPoolConfig{
+		ConnectionsPerHost:   2,
+		MaxConcurrentStreams: 1,
+	})
+	pool := NewConnPool(host)
+	defer pool.Close()
+
+	ctx := newTestContext("rpc-example")
+	receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("first stream failed: %s", l.reason)
+	}
+	// the first connection is busy, a second connection is created
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("second stream failed: %s", l.reason)
+	}
+	// all the connections are busy
+	if l := newPoolStream(pool, ctx, receiver); l.reason != types.Overflow {
+		t.Fatalf("expected overflow, got %s", l.reason)
+	}
+	if total := host.HostStats().UpstreamConnectionTotal.Count(); total != 2 {
+		t.Errorf("expected 2 connections, got %d", total)
+	}
+
+	// a stream finished, the connection is available again
+	srv.reply(t)
+	select {
+	case <-receiver.received:
+	case <-time.After(time.Second):
+		t.Fatal("no response received")
+	}
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("expected stream on the idle connection, got %s", l.reason)
+	}
+	if total := host.HostStats().UpstreamConnectionTotal.Count(); total != 2 {
+		t.Errorf("expected 2 connections, got %d", total)
+	}
+}
+
+// TestMultiplexConnPoolConcurrentStreams opens streams concurrently without retrying NewStream,
+// the pool must not be reported ready while the connection for the second stream is dialling
+func TestMultiplexConnPoolConcurrentStreams(t *testing.T) {
+	for _, mode := range []string{v2.ConnPoolModeMultiplex, v2.ConnPoolModePingPong} {
+		srv := newPoolTestServer(t)
+		host := newPoolTestHost(srv.ln.Addr().String(), &v2.ConnPoolConfig{
+			Mode:                 mode,
+			ConnectionsPerHost:   2,
+			MaxConcurrentStreams: 1,
+		})
+		pool := NewConnPool(host)
+
+		receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+
+		listeners := make(chan *poolTestListener, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				listeners <- newPoolStream(pool, newTestContext("rpc-example"), receiver)
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			if l := <-listeners; l.sender == nil {
+				t.Errorf("%s: concurrent stream failed: %s", mode, l.reason)
+			}
+		}
+		pool.Close()
+		srv.ln.Close()
+	}
+}
+
+func TestPingPongConnPool(t *testing.T) {
+	srv := newPoolTestServer(t)
+	defer srv.ln.Close()
+
+	host := newPoolTestHost(srv.ln.Addr().String(), &v2.ConnPoolConfig{
+		Mode:                 v2.ConnPoolModePingPong,
+		ConnectionsPerHost:   2,
+		MaxConcurrentStreams: 10, // ignored in pingpong mode
+	})
+	pool := NewConnPool(host)
+	defer pool.Close()
+
+	ctx := newTestContext("rpc-example")
+	receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+
+	stats := metrics.NewConnPoolStats("test_pool", v2.ConnPoolModePingPong)
+	timeoutClose := stats.Counter(metrics.UpstreamPoolTimeoutClose).Count()
+
+	first := newPoolStream(pool, ctx, receiver)
+	if first.sender == nil {
+		t.Fatalf("first stream failed: %s", first.reason)
+	}
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("second stream failed: %s", l.reason)
+	}
+	if l := newPoolStream(pool, ctx, receiver); l.reason != types.Overflow {
+		t.Fatalf("expected overflow, got %s", l.reason)
+	}
+
+	// the timeout stream closes its connection
+	first.sender.GetStream().ResetStream(types.StreamLocalReset)
+	if !waitCounter(host.HostStats().UpstreamConnectionClose, 1) {
+		t.Fatalf("expected the timeout connection closed, got %d closed", host.HostStats().UpstreamConnectionClose.Count())
+	}
+	if n := stats.Counter(metrics.UpstreamPoolTimeoutClose).Count() - timeoutClose; n != 1 {
+		t.Errorf("expected 1 timeout close, got %d", n)
+	}
+	// a new connection replaces the closed one
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("stream after timeout failed: %s", l.reason)
+	}
+	if total := host.HostStats().UpstreamConnectionTotal.Count(); total != 3 {
+		t.Errorf("expected 3 connections, got %d", total)
+	}
+}
+
+func TestMultiplexConnPoolDrain(t *testing.T) {
+	srv := newPoolTestServer(t)
+	defer srv.ln.Close()
+
+	host := newPoolTestHost(srv.ln.Addr().String(), &v2.ConnPoolConfig{
+		ConnectionsPerHost:   2,
+		MaxConcurrentStreams: 1,
+	})
+	pool := NewConnPool(host)
+	defer pool.Close()
+
+	ctx := newTestContext("rpc-example")
+	receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("first stream failed: %s", l.reason)
+	}
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("second stream failed: %s", l.reason)
+	}
+	// a connection is idle
+	srv.reply(t)
+	<-receiver.received
+
+	drained := pool.(types.DrainConnectionPool).Drain(5 * time.Second)
+	if !waitCounter(host.HostStats().UpstreamConnectionClose, 1) {
+		t.Fatal("the idle connection should be closed at once")
+	}
+	if pool.CheckAndInit(ctx) {
+		t.Fatal("the draining pool should not be available")
+	}
+	listener := &poolTestListener{}
+	pool.NewStream(ctx, receiver, listener)
+	if listener.sender != nil {
+		t.Fatal("no new stream should be assigned to the draining pool")
+	}
+	select {
+	case <-drained:
+		t.Fatal("the busy connection should not be closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// the busy connection is closed after its stream finished
+	srv.reply(t)
+	select {
+	case timeout := <-drained:
+		if timeout {
+			t.Fatal("the pool should be drained before the timeout")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the pool is not drained")
+	}
+}
+
+func TestMultiplexConnPoolDrainTimeout(t *testing.T) {
+	srv := newPoolTestServer(t)
+	defer srv.ln.Close()
+
+	host := newPoolTestHost(srv.ln.Addr().String(), nil)
+	pool := NewConnPool(host)
+	defer pool.Close()
+
+	ctx := newTestContext("rpc-example")
+	receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("stream failed: %s", l.reason)
+	}
+	drained := pool.(types.DrainConnectionPool).Drain(100 * time.Millisecond)
+	select {
+	case timeout := <-drained:
+		if !timeout {
+			t.Fatal("the busy connection should be closed by the timeout")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the pool is not drained after the timeout")
+	}
+}
+
+type bindTestConn struct {
+	api.Connection
+	id        uint64
+	listeners []api.ConnectionEventListener
+}
+
+func (c *bindTestConn) ID() uint64 {
+	return c.id
+}
+
+func (c *bindTestConn) AddConnectionEventListener(listener api.ConnectionEventListener) {
+	c.listeners = append(c.listeners, listener)
+}
+
+func (c *bindTestConn) close() {
+	for _, listener := range c.listeners {
+		listener.OnEvent(api.RemoteClose)
+	}
+}
+
+func TestBindConnPool(t *testing.T) {
+	srv := newPoolTestServer(t)
+	defer srv.ln.Close()
+
+	host := newPoolTestHost(srv.ln.Addr().String(), &v2.ConnPoolConfig{
+		Mode: v2.ConnPoolModeBind,
+	})
+	pool := NewConnPool(host)
+	defer pool.Close()
+
+	stats := metrics.NewConnPoolStats("test_pool", v2.ConnPoolModeBind)
+	bindTotal := stats.Counter(metrics.UpstreamPoolBindTotal).Count()
+	bindClose := stats.Counter(metrics.UpstreamPoolBindClose).Count()
+
+	receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+	downstream1 := &bindTestConn{id: 1}
+	downstream2 := &bindTestConn{id: 2}
+	ctx1 := mosnctx.WithValue(newTestContext("rpc-example"), types.ContextKeyDownstreamConnection, downstream1)
+	ctx2 := mosnctx.WithValue(newTestContext("rpc-example"), types.ContextKeyDownstreamConnection, downstream2)
+
+	for _, ctx := range []context.Context{ctx1, ctx1, ctx2} {
+		if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+			t.Fatalf("new stream failed: %s", l.reason)
+		}
+	}
+	// each downstream connection has its own upstream connection
+	if total := host.HostStats().UpstreamConnectionTotal.Count(); total != 2 {
+		t.Errorf("expected 2 connections, got %d", total)
+	}
+
+	downstream1.close()
+	if !waitCounter(host.HostStats().UpstreamConnectionClose, 1) {
+		t.Fatalf("expected the bound connection closed, got %d closed", host.HostStats().UpstreamConnectionClose.Count())
+	}
+	if n := stats.Counter(metrics.UpstreamPoolBindTotal).Count() - bindTotal; n != 2 {
+		t.Errorf("expected 2 bound connections, got %d", n)
+	}
+	if n := stats.Counter(metrics.UpstreamPoolBindClose).Count() - bindClose; n != 1 {
+		t.Errorf("expected 1 bound connection closed, got %d", n)
+	}
+	// the other downstream connection is not affected
+	if l := newPoolStream(pool, ctx2, receiver); l.sender == nil {
+		t.Fatalf("new stream failed: %s", l.reason)
+	}
+	if total := host.HostStats().UpstreamConnectionTotal.Count(); total != 2 {
+		t.Errorf("expected 2 connections, got %d", total)
+	}
+}
+
+func TestMultiplexConnPoolState(t *testing.T) {
+	srv := newPoolTestServer(t)
+	defer srv.ln.Close()
+
+	host := newPoolTestHost(srv.ln.Addr().String(), &v2.ConnPoolConfig{
+		ConnectionsPerHost:   2,
+		MaxConcurrentStreams: 1,
+	})
+	pool := NewConnPool(host)
+	defer pool.Close()
+	sp := pool.(types.StateConnectionPool)
+
+	ctx := newTestContext("rpc-example")
+	receiver := &poolTestReceiver{received: make(chan struct{}, 4)}
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("first stream failed: %s", l.reason)
+	}
+	if l := newPoolStream(pool, ctx, receiver); l.sender == nil {
+		t.Fatalf("second stream failed: %s", l.reason)
+	}
+	if state := sp.State(); state.Connections != 2 || state.ActiveConnections != 2 || state.ActiveStreams != 2 || state.IdleConnections != 0 {
+		t.Errorf("unexpected state with busy connections: %+v", state)
+	}
+
+	srv.reply(t)
+	select {
+	case <-receiver.received:
+	case <-time.After(time.Second):
+		t.Fatal("no response received")
+	}
+	if state := sp.State(); state.Connections != 2 || state.ActiveConnections != 1 || state.ActiveStreams != 1 || state.IdleConnections != 1 {
+		t.Errorf("unexpected state after a stream finished: %+v", state)
+	}
+}
+
+func TestMultiplexConnPoolConnectFailures(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	pool := NewConnPool(newPoolTestHost(addr, nil))
+	defer pool.Close()
+	sp := pool.(types.StateConnectionPool)
+	ctx := newTestContext("rpc-example")
+	for i := 0; i < 100 && sp.State().ConnectFailures == 0; i++ {
+		pool.CheckAndInit(ctx)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if state := sp.State(); state.ConnectFailures == 0 || state.Connections != 0 {
+		t.Errorf("unexpected state after connect failed: %+v", state)
+	}
+}
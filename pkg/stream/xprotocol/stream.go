@@ -97,6 +97,9 @@ func newStreamConnection(ctx context.Context, connection api.Connection, clientC
 	subProtocolName := xprotocol.SubProtocol(mosnctx.Get(ctx, types.ContextSubProtocol).(string))
 	log.DefaultLogger.Tracef("xprotocol subprotocol config name = %v", subProtocolName)
 	codec := xprotocol.CreateSubProtocolCodec(ctx, subProtocolName)
+	if codec == nil {
+		log.DefaultLogger.Errorf("xprotocol no codec found for subprotocol %v", subProtocolName)
+	}
 	log.DefaultLogger.Tracef("xprotocol new stream connection, codec type = %v", subProtocolName)
 	return &streamConnection{
 		context:                             ctx,
@@ -117,9 +120,24 @@ func (conn *streamConnection) Dispatch(buf buffer.IoBuffer) {
 	log.DefaultLogger.Tracef("stream connection dispatch data bytes = %v", buf.Bytes())
 	log.DefaultLogger.Tracef("stream connection dispatch data string = %v", buf.String())
 
+	if conn.codec == nil {
+		log.DefaultLogger.Errorf("xprotocol dispatch without codec, close connection %d", conn.connection.ID())
+		buf.Drain(buf.Len())
+		conn.connection.Close(api.NoFlush, api.LocalClose)
+		return
+	}
+
 	// get sub protocol codec
 	requestList := conn.codec.SplitFrame(buf.Bytes())
 	for _, request := range requestList {
+		// heartbeat is replied directly, never routed
+		if heartbeatCodec, ok := conn.codec.(xprotocol.Heartbeater); ok && heartbeatCodec.IsHeartbeat(request) {
+			if reply := heartbeatCodec.HeartbeatReply(request); reply != nil {
+				conn.connection.Write(buffer.NewIoBufferBytes(reply))
+			}
+			buf.Drain(len(request))
+			continue
+		}
 
 		// stream-level context
 		ctx := mbuffer.NewBufferPoolContext(mosnctx.Clone(conn.context))
@@ -155,6 +173,12 @@ func (conn *streamConnection) Dispatch(buf buffer.IoBuffer) {
 				log.DefaultLogger.Tracef("xprotocol handle request route ,headers = %v", headers)
 			}
 		}
+		// response status, used by the proxy retries and stats
+		if conn.serverStreamConnectionEventListener == nil {
+			if statusCodec, ok := conn.codec.(xprotocol.ResponseStatus); ok {
+				headers[types.HeaderStatus] = strconv.Itoa(statusCodec.GetStatusCode(request))
+			}
+		}
 		// tracing
 		tracingCodec, ok := conn.codec.(xprotocol.Tracing)
 		if ok {
@@ -200,10 +224,15 @@ func (conn *streamConnection) CheckReasonError(connected bool, event api.Connect
 }
 
 func (conn *streamConnection) Reset(reason types.StreamResetReason) {
-	conn.activeStream.mux.Lock()
-	defer conn.activeStream.mux.Unlock()
-
+	conn.activeStream.mux.RLock()
+	streams := make([]*stream, 0, len(conn.activeStream.smap))
 	for _, s := range conn.activeStream.smap {
+		streams = append(streams, s)
+	}
+	conn.activeStream.mux.RUnlock()
+
+	// reset out of the lock, the stream listeners may create new streams
+	for _, s := range streams {
 		s.ResetStream(reason)
 	}
 }
@@ -213,7 +242,7 @@ func (conn *streamConnection) NewStream(ctx context.Context, responseDecoder typ
 	nStreamID := atomic.AddUint64(&conn.streamIDXprotocolCount, 1)
 	streamID := strconv.FormatUint(nStreamID, 10)
 
-	stream := stream{
+	stream := &stream{
 		context:        mosnctx.WithValue(ctx, types.ContextKeyStreamID, streamID),
 		streamID:       streamID,
 		direction:      ClientStream,
@@ -222,7 +251,7 @@ func (conn *streamConnection) NewStream(ctx context.Context, responseDecoder typ
 	}
 	conn.activeStream.Set(streamID, stream)
 
-	return &stream
+	return stream
 }
 
 func (conn *streamConnection) OnReceive(ctx context.Context, streamID string, headers types.HeaderMap, data buffer.IoBuffer) api.FilterStatus {
@@ -247,14 +276,14 @@ func (conn *streamConnection) onNewStreamDetected(ctx context.Context, streamID
 	if ok := conn.activeStream.Has(streamID); ok {
 		return
 	}
-	stream := stream{
+	stream := &stream{
 		context:    mosnctx.WithValue(ctx, types.ContextKeyStreamID, streamID),
 		streamID:   streamID,
 		direction:  ServerStream,
 		connection: conn,
 	}
 
-	stream.streamReceiver = conn.serverStreamConnectionEventListener.NewStreamDetect(ctx, &stream, nil)
+	stream.streamReceiver = conn.serverStreamConnectionEventListener.NewStreamDetect(ctx, stream, nil)
 	conn.activeStream.Set(streamID, stream)
 }
 
@@ -271,6 +300,7 @@ type stream struct {
 	streamReceiver   types.StreamReceiveListener
 	encodedHeaders   types.IoBuffer
 	encodedData      types.IoBuffer
+	statusCode       int
 }
 
 // AddEventListener add stream event callback
@@ -294,6 +324,12 @@ func (s *stream) BufferLimit() uint32 {
 // types.StreamEncoder
 func (s *stream) AppendHeaders(context context.Context, headers types.HeaderMap, endStream bool) error {
 	log.DefaultLogger.Tracef("EncodeHeaders,request id = %s, direction = %d", s.streamID, s.direction)
+	if s.direction == ServerStream {
+		// the status is set by the proxy if the request is hijacked
+		if status, ok := headers.Get(types.HeaderStatus); ok {
+			s.statusCode, _ = strconv.Atoi(status)
+		}
+	}
 	if endStream {
 		s.endStream()
 	}
@@ -332,7 +368,18 @@ func (s *stream) endStream() {
 	}()
 
 	log.DefaultLogger.Tracef("xprotocol stream end stream invoked , request id = %s, direction = %d", s.streamID, s.direction)
-	if stream, ok := s.connection.activeStream.Get(s.streamID); ok {
+	if s.encodedData == nil && s.direction == ServerStream {
+		// no response data means the request is hijacked by the proxy
+		if hijackCodec, ok := s.connection.codec.(xprotocol.Hijacker); ok {
+			if resp := hijackCodec.HijackResponse(s.streamID, s.statusCode); resp != nil {
+				s.encodedData = buffer.NewIoBufferBytes(resp)
+			}
+		}
+	}
+
+	if s.encodedData == nil {
+		log.DefaultLogger.Errorf("xprotocol stream %s has no data to write, direction = %d", s.streamID, s.direction)
+	} else if stream, ok := s.connection.activeStream.Get(s.streamID); ok {
 		log.DefaultLogger.Tracef("xprotocol stream end stream write encodedata = %v", s.encodedData)
 		stream.connection.connection.Write(s.encodedData)
 	} else {
@@ -352,12 +399,12 @@ func (s *stream) GetStream() types.Stream {
 }
 
 type streamMap struct {
-	smap map[string]stream
+	smap map[string]*stream
 	mux  sync.RWMutex
 }
 
 func newStreamMap(context context.Context) streamMap {
-	smap := make(map[string]stream, 32)
+	smap := make(map[string]*stream, 32)
 
 	return streamMap{
 		smap: smap,
@@ -377,7 +424,7 @@ func (m *streamMap) Has(streamID string) bool {
 }
 
 // Get return stream
-func (m *streamMap) Get(streamID string) (stream, bool) {
+func (m *streamMap) Get(streamID string) (*stream, bool) {
 	m.mux.RLock()
 	defer m.mux.RUnlock()
 
@@ -385,7 +432,7 @@ func (m *streamMap) Get(streamID string) (stream, bool) {
 		return s, ok
 	}
 
-	return stream{}, false
+	return nil, false
 }
 
 // Remove delete stream
@@ -397,7 +444,7 @@ func (m *streamMap) Remove(streamID string) {
 }
 
 // Set add stream
-func (m *streamMap) Set(streamID string, s stream) {
+func (m *streamMap) Set(streamID string, s *stream) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -0,0 +1,180 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xprotocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"testing"
+
+	"mosn.io/api"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/rpc/xprotocol/example"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/buffer"
+)
+
+type testConn struct {
+	api.Connection
+	written bytes.Buffer
+	closed  bool
+}
+
+func (c *testConn) ID() uint64 {
+	return 1
+}
+
+func (c *testConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 12200}
+}
+
+func (c *testConn) Write(bufs ...buffer.IoBuffer) error {
+	for _, buf := range bufs {
+		c.written.Write(buf.Bytes())
+	}
+	return nil
+}
+
+func (c *testConn) Close(ccType api.ConnectionCloseType, eventType api.ConnectionEvent) error {
+	c.closed = true
+	return nil
+}
+
+type testReceiver struct {
+	headers []api.HeaderMap
+	sender  types.StreamSender
+}
+
+func (r *testReceiver) OnReceive(ctx context.Context, headers api.HeaderMap, data buffer.IoBuffer, trailers api.HeaderMap) {
+	r.headers = append(r.headers, headers)
+}
+
+func (r *testReceiver) OnDecodeError(ctx context.Context, err error, headers api.HeaderMap) {}
+
+func (r *testReceiver) OnGoAway() {}
+
+func (r *testReceiver) NewStreamDetect(ctx context.Context, sender types.StreamSender, span types.Span) types.StreamReceiveListener {
+	r.sender = sender
+	return r
+}
+
+func newTestContext(subProtocol string) context.Context {
+	return mosnctx.WithValue(context.Background(), types.ContextSubProtocol, subProtocol)
+}
+
+func newFrame(frameType byte, streamID uint64, status uint16) []byte {
+	frame := make([]byte, example.ReqDataLen)
+	frame[example.TypeOffset] = frameType
+	binary.BigEndian.PutUint16(frame[example.StatusOffset:], status)
+	binary.BigEndian.PutUint64(frame[example.ReqIDBeginOffset:], streamID)
+	return frame
+}
+
+func TestDispatchHeartbeat(t *testing.T) {
+	conn := &testConn{}
+	receiver := &testReceiver{}
+	sc := newStreamConnection(newTestContext("rpc-example"), conn, nil, receiver)
+
+	data := append(newFrame(example.TypeHeartbeat, 7, 0), newFrame(example.TypeRequest, 8, 0)...)
+	buf := buffer.NewIoBufferBytes(data)
+	sc.Dispatch(buf)
+
+	if buf.Len() != 0 {
+		t.Errorf("expected all frames drained, remain %d", buf.Len())
+	}
+	if len(receiver.headers) != 1 {
+		t.Fatalf("expected only the request routed, got %d", len(receiver.headers))
+	}
+	reply := conn.written.Bytes()
+	if len(reply) != example.ReqDataLen || reply[example.TypeOffset] != example.TypeHeartbeatResponse {
+		t.Fatalf("unexpected heartbeat reply: %v", reply)
+	}
+	if id := binary.BigEndian.Uint64(reply[example.ReqIDBeginOffset:]); id != 7 {
+		t.Errorf("expected heartbeat reply id 7, got %d", id)
+	}
+}
+
+func TestServerStreamHijack(t *testing.T) {
+	conn := &testConn{}
+	receiver := &testReceiver{}
+	sc := newStreamConnection(newTestContext("rpc-example"), conn, nil, receiver)
+	sc.Dispatch(buffer.NewIoBufferBytes(newFrame(example.TypeRequest, 5, 0)))
+	if receiver.sender == nil {
+		t.Fatal("no stream detected")
+	}
+
+	headers := protocol.CommonHeader{types.HeaderStatus: strconv.Itoa(404)}
+	if err := receiver.sender.AppendHeaders(context.Background(), headers, true); err != nil {
+		t.Fatalf("append headers failed: %v", err)
+	}
+	resp := conn.written.Bytes()
+	if len(resp) != example.ReqDataLen {
+		t.Fatalf("unexpected hijack response: %v", resp)
+	}
+	if resp[example.TypeOffset] != example.TypeResponse {
+		t.Errorf("expected response frame, got type %d", resp[example.TypeOffset])
+	}
+	if status := binary.BigEndian.Uint16(resp[example.StatusOffset:]); status != example.StatusNoService {
+		t.Errorf("expected status %d, got %d", example.StatusNoService, status)
+	}
+	if id := binary.BigEndian.Uint64(resp[example.ReqIDBeginOffset:]); id != 5 {
+		t.Errorf("expected response id 5, got %d", id)
+	}
+	if sc.ActiveStreamsNum() != 0 {
+		t.Errorf("expected stream removed after hijack")
+	}
+}
+
+func TestClientStreamResponseStatus(t *testing.T) {
+	conn := &testConn{}
+	receiver := &testReceiver{}
+	cc := newStreamConnection(newTestContext("rpc-example"), conn, receiver, nil)
+	sender := cc.NewStream(context.Background(), receiver)
+	if err := sender.AppendData(context.Background(), buffer.NewIoBufferBytes(newFrame(example.TypeRequest, 100, 0)), true); err != nil {
+		t.Fatalf("append data failed: %v", err)
+	}
+	if id := binary.BigEndian.Uint64(conn.written.Bytes()[example.ReqIDBeginOffset:]); id != 1 {
+		t.Fatalf("expected request id replaced by 1, got %d", id)
+	}
+
+	cc.Dispatch(buffer.NewIoBufferBytes(newFrame(example.TypeResponse, 1, example.StatusBusy)))
+	if len(receiver.headers) != 1 {
+		t.Fatalf("expected one response, got %d", len(receiver.headers))
+	}
+	code, err := protocol.MappingHeaderStatusCode(protocol.Xprotocol, receiver.headers[0])
+	if err != nil || code != 503 {
+		t.Errorf("expected status 503, got %d, %v", code, err)
+	}
+}
+
+func TestDispatchWithoutCodec(t *testing.T) {
+	conn := &testConn{}
+	sc := newStreamConnection(newTestContext("unknown"), conn, nil, &testReceiver{})
+	buf := buffer.NewIoBufferBytes(newFrame(example.TypeRequest, 1, 0))
+	sc.Dispatch(buf)
+	if !conn.closed {
+		t.Error("expected connection closed without codec")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected data drained, remain %d", buf.Len())
+	}
+}
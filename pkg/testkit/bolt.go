@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testkit
+
+import (
+	"net"
+
+	"mosn.io/mosn/pkg/protocol/rpc/sofarpc"
+	"mosn.io/mosn/pkg/protocol/rpc/sofarpc/codec"
+	"mosn.io/pkg/buffer"
+)
+
+type boltServer struct {
+	upstream
+	*connServer
+}
+
+// NewBoltServer starts a sofarpc bolt v1 upstream server on the addr, a free loopback port is used if addr is empty.
+// The Status of the behavior is the response status of the bolt response
+func NewBoltServer(addr string) (Server, error) {
+	s := &boltServer{}
+	cs, err := newConnServer(addr, s.serve)
+	if err != nil {
+		return nil, err
+	}
+	s.connServer = cs
+	return s, nil
+}
+
+func (s *boltServer) serve(conn net.Conn) {
+	data := buffer.NewIoBuffer(4096)
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		data.Write(buf[:n])
+		for data.Len() > 0 {
+			cmd, err := codec.BoltCodec.Decode(nil, data)
+			if err != nil {
+				return
+			}
+			if cmd == nil {
+				break
+			}
+			req, ok := cmd.(*sofarpc.BoltRequest)
+			if !ok || req.CmdType == sofarpc.REQUEST_ONEWAY {
+				continue
+			}
+			if req.CmdCode == sofarpc.HEARTBEAT {
+				if !s.heartbeat(conn, req) {
+					return
+				}
+				continue
+			}
+			if !s.respond(conn, req) {
+				return
+			}
+		}
+	}
+}
+
+// respond writes the response of the request, returns false if the connection should be closed
+func (s *boltServer) respond(conn net.Conn, req *sofarpc.BoltRequest) bool {
+	b := s.next()
+	if b.Reset {
+		return false
+	}
+	body := b.Body
+	if body == nil && req.Content != nil {
+		body = req.Content.Bytes()
+	}
+	resp := &sofarpc.BoltResponse{
+		Protocol:       req.Protocol,
+		CmdType:        sofarpc.RESPONSE,
+		CmdCode:        sofarpc.RPC_RESPONSE,
+		Version:        req.Version,
+		ReqID:          req.ReqID,
+		Codec:          req.Codec,
+		ResponseStatus: int16(b.Status),
+		HeaderLen:      req.HeaderLen,
+		HeaderMap:      req.HeaderMap,
+		ContentLen:     len(body),
+	}
+	if len(b.Headers) > 0 {
+		resp.HeaderLen = 0
+		resp.HeaderMap = nil
+		resp.ResponseHeader = b.Headers
+	}
+	encoded, err := codec.BoltCodec.Encode(nil, resp)
+	if err != nil {
+		return false
+	}
+	header := encoded.Bytes()
+	if b.PartialBytes > 0 && b.PartialBytes < len(body) {
+		conn.Write(append(header, body[:b.PartialBytes]...))
+		return false
+	}
+	_, err = conn.Write(append(header, body...))
+	return err == nil
+}
+
+// heartbeat acks the heartbeat request, which is not counted in the requests
+func (s *boltServer) heartbeat(conn net.Conn, req *sofarpc.BoltRequest) bool {
+	encoded, err := codec.BoltCodec.Encode(nil, &sofarpc.BoltResponse{
+		Protocol:       req.Protocol,
+		CmdType:        sofarpc.RESPONSE,
+		CmdCode:        sofarpc.HEARTBEAT,
+		Version:        req.Version,
+		ReqID:          req.ReqID,
+		Codec:          req.Codec,
+		ResponseStatus: sofarpc.RESPONSE_STATUS_SUCCESS,
+	})
+	if err != nil {
+		return false
+	}
+	_, err = conn.Write(encoded.Bytes())
+	return err == nil
+}
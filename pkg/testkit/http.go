@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testkit
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/net/http2"
+)
+
+// httpHandler handles the http requests by the behaviors
+type httpHandler struct {
+	upstream
+	// reset drops the response that is written or not
+	reset func(w http.ResponseWriter)
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b := h.next()
+	body := b.Body
+	if body == nil {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	if b.Reset {
+		h.reset(w)
+		return
+	}
+	for k, v := range b.Headers {
+		w.Header().Set(k, v)
+	}
+	status := b.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	if b.PartialBytes > 0 && b.PartialBytes < len(body) {
+		w.Write(body[:b.PartialBytes])
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		h.reset(w)
+		return
+	}
+	w.Write(body)
+}
+
+// resetHTTP1 closes the connection of the http1 response
+func resetHTTP1(w http.ResponseWriter) {
+	if hj, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hj.Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// resetHTTP2 resets the stream of the http2 response
+func resetHTTP2(w http.ResponseWriter) {
+	panic(http.ErrAbortHandler)
+}
+
+type http1Server struct {
+	*httpHandler
+	listener net.Listener
+	server   *http.Server
+}
+
+// NewHTTP1Server starts a http1 upstream server on the addr, a free loopback port is used if addr is empty
+func NewHTTP1Server(addr string) (Server, error) {
+	l, err := listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &http1Server{
+		httpHandler: &httpHandler{reset: resetHTTP1},
+		listener:    l,
+	}
+	s.server = &http.Server{
+		Handler: s.httpHandler,
+	}
+	go s.server.Serve(l)
+	return s, nil
+}
+
+func (s *http1Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *http1Server) Close() error {
+	return s.server.Close()
+}
+
+type http2Server struct {
+	*httpHandler
+	*connServer
+}
+
+// NewHTTP2Server starts a http2 upstream server without tls (h2c) on the addr,
+// a free loopback port is used if addr is empty
+func NewHTTP2Server(addr string) (Server, error) {
+	handler := &httpHandler{reset: resetHTTP2}
+	server := &http2.Server{}
+	opts := &http2.ServeConnOpts{Handler: handler}
+	cs, err := newConnServer(addr, func(conn net.Conn) {
+		server.ServeConn(conn, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &http2Server{
+		httpHandler: handler,
+		connServer:  cs,
+	}, nil
+}
@@ -0,0 +1,233 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testkit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+	"mosn.io/mosn/pkg/mosn"
+	"mosn.io/mosn/pkg/types"
+)
+
+// ReadyTimeout is the max time waiting for the listeners of the started mosn
+var ReadyTimeout = 5 * time.Second
+
+// ErrMosnRunning is returned if a mosn is started before the running one is closed,
+// as mosn keeps the clusters, listeners and stats globally
+var ErrMosnRunning = errors.New("a mosn is running in process")
+
+var (
+	runningMutex sync.Mutex
+	running      bool
+)
+
+// Mosn is a mosn started in process
+type Mosn struct {
+	mosn   *mosn.Mosn
+	config *v2.MOSNConfig
+	once   sync.Once
+}
+
+// StartMosn starts a mosn by the config in process, and waits until the listeners are ready.
+// Only one mosn runs in a process at a time
+func StartMosn(config *v2.MOSNConfig) (*Mosn, error) {
+	runningMutex.Lock()
+	defer runningMutex.Unlock()
+	if running {
+		return nil, ErrMosnRunning
+	}
+	m := &Mosn{
+		mosn:   mosn.NewMosn(config),
+		config: config,
+	}
+	m.mosn.Start()
+	running = true
+	for _, addr := range m.ListenerAddrs() {
+		if err := waitListening(addr, ReadyTimeout); err != nil {
+			m.close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// StartMosnFromFile starts a mosn by the config file in process
+func StartMosnFromFile(path string) (*Mosn, error) {
+	return StartMosn(configmanager.Load(path))
+}
+
+// StartMosnFromJSON starts a mosn by the json config in process
+func StartMosnFromJSON(data []byte) (*Mosn, error) {
+	config := &v2.MOSNConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return StartMosn(config)
+}
+
+// ListenerAddrs returns the addresses of the listeners bound to port
+func (m *Mosn) ListenerAddrs() []string {
+	var addrs []string
+	for _, server := range m.config.Servers {
+		for _, l := range server.Listeners {
+			if l.BindToPort {
+				addrs = append(addrs, l.AddrConfig)
+			}
+		}
+	}
+	return addrs
+}
+
+// Close stops the mosn, another mosn can be started after it is closed
+func (m *Mosn) Close() {
+	runningMutex.Lock()
+	defer runningMutex.Unlock()
+	m.close()
+}
+
+func (m *Mosn) close() {
+	m.once.Do(func() {
+		m.mosn.Close()
+		running = false
+	})
+}
+
+func waitListening(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("listener %s is not ready in %v: %v", addr, timeout, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// FreeAddr returns a free loopback address for the listener of mosn
+func FreeAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
+
+// ProxyConfig returns a config of mosn that proxies the requests received on the listener addr
+// to the hosts in the cluster "testkit", the upstream protocol is the same as the downstream.
+// The sofarpc requests are routed by the "service" header
+func ProxyConfig(addr string, proto types.Protocol, hosts ...string) *v2.MOSNConfig {
+	const clusterName = "testkit"
+	var vhosts []v2.Host
+	for _, host := range hosts {
+		vhosts = append(vhosts, v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: host,
+			},
+		})
+	}
+	routerConfig := v2.RouterConfiguration{
+		RouterConfigurationConfig: v2.RouterConfigurationConfig{
+			RouterConfigName: clusterName,
+		},
+		VirtualHosts: []*v2.VirtualHost{
+			{
+				Name:    clusterName,
+				Domains: []string{"*"},
+				Routers: []v2.Router{
+					newRouter(clusterName, v2.RouterMatch{Prefix: "/"}),
+					newRouter(clusterName, v2.RouterMatch{Headers: []v2.HeaderMatcher{{Name: "service", Value: ".*"}}}),
+				},
+			},
+		},
+	}
+	proxy := &v2.Proxy{
+		DownstreamProtocol: string(proto),
+		UpstreamProtocol:   string(proto),
+		RouterConfigName:   clusterName,
+	}
+	return &v2.MOSNConfig{
+		Servers: []v2.ServerConfig{
+			{
+				DefaultLogPath:  "stdout",
+				DefaultLogLevel: "ERROR",
+				Listeners: []v2.Listener{
+					{
+						ListenerConfig: v2.ListenerConfig{
+							Name:       clusterName,
+							AddrConfig: addr,
+							BindToPort: true,
+							FilterChains: []v2.FilterChain{
+								{
+									FilterChainConfig: v2.FilterChainConfig{
+										Filters: []v2.Filter{
+											{Type: v2.DEFAULT_NETWORK_FILTER, Config: toMap(proxy)},
+											{Type: v2.CONNECTION_MANAGER, Config: toMap(routerConfig)},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		ClusterManager: v2.ClusterManagerConfig{
+			Clusters: []v2.Cluster{
+				{
+					Name:        clusterName,
+					ClusterType: v2.SIMPLE_CLUSTER,
+					LbType:      v2.LB_ROUNDROBIN,
+					Hosts:       vhosts,
+				},
+			},
+		},
+	}
+}
+
+func newRouter(cluster string, match v2.RouterMatch) v2.Router {
+	return v2.Router{
+		RouterConfig: v2.RouterConfig{
+			Match: match,
+			Route: v2.RouteAction{
+				RouterActionConfig: v2.RouterActionConfig{
+					ClusterName: cluster,
+				},
+			},
+		},
+	}
+}
+
+// toMap converts the config into the map of the filter config
+func toMap(config interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	data, _ := json.Marshal(config)
+	json.Unmarshal(data, &m)
+	return m
+}
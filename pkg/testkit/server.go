@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package testkit helps to test the filters and the configs end to end, it provides the upstream servers
+// with the programmable behaviors, and boots a mosn in process.
+package testkit
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Behavior describes how an upstream server handles a request
+type Behavior struct {
+	// Latency is the delay before the request is handled
+	Latency time.Duration
+	// Reset closes the connection without a response, the http2 server resets the stream instead
+	Reset bool
+	// PartialBytes sends the response with the first PartialBytes of the body only, then closes the connection.
+	// The http2 server resets the stream instead
+	PartialBytes int
+	// Status is the response status, zero means 200 for http and success for sofarpc
+	Status int
+	// Headers are the response headers
+	Headers map[string]string
+	// Body is the response body, the request body is echoed if it is nil
+	Body []byte
+}
+
+// Server is an upstream server listening on the loopback address
+type Server interface {
+	// Addr returns the listening address
+	Addr() string
+	// SetBehaviors sets the behaviors of the following requests in order, the last behavior repeats
+	SetBehaviors(behaviors ...Behavior)
+	// Requests returns the number of the requests received
+	Requests() uint32
+	Close() error
+}
+
+// upstream holds the behaviors and the stats shared by the servers
+type upstream struct {
+	mutex     sync.Mutex
+	behaviors []Behavior
+	requests  uint32
+}
+
+func (u *upstream) SetBehaviors(behaviors ...Behavior) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.behaviors = append([]Behavior(nil), behaviors...)
+}
+
+func (u *upstream) Requests() uint32 {
+	return atomic.LoadUint32(&u.requests)
+}
+
+// next takes the behavior of a new request, and waits the latency
+func (u *upstream) next() Behavior {
+	atomic.AddUint32(&u.requests, 1)
+	u.mutex.Lock()
+	var b Behavior
+	if len(u.behaviors) > 0 {
+		b = u.behaviors[0]
+		if len(u.behaviors) > 1 {
+			u.behaviors = u.behaviors[1:]
+		}
+	}
+	u.mutex.Unlock()
+	if b.Latency > 0 {
+		time.Sleep(b.Latency)
+	}
+	return b
+}
+
+// listen listens the addr, a free loopback port is used if addr is empty
+func listen(addr string) (net.Listener, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	return net.Listen("tcp", addr)
+}
+
+// connServer serves the accepted connections, and closes them on Close
+type connServer struct {
+	listener net.Listener
+	serve    func(conn net.Conn)
+	mutex    sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func newConnServer(addr string, serve func(conn net.Conn)) (*connServer, error) {
+	l, err := listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &connServer{
+		listener: l,
+		serve:    serve,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	go s.accept()
+	return s, nil
+}
+
+func (s *connServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+		go func() {
+			defer s.remove(conn)
+			s.serve(conn)
+		}()
+	}
+}
+
+func (s *connServer) remove(conn net.Conn) {
+	conn.Close()
+	s.mutex.Lock()
+	delete(s.conns, conn)
+	s.mutex.Unlock()
+}
+
+func (s *connServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *connServer) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return s.listener.Close()
+}
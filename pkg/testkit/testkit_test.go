@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testkit
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/protocol/rpc/sofarpc"
+	"mosn.io/mosn/pkg/protocol/rpc/sofarpc/codec"
+	"mosn.io/pkg/buffer"
+)
+
+func TestHTTP1ServerBehaviors(t *testing.T) {
+	s, err := NewHTTP1Server("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetBehaviors(
+		Behavior{Reset: true},
+		Behavior{PartialBytes: 2, Body: []byte("hello")},
+		Behavior{Status: http.StatusAccepted, Headers: map[string]string{"X-Test": "ok"}},
+	)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	url := "http://" + s.Addr() + "/"
+	if _, err := client.Post(url, "text/plain", strings.NewReader("echo")); err == nil {
+		t.Error("expected the connection reset")
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadAll(resp.Body); err == nil {
+		t.Error("expected the partial body error")
+	}
+	resp.Body.Close()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(url, "text/plain", strings.NewReader("echo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted || resp.Header.Get("X-Test") != "ok" || string(body) != "echo" {
+			t.Errorf("unexpected response %d %v %s", resp.StatusCode, resp.Header, body)
+		}
+	}
+	if n := s.Requests(); n != 4 {
+		t.Errorf("expected 4 requests, but got %d", n)
+	}
+}
+
+func TestBoltServer(t *testing.T) {
+	s, err := NewBoltServer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetBehaviors(Behavior{Status: int(sofarpc.RESPONSE_STATUS_SERVER_EXCEPTION), Latency: 10 * time.Millisecond})
+	conn, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	content := []byte("request")
+	req := &sofarpc.BoltRequest{
+		Protocol:      sofarpc.PROTOCOL_CODE_V1,
+		CmdType:       sofarpc.REQUEST,
+		CmdCode:       sofarpc.RPC_REQUEST,
+		Version:       1,
+		ReqID:         1,
+		Codec:         sofarpc.HESSIAN2_SERIALIZE,
+		Timeout:       -1,
+		ContentLen:    len(content),
+		RequestHeader: map[string]string{"service": "testkit"},
+	}
+	encoded, err := codec.BoltCodec.Encode(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write(append(encoded.Bytes(), content...))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	data := buffer.NewIoBuffer(1024)
+	for {
+		if _, err := data.ReadOnce(conn); err != nil {
+			t.Fatal(err)
+		}
+		cmd, err := codec.BoltCodec.Decode(nil, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmd == nil {
+			continue
+		}
+		resp, ok := cmd.(*sofarpc.BoltResponse)
+		if !ok {
+			t.Fatalf("unexpected response %v", cmd)
+		}
+		if resp.ReqID != 1 || resp.ResponseStatus != sofarpc.RESPONSE_STATUS_SERVER_EXCEPTION || resp.Content.String() != "request" {
+			t.Errorf("unexpected response %+v", resp)
+		}
+		return
+	}
+}
+
+func TestProxyHTTP1(t *testing.T) {
+	s, err := NewHTTP1Server("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	addr, err := FreeAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := StartMosn(ProxyConfig(addr, protocol.HTTP1, s.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := StartMosn(ProxyConfig(addr, protocol.HTTP1, s.Addr())); err != ErrMosnRunning {
+		t.Errorf("expected mosn running error, but got %v", err)
+	}
+	s.SetBehaviors(Behavior{Body: []byte("from upstream")})
+	resp, err := http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "from upstream" {
+		t.Errorf("unexpected response %d %s", resp.StatusCode, body)
+	}
+}
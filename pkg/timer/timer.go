@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package timer provides timers based on hierarchical timing wheels.
+// The timers are cheaper than the runtime timers when there are lots of
+// timers that are stopped before expired, such as the stream timeout timers.
+package timer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+// DefaultTick is the precision of the timers
+const DefaultTick = 10 * time.Millisecond
+
+type wheelStats struct {
+	pending gometrics.Gauge
+	expired gometrics.Counter
+	stopped gometrics.Counter
+}
+
+func newWheelStats(shard int) *wheelStats {
+	s := metrics.NewTimerStats(shard)
+	return &wheelStats{
+		pending: s.Gauge(metrics.TimerPending),
+		expired: s.Counter(metrics.TimerExpired),
+		stopped: s.Counter(metrics.TimerStopped),
+	}
+}
+
+var (
+	initOnce sync.Once
+	wheels   []*wheel
+	index    uint32
+)
+
+func initWheels() {
+	shards := runtime.NumCPU()
+	wheels = make([]*wheel, shards)
+	for i := 0; i < shards; i++ {
+		w := newWheel(DefaultTick, newWheelStats(i))
+		wheels[i] = w
+		go w.run()
+	}
+}
+
+// AfterFunc waits for the duration to elapse and then calls the callback in its own goroutine.
+// The timers are sharded to the wheels, each wheel is driven by one goroutine.
+// The duration is rounded up to the DefaultTick.
+func AfterFunc(d time.Duration, callback func()) *Timer {
+	initOnce.Do(initWheels)
+	w := wheels[atomic.AddUint32(&index, 1)%uint32(len(wheels))]
+	return w.add(d, callback)
+}
@@ -0,0 +1,249 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/pkg/utils"
+)
+
+// the wheel has 5 levels, the first level has 256 slots, and the others have 64 slots.
+// a timer is placed in the level that covers its expire ticks, and cascades to
+// the lower levels when the higher level slot is expired.
+const (
+	rootBits   = 8
+	rootSize   = 1 << rootBits
+	rootMask   = rootSize - 1
+	levelBits  = 6
+	levelSize  = 1 << levelBits
+	levelMask  = levelSize - 1
+	levelCount = 4
+	// maxTicks is the max ticks a timer can wait, longer timers are bounded to it
+	maxTicks = 1<<(rootBits+levelCount*levelBits) - 1
+)
+
+// Timer is a timer in the timing wheel
+type Timer struct {
+	callback func()
+	wheel    *wheel
+	expire   uint64
+	// the timer is linked in a slot list, the slot is nil if the timer is not pending
+	slot       *slot
+	prev, next *Timer
+}
+
+// Stop prevents the timer from firing, returns false if the timer has already expired or been stopped
+func (t *Timer) Stop() bool {
+	if t == nil || t.wheel == nil {
+		return false
+	}
+	return t.wheel.remove(t)
+}
+
+// slot is a double linked list of timers
+type slot struct {
+	head Timer
+}
+
+func (s *slot) init() {
+	s.head.next = &s.head
+	s.head.prev = &s.head
+}
+
+func (s *slot) push(t *Timer) {
+	t.slot = s
+	t.prev = s.head.prev
+	t.next = &s.head
+	s.head.prev.next = t
+	s.head.prev = t
+}
+
+func (s *slot) unlink(t *Timer) {
+	t.prev.next = t.next
+	t.next.prev = t.prev
+	t.prev, t.next, t.slot = nil, nil, nil
+}
+
+// take removes all the timers in the slot, and returns the first one
+func (s *slot) take() *Timer {
+	if s.head.next == &s.head {
+		return nil
+	}
+	first := s.head.next
+	s.head.prev.next = nil
+	s.init()
+	return first
+}
+
+// wheel is a hierarchical timing wheel, all the timers in a wheel are driven by one goroutine
+type wheel struct {
+	mux     sync.Mutex
+	tick    time.Duration
+	current uint64
+	root    [rootSize]slot
+	levels  [levelCount][levelSize]slot
+	pending int64
+	stats   *wheelStats
+	start   time.Time
+	stop    chan struct{}
+}
+
+func newWheel(tick time.Duration, stats *wheelStats) *wheel {
+	w := &wheel{
+		tick:  tick,
+		stats: stats,
+		stop:  make(chan struct{}),
+	}
+	for i := range w.root {
+		w.root[i].init()
+	}
+	for l := range w.levels {
+		for i := range w.levels[l] {
+			w.levels[l][i].init()
+		}
+	}
+	return w
+}
+
+// run drives the wheel by the wall clock, the missing ticks are caught up
+func (w *wheel) run() {
+	w.start = time.Now()
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.advanceTo(uint64(now.Sub(w.start) / w.tick))
+		}
+	}
+}
+
+func (w *wheel) add(d time.Duration, callback func()) *Timer {
+	// the current tick is partially elapsed, so wait one more tick to make sure
+	// the timer is not fired earlier than the duration
+	ticks := uint64((d+w.tick-1)/w.tick) + 1
+	if ticks > maxTicks {
+		ticks = maxTicks
+	}
+	t := &Timer{
+		callback: callback,
+		wheel:    w,
+	}
+	w.mux.Lock()
+	t.expire = w.current + ticks
+	w.place(t)
+	w.mux.Unlock()
+	w.stats.pending.Update(atomic.AddInt64(&w.pending, 1))
+	return t
+}
+
+func (w *wheel) remove(t *Timer) bool {
+	w.mux.Lock()
+	if t.slot == nil {
+		w.mux.Unlock()
+		return false
+	}
+	t.slot.unlink(t)
+	w.mux.Unlock()
+	w.stats.pending.Update(atomic.AddInt64(&w.pending, -1))
+	w.stats.stopped.Inc(1)
+	return true
+}
+
+// place puts the timer into the slot by its expire ticks, must be called with lock
+func (w *wheel) place(t *Timer) {
+	delta := t.expire - w.current
+	if delta < rootSize {
+		w.root[t.expire&rootMask].push(t)
+		return
+	}
+	for l := 0; l < levelCount; l++ {
+		shift := uint(rootBits + l*levelBits)
+		if delta < 1<<(shift+levelBits) {
+			w.levels[l][(t.expire>>shift)&levelMask].push(t)
+			return
+		}
+	}
+}
+
+// cascade moves the timers in the level slot to the lower levels, returns the slot index
+func (w *wheel) cascade(l int) uint64 {
+	idx := (w.current >> uint(rootBits+l*levelBits)) & levelMask
+	for t := w.levels[l][idx].take(); t != nil; {
+		next := t.next
+		t.prev, t.next, t.slot = nil, nil, nil
+		w.place(t)
+		t = next
+	}
+	return idx
+}
+
+// advanceTo moves the wheel to the target ticks, and fires the expired timers
+func (w *wheel) advanceTo(target uint64) {
+	for {
+		w.mux.Lock()
+		if w.current >= target {
+			w.mux.Unlock()
+			return
+		}
+		w.current++
+		if w.current&rootMask == 0 {
+			for l := 0; l < levelCount; l++ {
+				if w.cascade(l) != 0 {
+					break
+				}
+			}
+		}
+		expired := w.root[w.current&rootMask].take()
+		// unlink the expired timers before firing, so they can not be stopped
+		var count int64
+		for t := expired; t != nil; t = t.next {
+			t.slot = nil
+			count++
+		}
+		w.mux.Unlock()
+		if count == 0 {
+			continue
+		}
+		w.stats.pending.Update(atomic.AddInt64(&w.pending, -count))
+		w.stats.expired.Inc(count)
+		for t := expired; t != nil; {
+			next := t.next
+			t.prev, t.next = nil, nil
+			w.fire(t.callback)
+			t = next
+		}
+	}
+}
+
+// fire runs the callback in a new goroutine, same as time.AfterFunc
+func (w *wheel) fire(callback func()) {
+	utils.GoWithRecover(callback, func(r interface{}) {
+		log.DefaultLogger.Errorf("[timer] [wheel] timer callback panic: %v", r)
+	})
+}
+
+func (w *wheel) close() {
+	close(w.stop)
+}
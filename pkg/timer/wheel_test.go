@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package timer
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testShard int32
+
+// newTestWheel makes a wheel that is not driven by the clock, each test wheel has its own stats
+func newTestWheel() *wheel {
+	shard := atomic.AddInt32(&testShard, -1)
+	return newWheel(time.Millisecond, newWheelStats(int(shard)))
+}
+
+func TestWheelFire(t *testing.T) {
+	w := newTestWheel()
+	ch := make(chan int, 10)
+	// cover the root and the cascaded levels
+	durations := []int{1, 255, 256, 300, 1 << 14, 1<<14 + 7, 1 << 20}
+	for i, d := range durations {
+		i := i
+		w.add(time.Duration(d)*time.Millisecond, func() {
+			ch <- i
+		})
+	}
+	if w.pending != int64(len(durations)) {
+		t.Fatalf("expected %d pending timers, but got %d", len(durations), w.pending)
+	}
+	for i, d := range durations {
+		// one more tick is waited for the partially elapsed tick
+		d++
+		w.advanceTo(uint64(d - 1))
+		select {
+		case got := <-ch:
+			t.Fatalf("timer %d fired before %d ticks", got, d)
+		case <-time.After(10 * time.Millisecond):
+		}
+		w.advanceTo(uint64(d))
+		select {
+		case got := <-ch:
+			if got != i {
+				t.Fatalf("expected timer %d fired, but got %d", i, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timer %d is not fired after %d ticks", i, d)
+		}
+	}
+	if w.pending != 0 || w.stats.expired.Count() != int64(len(durations)) {
+		t.Fatalf("unexpected stats, pending: %d, expired: %d", w.pending, w.stats.expired.Count())
+	}
+}
+
+func TestWheelStop(t *testing.T) {
+	w := newTestWheel()
+	var fired int32
+	timers := make([]*Timer, 0, 10)
+	for i := 0; i < 10; i++ {
+		timers = append(timers, w.add(time.Duration(i*100)*time.Millisecond, func() {
+			atomic.AddInt32(&fired, 1)
+		}))
+	}
+	for i := 0; i < 10; i += 2 {
+		if !timers[i].Stop() {
+			t.Fatalf("timer %d stop failed", i)
+		}
+		if timers[i].Stop() {
+			t.Fatalf("timer %d stopped twice", i)
+		}
+	}
+	w.advanceTo(1000)
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&fired) != 5 {
+		t.Fatalf("expected 5 timers fired, but got %d", fired)
+	}
+	// expired timer can not be stopped
+	if timers[1].Stop() {
+		t.Fatal("expired timer should not be stopped")
+	}
+	var nilTimer *Timer
+	if nilTimer.Stop() {
+		t.Fatal("nil timer should not be stopped")
+	}
+}
+
+func TestWheelBounded(t *testing.T) {
+	w := newTestWheel()
+	tm := w.add(time.Duration(maxTicks+100)*time.Millisecond, func() {})
+	if tm.expire != maxTicks {
+		t.Fatalf("timer should be bounded to %d ticks, but got %d", maxTicks, tm.expire)
+	}
+}
+
+func TestAfterFunc(t *testing.T) {
+	ch := make(chan struct{})
+	start := time.Now()
+	AfterFunc(50*time.Millisecond, func() {
+		close(ch)
+	})
+	select {
+	case <-ch:
+		if cost := time.Since(start); cost < 50*time.Millisecond {
+			t.Fatalf("timer fired too early: %v", cost)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timer is not fired")
+	}
+	if AfterFunc(time.Second, func() {}).Stop() == false {
+		t.Fatal("timer stop failed")
+	}
+}
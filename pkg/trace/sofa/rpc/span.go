@@ -78,6 +78,31 @@ func (s *SofaRPCSpan) SetRequestInfo(reqinfo types.RequestInfo) {
 		s.tags[DOWNSTEAM_HOST_ADDRESS] = reqinfo.DownstreamRemoteAddress().String()
 	}
 	s.tags[RESULT_STATUS] = strconv.Itoa(reqinfo.ResponseCode())
+	if timeline, ok := reqinfo.(types.RequestTimeline); ok {
+		s.tags[TIMELINE] = timelineString(timeline)
+	}
+	if recorder, ok := reqinfo.(types.UpstreamAttemptsRecorder); ok && len(recorder.UpstreamAttempts()) > 0 {
+		attempts := make([]string, 0, len(recorder.UpstreamAttempts()))
+		for _, attempt := range recorder.UpstreamAttempts() {
+			attempts = append(attempts, attempt.String())
+		}
+		s.tags[UPSTREAM_ATTEMPTS] = strings.Join(attempts, ",")
+	}
+}
+
+// timelineString formats the request timeline as key:duration pairs separated by comma,
+// the attempts durations are separated by '|'
+func timelineString(timeline types.RequestTimeline) string {
+	attempts := make([]string, 0, len(timeline.AttemptStartDurations()))
+	for _, d := range timeline.AttemptStartDurations() {
+		attempts = append(attempts, d.String())
+	}
+	return "route:" + timeline.RouteMatchedDuration().String() +
+		",connect:" + timeline.UpstreamConnectedDuration().String() +
+		",first_byte:" + timeline.FirstUpstreamByteDuration().String() +
+		",receive_filters:" + timeline.ReceiveFiltersDuration().String() +
+		",append_filters:" + timeline.AppendFiltersDuration().String() +
+		",attempts:" + strings.Join(attempts, "|")
 }
 
 func (s *SofaRPCSpan) Tag(key uint64) string {
@@ -155,6 +180,16 @@ func (s *SofaRPCSpan) log() error {
 	printData.WriteString("\"baggage\":")
 	printData.WriteString("\"" + s.tags[BAGGAGE_DATA] + "\",")
 
+	if s.tags[TIMELINE] != "" {
+		printData.WriteString("\"timeline\":")
+		printData.WriteString("\"" + s.tags[TIMELINE] + "\",")
+	}
+
+	if s.tags[UPSTREAM_ATTEMPTS] != "" {
+		printData.WriteString("\"upstream.attempts\":")
+		printData.WriteString("\"" + s.tags[UPSTREAM_ATTEMPTS] + "\",")
+	}
+
 	// Set status code. TODO can not get the result code if server throw an exception.
 
 	statusCode, _ := strconv.Atoi(s.tags[RESULT_STATUS])
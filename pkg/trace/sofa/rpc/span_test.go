@@ -21,6 +21,10 @@ import (
 	"log"
 	"runtime"
 	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
 )
 
 func TestSpanLog(t *testing.T) {
@@ -56,6 +60,24 @@ func TestEgressSpanLog(t *testing.T) {
 	span.log()
 }
 
+func TestSpanRequestTimeline(t *testing.T) {
+	info := network.NewRequestInfo()
+	timeline := info.(types.RequestTimeline)
+	timeline.SetRouteMatchedDuration(info.StartTime().Add(time.Millisecond))
+	timeline.AddAttemptStart(info.StartTime().Add(2 * time.Millisecond))
+	timeline.AddAttemptStart(info.StartTime().Add(5 * time.Millisecond))
+
+	span := &SofaRPCSpan{}
+	span.SetRequestInfo(info)
+	expected := "route:1ms,connect:0s,first_byte:0s,receive_filters:0s,append_filters:0s,attempts:2ms|5ms"
+	if span.Tag(TIMELINE) != expected {
+		t.Fatalf("unexpected timeline: %s", span.Tag(TIMELINE))
+	}
+	if span.Tag(UPSTREAM_ATTEMPTS) != "-|2ms|0s|-,-|5ms|0s|-" {
+		t.Fatalf("unexpected upstream attempts: %s", span.Tag(UPSTREAM_ATTEMPTS))
+	}
+}
+
 func BenchmarkSofaTracelog(b *testing.B) {
 	_, error := NewTracer(nil)
 	if error != nil {
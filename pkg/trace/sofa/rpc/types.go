@@ -48,6 +48,8 @@ const (
 	TARGET_CITY
 	ROUTE_RECORD
 	CALLER_CELL
+	TIMELINE
+	UPSTREAM_ATTEMPTS
 	//30-60 for other extends
 
 	TRACE_END = 60
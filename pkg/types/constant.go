@@ -35,6 +35,26 @@ const (
 	HeaderRPCMethod     = "x-mosn-rpc-method"
 )
 
+// Debug response header key types
+const (
+	HeaderUpstreamServiceTime = "x-mosn-upstream-service-time"
+	HeaderUpstreamHost        = "x-mosn-upstream-host"
+	HeaderRetryCount          = "x-mosn-retry-count"
+	HeaderRouteName           = "x-mosn-route-name"
+)
+
+// HeaderForwardedClientCert is the header contains the details of the downstream client certificates
+const HeaderForwardedClientCert = "x-forwarded-client-cert"
+
+// HeaderAttemptCount is the request header contains the upstream attempts of the request, including the retries
+const HeaderAttemptCount = "x-mosn-attempt-count"
+
+// HeaderHops is the default request header counts the proxies the request has passed
+const HeaderHops = "x-mosn-hops"
+
+// HeaderRetryAfter is the response header that indicates how long to wait before the next request
+const HeaderRetryAfter = "retry-after"
+
 // Error messages
 const (
 	ChannelFullException = "Channel is full"
@@ -66,5 +86,6 @@ const (
 	NoHealthUpstreamCode  = 502
 	UpstreamOverFlowCode  = 503
 	TimeoutExceptionCode  = 504
+	LoopDetectedCode      = 508
 	LimitExceededCode     = 509
 )
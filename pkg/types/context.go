@@ -41,6 +41,15 @@ const (
 	ContextKeyActiveSpan
 	ContextKeyTraceId
 	ContextKeyVariables
+	ContextKeyDataMasker
+	ContextKeyUpstreamOverrideHost
+	ContextKeyDownstreamConnection
+	ContextKeyRequestBlocklist
+	ContextKeyHTTP2Push
+	ContextKeyConnectionLog
+	ContextKeyConnectionBalance
+	ContextKeyDynamicForwardHost
+	ContextKeyBaggage
 	ContextKeyEnd
 )
 
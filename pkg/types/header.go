@@ -0,0 +1,46 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// The stream filter and codec APIs keep using the HeaderMap of mosn.io/api, which is not defined in this module.
+// The headers APIs below are optional interfaces of the HeaderMap, the filters and codecs access them by
+// protocol.HeaderValues, protocol.RangeHeaderValues and protocol.GetHeaderBytes, which fall back to the HeaderMap.
+
+// MultiValueHeaderMap is a HeaderMap keeps all the values of a key in order.
+// HeaderMap.Get returns the first value of a key, and HeaderMap.Range may visit the first value only,
+// so the multiple values are lost if the headers are accessed by the HeaderMap only.
+type MultiValueHeaderMap interface {
+	HeaderMap
+
+	// Values returns all the values of the key in order, the returned slice should not be modified
+	Values(key string) []string
+
+	// RangeValues calls f sequentially for each key and value present in the map,
+	// a key with multiple values is visited once for each value.
+	// If f returns false, range stops the iteration.
+	RangeValues(f func(key, value string) bool)
+}
+
+// BytesHeaderMap is a HeaderMap can be accessed by the byte slice keys without allocations
+type BytesHeaderMap interface {
+	HeaderMap
+
+	// GetBytes returns the first value of the key, the returned slice should not be modified
+	// and is valid until the headers are modified
+	GetBytes(key []byte) ([]byte, bool)
+}
@@ -54,6 +54,14 @@ type HealthCheckSession interface {
 	OnTimeout()
 }
 
+// DegradedHealthCheckSession is an optional interface of HealthCheckSession.
+// A session implements it can mark the server as degraded, for example, by a response header.
+type DegradedHealthCheckSession interface {
+	HealthCheckSession
+	// Degraded returns true if the latest successful CheckHealth finds the server is degraded
+	Degraded() bool
+}
+
 // HealthCheckSessionFactory creates a HealthCheckSession
 type HealthCheckSessionFactory interface {
 	NewSession(cfg map[string]interface{}, host Host) HealthCheckSession
@@ -31,6 +31,8 @@ type LoadBalancerType string
 const (
 	RoundRobin LoadBalancerType = "LB_ROUNDROBIN"
 	Random     LoadBalancerType = "LB_RANDOM"
+	// LocalityWeighted distributes requests across localities by the locality weights
+	LocalityWeighted LoadBalancerType = "LB_LOCALITY_WEIGHTED"
 )
 
 // LoadBalancer is a upstream load balancer.
@@ -40,5 +40,8 @@ const (
 	ErrorKeyUpstreamConn        = ErrorModuleMosn + ErrorSubModuleProxy + "upstream_conn_failed"
 	ErrorKeyCodec               = ErrorModuleMosn + ErrorSubModuleProxy + "codec_error"
 	ErrorKeyHeartBeat           = ErrorModuleMosn + ErrorSubModuleProxy + "heartbeat_unknown"
+	// the handshake failure keys are followed by the failure cause, such as mosn.io.tls_handshake_timeout
+	ErrorKeyTLSHandshake   = ErrorModuleMosn + ErrorSubModuleIO + "tls_handshake_"
+	ErrorKeyHTTP2Handshake = ErrorModuleMosn + ErrorSubModuleIO + "http2_handshake_"
 	// TODO: more keys
 )
@@ -19,6 +19,8 @@ package types
 
 import (
 	"errors"
+
+	"mosn.io/api"
 )
 
 var (
@@ -44,3 +46,19 @@ const (
 	UpRecvTrailer
 	End
 )
+
+// AsyncStreamReceiverFilterHandler is implemented by the receiver filter handlers that support
+// the filters to pause the stream without blocking the stream goroutine.
+// A filter calls PauseReceiving and returns api.StreamFilterStop, the stream is paused until the filter
+// calls ContinueReceiving, which runs the next filters, or sends the direct response set by the filter.
+// A paused stream does not handle the resets, the filter must call ContinueReceiving in a limited time.
+type AsyncStreamReceiverFilterHandler interface {
+	api.StreamReceiverFilterHandler
+
+	// PauseReceiving pauses the stream when the filter returns api.StreamFilterStop,
+	// it is ignored if the filter returns other status
+	PauseReceiving()
+
+	// ContinueReceiving resumes the paused stream, it can be called in any goroutine
+	ContinueReceiving()
+}
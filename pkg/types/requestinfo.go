@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package types
+
+import (
+	"strconv"
+	"time"
+)
+
+// RequestTimeline records the detailed timeline of a request besides the RequestInfo.
+// The durations are since the request's start time, a zero duration means the
+// request does not reach the point.
+type RequestTimeline interface {
+	// RouteMatchedDuration returns the duration to match the route
+	RouteMatchedDuration() time.Duration
+
+	// SetRouteMatchedDuration sets the time when the route is matched
+	SetRouteMatchedDuration(t time.Time)
+
+	// UpstreamConnectedDuration returns the duration to get a ready upstream stream from the connection pool
+	UpstreamConnectedDuration() time.Duration
+
+	// SetUpstreamConnectedDuration sets the time when the connection pool is ready
+	SetUpstreamConnectedDuration(t time.Time)
+
+	// FirstUpstreamByteDuration returns the duration to receive the first upstream response
+	FirstUpstreamByteDuration() time.Duration
+
+	// SetFirstUpstreamByteDuration sets the time when the upstream response is received,
+	// only the first call takes effect
+	SetFirstUpstreamByteDuration(t time.Time)
+
+	// ReceiveFiltersDuration returns the total duration spent in the stream receiver filters
+	ReceiveFiltersDuration() time.Duration
+
+	// AddReceiveFiltersDuration adds the duration spent in the stream receiver filters
+	AddReceiveFiltersDuration(d time.Duration)
+
+	// AppendFiltersDuration returns the total duration spent in the stream sender filters
+	AppendFiltersDuration() time.Duration
+
+	// AddAppendFiltersDuration adds the duration spent in the stream sender filters
+	AddAppendFiltersDuration(d time.Duration)
+
+	// AttemptStartDurations returns the start durations of the upstream attempts,
+	// the first one is the original request and the others are retries
+	AttemptStartDurations() []time.Duration
+
+	// AddAttemptStart records the time when an upstream attempt starts
+	AddAttemptStart(t time.Time)
+}
+
+// UpstreamAttempt is an upstream attempt of a request, the first attempt is the original request
+// and the others are retries
+type UpstreamAttempt struct {
+	// Host is the address of the upstream host, empty if no host is selected
+	Host string
+	// StartDuration is the duration since the request's start time when the attempt starts
+	StartDuration time.Duration
+	// Duration is the duration of the attempt, zero if the attempt is not finished
+	Duration time.Duration
+	// ResponseCode is the response code if the attempt receives a response
+	ResponseCode int
+	// ResetReason is the reason if the attempt is reset
+	ResetReason StreamResetReason
+}
+
+// String formats the attempt as host|start duration|duration|result, the result is the
+// response code or the reset reason, "-" means the value is unknown
+func (a UpstreamAttempt) String() string {
+	host := a.Host
+	if host == "" {
+		host = "-"
+	}
+	result := "-"
+	if a.ResetReason != "" {
+		result = string(a.ResetReason)
+	} else if a.ResponseCode != 0 {
+		result = strconv.Itoa(a.ResponseCode)
+	}
+	return host + "|" + a.StartDuration.String() + "|" + a.Duration.String() + "|" + result
+}
+
+// UpstreamAttemptsRecorder records each upstream attempt of a request.
+// An attempt is started by RequestTimeline.AddAttemptStart.
+type UpstreamAttemptsRecorder interface {
+	// UpstreamAttempts returns all the upstream attempts
+	UpstreamAttempts() []UpstreamAttempt
+
+	// SetAttemptHost sets the upstream host of the current attempt
+	SetAttemptHost(host string)
+
+	// FinishAttempt finishes the current attempt with the response code or the reset reason,
+	// only the first call takes effect for an attempt
+	FinishAttempt(t time.Time, responseCode int, resetReason StreamResetReason)
+}
@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"mosn.io/api"
+)
+
+// The response flags besides the api response flags, the api response flags use the bits below 0x2000.
+// The extensions can define their own flags above ResponseFlagExtensionStart and register the names.
+const (
+	// DownstreamConnectionTermination means the downstream terminates the connection before the response is sent
+	DownstreamConnectionTermination api.ResponseFlag = 0x2000
+	// UnauthorizedExternalService means the request is rejected by the authentication or the authorization
+	UnauthorizedExternalService api.ResponseFlag = 0x4000
+	// UpstreamRetryLimitExceeded means the response should be retried, but the retries are exhausted
+	UpstreamRetryLimitExceeded api.ResponseFlag = 0x8000
+	// RequestRejected means the request is rejected by the request blocklist or the firewall
+	RequestRejected api.ResponseFlag = 0x10000
+	// LoopDetected means the request is rejected as it has passed too many proxies
+	LoopDetected api.ResponseFlag = 0x20000
+	// RouteConcurrencyLimited means the request is rejected by the concurrency limit of the route
+	RouteConcurrencyLimited api.ResponseFlag = 0x40000
+	// OverloadShed means the request is shed by its priority as the upstream or the local resources are saturated
+	OverloadShed api.ResponseFlag = 0x80000
+	// UpstreamFailover means the request is routed to the failover cluster of the route
+	UpstreamFailover api.ResponseFlag = 0x100000
+
+	// ResponseFlagExtensionStart is the lowest bit of the response flags defined by the extensions
+	ResponseFlagExtensionStart api.ResponseFlag = 0x1000000
+)
+
+// NoResponseFlag is the name used if no response flag is set
+const NoResponseFlag = "-"
+
+var (
+	responseFlagMutex sync.RWMutex
+	responseFlagNames = map[api.ResponseFlag]string{
+		api.NoHealthyUpstream:             "UH",
+		api.UpstreamRequestTimeout:        "UT",
+		api.UpstreamLocalReset:            "LR",
+		api.UpstreamRemoteReset:           "UR",
+		api.UpstreamConnectionFailure:     "UF",
+		api.UpstreamConnectionTermination: "UC",
+		api.UpstreamOverflow:              "UO",
+		api.NoRouteFound:                  "NR",
+		api.DelayInjected:                 "DI",
+		api.FaultInjected:                 "FI",
+		api.RateLimited:                   "RL",
+		api.ReqEntityTooLarge:             "PL",
+		DownstreamConnectionTermination:   "DC",
+		UnauthorizedExternalService:       "UAEX",
+		UpstreamRetryLimitExceeded:        "URX",
+		RequestRejected:                   "RJ",
+		LoopDetected:                      "LD",
+		RouteConcurrencyLimited:           "CL",
+		OverloadShed:                      "LS",
+		UpstreamFailover:                  "FO",
+	}
+	// the flags in the bit order, which is the order of the names
+	responseFlags = sortResponseFlags(responseFlagNames)
+)
+
+// RegisterResponseFlag registers the name of a response flag defined by an extension,
+// the name is used in the access logs and the stats
+func RegisterResponseFlag(flag api.ResponseFlag, name string) error {
+	if flag < ResponseFlagExtensionStart || flag&(flag-1) != 0 {
+		return fmt.Errorf("response flag %#x is not a single bit from %#x", int(flag), int(ResponseFlagExtensionStart))
+	}
+	if name == "" || name == NoResponseFlag {
+		return fmt.Errorf("invalid response flag name %q", name)
+	}
+	responseFlagMutex.Lock()
+	defer responseFlagMutex.Unlock()
+	for f, n := range responseFlagNames {
+		if n == name && f != flag {
+			return fmt.Errorf("response flag name %s is registered by %#x", name, int(f))
+		}
+	}
+	responseFlagNames[flag] = name
+	responseFlags = sortResponseFlags(responseFlagNames)
+	return nil
+}
+
+func sortResponseFlags(names map[api.ResponseFlag]string) []api.ResponseFlag {
+	flags := make([]api.ResponseFlag, 0, len(names))
+	for f := range names {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i] < flags[j]
+	})
+	return flags
+}
+
+// ResponseFlagName returns the name of a response flag, it is empty if the flag is not registered
+func ResponseFlagName(flag api.ResponseFlag) string {
+	responseFlagMutex.RLock()
+	defer responseFlagMutex.RUnlock()
+	return responseFlagNames[flag]
+}
+
+// ResponseFlagNames returns the names of the response flags set in the request info, in the bit order
+func ResponseFlagNames(info api.RequestInfo) []string {
+	responseFlagMutex.RLock()
+	defer responseFlagMutex.RUnlock()
+	var names []string
+	for _, f := range responseFlags {
+		if info.GetResponseFlag(f) {
+			names = append(names, responseFlagNames[f])
+		}
+	}
+	return names
+}
+
+// FormatResponseFlags returns the names of the response flags set in the request info joined by comma,
+// NoResponseFlag is returned if no flag is set
+func FormatResponseFlags(info api.RequestInfo) string {
+	names := ResponseFlagNames(info)
+	if len(names) == 0 {
+		return NoResponseFlag
+	}
+	return strings.Join(names, ",")
+}
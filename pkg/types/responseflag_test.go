@@ -0,0 +1,79 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"testing"
+
+	"mosn.io/api"
+)
+
+type mockFlagRequestInfo struct {
+	api.RequestInfo
+	flag api.ResponseFlag
+}
+
+func (r *mockFlagRequestInfo) GetResponseFlag(flag api.ResponseFlag) bool {
+	return r.flag&flag != 0
+}
+
+func TestFormatResponseFlags(t *testing.T) {
+	for _, tc := range []struct {
+		flag     api.ResponseFlag
+		expected string
+	}{
+		{0, NoResponseFlag},
+		{api.NoHealthyUpstream, "UH"},
+		{DownstreamConnectionTermination | api.UpstreamRequestTimeout, "UT,DC"},
+		{UpstreamRetryLimitExceeded | api.UpstreamRemoteReset, "UR,URX"},
+	} {
+		if s := FormatResponseFlags(&mockFlagRequestInfo{flag: tc.flag}); s != tc.expected {
+			t.Errorf("flag %#x expected %s, got %s", int(tc.flag), tc.expected, s)
+		}
+	}
+}
+
+func TestRegisterResponseFlag(t *testing.T) {
+	flag := ResponseFlagExtensionStart << 1
+	if err := RegisterResponseFlag(flag, "TEST"); err != nil {
+		t.Fatal(err)
+	}
+	if ResponseFlagName(flag) != "TEST" {
+		t.Fatalf("unexpected name: %s", ResponseFlagName(flag))
+	}
+	if s := FormatResponseFlags(&mockFlagRequestInfo{flag: flag | api.NoRouteFound}); s != "NR,TEST" {
+		t.Fatalf("unexpected flags: %s", s)
+	}
+	for _, tc := range []struct {
+		flag api.ResponseFlag
+		name string
+	}{
+		// the flags below the extension start are reserved
+		{0x80000, "RESERVED"},
+		// not a single bit
+		{ResponseFlagExtensionStart | flag, "MULTI"},
+		{ResponseFlagExtensionStart, ""},
+		{ResponseFlagExtensionStart, NoResponseFlag},
+		// the name is registered by another flag
+		{ResponseFlagExtensionStart, "UH"},
+	} {
+		if err := RegisterResponseFlag(tc.flag, tc.name); err == nil {
+			t.Errorf("register flag %#x with name %s should be failed", int(tc.flag), tc.name)
+		}
+	}
+}
@@ -106,6 +106,101 @@ type VirtualHost interface {
 	RemoveAllRoutes()
 }
 
+// NamedRouteRule is a route rule that has a name configured.
+// the route name is used in per-route stats
+type NamedRouteRule interface {
+	// RouteName returns the route's name, empty string means no name is configured
+	RouteName() string
+}
+
+// ConcurrencyLimiter limits the in-flight requests of a route
+type ConcurrencyLimiter interface {
+	// Acquire gets an in-flight slot without blocking, returns true if the slot is got.
+	// If no slot is free, the request is queued and a cancel func is returned, notify is called
+	// once in another goroutine when the slot is got or the wait is timeout.
+	// A nil cancel func means the queue is full.
+	Acquire(notify func(acquired bool)) (acquired bool, cancel func())
+	// Release releases the slot got by Acquire
+	Release()
+}
+
+// ConcurrencyLimitedRouteRule is a route rule that may have a concurrency limit configured
+type ConcurrencyLimitedRouteRule interface {
+	// ConcurrencyLimiter returns nil if no concurrency limit is configured
+	ConcurrencyLimiter() ConcurrencyLimiter
+}
+
+// ContentTypeDirectResponseRule is a direct response rule that has a content type
+type ContentTypeDirectResponseRule interface {
+	// ContentType returns the content type of the body, empty string means not set
+	ContentType() string
+}
+
+// StaticFiles serves the files in a directory
+type StaticFiles interface {
+	// Serve returns the status code, the content type and the content of the file
+	// requested by the method and the path
+	Serve(method, path string) (int, string, []byte)
+}
+
+// StaticFilesRouteRule is a route rule that may serve the static files
+type StaticFilesRouteRule interface {
+	// StaticFiles returns nil if the route does not serve the static files
+	StaticFiles() StaticFiles
+}
+
+// RetryAfterPolicy is a retry policy that limits the Retry-After the retry waits for
+type RetryAfterPolicy interface {
+	// RetryAfterMaxInterval returns the max Retry-After, zero means the default
+	RetryAfterMaxInterval() time.Duration
+}
+
+// InternalRedirectPolicy decides whether the redirect responses of the upstream are followed
+type InternalRedirectPolicy interface {
+	// MaxInternalRedirects returns the max internal redirects of a request
+	MaxInternalRedirects() uint32
+	// ShouldRedirect returns true if the response with the status code and the location should be followed
+	ShouldRedirect(code int, location string) bool
+	// AllowCrossSchemeRedirect returns true if the location can be a scheme different from the downstream request
+	AllowCrossSchemeRedirect() bool
+}
+
+// InternalRedirectRouteRule is a route rule that may have an internal redirect policy configured
+type InternalRedirectRouteRule interface {
+	// InternalRedirectPolicy returns nil if no internal redirect policy is configured
+	InternalRedirectPolicy() InternalRedirectPolicy
+}
+
+// FailoverPolicy decides whether a request is routed to the failover cluster
+type FailoverPolicy interface {
+	// ClusterName returns the failover cluster name
+	ClusterName() string
+	// ShouldFailover returns true if the upstream response with the status code should fail over,
+	// the status code is zero if the upstream request is reset
+	ShouldFailover(code int) bool
+}
+
+// FailoverRouteRule is a route rule that may have a failover cluster configured
+type FailoverRouteRule interface {
+	// FailoverPolicy returns nil if no failover policy is configured
+	FailoverPolicy() FailoverPolicy
+}
+
+// StreamFilterOverridesRouteRule is a route rule that may override the listener stream filters
+type StreamFilterOverridesRouteRule interface {
+	// StreamFilterOverrides returns the factories keyed by the overridden filter types,
+	// a nil factory means the filter is disabled on the route
+	StreamFilterOverrides() map[string]api.StreamFilterChainFactory
+}
+
+// ContextRouteRule is a route rule finalizes the headers with the stream context,
+// so the variables of the stream can be used in the headers to add
+type ContextRouteRule interface {
+	FinalizeRequestHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo)
+
+	FinalizeResponseHeadersWithContext(ctx context.Context, headers api.HeaderMap, requestInfo api.RequestInfo)
+}
+
 type HeaderFormat interface {
 	Format(info api.RequestInfo) string
 	Append() bool
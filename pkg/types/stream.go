@@ -19,6 +19,7 @@ package types
 
 import (
 	"context"
+	"time"
 
 	"mosn.io/api"
 	"mosn.io/pkg/buffer"
@@ -249,6 +250,39 @@ type ConnectionPool interface {
 	Close()
 }
 
+// WarmupConnectionPool is an optional interface of ConnectionPool,
+// Warmup creates a connection in advance and returns whether the pool has an available connection
+type WarmupConnectionPool interface {
+	Warmup(ctx context.Context) bool
+}
+
+// ConnectionPoolState is a snapshot of the connections and the streams of a connection pool
+type ConnectionPoolState struct {
+	// Connections is the number of the connections, including the connecting ones
+	Connections int `json:"connections"`
+	// ActiveConnections is the number of the connections that have active streams
+	ActiveConnections int `json:"active_connections"`
+	// IdleConnections is the number of the connected connections without active streams
+	IdleConnections int `json:"idle_connections"`
+	ActiveStreams   int `json:"active_streams"`
+	// ConnectFailures is the number of the failed and timeout connects since the pool is created
+	ConnectFailures uint64 `json:"connect_failures"`
+	Draining        bool   `json:"draining"`
+}
+
+// StateConnectionPool is an optional interface of ConnectionPool, State returns the current state of the pool
+type StateConnectionPool interface {
+	State() ConnectionPoolState
+}
+
+// DrainConnectionPool is an optional interface of ConnectionPool, Drain stops assigning new streams,
+// closes the idle connections and closes the busy connections after their streams finished.
+// The connections are closed anyway after the timeout. The returned channel receives once all
+// of the connections are closed, the value is true if they are closed by the timeout.
+type DrainConnectionPool interface {
+	Drain(timeout time.Duration) <-chan bool
+}
+
 type PoolEventListener interface {
 	OnFailure(reason PoolFailureReason, host Host)
 
@@ -58,6 +58,8 @@ type SdsSecret struct {
 	CertificatePEM string
 	PrivateKeyPEM  string
 	ValidationPEM  string
+	// SessionTicketKeys is the key material of the tls session ticket keys
+	SessionTicketKeys [][]byte
 }
 
 type SdsUpdateCallbackFunc func(name string, secret *SdsSecret)
@@ -86,5 +88,12 @@ func SecretConvert(raw *auth.Secret) *SdsSecret {
 		secret.CertificatePEM = string(certSpec.InlineBytes)
 		secret.PrivateKeyPEM = string(priKey.InlineBytes)
 	}
+	if ticketKeys, ok := raw.Type.(*auth.Secret_SessionTicketKeys); ok {
+		for _, key := range ticketKeys.SessionTicketKeys.Keys {
+			if ds, ok := key.Specifier.(*core.DataSource_InlineBytes); ok {
+				secret.SessionTicketKeys = append(secret.SessionTicketKeys, ds.InlineBytes)
+			}
+		}
+	}
 	return secret
 }
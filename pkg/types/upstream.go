@@ -41,8 +41,9 @@ type ClusterManager interface {
 	// Add Cluster health check callbacks
 	AddClusterHealthCheckCallbacks(name string, cb HealthCheckCb) error
 
-	// Get, use to get the snapshot of a cluster
-	GetClusterSnapshot(context context.Context, cluster string) ClusterSnapshot
+	// GetClusterSnapshot returns the current snapshot of a cluster, ok is false if the cluster is not found.
+	// A snapshot is immutable, the cluster stores a new one on updates, so it can be used without locks.
+	GetClusterSnapshot(context context.Context, cluster string) (snapshot ClusterSnapshot, ok bool)
 
 	// Deprecated: PutClusterSnapshot exists for historical compatibility and should not be used.
 	PutClusterSnapshot(ClusterSnapshot)
@@ -123,6 +124,11 @@ const (
 	FAILED_ACTIVE_HC HealthFlag = 0x1
 	// The host is currently considered an outlier and has been ejected.
 	FAILED_OUTLIER_CHECK HealthFlag = 0x02
+	// The host is currently marked as degraded by active health checks.
+	// A degraded host is used only if the healthy hosts are not enough.
+	DEGRADED_ACTIVE_HC HealthFlag = 0x04
+	// The host is warming, it has not passed the first active health check yet.
+	PENDING_ACTIVE_HC HealthFlag = 0x08
 )
 
 // Host is an upstream host
@@ -187,6 +193,12 @@ type ClusterInfo interface {
 
 	// ConectTimeout returns the connect timeout
 	ConnectTimeout() time.Duration
+
+	// UpstreamProtocol returns the protocol the cluster speaks, empty means the proxy's upstream protocol is used
+	UpstreamProtocol() Protocol
+
+	// ConnPoolConfig returns the connection pool config of the hosts
+	ConnPoolConfig() v2.ConnPoolConfig
 }
 
 // ResourceManager manages different types of Resource
@@ -265,6 +277,10 @@ type ClusterStats struct {
 	UpstreamResponseFailed                         metrics.Counter
 	LBSubSetsFallBack                              metrics.Counter
 	LBSubsetsCreated                               metrics.Gauge
+	UpstreamRequestDegraded                        metrics.Counter
+	UpstreamHostBackoff                            metrics.Counter
+	UpstreamRequestFailover                        metrics.Counter
+	UpstreamRequestFailoverReceived                metrics.Counter
 }
 
 type CreateConnectionData struct {
@@ -272,6 +288,18 @@ type CreateConnectionData struct {
 	Host       Host
 }
 
+// SLOClusterInfo is a cluster info that may track the slo of the upstream responses
+type SLOClusterInfo interface {
+	// SLOTracker returns nil if no slo is configured
+	SLOTracker() SLOTracker
+}
+
+// SLOTracker tracks the upstream responses of a cluster over the sliding windows
+type SLOTracker interface {
+	// Record records an upstream response code, zero means the request gets no response
+	Record(code int)
+}
+
 // SimpleCluster is a simple cluster in memory
 type SimpleCluster interface {
 	UpdateHosts(newHosts []Host)
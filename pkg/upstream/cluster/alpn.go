@@ -0,0 +1,179 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/mtls"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// the ALPN protocol ids offered by the HTTP auto protocol
+const (
+	http2ALPN = "h2"
+	http1ALPN = "http/1.1"
+)
+
+// negotiatedConnTimeout is the duration that the connection of the ALPN probe waits for the pool to use it
+var negotiatedConnTimeout = 10 * time.Second
+
+var (
+	// alpnProtocols caches the HTTP protocol negotiated with the upstream hosts,
+	// the key is the host address.
+	alpnProtocols sync.Map
+
+	// alpnProbes deduplicates the concurrent probes of a host address
+	alpnProbesMux sync.Mutex
+	alpnProbes    = make(map[string]*alpnProbe)
+)
+
+// alpnProbe is a probe in flight, the waiters get the result after done is closed
+type alpnProbe struct {
+	done chan struct{}
+	prot types.Protocol
+	err  error
+}
+
+// autoHTTPProtocol resolves the protocol.HTTPAuto for the host.
+// HTTP/2 is chosen if the upstream TLS handshake negotiates h2, otherwise HTTP/1.1 is chosen.
+// The first request to a TLS host probes the protocol by a handshake offering h2 and http/1.1, and the result is cached.
+// The concurrent requests to the host wait for the same probe. The connection of the probe is returned
+// to the request that made the probe, it should be used by the connection pool of the protocol or closed.
+func autoHTTPProtocol(host types.Host) (types.Protocol, net.Conn) {
+	if !host.SupportTLS() {
+		return protocol.HTTP1, nil
+	}
+	addr := host.AddressString()
+	if prot, ok := alpnProtocols.Load(addr); ok {
+		return prot.(types.Protocol), nil
+	}
+	alpnProbesMux.Lock()
+	if probe, ok := alpnProbes[addr]; ok {
+		alpnProbesMux.Unlock()
+		<-probe.done
+		if probe.err != nil {
+			return protocol.HTTP1, nil
+		}
+		return probe.prot, nil
+	}
+	probe := &alpnProbe{done: make(chan struct{})}
+	alpnProbes[addr] = probe
+	alpnProbesMux.Unlock()
+
+	negotiated, conn, err := probeALPN(host)
+	probe.prot, probe.err = protocol.HTTP1, err
+	if negotiated == http2ALPN {
+		probe.prot = protocol.HTTP2
+	}
+	if err != nil {
+		// not cached, probes again for the next request
+		log.DefaultLogger.Warnf("[upstream] [alpn] probe host %s failed, use http1: %v", addr, err)
+	} else {
+		alpnProtocols.Store(addr, probe.prot)
+		if log.DefaultLogger.GetLogLevel() >= log.INFO {
+			log.DefaultLogger.Infof("[upstream] [alpn] host %s negotiated protocol %q, use %s", addr, negotiated, probe.prot)
+		}
+	}
+	alpnProbesMux.Lock()
+	delete(alpnProbes, addr)
+	alpnProbesMux.Unlock()
+	close(probe.done)
+	return probe.prot, conn
+}
+
+// probeALPN makes a TLS handshake with the host and returns the negotiated protocol and the connection
+func probeALPN(host types.Host) (string, net.Conn, error) {
+	timeout := host.ClusterInfo().ConnectTimeout()
+	if timeout == 0 {
+		timeout = network.DefaultConnectTimeout
+	}
+	raw, err := net.DialTimeout("tcp", host.AddressString(), timeout)
+	if err != nil {
+		return "", nil, err
+	}
+	c, err := host.ClusterInfo().TLSMng().Conn(raw)
+	if err != nil {
+		raw.Close()
+		return "", nil, err
+	}
+	tlsConn, ok := c.(*mtls.TLSConn)
+	if !ok {
+		raw.Close()
+		return "", nil, nil
+	}
+	// the configured ALPN is kept, h2 and http/1.1 are always offered
+	tlsConn.SetALPN(http2ALPN)
+	tlsConn.SetALPN(http1ALPN)
+	tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		tlsConn.Close()
+		return "", nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn.ConnectionState().NegotiatedProtocol, tlsConn, nil
+}
+
+// negotiatedHost creates the first connection on the connection of the ALPN probe
+type negotiatedHost struct {
+	*simpleHost
+	mux  sync.Mutex
+	conn net.Conn
+}
+
+// newNegotiatedHost returns the host that the pool of the negotiated protocol is created with,
+// the connection is closed if it can not be used.
+func newNegotiatedHost(host types.Host, conn net.Conn) types.Host {
+	if conn == nil {
+		return host
+	}
+	sh, ok := host.(*simpleHost)
+	// the established connection has no file descriptor for netpoll
+	if !ok || network.UseNetpollMode {
+		conn.Close()
+		return host
+	}
+	h := &negotiatedHost{
+		simpleHost: sh,
+		conn:       conn,
+	}
+	time.AfterFunc(negotiatedConnTimeout, func() {
+		if conn := h.takeConn(); conn != nil {
+			conn.Close()
+		}
+	})
+	return h
+}
+
+func (h *negotiatedHost) takeConn() net.Conn {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	conn := h.conn
+	h.conn = nil
+	return conn
+}
+
+func (h *negotiatedHost) CreateConnection(context context.Context) types.CreateConnectionData {
+	return h.simpleHost.createConnection(h.takeConn())
+}
@@ -0,0 +1,204 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/mtls/certtool"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+// startALPNServer starts a tls server that supports the protos, returns the listener and the accepted count
+func startALPNServer(t *testing.T, protos []string) (net.Listener, *int32) {
+	priv, err := certtool.GeneratePrivateKey("P256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := certtool.CreateTemplate("127.0.0.1", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := certtool.SignCertificate(tmpl, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair([]byte(info.CertPem), []byte(info.KeyPem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   protos,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted := new(int32)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(accepted, 1)
+			c.(*tls.Conn).Handshake()
+			c.Close()
+		}
+	}()
+	return ln, accepted
+}
+
+func newALPNHost(addr string, tlsDisable bool) types.Host {
+	cluster := newSimpleCluster(v2.Cluster{
+		Name: "alpn",
+		TLS: v2.TLSConfig{
+			Status:       true,
+			InsecureSkip: true,
+		},
+	})
+	return NewSimpleHost(v2.Host{
+		HostConfig: v2.HostConfig{
+			Address:    addr,
+			TLSDisable: tlsDisable,
+		},
+	}, cluster.Snapshot().ClusterInfo())
+}
+
+func TestAutoHTTPProtocol(t *testing.T) {
+	h2, _ := startALPNServer(t, []string{"h2", "http/1.1"})
+	defer h2.Close()
+	h1, _ := startALPNServer(t, []string{"http/1.1"})
+	defer h1.Close()
+
+	testCases := []struct {
+		host     types.Host
+		expected types.Protocol
+		probed   bool
+	}{
+		// h2 and http/1.1 are offered without the ALPN configured
+		{newALPNHost(h2.Addr().String(), false), protocol.HTTP2, true},
+		{newALPNHost(h1.Addr().String(), false), protocol.HTTP1, true},
+		// no tls, no alpn
+		{newALPNHost(h2.Addr().String(), true), protocol.HTTP1, false},
+	}
+	for i, tc := range testCases {
+		prot, conn := autoHTTPProtocol(tc.host)
+		if prot != tc.expected {
+			t.Errorf("case %d: expected %s, got %s", i, tc.expected, prot)
+		}
+		if (conn != nil) != tc.probed {
+			t.Errorf("case %d: expected the probe connection returned: %v", i, tc.probed)
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	// the negotiated protocol is cached
+	h2.Close()
+	if prot, conn := autoHTTPProtocol(testCases[0].host); prot != protocol.HTTP2 || conn != nil {
+		t.Errorf("expected cached protocol without probe, got %s", prot)
+	}
+	alpnProtocols.Delete(h2.Addr().String())
+	// probe failed, use http1 without cache
+	if prot, _ := autoHTTPProtocol(testCases[0].host); prot != protocol.HTTP1 {
+		t.Errorf("expected http1 if probe failed, got %s", prot)
+	}
+	if _, ok := alpnProtocols.Load(h2.Addr().String()); ok {
+		t.Error("failed probe should not be cached")
+	}
+}
+
+func TestAutoHTTPProtocolSingleProbe(t *testing.T) {
+	ln, accepted := startALPNServer(t, []string{"h2", "http/1.1"})
+	defer ln.Close()
+	host := newALPNHost(ln.Addr().String(), false)
+
+	var wg sync.WaitGroup
+	var conns int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prot, conn := autoHTTPProtocol(host)
+			if prot != protocol.HTTP2 {
+				t.Errorf("expected http2, got %s", prot)
+			}
+			if conn != nil {
+				atomic.AddInt32(&conns, 1)
+				conn.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(accepted); n != 1 {
+		t.Errorf("expected 1 probe, got %d", n)
+	}
+	if conns != 1 {
+		t.Errorf("expected the probe connection returned once, got %d", conns)
+	}
+}
+
+func TestNegotiatedHostConnection(t *testing.T) {
+	ln, accepted := startALPNServer(t, []string{"h2", "http/1.1"})
+	defer ln.Close()
+	host := newALPNHost(ln.Addr().String(), false)
+
+	_, conn, err := probeALPN(host)
+	if err != nil || conn == nil {
+		t.Fatalf("probe should return the connection, error: %v", err)
+	}
+	negotiated := newNegotiatedHost(host, conn)
+	// the first connection is the probe connection
+	data := negotiated.CreateConnection(context.Background())
+	if err := data.Connection.Connect(); err != nil {
+		t.Fatalf("connect on the probe connection failed: %v", err)
+	}
+	data.Connection.Close(api.NoFlush, api.LocalClose)
+	if n := atomic.LoadInt32(accepted); n != 1 {
+		t.Fatalf("expected no new connection dialed, accepted %d", n)
+	}
+	// the next connection dials a new one
+	data = negotiated.CreateConnection(context.Background())
+	if err := data.Connection.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	data.Connection.Close(api.NoFlush, api.LocalClose)
+	if !waitAccepted(accepted, 2) {
+		t.Fatalf("expected a new connection dialed, accepted %d", atomic.LoadInt32(accepted))
+	}
+}
+
+func waitAccepted(accepted *int32, expected int32) bool {
+	for i := 0; i < 100; i++ {
+		if atomic.LoadInt32(accepted) == expected {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
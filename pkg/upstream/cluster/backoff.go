@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// maxHostBackoff limits the backoff of a host, the hosts are only backed off shortly
+const maxHostBackoff = 30 * time.Second
+
+var (
+	// hostBackoffs keeps the time until which the hosts are backed off,
+	// the key is the cluster name and the host address, so the backoff survives the host updates
+	hostBackoffs sync.Map
+	// backoffHosts is the count of the hosts in hostBackoffs, the lookup is skipped if it is zero
+	backoffHosts int64
+)
+
+func hostBackoffKey(host types.Host) string {
+	return host.ClusterInfo().Name() + "|" + host.AddressString()
+}
+
+// BackoffHost makes the load balancer avoid the host for the duration, such as the Retry-After of
+// an overloaded host. The duration is limited to 30s, the host is still chosen if no other host is available.
+func BackoffHost(host types.Host, d time.Duration) {
+	if host == nil || d <= 0 {
+		return
+	}
+	if d > maxHostBackoff {
+		d = maxHostBackoff
+	}
+	until := time.Now().Add(d).UnixNano()
+	if _, loaded := hostBackoffs.LoadOrStore(hostBackoffKey(host), until); loaded {
+		hostBackoffs.Store(hostBackoffKey(host), until)
+	} else {
+		atomic.AddInt64(&backoffHosts, 1)
+	}
+	host.ClusterInfo().Stats().UpstreamHostBackoff.Inc(1)
+}
+
+// inBackoff returns true if the host is backed off, the expired backoff is removed
+func inBackoff(host types.Host) bool {
+	if atomic.LoadInt64(&backoffHosts) == 0 {
+		return false
+	}
+	key := hostBackoffKey(host)
+	v, ok := hostBackoffs.Load(key)
+	if !ok {
+		return false
+	}
+	if time.Now().UnixNano() < v.(int64) {
+		return true
+	}
+	hostBackoffs.Delete(key)
+	atomic.AddInt64(&backoffHosts, -1)
+	return false
+}
+
+// chooseHostAvoidBackoff chooses another host if the host chosen by the load balancer is backed off
+func chooseHostAvoidBackoff(snapshot types.ClusterSnapshot, lbCtx types.LoadBalancerContext) types.Host {
+	lb := snapshot.LoadBalancer()
+	host := lb.ChooseHost(lbCtx)
+	for i := 0; i < cycleTimes && host != nil && inBackoff(host); i++ {
+		next := lb.ChooseHost(lbCtx)
+		if next == nil {
+			break
+		}
+		host = next
+	}
+	return host
+}
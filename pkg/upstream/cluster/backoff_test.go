@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func newBackoffTestHosts(cluster types.Cluster, n int) []types.Host {
+	var hosts []types.Host
+	for i := 0; i < n; i++ {
+		hosts = append(hosts, NewSimpleHost(v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: fmt.Sprintf("127.0.0.1:%d", 10000+i),
+			},
+		}, cluster.Snapshot().ClusterInfo()))
+	}
+	return hosts
+}
+
+func TestBackoffHost(t *testing.T) {
+	cluster := NewCluster(v2.Cluster{
+		Name:   "test_backoff_host",
+		LbType: v2.LB_ROUNDROBIN,
+	})
+	cluster.UpdateHosts(newBackoffTestHosts(cluster, 2))
+	snapshot := cluster.Snapshot()
+	backoff := snapshot.HostSet().Hosts()[0]
+
+	BackoffHost(backoff, 100*time.Millisecond)
+	if !inBackoff(backoff) {
+		t.Fatal("expected host in backoff")
+	}
+	if cnt := snapshot.ClusterInfo().Stats().UpstreamHostBackoff.Count(); cnt != 1 {
+		t.Fatalf("expected backoff stats 1, but got %d", cnt)
+	}
+	lbCtx := &mockLbContext{ctx: context.Background()}
+	for i := 0; i < 4; i++ {
+		if host := chooseHost(snapshot, lbCtx); host == nil || host.AddressString() == backoff.AddressString() {
+			t.Fatal("expected host not in backoff")
+		}
+	}
+	time.Sleep(150 * time.Millisecond)
+	if inBackoff(backoff) {
+		t.Fatal("expected backoff expired")
+	}
+	chosen := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		chosen[chooseHost(snapshot, lbCtx).AddressString()] = true
+	}
+	if !chosen[backoff.AddressString()] {
+		t.Fatal("expected host chosen after backoff expired")
+	}
+}
+
+func TestBackoffAllHosts(t *testing.T) {
+	cluster := NewCluster(v2.Cluster{
+		Name:   "test_backoff_all_hosts",
+		LbType: v2.LB_ROUNDROBIN,
+	})
+	cluster.UpdateHosts(newBackoffTestHosts(cluster, 1))
+	snapshot := cluster.Snapshot()
+	host := snapshot.HostSet().Hosts()[0]
+	// the max backoff is limited
+	BackoffHost(host, time.Hour)
+	defer func() {
+		hostBackoffs.Delete(hostBackoffKey(host))
+		atomic.AddInt64(&backoffHosts, -1)
+	}()
+	v, _ := hostBackoffs.Load(hostBackoffKey(host))
+	if until := time.Unix(0, v.(int64)); time.Until(until) > maxHostBackoff {
+		t.Fatalf("expected backoff limited, but until %v", until)
+	}
+	// the backed off host is still chosen if no other host is available
+	if chosen := chooseHost(snapshot, &mockLbContext{ctx: context.Background()}); chosen == nil {
+		t.Fatal("expected host chosen")
+	}
+}
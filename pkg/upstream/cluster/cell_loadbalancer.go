@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"hash/fnv"
+	"sort"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+// cell is a group of hosts in the same locality
+type cell struct {
+	name  string
+	hosts types.HostSet
+	lb    types.LoadBalancer
+	stats types.Metrics
+}
+
+func (c *cell) available() bool {
+	return c != nil && len(c.hosts.HealthyHosts()) > 0
+}
+
+// cellLoadBalancer routes a request to the hosts in the cell of the request,
+// the hosts in a cell are chosen by the load balancer of the cluster lb type.
+// The stats are counted for the cells of the hosts only, so the unknown cells in the requests
+// make no stats.
+type cellLoadBalancer struct {
+	config *v2.CellRouting
+	cells  map[string]*cell
+	// hashCells are the cells that the hash header is hashed to
+	hashCells []string
+	// fallbacks are the cells in the cross cell fallback order, the local cell is the first
+	fallbacks []*cell
+	all       types.LoadBalancer
+}
+
+func newCellLoadBalancer(info *clusterInfo, hosts *hostSet) types.LoadBalancer {
+	lb := &cellLoadBalancer{
+		config: info.cellRouting,
+		cells:  make(map[string]*cell),
+		all:    NewLoadBalancer(info.lbType, hosts),
+	}
+	var names []string
+	for _, h := range hosts.Hosts() {
+		name := h.Config().Locality
+		if _, ok := lb.cells[name]; ok || name == "" {
+			continue
+		}
+		sub := hosts.createSubset(func(host types.Host) bool {
+			return host.Config().Locality == name
+		})
+		lb.cells[name] = &cell{
+			name:  name,
+			hosts: sub,
+			lb:    NewLoadBalancer(info.lbType, sub),
+			stats: metrics.NewCellStats(info.name, name),
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lb.hashCells = lb.config.Cells
+	if len(lb.hashCells) == 0 {
+		lb.hashCells = names
+	}
+	if local, ok := lb.cells[lb.config.LocalCell]; ok {
+		lb.fallbacks = append(lb.fallbacks, local)
+	}
+	for _, name := range names {
+		if name != lb.config.LocalCell {
+			lb.fallbacks = append(lb.fallbacks, lb.cells[name])
+		}
+	}
+	return lb
+}
+
+// requestCell returns the cell of the request. strict is false if the cell is the preferred local cell,
+// the request is routed to the other cells if the local cell is not available
+func (lb *cellLoadBalancer) requestCell(context types.LoadBalancerContext) (name string, strict bool) {
+	var headers api.HeaderMap
+	if context != nil {
+		headers = context.DownstreamHeaders()
+	}
+	if headers != nil {
+		if lb.config.Header != "" {
+			if v, ok := headers.Get(lb.config.Header); ok && v != "" {
+				return v, true
+			}
+		}
+		if lb.config.HashHeader != "" && len(lb.hashCells) > 0 {
+			if v, ok := headers.Get(lb.config.HashHeader); ok && v != "" {
+				h := fnv.New32a()
+				h.Write([]byte(v))
+				return lb.hashCells[h.Sum32()%uint32(len(lb.hashCells))], true
+			}
+		}
+	}
+	if lb.config.PreferLocalCell {
+		return lb.config.LocalCell, false
+	}
+	return "", false
+}
+
+func (lb *cellLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	name, strict := lb.requestCell(context)
+	if name == "" {
+		return lb.all.ChooseHost(context)
+	}
+	c := lb.cells[name]
+	if c.available() {
+		return lb.chooseInCell(c, context)
+	}
+	if strict && !lb.config.CrossCellFallback {
+		if c != nil {
+			c.stats.Counter(metrics.UpstreamCellRequestNoHost).Inc(1)
+		}
+		return nil
+	}
+	if c != nil {
+		c.stats.Counter(metrics.UpstreamCellRequestCrossCell).Inc(1)
+	}
+	for _, fallback := range lb.fallbacks {
+		if fallback != c && fallback.available() {
+			return lb.chooseInCell(fallback, context)
+		}
+	}
+	// the hosts without a cell
+	return lb.all.ChooseHost(context)
+}
+
+func (lb *cellLoadBalancer) chooseInCell(c *cell, context types.LoadBalancerContext) types.Host {
+	host := c.lb.ChooseHost(context)
+	if host != nil {
+		c.stats.Counter(metrics.UpstreamCellRequestTotal).Inc(1)
+	}
+	return host
+}
+
+func (lb *cellLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return lb.all.IsExistsHosts(metadata)
+}
+
+func (lb *cellLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return lb.all.HostNum(metadata)
+}
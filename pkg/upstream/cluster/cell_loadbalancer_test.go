@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func newCellTestLoadBalancer(cfg *v2.CellRouting) (types.LoadBalancer, *hostSet, map[string][]types.Host) {
+	cells := map[string][]types.Host{
+		"cell-a": makeLocalityHosts("cell-a", 0, 2),
+		"cell-b": makeLocalityHosts("cell-b", 0, 2),
+		"cell-c": makeLocalityHosts("cell-c", 0, 2),
+	}
+	var hosts []types.Host
+	for _, name := range []string{"cell-a", "cell-b", "cell-c"} {
+		hosts = append(hosts, cells[name]...)
+	}
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	info := &clusterInfo{
+		name:        "cell_test",
+		lbType:      types.RoundRobin,
+		cellRouting: cfg,
+	}
+	return newCellLoadBalancer(info, hs), hs, cells
+}
+
+func chooseCell(lb types.LoadBalancer, headers map[string]string) string {
+	h := lb.ChooseHost(&mockLbContext{header: protocol.CommonHeader(headers)})
+	if h == nil {
+		return ""
+	}
+	return h.Config().Locality
+}
+
+func TestCellLoadBalancer(t *testing.T) {
+	lb, _, _ := newCellTestLoadBalancer(&v2.CellRouting{
+		Header:          "x-cell",
+		HashHeader:      "x-uid",
+		Cells:           []string{"cell-a", "cell-b"},
+		LocalCell:       "cell-c",
+		PreferLocalCell: true,
+	})
+	for i := 0; i < 10; i++ {
+		if cell := chooseCell(lb, map[string]string{"x-cell": "cell-b"}); cell != "cell-b" {
+			t.Fatalf("expected cell-b, but got %s", cell)
+		}
+	}
+	// the hash header is hashed to the configured cells, and a key is always routed to the same cell
+	hashed := map[string]bool{}
+	for _, uid := range []string{"1", "2", "3", "4", "5", "6", "7", "8"} {
+		cell := chooseCell(lb, map[string]string{"x-uid": uid})
+		if cell != "cell-a" && cell != "cell-b" {
+			t.Fatalf("unexpected hashed cell: %s", cell)
+		}
+		if again := chooseCell(lb, map[string]string{"x-uid": uid}); again != cell {
+			t.Fatalf("uid %s is hashed to %s and %s", uid, cell, again)
+		}
+		hashed[cell] = true
+	}
+	if len(hashed) != 2 {
+		t.Fatalf("the uids should be hashed to both cells: %v", hashed)
+	}
+	// the cell header is preferred to the hash header
+	if cell := chooseCell(lb, map[string]string{"x-cell": "cell-c", "x-uid": "1"}); cell != "cell-c" {
+		t.Fatalf("expected cell-c, but got %s", cell)
+	}
+	// the request without a cell prefers the local cell
+	if cell := chooseCell(lb, nil); cell != "cell-c" {
+		t.Fatalf("expected the local cell, but got %s", cell)
+	}
+}
+
+func TestCellLoadBalancerFallback(t *testing.T) {
+	cfg := &v2.CellRouting{
+		Header:          "x-cell",
+		LocalCell:       "cell-c",
+		PreferLocalCell: true,
+	}
+	lb, hs, cells := newCellTestLoadBalancer(cfg)
+	for _, h := range cells["cell-a"] {
+		h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+		hs.refreshHealthHost(h)
+	}
+	stats := metrics.NewCellStats("cell_test", "cell-a")
+	noHost := stats.Counter(metrics.UpstreamCellRequestNoHost).Count()
+	if cell := chooseCell(lb, map[string]string{"x-cell": "cell-a"}); cell != "" {
+		t.Fatalf("expected no host without cross cell fallback, but got %s", cell)
+	}
+	if stats.Counter(metrics.UpstreamCellRequestNoHost).Count() != noHost+1 {
+		t.Error("the request without a host is not counted")
+	}
+
+	// the local cell is preferred by the fallback
+	cfg.CrossCellFallback = true
+	lb, hs, cells = newCellTestLoadBalancer(cfg)
+	for _, h := range append(cells["cell-a"], cells["cell-c"][0]) {
+		h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+		hs.refreshHealthHost(h)
+	}
+	crossCell := stats.Counter(metrics.UpstreamCellRequestCrossCell).Count()
+	if cell := chooseCell(lb, map[string]string{"x-cell": "cell-a"}); cell != "cell-c" {
+		t.Fatalf("expected the local cell, but got %s", cell)
+	}
+	if stats.Counter(metrics.UpstreamCellRequestCrossCell).Count() != crossCell+1 {
+		t.Error("the cross cell request is not counted")
+	}
+	// the other cells are used if the local cell is not available
+	cells["cell-c"][1].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	hs.refreshHealthHost(cells["cell-c"][1])
+	if cell := chooseCell(lb, map[string]string{"x-cell": "cell-a"}); cell != "cell-b" {
+		t.Fatalf("expected cell-b, but got %s", cell)
+	}
+	// the preferred local cell always falls back
+	cfg.CrossCellFallback = false
+	if cell := chooseCell(lb, nil); cell != "cell-b" {
+		t.Fatalf("expected cell-b, but got %s", cell)
+	}
+}
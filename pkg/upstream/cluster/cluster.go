@@ -33,9 +33,18 @@ import (
 
 func NewCluster(clusterConfig v2.Cluster) types.Cluster {
 	// TODO: support cluster type registered
+	switch clusterConfig.ClusterType {
+	case v2.DYNAMIC_FORWARD_PROXY_CLUSTER:
+		return newDynamicForwardProxyCluster(clusterConfig)
+	case v2.STATIC_RESPONSE_CLUSTER:
+		return newStaticResponseCluster(clusterConfig)
+	}
 	return newSimpleCluster(clusterConfig)
 }
 
+// defaultWarmTimeout is used if the new hosts are warming but no warm timeout is configured
+const defaultWarmTimeout = 30 * time.Second
+
 // simpleCluster is an implementation of types.Cluster
 type simpleCluster struct {
 	info          *clusterInfo
@@ -55,6 +64,21 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 		lbSubsetInfo:         NewLBSubsetInfo(&clusterConfig.LBSubSetConfig), // new subset load balancer info
 		lbType:               types.LoadBalancerType(clusterConfig.LbType),
 		resourceManager:      NewResourceManager(clusterConfig.CirBreThresholds),
+		tenantPool:           newTenantPool(clusterConfig.Name, clusterConfig.TenantPool),
+		upstreamProtocol:     types.Protocol(clusterConfig.UpstreamProtocol),
+		slo:                  newSLOTracker(clusterConfig.Name, clusterConfig.SLO),
+		cellRouting:          clusterConfig.CellRouting,
+		laneRouting:          clusterConfig.LaneRouting,
+		bufferPolicy:         network.NewBufferPolicy(clusterConfig.ConnectionBuffer),
+	}
+
+	if clusterConfig.ConnPool != nil {
+		info.connPoolConfig = *clusterConfig.ConnPool
+	}
+
+	info.drainTimeout = defaultDrainTimeout
+	if clusterConfig.DrainTimeout != nil && clusterConfig.DrainTimeout.Duration > 0 {
+		info.drainTimeout = clusterConfig.DrainTimeout.Duration
 	}
 
 	// set ConnectTimeout
@@ -64,6 +88,17 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 		info.connectTimeout = network.DefaultConnectTimeout
 	}
 
+	// new hosts warming needs active health check
+	if clusterConfig.IgnoreNewHostsUntilFirstHC {
+		if clusterConfig.HealthCheck.ServiceName == "" {
+			log.DefaultLogger.Warnf("[upstream] [cluster] [new cluster] cluster %s ignores new hosts until first health check, but no health check is configured", clusterConfig.Name)
+		} else if clusterConfig.WarmTimeout != nil && clusterConfig.WarmTimeout.Duration > 0 {
+			info.warmTimeout = clusterConfig.WarmTimeout.Duration
+		} else {
+			info.warmTimeout = defaultWarmTimeout
+		}
+	}
+
 	// tls mng
 	mgr, err := mtls.NewTLSClientContextManager(&clusterConfig.TLS)
 	if err != nil {
@@ -96,12 +131,20 @@ func newSimpleCluster(clusterConfig v2.Cluster) *simpleCluster {
 
 func (sc *simpleCluster) UpdateHosts(newHosts []types.Host) {
 	info := sc.info
+	var warmingHosts []types.Host
+	if info.warmTimeout > 0 {
+		warmingHosts = sc.markWarmingHosts(newHosts)
+	}
 	hostSet := &hostSet{}
 	hostSet.setFinalHost(newHosts)
 	// load balance
 	var lb types.LoadBalancer
 	if info.lbSubsetInfo.IsEnabled() {
 		lb = NewSubsetLoadBalancer(info, hostSet)
+	} else if info.laneRouting != nil {
+		lb = newLaneLoadBalancer(info, hostSet)
+	} else if info.cellRouting != nil {
+		lb = newCellLoadBalancer(info, hostSet)
 	} else {
 		lb = NewLoadBalancer(info.lbType, hostSet)
 	}
@@ -117,7 +160,46 @@ func (sc *simpleCluster) UpdateHosts(newHosts []types.Host) {
 			sc.healthChecker.SetHealthCheckerHostSet(hostSet)
 		}, nil)
 	}
+	if len(warmingHosts) > 0 {
+		time.AfterFunc(info.warmTimeout, func() {
+			sc.warmTimeout(warmingHosts)
+		})
+	}
+}
+
+// markWarmingHosts marks the hosts that are not in the cluster as warming.
+// the hosts that moved from the old cluster when a cluster config is updated
+// are created by the old cluster info, they are not new hosts.
+func (sc *simpleCluster) markWarmingHosts(newHosts []types.Host) []types.Host {
+	exists := map[string]struct{}{}
+	if sc.hostSet != nil {
+		for _, h := range sc.hostSet.Hosts() {
+			exists[h.AddressString()] = struct{}{}
+		}
+	}
+	var warmingHosts []types.Host
+	for _, h := range newHosts {
+		if _, ok := exists[h.AddressString()]; ok {
+			continue
+		}
+		if h.ClusterInfo() != types.ClusterInfo(sc.info) {
+			continue
+		}
+		h.SetHealthFlag(types.PENDING_ACTIVE_HC)
+		warmingHosts = append(warmingHosts, h)
+	}
+	return warmingHosts
+}
 
+// warmTimeout makes the hosts that are still warming ready
+func (sc *simpleCluster) warmTimeout(hosts []types.Host) {
+	for _, h := range hosts {
+		if h.ContainHealthFlag(types.PENDING_ACTIVE_HC) {
+			log.DefaultLogger.Infof("[upstream] [cluster] host %s warm timeout", h.AddressString())
+			h.ClearHealthFlag(types.PENDING_ACTIVE_HC)
+			sc.hostSet.refreshHealthHost(h)
+		}
+	}
 }
 
 func (sc *simpleCluster) Snapshot() types.ClusterSnapshot {
@@ -145,6 +227,30 @@ type clusterInfo struct {
 	lbSubsetInfo         types.LBSubsetInfo
 	tlsMng               types.TLSContextManager
 	connectTimeout       time.Duration
+	// warmTimeout is the max duration of a new host is warming, zero means the new hosts are not warmed
+	warmTimeout time.Duration
+	// drainTimeout is the max duration of draining the connection pools of a removed host
+	drainTimeout time.Duration
+	// tenantPool isolates the connection pools by downstream tenant, nil means not isolated
+	tenantPool *tenantPool
+	// upstreamProtocol overrides the proxy's upstream protocol
+	upstreamProtocol types.Protocol
+	connPoolConfig   v2.ConnPoolConfig
+	// slo tracks the upstream responses, nil means no slo is configured
+	slo *sloTracker
+	// cellRouting routes the requests by cells, nil means no cell routing is configured
+	cellRouting *v2.CellRouting
+	// laneRouting routes the colored requests by lanes, nil means no lane routing is configured
+	laneRouting *v2.LaneRouting
+	// bufferPolicy is the read and write buffer policy of the upstream connections, nil means the default policy
+	bufferPolicy *network.BufferPolicy
+}
+
+func (ci *clusterInfo) SLOTracker() types.SLOTracker {
+	if ci.slo == nil {
+		return nil
+	}
+	return ci.slo
 }
 
 func (ci *clusterInfo) Name() string {
@@ -187,6 +293,14 @@ func (ci *clusterInfo) ConnectTimeout() time.Duration {
 	return ci.connectTimeout
 }
 
+func (ci *clusterInfo) UpstreamProtocol() types.Protocol {
+	return ci.upstreamProtocol
+}
+
+func (ci *clusterInfo) ConnPoolConfig() v2.ConnPoolConfig {
+	return ci.connPoolConfig
+}
+
 type clusterSnapshot struct {
 	info    types.ClusterInfo
 	hostSet types.HostSet
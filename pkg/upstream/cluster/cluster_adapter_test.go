@@ -68,7 +68,7 @@ func TestClusterManagerFromConfig(t *testing.T) {
 	// create simple example config
 	_createClusterManager()
 	// use get for test
-	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	snap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	// check hosts exists
 	// check subset exists
 	mockLb1 := newMockLbContext(map[string]string{
@@ -149,7 +149,7 @@ func TestClusterManagerUpdateClusterSelectors(t *testing.T) {
 	}); err != nil {
 		t.Fatal("update cluster failed:", err)
 	}
-	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	snap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	if host := snap.LoadBalancer().ChooseHost(newMockLbContext((map[string]string{
 		"zone":    "a",
 		"version": "1.0.0",
@@ -173,7 +173,7 @@ func TestClusterUpdateHostsWithSnapshot(t *testing.T) {
 		"version": "1.0.0",
 		"zone":    "b",
 	})
-	oldSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	oldSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	// Update Hosts
 	GetClusterMngAdapterInstance().TriggerClusterHostUpdate("test1", []v2.Host{
 		{
@@ -194,7 +194,7 @@ func TestClusterUpdateHostsWithSnapshot(t *testing.T) {
 			},
 		},
 	})
-	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	newSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	if !(!oldSnap.IsExistsHosts(mockLb1.MetadataMatchCriteria()) &&
 		oldSnap.IsExistsHosts(mockLb2.MetadataMatchCriteria()) &&
 		!oldSnap.IsExistsHosts(mockLb3.MetadataMatchCriteria())) {
@@ -209,7 +209,7 @@ func TestClusterUpdateHostsWithSnapshot(t *testing.T) {
 
 func TestClusterAppendHostWithSnapshot(t *testing.T) {
 	_createClusterManager()
-	oldSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	oldSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	GetClusterMngAdapterInstance().TriggerHostAppend("test1", []v2.Host{
 		{
 			HostConfig: v2.HostConfig{
@@ -217,7 +217,7 @@ func TestClusterAppendHostWithSnapshot(t *testing.T) {
 			},
 		},
 	})
-	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	newSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	if !(len(oldSnap.HostSet().Hosts()) == 2 && len(newSnap.HostSet().Hosts()) == 3) {
 		t.Fatalf("append hosts snapshot check failed, old: %d, new: %d ", len(oldSnap.HostSet().Hosts()), len(newSnap.HostSet().Hosts()))
 	}
@@ -225,17 +225,31 @@ func TestClusterAppendHostWithSnapshot(t *testing.T) {
 
 func TestClusterRemoveHostWithSnapshot(t *testing.T) {
 	_createClusterManager()
-	oldSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	oldSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	GetClusterMngAdapterInstance().TriggerHostDel("test1", []string{"127.0.0.1:10001"})
-	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
+	newSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1")
 	if !(len(oldSnap.HostSet().Hosts()) == 2 && len(newSnap.HostSet().Hosts()) == 1) {
 		t.Fatal("remove hosts snapshot check failed")
 	}
 }
 
+func TestGetClusterSnapshot(t *testing.T) {
+	_createClusterManager()
+	if snap, ok := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test1"); !ok || snap == nil {
+		t.Fatal("get cluster snapshot failed")
+	}
+	snap, ok := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "unknown")
+	if ok || snap != nil {
+		t.Fatalf("get unknown cluster snapshot, expected nil, but got: %v", snap)
+	}
+	if pool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol); pool != nil {
+		t.Fatal("get conn pool for unknown cluster should be nil")
+	}
+}
+
 func TestConnPoolForCluster(t *testing.T) {
 	_createClusterManager()
-	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
+	snap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
 	connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol)
 	if connPool == nil {
 		t.Fatal("get conn pool failed")
@@ -261,7 +275,7 @@ func TestConnPoolUpdateTLS(t *testing.T) {
 	NewClusterManagerSingleton([]v2.Cluster{clusterConfig}, map[string][]v2.Host{
 		"test1": []v2.Host{host},
 	})
-	snap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
+	snap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
 	if connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), snap, mockProtocol); connPool.SupportTLS() {
 		t.Fatal("conn pool support tls")
 	}
@@ -274,7 +288,7 @@ func TestConnPoolUpdateTLS(t *testing.T) {
 	}); err != nil {
 		t.Fatalf("update cluster hosts failed, %v", err)
 	}
-	newSnap := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
+	newSnap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(nil, "test1")
 	if connPool := GetClusterMngAdapterInstance().ConnPoolForCluster(newMockLbContext(nil), newSnap, mockProtocol); !connPool.SupportTLS() {
 		t.Fatal("conn pool does not support tls")
 	}
@@ -21,15 +21,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"mosn.io/mosn/pkg/admin/store"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/event"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/network"
+	mosnprotocol "mosn.io/mosn/pkg/protocol"
 	"mosn.io/mosn/pkg/types"
 )
 
@@ -45,6 +49,9 @@ func refreshHostsConfig(c types.Cluster) {
 		hostsConfig = append(hostsConfig, h.Config())
 	}
 	store.SetHosts(name, hostsConfig)
+	event.Publish(event.HostsUpdated, name, map[string]string{
+		"hosts": strconv.Itoa(len(hosts)),
+	})
 	if log.DefaultLogger.GetLogLevel() >= log.INFO {
 		log.DefaultLogger.Infof("[cluster] [primaryCluster] [UpdateHosts] cluster %s update hosts: %d", name, len(hosts))
 	}
@@ -53,6 +60,31 @@ func refreshHostsConfig(c types.Cluster) {
 	}
 }
 
+// ClusterConfigCallback is called when a primary cluster is added, updated or removed,
+// the config is nil if the cluster is removed.
+type ClusterConfigCallback func(clusterName string, config *v2.Cluster)
+
+var (
+	clusterConfigCallbacks   []ClusterConfigCallback
+	clusterConfigCallbacksMu sync.RWMutex
+)
+
+// RegisterClusterConfigCallback registers a callback that is called when the primary clusters changed
+func RegisterClusterConfigCallback(cb ClusterConfigCallback) {
+	clusterConfigCallbacksMu.Lock()
+	defer clusterConfigCallbacksMu.Unlock()
+	clusterConfigCallbacks = append(clusterConfigCallbacks, cb)
+}
+
+func notifyClusterConfig(clusterName string, config *v2.Cluster) {
+	clusterConfigCallbacksMu.RLock()
+	cbs := clusterConfigCallbacks
+	clusterConfigCallbacksMu.RUnlock()
+	for _, cb := range cbs {
+		cb(clusterName, config)
+	}
+}
+
 // types.ClusterManager
 type clusterManager struct {
 	clustersMap      sync.Map
@@ -63,12 +95,22 @@ type clusterManager struct {
 type clusterManagerSingleton struct {
 	instanceMutex sync.Mutex
 	*clusterManager
+	poolStatsFlusher *connPoolStatsFlusher
 }
 
 func (singleton *clusterManagerSingleton) Destroy() {
 	clusterMangerInstance.instanceMutex.Lock()
 	defer clusterMangerInstance.instanceMutex.Unlock()
 	clusterMangerInstance.clusterManager = nil
+	if clusterMangerInstance.poolStatsFlusher != nil {
+		clusterMangerInstance.poolStatsFlusher.Stop()
+		clusterMangerInstance.poolStatsFlusher = nil
+	}
+	connPools.Range(func(pool, _ interface{}) bool {
+		connPools.Delete(pool)
+		return true
+	})
+	stopStaticResponseServers()
 }
 
 var clusterMangerInstance = &clusterManagerSingleton{}
@@ -80,6 +122,7 @@ func NewClusterManagerSingleton(clusters []v2.Cluster, clusterMap map[string][]v
 		return clusterMangerInstance
 	}
 	clusterMangerInstance.clusterManager = &clusterManager{}
+	clusterMangerInstance.poolStatsFlusher = startConnPoolStatsFlusher()
 	for k := range types.ConnPoolFactories {
 		clusterMangerInstance.protocolConnPool.Store(k, &sync.Map{})
 	}
@@ -124,6 +167,12 @@ func (cm *clusterManager) AddOrUpdatePrimaryCluster(cluster v2.Cluster) error {
 	}
 	cm.clustersMap.Store(clusterName, newCluster)
 	log.DefaultLogger.Infof("[cluster] [cluster manager] [AddOrUpdatePrimaryCluster] cluster %s updated", clusterName)
+	if exists {
+		event.Publish(event.ClusterUpdated, clusterName, nil)
+	} else {
+		event.Publish(event.ClusterAdded, clusterName, nil)
+	}
+	notifyClusterConfig(clusterName, &cluster)
 	return nil
 }
 
@@ -155,11 +204,20 @@ func (cm *clusterManager) RemovePrimaryCluster(clusterNames ...string) error {
 	}
 	// delete all of them
 	for _, clusterName := range clusterNames {
+		var snap types.ClusterSnapshot
+		if ci, ok := cm.clustersMap.Load(clusterName); ok {
+			snap = ci.(types.Cluster).Snapshot()
+		}
 		cm.clustersMap.Delete(clusterName)
+		if snap != nil {
+			cm.drainHosts(clusterName, removedHosts(snap.HostSet().Hosts(), nil), drainTimeout(snap))
+		}
 		store.RemoveClusterConfig(clusterName)
 		if log.DefaultLogger.GetLogLevel() >= log.INFO {
 			log.DefaultLogger.Infof("[upstream] [cluster manager] Remove Primary Cluster, Cluster Name = %s", clusterName)
 		}
+		notifyClusterConfig(clusterName, nil)
+		event.Publish(event.ClusterRemoved, clusterName, nil)
 	}
 	return nil
 }
@@ -179,6 +237,7 @@ func (cm *clusterManager) UpdateClusterHosts(clusterName string, hostConfigs []v
 	}
 	c.UpdateHosts(hosts)
 	refreshHostsConfig(c)
+	cm.drainHosts(clusterName, removedHosts(snap.HostSet().Hosts(), hosts), drainTimeout(snap))
 	return nil
 }
 
@@ -226,24 +285,26 @@ func (cm *clusterManager) RemoveClusterHosts(clusterName string, addrs []string)
 	}
 	c.UpdateHosts(sortedHosts)
 	refreshHostsConfig(c)
+	cm.drainHosts(clusterName, removedHosts(hosts, sortedHosts), drainTimeout(snap))
 	return nil
 }
 
 // GetClusterSnapshot returns cluster snap
 // do not needs PutClusterSnapshot any more
-func (cm *clusterManager) GetClusterSnapshot(ctx context.Context, clusterName string) types.ClusterSnapshot {
+func (cm *clusterManager) GetClusterSnapshot(ctx context.Context, clusterName string) (types.ClusterSnapshot, bool) {
 	ci, ok := cm.clustersMap.Load(clusterName)
 	if !ok {
-		return nil
+		return nil, false
 	}
-	return ci.(types.Cluster).Snapshot()
+	snap := ci.(types.Cluster).Snapshot()
+	return snap, snap != nil
 }
 
 func (cm *clusterManager) PutClusterSnapshot(snap types.ClusterSnapshot) {
 }
 
 func (cm *clusterManager) TCPConnForCluster(lbCtx types.LoadBalancerContext, snapshot types.ClusterSnapshot) types.CreateConnectionData {
-	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+	if snapshot == nil {
 		return types.CreateConnectionData{}
 	}
 	host := snapshot.LoadBalancer().ChooseHost(lbCtx)
@@ -254,7 +315,7 @@ func (cm *clusterManager) TCPConnForCluster(lbCtx types.LoadBalancerContext, sna
 }
 
 func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerContext, snapshot types.ClusterSnapshot, protocol types.Protocol) types.ConnectionPool {
-	if snapshot == nil || reflect.ValueOf(snapshot).IsNil() {
+	if snapshot == nil {
 		log.DefaultLogger.Errorf("[upstream] [cluster manager]  %s ConnPool For Cluster is nil", protocol)
 		return nil
 	}
@@ -274,8 +335,10 @@ var (
 )
 
 func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBalancerContext, clusterSnapshot types.ClusterSnapshot, protocol types.Protocol) (types.ConnectionPool, error) {
+	// the HTTP auto protocol is resolved for each chosen host
+	autoHTTP := protocol == mosnprotocol.HTTPAuto
 	factory, ok := network.ConnNewPoolFactories[protocol]
-	if !ok {
+	if !ok && !autoHTTP {
 		return nil, fmt.Errorf("protocol %v is not registered is pool factory", protocol)
 	}
 
@@ -288,8 +351,22 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 	if try > cycleTimes {
 		try = cycleTimes
 	}
+	// the tenant of the request, uses the isolated pools if it is not empty
+	var tenant string
+	var tenantStats *tenantStats
+	if info, ok := clusterSnapshot.ClusterInfo().(*clusterInfo); ok && info.tenantPool != nil {
+		var evicted []string
+		tenant, tenantStats, evicted = info.tenantPool.tenant(balancerContext)
+		cm.drainTenants(clusterSnapshot, evicted)
+	}
+	choosePool := func(pool types.ConnectionPool) types.ConnectionPool {
+		if tenantStats != nil {
+			tenantStats.request.Inc(1)
+		}
+		return pool
+	}
 	for i := 0; i < try; i++ {
-		host := clusterSnapshot.LoadBalancer().ChooseHost(balancerContext)
+		host := chooseHost(clusterSnapshot, balancerContext)
 		if host == nil {
 			return nil, errNilHostChoose
 		}
@@ -298,8 +375,24 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
 			log.DefaultLogger.Debugf("[upstream] [cluster manager] clusterSnapshot.loadbalancer.ChooseHost result is %s, cluster name = %s", addr, clusterSnapshot.ClusterInfo().Name())
 		}
-		value, ok := cm.protocolConnPool.Load(protocol)
+		key := tenantPoolKey(addr, tenant)
+		prot := protocol
+		// the connection of the ALPN probe, used by the new pool of the negotiated protocol
+		var negotiated net.Conn
+		if autoHTTP {
+			prot, negotiated = autoHTTPProtocol(host)
+			if factory, ok = network.ConnNewPoolFactories[prot]; !ok {
+				if negotiated != nil {
+					negotiated.Close()
+				}
+				return nil, fmt.Errorf("protocol %v is not registered is pool factory", prot)
+			}
+		}
+		value, ok := cm.protocolConnPool.Load(prot)
 		if !ok {
+			if negotiated != nil {
+				negotiated.Close()
+			}
 			return nil, errUnknownProtocol
 		}
 
@@ -307,21 +400,30 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 		// we cannot use sync.Map.LoadOrStore directly, becasue we do not want to new a connpool every time
 		loadOrStoreConnPool := func() (types.ConnectionPool, bool) {
 			// avoid locking if it is already exists
-			if connPool, ok := connectionPool.Load(addr); ok {
+			if connPool, ok := connectionPool.Load(key); ok {
 				pool := connPool.(types.ConnectionPool)
 				return pool, true
 			}
 			cm.mux.Lock()
 			defer cm.mux.Unlock()
-			if connPool, ok := connectionPool.Load(addr); ok {
+			if connPool, ok := connectionPool.Load(key); ok {
 				pool := connPool.(types.ConnectionPool)
 				return pool, true
 			}
-			pool := factory(host)
-			connectionPool.Store(addr, pool)
+			pool := factory(tenantStats.poolHost(newNegotiatedHost(host, negotiated)))
+			negotiated = nil
+			connectionPool.Store(key, pool)
+			addConnPool(pool, host, key)
+			if tenantStats != nil {
+				tenantStats.poolCreated.Inc(1)
+			}
 			return pool, false
 		}
 		pool, loaded := loadOrStoreConnPool()
+		if negotiated != nil {
+			// the pool exists, the probe connection is not used
+			negotiated.Close()
+		}
 		if loaded {
 			if pool.SupportTLS() != host.SupportTLS() {
 				if log.DefaultLogger.GetLogLevel() >= log.INFO {
@@ -332,26 +434,33 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 					cm.mux.Lock()
 					defer cm.mux.Unlock()
 					// recheck whether the pool is changed
-					if connPool, ok := connectionPool.Load(addr); ok {
+					if connPool, ok := connectionPool.Load(key); ok {
 						pool = connPool.(types.ConnectionPool)
 						if pool.SupportTLS() == host.SupportTLS() {
 							return
 						}
-						connectionPool.Delete(addr)
+						connectionPool.Delete(key)
 						pool.Shutdown()
-						pool = factory(host)
-						connectionPool.Store(addr, pool)
+						removeConnPool(pool)
+						pool = factory(tenantStats.poolHost(host))
+						connectionPool.Store(key, pool)
+						addConnPool(pool, host, key)
 					}
 				}()
 			}
 		}
 		if pool.CheckAndInit(balancerContext.DownstreamContext()) {
-			return pool, nil
+			return choosePool(pool), nil
 		}
 		pools[i] = pool
 	}
 
 	// perhaps the first request, wait for tcp handshaking. total wait time is 1ms + 10ms + 100ms
+	for i := 0; i < try; i++ {
+		if pools[i] != nil {
+			defer waitConnPool(pools[i])()
+		}
+	}
 	waitTime := time.Millisecond
 	for t := 0; t < cycleTimes; t++ {
 		time.Sleep(waitTime)
@@ -360,7 +469,7 @@ func (cm *clusterManager) getActiveConnectionPool(balancerContext types.LoadBala
 				continue
 			}
 			if pools[i].CheckAndInit(balancerContext.DownstreamContext()) {
-				return pools[i], nil
+				return choosePool(pools[i]), nil
 			}
 		}
 		waitTime *= 10
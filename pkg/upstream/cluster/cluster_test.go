@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"testing"
+	"time"
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
@@ -164,3 +165,53 @@ func TestUpdateHostLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestClusterWarmingHosts(t *testing.T) {
+	cluster := newSimpleCluster(v2.Cluster{
+		Name:                       "warming",
+		LbType:                     v2.LB_ROUNDROBIN,
+		IgnoreNewHostsUntilFirstHC: true,
+		WarmTimeout: &api.DurationConfig{
+			Duration: 100 * time.Millisecond,
+		},
+	})
+	// no health check, the hosts are not warming
+	if cluster.info.warmTimeout != 0 {
+		t.Fatal("warm timeout should be disabled without health check")
+	}
+	cluster.info.warmTimeout = 100 * time.Millisecond
+	newHost := func(addr string) types.Host {
+		return NewSimpleHost(v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: addr,
+			},
+		}, cluster.info)
+	}
+	cluster.UpdateHosts([]types.Host{newHost("127.0.0.1:8080")})
+	// all the hosts in new cluster are warming
+	if len(cluster.Snapshot().HostSet().HealthyHosts()) != 0 {
+		t.Fatal("new host should be warming")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if len(cluster.Snapshot().HostSet().HealthyHosts()) != 1 {
+		t.Fatal("host should be ready after warm timeout")
+	}
+	// only the new host is warming
+	newHosts := []types.Host{newHost("127.0.0.1:8080"), newHost("127.0.0.1:8081")}
+	cluster.UpdateHosts(newHosts)
+	healthyHosts := cluster.Snapshot().HostSet().HealthyHosts()
+	if len(healthyHosts) != 1 || healthyHosts[0].AddressString() != "127.0.0.1:8080" {
+		t.Fatalf("unexpected healthy hosts: %v", healthyHosts)
+	}
+	// the hosts are moved to the updated cluster are not new hosts
+	updated := newSimpleCluster(v2.Cluster{
+		Name:   "warming",
+		LbType: v2.LB_ROUNDROBIN,
+	})
+	updated.info.warmTimeout = 100 * time.Millisecond
+	newHosts[1].ClearHealthFlag(types.PENDING_ACTIVE_HC)
+	updated.UpdateHosts(cluster.Snapshot().HostSet().Hosts())
+	if len(updated.Snapshot().HostSet().HealthyHosts()) != 2 {
+		t.Fatal("moved hosts should not be warming")
+	}
+}
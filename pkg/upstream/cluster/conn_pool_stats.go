@@ -0,0 +1,212 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	rawjson "encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	admin "mosn.io/mosn/pkg/admin/server"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	connPoolsPath = "/api/v1/conn_pools"
+	// connPoolStatsInterval is the interval to update the gauges of the connection pools
+	connPoolStatsInterval = 10 * time.Second
+)
+
+func init() {
+	admin.RegisterAdminHandleFunc(connPoolsPath, serveConnPools)
+}
+
+// ConnPoolInfo is the state of a connection pool in the cluster manager
+type ConnPoolInfo struct {
+	Cluster  string    `json:"cluster"`
+	Host     string    `json:"host"`
+	Protocol string    `json:"protocol"`
+	Tenant   string    `json:"tenant,omitempty"`
+	Created  time.Time `json:"created"`
+	// PendingStreams is the number of the requests waiting for the pool connected
+	PendingStreams int64 `json:"pending_streams"`
+	types.ConnectionPoolState
+}
+
+// connPoolStats are the stats of a connection pool created by the cluster manager
+type connPoolStats struct {
+	info    ConnPoolInfo
+	pool    types.ConnectionPool
+	pending int64
+	stats   types.Metrics
+	// connectFailures is the connect failures of the pool at the last flush
+	connectFailures uint64
+}
+
+// connPools keeps the stats of the connection pools in the cluster manager, keyed by the pool
+var connPools sync.Map
+
+// addConnPool records the pool created for the host, key is the pool key in the cluster manager
+func addConnPool(pool types.ConnectionPool, host types.Host, key string) {
+	cluster := host.ClusterInfo().Name()
+	addr := poolKeyAddr(key)
+	s := &connPoolStats{
+		info: ConnPoolInfo{
+			Cluster:  cluster,
+			Host:     addr,
+			Protocol: string(pool.Protocol()),
+			Created:  time.Now(),
+		},
+		pool:  pool,
+		stats: metrics.NewPoolStats(cluster, addr, string(pool.Protocol())),
+	}
+	if len(key) > len(addr) {
+		s.info.Tenant = key[len(addr)+1:]
+	}
+	connPools.Store(pool, s)
+	metrics.NewClusterStats(cluster).Counter(metrics.UpstreamPoolCreated).Inc(1)
+}
+
+// removeConnPool removes the stats of the pool, the gauges are reset as the pool is gone
+func removeConnPool(pool types.ConnectionPool) {
+	value, ok := connPools.Load(pool)
+	if !ok {
+		return
+	}
+	connPools.Delete(pool)
+	s := value.(*connPoolStats)
+	s.stats.Gauge(metrics.UpstreamPoolConnections).Update(0)
+	s.stats.Gauge(metrics.UpstreamPoolConnectionsActive).Update(0)
+	s.stats.Gauge(metrics.UpstreamPoolConnectionsIdle).Update(0)
+	s.stats.Gauge(metrics.UpstreamPoolActiveStreams).Update(0)
+	s.stats.Gauge(metrics.UpstreamPoolPendingStreams).Update(0)
+	metrics.NewClusterStats(s.info.Cluster).Counter(metrics.UpstreamPoolClosed).Inc(1)
+}
+
+// waitConnPool counts the request waiting for the pool connected, the returned function is called when the wait is over
+func waitConnPool(pool types.ConnectionPool) func() {
+	value, ok := connPools.Load(pool)
+	if !ok {
+		return func() {}
+	}
+	s := value.(*connPoolStats)
+	atomic.AddInt64(&s.pending, 1)
+	return func() {
+		atomic.AddInt64(&s.pending, -1)
+	}
+}
+
+// flush updates the gauges by the current state of the pool
+func (s *connPoolStats) flush() ConnPoolInfo {
+	info := s.info
+	info.PendingStreams = atomic.LoadInt64(&s.pending)
+	if sp, ok := s.pool.(types.StateConnectionPool); ok {
+		info.ConnectionPoolState = sp.State()
+	}
+	s.stats.Gauge(metrics.UpstreamPoolConnections).Update(int64(info.Connections))
+	s.stats.Gauge(metrics.UpstreamPoolConnectionsActive).Update(int64(info.ActiveConnections))
+	s.stats.Gauge(metrics.UpstreamPoolConnectionsIdle).Update(int64(info.IdleConnections))
+	s.stats.Gauge(metrics.UpstreamPoolActiveStreams).Update(int64(info.ActiveStreams))
+	s.stats.Gauge(metrics.UpstreamPoolPendingStreams).Update(info.PendingStreams)
+	if last := atomic.SwapUint64(&s.connectFailures, info.ConnectFailures); info.ConnectFailures > last {
+		s.stats.Counter(metrics.UpstreamPoolConnectFailed).Inc(int64(info.ConnectFailures - last))
+	}
+	return info
+}
+
+// FlushConnPoolStats updates the stats of all the connection pools, and returns their states
+// ordered by the cluster, host, protocol and tenant
+func FlushConnPoolStats() []ConnPoolInfo {
+	var infos []ConnPoolInfo
+	connPools.Range(func(_, value interface{}) bool {
+		infos = append(infos, value.(*connPoolStats).flush())
+		return true
+	})
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := &infos[i], &infos[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.Tenant < b.Tenant
+	})
+	return infos
+}
+
+// connPoolStatsFlusher updates the connection pool stats periodically
+type connPoolStatsFlusher struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+func startConnPoolStatsFlusher() *connPoolStatsFlusher {
+	f := &connPoolStatsFlusher{
+		stop: make(chan struct{}),
+	}
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(connPoolStatsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				FlushConnPoolStats()
+			case <-f.stop:
+				return
+			}
+		}
+	}, nil)
+	return f
+}
+
+func (f *connPoolStatsFlusher) Stop() {
+	f.once.Do(func() {
+		close(f.stop)
+	})
+}
+
+// serveConnPools lists the connection pools with their current states, the pools can be filtered
+// by the query "cluster" and "host", it helps to find out why a request gets no healthy upstream
+func serveConnPools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.DefaultLogger.Alertf(types.ErrorKeyAdmin, "api: %s, error: invalid method: %s", "conn pools", r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cluster := r.URL.Query().Get("cluster")
+	host := r.URL.Query().Get("host")
+	pools := []ConnPoolInfo{}
+	for _, info := range FlushConnPoolStats() {
+		if (cluster == "" || info.Cluster == cluster) && (host == "" || info.Host == host) {
+			pools = append(pools, info)
+		}
+	}
+	buf, _ := rawjson.Marshal(pools)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf)
+}
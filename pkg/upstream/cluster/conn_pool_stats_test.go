@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+)
+
+const mockStateProtocol = types.Protocol("mock_state")
+
+type mockStatePool struct {
+	mockConnPool
+	state types.ConnectionPoolState
+}
+
+func (p *mockStatePool) Protocol() types.Protocol {
+	return mockStateProtocol
+}
+
+func (p *mockStatePool) State() types.ConnectionPoolState {
+	return p.state
+}
+
+func init() {
+	network.RegisterNewPoolFactory(mockStateProtocol, func(h types.Host) types.ConnectionPool {
+		return &mockStatePool{
+			mockConnPool: mockConnPool{h: h},
+			state: types.ConnectionPoolState{
+				Connections:       3,
+				ActiveConnections: 2,
+				IdleConnections:   1,
+				ActiveStreams:     5,
+				ConnectFailures:   4,
+			},
+		}
+	})
+	types.RegisterConnPoolFactory(mockStateProtocol, true)
+}
+
+func TestConnPoolStats(t *testing.T) {
+	clusterMangerInstance.Destroy() // Destroy for test
+	cm := NewClusterManagerSingleton([]v2.Cluster{
+		{
+			Name:   "pool_stats",
+			LbType: v2.LB_RANDOM,
+		},
+	}, map[string][]v2.Host{
+		"pool_stats": {
+			{HostConfig: v2.HostConfig{Address: "127.0.0.1:10011"}},
+		},
+	})
+	defer cm.Destroy()
+
+	snap, _ := cm.GetClusterSnapshot(nil, "pool_stats")
+	if pool := cm.ConnPoolForCluster(newMockLbContext(nil), snap, mockStateProtocol); pool == nil {
+		t.Fatal("get connection pool failed")
+	}
+	clusterStats := metrics.NewClusterStats("pool_stats")
+	if clusterStats.Counter(metrics.UpstreamPoolCreated).Count() != 1 {
+		t.Error("pool created is not counted")
+	}
+
+	infos := FlushConnPoolStats()
+	if len(infos) != 1 {
+		t.Fatalf("expected 1 pool, but got %d", len(infos))
+	}
+	info := infos[0]
+	if info.Cluster != "pool_stats" || info.Host != "127.0.0.1:10011" || info.Protocol != string(mockStateProtocol) ||
+		info.Connections != 3 || info.IdleConnections != 1 || info.ActiveStreams != 5 {
+		t.Errorf("unexpected pool info: %+v", info)
+	}
+	stats := metrics.NewPoolStats("pool_stats", "127.0.0.1:10011", string(mockStateProtocol))
+	if stats.Gauge(metrics.UpstreamPoolConnectionsActive).Value() != 2 ||
+		stats.Counter(metrics.UpstreamPoolConnectFailed).Count() != 4 {
+		t.Error("pool stats are not flushed")
+	}
+	// the connect failures are counted once
+	FlushConnPoolStats()
+	if stats.Counter(metrics.UpstreamPoolConnectFailed).Count() != 4 {
+		t.Error("connect failures are counted repeatedly")
+	}
+
+	for query, expected := range map[string]int{
+		"":                                 1,
+		"?cluster=pool_stats":              1,
+		"?cluster=unknown":                 0,
+		"?host=127.0.0.1:10011":            1,
+		"?cluster=pool_stats&host=unknown": 0,
+	} {
+		w := httptest.NewRecorder()
+		serveConnPools(w, httptest.NewRequest(http.MethodGet, connPoolsPath+query, nil))
+		var pools []ConnPoolInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &pools); err != nil || len(pools) != expected {
+			t.Errorf("query %s expected %d pools, but got %s, error: %v", query, expected, w.Body.String(), err)
+		}
+	}
+	w := httptest.NewRecorder()
+	serveConnPools(w, httptest.NewRequest(http.MethodPost, connPoolsPath, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("unexpected status code: %d", w.Code)
+	}
+
+	if err := cm.RemoveClusterHosts("pool_stats", []string{"127.0.0.1:10011"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(FlushConnPoolStats()) != 0 || clusterStats.Counter(metrics.UpstreamPoolClosed).Count() != 1 {
+		t.Error("the pool of the removed host should be removed")
+	}
+	if stats.Gauge(metrics.UpstreamPoolConnections).Value() != 0 {
+		t.Error("the gauges of the removed pool should be reset")
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const defaultDrainTimeout = 30 * time.Second
+
+// hostDrainer counts the draining hosts of the clusters
+type hostDrainer struct {
+	mux      sync.Mutex
+	draining map[string]int64
+}
+
+var drainer = &hostDrainer{
+	draining: make(map[string]int64),
+}
+
+func (d *hostDrainer) update(cluster string, delta int64) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	n := d.draining[cluster] + delta
+	if n <= 0 {
+		delete(d.draining, cluster)
+	} else {
+		d.draining[cluster] = n
+	}
+	metrics.NewClusterStats(cluster).Gauge(metrics.UpstreamHostDraining).Update(n)
+}
+
+// removedHosts returns the addresses of the old hosts not in the new hosts
+func removedHosts(oldHosts []types.Host, newHosts []types.Host) []string {
+	if len(oldHosts) == 0 {
+		return nil
+	}
+	addrs := make(map[string]bool, len(newHosts))
+	for _, h := range newHosts {
+		addrs[h.AddressString()] = true
+	}
+	var removed []string
+	for _, h := range oldHosts {
+		if addr := h.AddressString(); !addrs[addr] {
+			removed = append(removed, addr)
+		}
+	}
+	return removed
+}
+
+// drainTimeout returns the drain timeout of the cluster
+func drainTimeout(snap types.ClusterSnapshot) time.Duration {
+	if info, ok := snap.ClusterInfo().(*clusterInfo); ok && info.drainTimeout > 0 {
+		return info.drainTimeout
+	}
+	return defaultDrainTimeout
+}
+
+// drainHosts drains the connection pools of the hosts removed from the cluster.
+// The pools are shared by the address, so the hosts still used by the other clusters are not drained.
+// The detailed stats of the removed hosts are removed too.
+func (cm *clusterManager) drainHosts(clusterName string, addrs []string, timeout time.Duration) {
+	if len(addrs) == 0 {
+		return
+	}
+	for _, addr := range addrs {
+		metrics.RemoveHostDetailStats(clusterName, addr)
+	}
+	inUse := make(map[string]bool)
+	cm.clustersMap.Range(func(_, value interface{}) bool {
+		for _, h := range value.(types.Cluster).Snapshot().HostSet().Hosts() {
+			inUse[h.AddressString()] = true
+		}
+		return true
+	})
+	for _, addr := range addrs {
+		if inUse[addr] {
+			continue
+		}
+		if pools := cm.removeHostPools(addr); len(pools) > 0 {
+			drainPools(clusterName, addr, pools, timeout)
+		}
+	}
+}
+
+// removeHostPools removes the connection pools of the host from the cluster manager,
+// so no new stream is assigned to them
+func (cm *clusterManager) removeHostPools(addr string) []types.ConnectionPool {
+	cm.mux.Lock()
+	defer cm.mux.Unlock()
+	var pools []types.ConnectionPool
+	cm.protocolConnPool.Range(func(_, value interface{}) bool {
+		connPools := value.(*sync.Map)
+		connPools.Range(func(key, pool interface{}) bool {
+			if k, ok := key.(string); ok && poolKeyAddr(k) == addr {
+				connPools.Delete(key)
+				pools = append(pools, pool.(types.ConnectionPool))
+				removeConnPool(pool.(types.ConnectionPool))
+			}
+			return true
+		})
+		return true
+	})
+	return pools
+}
+
+// drainTenants drains the connection pools of the tenants evicted from the cluster
+func (cm *clusterManager) drainTenants(snap types.ClusterSnapshot, tenants []string) {
+	if len(tenants) == 0 {
+		return
+	}
+	timeout := drainTimeout(snap)
+	for _, host := range snap.HostSet().Hosts() {
+		for _, tenant := range tenants {
+			for _, pool := range cm.removePools(tenantPoolKey(host.AddressString(), tenant)) {
+				drainPool(pool, timeout)
+			}
+		}
+	}
+}
+
+// removePools removes the connection pools of the pool key from the cluster manager
+func (cm *clusterManager) removePools(key string) []types.ConnectionPool {
+	cm.mux.Lock()
+	defer cm.mux.Unlock()
+	var pools []types.ConnectionPool
+	cm.protocolConnPool.Range(func(_, value interface{}) bool {
+		connPools := value.(*sync.Map)
+		if pool, ok := connPools.Load(key); ok {
+			connPools.Delete(key)
+			pools = append(pools, pool.(types.ConnectionPool))
+			removeConnPool(pool.(types.ConnectionPool))
+		}
+		return true
+	})
+	return pools
+}
+
+// drainPools waits for the pools drained in background, the host is counted as draining until then
+func drainPools(clusterName string, addr string, pools []types.ConnectionPool, timeout time.Duration) {
+	if log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [cluster manager] cluster %s drains the removed host %s, pools: %d, timeout: %v", clusterName, addr, len(pools), timeout)
+	}
+	drainer.update(clusterName, 1)
+	drained := make([]<-chan bool, 0, len(pools))
+	for _, pool := range pools {
+		drained = append(drained, drainPool(pool, timeout))
+	}
+	utils.GoWithRecover(func() {
+		timeoutClosed := false
+		for _, ch := range drained {
+			if <-ch {
+				timeoutClosed = true
+			}
+		}
+		stats := metrics.NewClusterStats(clusterName)
+		stats.Counter(metrics.UpstreamHostDrained).Inc(1)
+		if timeoutClosed {
+			stats.Counter(metrics.UpstreamHostDrainTimeout).Inc(1)
+			log.DefaultLogger.Warnf("[upstream] [cluster manager] cluster %s drains host %s timeout, the connections are closed with active streams", clusterName, addr)
+		}
+		drainer.update(clusterName, -1)
+	}, nil)
+}
+
+// drainPool drains the pool, the pools not supporting drain are shut down and closed after the timeout
+func drainPool(pool types.ConnectionPool, timeout time.Duration) <-chan bool {
+	if dp, ok := pool.(types.DrainConnectionPool); ok {
+		return dp.Drain(timeout)
+	}
+	pool.Shutdown()
+	drained := make(chan bool, 1)
+	time.AfterFunc(timeout, func() {
+		pool.Close()
+		drained <- false
+		close(drained)
+	})
+	return drained
+}
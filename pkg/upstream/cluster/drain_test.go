@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/network"
+	"mosn.io/mosn/pkg/types"
+)
+
+const mockDrainProtocol = types.Protocol("mock_drain")
+
+type mockDrainPool struct {
+	mockConnPool
+	timeout time.Duration
+	drained chan bool
+}
+
+func (p *mockDrainPool) Drain(timeout time.Duration) <-chan bool {
+	p.timeout = timeout
+	return p.drained
+}
+
+func init() {
+	network.RegisterNewPoolFactory(mockDrainProtocol, func(h types.Host) types.ConnectionPool {
+		return &mockDrainPool{
+			mockConnPool: mockConnPool{h: h},
+			drained:      make(chan bool, 1),
+		}
+	})
+	types.RegisterConnPoolFactory(mockDrainProtocol, true)
+}
+
+func TestDrainRemovedHosts(t *testing.T) {
+	clusterMangerInstance.Destroy() // Destroy for test
+	cm := NewClusterManagerSingleton([]v2.Cluster{
+		{
+			Name:         "drain_a",
+			LbType:       v2.LB_RANDOM,
+			DrainTimeout: &api.DurationConfig{Duration: 5 * time.Second},
+		},
+		{
+			Name:   "drain_b",
+			LbType: v2.LB_RANDOM,
+		},
+	}, map[string][]v2.Host{
+		"drain_a": {
+			{HostConfig: v2.HostConfig{Address: "127.0.0.1:10001"}},
+			{HostConfig: v2.HostConfig{Address: "127.0.0.1:10002"}},
+		},
+		"drain_b": {
+			{HostConfig: v2.HostConfig{Address: "127.0.0.1:10002"}},
+		},
+	})
+	defer cm.Destroy()
+
+	value, _ := clusterMangerInstance.protocolConnPool.Load(mockDrainProtocol)
+	connPools := value.(*sync.Map)
+	snap, _ := cm.GetClusterSnapshot(context.Background(), "drain_a")
+	pools := map[string]*mockDrainPool{}
+	for _, h := range snap.HostSet().Hosts() {
+		for _, key := range []string{h.AddressString(), tenantPoolKey(h.AddressString(), "tenant")} {
+			pool := network.ConnNewPoolFactories[mockDrainProtocol](h).(*mockDrainPool)
+			pools[key] = pool
+			connPools.Store(key, pool)
+		}
+	}
+
+	// the host is still used by drain_a
+	if err := cm.UpdateClusterHosts("drain_b", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := connPools.Load("127.0.0.1:10002"); !ok {
+		t.Fatal("the host used by the other cluster should not be drained")
+	}
+
+	if err := cm.RemoveClusterHosts("drain_a", []string{"127.0.0.1:10002"}); err != nil {
+		t.Fatal(err)
+	}
+	_, ok1 := connPools.Load("127.0.0.1:10002")
+	_, ok2 := connPools.Load("127.0.0.1:10002#tenant")
+	if ok1 || ok2 {
+		t.Fatal("the pools of the removed host should be removed")
+	}
+	if _, ok := connPools.Load("127.0.0.1:10001"); !ok {
+		t.Fatal("the pool of the kept host should not be removed")
+	}
+	if pools["127.0.0.1:10002"].timeout != 5*time.Second {
+		t.Fatalf("unexpected drain timeout: %v", pools["127.0.0.1:10002"].timeout)
+	}
+	stats := metrics.NewClusterStats("drain_a")
+	if stats.Gauge(metrics.UpstreamHostDraining).Value() != 1 {
+		t.Fatal("the removed host should be draining")
+	}
+
+	pools["127.0.0.1:10002"].drained <- false
+	if stats.Gauge(metrics.UpstreamHostDraining).Value() != 1 {
+		t.Fatal("the host should be draining until all of its pools drained")
+	}
+	// the tenant pool is closed by the timeout
+	pools["127.0.0.1:10002#tenant"].drained <- true
+	for i := 0; i < 100 && stats.Gauge(metrics.UpstreamHostDraining).Value() != 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats.Gauge(metrics.UpstreamHostDraining).Value() != 0 ||
+		stats.Counter(metrics.UpstreamHostDrained).Count() != 1 ||
+		stats.Counter(metrics.UpstreamHostDrainTimeout).Count() != 1 {
+		t.Fatal("unexpected drain stats")
+	}
+}
+
+func TestRemovedHosts(t *testing.T) {
+	info := &clusterInfo{name: "removed_hosts"}
+	newHost := func(addr string) types.Host {
+		return NewSimpleHost(v2.Host{HostConfig: v2.HostConfig{Address: addr}}, info)
+	}
+	oldHosts := []types.Host{newHost("127.0.0.1:1"), newHost("127.0.0.1:2"), newHost("127.0.0.1:3")}
+	removed := removedHosts(oldHosts, []types.Host{newHost("127.0.0.1:2"), newHost("127.0.0.1:4")})
+	if len(removed) != 2 || removed[0] != "127.0.0.1:1" || removed[1] != "127.0.0.1:3" {
+		t.Fatalf("unexpected removed hosts: %v", removed)
+	}
+	if poolKeyAddr(tenantPoolKey("127.0.0.1:1", "a")) != "127.0.0.1:1" || poolKeyAddr("127.0.0.1:1") != "127.0.0.1:1" {
+		t.Fatal("unexpected pool key address")
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/dns"
+	"mosn.io/mosn/pkg/log"
+	mosnmetrics "mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const (
+	defaultDynamicMaxHosts = 1024
+	defaultDynamicHostTTL  = 5 * time.Minute
+)
+
+// SetDynamicForwardHost sets the "host:port" that the dynamic forward proxy cluster sends the stream to
+func SetDynamicForwardHost(ctx context.Context, hostport string) context.Context {
+	return mosnctx.WithValue(ctx, types.ContextKeyDynamicForwardHost, hostport)
+}
+
+// dynamicForwardProxyCluster is a cluster whose hosts are created on demand by the host in the
+// stream context, the hosts are not updated by the config
+type dynamicForwardProxyCluster struct {
+	*simpleCluster
+}
+
+func newDynamicForwardProxyCluster(clusterConfig v2.Cluster) *dynamicForwardProxyCluster {
+	sc := newSimpleCluster(clusterConfig)
+	lb := newDynamicHostLoadBalancer(sc.info, clusterConfig.DynamicForwardProxy)
+	hostSet := &hostSet{}
+	sc.lbInstance = lb
+	sc.hostSet = hostSet
+	sc.snapshot.Store(&clusterSnapshot{
+		info:    sc.info,
+		hostSet: hostSet,
+		lb:      lb,
+	})
+	return &dynamicForwardProxyCluster{
+		simpleCluster: sc,
+	}
+}
+
+func (c *dynamicForwardProxyCluster) UpdateHosts(hosts []types.Host) {
+	if len(hosts) > 0 {
+		log.DefaultLogger.Warnf("[upstream] [cluster] dynamic forward proxy cluster %s ignores the %d hosts updated", c.info.name, len(hosts))
+	}
+}
+
+type dynamicHost struct {
+	host     types.Host
+	lastUsed time.Time
+	elem     *list.Element
+}
+
+// dynamicHostLoadBalancer chooses the host by the dynamic forward host in the stream context,
+// the hosts are cached by the resolved address, the least recently used host is evicted if the cache is full
+type dynamicHostLoadBalancer struct {
+	info     types.ClusterInfo
+	maxHosts int
+	ttl      time.Duration
+	resolve  func(ctx context.Context, hostport string) (*net.TCPAddr, error)
+
+	created metrics.Counter
+	evicted metrics.Counter
+	failure metrics.Counter
+
+	mux   sync.Mutex
+	hosts map[string]*dynamicHost
+	// the addresses from the least recently used one
+	lru *list.List
+}
+
+func newDynamicHostLoadBalancer(info types.ClusterInfo, config *v2.DynamicForwardProxyConfig) *dynamicHostLoadBalancer {
+	s := mosnmetrics.NewClusterStats(info.Name())
+	lb := &dynamicHostLoadBalancer{
+		info:     info,
+		maxHosts: defaultDynamicMaxHosts,
+		ttl:      defaultDynamicHostTTL,
+		resolve:  resolveDynamicHost,
+		created:  s.Counter(mosnmetrics.UpstreamDynamicHostCreated),
+		evicted:  s.Counter(mosnmetrics.UpstreamDynamicHostEvicted),
+		failure:  s.Counter(mosnmetrics.UpstreamDynamicHostFailure),
+		hosts:    make(map[string]*dynamicHost),
+		lru:      list.New(),
+	}
+	if config != nil {
+		if config.MaxHosts > 0 {
+			lb.maxHosts = int(config.MaxHosts)
+		}
+		if config.HostTTL.Duration > 0 {
+			lb.ttl = config.HostTTL.Duration
+		}
+	}
+	return lb
+}
+
+// resolveDynamicHost uses the default resolver when it is called, the resolver may be configured after the cluster is created
+func resolveDynamicHost(ctx context.Context, hostport string) (*net.TCPAddr, error) {
+	return dns.Default().ResolveTCPAddr(ctx, hostport)
+}
+
+func (lb *dynamicHostLoadBalancer) ChooseHost(lbCtx types.LoadBalancerContext) types.Host {
+	if lbCtx == nil || lbCtx.DownstreamContext() == nil {
+		return nil
+	}
+	hostport, ok := mosnctx.Get(lbCtx.DownstreamContext(), types.ContextKeyDynamicForwardHost).(string)
+	if !ok || hostport == "" {
+		return nil
+	}
+	// the addresses are cached by the resolver, so it is resolved each time to follow the dns changes
+	addr, err := lb.resolve(lbCtx.DownstreamContext(), hostport)
+	if err != nil {
+		lb.failure.Inc(1)
+		log.DefaultLogger.Errorf("[upstream] [cluster] dynamic forward proxy cluster %s resolve %s failed: %v", lb.info.Name(), hostport, err)
+		return nil
+	}
+	return lb.getOrCreateHost(hostport, addr.String())
+}
+
+func (lb *dynamicHostLoadBalancer) getOrCreateHost(hostport, addr string) types.Host {
+	now := time.Now()
+	lb.mux.Lock()
+	defer lb.mux.Unlock()
+	if h, ok := lb.hosts[addr]; ok {
+		h.lastUsed = now
+		lb.lru.MoveToBack(h.elem)
+		return h.host
+	}
+	lb.evictLocked(now)
+	hostname, _, _ := net.SplitHostPort(hostport)
+	h := &dynamicHost{
+		host: NewSimpleHost(v2.Host{
+			HostConfig: v2.HostConfig{
+				Address:  addr,
+				Hostname: hostname,
+			},
+		}, lb.info),
+		lastUsed: now,
+	}
+	h.elem = lb.lru.PushBack(addr)
+	lb.hosts[addr] = h
+	lb.created.Inc(1)
+	if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+		log.DefaultLogger.Debugf("[upstream] [cluster] dynamic forward proxy cluster %s creates host %s for %s", lb.info.Name(), addr, hostport)
+	}
+	return h.host
+}
+
+// evictLocked evicts the idle hosts, and the least recently used ones if the cache is full
+func (lb *dynamicHostLoadBalancer) evictLocked(now time.Time) {
+	for e := lb.lru.Front(); e != nil; e = lb.lru.Front() {
+		addr := e.Value.(string)
+		if len(lb.hosts) < lb.maxHosts && now.Sub(lb.hosts[addr].lastUsed) < lb.ttl {
+			return
+		}
+		lb.lru.Remove(e)
+		delete(lb.hosts, addr)
+		lb.evicted.Inc(1)
+	}
+}
+
+// IsExistsHosts is always true, the host is created by the request
+func (lb *dynamicHostLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return true
+}
+
+// HostNum is 1, the request has only one host to send to
+func (lb *dynamicHostLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return 1
+}
+
+func (lb *dynamicHostLoadBalancer) hostCount() int {
+	lb.mux.Lock()
+	defer lb.mux.Unlock()
+	return len(lb.hosts)
+}
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestDynamicForwardProxyCluster(t *testing.T) {
+	c := NewCluster(v2.Cluster{
+		Name:        "dynamic_forward_proxy",
+		ClusterType: v2.DYNAMIC_FORWARD_PROXY_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+		DynamicForwardProxy: &v2.DynamicForwardProxyConfig{
+			MaxHosts: 2,
+			HostTTL:  api.DurationConfig{Duration: time.Minute},
+		},
+	})
+	// the hosts config is ignored
+	c.UpdateHosts([]types.Host{})
+	snapshot := c.Snapshot()
+	lb := snapshot.LoadBalancer().(*dynamicHostLoadBalancer)
+	addrs := map[string]string{
+		"a.example.com:80": "10.0.0.1:80",
+		"b.example.com:80": "10.0.0.2:80",
+		"c.example.com:80": "10.0.0.3:80",
+		"d.example.com:80": "10.0.0.1:80",
+	}
+	lb.resolve = func(ctx context.Context, hostport string) (*net.TCPAddr, error) {
+		addr, ok := addrs[hostport]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return net.ResolveTCPAddr("tcp", addr)
+	}
+	choose := func(hostport string) types.Host {
+		ctx := context.Background()
+		if hostport != "" {
+			ctx = SetDynamicForwardHost(ctx, hostport)
+		}
+		return chooseHost(snapshot, &mockLbContext{ctx: ctx})
+	}
+	if snapshot.HostNum(nil) != 1 || !snapshot.IsExistsHosts(nil) {
+		t.Fatal("expected the dynamic host always exists")
+	}
+	if choose("") != nil || choose("unknown.example.com:80") != nil {
+		t.Fatal("expected no host chosen")
+	}
+	a := choose("a.example.com:80")
+	if a == nil || a.AddressString() != "10.0.0.1:80" || a.Hostname() != "a.example.com" {
+		t.Fatalf("unexpected host: %v", a)
+	}
+	// the hosts of the same address are shared
+	if choose("d.example.com:80") != a {
+		t.Fatal("expected the cached host")
+	}
+	choose("b.example.com:80")
+	// a is the most recently used, so b is evicted
+	choose("a.example.com:80")
+	choose("c.example.com:80")
+	if lb.hostCount() != 2 {
+		t.Fatalf("expected the hosts bounded, got %d", lb.hostCount())
+	}
+	if choose("a.example.com:80") != a {
+		t.Fatal("expected the recently used host kept")
+	}
+	if lb.created.Count() != 3 || lb.evicted.Count() != 1 {
+		t.Fatalf("unexpected stats: created %d, evicted %d", lb.created.Count(), lb.evicted.Count())
+	}
+}
+
+func TestDynamicHostIdleEvicted(t *testing.T) {
+	c := NewCluster(v2.Cluster{
+		Name:        "dynamic_forward_proxy_idle",
+		ClusterType: v2.DYNAMIC_FORWARD_PROXY_CLUSTER,
+	})
+	lb := c.Snapshot().LoadBalancer().(*dynamicHostLoadBalancer)
+	lb.getOrCreateHost("a.example.com:80", "10.0.0.1:80")
+	lb.hosts["10.0.0.1:80"].lastUsed = time.Now().Add(-defaultDynamicHostTTL)
+	lb.getOrCreateHost("b.example.com:80", "10.0.0.2:80")
+	if lb.hostCount() != 1 {
+		t.Fatalf("expected the idle host evicted, got %d hosts", lb.hostCount())
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"mosn.io/api"
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/dns"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/network"
 	"mosn.io/mosn/pkg/types"
@@ -39,6 +40,9 @@ type simpleHost struct {
 	tlsDisable    bool
 	weight        uint32
 	healthFlags   uint64
+	// locality and locality weight are pushed by the control plane
+	locality       string
+	localityWeight uint32
 }
 
 func NewSimpleHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
@@ -46,13 +50,15 @@ func NewSimpleHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
 	// pre resolve address
 	GetOrCreateAddr(config.Address)
 	return &simpleHost{
-		hostname:      config.Hostname,
-		addressString: config.Address,
-		clusterInfo:   clusterInfo,
-		stats:         newHostStats(clusterInfo.Name(), config.Address),
-		metaData:      config.MetaData,
-		tlsDisable:    config.TLSDisable,
-		weight:        config.Weight,
+		hostname:       config.Hostname,
+		addressString:  config.Address,
+		clusterInfo:    clusterInfo,
+		stats:          newHostStats(clusterInfo.Name(), config.Address),
+		metaData:       config.MetaData,
+		tlsDisable:     config.TLSDisable,
+		weight:         config.Weight,
+		locality:       config.Locality,
+		localityWeight: config.LocalityWeight,
 	}
 }
 
@@ -88,10 +94,12 @@ func (sh *simpleHost) Weight() uint32 {
 func (sh *simpleHost) Config() v2.Host {
 	return v2.Host{
 		HostConfig: v2.HostConfig{
-			Address:    sh.addressString,
-			Hostname:   sh.hostname,
-			TLSDisable: sh.tlsDisable,
-			Weight:     sh.weight,
+			Address:        sh.addressString,
+			Hostname:       sh.hostname,
+			TLSDisable:     sh.tlsDisable,
+			Weight:         sh.weight,
+			Locality:       sh.locality,
+			LocalityWeight: sh.localityWeight,
 		},
 		MetaData: sh.metaData,
 	}
@@ -103,12 +111,25 @@ func (sh *simpleHost) SupportTLS() bool {
 
 // types.Host Implement
 func (sh *simpleHost) CreateConnection(context context.Context) types.CreateConnectionData {
+	return sh.createConnection(nil)
+}
+
+// createConnection creates a connection on the established connection, dials a new one if it is nil
+func (sh *simpleHost) createConnection(established net.Conn) types.CreateConnectionData {
 	var tlsMng types.TLSContextManager
 	if !sh.tlsDisable {
 		tlsMng = sh.clusterInfo.TLSMng()
 	}
-	clientConn := network.NewClientConnection(nil, sh.clusterInfo.ConnectTimeout(), tlsMng, sh.Address(), nil)
+	var clientConn types.ClientConnection
+	if established != nil {
+		clientConn = network.NewClientConnectionWithConn(established, sh.clusterInfo.ConnectTimeout(), tlsMng, sh.Address(), nil)
+	} else {
+		clientConn = network.NewClientConnection(nil, sh.clusterInfo.ConnectTimeout(), tlsMng, sh.Address(), nil)
+	}
 	clientConn.SetBufferLimit(sh.clusterInfo.ConnBufferLimitBytes())
+	if info, ok := sh.clusterInfo.(*clusterInfo); ok {
+		network.SetBufferPolicy(clientConn, info.bufferPolicy)
+	}
 
 	return types.CreateConnectionData{
 		Connection: clientConn,
@@ -143,7 +164,7 @@ func GetOrCreateAddr(addrstr string) net.Addr {
 	if addr, ok := AddrStore.Load(addrstr); ok {
 		return addr.(net.Addr)
 	}
-	addr, err := net.ResolveTCPAddr("tcp", addrstr)
+	addr, err := dns.Default().ResolveTCPAddr(context.Background(), addrstr)
 	if err != nil {
 		log.DefaultLogger.Errorf("[upstream] resolve addr %s failed: %v", addrstr, err)
 		return nil
@@ -56,13 +56,35 @@ func (hs *hostSet) getRefreshNotify() []func(host types.Host) {
 	return hs.refreshNotify
 }
 
-func (hs *hostSet) resetHealthyHosts() {
-	healthyHosts := make([]types.Host, 0, len(hs.allHosts))
-	for _, h := range hs.allHosts {
+// degradedFlags contains the flags that a host is degraded but not failed
+const degradedFlags = types.DEGRADED_ACTIVE_HC
+
+// isDegraded returns true if the host is degraded and has no failed flags
+func isDegraded(host types.Host) bool {
+	flag := host.HealthFlag()
+	return flag != 0 && flag&^degradedFlags == 0
+}
+
+// availableHosts returns the hosts that can be chosen by the load balancer.
+// the degraded hosts are available only if the healthy hosts are not enough
+func availableHosts(hosts []types.Host) []types.Host {
+	healthyHosts := make([]types.Host, 0, len(hosts))
+	var degradedHosts []types.Host
+	for _, h := range hosts {
 		if h.Health() {
 			healthyHosts = append(healthyHosts, h)
+		} else if isDegraded(h) {
+			degradedHosts = append(degradedHosts, h)
 		}
 	}
+	if len(degradedHosts) > 0 && float64(len(healthyHosts))*overprovisioningFactor < float64(len(hosts)) {
+		healthyHosts = append(healthyHosts, degradedHosts...)
+	}
+	return healthyHosts
+}
+
+func (hs *hostSet) resetHealthyHosts() {
+	healthyHosts := availableHosts(hs.allHosts)
 	hs.mux.Lock()
 	defer hs.mux.Unlock()
 	hs.healthyHosts = healthyHosts
@@ -82,20 +104,16 @@ func (hs *hostSet) refreshHealthHost(host types.Host) {
 func (hs *hostSet) createSubset(predicate types.HostPredicate) types.HostSet {
 	allHosts := hs.Hosts()
 	var subHosts []types.Host
-	var healthyHosts []types.Host
 	for _, h := range allHosts {
 		if predicate(h) {
 			subHosts = append(subHosts, h)
-			if h.Health() {
-				healthyHosts = append(healthyHosts, h)
-			}
 		}
 	}
 	sub := &subHostSet{
-		predicate:    predicate,
-		allHosts:     subHosts,
-		healthyHosts: healthyHosts,
+		predicate: predicate,
+		allHosts:  subHosts,
 	}
+	sub.resetHealthyHosts()
 	// register refresh notify
 	hs.addRefreshNotify(sub.refresh)
 	return sub
@@ -147,12 +165,7 @@ func (sub *subHostSet) HealthyHosts() []types.Host {
 }
 
 func (sub *subHostSet) resetHealthyHosts() {
-	healthyHosts := make([]types.Host, 0, len(sub.allHosts))
-	for _, h := range sub.allHosts {
-		if h.Health() {
-			healthyHosts = append(healthyHosts, h)
-		}
-	}
+	healthyHosts := availableHosts(sub.allHosts)
 	sub.mux.Lock()
 	defer sub.mux.Unlock()
 	sub.healthyHosts = healthyHosts
@@ -117,3 +117,37 @@ func TestHostSetRefresh(t *testing.T) {
 		t.Fatal("health check state changed not expected")
 	}
 }
+
+func TestHostSetDegraded(t *testing.T) {
+	hs := &hostSet{}
+	var hosts []types.Host
+	for i := 0; i < 10; i++ {
+		hosts = append(hosts, newSimpleMockHost(fmt.Sprintf("127.0.0.1:%d", 10000+i), "v1"))
+	}
+	hs.setFinalHost(hosts)
+	// 8 of 10 hosts are healthy, the degraded hosts are not used
+	hosts[0].SetHealthFlag(types.DEGRADED_ACTIVE_HC)
+	hosts[1].SetHealthFlag(types.DEGRADED_ACTIVE_HC)
+	hs.refreshHealthHost(hosts[1])
+	if len(hs.HealthyHosts()) != 8 {
+		t.Fatalf("expected 8 healthy hosts, but got %d", len(hs.HealthyHosts()))
+	}
+	// 6 of 10 hosts are healthy, the degraded hosts are used
+	hosts[2].SetHealthFlag(types.DEGRADED_ACTIVE_HC)
+	hosts[3].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	hs.refreshHealthHost(hosts[3])
+	if len(hs.HealthyHosts()) != 9 {
+		t.Fatalf("expected 9 available hosts, but got %d", len(hs.HealthyHosts()))
+	}
+	// a degraded host that fails active health check is not used
+	hosts[2].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	hs.refreshHealthHost(hosts[2])
+	if len(hs.HealthyHosts()) != 8 {
+		t.Fatalf("expected 8 available hosts, but got %d", len(hs.HealthyHosts()))
+	}
+	for _, h := range hs.HealthyHosts() {
+		if h == hosts[2] || h == hosts[3] {
+			t.Fatalf("unexpected host %s", h.AddressString())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultLaneMetadataKey = "lane"
+
+// lane is a group of hosts with the same lane metadata
+type lane struct {
+	hosts types.HostSet
+	lb    types.LoadBalancer
+}
+
+func (l *lane) available() bool {
+	return l != nil && len(l.hosts.HealthyHosts()) > 0
+}
+
+// laneLoadBalancer routes a colored request to the hosts in its lane, and falls back to the base lane.
+// the hosts in a lane are chosen by the load balancer of the cluster lb type
+type laneLoadBalancer struct {
+	header   string
+	baseLane string
+	lanes    map[string]*lane
+	base     *lane
+	all      types.LoadBalancer
+	stats    types.Metrics
+}
+
+func newLaneLoadBalancer(info *clusterInfo, hosts *hostSet) types.LoadBalancer {
+	key := info.laneRouting.MetadataKey
+	if key == "" {
+		key = defaultLaneMetadataKey
+	}
+	baseLane := info.laneRouting.BaseLane
+	laneOf := func(host types.Host) string {
+		name := host.Metadata()[key]
+		if name == baseLane {
+			return ""
+		}
+		return name
+	}
+	lb := &laneLoadBalancer{
+		header:   info.laneRouting.Header,
+		baseLane: baseLane,
+		lanes:    make(map[string]*lane),
+		all:      NewLoadBalancer(info.lbType, hosts),
+		stats:    metrics.NewClusterStats(info.name),
+	}
+	for _, h := range hosts.Hosts() {
+		name := laneOf(h)
+		if _, ok := lb.lanes[name]; ok {
+			continue
+		}
+		sub := hosts.createSubset(func(host types.Host) bool {
+			return laneOf(host) == name
+		})
+		lb.lanes[name] = &lane{
+			hosts: sub,
+			lb:    NewLoadBalancer(info.lbType, sub),
+		}
+	}
+	lb.base = lb.lanes[""]
+	return lb
+}
+
+func (lb *laneLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	var color string
+	if context != nil && lb.header != "" {
+		if headers := context.DownstreamHeaders(); headers != nil {
+			color, _ = headers.Get(lb.header)
+		}
+	}
+	if color != "" && color != lb.baseLane {
+		if l := lb.lanes[color]; l.available() {
+			lb.stats.Counter(metrics.UpstreamLaneRequestTotal).Inc(1)
+			return l.lb.ChooseHost(context)
+		}
+		lb.stats.Counter(metrics.UpstreamLaneRequestFallback).Inc(1)
+	}
+	if lb.base.available() {
+		return lb.base.lb.ChooseHost(context)
+	}
+	// no base lane, the request is routed to all hosts
+	return lb.all.ChooseHost(context)
+}
+
+func (lb *laneLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return lb.all.IsExistsHosts(metadata)
+}
+
+func (lb *laneLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return lb.all.HostNum(metadata)
+}
@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/protocol"
+	"mosn.io/mosn/pkg/types"
+)
+
+func makeLaneHosts(lane string, size int) []types.Host {
+	info := &clusterInfo{name: "lane_test"}
+	prefix := lane
+	if prefix == "" {
+		prefix = "base"
+	}
+	hosts := make([]types.Host, 0, size)
+	for i := 0; i < size; i++ {
+		cfg := v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: fmt.Sprintf("%s:%d", prefix, 8080+i),
+			},
+		}
+		if lane != "" {
+			cfg.MetaData = api.Metadata{"lane": lane}
+		}
+		hosts = append(hosts, NewSimpleHost(cfg, info))
+	}
+	return hosts
+}
+
+func chooseLane(lb types.LoadBalancer, color string) string {
+	headers := protocol.CommonHeader{}
+	if color != "" {
+		headers["x-lane"] = color
+	}
+	h := lb.ChooseHost(&mockLbContext{header: headers})
+	if h == nil {
+		return "-"
+	}
+	return h.Metadata()["lane"]
+}
+
+func TestLaneLoadBalancer(t *testing.T) {
+	base := makeLaneHosts("", 2)
+	featureX := makeLaneHosts("feature-x", 2)
+	featureY := makeLaneHosts("feature-y", 1)
+	hs := &hostSet{}
+	hs.setFinalHost(append(append(base, featureX...), featureY...))
+	info := &clusterInfo{
+		name:        "lane_test",
+		lbType:      types.RoundRobin,
+		laneRouting: &v2.LaneRouting{Header: "x-lane"},
+	}
+	lb := newLaneLoadBalancer(info, hs)
+	stats := metrics.NewClusterStats("lane_test")
+	total := stats.Counter(metrics.UpstreamLaneRequestTotal).Count()
+	fallback := stats.Counter(metrics.UpstreamLaneRequestFallback).Count()
+
+	for i := 0; i < 4; i++ {
+		if lane := chooseLane(lb, "feature-x"); lane != "feature-x" {
+			t.Fatalf("expected feature-x, but got %s", lane)
+		}
+		// the requests without a color are routed to the base lane
+		if lane := chooseLane(lb, ""); lane != "" {
+			t.Fatalf("expected the base lane, but got %s", lane)
+		}
+	}
+	// the unknown lane falls back to the base lane
+	if lane := chooseLane(lb, "feature-z"); lane != "" {
+		t.Fatalf("expected the base lane, but got %s", lane)
+	}
+	// the lane without healthy hosts falls back to the base lane
+	featureY[0].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	hs.refreshHealthHost(featureY[0])
+	if lane := chooseLane(lb, "feature-y"); lane != "" {
+		t.Fatalf("expected the base lane, but got %s", lane)
+	}
+	if stats.Counter(metrics.UpstreamLaneRequestTotal).Count() != total+4 ||
+		stats.Counter(metrics.UpstreamLaneRequestFallback).Count() != fallback+2 {
+		t.Error("unexpected lane stats")
+	}
+	// no healthy host in the base lane, the request is routed to all hosts
+	for _, h := range base {
+		h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+		hs.refreshHealthHost(h)
+	}
+	if lane := chooseLane(lb, ""); lane != "feature-x" {
+		t.Fatalf("expected feature-x, but got %s", lane)
+	}
+}
+
+func TestLaneLoadBalancerBaseLane(t *testing.T) {
+	stable := makeLaneHosts("stable", 1)
+	featureX := makeLaneHosts("feature-x", 1)
+	hs := &hostSet{}
+	hs.setFinalHost(append(stable, featureX...))
+	info := &clusterInfo{
+		name:   "lane_test",
+		lbType: types.RoundRobin,
+		laneRouting: &v2.LaneRouting{
+			Header:   "x-lane",
+			BaseLane: "stable",
+		},
+	}
+	lb := newLaneLoadBalancer(info, hs)
+	for _, tc := range []struct {
+		color    string
+		expected string
+	}{
+		{"", "stable"},
+		{"stable", "stable"},
+		{"feature-x", "feature-x"},
+		{"feature-z", "stable"},
+	} {
+		if lane := chooseLane(lb, tc.color); lane != tc.expected {
+			t.Errorf("color %s expected %s, but got %s", tc.color, tc.expected, lane)
+		}
+	}
+}
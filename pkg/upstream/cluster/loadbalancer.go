@@ -27,6 +27,10 @@ import (
 	"mosn.io/mosn/pkg/types"
 )
 
+// overprovisioningFactor is same as envoy's default value, the healthy hosts are considered
+// enough until less than 1/1.4 (about 71%) of the hosts are healthy
+const overprovisioningFactor = 1.4
+
 // NewLoadBalancer can be register self defined type
 var lbFactories map[types.LoadBalancerType]func(types.HostSet) types.LoadBalancer
 
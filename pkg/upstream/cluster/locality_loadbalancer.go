@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"mosn.io/api"
+	"mosn.io/mosn/pkg/types"
+)
+
+func init() {
+	RegisterLBType(types.LocalityWeighted, newLocalityWeightedLoadBalancer)
+}
+
+type locality struct {
+	weight  uint32
+	total   int
+	healthy []types.Host
+	rrIndex uint32
+}
+
+// effectiveWeight scales the locality weight by the healthy ratio of the locality
+func (l *locality) effectiveWeight() float64 {
+	if l.weight == 0 || l.total == 0 || len(l.healthy) == 0 {
+		return 0
+	}
+	ratio := float64(len(l.healthy)) * overprovisioningFactor / float64(l.total)
+	if ratio > 1 {
+		ratio = 1
+	}
+	return float64(l.weight) * ratio
+}
+
+// localityWeightedLoadBalancer chooses a locality by the locality weights pushed by control plane,
+// and chooses a host in the locality by round robin.
+// if no locality weights are found, it works as a round robin load balancer
+type localityWeightedLoadBalancer struct {
+	mutex      sync.Mutex
+	rand       *rand.Rand
+	hosts      types.HostSet
+	localities []*locality
+	index      map[string]*locality
+	// healthy is the healthy hosts that the localities healthy hosts are built from
+	healthy  []types.Host
+	fallback types.LoadBalancer
+}
+
+func newLocalityWeightedLoadBalancer(hosts types.HostSet) types.LoadBalancer {
+	lb := &localityWeightedLoadBalancer{
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		hosts:    hosts,
+		index:    make(map[string]*locality),
+		fallback: rrFactory.newRoundRobinLoadBalancer(hosts),
+	}
+	for _, h := range hosts.Hosts() {
+		cfg := h.Config()
+		l, ok := lb.index[cfg.Locality]
+		if !ok {
+			l = &locality{}
+			lb.index[cfg.Locality] = l
+			lb.localities = append(lb.localities, l)
+		}
+		if l.weight == 0 {
+			l.weight = cfg.LocalityWeight
+		}
+		l.total++
+	}
+	return lb
+}
+
+func (lb *localityWeightedLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	healthy := lb.hosts.HealthyHosts()
+	if len(healthy) == 0 {
+		return nil
+	}
+	lb.mutex.Lock()
+	lb.refresh(healthy)
+	var total float64
+	for _, l := range lb.localities {
+		total += l.effectiveWeight()
+	}
+	if total == 0 {
+		lb.mutex.Unlock()
+		return lb.fallback.ChooseHost(context)
+	}
+	defer lb.mutex.Unlock()
+	target := lb.rand.Float64() * total
+	var chosen *locality
+	for _, l := range lb.localities {
+		w := l.effectiveWeight()
+		if w == 0 {
+			continue
+		}
+		chosen = l
+		if target < w {
+			break
+		}
+		target -= w
+	}
+	chosen.rrIndex++
+	return chosen.healthy[chosen.rrIndex%uint32(len(chosen.healthy))]
+}
+
+// refresh rebuilds the localities healthy hosts if the healthy hosts changed.
+// the host set makes a new slice when the healthy hosts changed, so compare the slice is enough
+func (lb *localityWeightedLoadBalancer) refresh(healthy []types.Host) {
+	if len(healthy) == len(lb.healthy) && &healthy[0] == &lb.healthy[0] {
+		return
+	}
+	for _, l := range lb.localities {
+		l.healthy = l.healthy[:0]
+	}
+	for _, h := range healthy {
+		if l, ok := lb.index[h.Config().Locality]; ok {
+			l.healthy = append(l.healthy, h)
+		}
+	}
+	lb.healthy = healthy
+}
+
+func (lb *localityWeightedLoadBalancer) IsExistsHosts(metadata api.MetadataMatchCriteria) bool {
+	return len(lb.hosts.Hosts()) > 0
+}
+
+func (lb *localityWeightedLoadBalancer) HostNum(metadata api.MetadataMatchCriteria) int {
+	return len(lb.hosts.Hosts())
+}
@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func makeLocalityHosts(locality string, weight uint32, size int) []types.Host {
+	info := &clusterInfo{name: "locality_test"}
+	hosts := make([]types.Host, 0, size)
+	for i := 0; i < size; i++ {
+		hosts = append(hosts, NewSimpleHost(v2.Host{
+			HostConfig: v2.HostConfig{
+				Address:        fmt.Sprintf("%s:%d", locality, 8080+i),
+				Locality:       locality,
+				LocalityWeight: weight,
+			},
+		}, info))
+	}
+	return hosts
+}
+
+func chooseLocalities(lb types.LoadBalancer, times int) map[string]int {
+	result := map[string]int{}
+	for i := 0; i < times; i++ {
+		h := lb.ChooseHost(nil)
+		if h == nil {
+			result[""]++
+			continue
+		}
+		result[h.Config().Locality]++
+	}
+	return result
+}
+
+func TestLocalityWeightedLoadBalancer(t *testing.T) {
+	hosts := append(makeLocalityHosts("cn/zone-a/", 1, 2), makeLocalityHosts("cn/zone-b/", 3, 4)...)
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	lb := NewLoadBalancer(types.LocalityWeighted, hs)
+	result := chooseLocalities(lb, 10000)
+	// the weights are 1:3, host numbers are ignored
+	if result["cn/zone-a/"] < 2000 || result["cn/zone-a/"] > 3000 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if result["cn/zone-a/"]+result["cn/zone-b/"] != 10000 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestLocalityWeightedLoadBalancerHealthy(t *testing.T) {
+	zoneA := makeLocalityHosts("cn/zone-a/", 1, 2)
+	zoneB := makeLocalityHosts("cn/zone-b/", 1, 4)
+	hs := &hostSet{}
+	hs.setFinalHost(append(zoneA, zoneB...))
+	lb := NewLoadBalancer(types.LocalityWeighted, hs)
+	// 3 of 4 healthy is still more than 1/1.4, keeps the full weight
+	zoneB[0].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	hs.refreshHealthHost(zoneB[0])
+	result := chooseLocalities(lb, 10000)
+	if result["cn/zone-b/"] < 4500 || result["cn/zone-b/"] > 5500 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	// 1 of 4 healthy, the effective weight is 0.35
+	zoneB[1].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	zoneB[2].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	hs.refreshHealthHost(zoneB[2])
+	result = chooseLocalities(lb, 10000)
+	if result["cn/zone-b/"] < 2200 || result["cn/zone-b/"] > 3000 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	// all the hosts in zone a are unhealthy
+	for _, h := range zoneA {
+		h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+	}
+	hs.refreshHealthHost(zoneA[0])
+	result = chooseLocalities(lb, 100)
+	if result["cn/zone-b/"] != 100 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	for _, h := range zoneB {
+		h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+	}
+	hs.refreshHealthHost(zoneB[0])
+	if h := lb.ChooseHost(nil); h != nil {
+		t.Fatalf("expected no host, but got %s", h.AddressString())
+	}
+}
+
+func TestLocalityWeightedLoadBalancerNoWeights(t *testing.T) {
+	hosts := append(makeLocalityHosts("cn/zone-a/", 0, 1), makeLocalityHosts("cn/zone-b/", 0, 3)...)
+	hs := &hostSet{}
+	hs.setFinalHost(hosts)
+	lb := NewLoadBalancer(types.LocalityWeighted, hs)
+	// works as round robin
+	result := chooseLocalities(lb, 400)
+	if result["cn/zone-a/"] != 100 || result["cn/zone-b/"] != 300 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
@@ -122,6 +122,9 @@ func (p *mockConnPool) SupportTLS() bool {
 func (p *mockConnPool) Shutdown() {
 }
 
+func (p *mockConnPool) Close() {
+}
+
 func init() {
 	network.RegisterNewPoolFactory(mockProtocol, func(h types.Host) types.ConnectionPool {
 		return &mockConnPool{
@@ -135,6 +138,7 @@ type mockLbContext struct {
 	types.LoadBalancerContext
 	mmc    api.MetadataMatchCriteria
 	header api.HeaderMap
+	ctx    context.Context
 }
 
 func newMockLbContext(m map[string]string) types.LoadBalancerContext {
@@ -164,5 +168,5 @@ func (ctx *mockLbContext) DownstreamHeaders() types.HeaderMap {
 }
 
 func (ctx *mockLbContext) DownstreamContext() context.Context {
-	return nil
+	return ctx.ctx
 }
@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/types"
+)
+
+// SetOverrideHost sets the address of the upstream host that the stream prefers, such as the host
+// of a sticky session. The load balancer is bypassed if the host is healthy in the cluster.
+func SetOverrideHost(ctx context.Context, addr string) context.Context {
+	return mosnctx.WithValue(ctx, types.ContextKeyUpstreamOverrideHost, addr)
+}
+
+// chooseHost returns the override host if it is a healthy host of the cluster,
+// otherwise the host is chosen by the load balancer, and the backed off hosts are avoided
+func chooseHost(snapshot types.ClusterSnapshot, lbCtx types.LoadBalancerContext) types.Host {
+	host := overrideHost(snapshot, lbCtx)
+	if host == nil {
+		host = chooseHostAvoidBackoff(snapshot, lbCtx)
+	}
+	if host != nil && isDegraded(host) {
+		snapshot.ClusterInfo().Stats().UpstreamRequestDegraded.Inc(1)
+	}
+	return host
+}
+
+func overrideHost(snapshot types.ClusterSnapshot, lbCtx types.LoadBalancerContext) types.Host {
+	if lbCtx == nil {
+		return nil
+	}
+	ctx := lbCtx.DownstreamContext()
+	if ctx == nil {
+		return nil
+	}
+	addr, ok := mosnctx.Get(ctx, types.ContextKeyUpstreamOverrideHost).(string)
+	if !ok || addr == "" {
+		return nil
+	}
+	for _, host := range snapshot.HostSet().HealthyHosts() {
+		if host.AddressString() == addr && (host.Health() || isDegraded(host)) {
+			return host
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestChooseOverrideHost(t *testing.T) {
+	cluster := NewCluster(v2.Cluster{
+		Name:   "test_override_host",
+		LbType: v2.LB_ROUNDROBIN,
+	})
+	hosts := makePool(4).MakeHosts(4, nil)
+	cluster.UpdateHosts(hosts)
+	snapshot := cluster.Snapshot()
+
+	target := hosts[2].AddressString()
+	lbCtx := &mockLbContext{ctx: SetOverrideHost(context.Background(), target)}
+	for i := 0; i < 4; i++ {
+		if host := chooseHost(snapshot, lbCtx); host.AddressString() != target {
+			t.Fatalf("expected override host %s, but got %s", target, host.AddressString())
+		}
+	}
+	// the load balancer is used if the override host is unhealthy
+	hosts[2].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	cluster.UpdateHosts(hosts)
+	snapshot = cluster.Snapshot()
+	for i := 0; i < 4; i++ {
+		if host := chooseHost(snapshot, lbCtx); host == nil || host.AddressString() == target {
+			t.Fatal("expected host chosen by load balancer")
+		}
+	}
+	// the load balancer is used if the override host is not in the cluster
+	lbCtx = &mockLbContext{ctx: SetOverrideHost(context.Background(), "10.0.0.1:80")}
+	if host := chooseHost(snapshot, lbCtx); host == nil {
+		t.Fatal("expected host chosen by load balancer")
+	}
+	if host := chooseHost(snapshot, &mockLbContext{}); host == nil {
+		t.Fatal("expected host chosen by load balancer")
+	}
+}
@@ -0,0 +1,282 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultSLOTarget      = 0.999
+	defaultSLOMinRequests = 10
+	// sloBuckets is the number of buckets in a sliding window,
+	// the rates are evaluated when the current bucket is rotated
+	sloBuckets = 60
+	// sloClasses are the code classes: the requests get no response and 1xx~5xx
+	sloClasses        = 6
+	sloWebhookTimeout = 3 * time.Second
+)
+
+var defaultSLOWindows = []time.Duration{5 * time.Minute, time.Hour}
+
+// sloAlert is posted to the webhook when the burn rate of a window exceeds its threshold
+type sloAlert struct {
+	Cluster     string    `json:"cluster"`
+	Window      string    `json:"window"`
+	SuccessRate float64   `json:"success_rate"`
+	BurnRate    float64   `json:"burn_rate"`
+	Threshold   float64   `json:"threshold"`
+	Time        time.Time `json:"time"`
+}
+
+var sloWebhookClient = &http.Client{Timeout: sloWebhookTimeout}
+
+// sloNotify notifies the alert asynchronously, it can be replaced in tests
+var sloNotify = func(webhook string, alert sloAlert) {
+	utils.GoWithRecover(func() {
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return
+		}
+		resp, err := sloWebhookClient.Post(webhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.DefaultLogger.Errorf("[upstream] [slo] notify webhook %s failed: %v", webhook, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			log.DefaultLogger.Errorf("[upstream] [slo] notify webhook %s failed, status code: %d", webhook, resp.StatusCode)
+		}
+	}, nil)
+}
+
+// sloTracker is an implementation of types.SLOTracker
+type sloTracker struct {
+	cluster string
+	target  float64
+	webhook string
+	windows []*sloWindow
+	now     func() time.Time
+}
+
+type sloBucket [sloClasses]uint64
+
+type sloWindow struct {
+	name        string
+	bucketSize  time.Duration
+	threshold   float64
+	minRequests uint64
+
+	mux          sync.Mutex
+	buckets      [sloBuckets]sloBucket
+	current      int
+	currentStart time.Time
+	alerting     bool
+
+	successRate gometrics.Gauge
+	burnRate    gometrics.Gauge
+	responses   [sloClasses]gometrics.Gauge
+}
+
+func newSLOTracker(cluster string, cfg *v2.SLOConfig) *sloTracker {
+	if cfg == nil {
+		return nil
+	}
+	target := cfg.Target
+	if target <= 0 || target >= 1 {
+		log.DefaultLogger.Warnf("[upstream] [slo] cluster %s slo target %v is invalid, use default %v", cluster, target, defaultSLOTarget)
+		target = defaultSLOTarget
+	}
+	tracker := &sloTracker{
+		cluster: cluster,
+		target:  target,
+		webhook: cfg.Webhook,
+		now:     time.Now,
+	}
+	now := tracker.now()
+	if len(cfg.Windows) == 0 {
+		for _, d := range defaultSLOWindows {
+			tracker.windows = append(tracker.windows, newSLOWindow(cluster, d, 0, 0, now))
+		}
+		return tracker
+	}
+	for _, w := range cfg.Windows {
+		if w.Window.Duration <= 0 {
+			log.DefaultLogger.Warnf("[upstream] [slo] cluster %s slo window %v is invalid, ignore it", cluster, w.Window.Duration)
+			continue
+		}
+		tracker.windows = append(tracker.windows, newSLOWindow(cluster, w.Window.Duration, w.BurnRateThreshold, w.MinRequests, now))
+	}
+	return tracker
+}
+
+func newSLOWindow(cluster string, d time.Duration, threshold float64, minRequests uint64, now time.Time) *sloWindow {
+	if minRequests == 0 {
+		minRequests = defaultSLOMinRequests
+	}
+	bucketSize := d / sloBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	name := sloWindowName(d)
+	s := metrics.NewSLOStats(cluster, name)
+	w := &sloWindow{
+		name:         name,
+		bucketSize:   bucketSize,
+		threshold:    threshold,
+		minRequests:  minRequests,
+		currentStart: now,
+		successRate:  s.Gauge(metrics.UpstreamSLOSuccessRate),
+		burnRate:     s.Gauge(metrics.UpstreamSLOBurnRate),
+	}
+	for class := range w.responses {
+		w.responses[class] = s.Gauge(metrics.UpstreamSLOResponsePrefix + sloClassName(class))
+	}
+	w.successRate.Update(10000)
+	return w
+}
+
+// sloWindowName formats the window such as 5m and 1h
+func sloWindowName(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return d.String()
+}
+
+func sloClassName(class int) string {
+	if class == 0 {
+		return "reset"
+	}
+	return fmt.Sprintf("%dxx", class)
+}
+
+// sloClass returns the code class, the unknown codes are treated as no response
+func sloClass(code int) int {
+	class := code / 100
+	if class < 1 || class >= sloClasses {
+		return 0
+	}
+	return class
+}
+
+func (t *sloTracker) Record(code int) {
+	class := sloClass(code)
+	now := t.now()
+	for _, w := range t.windows {
+		if alert, ok := w.record(class, now, t.target); ok {
+			alert.Cluster = t.cluster
+			log.DefaultLogger.Warnf("[upstream] [slo] cluster %s burn rate %.2f of window %s exceeds threshold %.2f, success rate: %.4f",
+				t.cluster, alert.BurnRate, alert.Window, alert.Threshold, alert.SuccessRate)
+			if t.webhook != "" {
+				sloNotify(t.webhook, alert)
+			}
+		}
+	}
+}
+
+// record counts the class in the current bucket, and evaluates the window if the bucket is rotated.
+// returns true if the window starts alerting
+func (w *sloWindow) record(class int, now time.Time, target float64) (sloAlert, bool) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	rotated := w.rotate(now)
+	w.buckets[w.current][class]++
+	if !rotated {
+		return sloAlert{}, false
+	}
+	return w.evaluate(now, target)
+}
+
+func (w *sloWindow) rotate(now time.Time) bool {
+	elapsed := now.Sub(w.currentStart)
+	if elapsed < w.bucketSize {
+		return false
+	}
+	n := elapsed / w.bucketSize
+	w.currentStart = w.currentStart.Add(n * w.bucketSize)
+	if n > sloBuckets {
+		n = sloBuckets
+	}
+	for i := 0; i < int(n); i++ {
+		w.current = (w.current + 1) % sloBuckets
+		w.buckets[w.current] = sloBucket{}
+	}
+	return true
+}
+
+func (w *sloWindow) evaluate(now time.Time, target float64) (sloAlert, bool) {
+	var sum sloBucket
+	for i := range w.buckets {
+		for class, count := range w.buckets[i] {
+			sum[class] += count
+		}
+	}
+	var total uint64
+	for class, count := range sum {
+		total += count
+		w.responses[class].Update(int64(count))
+	}
+	successRate, burnRate := 1.0, 0.0
+	if total > 0 {
+		errorRate := float64(sum[0]+sum[5]) / float64(total)
+		successRate = 1 - errorRate
+		burnRate = errorRate / (1 - target)
+	}
+	w.successRate.Update(int64(successRate * 10000))
+	w.burnRate.Update(int64(burnRate * 100))
+
+	if w.threshold <= 0 {
+		return sloAlert{}, false
+	}
+	exceeded := total >= w.minRequests && burnRate > w.threshold
+	if !exceeded {
+		w.alerting = false
+		return sloAlert{}, false
+	}
+	if w.alerting {
+		return sloAlert{}, false
+	}
+	w.alerting = true
+	return sloAlert{
+		Window:      w.name,
+		SuccessRate: successRate,
+		BurnRate:    burnRate,
+		Threshold:   w.threshold,
+		Time:        now,
+	}, true
+}
+
+var _ types.SLOTracker = (*sloTracker)(nil)
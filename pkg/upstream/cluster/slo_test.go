@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestSLOTracker(t *testing.T) {
+	var alerts []sloAlert
+	notify := sloNotify
+	sloNotify = func(webhook string, alert sloAlert) {
+		if webhook != "http://127.0.0.1/alert" {
+			t.Errorf("unexpected webhook: %s", webhook)
+		}
+		alerts = append(alerts, alert)
+	}
+	defer func() {
+		sloNotify = notify
+	}()
+
+	info := newSimpleCluster(v2.Cluster{
+		Name: "test_slo",
+		SLO: &v2.SLOConfig{
+			Target: 0.99,
+			Windows: []v2.SLOWindow{
+				{
+					Window:            api.DurationConfig{Duration: time.Minute},
+					BurnRateThreshold: 10,
+				},
+			},
+			Webhook: "http://127.0.0.1/alert",
+		},
+	}).Snapshot().ClusterInfo()
+	sloInfo, ok := info.(types.SLOClusterInfo)
+	if !ok || sloInfo.SLOTracker() == nil {
+		t.Fatal("cluster should track slo")
+	}
+	tracker := sloInfo.SLOTracker().(*sloTracker)
+	now := time.Now()
+	tracker.now = func() time.Time {
+		return now
+	}
+	window := tracker.windows[0]
+	if window.name != "1m" || window.bucketSize != time.Second {
+		t.Fatalf("unexpected window: %s, bucket size: %v", window.name, window.bucketSize)
+	}
+	// 80 success, 10 5xx and 10 resets
+	for i := 0; i < 80; i++ {
+		tracker.Record(200)
+	}
+	for i := 0; i < 10; i++ {
+		tracker.Record(503)
+		tracker.Record(0)
+	}
+	if window.successRate.Value() != 10000 {
+		t.Fatal("window should not be evaluated before the bucket rotated")
+	}
+	now = now.Add(time.Second)
+	tracker.Record(200)
+	// 20 failed of 101 requests, burn rate is about 19.8
+	if window.successRate.Value() != 8019 || window.burnRate.Value() != 1980 {
+		t.Fatalf("unexpected rates, success rate: %d, burn rate: %d", window.successRate.Value(), window.burnRate.Value())
+	}
+	if window.responses[2].Value() != 81 || window.responses[5].Value() != 10 || window.responses[0].Value() != 10 {
+		t.Fatal("unexpected response counts")
+	}
+	if len(alerts) != 1 || alerts[0].Cluster != "test_slo" || alerts[0].Window != "1m" {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+	// still exceeded, no more alerts
+	now = now.Add(time.Second)
+	tracker.Record(200)
+	if len(alerts) != 1 {
+		t.Fatalf("alert should be notified once, got %d", len(alerts))
+	}
+	// the failed requests slide out of the window
+	now = now.Add(time.Minute)
+	tracker.Record(200)
+	if window.successRate.Value() != 10000 || window.burnRate.Value() != 0 || window.responses[2].Value() != 1 {
+		t.Fatalf("unexpected rates after sliding, success rate: %d, burn rate: %d", window.successRate.Value(), window.burnRate.Value())
+	}
+	if window.alerting {
+		t.Fatal("window should recover")
+	}
+}
+
+func TestSLOTrackerDefault(t *testing.T) {
+	tracker := newSLOTracker("test_slo_default", &v2.SLOConfig{Target: 2})
+	if tracker.target != defaultSLOTarget || len(tracker.windows) != 2 {
+		t.Fatalf("unexpected default tracker, target: %v, windows: %d", tracker.target, len(tracker.windows))
+	}
+	if tracker.windows[0].name != "5m" || tracker.windows[1].name != "1h" {
+		t.Fatalf("unexpected default windows: %s, %s", tracker.windows[0].name, tracker.windows[1].name)
+	}
+	if newSLOTracker("test_slo_none", nil) != nil {
+		t.Fatal("no slo configured, expected nil tracker")
+	}
+	if sloClass(0) != 0 || sloClass(600) != 0 || sloClass(404) != 4 {
+		t.Fatal("unexpected code class")
+	}
+}
@@ -0,0 +1,315 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http2"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/event"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+var errListenerClosed = errors.New("static response server is closed")
+
+// staticResponseCluster sends the requests to a local server that serves the static responses,
+// the local server is kept when the cluster is updated, and stopped when the cluster is removed
+type staticResponseCluster struct {
+	*simpleCluster
+}
+
+func newStaticResponseCluster(clusterConfig v2.Cluster) *staticResponseCluster {
+	server, err := startStaticResponseServer(clusterConfig.Name, clusterConfig.StaticResponse)
+	if err != nil {
+		log.DefaultLogger.Errorf("[upstream] [cluster] start static response server of cluster %s failed: %v", clusterConfig.Name, err)
+		return nil
+	}
+	sc := newSimpleCluster(clusterConfig)
+	sc.UpdateHosts([]types.Host{
+		NewSimpleHost(v2.Host{
+			HostConfig: v2.HostConfig{
+				Address: server.Addr(),
+			},
+		}, sc.info),
+	})
+	return &staticResponseCluster{
+		simpleCluster: sc,
+	}
+}
+
+func (c *staticResponseCluster) UpdateHosts(hosts []types.Host) {
+	if len(hosts) > 0 && !(len(hosts) == 1 && hosts[0].AddressString() == c.localAddr()) {
+		log.DefaultLogger.Warnf("[upstream] [cluster] static response cluster %s ignores the %d hosts updated", c.info.name, len(hosts))
+	}
+}
+
+func (c *staticResponseCluster) localAddr() string {
+	if hosts := c.hostSet.Hosts(); len(hosts) > 0 {
+		return hosts[0].AddressString()
+	}
+	return ""
+}
+
+var staticResponseServers = struct {
+	sync.Mutex
+	servers map[string]*staticResponseServer
+	once    sync.Once
+}{
+	servers: make(map[string]*staticResponseServer),
+}
+
+// startStaticResponseServer starts the local server of the cluster, or updates the config if it is started
+func startStaticResponseServer(name string, config *v2.StaticResponseConfig) (*staticResponseServer, error) {
+	staticResponseServers.once.Do(func() {
+		event.Subscribe(func(e event.Event) {
+			onStaticResponseClusterRemoved(e.Source)
+		}, event.ClusterRemoved)
+	})
+	if config == nil {
+		config = &v2.StaticResponseConfig{}
+	}
+	staticResponseServers.Lock()
+	defer staticResponseServers.Unlock()
+	if s, ok := staticResponseServers.servers[name]; ok {
+		s.config.Store(config)
+		return s, nil
+	}
+	s, err := newStaticResponseServer(config)
+	if err != nil {
+		return nil, err
+	}
+	staticResponseServers.servers[name] = s
+	return s, nil
+}
+
+// onStaticResponseClusterRemoved stops the local server, unless the cluster is added again
+func onStaticResponseClusterRemoved(name string) {
+	clusterMangerInstance.instanceMutex.Lock()
+	exists := clusterMangerInstance.clusterManager != nil && clusterMangerInstance.ClusterExist(name)
+	clusterMangerInstance.instanceMutex.Unlock()
+	if exists {
+		return
+	}
+	staticResponseServers.Lock()
+	defer staticResponseServers.Unlock()
+	if s, ok := staticResponseServers.servers[name]; ok {
+		s.Close()
+		delete(staticResponseServers.servers, name)
+	}
+}
+
+// stopStaticResponseServers stops all the local servers
+func stopStaticResponseServers() {
+	staticResponseServers.Lock()
+	defer staticResponseServers.Unlock()
+	for name, s := range staticResponseServers.servers {
+		s.Close()
+		delete(staticResponseServers.servers, name)
+	}
+}
+
+// staticResponseServer serves both http1 and http2 on a loopback address
+type staticResponseServer struct {
+	listener net.Listener
+	config   atomic.Value // *v2.StaticResponseConfig
+	http1    *http.Server
+	http1Ln  *connListener
+	http2    *http2.Server
+}
+
+func newStaticResponseServer(config *v2.StaticResponseConfig) (*staticResponseServer, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &staticResponseServer{
+		listener: l,
+		http1Ln:  newConnListener(l.Addr()),
+		http2:    &http2.Server{},
+	}
+	s.config.Store(config)
+	s.http1 = &http.Server{Handler: s}
+	go s.http1.Serve(s.http1Ln)
+	go s.accept()
+	return s, nil
+}
+
+func (s *staticResponseServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *staticResponseServer) Close() {
+	s.listener.Close()
+	s.http1.Close()
+}
+
+func (s *staticResponseServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn serves the connection by http2 if it starts with the http2 client preface, by http1 otherwise
+func (s *staticResponseServer) serveConn(conn net.Conn) {
+	br := bufio.NewReader(conn)
+	prefix, err := br.Peek(3)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	bc := &bufferedConn{Conn: conn, reader: br}
+	if string(prefix) == http2.ClientPreface[:3] {
+		s.http2.ServeConn(bc, &http2.ServeConnOpts{Handler: s})
+		conn.Close()
+		return
+	}
+	if !s.http1Ln.push(bc) {
+		conn.Close()
+	}
+}
+
+func (s *staticResponseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	config := s.config.Load().(*v2.StaticResponseConfig)
+	delay := config.Latency.Duration
+	if config.Jitter.Duration > 0 {
+		delay += time.Duration(rand.Int63n(int64(config.Jitter.Duration)))
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	for _, resp := range config.Responses {
+		if !strings.HasPrefix(r.URL.Path, resp.Prefix) {
+			continue
+		}
+		for k, v := range resp.Headers {
+			w.Header().Set(k, v)
+		}
+		status := resp.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(resp.Body)))
+		w.WriteHeader(status)
+		w.Write([]byte(resp.Body))
+		return
+	}
+	echo(w, r)
+}
+
+// echoedRequest is the json response of the echoed request
+type echoedRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Host    string            `json:"host"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body,omitempty"`
+}
+
+func echo(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	req := echoedRequest{
+		Method:  r.Method,
+		Path:    r.URL.RequestURI(),
+		Host:    r.Host,
+		Headers: make(map[string]string, len(r.Header)),
+		Body:    string(body),
+	}
+	for k, v := range r.Header {
+		req.Headers[k] = strings.Join(v, ",")
+	}
+	data, _ := json.Marshal(req)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// bufferedConn reads the bytes peeked before
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// connListener is a listener of the connections pushed into it
+type connListener struct {
+	addr   net.Addr
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{
+		addr:   addr,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *connListener) push(conn net.Conn) bool {
+	select {
+	case l.conns <- conn:
+		return true
+	case <-l.closed:
+		return false
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errListenerClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
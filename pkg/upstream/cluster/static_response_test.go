@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestStaticResponseCluster(t *testing.T) {
+	defer stopStaticResponseServers()
+	config := v2.Cluster{
+		Name:        "static_response",
+		ClusterType: v2.STATIC_RESPONSE_CLUSTER,
+		LbType:      v2.LB_ROUNDROBIN,
+		StaticResponse: &v2.StaticResponseConfig{
+			Responses: []v2.StaticResponse{
+				{
+					Prefix:  "/canned",
+					Status:  http.StatusCreated,
+					Headers: map[string]string{"X-Canned": "true"},
+					Body:    "canned",
+				},
+			},
+			Latency: api.DurationConfig{Duration: 10 * time.Millisecond},
+		},
+	}
+	c := NewCluster(config)
+	hosts := c.Snapshot().HostSet().Hosts()
+	if len(hosts) != 1 {
+		t.Fatalf("expected a local host, but got %d", len(hosts))
+	}
+	addr := hosts[0].AddressString()
+	// the configured hosts are ignored
+	c.UpdateHosts([]types.Host{NewSimpleHost(v2.Host{HostConfig: v2.HostConfig{Address: "127.0.0.1:1"}}, c.Snapshot().ClusterInfo())})
+	if hosts := c.Snapshot().HostSet().Hosts(); len(hosts) != 1 || hosts[0].AddressString() != addr {
+		t.Fatal("the hosts of static response cluster should not be updated")
+	}
+
+	start := time.Now()
+	resp, err := http.Get("http://" + addr + "/canned/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Canned") != "true" || string(body) != "canned" {
+		t.Errorf("unexpected canned response %d %v %s", resp.StatusCode, resp.Header, body)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("expected the response delayed")
+	}
+
+	h2c := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	resp, err = h2c.Post("http://"+addr+"/echo?a=b", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	echoed := echoedRequest{}
+	err = json.NewDecoder(resp.Body).Decode(&echoed)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProtoMajor != 2 || echoed.Method != "POST" || echoed.Path != "/echo?a=b" || echoed.Body != "hello" ||
+		echoed.Headers["Content-Type"] != "text/plain" {
+		t.Errorf("unexpected echoed request %d %+v", resp.ProtoMajor, echoed)
+	}
+
+	// the local server is kept when the cluster is updated
+	config.StaticResponse = nil
+	c = NewCluster(config)
+	if hosts := c.Snapshot().HostSet().Hosts(); len(hosts) != 1 || hosts[0].AddressString() != addr {
+		t.Fatal("the local server should be kept")
+	}
+	resp, err = http.Get("http://" + addr + "/canned/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected the request echoed after the config updated, but got %d", resp.StatusCode)
+	}
+
+	onStaticResponseClusterRemoved(config.Name)
+	if _, err := http.Get("http://" + addr + "/"); err == nil {
+		t.Error("the local server should be stopped after the cluster is removed")
+	}
+}
@@ -79,5 +79,9 @@ func newClusterStats(clustername string) types.ClusterStats {
 		UpstreamResponseFailed:                         s.Counter(metrics.UpstreamResponseFailed),
 		LBSubSetsFallBack:                              s.Counter(metrics.UpstreamLBSubSetsFallBack),
 		LBSubsetsCreated:                               s.Gauge(metrics.UpstreamLBSubsetsCreated),
+		UpstreamRequestDegraded:                        s.Counter(metrics.UpstreamRequestDegraded),
+		UpstreamHostBackoff:                            s.Counter(metrics.UpstreamHostBackoff),
+		UpstreamRequestFailover:                        s.Counter(metrics.UpstreamRequestFailover),
+		UpstreamRequestFailoverReceived:                s.Counter(metrics.UpstreamRequestFailoverReceived),
 	}
 }
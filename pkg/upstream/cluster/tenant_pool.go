@@ -0,0 +1,307 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	gotls "crypto/tls"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	mosnctx "mosn.io/mosn/pkg/context"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/mosn/pkg/types"
+)
+
+const defaultMaxTenants = 128
+
+// tenantPool isolates the connection pools of a cluster by the downstream tenant
+type tenantPool struct {
+	cluster        string
+	header         string
+	fromCert       bool
+	maxTenants     int
+	allowed        map[string]bool
+	maxConnections uint64
+	maxRequests    uint64
+	idleTimeout    time.Duration
+	overflow       gometrics.Counter
+	mux            sync.RWMutex
+	tenants        map[string]*tenantStats
+}
+
+type tenantStats struct {
+	poolCreated gometrics.Counter
+	request     gometrics.Counter
+	// lastUsed is the unix nano time that the tenant is used
+	lastUsed int64
+	// resources limits the tenant, nil means the tenant is only limited by the cluster
+	resources *tenantResources
+}
+
+func newTenantPool(cluster string, cfg *v2.TenantPool) *tenantPool {
+	if cfg == nil || (cfg.Header == "" && !cfg.FromCert) {
+		return nil
+	}
+	maxTenants := int(cfg.MaxTenants)
+	if maxTenants == 0 {
+		maxTenants = defaultMaxTenants
+	}
+	tp := &tenantPool{
+		cluster:        cluster,
+		header:         cfg.Header,
+		fromCert:       cfg.FromCert,
+		maxTenants:     maxTenants,
+		maxConnections: uint64(cfg.MaxConnections),
+		maxRequests:    uint64(cfg.MaxRequests),
+		idleTimeout:    cfg.IdleTimeout.Duration,
+		overflow:       metrics.NewClusterStats(cluster).Counter(metrics.UpstreamTenantPoolOverflow),
+		tenants:        make(map[string]*tenantStats),
+	}
+	if len(cfg.AllowedTenants) > 0 {
+		tp.allowed = make(map[string]bool, len(cfg.AllowedTenants))
+		for _, tenant := range cfg.AllowedTenants {
+			tp.allowed[tenant] = true
+		}
+	}
+	return tp
+}
+
+// tenant returns the tenant of the request and its stats, and the idle tenants evicted for the new tenant.
+// an empty tenant means the request uses the shared pools
+func (tp *tenantPool) tenant(lbCtx types.LoadBalancerContext) (string, *tenantStats, []string) {
+	tenant := tp.tenantID(lbCtx)
+	if tenant == "" {
+		return "", nil, nil
+	}
+	if tp.allowed != nil && !tp.allowed[tenant] {
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[upstream] [tenant pool] cluster %s tenant %s is not allowed, uses the shared pools", tp.cluster, tenant)
+		}
+		return "", nil, nil
+	}
+	now := time.Now().UnixNano()
+	tp.mux.RLock()
+	stats, ok := tp.tenants[tenant]
+	tp.mux.RUnlock()
+	if ok {
+		atomic.StoreInt64(&stats.lastUsed, now)
+		return tenant, stats, nil
+	}
+	tp.mux.Lock()
+	defer tp.mux.Unlock()
+	if stats, ok := tp.tenants[tenant]; ok {
+		atomic.StoreInt64(&stats.lastUsed, now)
+		return tenant, stats, nil
+	}
+	var evicted []string
+	if len(tp.tenants) >= tp.maxTenants {
+		evicted = tp.evictIdle(now)
+	}
+	if len(tp.tenants) >= tp.maxTenants {
+		tp.overflow.Inc(1)
+		if log.DefaultLogger.GetLogLevel() >= log.DEBUG {
+			log.DefaultLogger.Debugf("[upstream] [tenant pool] cluster %s tenants overflow, tenant %s uses the shared pools", tp.cluster, tenant)
+		}
+		return "", nil, evicted
+	}
+	s := metrics.NewTenantStats(tp.cluster, tenant)
+	stats = &tenantStats{
+		poolCreated: s.Counter(metrics.UpstreamTenantPoolCreated),
+		request:     s.Counter(metrics.UpstreamTenantRequestTotal),
+		lastUsed:    now,
+	}
+	if tp.maxConnections > 0 || tp.maxRequests > 0 {
+		stats.resources = &tenantResources{
+			connections: &resource{max: tp.maxConnections},
+			requests:    &resource{max: tp.maxRequests},
+		}
+	}
+	tp.tenants[tenant] = stats
+	return tenant, stats, evicted
+}
+
+// evictIdle removes the tenants not used in the idle timeout, must be called with the lock held
+func (tp *tenantPool) evictIdle(now int64) []string {
+	if tp.idleTimeout <= 0 {
+		return nil
+	}
+	var evicted []string
+	for tenant, stats := range tp.tenants {
+		if now-atomic.LoadInt64(&stats.lastUsed) >= int64(tp.idleTimeout) {
+			delete(tp.tenants, tenant)
+			evicted = append(evicted, tenant)
+		}
+	}
+	if len(evicted) > 0 && log.DefaultLogger.GetLogLevel() >= log.INFO {
+		log.DefaultLogger.Infof("[upstream] [tenant pool] cluster %s evicts the idle tenants: %v", tp.cluster, evicted)
+	}
+	return evicted
+}
+
+// poolHost returns the host that the pools of the tenant are created with,
+// the host reports the resources of the tenant to the pools
+func (s *tenantStats) poolHost(host types.Host) types.Host {
+	if s == nil || s.resources == nil {
+		return host
+	}
+	return &tenantHost{
+		Host: host,
+		info: &tenantClusterInfo{
+			ClusterInfo: host.ClusterInfo(),
+			resources: &tenantResourceManager{
+				ResourceManager: host.ClusterInfo().ResourceManager(),
+				tenant:          s.resources,
+			},
+		},
+	}
+}
+
+// tenantResources is the resources limit of a tenant
+type tenantResources struct {
+	connections *resource
+	requests    *resource
+}
+
+type tenantHost struct {
+	types.Host
+	info types.ClusterInfo
+}
+
+func (h *tenantHost) ClusterInfo() types.ClusterInfo {
+	return h.info
+}
+
+type tenantClusterInfo struct {
+	types.ClusterInfo
+	resources types.ResourceManager
+}
+
+func (ci *tenantClusterInfo) ResourceManager() types.ResourceManager {
+	return ci.resources
+}
+
+// tenantResourceManager limits the connections and requests by the tenant,
+// the requests are limited by the cluster too
+type tenantResourceManager struct {
+	types.ResourceManager
+	tenant *tenantResources
+}
+
+func (rm *tenantResourceManager) Connections() types.Resource {
+	if rm.tenant.connections.Max() == 0 {
+		return rm.ResourceManager.Connections()
+	}
+	return rm.tenant.connections
+}
+
+func (rm *tenantResourceManager) Requests() types.Resource {
+	if rm.tenant.requests.Max() == 0 {
+		return rm.ResourceManager.Requests()
+	}
+	return multiResource{rm.tenant.requests, rm.ResourceManager.Requests()}
+}
+
+// multiResource is a resource can be created only if all the resources can be created
+type multiResource []types.Resource
+
+func (r multiResource) CanCreate() bool {
+	for _, res := range r {
+		if !res.CanCreate() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r multiResource) Increase() {
+	for _, res := range r {
+		res.Increase()
+	}
+}
+
+func (r multiResource) Decrease() {
+	for _, res := range r {
+		res.Decrease()
+	}
+}
+
+// Max returns the max of the first resource
+func (r multiResource) Max() uint64 {
+	return r[0].Max()
+}
+
+func (tp *tenantPool) tenantID(lbCtx types.LoadBalancerContext) string {
+	if tp.header != "" {
+		if headers := lbCtx.DownstreamHeaders(); headers != nil {
+			if tenant, ok := headers.Get(tp.header); ok && tenant != "" {
+				return tenant
+			}
+		}
+	}
+	if tp.fromCert {
+		return certTenant(lbCtx.DownstreamContext())
+	}
+	return ""
+}
+
+type tlsConnectionState interface {
+	ConnectionState() gotls.ConnectionState
+}
+
+// certTenant returns the common name of the downstream client certificate
+func certTenant(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	conn, ok := mosnctx.Get(ctx, types.ContextKeyDownstreamConnection).(api.Connection)
+	if !ok {
+		return ""
+	}
+	tlsConn, ok := conn.RawConn().(tlsConnectionState)
+	if !ok {
+		return ""
+	}
+	state := tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return state.PeerCertificates[0].Subject.CommonName
+}
+
+// tenantPoolKey returns the connection pool key of the host for the tenant
+func tenantPoolKey(addr string, tenant string) string {
+	if tenant == "" {
+		return addr
+	}
+	return addr + "#" + tenant
+}
+
+// poolKeyAddr returns the host address of the connection pool key
+func poolKeyAddr(key string) string {
+	if i := strings.IndexByte(key, '#'); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/protocol"
+)
+
+func TestConnPoolForTenant(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "test_tenant",
+		LbType: v2.LB_RANDOM,
+		TenantPool: &v2.TenantPool{
+			Header:     "x-tenant",
+			MaxTenants: 1,
+		},
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:10000",
+		},
+	}
+	clusterMangerInstance.Destroy() // Destroy for test
+	NewClusterManagerSingleton([]v2.Cluster{clusterConfig}, map[string][]v2.Host{
+		"test_tenant": []v2.Host{host},
+	})
+	snap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test_tenant")
+	poolForTenant := func(tenant string) *mockConnPool {
+		lbCtx := &mockLbContext{}
+		if tenant != "" {
+			lbCtx.header = protocol.CommonHeader{"x-tenant": tenant}
+		}
+		return GetClusterMngAdapterInstance().ConnPoolForCluster(lbCtx, snap, mockProtocol).(*mockConnPool)
+	}
+	shared := poolForTenant("")
+	poolA := poolForTenant("a")
+	if poolA == shared {
+		t.Fatal("tenant should use an isolated pool")
+	}
+	if poolForTenant("a") != poolA {
+		t.Fatal("tenant should reuse its pool")
+	}
+	// max tenants is 1, tenant b uses the shared pool
+	if poolForTenant("b") != shared {
+		t.Fatal("overflow tenant should use the shared pool")
+	}
+	tp := snap.ClusterInfo().(*clusterInfo).tenantPool
+	stats := tp.tenants["a"]
+	if stats.poolCreated.Count() != 1 || stats.request.Count() != 2 {
+		t.Fatalf("unexpected tenant stats, pool created: %d, request: %d", stats.poolCreated.Count(), stats.request.Count())
+	}
+	if tp.overflow.Count() != 1 {
+		t.Fatalf("expected 1 overflow, but got %d", tp.overflow.Count())
+	}
+}
+
+func TestTenantPoolLimits(t *testing.T) {
+	clusterConfig := v2.Cluster{
+		Name:   "test_tenant_limits",
+		LbType: v2.LB_RANDOM,
+		TenantPool: &v2.TenantPool{
+			Header:         "x-tenant",
+			MaxTenants:     1,
+			AllowedTenants: []string{"a", "b"},
+			MaxConnections: 2,
+			MaxRequests:    1,
+			IdleTimeout:    api.DurationConfig{Duration: 50 * time.Millisecond},
+		},
+	}
+	host := v2.Host{
+		HostConfig: v2.HostConfig{
+			Address: "127.0.0.1:10000",
+		},
+	}
+	clusterMangerInstance.Destroy() // Destroy for test
+	NewClusterManagerSingleton([]v2.Cluster{clusterConfig}, map[string][]v2.Host{
+		"test_tenant_limits": []v2.Host{host},
+	})
+	snap, _ := GetClusterMngAdapterInstance().GetClusterSnapshot(context.Background(), "test_tenant_limits")
+	poolForTenant := func(tenant string) *mockConnPool {
+		lbCtx := &mockLbContext{}
+		if tenant != "" {
+			lbCtx.header = protocol.CommonHeader{"x-tenant": tenant}
+		}
+		return GetClusterMngAdapterInstance().ConnPoolForCluster(lbCtx, snap, mockProtocol).(*mockConnPool)
+	}
+	shared := poolForTenant("")
+	// tenant not allowed uses the shared pool
+	if poolForTenant("c") != shared {
+		t.Fatal("tenant not allowed should use the shared pool")
+	}
+	tp := snap.ClusterInfo().(*clusterInfo).tenantPool
+	if tp.overflow.Count() != 0 {
+		t.Fatalf("tenant not allowed should not be counted as overflow, got %d", tp.overflow.Count())
+	}
+
+	// the tenant pool is limited by the tenant resources
+	poolA := poolForTenant("a")
+	if poolA == shared {
+		t.Fatal("tenant should use an isolated pool")
+	}
+	rm := poolA.h.ClusterInfo().ResourceManager()
+	if rm.Connections().Max() != 2 {
+		t.Fatalf("expected tenant max connections 2, got %d", rm.Connections().Max())
+	}
+	if !rm.Requests().CanCreate() {
+		t.Fatal("tenant request should be created")
+	}
+	rm.Requests().Increase()
+	if rm.Requests().CanCreate() {
+		t.Fatal("tenant requests should be limited")
+	}
+	if !shared.h.ClusterInfo().ResourceManager().Requests().CanCreate() {
+		t.Fatal("shared pool should not be limited by the tenant")
+	}
+	rm.Requests().Decrease()
+
+	// tenant b overflows until tenant a is idle
+	if poolForTenant("b") != shared {
+		t.Fatal("overflow tenant should use the shared pool")
+	}
+	time.Sleep(60 * time.Millisecond)
+	poolB := poolForTenant("b")
+	if poolB == shared {
+		t.Fatal("tenant b should use an isolated pool after tenant a evicted")
+	}
+	if _, ok := tp.tenants["a"]; ok {
+		t.Fatal("idle tenant a should be evicted")
+	}
+	value, _ := clusterMangerInstance.protocolConnPool.Load(mockProtocol)
+	if _, ok := value.(*sync.Map).Load(tenantPoolKey("127.0.0.1:10000", "a")); ok {
+		t.Fatal("the pool of the evicted tenant should be removed")
+	}
+	if poolForTenant("a") != shared {
+		t.Fatal("evicted tenant should use the shared pool when the tenants are full")
+	}
+}
+
+func TestTenantPoolDisabled(t *testing.T) {
+	if tp := newTenantPool("test", &v2.TenantPool{MaxTenants: 10}); tp != nil {
+		t.Fatal("tenant pool without tenant source should be disabled")
+	}
+	if certTenant(context.Background()) != "" {
+		t.Fatal("no downstream connection should have no tenant")
+	}
+}
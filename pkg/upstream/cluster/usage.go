@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"mosn.io/mosn/pkg/types"
+)
+
+// ClusterUsage is the upstream usage of a cluster, which is used to find the hot clusters to warm up
+type ClusterUsage struct {
+	Cluster  string         `json:"cluster"`
+	Protocol types.Protocol `json:"protocol"`
+	Count    int64          `json:"count"`
+}
+
+type usageTable struct {
+	mutex    sync.RWMutex
+	clusters map[string]*ClusterUsage
+}
+
+var clusterUsages = &usageTable{
+	clusters: make(map[string]*ClusterUsage),
+}
+
+// RecordClusterUsage records an upstream request of the cluster with the protocol
+func RecordClusterUsage(name string, protocol types.Protocol) {
+	clusterUsages.mutex.RLock()
+	u, ok := clusterUsages.clusters[name]
+	clusterUsages.mutex.RUnlock()
+	if !ok || u.Protocol != protocol {
+		clusterUsages.mutex.Lock()
+		u, ok = clusterUsages.clusters[name]
+		// the protocol is changed, restarts the count
+		if !ok || u.Protocol != protocol {
+			u = &ClusterUsage{
+				Cluster:  name,
+				Protocol: protocol,
+			}
+			clusterUsages.clusters[name] = u
+		}
+		clusterUsages.mutex.Unlock()
+	}
+	atomic.AddInt64(&u.Count, 1)
+}
+
+// LoadClusterUsages merges the usages, such as the usages saved before restart
+func LoadClusterUsages(usages []ClusterUsage) {
+	clusterUsages.mutex.Lock()
+	defer clusterUsages.mutex.Unlock()
+	for _, usage := range usages {
+		if u, ok := clusterUsages.clusters[usage.Cluster]; ok && u.Protocol == usage.Protocol {
+			atomic.AddInt64(&u.Count, usage.Count)
+			continue
+		}
+		u := usage
+		clusterUsages.clusters[usage.Cluster] = &u
+	}
+}
+
+// HotClusters returns the top n clusters ordered by the usage count, all the clusters are returned if n is not positive
+func HotClusters(n int) []ClusterUsage {
+	clusterUsages.mutex.RLock()
+	usages := make([]ClusterUsage, 0, len(clusterUsages.clusters))
+	for _, u := range clusterUsages.clusters {
+		usages = append(usages, ClusterUsage{
+			Cluster:  u.Cluster,
+			Protocol: u.Protocol,
+			Count:    atomic.LoadInt64(&u.Count),
+		})
+	}
+	clusterUsages.mutex.RUnlock()
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Count != usages[j].Count {
+			return usages[i].Count > usages[j].Count
+		}
+		return usages[i].Cluster < usages[j].Cluster
+	})
+	if n > 0 && n < len(usages) {
+		usages = usages[:n]
+	}
+	return usages
+}
+
+// ResetClusterUsages is only for test and internal usage
+func ResetClusterUsages() {
+	clusterUsages.mutex.Lock()
+	clusterUsages.clusters = make(map[string]*ClusterUsage)
+	clusterUsages.mutex.Unlock()
+}
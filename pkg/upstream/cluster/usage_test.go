@@ -0,0 +1,47 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"testing"
+
+	"mosn.io/mosn/pkg/protocol"
+)
+
+func TestClusterUsage(t *testing.T) {
+	ResetClusterUsages()
+	defer ResetClusterUsages()
+	for i := 0; i < 3; i++ {
+		RecordClusterUsage("a", protocol.HTTP1)
+	}
+	RecordClusterUsage("b", protocol.HTTP1)
+	// the saved usages are merged
+	LoadClusterUsages([]ClusterUsage{
+		{Cluster: "b", Protocol: protocol.HTTP1, Count: 5},
+		{Cluster: "c", Protocol: protocol.HTTP2, Count: 2},
+	})
+	hot := HotClusters(2)
+	if len(hot) != 2 || hot[0].Cluster != "b" || hot[0].Count != 6 || hot[1].Cluster != "a" || hot[1].Count != 3 {
+		t.Fatalf("unexpected hot clusters: %v", hot)
+	}
+	// the protocol is changed
+	RecordClusterUsage("b", protocol.HTTP2)
+	hot = HotClusters(0)
+	if len(hot) != 3 || hot[0].Cluster != "a" || hot[2].Cluster != "b" || hot[2].Protocol != protocol.HTTP2 || hot[2].Count != 1 {
+		t.Fatalf("unexpected hot clusters: %v", hot)
+	}
+}
@@ -33,6 +33,12 @@ func RegisterSessionFactory(p types.Protocol, f types.HealthCheckSessionFactory)
 	sessionFactories[p] = f
 }
 
+// IsSessionFactoryRegistered returns true if a session factory is registered for the protocol
+func IsSessionFactoryRegistered(p types.Protocol) bool {
+	_, ok := sessionFactories[p]
+	return ok
+}
+
 // CreateHealthCheck is a extendable function that can create different health checker
 // by different health check session.
 // The Default session is TCPDial session
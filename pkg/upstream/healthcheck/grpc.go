@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// GrpcHealthCheck is the health check protocol that uses the standard grpc health checking protocol
+// grpc.health.v1.Health/Check, see https://github.com/grpc/grpc/blob/master/doc/health-checking.md
+//
+// The check config supports:
+// "service_names": the services to be checked, the host is healthy only if all of the services are serving.
+// if no service is configured, the overall health of the server is checked.
+// "authority": the :authority header of the health check request, default is the host address.
+// "degraded_header": the host is degraded if any response contains the header, default is x-envoy-degraded.
+const GrpcHealthCheck types.Protocol = "Grpc"
+
+const (
+	grpcHealthCheckMethod = "/grpc.health.v1.Health/Check"
+	// default dial and request timeout, maybe already timeout by checker
+	grpcHealthCheckTimeout = 30 * time.Second
+)
+
+// grpc health check serving status
+const (
+	grpcHealthUnknown int32 = iota
+	grpcHealthServing
+	grpcHealthNotServing
+	grpcHealthServiceUnknown
+)
+
+func init() {
+	RegisterSessionFactory(GrpcHealthCheck, &GrpcSessionFactory{})
+}
+
+// grpcHealthCheckRequest is the grpc.health.v1.HealthCheckRequest
+type grpcHealthCheckRequest struct {
+	Service string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+}
+
+func (m *grpcHealthCheckRequest) Reset()         { *m = grpcHealthCheckRequest{} }
+func (m *grpcHealthCheckRequest) String() string { return proto.CompactTextString(m) }
+func (*grpcHealthCheckRequest) ProtoMessage()    {}
+
+// grpcHealthCheckResponse is the grpc.health.v1.HealthCheckResponse
+type grpcHealthCheckResponse struct {
+	Status int32 `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (m *grpcHealthCheckResponse) Reset()         { *m = grpcHealthCheckResponse{} }
+func (m *grpcHealthCheckResponse) String() string { return proto.CompactTextString(m) }
+func (*grpcHealthCheckResponse) ProtoMessage()    {}
+
+type GrpcSessionFactory struct{}
+
+func (f *GrpcSessionFactory) NewSession(cfg map[string]interface{}, host types.Host) types.HealthCheckSession {
+	s := &GrpcSession{
+		addr:           host.AddressString(),
+		services:       []string{""},
+		degradedHeader: strings.ToLower(degradedHeader(cfg)),
+	}
+	if names, ok := cfg["service_names"].([]interface{}); ok && len(names) > 0 {
+		s.services = make([]string, 0, len(names))
+		for _, name := range names {
+			if service, ok := name.(string); ok {
+				s.services = append(s.services, service)
+			}
+		}
+	}
+	if authority, ok := cfg["authority"].(string); ok {
+		s.authority = authority
+	}
+	return s
+}
+
+type GrpcSession struct {
+	addr           string
+	authority      string
+	services       []string
+	degradedHeader string
+	degraded       uint32
+}
+
+func (s *GrpcSession) CheckHealth() bool {
+	atomic.StoreUint32(&s.degraded, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), grpcHealthCheckTimeout)
+	defer cancel()
+	opts := []grpc.DialOption{grpc.WithInsecure(), grpc.WithBlock(), grpc.FailOnNonTempDialError(true)}
+	if s.authority != "" {
+		opts = append(opts, grpc.WithAuthority(s.authority))
+	}
+	conn, err := grpc.DialContext(ctx, s.addr, opts...)
+	if err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [grpc session] dial grpc for host %s error: %v", s.addr, err)
+		return false
+	}
+	defer conn.Close()
+	degraded := false
+	for _, service := range s.services {
+		resp := &grpcHealthCheckResponse{}
+		var md metadata.MD
+		if err := conn.Invoke(ctx, grpcHealthCheckMethod, &grpcHealthCheckRequest{Service: service}, resp, grpc.Header(&md)); err != nil {
+			log.DefaultLogger.Infof("[upstream] [health check] [grpc session] check service %q for host %s error: %v", service, s.addr, err)
+			return false
+		}
+		if resp.Status != grpcHealthServing {
+			log.DefaultLogger.Infof("[upstream] [health check] [grpc session] service %q for host %s is not serving, status: %d", service, s.addr, resp.Status)
+			return false
+		}
+		if len(md.Get(s.degradedHeader)) > 0 {
+			degraded = true
+		}
+	}
+	if degraded {
+		atomic.StoreUint32(&s.degraded, 1)
+	}
+	return true
+}
+
+// Degraded returns true if any response of the latest check contains the degraded header
+func (s *GrpcSession) Degraded() bool {
+	return atomic.LoadUint32(&s.degraded) == 1
+}
+
+func (s *GrpcSession) OnTimeout() {}
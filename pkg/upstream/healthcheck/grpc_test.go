@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"mosn.io/mosn/pkg/types"
+)
+
+type mockHealthServer struct {
+	status   map[string]int32
+	degraded map[string]bool
+}
+
+func (s *mockHealthServer) check(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &grpcHealthCheckRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if s.degraded[req.Service] {
+		grpc.SetHeader(ctx, metadata.Pairs(defaultDegradedHeader, "true"))
+	}
+	status, ok := s.status[req.Service]
+	if !ok {
+		status = grpcHealthServiceUnknown
+	}
+	return &grpcHealthCheckResponse{Status: status}, nil
+}
+
+func startMockHealthServer(t *testing.T, status map[string]int32, degraded map[string]bool) (*grpc.Server, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := &mockHealthServer{status: status, degraded: degraded}
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "grpc.health.v1.Health",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "Check",
+				Handler:    hs.check,
+			},
+		},
+	}, hs)
+	go s.Serve(ln)
+	return s, ln.Addr().String()
+}
+
+func TestGrpcHealthCheck(t *testing.T) {
+	s, addr := startMockHealthServer(t, map[string]int32{
+		"":         grpcHealthServing,
+		"serviceA": grpcHealthServing,
+		"serviceB": grpcHealthNotServing,
+		"serviceD": grpcHealthServing,
+	}, map[string]bool{
+		"serviceD": true,
+	})
+	host := &mockHost{
+		addr: addr,
+	}
+	factory := &GrpcSessionFactory{}
+	for _, c := range []struct {
+		cfg      map[string]interface{}
+		healthy  bool
+		degraded bool
+	}{
+		{nil, true, false},
+		{map[string]interface{}{"service_names": []interface{}{"serviceA"}}, true, false},
+		{map[string]interface{}{"service_names": []interface{}{"serviceA", "serviceB"}}, false, false},
+		{map[string]interface{}{"service_names": []interface{}{"serviceC"}}, false, false},
+		{map[string]interface{}{"service_names": []interface{}{"serviceA", "serviceD"}}, true, true},
+		{map[string]interface{}{"service_names": []interface{}{"serviceD"}, "degraded_header": "x-other"}, true, false},
+	} {
+		session := factory.NewSession(c.cfg, host)
+		if session.CheckHealth() != c.healthy {
+			t.Errorf("check %v expected healthy: %v", c.cfg, c.healthy)
+		}
+		if session.(types.DegradedHealthCheckSession).Degraded() != c.degraded {
+			t.Errorf("check %v expected degraded: %v", c.cfg, c.degraded)
+		}
+	}
+	s.Stop()
+	session := factory.NewSession(nil, host)
+	if session.CheckHealth() {
+		t.Error("check a stopped server, but returns ok")
+	}
+	if !IsSessionFactoryRegistered(GrpcHealthCheck) {
+		t.Error("grpc health check session factory is not registered")
+	}
+}
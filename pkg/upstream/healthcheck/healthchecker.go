@@ -19,11 +19,13 @@ package healthcheck
 
 import (
 	"math/rand"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/event"
 	"mosn.io/mosn/pkg/log"
 	"mosn.io/mosn/pkg/types"
 	"mosn.io/pkg/utils"
@@ -38,6 +40,7 @@ const (
 // we use different implementations of types.Session to implement different health checker
 type healthChecker struct {
 	//
+	serviceName         string
 	sessionConfig       map[string]interface{}
 	sessionFactory      types.HealthCheckSessionFactory
 	mutex               sync.Mutex
@@ -66,6 +69,7 @@ func newHealthChecker(cfg v2.HealthCheck, f types.HealthCheckSessionFactory) typ
 	}
 	hc := &healthChecker{
 		// cfg
+		serviceName:        cfg.ServiceName,
 		sessionConfig:      cfg.SessionConfig,
 		timeout:            timeout,
 		intervalBase:       interval,
@@ -164,6 +168,12 @@ func (hc *healthChecker) stopCheck(host types.Host) {
 
 func (hc *healthChecker) runCallbacks(host types.Host, changed bool, isHealthy bool) {
 	hc.stats.healthy.Update(atomic.LoadInt64(&hc.localProcessHealthy))
+	if changed {
+		event.Publish(event.HostHealthChanged, hc.serviceName, map[string]string{
+			"host":    host.AddressString(),
+			"healthy": strconv.FormatBool(isHealthy),
+		})
+	}
 	for _, cb := range hc.hostCheckCallbacks {
 		cb(host, changed, isHealthy)
 	}
@@ -215,3 +215,65 @@ func TestHealthCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestSessionCheckerDegraded(t *testing.T) {
+	hc := newHealthChecker(v2.HealthCheck{
+		HealthCheckConfig: v2.HealthCheckConfig{
+			ServiceName: "test_degraded",
+		},
+	}, &mockSessionFactory{}).(*healthChecker)
+	result := &testResult{
+		results: map[string]*testCounter{},
+	}
+	hc.AddHostCheckCompleteCb(result.testCallback)
+	host := &mockHost{
+		addr:   "test_degraded",
+		status: true,
+	}
+	c := newChecker(&mockSession{host}, host, hc)
+	c.HandleDegraded(true)
+	if !host.ContainHealthFlag(types.DEGRADED_ACTIVE_HC) {
+		t.Fatal("host should be degraded")
+	}
+	// degraded again, no changed
+	c.HandleDegraded(true)
+	c.HandleDegraded(false)
+	if host.ContainHealthFlag(types.DEGRADED_ACTIVE_HC) {
+		t.Fatal("host should not be degraded")
+	}
+	if result.results["test_degraded"].changed != 2 {
+		t.Fatalf("expected 2 changed callbacks, but got %d", result.results["test_degraded"].changed)
+	}
+	if hc.stats.degraded.Count() != 2 {
+		t.Fatalf("expected 2 degraded checks, but got %d", hc.stats.degraded.Count())
+	}
+}
+
+func TestSessionCheckerWarming(t *testing.T) {
+	hc := newHealthChecker(v2.HealthCheck{
+		HealthCheckConfig: v2.HealthCheckConfig{
+			ServiceName: "test_warming",
+		},
+	}, &mockSessionFactory{}).(*healthChecker)
+	result := &testResult{
+		results: map[string]*testCounter{},
+	}
+	hc.AddHostCheckCompleteCb(result.testCallback)
+	host := &mockHost{
+		addr:   "test_warming",
+		status: true,
+	}
+	host.SetHealthFlag(types.PENDING_ACTIVE_HC)
+	c := newChecker(&mockSession{host}, host, hc)
+	c.HandleFailure(types.FailureActive)
+	if !host.ContainHealthFlag(types.PENDING_ACTIVE_HC) {
+		t.Fatal("host should be warming until a successful check")
+	}
+	c.HandleSuccess()
+	if host.ContainHealthFlag(types.PENDING_ACTIVE_HC) {
+		t.Fatal("host should be warmed")
+	}
+	if result.results["test_warming"].changed != 1 {
+		t.Fatalf("expected 1 changed callbacks, but got %d", result.results["test_warming"].changed)
+	}
+}
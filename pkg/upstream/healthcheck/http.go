@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/types"
+)
+
+// HttpHealthCheck is the health check protocol that sends a http GET request to the host,
+// the host is healthy if the response status code is 2xx.
+//
+// The check config supports:
+// "path": the path of the health check request, default is /.
+// "host": the host header of the health check request, default is the host address.
+// "degraded_header": the host is degraded if the response contains the header, default is x-envoy-degraded.
+const HttpHealthCheck types.Protocol = "Http"
+
+const (
+	defaultHttpHealthCheckPath = "/"
+	// defaultDegradedHeader is the header that marks the host is degraded, compatible with envoy
+	defaultDegradedHeader = "x-envoy-degraded"
+	// default request timeout, maybe already timeout by checker
+	httpHealthCheckTimeout = 30 * time.Second
+)
+
+func init() {
+	RegisterSessionFactory(HttpHealthCheck, &HttpSessionFactory{})
+}
+
+type HttpSessionFactory struct{}
+
+func (f *HttpSessionFactory) NewSession(cfg map[string]interface{}, host types.Host) types.HealthCheckSession {
+	path := defaultHttpHealthCheckPath
+	if p, ok := cfg["path"].(string); ok && p != "" {
+		path = p
+	}
+	s := &HttpSession{
+		addr:           host.AddressString(),
+		url:            fmt.Sprintf("http://%s%s", host.AddressString(), path),
+		degradedHeader: degradedHeader(cfg),
+		client: &http.Client{
+			Timeout: httpHealthCheckTimeout,
+			// a new connection for each check, just like the other sessions
+			Transport: &http.Transport{DisableKeepAlives: true},
+			// the health check result should not depend on the redirect target
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	if h, ok := cfg["host"].(string); ok {
+		s.host = h
+	}
+	return s
+}
+
+type HttpSession struct {
+	addr           string
+	url            string
+	host           string
+	degradedHeader string
+	client         *http.Client
+	degraded       uint32
+}
+
+func (s *HttpSession) CheckHealth() bool {
+	atomic.StoreUint32(&s.degraded, 0)
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		log.DefaultLogger.Errorf("[upstream] [health check] [http session] create request for host %s error: %v", s.addr, err)
+		return false
+	}
+	if s.host != "" {
+		req.Host = s.host
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.DefaultLogger.Infof("[upstream] [health check] [http session] request host %s error: %v", s.addr, err)
+		return false
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.DefaultLogger.Infof("[upstream] [health check] [http session] host %s is unhealthy, status: %d", s.addr, resp.StatusCode)
+		return false
+	}
+	if _, ok := resp.Header[http.CanonicalHeaderKey(s.degradedHeader)]; ok {
+		atomic.StoreUint32(&s.degraded, 1)
+	}
+	return true
+}
+
+// Degraded returns true if the latest response contains the degraded header
+func (s *HttpSession) Degraded() bool {
+	return atomic.LoadUint32(&s.degraded) == 1
+}
+
+func (s *HttpSession) OnTimeout() {}
+
+// degradedHeader returns the configured degraded header
+func degradedHeader(cfg map[string]interface{}) string {
+	if h, ok := cfg["degraded_header"].(string); ok && h != "" {
+		return h
+	}
+	return defaultDegradedHeader
+}
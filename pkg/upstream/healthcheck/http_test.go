@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/types"
+)
+
+func TestHttpHealthCheck(t *testing.T) {
+	var degraded uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if atomic.LoadUint32(&degraded) == 1 {
+				w.Header().Set("X-Degraded", "true")
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "/health", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	host := &mockHost{
+		addr: strings.TrimPrefix(srv.URL, "http://"),
+	}
+	factory := &HttpSessionFactory{}
+	for _, c := range []struct {
+		cfg     map[string]interface{}
+		healthy bool
+	}{
+		{map[string]interface{}{"path": "/health"}, true},
+		{map[string]interface{}{"path": "/redirect"}, false},
+		{nil, false},
+	} {
+		session := factory.NewSession(c.cfg, host)
+		if session.CheckHealth() != c.healthy {
+			t.Errorf("check %v expected healthy: %v", c.cfg, c.healthy)
+		}
+	}
+
+	cfg := map[string]interface{}{"path": "/health", "degraded_header": "x-degraded"}
+	session := factory.NewSession(cfg, host).(types.DegradedHealthCheckSession)
+	atomic.StoreUint32(&degraded, 1)
+	if !session.CheckHealth() || !session.Degraded() {
+		t.Error("the host with the degraded header should be healthy and degraded")
+	}
+	atomic.StoreUint32(&degraded, 0)
+	if !session.CheckHealth() || session.Degraded() {
+		t.Error("the host without the degraded header should not be degraded")
+	}
+	srv.Close()
+	if session.CheckHealth() {
+		t.Error("check a stopped server, but returns ok")
+	}
+	if !IsSessionFactoryRegistered(HttpHealthCheck) {
+		t.Error("http health check session factory is not registered")
+	}
+}
+
+func TestHttpHealthCheckDegradedHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(defaultDegradedHeader, "true")
+	}))
+	defer srv.Close()
+	host := &mockHost{
+		addr:   strings.TrimPrefix(srv.URL, "http://"),
+		status: true,
+	}
+	firstInterval = 10 * time.Millisecond
+	hc := CreateHealthCheck(v2.HealthCheck{
+		HealthCheckConfig: v2.HealthCheckConfig{
+			Protocol:           string(HttpHealthCheck),
+			HealthyThreshold:   1,
+			UnhealthyThreshold: 1,
+			ServiceName:        "test_http_degraded",
+		},
+		Interval: 50 * time.Millisecond,
+	})
+	// the checks are started with the host set
+	hc.SetHealthCheckerHostSet(&mockHostSet{hosts: []types.Host{host}})
+	time.Sleep(200 * time.Millisecond)
+	hc.Stop()
+	raw := hc.(*healthChecker)
+	if raw.stats.degraded.Count() == 0 {
+		t.Fatal("expected degraded checks")
+	}
+	if !host.ContainHealthFlag(types.DEGRADED_ACTIVE_HC) {
+		t.Fatal("host should be marked degraded by the http session")
+	}
+}
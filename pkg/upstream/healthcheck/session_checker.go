@@ -44,8 +44,9 @@ type sessionChecker struct {
 }
 
 type checkResponse struct {
-	ID      uint64
-	Healthy bool
+	ID       uint64
+	Healthy  bool
+	Degraded bool
 }
 
 func newChecker(s types.HealthCheckSession, h types.Host, hc *healthChecker) *sessionChecker {
@@ -88,6 +89,7 @@ func (c *sessionChecker) Start() {
 					c.checkTimeout.Stop()
 					if resp.Healthy {
 						c.HandleSuccess()
+						c.HandleDegraded(resp.Degraded)
 					} else {
 						c.HandleFailure(types.FailureActive)
 					}
@@ -131,6 +133,12 @@ func (c *sessionChecker) HandleSuccess() {
 		}
 	}
 	c.HealthChecker.incHealthy(c.Host, changed)
+	// a warming host is ready after the first successful health check
+	if c.Host.ContainHealthFlag(types.PENDING_ACTIVE_HC) {
+		log.DefaultLogger.Infof("[upstream] [health check] [session checker] host %s is warmed", c.Host.AddressString())
+		c.Host.ClearHealthFlag(types.PENDING_ACTIVE_HC)
+		c.HealthChecker.runCallbacks(c.Host, true, c.Host.Health())
+	}
 }
 
 func (c *sessionChecker) HandleFailure(reason types.FailureType) {
@@ -147,6 +155,25 @@ func (c *sessionChecker) HandleFailure(reason types.FailureType) {
 	c.HealthChecker.decHealthy(c.Host, reason, changed)
 }
 
+// HandleDegraded sets or clears the degraded flag, the host set will be refreshed
+// by the check callbacks if the flag is changed
+func (c *sessionChecker) HandleDegraded(degraded bool) {
+	if degraded {
+		c.HealthChecker.stats.degraded.Inc(1)
+	}
+	if degraded == c.Host.ContainHealthFlag(types.DEGRADED_ACTIVE_HC) {
+		return
+	}
+	if degraded {
+		log.DefaultLogger.Infof("[upstream] [health check] [session checker] host %s is degraded", c.Host.AddressString())
+		c.Host.SetHealthFlag(types.DEGRADED_ACTIVE_HC)
+	} else {
+		log.DefaultLogger.Infof("[upstream] [health check] [session checker] host %s is no longer degraded", c.Host.AddressString())
+		c.Host.ClearHealthFlag(types.DEGRADED_ACTIVE_HC)
+	}
+	c.HealthChecker.runCallbacks(c.Host, true, c.Host.Health())
+}
+
 func (c *sessionChecker) OnCheck() {
 	// record current id
 	id := atomic.LoadUint64(&c.checkID)
@@ -154,10 +181,14 @@ func (c *sessionChecker) OnCheck() {
 	// start a timeout before check health
 	c.checkTimeout.Stop()
 	c.checkTimeout = utils.NewTimer(c.HealthChecker.timeout, c.OnTimeout)
-	c.resp <- checkResponse{
+	resp := checkResponse{
 		ID:      id,
 		Healthy: c.Session.CheckHealth(),
 	}
+	if s, ok := c.Session.(types.DegradedHealthCheckSession); ok && resp.Healthy {
+		resp.Degraded = s.Degraded()
+	}
+	c.resp <- resp
 }
 
 func (c *sessionChecker) OnTimeout() {
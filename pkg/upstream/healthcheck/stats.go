@@ -34,6 +34,8 @@ type healthCheckStats struct {
 	networkFailure gometrics.Counter
 	verifyCluster  gometrics.Counter
 	healthy        gometrics.Gauge
+	// total counts for check health returns ok, but the host is degraded
+	degraded gometrics.Counter
 }
 
 func newHealthCheckStats(namespace string) *healthCheckStats {
@@ -47,5 +49,6 @@ func newHealthCheckStats(namespace string) *healthCheckStats {
 		networkFailure: m.Counter(metrics.HealthCheckNetworkFailure),
 		verifyCluster:  m.Counter(metrics.HealthCheckVeirfyCluster),
 		healthy:        m.Gauge(metrics.HealthCheckHealthy),
+		degraded:       m.Counter(metrics.HealthCheckDegraded),
 	}
 }
@@ -55,10 +55,44 @@ func GetVariableValue(ctx context.Context, name string) (string, error) {
 	return "", errors.New(errUndefinedVariable + name)
 }
 
+// SetVariableValue sets the value of the variable in the stream context, the variable must be
+// an indexed variable with a setter. The value set is returned by GetVariableValue of the stream.
+func SetVariableValue(ctx context.Context, name string, value string) error {
+	mux.RLock()
+	variable, ok := variables[name]
+	mux.RUnlock()
+	if !ok {
+		return errors.New(errUndefinedVariable + name)
+	}
+	indexer, ok := variable.(Indexer)
+	if !ok {
+		return errors.New(errNotIndexedVariable + name)
+	}
+	setter := variable.Setter()
+	if setter == nil {
+		return errors.New(errSetterNotFound + name)
+	}
+	if variables := ctx.Value(types.ContextKeyVariables); variables != nil {
+		if values, ok := variables.([]IndexedValue); ok && int(indexer.GetIndex()) < len(values) {
+			return setter(&values[indexer.GetIndex()], value)
+		}
+	}
+	return errors.New(errNoVariablesInContext)
+}
+
+// BasicSetter stores the value in the stream context, it is the setter of the variables
+// set by the filters, such as the variables used by the access logs and the header mutations.
+func BasicSetter(variableValue *IndexedValue, value string) error {
+	variableValue.data = value
+	variableValue.Valid = true
+	variableValue.NotFound = false
+	return nil
+}
+
 // TODO: provide direct access to this function, so the cost of variable name finding could be optimized
 func getFlushedVariableValue(ctx context.Context, index uint32) (string, error) {
 	if variables := ctx.Value(types.ContextKeyVariables); variables != nil {
-		if values, ok := variables.([]IndexedValue); ok {
+		if values, ok := variables.([]IndexedValue); ok && int(index) < len(values) {
 			value := &values[index]
 			if value.Valid || value.NotFound {
 				if !value.noCacheable {
@@ -86,6 +120,10 @@ func getIndexedVariableValue(ctx context.Context, value *IndexedValue, index uin
 
 	getter := variable.Getter()
 	if getter == nil {
+		// the variable is not set in the stream
+		if variable.Setter() != nil {
+			return ValueNotFound, errors.New(errValueNotFound + variable.Name())
+		}
 		return "", errors.New(errGetterNotFound + variable.Name())
 	}
 	vdata, err := getter(ctx, value, variable.Data())
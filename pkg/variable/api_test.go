@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package variable
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetVariableValue(t *testing.T) {
+	name := "test_set_variable"
+	if err := RegisterVariable(NewIndexedVariable(name, nil, nil, BasicSetter, 0)); err != nil {
+		t.Fatal(err)
+	}
+	readonly := "test_readonly_variable"
+	if err := RegisterVariable(NewBasicVariable(readonly, nil, func(ctx context.Context, value *IndexedValue, data interface{}) (string, error) {
+		return "readonly", nil
+	}, nil, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewVariableContext(context.Background())
+	// not set yet
+	if v, err := GetVariableValue(ctx, name); err == nil || v != ValueNotFound {
+		t.Errorf("unexpected value of the variable not set: %s, %v", v, err)
+	}
+	if err := SetVariableValue(ctx, name, "value"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := GetVariableValue(ctx, name); err != nil || v != "value" {
+		t.Errorf("unexpected value: %s, %v", v, err)
+	}
+	// the variables are scoped to the stream context
+	if v, err := GetVariableValue(NewVariableContext(context.Background()), name); err == nil {
+		t.Errorf("variable should not be set in another stream: %s", v)
+	}
+
+	if err := SetVariableValue(ctx, readonly, "value"); err == nil {
+		t.Error("set a variable without setter should be failed")
+	}
+	if err := SetVariableValue(ctx, "test_undefined_variable", "value"); err == nil {
+		t.Error("set an undefined variable should be failed")
+	}
+	if err := SetVariableValue(context.Background(), name, "value"); err == nil {
+		t.Error("set a variable without variable context should be failed")
+	}
+}
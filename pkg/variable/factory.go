@@ -41,6 +41,8 @@ var (
 	errNoVariablesInContext = "no variables found in context"
 	errGetterNotFound       = "getter function undefined, variable name: "
 	errSetterNotFound       = "setter function undefined, variable name: "
+	errNotIndexedVariable   = "variable is not indexed, name: "
+	errValueNotFound        = "variable value not set, name: "
 )
 
 // AddVariable is used to check variable name exists. Typical usage is variables used in access logs.
@@ -0,0 +1,255 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package watchdog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	gometrics "github.com/rcrowley/go-metrics"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/log"
+	"mosn.io/mosn/pkg/metrics"
+	"mosn.io/pkg/utils"
+)
+
+const (
+	defaultInterval         = 30 * time.Second
+	defaultBaselineSamples  = 20
+	defaultGrowthRatio      = 2.0
+	defaultHeapDumpInterval = 10 * time.Minute
+	// minGrowth avoids the false positives of the resources that have a small baseline
+	minGrowth = 100
+)
+
+// the resources sampled by the watchdog
+const (
+	Goroutines    = "goroutines"
+	Connections   = "connections"
+	ActiveStreams = "active_streams"
+)
+
+// sampler samples the current value of a resource
+type sampler struct {
+	resource string
+	ceiling  int64
+	sample   func() int64
+
+	history []int64 // ring buffer of the historical samples
+	next    int
+	full    bool
+
+	value     gometrics.Gauge
+	suspected gometrics.Counter
+}
+
+// baseline returns the average of the historical samples
+func (s *sampler) baseline() float64 {
+	n := s.next
+	if s.full {
+		n = len(s.history)
+	}
+	if n == 0 {
+		return 0
+	}
+	var sum int64
+	for _, v := range s.history[:n] {
+		sum += v
+	}
+	return float64(sum) / float64(n)
+}
+
+func (s *sampler) record(v int64) {
+	s.history[s.next] = v
+	s.next++
+	if s.next == len(s.history) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+// Watchdog periodically samples the goroutines, connections and active streams,
+// and logs warnings when leaks are suspected
+type Watchdog struct {
+	interval         time.Duration
+	ratio            float64
+	heapDumpDir      string
+	heapDumpInterval time.Duration
+	lastHeapDump     time.Time
+	heapDumps        gometrics.Counter
+
+	samplers []*sampler
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// NewWatchdog creates a watchdog by the config
+func NewWatchdog(cfg *v2.WatchdogConfig) *Watchdog {
+	w := &Watchdog{
+		interval:         defaultInterval,
+		ratio:            defaultGrowthRatio,
+		heapDumpDir:      cfg.HeapDumpDir,
+		heapDumpInterval: defaultHeapDumpInterval,
+		heapDumps:        metrics.NewWatchdogStats("heap").Counter(metrics.WatchdogHeapDump),
+		stop:             make(chan struct{}),
+	}
+	if cfg.Interval.Duration > 0 {
+		w.interval = cfg.Interval.Duration
+	}
+	if cfg.GrowthRatio > 1 {
+		w.ratio = cfg.GrowthRatio
+	}
+	if cfg.HeapDumpInterval.Duration > 0 {
+		w.heapDumpInterval = cfg.HeapDumpInterval.Duration
+	}
+	samples := defaultBaselineSamples
+	if cfg.BaselineSamples > 0 {
+		samples = cfg.BaselineSamples
+	}
+	w.addSampler(Goroutines, cfg.MaxGoroutines, samples, func() int64 {
+		return int64(runtime.NumGoroutine())
+	})
+	w.addSampler(Connections, cfg.MaxConnections, samples, func() int64 {
+		// the downstream connections are counted by listeners,
+		// the upstream connections are counted by clusters
+		return sumCounters(metrics.DownstreamType, "listener", metrics.DownstreamConnectionActive) +
+			sumCounters(metrics.UpstreamType, "cluster", metrics.UpstreamConnectionActive)
+	})
+	w.addSampler(ActiveStreams, cfg.MaxActiveStreams, samples, func() int64 {
+		return sumCounters(metrics.UpstreamType, "cluster", metrics.UpstreamRequestActive)
+	})
+	return w
+}
+
+func (w *Watchdog) addSampler(resource string, ceiling int64, samples int, sample func() int64) {
+	stats := metrics.NewWatchdogStats(resource)
+	w.samplers = append(w.samplers, &sampler{
+		resource:  resource,
+		ceiling:   ceiling,
+		sample:    sample,
+		history:   make([]int64, samples),
+		value:     stats.Gauge(resource),
+		suspected: stats.Counter(metrics.WatchdogLeakSuspected),
+	})
+}
+
+// sumCounters sums the counters named by key in the metrics that only labeled by label
+func sumCounters(typ, label, key string) int64 {
+	var total int64
+	for _, m := range metrics.GetAll() {
+		if m.Type() != typ {
+			continue
+		}
+		labels := m.Labels()
+		if _, ok := labels[label]; !ok || len(labels) != 1 {
+			continue
+		}
+		m.Each(func(k string, i interface{}) {
+			if c, ok := i.(gometrics.Counter); ok && k == key {
+				total += c.Count()
+			}
+		})
+	}
+	return total
+}
+
+// Start starts the watchdog
+func (w *Watchdog) Start() {
+	utils.GoWithRecover(func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.check()
+			case <-w.stop:
+				return
+			}
+		}
+	}, nil)
+}
+
+// Stop stops the watchdog
+func (w *Watchdog) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+}
+
+// check samples all the resources, returns the resources that leaks are suspected
+func (w *Watchdog) check() []string {
+	var suspected []string
+	for _, s := range w.samplers {
+		v := s.sample()
+		s.value.Update(v)
+		baseline := s.baseline()
+		reason := ""
+		if s.ceiling > 0 && v > s.ceiling {
+			reason = "exceed ceiling"
+		} else if s.full && float64(v) > baseline*w.ratio && float64(v)-baseline >= minGrowth {
+			reason = "exceed baseline"
+		}
+		s.record(v)
+		if reason == "" {
+			continue
+		}
+		s.suspected.Inc(1)
+		suspected = append(suspected, s.resource)
+		log.DefaultLogger.Warnf("[watchdog] leak suspected, resource: %s, reason: %s, value: %d, ceiling: %d, baseline: %.2f, ratio: %.2f",
+			s.resource, reason, v, s.ceiling, baseline, w.ratio)
+	}
+	if len(suspected) > 0 {
+		w.heapDump()
+	}
+	return suspected
+}
+
+// heapDump writes a heap profile if heap dump is enabled and not dumped in the interval
+func (w *Watchdog) heapDump() {
+	if w.heapDumpDir == "" || time.Since(w.lastHeapDump) < w.heapDumpInterval {
+		return
+	}
+	w.lastHeapDump = time.Now()
+	path, err := writeHeapProfile(w.heapDumpDir)
+	if err != nil {
+		log.DefaultLogger.Errorf("[watchdog] write heap profile failed: %v", err)
+		return
+	}
+	w.heapDumps.Inc(1)
+	log.DefaultLogger.Warnf("[watchdog] heap profile is written to %s", path)
+}
+
+func writeHeapProfile(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("heap-%d-%s.prof", os.Getpid(), time.Now().Format("20060102150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := pprof.Lookup("heap").WriteTo(f, 0); err != nil {
+		return "", err
+	}
+	return path, nil
+}
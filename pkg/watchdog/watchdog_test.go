@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package watchdog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"mosn.io/api"
+	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/metrics"
+)
+
+func mockSampler(w *Watchdog, resource string, value *int64) {
+	for _, s := range w.samplers {
+		if s.resource == resource {
+			s.sample = func() int64 {
+				return *value
+			}
+		}
+	}
+}
+
+func TestWatchdogCeiling(t *testing.T) {
+	metrics.ResetAll()
+	w := NewWatchdog(&v2.WatchdogConfig{
+		MaxConnections: 10,
+	})
+	var goroutines, connections, streams int64 = 10, 10, 10
+	mockSampler(w, Goroutines, &goroutines)
+	mockSampler(w, Connections, &connections)
+	mockSampler(w, ActiveStreams, &streams)
+	if suspected := w.check(); len(suspected) != 0 {
+		t.Fatalf("unexpected suspected: %v", suspected)
+	}
+	connections = 11
+	if suspected := w.check(); len(suspected) != 1 || suspected[0] != Connections {
+		t.Fatalf("unexpected suspected: %v", suspected)
+	}
+	stats := metrics.NewWatchdogStats(Connections)
+	if stats.Gauge(Connections).Value() != 11 || stats.Counter(metrics.WatchdogLeakSuspected).Count() != 1 {
+		t.Fatal("unexpected watchdog stats")
+	}
+}
+
+func TestWatchdogBaseline(t *testing.T) {
+	metrics.ResetAll()
+	dir, err := ioutil.TempDir("", "mosn_watchdog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	w := NewWatchdog(&v2.WatchdogConfig{
+		BaselineSamples: 3,
+		HeapDumpDir:     dir,
+		HeapDumpInterval: api.DurationConfig{
+			Duration: time.Hour,
+		},
+	})
+	var goroutines, connections, streams int64 = 100, 0, 0
+	mockSampler(w, Goroutines, &goroutines)
+	mockSampler(w, Connections, &connections)
+	mockSampler(w, ActiveStreams, &streams)
+	// the baseline is not ready
+	for _, v := range []int64{100, 100, 1000} {
+		goroutines = v
+		if suspected := w.check(); len(suspected) != 0 {
+			t.Fatalf("unexpected suspected: %v", suspected)
+		}
+	}
+	// baseline is 400, not exceed the ratio
+	goroutines = 800
+	if suspected := w.check(); len(suspected) != 0 {
+		t.Fatalf("unexpected suspected: %v", suspected)
+	}
+	// baseline is 633.33
+	goroutines = 2000
+	if suspected := w.check(); len(suspected) != 1 || suspected[0] != Goroutines {
+		t.Fatalf("unexpected suspected: %v", suspected)
+	}
+	// small growth is ignored
+	streams = 10
+	if suspected := w.check(); len(suspected) != 0 {
+		t.Fatalf("unexpected suspected: %v", suspected)
+	}
+	// heap is dumped once in the interval
+	goroutines = 10000
+	if suspected := w.check(); len(suspected) != 1 {
+		t.Fatalf("unexpected suspected: %v", suspected)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Size() == 0 {
+		t.Fatalf("unexpected heap dump files: %v", files)
+	}
+}
+
+func TestWatchdogSampleMetrics(t *testing.T) {
+	metrics.ResetAll()
+	metrics.NewListenerStats("test").Counter(metrics.DownstreamConnectionActive).Inc(2)
+	metrics.NewProxyStats("test").Counter(metrics.DownstreamConnectionActive).Inc(2)
+	metrics.NewClusterStats("test").Counter(metrics.UpstreamConnectionActive).Inc(3)
+	metrics.NewClusterStats("test").Counter(metrics.UpstreamRequestActive).Inc(5)
+	metrics.NewHostStats("test", "127.0.0.1:80").Counter(metrics.UpstreamRequestActive).Inc(5)
+	w := NewWatchdog(&v2.WatchdogConfig{})
+	expected := map[string]int64{
+		Connections:   5,
+		ActiveStreams: 5,
+	}
+	for _, s := range w.samplers {
+		if v, ok := expected[s.resource]; ok && s.sample() != v {
+			t.Errorf("%s sample expected %d, but got %d", s.resource, v, s.sample())
+		}
+	}
+}
+
+func TestWatchdogStartStop(t *testing.T) {
+	w := NewWatchdog(&v2.WatchdogConfig{
+		Interval: api.DurationConfig{
+			Duration: 10 * time.Millisecond,
+		},
+	})
+	w.Start()
+	time.Sleep(50 * time.Millisecond)
+	w.Stop()
+	w.Stop()
+}
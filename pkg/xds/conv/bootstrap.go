@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conv
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	stdlog "log"
+	"path/filepath"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/configmanager"
+)
+
+// the file extensions of the envoy bootstrap files, the json bootstrap is loaded by the default config load
+// as the static_resources and dynamic_resources of the mosn config.
+var (
+	bootstrapBinaryExts = map[string]bool{
+		".pb": true,
+	}
+	bootstrapTextExts = map[string]bool{
+		".pb_text":   true,
+		".pbtxt":     true,
+		".textproto": true,
+	}
+)
+
+// IsBootstrapFile returns true if the file is an envoy bootstrap protobuf file
+func IsBootstrapFile(path string) bool {
+	ext := filepath.Ext(path)
+	return bootstrapBinaryExts[ext] || bootstrapTextExts[ext]
+}
+
+// BootstrapConfigLoad is a config load function that loads the envoy bootstrap protobuf files,
+// it is registered only if the config file is a protobuf file, other files are loaded by the default config load.
+func BootstrapConfigLoad(path string) *v2.MOSNConfig {
+	if !IsBootstrapFile(path) {
+		return configmanager.DefaultConfigLoad(path)
+	}
+	stdlog.Println("load envoy bootstrap from : ", path)
+	cfg, err := LoadBootstrap(path)
+	if err != nil {
+		stdlog.Fatalln("[config] [bootstrap load] load envoy bootstrap failed, ", err)
+	}
+	return cfg
+}
+
+// LoadBootstrap reads an envoy v2 bootstrap protobuf file in binary or text format, and converts it into a mosn config
+func LoadBootstrap(path string) (*v2.MOSNConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := &bootstrap.Bootstrap{}
+	ext := filepath.Ext(path)
+	switch {
+	case bootstrapBinaryExts[ext]:
+		err = proto.Unmarshal(content, b)
+	case bootstrapTextExts[ext]:
+		err = proto.UnmarshalText(string(content), b)
+	default:
+		err = fmt.Errorf("unsupported bootstrap file extension: %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return ConvertBootstrap(b)
+}
+
+// ConvertBootstrap converts an envoy v2 bootstrap into a mosn config.
+// The static listeners and clusters are converted into the mosn server and cluster manager config,
+// the admin and the resources are kept as raw messages, so the xds client can subscribe the dynamic resources.
+func ConvertBootstrap(b *bootstrap.Bootstrap) (*v2.MOSNConfig, error) {
+	cfg := &v2.MOSNConfig{}
+	server := v2.ServerConfig{
+		DefaultLogPath:  "stdout",
+		DefaultLogLevel: "INFO",
+	}
+	static := b.GetStaticResources()
+	for i := range static.GetListeners() {
+		listener := ConvertListenerConfig(&static.Listeners[i])
+		if listener == nil {
+			return nil, fmt.Errorf("unsupported listener: %s", static.Listeners[i].GetName())
+		}
+		server.Listeners = append(server.Listeners, *listener)
+	}
+	cfg.Servers = []v2.ServerConfig{server}
+
+	clusters := make([]*xdsapi.Cluster, 0, len(static.GetClusters()))
+	for i := range static.GetClusters() {
+		clusters = append(clusters, &static.Clusters[i])
+	}
+	for _, cluster := range ConvertClustersConfig(clusters) {
+		cfg.ClusterManager.Clusters = append(cfg.ClusterManager.Clusters, *cluster)
+	}
+
+	marshaler := &jsonpb.Marshaler{OrigName: true}
+	var err error
+	if b.Admin != nil {
+		if cfg.RawAdmin, err = marshalRaw(marshaler, b.Admin); err != nil {
+			return nil, err
+		}
+	}
+	if b.DynamicResources != nil {
+		if b.DynamicResources.GetAdsConfig() == nil {
+			return nil, errors.New("only ads_config is supported in dynamic_resources")
+		}
+		if cfg.RawDynamicResources, err = marshalRaw(marshaler, b.DynamicResources); err != nil {
+			return nil, err
+		}
+		// the static clusters contain the xds management server
+		if static == nil {
+			return nil, errors.New("static_resources is required by dynamic_resources")
+		}
+		if cfg.RawStaticResources, err = marshalRaw(marshaler, static); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func marshalRaw(marshaler *jsonpb.Marshaler, pb proto.Message) ([]byte, error) {
+	s, err := marshaler.MarshalToString(pb)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+	"github.com/gogo/protobuf/proto"
+	v2 "mosn.io/mosn/pkg/config/v2"
+)
+
+const testBootstrapText = `
+admin {
+  access_log_path: "/dev/null"
+  address { socket_address { address: "0.0.0.0" port_value: 34901 } }
+}
+static_resources {
+  listeners: {
+    name: "tcp_listener"
+    address { socket_address { address: "0.0.0.0" port_value: 2045 } }
+    filter_chains: {
+      filters: {
+        name: "envoy.tcp_proxy"
+        config {
+          fields { key: "stat_prefix" value { string_value: "tcp" } }
+          fields { key: "cluster" value { string_value: "backend" } }
+        }
+      }
+    }
+  }
+  clusters: {
+    name: "backend"
+    type: STATIC
+    connect_timeout { seconds: 1 }
+    hosts: { socket_address { address: "127.0.0.1" port_value: 8080 } }
+  }
+  clusters: {
+    name: "xds-grpc"
+    type: STATIC
+    connect_timeout { seconds: 1 }
+    http2_protocol_options {}
+    hosts: { socket_address { address: "127.0.0.1" port_value: 15010 } }
+  }
+}
+dynamic_resources {
+  ads_config {
+    api_type: GRPC
+    grpc_services { envoy_grpc { cluster_name: "xds-grpc" } }
+    refresh_delay { seconds: 1 }
+  }
+}
+`
+
+func TestLoadBootstrap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b := &bootstrap.Bootstrap{}
+	if err := proto.UnmarshalText(testBootstrapText, b); err != nil {
+		t.Fatal(err)
+	}
+	binary, err := proto.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"envoy.pb_text": []byte(testBootstrapText),
+		"envoy.pb":      binary,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if !IsBootstrapFile(path) {
+			t.Fatalf("%s is not a bootstrap file", name)
+		}
+		cfg, err := LoadBootstrap(path)
+		if err != nil {
+			t.Fatalf("load %s failed: %v", name, err)
+		}
+		if len(cfg.Servers) != 1 || len(cfg.Servers[0].Listeners) != 1 {
+			t.Fatalf("unexpected servers: %+v", cfg.Servers)
+		}
+		listener := cfg.Servers[0].Listeners[0]
+		if listener.Name != "tcp_listener" || listener.Addr.String() != "0.0.0.0:2045" {
+			t.Fatalf("unexpected listener: %+v", listener)
+		}
+		if len(listener.FilterChains) != 1 || listener.FilterChains[0].Filters[0].Type != v2.TCP_PROXY {
+			t.Fatalf("unexpected filter chains: %+v", listener.FilterChains)
+		}
+		clusters := cfg.ClusterManager.Clusters
+		if len(clusters) != 2 || clusters[0].Name != "backend" || clusters[0].Hosts[0].Address != "127.0.0.1:8080" {
+			t.Fatalf("unexpected clusters: %+v", clusters)
+		}
+		if cfg.Mode() != v2.Mix {
+			t.Fatalf("unexpected mode: %v", cfg.Mode())
+		}
+		if admin := cfg.GetAdmin(); admin == nil || admin.GetAddress().GetSocketAddress().GetPortValue() != 34901 {
+			t.Fatalf("unexpected admin: %s", cfg.RawAdmin)
+		}
+		dynamic := map[string]map[string]interface{}{}
+		if err := json.Unmarshal(cfg.RawDynamicResources, &dynamic); err != nil {
+			t.Fatal(err)
+		}
+		if dynamic["ads_config"]["refresh_delay"] != "1s" {
+			t.Fatalf("unexpected dynamic resources: %s", cfg.RawDynamicResources)
+		}
+	}
+}
+
+func TestLoadBootstrapInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"syntax.pb_text": "static_resources {",
+		// dynamic resources without ads config
+		"noads.pb_text": `dynamic_resources { lds_config { path: "/tmp/lds" } }`,
+		"unknown.json":  "{}",
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadBootstrap(path); err == nil {
+			t.Fatalf("%s expected an error", name)
+		}
+	}
+}
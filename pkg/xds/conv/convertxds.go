@@ -116,7 +116,7 @@ func ConvertClustersConfig(xdsClusters []*xdsapi.Cluster) []*v2.Cluster {
 		cluster := &v2.Cluster{
 			Name:                 xdsCluster.GetName(),
 			ClusterType:          convertClusterType(xdsCluster.GetType()),
-			LbType:               convertLbPolicy(xdsCluster.GetLbPolicy()),
+			LbType:               convertLbType(xdsCluster),
 			LBSubSetConfig:       convertLbSubSetConfig(xdsCluster.GetLbSubsetConfig()),
 			MaxRequestPerConn:    xdsCluster.GetMaxRequestsPerConnection().GetValue(),
 			ConnBufferLimitBytes: xdsCluster.GetPerConnectionBufferLimitBytes().GetValue(),
@@ -138,6 +138,8 @@ func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 	if xdsEndpoint == nil {
 		return nil
 	}
+	locality := convertLocality(xdsEndpoint.GetLocality())
+	localityWeight := xdsEndpoint.GetLoadBalancingWeight().GetValue()
 	hosts := make([]v2.Host, 0, len(xdsEndpoint.GetLbEndpoints()))
 	for _, xdsHost := range xdsEndpoint.GetLbEndpoints() {
 		var address string
@@ -159,7 +161,9 @@ func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 		}
 		host := v2.Host{
 			HostConfig: v2.HostConfig{
-				Address: address,
+				Address:        address,
+				Locality:       locality,
+				LocalityWeight: localityWeight,
 			},
 			MetaData: convertMeta(xdsHost.Metadata),
 		}
@@ -168,6 +172,8 @@ func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 			host.Weight = configmanager.MinHostWeight
 		} else if weight > configmanager.MaxHostWeight {
 			host.Weight = configmanager.MaxHostWeight
+		} else if featuregate.Enabled(featuregate.XdsHostWeightEnable) {
+			host.Weight = weight
 		}
 
 		hosts = append(hosts, host)
@@ -175,6 +181,18 @@ func ConvertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 	return hosts
 }
 
+// convertLocality formats the locality as region/zone/sub_zone
+func convertLocality(xdsLocality *xdscore.Locality) string {
+	if xdsLocality == nil {
+		return ""
+	}
+	region, zone, subZone := xdsLocality.GetRegion(), xdsLocality.GetZone(), xdsLocality.GetSubZone()
+	if region == "" && zone == "" && subZone == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s", region, zone, subZone)
+}
+
 // todo: more filter type support
 func isSupport(xdsListener *xdsapi.Listener) bool {
 	if xdsListener == nil {
@@ -464,8 +482,8 @@ func convertFilters(xdsFilters []xdslistener.Filter) []v2.Filter {
 
 		for typeKey, configValue := range filterMaps {
 			filters = append(filters, v2.Filter{
-				typeKey,
-				configValue,
+				Type:   typeKey,
+				Config: configValue,
 			})
 		}
 	}
@@ -946,6 +964,15 @@ func convertClusterType(xdsClusterType xdsapi.Cluster_DiscoveryType) v2.ClusterT
 	return v2.SIMPLE_CLUSTER
 }
 
+// convertLbType uses the locality weighted load balancer if the cluster enables
+// locality weighted lb, the weights are pushed by eds
+func convertLbType(xdsCluster *xdsapi.Cluster) v2.LbType {
+	if xdsCluster.GetCommonLbConfig().GetLocalityWeightedLbConfig() != nil {
+		return v2.LB_LOCALITY_WEIGHTED
+	}
+	return convertLbPolicy(xdsCluster.GetLbPolicy())
+}
+
 func convertLbPolicy(xdsLbPolicy xdsapi.Cluster_LbPolicy) v2.LbType {
 	switch xdsLbPolicy {
 	case xdsapi.Cluster_ROUND_ROBIN:
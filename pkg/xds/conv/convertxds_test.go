@@ -30,6 +30,7 @@ import (
 	"istio.io/api/mixer/v1/config/client"
 
 	v2 "mosn.io/mosn/pkg/config/v2"
+	"mosn.io/mosn/pkg/featuregate"
 	"mosn.io/mosn/pkg/filter/stream/faultinject"
 	"mosn.io/mosn/pkg/router"
 	"mosn.io/mosn/pkg/upstream/cluster"
@@ -71,9 +72,10 @@ func Test_convertEndpointsConfig(t *testing.T) {
 		xdsEndpoint *xdsendpoint.LocalityLbEndpoints
 	}
 	tests := []struct {
-		name string
-		args args
-		want []v2.Host
+		name       string
+		args       args
+		hostWeight bool
+		want       []v2.Host
 	}{
 		{
 			name: "case1",
@@ -84,9 +86,89 @@ func Test_convertEndpointsConfig(t *testing.T) {
 			},
 			want: []v2.Host{},
 		},
+		{
+			name:       "locality with host weight",
+			hostWeight: true,
+			args: args{
+				xdsEndpoint: &xdsendpoint.LocalityLbEndpoints{
+					Locality: &xdscore.Locality{
+						Region: "cn",
+						Zone:   "zone-a",
+					},
+					LoadBalancingWeight: &types.UInt32Value{Value: 20},
+					LbEndpoints: []xdsendpoint.LbEndpoint{
+						{
+							HostIdentifier: &xdsendpoint.LbEndpoint_Endpoint{
+								Endpoint: &xdsendpoint.Endpoint{
+									Address: &xdscore.Address{
+										Address: &xdscore.Address_SocketAddress{
+											SocketAddress: &xdscore.SocketAddress{
+												Address:       "127.0.0.1",
+												PortSpecifier: &xdscore.SocketAddress_PortValue{PortValue: 8080},
+											},
+										},
+									},
+								},
+							},
+							LoadBalancingWeight: &types.UInt32Value{Value: 5},
+						},
+					},
+				},
+			},
+			want: []v2.Host{
+				{
+					HostConfig: v2.HostConfig{
+						Address:        "127.0.0.1:8080",
+						Weight:         5,
+						Locality:       "cn/zone-a/",
+						LocalityWeight: 20,
+					},
+				},
+			},
+		},
+		{
+			name: "locality",
+			args: args{
+				xdsEndpoint: &xdsendpoint.LocalityLbEndpoints{
+					Locality: &xdscore.Locality{
+						Region: "cn",
+						Zone:   "zone-a",
+					},
+					LoadBalancingWeight: &types.UInt32Value{Value: 20},
+					LbEndpoints: []xdsendpoint.LbEndpoint{
+						{
+							HostIdentifier: &xdsendpoint.LbEndpoint_Endpoint{
+								Endpoint: &xdsendpoint.Endpoint{
+									Address: &xdscore.Address{
+										Address: &xdscore.Address_SocketAddress{
+											SocketAddress: &xdscore.SocketAddress{
+												Address:       "127.0.0.1",
+												PortSpecifier: &xdscore.SocketAddress_PortValue{PortValue: 8080},
+											},
+										},
+									},
+								},
+							},
+							LoadBalancingWeight: &types.UInt32Value{Value: 5},
+						},
+					},
+				},
+			},
+			want: []v2.Host{
+				{
+					HostConfig: v2.HostConfig{
+						Address:        "127.0.0.1:8080",
+						Locality:       "cn/zone-a/",
+						LocalityWeight: 20,
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			featuregate.SetFeatureState(featuregate.XdsHostWeightEnable, tt.hostWeight)
+			defer featuregate.SetFeatureState(featuregate.XdsHostWeightEnable, false)
 			if got := ConvertEndpointsConfig(tt.args.xdsEndpoint); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("convertEndpointsConfig() = %v, want %v", got, tt.want)
 			}
@@ -314,14 +396,14 @@ func Test_convertListenerConfig(t *testing.T) {
 							accessLogFilterConfig,
 						},
 					}},
-					UseRemoteAddress:                           NewBoolValue(false),
-					XffNumTrustedHops:                          0,
-					SkipXffAppend:                              false,
-					Via:                                        "",
-					GenerateRequestId:                          NewBoolValue(true),
-					ForwardClientCertDetails:                   xdshttp.SANITIZE,
-					SetCurrentClientCertDetails:                nil,
-					Proxy_100Continue:                          false,
+					UseRemoteAddress:            NewBoolValue(false),
+					XffNumTrustedHops:           0,
+					SkipXffAppend:               false,
+					Via:                         "",
+					GenerateRequestId:           NewBoolValue(true),
+					ForwardClientCertDetails:    xdshttp.SANITIZE,
+					SetCurrentClientCertDetails: nil,
+					Proxy_100Continue:           false,
 					RepresentIpv4RemoteAddressAsIpv4MappedIpv6: false,
 				},
 				filterName: "envoy.http_connection_manager",
@@ -53,6 +53,10 @@ func duration2String(duration *types.Duration) string {
 	return x + "s"
 }
 
+func isJSONString(raw []byte) bool {
+	return len(raw) > 0 && raw[0] == '"'
+}
+
 // UnmarshalResources used in order to convert bootstrap_v2 json to pb struct (go-control-plane), some fields must be exchanged format
 func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Bootstrap_DynamicResources, staticResources *bootstrap.Bootstrap_StaticResources, err error) {
 
@@ -72,7 +76,8 @@ func UnmarshalResources(config *mv2.MOSNConfig) (dynamicResources *bootstrap.Boo
 				log.DefaultLogger.Errorf("fail to unmarshal ads_config: %v", err)
 				return nil, nil, err
 			}
-			if refreshDelayRaw, ok := adsConfig["refresh_delay"]; ok {
+			// the refresh_delay in the protobuf json format is a string already, such as "1s"
+			if refreshDelayRaw, ok := adsConfig["refresh_delay"]; ok && !isJSONString(refreshDelayRaw) {
 				refreshDelay := types.Duration{}
 				err = json.Unmarshal([]byte(refreshDelayRaw), &refreshDelay)
 				if err != nil {